@@ -0,0 +1,64 @@
+// Command mockprovider serves fake OpenAI and Anthropic endpoints for
+// deterministic end-to-end tests of routing, retries and failover.
+//
+//	mockprovider -addr 127.0.0.1:9090 -rpm 60 -fail-rate 0.1
+//
+// See package internal/mockprovider for the query flags and control API.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/mockprovider"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9090", "Listen address")
+	latency := flag.Duration("latency", 0, "Latency added before every response")
+	chunkDelay := flag.Duration("chunk-delay", 0, "Pause between streamed chunks")
+	rpm := flag.Int("rpm", 600, "Requests per minute before 429s (0 disables rate limiting)")
+	failRate := flag.Float64("fail-rate", 0, "Fraction of requests failing with 500")
+	apiKey := flag.String("api-key", "", "Require this API key (Bearer or x-api-key)")
+	seed := flag.Int64("seed", 1, "Random seed for -fail-rate sampling")
+	reply := flag.String("reply", "", "Fixed reply text instead of echoing the prompt")
+	flag.Parse()
+
+	server := &http.Server{
+		Addr: *addr,
+		Handler: mockprovider.New(mockprovider.Config{
+			Latency:    *latency,
+			ChunkDelay: *chunkDelay,
+			RPM:        *rpm,
+			FailRate:   *failRate,
+			APIKey:     *apiKey,
+			Seed:       *seed,
+			Reply:      *reply,
+		}),
+	}
+
+	go func() {
+		log.Printf("Mock provider listening on http://%s (OpenAI: /v1/chat/completions, /v1/embeddings; Anthropic: /v1/messages)", *addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server error: %v", err)
+		}
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	<-sigChan
+
+	log.Println("Shutting down mock provider...")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown error: %v", err)
+	}
+}
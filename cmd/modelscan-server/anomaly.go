@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/anomaly"
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+)
+
+// buildAnomaly creates the usage anomaly detector settings from
+// configuration
+func buildAnomaly(cfg config.AnomalyConfig) (*anomaly.Config, error) {
+	if cfg.WindowSeconds < 0 || cfg.BaselineWindows < 0 || cfg.MinWindows < 0 || cfg.MinRequests < 0 || cfg.CooldownMinutes < 0 {
+		return nil, fmt.Errorf("windows, requests and cooldown cannot be negative")
+	}
+	if cfg.Sigma < 0 || cfg.MinIncreasePercent < 0 {
+		return nil, fmt.Errorf("sigma and min_increase_percent cannot be negative")
+	}
+	if cfg.MinErrorRatePercent < 0 || cfg.MinErrorRatePercent > 100 {
+		return nil, fmt.Errorf("min_error_rate_percent must be between 0 and 100, got %v", cfg.MinErrorRatePercent)
+	}
+	return &anomaly.Config{
+		Window:       time.Duration(cfg.WindowSeconds) * time.Second,
+		Baseline:     cfg.BaselineWindows,
+		MinBaseline:  cfg.MinWindows,
+		Sigma:        cfg.Sigma,
+		MinIncrease:  cfg.MinIncreasePercent / 100,
+		MinRequests:  cfg.MinRequests,
+		MinErrorRate: cfg.MinErrorRatePercent / 100,
+		Cooldown:     time.Duration(cfg.CooldownMinutes) * time.Minute,
+	}, nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jeffersonwarrior/modelscan/internal/chaos"
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+)
+
+// buildChaos creates the upstream fault injector from configuration
+func buildChaos(cfg config.ChaosConfig) (*chaos.Injector, error) {
+	injector := chaos.New(cfg.Seed)
+	for _, fc := range cfg.Faults {
+		fault := chaos.Fault{
+			Provider:      fc.Provider,
+			LatencyMs:     fc.LatencyMs,
+			JitterMs:      fc.JitterMs,
+			DropRate:      fc.DropRate,
+			ErrorRate:     fc.ErrorRate,
+			Status:        fc.Status,
+			TruncateRate:  fc.TruncateRate,
+			TruncateAfter: fc.TruncateAfter,
+		}
+		if err := injector.SetFault(fault); err != nil {
+			return nil, fmt.Errorf("chaos fault %q: %w", fc.Provider, err)
+		}
+	}
+	return injector, nil
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// buildCompletionHooks creates the completion hook settings from configuration
+func buildCompletionHooks(cfg config.CompletionsConfig) *proxy.CompletionHooksConfig {
+	return &proxy.CompletionHooksConfig{
+		Workers:   cfg.Workers,
+		QueueSize: cfg.QueueSize,
+		Timeout:   time.Duration(cfg.TimeoutMs) * time.Millisecond,
+	}
+}
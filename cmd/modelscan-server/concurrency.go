@@ -0,0 +1,20 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// buildConcurrency creates the proxy's in-flight request limiter from configuration
+func buildConcurrency(cfg config.ConcurrencyConfig) (*proxy.ConcurrencyLimiter, error) {
+	return proxy.NewConcurrencyLimiter(proxy.ConcurrencyConfig{
+		MaxInFlight:            cfg.MaxInFlight,
+		MaxInFlightPerProvider: cfg.MaxInFlightPerProvider,
+		Providers:              cfg.Providers,
+		QueueSize:              cfg.QueueSize,
+		QueueTimeout:           time.Duration(cfg.QueueTimeoutMs) * time.Millisecond,
+		RetryAfter:             time.Duration(cfg.RetryAfterMs) * time.Millisecond,
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"syscall"
 
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
 	"github.com/jeffersonwarrior/modelscan/internal/service"
 )
 
@@ -119,7 +120,7 @@ func SetupDaemonLogging() (func(), error) {
 
 	// Configure log to write to both file and console (in case of --daemon-child)
 	multiWriter := io.MultiWriter(os.Stdout, logFile)
-	log.SetOutput(multiWriter)
+	log.SetOutput(redact.NewWriter(multiWriter))
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
 	cleanup := func() {
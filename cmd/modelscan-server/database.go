@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/secrets"
+)
+
+// databaseDSNs returns the main and rate limit database connection
+// strings. A PostgreSQL URL serves both, so instances share one database.
+func databaseDSNs(cfg config.DatabaseConfig) (string, string) {
+	if cfg.URL != "" {
+		return cfg.URL, cfg.URL
+	}
+	return cfg.Path, cfg.RateLimitPath
+}
+
+// redactDSN hides the password in a connection URL for logging
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil {
+		return dsn
+	}
+	return u.Redacted()
+}
+
+// buildDatabaseOptions creates the SQLite pragmas, starting from WAL with
+// NORMAL sync. Invalid modes are reported when the database is opened.
+func buildDatabaseOptions(cfg config.DatabaseConfig) database.Options {
+	opts := database.DefaultOptions()
+	if cfg.JournalMode != "" {
+		opts.JournalMode = cfg.JournalMode
+	}
+	if cfg.Synchronous != "" {
+		opts.Synchronous = cfg.Synchronous
+	}
+	if cfg.BusyTimeoutMs > 0 {
+		opts.BusyTimeout = time.Duration(cfg.BusyTimeoutMs) * time.Millisecond
+	}
+	opts.ManualMigrations = cfg.ManualMigrations
+	return opts
+}
+
+// buildDatabaseBatch creates the write-behind batching configuration
+func buildDatabaseBatch(cfg config.DatabaseBatchConfig) *database.BatchConfig {
+	batchCfg := database.DefaultBatchConfig()
+	if cfg.MaxBatch > 0 {
+		batchCfg.MaxBatch = cfg.MaxBatch
+	}
+	if cfg.FlushIntervalMs > 0 {
+		batchCfg.FlushInterval = time.Duration(cfg.FlushIntervalMs) * time.Millisecond
+	}
+	if cfg.QueueSize > 0 {
+		batchCfg.QueueSize = cfg.QueueSize
+	}
+	return &batchCfg
+}
+
+// loadMasterKey reads the master key that unwraps the data keys for
+// encrypted API keys and client tokens
+func loadMasterKey(cfg config.DatabaseEncryptionConfig) (*secrets.MasterKey, error) {
+	return secrets.LoadMasterKey(cfg.MasterKeyEnv, cfg.MasterKeyCommand)
+}
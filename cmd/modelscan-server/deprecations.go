@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/deprecation"
+)
+
+// buildDeprecations creates the model retirement checker settings from
+// configuration
+func buildDeprecations(cfg config.DeprecationsConfig) (*deprecation.Config, error) {
+	if cfg.IntervalSeconds < 0 || cfg.WarningDays < 0 {
+		return nil, fmt.Errorf("interval_seconds and warning_days must not be negative")
+	}
+	return &deprecation.Config{
+		Interval: time.Duration(cfg.IntervalSeconds) * time.Second,
+		Warning:  time.Duration(cfg.WarningDays) * 24 * time.Hour,
+	}, nil
+}
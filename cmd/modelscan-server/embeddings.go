@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// buildEmbeddingCache creates the proxy's embedding cache settings from
+// configuration
+func buildEmbeddingCache(cfg config.EmbeddingsConfig) *proxy.EmbeddingCacheConfig {
+	return &proxy.EmbeddingCacheConfig{MaxEntries: cfg.MaxEntries}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/events"
+)
+
+// buildEvents creates the live event stream configuration
+func buildEvents(cfg config.EventsConfig) (*events.Config, error) {
+	for _, t := range cfg.BudgetThresholds {
+		if t <= 0 || t > 2 {
+			return nil, fmt.Errorf("budget threshold %v must be a fraction of the budget between 0 and 2", t)
+		}
+	}
+	return &events.Config{
+		History:      cfg.HistorySize,
+		ClientBuffer: cfg.ClientBuffer,
+		KeepAlive:    time.Duration(cfg.KeepAliveSeconds) * time.Second,
+	}, nil
+}
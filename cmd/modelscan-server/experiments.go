@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// buildExperiments creates the A/B experiments from configuration, keeping
+// their order
+func buildExperiments(cfg config.ExperimentsConfig) ([]proxy.Experiment, error) {
+	experiments := make([]proxy.Experiment, 0, len(cfg.Experiments))
+	seen := make(map[string]bool)
+	for _, ec := range cfg.Experiments {
+		e := proxy.Experiment{
+			Name:    ec.Name,
+			APIs:    ec.APIs,
+			Models:  ec.Models,
+			Clients: ec.Clients,
+			SplitBy: ec.SplitBy,
+			Percent: ec.Percent,
+		}
+		for _, vc := range ec.Variants {
+			v := proxy.Variant{Name: vc.Name, Weight: vc.Weight, Model: vc.Model, System: vc.System}
+			if len(vc.Set) > 0 {
+				v.Set = make(map[string]json.RawMessage, len(vc.Set))
+				for field, value := range vc.Set {
+					data, err := json.Marshal(value)
+					if err != nil {
+						return nil, fmt.Errorf("experiment %q: variant %s: set %s: %w", ec.Name, vc.Name, field, err)
+					}
+					v.Set[field] = data
+				}
+			}
+			e.Variants = append(e.Variants, v)
+		}
+		if seen[ec.Name] {
+			return nil, fmt.Errorf("experiment %q is defined twice", ec.Name)
+		}
+		seen[ec.Name] = true
+		if err := e.Validate(); err != nil {
+			return nil, fmt.Errorf("experiment %q: %w", ec.Name, err)
+		}
+		experiments = append(experiments, e)
+	}
+	return experiments, nil
+}
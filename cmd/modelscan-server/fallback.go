@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// buildFallbackChains creates the proxy's fallback chains from configuration
+func buildFallbackChains(cfg config.FallbackConfig) (*proxy.FallbackChains, error) {
+	chains := proxy.NewFallbackChains()
+	for _, cc := range cfg.Chains {
+		chain := proxy.FallbackChain{Model: cc.Model}
+		for _, sc := range cc.Steps {
+			chain.Steps = append(chain.Steps, proxy.FallbackStep{
+				Provider:     sc.Provider,
+				Model:        sc.Model,
+				MaxTokens:    sc.MaxTokens,
+				DropImages:   sc.DropImages,
+				SystemPrompt: sc.SystemPrompt,
+				TimeoutMs:    sc.TimeoutMs,
+			})
+		}
+		if err := chains.SetChain(chain); err != nil {
+			return nil, fmt.Errorf("fallback chain %q: %w", cc.Model, err)
+		}
+	}
+	return chains, nil
+}
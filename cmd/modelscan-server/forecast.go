@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/forecast"
+)
+
+// buildForecast creates the spend forecasting settings from configuration
+func buildForecast(cfg config.ForecastConfig) (*forecast.Config, error) {
+	if cfg.IntervalMinutes < 0 || cfg.LookbackDays < 0 {
+		return nil, fmt.Errorf("interval_minutes and lookback_days cannot be negative")
+	}
+	if cfg.LookbackDays == 1 {
+		return nil, fmt.Errorf("lookback_days must be at least 2 to fit a trend")
+	}
+	if cfg.Method != "" && !forecast.ValidMethod(cfg.Method) {
+		return nil, fmt.Errorf("unknown method %q (want run_rate or linear)", cfg.Method)
+	}
+	for provider, budget := range cfg.ProviderBudgets {
+		if budget <= 0 {
+			return nil, fmt.Errorf("provider %s: budget must be positive", provider)
+		}
+	}
+	for client, budget := range cfg.ClientBudgets {
+		if budget <= 0 {
+			return nil, fmt.Errorf("client %s: budget must be positive", client)
+		}
+	}
+	return &forecast.Config{
+		Interval:        time.Duration(cfg.IntervalMinutes) * time.Minute,
+		Method:          cfg.Method,
+		Lookback:        cfg.LookbackDays,
+		ProviderBudgets: cfg.ProviderBudgets,
+		ClientBudgets:   cfg.ClientBudgets,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/guardrail"
+)
+
+// buildGuardrails creates the proxy guardrail pipeline from configuration
+func buildGuardrails(cfg config.GuardrailsConfig) (*guardrail.Pipeline, error) {
+	rules := make([]guardrail.Rule, 0, len(cfg.Rules))
+	for _, rc := range cfg.Rules {
+		guard, err := buildGuard(rc)
+		if err != nil {
+			return nil, fmt.Errorf("guardrail rule %q: %w", rc.Name, err)
+		}
+
+		action, err := guardrail.ParseAction(rc.Action)
+		if err != nil {
+			return nil, fmt.Errorf("guardrail rule %q: %w", rc.Name, err)
+		}
+
+		rule := guardrail.Rule{Name: rc.Name, Guard: guard, Action: action}
+		for _, dir := range rc.ApplyTo {
+			switch guardrail.Direction(dir) {
+			case guardrail.Request, guardrail.Response:
+				rule.Directions = append(rule.Directions, guardrail.Direction(dir))
+			default:
+				return nil, fmt.Errorf("guardrail rule %q: invalid apply_to %q", rc.Name, dir)
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	pipeline, err := guardrail.NewPipeline(rules...)
+	if err != nil {
+		return nil, err
+	}
+
+	for clientID, cc := range cfg.Clients {
+		policy := guardrail.ClientPolicy{
+			Disabled: cc.Disabled,
+			Actions:  make(map[string]guardrail.Action, len(cc.Actions)),
+		}
+		for name, action := range cc.Actions {
+			policy.Actions[name] = guardrail.Action(action)
+		}
+		if err := pipeline.SetClientPolicy(clientID, policy); err != nil {
+			return nil, err
+		}
+	}
+
+	return pipeline, nil
+}
+
+// buildGuard creates the guard for a rule type
+func buildGuard(rc config.GuardrailRuleConfig) (guardrail.Guard, error) {
+	switch rc.Type {
+	case "regex":
+		return guardrail.NewRegexGuard(rc.Patterns)
+	case "pii":
+		return guardrail.NewPIIGuard(rc.Kinds...)
+	case "profanity":
+		return guardrail.NewProfanityGuard(rc.Words...), nil
+	case "injection":
+		return guardrail.NewInjectionGuard(rc.Threshold)
+	case "webhook":
+		return guardrail.NewWebhookGuard(rc.URL, time.Duration(rc.TimeoutMs)*time.Millisecond, rc.FailOpen)
+	default:
+		return nil, fmt.Errorf("unknown guardrail type %q", rc.Type)
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/heatmap"
+)
+
+// buildHeatmap creates the latency heatmap collector settings from
+// configuration
+func buildHeatmap(cfg config.HeatmapConfig) (*heatmap.Config, error) {
+	if cfg.WindowDays < 0 || cfg.WindowDays > 366 {
+		return nil, fmt.Errorf("window_days must be between 0 and 366, got %d", cfg.WindowDays)
+	}
+	if cfg.MinSamples < 0 {
+		return nil, fmt.Errorf("min_samples must not be negative, got %d", cfg.MinSamples)
+	}
+	return &heatmap.Config{
+		Window:     time.Duration(cfg.WindowDays) * 24 * time.Hour,
+		MinSamples: int64(cfg.MinSamples),
+	}, nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/keyhealth"
+)
+
+// buildKeyHealth creates the key prober settings from configuration
+func buildKeyHealth(cfg config.KeyHealthConfig) (*keyhealth.Config, error) {
+	if cfg.IntervalSeconds < 0 || cfg.TimeoutSeconds < 0 || cfg.ExpiryWarningDays < 0 {
+		return nil, fmt.Errorf("interval_seconds, timeout_seconds and expiry_warning_days must not be negative")
+	}
+	endpoints := make(map[string]keyhealth.Endpoint, len(cfg.Endpoints))
+	for i, ep := range cfg.Endpoints {
+		if ep.Provider == "" || ep.URL == "" {
+			return nil, fmt.Errorf("endpoint %d: provider and url are required", i+1)
+		}
+		endpoints[ep.Provider] = keyhealth.Endpoint{URL: ep.URL, Header: ep.Header, Prefix: ep.Prefix}
+	}
+	return &keyhealth.Config{
+		Interval:      time.Duration(cfg.IntervalSeconds) * time.Second,
+		Timeout:       time.Duration(cfg.TimeoutSeconds) * time.Second,
+		ExpiryWarning: time.Duration(cfg.ExpiryWarningDays) * 24 * time.Hour,
+		Endpoints:     endpoints,
+	}, nil
+}
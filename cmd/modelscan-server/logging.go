@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/logsink"
+)
+
+// buildLogSinks creates the extra log destinations from configuration,
+// returning nil when none is enabled
+func buildLogSinks(cfg config.LoggingConfig) (*logsink.Config, error) {
+	if cfg.BufferSize < 0 {
+		return nil, fmt.Errorf("buffer_size must not be negative")
+	}
+	out := &logsink.Config{BufferSize: cfg.BufferSize}
+
+	if f := cfg.File; f.Enabled {
+		if f.MaxSizeMB < 0 || f.RotateHours < 0 || f.MaxBackups < 0 {
+			return nil, fmt.Errorf("file: max_size_mb, rotate_hours and max_backups must not be negative")
+		}
+		path := f.Path
+		if path == "" {
+			path = "logs/modelscan.log"
+		}
+		maxSize := f.MaxSizeMB
+		if maxSize == 0 && f.RotateHours == 0 {
+			maxSize = 100
+		}
+		backups := f.MaxBackups
+		if backups == 0 {
+			backups = 7
+		}
+		out.File = &logsink.FileConfig{
+			Path:        path,
+			MaxSize:     int64(maxSize) << 20,
+			RotateEvery: time.Duration(f.RotateHours) * time.Hour,
+			MaxBackups:  backups,
+		}
+	}
+
+	if s := cfg.Syslog; s.Enabled {
+		if (s.Network == "") != (s.Address == "") {
+			return nil, fmt.Errorf("syslog: network and address must be set together")
+		}
+		out.Syslog = &logsink.SyslogConfig{Network: s.Network, Address: s.Address, Tag: s.Tag, Facility: s.Facility}
+	}
+
+	if h := cfg.HTTP; h.Enabled {
+		if h.URL == "" {
+			return nil, fmt.Errorf("http: url is required")
+		}
+		if h.BatchSize < 0 || h.FlushIntervalMs < 0 || h.TimeoutMs < 0 {
+			return nil, fmt.Errorf("http: batch_size, flush_interval_ms and timeout_ms must not be negative")
+		}
+		headers := make(map[string]string, len(h.Headers)+1)
+		for k, v := range h.Headers {
+			headers[k] = v
+		}
+		if h.BearerTokenEnv != "" {
+			token := os.Getenv(h.BearerTokenEnv)
+			if token == "" {
+				return nil, fmt.Errorf("http: %s is not set", h.BearerTokenEnv)
+			}
+			headers["Authorization"] = "Bearer " + token
+		}
+		out.HTTP = &logsink.HTTPConfig{
+			URL:           h.URL,
+			Format:        h.Format,
+			Index:         h.Index,
+			Labels:        h.Labels,
+			Headers:       headers,
+			BatchSize:     h.BatchSize,
+			FlushInterval: time.Duration(h.FlushIntervalMs) * time.Millisecond,
+			Timeout:       time.Duration(h.TimeoutMs) * time.Millisecond,
+		}
+	}
+
+	if out.File == nil && out.Syslog == nil && out.HTTP == nil {
+		return nil, nil
+	}
+	return out, nil
+}
@@ -3,18 +3,27 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+	"github.com/jeffersonwarrior/modelscan/internal/logsink"
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
 	"github.com/jeffersonwarrior/modelscan/internal/service"
 )
 
 const version = "0.5.5"
 
 func main() {
+	// Provider errors can echo keys; mask them in every log line
+	log.SetOutput(redact.NewWriter(os.Stderr))
+
 	// Command-line flags
 	configPath := flag.String("config", "config.yaml", "Path to configuration file")
 	showVersion := flag.Bool("version", false, "Show version information")
@@ -99,13 +108,41 @@ func main() {
 		log.Printf("Configuration loaded from: %s", *configPath)
 	}
 
-	log.Printf("Database: %s", cfg.Database.Path)
+	// Recovered panics are counted and written to crash reports
+	crashDir := cfg.CrashReports.Dir
+	if crashDir == "" {
+		crashDir = "crash-reports"
+	}
+	crash.Configure(crash.Config{
+		Dir:            crashDir,
+		MaxReports:     cfg.CrashReports.MaxReports,
+		RecentRequests: cfg.CrashReports.RecentRequests,
+		Version:        version,
+	})
+
+	// Extra log destinations; lines reach them masked, like stderr
+	sinkCfg, err := buildLogSinks(cfg.Logging)
+	if err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
+	}
+	if sinkCfg != nil {
+		sinks, err := logsink.Open(*sinkCfg)
+		if err != nil {
+			log.Fatalf("Failed to open log sinks: %v", err)
+		}
+		defer sinks.Close()
+		log.SetOutput(io.MultiWriter(log.Writer(), redact.NewWriter(sinks)))
+		log.Printf("Log sinks: %s", strings.Join(sinks.Names(), ", "))
+	}
+
+	dbPath, rateLimitDB := databaseDSNs(cfg.Database)
+	log.Printf("Database: %s", redactDSN(dbPath))
 	log.Printf("Server: %s:%d", cfg.Server.Host, cfg.Server.Port)
 	log.Printf("Agent Model: %s", cfg.Discovery.AgentModel)
 
 	// Create service
-	svc := service.NewService(&service.Config{
-		DatabasePath:  cfg.Database.Path,
+	svcCfg := &service.Config{
+		DatabasePath:  dbPath,
 		ServerHost:    cfg.Server.Host,
 		ServerPort:    cfg.Server.Port,
 		AgentModel:    cfg.Discovery.AgentModel,
@@ -113,7 +150,228 @@ func main() {
 		CacheDays:     cfg.Discovery.CacheDays,
 		OutputDir:     cfg.Discovery.OutputDir,
 		RoutingMode:   cfg.Discovery.RoutingMode,
-	})
+		RateLimitDB:   rateLimitDB,
+
+		DatabaseOptions: buildDatabaseOptions(cfg.Database),
+		ReadCacheTTL:    time.Duration(cfg.Database.CacheTTLSeconds) * time.Second,
+	}
+	if cfg.Database.Batch.Enabled {
+		svcCfg.DatabaseBatch = buildDatabaseBatch(cfg.Database.Batch)
+	}
+	if cfg.Database.Encryption.Enabled {
+		master, err := loadMasterKey(cfg.Database.Encryption)
+		if err != nil {
+			log.Fatalf("Invalid encryption configuration: %v", err)
+		}
+		svcCfg.DatabaseOptions.MasterKey = master
+	}
+	if cfg.Shadow.Enabled {
+		svcCfg.ShadowProvider = cfg.Shadow.Provider
+		svcCfg.ShadowModel = cfg.Shadow.Model
+		svcCfg.ShadowSampleRate = cfg.Shadow.SampleRate
+	}
+	if cfg.Guardrails.Enabled {
+		pipeline, err := buildGuardrails(cfg.Guardrails)
+		if err != nil {
+			log.Fatalf("Invalid guardrails configuration: %v", err)
+		}
+		svcCfg.Guardrails = pipeline
+	}
+	if cfg.Webhooks.Enabled {
+		webhooks, err := buildWebhooks(cfg.Webhooks)
+		if err != nil {
+			log.Fatalf("Invalid webhooks configuration: %v", err)
+		}
+		svcCfg.Webhooks = webhooks
+	}
+	if cfg.Scheduler.Enabled {
+		sched, err := buildScheduler(cfg.Scheduler)
+		if err != nil {
+			log.Fatalf("Invalid scheduler configuration: %v", err)
+		}
+		svcCfg.Scheduler = sched
+	}
+	if cfg.Scraper.Enabled {
+		svcCfg.Scraper = buildScraper(cfg.Scraper)
+	}
+	transports, err := buildTransports(cfg.Transport)
+	if err != nil {
+		log.Fatalf("Invalid transport configuration: %v", err)
+	}
+	svcCfg.Transports = transports
+	retryBudgets, err := buildRetryBudget(cfg.Transport)
+	if err != nil {
+		log.Fatalf("Invalid transport configuration: %v", err)
+	}
+	svcCfg.RetryBudgets = retryBudgets
+	if cfg.Warmup.Enabled {
+		warmupCfg, err := buildWarmup(cfg.Warmup)
+		if err != nil {
+			log.Fatalf("Invalid warmup configuration: %v", err)
+		}
+		svcCfg.Warmup = warmupCfg
+	}
+	if cfg.Signing.Enabled {
+		signer, err := buildSigner(cfg.Signing)
+		if err != nil {
+			log.Fatalf("Invalid signing configuration: %v", err)
+		}
+		svcCfg.Signer = signer
+	}
+	if cfg.Chaos.Enabled {
+		injector, err := buildChaos(cfg.Chaos)
+		if err != nil {
+			log.Fatalf("Invalid chaos configuration: %v", err)
+		}
+		svcCfg.Chaos = injector
+	}
+	if cfg.Fallback.Enabled {
+		chains, err := buildFallbackChains(cfg.Fallback)
+		if err != nil {
+			log.Fatalf("Invalid fallback configuration: %v", err)
+		}
+		svcCfg.Fallbacks = chains
+	}
+	if cfg.Transforms.Enabled {
+		rules, err := buildTransforms(cfg.Transforms)
+		if err != nil {
+			log.Fatalf("Invalid transforms configuration: %v", err)
+		}
+		svcCfg.Transforms = rules
+	}
+	if cfg.Experiments.Enabled {
+		experiments, err := buildExperiments(cfg.Experiments)
+		if err != nil {
+			log.Fatalf("Invalid experiments configuration: %v", err)
+		}
+		svcCfg.Experiments = experiments
+	}
+	if cfg.Residency.Enabled {
+		residency, err := buildResidency(cfg.Residency)
+		if err != nil {
+			log.Fatalf("Invalid residency configuration: %v", err)
+		}
+		svcCfg.Residency = residency
+	}
+	if cfg.Concurrency.Enabled {
+		limiter, err := buildConcurrency(cfg.Concurrency)
+		if err != nil {
+			log.Fatalf("Invalid concurrency configuration: %v", err)
+		}
+		svcCfg.Concurrency = limiter
+	}
+	if cfg.ModelLimits.Enabled {
+		limits, err := buildModelLimits(cfg.ModelLimits)
+		if err != nil {
+			log.Fatalf("Invalid model limits configuration: %v", err)
+		}
+		svcCfg.ModelLimits = limits
+	}
+	if cfg.Vision.Enabled {
+		svcCfg.Vision = buildVision(cfg.Vision)
+	}
+	if cfg.Embeddings.CacheEnabled {
+		svcCfg.EmbeddingCache = buildEmbeddingCache(cfg.Embeddings)
+		svcCfg.PersistEmbeddings = cfg.Embeddings.Persist
+	}
+	if cfg.VectorStore.Enabled {
+		svcCfg.VectorStore = buildVectorStore(cfg.VectorStore)
+	}
+	if cfg.Completions.Enabled {
+		svcCfg.CompletionHooks = buildCompletionHooks(cfg.Completions)
+		svcCfg.LogCompletions = cfg.Completions.Log
+		svcCfg.NotifyCompletions = cfg.Completions.Webhook
+	}
+	if cfg.StatusPages.Enabled {
+		statuses, err := buildStatusPages(cfg.StatusPages)
+		if err != nil {
+			log.Fatalf("Invalid status pages configuration: %v", err)
+		}
+		svcCfg.StatusPages = statuses
+	}
+	if cfg.SLA.Enabled {
+		slaCfg, err := buildSLA(cfg.SLA)
+		if err != nil {
+			log.Fatalf("Invalid SLA configuration: %v", err)
+		}
+		svcCfg.SLA = slaCfg
+	}
+	if cfg.Heatmap.Enabled {
+		heatmapCfg, err := buildHeatmap(cfg.Heatmap)
+		if err != nil {
+			log.Fatalf("Invalid latency heatmap configuration: %v", err)
+		}
+		svcCfg.Heatmap = heatmapCfg
+	}
+	if cfg.Anomaly.Enabled {
+		anomalyCfg, err := buildAnomaly(cfg.Anomaly)
+		if err != nil {
+			log.Fatalf("Invalid anomaly configuration: %v", err)
+		}
+		svcCfg.Anomaly = anomalyCfg
+	}
+	if cfg.Forecast.Enabled {
+		forecastCfg, err := buildForecast(cfg.Forecast)
+		if err != nil {
+			log.Fatalf("Invalid forecast configuration: %v", err)
+		}
+		svcCfg.Forecast = forecastCfg
+	}
+	if cfg.Watchdog.Enabled {
+		watchdogCfg, err := buildWatchdog(cfg.Watchdog)
+		if err != nil {
+			log.Fatalf("Invalid watchdog configuration: %v", err)
+		}
+		svcCfg.Watchdog = watchdogCfg
+	}
+	if cfg.KeyHealth.Enabled {
+		keyHealth, err := buildKeyHealth(cfg.KeyHealth)
+		if err != nil {
+			log.Fatalf("Invalid key health configuration: %v", err)
+		}
+		svcCfg.KeyHealth = keyHealth
+	}
+	if cfg.Deprecations.Enabled {
+		deprecations, err := buildDeprecations(cfg.Deprecations)
+		if err != nil {
+			log.Fatalf("Invalid deprecations configuration: %v", err)
+		}
+		svcCfg.Deprecations = deprecations
+	}
+	if cfg.Events.Enabled {
+		eventsCfg, err := buildEvents(cfg.Events)
+		if err != nil {
+			log.Fatalf("Invalid events configuration: %v", err)
+		}
+		svcCfg.Events = eventsCfg
+		svcCfg.BudgetThresholds = cfg.Events.BudgetThresholds
+	}
+	stream := buildStreaming(cfg.Streaming)
+	svcCfg.Streaming = &stream
+	svcCfg.AccessLog = cfg.Server.AccessLog
+	svcCfg.RequestTimeout = time.Duration(cfg.Server.RequestTimeoutSeconds) * time.Second
+	if cfg.Server.CORS.Enabled {
+		cors, err := buildCORS(cfg.Server.CORS)
+		if err != nil {
+			log.Fatalf("Invalid CORS configuration: %v", err)
+		}
+		svcCfg.CORS = cors
+	}
+	if cfg.Server.Gzip.Enabled {
+		gzip, err := buildGzip(cfg.Server.Gzip)
+		if err != nil {
+			log.Fatalf("Invalid gzip configuration: %v", err)
+		}
+		svcCfg.Gzip = gzip
+	}
+	if cfg.Server.Debug.Enabled {
+		debugCfg, err := buildDebug(cfg)
+		if err != nil {
+			log.Fatalf("Invalid debug configuration: %v", err)
+		}
+		svcCfg.Debug = debugCfg
+	}
+	svc := service.NewService(svcCfg)
 
 	// Initialize service
 	if err := svc.Initialize(); err != nil {
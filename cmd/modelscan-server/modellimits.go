@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// buildModelLimits creates the proxy's per-model caps from configuration
+func buildModelLimits(cfg config.ModelLimitsConfig) (*proxy.ModelLimits, error) {
+	limits := proxy.NewModelLimits(time.Duration(cfg.QueueTimeoutMs) * time.Millisecond)
+	seen := make(map[string]bool)
+	for _, lc := range cfg.Limits {
+		if seen[lc.Model] {
+			return nil, fmt.Errorf("model %q is capped twice", lc.Model)
+		}
+		seen[lc.Model] = true
+		err := limits.SetLimit(proxy.ModelLimit{Model: lc.Model, RPM: lc.RPM, TPM: lc.TPM, MaxInFlight: lc.MaxInFlight})
+		if err != nil {
+			return nil, fmt.Errorf("model limit %q: %w", lc.Model, err)
+		}
+	}
+	return limits, nil
+}
@@ -0,0 +1,10 @@
+//go:build postgres
+
+package main
+
+// Builds with the postgres tag link the pgx PostgreSQL driver, pinned in
+// go.mod, so database.url can point at a shared PostgreSQL server. The
+// default build links only SQLite:
+//
+//	go build -tags postgres -o modelscan-server ./cmd/modelscan-server
+import _ "github.com/jackc/pgx/v5/stdlib"
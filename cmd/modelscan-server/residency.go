@@ -0,0 +1,16 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// buildResidency creates the proxy's data residency policy from configuration
+func buildResidency(cfg config.ResidencyConfig) (*proxy.Residency, error) {
+	if len(cfg.Providers) == 0 {
+		return nil, errors.New("no provider regions configured; every request with a requirement would fail")
+	}
+	return proxy.NewResidency(cfg.Providers, cfg.Clients)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/scheduler"
+)
+
+// buildScheduler creates the provider re-validation and refresh schedule.
+// Providers without an override use the default interval and tasks.
+func buildScheduler(cfg config.SchedulerConfig) (*scheduler.Config, error) {
+	schedCfg := scheduler.DefaultConfig()
+	if cfg.IntervalMinutes > 0 {
+		schedCfg.Interval = time.Duration(cfg.IntervalMinutes) * time.Minute
+	}
+	if cfg.Jitter > 0 {
+		schedCfg.Jitter = cfg.Jitter
+	}
+	if cfg.TimeoutMs > 0 {
+		schedCfg.Timeout = time.Duration(cfg.TimeoutMs) * time.Millisecond
+	}
+
+	tasks, err := parseTasks(cfg.Tasks)
+	if err != nil {
+		return nil, fmt.Errorf("scheduler: %w", err)
+	}
+	schedCfg.Tasks = tasks
+
+	names := make([]string, 0, len(cfg.Providers))
+	for name := range cfg.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pc := cfg.Providers[name]
+		tasks, err := parseTasks(pc.Tasks)
+		if err != nil {
+			return nil, fmt.Errorf("scheduler provider %q: %w", name, err)
+		}
+		schedCfg.Schedules = append(schedCfg.Schedules, scheduler.Schedule{
+			Provider: name,
+			Interval: time.Duration(pc.IntervalMinutes) * time.Minute,
+			Tasks:    tasks,
+		})
+	}
+	return &schedCfg, nil
+}
+
+func parseTasks(names []string) ([]scheduler.Task, error) {
+	var tasks []scheduler.Task
+	for _, name := range names {
+		task, err := scheduler.ParseTask(name)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/scraper"
+)
+
+// buildScraper creates the documentation scraper configuration for the
+// built-in provider sources
+func buildScraper(cfg config.ScraperConfig) *scraper.Config {
+	scraperCfg := scraper.DefaultConfig()
+	if cfg.TimeoutMs > 0 {
+		scraperCfg.Timeout = time.Duration(cfg.TimeoutMs) * time.Millisecond
+	}
+	return &scraperCfg
+}
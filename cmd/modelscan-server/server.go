@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/admin"
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/middleware"
+)
+
+// buildCORS creates the CORS settings from configuration
+func buildCORS(cfg config.CORSConfig) (*middleware.CORSConfig, error) {
+	var origins []string
+	for _, o := range cfg.AllowedOrigins {
+		o = strings.TrimRight(strings.TrimSpace(o), "/")
+		if o == "" {
+			continue
+		}
+		if o != "*" && !strings.HasPrefix(o, "http://") && !strings.HasPrefix(o, "https://") {
+			return nil, fmt.Errorf("allowed origin %q must start with http:// or https://", o)
+		}
+		origins = append(origins, o)
+	}
+	if len(origins) == 0 {
+		return nil, fmt.Errorf("allowed_origins is required")
+	}
+	if cfg.MaxAgeSeconds < 0 {
+		return nil, fmt.Errorf("max_age_seconds must not be negative")
+	}
+	return &middleware.CORSConfig{
+		AllowedOrigins:   origins,
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   cfg.ExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           time.Duration(cfg.MaxAgeSeconds) * time.Second,
+	}, nil
+}
+
+// buildGzip creates the response compression settings from configuration
+func buildGzip(cfg config.GzipConfig) (*middleware.GzipConfig, error) {
+	if cfg.Level < 0 || cfg.Level > 9 {
+		return nil, fmt.Errorf("level must be between 1 and 9")
+	}
+	if cfg.MinSizeBytes < 0 {
+		return nil, fmt.Errorf("min_size_bytes must not be negative")
+	}
+	return &middleware.GzipConfig{MinSize: cfg.MinSizeBytes, Level: cfg.Level}, nil
+}
+
+// buildDebug creates the debug endpoint settings from configuration. The
+// endpoints expose heap contents and command lines, so a token is required.
+func buildDebug(cfg *config.Config) (*admin.DebugConfig, error) {
+	tokenEnv := cfg.Server.Debug.TokenEnv
+	if tokenEnv == "" {
+		tokenEnv = "MODELSCAN_DEBUG_TOKEN"
+	}
+	token := os.Getenv(tokenEnv)
+	if token == "" {
+		return nil, fmt.Errorf("%s must hold the bearer token for the debug endpoints", tokenEnv)
+	}
+	return &admin.DebugConfig{Token: token, Version: version, ConfigHash: cfg.Hash()}, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// defaultSigningSecretEnv holds the signing secret when secret_env is unset
+const defaultSigningSecretEnv = "MODELSCAN_SIGNING_SECRET"
+
+// buildSigner creates the upstream request signer from configuration
+func buildSigner(cfg config.SigningConfig) (mshttp.SignHook, error) {
+	if cfg.KeyID == "" {
+		return nil, fmt.Errorf("key_id is required")
+	}
+	secretEnv := cfg.SecretEnv
+	if secretEnv == "" {
+		secretEnv = defaultSigningSecretEnv
+	}
+	secret := os.Getenv(secretEnv)
+	if secret == "" {
+		return nil, fmt.Errorf("signing secret env %s is not set", secretEnv)
+	}
+
+	sign := mshttp.NewHMACSigner(cfg.KeyID, []byte(secret))
+	if len(cfg.Providers) == 0 {
+		return sign, nil
+	}
+	providers := make(map[string]bool, len(cfg.Providers))
+	for _, p := range cfg.Providers {
+		providers[p] = true
+	}
+	return func(req *http.Request, body []byte) error {
+		if !providers[mshttp.ProviderFrom(req.Context())] {
+			return nil
+		}
+		return sign(req, body)
+	}, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/sla"
+)
+
+// buildSLA creates the SLA tracker settings from configuration
+func buildSLA(cfg config.SLAConfig) (*sla.Config, error) {
+	if cfg.PeriodMinutes < 0 || (cfg.PeriodMinutes > 0 && 24*60%cfg.PeriodMinutes != 0) {
+		return nil, fmt.Errorf("period_minutes must divide a day, got %d", cfg.PeriodMinutes)
+	}
+	if cfg.DownErrorRate < 0 || cfg.DownErrorRate > 1 {
+		return nil, fmt.Errorf("down_error_rate must be between 0 and 1, got %v", cfg.DownErrorRate)
+	}
+	targets := make(map[string]time.Duration, len(cfg.LatencyTargetsMs))
+	for provider, ms := range cfg.LatencyTargetsMs {
+		if ms <= 0 {
+			return nil, fmt.Errorf("latency target for %s must be positive", provider)
+		}
+		targets[provider] = time.Duration(ms) * time.Millisecond
+	}
+	return &sla.Config{
+		Period:        time.Duration(cfg.PeriodMinutes) * time.Minute,
+		LatencyTarget: time.Duration(cfg.LatencyTargetMs) * time.Millisecond,
+		Targets:       targets,
+		DownErrorRate: cfg.DownErrorRate,
+	}, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/sdk/statuspage"
+)
+
+// buildStatusPages creates the status page watcher settings from configuration
+func buildStatusPages(cfg config.StatusPagesConfig) (*statuspage.Config, error) {
+	impact, err := statuspage.ParseImpact(cfg.MinImpact)
+	if err != nil {
+		return nil, fmt.Errorf("min_impact: %w", err)
+	}
+	sources := statuspage.DefaultSources()
+	if len(cfg.Sources) > 0 {
+		sources = make([]statuspage.Source, 0, len(cfg.Sources))
+		for i, src := range cfg.Sources {
+			if src.Provider == "" || src.URL == "" {
+				return nil, fmt.Errorf("source %d: provider and url are required", i+1)
+			}
+			sources = append(sources, statuspage.Source{Provider: src.Provider, URL: src.URL})
+		}
+	}
+	return &statuspage.Config{
+		Sources:   sources,
+		Interval:  time.Duration(cfg.IntervalSeconds) * time.Second,
+		MinImpact: impact,
+	}, nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// buildStreaming creates the stream timing from configuration; zero keeps
+// a default and a negative value disables it
+func buildStreaming(cfg config.StreamingConfig) proxy.StreamConfig {
+	stream := proxy.DefaultStreamConfig()
+	apply := func(d *time.Duration, seconds int) {
+		switch {
+		case seconds < 0:
+			*d = 0
+		case seconds > 0:
+			*d = time.Duration(seconds) * time.Second
+		}
+	}
+	apply(&stream.KeepAlive, cfg.KeepAliveSeconds)
+	apply(&stream.WriteTimeout, cfg.WriteTimeoutSeconds)
+	apply(&stream.IdleTimeout, cfg.IdleTimeoutSeconds)
+	return stream
+}
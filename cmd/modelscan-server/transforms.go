@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// buildTransforms creates the proxy's request transformation rules from
+// configuration, keeping their order
+func buildTransforms(cfg config.TransformsConfig) (*proxy.TransformRules, error) {
+	rules := proxy.NewTransformRules()
+	for _, rc := range cfg.Rules {
+		rule := proxy.TransformRule{
+			Name:    rc.Name,
+			APIs:    rc.APIs,
+			Models:  rc.Models,
+			Clients: rc.Clients,
+			Headers: rc.Headers,
+			Strip:   rc.Strip,
+		}
+		if len(rc.Set) > 0 {
+			rule.Set = make(map[string]json.RawMessage, len(rc.Set))
+			for field, value := range rc.Set {
+				data, err := json.Marshal(value)
+				if err != nil {
+					return nil, fmt.Errorf("transform rule %q: set %s: %w", rc.Name, field, err)
+				}
+				rule.Set[field] = data
+			}
+		}
+		if len(rc.Clamp) > 0 {
+			rule.Clamp = make(map[string]proxy.Range, len(rc.Clamp))
+			for field, r := range rc.Clamp {
+				rule.Clamp[field] = proxy.Range{Min: r.Min, Max: r.Max}
+			}
+		}
+		if _, exists := rules.Rule(rc.Name); exists {
+			return nil, fmt.Errorf("transform rule %q is defined twice", rc.Name)
+		}
+		if err := rules.SetRule(rule); err != nil {
+			return nil, fmt.Errorf("transform rule %q: %w", rc.Name, err)
+		}
+	}
+	return rules, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// buildTransports creates the per-provider upstream connection pools
+func buildTransports(cfg config.TransportConfig) (*mshttp.Pool, error) {
+	defaults, err := buildTransportConfig(cfg.Defaults)
+	if err != nil {
+		return nil, fmt.Errorf("transport defaults: %w", err)
+	}
+	overrides := make(map[string]mshttp.TransportConfig, len(cfg.Providers))
+	for provider, settings := range cfg.Providers {
+		if overrides[provider], err = buildTransportConfig(settings); err != nil {
+			return nil, fmt.Errorf("transport override for %s: %w", provider, err)
+		}
+	}
+	return mshttp.NewPool(defaults, overrides)
+}
+
+// buildRetryBudget creates the per-provider budget of upstream retries
+func buildRetryBudget(cfg config.TransportConfig) (*mshttp.RetryBudget, error) {
+	return mshttp.NewRetryBudget(mshttp.RetryBudgetConfig{Retries: cfg.RetriesPerMinute, Window: time.Minute})
+}
+
+// buildTransportConfig converts one pool's settings, filling in the proxy
+// password from the environment
+func buildTransportConfig(s config.TransportSettings) (mshttp.TransportConfig, error) {
+	tc := mshttp.TransportConfig{
+		MaxIdleConns:            s.MaxIdleConns,
+		MaxIdleConnsPerHost:     s.MaxIdleConnsPerHost,
+		MaxConnsPerHost:         s.MaxConnsPerHost,
+		IdleConnTimeoutMs:       s.IdleConnTimeoutMs,
+		KeepAliveMs:             s.KeepAliveMs,
+		DialTimeoutMs:           s.DialTimeoutMs,
+		TLSHandshakeTimeoutMs:   s.TLSHandshakeTimeoutMs,
+		ResponseHeaderTimeoutMs: s.ResponseHeaderTimeoutMs,
+		HTTP2:                   s.HTTP2,
+		KeepAlives:              s.KeepAlives,
+		Protocol:                s.Protocol,
+		Fallback:                s.Fallback,
+		FallbackRetryMs:         s.FallbackRetryMs,
+		Proxy:                   s.Proxy,
+		NoProxy:                 s.NoProxy,
+		MaxResponseBytes:        s.MaxResponseBytes,
+		MaxDecompressionRatio:   s.MaxDecompressionRatio,
+		ReadTimeoutMs:           s.ReadTimeoutMs,
+	}
+	if s.TLS != nil {
+		tc.TLS = &mshttp.TLSConfig{
+			MinVersion:         s.TLS.MinVersion,
+			CAFile:             s.TLS.CAFile,
+			ServerName:         s.TLS.ServerName,
+			InsecureSkipVerify: s.TLS.InsecureSkipVerify,
+			CertFile:           s.TLS.CertFile,
+			KeyFile:            s.TLS.KeyFile,
+		}
+	}
+	if s.ProxyPasswordEnv != "" {
+		password := os.Getenv(s.ProxyPasswordEnv)
+		if password == "" {
+			return tc, fmt.Errorf("proxy_password_env %s is not set", s.ProxyPasswordEnv)
+		}
+		proxyURL, err := url.Parse(s.Proxy)
+		if err != nil || proxyURL.User == nil {
+			return tc, fmt.Errorf("proxy_password_env needs a proxy URL with a username")
+		}
+		proxyURL.User = url.UserPassword(proxyURL.User.Username(), password)
+		tc.Proxy = proxyURL.String()
+	}
+	return tc, nil
+}
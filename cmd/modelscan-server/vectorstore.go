@@ -0,0 +1,15 @@
+package main
+
+import (
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/vectorstore"
+)
+
+// buildVectorStore creates the vector store settings from configuration
+func buildVectorStore(cfg config.VectorStoreConfig) *vectorstore.Config {
+	return &vectorstore.Config{
+		MaxDimensions:  cfg.MaxDimensions,
+		IndexThreshold: cfg.IndexThreshold,
+		Probes:         cfg.Probes,
+	}
+}
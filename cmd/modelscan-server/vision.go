@@ -0,0 +1,22 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// buildVision creates the proxy's image validation settings from configuration
+func buildVision(cfg config.VisionConfig) *proxy.VisionConfig {
+	limits := make(map[string]proxy.VisionLimits, len(cfg.Limits))
+	for provider, l := range cfg.Limits {
+		limits[provider] = proxy.VisionLimits{MaxImages: l.MaxImages, MaxImageBytes: l.MaxImageBytes}
+	}
+	return &proxy.VisionConfig{
+		InlineProviders:      cfg.InlineProviders,
+		Limits:               limits,
+		FetchTimeout:         time.Duration(cfg.FetchTimeoutMs) * time.Millisecond,
+		AllowPrivateNetworks: cfg.AllowPrivateNetworks,
+	}
+}
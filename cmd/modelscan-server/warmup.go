@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/warmup"
+)
+
+// buildWarmup creates the startup warm-up settings from configuration
+func buildWarmup(cfg config.WarmupConfig) (*warmup.Config, error) {
+	if cfg.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("timeout_seconds must not be negative, got %d", cfg.TimeoutSeconds)
+	}
+	if cfg.Concurrency < 0 {
+		return nil, fmt.Errorf("concurrency must not be negative, got %d", cfg.Concurrency)
+	}
+	for provider, model := range cfg.Completions {
+		if model == "" {
+			return nil, fmt.Errorf("completion model for %s is empty", provider)
+		}
+	}
+	return &warmup.Config{
+		Timeout:     time.Duration(cfg.TimeoutSeconds) * time.Second,
+		Concurrency: cfg.Concurrency,
+		Providers:   cfg.Providers,
+		Models:      cfg.Completions,
+	}, nil
+}
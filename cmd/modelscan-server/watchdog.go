@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/watchdog"
+)
+
+// buildWatchdog creates the resource watchdog settings from configuration
+func buildWatchdog(cfg config.WatchdogConfig) (*watchdog.Config, error) {
+	if cfg.IntervalSeconds < 0 || cfg.CooldownMinutes < 0 || cfg.MaxDumps < 0 {
+		return nil, fmt.Errorf("interval_seconds, cooldown_minutes and max_dumps cannot be negative")
+	}
+	if cfg.MaxGoroutines < 0 || cfg.MaxOpenFiles < 0 || cfg.MaxDBInUse < 0 {
+		return nil, fmt.Errorf("ceilings cannot be negative")
+	}
+	if cfg.TrendSamples == 1 || cfg.TrendSamples < 0 {
+		return nil, fmt.Errorf("trend_samples must be at least 2, got %d", cfg.TrendSamples)
+	}
+	if cfg.MinGrowthPercent < 0 || cfg.MinIncrease < 0 {
+		return nil, fmt.Errorf("min_growth_percent and min_increase cannot be negative")
+	}
+	return &watchdog.Config{
+		Interval:      time.Duration(cfg.IntervalSeconds) * time.Second,
+		MaxGoroutines: cfg.MaxGoroutines,
+		MaxOpenFDs:    cfg.MaxOpenFiles,
+		MaxDBInUse:    cfg.MaxDBInUse,
+		Window:        cfg.TrendSamples,
+		MinGrowth:     cfg.MinGrowthPercent / 100,
+		MinIncrease:   cfg.MinIncrease,
+		Cooldown:      time.Duration(cfg.CooldownMinutes) * time.Minute,
+		DumpDir:       cfg.DumpDir,
+		MaxDumps:      cfg.MaxDumps,
+	}, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/webhook"
+)
+
+// buildWebhooks creates the webhook delivery configuration. Endpoints are
+// fully validated when the service starts the dispatcher.
+func buildWebhooks(cfg config.WebhooksConfig) (*webhook.Config, error) {
+	endpoints := make([]webhook.Endpoint, 0, len(cfg.Endpoints))
+	for _, ec := range cfg.Endpoints {
+		secret := ec.Secret
+		if ec.SecretEnv != "" {
+			secret = os.Getenv(ec.SecretEnv)
+			if secret == "" {
+				return nil, fmt.Errorf("webhook endpoint %q: environment variable %s is not set", ec.Name, ec.SecretEnv)
+			}
+		}
+
+		endpoint := webhook.Endpoint{Name: ec.Name, URL: ec.URL, Secret: secret}
+		for _, name := range ec.Events {
+			eventType, err := webhook.ParseEventType(name)
+			if err != nil {
+				return nil, fmt.Errorf("webhook endpoint %q: %w", ec.Name, err)
+			}
+			endpoint.Events = append(endpoint.Events, eventType)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	webhookCfg := webhook.DefaultConfig(endpoints...)
+	if cfg.MaxAttempts > 0 {
+		webhookCfg.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.TimeoutMs > 0 {
+		webhookCfg.Timeout = time.Duration(cfg.TimeoutMs) * time.Millisecond
+	}
+	return &webhookCfg, nil
+}
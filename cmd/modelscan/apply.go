@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/gitops"
+	"github.com/jeffersonwarrior/modelscan/internal/secrets"
+)
+
+const applyUsage = `Usage: modelscan apply -f DIR [flags]
+
+Reconciles the database with declarative YAML resources, so configuration
+can live in git. Every .yaml and .yml file under DIR holds documents of
+one of these kinds:
+
+  Tenant     Budget and rate limits (id, name, monthly_budget, rpm_limit, tpm_limit)
+  Provider   Upstream provider (id, name, base_url, auth_method, auth_header, pricing_model)
+  Client     Virtual key (id, name, tenant, capabilities, config); its token is
+             generated on creation and printed once
+  Alias      Model alias (name, model, client)
+  RemapRule  Routing policy (client, from_model, to_model, to_provider, strategy,
+             candidates, priority, enabled)
+
+The plan is printed first. Resources are created and updated to match, and
+rows of a kind the directory declares are deleted when not declared; kinds
+it does not declare at all are left alone. A running server picks up alias
+and remap rule changes on its next reload.
+
+Flags:
+  --config PATH      Path to configuration file (default config.yaml)
+  -f DIR             Directory of resource files
+  --dry-run          Print the plan without applying it
+  --auto-approve     Apply without asking for confirmation
+`
+
+// runApply implements the apply subcommand
+func runApply(args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	dir := fs.String("f", "", "Directory of resource files")
+	dryRun := fs.Bool("dry-run", false, "Print the plan without applying it")
+	autoApprove := fs.Bool("auto-approve", false, "Apply without asking for confirmation")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, applyUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		fs.Usage()
+		return fmt.Errorf("apply needs -f DIR")
+	}
+
+	res, err := gitops.Load(*dir)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	dsn := cfg.Database.Path
+	if cfg.Database.URL != "" {
+		dsn = cfg.Database.URL
+	}
+
+	// Applying never migrates; an outdated schema is reported instead.
+	// Client tokens are sealed with the master key when encryption is on.
+	opts := database.Options{ManualMigrations: true}
+	if enc := cfg.Database.Encryption; enc.Enabled {
+		if opts.MasterKey, err = secrets.LoadMasterKey(enc.MasterKeyEnv, enc.MasterKeyCommand); err != nil {
+			return err
+		}
+	}
+	db, err := database.OpenWithOptions(dsn, opts)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	plan, err := gitops.NewPlan(db, res)
+	if err != nil {
+		return err
+	}
+	if plan.Empty() {
+		fmt.Println("No changes; the database matches the configuration")
+		return nil
+	}
+	printPlan(plan)
+
+	if *dryRun {
+		return nil
+	}
+	if !*autoApprove && !confirm("Apply these changes?") {
+		return fmt.Errorf("apply cancelled")
+	}
+
+	return plan.Apply(func(c *gitops.Change, note string) {
+		if note != "" {
+			fmt.Printf("✓ %s %s %s (%s)\n", c.Op, c.Kind, c.Name, note)
+		} else {
+			fmt.Printf("✓ %s %s %s\n", c.Op, c.Kind, c.Name)
+		}
+	})
+}
+
+// printPlan writes the plan table and a summary line
+func printPlan(plan *gitops.Plan) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "OP\tKIND\tNAME\tDETAIL")
+	for _, c := range plan.Changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Op, c.Kind, c.Name, c.Detail)
+	}
+	w.Flush()
+	fmt.Printf("\nPlan: %d to create, %d to update, %d to delete\n",
+		plan.Count(gitops.OpCreate), plan.Count(gitops.OpUpdate), plan.Count(gitops.OpDelete))
+}
+
+// confirm asks a yes/no question on stdin
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s Only 'yes' is accepted: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(answer) == "yes"
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/jeffersonwarrior/modelscan/internal/bench"
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
+	"github.com/jeffersonwarrior/modelscan/sdk/ratelimit"
+	"github.com/jeffersonwarrior/modelscan/storage"
+)
+
+const benchUsage = `Usage: modelscan bench --model MODEL [flags] [TARGET...]
+
+Sends concurrent synthetic chat requests to each target in turn and writes a
+comparison report. A target is a provider name, which forces that provider,
+or "proxy" to use the proxy's own routing (the default). Requests go through
+an in-process proxy unless --url points at a running server.
+
+Requests are throttled with the provider's published limits for --plan from
+the rate limit database, falling back to --rpm when none are known.
+
+Flags:
+  --config PATH       Path to configuration file (default config.yaml)
+  --model MODEL       Model to request (required)
+  --requests N        Requests per target (default 20)
+  --concurrency N     Concurrent requests (default 4)
+  --prompt TEXT       Prompt sent with every request
+  --max-tokens N      Maximum tokens per reply (default 16)
+  --stream            Stream replies and measure time to first token
+  --timeout DURATION  Per-request timeout (default 60s)
+  --url URL           Benchmark a running server instead of an in-process proxy
+  --plan PLAN         Rate limit plan to respect (default tier-1)
+  --rpm N             Requests per minute when no limits are known, 0 for none (default 60)
+  --format FORMAT     Report format: text or json (default text)
+  --output PATH       Report file, or - for stdout (default -)
+  --verbose           Show service logs
+`
+
+// benchProxyTarget uses the proxy's routing without forcing a provider
+const benchProxyTarget = "proxy"
+
+// runBench implements the bench subcommand
+func runBench(args []string) error {
+	defaults := bench.DefaultConfig()
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	model := fs.String("model", "", "Model to request")
+	requests := fs.Int("requests", defaults.Requests, "Requests per target")
+	concurrency := fs.Int("concurrency", defaults.Concurrency, "Concurrent requests")
+	prompt := fs.String("prompt", defaults.Prompt, "Prompt sent with every request")
+	maxTokens := fs.Int("max-tokens", defaults.MaxTokens, "Maximum tokens per reply")
+	stream := fs.Bool("stream", false, "Stream replies")
+	timeout := fs.Duration("timeout", defaults.Timeout, "Per-request timeout")
+	serverURL := fs.String("url", "", "Running server to benchmark")
+	plan := fs.String("plan", "tier-1", "Rate limit plan to respect")
+	rpm := fs.Int("rpm", 60, "Requests per minute when no limits are known")
+	format := fs.String("format", "text", "Report format")
+	output := fs.String("output", "-", "Report file")
+	verbose := fs.Bool("verbose", false, "Show service logs")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, benchUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *model == "" {
+		fs.Usage()
+		return fmt.Errorf("--model is required")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown report format %q (want text or json)", *format)
+	}
+	if *requests <= 0 || *concurrency <= 0 {
+		return fmt.Errorf("--requests and --concurrency must be positive")
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Published rate limits, when a rate limit database is configured
+	rateLimitDB := cfg.Database.RateLimitPath
+	if cfg.Database.URL != "" {
+		rateLimitDB = cfg.Database.URL
+	}
+	if rateLimitDB != "" && storage.GetRateLimitDB() == nil {
+		if err := storage.InitRateLimitDB(rateLimitDB); err != nil {
+			return fmt.Errorf("failed to open rate limit database: %w", err)
+		}
+		defer storage.CloseRateLimitDB()
+	}
+
+	baseURL := strings.TrimSuffix(*serverURL, "/")
+	if baseURL == "" {
+		local, err := startLocalServer(cfg, *verbose)
+		if err != nil {
+			return err
+		}
+		defer local.Close()
+		baseURL = local.URL
+	}
+
+	targets := fs.Args()
+	if len(targets) == 0 {
+		targets = []string{benchProxyTarget}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	benchCfg := bench.Config{
+		Model:       *model,
+		Prompt:      *prompt,
+		MaxTokens:   *maxTokens,
+		Requests:    *requests,
+		Concurrency: *concurrency,
+		Stream:      *stream,
+		Timeout:     *timeout,
+		Client:      &http.Client{},
+	}
+
+	var results []*bench.Result
+	for _, name := range targets {
+		target := bench.Target{
+			Name:    name,
+			URL:     baseURL + "/v1/chat/completions",
+			Header:  http.Header{},
+			Limiter: benchLimiter(name, *plan, *rpm),
+		}
+		if name != benchProxyTarget {
+			target.Header.Set(proxy.HeaderProvider, name)
+		}
+
+		fmt.Fprintf(os.Stderr, "Benchmarking %s (%d requests, %d concurrent)...\n", name, *requests, *concurrency)
+		result, err := bench.Run(ctx, benchCfg, target)
+		if result != nil {
+			results = append(results, result)
+		}
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintln(os.Stderr, "Interrupted; reporting partial results")
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create report: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	// Reports quote provider errors, which can echo keys
+	w = redact.NewWriter(w)
+	if *format == "json" {
+		return bench.WriteJSON(w, results)
+	}
+	return bench.WriteText(w, results)
+}
+
+// benchLimiter picks the provider's published limits for plan, or paces
+// at rpm when none are known
+func benchLimiter(provider, plan string, rpm int) bench.Limiter {
+	if provider != benchProxyTarget && storage.GetRateLimitDB() != nil {
+		if limiter, err := ratelimit.NewRateLimiter(provider, plan); err == nil {
+			fmt.Fprintf(os.Stderr, "%s: respecting %s rate limits\n", provider, plan)
+			limiter.SetSmoothing(true) // Pace like the fallback rather than bursting
+			return limiter
+		}
+	}
+	switch {
+	case rpm > 0 && provider == benchProxyTarget:
+		fmt.Fprintf(os.Stderr, "%s: pacing at %d requests/minute\n", provider, rpm)
+	case rpm > 0:
+		fmt.Fprintf(os.Stderr, "%s: no %s rate limits known, pacing at %d requests/minute\n", provider, plan, rpm)
+	default:
+		fmt.Fprintf(os.Stderr, "%s: not throttled\n", provider)
+	}
+	return bench.NewPacer(rpm)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/chargeback"
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+const chargebackUsage = `Usage: modelscan chargeback [flags]
+
+Writes a month's chargeback report from the cost ledger: each tenant's
+spend split by client (virtual key), and each client's by model. Months
+are UTC calendar months. Only requests charged to a tenant are in the
+ledger.
+
+Flags:
+  --config PATH     Path to configuration file (default config.yaml)
+  --month YYYY-MM   Month to report (default last month)
+  --tenant ID       Report one tenant (default all)
+  --format FORMAT   Output format: markdown, csv or json (default markdown)
+  --output PATH     File to write ("-" for stdout, the default)
+`
+
+// runChargeback implements the chargeback subcommand
+func runChargeback(args []string) error {
+	fs := flag.NewFlagSet("chargeback", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	monthFlag := fs.String("month", "", "Month to report")
+	tenant := fs.String("tenant", "", "Report one tenant")
+	format := fs.String("format", chargeback.FormatMarkdown, "Output format")
+	output := fs.String("output", "-", "File to write")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, chargebackUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if !slices.Contains(chargeback.Formats, *format) {
+		return fmt.Errorf("unknown format %q (want markdown, csv or json)", *format)
+	}
+	month := chargeback.MonthOf(time.Now()).AddDate(0, -1, 0)
+	if *monthFlag != "" {
+		var err error
+		if month, err = chargeback.ParseMonth(*monthFlag); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	dsn := cfg.Database.Path
+	if cfg.Database.URL != "" {
+		dsn = cfg.Database.URL
+	}
+
+	// Reporting never migrates; an outdated schema is reported instead
+	db, err := database.OpenWithOptions(dsn, database.Options{ManualMigrations: true})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	report, err := chargeback.Build(database.NewTenantRepository(db), database.NewClientRepository(db), month, *tenant)
+	if err != nil {
+		return err
+	}
+
+	if *output == "-" {
+		return chargeback.Write(os.Stdout, report, *format)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	err = chargeback.Write(file, report, *format)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✓ Wrote the %s chargeback for %d tenants to %s\n", report.Month.Format("2006-01"), len(report.Tenants), *output)
+	return nil
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+const chatUsage = `Usage: modelscan chat --model MODEL [flags] [MESSAGE...]
+
+Sends a conversation through the same proxy, remapping and key selection
+stack the server uses. With a MESSAGE the reply is printed and chat exits;
+otherwise an interactive session starts (/reset clears the conversation,
+/quit or EOF exits).
+
+Flags:
+  --config PATH      Path to configuration file (default config.yaml)
+  --model MODEL      Model to request (required)
+  --provider NAME    Force the upstream provider, bypassing remap rules
+  --stream           Stream tokens as they arrive
+  --system TEXT      System prompt
+  --max-tokens N     Maximum tokens per reply (default: proxy default)
+  --verbose          Show service logs
+`
+
+// chatSession holds the in-process proxy endpoint and the conversation so far
+type chatSession struct {
+	url      string
+	client   *http.Client
+	db       *database.DB
+	model    string
+	provider string
+	stream   bool
+	system   string
+	maxTok   int
+	messages []proxy.OpenAIMessage
+	out      io.Writer
+	info     io.Writer
+}
+
+// chatResult is what one completion reported back
+type chatResult struct {
+	content  string
+	usage    *proxy.OpenAIUsage
+	provider string
+	model    string
+	key      string
+	elapsed  time.Duration
+}
+
+// runChat implements the chat subcommand
+func runChat(args []string) error {
+	fs := flag.NewFlagSet("chat", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	model := fs.String("model", "", "Model to request")
+	provider := fs.String("provider", "", "Force the upstream provider")
+	stream := fs.Bool("stream", false, "Stream tokens as they arrive")
+	system := fs.String("system", "", "System prompt")
+	maxTokens := fs.Int("max-tokens", 0, "Maximum tokens per reply")
+	verbose := fs.Bool("verbose", false, "Show service logs")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, chatUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *model == "" {
+		fs.Usage()
+		return fmt.Errorf("--model is required")
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	local, err := startLocalServer(cfg, *verbose)
+	if err != nil {
+		return err
+	}
+	defer local.Close()
+
+	// Key and pricing lookups only; the service owns migrations
+	db, err := database.OpenWithOptions(local.DSN, database.Options{ManualMigrations: true})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	session := &chatSession{
+		url:      local.URL + "/v1/chat/completions",
+		client:   &http.Client{},
+		db:       db,
+		model:    *model,
+		provider: *provider,
+		stream:   *stream,
+		system:   *system,
+		maxTok:   *maxTokens,
+		out:      os.Stdout,
+		info:     os.Stderr,
+	}
+
+	if fs.NArg() > 0 {
+		return session.turn(strings.Join(fs.Args(), " "))
+	}
+	return session.repl(os.Stdin)
+}
+
+// repl reads user messages line by line until EOF or /quit
+func (s *chatSession) repl(in io.Reader) error {
+	fmt.Fprintf(s.info, "Chatting with %s (/reset clears the conversation, /quit exits)\n", s.model)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for {
+		fmt.Fprint(s.info, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(s.info)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		switch line {
+		case "":
+			continue
+		case "/quit", "/exit":
+			return nil
+		case "/reset":
+			s.messages = nil
+			fmt.Fprintln(s.info, "Conversation cleared")
+			continue
+		}
+		if err := s.turn(line); err != nil {
+			fmt.Fprintf(s.info, "Error: %v\n", err)
+		}
+	}
+}
+
+// turn sends one user message and records the reply in the conversation.
+// A failed turn leaves the conversation unchanged.
+func (s *chatSession) turn(message string) error {
+	messages := append(s.messages, proxy.OpenAIMessage{Role: "user", Content: message})
+
+	result, err := s.complete(messages)
+	if err != nil {
+		return err
+	}
+	s.messages = append(messages, proxy.OpenAIMessage{Role: "assistant", Content: result.content})
+	s.printSummary(result)
+	return nil
+}
+
+// complete posts the conversation to the proxy and prints the reply
+func (s *chatSession) complete(messages []proxy.OpenAIMessage) (*chatResult, error) {
+	req := proxy.OpenAIRequest{
+		Model:  s.model,
+		Stream: s.stream,
+	}
+	if s.system != "" {
+		req.Messages = append(req.Messages, proxy.OpenAIMessage{Role: "system", Content: s.system})
+	}
+	req.Messages = append(req.Messages, messages...)
+	if s.maxTok > 0 {
+		req.MaxTokens = &s.maxTok
+	}
+	if s.stream {
+		req.StreamOptions = &proxy.StreamOptions{IncludeUsage: true}
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if s.provider != "" {
+		httpReq.Header.Set(proxy.HeaderProvider, s.provider)
+	}
+
+	start := time.Now()
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, errorMessage(resp.Body))
+	}
+
+	result := &chatResult{
+		provider: resp.Header.Get(proxy.HeaderProvider),
+		model:    resp.Header.Get(proxy.HeaderModel),
+		key:      resp.Header.Get(proxy.HeaderKey),
+	}
+	if s.stream {
+		err = s.readStream(resp.Body, result)
+	} else {
+		err = s.readResponse(resp.Body, result)
+	}
+	result.elapsed = time.Since(start)
+	return result, err
+}
+
+// readResponse prints a non-streaming completion
+func (s *chatSession) readResponse(body io.Reader, result *chatResult) error {
+	var resp proxy.OpenAIResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if len(resp.Choices) > 0 {
+		result.content, _ = resp.Choices[0].Message.Content.(string)
+	}
+	result.usage = resp.Usage
+	fmt.Fprintln(s.out, result.content)
+	return nil
+}
+
+// readStream prints tokens from an SSE stream as they arrive
+func (s *chatSession) readStream(body io.Reader, result *chatResult) error {
+	var content strings.Builder
+	defer func() { result.content = content.String() }()
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	event := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			event = strings.TrimPrefix(line, "event: ")
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		if event == "error" {
+			fmt.Fprintln(s.out)
+			return fmt.Errorf("stream error: %s", errorMessage(strings.NewReader(data)))
+		}
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk proxy.OpenAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		if chunk.Usage != nil {
+			result.usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Index == 0 && choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				fmt.Fprint(s.out, choice.Delta.Content)
+			}
+		}
+	}
+	fmt.Fprintln(s.out)
+	return scanner.Err()
+}
+
+// printSummary reports who served the request, token usage and cost
+func (s *chatSession) printSummary(result *chatResult) {
+	parts := []string{valueOr(result.provider, "unknown provider"), valueOr(result.model, s.model)}
+	if result.key != "" {
+		parts = append(parts, s.describeKey(result.provider, result.key))
+	}
+	if u := result.usage; u != nil {
+		parts = append(parts, fmt.Sprintf("%d in / %d out tokens", u.PromptTokens, u.CompletionTokens))
+		if cost, ok := s.cost(result.model, u); ok {
+			parts = append(parts, fmt.Sprintf("$%.6f", cost))
+		}
+	}
+	parts = append(parts, result.elapsed.Round(time.Millisecond).String())
+	fmt.Fprintf(s.info, "[%s]\n", strings.Join(parts, " · "))
+}
+
+// describeKey resolves a key fingerprint to its ID and masked prefix
+func (s *chatSession) describeKey(provider, fingerprint string) string {
+	keys, err := s.db.ListAPIKeys()
+	if err == nil {
+		for _, k := range keys {
+			if k.ProviderID == provider && strings.HasPrefix(k.KeyHash, fingerprint) {
+				prefix := ""
+				if k.KeyPrefix != nil {
+					prefix = " " + strings.TrimSuffix(*k.KeyPrefix, "...") + "…"
+				}
+				return fmt.Sprintf("key #%d%s", k.ID, prefix)
+			}
+		}
+	}
+	// Keys supplied outside the database (e.g. environment variables)
+	return "key " + fingerprint
+}
+
+// cost prices usage from the model's catalog rates, if known
+func (s *chatSession) cost(model string, u *proxy.OpenAIUsage) (float64, bool) {
+	if model == "" {
+		model = s.model
+	}
+	models, err := s.db.ListModels()
+	if err != nil {
+		return 0, false
+	}
+	for _, m := range models {
+		if m.ID != model || (m.CostPer1MIn == nil && m.CostPer1MOut == nil) {
+			continue
+		}
+		var cost float64
+		if m.CostPer1MIn != nil {
+			cost += float64(u.PromptTokens) * *m.CostPer1MIn / 1e6
+		}
+		if m.CostPer1MOut != nil {
+			cost += float64(u.CompletionTokens) * *m.CostPer1MOut / 1e6
+		}
+		return cost, true
+	}
+	return 0, false
+}
+
+// errorMessage extracts the message from an OpenAI-format error body
+func errorMessage(body io.Reader) string {
+	raw, _ := io.ReadAll(io.LimitReader(body, 64*1024))
+	var resp struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(raw, &resp) == nil && resp.Error.Message != "" {
+		return resp.Error.Message
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+func valueOr(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
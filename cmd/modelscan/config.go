@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+const configUsage = `Usage: modelscan config [flags] <command> [FILE]
+
+Moves an instance's configuration (providers, key references, aliases and
+remap rules) between instances, such as from staging to production. Keys
+are referenced by hash; their secrets are never exported, and an import
+reports keys missing on the target instead of creating them. A running
+server picks imported aliases and remap rules up on its next reload.
+
+Commands:
+  export   Write the configuration bundle to FILE (default stdout)
+  import   Create and update items from the bundle in FILE (- for stdin),
+           leaving items the bundle does not mention alone
+
+Flags:
+  --config PATH     Path to configuration file (default config.yaml)
+  --format FORMAT   export: yaml or json (default yaml); import reads either
+  --dry-run         import: show the changes without making them
+  --verbose         import: also list unchanged items
+`
+
+// runConfig implements the config subcommand
+func runConfig(args []string) error {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	format := fs.String("format", "yaml", "Export format")
+	dryRun := fs.Bool("dry-run", false, "Show the changes without making them")
+	verbose := fs.Bool("verbose", false, "Also list unchanged items")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, configUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 || fs.NArg() > 2 {
+		fs.Usage()
+		return fmt.Errorf("missing config command")
+	}
+	command, file := fs.Arg(0), fs.Arg(1)
+	switch {
+	case command != "export" && command != "import":
+		fs.Usage()
+		return fmt.Errorf("unknown config command %q", command)
+	case command == "import" && file == "":
+		return fmt.Errorf("import needs a bundle file, or - for stdin")
+	case *format != "yaml" && *format != "json":
+		return fmt.Errorf("unknown format %q (want yaml or json)", *format)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	dsn := cfg.Database.Path
+	if cfg.Database.URL != "" {
+		dsn = cfg.Database.URL
+	}
+
+	// Bundles never migrate; an outdated schema is reported instead
+	db, err := database.OpenWithOptions(dsn, database.Options{ManualMigrations: true})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if command == "export" {
+		return exportConfig(db, *format, file)
+	}
+	return importConfig(db, file, *dryRun, *verbose)
+}
+
+// exportConfig writes the bundle to path, or stdout when path is empty
+func exportConfig(db *database.DB, format, path string) error {
+	bundle, err := db.ExportConfig()
+	if err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if path != "" && path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create bundle file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(bundle)
+	} else {
+		enc := yaml.NewEncoder(out)
+		enc.SetIndent(2)
+		if err = enc.Encode(bundle); err == nil {
+			err = enc.Close()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if out != io.Writer(os.Stdout) {
+		fmt.Fprintf(os.Stderr, "✓ Exported %d providers, %d keys, %d aliases and %d remap rules to %s\n",
+			len(bundle.Providers), len(bundle.Keys), len(bundle.Aliases), len(bundle.RemapRules), path)
+	}
+	return nil
+}
+
+// importConfig applies the bundle in path, or stdin for "-", and prints
+// the changes
+func importConfig(db *database.DB, path string, dryRun, verbose bool) error {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	// JSON is YAML too, so one decoder reads both formats
+	var bundle database.ConfigBundle
+	if err := yaml.Unmarshal(data, &bundle); err != nil {
+		return fmt.Errorf("invalid config bundle: %w", err)
+	}
+
+	diff, err := db.ImportConfig(&bundle, dryRun)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tACTION\tDETAIL")
+	for _, c := range diff.Changes {
+		if c.Action == database.ConfigUnchanged && !verbose {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Kind, c.Name, c.Action, c.Detail)
+	}
+	w.Flush()
+
+	summary := fmt.Sprintf("%d created, %d updated, %d unchanged, %d keys missing",
+		diff.Counts[database.ConfigCreate], diff.Counts[database.ConfigUpdate],
+		diff.Counts[database.ConfigUnchanged], diff.Counts[database.ConfigMissing])
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "Dry run: %s; nothing was changed\n", summary)
+	} else {
+		fmt.Fprintf(os.Stderr, "✓ Imported: %s\n", summary)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/deprecation"
+)
+
+const deprecationsUsage = `Usage: modelscan deprecations [flags]
+
+Lists the model retirements providers have announced, soonest first, with
+suggested replacements and the aliases pointing at each model, and warns
+about aliases whose model is retired or retires soon. Retirements come
+from a built-in list and, when the server runs with the scraper enabled,
+the providers' deprecation pages.
+
+Flags:
+  --config PATH     Path to configuration file (default config.yaml)
+  --provider NAME   Only retirements for this provider
+  --warn-days N     Flag aliases whose model retires within N days (default deprecations.warning_days, or 30)
+  --aliased         Only retirements of models an alias points at
+  --format FORMAT   Output format: text or json (default text)
+  --strict          Exit non-zero when any alias has a warning
+`
+
+// errAliasWarnings makes deprecations --strict exit non-zero
+var errAliasWarnings = errors.New("aliases point at retiring models")
+
+// runDeprecations implements the deprecations subcommand
+func runDeprecations(args []string) error {
+	fs := flag.NewFlagSet("deprecations", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	provider := fs.String("provider", "", "Only retirements for this provider")
+	warnDays := fs.Int("warn-days", -1, "Flag aliases whose model retires within this many days")
+	aliased := fs.Bool("aliased", false, "Only retirements of models an alias points at")
+	format := fs.String("format", "text", "Output format")
+	strict := fs.Bool("strict", false, "Exit non-zero when any alias has a warning")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, deprecationsUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown output format %q (want text or json)", *format)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	warn := deprecation.DefaultWarning
+	if *warnDays >= 0 {
+		warn = time.Duration(*warnDays) * 24 * time.Hour
+	} else if cfg.Deprecations.WarningDays > 0 {
+		warn = time.Duration(cfg.Deprecations.WarningDays) * 24 * time.Hour
+	}
+	dsn := cfg.Database.Path
+	if cfg.Database.URL != "" {
+		dsn = cfg.Database.URL
+	}
+
+	// Listing retirements never migrates; an outdated schema is reported instead
+	db, err := database.OpenWithOptions(dsn, database.Options{ManualMigrations: true})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	report, err := deprecation.Check(db, time.Now(), warn)
+	if err != nil {
+		return err
+	}
+	filtered := &deprecation.Report{Deprecations: []*deprecation.Deprecation{}, Warnings: []*deprecation.AliasWarning{}}
+	for _, d := range report.Deprecations {
+		if (*provider == "" || d.ProviderID == *provider) && (!*aliased || len(d.Aliases) > 0) {
+			filtered.Deprecations = append(filtered.Deprecations, d)
+		}
+	}
+	for _, w := range report.Warnings {
+		if *provider == "" || w.ProviderID == *provider {
+			filtered.Warnings = append(filtered.Warnings, w)
+		}
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(filtered)
+	} else {
+		printDeprecations(filtered)
+	}
+	if err == nil && *strict && len(filtered.Warnings) > 0 {
+		err = errAliasWarnings
+	}
+	return err
+}
+
+// printDeprecations writes the retirement table and then one warning per
+// alias that needs attention to stderr
+func printDeprecations(report *deprecation.Report) {
+	if len(report.Deprecations) == 0 {
+		fmt.Println("No model retirements tracked")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tMODEL\tSTATE\tRETIRES\tSUGGESTED\tALIASES")
+	for _, d := range report.Deprecations {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			d.ProviderID, d.ModelID, d.State, d.RetiresAt.Format("2006-01-02"), listOrDash(d.Suggestions), listOrDash(d.Aliases))
+	}
+	w.Flush()
+
+	for _, aw := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", aw.Warning)
+	}
+}
+
+// listOrDash joins a list for a table cell
+func listOrDash(list []string) string {
+	if len(list) == 0 {
+		return "-"
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/eval"
+)
+
+const evalUsage = `Usage: modelscan eval [--config config.yaml] <command> [flags]
+
+Runs suites of prompts with answer checks against models and keeps the
+scores, latency and cost of every run so later runs can be compared.
+
+Commands:
+  run --suite FILE [flags] MODEL...   Run a suite against each model and save the runs
+  list [--suite NAME] [--model MODEL] [--limit N]
+                                      List saved runs, newest first
+  show RUN_ID                         Show a run's case results
+  compare BASE_ID HEAD_ID             Compare two runs case by case
+  compare --suite NAME --model MODEL  Compare the model's two latest runs
+
+Run flags:
+  --suite FILE        Suite definition (YAML)
+  --provider NAME     Force the upstream provider (default the proxy's routing)
+  --judge MODEL       Grade cases with a rubric using this model (default the suite's judge)
+  --concurrency N     Cases in flight at once (default 2)
+  --timeout DURATION  Per-case timeout (default 60s)
+  --url URL           Evaluate through a running server instead of an in-process proxy
+  --no-save           Print the results without saving them
+  --verbose           Show service logs
+
+Common flags:
+  --format FORMAT     text or json (default text)
+
+compare exits with status 1 when a case that passed in BASE fails in HEAD.
+`
+
+// errRegression makes compare exit non-zero when a run regressed
+var errRegression = errors.New("regressions found")
+
+// runEval implements the eval subcommand
+func runEval(args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, evalUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("missing eval command")
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	command, rest := fs.Arg(0), fs.Args()[1:]
+	switch command {
+	case "run":
+		return runEvalSuite(cfg, rest)
+	case "list", "show", "compare":
+		db, err := openEvalDB(cfg)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		repo := database.NewEvalRepository(db)
+		switch command {
+		case "list":
+			return listEvalRuns(repo, rest)
+		case "show":
+			return showEvalRun(repo, rest)
+		default:
+			return compareEvalRuns(repo, rest)
+		}
+	}
+	fs.Usage()
+	return fmt.Errorf("unknown eval command %q", command)
+}
+
+// openEvalDB opens the configured database, applying pending migrations
+func openEvalDB(cfg *config.Config) (*database.DB, error) {
+	dsn := cfg.Database.Path
+	if cfg.Database.URL != "" {
+		dsn = cfg.Database.URL
+	}
+	return database.OpenWithOptions(dsn, database.Options{})
+}
+
+// runEvalSuite implements eval run
+func runEvalSuite(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("eval run", flag.ContinueOnError)
+	suitePath := fs.String("suite", "", "Suite definition")
+	provider := fs.String("provider", "", "Upstream provider to force")
+	judgeModel := fs.String("judge", "", "Judge model")
+	concurrency := fs.Int("concurrency", 2, "Cases in flight at once")
+	timeout := fs.Duration("timeout", 60*time.Second, "Per-case timeout")
+	serverURL := fs.String("url", "", "Running server to evaluate through")
+	noSave := fs.Bool("no-save", false, "Do not save the runs")
+	format := fs.String("format", "text", "Output format")
+	verbose := fs.Bool("verbose", false, "Show service logs")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, evalUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *suitePath == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("--suite and at least one model are required")
+	}
+	if err := checkEvalFormat(*format); err != nil {
+		return err
+	}
+
+	suite, err := eval.LoadSuite(*suitePath)
+	if err != nil {
+		return err
+	}
+	if *judgeModel != "" {
+		if suite.Judge == nil {
+			suite.Judge = &eval.Judge{}
+		}
+		suite.Judge.Model = *judgeModel
+	}
+	db, err := openEvalDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	pricer, err := newCatalogPricer(db)
+	if err != nil {
+		return err
+	}
+
+	baseURL := strings.TrimSuffix(*serverURL, "/")
+	if baseURL == "" {
+		local, err := startLocalServer(cfg, *verbose)
+		if err != nil {
+			return err
+		}
+		defer local.Close()
+		baseURL = local.URL
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	runCfg := eval.Config{
+		URL:         baseURL + "/v1/chat/completions",
+		Provider:    *provider,
+		Concurrency: *concurrency,
+		Timeout:     *timeout,
+		Client:      &http.Client{},
+		Pricer:      pricer,
+	}
+	repo := database.NewEvalRepository(db)
+	var runs []*database.EvalRun
+	for _, model := range fs.Args() {
+		fmt.Fprintf(os.Stderr, "Evaluating %s on %s (%d cases)...\n", model, suite.Name, len(suite.Cases))
+		run, err := eval.Run(ctx, runCfg, suite, model)
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintln(os.Stderr, "Interrupted; partial runs are not saved")
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !*noSave {
+			if err := repo.Create(run); err != nil {
+				return err
+			}
+		}
+		runs = append(runs, run)
+	}
+
+	if *format == "json" {
+		return eval.WriteJSON(os.Stdout, runs)
+	}
+	for i, run := range runs {
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := eval.WriteRun(os.Stdout, run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listEvalRuns implements eval list
+func listEvalRuns(repo *database.EvalRepository, args []string) error {
+	fs := flag.NewFlagSet("eval list", flag.ContinueOnError)
+	suite := fs.String("suite", "", "Only runs of this suite")
+	model := fs.String("model", "", "Only runs of this model")
+	limit := fs.Int("limit", 20, "Maximum runs to list")
+	format := fs.String("format", "text", "Output format")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, evalUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkEvalFormat(*format); err != nil {
+		return err
+	}
+
+	runs, err := repo.List(database.EvalRunFilter{Suite: *suite, Model: *model, Limit: *limit})
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		if runs == nil {
+			runs = []*database.EvalRun{}
+		}
+		return eval.WriteJSON(os.Stdout, runs)
+	}
+	if len(runs) == 0 {
+		fmt.Println("no runs")
+		return nil
+	}
+	return eval.WriteRuns(os.Stdout, runs)
+}
+
+// showEvalRun implements eval show
+func showEvalRun(repo *database.EvalRepository, args []string) error {
+	fs := flag.NewFlagSet("eval show", flag.ContinueOnError)
+	format := fs.String("format", "text", "Output format")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, evalUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkEvalFormat(*format); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("show needs a run ID")
+	}
+
+	run, err := loadEvalRun(repo, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		return eval.WriteJSON(os.Stdout, run)
+	}
+	return eval.WriteRun(os.Stdout, run)
+}
+
+// compareEvalRuns implements eval compare
+func compareEvalRuns(repo *database.EvalRepository, args []string) error {
+	fs := flag.NewFlagSet("eval compare", flag.ContinueOnError)
+	suite := fs.String("suite", "", "Suite of the runs to compare")
+	model := fs.String("model", "", "Model of the runs to compare")
+	format := fs.String("format", "text", "Output format")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, evalUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkEvalFormat(*format); err != nil {
+		return err
+	}
+
+	var base, head *database.EvalRun
+	switch {
+	case fs.NArg() == 2:
+		var err error
+		if base, err = loadEvalRun(repo, fs.Arg(0)); err != nil {
+			return err
+		}
+		if head, err = loadEvalRun(repo, fs.Arg(1)); err != nil {
+			return err
+		}
+	case fs.NArg() == 0 && *suite != "" && *model != "":
+		latest, err := repo.List(database.EvalRunFilter{Suite: *suite, Model: *model, Limit: 2})
+		if err != nil {
+			return err
+		}
+		if len(latest) < 2 {
+			return fmt.Errorf("need two runs of %s on %s to compare, found %d", *model, *suite, len(latest))
+		}
+		if base, err = loadEvalRun(repo, strconv.Itoa(latest[1].ID)); err != nil {
+			return err
+		}
+		if head, err = loadEvalRun(repo, strconv.Itoa(latest[0].ID)); err != nil {
+			return err
+		}
+	default:
+		fs.Usage()
+		return fmt.Errorf("compare needs two run IDs, or --suite and --model")
+	}
+
+	c := eval.Compare(base, head)
+	var err error
+	if *format == "json" {
+		err = eval.WriteJSON(os.Stdout, c)
+	} else {
+		err = eval.WriteComparison(os.Stdout, c)
+	}
+	if err == nil && c.Regressed() {
+		err = errRegression
+	}
+	return err
+}
+
+// loadEvalRun reads a run with its results by ID
+func loadEvalRun(repo *database.EvalRepository, arg string) (*database.EvalRun, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid run ID %q", arg)
+	}
+	run, err := repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, fmt.Errorf("run %d not found", id)
+	}
+	return run, nil
+}
+
+// checkEvalFormat validates an output format flag
+func checkEvalFormat(format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown format %q (want text or json)", format)
+	}
+	return nil
+}
+
+// catalogPricer prices requests from the model catalog
+type catalogPricer map[string][2]float64
+
+// newCatalogPricer loads model prices from the database
+func newCatalogPricer(db *database.DB) (catalogPricer, error) {
+	models, err := db.ListModels()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model prices: %w", err)
+	}
+	prices := make(catalogPricer, len(models))
+	for _, m := range models {
+		var p [2]float64
+		if m.CostPer1MIn != nil {
+			p[0] = *m.CostPer1MIn
+		}
+		if m.CostPer1MOut != nil {
+			p[1] = *m.CostPer1MOut
+		}
+		prices[m.ID] = p
+	}
+	return prices, nil
+}
+
+func (p catalogPricer) Price(model string) (float64, float64) {
+	return p[model][0], p[model][1]
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/export"
+)
+
+const exportUsage = `Usage: modelscan export [flags] [DATASET...]
+
+Datasets: models, usage, costs, request_logs (default: all)
+
+Flags:
+  --config PATH    Path to configuration file (default config.yaml)
+  --format FORMAT  csv, jsonl or columnar (default csv)
+  --output PATH    Output directory, or - for stdout with one dataset (default .)
+  --since TIME     Only rows at or after TIME (RFC 3339 or YYYY-MM-DD)
+  --until TIME     Only rows before TIME
+`
+
+// runExport implements the export subcommand
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	formatName := fs.String("format", "csv", "Export format")
+	output := fs.String("output", ".", "Output directory")
+	since := fs.String("since", "", "Only rows at or after this time")
+	until := fs.String("until", "", "Only rows before this time")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, exportUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := export.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	var filter database.ExportFilter
+	if *since != "" {
+		t, err := export.ParseTime(*since)
+		if err != nil {
+			return err
+		}
+		filter.Since = &t
+	}
+	if *until != "" {
+		t, err := export.ParseTime(*until)
+		if err != nil {
+			return err
+		}
+		filter.Until = &t
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	dsn := cfg.Database.Path
+	if cfg.Database.URL != "" {
+		dsn = cfg.Database.URL
+	}
+
+	// Exporting never migrates; an outdated schema is reported instead
+	db, err := database.OpenWithOptions(dsn, database.Options{ManualMigrations: true})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	datasets := fs.Args()
+	if len(datasets) == 0 {
+		datasets = db.ExportDatasets()
+	}
+	for _, dataset := range datasets {
+		if !slices.Contains(db.ExportDatasets(), dataset) {
+			return fmt.Errorf("unknown dataset %q (want one of %s)", dataset, strings.Join(db.ExportDatasets(), ", "))
+		}
+	}
+
+	if *output == "-" {
+		if len(datasets) != 1 {
+			return fmt.Errorf("exporting to stdout needs exactly one dataset")
+		}
+		_, err := exportDataset(db, datasets[0], filter, format, os.Stdout)
+		return err
+	}
+
+	if err := os.MkdirAll(*output, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	for _, dataset := range datasets {
+		path := filepath.Join(*output, dataset+"."+format.Extension())
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		n, err := exportDataset(db, dataset, filter, format, file)
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "✓ Exported %d %s rows to %s\n", n, dataset, path)
+	}
+	return nil
+}
+
+func exportDataset(db *database.DB, dataset string, filter database.ExportFilter, format export.Format, out io.Writer) (int, error) {
+	w, err := export.NewWriter(format, out)
+	if err != nil {
+		return 0, err
+	}
+	n, err := db.Export(dataset, filter, w)
+	if err != nil {
+		return n, err
+	}
+	return n, w.Close()
+}
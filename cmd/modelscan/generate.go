@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jeffersonwarrior/modelscan/internal/discovery"
+	"github.com/jeffersonwarrior/modelscan/internal/generator"
+)
+
+const generateUsage = `Usage: modelscan generate [flags]
+
+Writes a typed Go client package per provider from discovery results, one
+directory per provider under the output directory. Use it from go:generate:
+
+  //go:generate go run github.com/jeffersonwarrior/modelscan/cmd/modelscan generate --input discovery.json --output .
+
+Flags:
+  --input PATH   Discovery results: a JSON object or array, or - for stdin (required)
+  --output PATH  Output directory (default generated)
+`
+
+// runGenerate implements the generate subcommand
+func runGenerate(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	input := fs.String("input", "", "Discovery results JSON")
+	output := fs.String("output", "generated", "Output directory")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, generateUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" {
+		fs.Usage()
+		return fmt.Errorf("--input is required")
+	}
+
+	var data []byte
+	var err error
+	if *input == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*input)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read discovery results: %w", err)
+	}
+	results, err := parseDiscoveryResults(data)
+	if err != nil {
+		return err
+	}
+
+	gen, err := generator.NewGenerator(generator.Config{OutputDir: *output})
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		res, err := gen.GenerateTyped(generator.FromDiscovery(result))
+		if err != nil {
+			return fmt.Errorf("provider %s: %w", result.Provider.ID, err)
+		}
+		fmt.Fprintf(os.Stderr, "✓ Generated %s\n", res.FilePath)
+	}
+	return nil
+}
+
+// parseDiscoveryResults accepts one discovery result or an array of them
+func parseDiscoveryResults(data []byte) ([]*discovery.DiscoveryResult, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var results []*discovery.DiscoveryResult
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, fmt.Errorf("invalid discovery results: %w", err)
+		}
+		return results, nil
+	}
+	var result discovery.DiscoveryResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid discovery result: %w", err)
+	}
+	return []*discovery.DiscoveryResult{&result}, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/keyhealth"
+)
+
+const keysUsage = `Usage: modelscan keys [flags]
+
+Lists the stored API keys with their latest health probe, most urgent
+first, and warns about keys that are failing probes, expired or close to
+expiry. Probes run in the server when key_health is enabled.
+
+Flags:
+  --config PATH     Path to configuration file (default config.yaml)
+  --provider NAME   Only keys for this provider
+  --warn-days N     Flag keys expiring within N days (default key_health.expiry_warning_days, or 7)
+  --format FORMAT   Output format: text or json (default text)
+  --strict          Exit non-zero when any key has a warning
+`
+
+// errKeyWarnings makes keys --strict exit non-zero
+var errKeyWarnings = errors.New("keys need attention")
+
+// runKeys implements the keys subcommand
+func runKeys(args []string) error {
+	fs := flag.NewFlagSet("keys", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	provider := fs.String("provider", "", "Only keys for this provider")
+	warnDays := fs.Int("warn-days", -1, "Flag keys expiring within this many days")
+	format := fs.String("format", "text", "Output format")
+	strict := fs.Bool("strict", false, "Exit non-zero when any key has a warning")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, keysUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown output format %q (want text or json)", *format)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	warn := keyhealth.DefaultExpiryWarning
+	if *warnDays >= 0 {
+		warn = time.Duration(*warnDays) * 24 * time.Hour
+	} else if cfg.KeyHealth.ExpiryWarningDays > 0 {
+		warn = time.Duration(cfg.KeyHealth.ExpiryWarningDays) * 24 * time.Hour
+	}
+	dsn := cfg.Database.Path
+	if cfg.Database.URL != "" {
+		dsn = cfg.Database.URL
+	}
+
+	// Listing keys never migrates; an outdated schema is reported instead
+	db, err := database.OpenWithOptions(dsn, database.Options{ManualMigrations: true})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	report, err := keyhealth.Report(db, time.Now(), warn)
+	if err != nil {
+		return err
+	}
+	keys := make([]*keyhealth.Health, 0, len(report))
+	warnings := 0
+	for _, h := range report {
+		if *provider != "" && h.ProviderID != *provider {
+			continue
+		}
+		if h.Warning != "" {
+			warnings++
+		}
+		keys = append(keys, h)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(keys)
+	} else {
+		printKeyHealth(keys)
+	}
+	if err == nil && *strict && warnings > 0 {
+		err = errKeyWarnings
+	}
+	return err
+}
+
+// printKeyHealth writes the key table and then one warning per key that
+// needs attention to stderr
+func printKeyHealth(keys []*keyhealth.Health) {
+	if len(keys) == 0 {
+		fmt.Println("No API keys stored")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPROVIDER\tKEY\tSTATE\tLAST SUCCESS\tFAILURES\tQUOTA\tEXPIRES")
+	for _, h := range keys {
+		quota := "-"
+		if h.QuotaRemaining != nil {
+			quota = fmt.Sprint(*h.QuotaRemaining)
+			if h.QuotaLimit != nil {
+				quota += fmt.Sprintf("/%d", *h.QuotaLimit)
+			}
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			h.KeyID, h.ProviderID, h.KeyPrefix, h.State, formatTime(h.LastSuccessAt), h.Failures, quota, formatTime(h.ExpiresAt))
+	}
+	w.Flush()
+
+	for _, h := range keys {
+		if h.Warning != "" {
+			fmt.Fprintf(os.Stderr, "Warning: %s key %d (%s) is %s: %s\n", h.ProviderID, h.KeyID, h.KeyPrefix, h.State, h.Warning)
+		}
+	}
+}
+
+// formatTime formats an optional time for a table cell
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/service"
+)
+
+// localServer runs the service on a loopback port so CLI requests take
+// exactly the path they take through the server
+type localServer struct {
+	DSN    string
+	URL    string // Base URL, e.g. http://127.0.0.1:41234
+	svc    *service.Service
+	server *http.Server
+	quiet  bool
+	logOut io.Writer // log output restored by Close
+}
+
+// startLocalServer initializes the service from cfg and serves it on a
+// random loopback port. Service logs are discarded unless verbose is set.
+func startLocalServer(cfg *config.Config, verbose bool) (*localServer, error) {
+	dsn := cfg.Database.Path
+	if cfg.Database.URL != "" {
+		dsn = cfg.Database.URL
+	}
+
+	l := &localServer{DSN: dsn, quiet: !verbose}
+	if l.quiet {
+		l.logOut = log.Writer()
+		log.SetOutput(io.Discard)
+	}
+
+	l.svc = service.NewService(&service.Config{
+		DatabasePath:  dsn,
+		ServerHost:    "127.0.0.1",
+		AgentModel:    cfg.Discovery.AgentModel,
+		ParallelBatch: cfg.Discovery.ParallelBatch,
+		CacheDays:     cfg.Discovery.CacheDays,
+		OutputDir:     cfg.Discovery.OutputDir,
+		RoutingMode:   cfg.Discovery.RoutingMode,
+	})
+	if err := l.svc.Initialize(); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("service initialization failed: %w", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		l.Close()
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+	l.URL = "http://" + ln.Addr().String()
+	l.server = &http.Server{Handler: l.svc.Handler()}
+	go l.server.Serve(ln)
+	return l, nil
+}
+
+// Close stops the server and the service and restores logging
+func (l *localServer) Close() {
+	if l.server != nil {
+		l.server.Close()
+	}
+	l.svc.Stop()
+	if l.quiet {
+		log.SetOutput(l.logOut)
+	}
+}
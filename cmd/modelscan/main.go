@@ -11,12 +11,58 @@ import (
 
 	"github.com/jeffersonwarrior/modelscan/internal/config"
 	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
 	"github.com/jeffersonwarrior/modelscan/internal/service"
 )
 
 const version = "0.3.0"
 
 func main() {
+	// Provider errors can echo keys; mask them in every log line
+	log.SetOutput(redact.NewWriter(os.Stderr))
+
+	// Subcommands
+	if len(os.Args) > 1 {
+		var run func([]string) error
+		switch os.Args[1] {
+		case "migrate":
+			run = runMigrate
+		case "export":
+			run = runExport
+		case "chat":
+			run = runChat
+		case "bench":
+			run = runBench
+		case "generate":
+			run = runGenerate
+		case "eval":
+			run = runEval
+		case "replay":
+			run = runReplay
+		case "keys":
+			run = runKeys
+		case "deprecations":
+			run = runDeprecations
+		case "reconcile":
+			run = runReconcile
+		case "chargeback":
+			run = runChargeback
+		case "secrets":
+			run = runSecrets
+		case "config":
+			run = runConfig
+		case "apply":
+			run = runApply
+		}
+		if run != nil {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+	}
+
 	// Command-line flags
 	configPath := flag.String("config", "config.yaml", "Path to configuration file")
 	showVersion := flag.Bool("version", false, "Show version information")
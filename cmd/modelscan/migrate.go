@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+const migrateUsage = `Usage: modelscan migrate [--config config.yaml] <command>
+
+Commands:
+  status         Show applied and pending migrations
+  up [VERSION]   Apply pending migrations, up to VERSION if given
+  down [STEPS]   Revert the last STEPS migrations (default 1)
+  force VERSION  Mark the schema as at VERSION and clear the dirty state
+                 without running any scripts
+`
+
+// runMigrate implements the migrate subcommand
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, migrateUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("missing migrate command")
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	dsn := cfg.Database.Path
+	if cfg.Database.URL != "" {
+		dsn = cfg.Database.URL
+	}
+
+	db, err := database.OpenForMigration(dsn, database.Options{})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	command, arg := fs.Arg(0), fs.Arg(1)
+	switch command {
+	case "status":
+		status, err := db.SchemaStatus()
+		if err != nil {
+			return err
+		}
+		printSchemaStatus(status)
+		return nil
+
+	case "up":
+		target, err := optionalInt(arg, 0)
+		if err != nil {
+			return err
+		}
+		applied, err := db.MigrateUp(target)
+		for _, v := range applied {
+			fmt.Printf("applied %d\n", v)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+
+	case "down":
+		steps, err := optionalInt(arg, 1)
+		if err != nil {
+			return err
+		}
+		reverted, err := db.MigrateDown(steps)
+		for _, v := range reverted {
+			fmt.Printf("reverted %d\n", v)
+		}
+		return err
+
+	case "force":
+		if arg == "" {
+			return fmt.Errorf("force requires a version")
+		}
+		version, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", arg)
+		}
+		if err := db.ForceVersion(version); err != nil {
+			return err
+		}
+		fmt.Printf("schema forced to version %d\n", version)
+		return nil
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+}
+
+// optionalInt parses a positional number, returning def when it is absent
+func optionalInt(arg string, def int) (int, error) {
+	if arg == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number %q", arg)
+	}
+	return n, nil
+}
+
+func printSchemaStatus(status *database.SchemaStatus) {
+	fmt.Printf("Schema version: %d (latest %d)\n", status.Version, status.Latest)
+	if status.Dirty {
+		fmt.Printf("DIRTY: migration %d failed; repair the schema and run 'modelscan migrate force VERSION'\n", status.DirtyVersion)
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tAPPLIED\tDESCRIPTION")
+	for _, m := range status.Migrations {
+		applied := "pending"
+		if m.AppliedAt != nil {
+			applied = m.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", m.Version, applied, m.Description)
+	}
+	w.Flush()
+}
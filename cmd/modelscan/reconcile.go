@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/reconcile"
+)
+
+const reconcileUsage = `Usage: modelscan reconcile --source FORMAT [flags] FILE
+
+Checks a provider's invoice against the cost ledger. FILE is the usage or
+cost CSV exported from the provider's console ("-" for stdin); its cost is
+totalled by UTC day and model and set beside what the ledger recorded for
+the provider on the same days. Differences over both thresholds are
+flagged. Only requests charged to a tenant are in the ledger.
+
+Flags:
+  --config PATH             Path to configuration file (default config.yaml)
+  --source FORMAT           Export format: openai or anthropic
+  --provider NAME           Ledger provider to compare with (default the format's)
+  --threshold-percent N     Flag differences over N% of the larger amount (default 5)
+  --threshold-usd N         and over N dollars (default 0.01)
+  --all                     List every day and model, not only discrepancies
+  --format FORMAT           Output format: text or json (default text)
+  --strict                  Exit non-zero when any discrepancy is found
+`
+
+// errDiscrepancies makes reconcile --strict exit non-zero
+var errDiscrepancies = errors.New("the invoice does not match the ledger")
+
+// runReconcile implements the reconcile subcommand
+func runReconcile(args []string) error {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	source := fs.String("source", "", "Export format")
+	provider := fs.String("provider", "", "Ledger provider to compare with")
+	percent := fs.Float64("threshold-percent", reconcile.DefaultThresholdPercent, "Flag differences over this percentage")
+	usd := fs.Float64("threshold-usd", reconcile.DefaultThresholdUSD, "Flag differences over this many dollars")
+	all := fs.Bool("all", false, "List every day and model")
+	format := fs.String("format", "text", "Output format")
+	strict := fs.Bool("strict", false, "Exit non-zero when any discrepancy is found")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, reconcileUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one export file")
+	}
+	if _, ok := reconcile.Formats[*source]; !ok {
+		return fmt.Errorf("unknown source %q (want openai or anthropic)", *source)
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown output format %q (want text or json)", *format)
+	}
+
+	var in io.Reader = os.Stdin
+	if path := fs.Arg(0); path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	dsn := cfg.Database.Path
+	if cfg.Database.URL != "" {
+		dsn = cfg.Database.URL
+	}
+
+	// Reconciling never migrates; an outdated schema is reported instead
+	db, err := database.OpenWithOptions(dsn, database.Options{ManualMigrations: true})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	report, err := reconcile.Import(in, *source, database.NewTenantRepository(db), reconcile.Options{
+		Provider:         *provider,
+		ThresholdPercent: *percent,
+		ThresholdUSD:     *usd,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		printReconciliation(report, *all)
+	}
+	if err == nil && *strict && report.Discrepancies > 0 {
+		err = errDiscrepancies
+	}
+	return err
+}
+
+// printReconciliation writes the totals, then the discrepancies or every
+// line
+func printReconciliation(r *reconcile.Report, all bool) {
+	fmt.Printf("%s invoice, %s to %s, against the %s ledger\n",
+		r.Format, r.From.Format("2006-01-02"), r.To.Format("2006-01-02"), r.Provider)
+	fmt.Printf("Invoiced $%.2f, recorded $%.2f, difference $%.2f (%.1f%%)\n", r.Invoiced, r.Recorded, r.Difference, r.Percent)
+	if r.Skipped > 0 {
+		fmt.Printf("Skipped %d of %d rows without a date or cost\n", r.Skipped, r.Rows)
+	}
+	if r.Discrepancies == 0 && !all {
+		fmt.Printf("No discrepancies over %.1f%% and $%.2f\n", r.ThresholdPercent, r.ThresholdUSD)
+		return
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "DAY\tMODEL\tINVOICED\tRECORDED\tDIFFERENCE\tPERCENT\t")
+	for _, l := range r.Lines {
+		if !all && !l.Discrepancy {
+			continue
+		}
+		mark := ""
+		if l.Discrepancy {
+			mark = "!"
+		}
+		fmt.Fprintf(w, "%s\t%s\t$%.4f\t$%.4f\t$%.4f\t%.1f%%\t%s\n",
+			l.Day.Format("2006-01-02"), l.Model, l.Invoiced, l.Recorded, l.Difference, l.Percent, mark)
+	}
+	w.Flush()
+}
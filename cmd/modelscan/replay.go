@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
+	"github.com/jeffersonwarrior/modelscan/internal/replay"
+)
+
+const replayUsage = `Usage: modelscan replay [flags] CASSETTE...
+
+Re-sends the chat requests in cassettes written by the HTTP recorder (such
+as the scanner's --record cassettes) to another model or provider and
+reports how the answers changed: a line diff of each changed answer with
+its token, cost and latency deltas, then the totals. OpenAI-style chat
+completions and Anthropic messages requests are replayed; streamed requests
+are replayed without streaming. Requests go through an in-process proxy
+unless --url points at a running server.
+
+Flags:
+  --config PATH       Path to configuration file (default config.yaml)
+  --model MODEL       Model to replay against (default the recorded model)
+  --provider NAME     Force the upstream provider (default the proxy's routing)
+  --concurrency N     Requests in flight at once (default 2)
+  --timeout DURATION  Per-request timeout (default 60s)
+  --url URL           Replay through a running server instead of an in-process proxy
+  --format FORMAT     Report format: text or json (default text)
+  --output PATH       Report file, or - for stdout (default -)
+  --verbose           Show service logs
+
+At least one of --model and --provider is required.
+`
+
+// runReplay implements the replay subcommand
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	model := fs.String("model", "", "Model to replay against")
+	provider := fs.String("provider", "", "Upstream provider to force")
+	concurrency := fs.Int("concurrency", 2, "Requests in flight at once")
+	timeout := fs.Duration("timeout", 60*time.Second, "Per-request timeout")
+	serverURL := fs.String("url", "", "Running server to replay through")
+	format := fs.String("format", "text", "Report format")
+	output := fs.String("output", "-", "Report file")
+	verbose := fs.Bool("verbose", false, "Show service logs")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, replayUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 || (*model == "" && *provider == "") {
+		fs.Usage()
+		return fmt.Errorf("a cassette and --model or --provider are required")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown report format %q (want text or json)", *format)
+	}
+
+	var exchanges []*replay.Exchange
+	skipped := 0
+	for _, path := range fs.Args() {
+		loaded, n, err := replay.Load(path)
+		if err != nil {
+			return err
+		}
+		exchanges = append(exchanges, loaded...)
+		skipped += n
+	}
+	if len(exchanges) == 0 {
+		return fmt.Errorf("no chat requests to replay (%d interactions skipped)", skipped)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	dsn := cfg.Database.Path
+	if cfg.Database.URL != "" {
+		dsn = cfg.Database.URL
+	}
+	db, err := database.OpenWithOptions(dsn, database.Options{})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	pricer, err := newCatalogPricer(db)
+	if err != nil {
+		return err
+	}
+
+	baseURL := strings.TrimSuffix(*serverURL, "/")
+	if baseURL == "" {
+		local, err := startLocalServer(cfg, *verbose)
+		if err != nil {
+			return err
+		}
+		defer local.Close()
+		baseURL = local.URL
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "Replaying %d requests...\n", len(exchanges))
+	report, err := replay.Run(ctx, replay.Config{
+		URL:         baseURL + "/v1/chat/completions",
+		Model:       *model,
+		Provider:    *provider,
+		Concurrency: *concurrency,
+		Timeout:     *timeout,
+		Client:      &http.Client{},
+		Pricer:      pricer,
+	}, exchanges)
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "Interrupted; reporting partial results")
+	} else if err != nil {
+		return err
+	}
+	report.Skipped = skipped
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create report: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	// Reports quote provider errors, which can echo keys
+	w = redact.NewWriter(w)
+	if *format == "json" {
+		return replay.WriteJSON(w, report)
+	}
+	return replay.WriteText(w, report)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jeffersonwarrior/modelscan/internal/config"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/secrets"
+)
+
+const secretsUsage = `Usage: modelscan secrets [flags] <command>
+
+Manages encryption at rest of API keys and client tokens. The master key is
+read from database.encryption.master_key_env (default MODELSCAN_MASTER_KEY)
+or the output of database.encryption.master_key_command.
+
+Commands:
+  status   Show whether secrets are encrypted and how many are in plaintext
+  encrypt  Encrypt client tokens stored before encryption was enabled
+  rotate   Rewrap the data keys with a new master key; set the new key in
+           the server's environment before restarting it
+
+Flags:
+  --config PATH           Path to configuration file (default config.yaml)
+  --new-key-env VAR       rotate: env var holding the new master key
+  --new-key-command CMD   rotate: command printing the new master key
+`
+
+// runSecrets implements the secrets subcommand
+func runSecrets(args []string) error {
+	fs := flag.NewFlagSet("secrets", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	newKeyEnv := fs.String("new-key-env", "", "Env var holding the new master key")
+	newKeyCommand := fs.String("new-key-command", "", "Command printing the new master key")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, secretsUsage) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("missing secrets command")
+	}
+	command := fs.Arg(0)
+	if command != "status" && command != "encrypt" && command != "rotate" {
+		fs.Usage()
+		return fmt.Errorf("unknown secrets command %q", command)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	dsn := cfg.Database.Path
+	if cfg.Database.URL != "" {
+		dsn = cfg.Database.URL
+	}
+
+	// status also works without a master key; the other commands need it
+	enc := cfg.Database.Encryption
+	master, err := secrets.LoadMasterKey(enc.MasterKeyEnv, enc.MasterKeyCommand)
+	if err != nil && command != "status" {
+		return err
+	}
+
+	var next *secrets.MasterKey
+	if command == "rotate" {
+		if *newKeyEnv == "" && *newKeyCommand == "" {
+			return fmt.Errorf("rotate requires --new-key-env or --new-key-command")
+		}
+		if next, err = secrets.LoadMasterKey(*newKeyEnv, *newKeyCommand); err != nil {
+			return fmt.Errorf("new master key: %w", err)
+		}
+		if next.ID() == master.ID() {
+			return fmt.Errorf("new master key is the same as the current one")
+		}
+	}
+
+	// status is read-only: opening with a master key creates the first data key
+	opts := database.Options{ManualMigrations: true}
+	if command != "status" {
+		opts.MasterKey = master
+	}
+	db, err := database.OpenWithOptions(dsn, opts)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch command {
+	case "encrypt":
+		n, err := db.EncryptPlaintextSecrets()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("encrypted %d client tokens\n", n)
+		return nil
+
+	case "rotate":
+		if err := db.RotateMasterKey(next); err != nil {
+			return err
+		}
+		fmt.Printf("data keys rewrapped from master key %s to %s\n", master.ID(), next.ID())
+		return nil
+	}
+
+	status, err := db.SecretStatus()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	masterID := "not loaded"
+	if master != nil {
+		masterID = master.ID()
+	}
+	fmt.Fprintf(w, "Master key:\t%s\n", masterID)
+	fmt.Fprintf(w, "Data keys:\t%d\n", status.DataKeys)
+	fmt.Fprintf(w, "Client tokens:\t%d encrypted, %d plaintext\n", status.EncryptedTokens, status.PlaintextTokens)
+	fmt.Fprintf(w, "API key values:\t%d stored encrypted, %d not stored\n", status.StoredKeys, status.UnstoredKeys)
+	w.Flush()
+	if status.PlaintextTokens > 0 && master != nil {
+		fmt.Fprintln(os.Stderr, "Warning: run modelscan secrets encrypt to encrypt the plaintext client tokens")
+	}
+	return nil
+}
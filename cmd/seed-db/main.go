@@ -36,9 +36,21 @@ func main() {
 		log.Fatalf("Failed to seed pricing: %v", err)
 	}
 
+	// Seed model quality ratings
+	if err := scraper.SeedInitialQuality(); err != nil {
+		log.Fatalf("Failed to seed quality ratings: %v", err)
+	}
+
+	// Seed the model capability matrix
+	if err := scraper.SeedInitialCapabilities(); err != nil {
+		log.Fatalf("Failed to seed capabilities: %v", err)
+	}
+
 	log.Println("✅ Database seeded successfully!")
 	log.Println("\nNext steps:")
 	log.Println("  1. Review rate_limits.db with: sqlite3 rate_limits.db")
 	log.Println("  2. Query rate limits: SELECT * FROM rate_limits WHERE provider_name='openai';")
 	log.Println("  3. Query pricing: SELECT * FROM provider_pricing WHERE provider_name='anthropic';")
+	log.Println("  4. Query quality: SELECT * FROM model_quality ORDER BY score DESC;")
+	log.Println("  5. Query capabilities: SELECT * FROM model_capabilities WHERE supports_vision = 1;")
 }
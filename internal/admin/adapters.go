@@ -9,6 +9,9 @@ import (
 	"github.com/jeffersonwarrior/modelscan/internal/discovery"
 	"github.com/jeffersonwarrior/modelscan/internal/generator"
 	"github.com/jeffersonwarrior/modelscan/internal/keymanager"
+	"github.com/jeffersonwarrior/modelscan/internal/scheduler"
+	"github.com/jeffersonwarrior/modelscan/internal/webhook"
+	"github.com/jeffersonwarrior/modelscan/routing"
 )
 
 // DatabaseAdapter adapts database.DB to admin.Database interface
@@ -131,6 +134,11 @@ func (a *DatabaseAdapter) GetUsageStats(modelID string, since time.Time) (map[st
 	return a.db.GetUsageStats(modelID, since)
 }
 
+// UsageByModel returns per-model usage since a time
+func (a *DatabaseAdapter) UsageByModel(since time.Time) ([]*ModelUsageSummary, error) {
+	return usageByModel(a.db, since)
+}
+
 func (a *DatabaseAdapter) GetKeyStats(keyID int, since time.Time) (*KeyStats, error) {
 	stats, err := a.db.GetKeyStats(keyID, since)
 	if err != nil {
@@ -403,6 +411,8 @@ func (a *DatabaseRemapAdapter) Create(rule *RemapRule) error {
 		FromModel:  rule.FromModel,
 		ToModel:    rule.ToModel,
 		ToProvider: rule.ToProvider,
+		Strategy:   rule.Strategy,
+		Candidates: rule.Candidates,
 		Priority:   rule.Priority,
 		Enabled:    rule.Enabled,
 		CreatedAt:  rule.CreatedAt,
@@ -411,6 +421,7 @@ func (a *DatabaseRemapAdapter) Create(rule *RemapRule) error {
 		return err
 	}
 	rule.ID = dbRule.ID
+	rule.Strategy = dbRule.Strategy
 	return nil
 }
 
@@ -429,6 +440,8 @@ func (a *DatabaseRemapAdapter) Get(id int) (*RemapRule, error) {
 		FromModel:  dbRule.FromModel,
 		ToModel:    dbRule.ToModel,
 		ToProvider: dbRule.ToProvider,
+		Strategy:   dbRule.Strategy,
+		Candidates: dbRule.Candidates,
 		Priority:   dbRule.Priority,
 		Enabled:    dbRule.Enabled,
 		CreatedAt:  dbRule.CreatedAt,
@@ -449,6 +462,8 @@ func (a *DatabaseRemapAdapter) List(clientID *string) ([]*RemapRule, error) {
 			FromModel:  dbRule.FromModel,
 			ToModel:    dbRule.ToModel,
 			ToProvider: dbRule.ToProvider,
+			Strategy:   dbRule.Strategy,
+			Candidates: dbRule.Candidates,
 			Priority:   dbRule.Priority,
 			Enabled:    dbRule.Enabled,
 			CreatedAt:  dbRule.CreatedAt,
@@ -465,6 +480,8 @@ func (a *DatabaseRemapAdapter) Update(rule *RemapRule) error {
 		FromModel:  rule.FromModel,
 		ToModel:    rule.ToModel,
 		ToProvider: rule.ToProvider,
+		Strategy:   rule.Strategy,
+		Candidates: rule.Candidates,
 		Priority:   rule.Priority,
 		Enabled:    rule.Enabled,
 		CreatedAt:  rule.CreatedAt,
@@ -606,3 +623,605 @@ func convertToDBRateLimit(rl *ClientRateLimit) *database.ClientRateLimit {
 		LastReset:    rl.LastReset,
 	}
 }
+
+// CanaryAdapter adapts routing.CanaryRouter and database.CanaryResultRepository
+// to the CanaryManager interface
+type CanaryAdapter struct {
+	router *routing.CanaryRouter
+	repo   *database.CanaryResultRepository
+}
+
+// NewCanaryAdapter creates a new adapter
+func NewCanaryAdapter(router *routing.CanaryRouter, repo *database.CanaryResultRepository) *CanaryAdapter {
+	return &CanaryAdapter{router: router, repo: repo}
+}
+
+// ListCanaries returns the live state of all canaries
+func (a *CanaryAdapter) ListCanaries() ([]*Canary, error) {
+	results := a.router.List()
+	canaries := make([]*Canary, len(results))
+	for i, result := range results {
+		canaries[i] = toAdminCanary(result)
+	}
+	return canaries, nil
+}
+
+// GetCanary returns the live state of a canary, or nil if not found
+func (a *CanaryAdapter) GetCanary(id string) (*Canary, error) {
+	result, ok := a.router.Get(id)
+	if !ok {
+		return nil, nil
+	}
+	return toAdminCanary(result), nil
+}
+
+// AddCanary starts a new canary policy
+func (a *CanaryAdapter) AddCanary(policy *CanaryPolicy) error {
+	return a.router.AddPolicy(routing.CanaryPolicy{
+		ID:                   policy.ID,
+		ModelPattern:         policy.ModelPattern,
+		CanaryModel:          policy.CanaryModel,
+		CanaryProvider:       policy.CanaryProvider,
+		Percent:              policy.Percent,
+		MinSamples:           policy.MinSamples,
+		PromoteAfter:         policy.PromoteAfter,
+		MaxErrorRateIncrease: policy.MaxErrorRateIncrease,
+		MaxLatencyRatio:      policy.MaxLatencyRatio,
+		MaxQualityDrop:       policy.MaxQualityDrop,
+	})
+}
+
+// RemoveCanary stops a canary policy
+func (a *CanaryAdapter) RemoveCanary(id string) error {
+	return a.router.RemovePolicy(id)
+}
+
+// PromoteCanary manually promotes a canary
+func (a *CanaryAdapter) PromoteCanary(id, reason string) error {
+	return a.router.Promote(id, reason)
+}
+
+// RollbackCanary manually rolls back a canary
+func (a *CanaryAdapter) RollbackCanary(id, reason string) error {
+	return a.router.Rollback(id, reason)
+}
+
+// ListCanaryResults returns persisted canary results, newest first
+func (a *CanaryAdapter) ListCanaryResults(policyID string, limit int) ([]*CanaryResult, error) {
+	dbResults, err := a.repo.List(policyID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*CanaryResult, len(dbResults))
+	for i, r := range dbResults {
+		results[i] = &CanaryResult{
+			ID:             r.ID,
+			PolicyID:       r.PolicyID,
+			ModelPattern:   r.ModelPattern,
+			CanaryModel:    r.CanaryModel,
+			CanaryProvider: r.CanaryProvider,
+			Percent:        r.Percent,
+			Status:         r.Status,
+			Reason:         r.Reason,
+			Baseline: CanaryArm{
+				Requests:     r.BaselineRequests,
+				Errors:       r.BaselineErrors,
+				ErrorRate:    errorRate(r.BaselineErrors, r.BaselineRequests),
+				AvgLatencyMs: r.BaselineAvgLatencyMs,
+				AvgQuality:   r.BaselineAvgQuality,
+			},
+			Canary: CanaryArm{
+				Requests:     r.CanaryRequests,
+				Errors:       r.CanaryErrors,
+				ErrorRate:    errorRate(r.CanaryErrors, r.CanaryRequests),
+				AvgLatencyMs: r.CanaryAvgLatencyMs,
+				AvgQuality:   r.CanaryAvgQuality,
+			},
+			CreatedAt: r.CreatedAt,
+		}
+	}
+	return results, nil
+}
+
+// toAdminCanary converts a routing canary result to the admin format
+func toAdminCanary(result *routing.CanaryResult) *Canary {
+	p := result.Policy
+	return &Canary{
+		Policy: CanaryPolicy{
+			ID:                   p.ID,
+			ModelPattern:         p.ModelPattern,
+			CanaryModel:          p.CanaryModel,
+			CanaryProvider:       p.CanaryProvider,
+			Percent:              p.Percent,
+			MinSamples:           p.MinSamples,
+			PromoteAfter:         p.PromoteAfter,
+			MaxErrorRateIncrease: p.MaxErrorRateIncrease,
+			MaxLatencyRatio:      p.MaxLatencyRatio,
+			MaxQualityDrop:       p.MaxQualityDrop,
+		},
+		Status:    string(result.Status),
+		Reason:    result.Reason,
+		Baseline:  toAdminCanaryArm(result.Baseline),
+		Canary:    toAdminCanaryArm(result.Canary),
+		UpdatedAt: result.UpdatedAt,
+	}
+}
+
+// toAdminCanaryArm converts routing arm stats to the admin format
+func toAdminCanaryArm(stats routing.CanaryArmStats) CanaryArm {
+	arm := CanaryArm{
+		Requests:     stats.Requests,
+		Errors:       stats.Errors,
+		ErrorRate:    stats.ErrorRate(),
+		AvgLatencyMs: float64(stats.AvgLatency()) / float64(time.Millisecond),
+	}
+	if quality, ok := stats.AvgQuality(); ok {
+		arm.AvgQuality = &quality
+	}
+	return arm
+}
+
+// errorRate returns errors/requests, or zero when there were no requests
+func errorRate(errors, requests int) float64 {
+	if requests == 0 {
+		return 0
+	}
+	return float64(errors) / float64(requests)
+}
+
+// DatabaseShadowAdapter adapts database.DB to the ShadowStore interface
+type DatabaseShadowAdapter struct {
+	db *database.DB
+}
+
+// NewDatabaseShadowAdapter creates a new adapter
+func NewDatabaseShadowAdapter(db *database.DB) *DatabaseShadowAdapter {
+	return &DatabaseShadowAdapter{db: db}
+}
+
+// ListShadowResults returns recorded shadow comparisons, newest first
+func (a *DatabaseShadowAdapter) ListShadowResults(filter ShadowFilter) ([]*ShadowResult, error) {
+	dbResults, err := a.db.ListShadowResults(toDBShadowFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*ShadowResult, len(dbResults))
+	for i, r := range dbResults {
+		result := &ShadowResult{
+			ID:            r.ID,
+			PrimaryStream: r.PrimaryStream,
+			Primary: ShadowSide{
+				Provider:         r.PrimaryProvider,
+				Model:            r.PrimaryModel,
+				Status:           r.PrimaryStatus,
+				LatencyMs:        r.PrimaryLatencyMs,
+				Response:         r.PrimaryResponse,
+				PromptTokens:     r.PrimaryPromptTokens,
+				CompletionTokens: r.PrimaryCompletionTokens,
+			},
+			Shadow: ShadowSide{
+				Provider:         r.ShadowProvider,
+				Model:            r.ShadowModel,
+				Status:           r.ShadowStatus,
+				LatencyMs:        r.ShadowLatencyMs,
+				Response:         r.ShadowResponse,
+				PromptTokens:     r.ShadowPromptTokens,
+				CompletionTokens: r.ShadowCompletionTokens,
+			},
+			CreatedAt: r.CreatedAt,
+		}
+		if r.ClientID != nil {
+			result.ClientID = *r.ClientID
+		}
+		if r.ShadowError != nil {
+			result.Shadow.Error = *r.ShadowError
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// SummarizeShadowResults aggregates shadow comparisons per model pair
+func (a *DatabaseShadowAdapter) SummarizeShadowResults(filter ShadowFilter) ([]*ShadowSummary, error) {
+	dbSummaries, err := a.db.GetShadowSummaries(toDBShadowFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]*ShadowSummary, len(dbSummaries))
+	for i, s := range dbSummaries {
+		summaries[i] = &ShadowSummary{
+			Count: s.Count,
+			Primary: ShadowSideSummary{
+				Provider:         s.PrimaryProvider,
+				Model:            s.PrimaryModel,
+				Errors:           s.PrimaryErrors,
+				ErrorRate:        errorRate(s.PrimaryErrors, s.Count),
+				AvgLatencyMs:     s.PrimaryAvgLatencyMs,
+				PromptTokens:     s.PrimaryPromptTokens,
+				CompletionTokens: s.PrimaryCompletionTokens,
+			},
+			Shadow: ShadowSideSummary{
+				Provider:         s.ShadowProvider,
+				Model:            s.ShadowModel,
+				Errors:           s.ShadowErrors,
+				ErrorRate:        errorRate(s.ShadowErrors, s.Count),
+				AvgLatencyMs:     s.ShadowAvgLatencyMs,
+				PromptTokens:     s.ShadowPromptTokens,
+				CompletionTokens: s.ShadowCompletionTokens,
+			},
+		}
+	}
+	return summaries, nil
+}
+
+// toDBShadowFilter converts an admin shadow filter to the database format
+func toDBShadowFilter(filter ShadowFilter) *database.ShadowResultFilter {
+	dbFilter := &database.ShadowResultFilter{
+		Since: filter.Since,
+	}
+	if filter.PrimaryModel != "" {
+		dbFilter.PrimaryModel = &filter.PrimaryModel
+	}
+	if filter.ShadowModel != "" {
+		dbFilter.ShadowModel = &filter.ShadowModel
+	}
+	return dbFilter
+}
+
+// DatabaseWebhookAdapter adapts database.DB to the WebhookStore and
+// webhook.DeadLetterStore interfaces
+type DatabaseWebhookAdapter struct {
+	db *database.DB
+}
+
+// NewDatabaseWebhookAdapter creates a new adapter
+func NewDatabaseWebhookAdapter(db *database.DB) *DatabaseWebhookAdapter {
+	return &DatabaseWebhookAdapter{db: db}
+}
+
+// SaveDeadLetter persists a failed delivery
+func (a *DatabaseWebhookAdapter) SaveDeadLetter(dl *webhook.DeadLetter) error {
+	dbLetter := &database.WebhookDeadLetter{
+		Endpoint:  dl.Endpoint,
+		URL:       dl.URL,
+		EventID:   dl.EventID,
+		EventType: string(dl.EventType),
+		Payload:   dl.Payload,
+		Attempts:  dl.Attempts,
+		CreatedAt: dl.CreatedAt,
+	}
+	if dl.LastStatus != 0 {
+		dbLetter.LastStatus = &dl.LastStatus
+	}
+	if dl.LastError != "" {
+		dbLetter.LastError = &dl.LastError
+	}
+	if err := a.db.CreateWebhookDeadLetter(dbLetter); err != nil {
+		return err
+	}
+	dl.ID = dbLetter.ID
+	return nil
+}
+
+// ListDeadLetters returns failed deliveries, newest first
+func (a *DatabaseWebhookAdapter) ListDeadLetters(filter DeadLetterFilter) ([]*webhook.DeadLetter, error) {
+	dbFilter := &database.WebhookDeadLetterFilter{}
+	if filter.Endpoint != "" {
+		dbFilter.Endpoint = &filter.Endpoint
+	}
+	if filter.EventType != "" {
+		dbFilter.EventType = &filter.EventType
+	}
+
+	dbLetters, err := a.db.ListWebhookDeadLetters(dbFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	letters := make([]*webhook.DeadLetter, len(dbLetters))
+	for i, dl := range dbLetters {
+		letters[i] = fromDBDeadLetter(dl)
+	}
+	return letters, nil
+}
+
+// GetDeadLetter returns a failed delivery by ID, or nil if it does not exist
+func (a *DatabaseWebhookAdapter) GetDeadLetter(id int) (*webhook.DeadLetter, error) {
+	dl, err := a.db.GetWebhookDeadLetter(id)
+	if err != nil || dl == nil {
+		return nil, err
+	}
+	return fromDBDeadLetter(dl), nil
+}
+
+// DeleteDeadLetter removes a failed delivery
+func (a *DatabaseWebhookAdapter) DeleteDeadLetter(id int) error {
+	return a.db.DeleteWebhookDeadLetter(id)
+}
+
+// fromDBDeadLetter converts a database dead letter to the webhook format
+func fromDBDeadLetter(dl *database.WebhookDeadLetter) *webhook.DeadLetter {
+	letter := &webhook.DeadLetter{
+		ID:        dl.ID,
+		Endpoint:  dl.Endpoint,
+		URL:       dl.URL,
+		EventID:   dl.EventID,
+		EventType: webhook.EventType(dl.EventType),
+		Payload:   dl.Payload,
+		Attempts:  dl.Attempts,
+		CreatedAt: dl.CreatedAt,
+	}
+	if dl.LastStatus != nil {
+		letter.LastStatus = *dl.LastStatus
+	}
+	if dl.LastError != nil {
+		letter.LastError = *dl.LastError
+	}
+	return letter
+}
+
+// DatabaseScheduleAdapter adapts database.DB to the ScheduleRunStore and
+// scheduler.RunStore interfaces
+type DatabaseScheduleAdapter struct {
+	db *database.DB
+}
+
+// NewDatabaseScheduleAdapter creates a new adapter
+func NewDatabaseScheduleAdapter(db *database.DB) *DatabaseScheduleAdapter {
+	return &DatabaseScheduleAdapter{db: db}
+}
+
+// RecordRun persists a scheduled or manual task run
+func (a *DatabaseScheduleAdapter) RecordRun(run *scheduler.Run) error {
+	dbRun := &database.ScheduledRun{
+		Provider:   run.Provider,
+		Task:       string(run.Task),
+		Trigger:    run.Trigger,
+		Status:     run.Status,
+		StartedAt:  run.StartedAt,
+		DurationMs: run.Duration.Milliseconds(),
+	}
+	if run.Details != "" {
+		dbRun.Details = &run.Details
+	}
+	if run.Error != "" {
+		dbRun.Error = &run.Error
+	}
+	if err := a.db.CreateScheduledRun(dbRun); err != nil {
+		return err
+	}
+	run.ID = dbRun.ID
+	return nil
+}
+
+// ListRuns returns run history, newest first
+func (a *DatabaseScheduleAdapter) ListRuns(filter ScheduleRunFilter) ([]*scheduler.Run, error) {
+	dbFilter := &database.ScheduledRunFilter{}
+	if filter.Provider != "" {
+		dbFilter.Provider = &filter.Provider
+	}
+	if filter.Task != "" {
+		dbFilter.Task = &filter.Task
+	}
+
+	dbRuns, err := a.db.ListScheduledRuns(dbFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]*scheduler.Run, len(dbRuns))
+	for i, r := range dbRuns {
+		run := &scheduler.Run{
+			ID:        r.ID,
+			Provider:  r.Provider,
+			Task:      scheduler.Task(r.Task),
+			Trigger:   r.Trigger,
+			Status:    r.Status,
+			StartedAt: r.StartedAt,
+			Duration:  time.Duration(r.DurationMs) * time.Millisecond,
+		}
+		if r.Details != nil {
+			run.Details = *r.Details
+		}
+		if r.Error != nil {
+			run.Error = *r.Error
+		}
+		runs[i] = run
+	}
+	return runs, nil
+}
+
+// DatabaseDashboardAdapter adapts database.DB to the DashboardStore interface
+type DatabaseDashboardAdapter struct {
+	db *database.DB
+}
+
+// NewDatabaseDashboardAdapter creates a new adapter
+func NewDatabaseDashboardAdapter(db *database.DB) *DatabaseDashboardAdapter {
+	return &DatabaseDashboardAdapter{db: db}
+}
+
+// Providers returns every provider with its model and key counts
+func (a *DatabaseDashboardAdapter) Providers() ([]*DashboardProvider, error) {
+	dbProviders, err := a.db.ListProviders()
+	if err != nil {
+		return nil, err
+	}
+	models, err := a.Models()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := a.db.ListAPIKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make([]*DashboardProvider, len(dbProviders))
+	byID := make(map[string]*DashboardProvider, len(dbProviders))
+	for i, p := range dbProviders {
+		provider := &DashboardProvider{
+			ID:            p.ID,
+			Name:          p.Name,
+			BaseURL:       p.BaseURL,
+			Status:        p.Status,
+			LastValidated: p.LastValidated,
+		}
+		if p.LastError != nil {
+			provider.LastError = *p.LastError
+		}
+		providers[i] = provider
+		byID[p.ID] = provider
+	}
+	for _, m := range models {
+		if p, ok := byID[m.Provider]; ok {
+			p.Models++
+			if m.Status == "online" {
+				p.ModelsOnline++
+			}
+		}
+	}
+	for _, k := range keys {
+		if p, ok := byID[k.ProviderID]; ok {
+			p.Keys++
+		}
+	}
+	return providers, nil
+}
+
+// Keys returns every API key with its secret masked
+func (a *DatabaseDashboardAdapter) Keys() ([]*DashboardKey, error) {
+	dbKeys, err := a.db.ListAPIKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*DashboardKey, len(dbKeys))
+	for i, k := range dbKeys {
+		keys[i] = &DashboardKey{
+			ID:            k.ID,
+			ProviderID:    k.ProviderID,
+			Key:           maskKeyPrefix(k.KeyPrefix),
+			Tier:          k.Tier,
+			Requests:      k.RequestsCount,
+			Tokens:        k.TokensCount,
+			Active:        k.Active,
+			Degraded:      k.Degraded,
+			DegradedUntil: k.DegradedUntil,
+			TenantID:      k.TenantID,
+			CreatedAt:     k.CreatedAt,
+		}
+	}
+	return keys, nil
+}
+
+// Models returns every model with its provider
+func (a *DatabaseDashboardAdapter) Models() ([]*DashboardModel, error) {
+	families, err := a.db.ListModelFamilies()
+	if err != nil {
+		return nil, err
+	}
+	dbModels, err := a.db.ListModels()
+	if err != nil {
+		return nil, err
+	}
+
+	familyByID := make(map[string]*database.ModelFamily, len(families))
+	for _, f := range families {
+		familyByID[f.ID] = f
+	}
+
+	models := make([]*DashboardModel, len(dbModels))
+	for i, m := range dbModels {
+		model := &DashboardModel{
+			ID:            m.ID,
+			Name:          m.Name,
+			Family:        m.FamilyID,
+			Status:        m.Status,
+			CostPer1MIn:   m.CostPer1MIn,
+			CostPer1MOut:  m.CostPer1MOut,
+			ContextWindow: m.ContextWindow,
+			LastTested:    m.LastTested,
+		}
+		if f, ok := familyByID[m.FamilyID]; ok {
+			model.Provider = f.ProviderID
+			model.Family = f.Name
+		}
+		if m.LastError != nil {
+			model.LastError = *m.LastError
+		}
+		models[i] = model
+	}
+	return models, nil
+}
+
+// UsageSeries returns bucketed usage since a time
+func (a *DatabaseDashboardAdapter) UsageSeries(since time.Time, bucket time.Duration) ([]*UsagePoint, error) {
+	buckets, err := a.db.UsageSeries(since, bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]*UsagePoint, len(buckets))
+	for i, b := range buckets {
+		points[i] = &UsagePoint{
+			Start:     b.Start,
+			Requests:  b.Requests,
+			TokensIn:  b.TokensIn,
+			TokensOut: b.TokensOut,
+			Cost:      b.Cost,
+			Failures:  b.Failures,
+		}
+	}
+	return points, nil
+}
+
+// UsageByModel returns per-model usage since a time
+func (a *DatabaseDashboardAdapter) UsageByModel(since time.Time) ([]*ModelUsageSummary, error) {
+	return usageByModel(a.db, since)
+}
+
+func usageByModel(db *database.DB, since time.Time) ([]*ModelUsageSummary, error) {
+	dbUsage, err := db.UsageByModel(since)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make([]*ModelUsageSummary, len(dbUsage))
+	for i, u := range dbUsage {
+		usage[i] = &ModelUsageSummary{
+			Model:     u.ModelID,
+			Requests:  u.Requests,
+			TokensIn:  u.TokensIn,
+			TokensOut: u.TokensOut,
+			Cost:      u.Cost,
+			Failures:  u.Failures,
+		}
+	}
+	return usage, nil
+}
+
+// HealthEvents returns scheduled and manual task runs since a time
+func (a *DatabaseDashboardAdapter) HealthEvents(since time.Time) ([]*HealthEvent, error) {
+	runs, err := a.db.ListScheduledRuns(&database.ScheduledRunFilter{Since: &since, Limit: 5000})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*HealthEvent, len(runs))
+	for i, r := range runs {
+		events[i] = &HealthEvent{
+			Provider:   r.Provider,
+			Time:       r.StartedAt,
+			Task:       r.Task,
+			Status:     r.Status,
+			DurationMs: r.DurationMs,
+		}
+		if r.Error != nil {
+			events[i].Error = *r.Error
+		}
+	}
+	return events, nil
+}
@@ -2,6 +2,7 @@ package admin
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -25,9 +26,15 @@ type Alias struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// Reloader is notified after alias changes so cached resolvers pick them up
+type Reloader interface {
+	Reload() error
+}
+
 // AliasAPI handles alias management endpoints
 type AliasAPI struct {
-	store AliasStore
+	store    AliasStore
+	reloader Reloader
 }
 
 // NewAliasAPI creates a new AliasAPI
@@ -35,6 +42,21 @@ func NewAliasAPI(store AliasStore) *AliasAPI {
 	return &AliasAPI{store: store}
 }
 
+// SetReloader sets the resolver to reload after alias changes
+func (a *AliasAPI) SetReloader(reloader Reloader) {
+	a.reloader = reloader
+}
+
+// reload refreshes the resolver; failures are logged and picked up by its periodic reload
+func (a *AliasAPI) reload() {
+	if a.reloader == nil {
+		return
+	}
+	if err := a.reloader.Reload(); err != nil {
+		log.Printf("admin: failed to reload aliases: %v", err)
+	}
+}
+
 // AliasCreateRequest represents the request body for creating an alias
 type AliasCreateRequest struct {
 	Name     string  `json:"name"`
@@ -94,15 +116,25 @@ func (a *AliasAPI) handleListAliases(w http.ResponseWriter, r *http.Request) {
 			CreatedAt: alias.CreatedAt,
 		})
 	}
-	if resp == nil {
-		resp = []AliasResponse{}
-	}
+	writeList(w, r, resp, aliasList)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"aliases": resp,
-		"count":   len(resp),
-	})
+// aliasList pages GET /api/aliases. client_id is not a filter: it selects
+// the client's aliases along with the global ones.
+var aliasList = listSpec[AliasResponse]{
+	id: func(a AliasResponse) string {
+		if a.ClientID == nil {
+			return a.Name
+		}
+		return *a.ClientID + ":" + a.Name
+	},
+	fields: map[string]listField[AliasResponse]{
+		"name":       strField(func(a AliasResponse) string { return a.Name }),
+		"model_id":   strField(func(a AliasResponse) string { return a.ModelID }),
+		"is_global":  boolField(func(a AliasResponse) bool { return a.IsGlobal }),
+		"created_at": timeField(func(a AliasResponse) time.Time { return a.CreatedAt }),
+	},
+	sort: "name",
 }
 
 // handleCreateAlias handles POST /api/aliases
@@ -146,6 +178,7 @@ func (a *AliasAPI) handleCreateAlias(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to create alias: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	a.reload()
 
 	resp := AliasResponse{
 		Name:      alias.Name,
@@ -241,6 +274,7 @@ func (a *AliasAPI) handleDeleteAlias(w http.ResponseWriter, r *http.Request, nam
 		http.Error(w, "Failed to delete alias: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	a.reload()
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -279,6 +313,7 @@ func (a *AliasAPI) handleUpdateAlias(w http.ResponseWriter, r *http.Request, nam
 		http.Error(w, "Failed to update alias: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	a.reload()
 
 	// Return updated alias
 	alias.ModelID = req.ModelID
@@ -90,8 +90,8 @@ func TestAliasAPI_HandleListAliases_Empty(t *testing.T) {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	if response["count"].(float64) != 0 {
-		t.Errorf("expected 0 aliases, got %v", response["count"])
+	if response["total"].(float64) != 0 {
+		t.Errorf("expected 0 aliases, got %v", response["total"])
 	}
 }
 
@@ -257,8 +257,8 @@ func TestAliasAPI_HandleListAliases(t *testing.T) {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	if response["count"].(float64) != 2 {
-		t.Errorf("expected 2 aliases, got %v", response["count"])
+	if response["total"].(float64) != 2 {
+		t.Errorf("expected 2 aliases, got %v", response["total"])
 	}
 }
 
@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/anomaly"
+)
+
+// AnomalySource lists recent usage anomalies
+type AnomalySource interface {
+	Recent() []anomaly.Alert
+}
+
+// AnomalyAPI handles usage anomaly endpoints
+type AnomalyAPI struct {
+	source AnomalySource
+}
+
+// NewAnomalyAPI creates a new AnomalyAPI
+func NewAnomalyAPI(source AnomalySource) *AnomalyAPI {
+	return &AnomalyAPI{source: source}
+}
+
+// HandleAnomalies handles GET /api/anomalies?dimension=provider|client&key=<id>&metric=<name>,
+// newest first
+func (a *AnomalyAPI) HandleAnomalies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeList(w, r, a.source.Recent(), anomalyList)
+}
+
+// anomalyList pages GET /api/anomalies, newest first
+var anomalyList = listSpec[anomaly.Alert]{
+	id: func(a anomaly.Alert) string {
+		return a.Dimension + "/" + a.Key + "/" + a.Metric + "@" + a.WindowStart.Format(time.RFC3339Nano)
+	},
+	fields: map[string]listField[anomaly.Alert]{
+		"dimension":    strField(func(a anomaly.Alert) string { return a.Dimension }),
+		"key":          strField(func(a anomaly.Alert) string { return a.Key }),
+		"metric":       strField(func(a anomaly.Alert) string { return a.Metric }),
+		"value":        numField(func(a anomaly.Alert) float64 { return a.Value }),
+		"sigma":        numField(func(a anomaly.Alert) float64 { return a.Sigma }),
+		"window_start": timeField(func(a anomaly.Alert) time.Time { return a.WindowStart }),
+	},
+}
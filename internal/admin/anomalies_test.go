@@ -0,0 +1,44 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/anomaly"
+)
+
+type fakeAnomalySource []anomaly.Alert
+
+func (f fakeAnomalySource) Recent() []anomaly.Alert { return f }
+
+func TestAnomalyAPI(t *testing.T) {
+	api := NewAnomalyAPI(fakeAnomalySource{
+		{Dimension: anomaly.DimensionClient, Key: "app", Metric: anomaly.MetricCost, Value: 4.2},
+		{Dimension: anomaly.DimensionProvider, Key: "openai", Metric: anomaly.MetricRequests, Value: 900},
+		{Dimension: anomaly.DimensionClient, Key: "app", Metric: anomaly.MetricRequests, Value: 900},
+	})
+
+	rec := httptest.NewRecorder()
+	api.HandleAnomalies(rec, httptest.NewRequest(http.MethodGet, "/api/anomalies?dimension=client&limit=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Anomalies []anomaly.Alert `json:"items"`
+		Total     int             `json:"total"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Total != 2 || len(resp.Anomalies) != 1 || resp.Anomalies[0].Metric != anomaly.MetricCost {
+		t.Errorf("expected the newest client anomaly, got %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleAnomalies(rec, httptest.NewRequest(http.MethodGet, "/api/anomalies?limit=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid limit, got %d", rec.Code)
+	}
+}
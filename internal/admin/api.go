@@ -11,17 +11,55 @@ import (
 
 // API provides HTTP endpoints for admin operations
 type API struct {
-	mux          *http.ServeMux
-	db           Database
-	discovery    DiscoveryAgent
-	generator    Generator
-	keyManager   KeyManager
-	clientAPI    *ClientAPI
-	aliasAPI     *AliasAPI
-	remapAPI     *RemapAPI
-	rateLimitAPI *RateLimitAPI
-	serverAPI    *ServerAPI
-	modelService ModelService
+	mux            *http.ServeMux
+	db             Database
+	discovery      DiscoveryAgent
+	generator      Generator
+	keyManager     KeyManager
+	clientAPI      *ClientAPI
+	aliasAPI       *AliasAPI
+	remapAPI       *RemapAPI
+	rateLimitAPI   *RateLimitAPI
+	serverAPI      *ServerAPI
+	canaryAPI      *CanaryAPI
+	shadowAPI      *ShadowAPI
+	guardrailAPI   *GuardrailAPI
+	webhookAPI     *WebhookAPI
+	schedulerAPI   *SchedulerAPI
+	scraperAPI     *ScraperAPI
+	exportAPI      *ExportAPI
+	dashboardAPI   *DashboardAPI
+	chaosAPI       *ChaosAPI
+	transportAPI   *TransportAPI
+	fallbackAPI    *FallbackAPI
+	transformAPI   *TransformAPI
+	experimentAPI  *ExperimentAPI
+	qualityAPI     *QualityAPI
+	tenantAPI      *TenantAPI
+	concurrencyAPI *ConcurrencyAPI
+	retryBudgetAPI *RetryBudgetAPI
+	modelLimitAPI  *ModelLimitAPI
+	policyAPI      *PromptPolicyAPI
+	maintenanceAPI *MaintenanceAPI
+	slaAPI         *SLAAPI
+	anomalyAPI     *AnomalyAPI
+	routeAPI       *RouteAPI
+	bundleAPI      *BundleAPI
+	diagnosticsAPI *DiagnosticsAPI
+	keyHealthAPI   *KeyHealthAPI
+	deprecationAPI *DeprecationAPI
+	catalogAPI     *CatalogAPI
+	heatmapAPI     *HeatmapAPI
+	cacheAPI       *CacheAPI
+	debugAPI       *DebugAPI
+	watchdogAPI    *WatchdogAPI
+	forecastAPI    *ForecastAPI
+	reconcileAPI   *ReconcileAPI
+	chargebackAPI  *ChargebackAPI
+	embeddingsAPI  *EmbeddingCacheAPI
+	onMutation     func(r *http.Request)
+	eventStream    http.Handler
+	modelService   ModelService
 }
 
 // Database interface for data operations
@@ -34,6 +72,7 @@ type Database interface {
 	DeleteAPIKey(id int) error
 	ListActiveAPIKeys(providerID string) ([]*APIKey, error)
 	GetUsageStats(modelID string, since time.Time) (map[string]interface{}, error)
+	UsageByModel(since time.Time) ([]*ModelUsageSummary, error)
 	GetKeyStats(keyID int, since time.Time) (*KeyStats, error)
 }
 
@@ -174,15 +213,221 @@ func (a *API) SetRateLimitAPI(rateLimitAPI *RateLimitAPI) {
 	a.rateLimitAPI = rateLimitAPI
 }
 
+// SetCanaryAPI sets the canary API handler
+func (a *API) SetCanaryAPI(canaryAPI *CanaryAPI) {
+	a.canaryAPI = canaryAPI
+}
+
+// SetShadowAPI sets the shadow traffic handler
+func (a *API) SetShadowAPI(shadowAPI *ShadowAPI) {
+	a.shadowAPI = shadowAPI
+}
+
+// SetGuardrailAPI sets the guardrail metrics handler
+func (a *API) SetGuardrailAPI(guardrailAPI *GuardrailAPI) {
+	a.guardrailAPI = guardrailAPI
+}
+
+// SetWebhookAPI sets the webhook dead-letter handler
+func (a *API) SetWebhookAPI(webhookAPI *WebhookAPI) {
+	a.webhookAPI = webhookAPI
+}
+
+// SetSchedulerAPI sets the provider schedule handler
+func (a *API) SetSchedulerAPI(schedulerAPI *SchedulerAPI) {
+	a.schedulerAPI = schedulerAPI
+}
+
+// SetScraperAPI sets the scraped pricing review handler
+func (a *API) SetScraperAPI(scraperAPI *ScraperAPI) {
+	a.scraperAPI = scraperAPI
+}
+
+// SetExportAPI sets the dataset export handler
+func (a *API) SetExportAPI(exportAPI *ExportAPI) {
+	a.exportAPI = exportAPI
+}
+
+// SetDashboardAPI sets the web dashboard data handler
+func (a *API) SetDashboardAPI(dashboardAPI *DashboardAPI) {
+	a.dashboardAPI = dashboardAPI
+}
+
+// SetChaosAPI sets the fault injection handler
+func (a *API) SetChaosAPI(chaosAPI *ChaosAPI) {
+	a.chaosAPI = chaosAPI
+}
+
+// SetTransportAPI sets the connection pool handler
+func (a *API) SetTransportAPI(transportAPI *TransportAPI) {
+	a.transportAPI = transportAPI
+}
+
+// SetFallbackAPI sets the fallback chain handler
+func (a *API) SetFallbackAPI(fallbackAPI *FallbackAPI) {
+	a.fallbackAPI = fallbackAPI
+}
+
+// SetTransformAPI sets the request transformation rule handler
+func (a *API) SetTransformAPI(transformAPI *TransformAPI) {
+	a.transformAPI = transformAPI
+}
+
+// SetExperimentAPI sets the A/B experiment handler
+func (a *API) SetExperimentAPI(experimentAPI *ExperimentAPI) {
+	a.experimentAPI = experimentAPI
+}
+
+// SetQualityAPI sets the model quality handler
+func (a *API) SetQualityAPI(qualityAPI *QualityAPI) {
+	a.qualityAPI = qualityAPI
+}
+
+// SetTenantAPI sets the tenant handler
+func (a *API) SetTenantAPI(tenantAPI *TenantAPI) {
+	a.tenantAPI = tenantAPI
+}
+
+// SetConcurrencyAPI sets the concurrency limit handler
+func (a *API) SetConcurrencyAPI(concurrencyAPI *ConcurrencyAPI) {
+	a.concurrencyAPI = concurrencyAPI
+}
+
+// SetRetryBudgetAPI sets the retry budget handler
+func (a *API) SetRetryBudgetAPI(retryBudgetAPI *RetryBudgetAPI) {
+	a.retryBudgetAPI = retryBudgetAPI
+}
+
+// SetModelLimitAPI sets the per-model cap handler
+func (a *API) SetModelLimitAPI(modelLimitAPI *ModelLimitAPI) {
+	a.modelLimitAPI = modelLimitAPI
+}
+
+// SetPromptPolicyAPI sets the system prompt policy handler
+func (a *API) SetPromptPolicyAPI(policyAPI *PromptPolicyAPI) {
+	a.policyAPI = policyAPI
+}
+
+// SetMaintenanceAPI sets the provider maintenance handler
+func (a *API) SetMaintenanceAPI(maintenanceAPI *MaintenanceAPI) {
+	a.maintenanceAPI = maintenanceAPI
+}
+
+// SetSLAAPI sets the provider SLA report handler
+func (a *API) SetSLAAPI(slaAPI *SLAAPI) {
+	a.slaAPI = slaAPI
+}
+
+// SetHeatmapAPI sets the provider latency heatmap handler
+func (a *API) SetHeatmapAPI(heatmapAPI *HeatmapAPI) {
+	a.heatmapAPI = heatmapAPI
+}
+
+// SetCacheAPI sets the read cache handler
+func (a *API) SetCacheAPI(cacheAPI *CacheAPI) {
+	a.cacheAPI = cacheAPI
+}
+
+// SetWatchdogAPI sets the resource watchdog handler
+func (a *API) SetWatchdogAPI(watchdogAPI *WatchdogAPI) {
+	a.watchdogAPI = watchdogAPI
+}
+
+// SetForecastAPI sets the spend forecasting handler
+func (a *API) SetForecastAPI(forecastAPI *ForecastAPI) {
+	a.forecastAPI = forecastAPI
+}
+
+// SetReconcileAPI sets the invoice reconciliation handler
+func (a *API) SetReconcileAPI(reconcileAPI *ReconcileAPI) {
+	a.reconcileAPI = reconcileAPI
+}
+
+// SetChargebackAPI sets the chargeback report handler
+func (a *API) SetChargebackAPI(chargebackAPI *ChargebackAPI) {
+	a.chargebackAPI = chargebackAPI
+}
+
+// SetEmbeddingCacheAPI sets the embedding cache handler
+func (a *API) SetEmbeddingCacheAPI(embeddingsAPI *EmbeddingCacheAPI) {
+	a.embeddingsAPI = embeddingsAPI
+}
+
+// SetDebugAPI sets the profiling and runtime debug API
+func (a *API) SetDebugAPI(debugAPI *DebugAPI) {
+	a.debugAPI = debugAPI
+}
+
+// SetMutationHook sets a function called after each successful admin
+// request that may change data (any method but GET, HEAD and OPTIONS), so
+// caches of that data can be dropped
+func (a *API) SetMutationHook(hook func(r *http.Request)) {
+	a.onMutation = hook
+}
+
+// SetAnomalyAPI sets the usage anomaly handler
+func (a *API) SetAnomalyAPI(anomalyAPI *AnomalyAPI) {
+	a.anomalyAPI = anomalyAPI
+}
+
+// SetRouteAPI sets the routing debug handler
+func (a *API) SetRouteAPI(routeAPI *RouteAPI) {
+	a.routeAPI = routeAPI
+}
+
+// SetBundleAPI sets the configuration bundle handler
+func (a *API) SetBundleAPI(bundleAPI *BundleAPI) {
+	a.bundleAPI = bundleAPI
+}
+
+// SetDiagnosticsAPI sets the provider test-connection handler
+func (a *API) SetDiagnosticsAPI(diagnosticsAPI *DiagnosticsAPI) {
+	a.diagnosticsAPI = diagnosticsAPI
+}
+
+// SetKeyHealthAPI sets the API key health handler
+func (a *API) SetKeyHealthAPI(keyHealthAPI *KeyHealthAPI) {
+	a.keyHealthAPI = keyHealthAPI
+}
+
+// SetDeprecationAPI sets the model retirement handler
+func (a *API) SetDeprecationAPI(deprecationAPI *DeprecationAPI) {
+	a.deprecationAPI = deprecationAPI
+}
+
+// SetCatalogAPI sets the model catalog history handler
+func (a *API) SetCatalogAPI(catalogAPI *CatalogAPI) {
+	a.catalogAPI = catalogAPI
+}
+
+// SetEventStream sets the live event stream served at /api/events
+func (a *API) SetEventStream(stream http.Handler) {
+	a.eventStream = stream
+}
+
 // setupRoutes configures HTTP routes
 func (a *API) setupRoutes() {
 	// Provider management
 	a.mux.HandleFunc("/api/providers", a.handleProviders)
 	a.mux.HandleFunc("/api/providers/add", a.handleAddProvider)
+	a.mux.HandleFunc("/api/providers/maintenance", a.handleMaintenance)
+	a.mux.HandleFunc("/api/providers/maintenance/", a.handleMaintenanceByID)
+	a.mux.HandleFunc("/api/providers/", a.handleProviderTest)
+	a.mux.HandleFunc("/api/sla", a.handleSLA)
+	a.mux.HandleFunc("/api/latency/heatmap", a.handleLatencyHeatmap)
+	a.mux.HandleFunc("/api/cache", a.handleCache)
+	a.mux.HandleFunc("/api/embeddings/cache", a.handleEmbeddingCache)
+	a.mux.HandleFunc("/api/anomalies", a.handleAnomalies)
+	a.mux.HandleFunc("/api/watchdog", a.handleWatchdog)
+	a.mux.HandleFunc("/api/forecast", a.handleForecast)
+	a.mux.HandleFunc("/api/reconcile", a.handleReconcile)
+	a.mux.HandleFunc("/api/chargeback", a.handleChargeback)
+	a.mux.HandleFunc("/api/route/explain", a.handleRouteExplain)
 
 	// API key management
 	a.mux.HandleFunc("/api/keys", a.handleKeys)
 	a.mux.HandleFunc("/api/keys/add", a.handleAddKey)
+	a.mux.HandleFunc("/api/keys/health", a.handleKeyHealth)
 	a.mux.HandleFunc("/api/keys/", a.handleKeyByID)
 
 	// Discovery
@@ -197,6 +442,12 @@ func (a *API) setupRoutes() {
 
 	// Models (hierarchical)
 	a.mux.HandleFunc("/api/models", a.handleModels)
+	a.mux.HandleFunc("/api/models/deprecations", a.handleDeprecations)
+
+	// Model catalog history
+	a.mux.HandleFunc("/api/catalog/changes", a.handleCatalogChanges)
+	a.mux.HandleFunc("/api/catalog/snapshots", a.handleCatalogSnapshots)
+	a.mux.HandleFunc("/api/catalog/snapshots/", a.handleCatalogSnapshots)
 
 	// Client management
 	a.mux.HandleFunc("/api/clients/register", a.handleClientsRegister)
@@ -209,16 +460,112 @@ func (a *API) setupRoutes() {
 
 	// Remap rules management
 	a.mux.HandleFunc("/api/rules/remap", a.handleRemaps)
+	a.mux.HandleFunc("/api/rules/remap/reload", a.handleRemapReload)
+	a.mux.HandleFunc("/api/rules/remap/resolve", a.handleRemapResolve)
 	a.mux.HandleFunc("/api/rules/remap/", a.handleRemapByID)
 
 	// Rate limit management
 	a.mux.HandleFunc("/api/ratelimits", a.handleRateLimits)
 	a.mux.HandleFunc("/api/ratelimits/", a.handleRateLimitByClientID)
 
+	// Canary routing
+	a.mux.HandleFunc("/api/canaries", a.handleCanaries)
+	a.mux.HandleFunc("/api/canaries/results", a.handleCanaryResults)
+	a.mux.HandleFunc("/api/canaries/", a.handleCanaryByID)
+
+	// Shadow traffic evaluation
+	a.mux.HandleFunc("/api/shadow/results", a.handleShadowResults)
+	a.mux.HandleFunc("/api/shadow/summary", a.handleShadowSummary)
+
+	// Guardrails
+	a.mux.HandleFunc("/api/guardrails/stats", a.handleGuardrailStats)
+
+	// Webhook notifications
+	a.mux.HandleFunc("/api/webhooks/dead-letters", a.handleWebhookDeadLetters)
+	a.mux.HandleFunc("/api/webhooks/dead-letters/", a.handleWebhookDeadLetterByID)
+
+	// Scheduled re-validation and refresh
+	a.mux.HandleFunc("/api/schedules", a.handleSchedules)
+	a.mux.HandleFunc("/api/schedules/runs", a.handleScheduleRuns)
+	a.mux.HandleFunc("/api/schedules/", a.handleScheduleTrigger)
+
+	// Scraped rate limit and pricing review
+	a.mux.HandleFunc("/api/scraper/run", a.handleScraperRun)
+	a.mux.HandleFunc("/api/scraper/changes", a.handleScraperChanges)
+	a.mux.HandleFunc("/api/scraper/changes/", a.handleScraperChangeByID)
+
+	// Data export
+	a.mux.HandleFunc("/api/export", a.handleExport)
+	a.mux.HandleFunc("/api/export/", a.handleExportDataset)
+
+	// Configuration bundles
+	a.mux.HandleFunc("/api/config/export", a.handleConfigExport)
+	a.mux.HandleFunc("/api/config/import", a.handleConfigImport)
+
+	// Web dashboard UI (embedded static files)
+	a.mux.Handle("/dashboard/", DashboardHandler())
+
+	// Web dashboard data
+	a.mux.HandleFunc("/api/dashboard/overview", a.handleDashboardOverview)
+	a.mux.HandleFunc("/api/dashboard/providers", a.handleDashboardProviders)
+	a.mux.HandleFunc("/api/dashboard/keys", a.handleDashboardKeys)
+	a.mux.HandleFunc("/api/dashboard/models", a.handleDashboardModels)
+	a.mux.HandleFunc("/api/dashboard/usage", a.handleDashboardUsage)
+	a.mux.HandleFunc("/api/dashboard/health", a.handleDashboardHealth)
+
+	// Fault injection
+	a.mux.HandleFunc("/api/chaos", a.handleChaos)
+	a.mux.HandleFunc("/api/chaos/", a.handleChaosProvider)
+
+	// Upstream connection pools
+	a.mux.HandleFunc("/api/transport", a.handleTransport)
+	a.mux.HandleFunc("/api/transport/", a.handleTransportProvider)
+
+	// Model fallback chains
+	a.mux.HandleFunc("/api/fallbacks", a.handleFallbacks)
+	a.mux.HandleFunc("/api/fallbacks/", a.handleFallbackModel)
+
+	// Request transformation rules
+	a.mux.HandleFunc("/api/transforms", a.handleTransforms)
+	a.mux.HandleFunc("/api/transforms/", a.handleTransformRule)
+
+	// A/B parameter experiments
+	a.mux.HandleFunc("/api/experiments", a.handleExperiments)
+	a.mux.HandleFunc("/api/experiments/", a.handleExperiment)
+
+	// Model quality tiers
+	a.mux.HandleFunc("/api/quality", a.handleQuality)
+	a.mux.HandleFunc("/api/quality/", a.handleQualityModel)
+
+	// Tenants
+	a.mux.HandleFunc("/api/tenants", a.handleTenants)
+	a.mux.HandleFunc("/api/tenants/", a.handleTenant)
+
+	// Concurrency limits
+	a.mux.HandleFunc("/api/concurrency", a.handleConcurrency)
+
+	// Retry budgets
+	a.mux.HandleFunc("/api/retry-budgets", a.handleRetryBudgets)
+
+	// Per-model caps
+	a.mux.HandleFunc("/api/modellimits", a.handleModelLimits)
+	a.mux.HandleFunc("/api/modellimits/", a.handleModelLimit)
+
+	// System prompt policies
+	a.mux.HandleFunc("/api/policies/prompt", a.handlePromptPolicies)
+	a.mux.HandleFunc("/api/policies/prompt/preview", a.handlePromptPolicyPreview)
+	a.mux.HandleFunc("/api/policies/prompt/", a.handlePromptPolicyByID)
+
+	// Live events
+	a.mux.HandleFunc("/api/events", a.handleEvents)
+
 	// Server info and control
 	a.mux.HandleFunc("/api/server/info", a.handleServerInfo)
 	a.mux.HandleFunc("/api/server/shutdown", a.handleServerShutdown)
 
+	// Profiling and runtime debug
+	a.mux.HandleFunc("/debug/", a.handleDebug)
+
 	// Health check
 	a.mux.HandleFunc("/health", a.handleHealth)
 }
@@ -301,6 +648,24 @@ func (a *API) handleRemaps(w http.ResponseWriter, r *http.Request) {
 	a.remapAPI.HandleRemaps(w, r)
 }
 
+// handleRemapReload handles POST /api/rules/remap/reload
+func (a *API) handleRemapReload(w http.ResponseWriter, r *http.Request) {
+	if a.remapAPI == nil {
+		http.Error(w, "Remap API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.remapAPI.HandleReload(w, r)
+}
+
+// handleRemapResolve handles GET /api/rules/remap/resolve
+func (a *API) handleRemapResolve(w http.ResponseWriter, r *http.Request) {
+	if a.remapAPI == nil {
+		http.Error(w, "Remap API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.remapAPI.HandleResolve(w, r)
+}
+
 // handleRemapByID handles GET/PATCH/DELETE /api/rules/remap/{id}
 func (a *API) handleRemapByID(w http.ResponseWriter, r *http.Request) {
 	if a.remapAPI == nil {
@@ -322,12 +687,20 @@ func (a *API) handleProviders(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	writeList(w, r, providers, providerList)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"providers": providers,
-		"count":     len(providers),
-	})
+// providerList pages GET /api/providers
+var providerList = listSpec[*Provider]{
+	id: func(p *Provider) string { return p.ID },
+	fields: map[string]listField[*Provider]{
+		"id":            strField(func(p *Provider) string { return p.ID }),
+		"name":          strField(func(p *Provider) string { return p.Name }),
+		"status":        strField(func(p *Provider) string { return p.Status }),
+		"auth_method":   strField(func(p *Provider) string { return p.AuthMethod }),
+		"pricing_model": strField(func(p *Provider) string { return p.PricingModel }),
+	},
+	sort: "id",
 }
 
 // handleAddProvider adds a new provider
@@ -402,12 +775,20 @@ func (a *API) handleKeys(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	writeList(w, r, keys, keyList)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"keys":  keys,
-		"count": len(keys),
-	})
+// keyList pages GET /api/keys
+var keyList = listSpec[*APIKey]{
+	id: func(k *APIKey) string { return strconv.Itoa(k.ID) },
+	fields: map[string]listField[*APIKey]{
+		"id":             intField(func(k *APIKey) int { return k.ID }),
+		"requests_count": intField(func(k *APIKey) int { return k.RequestsCount }),
+		"tokens_count":   intField(func(k *APIKey) int { return k.TokensCount }),
+		"active":         boolField(func(k *APIKey) bool { return k.Active }),
+		"degraded":       boolField(func(k *APIKey) bool { return k.Degraded }),
+	},
+	sort: "id",
 }
 
 // handleAddKey adds a new API key
@@ -481,11 +862,16 @@ func (a *API) handleSDKs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"sdks":  sdks,
-		"count": len(sdks),
-	})
+	writeList(w, r, sdks, nameList)
+}
+
+// nameList pages lists of names, such as GET /api/sdks
+var nameList = listSpec[string]{
+	id: func(s string) string { return s },
+	fields: map[string]listField[string]{
+		"name": strField(func(s string) string { return s }),
+	},
+	sort: "name",
 }
 
 // handleGenerateSDK generates an SDK
@@ -511,7 +897,8 @@ func (a *API) handleGenerateSDK(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(result)
 }
 
-// handleStats retrieves usage statistics
+// handleStats handles GET /api/stats?model=<id>, one model's usage over the
+// last 7 days, and GET /api/stats?days=7, every model's usage as a list
 func (a *API) handleStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -520,7 +907,17 @@ func (a *API) handleStats(w http.ResponseWriter, r *http.Request) {
 
 	modelID := r.URL.Query().Get("model")
 	if modelID == "" {
-		http.Error(w, "model parameter required", http.StatusBadRequest)
+		days, ok := boundedInt(r.URL.Query().Get("days"), 7, 1, 90)
+		if !ok {
+			http.Error(w, "days must be between 1 and 90", http.StatusBadRequest)
+			return
+		}
+		usage, err := a.db.UsageByModel(time.Now().UTC().AddDate(0, 0, -days))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeList(w, r, usage, usageList)
 		return
 	}
 
@@ -535,7 +932,22 @@ func (a *API) handleStats(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(stats)
 }
 
-// handleKeyByID routes requests for /api/keys/{id}, /api/keys/{id}/test, and /api/keys/{id}/stats
+// usageList pages GET /api/stats, busiest models first
+var usageList = listSpec[*ModelUsageSummary]{
+	id: func(u *ModelUsageSummary) string { return u.Model },
+	fields: map[string]listField[*ModelUsageSummary]{
+		"model":      strField(func(u *ModelUsageSummary) string { return u.Model }),
+		"requests":   intField(func(u *ModelUsageSummary) int { return u.Requests }),
+		"tokens_in":  intField(func(u *ModelUsageSummary) int { return u.TokensIn }),
+		"tokens_out": intField(func(u *ModelUsageSummary) int { return u.TokensOut }),
+		"cost":       numField(func(u *ModelUsageSummary) float64 { return u.Cost }),
+		"failures":   intField(func(u *ModelUsageSummary) int { return u.Failures }),
+	},
+	sort: "-requests",
+}
+
+// handleKeyByID routes requests for /api/keys/{id}, /api/keys/{id}/test,
+// /api/keys/{id}/stats and /api/keys/{id}/expiry
 func (a *API) handleKeyByID(w http.ResponseWriter, r *http.Request) {
 	// Parse path: /api/keys/{id} or /api/keys/{id}/test or /api/keys/{id}/stats
 	path := strings.TrimPrefix(r.URL.Path, "/api/keys/")
@@ -561,6 +973,13 @@ func (a *API) handleKeyByID(w http.ResponseWriter, r *http.Request) {
 		case "stats":
 			a.handleKeyStats(w, r, keyID)
 			return
+		case "expiry":
+			if a.keyHealthAPI == nil {
+				http.Error(w, "Key health API not configured", http.StatusServiceUnavailable)
+				return
+			}
+			a.keyHealthAPI.HandleExpiry(w, r, keyID)
+			return
 		}
 	}
 
@@ -687,6 +1106,583 @@ func (a *API) handleRateLimitByClientID(w http.ResponseWriter, r *http.Request)
 	a.rateLimitAPI.HandleRateLimitByClientID(w, r)
 }
 
+// handleCanaries handles GET/POST /api/canaries
+func (a *API) handleCanaries(w http.ResponseWriter, r *http.Request) {
+	if a.canaryAPI == nil {
+		http.Error(w, "Canary API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.canaryAPI.HandleCanaries(w, r)
+}
+
+// handleCanaryResults handles GET /api/canaries/results
+func (a *API) handleCanaryResults(w http.ResponseWriter, r *http.Request) {
+	if a.canaryAPI == nil {
+		http.Error(w, "Canary API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.canaryAPI.HandleCanaryResults(w, r)
+}
+
+// handleCanaryByID handles GET/DELETE /api/canaries/{id} and POST /api/canaries/{id}/{promote,rollback}
+func (a *API) handleCanaryByID(w http.ResponseWriter, r *http.Request) {
+	if a.canaryAPI == nil {
+		http.Error(w, "Canary API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.canaryAPI.HandleCanaryByID(w, r)
+}
+
+// handleShadowResults handles GET /api/shadow/results
+func (a *API) handleShadowResults(w http.ResponseWriter, r *http.Request) {
+	if a.shadowAPI == nil {
+		http.Error(w, "Shadow API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.shadowAPI.HandleShadowResults(w, r)
+}
+
+// handleShadowSummary handles GET /api/shadow/summary
+func (a *API) handleShadowSummary(w http.ResponseWriter, r *http.Request) {
+	if a.shadowAPI == nil {
+		http.Error(w, "Shadow API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.shadowAPI.HandleShadowSummary(w, r)
+}
+
+// handleGuardrailStats handles GET /api/guardrails/stats
+func (a *API) handleGuardrailStats(w http.ResponseWriter, r *http.Request) {
+	if a.guardrailAPI == nil {
+		http.Error(w, "Guardrail API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.guardrailAPI.HandleGuardrailStats(w, r)
+}
+
+// handleWebhookDeadLetters handles GET /api/webhooks/dead-letters
+func (a *API) handleWebhookDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if a.webhookAPI == nil {
+		http.Error(w, "Webhook API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.webhookAPI.HandleDeadLetters(w, r)
+}
+
+// handleWebhookDeadLetterByID handles GET/DELETE /api/webhooks/dead-letters/{id} and POST .../{id}/redeliver
+func (a *API) handleWebhookDeadLetterByID(w http.ResponseWriter, r *http.Request) {
+	if a.webhookAPI == nil {
+		http.Error(w, "Webhook API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.webhookAPI.HandleDeadLetterByID(w, r)
+}
+
+// handleSchedules handles GET /api/schedules
+func (a *API) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	if a.schedulerAPI == nil {
+		http.Error(w, "Scheduler API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.schedulerAPI.HandleSchedules(w, r)
+}
+
+// handleScheduleRuns handles GET /api/schedules/runs
+func (a *API) handleScheduleRuns(w http.ResponseWriter, r *http.Request) {
+	if a.schedulerAPI == nil {
+		http.Error(w, "Scheduler API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.schedulerAPI.HandleRuns(w, r)
+}
+
+// handleScheduleTrigger handles POST /api/schedules/{provider}/run
+func (a *API) handleScheduleTrigger(w http.ResponseWriter, r *http.Request) {
+	if a.schedulerAPI == nil {
+		http.Error(w, "Scheduler API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.schedulerAPI.HandleTrigger(w, r)
+}
+
+// handleScraperRun handles POST /api/scraper/run
+func (a *API) handleScraperRun(w http.ResponseWriter, r *http.Request) {
+	if a.scraperAPI == nil {
+		http.Error(w, "Scraper API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.scraperAPI.HandleRun(w, r)
+}
+
+// handleScraperChanges handles GET /api/scraper/changes
+func (a *API) handleScraperChanges(w http.ResponseWriter, r *http.Request) {
+	if a.scraperAPI == nil {
+		http.Error(w, "Scraper API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.scraperAPI.HandleChanges(w, r)
+}
+
+// handleScraperChangeByID handles GET /api/scraper/changes/{id} and POST .../{id}/approve|reject
+func (a *API) handleScraperChangeByID(w http.ResponseWriter, r *http.Request) {
+	if a.scraperAPI == nil {
+		http.Error(w, "Scraper API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.scraperAPI.HandleChangeByID(w, r)
+}
+
+// handleExport handles GET /api/export
+func (a *API) handleExport(w http.ResponseWriter, r *http.Request) {
+	if a.exportAPI == nil {
+		http.Error(w, "Export API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.exportAPI.HandleDatasets(w, r)
+}
+
+// handleExportDataset handles GET /api/export/{dataset}
+func (a *API) handleExportDataset(w http.ResponseWriter, r *http.Request) {
+	if a.exportAPI == nil {
+		http.Error(w, "Export API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.exportAPI.HandleExport(w, r)
+}
+
+// handleConfigExport handles GET /api/config/export
+func (a *API) handleConfigExport(w http.ResponseWriter, r *http.Request) {
+	if a.bundleAPI == nil {
+		http.Error(w, "Config bundles not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.bundleAPI.HandleExport(w, r)
+}
+
+// handleConfigImport handles POST /api/config/import
+func (a *API) handleConfigImport(w http.ResponseWriter, r *http.Request) {
+	if a.bundleAPI == nil {
+		http.Error(w, "Config bundles not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.bundleAPI.HandleImport(w, r)
+}
+
+// handleDashboardOverview handles GET /api/dashboard/overview
+func (a *API) handleDashboardOverview(w http.ResponseWriter, r *http.Request) {
+	if a.dashboardAPI == nil {
+		http.Error(w, "Dashboard API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.dashboardAPI.HandleOverview(w, r)
+}
+
+// handleDashboardProviders handles GET /api/dashboard/providers
+func (a *API) handleDashboardProviders(w http.ResponseWriter, r *http.Request) {
+	if a.dashboardAPI == nil {
+		http.Error(w, "Dashboard API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.dashboardAPI.HandleProviders(w, r)
+}
+
+// handleDashboardKeys handles GET /api/dashboard/keys
+func (a *API) handleDashboardKeys(w http.ResponseWriter, r *http.Request) {
+	if a.dashboardAPI == nil {
+		http.Error(w, "Dashboard API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.dashboardAPI.HandleKeys(w, r)
+}
+
+// handleDashboardModels handles GET /api/dashboard/models
+func (a *API) handleDashboardModels(w http.ResponseWriter, r *http.Request) {
+	if a.dashboardAPI == nil {
+		http.Error(w, "Dashboard API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.dashboardAPI.HandleModels(w, r)
+}
+
+// handleDashboardUsage handles GET /api/dashboard/usage
+func (a *API) handleDashboardUsage(w http.ResponseWriter, r *http.Request) {
+	if a.dashboardAPI == nil {
+		http.Error(w, "Dashboard API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.dashboardAPI.HandleUsage(w, r)
+}
+
+// handleDashboardHealth handles GET /api/dashboard/health
+func (a *API) handleDashboardHealth(w http.ResponseWriter, r *http.Request) {
+	if a.dashboardAPI == nil {
+		http.Error(w, "Dashboard API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.dashboardAPI.HandleHealth(w, r)
+}
+
+// handleChaos handles GET/POST/DELETE /api/chaos
+func (a *API) handleChaos(w http.ResponseWriter, r *http.Request) {
+	if a.chaosAPI == nil {
+		http.Error(w, "Chaos API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.chaosAPI.HandleChaos(w, r)
+}
+
+// handleChaosProvider handles DELETE /api/chaos/{provider}
+func (a *API) handleChaosProvider(w http.ResponseWriter, r *http.Request) {
+	if a.chaosAPI == nil {
+		http.Error(w, "Chaos API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.chaosAPI.HandleChaosProvider(w, r)
+}
+
+// handleTransport handles GET /api/transport
+func (a *API) handleTransport(w http.ResponseWriter, r *http.Request) {
+	if a.transportAPI == nil {
+		http.Error(w, "Transport API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.transportAPI.HandleTransport(w, r)
+}
+
+// handleTransportProvider handles GET/PUT/DELETE /api/transport/{provider}
+func (a *API) handleTransportProvider(w http.ResponseWriter, r *http.Request) {
+	if a.transportAPI == nil {
+		http.Error(w, "Transport API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.transportAPI.HandleTransportProvider(w, r)
+}
+
+// handleFallbacks handles GET/POST /api/fallbacks
+func (a *API) handleFallbacks(w http.ResponseWriter, r *http.Request) {
+	if a.fallbackAPI == nil {
+		http.Error(w, "Fallback API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.fallbackAPI.HandleFallbacks(w, r)
+}
+
+// handleFallbackModel handles GET/DELETE /api/fallbacks/{model}
+func (a *API) handleFallbackModel(w http.ResponseWriter, r *http.Request) {
+	if a.fallbackAPI == nil {
+		http.Error(w, "Fallback API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.fallbackAPI.HandleFallbackModel(w, r)
+}
+
+// handleTransforms handles GET/POST /api/transforms
+func (a *API) handleTransforms(w http.ResponseWriter, r *http.Request) {
+	if a.transformAPI == nil {
+		http.Error(w, "Transform API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.transformAPI.HandleTransforms(w, r)
+}
+
+// handleTransformRule handles GET/DELETE /api/transforms/{name}
+func (a *API) handleTransformRule(w http.ResponseWriter, r *http.Request) {
+	if a.transformAPI == nil {
+		http.Error(w, "Transform API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.transformAPI.HandleTransformRule(w, r)
+}
+
+// handleExperiments handles GET/POST /api/experiments
+func (a *API) handleExperiments(w http.ResponseWriter, r *http.Request) {
+	if a.experimentAPI == nil {
+		http.Error(w, "Experiment API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.experimentAPI.HandleExperiments(w, r)
+}
+
+// handleExperiment handles GET/DELETE /api/experiments/{name}
+func (a *API) handleExperiment(w http.ResponseWriter, r *http.Request) {
+	if a.experimentAPI == nil {
+		http.Error(w, "Experiment API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.experimentAPI.HandleExperiment(w, r)
+}
+
+// handleQuality handles GET/POST /api/quality
+func (a *API) handleQuality(w http.ResponseWriter, r *http.Request) {
+	if a.qualityAPI == nil {
+		http.Error(w, "Quality API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.qualityAPI.HandleQuality(w, r)
+}
+
+// handleQualityModel handles GET/DELETE /api/quality/{model}
+func (a *API) handleQualityModel(w http.ResponseWriter, r *http.Request) {
+	if a.qualityAPI == nil {
+		http.Error(w, "Quality API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.qualityAPI.HandleQualityModel(w, r)
+}
+
+// handleTenants handles GET/POST /api/tenants
+func (a *API) handleTenants(w http.ResponseWriter, r *http.Request) {
+	if a.tenantAPI == nil {
+		http.Error(w, "Tenant API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.tenantAPI.HandleTenants(w, r)
+}
+
+// handleTenant handles /api/tenants/{id} and its clients, keys and usage
+func (a *API) handleTenant(w http.ResponseWriter, r *http.Request) {
+	if a.tenantAPI == nil {
+		http.Error(w, "Tenant API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.tenantAPI.HandleTenant(w, r)
+}
+
+// handleConcurrency handles GET /api/concurrency
+func (a *API) handleConcurrency(w http.ResponseWriter, r *http.Request) {
+	if a.concurrencyAPI == nil {
+		http.Error(w, "Concurrency API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.concurrencyAPI.HandleConcurrency(w, r)
+}
+
+// handleRetryBudgets handles GET /api/retry-budgets
+func (a *API) handleRetryBudgets(w http.ResponseWriter, r *http.Request) {
+	if a.retryBudgetAPI == nil {
+		http.Error(w, "Retry budget API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.retryBudgetAPI.HandleRetryBudgets(w, r)
+}
+
+// handleModelLimits handles GET/POST /api/modellimits
+func (a *API) handleModelLimits(w http.ResponseWriter, r *http.Request) {
+	if a.modelLimitAPI == nil {
+		http.Error(w, "Model limit API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.modelLimitAPI.HandleModelLimits(w, r)
+}
+
+// handleModelLimit handles GET/DELETE /api/modellimits/{model}
+func (a *API) handleModelLimit(w http.ResponseWriter, r *http.Request) {
+	if a.modelLimitAPI == nil {
+		http.Error(w, "Model limit API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.modelLimitAPI.HandleModelLimit(w, r)
+}
+
+// handlePromptPolicies handles GET/POST /api/policies/prompt
+func (a *API) handlePromptPolicies(w http.ResponseWriter, r *http.Request) {
+	if a.policyAPI == nil {
+		http.Error(w, "Prompt policy API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.policyAPI.HandlePolicies(w, r)
+}
+
+// handlePromptPolicyPreview handles GET /api/policies/prompt/preview
+func (a *API) handlePromptPolicyPreview(w http.ResponseWriter, r *http.Request) {
+	if a.policyAPI == nil {
+		http.Error(w, "Prompt policy API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.policyAPI.HandlePreview(w, r)
+}
+
+// handlePromptPolicyByID handles GET/PATCH/DELETE /api/policies/prompt/{id}
+func (a *API) handlePromptPolicyByID(w http.ResponseWriter, r *http.Request) {
+	if a.policyAPI == nil {
+		http.Error(w, "Prompt policy API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.policyAPI.HandlePolicyByID(w, r)
+}
+
+// handleMaintenance handles GET/POST /api/providers/maintenance
+func (a *API) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	if a.maintenanceAPI == nil {
+		http.Error(w, "Maintenance API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.maintenanceAPI.HandleMaintenance(w, r)
+}
+
+// handleMaintenanceByID handles GET/DELETE /api/providers/maintenance/{id}
+func (a *API) handleMaintenanceByID(w http.ResponseWriter, r *http.Request) {
+	if a.maintenanceAPI == nil {
+		http.Error(w, "Maintenance API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.maintenanceAPI.HandleMaintenanceByID(w, r)
+}
+
+// handleProviderTest handles POST /api/providers/{id}/test
+func (a *API) handleProviderTest(w http.ResponseWriter, r *http.Request) {
+	if a.diagnosticsAPI == nil {
+		http.Error(w, "Provider diagnostics not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.diagnosticsAPI.HandleTest(w, r)
+}
+
+// handleKeyHealth handles GET /api/keys/health
+func (a *API) handleKeyHealth(w http.ResponseWriter, r *http.Request) {
+	if a.keyHealthAPI == nil {
+		http.Error(w, "Key health API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.keyHealthAPI.HandleHealth(w, r)
+}
+
+// handleDeprecations handles GET /api/models/deprecations
+func (a *API) handleDeprecations(w http.ResponseWriter, r *http.Request) {
+	if a.deprecationAPI == nil {
+		http.Error(w, "Deprecation API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.deprecationAPI.HandleDeprecations(w, r)
+}
+
+// handleCatalogChanges handles GET /api/catalog/changes
+func (a *API) handleCatalogChanges(w http.ResponseWriter, r *http.Request) {
+	if a.catalogAPI == nil {
+		http.Error(w, "Catalog API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.catalogAPI.HandleChanges(w, r)
+}
+
+// handleCatalogSnapshots handles GET /api/catalog/snapshots[/{id}]
+func (a *API) handleCatalogSnapshots(w http.ResponseWriter, r *http.Request) {
+	if a.catalogAPI == nil {
+		http.Error(w, "Catalog API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.catalogAPI.HandleSnapshots(w, r)
+}
+
+// handleSLA handles GET /api/sla
+func (a *API) handleSLA(w http.ResponseWriter, r *http.Request) {
+	if a.slaAPI == nil {
+		http.Error(w, "SLA API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.slaAPI.HandleSLA(w, r)
+}
+
+// handleCache handles GET and DELETE /api/cache
+func (a *API) handleCache(w http.ResponseWriter, r *http.Request) {
+	if a.cacheAPI == nil {
+		http.Error(w, "Cache API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.cacheAPI.HandleCache(w, r)
+}
+
+// handleDebug handles /debug/pprof/, /debug/vars and /debug/status. They
+// are not found unless enabled, so their presence is not advertised.
+func (a *API) handleDebug(w http.ResponseWriter, r *http.Request) {
+	if a.debugAPI == nil {
+		http.NotFound(w, r)
+		return
+	}
+	a.debugAPI.ServeHTTP(w, r)
+}
+
+// handleLatencyHeatmap handles GET /api/latency/heatmap
+func (a *API) handleLatencyHeatmap(w http.ResponseWriter, r *http.Request) {
+	if a.heatmapAPI == nil {
+		http.Error(w, "Heatmap API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.heatmapAPI.HandleHeatmap(w, r)
+}
+
+// handleWatchdog handles GET /api/watchdog
+func (a *API) handleWatchdog(w http.ResponseWriter, r *http.Request) {
+	if a.watchdogAPI == nil {
+		http.Error(w, "Watchdog API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.watchdogAPI.HandleWatchdog(w, r)
+}
+
+// handleForecast handles GET /api/forecast
+func (a *API) handleForecast(w http.ResponseWriter, r *http.Request) {
+	if a.forecastAPI == nil {
+		http.Error(w, "Forecast API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.forecastAPI.HandleForecast(w, r)
+}
+
+// handleReconcile handles POST /api/reconcile
+func (a *API) handleReconcile(w http.ResponseWriter, r *http.Request) {
+	if a.reconcileAPI == nil {
+		http.Error(w, "Reconcile API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.reconcileAPI.HandleReconcile(w, r)
+}
+
+// handleChargeback handles GET /api/chargeback
+func (a *API) handleChargeback(w http.ResponseWriter, r *http.Request) {
+	if a.chargebackAPI == nil {
+		http.Error(w, "Chargeback API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.chargebackAPI.HandleChargeback(w, r)
+}
+
+// handleEmbeddingCache handles GET and DELETE /api/embeddings/cache
+func (a *API) handleEmbeddingCache(w http.ResponseWriter, r *http.Request) {
+	if a.embeddingsAPI == nil {
+		http.Error(w, "Embedding cache API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.embeddingsAPI.HandleEmbeddingCache(w, r)
+}
+
+// handleAnomalies handles GET /api/anomalies
+func (a *API) handleAnomalies(w http.ResponseWriter, r *http.Request) {
+	if a.anomalyAPI == nil {
+		http.Error(w, "Anomaly detection not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.anomalyAPI.HandleAnomalies(w, r)
+}
+
+// handleRouteExplain handles POST /api/route/explain
+func (a *API) handleRouteExplain(w http.ResponseWriter, r *http.Request) {
+	if a.routeAPI == nil {
+		http.Error(w, "Route explanation not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.routeAPI.HandleExplain(w, r)
+}
+
+// handleEvents handles GET /api/events (SSE)
+func (a *API) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if a.eventStream == nil {
+		http.Error(w, "Event stream not configured", http.StatusServiceUnavailable)
+		return
+	}
+	a.eventStream.ServeHTTP(w, r)
+}
+
 // handleServerInfo handles GET /api/server/info
 func (a *API) handleServerInfo(w http.ResponseWriter, r *http.Request) {
 	if a.serverAPI == nil {
@@ -716,7 +1712,21 @@ func (a *API) handleHealth(w http.ResponseWriter, r *http.Request) {
 
 // ServeHTTP implements http.Handler
 func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	a.mux.ServeHTTP(w, r)
+	if a.onMutation == nil || !isMutation(r) {
+		a.mux.ServeHTTP(w, r)
+		return
+	}
+	mw := &mutationWriter{ResponseWriter: w}
+	a.mux.ServeHTTP(mw, r)
+	if mw.status < http.StatusBadRequest {
+		a.onMutation(r)
+	}
+}
+
+// HandleFunc mounts an additional handler, such as the LLM proxy endpoints,
+// on the same server as the admin API
+func (a *API) HandleFunc(pattern string, handler http.HandlerFunc) {
+	a.mux.HandleFunc(pattern, handler)
 }
 
 // Start starts the API server
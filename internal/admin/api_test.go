@@ -45,6 +45,13 @@ func (m *mockDB) GetUsageStats(modelID string, since time.Time) (map[string]inte
 	}, nil
 }
 
+func (m *mockDB) UsageByModel(since time.Time) ([]*ModelUsageSummary, error) {
+	return []*ModelUsageSummary{
+		{Model: "gpt-4", Requests: 1000, Cost: 10.50},
+		{Model: "claude-sonnet-4", Requests: 400, Cost: 4.20},
+	}, nil
+}
+
 func (m *mockDB) GetAPIKey(id int) (*APIKey, error) {
 	if id == 1 {
 		prefix := "sk-test..."
@@ -177,8 +184,8 @@ func TestHandleProviders(t *testing.T) {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	if response["count"].(float64) != 2 {
-		t.Errorf("expected 2 providers, got %v", response["count"])
+	if response["total"].(float64) != 2 || len(response["items"].([]interface{})) != 2 {
+		t.Errorf("expected 2 providers, got %v", response)
 	}
 }
 
@@ -199,8 +206,8 @@ func TestHandleKeys(t *testing.T) {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	if response["count"].(float64) != 2 {
-		t.Errorf("expected 2 keys, got %v", response["count"])
+	if response["total"].(float64) != 2 || len(response["items"].([]interface{})) != 2 {
+		t.Errorf("expected 2 keys, got %v", response)
 	}
 }
 
@@ -249,8 +256,8 @@ func TestHandleSDKs(t *testing.T) {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	if response["count"].(float64) != 2 {
-		t.Errorf("expected 2 SDKs, got %v", response["count"])
+	if response["total"].(float64) != 2 {
+		t.Errorf("expected 2 SDKs, got %v", response["total"])
 	}
 }
 
@@ -453,14 +460,29 @@ func TestHandleSDKs_MethodNotAllowed(t *testing.T) {
 	}
 }
 
-func TestHandleStats_MissingModel(t *testing.T) {
+func TestHandleStats_ListsUsage(t *testing.T) {
 	api := NewAPI(Config{}, &mockDB{}, &mockDiscovery{}, &mockGenerator{}, &mockKeyManager{})
 
-	req := httptest.NewRequest("GET", "/api/stats", nil)
+	req := httptest.NewRequest("GET", "/api/stats?limit=1", nil)
 	w := httptest.NewRecorder()
 
 	api.ServeHTTP(w, req)
 
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var page ListPage[ModelUsageSummary]
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if page.Total != 2 || len(page.Items) != 1 || page.Items[0].Model != "gpt-4" || page.NextCursor == "" {
+		t.Errorf("expected the busiest model first, got %+v", page)
+	}
+
+	req = httptest.NewRequest("GET", "/api/stats?days=0", nil)
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, req)
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("expected status 400, got %d", w.Code)
 	}
@@ -680,3 +702,22 @@ func TestHandleKeyStats_MethodNotAllowed(t *testing.T) {
 		t.Errorf("expected status 405, got %d", w.Code)
 	}
 }
+
+func TestHandleEvents(t *testing.T) {
+	api := NewAPI(Config{}, nil, nil, nil, nil)
+
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/events", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without an event stream, got %d", rec.Code)
+	}
+
+	api.SetEventStream(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+	}))
+	rec = httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/events", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("expected the event stream to serve /api/events, got %d", rec.Code)
+	}
+}
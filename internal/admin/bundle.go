@@ -0,0 +1,136 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// MaxBundleSize caps the body of a configuration import
+const MaxBundleSize = 10 << 20
+
+// ConfigBundler exports and imports configuration bundles (usually a
+// *database.DB)
+type ConfigBundler interface {
+	ExportConfig() (*database.ConfigBundle, error)
+	ImportConfig(b *database.ConfigBundle, dryRun bool) (*database.ConfigDiff, error)
+}
+
+// BundleAPI handles configuration bundle endpoints, for promoting an
+// instance's configuration from staging to production
+type BundleAPI struct {
+	bundler  ConfigBundler
+	reloader Reloader
+}
+
+// NewBundleAPI creates a new BundleAPI
+func NewBundleAPI(bundler ConfigBundler) *BundleAPI {
+	return &BundleAPI{bundler: bundler}
+}
+
+// SetReloader sets the resolver to reload after an import changes aliases
+// or remap rules
+func (a *BundleAPI) SetReloader(reloader Reloader) {
+	a.reloader = reloader
+}
+
+// HandleExport handles GET /api/config/export?format=yaml|json. The bundle
+// is served as a file download; format defaults to yaml.
+func (a *BundleAPI) HandleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "yaml"
+	}
+	if format != "yaml" && format != "json" {
+		http.Error(w, "format must be yaml or json", http.StatusBadRequest)
+		return
+	}
+
+	bundle, err := a.bundler.ExportConfig()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="modelscan-config.`+format+`"`)
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(bundle)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(bundle); err != nil {
+		log.Printf("admin: failed to encode config bundle: %v", err)
+	}
+	enc.Close()
+}
+
+// HandleImport handles POST /api/config/import?dry_run=true. The body is a
+// bundle in YAML or JSON; the response lists the changes made, or with
+// dry_run the changes that would be.
+func (a *BundleAPI) HandleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "dry_run must be true or false", http.StatusBadRequest)
+			return
+		}
+		dryRun = parsed
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBundleSize))
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	bundle, err := parseConfigBundle(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	diff, err := a.bundler.ImportConfig(bundle, dryRun)
+	if err != nil {
+		http.Error(w, "Import failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !dryRun && a.reloader != nil {
+		if err := a.reloader.Reload(); err != nil {
+			log.Printf("admin: failed to reload after config import: %v", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+// parseConfigBundle decodes a bundle in YAML or JSON, which is YAML too
+func parseConfigBundle(data []byte) (*database.ConfigBundle, error) {
+	var bundle database.ConfigBundle
+	if err := yaml.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid config bundle: %w", err)
+	}
+	return &bundle, nil
+}
@@ -0,0 +1,116 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// countingReloader counts reloads
+type countingReloader struct {
+	reloads int
+}
+
+func (r *countingReloader) Reload() error {
+	r.reloads++
+	return nil
+}
+
+func openBundleDB(t *testing.T, name string) *database.DB {
+	t.Helper()
+	db, err := database.Open(filepath.Join(t.TempDir(), name))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestBundleAPI_ExportImport(t *testing.T) {
+	staging := openBundleDB(t, "staging.db")
+	staging.CreateProvider(&database.Provider{ID: "groq", Name: "Groq", BaseURL: "https://api.groq.com", AuthMethod: "bearer", PricingModel: "usage"})
+	staging.CreateAlias(&database.Alias{Name: "fast", ModelID: "llama-3.3-70b"})
+
+	for _, format := range []string{"yaml", "json"} {
+		t.Run(format, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewBundleAPI(staging).HandleExport(rec, httptest.NewRequest(http.MethodGet, "/api/config/export?format="+format, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Header().Get("Content-Disposition"), "modelscan-config."+format) {
+				t.Errorf("expected a download, got %q", rec.Header().Get("Content-Disposition"))
+			}
+			bundle := rec.Body.String()
+
+			prod := openBundleDB(t, "prod.db")
+			reloader := &countingReloader{}
+			api := NewBundleAPI(prod)
+			api.SetReloader(reloader)
+			importBundle := func(query string) *database.ConfigDiff {
+				rec := httptest.NewRecorder()
+				api.HandleImport(rec, httptest.NewRequest(http.MethodPost, "/api/config/import"+query, strings.NewReader(bundle)))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+				}
+				var diff database.ConfigDiff
+				if err := json.NewDecoder(rec.Body).Decode(&diff); err != nil {
+					t.Fatalf("failed to decode diff: %v", err)
+				}
+				return &diff
+			}
+
+			diff := importBundle("?dry_run=true")
+			if !diff.DryRun || diff.Counts[database.ConfigCreate] != 2 || reloader.reloads != 0 {
+				t.Errorf("expected a dry run creating groq and fast, got %+v", diff)
+			}
+			if p, _ := prod.GetProvider("groq"); p != nil {
+				t.Error("expected the dry run to create nothing")
+			}
+
+			diff = importBundle("")
+			if diff.DryRun || diff.Counts[database.ConfigCreate] != 2 || reloader.reloads != 1 {
+				t.Errorf("expected groq and fast to be created, got %+v", diff)
+			}
+			if model, _ := prod.ResolveAlias("fast", nil); model != "llama-3.3-70b" {
+				t.Errorf("expected fast to be imported, resolves to %s", model)
+			}
+		})
+	}
+}
+
+func TestBundleAPI_Errors(t *testing.T) {
+	api := NewBundleAPI(openBundleDB(t, "bundle.db"))
+
+	tests := []struct {
+		name   string
+		req    *http.Request
+		export bool
+		want   int
+	}{
+		{"unknown format", httptest.NewRequest(http.MethodGet, "/api/config/export?format=xml", nil), true, http.StatusBadRequest},
+		{"export method", httptest.NewRequest(http.MethodPost, "/api/config/export", nil), true, http.StatusMethodNotAllowed},
+		{"import method", httptest.NewRequest(http.MethodGet, "/api/config/import", nil), false, http.StatusMethodNotAllowed},
+		{"invalid body", httptest.NewRequest(http.MethodPost, "/api/config/import", strings.NewReader("{not yaml")), false, http.StatusBadRequest},
+		{"wrong version", httptest.NewRequest(http.MethodPost, "/api/config/import", strings.NewReader("version: 7")), false, http.StatusBadRequest},
+		{"invalid dry_run", httptest.NewRequest(http.MethodPost, "/api/config/import?dry_run=maybe", strings.NewReader("version: 1")), false, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			if tt.export {
+				api.HandleExport(rec, tt.req)
+			} else {
+				api.HandleImport(rec, tt.req)
+			}
+			if rec.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jeffersonwarrior/modelscan/internal/cache"
+)
+
+// ReadCaches are the caches in front of hot database reads
+type ReadCaches interface {
+	Stats() []cache.Stats
+	Invalidate()
+}
+
+// CacheAPI handles read cache endpoints
+type CacheAPI struct {
+	caches ReadCaches
+}
+
+// NewCacheAPI creates a new CacheAPI
+func NewCacheAPI(caches ReadCaches) *CacheAPI {
+	return &CacheAPI{caches: caches}
+}
+
+// cacheList pages GET /api/cache
+var cacheList = listSpec[cache.Stats]{
+	id: func(s cache.Stats) string { return s.Name },
+	fields: map[string]listField[cache.Stats]{
+		"name":     strField(func(s cache.Stats) string { return s.Name }),
+		"entries":  intField(func(s cache.Stats) int { return s.Entries }),
+		"hits":     numField(func(s cache.Stats) float64 { return float64(s.Hits) }),
+		"misses":   numField(func(s cache.Stats) float64 { return float64(s.Misses) }),
+		"hit_rate": numField(func(s cache.Stats) float64 { return s.HitRate }),
+	},
+	sort: "name",
+}
+
+// HandleCache handles GET /api/cache, which reports hit metrics, and
+// DELETE /api/cache, which drops every cached value
+func (a *CacheAPI) HandleCache(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeList(w, r, a.caches.Stats(), cacheList)
+	case http.MethodDelete:
+		a.caches.Invalidate()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// isMutation reports whether r may change admin-managed data
+func isMutation(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return strings.HasPrefix(r.URL.Path, "/api/")
+}
+
+// mutationWriter records the status of an admin mutation
+type mutationWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (mw *mutationWriter) WriteHeader(status int) {
+	if mw.status == 0 {
+		mw.status = status
+	}
+	mw.ResponseWriter.WriteHeader(status)
+}
+
+func (mw *mutationWriter) Write(b []byte) (int, error) {
+	if mw.status == 0 {
+		mw.status = http.StatusOK
+	}
+	return mw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (mw *mutationWriter) Unwrap() http.ResponseWriter {
+	return mw.ResponseWriter
+}
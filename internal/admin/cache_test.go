@@ -0,0 +1,65 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/cache"
+)
+
+type fakeReadCaches struct {
+	invalidations int
+}
+
+func (f *fakeReadCaches) Stats() []cache.Stats {
+	return []cache.Stats{{Name: "models", Entries: 1, Hits: 9, Misses: 1, HitRate: 0.9}}
+}
+
+func (f *fakeReadCaches) Invalidate() { f.invalidations++ }
+
+func TestCacheAPI(t *testing.T) {
+	caches := &fakeReadCaches{}
+	api := NewAPI(Config{}, &mockDB{}, &mockDiscovery{}, &mockGenerator{}, &mockKeyManager{})
+	api.SetCacheAPI(NewCacheAPI(caches))
+
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/cache", nil))
+	var resp struct {
+		Caches []cache.Stats `json:"items"`
+		Total  int           `json:"total"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusOK || resp.Total != 1 || resp.Caches[0].HitRate != 0.9 {
+		t.Errorf("unexpected stats response %d: %+v", rec.Code, resp)
+	}
+
+	rec = httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/cache", nil))
+	if rec.Code != http.StatusNoContent || caches.invalidations != 1 {
+		t.Errorf("expected the caches to be dropped, got %d after %d invalidations", rec.Code, caches.invalidations)
+	}
+}
+
+func TestAPI_MutationHook(t *testing.T) {
+	api := NewAPI(Config{}, &mockDB{}, &mockDiscovery{}, &mockGenerator{}, &mockKeyManager{})
+	var mutated []string
+	api.SetMutationHook(func(r *http.Request) { mutated = append(mutated, r.Method+" "+r.URL.Path) })
+	api.HandleFunc("/api/test/ok", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) })
+	api.HandleFunc("/api/test/bad", func(w http.ResponseWriter, r *http.Request) { http.Error(w, "bad", http.StatusBadRequest) })
+	api.HandleFunc("/v1/test", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/api/test/ok", nil),
+		httptest.NewRequest(http.MethodGet, "/api/test/ok", nil),
+		httptest.NewRequest(http.MethodPut, "/api/test/bad", nil),
+		httptest.NewRequest(http.MethodPost, "/v1/test", nil),
+		httptest.NewRequest(http.MethodDelete, "/api/test/ok", nil),
+	} {
+		api.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if len(mutated) != 2 || mutated[0] != "POST /api/test/ok" || mutated[1] != "DELETE /api/test/ok" {
+		t.Errorf("expected only successful admin mutations, got %q", mutated)
+	}
+}
@@ -0,0 +1,284 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CanaryManager controls live canary policies and their persisted results
+type CanaryManager interface {
+	ListCanaries() ([]*Canary, error)
+	GetCanary(id string) (*Canary, error)
+	AddCanary(policy *CanaryPolicy) error
+	RemoveCanary(id string) error
+	PromoteCanary(id, reason string) error
+	RollbackCanary(id, reason string) error
+	ListCanaryResults(policyID string, limit int) ([]*CanaryResult, error)
+}
+
+// CanaryPolicy describes a canary rollout
+type CanaryPolicy struct {
+	ID                   string  `json:"id"`
+	ModelPattern         string  `json:"model_pattern"`
+	CanaryModel          string  `json:"canary_model"`
+	CanaryProvider       string  `json:"canary_provider,omitempty"`
+	Percent              float64 `json:"percent"`
+	MinSamples           int     `json:"min_samples"`
+	PromoteAfter         int     `json:"promote_after,omitempty"`
+	MaxErrorRateIncrease float64 `json:"max_error_rate_increase"`
+	MaxLatencyRatio      float64 `json:"max_latency_ratio,omitempty"`
+	MaxQualityDrop       float64 `json:"max_quality_drop,omitempty"`
+}
+
+// CanaryArm summarizes one side of a canary
+type CanaryArm struct {
+	Requests     int      `json:"requests"`
+	Errors       int      `json:"errors"`
+	ErrorRate    float64  `json:"error_rate"`
+	AvgLatencyMs float64  `json:"avg_latency_ms"`
+	AvgQuality   *float64 `json:"avg_quality,omitempty"`
+}
+
+// Canary is the live state of a canary policy
+type Canary struct {
+	Policy    CanaryPolicy `json:"policy"`
+	Status    string       `json:"status"`
+	Reason    string       `json:"reason,omitempty"`
+	Baseline  CanaryArm    `json:"baseline"`
+	Canary    CanaryArm    `json:"canary"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// CanaryResult is a persisted canary decision or snapshot
+type CanaryResult struct {
+	ID             int       `json:"id"`
+	PolicyID       string    `json:"policy_id"`
+	ModelPattern   string    `json:"model_pattern"`
+	CanaryModel    string    `json:"canary_model"`
+	CanaryProvider string    `json:"canary_provider,omitempty"`
+	Percent        float64   `json:"percent"`
+	Status         string    `json:"status"`
+	Reason         string    `json:"reason,omitempty"`
+	Baseline       CanaryArm `json:"baseline"`
+	Canary         CanaryArm `json:"canary"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CanaryDecisionRequest is the optional body for promote/rollback
+type CanaryDecisionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CanaryAPI handles canary routing endpoints
+type CanaryAPI struct {
+	manager CanaryManager
+}
+
+// NewCanaryAPI creates a new CanaryAPI
+func NewCanaryAPI(manager CanaryManager) *CanaryAPI {
+	return &CanaryAPI{manager: manager}
+}
+
+// HandleCanaries handles GET /api/canaries (list) and POST /api/canaries (create)
+func (a *CanaryAPI) HandleCanaries(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		a.handleListCanaries(w, r)
+	case http.MethodPost:
+		a.handleCreateCanary(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleListCanaries handles GET /api/canaries
+func (a *CanaryAPI) handleListCanaries(w http.ResponseWriter, r *http.Request) {
+	canaries, err := a.manager.ListCanaries()
+	if err != nil {
+		http.Error(w, "Failed to list canaries: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeList(w, r, canaries, canaryList)
+}
+
+// canaryList pages GET /api/canaries
+var canaryList = listSpec[*Canary]{
+	id: func(c *Canary) string { return c.Policy.ID },
+	fields: map[string]listField[*Canary]{
+		"id":              strField(func(c *Canary) string { return c.Policy.ID }),
+		"model_pattern":   strField(func(c *Canary) string { return c.Policy.ModelPattern }),
+		"canary_model":    strField(func(c *Canary) string { return c.Policy.CanaryModel }),
+		"canary_provider": strField(func(c *Canary) string { return c.Policy.CanaryProvider }),
+		"status":          strField(func(c *Canary) string { return c.Status }),
+		"updated_at":      timeField(func(c *Canary) time.Time { return c.UpdatedAt }),
+	},
+	sort: "id",
+}
+
+// handleCreateCanary handles POST /api/canaries
+func (a *CanaryAPI) handleCreateCanary(w http.ResponseWriter, r *http.Request) {
+	var policy CanaryPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := a.manager.AddCanary(&policy); err != nil {
+		http.Error(w, "Failed to create canary: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	canary, err := a.manager.GetCanary(policy.ID)
+	if err != nil || canary == nil {
+		http.Error(w, "Failed to get canary after create", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(canary)
+}
+
+// HandleCanaryResults handles GET /api/canaries/results?policy_id=..., newest
+// first
+func (a *CanaryAPI) HandleCanaryResults(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	results, err := a.manager.ListCanaryResults(r.URL.Query().Get("policy_id"), 0)
+	if err != nil {
+		http.Error(w, "Failed to list canary results: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeList(w, r, results, canaryResultList)
+}
+
+// canaryResultList pages GET /api/canaries/results
+var canaryResultList = listSpec[*CanaryResult]{
+	id: func(c *CanaryResult) string { return strconv.Itoa(c.ID) },
+	fields: map[string]listField[*CanaryResult]{
+		"id":           intField(func(c *CanaryResult) int { return c.ID }),
+		"policy_id":    strField(func(c *CanaryResult) string { return c.PolicyID }),
+		"canary_model": strField(func(c *CanaryResult) string { return c.CanaryModel }),
+		"status":       strField(func(c *CanaryResult) string { return c.Status }),
+		"created_at":   timeField(func(c *CanaryResult) time.Time { return c.CreatedAt }),
+	},
+	sort: "-id",
+}
+
+// HandleCanaryByID handles GET/DELETE /api/canaries/{id} and
+// POST /api/canaries/{id}/promote, POST /api/canaries/{id}/rollback
+func (a *CanaryAPI) HandleCanaryByID(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/canaries/")
+	parts := strings.Split(path, "/")
+	id := parts[0]
+	if id == "" {
+		http.Error(w, "Canary ID required", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 2 {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		switch parts[1] {
+		case "promote":
+			a.handleDecision(w, r, id, a.manager.PromoteCanary)
+		case "rollback":
+			a.handleDecision(w, r, id, a.manager.RollbackCanary)
+		default:
+			http.Error(w, "Not found", http.StatusNotFound)
+		}
+		return
+	}
+	if len(parts) > 2 {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		a.handleGetCanary(w, r, id)
+	case http.MethodDelete:
+		a.handleDeleteCanary(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleGetCanary handles GET /api/canaries/{id}
+func (a *CanaryAPI) handleGetCanary(w http.ResponseWriter, r *http.Request, id string) {
+	canary, err := a.manager.GetCanary(id)
+	if err != nil {
+		http.Error(w, "Failed to get canary: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if canary == nil {
+		http.Error(w, "Canary not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(canary)
+}
+
+// handleDeleteCanary handles DELETE /api/canaries/{id}
+func (a *CanaryAPI) handleDeleteCanary(w http.ResponseWriter, r *http.Request, id string) {
+	canary, err := a.manager.GetCanary(id)
+	if err != nil {
+		http.Error(w, "Failed to check canary: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if canary == nil {
+		http.Error(w, "Canary not found", http.StatusNotFound)
+		return
+	}
+
+	if err := a.manager.RemoveCanary(id); err != nil {
+		http.Error(w, "Failed to delete canary: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDecision applies a manual promote or rollback
+func (a *CanaryAPI) handleDecision(w http.ResponseWriter, r *http.Request, id string, decide func(id, reason string) error) {
+	canary, err := a.manager.GetCanary(id)
+	if err != nil {
+		http.Error(w, "Failed to check canary: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if canary == nil {
+		http.Error(w, "Canary not found", http.StatusNotFound)
+		return
+	}
+
+	var req CanaryDecisionRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := decide(id, req.Reason); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	canary, err = a.manager.GetCanary(id)
+	if err != nil {
+		http.Error(w, "Failed to get canary: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(canary)
+}
@@ -0,0 +1,200 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// mockCanaryManager implements CanaryManager for testing
+type mockCanaryManager struct {
+	canaries map[string]*Canary
+	results  []*CanaryResult
+}
+
+func newMockCanaryManager() *mockCanaryManager {
+	return &mockCanaryManager{canaries: make(map[string]*Canary)}
+}
+
+func (m *mockCanaryManager) ListCanaries() ([]*Canary, error) {
+	var out []*Canary
+	for _, c := range m.canaries {
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (m *mockCanaryManager) GetCanary(id string) (*Canary, error) {
+	return m.canaries[id], nil
+}
+
+func (m *mockCanaryManager) AddCanary(policy *CanaryPolicy) error {
+	if policy.ID == "" {
+		return errors.New("canary policy ID is required")
+	}
+	m.canaries[policy.ID] = &Canary{Policy: *policy, Status: "running"}
+	return nil
+}
+
+func (m *mockCanaryManager) RemoveCanary(id string) error {
+	delete(m.canaries, id)
+	return nil
+}
+
+func (m *mockCanaryManager) PromoteCanary(id, reason string) error {
+	return m.decide(id, "promoted", reason)
+}
+
+func (m *mockCanaryManager) RollbackCanary(id, reason string) error {
+	return m.decide(id, "rolled_back", reason)
+}
+
+func (m *mockCanaryManager) decide(id, status, reason string) error {
+	c := m.canaries[id]
+	if c.Status != "running" {
+		return errors.New("canary is not running")
+	}
+	c.Status = status
+	c.Reason = reason
+	return nil
+}
+
+func (m *mockCanaryManager) ListCanaryResults(policyID string, limit int) ([]*CanaryResult, error) {
+	var out []*CanaryResult
+	for _, r := range m.results {
+		if policyID == "" || r.PolicyID == policyID {
+			out = append(out, r)
+		}
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func TestCanaryAPI_CreateAndList(t *testing.T) {
+	api := NewCanaryAPI(newMockCanaryManager())
+
+	body, _ := json.Marshal(CanaryPolicy{
+		ID:           "gpt5",
+		ModelPattern: "gpt-4*",
+		CanaryModel:  "gpt-5",
+		Percent:      10,
+		MinSamples:   50,
+	})
+	w := httptest.NewRecorder()
+	api.HandleCanaries(w, httptest.NewRequest("POST", "/api/canaries", bytes.NewBuffer(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	api.HandleCanaries(w, httptest.NewRequest("GET", "/api/canaries", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var resp struct {
+		Canaries []Canary `json:"items"`
+		Total    int      `json:"total"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Total != 1 || resp.Canaries[0].Policy.CanaryModel != "gpt-5" {
+		t.Errorf("unexpected list response: %+v", resp)
+	}
+}
+
+func TestCanaryAPI_CreateInvalid(t *testing.T) {
+	api := NewCanaryAPI(newMockCanaryManager())
+
+	w := httptest.NewRecorder()
+	api.HandleCanaries(w, httptest.NewRequest("POST", "/api/canaries", bytes.NewBufferString("{")))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for bad JSON, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	api.HandleCanaries(w, httptest.NewRequest("POST", "/api/canaries", bytes.NewBufferString(`{"model_pattern":"gpt-4*"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid policy, got %d", w.Code)
+	}
+}
+
+func TestCanaryAPI_ByID(t *testing.T) {
+	manager := newMockCanaryManager()
+	manager.canaries["gpt5"] = &Canary{Policy: CanaryPolicy{ID: "gpt5"}, Status: "running"}
+	api := NewCanaryAPI(manager)
+
+	w := httptest.NewRecorder()
+	api.HandleCanaryByID(w, httptest.NewRequest("GET", "/api/canaries/gpt5", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("GET: expected status 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	api.HandleCanaryByID(w, httptest.NewRequest("GET", "/api/canaries/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET missing: expected status 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	api.HandleCanaryByID(w, httptest.NewRequest("GET", "/api/canaries/gpt5/promote", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET promote: expected status 405, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	api.HandleCanaryByID(w, httptest.NewRequest("POST", "/api/canaries/gpt5/rollback", bytes.NewBufferString(`{"reason":"regression"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("rollback: expected status 200, got %d", w.Code)
+	}
+	if manager.canaries["gpt5"].Status != "rolled_back" || manager.canaries["gpt5"].Reason != "regression" {
+		t.Errorf("unexpected canary after rollback: %+v", manager.canaries["gpt5"])
+	}
+
+	w = httptest.NewRecorder()
+	api.HandleCanaryByID(w, httptest.NewRequest("POST", "/api/canaries/gpt5/promote", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("promote after rollback: expected status 409, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	api.HandleCanaryByID(w, httptest.NewRequest("DELETE", "/api/canaries/gpt5", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("DELETE: expected status 204, got %d", w.Code)
+	}
+	if _, ok := manager.canaries["gpt5"]; ok {
+		t.Error("expected canary to be removed")
+	}
+}
+
+func TestCanaryAPI_Results(t *testing.T) {
+	manager := newMockCanaryManager()
+	manager.results = []*CanaryResult{
+		{ID: 2, PolicyID: "gpt5", Status: "promoted"},
+		{ID: 1, PolicyID: "other", Status: "rolled_back"},
+	}
+	api := NewCanaryAPI(manager)
+
+	w := httptest.NewRecorder()
+	api.HandleCanaryResults(w, httptest.NewRequest("GET", "/api/canaries/results?policy_id=gpt5", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var resp struct {
+		Results []CanaryResult `json:"items"`
+		Total   int            `json:"total"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Total != 1 || resp.Results[0].Status != "promoted" {
+		t.Errorf("unexpected results: %+v", resp)
+	}
+
+	w = httptest.NewRecorder()
+	api.HandleCanaryResults(w, httptest.NewRequest("GET", "/api/canaries/results?limit=abc", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for bad limit, got %d", w.Code)
+	}
+}
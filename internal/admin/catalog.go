@@ -0,0 +1,127 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// CatalogStore reads model catalog history
+type CatalogStore interface {
+	ListCatalogChanges(filter database.CatalogChangeFilter) ([]*database.CatalogChange, error)
+	ListCatalogSnapshots(providerID string) ([]*database.CatalogSnapshot, error)
+	GetCatalogSnapshot(id int) (*database.CatalogSnapshot, error)
+}
+
+// CatalogAPI handles model catalog history endpoints
+type CatalogAPI struct {
+	store CatalogStore
+}
+
+// NewCatalogAPI creates a new CatalogAPI
+func NewCatalogAPI(store CatalogStore) *CatalogAPI {
+	return &CatalogAPI{store: store}
+}
+
+// catalogChangeList pages GET /api/catalog/changes
+var catalogChangeList = listSpec[*database.CatalogChange]{
+	id: func(c *database.CatalogChange) string { return strconv.Itoa(c.ID) },
+	fields: map[string]listField[*database.CatalogChange]{
+		"id":       intField(func(c *database.CatalogChange) int { return c.ID }),
+		"provider": strField(func(c *database.CatalogChange) string { return c.ProviderID }),
+		"model":    strField(func(c *database.CatalogChange) string { return c.ModelID }),
+		"kind":     strField(func(c *database.CatalogChange) string { return c.Kind }),
+	},
+	sort: "-id",
+}
+
+// catalogSnapshotList pages GET /api/catalog/snapshots
+var catalogSnapshotList = listSpec[*database.CatalogSnapshot]{
+	id: func(s *database.CatalogSnapshot) string { return strconv.Itoa(s.ID) },
+	fields: map[string]listField[*database.CatalogSnapshot]{
+		"id":          intField(func(s *database.CatalogSnapshot) int { return s.ID }),
+		"provider":    strField(func(s *database.CatalogSnapshot) string { return s.ProviderID }),
+		"source":      strField(func(s *database.CatalogSnapshot) string { return s.Source }),
+		"model_count": intField(func(s *database.CatalogSnapshot) int { return s.ModelCount }),
+	},
+	sort: "-id",
+}
+
+// HandleChanges handles GET /api/catalog/changes, the feed of models
+// appearing, disappearing and changing price. Besides the list parameters
+// it takes since=<RFC3339> and since_id=<id>; pollers pass the highest ID
+// they have seen as since_id to get only newer changes.
+func (a *CatalogAPI) HandleChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := database.CatalogChangeFilter{ProviderID: q.Get("provider")}
+	if s := q.Get("since"); s != "" {
+		since, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			http.Error(w, "since must be an RFC3339 time", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+	if s := q.Get("since_id"); s != "" {
+		id, err := strconv.Atoi(s)
+		if err != nil || id < 0 {
+			http.Error(w, "since_id must be a change ID", http.StatusBadRequest)
+			return
+		}
+		filter.AfterID = id
+	}
+
+	changes, err := a.store.ListCatalogChanges(filter)
+	if err != nil {
+		http.Error(w, "Failed to list catalog changes: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeList(w, r, changes, catalogChangeList)
+}
+
+// HandleSnapshots handles GET /api/catalog/snapshots, listing snapshots
+// without their models, and GET /api/catalog/snapshots/{id}, returning
+// one with its models
+func (a *CatalogAPI) HandleSnapshots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/catalog/snapshots"), "/")
+	if idStr == "" {
+		snaps, err := a.store.ListCatalogSnapshots(r.URL.Query().Get("provider"))
+		if err != nil {
+			http.Error(w, "Failed to list catalog snapshots: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeList(w, r, snaps, catalogSnapshotList)
+		return
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid snapshot ID", http.StatusBadRequest)
+		return
+	}
+	snap, err := a.store.GetCatalogSnapshot(id)
+	if err != nil {
+		http.Error(w, "Failed to get catalog snapshot: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if snap == nil {
+		http.Error(w, "Snapshot not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snap)
+}
@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+func TestCatalogAPI(t *testing.T) {
+	db := openBundleDB(t, "catalog.db")
+	api := NewCatalogAPI(db)
+
+	price := 2.5
+	taken := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	snap := &database.CatalogSnapshot{ProviderID: "openai", Source: database.CatalogSourceScheduler, TakenAt: taken,
+		Models: []*database.CatalogModel{{ModelID: "gpt-4.1", CostPer1MIn: &price}}}
+	changes := []*database.CatalogChange{
+		{ModelID: "gpt-4.1", Kind: database.CatalogModelAdded, NewCostPer1MIn: &price},
+		{ModelID: "o1-mini", Kind: database.CatalogModelRemoved},
+	}
+	if err := db.CreateCatalogSnapshot(snap, changes); err != nil {
+		t.Fatalf("CreateCatalogSnapshot failed: %v", err)
+	}
+	if err := db.CreateCatalogSnapshot(&database.CatalogSnapshot{ProviderID: "groq", Source: database.CatalogSourceDiscovery, TakenAt: taken.Add(time.Hour)},
+		[]*database.CatalogChange{{ModelID: "gemma-7b-it", Kind: database.CatalogModelRemoved}}); err != nil {
+		t.Fatalf("CreateCatalogSnapshot failed: %v", err)
+	}
+
+	get := func(handler http.HandlerFunc, target string, v interface{}) int {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if v != nil {
+			json.NewDecoder(rec.Body).Decode(v)
+		}
+		return rec.Code
+	}
+
+	var feed ListPage[*database.CatalogChange]
+	if code := get(api.HandleChanges, "/api/catalog/changes", &feed); code != http.StatusOK || feed.Total != 3 {
+		t.Fatalf("expected all 3 changes, got %d %+v", code, feed)
+	}
+	if feed.Items[0].ModelID != "gemma-7b-it" {
+		t.Errorf("expected the newest change first, got %s", feed.Items[0].ModelID)
+	}
+
+	feed = ListPage[*database.CatalogChange]{}
+	get(api.HandleChanges, "/api/catalog/changes?provider=openai&kind=model_removed", &feed)
+	if feed.Total != 1 || feed.Items[0].ModelID != "o1-mini" {
+		t.Errorf("expected the o1-mini removal, got %+v", feed.Items)
+	}
+
+	feed = ListPage[*database.CatalogChange]{}
+	get(api.HandleChanges, "/api/catalog/changes?since_id="+strconv.Itoa(changes[1].ID), &feed)
+	if feed.Total != 1 || feed.Items[0].ProviderID != "groq" {
+		t.Errorf("expected only the groq change after since_id, got %+v", feed.Items)
+	}
+
+	feed = ListPage[*database.CatalogChange]{}
+	get(api.HandleChanges, "/api/catalog/changes?since="+taken.Add(30*time.Minute).Format(time.RFC3339), &feed)
+	if feed.Total != 1 {
+		t.Errorf("expected one change since 00:30, got %+v", feed.Items)
+	}
+	if code := get(api.HandleChanges, "/api/catalog/changes?since=yesterday", nil); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid since, got %d", code)
+	}
+
+	var snaps ListPage[*database.CatalogSnapshot]
+	if code := get(api.HandleSnapshots, "/api/catalog/snapshots?provider=openai", &snaps); code != http.StatusOK || snaps.Total != 1 {
+		t.Fatalf("expected one openai snapshot, got %d %+v", code, snaps)
+	}
+
+	var full database.CatalogSnapshot
+	if code := get(api.HandleSnapshots, "/api/catalog/snapshots/"+strconv.Itoa(snap.ID), &full); code != http.StatusOK || len(full.Models) != 1 {
+		t.Errorf("expected the snapshot with its model, got %d %+v", code, full)
+	}
+	if code := get(api.HandleSnapshots, "/api/catalog/snapshots/999", nil); code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown snapshot, got %d", code)
+	}
+}
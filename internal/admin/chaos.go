@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/jeffersonwarrior/modelscan/internal/chaos"
+)
+
+// FaultInjector manages injected provider faults (usually a *chaos.Injector)
+type FaultInjector interface {
+	Faults() []chaos.Fault
+	Stats() []chaos.Stats
+	SetFault(f chaos.Fault) error
+	RemoveFault(provider string) bool
+	Clear()
+}
+
+// ChaosAPI handles fault injection endpoints
+type ChaosAPI struct {
+	injector FaultInjector
+}
+
+// NewChaosAPI creates a new ChaosAPI
+func NewChaosAPI(injector FaultInjector) *ChaosAPI {
+	return &ChaosAPI{injector: injector}
+}
+
+// faultList pages the faults of GET /api/chaos
+var faultList = listSpec[chaos.Fault]{
+	id: func(f chaos.Fault) string { return f.Provider },
+	fields: map[string]listField[chaos.Fault]{
+		"provider":      strField(func(f chaos.Fault) string { return f.Provider }),
+		"latency_ms":    intField(func(f chaos.Fault) int { return f.LatencyMs }),
+		"drop_rate":     numField(func(f chaos.Fault) float64 { return f.DropRate }),
+		"error_rate":    numField(func(f chaos.Fault) float64 { return f.ErrorRate }),
+		"truncate_rate": numField(func(f chaos.Fault) float64 { return f.TruncateRate }),
+	},
+	sort: "provider",
+}
+
+// HandleChaos handles GET /api/chaos (faults and counters), POST /api/chaos
+// (add or replace a provider's fault) and DELETE /api/chaos (clear all)
+func (a *ChaosAPI) HandleChaos(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeListWith(w, r, a.injector.Faults(), faultList, map[string]interface{}{
+			"stats": a.injector.Stats(),
+		})
+	case http.MethodPost:
+		var fault chaos.Fault
+		if err := json.NewDecoder(r.Body).Decode(&fault); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := a.injector.SetFault(fault); err != nil {
+			http.Error(w, "Invalid fault: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(fault)
+	case http.MethodDelete:
+		a.injector.Clear()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleChaosProvider handles DELETE /api/chaos/{provider}
+func (a *ChaosAPI) HandleChaosProvider(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	provider := strings.TrimPrefix(r.URL.Path, "/api/chaos/")
+	if provider == "" || strings.Contains(provider, "/") {
+		http.Error(w, "Provider required", http.StatusBadRequest)
+		return
+	}
+	if !a.injector.RemoveFault(provider) {
+		http.Error(w, "Fault not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/chaos"
+)
+
+func TestChaosAPI(t *testing.T) {
+	api := NewChaosAPI(chaos.New(1))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if strings.HasPrefix(path, "/api/chaos/") {
+			api.HandleChaosProvider(rec, req)
+		} else {
+			api.HandleChaos(rec, req)
+		}
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/chaos", `{"provider":"openai","error_rate":0.5,"status":429}`); rec.Code != http.StatusCreated {
+		t.Fatalf("POST expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/chaos", `{"provider":"openai","drop_rate":2}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid fault expected 400, got %d", rec.Code)
+	}
+	do(http.MethodPost, "/api/chaos", `{"provider":"*","latency_ms":10}`)
+
+	rec := do(http.MethodGet, "/api/chaos", "")
+	var resp struct {
+		Faults []chaos.Fault `json:"items"`
+		Total  int           `json:"total"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Total != 2 || resp.Faults[1].Provider != "openai" || resp.Faults[1].Status != 429 {
+		t.Errorf("unexpected faults: %+v", resp)
+	}
+
+	if rec := do(http.MethodDelete, "/api/chaos/openai", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE provider expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/chaos/openai", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("DELETE missing provider expected 404, got %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/chaos", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE all expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/api/chaos", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT expected 405, got %d", rec.Code)
+	}
+}
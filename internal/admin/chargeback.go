@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/chargeback"
+)
+
+// ChargebackAPI handles chargeback report endpoints
+type ChargebackAPI struct {
+	ledger  chargeback.Ledger
+	clients chargeback.Clients
+}
+
+// NewChargebackAPI creates a new ChargebackAPI
+func NewChargebackAPI(ledger chargeback.Ledger, clients chargeback.Clients) *ChargebackAPI {
+	return &ChargebackAPI{ledger: ledger, clients: clients}
+}
+
+// HandleChargeback handles GET /api/chargeback?month=YYYY-MM&tenant=<id>&format=json|csv|markdown,
+// returning a month's chargeback (default last month). CSV and Markdown
+// are sent as file downloads.
+func (a *ChargebackAPI) HandleChargeback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = chargeback.FormatJSON
+	}
+	if !slices.Contains(chargeback.Formats, format) {
+		http.Error(w, "format must be json, csv or markdown", http.StatusBadRequest)
+		return
+	}
+	month := chargeback.MonthOf(time.Now()).AddDate(0, -1, 0)
+	if v := q.Get("month"); v != "" {
+		var err error
+		if month, err = chargeback.ParseMonth(v); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := chargeback.Build(a.ledger, a.clients, month, q.Get("tenant"))
+	if err != nil {
+		http.Error(w, "Failed to build chargeback: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Render before writing headers so a failure can still be reported
+	var buf bytes.Buffer
+	if err := chargeback.Write(&buf, report, format); err != nil {
+		http.Error(w, "Failed to write chargeback: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", chargeback.ContentType(format))
+	if format != chargeback.FormatJSON {
+		filename := fmt.Sprintf("chargeback-%s.%s", report.Month.Format("2006-01"), chargeback.Extension(format))
+		if tenant := q.Get("tenant"); tenant != "" {
+			filename = fmt.Sprintf("chargeback-%s-%s.%s", tenant, report.Month.Format("2006-01"), chargeback.Extension(format))
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/chargeback"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+type fakeChargebackLedger struct {
+	err  error
+	from time.Time
+}
+
+func (l *fakeChargebackLedger) UsageByClient(from, to time.Time) ([]*database.TenantClientUsage, error) {
+	l.from = from
+	return []*database.TenantClientUsage{
+		{TenantID: "team-a", ClientID: "cli-1", TenantModelUsage: database.TenantModelUsage{Provider: "openai", Model: "gpt-4o", Requests: 3, Cost: 1.5}},
+		{TenantID: "team-b", TenantModelUsage: database.TenantModelUsage{Provider: "openai", Model: "gpt-4o", Requests: 1, Cost: 0.5}},
+	}, l.err
+}
+
+func (l *fakeChargebackLedger) List() ([]*database.Tenant, error) {
+	return []*database.Tenant{{ID: "team-a", Name: "Search"}}, nil
+}
+
+type fakeClientList struct{}
+
+func (fakeClientList) List() ([]*database.Client, error) {
+	return []*database.Client{{ID: "cli-1", Name: "indexer"}}, nil
+}
+
+func TestChargebackAPI(t *testing.T) {
+	api := NewAPI(Config{}, &mockDB{}, &mockDiscovery{}, &mockGenerator{}, &mockKeyManager{})
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := get("/api/chargeback"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without chargeback, got %d", rec.Code)
+	}
+
+	ledger := &fakeChargebackLedger{}
+	api.SetChargebackAPI(NewChargebackAPI(ledger, fakeClientList{}))
+	rec := get("/api/chargeback?month=2025-03")
+	var report chargeback.Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d: %v", rec.Code, err)
+	}
+	if !ledger.from.Equal(time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)) || report.Cost != 2 || len(report.Tenants) != 2 {
+		t.Errorf("unexpected report from %v: %+v", ledger.from, report)
+	}
+	if report.Tenants[0].Name != "Search" || report.Tenants[0].Clients[0].Name != "indexer" {
+		t.Errorf("expected names resolved, got %+v", report.Tenants[0])
+	}
+
+	rec = get("/api/chargeback?month=2025-03&format=csv&tenant=team-a")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("unexpected CSV response %d: %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="chargeback-team-a-2025-03.csv"` {
+		t.Errorf("unexpected Content-Disposition %q", cd)
+	}
+	if lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n"); len(lines) != 2 || !strings.Contains(lines[1], "team-a,Search,cli-1,indexer") {
+		t.Errorf("expected one team-a row, got %q", rec.Body.String())
+	}
+
+	rec = get("/api/chargeback?month=2025-03&format=markdown")
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="chargeback-2025-03.md"` || !strings.Contains(rec.Body.String(), "# Chargeback report: March 2025") {
+		t.Errorf("unexpected Markdown response %q: %s", cd, rec.Body.String())
+	}
+
+	for _, path := range []string{"/api/chargeback?month=March", "/api/chargeback?format=pdf"} {
+		if rec := get(path); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", path, rec.Code)
+		}
+	}
+
+	api.SetChargebackAPI(NewChargebackAPI(&fakeChargebackLedger{err: errors.New("database is locked")}, fakeClientList{}))
+	if rec := get("/api/chargeback"); rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 when the ledger cannot be read, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/chargeback", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", rec.Code)
+	}
+}
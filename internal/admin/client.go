@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client reads the list endpoints of a running admin API
+type Client struct {
+	BaseURL    string       // e.g. "http://localhost:8080"
+	HTTPClient *http.Client // Default http.DefaultClient
+}
+
+// NewClient creates a client for the admin API at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Providers fetches a page of GET /api/providers
+func (c *Client) Providers(ctx context.Context, opts ListOptions) (*ListPage[*Provider], error) {
+	return List[*Provider](ctx, c, "/api/providers", opts)
+}
+
+// Keys fetches a page of GET /api/keys for a provider
+func (c *Client) Keys(ctx context.Context, providerID string, opts ListOptions) (*ListPage[*APIKey], error) {
+	return List[*APIKey](ctx, c, "/api/keys?provider="+url.QueryEscape(providerID), opts)
+}
+
+// Models fetches a page of the flat GET /api/models
+func (c *Client) Models(ctx context.Context, opts ListOptions) (*ListPage[ModelWithProviderInfo], error) {
+	return List[ModelWithProviderInfo](ctx, c, "/api/models", opts)
+}
+
+// List fetches a page of any list endpoint. path may carry the endpoint's
+// own query parameters ("/api/schedules/runs?provider=openai"); opts are
+// added to them.
+func List[T any](ctx context.Context, c *Client, path string, opts ListOptions) (*ListPage[T], error) {
+	u, err := url.Parse(c.BaseURL + path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %q: %w", path, err)
+	}
+	q := u.Query()
+	for k, v := range opts.Query() {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("GET %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	var page ListPage[T]
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("GET %s: invalid list response: %w", path, err)
+	}
+	return &page, nil
+}
+
+// ListAll fetches every page of a list endpoint, starting at opts.Cursor
+func ListAll[T any](ctx context.Context, c *Client, path string, opts ListOptions) ([]T, error) {
+	var items []T
+	for {
+		page, err := List[T](ctx, c, path, opts)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Items...)
+		if page.NextCursor == "" {
+			return items, nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_List(t *testing.T) {
+	api := NewAPI(Config{}, &mockDB{}, &mockDiscovery{}, &mockGenerator{}, &mockKeyManager{})
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	c := NewClient(srv.URL + "/")
+	ctx := context.Background()
+
+	page, err := c.Providers(ctx, ListOptions{Limit: 1, Sort: "-id"})
+	if err != nil {
+		t.Fatalf("Providers: %v", err)
+	}
+	if page.Total != 2 || len(page.Items) != 1 || page.Items[0].ID != "openai" || page.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+	page, err = c.Providers(ctx, ListOptions{Limit: 1, Sort: "-id", Cursor: page.NextCursor})
+	if err != nil || len(page.Items) != 1 || page.Items[0].ID != "anthropic" || page.NextCursor != "" {
+		t.Fatalf("unexpected last page: %+v, %v", page, err)
+	}
+
+	filtered, err := c.Providers(ctx, ListOptions{Filters: map[string]string{"name": "anthropic"}})
+	if err != nil || filtered.Total != 1 || filtered.Items[0].ID != "anthropic" {
+		t.Errorf("expected the filter to match anthropic, got %+v, %v", filtered, err)
+	}
+
+	keys, err := ListAll[*APIKey](ctx, c, "/api/keys?provider=openai", ListOptions{Limit: 1})
+	if err != nil || len(keys) != 2 || keys[0].ProviderID != "openai" {
+		t.Errorf("expected both openai keys across pages, got %+v, %v", keys, err)
+	}
+
+	if _, err := c.Providers(ctx, ListOptions{Sort: "base_url"}); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("expected a 400 error for an unknown sort field, got %v", err)
+	}
+}
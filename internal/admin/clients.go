@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
 )
 
 // ClientAPI handles client registration and management endpoints
@@ -55,6 +56,7 @@ type ConfigUpdateRequest struct {
 	MaxOutputTokens  *int      `json:"max_output_tokens,omitempty"`
 	TimeoutMs        *int      `json:"timeout_ms,omitempty"`
 	ProviderPriority *[]string `json:"provider_priority,omitempty"`
+	ContextOverflow  *string   `json:"context_overflow,omitempty"`
 }
 
 // generateToken creates a cryptographically secure random token
@@ -155,6 +157,10 @@ func (c *ClientAPI) HandleRegister(w http.ResponseWriter, r *http.Request) {
 		client.Capabilities = []string{}
 	}
 	if req.Config != nil {
+		if _, err := proxy.ParseOverflowPolicy(req.Config.ContextOverflow); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		client.Config = *req.Config
 	}
 
@@ -281,6 +287,13 @@ func (c *ClientAPI) HandleUpdateConfig(w http.ResponseWriter, r *http.Request) {
 	if req.ProviderPriority != nil {
 		config.ProviderPriority = *req.ProviderPriority
 	}
+	if req.ContextOverflow != nil {
+		if _, err := proxy.ParseOverflowPolicy(*req.ContextOverflow); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		config.ContextOverflow = *req.ContextOverflow
+	}
 
 	// Update config
 	if err := c.clientRepo.UpdateConfig(clientID, config); err != nil {
@@ -359,15 +372,19 @@ func (c *ClientAPI) HandleListClients(w http.ResponseWriter, r *http.Request) {
 			LastSeenAt:   client.LastSeenAt,
 		})
 	}
-	if resp == nil {
-		resp = []ClientResponse{}
-	}
+	writeList(w, r, resp, clientList)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"clients": resp,
-		"count":   len(resp),
-	})
+// clientList pages GET /api/clients
+var clientList = listSpec[ClientResponse]{
+	id: func(c ClientResponse) string { return c.ID },
+	fields: map[string]listField[ClientResponse]{
+		"id":         strField(func(c ClientResponse) string { return c.ID }),
+		"name":       strField(func(c ClientResponse) string { return c.Name }),
+		"version":    strField(func(c ClientResponse) string { return c.Version }),
+		"created_at": timeField(func(c ClientResponse) time.Time { return c.CreatedAt }),
+	},
+	sort: "id",
 }
 
 // ClientStatsResponse represents statistics for a client
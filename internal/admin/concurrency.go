@@ -0,0 +1,44 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// ConcurrencyStatsSource reports proxy concurrency caps (usually a
+// *proxy.ConcurrencyLimiter)
+type ConcurrencyStatsSource interface {
+	Stats() []proxy.ConcurrencyStats
+}
+
+// ConcurrencyAPI handles concurrency limit endpoints
+type ConcurrencyAPI struct {
+	source ConcurrencyStatsSource
+}
+
+// NewConcurrencyAPI creates a new ConcurrencyAPI
+func NewConcurrencyAPI(source ConcurrencyStatsSource) *ConcurrencyAPI {
+	return &ConcurrencyAPI{source: source}
+}
+
+// HandleConcurrency handles GET /api/concurrency: in-flight, queued and
+// shed requests for the global cap and each provider
+func (a *ConcurrencyAPI) HandleConcurrency(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeList(w, r, a.source.Stats(), concurrencyList)
+}
+
+// concurrencyList pages GET /api/concurrency, global cap first
+var concurrencyList = listSpec[proxy.ConcurrencyStats]{
+	id: func(s proxy.ConcurrencyStats) string { return s.Scope },
+	fields: map[string]listField[proxy.ConcurrencyStats]{
+		"scope":     strField(func(s proxy.ConcurrencyStats) string { return s.Scope }),
+		"in_flight": intField(func(s proxy.ConcurrencyStats) int { return s.InFlight }),
+		"queued":    numField(func(s proxy.ConcurrencyStats) float64 { return float64(s.Queued) }),
+		"shed":      numField(func(s proxy.ConcurrencyStats) float64 { return float64(s.Shed) }),
+	},
+}
@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+type fixedConcurrencyStats []proxy.ConcurrencyStats
+
+func (s fixedConcurrencyStats) Stats() []proxy.ConcurrencyStats { return s }
+
+func TestConcurrencyAPI(t *testing.T) {
+	api := NewConcurrencyAPI(fixedConcurrencyStats{
+		{Scope: "global", Limit: 10, InFlight: 3},
+		{Scope: "openai", Limit: 2, InFlight: 2, Queued: 4, Shed: 1},
+	})
+
+	rec := httptest.NewRecorder()
+	api.HandleConcurrency(rec, httptest.NewRequest(http.MethodGet, "/api/concurrency", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var resp struct {
+		Limits []proxy.ConcurrencyStats `json:"items"`
+		Total  int                      `json:"total"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Total != 2 || resp.Limits[1].Queued != 4 || resp.Limits[1].Shed != 1 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleConcurrency(rec, httptest.NewRequest(http.MethodPost, "/api/concurrency", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
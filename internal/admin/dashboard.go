@@ -0,0 +1,386 @@
+package admin
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// DashboardHandler serves the embedded web UI; mount it at /dashboard/
+func DashboardHandler() http.Handler {
+	files, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err) // the directory is embedded at build time
+	}
+	fileServer := http.StripPrefix("/dashboard/", http.FileServer(http.FS(files)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+// DashboardProvider is a provider row on the dashboard
+type DashboardProvider struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	BaseURL       string     `json:"base_url"`
+	Status        string     `json:"status"`
+	LastValidated *time.Time `json:"last_validated,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	Models        int        `json:"models"`
+	ModelsOnline  int        `json:"models_online"`
+	Keys          int        `json:"keys"`
+}
+
+// DashboardKey is an API key with its secret masked
+type DashboardKey struct {
+	ID            int        `json:"id"`
+	ProviderID    string     `json:"provider_id"`
+	Key           string     `json:"key"`
+	Tier          string     `json:"tier"`
+	Requests      int        `json:"requests"`
+	Tokens        int        `json:"tokens"`
+	Active        bool       `json:"active"`
+	Degraded      bool       `json:"degraded"`
+	DegradedUntil *time.Time `json:"degraded_until,omitempty"`
+	TenantID      *string    `json:"tenant_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// DashboardModel is a model row on the dashboard
+type DashboardModel struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Provider      string     `json:"provider"`
+	Family        string     `json:"family"`
+	Status        string     `json:"status"`
+	CostPer1MIn   *float64   `json:"cost_per_1m_in,omitempty"`
+	CostPer1MOut  *float64   `json:"cost_per_1m_out,omitempty"`
+	ContextWindow *int       `json:"context_window,omitempty"`
+	LastTested    *time.Time `json:"last_tested,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// UsagePoint is one bucket of a usage time series
+type UsagePoint struct {
+	Start     time.Time `json:"start"`
+	Requests  int       `json:"requests"`
+	TokensIn  int       `json:"tokens_in"`
+	TokensOut int       `json:"tokens_out"`
+	Cost      float64   `json:"cost"`
+	Failures  int       `json:"failures"`
+}
+
+// ModelUsageSummary is usage for one model over a window
+type ModelUsageSummary struct {
+	Model     string  `json:"model"`
+	Requests  int     `json:"requests"`
+	TokensIn  int     `json:"tokens_in"`
+	TokensOut int     `json:"tokens_out"`
+	Cost      float64 `json:"cost"`
+	Failures  int     `json:"failures"`
+}
+
+// HealthEvent is a validation or refresh run on a provider's timeline
+type HealthEvent struct {
+	Provider   string    `json:"-"`
+	Time       time.Time `json:"time"`
+	Task       string    `json:"task"`
+	Status     string    `json:"status"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// ProviderHealth is a provider's current status and recent events, oldest
+// first
+type ProviderHealth struct {
+	Provider  string         `json:"provider"`
+	Status    string         `json:"status"`
+	LastError string         `json:"last_error,omitempty"`
+	Events    []*HealthEvent `json:"events"`
+}
+
+// DashboardOverview summarizes the deployment for the dashboard landing page
+type DashboardOverview struct {
+	Providers       int     `json:"providers"`
+	ProvidersOnline int     `json:"providers_online"`
+	Models          int     `json:"models"`
+	ModelsOnline    int     `json:"models_online"`
+	Keys            int     `json:"keys"`
+	KeysActive      int     `json:"keys_active"`
+	KeysDegraded    int     `json:"keys_degraded"`
+	Requests24h     int     `json:"requests_24h"`
+	Tokens24h       int     `json:"tokens_24h"`
+	Cost24h         float64 `json:"cost_24h"`
+	Failures24h     int     `json:"failures_24h"`
+}
+
+// DashboardStore provides the data behind the dashboard
+type DashboardStore interface {
+	Providers() ([]*DashboardProvider, error)
+	Keys() ([]*DashboardKey, error)
+	Models() ([]*DashboardModel, error)
+	UsageSeries(since time.Time, bucket time.Duration) ([]*UsagePoint, error)
+	UsageByModel(since time.Time) ([]*ModelUsageSummary, error)
+	HealthEvents(since time.Time) ([]*HealthEvent, error)
+}
+
+// DashboardAPI handles the JSON endpoints behind the web dashboard
+type DashboardAPI struct {
+	store DashboardStore
+}
+
+// NewDashboardAPI creates a new DashboardAPI
+func NewDashboardAPI(store DashboardStore) *DashboardAPI {
+	return &DashboardAPI{store: store}
+}
+
+// HandleOverview handles GET /api/dashboard/overview
+func (a *DashboardAPI) HandleOverview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	providers, err := a.store.Providers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	keys, err := a.store.Keys()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	usage, err := a.store.UsageByModel(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	overview := DashboardOverview{Providers: len(providers), Keys: len(keys)}
+	for _, p := range providers {
+		if p.Status == "online" {
+			overview.ProvidersOnline++
+		}
+		overview.Models += p.Models
+		overview.ModelsOnline += p.ModelsOnline
+	}
+	for _, k := range keys {
+		if k.Degraded {
+			overview.KeysDegraded++
+		} else if k.Active {
+			overview.KeysActive++
+		}
+	}
+	for _, u := range usage {
+		overview.Requests24h += u.Requests
+		overview.Tokens24h += u.TokensIn + u.TokensOut
+		overview.Cost24h += u.Cost
+		overview.Failures24h += u.Failures
+	}
+
+	writeDashboardJSON(w, overview)
+}
+
+// HandleProviders handles GET /api/dashboard/providers
+func (a *DashboardAPI) HandleProviders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	providers, err := a.store.Providers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeDashboardJSON(w, map[string]interface{}{
+		"providers": providers,
+		"count":     len(providers),
+	})
+}
+
+// HandleKeys handles GET /api/dashboard/keys
+func (a *DashboardAPI) HandleKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	keys, err := a.store.Keys()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeDashboardJSON(w, map[string]interface{}{
+		"keys":  keys,
+		"count": len(keys),
+	})
+}
+
+// HandleModels handles GET /api/dashboard/models
+func (a *DashboardAPI) HandleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	models, err := a.store.Models()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeDashboardJSON(w, map[string]interface{}{
+		"models": models,
+		"count":  len(models),
+	})
+}
+
+// HandleUsage handles GET /api/dashboard/usage?days=7&bucket=hour|day
+// The bucket defaults to hour for windows up to two days and day otherwise.
+func (a *DashboardAPI) HandleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	days, ok := boundedInt(q.Get("days"), 7, 1, 90)
+	if !ok {
+		http.Error(w, "days must be between 1 and 90", http.StatusBadRequest)
+		return
+	}
+	bucketName := q.Get("bucket")
+	if bucketName == "" {
+		bucketName = "day"
+		if days <= 2 {
+			bucketName = "hour"
+		}
+	}
+	var bucket time.Duration
+	switch bucketName {
+	case "hour":
+		bucket = time.Hour
+	case "day":
+		bucket = 24 * time.Hour
+	default:
+		http.Error(w, "bucket must be hour or day", http.StatusBadRequest)
+		return
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -days)
+	series, err := a.store.UsageSeries(since, bucket)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	models, err := a.store.UsageByModel(since)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeDashboardJSON(w, map[string]interface{}{
+		"since":  since,
+		"bucket": bucketName,
+		"series": series,
+		"models": models,
+	})
+}
+
+// HandleHealth handles GET /api/dashboard/health?hours=24
+// Each provider's timeline holds its scheduled and manual task runs.
+func (a *DashboardAPI) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hours, ok := boundedInt(r.URL.Query().Get("hours"), 24, 1, 24*30)
+	if !ok {
+		http.Error(w, "hours must be between 1 and 720", http.StatusBadRequest)
+		return
+	}
+	since := time.Now().UTC().Add(-time.Duration(hours) * time.Hour)
+
+	providers, err := a.store.Providers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	events, err := a.store.HealthEvents(since)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	byProvider := make(map[string]*ProviderHealth)
+	timelines := []*ProviderHealth{}
+	for _, p := range providers {
+		h := &ProviderHealth{Provider: p.ID, Status: p.Status, LastError: p.LastError, Events: []*HealthEvent{}}
+		byProvider[p.ID] = h
+		timelines = append(timelines, h)
+	}
+	for _, e := range events {
+		h, ok := byProvider[e.Provider]
+		if !ok {
+			// Scheduled providers need not be in the providers table
+			h = &ProviderHealth{Provider: e.Provider, Status: "unknown", Events: []*HealthEvent{}}
+			byProvider[e.Provider] = h
+			timelines = append(timelines, h)
+		}
+		h.Events = append(h.Events, e)
+	}
+	for _, h := range timelines {
+		sort.Slice(h.Events, func(i, j int) bool { return h.Events[i].Time.Before(h.Events[j].Time) })
+	}
+	sort.Slice(timelines, func(i, j int) bool { return timelines[i].Provider < timelines[j].Provider })
+
+	writeDashboardJSON(w, map[string]interface{}{
+		"since":     since,
+		"providers": timelines,
+		"count":     len(timelines),
+	})
+}
+
+func writeDashboardJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// boundedInt parses an optional query parameter, returning def when it is
+// empty and false when it is invalid or outside [lo, hi]
+func boundedInt(s string, def, lo, hi int) (int, bool) {
+	if s == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < lo || n > hi {
+		return 0, false
+	}
+	return n, true
+}
+
+// maskKeyPrefix reduces a stored key prefix to its first few characters so
+// the dashboard never shows enough of a key to identify it
+func maskKeyPrefix(prefix *string) string {
+	const visible = 6
+	if prefix == nil {
+		return "••••"
+	}
+	p := strings.TrimSuffix(*prefix, "...")
+	if len(p) > visible {
+		p = p[:visible]
+	}
+	return p + "••••"
+}
@@ -0,0 +1,252 @@
+// modelscan dashboard: a dependency-free client for /api/dashboard/*.
+// All data is inserted with textContent so nothing from the API is parsed as
+// HTML.
+"use strict";
+
+const pages = ["overview", "providers", "keys", "models", "usage", "health"];
+const REFRESH_MS = 30000;
+
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [k, v] of Object.entries(attrs || {})) {
+    if (k === "class") node.className = v;
+    else node.setAttribute(k, v);
+  }
+  for (const child of children) {
+    if (child == null) continue;
+    node.append(child instanceof Node ? child : String(child));
+  }
+  return node;
+}
+
+function svg(tag, attrs) {
+  const node = document.createElementNS("http://www.w3.org/2000/svg", tag);
+  for (const [k, v] of Object.entries(attrs || {})) node.setAttribute(k, v);
+  return node;
+}
+
+async function getJSON(path) {
+  const resp = await fetch(path, { headers: { Accept: "application/json" } });
+  if (!resp.ok) throw new Error(`${path}: ${resp.status} ${(await resp.text()).trim()}`);
+  return resp.json();
+}
+
+const fmt = {
+  int: (n) => (n || 0).toLocaleString(),
+  usd: (n) => "$" + (n || 0).toFixed((n || 0) < 1 ? 4 : 2),
+  cost: (n) => (n == null ? "–" : "$" + n.toFixed(3)),
+  time: (t) => (t ? new Date(t).toLocaleString() : "–"),
+  ago: (t) => {
+    if (!t) return "never";
+    const s = (Date.now() - new Date(t).getTime()) / 1000;
+    if (s < 90) return Math.round(s) + "s ago";
+    if (s < 5400) return Math.round(s / 60) + "m ago";
+    if (s < 129600) return Math.round(s / 3600) + "h ago";
+    return Math.round(s / 86400) + "d ago";
+  },
+};
+
+function badge(status) {
+  return el("span", { class: "badge " + (status || "unknown") }, status || "unknown");
+}
+
+// table renders rows into a <table>; columns are [header, cell(row), className]
+function table(node, columns, rows, empty) {
+  node.replaceChildren();
+  const head = el("tr");
+  for (const [title, , cls] of columns) head.append(el("th", { class: cls || "" }, title));
+  node.append(el("thead", {}, head));
+  const body = el("tbody");
+  if (!rows.length) {
+    body.append(el("tr", {}, el("td", { colspan: columns.length, class: "note" }, empty || "Nothing to show")));
+  }
+  for (const row of rows) {
+    const tr = el("tr");
+    for (const [, cell, cls] of columns) tr.append(el("td", { class: cls || "" }, cell(row)));
+    body.append(tr);
+  }
+  node.append(body);
+}
+
+// barChart draws a bar per point; failures are stacked in red when present
+function barChart(node, points, value, failures, label) {
+  node.replaceChildren();
+  const w = 1000, h = 180, pad = { l: 48, r: 8, t: 8, b: 22 };
+  const chart = svg("svg", { viewBox: `0 0 ${w} ${h}`, preserveAspectRatio: "none" });
+  const max = Math.max(1e-9, ...points.map(value));
+  const bw = (w - pad.l - pad.r) / Math.max(points.length, 1);
+  const y = (v) => h - pad.b - (v / max) * (h - pad.t - pad.b);
+
+  for (const frac of [0, 0.5, 1]) {
+    const gy = y(max * frac);
+    chart.append(svg("line", { class: "grid", x1: pad.l, x2: w - pad.r, y1: gy, y2: gy }));
+    const t = svg("text", { class: "axis", x: pad.l - 6, y: gy + 3, "text-anchor": "end" });
+    t.textContent = label(max * frac);
+    chart.append(t);
+  }
+
+  points.forEach((p, i) => {
+    const x = pad.l + i * bw + bw * 0.1;
+    const v = value(p);
+    const bar = svg("rect", { class: "bar", x, y: y(v), width: bw * 0.8, height: h - pad.b - y(v) });
+    const title = svg("title");
+    title.textContent = `${fmt.time(p.start)}: ${label(v)}`;
+    bar.append(title);
+    chart.append(bar);
+    const f = failures ? failures(p) : 0;
+    if (f > 0) {
+      chart.append(svg("rect", { class: "bar fail", x, y: y(f), width: bw * 0.8, height: h - pad.b - y(f) }));
+    }
+  });
+
+  const step = Math.max(1, Math.ceil(points.length / 8));
+  points.forEach((p, i) => {
+    if (i % step) return;
+    const t = svg("text", { class: "axis", x: pad.l + i * bw + bw / 2, y: h - 6, "text-anchor": "middle" });
+    const d = new Date(p.start);
+    t.textContent = points.length > 1 && new Date(points[1].start) - new Date(points[0].start) < 86400000
+      ? d.getHours() + ":00"
+      : d.getMonth() + 1 + "/" + d.getDate();
+    chart.append(t);
+  });
+  node.append(chart);
+}
+
+const loaders = {
+  async overview() {
+    const [o, usage] = await Promise.all([
+      getJSON("/api/dashboard/overview"),
+      getJSON("/api/dashboard/usage?days=1&bucket=hour"),
+    ]);
+    const card = (label, value, sub) =>
+      el("div", { class: "card" }, el("div", { class: "label" }, label), el("div", { class: "value" }, value), el("div", { class: "sub" }, sub));
+    document.getElementById("overview-cards").replaceChildren(
+      card("Providers", fmt.int(o.providers), `${fmt.int(o.providers_online)} online`),
+      card("Models", fmt.int(o.models), `${fmt.int(o.models_online)} online`),
+      card("API keys", fmt.int(o.keys), `${fmt.int(o.keys_active)} active, ${fmt.int(o.keys_degraded)} degraded`),
+      card("Requests (24h)", fmt.int(o.requests_24h), `${fmt.int(o.failures_24h)} failed`),
+      card("Tokens (24h)", fmt.int(o.tokens_24h), ""),
+      card("Cost (24h)", fmt.usd(o.cost_24h), ""),
+    );
+    barChart(document.getElementById("overview-chart"), usage.series, (p) => p.requests, (p) => p.failures, (v) => fmt.int(Math.round(v)));
+  },
+
+  async providers() {
+    const { providers } = await getJSON("/api/dashboard/providers");
+    table(document.getElementById("providers-table"), [
+      ["Provider", (p) => p.name || p.id],
+      ["Status", (p) => badge(p.status)],
+      ["Models", (p) => `${p.models_online}/${p.models}`, "num"],
+      ["Keys", (p) => fmt.int(p.keys), "num"],
+      ["Last validated", (p) => fmt.ago(p.last_validated)],
+      ["Base URL", (p) => p.base_url, "mono"],
+      ["Last error", (p) => p.last_error || "", "err"],
+    ], providers, "No providers registered");
+  },
+
+  async keys() {
+    const { keys } = await getJSON("/api/dashboard/keys");
+    table(document.getElementById("keys-table"), [
+      ["ID", (k) => k.id, "num"],
+      ["Provider", (k) => k.provider_id],
+      ["Key", (k) => k.key, "mono"],
+      ["Tier", (k) => k.tier],
+      ["State", (k) => badge(k.degraded ? "degraded" : k.active ? "active" : "inactive")],
+      ["Requests", (k) => fmt.int(k.requests), "num"],
+      ["Tokens", (k) => fmt.int(k.tokens), "num"],
+      ["Degraded until", (k) => (k.degraded ? fmt.time(k.degraded_until) : "")],
+      ["Added", (k) => fmt.time(k.created_at)],
+    ], keys, "No API keys");
+  },
+
+  async models() {
+    const { models } = await getJSON("/api/dashboard/models");
+    const filter = document.getElementById("models-filter");
+    const render = () => {
+      const q = filter.value.trim().toLowerCase();
+      const rows = models.filter((m) => !q || [m.id, m.name, m.provider, m.family].some((s) => (s || "").toLowerCase().includes(q)));
+      table(document.getElementById("models-table"), [
+        ["Model", (m) => m.id, "mono"],
+        ["Provider", (m) => m.provider],
+        ["Family", (m) => m.family],
+        ["Status", (m) => badge(m.status)],
+        ["Context", (m) => (m.context_window ? fmt.int(m.context_window) : "–"), "num"],
+        ["In / 1M", (m) => fmt.cost(m.cost_per_1m_in), "num"],
+        ["Out / 1M", (m) => fmt.cost(m.cost_per_1m_out), "num"],
+        ["Last tested", (m) => fmt.ago(m.last_tested)],
+        ["Last error", (m) => m.last_error || "", "err"],
+      ], rows, "No models");
+    };
+    filter.oninput = render;
+    render();
+  },
+
+  async usage() {
+    const days = document.getElementById("usage-days").value;
+    const usage = await getJSON(`/api/dashboard/usage?days=${encodeURIComponent(days)}`);
+    barChart(document.getElementById("usage-requests"), usage.series, (p) => p.requests, (p) => p.failures, (v) => fmt.int(Math.round(v)));
+    barChart(document.getElementById("usage-cost"), usage.series, (p) => p.cost, null, (v) => fmt.usd(v));
+    table(document.getElementById("usage-models"), [
+      ["Model", (u) => u.model, "mono"],
+      ["Requests", (u) => fmt.int(u.requests), "num"],
+      ["Failed", (u) => fmt.int(u.failures), "num"],
+      ["Tokens in", (u) => fmt.int(u.tokens_in), "num"],
+      ["Tokens out", (u) => fmt.int(u.tokens_out), "num"],
+      ["Cost", (u) => fmt.usd(u.cost), "num"],
+    ], usage.models, "No usage in this window");
+  },
+
+  async health() {
+    const hours = document.getElementById("health-hours").value;
+    const health = await getJSON(`/api/dashboard/health?hours=${encodeURIComponent(hours)}`);
+    const start = new Date(health.since).getTime();
+    const span = Date.now() - start;
+    const container = document.getElementById("health-timelines");
+    container.replaceChildren();
+    if (!health.providers.length) container.append(el("p", { class: "note" }, "No providers registered"));
+    for (const p of health.providers) {
+      const track = el("div", { class: "track" });
+      for (const e of p.events) {
+        const left = Math.min(100, Math.max(0, ((new Date(e.time).getTime() - start) / span) * 100));
+        const mark = el("div", { class: "event " + e.status, style: `left:${left}%` });
+        mark.title = `${fmt.time(e.time)} ${e.task}: ${e.status} (${e.duration_ms}ms)${e.error ? "\n" + e.error : ""}`;
+        track.append(mark);
+      }
+      container.append(el("div", { class: "timeline" },
+        el("div", { class: "name" }, p.provider), badge(p.status), track,
+        el("span", { class: "note" }, `${p.events.length} runs`)));
+    }
+  },
+};
+
+let current = null;
+
+async function load() {
+  const error = document.getElementById("error");
+  try {
+    await loaders[current]();
+    error.hidden = true;
+    document.getElementById("updated").textContent = "Updated " + new Date().toLocaleTimeString();
+  } catch (err) {
+    error.textContent = err.message;
+    error.hidden = false;
+  }
+}
+
+function show() {
+  const page = location.hash.slice(1);
+  current = pages.includes(page) ? page : "overview";
+  for (const p of pages) {
+    document.getElementById("page-" + p).classList.toggle("active", p === current);
+  }
+  for (const a of document.querySelectorAll("header nav a")) {
+    a.classList.toggle("active", a.getAttribute("href") === "#" + current);
+  }
+  load();
+}
+
+document.getElementById("usage-days").onchange = load;
+document.getElementById("health-hours").onchange = load;
+window.addEventListener("hashchange", show);
+setInterval(() => { if (!document.hidden) load(); }, REFRESH_MS);
+show();
@@ -0,0 +1,215 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// mockDashboardStore implements DashboardStore for testing
+type mockDashboardStore struct {
+	lastBucket time.Duration
+}
+
+func (m *mockDashboardStore) Providers() ([]*DashboardProvider, error) {
+	return []*DashboardProvider{
+		{ID: "openai", Status: "online", Models: 3, ModelsOnline: 2, Keys: 2},
+		{ID: "groq", Status: "offline", Models: 1},
+	}, nil
+}
+
+func (m *mockDashboardStore) Keys() ([]*DashboardKey, error) {
+	return []*DashboardKey{
+		{ID: 1, ProviderID: "openai", Key: "sk-abc••••", Active: true},
+		{ID: 2, ProviderID: "openai", Key: "sk-def••••", Active: true, Degraded: true},
+	}, nil
+}
+
+func (m *mockDashboardStore) Models() ([]*DashboardModel, error) {
+	return []*DashboardModel{{ID: "gpt-4o", Provider: "openai", Status: "online"}}, nil
+}
+
+func (m *mockDashboardStore) UsageSeries(since time.Time, bucket time.Duration) ([]*UsagePoint, error) {
+	m.lastBucket = bucket
+	return []*UsagePoint{{Start: since, Requests: 4}}, nil
+}
+
+func (m *mockDashboardStore) UsageByModel(since time.Time) ([]*ModelUsageSummary, error) {
+	return []*ModelUsageSummary{
+		{Model: "gpt-4o", Requests: 10, TokensIn: 100, TokensOut: 50, Cost: 0.5, Failures: 1},
+		{Model: "gpt-4", Requests: 2, Cost: 1.25},
+	}, nil
+}
+
+func (m *mockDashboardStore) HealthEvents(since time.Time) ([]*HealthEvent, error) {
+	now := time.Now()
+	return []*HealthEvent{
+		{Provider: "openai", Time: now, Task: "validate", Status: "success"},
+		{Provider: "openai", Time: now.Add(-time.Hour), Task: "validate", Status: "failed", Error: "timeout"},
+		{Provider: "deepseek", Time: now, Task: "pricing", Status: "success"},
+	}, nil
+}
+
+func TestDashboardAPI_Overview(t *testing.T) {
+	api := NewDashboardAPI(&mockDashboardStore{})
+
+	rec := httptest.NewRecorder()
+	api.HandleOverview(rec, httptest.NewRequest(http.MethodGet, "/api/dashboard/overview", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var o DashboardOverview
+	json.NewDecoder(rec.Body).Decode(&o)
+	want := DashboardOverview{
+		Providers: 2, ProvidersOnline: 1, Models: 4, ModelsOnline: 2,
+		Keys: 2, KeysActive: 1, KeysDegraded: 1,
+		Requests24h: 12, Tokens24h: 150, Cost24h: 1.75, Failures24h: 1,
+	}
+	if o != want {
+		t.Errorf("overview = %+v, want %+v", o, want)
+	}
+}
+
+func TestDashboardAPI_Usage(t *testing.T) {
+	store := &mockDashboardStore{}
+	api := NewDashboardAPI(store)
+
+	tests := []struct {
+		query  string
+		code   int
+		bucket time.Duration
+	}{
+		{"", http.StatusOK, 24 * time.Hour},
+		{"?days=1", http.StatusOK, time.Hour},
+		{"?days=30&bucket=hour", http.StatusOK, time.Hour},
+		{"?days=0", http.StatusBadRequest, 0},
+		{"?days=365", http.StatusBadRequest, 0},
+		{"?bucket=minute", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		store.lastBucket = 0
+		rec := httptest.NewRecorder()
+		api.HandleUsage(rec, httptest.NewRequest(http.MethodGet, "/api/dashboard/usage"+tt.query, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%q: expected %d, got %d", tt.query, tt.code, rec.Code)
+			continue
+		}
+		if store.lastBucket != tt.bucket {
+			t.Errorf("%q: expected bucket %s, got %s", tt.query, tt.bucket, store.lastBucket)
+		}
+	}
+}
+
+func TestDashboardAPI_Health(t *testing.T) {
+	api := NewDashboardAPI(&mockDashboardStore{})
+
+	rec := httptest.NewRecorder()
+	api.HandleHealth(rec, httptest.NewRequest(http.MethodGet, "/api/dashboard/health?hours=48", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var resp struct {
+		Providers []ProviderHealth `json:"providers"`
+		Count     int              `json:"count"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+
+	// Providers without a row still get a timeline; events are oldest first
+	if resp.Count != 3 || resp.Providers[0].Provider != "deepseek" || resp.Providers[0].Status != "unknown" {
+		t.Fatalf("unexpected timelines %+v", resp.Providers)
+	}
+	openai := resp.Providers[2]
+	if len(openai.Events) != 2 || openai.Events[0].Status != "failed" || openai.Events[0].Error != "timeout" {
+		t.Errorf("unexpected openai timeline %+v", openai.Events)
+	}
+	if len(resp.Providers[1].Events) != 0 {
+		t.Errorf("expected an empty groq timeline")
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleHealth(rec, httptest.NewRequest(http.MethodGet, "/api/dashboard/health?hours=abc", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid hours, got %d", rec.Code)
+	}
+}
+
+func TestDashboardHandler(t *testing.T) {
+	api := NewAPI(Config{}, nil, nil, nil, nil)
+
+	for path, contentType := range map[string]string{
+		"/dashboard/":          "text/html",
+		"/dashboard/app.js":    "javascript",
+		"/dashboard/style.css": "text/css",
+	} {
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", path, rec.Code)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.Contains(ct, contentType) {
+			t.Errorf("%s: unexpected content type %q", path, ct)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+	if rec.Code != http.StatusTemporaryRedirect && rec.Code != http.StatusMovedPermanently {
+		t.Errorf("expected /dashboard to redirect, got %d", rec.Code)
+	}
+
+	// Data endpoints are unavailable until the API is configured
+	rec = httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/dashboard/overview", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a dashboard API, got %d", rec.Code)
+	}
+}
+
+func TestDatabaseDashboardAdapter(t *testing.T) {
+	db, err := database.Open(":memory:")
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	defer db.Close()
+
+	db.CreateProvider(&database.Provider{ID: "openai", Name: "OpenAI", BaseURL: "https://api.openai.com", AuthMethod: "bearer", PricingModel: "usage", Status: "online"})
+	db.CreateModelFamily(&database.ModelFamily{ID: "gpt", ProviderID: "openai", Name: "GPT"})
+	db.CreateModel(&database.Model{ID: "gpt-4o", FamilyID: "gpt", Name: "GPT-4o", Status: "online"})
+	db.CreateModel(&database.Model{ID: "gpt-4", FamilyID: "gpt", Name: "GPT-4", Status: "offline"})
+	db.CreateAPIKey("openai", "sk-proj-abcdefghijklmnop")
+	db.CreateScheduledRun(&database.ScheduledRun{Provider: "openai", Task: "validate", Trigger: "scheduled", Status: "success"})
+
+	adapter := NewDatabaseDashboardAdapter(db)
+
+	providers, err := adapter.Providers()
+	if err != nil || len(providers) != 1 {
+		t.Fatalf("Providers() = %v, %v", providers, err)
+	}
+	if p := providers[0]; p.Models != 2 || p.ModelsOnline != 1 || p.Keys != 1 {
+		t.Errorf("unexpected provider counts %+v", p)
+	}
+
+	keys, err := adapter.Keys()
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("Keys() = %v, %v", keys, err)
+	}
+	if keys[0].Key != "sk-pro••••" {
+		t.Errorf("expected a masked key, got %q", keys[0].Key)
+	}
+
+	models, _ := adapter.Models()
+	if len(models) != 2 || models[0].Provider != "openai" || models[0].Family != "GPT" {
+		t.Errorf("unexpected models %+v", models[0])
+	}
+
+	events, err := adapter.HealthEvents(time.Now().Add(-time.Hour))
+	if err != nil || len(events) != 1 || events[0].Provider != "openai" {
+		t.Errorf("HealthEvents() = %v, %v", events, err)
+	}
+}
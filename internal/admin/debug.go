@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+)
+
+// DebugConfig configures the profiling and runtime debug endpoints
+type DebugConfig struct {
+	// Token must be sent as "Authorization: Bearer <token>"; it is required
+	Token string
+	// Version and ConfigHash identify the running build and configuration
+	// in /debug/status
+	Version    string
+	ConfigHash string
+}
+
+// DebugAPI serves net/http/pprof, expvar and a runtime status report under
+// /debug/. Every request must carry the configured bearer token.
+type DebugAPI struct {
+	cfg     DebugConfig
+	started time.Time
+	mux     *http.ServeMux
+}
+
+// DebugStatus is the response for GET /debug/status
+type DebugStatus struct {
+	Version       string      `json:"version"`
+	ConfigHash    string      `json:"config_hash"`
+	PID           int         `json:"pid"`
+	UptimeSeconds int64       `json:"uptime_seconds"`
+	Goroutines    int         `json:"goroutines"`
+	Crashes       int64       `json:"crashes"` // Panics recovered since start
+	CPUs          int         `json:"cpus"`
+	GOMAXPROCS    int         `json:"gomaxprocs"`
+	Heap          DebugHeap   `json:"heap"`
+	GC            DebugGC     `json:"gc"`
+	Build         *DebugBuild `json:"build,omitempty"`
+}
+
+// DebugHeap reports heap usage in bytes
+type DebugHeap struct {
+	Alloc    uint64 `json:"alloc"`
+	InUse    uint64 `json:"in_use"`
+	Idle     uint64 `json:"idle"`
+	Released uint64 `json:"released"`
+	Sys      uint64 `json:"sys"`
+	Objects  uint64 `json:"objects"`
+}
+
+// DebugGC reports garbage collector activity
+type DebugGC struct {
+	Cycles       uint32     `json:"cycles"`
+	Forced       uint32     `json:"forced"`
+	NextTarget   uint64     `json:"next_target_bytes"`
+	PauseTotalMs float64    `json:"pause_total_ms"`
+	LastPauseMs  float64    `json:"last_pause_ms"`
+	LastRun      *time.Time `json:"last_run,omitempty"`
+	CPUFraction  float64    `json:"cpu_fraction"`
+}
+
+// DebugBuild reports how the binary was built
+type DebugBuild struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path"`
+	Module    string            `json:"module_version"`
+	Settings  map[string]string `json:"settings,omitempty"` // vcs.revision, -tags, GOOS...
+}
+
+// NewDebugAPI creates a new DebugAPI
+func NewDebugAPI(cfg DebugConfig) *DebugAPI {
+	a := &DebugAPI{cfg: cfg, started: time.Now(), mux: http.NewServeMux()}
+	a.mux.HandleFunc("/debug/pprof/", pprof.Index)
+	a.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	a.mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	a.mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	a.mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	a.mux.Handle("/debug/vars", expvar.Handler())
+	a.mux.HandleFunc("/debug/status", a.handleStatus)
+	return a
+}
+
+// ServeHTTP checks the bearer token and serves the debug endpoints
+func (a *DebugAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || a.cfg.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.cfg.Token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="modelscan debug"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	a.mux.ServeHTTP(w, r)
+}
+
+// handleStatus handles GET /debug/status
+func (a *DebugAPI) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a.status())
+}
+
+// status reads the runtime statistics. ReadMemStats briefly stops the
+// world, which is fine for an on-demand endpoint.
+func (a *DebugAPI) status() DebugStatus {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	st := DebugStatus{
+		Version:       a.cfg.Version,
+		ConfigHash:    a.cfg.ConfigHash,
+		PID:           os.Getpid(),
+		UptimeSeconds: int64(time.Since(a.started).Seconds()),
+		Goroutines:    runtime.NumGoroutine(),
+		Crashes:       crash.Count(),
+		CPUs:          runtime.NumCPU(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		Heap: DebugHeap{
+			Alloc:    m.HeapAlloc,
+			InUse:    m.HeapInuse,
+			Idle:     m.HeapIdle,
+			Released: m.HeapReleased,
+			Sys:      m.HeapSys,
+			Objects:  m.HeapObjects,
+		},
+		GC: DebugGC{
+			Cycles:       m.NumGC,
+			Forced:       m.NumForcedGC,
+			NextTarget:   m.NextGC,
+			PauseTotalMs: float64(m.PauseTotalNs) / 1e6,
+			CPUFraction:  m.GCCPUFraction,
+		},
+	}
+	if m.NumGC > 0 {
+		st.GC.LastPauseMs = float64(m.PauseNs[(m.NumGC+255)%256]) / 1e6
+		last := time.Unix(0, int64(m.LastGC))
+		st.GC.LastRun = &last
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		st.Build = &DebugBuild{GoVersion: info.GoVersion, Path: info.Path, Module: info.Main.Version}
+		if len(info.Settings) > 0 {
+			st.Build.Settings = make(map[string]string, len(info.Settings))
+			for _, s := range info.Settings {
+				st.Build.Settings[s.Key] = s.Value
+			}
+		}
+	}
+	return st
+}
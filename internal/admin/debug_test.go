@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugAPI(t *testing.T) {
+	api := NewAPI(Config{}, &mockDB{}, &mockDiscovery{}, &mockGenerator{}, &mockKeyManager{})
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Disabled: not found, even with a token
+	if rec := get("/debug/status", "secret"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 while disabled, got %d", rec.Code)
+	}
+
+	api.SetDebugAPI(NewDebugAPI(DebugConfig{Token: "secret", Version: "1.2.3", ConfigHash: "abc123"}))
+	for _, token := range []string{"", "wrong"} {
+		if rec := get("/debug/pprof/", token); rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("expected token %q to be refused, got %d", token, rec.Code)
+		}
+	}
+
+	rec := get("/debug/status", "secret")
+	var st DebugStatus
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status response %d: %v", rec.Code, err)
+	}
+	if st.Version != "1.2.3" || st.ConfigHash != "abc123" || st.Goroutines == 0 || st.Heap.Sys == 0 || st.Build == nil {
+		t.Errorf("unexpected status: %+v", st)
+	}
+
+	if rec := get("/debug/pprof/", "secret"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "goroutine") {
+		t.Errorf("expected the pprof index, got %d", rec.Code)
+	}
+	if rec := get("/debug/pprof/goroutine?debug=1", "secret"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "goroutine profile") {
+		t.Errorf("expected a goroutine profile, got %d", rec.Code)
+	}
+	rec = get("/debug/vars", "secret")
+	var vars map[string]json.RawMessage
+	if err := json.NewDecoder(rec.Body).Decode(&vars); err != nil || vars["memstats"] == nil {
+		t.Errorf("expected expvar output, got %d: %v", rec.Code, err)
+	}
+}
@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/deprecation"
+)
+
+// DeprecationAPI handles model retirement endpoints
+type DeprecationAPI struct {
+	store deprecation.Store
+	warn  time.Duration
+	now   func() time.Time
+}
+
+// NewDeprecationAPI creates a new DeprecationAPI. Aliases whose model
+// retires within warn are flagged (default deprecation.DefaultWarning).
+func NewDeprecationAPI(store deprecation.Store, warn time.Duration) *DeprecationAPI {
+	if warn <= 0 {
+		warn = deprecation.DefaultWarning
+	}
+	return &DeprecationAPI{store: store, warn: warn, now: time.Now}
+}
+
+// HandleDeprecations handles
+// GET /api/models/deprecations?provider=<id>&warn_days=30, listing every
+// tracked retirement soonest first with suggested replacements, and the
+// aliases pointing at retired or soon-retiring models
+func (a *DeprecationAPI) HandleDeprecations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	warn := a.warn
+	if q.Get("warn_days") != "" {
+		days, ok := boundedInt(q.Get("warn_days"), 0, 0, 366)
+		if !ok {
+			http.Error(w, "warn_days must be between 0 and 366", http.StatusBadRequest)
+			return
+		}
+		warn = time.Duration(days) * 24 * time.Hour
+	}
+
+	report, err := deprecation.Check(a.store, a.now(), warn)
+	if err != nil {
+		http.Error(w, "Failed to check model retirements: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if provider := q.Get("provider"); provider != "" {
+		deprecations := make([]*deprecation.Deprecation, 0, len(report.Deprecations))
+		for _, d := range report.Deprecations {
+			if d.ProviderID == provider {
+				deprecations = append(deprecations, d)
+			}
+		}
+		warnings := make([]*deprecation.AliasWarning, 0, len(report.Warnings))
+		for _, aw := range report.Warnings {
+			if aw.ProviderID == provider {
+				warnings = append(warnings, aw)
+			}
+		}
+		report.Deprecations, report.Warnings = deprecations, warnings
+	}
+
+	writeListWith(w, r, report.Deprecations, deprecationList, map[string]interface{}{
+		"warnings": report.Warnings,
+	})
+}
+
+// deprecationList pages the retirements of GET /api/models/deprecations.
+// The provider parameter also narrows the alias warnings, so it is not a
+// list filter.
+var deprecationList = listSpec[*deprecation.Deprecation]{
+	id: func(d *deprecation.Deprecation) string { return d.ProviderID + "/" + d.ModelID },
+	fields: map[string]listField[*deprecation.Deprecation]{
+		"provider_id": strField(func(d *deprecation.Deprecation) string { return d.ProviderID }),
+		"model_id":    strField(func(d *deprecation.Deprecation) string { return d.ModelID }),
+		"state":       strField(func(d *deprecation.Deprecation) string { return string(d.State) }),
+		"source":      strField(func(d *deprecation.Deprecation) string { return d.Source }),
+		"retires_at":  timeField(func(d *deprecation.Deprecation) time.Time { return d.RetiresAt }),
+	},
+	sort: "retires_at",
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/deprecation"
+)
+
+func TestDeprecationAPI_HandleDeprecations(t *testing.T) {
+	db := openBundleDB(t, "deprecations.db")
+	api := NewDeprecationAPI(db, 0)
+	// The default haiku alias points at a model retiring 2026-02-19
+	api.now = func() time.Time { return time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC) }
+
+	type response struct {
+		Deprecations []*deprecation.Deprecation  `json:"items"`
+		Warnings     []*deprecation.AliasWarning `json:"warnings"`
+	}
+	get := func(query string) (int, response) {
+		rec := httptest.NewRecorder()
+		api.HandleDeprecations(rec, httptest.NewRequest(http.MethodGet, "/api/models/deprecations"+query, nil))
+		var report response
+		json.NewDecoder(rec.Body).Decode(&report)
+		return rec.Code, report
+	}
+
+	code, report := get("?provider=anthropic")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(report.Deprecations) == 0 || len(report.Warnings) != 1 {
+		t.Fatalf("expected anthropic deprecations and one warning, got %+v", report)
+	}
+	haiku := report.Warnings[0]
+	if haiku.Alias != "haiku" || haiku.State != deprecation.StateRetiring || len(haiku.Suggestions) == 0 {
+		t.Errorf("unexpected haiku warning %+v", haiku)
+	}
+	for _, d := range report.Deprecations {
+		if d.ProviderID != "anthropic" {
+			t.Errorf("expected only anthropic deprecations, got %s", d.ProviderID)
+		}
+	}
+
+	// A shorter window leaves haiku scheduled
+	if _, report := get("?provider=anthropic&warn_days=7"); len(report.Warnings) != 0 {
+		t.Errorf("expected no warnings with a 7 day window, got %+v", report.Warnings)
+	}
+	if code, _ := get("?warn_days=999"); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an out of range window, got %d", code)
+	}
+}
@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jeffersonwarrior/modelscan/internal/diagnose"
+)
+
+// ProviderTester runs connection diagnostics against a stored provider,
+// returning a nil report when the provider does not exist
+type ProviderTester interface {
+	TestProvider(ctx context.Context, providerID, model string) (*diagnose.Report, error)
+}
+
+// DiagnosticsAPI handles provider test-connection endpoints
+type DiagnosticsAPI struct {
+	tester ProviderTester
+}
+
+// NewDiagnosticsAPI creates a new DiagnosticsAPI
+func NewDiagnosticsAPI(tester ProviderTester) *DiagnosticsAPI {
+	return &DiagnosticsAPI{tester: tester}
+}
+
+// TestRequest is the optional body of a test-connection request
+type TestRequest struct {
+	Model string `json:"model"` // Model for the completion (default the first one listed)
+}
+
+// HandleTest handles POST /api/providers/{id}/test. The report is returned
+// with 200 whether or not the provider passed; failed_stage names the
+// stage that failed.
+func (a *DiagnosticsAPI) HandleTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/providers/"), "/test")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	var req TestRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := a.tester.TestProvider(r.Context(), id, req.Model)
+	if err != nil {
+		http.Error(w, "Failed to test provider: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if report == nil {
+		http.Error(w, "Provider not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/diagnose"
+)
+
+// fakeTester knows one provider and records the model it was asked for
+type fakeTester struct {
+	model string
+}
+
+func (f *fakeTester) TestProvider(ctx context.Context, providerID, model string) (*diagnose.Report, error) {
+	if providerID != "groq" {
+		return nil, nil
+	}
+	f.model = model
+	return &diagnose.Report{Provider: providerID, FailedStage: diagnose.StageAuth}, nil
+}
+
+func TestDiagnosticsAPI_HandleTest(t *testing.T) {
+	tester := &fakeTester{}
+	api := NewDiagnosticsAPI(tester)
+
+	rec := httptest.NewRecorder()
+	api.HandleTest(rec, httptest.NewRequest(http.MethodPost, "/api/providers/groq/test", strings.NewReader(`{"model":"llama-3.3-70b"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var report diagnose.Report
+	json.NewDecoder(rec.Body).Decode(&report)
+	if report.Provider != "groq" || report.FailedStage != diagnose.StageAuth {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if tester.model != "llama-3.3-70b" {
+		t.Errorf("expected the requested model to be tested, got %q", tester.model)
+	}
+
+	// The body is optional
+	rec = httptest.NewRecorder()
+	api.HandleTest(rec, httptest.NewRequest(http.MethodPost, "/api/providers/groq/test", nil))
+	if rec.Code != http.StatusOK || tester.model != "" {
+		t.Errorf("expected 200 with no model, got %d and %q", rec.Code, tester.model)
+	}
+
+	tests := []struct {
+		method, path string
+		code         int
+	}{
+		{http.MethodPost, "/api/providers/missing/test", http.StatusNotFound},
+		{http.MethodPost, "/api/providers/groq", http.StatusNotFound},
+		{http.MethodGet, "/api/providers/groq/test", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		api.HandleTest(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.code, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jeffersonwarrior/modelscan/internal/cache"
+)
+
+// EmbeddingCache is the proxy's cache of embedded inputs
+type EmbeddingCache interface {
+	Stats() cache.Stats
+	Persistent() bool
+	MaxEntries() int
+	Purge() error
+}
+
+// EmbeddingCacheAPI handles embedding cache endpoints
+type EmbeddingCacheAPI struct {
+	cache EmbeddingCache
+}
+
+// NewEmbeddingCacheAPI creates a new EmbeddingCacheAPI
+func NewEmbeddingCacheAPI(c EmbeddingCache) *EmbeddingCacheAPI {
+	return &EmbeddingCacheAPI{cache: c}
+}
+
+// HandleEmbeddingCache handles GET /api/embeddings/cache, which reports the
+// hit rate by input, and DELETE /api/embeddings/cache, which drops every
+// cached vector, stored ones included
+func (a *EmbeddingCacheAPI) HandleEmbeddingCache(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"stats":       a.cache.Stats(),
+			"persistent":  a.cache.Persistent(),
+			"max_entries": a.cache.MaxEntries(),
+		})
+	case http.MethodDelete:
+		if err := a.cache.Purge(); err != nil {
+			http.Error(w, "Failed to purge embeddings: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/cache"
+)
+
+type fakeEmbeddingCache struct {
+	purges int
+	err    error
+}
+
+func (f *fakeEmbeddingCache) Stats() cache.Stats {
+	return cache.Stats{Name: "embeddings", Entries: 3, Hits: 6, Misses: 2, HitRate: 0.75}
+}
+
+func (f *fakeEmbeddingCache) Persistent() bool { return true }
+
+func (f *fakeEmbeddingCache) MaxEntries() int { return 10000 }
+
+func (f *fakeEmbeddingCache) Purge() error {
+	f.purges++
+	return f.err
+}
+
+func TestEmbeddingCacheAPI(t *testing.T) {
+	api := NewAPI(Config{}, &mockDB{}, &mockDiscovery{}, &mockGenerator{}, &mockKeyManager{})
+	do := func(method string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, httptest.NewRequest(method, "/api/embeddings/cache", nil))
+		return rec
+	}
+
+	if rec := do(http.MethodGet); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without the cache, got %d", rec.Code)
+	}
+
+	c := &fakeEmbeddingCache{}
+	api.SetEmbeddingCacheAPI(NewEmbeddingCacheAPI(c))
+	rec := do(http.MethodGet)
+	var resp struct {
+		Stats      cache.Stats `json:"stats"`
+		Persistent bool        `json:"persistent"`
+		MaxEntries int         `json:"max_entries"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d: %v", rec.Code, err)
+	}
+	if resp.Stats.HitRate != 0.75 || !resp.Persistent || resp.MaxEntries != 10000 {
+		t.Errorf("unexpected stats: %+v", resp)
+	}
+
+	if rec := do(http.MethodDelete); rec.Code != http.StatusNoContent || c.purges != 1 {
+		t.Errorf("expected the cache purged, got %d after %d purges", rec.Code, c.purges)
+	}
+	c.err = errors.New("database is locked")
+	if rec := do(http.MethodDelete); rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 when the purge fails, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", rec.Code)
+	}
+}
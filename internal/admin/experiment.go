@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// ExperimentStore manages A/B experiments and their results (usually a *proxy.Experiments)
+type ExperimentStore interface {
+	Experiments() []proxy.Experiment
+	Experiment(name string) (proxy.Experiment, bool)
+	SetExperiment(e proxy.Experiment) error
+	RemoveExperiment(name string) bool
+	Results(name string) (proxy.ExperimentResults, bool)
+}
+
+// ExperimentAPI handles A/B experiment endpoints
+type ExperimentAPI struct {
+	store ExperimentStore
+}
+
+// NewExperimentAPI creates a new ExperimentAPI
+func NewExperimentAPI(store ExperimentStore) *ExperimentAPI {
+	return &ExperimentAPI{store: store}
+}
+
+// experimentList pages GET /api/experiments, in the order experiments are
+// tried
+var experimentList = listSpec[proxy.Experiment]{
+	id: func(e proxy.Experiment) string { return e.Name },
+	fields: map[string]listField[proxy.Experiment]{
+		"name":     strField(func(e proxy.Experiment) string { return e.Name }),
+		"split_by": strField(func(e proxy.Experiment) string { return e.SplitBy }),
+		"percent":  numField(func(e proxy.Experiment) float64 { return e.Percent }),
+	},
+}
+
+// HandleExperiments handles GET /api/experiments (all experiments, in the
+// order they are tried) and POST /api/experiments (start an experiment,
+// replacing the one with the same name and its results)
+func (a *ExperimentAPI) HandleExperiments(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeList(w, r, a.store.Experiments(), experimentList)
+	case http.MethodPost:
+		var e proxy.Experiment
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := a.store.SetExperiment(e); err != nil {
+			http.Error(w, "Invalid experiment: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(e)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleExperiment handles GET /api/experiments/{name} (the experiment and
+// its results per variant) and DELETE /api/experiments/{name}
+func (a *ExperimentAPI) HandleExperiment(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/experiments/")
+	if name == "" {
+		http.Error(w, "Experiment name required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		e, ok := a.store.Experiment(name)
+		results, found := a.store.Results(name)
+		if !ok || !found {
+			http.Error(w, "Experiment not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"experiment": e,
+			"results":    results,
+		})
+	case http.MethodDelete:
+		if !a.store.RemoveExperiment(name) {
+			http.Error(w, "Experiment not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
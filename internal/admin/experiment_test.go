@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+func TestExperimentAPI(t *testing.T) {
+	api := NewExperimentAPI(proxy.NewExperiments(nil))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if strings.HasPrefix(path, "/api/experiments/") {
+			api.HandleExperiment(rec, req)
+		} else {
+			api.HandleExperiments(rec, req)
+		}
+		return rec
+	}
+
+	experiment := `{"name":"tuning","models":["gpt-4o*"],"percent":20,"variants":[{"name":"control"},{"name":"cooler","set":{"temperature":0.2}}]}`
+	if rec := do(http.MethodPost, "/api/experiments", experiment); rec.Code != http.StatusCreated {
+		t.Fatalf("POST expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/experiments", `{"name":"solo","variants":[{"name":"only"}]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid experiment expected 400, got %d", rec.Code)
+	}
+
+	rec := do(http.MethodGet, "/api/experiments", "")
+	var list struct {
+		Experiments []proxy.Experiment `json:"items"`
+		Total       int                `json:"total"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if list.Total != 1 || list.Experiments[0].Percent != 20 || len(list.Experiments[0].Variants) != 2 {
+		t.Errorf("unexpected experiments: %+v", list)
+	}
+
+	rec = do(http.MethodGet, "/api/experiments/tuning", "")
+	var got struct {
+		Experiment proxy.Experiment        `json:"experiment"`
+		Results    proxy.ExperimentResults `json:"results"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if got.Experiment.Name != "tuning" || len(got.Results.Variants) != 2 || got.Results.Variants[1].Variant != "cooler" {
+		t.Errorf("unexpected experiment: %+v", got)
+	}
+
+	if rec := do(http.MethodDelete, "/api/experiments/tuning", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/experiments/tuning", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET deleted experiment expected 404, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/api/experiments", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT expected 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/export"
+)
+
+// Exporter streams datasets row by row (usually a *database.DB)
+type Exporter interface {
+	ExportDatasets() []string
+	Export(dataset string, filter database.ExportFilter, w export.Writer) (int, error)
+}
+
+// ExportAPI handles dataset download endpoints
+type ExportAPI struct {
+	exporter Exporter
+}
+
+// NewExportAPI creates a new ExportAPI
+func NewExportAPI(exporter Exporter) *ExportAPI {
+	return &ExportAPI{exporter: exporter}
+}
+
+// HandleDatasets handles GET /api/export
+func (a *ExportAPI) HandleDatasets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeListWith(w, r, a.exporter.ExportDatasets(), nameList, map[string]interface{}{
+		"formats": export.Formats,
+	})
+}
+
+// HandleExport handles GET /api/export/{dataset}?format=...&since=...&until=...
+// The dataset is streamed as a file download; format defaults to csv.
+func (a *ExportAPI) HandleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dataset := strings.TrimPrefix(r.URL.Path, "/api/export/")
+	if !slices.Contains(a.exporter.ExportDatasets(), dataset) {
+		http.Error(w, fmt.Sprintf("Unknown dataset %q", dataset), http.StatusNotFound)
+		return
+	}
+
+	q := r.URL.Query()
+	format := export.FormatCSV
+	if f := q.Get("format"); f != "" {
+		parsed, err := export.ParseFormat(f)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		format = parsed
+	}
+
+	var filter database.ExportFilter
+	for param, dst := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(param); v != "" {
+			t, err := export.ParseTime(v)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			*dst = &t
+		}
+	}
+
+	ew, err := export.NewWriter(format, w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", dataset, time.Now().UTC().Format("20060102"), format.Extension())
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Once rows are flowing the status is sent; a later failure can only
+	// truncate the download
+	n, err := a.exporter.Export(dataset, filter, ew)
+	if err == nil {
+		err = ew.Close()
+	}
+	if err != nil {
+		log.Printf("Export of %s failed after %d rows: %v", dataset, n, err)
+	}
+}
@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/export"
+)
+
+// mockExporter implements Exporter for testing
+type mockExporter struct {
+	lastFilter database.ExportFilter
+}
+
+func (m *mockExporter) ExportDatasets() []string {
+	return []string{"models", "usage"}
+}
+
+func (m *mockExporter) Export(dataset string, filter database.ExportFilter, w export.Writer) (int, error) {
+	m.lastFilter = filter
+	w.WriteHeader([]string{"dataset", "n"})
+	for i := 0; i < 3; i++ {
+		if err := w.WriteRow([]interface{}{dataset, int64(i)}); err != nil {
+			return i, err
+		}
+	}
+	return 3, nil
+}
+
+func TestExportAPI_Datasets(t *testing.T) {
+	api := NewExportAPI(&mockExporter{})
+
+	rec := httptest.NewRecorder()
+	api.HandleDatasets(rec, httptest.NewRequest(http.MethodGet, "/api/export", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var resp struct {
+		Datasets []string `json:"datasets"`
+		Formats  []string `json:"formats"`
+		Total    int      `json:"total"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Total != 2 || len(resp.Formats) != 3 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+func TestExportAPI_Export(t *testing.T) {
+	exporter := &mockExporter{}
+	api := NewExportAPI(exporter)
+
+	rec := httptest.NewRecorder()
+	api.HandleExport(rec, httptest.NewRequest(http.MethodGet, "/api/export/usage?format=jsonl&since=2026-03-01", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, `attachment; filename="usage-`) || !strings.HasSuffix(cd, `.jsonl"`) {
+		t.Errorf("unexpected content disposition %q", cd)
+	}
+	if lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n"); len(lines) != 3 || lines[0] != `{"dataset":"usage","n":0}` {
+		t.Errorf("unexpected body %q", rec.Body.String())
+	}
+	if exporter.lastFilter.Since == nil || exporter.lastFilter.Since.Format("2006-01-02") != "2026-03-01" || exporter.lastFilter.Until != nil {
+		t.Errorf("unexpected filter %+v", exporter.lastFilter)
+	}
+
+	// CSV is the default format
+	rec = httptest.NewRecorder()
+	api.HandleExport(rec, httptest.NewRequest(http.MethodGet, "/api/export/models", nil))
+	if !strings.HasPrefix(rec.Body.String(), "dataset,n\nmodels,0\n") {
+		t.Errorf("unexpected CSV body %q", rec.Body.String())
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		code   int
+	}{
+		{"unknown dataset", http.MethodGet, "/api/export/secrets", http.StatusNotFound},
+		{"unknown format", http.MethodGet, "/api/export/usage?format=xml", http.StatusBadRequest},
+		{"invalid since", http.MethodGet, "/api/export/usage?since=yesterday", http.StatusBadRequest},
+		{"wrong method", http.MethodPost, "/api/export/usage", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			api.HandleExport(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.code {
+				t.Errorf("expected %d, got %d", tt.code, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// FallbackChainStore manages model fallback chains (usually a *proxy.FallbackChains)
+type FallbackChainStore interface {
+	Chains() []proxy.FallbackChain
+	Chain(model string) (proxy.FallbackChain, bool)
+	SetChain(c proxy.FallbackChain) error
+	RemoveChain(model string) bool
+}
+
+// FallbackAPI handles fallback chain endpoints
+type FallbackAPI struct {
+	store FallbackChainStore
+}
+
+// NewFallbackAPI creates a new FallbackAPI
+func NewFallbackAPI(store FallbackChainStore) *FallbackAPI {
+	return &FallbackAPI{store: store}
+}
+
+// fallbackList pages GET /api/fallbacks
+var fallbackList = listSpec[proxy.FallbackChain]{
+	id: func(c proxy.FallbackChain) string { return c.Model },
+	fields: map[string]listField[proxy.FallbackChain]{
+		"model": strField(func(c proxy.FallbackChain) string { return c.Model }),
+		"steps": intField(func(c proxy.FallbackChain) int { return len(c.Steps) }),
+	},
+	sort: "model",
+}
+
+// HandleFallbacks handles GET /api/fallbacks (all chains) and
+// POST /api/fallbacks (add or replace the chain for a model)
+func (a *FallbackAPI) HandleFallbacks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeList(w, r, a.store.Chains(), fallbackList)
+	case http.MethodPost:
+		var chain proxy.FallbackChain
+		if err := json.NewDecoder(r.Body).Decode(&chain); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := a.store.SetChain(chain); err != nil {
+			http.Error(w, "Invalid fallback chain: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(chain)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleFallbackModel handles GET and DELETE /api/fallbacks/{model}.
+// Model names may contain slashes.
+func (a *FallbackAPI) HandleFallbackModel(w http.ResponseWriter, r *http.Request) {
+	model := strings.TrimPrefix(r.URL.Path, "/api/fallbacks/")
+	if model == "" {
+		http.Error(w, "Model required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		chain, ok := a.store.Chain(model)
+		if !ok {
+			http.Error(w, "Fallback chain not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(chain)
+	case http.MethodDelete:
+		if !a.store.RemoveChain(model) {
+			http.Error(w, "Fallback chain not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+func TestFallbackAPI(t *testing.T) {
+	api := NewFallbackAPI(proxy.NewFallbackChains())
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if strings.HasPrefix(path, "/api/fallbacks/") {
+			api.HandleFallbackModel(rec, req)
+		} else {
+			api.HandleFallbacks(rec, req)
+		}
+		return rec
+	}
+
+	chain := `{"model":"gpt-4o","steps":[{"provider":"openai"},{"provider":"groq","model":"llama-3.1-70b","max_tokens":1024,"drop_images":true}]}`
+	if rec := do(http.MethodPost, "/api/fallbacks", chain); rec.Code != http.StatusCreated {
+		t.Fatalf("POST expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/fallbacks", `{"model":"gpt-4o","steps":[{"model":"x"}]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid chain expected 400, got %d", rec.Code)
+	}
+	do(http.MethodPost, "/api/fallbacks", `{"model":"meta-llama/llama-3-8b","steps":[{"provider":"together"}]}`)
+
+	rec := do(http.MethodGet, "/api/fallbacks", "")
+	var resp struct {
+		Chains []proxy.FallbackChain `json:"items"`
+		Total  int                   `json:"total"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Total != 2 || resp.Chains[0].Model != "gpt-4o" || resp.Chains[0].Steps[1].MaxTokens != 1024 {
+		t.Errorf("unexpected chains: %+v", resp)
+	}
+
+	if rec := do(http.MethodGet, "/api/fallbacks/meta-llama/llama-3-8b", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "together") {
+		t.Errorf("GET model expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodDelete, "/api/fallbacks/gpt-4o", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/fallbacks/gpt-4o", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET deleted chain expected 404, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/api/fallbacks", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT expected 405, got %d", rec.Code)
+	}
+}
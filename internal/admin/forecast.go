@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/forecast"
+)
+
+// ForecastSource projects month-end spend and lists budget warnings
+type ForecastSource interface {
+	Report(now time.Time, method string) (*forecast.Report, error)
+	Recent() []forecast.Forecast
+}
+
+// ForecastAPI handles spend forecasting endpoints
+type ForecastAPI struct {
+	source ForecastSource
+}
+
+// NewForecastAPI creates a new ForecastAPI
+func NewForecastAPI(source ForecastSource) *ForecastAPI {
+	return &ForecastAPI{source: source}
+}
+
+// HandleForecast handles GET /api/forecast?method=run_rate|linear&dimension=provider|client|tenant&key=<id>&at_risk=true:
+// each month-end projection and the recent budget warnings, newest first
+func (a *ForecastAPI) HandleForecast(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	method, dimension, key := q.Get("method"), q.Get("dimension"), q.Get("key")
+	if method != "" && !forecast.ValidMethod(method) {
+		http.Error(w, "method must be run_rate or linear", http.StatusBadRequest)
+		return
+	}
+	if dimension != "" && dimension != forecast.DimensionProvider && dimension != forecast.DimensionClient && dimension != forecast.DimensionTenant {
+		http.Error(w, "dimension must be provider, client or tenant", http.StatusBadRequest)
+		return
+	}
+	atRisk := q.Get("at_risk") == "true"
+
+	report, err := a.source.Report(time.Now(), method)
+	if err != nil {
+		http.Error(w, "Failed to forecast spend: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	forecasts := []forecast.Forecast{}
+	for _, fc := range report.Forecasts {
+		if (dimension != "" && fc.Dimension != dimension) || (key != "" && fc.Key != key) || (atRisk && !fc.AtRisk) {
+			continue
+		}
+		forecasts = append(forecasts, fc)
+	}
+
+	writeListWith(w, r, forecasts, forecastList, map[string]interface{}{
+		"method":        report.Method,
+		"month":         report.Month,
+		"at":            report.At,
+		"days_elapsed":  report.DaysElapsed,
+		"days_in_month": report.DaysInMonth,
+		"warnings":      a.source.Recent(),
+	})
+}
+
+// forecastList pages the projections of GET /api/forecast, highest first
+var forecastList = listSpec[forecast.Forecast]{
+	id: func(f forecast.Forecast) string { return f.Dimension + "/" + f.Key },
+	fields: map[string]listField[forecast.Forecast]{
+		"month_to_date": numField(func(f forecast.Forecast) float64 { return f.MonthToDate }),
+		"daily_rate":    numField(func(f forecast.Forecast) float64 { return f.DailyRate }),
+		"projected":     numField(func(f forecast.Forecast) float64 { return f.Projected }),
+		"budget":        numField(func(f forecast.Forecast) float64 { return f.Budget }),
+		"exceeded":      boolField(func(f forecast.Forecast) bool { return f.Exceeded }),
+	},
+	sort: "-projected",
+}
@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/forecast"
+)
+
+type fakeForecaster struct {
+	method string
+	err    error
+}
+
+func (f *fakeForecaster) Report(now time.Time, method string) (*forecast.Report, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.method = method
+	return &forecast.Report{Method: forecast.MethodLinear, DaysInMonth: 31, Forecasts: []forecast.Forecast{
+		{Dimension: forecast.DimensionProvider, Key: "openai", Projected: 310},
+		{Dimension: forecast.DimensionClient, Key: "cli-a", Projected: 496, Budget: 300, AtRisk: true},
+		{Dimension: forecast.DimensionClient, Key: "cli-b", Projected: 20},
+	}}, nil
+}
+
+func (f *fakeForecaster) Recent() []forecast.Forecast {
+	return []forecast.Forecast{{Dimension: forecast.DimensionClient, Key: "cli-a", AtRisk: true}}
+}
+
+func TestForecastAPI(t *testing.T) {
+	api := NewAPI(Config{}, &mockDB{}, &mockDiscovery{}, &mockGenerator{}, &mockKeyManager{})
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/forecast", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without forecasting, got %d", rec.Code)
+	}
+
+	source := &fakeForecaster{}
+	api.SetForecastAPI(NewForecastAPI(source))
+	get := func(path string) (int, map[string]json.RawMessage) {
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]json.RawMessage
+		json.NewDecoder(rec.Body).Decode(&body)
+		return rec.Code, body
+	}
+
+	code, body := get("/api/forecast")
+	var forecasts []forecast.Forecast
+	if err := json.Unmarshal(body["items"], &forecasts); err != nil || code != http.StatusOK {
+		t.Fatalf("unexpected response %d: %v", code, err)
+	}
+	var daysInMonth int
+	json.Unmarshal(body["days_in_month"], &daysInMonth)
+	var warnings []forecast.Forecast
+	json.Unmarshal(body["warnings"], &warnings)
+	if len(forecasts) != 3 || daysInMonth != 31 || len(warnings) != 1 {
+		t.Errorf("unexpected forecast response: %+v, %+v", forecasts, warnings)
+	}
+
+	code, body = get("/api/forecast?method=run_rate&dimension=client&at_risk=true")
+	forecasts = nil
+	json.Unmarshal(body["items"], &forecasts)
+	if code != http.StatusOK || source.method != forecast.MethodRunRate || len(forecasts) != 1 || forecasts[0].Key != "cli-a" {
+		t.Errorf("expected only cli-a at risk, got %d %+v", code, forecasts)
+	}
+
+	if code, _ := get("/api/forecast?method=exponential"); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown method, got %d", code)
+	}
+	if code, _ := get("/api/forecast?dimension=model"); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown dimension, got %d", code)
+	}
+
+	source.err = errors.New("database is locked")
+	if code, _ := get("/api/forecast"); code != http.StatusInternalServerError {
+		t.Errorf("expected 500 when the ledger cannot be read, got %d", code)
+	}
+
+	rec = httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/forecast", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", rec.Code)
+	}
+}
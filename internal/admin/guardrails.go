@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/jeffersonwarrior/modelscan/internal/guardrail"
+)
+
+// GuardrailStatsSource reports how often guardrail rules were triggered
+type GuardrailStatsSource interface {
+	Stats() []guardrail.RuleStats
+}
+
+// GuardrailAPI exposes guardrail metrics
+type GuardrailAPI struct {
+	source GuardrailStatsSource
+}
+
+// NewGuardrailAPI creates a new GuardrailAPI
+func NewGuardrailAPI(source GuardrailStatsSource) *GuardrailAPI {
+	return &GuardrailAPI{source: source}
+}
+
+// HandleGuardrailStats handles GET /api/guardrails/stats: how often each
+// rule fired, and hits, the sum across rules
+func (a *GuardrailAPI) HandleGuardrailStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats := a.source.Stats()
+	var hits int64
+	for _, s := range stats {
+		hits += s.Count
+	}
+	writeListWith(w, r, stats, guardrailStatsList, map[string]interface{}{
+		"hits": hits,
+	})
+}
+
+// guardrailStatsList pages GET /api/guardrails/stats
+var guardrailStatsList = listSpec[guardrail.RuleStats]{
+	id: func(s guardrail.RuleStats) string { return s.Rule + "/" + string(s.Direction) },
+	fields: map[string]listField[guardrail.RuleStats]{
+		"rule":      strField(func(s guardrail.RuleStats) string { return s.Rule }),
+		"detector":  strField(func(s guardrail.RuleStats) string { return s.Detector }),
+		"direction": strField(func(s guardrail.RuleStats) string { return string(s.Direction) }),
+		"action":    strField(func(s guardrail.RuleStats) string { return string(s.Action) }),
+		"count":     numField(func(s guardrail.RuleStats) float64 { return float64(s.Count) }),
+	},
+	sort: "rule",
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/guardrail"
+)
+
+type mockGuardrailStats struct {
+	stats []guardrail.RuleStats
+}
+
+func (m *mockGuardrailStats) Stats() []guardrail.RuleStats {
+	return m.stats
+}
+
+func TestGuardrailAPI_HandleGuardrailStats(t *testing.T) {
+	api := NewGuardrailAPI(&mockGuardrailStats{stats: []guardrail.RuleStats{
+		{Rule: "pii", Detector: "email", Direction: guardrail.Request, Action: guardrail.ActionRedact, Count: 3},
+		{Rule: "secrets", Detector: "api_key", Direction: guardrail.Request, Action: guardrail.ActionBlock, Count: 1},
+	}})
+
+	rec := httptest.NewRecorder()
+	api.HandleGuardrailStats(rec, httptest.NewRequest(http.MethodGet, "/api/guardrails/stats", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var resp struct {
+		Rules []guardrail.RuleStats `json:"items"`
+		Total int                   `json:"total"`
+		Hits  int64                 `json:"hits"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Total != 2 || resp.Hits != 4 || resp.Rules[0].Rule != "pii" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleGuardrailStats(rec, httptest.NewRequest(http.MethodPost, "/api/guardrails/stats", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
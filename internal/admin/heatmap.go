@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/heatmap"
+)
+
+// LatencyHeatmapper aggregates recorded provider latency into heatmaps
+type LatencyHeatmapper interface {
+	Heatmaps(since time.Time, provider string, loc *time.Location) ([]*heatmap.Heatmap, error)
+}
+
+// HeatmapAPI handles provider latency heatmap endpoints
+type HeatmapAPI struct {
+	heatmaps LatencyHeatmapper
+	now      func() time.Time
+}
+
+// NewHeatmapAPI creates a new HeatmapAPI
+func NewHeatmapAPI(heatmaps LatencyHeatmapper) *HeatmapAPI {
+	return &HeatmapAPI{heatmaps: heatmaps, now: time.Now}
+}
+
+// HandleHeatmap handles GET /api/latency/heatmap?days=28&provider=<id>&tz=<zone>.
+// Cells are days of the week and hours of the day in the tz time zone, an
+// IANA name such as Europe/Berlin (default UTC).
+func (a *HeatmapAPI) HandleHeatmap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	days, ok := boundedInt(q.Get("days"), 28, 1, 366)
+	if !ok {
+		http.Error(w, "days must be between 1 and 366", http.StatusBadRequest)
+		return
+	}
+	loc := time.UTC
+	if tz := q.Get("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			http.Error(w, "Unknown time zone: "+tz, http.StatusBadRequest)
+			return
+		}
+	}
+
+	since := a.now().UTC().Truncate(time.Hour).AddDate(0, 0, -days)
+	maps, err := a.heatmaps.Heatmaps(since, q.Get("provider"), loc)
+	if err != nil {
+		http.Error(w, "Failed to build latency heatmap: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeListWith(w, r, maps, heatmapList, map[string]interface{}{
+		"since":     since,
+		"time_zone": loc.String(),
+	})
+}
+
+// heatmapList pages GET /api/latency/heatmap
+var heatmapList = listSpec[*heatmap.Heatmap]{
+	id: func(h *heatmap.Heatmap) string { return h.Provider },
+	fields: map[string]listField[*heatmap.Heatmap]{
+		"provider":       strField(func(h *heatmap.Heatmap) string { return h.Provider }),
+		"samples":        numField(func(h *heatmap.Heatmap) float64 { return float64(h.Samples) }),
+		"errors":         numField(func(h *heatmap.Heatmap) float64 { return float64(h.Errors) }),
+		"avg_latency_ms": numField(func(h *heatmap.Heatmap) float64 { return h.AvgLatencyMs }),
+	},
+	sort: "provider",
+}
@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/heatmap"
+)
+
+type fakeHeatmapper struct {
+	since    time.Time
+	provider string
+	loc      *time.Location
+}
+
+func (f *fakeHeatmapper) Heatmaps(since time.Time, provider string, loc *time.Location) ([]*heatmap.Heatmap, error) {
+	f.since, f.provider, f.loc = since, provider, loc
+	return []*heatmap.Heatmap{{Provider: "openai", Samples: 40, AvgLatencyMs: 640}}, nil
+}
+
+func TestHeatmapAPI(t *testing.T) {
+	heatmaps := &fakeHeatmapper{}
+	api := NewHeatmapAPI(heatmaps)
+	api.now = func() time.Time { return time.Date(2026, 3, 5, 15, 40, 0, 0, time.UTC) }
+
+	rec := httptest.NewRecorder()
+	api.HandleHeatmap(rec, httptest.NewRequest(http.MethodGet, "/api/latency/heatmap?days=7&provider=openai&tz=America/New_York", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if heatmaps.provider != "openai" || heatmaps.loc.String() != "America/New_York" || !heatmaps.since.Equal(time.Date(2026, 2, 26, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected heatmap query: %+v", heatmaps)
+	}
+	var resp struct {
+		TimeZone  string             `json:"time_zone"`
+		Providers []*heatmap.Heatmap `json:"items"`
+		Total     int                `json:"total"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Total != 1 || resp.TimeZone != "America/New_York" || resp.Providers[0].AvgLatencyMs != 640 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	// The default window is four weeks of UTC hours
+	rec = httptest.NewRecorder()
+	api.HandleHeatmap(rec, httptest.NewRequest(http.MethodGet, "/api/latency/heatmap", nil))
+	if rec.Code != http.StatusOK || heatmaps.loc != time.UTC || !heatmaps.since.Equal(time.Date(2026, 2, 5, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected default query: %d %+v", rec.Code, heatmaps)
+	}
+
+	for _, q := range []string{"days=0", "days=abc", "tz=Mars/Olympus"} {
+		rec = httptest.NewRecorder()
+		api.HandleHeatmap(rec, httptest.NewRequest(http.MethodGet, "/api/latency/heatmap?"+q, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/keyhealth"
+)
+
+// KeyHealthStore holds keys, their probe results and expiry dates
+type KeyHealthStore interface {
+	keyhealth.Store
+	GetAPIKey(id int) (*database.APIKey, error)
+	SetAPIKeyExpiry(id int, expiresAt *time.Time) error
+}
+
+// KeyHealthAPI handles API key health endpoints
+type KeyHealthAPI struct {
+	store KeyHealthStore
+	warn  time.Duration
+	now   func() time.Time
+}
+
+// NewKeyHealthAPI creates a new KeyHealthAPI. Keys expiring within warn are
+// flagged (default keyhealth.DefaultExpiryWarning).
+func NewKeyHealthAPI(store KeyHealthStore, warn time.Duration) *KeyHealthAPI {
+	if warn <= 0 {
+		warn = keyhealth.DefaultExpiryWarning
+	}
+	return &KeyHealthAPI{store: store, warn: warn, now: time.Now}
+}
+
+// HandleHealth handles GET /api/keys/health?provider=<id>&warn_days=7,
+// listing every key most urgent first with the number needing attention
+func (a *KeyHealthAPI) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	warn := a.warn
+	if q.Get("warn_days") != "" {
+		days, ok := boundedInt(q.Get("warn_days"), 0, 0, 366)
+		if !ok {
+			http.Error(w, "warn_days must be between 0 and 366", http.StatusBadRequest)
+			return
+		}
+		warn = time.Duration(days) * 24 * time.Hour
+	}
+
+	report, err := keyhealth.Report(a.store, a.now(), warn)
+	if err != nil {
+		http.Error(w, "Failed to build key health report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	keys := make([]*keyhealth.Health, 0, len(report))
+	warnings := 0
+	for _, h := range report {
+		if provider := q.Get("provider"); provider != "" && h.ProviderID != provider {
+			continue
+		}
+		if h.Warning != "" {
+			warnings++
+		}
+		keys = append(keys, h)
+	}
+
+	writeListWith(w, r, keys, keyHealthList, map[string]interface{}{
+		"warnings": warnings,
+	})
+}
+
+// keyHealthList pages GET /api/keys/health, most urgent first
+var keyHealthList = listSpec[*keyhealth.Health]{
+	id: func(h *keyhealth.Health) string { return strconv.Itoa(h.KeyID) },
+	fields: map[string]listField[*keyhealth.Health]{
+		"key_id":      intField(func(h *keyhealth.Health) int { return h.KeyID }),
+		"provider_id": strField(func(h *keyhealth.Health) string { return h.ProviderID }),
+		"state":       strField(func(h *keyhealth.Health) string { return string(h.State) }),
+		"degraded":    boolField(func(h *keyhealth.Health) bool { return h.Degraded }),
+		"failures":    intField(func(h *keyhealth.Health) int { return h.Failures }),
+	},
+}
+
+// HandleExpiry handles PUT /api/keys/{id}/expiry with
+// {"expires_at": "2026-12-31T00:00:00Z"}; a null or missing expires_at
+// clears it
+func (a *KeyHealthAPI) HandleExpiry(w http.ResponseWriter, r *http.Request, keyID int) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	key, err := a.store.GetAPIKey(keyID)
+	if err != nil {
+		http.Error(w, "Failed to get key: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if key == nil {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	}
+	if err := a.store.SetAPIKeyExpiry(keyID, req.ExpiresAt); err != nil {
+		http.Error(w, "Failed to set key expiry: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key_id":     keyID,
+		"expires_at": req.ExpiresAt,
+	})
+}
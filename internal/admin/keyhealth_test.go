@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/keyhealth"
+)
+
+func TestKeyHealthAPI(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "keys.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	for _, id := range []string{"openai", "anthropic"} {
+		if err := db.CreateProvider(&database.Provider{ID: id, Name: id, BaseURL: "https://" + id, Status: "online"}); err != nil {
+			t.Fatalf("CreateProvider failed: %v", err)
+		}
+	}
+	openai, _ := db.CreateAPIKey("openai", "sk-openai-0123456789")
+	anthropic, _ := db.CreateAPIKey("anthropic", "sk-ant-0123456789")
+	now := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	if err := db.RecordKeyProbe(&database.KeyProbe{KeyID: anthropic.ID, At: now, OK: true, Status: 200}); err != nil {
+		t.Fatalf("RecordKeyProbe failed: %v", err)
+	}
+
+	api := NewKeyHealthAPI(db, 0)
+	api.now = func() time.Time { return now }
+
+	// Set an expiry ten days out
+	rec := httptest.NewRecorder()
+	body := `{"expires_at": "2026-07-11T00:00:00Z"}`
+	api.HandleExpiry(rec, httptest.NewRequest(http.MethodPut, "/api/keys/1/expiry", strings.NewReader(body)), openai.ID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	get := func(query string) (int, []*keyhealth.Health, int) {
+		rec := httptest.NewRecorder()
+		api.HandleHealth(rec, httptest.NewRequest(http.MethodGet, "/api/keys/health"+query, nil))
+		var resp struct {
+			Keys     []*keyhealth.Health `json:"items"`
+			Warnings int                 `json:"warnings"`
+		}
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return rec.Code, resp.Keys, resp.Warnings
+	}
+
+	code, keys, warnings := get("")
+	if code != http.StatusOK || len(keys) != 2 || warnings != 0 {
+		t.Fatalf("unexpected report: %d %d keys, %d warnings", code, len(keys), warnings)
+	}
+	if code, keys, warnings = get("?warn_days=14&provider=openai"); len(keys) != 1 || keys[0].State != keyhealth.StateExpiring || warnings != 1 {
+		t.Errorf("expected an expiry warning, got %d %+v", code, keys)
+	}
+	if code, _, _ := get("?warn_days=-1"); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a negative window, got %d", code)
+	}
+
+	for _, tt := range []struct {
+		method, body string
+		keyID, want  int
+	}{
+		{http.MethodPut, `{"expires_at": null}`, openai.ID, http.StatusOK},
+		{http.MethodPut, `{}`, 999, http.StatusNotFound},
+		{http.MethodPut, `{"expires_at": "soon"}`, openai.ID, http.StatusBadRequest},
+		{http.MethodGet, ``, openai.ID, http.StatusMethodNotAllowed},
+	} {
+		rec := httptest.NewRecorder()
+		api.HandleExpiry(rec, httptest.NewRequest(tt.method, "/api/keys/x/expiry", strings.NewReader(tt.body)), tt.keyID)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.body, tt.want, rec.Code)
+		}
+	}
+	if key, _ := db.GetAPIKey(openai.ID); key.ExpiresAt != nil {
+		t.Errorf("expiry not cleared: %v", key.ExpiresAt)
+	}
+}
@@ -0,0 +1,307 @@
+package admin
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page sizes for list endpoints
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+// ListOptions selects a page of a list endpoint. The zero value is the
+// first DefaultListLimit items in the endpoint's default order.
+type ListOptions struct {
+	Limit   int               // Items per page, 1 to MaxListLimit (default DefaultListLimit)
+	Cursor  string            // NextCursor of the previous page
+	Sort    string            // Field to sort by; a leading "-" sorts descending
+	Filters map[string]string // Field -> value an item must have
+}
+
+// Query encodes the options as query parameters, to be added to an
+// endpoint's own
+func (o ListOptions) Query() url.Values {
+	q := url.Values{}
+	if o.Limit > 0 {
+		q.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Cursor != "" {
+		q.Set("cursor", o.Cursor)
+	}
+	if o.Sort != "" {
+		q.Set("sort", o.Sort)
+	}
+	for field, value := range o.Filters {
+		q.Set(field, value)
+	}
+	return q
+}
+
+// ListPage is the response of every list endpoint. NextCursor is empty on
+// the last page; Total counts the items matching the filters across all
+// pages.
+type ListPage[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor"`
+	Total      int    `json:"total"`
+}
+
+// listField is a field of T that a list can be filtered and sorted on.
+// Exactly one of str and num is set.
+type listField[T any] struct {
+	str func(T) string
+	num func(T) float64
+}
+
+func strField[T any](f func(T) string) listField[T] {
+	return listField[T]{str: f}
+}
+
+func numField[T any](f func(T) float64) listField[T] {
+	return listField[T]{num: f}
+}
+
+func intField[T any](f func(T) int) listField[T] {
+	return numField(func(v T) float64 { return float64(f(v)) })
+}
+
+func boolField[T any](f func(T) bool) listField[T] {
+	return numField(func(v T) float64 { return b2f(f(v)) })
+}
+
+// timeField sorts on a time to the millisecond
+func timeField[T any](f func(T) time.Time) listField[T] {
+	return numField(func(v T) float64 { return float64(f(v).UnixMilli()) })
+}
+
+func b2f(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// key returns the field's sort key for an item
+func (f listField[T]) key(v T) listKey {
+	if f.str != nil {
+		return listKey{S: f.str(v)}
+	}
+	return listKey{N: f.num(v)}
+}
+
+// matches reports whether an item's field equals a filter value. Strings
+// compare case-insensitively; booleans are 1/0 or true/false.
+func (f listField[T]) matches(v T, want string) (bool, error) {
+	if f.str != nil {
+		return strings.EqualFold(f.str(v), want), nil
+	}
+	n, err := strconv.ParseFloat(want, 64)
+	if err != nil {
+		b, berr := strconv.ParseBool(want)
+		if berr != nil {
+			return false, err
+		}
+		n = b2f(b)
+	}
+	return f.num(v) == n, nil
+}
+
+// listSpec describes how a list endpoint's items are paged: their unique
+// ID, which breaks sort ties, their fields and the default sort. An empty
+// sort keeps the order the items are listed in, for lists where it
+// matters, such as rules applied in turn; such lists page by position.
+type listSpec[T any] struct {
+	id     func(T) string
+	fields map[string]listField[T]
+	sort   string
+}
+
+// listKey is an item's position in a sorted list
+type listKey struct {
+	S  string  `json:"s,omitempty"`
+	N  float64 `json:"n,omitempty"`
+	ID string  `json:"id"`
+}
+
+func (k listKey) compare(o listKey) int {
+	return cmp.Or(cmp.Compare(k.N, o.N), strings.Compare(k.S, o.S), strings.Compare(k.ID, o.ID))
+}
+
+// listCursor is the decoded form of a page's NextCursor: the sort it was
+// made for and the last item returned
+type listCursor struct {
+	Sort  string  `json:"sort"`
+	After listKey `json:"after"`
+}
+
+func (c listCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(s string) (listCursor, error) {
+	var c listCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// parseListOptions reads limit, cursor, sort and the spec's field filters
+// from query parameters
+func parseListOptions[T any](q url.Values, spec listSpec[T]) (ListOptions, error) {
+	limit, ok := boundedInt(q.Get("limit"), DefaultListLimit, 1, MaxListLimit)
+	if !ok {
+		return ListOptions{}, fmt.Errorf("limit must be between 1 and %d", MaxListLimit)
+	}
+	opts := ListOptions{Limit: limit, Cursor: q.Get("cursor"), Sort: q.Get("sort")}
+	if opts.Sort == "" {
+		opts.Sort = spec.sort
+	}
+	if _, ok := spec.fields[strings.TrimPrefix(opts.Sort, "-")]; !ok && opts.Sort != "" {
+		return ListOptions{}, fmt.Errorf("cannot sort by %q (fields: %s)", opts.Sort, strings.Join(spec.fieldNames(), ", "))
+	}
+	for name := range spec.fields {
+		if v := q.Get(name); v != "" {
+			if opts.Filters == nil {
+				opts.Filters = make(map[string]string)
+			}
+			opts.Filters[name] = v
+		}
+	}
+	return opts, nil
+}
+
+func (spec listSpec[T]) fieldNames() []string {
+	names := make([]string, 0, len(spec.fields))
+	for name := range spec.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// paginate filters, sorts and pages items. Pages are keyed on the last
+// item returned rather than an offset, so items added or removed between
+// requests do not shift later pages.
+func paginate[T any](items []T, spec listSpec[T], opts ListOptions) (*ListPage[T], error) {
+	field, desc := spec.fields[strings.TrimPrefix(opts.Sort, "-")], strings.HasPrefix(opts.Sort, "-")
+	filtered := make([]listEntry[T], 0, len(items))
+	for i, item := range items {
+		keep := true
+		for name, want := range opts.Filters {
+			ok, err := spec.fields[name].matches(item, want)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s filter %q", name, want)
+			}
+			keep = keep && ok
+		}
+		if !keep {
+			continue
+		}
+		key := listKey{N: float64(i)}
+		if opts.Sort != "" {
+			key = field.key(item)
+		}
+		key.ID = spec.id(item)
+		filtered = append(filtered, listEntry[T]{item: item, key: key})
+	}
+
+	order := func(a, b listKey) int {
+		if desc {
+			return b.compare(a)
+		}
+		return a.compare(b)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool { return order(filtered[i].key, filtered[j].key) < 0 })
+
+	start := 0
+	if opts.Cursor != "" {
+		cursor, err := decodeListCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != opts.Sort {
+			return nil, fmt.Errorf("cursor was made for sort %q, not %q", cursor.Sort, opts.Sort)
+		}
+		start = sort.Search(len(filtered), func(i int) bool { return order(filtered[i].key, cursor.After) > 0 })
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	end := min(start+limit, len(filtered))
+	page := &ListPage[T]{Items: make([]T, 0, end-start), Total: len(filtered)}
+	for _, e := range filtered[start:end] {
+		page.Items = append(page.Items, e.item)
+	}
+	if end < len(filtered) {
+		page.NextCursor = listCursor{Sort: opts.Sort, After: filtered[end-1].key}.encode()
+	}
+	return page, nil
+}
+
+// listEntry is an item with its sort key
+type listEntry[T any] struct {
+	item T
+	key  listKey
+}
+
+// writeList writes a page of items selected by the request's query
+// parameters, or 400 for invalid ones
+func writeList[T any](w http.ResponseWriter, r *http.Request, items []T, spec listSpec[T]) {
+	page, ok := selectPage(w, r, items, spec)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// writeListWith writes a page like writeList, adding fields that describe
+// the list as a whole, such as the window it covers, beside the envelope.
+// They cannot replace items, next_cursor or total.
+func writeListWith[T any](w http.ResponseWriter, r *http.Request, items []T, spec listSpec[T], extra map[string]interface{}) {
+	page, ok := selectPage(w, r, items, spec)
+	if !ok {
+		return
+	}
+	resp := make(map[string]interface{}, len(extra)+3)
+	for k, v := range extra {
+		resp[k] = v
+	}
+	resp["items"], resp["next_cursor"], resp["total"] = page.Items, page.NextCursor, page.Total
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// selectPage pages items by the request's query parameters, writing 400
+// for invalid ones
+func selectPage[T any](w http.ResponseWriter, r *http.Request, items []T, spec listSpec[T]) (*ListPage[T], bool) {
+	opts, err := parseListOptions(r.URL.Query(), spec)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	page, err := paginate(items, spec, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return page, true
+}
@@ -0,0 +1,163 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type listItem struct {
+	id    string
+	group string
+	score int
+	on    bool
+}
+
+var testList = listSpec[listItem]{
+	id: func(i listItem) string { return i.id },
+	fields: map[string]listField[listItem]{
+		"id":    strField(func(i listItem) string { return i.id }),
+		"group": strField(func(i listItem) string { return i.group }),
+		"score": intField(func(i listItem) int { return i.score }),
+		"on":    boolField(func(i listItem) bool { return i.on }),
+	},
+	sort: "id",
+}
+
+func listPage(t *testing.T, items []listItem, query string) *ListPage[listItem] {
+	t.Helper()
+	q, _ := url.ParseQuery(query)
+	opts, err := parseListOptions(q, testList)
+	if err != nil {
+		t.Fatalf("parseListOptions(%q): %v", query, err)
+	}
+	page, err := paginate(items, testList, opts)
+	if err != nil {
+		t.Fatalf("paginate(%q): %v", query, err)
+	}
+	return page
+}
+
+func ids(page *ListPage[listItem]) string {
+	s := ""
+	for _, i := range page.Items {
+		s += i.id
+	}
+	return s
+}
+
+func TestPaginate_CursorWalksAllPages(t *testing.T) {
+	items := []listItem{
+		{id: "e", score: 1}, {id: "a", score: 3}, {id: "d", score: 3},
+		{id: "b", score: 2}, {id: "c", score: 5},
+	}
+
+	got := ""
+	query := "limit=2&sort=-score"
+	for pages := 0; ; pages++ {
+		page := listPage(t, items, query)
+		if page.Total != 5 {
+			t.Fatalf("expected a total of 5, got %d", page.Total)
+		}
+		got += ids(page)
+		if page.NextCursor == "" {
+			if pages != 2 {
+				t.Errorf("expected 3 pages, got %d", pages+1)
+			}
+			break
+		}
+		query = "limit=2&sort=-score&cursor=" + page.NextCursor
+	}
+	// Ties on score fall back to the ID, in the sort's direction
+	if got != "cdabe" {
+		t.Errorf("expected cdabe, got %s", got)
+	}
+}
+
+func TestPaginate_CursorSurvivesDeletes(t *testing.T) {
+	items := []listItem{{id: "a"}, {id: "b"}, {id: "c"}, {id: "d"}}
+	first := listPage(t, items, "limit=2")
+
+	// b is deleted before the next page is read
+	items = []listItem{{id: "a"}, {id: "c"}, {id: "d"}}
+	if next := listPage(t, items, "limit=2&cursor="+first.NextCursor); ids(next) != "cd" {
+		t.Errorf("expected the page after b to be cd, got %s", ids(next))
+	}
+}
+
+func TestPaginate_Filters(t *testing.T) {
+	items := []listItem{
+		{id: "a", group: "Red", on: true}, {id: "b", group: "blue", on: true},
+		{id: "c", group: "red"}, {id: "d", group: "red", on: true},
+	}
+	if page := listPage(t, items, "group=red&on=true"); ids(page) != "ad" || page.Total != 2 {
+		t.Errorf("expected ad, got %s (total %d)", ids(page), page.Total)
+	}
+	if page := listPage(t, items, "on=0"); ids(page) != "c" {
+		t.Errorf("expected c, got %s", ids(page))
+	}
+}
+
+func TestParseListOptions_Errors(t *testing.T) {
+	items := []listItem{{id: "a"}, {id: "b"}}
+	for _, query := range []string{"limit=0", "limit=501", "sort=color"} {
+		q, _ := url.ParseQuery(query)
+		if _, err := parseListOptions(q, testList); err == nil {
+			t.Errorf("expected an error for %q", query)
+		}
+	}
+
+	first := listPage(t, items, "limit=1")
+	for _, query := range []string{"cursor=not-a-cursor", "sort=-id&cursor=" + first.NextCursor, "score=high"} {
+		q, _ := url.ParseQuery(query)
+		opts, err := parseListOptions(q, testList)
+		if err != nil {
+			t.Fatalf("parseListOptions(%q): %v", query, err)
+		}
+		if _, err := paginate(items, testList, opts); err == nil {
+			t.Errorf("expected an error for %q", query)
+		}
+	}
+}
+
+func TestListOptions_Query(t *testing.T) {
+	q := ListOptions{Limit: 10, Cursor: "abc", Sort: "-cost", Filters: map[string]string{"provider": "openai"}}.Query()
+	if q.Encode() != "cursor=abc&limit=10&provider=openai&sort=-cost" {
+		t.Errorf("unexpected query %s", q.Encode())
+	}
+}
+
+type fakeModelService []ModelWithProviderInfo
+
+func (f fakeModelService) ListModelsWithProvider() ([]ModelWithProviderInfo, error) { return f, nil }
+func (f fakeModelService) HasKeyForProvider(string) bool                            { return true }
+
+func TestHandleModels_Paged(t *testing.T) {
+	api := NewAPI(Config{}, &mockDB{}, &mockDiscovery{}, &mockGenerator{}, &mockKeyManager{})
+	api.SetModelService(fakeModelService{
+		{ID: "gpt-4o", Provider: "openai", CostPer1MIn: 2.5},
+		{ID: "gpt-4o-mini", Provider: "openai", CostPer1MIn: 0.15},
+		{ID: "claude-sonnet-4", Provider: "anthropic", CostPer1MIn: 3},
+	})
+
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/models?provider=openai&sort=cost_per_1m_in", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var page ListPage[ModelWithProviderInfo]
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if page.Total != 2 || page.Items[0].ID != "gpt-4o-mini" || page.NextCursor != "" {
+		t.Errorf("expected openai models cheapest first, got %+v", page)
+	}
+
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/models?sort=color", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown sort field, got %d", w.Code)
+	}
+}
@@ -0,0 +1,170 @@
+package admin
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// MaintenanceStore manages stored provider maintenance windows
+type MaintenanceStore interface {
+	List() ([]*database.MaintenanceWindow, error)
+	Get(id int) (*database.MaintenanceWindow, error)
+	Create(m *database.MaintenanceWindow) error
+	End(id int, at time.Time) error
+	Delete(id int) error
+}
+
+// MaintenanceTracker is the live window set used by the proxy and
+// scheduler. The admin API reloads it after changes so they apply at once.
+type MaintenanceTracker interface {
+	Reloader
+	Active() []*database.MaintenanceWindow
+}
+
+// MaintenanceAPI handles provider drain and maintenance window endpoints
+type MaintenanceAPI struct {
+	store   MaintenanceStore
+	tracker MaintenanceTracker
+	now     func() time.Time
+}
+
+// NewMaintenanceAPI creates a new MaintenanceAPI. tracker may be nil.
+func NewMaintenanceAPI(store MaintenanceStore, tracker MaintenanceTracker) *MaintenanceAPI {
+	return &MaintenanceAPI{store: store, tracker: tracker, now: time.Now}
+}
+
+// MaintenanceRequest represents the request body for draining a provider or
+// scheduling a maintenance window. Without starts_at the window starts now;
+// without ends_at it lasts until it is ended through the API.
+type MaintenanceRequest struct {
+	Provider string     `json:"provider"`
+	Reason   string     `json:"reason,omitempty"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// reloadTracker refreshes the live window set; failures are logged
+func (a *MaintenanceAPI) reloadTracker() {
+	if a.tracker == nil {
+		return
+	}
+	if err := a.tracker.Reload(); err != nil {
+		log.Printf("admin: failed to reload maintenance windows: %v", err)
+	}
+}
+
+// maintenanceList pages GET /api/providers/maintenance
+var maintenanceList = listSpec[*database.MaintenanceWindow]{
+	id: func(m *database.MaintenanceWindow) string { return strconv.Itoa(m.ID) },
+	fields: map[string]listField[*database.MaintenanceWindow]{
+		"id":        intField(func(m *database.MaintenanceWindow) int { return m.ID }),
+		"provider":  strField(func(m *database.MaintenanceWindow) string { return m.Provider }),
+		"starts_at": timeField(func(m *database.MaintenanceWindow) time.Time { return m.StartsAt }),
+	},
+	sort: "starts_at",
+}
+
+// HandleMaintenance handles GET /api/providers/maintenance (list) and
+// POST /api/providers/maintenance (drain or schedule)
+func (a *MaintenanceAPI) HandleMaintenance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		windows, err := a.store.List()
+		if err != nil {
+			http.Error(w, "Failed to list maintenance windows: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if windows == nil {
+			windows = []*database.MaintenanceWindow{}
+		}
+		extra := map[string]interface{}{}
+		if a.tracker != nil {
+			active := a.tracker.Active()
+			if active == nil {
+				active = []*database.MaintenanceWindow{}
+			}
+			extra["active"] = active
+		}
+		writeListWith(w, r, windows, maintenanceList, extra)
+	case http.MethodPost:
+		var req MaintenanceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Provider == "" {
+			http.Error(w, "provider is required", http.StatusBadRequest)
+			return
+		}
+
+		now := a.now()
+		m := &database.MaintenanceWindow{Provider: req.Provider, Reason: req.Reason, StartsAt: now, EndsAt: req.EndsAt}
+		if req.StartsAt != nil {
+			m.StartsAt = *req.StartsAt
+		}
+		if m.EndsAt != nil && (!m.EndsAt.After(m.StartsAt) || !m.EndsAt.After(now)) {
+			http.Error(w, "ends_at must be after starts_at and in the future", http.StatusBadRequest)
+			return
+		}
+		if err := a.store.Create(m); err != nil {
+			http.Error(w, "Failed to create maintenance window: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		a.reloadTracker()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(m)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleMaintenanceByID handles GET/DELETE /api/providers/maintenance/{id}.
+// Deleting a window that has started ends it now and keeps it as history;
+// an upcoming window is removed.
+func (a *MaintenanceAPI) HandleMaintenanceByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/providers/maintenance/"))
+	if err != nil {
+		http.Error(w, "Invalid maintenance window ID", http.StatusBadRequest)
+		return
+	}
+
+	m, err := a.store.Get(id)
+	if err != nil {
+		http.Error(w, "Failed to get maintenance window: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if m == nil {
+		http.Error(w, "Maintenance window not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(m)
+	case http.MethodDelete:
+		now := a.now()
+		switch {
+		case now.Before(m.StartsAt):
+			err = a.store.Delete(id)
+		case m.ActiveAt(now):
+			err = a.store.End(id, now)
+		}
+		if err != nil {
+			http.Error(w, "Failed to end maintenance window: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		a.reloadTracker()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
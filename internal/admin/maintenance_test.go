@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/maintenance"
+)
+
+type maintenanceDB struct {
+	repo *database.MaintenanceRepository
+}
+
+func (d maintenanceDB) ListCurrentMaintenance(now time.Time) ([]*database.MaintenanceWindow, error) {
+	return d.repo.ListCurrent(now)
+}
+
+func TestMaintenanceAPI(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "maintenance.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := database.NewMaintenanceRepository(db)
+	tracker, err := maintenance.NewTracker(maintenanceDB{repo: repo})
+	if err != nil {
+		t.Fatalf("NewTracker failed: %v", err)
+	}
+	api := NewMaintenanceAPI(repo, tracker)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if strings.HasPrefix(path, "/api/providers/maintenance/") {
+			api.HandleMaintenanceByID(rec, req)
+		} else {
+			api.HandleMaintenance(rec, req)
+		}
+		return rec
+	}
+
+	// Drain now, until ended
+	rec := do(http.MethodPost, "/api/providers/maintenance", `{"provider":"openai","reason":"key rotation"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("drain: expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var drain database.MaintenanceWindow
+	json.NewDecoder(rec.Body).Decode(&drain)
+	if _, down := tracker.Unavailable("openai"); !down {
+		t.Error("expected the drain to apply without a restart")
+	}
+
+	// Schedule a window for later
+	start := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	end := time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339)
+	rec = do(http.MethodPost, "/api/providers/maintenance", fmt.Sprintf(`{"provider":"anthropic","starts_at":%q,"ends_at":%q}`, start, end))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("schedule: expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var scheduled database.MaintenanceWindow
+	json.NewDecoder(rec.Body).Decode(&scheduled)
+	if _, down := tracker.Unavailable("anthropic"); down {
+		t.Error("scheduled window should not apply yet")
+	}
+
+	for _, body := range []string{
+		`{"reason":"no provider"}`,
+		fmt.Sprintf(`{"provider":"groq","starts_at":%q,"ends_at":%q}`, end, start),
+		`{"provider":"groq","ends_at":"2020-01-01T00:00:00Z"}`,
+		`not json`,
+	} {
+		if rec := do(http.MethodPost, "/api/providers/maintenance", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rec.Code)
+		}
+	}
+
+	rec = do(http.MethodGet, "/api/providers/maintenance", "")
+	var list struct {
+		Windows []database.MaintenanceWindow `json:"items"`
+		Active  []database.MaintenanceWindow `json:"active"`
+		Total   int                          `json:"total"`
+	}
+	json.NewDecoder(rec.Body).Decode(&list)
+	if list.Total != 2 || len(list.Active) != 1 || list.Active[0].Provider != "openai" {
+		t.Errorf("unexpected list: %+v", list)
+	}
+
+	// Ending the drain keeps it as history; the upcoming window is removed
+	if rec := do(http.MethodDelete, fmt.Sprintf("/api/providers/maintenance/%d", drain.ID), ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("end drain: expected 204, got %d", rec.Code)
+	}
+	if _, down := tracker.Unavailable("openai"); down {
+		t.Error("expected openai back in rotation")
+	}
+	if rec := do(http.MethodGet, fmt.Sprintf("/api/providers/maintenance/%d", drain.ID), ""); rec.Code != http.StatusOK {
+		t.Errorf("expected the ended drain to be kept, got %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, fmt.Sprintf("/api/providers/maintenance/%d", scheduled.ID), ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("cancel window: expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, fmt.Sprintf("/api/providers/maintenance/%d", scheduled.ID), ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected the cancelled window to be gone, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/providers/maintenance/abc", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad ID, got %d", rec.Code)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
 )
 
 // ClientContextKey is the key for storing client in request context
@@ -33,6 +35,7 @@ type MiddlewareClientConfig struct {
 	MaxOutputTokens  int      `json:"max_output_tokens,omitempty"`
 	TimeoutMs        int      `json:"timeout_ms,omitempty"`
 	ProviderPriority []string `json:"provider_priority,omitempty"`
+	ContextOverflow  string   `json:"context_overflow,omitempty"`
 }
 
 // ClientStore interface for client operations needed by middleware
@@ -90,9 +93,9 @@ func (m *ClientMiddleware) Wrap(next http.Handler) http.Handler {
 
 			// Create a channel to receive the error
 			done := make(chan error, 1)
-			go func() {
+			crash.Go("client last seen", func() {
 				done <- m.store.UpdateLastSeen(clientID)
-			}()
+			})
 
 			select {
 			case err := <-done:
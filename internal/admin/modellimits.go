@@ -0,0 +1,93 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// ModelLimitStore manages per-model request caps (usually a *proxy.ModelLimits)
+type ModelLimitStore interface {
+	Stats() []proxy.ModelLimitStats
+	SetLimit(l proxy.ModelLimit) error
+	RemoveLimit(model string) bool
+}
+
+// ModelLimitAPI handles per-model cap endpoints
+type ModelLimitAPI struct {
+	store ModelLimitStore
+}
+
+// NewModelLimitAPI creates a new ModelLimitAPI
+func NewModelLimitAPI(store ModelLimitStore) *ModelLimitAPI {
+	return &ModelLimitAPI{store: store}
+}
+
+// modelLimitList pages GET /api/modellimits
+var modelLimitList = listSpec[proxy.ModelLimitStats]{
+	id: func(s proxy.ModelLimitStats) string { return s.Model },
+	fields: map[string]listField[proxy.ModelLimitStats]{
+		"model":     strField(func(s proxy.ModelLimitStats) string { return s.Model }),
+		"requests":  intField(func(s proxy.ModelLimitStats) int { return s.Requests }),
+		"tokens":    intField(func(s proxy.ModelLimitStats) int { return s.Tokens }),
+		"in_flight": intField(func(s proxy.ModelLimitStats) int { return s.InFlight }),
+		"rejected":  numField(func(s proxy.ModelLimitStats) float64 { return float64(s.Rejected) }),
+	},
+	sort: "model",
+}
+
+// HandleModelLimits handles GET /api/modellimits (each cap with its use
+// this minute) and POST /api/modellimits (set the cap for a model or
+// pattern, replacing any before)
+func (a *ModelLimitAPI) HandleModelLimits(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeList(w, r, a.store.Stats(), modelLimitList)
+	case http.MethodPost:
+		var l proxy.ModelLimit
+		if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := a.store.SetLimit(l); err != nil {
+			http.Error(w, "Invalid model limit: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(l)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleModelLimit handles GET and DELETE /api/modellimits/{model}
+func (a *ModelLimitAPI) HandleModelLimit(w http.ResponseWriter, r *http.Request) {
+	model := strings.TrimPrefix(r.URL.Path, "/api/modellimits/")
+	if model == "" {
+		http.Error(w, "Model required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		for _, s := range a.store.Stats() {
+			if s.Model == model {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(s)
+				return
+			}
+		}
+		http.Error(w, "Model limit not found", http.StatusNotFound)
+	case http.MethodDelete:
+		if !a.store.RemoveLimit(model) {
+			http.Error(w, "Model limit not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
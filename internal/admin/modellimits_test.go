@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+func TestModelLimitAPI(t *testing.T) {
+	api := NewModelLimitAPI(proxy.NewModelLimits(0))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if strings.HasPrefix(path, "/api/modellimits/") {
+			api.HandleModelLimit(rec, req)
+		} else {
+			api.HandleModelLimits(rec, req)
+		}
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/modellimits", `{"model":"o1","rpm":20,"tpm":200000,"max_in_flight":4}`); rec.Code != http.StatusCreated {
+		t.Fatalf("POST expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/modellimits", `{"model":"o1"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("limit without caps expected 400, got %d", rec.Code)
+	}
+	do(http.MethodPost, "/api/modellimits", `{"model":"meta-llama/llama-3-70b*","max_in_flight":8}`)
+
+	rec := do(http.MethodGet, "/api/modellimits", "")
+	var resp struct {
+		Limits []proxy.ModelLimitStats `json:"items"`
+		Total  int                     `json:"total"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Total != 2 || resp.Limits[1].Model != "o1" || resp.Limits[1].TPM != 200000 || resp.Limits[1].InFlight != 0 {
+		t.Errorf("unexpected limits: %+v", resp)
+	}
+
+	if rec := do(http.MethodGet, "/api/modellimits/meta-llama/llama-3-70b*", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"max_in_flight":8`) {
+		t.Errorf("GET model expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodDelete, "/api/modellimits/o1", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/modellimits/o1", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET removed limit expected 404, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/api/modellimits", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT expected 405, got %d", rec.Code)
+	}
+}
@@ -52,7 +52,8 @@ type ModelWithProviderInfo struct {
 	CostPer1MOut  float64 `json:"cost_per_1m_out"`
 }
 
-// handleModels handles GET /api/models with optional format parameter
+// handleModels handles GET /api/models, a flat list, or
+// GET /api/models?format=hierarchy, grouped by provider and family
 func (a *API) handleModels(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -73,19 +74,30 @@ func (a *API) handleModels(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-
 	if format == "hierarchy" {
 		hierarchy := buildModelHierarchy(models, a.modelService)
+		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(hierarchy)
 		return
 	}
 
 	// Default flat list
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"models": models,
-		"count":  len(models),
-	})
+	writeList(w, r, models, modelList)
+}
+
+// modelList pages the flat GET /api/models
+var modelList = listSpec[ModelWithProviderInfo]{
+	id: func(m ModelWithProviderInfo) string { return m.Provider + "/" + m.ID },
+	fields: map[string]listField[ModelWithProviderInfo]{
+		"id":              strField(func(m ModelWithProviderInfo) string { return m.ID }),
+		"name":            strField(func(m ModelWithProviderInfo) string { return m.Name }),
+		"provider":        strField(func(m ModelWithProviderInfo) string { return m.Provider }),
+		"context_window":  intField(func(m ModelWithProviderInfo) int { return m.ContextWindow }),
+		"max_tokens":      intField(func(m ModelWithProviderInfo) int { return m.MaxTokens }),
+		"cost_per_1m_in":  numField(func(m ModelWithProviderInfo) float64 { return m.CostPer1MIn }),
+		"cost_per_1m_out": numField(func(m ModelWithProviderInfo) float64 { return m.CostPer1MOut }),
+	},
+	sort: "id",
 }
 
 // buildModelHierarchy groups models by provider and family
@@ -0,0 +1,223 @@
+package admin
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/policy"
+)
+
+// PromptPolicyStore manages stored prompt policies
+type PromptPolicyStore interface {
+	List() ([]*database.PromptPolicy, error)
+	Get(id int) (*database.PromptPolicy, error)
+	Create(p *database.PromptPolicy) error
+	Update(p *database.PromptPolicy) error
+	Delete(id int) error
+}
+
+// PromptPolicyEngine is the live policy set used by the proxy.
+// The admin API reloads it after changes so edits apply without restart.
+type PromptPolicyEngine interface {
+	Reloader
+	SystemPrompt(model, clientID string) (prepend, appendText string, err error)
+}
+
+// PromptPolicyAPI handles system prompt policy endpoints
+type PromptPolicyAPI struct {
+	store  PromptPolicyStore
+	engine PromptPolicyEngine
+}
+
+// NewPromptPolicyAPI creates a new PromptPolicyAPI. engine may be nil.
+func NewPromptPolicyAPI(store PromptPolicyStore, engine PromptPolicyEngine) *PromptPolicyAPI {
+	return &PromptPolicyAPI{store: store, engine: engine}
+}
+
+// PromptPolicyUpdateRequest represents the request body for updating a
+// prompt policy
+type PromptPolicyUpdateRequest struct {
+	Name         *string `json:"name,omitempty"`
+	ClientID     *string `json:"client_id,omitempty"`
+	ModelPattern *string `json:"model_pattern,omitempty"`
+	Position     *string `json:"position,omitempty"`
+	Template     *string `json:"template,omitempty"`
+	Priority     *int    `json:"priority,omitempty"`
+	Enabled      *bool   `json:"enabled,omitempty"`
+}
+
+// reloadEngine refreshes the live policy set; failures are logged
+func (a *PromptPolicyAPI) reloadEngine() {
+	if a.engine == nil {
+		return
+	}
+	if err := a.engine.Reload(); err != nil {
+		log.Printf("admin: failed to reload prompt policies: %v", err)
+	}
+}
+
+// promptPolicyList pages GET /api/policies/prompt in the order policies
+// are applied
+var promptPolicyList = listSpec[*database.PromptPolicy]{
+	id: func(p *database.PromptPolicy) string { return strconv.Itoa(p.ID) },
+	fields: map[string]listField[*database.PromptPolicy]{
+		"id":            intField(func(p *database.PromptPolicy) int { return p.ID }),
+		"name":          strField(func(p *database.PromptPolicy) string { return p.Name }),
+		"client_id":     strField(func(p *database.PromptPolicy) string { return p.ClientID }),
+		"model_pattern": strField(func(p *database.PromptPolicy) string { return p.ModelPattern }),
+		"position":      strField(func(p *database.PromptPolicy) string { return p.Position }),
+		"priority":      intField(func(p *database.PromptPolicy) int { return p.Priority }),
+		"enabled":       boolField(func(p *database.PromptPolicy) bool { return p.Enabled }),
+	},
+}
+
+// HandlePolicies handles GET /api/policies/prompt (list) and
+// POST /api/policies/prompt (create)
+func (a *PromptPolicyAPI) HandlePolicies(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		policies, err := a.store.List()
+		if err != nil {
+			http.Error(w, "Failed to list prompt policies: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if policies == nil {
+			policies = []*database.PromptPolicy{}
+		}
+		writeList(w, r, policies, promptPolicyList)
+	case http.MethodPost:
+		// Policies are enabled unless the request says otherwise
+		p := &database.PromptPolicy{Enabled: true}
+		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		p.ID = 0
+		if err := policy.Validate(p); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := a.store.Create(p); err != nil {
+			http.Error(w, "Failed to create prompt policy: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		a.reloadEngine()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(p)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandlePolicyByID handles GET/PATCH/DELETE /api/policies/prompt/{id}
+func (a *PromptPolicyAPI) HandlePolicyByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/policies/prompt/"))
+	if err != nil {
+		http.Error(w, "Invalid policy ID", http.StatusBadRequest)
+		return
+	}
+
+	p, err := a.store.Get(id)
+	if err != nil {
+		http.Error(w, "Failed to get prompt policy: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if p == nil {
+		http.Error(w, "Prompt policy not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p)
+	case http.MethodPatch:
+		var req PromptPolicyUpdateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Name != nil {
+			p.Name = *req.Name
+		}
+		if req.ClientID != nil {
+			p.ClientID = *req.ClientID
+		}
+		if req.ModelPattern != nil {
+			p.ModelPattern = *req.ModelPattern
+		}
+		if req.Position != nil {
+			p.Position = *req.Position
+		}
+		if req.Template != nil {
+			p.Template = *req.Template
+		}
+		if req.Priority != nil {
+			p.Priority = *req.Priority
+		}
+		if req.Enabled != nil {
+			p.Enabled = *req.Enabled
+		}
+		if err := policy.Validate(p); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := a.store.Update(p); err != nil {
+			http.Error(w, "Failed to update prompt policy: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		a.reloadEngine()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p)
+	case http.MethodDelete:
+		if err := a.store.Delete(id); err != nil {
+			http.Error(w, "Failed to delete prompt policy: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		a.reloadEngine()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandlePreview handles GET /api/policies/prompt/preview?model=...&client_id=...
+// It renders the text the live policies would add, without proxying
+func (a *PromptPolicyAPI) HandlePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.engine == nil {
+		http.Error(w, "Prompt policy engine not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	model := r.URL.Query().Get("model")
+	if model == "" {
+		http.Error(w, "model is required", http.StatusBadRequest)
+		return
+	}
+	clientID := r.URL.Query().Get("client_id")
+
+	prepend, appendText, err := a.engine.SystemPrompt(model, clientID)
+	if err != nil {
+		http.Error(w, "Failed to render prompt policies: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"model":     model,
+		"client_id": clientID,
+		"prepend":   prepend,
+		"append":    appendText,
+	})
+}
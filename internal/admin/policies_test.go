@@ -0,0 +1,105 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/policy"
+)
+
+type policyDB struct {
+	repo *database.PromptPolicyRepository
+}
+
+func (d policyDB) ListEnabledPromptPolicies() ([]*database.PromptPolicy, error) {
+	return d.repo.ListEnabled()
+}
+
+func TestPromptPolicyAPI(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "policies.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := database.NewPromptPolicyRepository(db)
+	engine, err := policy.NewEngine(policyDB{repo: repo})
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	api := NewPromptPolicyAPI(repo, engine)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		switch {
+		case strings.HasPrefix(path, "/api/policies/prompt/preview"):
+			api.HandlePreview(rec, req)
+		case strings.HasPrefix(path, "/api/policies/prompt/"):
+			api.HandlePolicyByID(rec, req)
+		default:
+			api.HandlePolicies(rec, req)
+		}
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/api/policies/prompt", `{"name":"compliance","model_pattern":"gpt-*","template":"You serve {{.ClientID}}. Do not give legal advice."}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created database.PromptPolicy
+	json.NewDecoder(rec.Body).Decode(&created)
+	if !created.Enabled || created.Position != database.PolicyPrepend {
+		t.Errorf("unexpected defaults: %+v", created)
+	}
+	if rec := do(http.MethodPost, "/api/policies/prompt", `{"name":"bad","template":"{{.Unknown}}"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad template: expected 400, got %d", rec.Code)
+	}
+
+	// Changes apply to the live engine straight away
+	rec = do(http.MethodGet, "/api/policies/prompt/preview?model=gpt-4o&client_id=acme", "")
+	var preview map[string]string
+	json.NewDecoder(rec.Body).Decode(&preview)
+	if preview["prepend"] != "You serve acme. Do not give legal advice." {
+		t.Errorf("unexpected preview: %+v", preview)
+	}
+
+	path := "/api/policies/prompt/" + strconv.Itoa(created.ID)
+	if rec := do(http.MethodPatch, path, `{"position":"append"}`); rec.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = do(http.MethodGet, "/api/policies/prompt/preview?model=gpt-4o", "")
+	json.NewDecoder(rec.Body).Decode(&preview)
+	if preview["prepend"] != "" || !strings.HasPrefix(preview["append"], "You serve .") {
+		t.Errorf("unexpected preview after update: %+v", preview)
+	}
+	if rec := do(http.MethodPatch, path, `{"position":"sideways"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad position: expected 400, got %d", rec.Code)
+	}
+
+	var list struct {
+		Policies []database.PromptPolicy `json:"items"`
+		Total    int                     `json:"total"`
+	}
+	json.NewDecoder(do(http.MethodGet, "/api/policies/prompt", "").Body).Decode(&list)
+	if list.Total != 1 || list.Policies[0].Position != database.PolicyAppend {
+		t.Errorf("unexpected list: %+v", list)
+	}
+
+	if rec := do(http.MethodDelete, path, ""); rec.Code != http.StatusNoContent {
+		t.Errorf("delete: expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, path, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("get deleted: expected 404, got %d", rec.Code)
+	}
+	rec = do(http.MethodGet, "/api/policies/prompt/preview?model=gpt-4o", "")
+	json.NewDecoder(rec.Body).Decode(&preview)
+	if preview["append"] != "" {
+		t.Errorf("deleted policy still applied: %+v", preview)
+	}
+}
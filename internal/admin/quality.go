@@ -0,0 +1,116 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/storage"
+)
+
+// ModelQualityStore manages model quality ratings
+type ModelQualityStore interface {
+	ListModelQuality() ([]storage.ModelQuality, error)
+	GetModelQuality(modelID string) (*storage.ModelQuality, error)
+	SetModelQuality(q storage.ModelQuality) error
+	DeleteModelQuality(modelID string) (bool, error)
+}
+
+// QualityAPI handles model quality tier endpoints
+type QualityAPI struct {
+	store ModelQualityStore
+}
+
+// NewQualityAPI creates a new QualityAPI
+func NewQualityAPI(store ModelQualityStore) *QualityAPI {
+	return &QualityAPI{store: store}
+}
+
+// qualityList pages GET /api/quality
+var qualityList = listSpec[storage.ModelQuality]{
+	id: func(q storage.ModelQuality) string { return q.ModelID },
+	fields: map[string]listField[storage.ModelQuality]{
+		"model_id":   strField(func(q storage.ModelQuality) string { return q.ModelID }),
+		"tier":       strField(func(q storage.ModelQuality) string { return q.Tier.String() }),
+		"score":      numField(func(q storage.ModelQuality) float64 { return q.Score }),
+		"source":     strField(func(q storage.ModelQuality) string { return q.Source }),
+		"updated_at": timeField(func(q storage.ModelQuality) time.Time { return q.UpdatedAt }),
+	},
+	sort: "model_id",
+}
+
+// HandleQuality handles GET /api/quality (all ratings) and
+// POST /api/quality (set a model's tier or score)
+func (a *QualityAPI) HandleQuality(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		models, err := a.store.ListModelQuality()
+		if err != nil {
+			http.Error(w, "Failed to list quality ratings: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeList(w, r, models, qualityList)
+	case http.MethodPost:
+		var q storage.ModelQuality
+		if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		// Admin ratings are pinned so benchmark reseeding leaves them alone
+		q.Source = storage.QualitySourceAdmin
+		q.UpdatedAt = time.Time{}
+		if err := a.store.SetModelQuality(q); err != nil {
+			http.Error(w, "Invalid quality rating: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		saved, err := a.store.GetModelQuality(q.ModelID)
+		if err != nil || saved == nil {
+			http.Error(w, "Failed to read quality rating", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(saved)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleQualityModel handles GET and DELETE /api/quality/{model}.
+// Model names may contain slashes.
+func (a *QualityAPI) HandleQualityModel(w http.ResponseWriter, r *http.Request) {
+	model := strings.TrimPrefix(r.URL.Path, "/api/quality/")
+	if model == "" {
+		http.Error(w, "Model required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		q, err := a.store.GetModelQuality(model)
+		if err != nil {
+			http.Error(w, "Failed to get quality rating: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if q == nil {
+			http.Error(w, "Quality rating not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(q)
+	case http.MethodDelete:
+		ok, err := a.store.DeleteModelQuality(model)
+		if err != nil {
+			http.Error(w, "Failed to delete quality rating: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "Quality rating not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/storage"
+)
+
+type qualityStore struct{}
+
+func (qualityStore) ListModelQuality() ([]storage.ModelQuality, error) {
+	return storage.ListModelQuality()
+}
+
+func (qualityStore) GetModelQuality(modelID string) (*storage.ModelQuality, error) {
+	return storage.GetModelQuality(modelID)
+}
+
+func (qualityStore) SetModelQuality(q storage.ModelQuality) error {
+	return storage.SetModelQuality(q)
+}
+
+func (qualityStore) DeleteModelQuality(modelID string) (bool, error) {
+	return storage.DeleteModelQuality(modelID)
+}
+
+func TestQualityAPI(t *testing.T) {
+	if err := storage.InitRateLimitDB(filepath.Join(t.TempDir(), "rate_limits.db")); err != nil {
+		t.Fatalf("failed to init rate limit DB: %v", err)
+	}
+	defer storage.CloseRateLimitDB()
+
+	api := NewQualityAPI(qualityStore{})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if strings.HasPrefix(path, "/api/quality/") {
+			api.HandleQualityModel(rec, req)
+		} else {
+			api.HandleQuality(rec, req)
+		}
+		return rec
+	}
+
+	storage.SetModelQuality(storage.ModelQuality{ModelID: "gpt-4o", Score: 84, Source: storage.QualitySourceBenchmark})
+
+	rec := do(http.MethodPost, "/api/quality", `{"model_id":"gpt-4o","tier":"frontier","source":"benchmark"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var saved storage.ModelQuality
+	json.NewDecoder(rec.Body).Decode(&saved)
+	if saved.Tier != storage.TierFrontier || saved.Source != storage.QualitySourceAdmin {
+		t.Errorf("unexpected rating: %+v", saved)
+	}
+
+	if rec := do(http.MethodPost, "/api/quality", `{"model_id":"x","tier":"genius"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid tier expected 400, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/quality", `{"model_id":"x","score":140}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid score expected 400, got %d", rec.Code)
+	}
+	do(http.MethodPost, "/api/quality", `{"model_id":"meta-llama/llama-3-8b","score":40}`)
+
+	rec = do(http.MethodGet, "/api/quality", "")
+	var resp struct {
+		Models []storage.ModelQuality `json:"items"`
+		Total  int                    `json:"total"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Total != 2 || resp.Models[0].ModelID != "gpt-4o" || resp.Models[1].Tier != storage.TierBasic {
+		t.Errorf("unexpected ratings: %+v", resp)
+	}
+
+	if rec := do(http.MethodGet, "/api/quality/meta-llama/llama-3-8b", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"basic"`) {
+		t.Errorf("GET model expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodDelete, "/api/quality/gpt-4o", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/quality/gpt-4o", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET deleted rating expected 404, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/api/quality", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT expected 405, got %d", rec.Code)
+	}
+}
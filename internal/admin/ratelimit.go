@@ -81,6 +81,18 @@ func (a *RateLimitAPI) HandleRateLimits(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// rateLimitList pages GET /api/ratelimits
+var rateLimitList = listSpec[*ClientRateLimit]{
+	id: func(l *ClientRateLimit) string { return l.ClientID },
+	fields: map[string]listField[*ClientRateLimit]{
+		"client_id":     strField(func(l *ClientRateLimit) string { return l.ClientID }),
+		"current_rpm":   intField(func(l *ClientRateLimit) int { return l.CurrentRPM }),
+		"current_tpm":   intField(func(l *ClientRateLimit) int { return l.CurrentTPM }),
+		"current_daily": intField(func(l *ClientRateLimit) int { return l.CurrentDaily }),
+	},
+	sort: "client_id",
+}
+
 // handleListRateLimits handles GET /api/ratelimits
 func (a *RateLimitAPI) handleListRateLimits(w http.ResponseWriter, r *http.Request) {
 	limits, err := a.store.List()
@@ -93,11 +105,7 @@ func (a *RateLimitAPI) handleListRateLimits(w http.ResponseWriter, r *http.Reque
 		limits = []*ClientRateLimit{}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"rate_limits": limits,
-		"count":       len(limits),
-	})
+	writeList(w, r, limits, rateLimitList)
 }
 
 // handleCreateRateLimit handles POST /api/ratelimits
@@ -160,9 +160,9 @@ func TestRateLimitAPI_HandleRateLimits_List(t *testing.T) {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	count, ok := response["count"].(float64)
+	count, ok := response["total"].(float64)
 	if !ok || int(count) != 1 {
-		t.Errorf("expected count 1, got %v", response["count"])
+		t.Errorf("expected count 1, got %v", response["total"])
 	}
 }
 
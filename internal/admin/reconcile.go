@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/jeffersonwarrior/modelscan/internal/reconcile"
+)
+
+// MaxInvoiceSize caps the body of an invoice export
+const MaxInvoiceSize = 32 << 20
+
+// ReconcileAPI handles invoice reconciliation endpoints
+type ReconcileAPI struct {
+	ledger reconcile.Ledger
+}
+
+// NewReconcileAPI creates a new ReconcileAPI
+func NewReconcileAPI(ledger reconcile.Ledger) *ReconcileAPI {
+	return &ReconcileAPI{ledger: ledger}
+}
+
+// HandleReconcile handles POST /api/reconcile?source=openai|anthropic&provider=<id>&threshold_percent=5&threshold_usd=0.01
+// with a provider's CSV export as the body, returning the reconciliation
+// report
+func (a *ReconcileAPI) HandleReconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	format, ok := reconcile.Formats[q.Get("source")]
+	if !ok {
+		http.Error(w, "source must be openai or anthropic", http.StatusBadRequest)
+		return
+	}
+	opts := reconcile.Options{Provider: q.Get("provider")}
+	for name, dst := range map[string]*float64{"threshold_percent": &opts.ThresholdPercent, "threshold_usd": &opts.ThresholdUSD} {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil || n < 0 {
+				http.Error(w, name+" must be a non-negative number", http.StatusBadRequest)
+				return
+			}
+			*dst = n
+		}
+	}
+
+	parsed, err := reconcile.Parse(http.MaxBytesReader(w, r.Body, MaxInvoiceSize), format)
+	if err != nil {
+		http.Error(w, "Invalid export: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	report, err := reconcile.Reconcile(parsed, format, a.ledger, opts)
+	if err != nil {
+		http.Error(w, "Failed to reconcile: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
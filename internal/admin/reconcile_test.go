@@ -0,0 +1,71 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/reconcile"
+)
+
+type fakeLedger struct {
+	err error
+}
+
+func (l fakeLedger) DailySpend(since time.Time) ([]*database.TenantDailySpend, error) {
+	return []*database.TenantDailySpend{
+		{Day: time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC), TenantID: "team-a", Provider: "openai", Model: "gpt-4o", Cost: 10},
+	}, l.err
+}
+
+func TestReconcileAPI(t *testing.T) {
+	api := NewAPI(Config{}, &mockDB{}, &mockDiscovery{}, &mockGenerator{}, &mockKeyManager{})
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return rec
+	}
+	const export = "date,model,cost\n2025-03-01,gpt-4o-2024-08-06,12.50\n"
+
+	if rec := post("/api/reconcile?source=openai", export); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without reconciliation, got %d", rec.Code)
+	}
+
+	api.SetReconcileAPI(NewReconcileAPI(fakeLedger{}))
+	rec := post("/api/reconcile?source=openai&threshold_percent=10", export)
+	var report reconcile.Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d: %v", rec.Code, err)
+	}
+	if report.Invoiced != 12.5 || report.Recorded != 10 || report.ThresholdPercent != 10 || report.Discrepancies != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	for path, body := range map[string]string{
+		"/api/reconcile":                                  export,
+		"/api/reconcile?source=azure":                     export,
+		"/api/reconcile?source=openai&threshold_usd=-1":   export,
+		"/api/reconcile?source=openai":                    "date,model\n2025-03-01,gpt-4o\n",
+		"/api/reconcile?source=anthropic&threshold_usd=x": export,
+	} {
+		if rec := post(path, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", path, rec.Code)
+		}
+	}
+
+	api.SetReconcileAPI(NewReconcileAPI(fakeLedger{err: errors.New("database is locked")}))
+	if rec := post("/api/reconcile?source=openai", export); rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 when the ledger cannot be read, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/reconcile", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", rec.Code)
+	}
+}
@@ -1,7 +1,9 @@
 package admin
 
 import (
+	"context"
 	"encoding/json"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -18,13 +20,30 @@ type RemapStore interface {
 	SetEnabled(id int, enabled bool) error
 }
 
-// RemapRule represents a model remapping rule
+// RemapEngine is the live rule set used by the proxy.
+// The admin API reloads it after changes so edits apply without restart.
+type RemapEngine interface {
+	Reloader
+	RemapModel(ctx context.Context, model string, clientID string) (remappedModel, targetProvider string, err error)
+}
+
+// Remap strategies
+const (
+	RemapStrategyDirect   = "direct"
+	RemapStrategyCheapest = "cheapest"
+	RemapStrategyFastest  = "fastest"
+)
+
+// RemapRule represents a model remapping rule.
+// An empty ClientID makes the rule global; client rules override global ones.
 type RemapRule struct {
 	ID         int       `json:"id"`
 	ClientID   string    `json:"client_id"`
 	FromModel  string    `json:"from_model"`
 	ToModel    string    `json:"to_model"`
 	ToProvider string    `json:"to_provider"`
+	Strategy   string    `json:"strategy"`
+	Candidates []string  `json:"candidates,omitempty"`
 	Priority   int       `json:"priority"`
 	Enabled    bool      `json:"enabled"`
 	CreatedAt  time.Time `json:"created_at"`
@@ -32,7 +51,8 @@ type RemapRule struct {
 
 // RemapAPI handles remap rule management endpoints
 type RemapAPI struct {
-	store RemapStore
+	store  RemapStore
+	engine RemapEngine
 }
 
 // NewRemapAPI creates a new RemapAPI
@@ -40,24 +60,72 @@ func NewRemapAPI(store RemapStore) *RemapAPI {
 	return &RemapAPI{store: store}
 }
 
+// SetEngine sets the live remap engine to reload after rule changes
+func (a *RemapAPI) SetEngine(engine RemapEngine) {
+	a.engine = engine
+}
+
+// reloadEngine refreshes the live rule set; failures are logged and picked up
+// by the engine's periodic reload
+func (a *RemapAPI) reloadEngine() {
+	if a.engine == nil {
+		return
+	}
+	if err := a.engine.Reload(); err != nil {
+		log.Printf("admin: failed to reload remap rules: %v", err)
+	}
+}
+
+// validateRemapRule checks strategy-specific required fields
+func validateRemapRule(rule *RemapRule) string {
+	if rule.FromModel == "" {
+		return "from_model is required"
+	}
+	switch rule.Strategy {
+	case RemapStrategyDirect:
+		if rule.ToModel == "" {
+			return "to_model is required"
+		}
+		if rule.ToProvider == "" {
+			return "to_provider is required"
+		}
+	case RemapStrategyCheapest, RemapStrategyFastest:
+		if len(rule.Candidates) == 0 {
+			return "candidates are required for " + rule.Strategy + " strategy"
+		}
+		for _, candidate := range rule.Candidates {
+			if !strings.Contains(candidate, "/") {
+				return "candidates must be in provider/model format"
+			}
+		}
+	default:
+		return "strategy must be direct, cheapest or fastest"
+	}
+	return ""
+}
+
 // RemapCreateRequest represents the request body for creating a remap rule
 type RemapCreateRequest struct {
-	ClientID   string `json:"client_id"`
-	FromModel  string `json:"from_model"`
-	ToModel    string `json:"to_model"`
-	ToProvider string `json:"to_provider"`
-	Priority   int    `json:"priority"`
-	Enabled    *bool  `json:"enabled,omitempty"`
+	ClientID   string   `json:"client_id"`
+	FromModel  string   `json:"from_model"`
+	ToModel    string   `json:"to_model"`
+	ToProvider string   `json:"to_provider"`
+	Strategy   string   `json:"strategy,omitempty"`
+	Candidates []string `json:"candidates,omitempty"`
+	Priority   int      `json:"priority"`
+	Enabled    *bool    `json:"enabled,omitempty"`
 }
 
 // RemapUpdateRequest represents the request body for updating a remap rule
 type RemapUpdateRequest struct {
-	ClientID   *string `json:"client_id,omitempty"`
-	FromModel  *string `json:"from_model,omitempty"`
-	ToModel    *string `json:"to_model,omitempty"`
-	ToProvider *string `json:"to_provider,omitempty"`
-	Priority   *int    `json:"priority,omitempty"`
-	Enabled    *bool   `json:"enabled,omitempty"`
+	ClientID   *string   `json:"client_id,omitempty"`
+	FromModel  *string   `json:"from_model,omitempty"`
+	ToModel    *string   `json:"to_model,omitempty"`
+	ToProvider *string   `json:"to_provider,omitempty"`
+	Strategy   *string   `json:"strategy,omitempty"`
+	Candidates *[]string `json:"candidates,omitempty"`
+	Priority   *int      `json:"priority,omitempty"`
+	Enabled    *bool     `json:"enabled,omitempty"`
 }
 
 // HandleRemaps handles GET /api/rules/remap (list) and POST /api/rules/remap (create)
@@ -72,6 +140,21 @@ func (a *RemapAPI) HandleRemaps(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// remapList pages GET /api/rules/remap in the order rules are tried.
+// client_id is passed to the store, which also returns global rules.
+var remapList = listSpec[*RemapRule]{
+	id: func(r *RemapRule) string { return strconv.Itoa(r.ID) },
+	fields: map[string]listField[*RemapRule]{
+		"id":          intField(func(r *RemapRule) int { return r.ID }),
+		"from_model":  strField(func(r *RemapRule) string { return r.FromModel }),
+		"to_model":    strField(func(r *RemapRule) string { return r.ToModel }),
+		"to_provider": strField(func(r *RemapRule) string { return r.ToProvider }),
+		"strategy":    strField(func(r *RemapRule) string { return r.Strategy }),
+		"priority":    intField(func(r *RemapRule) int { return r.Priority }),
+		"enabled":     boolField(func(r *RemapRule) bool { return r.Enabled }),
+	},
+}
+
 // handleListRemaps handles GET /api/rules/remap
 func (a *RemapAPI) handleListRemaps(w http.ResponseWriter, r *http.Request) {
 	// Check for client_id filter
@@ -91,11 +174,7 @@ func (a *RemapAPI) handleListRemaps(w http.ResponseWriter, r *http.Request) {
 		rules = []*RemapRule{}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"rules": rules,
-		"count": len(rules),
-	})
+	writeList(w, r, rules, remapList)
 }
 
 // handleCreateRemap handles POST /api/rules/remap
@@ -106,45 +185,40 @@ func (a *RemapAPI) handleCreateRemap(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate required fields
-	if req.ClientID == "" {
-		http.Error(w, "client_id is required", http.StatusBadRequest)
-		return
-	}
-	if req.FromModel == "" {
-		http.Error(w, "from_model is required", http.StatusBadRequest)
-		return
-	}
-	if req.ToModel == "" {
-		http.Error(w, "to_model is required", http.StatusBadRequest)
-		return
-	}
-	if req.ToProvider == "" {
-		http.Error(w, "to_provider is required", http.StatusBadRequest)
-		return
-	}
-
 	// Default enabled to true if not specified
 	enabled := true
 	if req.Enabled != nil {
 		enabled = *req.Enabled
 	}
 
-	// Create rule
+	strategy := req.Strategy
+	if strategy == "" {
+		strategy = RemapStrategyDirect
+	}
+
+	// Create rule (empty client_id creates a global rule)
 	rule := &RemapRule{
 		ClientID:   req.ClientID,
 		FromModel:  req.FromModel,
 		ToModel:    req.ToModel,
 		ToProvider: req.ToProvider,
+		Strategy:   strategy,
+		Candidates: req.Candidates,
 		Priority:   req.Priority,
 		Enabled:    enabled,
 		CreatedAt:  time.Now(),
 	}
 
+	if msg := validateRemapRule(rule); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
 	if err := a.store.Create(rule); err != nil {
 		http.Error(w, "Failed to create remap rule: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	a.reloadEngine()
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -226,6 +300,12 @@ func (a *RemapAPI) handleUpdateRemap(w http.ResponseWriter, r *http.Request, rul
 	if req.ToProvider != nil {
 		rule.ToProvider = *req.ToProvider
 	}
+	if req.Strategy != nil {
+		rule.Strategy = *req.Strategy
+	}
+	if req.Candidates != nil {
+		rule.Candidates = *req.Candidates
+	}
 	if req.Priority != nil {
 		rule.Priority = *req.Priority
 	}
@@ -233,11 +313,20 @@ func (a *RemapAPI) handleUpdateRemap(w http.ResponseWriter, r *http.Request, rul
 		rule.Enabled = *req.Enabled
 	}
 
+	if rule.Strategy == "" {
+		rule.Strategy = RemapStrategyDirect
+	}
+	if msg := validateRemapRule(rule); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
 	// Update rule
 	if err := a.store.Update(rule); err != nil {
 		http.Error(w, "Failed to update remap rule: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	a.reloadEngine()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(rule)
@@ -261,6 +350,63 @@ func (a *RemapAPI) handleDeleteRemap(w http.ResponseWriter, r *http.Request, rul
 		http.Error(w, "Failed to delete remap rule: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	a.reloadEngine()
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// HandleReload handles POST /api/rules/remap/reload
+func (a *RemapAPI) HandleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.engine == nil {
+		http.Error(w, "Remap engine not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := a.engine.Reload(); err != nil {
+		http.Error(w, "Failed to reload remap rules: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "reloaded",
+	})
+}
+
+// HandleResolve handles GET /api/rules/remap/resolve?model=...&client_id=...
+// It reports what the live rule set would do with a model, without proxying
+func (a *RemapAPI) HandleResolve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.engine == nil {
+		http.Error(w, "Remap engine not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	model := r.URL.Query().Get("model")
+	if model == "" {
+		http.Error(w, "model is required", http.StatusBadRequest)
+		return
+	}
+	clientID := r.URL.Query().Get("client_id")
+
+	remapped, provider, err := a.engine.RemapModel(r.Context(), model, clientID)
+	if err != nil {
+		http.Error(w, "Failed to resolve model: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"model":     model,
+		"client_id": clientID,
+		"remapped":  remapped,
+		"provider":  provider,
+	})
+}
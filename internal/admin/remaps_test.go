@@ -2,7 +2,9 @@ package admin
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -104,8 +106,8 @@ func TestRemapAPI_HandleListRemaps_Empty(t *testing.T) {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	if response["count"].(float64) != 0 {
-		t.Errorf("expected 0 rules, got %v", response["count"])
+	if response["total"].(float64) != 0 {
+		t.Errorf("expected 0 rules, got %v", response["total"])
 	}
 }
 
@@ -194,7 +196,7 @@ func TestRemapAPI_HandleCreateRemap_Disabled(t *testing.T) {
 	}
 }
 
-func TestRemapAPI_HandleCreateRemap_MissingClientID(t *testing.T) {
+func TestRemapAPI_HandleCreateRemap_GlobalRule(t *testing.T) {
 	store := newMockRemapStore()
 	api := NewRemapAPI(store)
 
@@ -211,8 +213,78 @@ func TestRemapAPI_HandleCreateRemap_MissingClientID(t *testing.T) {
 
 	api.HandleRemaps(w, req)
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("expected status 400, got %d", w.Code)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp RemapRule
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.ClientID != "" {
+		t.Errorf("expected global rule, got client_id %q", resp.ClientID)
+	}
+	if resp.Strategy != RemapStrategyDirect {
+		t.Errorf("expected strategy %q, got %q", RemapStrategyDirect, resp.Strategy)
+	}
+}
+
+func TestRemapAPI_HandleCreateRemap_CheapestStrategy(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantStatus int
+	}{
+		{
+			name: "valid candidates",
+			body: map[string]interface{}{
+				"from_model": "gpt-4*",
+				"strategy":   "cheapest",
+				"candidates": []string{"openai/gpt-4o-mini", "together/meta-llama/Llama-3.3-70B"},
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "missing candidates",
+			body: map[string]interface{}{
+				"from_model": "gpt-4*",
+				"strategy":   "cheapest",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "candidate without provider",
+			body: map[string]interface{}{
+				"from_model": "gpt-4*",
+				"strategy":   "cheapest",
+				"candidates": []string{"gpt-4o-mini"},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown strategy",
+			body: map[string]interface{}{
+				"from_model":  "gpt-4*",
+				"to_model":    "gpt-4o",
+				"to_provider": "openai",
+				"strategy":    "random",
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := NewRemapAPI(newMockRemapStore())
+
+			jsonBody, _ := json.Marshal(tt.body)
+			req := httptest.NewRequest("POST", "/api/rules/remap", bytes.NewBuffer(jsonBody))
+			w := httptest.NewRecorder()
+
+			api.HandleRemaps(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
 	}
 }
 
@@ -304,8 +376,8 @@ func TestRemapAPI_HandleListRemaps(t *testing.T) {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	if response["count"].(float64) != 2 {
-		t.Errorf("expected 2 rules, got %v", response["count"])
+	if response["total"].(float64) != 2 {
+		t.Errorf("expected 2 rules, got %v", response["total"])
 	}
 }
 
@@ -331,8 +403,8 @@ func TestRemapAPI_HandleListRemaps_FilterByClientID(t *testing.T) {
 		t.Fatalf("failed to decode response: %v", err)
 	}
 
-	if response["count"].(float64) != 1 {
-		t.Errorf("expected 1 rule, got %v", response["count"])
+	if response["total"].(float64) != 1 {
+		t.Errorf("expected 1 rule, got %v", response["total"])
 	}
 }
 
@@ -565,3 +637,123 @@ func TestRemapAPI_HandleRemapByID_MethodNotAllowed(t *testing.T) {
 		t.Errorf("expected status 405, got %d", w.Code)
 	}
 }
+
+// mockRemapEngine records reloads and resolves models from a fixed table
+type mockRemapEngine struct {
+	reloads   int
+	reloadErr error
+	remaps    map[string][2]string
+}
+
+func (m *mockRemapEngine) Reload() error {
+	m.reloads++
+	return m.reloadErr
+}
+
+func (m *mockRemapEngine) RemapModel(ctx context.Context, model string, clientID string) (string, string, error) {
+	if target, ok := m.remaps[clientID+"|"+model]; ok {
+		return target[0], target[1], nil
+	}
+	return model, "", nil
+}
+
+func TestRemapAPI_ReloadsEngineOnChanges(t *testing.T) {
+	store := newMockRemapStore()
+	engine := &mockRemapEngine{}
+	api := NewRemapAPI(store)
+	api.SetEngine(engine)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"client_id":   "client-1",
+		"from_model":  "gpt-4",
+		"to_model":    "claude-sonnet-4-5",
+		"to_provider": "anthropic",
+	})
+	w := httptest.NewRecorder()
+	api.HandleRemaps(w, httptest.NewRequest("POST", "/api/rules/remap", bytes.NewBuffer(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected status 201, got %d", w.Code)
+	}
+
+	body, _ = json.Marshal(map[string]interface{}{"priority": 5})
+	w = httptest.NewRecorder()
+	api.HandleRemapByID(w, httptest.NewRequest("PATCH", "/api/rules/remap/1", bytes.NewBuffer(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: expected status 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	api.HandleRemapByID(w, httptest.NewRequest("DELETE", "/api/rules/remap/1", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected status 204, got %d", w.Code)
+	}
+
+	if engine.reloads != 3 {
+		t.Errorf("expected 3 reloads, got %d", engine.reloads)
+	}
+}
+
+func TestRemapAPI_HandleReload(t *testing.T) {
+	api := NewRemapAPI(newMockRemapStore())
+
+	w := httptest.NewRecorder()
+	api.HandleReload(w, httptest.NewRequest("POST", "/api/rules/remap/reload", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 without engine, got %d", w.Code)
+	}
+
+	engine := &mockRemapEngine{}
+	api.SetEngine(engine)
+
+	w = httptest.NewRecorder()
+	api.HandleReload(w, httptest.NewRequest("GET", "/api/rules/remap/reload", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	api.HandleReload(w, httptest.NewRequest("POST", "/api/rules/remap/reload", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+	if engine.reloads != 1 {
+		t.Errorf("expected 1 reload, got %d", engine.reloads)
+	}
+
+	engine.reloadErr = errors.New("database locked")
+	w = httptest.NewRecorder()
+	api.HandleReload(w, httptest.NewRequest("POST", "/api/rules/remap/reload", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", w.Code)
+	}
+}
+
+func TestRemapAPI_HandleResolve(t *testing.T) {
+	api := NewRemapAPI(newMockRemapStore())
+	api.SetEngine(&mockRemapEngine{
+		remaps: map[string][2]string{
+			"client-1|gpt-4": {"claude-sonnet-4-5", "anthropic"},
+		},
+	})
+
+	w := httptest.NewRecorder()
+	api.HandleResolve(w, httptest.NewRequest("GET", "/api/rules/remap/resolve", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without model, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	api.HandleResolve(w, httptest.NewRequest("GET", "/api/rules/remap/resolve?model=gpt-4&client_id=client-1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var resp map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp["remapped"] != "claude-sonnet-4-5" {
+		t.Errorf("expected remapped claude-sonnet-4-5, got %v", resp["remapped"])
+	}
+	if resp["provider"] != "anthropic" {
+		t.Errorf("expected provider anthropic, got %v", resp["provider"])
+	}
+}
@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"net/http"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// RetryBudgetSource reports the retry budget left per provider (such as the
+// proxies' budget, or an *sdk.Client's)
+type RetryBudgetSource interface {
+	RetryBudgets() []mshttp.RetryBudgetStats
+}
+
+// RetryBudgetAPI handles retry budget endpoints
+type RetryBudgetAPI struct {
+	source RetryBudgetSource
+}
+
+// NewRetryBudgetAPI creates a new RetryBudgetAPI
+func NewRetryBudgetAPI(source RetryBudgetSource) *RetryBudgetAPI {
+	return &RetryBudgetAPI{source: source}
+}
+
+// HandleRetryBudgets handles GET /api/retry-budgets: retries left, granted
+// and refused for each provider
+func (a *RetryBudgetAPI) HandleRetryBudgets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeList(w, r, a.source.RetryBudgets(), retryBudgetList)
+}
+
+// retryBudgetList pages GET /api/retry-budgets
+var retryBudgetList = listSpec[mshttp.RetryBudgetStats]{
+	id: func(s mshttp.RetryBudgetStats) string { return s.Provider },
+	fields: map[string]listField[mshttp.RetryBudgetStats]{
+		"provider":  strField(func(s mshttp.RetryBudgetStats) string { return s.Provider }),
+		"remaining": numField(func(s mshttp.RetryBudgetStats) float64 { return s.Remaining }),
+		"allowed":   numField(func(s mshttp.RetryBudgetStats) float64 { return float64(s.Allowed) }),
+		"denied":    numField(func(s mshttp.RetryBudgetStats) float64 { return float64(s.Denied) }),
+	},
+	sort: "provider",
+}
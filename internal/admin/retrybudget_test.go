@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+type fixedRetryBudgets []mshttp.RetryBudgetStats
+
+func (b fixedRetryBudgets) RetryBudgets() []mshttp.RetryBudgetStats { return b }
+
+func TestRetryBudgetAPI(t *testing.T) {
+	api := NewRetryBudgetAPI(fixedRetryBudgets{
+		{Provider: "openai", Remaining: 10, Capacity: 10},
+		{Provider: "anthropic", Remaining: 0.5, Capacity: 10, Allowed: 12, Denied: 3},
+	})
+
+	rec := httptest.NewRecorder()
+	api.HandleRetryBudgets(rec, httptest.NewRequest(http.MethodGet, "/api/retry-budgets?sort=remaining", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var resp struct {
+		Budgets []mshttp.RetryBudgetStats `json:"items"`
+		Total   int                       `json:"total"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Total != 2 || resp.Budgets[0].Provider != "anthropic" || resp.Budgets[0].Denied != 3 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleRetryBudgets(rec, httptest.NewRequest(http.MethodPost, "/api/retry-budgets", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// RouteExplainer explains where a request would be routed
+type RouteExplainer interface {
+	// ExplainRoute returns the routing decision for a request to api
+	// ("openai" or "anthropic"), or an error for an unknown api
+	ExplainRoute(ctx context.Context, api, model, clientID, provider string) (*proxy.RouteDecision, error)
+}
+
+// RouteAPI handles routing debug endpoints
+type RouteAPI struct {
+	explainer RouteExplainer
+}
+
+// NewRouteAPI creates a new RouteAPI
+func NewRouteAPI(explainer RouteExplainer) *RouteAPI {
+	return &RouteAPI{explainer: explainer}
+}
+
+// RouteExplainRequest is a hypothetical request to explain. Other fields
+// of a completion request, such as messages, may be included and are
+// ignored: they do not affect routing.
+type RouteExplainRequest struct {
+	API      string `json:"api"` // "openai" (default) or "anthropic"
+	Model    string `json:"model"`
+	ClientID string `json:"client_id"`
+	Provider string `json:"provider"` // As the X-Modelscan-Provider header
+}
+
+// HandleExplain handles POST /api/route/explain, returning the full
+// decision trace for a hypothetical request without sending it
+func (a *RouteAPI) HandleExplain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req RouteExplainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Model == "" {
+		http.Error(w, "model is required", http.StatusBadRequest)
+		return
+	}
+	if req.API == "" {
+		req.API = "openai"
+	}
+
+	decision, err := a.explainer.ExplainRoute(r.Context(), req.API, req.Model, req.ClientID, req.Provider)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(decision)
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+type fakeRouteExplainer struct{}
+
+func (fakeRouteExplainer) ExplainRoute(ctx context.Context, api, model, clientID, provider string) (*proxy.RouteDecision, error) {
+	if api != "openai" && api != "anthropic" {
+		return nil, fmt.Errorf("unknown api %q", api)
+	}
+	return &proxy.RouteDecision{API: api, Model: model, ClientID: clientID, Provider: "groq", UpstreamModel: "llama-3.3-70b",
+		Trace: []string{"routed to llama-3.3-70b on groq"}}, nil
+}
+
+func TestRouteAPI_Explain(t *testing.T) {
+	api := NewRouteAPI(fakeRouteExplainer{})
+
+	rec := httptest.NewRecorder()
+	api.HandleExplain(rec, httptest.NewRequest(http.MethodPost, "/api/route/explain",
+		strings.NewReader(`{"model":"cheap","client_id":"app","messages":[{"role":"user","content":"hi"}]}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var decision proxy.RouteDecision
+	if err := json.NewDecoder(rec.Body).Decode(&decision); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if decision.API != "openai" || decision.ClientID != "app" || decision.Provider != "groq" || len(decision.Trace) != 1 {
+		t.Errorf("unexpected decision %+v", decision)
+	}
+
+	for _, body := range []string{`{}`, `{"model":"cheap","api":"gemini"}`, `not json`} {
+		rec = httptest.NewRecorder()
+		api.HandleExplain(rec, httptest.NewRequest(http.MethodPost, "/api/route/explain", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", body, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleExplain(rec, httptest.NewRequest(http.MethodGet, "/api/route/explain", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
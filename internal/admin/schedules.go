@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/scheduler"
+)
+
+// Scheduler exposes provider schedules and manual triggers (usually a *scheduler.Scheduler)
+type Scheduler interface {
+	Statuses() []scheduler.Status
+	Trigger(ctx context.Context, provider string, tasks ...scheduler.Task) ([]*scheduler.Run, error)
+}
+
+// ScheduleRunStore provides access to persisted run history
+type ScheduleRunStore interface {
+	ListRuns(filter ScheduleRunFilter) ([]*scheduler.Run, error)
+}
+
+// ScheduleRunFilter narrows run history by provider and task
+type ScheduleRunFilter struct {
+	Provider string
+	Task     string
+}
+
+// SchedulerAPI handles scheduled re-validation and refresh endpoints
+type SchedulerAPI struct {
+	scheduler Scheduler
+	runs      ScheduleRunStore
+}
+
+// NewSchedulerAPI creates a new SchedulerAPI
+func NewSchedulerAPI(scheduler Scheduler, runs ScheduleRunStore) *SchedulerAPI {
+	return &SchedulerAPI{scheduler: scheduler, runs: runs}
+}
+
+// scheduleList pages GET /api/schedules
+var scheduleList = listSpec[scheduler.Status]{
+	id: func(s scheduler.Status) string { return s.Provider },
+	fields: map[string]listField[scheduler.Status]{
+		"provider":    strField(func(s scheduler.Status) string { return s.Provider }),
+		"next_run":    timeField(func(s scheduler.Status) time.Time { return s.NextRun }),
+		"last_status": strField(func(s scheduler.Status) string { return s.LastStatus }),
+		"running":     boolField(func(s scheduler.Status) bool { return s.Running }),
+	},
+	sort: "provider",
+}
+
+// HandleSchedules handles GET /api/schedules
+func (a *SchedulerAPI) HandleSchedules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeList(w, r, a.scheduler.Statuses(), scheduleList)
+}
+
+// runList pages GET /api/schedules/runs, newest first. provider and task
+// are passed to the store.
+var runList = listSpec[*scheduler.Run]{
+	id: func(r *scheduler.Run) string { return strconv.Itoa(r.ID) },
+	fields: map[string]listField[*scheduler.Run]{
+		"id":         intField(func(r *scheduler.Run) int { return r.ID }),
+		"trigger":    strField(func(r *scheduler.Run) string { return r.Trigger }),
+		"status":     strField(func(r *scheduler.Run) string { return r.Status }),
+		"started_at": timeField(func(r *scheduler.Run) time.Time { return r.StartedAt }),
+	},
+	sort: "-started_at",
+}
+
+// HandleRuns handles GET /api/schedules/runs?provider=...&task=...
+func (a *SchedulerAPI) HandleRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if a.runs == nil {
+		http.Error(w, "Run history not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := ScheduleRunFilter{
+		Provider: q.Get("provider"),
+		Task:     q.Get("task"),
+	}
+	if filter.Task != "" {
+		if _, err := scheduler.ParseTask(filter.Task); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	runs, err := a.runs.ListRuns(filter)
+	if err != nil {
+		http.Error(w, "Failed to list runs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if runs == nil {
+		runs = []*scheduler.Run{}
+	}
+
+	writeList(w, r, runs, runList)
+}
+
+// HandleTrigger handles POST /api/schedules/{provider}/run?task=...
+// The task parameter may be repeated; without it all scheduled tasks run.
+func (a *SchedulerAPI) HandleTrigger(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/schedules/")
+	parts := strings.Split(path, "/")
+	if parts[0] == "" {
+		http.Error(w, "Provider required", http.StatusBadRequest)
+		return
+	}
+	if len(parts) != 2 || parts[1] != "run" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var tasks []scheduler.Task
+	for _, name := range r.URL.Query()["task"] {
+		task, err := scheduler.ParseTask(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tasks = append(tasks, task)
+	}
+
+	runs, err := a.scheduler.Trigger(r.Context(), parts[0], tasks...)
+	if errors.Is(err, scheduler.ErrUnknownProvider) {
+		http.Error(w, "No schedule for provider "+parts[0], http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to run schedule: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status := scheduler.StatusSuccess
+	for _, run := range runs {
+		if run.Status == scheduler.StatusFailed {
+			status = scheduler.StatusFailed
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"provider": parts[0],
+		"status":   status,
+		"runs":     runs,
+	})
+}
@@ -0,0 +1,162 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/scheduler"
+)
+
+// mockScheduler implements Scheduler for testing
+type mockScheduler struct {
+	providers []string
+	failTask  scheduler.Task
+	triggered []scheduler.Task
+}
+
+func (m *mockScheduler) Statuses() []scheduler.Status {
+	statuses := make([]scheduler.Status, len(m.providers))
+	for i, p := range m.providers {
+		statuses[i] = scheduler.Status{Provider: p, Interval: time.Hour, Tasks: scheduler.AllTasks}
+	}
+	return statuses
+}
+
+func (m *mockScheduler) Trigger(ctx context.Context, provider string, tasks ...scheduler.Task) ([]*scheduler.Run, error) {
+	known := false
+	for _, p := range m.providers {
+		known = known || p == provider
+	}
+	if !known {
+		return nil, fmt.Errorf("%w %s", scheduler.ErrUnknownProvider, provider)
+	}
+	if len(tasks) == 0 {
+		tasks = scheduler.AllTasks
+	}
+	m.triggered = tasks
+
+	runs := make([]*scheduler.Run, len(tasks))
+	for i, task := range tasks {
+		runs[i] = &scheduler.Run{Provider: provider, Task: task, Trigger: scheduler.TriggerManual, Status: scheduler.StatusSuccess}
+		if task == m.failTask {
+			runs[i].Status = scheduler.StatusFailed
+		}
+	}
+	return runs, nil
+}
+
+// mockRunStore implements ScheduleRunStore for testing
+type mockRunStore struct {
+	runs       []*scheduler.Run
+	lastFilter ScheduleRunFilter
+}
+
+func (m *mockRunStore) ListRuns(filter ScheduleRunFilter) ([]*scheduler.Run, error) {
+	m.lastFilter = filter
+	return m.runs, nil
+}
+
+func TestSchedulerAPI_Schedules(t *testing.T) {
+	api := NewSchedulerAPI(&mockScheduler{providers: []string{"anthropic", "openai"}}, nil)
+
+	w := httptest.NewRecorder()
+	api.HandleSchedules(w, httptest.NewRequest(http.MethodGet, "/api/schedules", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Schedules []scheduler.Status `json:"items"`
+		Total     int                `json:"total"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Total != 2 || resp.Schedules[1].Provider != "openai" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	// Run history is optional
+	w = httptest.NewRecorder()
+	api.HandleRuns(w, httptest.NewRequest(http.MethodGet, "/api/schedules/runs", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 without run store, got %d", w.Code)
+	}
+}
+
+func TestSchedulerAPI_Runs(t *testing.T) {
+	store := &mockRunStore{runs: []*scheduler.Run{
+		{ID: 1, Provider: "openai", Task: scheduler.TaskPricing, Status: scheduler.StatusSuccess},
+	}}
+	api := NewSchedulerAPI(&mockScheduler{}, store)
+
+	w := httptest.NewRecorder()
+	api.HandleRuns(w, httptest.NewRequest(http.MethodGet, "/api/schedules/runs?provider=openai&task=pricing&limit=5", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	want := ScheduleRunFilter{Provider: "openai", Task: "pricing"}
+	if store.lastFilter != want {
+		t.Errorf("unexpected filter: %+v", store.lastFilter)
+	}
+
+	for _, query := range []string{"?task=deploy", "?limit=-1"} {
+		w = httptest.NewRecorder()
+		api.HandleRuns(w, httptest.NewRequest(http.MethodGet, "/api/schedules/runs"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestSchedulerAPI_Trigger(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		want       int
+		wantStatus string
+		wantTasks  []scheduler.Task
+	}{
+		{"all tasks", http.MethodPost, "/api/schedules/openai/run", http.StatusOK, scheduler.StatusFailed, scheduler.AllTasks},
+		{"single task", http.MethodPost, "/api/schedules/openai/run?task=pricing", http.StatusOK, scheduler.StatusSuccess, []scheduler.Task{scheduler.TaskPricing}},
+		{"unknown task", http.MethodPost, "/api/schedules/openai/run?task=deploy", http.StatusBadRequest, "", nil},
+		{"unknown provider", http.MethodPost, "/api/schedules/acme/run", http.StatusNotFound, "", nil},
+		{"missing provider", http.MethodPost, "/api/schedules/", http.StatusBadRequest, "", nil},
+		{"unknown action", http.MethodPost, "/api/schedules/openai/pause", http.StatusNotFound, "", nil},
+		{"wrong method", http.MethodGet, "/api/schedules/openai/run", http.StatusMethodNotAllowed, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched := &mockScheduler{providers: []string{"openai"}, failTask: scheduler.TaskValidate}
+			api := NewSchedulerAPI(sched, nil)
+
+			w := httptest.NewRecorder()
+			api.HandleTrigger(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.want {
+				t.Fatalf("expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Status string           `json:"status"`
+				Runs   []*scheduler.Run `json:"runs"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Status != tt.wantStatus || len(resp.Runs) != len(tt.wantTasks) {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+			if fmt.Sprint(sched.triggered) != fmt.Sprint(tt.wantTasks) {
+				t.Errorf("expected tasks %v, got %v", tt.wantTasks, sched.triggered)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/scraper"
+)
+
+// PricingScraper scrapes provider documentation and manages the resulting
+// staged changes (usually a *scraper.Scraper)
+type PricingScraper interface {
+	Providers() []string
+	Stage(ctx context.Context, provider string) (*scraper.StageResult, error)
+	ListChanges(status, provider string) ([]*scraper.Change, error)
+	GetChange(id int64) (*scraper.Change, error)
+	ApproveChange(id int64) error
+	RejectChange(id int64) error
+}
+
+// ScraperAPI handles scraped rate limit and pricing review endpoints
+type ScraperAPI struct {
+	scraper PricingScraper
+}
+
+// NewScraperAPI creates a new ScraperAPI
+func NewScraperAPI(scraper PricingScraper) *ScraperAPI {
+	return &ScraperAPI{scraper: scraper}
+}
+
+// HandleRun handles POST /api/scraper/run?provider=...
+// Without a provider every configured source is scraped.
+func (a *ScraperAPI) HandleRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	providers := a.scraper.Providers()
+	if provider := r.URL.Query().Get("provider"); provider != "" {
+		providers = []string{provider}
+	}
+
+	results := make([]*scraper.StageResult, 0, len(providers))
+	failures := map[string]string{}
+	for _, provider := range providers {
+		result, err := a.scraper.Stage(r.Context(), provider)
+		if errors.Is(err, scraper.ErrUnknownSource) {
+			http.Error(w, "No scraper source for provider "+provider, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			failures[provider] = err.Error()
+			continue
+		}
+		results = append(results, result)
+	}
+
+	status := http.StatusOK
+	if len(results) == 0 && len(failures) > 0 {
+		status = http.StatusBadGateway
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results": results,
+		"errors":  failures,
+	})
+}
+
+// changeList pages GET /api/scraper/changes, newest first. status and
+// provider are passed to the scraper.
+var changeList = listSpec[*scraper.Change]{
+	id: func(c *scraper.Change) string { return strconv.FormatInt(c.ID, 10) },
+	fields: map[string]listField[*scraper.Change]{
+		"id":         numField(func(c *scraper.Change) float64 { return float64(c.ID) }),
+		"kind":       strField(func(c *scraper.Change) string { return c.Kind }),
+		"plan_type":  strField(func(c *scraper.Change) string { return c.PlanType }),
+		"model_id":   strField(func(c *scraper.Change) string { return c.ModelID }),
+		"created_at": timeField(func(c *scraper.Change) time.Time { return c.CreatedAt }),
+	},
+	sort: "-id",
+}
+
+// HandleChanges handles GET /api/scraper/changes?status=...&provider=...
+// Status defaults to pending; "all" lists every change.
+func (a *ScraperAPI) HandleChanges(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	status := q.Get("status")
+	switch status {
+	case "":
+		status = "pending"
+	case "all":
+		status = ""
+	case "pending", "approved", "rejected":
+	default:
+		http.Error(w, "Invalid status (want pending, approved, rejected or all)", http.StatusBadRequest)
+		return
+	}
+
+	changes, err := a.scraper.ListChanges(status, q.Get("provider"))
+	if err != nil {
+		http.Error(w, "Failed to list changes: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if changes == nil {
+		changes = []*scraper.Change{}
+	}
+
+	writeList(w, r, changes, changeList)
+}
+
+// HandleChangeByID handles GET /api/scraper/changes/{id} and
+// POST /api/scraper/changes/{id}/approve or /reject
+func (a *ScraperAPI) HandleChangeByID(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/scraper/changes/")
+	parts := strings.Split(path, "/")
+	if parts[0] == "" {
+		http.Error(w, "Change ID required", http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid change ID", http.StatusBadRequest)
+		return
+	}
+
+	change, err := a.scraper.GetChange(id)
+	if err != nil {
+		http.Error(w, "Failed to get change: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if change == nil {
+		http.Error(w, "Change not found", http.StatusNotFound)
+		return
+	}
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(change)
+		return
+	}
+
+	var review func(int64) error
+	switch {
+	case len(parts) == 2 && parts[1] == "approve":
+		review = a.scraper.ApproveChange
+	case len(parts) == 2 && parts[1] == "reject":
+		review = a.scraper.RejectChange
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := review(id); err != nil {
+		if errors.Is(err, scraper.ErrNotPending) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to review change: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	change, err = a.scraper.GetChange(id)
+	if err != nil {
+		http.Error(w, "Failed to get change: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(change)
+}
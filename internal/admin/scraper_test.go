@@ -0,0 +1,178 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/scraper"
+)
+
+// mockPricingScraper implements PricingScraper for testing
+type mockPricingScraper struct {
+	changes    map[int64]*scraper.Change
+	failStage  map[string]bool
+	staged     []string
+	lastStatus string
+}
+
+func newMockPricingScraper() *mockPricingScraper {
+	return &mockPricingScraper{
+		changes: map[int64]*scraper.Change{
+			1: {ID: 1, Kind: scraper.KindPricing, Provider: "openai", Status: "pending"},
+			2: {ID: 2, Kind: scraper.KindRateLimit, Provider: "groq", Status: "approved"},
+		},
+		failStage: map[string]bool{},
+	}
+}
+
+func (m *mockPricingScraper) Providers() []string { return []string{"groq", "openai"} }
+
+func (m *mockPricingScraper) Stage(ctx context.Context, provider string) (*scraper.StageResult, error) {
+	if provider == "acme" {
+		return nil, fmt.Errorf("%w %s", scraper.ErrUnknownSource, provider)
+	}
+	if m.failStage[provider] {
+		return nil, errors.New("status 503")
+	}
+	m.staged = append(m.staged, provider)
+	return &scraper.StageResult{Provider: provider, Staged: 1}, nil
+}
+
+func (m *mockPricingScraper) ListChanges(status, provider string) ([]*scraper.Change, error) {
+	m.lastStatus = status
+	var list []*scraper.Change
+	for _, c := range m.changes {
+		if (status == "" || c.Status == status) && (provider == "" || c.Provider == provider) {
+			list = append(list, c)
+		}
+	}
+	return list, nil
+}
+
+func (m *mockPricingScraper) GetChange(id int64) (*scraper.Change, error) {
+	return m.changes[id], nil
+}
+
+func (m *mockPricingScraper) review(id int64, status string) error {
+	if m.changes[id].Status != "pending" {
+		return fmt.Errorf("%w: %d", scraper.ErrNotPending, id)
+	}
+	m.changes[id].Status = status
+	return nil
+}
+
+func (m *mockPricingScraper) ApproveChange(id int64) error { return m.review(id, "approved") }
+func (m *mockPricingScraper) RejectChange(id int64) error  { return m.review(id, "rejected") }
+
+func TestScraperAPI_Run(t *testing.T) {
+	m := newMockPricingScraper()
+	m.failStage["groq"] = true
+	api := NewScraperAPI(m)
+
+	w := httptest.NewRecorder()
+	api.HandleRun(w, httptest.NewRequest(http.MethodPost, "/api/scraper/run", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Results []scraper.StageResult `json:"results"`
+		Errors  map[string]string     `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Provider != "openai" || resp.Errors["groq"] == "" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"single provider", http.MethodPost, "/api/scraper/run?provider=openai", http.StatusOK},
+		{"all failed", http.MethodPost, "/api/scraper/run?provider=groq", http.StatusBadGateway},
+		{"unknown provider", http.MethodPost, "/api/scraper/run?provider=acme", http.StatusNotFound},
+		{"wrong method", http.MethodGet, "/api/scraper/run", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			api.HandleRun(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestScraperAPI_Changes(t *testing.T) {
+	m := newMockPricingScraper()
+	api := NewScraperAPI(m)
+
+	tests := []struct {
+		query      string
+		want       int
+		wantStatus string
+		wantCount  int
+	}{
+		{"", http.StatusOK, "pending", 1},
+		{"?status=all", http.StatusOK, "", 2},
+		{"?status=approved&provider=groq", http.StatusOK, "approved", 1},
+		{"?status=merged", http.StatusBadRequest, "", 0},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		api.HandleChanges(w, httptest.NewRequest(http.MethodGet, "/api/scraper/changes"+tt.query, nil))
+		if w.Code != tt.want {
+			t.Errorf("%q: expected status %d, got %d", tt.query, tt.want, w.Code)
+			continue
+		}
+		if tt.want != http.StatusOK {
+			continue
+		}
+		var resp struct {
+			Total int `json:"total"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		if m.lastStatus != tt.wantStatus || resp.Total != tt.wantCount {
+			t.Errorf("%q: got status filter %q and %d changes", tt.query, m.lastStatus, resp.Total)
+		}
+	}
+}
+
+func TestScraperAPI_ChangeByID(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"get", http.MethodGet, "/api/scraper/changes/1", http.StatusOK},
+		{"get missing", http.MethodGet, "/api/scraper/changes/9", http.StatusNotFound},
+		{"invalid id", http.MethodGet, "/api/scraper/changes/abc", http.StatusBadRequest},
+		{"missing id", http.MethodGet, "/api/scraper/changes/", http.StatusBadRequest},
+		{"approve", http.MethodPost, "/api/scraper/changes/1/approve", http.StatusOK},
+		{"reject", http.MethodPost, "/api/scraper/changes/1/reject", http.StatusOK},
+		{"approve reviewed", http.MethodPost, "/api/scraper/changes/2/approve", http.StatusConflict},
+		{"approve wrong method", http.MethodGet, "/api/scraper/changes/1/approve", http.StatusMethodNotAllowed},
+		{"delete", http.MethodDelete, "/api/scraper/changes/1", http.StatusMethodNotAllowed},
+		{"unknown action", http.MethodPost, "/api/scraper/changes/1/apply", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := NewScraperAPI(newMockPricingScraper())
+			w := httptest.NewRecorder()
+			api.HandleChangeByID(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
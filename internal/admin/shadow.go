@@ -0,0 +1,163 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ShadowStore provides access to recorded shadow traffic comparisons
+type ShadowStore interface {
+	ListShadowResults(filter ShadowFilter) ([]*ShadowResult, error)
+	SummarizeShadowResults(filter ShadowFilter) ([]*ShadowSummary, error)
+}
+
+// ShadowFilter narrows shadow results by model pair and time
+type ShadowFilter struct {
+	PrimaryModel string
+	ShadowModel  string
+	Since        *time.Time
+}
+
+// ShadowSide is one side of a shadow comparison
+type ShadowSide struct {
+	Provider         string `json:"provider"`
+	Model            string `json:"model"`
+	Status           int    `json:"status"`
+	LatencyMs        int    `json:"latency_ms"`
+	Response         string `json:"response,omitempty"`
+	PromptTokens     *int   `json:"prompt_tokens,omitempty"`
+	CompletionTokens *int   `json:"completion_tokens,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// ShadowResult pairs a primary response with its mirrored shadow response
+type ShadowResult struct {
+	ID            int        `json:"id"`
+	ClientID      string     `json:"client_id,omitempty"`
+	PrimaryStream bool       `json:"primary_stream"`
+	Primary       ShadowSide `json:"primary"`
+	Shadow        ShadowSide `json:"shadow"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ShadowSideSummary aggregates one side of a primary/shadow pair
+type ShadowSideSummary struct {
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	Errors           int     `json:"errors"`
+	ErrorRate        float64 `json:"error_rate"`
+	AvgLatencyMs     float64 `json:"avg_latency_ms"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+}
+
+// ShadowSummary compares a primary model against its shadow
+type ShadowSummary struct {
+	Count   int               `json:"count"`
+	Primary ShadowSideSummary `json:"primary"`
+	Shadow  ShadowSideSummary `json:"shadow"`
+}
+
+// ShadowAPI handles shadow traffic evaluation endpoints
+type ShadowAPI struct {
+	store ShadowStore
+}
+
+// NewShadowAPI creates a new ShadowAPI
+func NewShadowAPI(store ShadowStore) *ShadowAPI {
+	return &ShadowAPI{store: store}
+}
+
+// shadowResultList pages GET /api/shadow/results, newest first
+var shadowResultList = listSpec[*ShadowResult]{
+	id: func(s *ShadowResult) string { return strconv.Itoa(s.ID) },
+	fields: map[string]listField[*ShadowResult]{
+		"id":             intField(func(s *ShadowResult) int { return s.ID }),
+		"client_id":      strField(func(s *ShadowResult) string { return s.ClientID }),
+		"primary_stream": boolField(func(s *ShadowResult) bool { return s.PrimaryStream }),
+		"created_at":     timeField(func(s *ShadowResult) time.Time { return s.CreatedAt }),
+	},
+	sort: "-id",
+}
+
+// shadowSummaryList pages GET /api/shadow/summary
+var shadowSummaryList = listSpec[*ShadowSummary]{
+	id: func(s *ShadowSummary) string { return s.Primary.Model + ">" + s.Shadow.Model },
+	fields: map[string]listField[*ShadowSummary]{
+		"count":            intField(func(s *ShadowSummary) int { return s.Count }),
+		"primary_provider": strField(func(s *ShadowSummary) string { return s.Primary.Provider }),
+		"shadow_provider":  strField(func(s *ShadowSummary) string { return s.Shadow.Provider }),
+	},
+	sort: "-count",
+}
+
+// HandleShadowResults handles GET /api/shadow/results?primary_model=...&shadow_model=...&since=...
+func (a *ShadowAPI) HandleShadowResults(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseShadowFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := a.store.ListShadowResults(filter)
+	if err != nil {
+		http.Error(w, "Failed to list shadow results: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if results == nil {
+		results = []*ShadowResult{}
+	}
+
+	writeList(w, r, results, shadowResultList)
+}
+
+// HandleShadowSummary handles GET /api/shadow/summary?primary_model=...&shadow_model=...&since=...
+func (a *ShadowAPI) HandleShadowSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseShadowFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	summaries, err := a.store.SummarizeShadowResults(filter)
+	if err != nil {
+		http.Error(w, "Failed to summarize shadow results: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if summaries == nil {
+		summaries = []*ShadowSummary{}
+	}
+
+	writeList(w, r, summaries, shadowSummaryList)
+}
+
+// parseShadowFilter reads shadow filter query parameters
+func parseShadowFilter(r *http.Request) (ShadowFilter, error) {
+	q := r.URL.Query()
+	filter := ShadowFilter{
+		PrimaryModel: q.Get("primary_model"),
+		ShadowModel:  q.Get("shadow_model"),
+	}
+
+	if sinceStr := q.Get("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			return filter, errors.New("Invalid since, expected RFC3339")
+		}
+		filter.Since = &since
+	}
+
+	return filter, nil
+}
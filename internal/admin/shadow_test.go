@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// mockShadowStore implements ShadowStore for testing
+type mockShadowStore struct {
+	results    []*ShadowResult
+	summaries  []*ShadowSummary
+	lastFilter ShadowFilter
+	err        error
+}
+
+func (m *mockShadowStore) ListShadowResults(filter ShadowFilter) ([]*ShadowResult, error) {
+	m.lastFilter = filter
+	return m.results, m.err
+}
+
+func (m *mockShadowStore) SummarizeShadowResults(filter ShadowFilter) ([]*ShadowSummary, error) {
+	m.lastFilter = filter
+	return m.summaries, m.err
+}
+
+func TestShadowAPI_Results(t *testing.T) {
+	store := &mockShadowStore{
+		results: []*ShadowResult{{
+			ID:      1,
+			Primary: ShadowSide{Provider: "openai", Model: "gpt-4o", Status: 200, LatencyMs: 800},
+			Shadow:  ShadowSide{Provider: "together", Model: "llama-3.3-70b", Status: 200, LatencyMs: 400},
+		}},
+	}
+	api := NewShadowAPI(store)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/shadow/results?primary_model=gpt-4o&limit=5&since=2026-01-01T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+	api.HandleShadowResults(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Results []*ShadowResult `json:"items"`
+		Total   int             `json:"total"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Total != 1 || resp.Results[0].Shadow.Model != "llama-3.3-70b" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	want := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if store.lastFilter.PrimaryModel != "gpt-4o" ||
+		store.lastFilter.Since == nil || !store.lastFilter.Since.Equal(want) {
+		t.Errorf("unexpected filter: %+v", store.lastFilter)
+	}
+}
+
+func TestShadowAPI_ResultsInvalidParams(t *testing.T) {
+	api := NewShadowAPI(&mockShadowStore{})
+
+	for _, query := range []string{"limit=0", "limit=abc", "since=yesterday"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/shadow/results?"+query, nil)
+		w := httptest.NewRecorder()
+		api.HandleShadowResults(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/shadow/results", nil)
+	w := httptest.NewRecorder()
+	api.HandleShadowResults(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+}
+
+func TestShadowAPI_Summary(t *testing.T) {
+	store := &mockShadowStore{
+		summaries: []*ShadowSummary{{
+			Count:   10,
+			Primary: ShadowSideSummary{Model: "gpt-4o", AvgLatencyMs: 900},
+			Shadow:  ShadowSideSummary{Model: "llama-3.3-70b", Errors: 1, ErrorRate: 0.1, AvgLatencyMs: 450},
+		}},
+	}
+	api := NewShadowAPI(store)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/shadow/summary?shadow_model=llama-3.3-70b", nil)
+	w := httptest.NewRecorder()
+	api.HandleShadowSummary(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var resp struct {
+		Summaries []*ShadowSummary `json:"items"`
+		Total     int              `json:"total"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Total != 1 || resp.Summaries[0].Shadow.ErrorRate != 0.1 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if store.lastFilter.ShadowModel != "llama-3.3-70b" {
+		t.Errorf("expected shadow model filter, got %+v", store.lastFilter)
+	}
+}
+
+func TestShadowAPI_StoreError(t *testing.T) {
+	api := NewShadowAPI(&mockShadowStore{err: errors.New("database locked")})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/shadow/summary", nil)
+	w := httptest.NewRecorder()
+	api.HandleShadowSummary(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", w.Code)
+	}
+}
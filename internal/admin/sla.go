@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/sla"
+)
+
+// SLAReporter summarizes recorded provider SLA figures
+type SLAReporter interface {
+	Report(since time.Time, g sla.Granularity, provider string) ([]*sla.ProviderReport, error)
+}
+
+// SLAAPI handles provider SLA report endpoints
+type SLAAPI struct {
+	reporter SLAReporter
+	now      func() time.Time
+}
+
+// NewSLAAPI creates a new SLAAPI
+func NewSLAAPI(reporter SLAReporter) *SLAAPI {
+	return &SLAAPI{reporter: reporter, now: time.Now}
+}
+
+// slaList pages GET /api/sla in the reporter's order. provider is passed
+// to the reporter.
+var slaList = listSpec[*sla.ProviderReport]{
+	id: func(p *sla.ProviderReport) string { return p.Provider },
+}
+
+// HandleSLA handles GET /api/sla?period=daily|weekly&days=30&provider=<id>.
+// The window starts at the beginning of the first day or week it covers so
+// the first period is complete.
+func (a *SLAAPI) HandleSLA(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	g, err := sla.ParseGranularity(q.Get("period"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	days, ok := boundedInt(q.Get("days"), 30, 1, 366)
+	if !ok {
+		http.Error(w, "days must be between 1 and 366", http.StatusBadRequest)
+		return
+	}
+
+	now := a.now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-days)
+	if g == sla.Weekly {
+		since = since.AddDate(0, 0, -((int(since.Weekday()) + 6) % 7))
+	}
+	reports, err := a.reporter.Report(since, g, q.Get("provider"))
+	if err != nil {
+		http.Error(w, "Failed to build SLA report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeListWith(w, r, reports, slaList, map[string]interface{}{
+		"period": g,
+		"since":  since,
+	})
+}
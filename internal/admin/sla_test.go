@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/sla"
+)
+
+type fakeSLAReporter struct {
+	since    time.Time
+	g        sla.Granularity
+	provider string
+}
+
+func (f *fakeSLAReporter) Report(since time.Time, g sla.Granularity, provider string) ([]*sla.ProviderReport, error) {
+	f.since, f.g, f.provider = since, g, provider
+	return []*sla.ProviderReport{{Provider: "openai", Total: &sla.Summary{Requests: 10, Availability: 90}}}, nil
+}
+
+func TestSLAAPI(t *testing.T) {
+	reporter := &fakeSLAReporter{}
+	api := NewSLAAPI(reporter)
+	api.now = func() time.Time { return time.Date(2026, 3, 5, 15, 0, 0, 0, time.UTC) } // Thursday
+
+	rec := httptest.NewRecorder()
+	api.HandleSLA(rec, httptest.NewRequest(http.MethodGet, "/api/sla?days=7&provider=openai", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if reporter.g != sla.Daily || reporter.provider != "openai" || !reporter.since.Equal(time.Date(2026, 2, 27, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected report query: %+v", reporter)
+	}
+	var resp struct {
+		Providers []*sla.ProviderReport `json:"items"`
+		Total     int                   `json:"total"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Total != 1 || resp.Providers[0].Total.Availability != 90 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	// Weekly windows start on a Monday
+	rec = httptest.NewRecorder()
+	api.HandleSLA(rec, httptest.NewRequest(http.MethodGet, "/api/sla?period=weekly&days=7", nil))
+	if rec.Code != http.StatusOK || !reporter.since.Equal(time.Date(2026, 2, 23, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected a Monday start, got %d since %v", rec.Code, reporter.since)
+	}
+
+	for _, q := range []string{"period=monthly", "days=0", "days=abc"} {
+		rec = httptest.NewRecorder()
+		api.HandleSLA(rec, httptest.NewRequest(http.MethodGet, "/api/sla?"+q, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleSLA(rec, httptest.NewRequest(http.MethodPost, "/api/sla", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
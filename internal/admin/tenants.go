@@ -0,0 +1,323 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// TenantStore manages tenants, the clients and keys they own, and their
+// usage reports
+type TenantStore interface {
+	List() ([]*database.Tenant, error)
+	Get(id string) (*database.Tenant, error)
+	Create(t *database.Tenant) error
+	Update(t *database.Tenant) error
+	Delete(id string) error
+	ListClients(tenantID string) ([]string, error)
+	AssignClient(clientID, tenantID string) error
+	ReleaseClient(clientID, tenantID string) (bool, error)
+	AssignKey(keyID int, tenantID string) error
+	ReleaseKey(keyID int, tenantID string) (bool, error)
+	Usage(tenantID string, since time.Time) (*database.TenantUsageReport, error)
+}
+
+// TenantCache is told about tenant changes so the proxy applies them
+// without waiting for cached settings to expire
+type TenantCache interface {
+	Invalidate(tenantID string)
+	InvalidateClient(clientID string)
+}
+
+// TenantAPI handles tenant endpoints
+type TenantAPI struct {
+	store TenantStore
+	cache TenantCache
+}
+
+// NewTenantAPI creates a new TenantAPI. cache may be nil.
+func NewTenantAPI(store TenantStore, cache TenantCache) *TenantAPI {
+	return &TenantAPI{store: store, cache: cache}
+}
+
+// tenantDetail is a tenant with the IDs of its clients
+type tenantDetail struct {
+	*database.Tenant
+	Clients []string `json:"clients"`
+}
+
+// tenantList pages GET /api/tenants
+var tenantList = listSpec[*database.Tenant]{
+	id: func(t *database.Tenant) string { return t.ID },
+	fields: map[string]listField[*database.Tenant]{
+		"id":             strField(func(t *database.Tenant) string { return t.ID }),
+		"name":           strField(func(t *database.Tenant) string { return t.Name }),
+		"monthly_budget": numField(func(t *database.Tenant) float64 { return t.MonthlyBudget }),
+		"created_at":     timeField(func(t *database.Tenant) time.Time { return t.CreatedAt }),
+	},
+	sort: "name",
+}
+
+// HandleTenants handles GET /api/tenants (all tenants) and
+// POST /api/tenants (create a tenant)
+func (a *TenantAPI) HandleTenants(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		tenants, err := a.store.List()
+		if err != nil {
+			http.Error(w, "Failed to list tenants: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if tenants == nil {
+			tenants = []*database.Tenant{}
+		}
+		writeList(w, r, tenants, tenantList)
+	case http.MethodPost:
+		var t database.Tenant
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if t.ID == "" || t.Name == "" {
+			http.Error(w, "id and name are required", http.StatusBadRequest)
+			return
+		}
+		if !validTenantLimits(&t) {
+			http.Error(w, "Budget and limits must not be negative", http.StatusBadRequest)
+			return
+		}
+		if existing, err := a.store.Get(t.ID); err != nil {
+			http.Error(w, "Failed to get tenant: "+err.Error(), http.StatusInternalServerError)
+			return
+		} else if existing != nil {
+			http.Error(w, "Tenant already exists", http.StatusConflict)
+			return
+		}
+		t.CreatedAt = time.Time{}
+		if err := a.store.Create(&t); err != nil {
+			http.Error(w, "Failed to create tenant: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(t)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleTenant handles /api/tenants/{id} (GET, PUT, DELETE) and its
+// sub-resources:
+//
+//	POST   /api/tenants/{id}/clients        assign a client {"client_id": "..."}
+//	DELETE /api/tenants/{id}/clients/{cid}  make the client shared again
+//	POST   /api/tenants/{id}/keys           assign an API key {"key_id": 1}
+//	DELETE /api/tenants/{id}/keys/{kid}     return the key to the shared pool
+//	GET    /api/tenants/{id}/usage          usage by model (?since=RFC3339,
+//	                                        default the start of the month)
+func (a *TenantAPI) HandleTenant(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/api/tenants/"), "/", 3)
+	id := parts[0]
+	if id == "" {
+		http.Error(w, "Tenant ID required", http.StatusBadRequest)
+		return
+	}
+
+	t, err := a.store.Get(id)
+	if err != nil {
+		http.Error(w, "Failed to get tenant: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if t == nil {
+		http.Error(w, "Tenant not found", http.StatusNotFound)
+		return
+	}
+
+	if len(parts) == 1 {
+		a.handleTenant(w, r, t)
+		return
+	}
+	sub := ""
+	if len(parts) == 3 {
+		sub = parts[2]
+	}
+	switch parts[1] {
+	case "clients":
+		a.handleTenantClients(w, r, id, sub)
+	case "keys":
+		a.handleTenantKeys(w, r, id, sub)
+	case "usage":
+		a.handleTenantUsage(w, r, id)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// handleTenant reads, updates or deletes a tenant
+func (a *TenantAPI) handleTenant(w http.ResponseWriter, r *http.Request, t *database.Tenant) {
+	switch r.Method {
+	case http.MethodGet:
+		clients, err := a.store.ListClients(t.ID)
+		if err != nil {
+			http.Error(w, "Failed to list tenant clients: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if clients == nil {
+			clients = []string{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tenantDetail{Tenant: t, Clients: clients})
+	case http.MethodPut:
+		var update database.Tenant
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if update.Name == "" {
+			update.Name = t.Name
+		}
+		if !validTenantLimits(&update) {
+			http.Error(w, "Budget and limits must not be negative", http.StatusBadRequest)
+			return
+		}
+		update.ID, update.CreatedAt = t.ID, t.CreatedAt
+		if err := a.store.Update(&update); err != nil {
+			http.Error(w, "Failed to update tenant: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		a.invalidate(t.ID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(update)
+	case http.MethodDelete:
+		if err := a.store.Delete(t.ID); err != nil {
+			http.Error(w, "Failed to delete tenant: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		a.invalidate(t.ID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleTenantClients assigns clients to a tenant or releases them
+func (a *TenantAPI) handleTenantClients(w http.ResponseWriter, r *http.Request, tenantID, clientID string) {
+	switch {
+	case r.Method == http.MethodPost && clientID == "":
+		var req struct {
+			ClientID string `json:"client_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ClientID == "" {
+			http.Error(w, "client_id is required", http.StatusBadRequest)
+			return
+		}
+		if err := a.store.AssignClient(req.ClientID, tenantID); err != nil {
+			http.Error(w, "Failed to assign client: "+err.Error(), http.StatusNotFound)
+			return
+		}
+		a.invalidateClient(req.ClientID)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete && clientID != "":
+		ok, err := a.store.ReleaseClient(clientID, tenantID)
+		if err != nil {
+			http.Error(w, "Failed to release client: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "Client not owned by tenant", http.StatusNotFound)
+			return
+		}
+		a.invalidateClient(clientID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleTenantKeys gives a tenant exclusive use of API keys or returns
+// them to the shared pool
+func (a *TenantAPI) handleTenantKeys(w http.ResponseWriter, r *http.Request, tenantID, keyID string) {
+	switch {
+	case r.Method == http.MethodPost && keyID == "":
+		var req struct {
+			KeyID int `json:"key_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.KeyID <= 0 {
+			http.Error(w, "key_id is required", http.StatusBadRequest)
+			return
+		}
+		if err := a.store.AssignKey(req.KeyID, tenantID); err != nil {
+			http.Error(w, "Failed to assign key: "+err.Error(), http.StatusNotFound)
+			return
+		}
+		a.invalidate(tenantID)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete && keyID != "":
+		id, err := strconv.Atoi(keyID)
+		if err != nil {
+			http.Error(w, "Invalid key ID", http.StatusBadRequest)
+			return
+		}
+		ok, err := a.store.ReleaseKey(id, tenantID)
+		if err != nil {
+			http.Error(w, "Failed to release key: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "Key not owned by tenant", http.StatusNotFound)
+			return
+		}
+		a.invalidate(tenantID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleTenantUsage reports a tenant's usage by model
+func (a *TenantAPI) handleTenantUsage(w http.ResponseWriter, r *http.Request, tenantID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	if s := r.URL.Query().Get("since"); s != "" {
+		parsed, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			http.Error(w, "Invalid since: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		since = parsed
+	}
+
+	report, err := a.store.Usage(tenantID, since)
+	if err != nil {
+		http.Error(w, "Failed to get tenant usage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (a *TenantAPI) invalidate(tenantID string) {
+	if a.cache != nil {
+		a.cache.Invalidate(tenantID)
+	}
+}
+
+func (a *TenantAPI) invalidateClient(clientID string) {
+	if a.cache != nil {
+		a.cache.InvalidateClient(clientID)
+	}
+}
+
+// validTenantLimits reports whether a tenant's budget and limits are usable
+func validTenantLimits(t *database.Tenant) bool {
+	return t.MonthlyBudget >= 0 && t.RPMLimit >= 0 && t.TPMLimit >= 0
+}
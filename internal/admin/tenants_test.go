@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+type recordingTenantCache struct {
+	tenants, clients []string
+}
+
+func (c *recordingTenantCache) Invalidate(tenantID string) {
+	c.tenants = append(c.tenants, tenantID)
+}
+
+func (c *recordingTenantCache) InvalidateClient(clientID string) {
+	c.clients = append(c.clients, clientID)
+}
+
+func TestTenantAPI(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "tenants.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := database.NewTenantRepository(db)
+	cache := &recordingTenantCache{}
+	api := NewTenantAPI(repo, cache)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if strings.HasPrefix(path, "/api/tenants/") {
+			api.HandleTenant(rec, req)
+		} else {
+			api.HandleTenants(rec, req)
+		}
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/tenants", `{"id":"team-a","name":"Team A","monthly_budget":100,"rpm_limit":60}`); rec.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/tenants", `{"id":"team-a","name":"Again"}`); rec.Code != http.StatusConflict {
+		t.Errorf("duplicate: expected 409, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/tenants", `{"id":"team-b","name":"B","rpm_limit":-1}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("negative limit: expected 400, got %d", rec.Code)
+	}
+
+	var list struct {
+		Tenants []database.Tenant `json:"items"`
+		Total   int               `json:"total"`
+	}
+	json.NewDecoder(do(http.MethodGet, "/api/tenants", "").Body).Decode(&list)
+	if list.Total != 1 || list.Tenants[0].MonthlyBudget != 100 {
+		t.Errorf("unexpected list: %+v", list)
+	}
+
+	// Update invalidates the proxy's cached limits
+	rec := do(http.MethodPut, "/api/tenants/team-a", `{"monthly_budget":250,"rpm_limit":60,"tpm_limit":90000}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got, _ := repo.Get("team-a"); got.MonthlyBudget != 250 || got.Name != "Team A" {
+		t.Errorf("update not saved: %+v", got)
+	}
+	if len(cache.tenants) != 1 || cache.tenants[0] != "team-a" {
+		t.Errorf("expected team-a invalidated, got %v", cache.tenants)
+	}
+
+	// Clients
+	database.NewClientRepository(db).Create(&database.Client{ID: "c1", Name: "cli", Version: "1", Token: "tok"})
+	if rec := do(http.MethodPost, "/api/tenants/team-a/clients", `{"client_id":"c1"}`); rec.Code != http.StatusNoContent {
+		t.Fatalf("assign client: expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/tenants/team-a/clients", `{"client_id":"missing"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("assign missing client: expected 404, got %d", rec.Code)
+	}
+	var detail struct {
+		ID      string   `json:"id"`
+		Clients []string `json:"clients"`
+	}
+	json.NewDecoder(do(http.MethodGet, "/api/tenants/team-a", "").Body).Decode(&detail)
+	if detail.ID != "team-a" || len(detail.Clients) != 1 || detail.Clients[0] != "c1" {
+		t.Errorf("unexpected detail: %+v", detail)
+	}
+	if rec := do(http.MethodDelete, "/api/tenants/team-a/clients/c1", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("release client: expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/tenants/team-a/clients/c1", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("release unowned client: expected 404, got %d", rec.Code)
+	}
+	if len(cache.clients) != 2 {
+		t.Errorf("expected client invalidations, got %v", cache.clients)
+	}
+
+	// Keys
+	db.CreateProvider(&database.Provider{ID: "openai", Name: "OpenAI", BaseURL: "https://api.openai.com", AuthMethod: "bearer", PricingModel: "usage", Status: "online"})
+	key, _ := db.CreateAPIKey("openai", "sk-team-a-0000000000")
+	if rec := do(http.MethodPost, "/api/tenants/team-a/keys", `{"key_id":`+strconv.Itoa(key.ID)+`}`); rec.Code != http.StatusNoContent {
+		t.Fatalf("assign key: expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if k, _ := db.GetAPIKey(key.ID); k.TenantID == nil || *k.TenantID != "team-a" {
+		t.Errorf("key not assigned: %+v", k)
+	}
+
+	// Usage defaults to the current month
+	repo.RecordUsage(&database.TenantUsage{TenantID: "team-a", Provider: "openai", Model: "gpt-4o", PromptTokens: 100, Cost: 0.5})
+	var report database.TenantUsageReport
+	json.NewDecoder(do(http.MethodGet, "/api/tenants/team-a/usage", "").Body).Decode(&report)
+	if report.Requests != 1 || report.Cost != 0.5 || len(report.Models) != 1 {
+		t.Errorf("unexpected usage report: %+v", report)
+	}
+	if rec := do(http.MethodGet, "/api/tenants/team-a/usage?since=yesterday", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad since: expected 400, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodDelete, "/api/tenants/team-a", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("delete: expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/tenants/team-a", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("get deleted: expected 404, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// TransformRuleStore manages request transformation rules (usually a *proxy.TransformRules)
+type TransformRuleStore interface {
+	Rules() []proxy.TransformRule
+	Rule(name string) (proxy.TransformRule, bool)
+	SetRule(r proxy.TransformRule) error
+	RemoveRule(name string) bool
+}
+
+// TransformAPI handles request transformation rule endpoints
+type TransformAPI struct {
+	store TransformRuleStore
+}
+
+// NewTransformAPI creates a new TransformAPI
+func NewTransformAPI(store TransformRuleStore) *TransformAPI {
+	return &TransformAPI{store: store}
+}
+
+// transformList pages GET /api/transforms in the order rules apply
+var transformList = listSpec[proxy.TransformRule]{
+	id: func(t proxy.TransformRule) string { return t.Name },
+	fields: map[string]listField[proxy.TransformRule]{
+		"name": strField(func(t proxy.TransformRule) string { return t.Name }),
+	},
+}
+
+// HandleTransforms handles GET /api/transforms (all rules, in the order
+// they apply) and POST /api/transforms (replace the rule with the same
+// name, or add it last)
+func (a *TransformAPI) HandleTransforms(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeList(w, r, a.store.Rules(), transformList)
+	case http.MethodPost:
+		var rule proxy.TransformRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := a.store.SetRule(rule); err != nil {
+			http.Error(w, "Invalid transform rule: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rule)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleTransformRule handles GET and DELETE /api/transforms/{name}
+func (a *TransformAPI) HandleTransformRule(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/transforms/")
+	if name == "" {
+		http.Error(w, "Rule name required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rule, ok := a.store.Rule(name)
+		if !ok {
+			http.Error(w, "Transform rule not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rule)
+	case http.MethodDelete:
+		if !a.store.RemoveRule(name) {
+			http.Error(w, "Transform rule not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
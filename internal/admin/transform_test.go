@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+func TestTransformAPI(t *testing.T) {
+	api := NewTransformAPI(proxy.NewTransformRules())
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if strings.HasPrefix(path, "/api/transforms/") {
+			api.HandleTransformRule(rec, req)
+		} else {
+			api.HandleTransforms(rec, req)
+		}
+		return rec
+	}
+
+	rule := `{"name":"privacy","apis":["openai"],"strip":["user","metadata.user_id"],"clamp":{"temperature":{"max":1}}}`
+	if rec := do(http.MethodPost, "/api/transforms", rule); rec.Code != http.StatusCreated {
+		t.Fatalf("POST expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, "/api/transforms", `{"name":"bad","strip":["model"]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid rule expected 400, got %d", rec.Code)
+	}
+	do(http.MethodPost, "/api/transforms", `{"name":"seeded","set":{"seed":7}}`)
+
+	rec := do(http.MethodGet, "/api/transforms", "")
+	var resp struct {
+		Rules []proxy.TransformRule `json:"items"`
+		Total int                   `json:"total"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Total != 2 || resp.Rules[0].Name != "privacy" || len(resp.Rules[0].Strip) != 2 || string(resp.Rules[1].Set["seed"]) != "7" {
+		t.Errorf("unexpected rules: %+v", resp)
+	}
+
+	if rec := do(http.MethodGet, "/api/transforms/seeded", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "seed") {
+		t.Errorf("GET rule expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodDelete, "/api/transforms/privacy", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/transforms/privacy", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET deleted rule expected 404, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/api/transforms", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT expected 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// TransportPool exposes upstream connection pool settings and metrics
+// (usually an internal/http Pool)
+type TransportPool interface {
+	Defaults() mshttp.TransportConfig
+	Config(provider string) mshttp.TransportConfig
+	Overrides() map[string]mshttp.TransportConfig
+	SetOverride(provider string, cfg mshttp.TransportConfig) error
+	RemoveOverride(provider string) bool
+	Stats() []mshttp.PoolStats
+}
+
+// TransportAPI handles connection pool metrics and tuning endpoints
+type TransportAPI struct {
+	pool TransportPool
+}
+
+// NewTransportAPI creates a new TransportAPI
+func NewTransportAPI(pool TransportPool) *TransportAPI {
+	return &TransportAPI{pool: pool}
+}
+
+// poolList pages the pool metrics of GET /api/transport
+var poolList = listSpec[mshttp.PoolStats]{
+	id: func(p mshttp.PoolStats) string { return p.Name },
+	fields: map[string]listField[mshttp.PoolStats]{
+		"name":       strField(func(p mshttp.PoolStats) string { return p.Name }),
+		"in_flight":  numField(func(p mshttp.PoolStats) float64 { return float64(p.InFlight) }),
+		"open_conns": numField(func(p mshttp.PoolStats) float64 { return float64(p.OpenConns) }),
+		"requests":   numField(func(p mshttp.PoolStats) float64 { return float64(p.Requests) }),
+		"errors":     numField(func(p mshttp.PoolStats) float64 { return float64(p.Errors) }),
+	},
+	sort: "name",
+}
+
+// HandleTransport handles GET /api/transport (defaults, overrides and pool
+// metrics for every provider)
+func (a *TransportAPI) HandleTransport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	overrides := a.pool.Overrides()
+	for provider, o := range overrides {
+		overrides[provider] = o.Redacted()
+	}
+	writeListWith(w, r, a.pool.Stats(), poolList, map[string]interface{}{
+		"defaults":  a.pool.Defaults().Redacted(),
+		"overrides": overrides,
+	})
+}
+
+// HandleTransportProvider handles GET/PUT/DELETE /api/transport/{provider}
+func (a *TransportAPI) HandleTransportProvider(w http.ResponseWriter, r *http.Request) {
+	provider := strings.TrimPrefix(r.URL.Path, "/api/transport/")
+	if provider == "" || strings.Contains(provider, "/") {
+		http.Error(w, "Provider required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		a.writeProvider(w, provider)
+	case http.MethodPut:
+		var override mshttp.TransportConfig
+		if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := a.pool.SetOverride(provider, override); err != nil {
+			http.Error(w, "Invalid transport settings: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.writeProvider(w, provider)
+	case http.MethodDelete:
+		if !a.pool.RemoveOverride(provider) {
+			http.Error(w, "Override not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// writeProvider writes one provider's effective settings, override and
+// metrics, with proxy passwords masked
+func (a *TransportAPI) writeProvider(w http.ResponseWriter, provider string) {
+	resp := map[string]interface{}{
+		"provider": provider,
+		"config":   a.pool.Config(provider).Redacted(),
+	}
+	if override, ok := a.pool.Overrides()[provider]; ok {
+		resp["override"] = override.Redacted()
+	}
+	for _, s := range a.pool.Stats() {
+		if s.Name == provider {
+			resp["pool"] = s
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/watchdog"
+)
+
+// WatchdogSource reports sampled resources and leak warnings
+type WatchdogSource interface {
+	Current() watchdog.Snapshot
+	Recent() []watchdog.Warning
+	Config() watchdog.Config
+}
+
+// WatchdogAPI handles resource watchdog endpoints
+type WatchdogAPI struct {
+	source WatchdogSource
+}
+
+// NewWatchdogAPI creates a new WatchdogAPI
+func NewWatchdogAPI(source WatchdogSource) *WatchdogAPI {
+	return &WatchdogAPI{source: source}
+}
+
+// warningList pages the recent warnings of GET /api/watchdog, newest first
+var warningList = listSpec[watchdog.Warning]{
+	id: func(w watchdog.Warning) string {
+		return w.At.Format(time.RFC3339Nano) + " " + w.Resource + " " + w.Kind
+	},
+	fields: map[string]listField[watchdog.Warning]{
+		"resource": strField(func(w watchdog.Warning) string { return w.Resource }),
+		"kind":     strField(func(w watchdog.Warning) string { return w.Kind }),
+		"value":    intField(func(w watchdog.Warning) int { return w.Value }),
+		"at":       timeField(func(w watchdog.Warning) time.Time { return w.At }),
+	},
+}
+
+// HandleWatchdog handles GET /api/watchdog: the latest sample, the
+// ceilings it is judged against and the recent warnings, newest first
+func (a *WatchdogAPI) HandleWatchdog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cfg := a.source.Config()
+	writeListWith(w, r, a.source.Recent(), warningList, map[string]interface{}{
+		"current": a.source.Current(),
+		"ceilings": map[string]int{
+			watchdog.ResourceGoroutines: cfg.MaxGoroutines,
+			watchdog.ResourceOpenFDs:    cfg.MaxOpenFDs,
+			watchdog.ResourceDBInUse:    cfg.MaxDBInUse,
+		},
+		"interval_seconds": int(cfg.Interval.Seconds()),
+	})
+}
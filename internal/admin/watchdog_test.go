@@ -0,0 +1,56 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/watchdog"
+)
+
+type fakeWatchdog struct{}
+
+func (fakeWatchdog) Current() watchdog.Snapshot {
+	return watchdog.Snapshot{Goroutines: 812, OpenFDs: 40}
+}
+
+func (fakeWatchdog) Recent() []watchdog.Warning {
+	return []watchdog.Warning{{Resource: watchdog.ResourceGoroutines, Kind: watchdog.KindTrend, Value: 812, Baseline: 300}}
+}
+
+func (fakeWatchdog) Config() watchdog.Config {
+	return watchdog.Config{Interval: 30 * time.Second, MaxGoroutines: 10000, MaxOpenFDs: 819}
+}
+
+func TestWatchdogAPI(t *testing.T) {
+	api := NewAPI(Config{}, &mockDB{}, &mockDiscovery{}, &mockGenerator{}, &mockKeyManager{})
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/watchdog", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a watchdog, got %d", rec.Code)
+	}
+
+	api.SetWatchdogAPI(NewWatchdogAPI(fakeWatchdog{}))
+	rec = httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/watchdog", nil))
+	var resp struct {
+		Current  watchdog.Snapshot  `json:"current"`
+		Ceilings map[string]int     `json:"ceilings"`
+		Interval int                `json:"interval_seconds"`
+		Warnings []watchdog.Warning `json:"items"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d: %v", rec.Code, err)
+	}
+	if resp.Current.Goroutines != 812 || resp.Ceilings[watchdog.ResourceOpenFDs] != 819 || resp.Interval != 30 || len(resp.Warnings) != 1 {
+		t.Errorf("unexpected watchdog response: %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/watchdog", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,170 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/webhook"
+)
+
+// WebhookStore provides access to webhook deliveries that exhausted their retries
+type WebhookStore interface {
+	ListDeadLetters(filter DeadLetterFilter) ([]*webhook.DeadLetter, error)
+	GetDeadLetter(id int) (*webhook.DeadLetter, error)
+	DeleteDeadLetter(id int) error
+}
+
+// WebhookRedeliverer resends a dead-lettered delivery (usually a *webhook.Dispatcher)
+type WebhookRedeliverer interface {
+	Redeliver(ctx context.Context, dl *webhook.DeadLetter) error
+}
+
+// DeadLetterFilter narrows dead letters by endpoint and event type
+type DeadLetterFilter struct {
+	Endpoint  string
+	EventType string
+}
+
+// WebhookAPI handles webhook dead-letter endpoints
+type WebhookAPI struct {
+	store       WebhookStore
+	redeliverer WebhookRedeliverer
+}
+
+// NewWebhookAPI creates a new WebhookAPI
+func NewWebhookAPI(store WebhookStore, redeliverer WebhookRedeliverer) *WebhookAPI {
+	return &WebhookAPI{store: store, redeliverer: redeliverer}
+}
+
+// deadLetterList pages GET /api/webhooks/dead-letters, newest first.
+// endpoint and event_type are passed to the store.
+var deadLetterList = listSpec[*webhook.DeadLetter]{
+	id: func(d *webhook.DeadLetter) string { return strconv.Itoa(d.ID) },
+	fields: map[string]listField[*webhook.DeadLetter]{
+		"id":          intField(func(d *webhook.DeadLetter) int { return d.ID }),
+		"attempts":    intField(func(d *webhook.DeadLetter) int { return d.Attempts }),
+		"last_status": intField(func(d *webhook.DeadLetter) int { return d.LastStatus }),
+		"created_at":  timeField(func(d *webhook.DeadLetter) time.Time { return d.CreatedAt }),
+	},
+	sort: "-id",
+}
+
+// HandleDeadLetters handles GET /api/webhooks/dead-letters?endpoint=...&event_type=...
+func (a *WebhookAPI) HandleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := DeadLetterFilter{
+		Endpoint:  q.Get("endpoint"),
+		EventType: q.Get("event_type"),
+	}
+
+	letters, err := a.store.ListDeadLetters(filter)
+	if err != nil {
+		http.Error(w, "Failed to list dead letters: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if letters == nil {
+		letters = []*webhook.DeadLetter{}
+	}
+
+	writeList(w, r, letters, deadLetterList)
+}
+
+// HandleDeadLetterByID handles GET/DELETE /api/webhooks/dead-letters/{id} and
+// POST /api/webhooks/dead-letters/{id}/redeliver
+func (a *WebhookAPI) HandleDeadLetterByID(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/webhooks/dead-letters/")
+	parts := strings.Split(path, "/")
+	if parts[0] == "" {
+		http.Error(w, "Dead letter ID required", http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid dead letter ID", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "redeliver" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		a.handleRedeliver(w, r, id)
+		return
+	}
+	if len(parts) > 1 {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		dl, ok := a.getDeadLetter(w, id)
+		if !ok {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(dl)
+	case http.MethodDelete:
+		if _, ok := a.getDeadLetter(w, id); !ok {
+			return
+		}
+		if err := a.store.DeleteDeadLetter(id); err != nil {
+			http.Error(w, "Failed to delete dead letter: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleRedeliver resends a dead letter and removes it on success
+func (a *WebhookAPI) handleRedeliver(w http.ResponseWriter, r *http.Request, id int) {
+	if a.redeliverer == nil {
+		http.Error(w, "Webhook delivery not configured", http.StatusServiceUnavailable)
+		return
+	}
+	dl, ok := a.getDeadLetter(w, id)
+	if !ok {
+		return
+	}
+
+	if err := a.redeliverer.Redeliver(r.Context(), dl); err != nil {
+		http.Error(w, "Redelivery failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	if err := a.store.DeleteDeadLetter(id); err != nil {
+		http.Error(w, "Redelivered but failed to delete dead letter: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "redelivered",
+		"event_id": dl.EventID,
+	})
+}
+
+// getDeadLetter loads a dead letter, writing an error response when it cannot
+func (a *WebhookAPI) getDeadLetter(w http.ResponseWriter, id int) (*webhook.DeadLetter, bool) {
+	dl, err := a.store.GetDeadLetter(id)
+	if err != nil {
+		http.Error(w, "Failed to get dead letter: "+err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if dl == nil {
+		http.Error(w, "Dead letter not found", http.StatusNotFound)
+		return nil, false
+	}
+	return dl, true
+}
@@ -0,0 +1,158 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/webhook"
+)
+
+// mockWebhookStore implements WebhookStore for testing
+type mockWebhookStore struct {
+	letters    map[int]*webhook.DeadLetter
+	lastFilter DeadLetterFilter
+	deleted    []int
+}
+
+func (m *mockWebhookStore) ListDeadLetters(filter DeadLetterFilter) ([]*webhook.DeadLetter, error) {
+	m.lastFilter = filter
+	var list []*webhook.DeadLetter
+	for _, dl := range m.letters {
+		list = append(list, dl)
+	}
+	return list, nil
+}
+
+func (m *mockWebhookStore) GetDeadLetter(id int) (*webhook.DeadLetter, error) {
+	return m.letters[id], nil
+}
+
+func (m *mockWebhookStore) DeleteDeadLetter(id int) error {
+	m.deleted = append(m.deleted, id)
+	delete(m.letters, id)
+	return nil
+}
+
+// mockRedeliverer implements WebhookRedeliverer for testing
+type mockRedeliverer struct {
+	err       error
+	delivered []*webhook.DeadLetter
+}
+
+func (m *mockRedeliverer) Redeliver(ctx context.Context, dl *webhook.DeadLetter) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.delivered = append(m.delivered, dl)
+	return nil
+}
+
+func newMockWebhookStore() *mockWebhookStore {
+	return &mockWebhookStore{letters: map[int]*webhook.DeadLetter{
+		1: {ID: 1, Endpoint: "ops", EventID: "evt-1", EventType: webhook.EventProviderUnhealthy, Payload: `{"id":"evt-1"}`, Attempts: 5},
+	}}
+}
+
+func TestWebhookAPI_DeadLetters(t *testing.T) {
+	store := newMockWebhookStore()
+	api := NewWebhookAPI(store, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/webhooks/dead-letters?endpoint=ops&event_type=provider.unhealthy&limit=10", nil)
+	w := httptest.NewRecorder()
+	api.HandleDeadLetters(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		DeadLetters []*webhook.DeadLetter `json:"items"`
+		Total       int                   `json:"total"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Total != 1 || resp.DeadLetters[0].EventID != "evt-1" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	want := DeadLetterFilter{Endpoint: "ops", EventType: "provider.unhealthy"}
+	if store.lastFilter != want {
+		t.Errorf("unexpected filter: %+v", store.lastFilter)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/webhooks/dead-letters?limit=0", nil)
+	w = httptest.NewRecorder()
+	api.HandleDeadLetters(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid limit, got %d", w.Code)
+	}
+}
+
+func TestWebhookAPI_DeadLetterByID(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"get", http.MethodGet, "/api/webhooks/dead-letters/1", http.StatusOK},
+		{"get missing", http.MethodGet, "/api/webhooks/dead-letters/2", http.StatusNotFound},
+		{"invalid id", http.MethodGet, "/api/webhooks/dead-letters/abc", http.StatusBadRequest},
+		{"missing id", http.MethodGet, "/api/webhooks/dead-letters/", http.StatusBadRequest},
+		{"unknown action", http.MethodPost, "/api/webhooks/dead-letters/1/retry", http.StatusNotFound},
+		{"redeliver wrong method", http.MethodGet, "/api/webhooks/dead-letters/1/redeliver", http.StatusMethodNotAllowed},
+		{"put", http.MethodPut, "/api/webhooks/dead-letters/1", http.StatusMethodNotAllowed},
+		{"delete", http.MethodDelete, "/api/webhooks/dead-letters/1", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := NewWebhookAPI(newMockWebhookStore(), &mockRedeliverer{})
+			w := httptest.NewRecorder()
+			api.HandleDeadLetterByID(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWebhookAPI_Redeliver(t *testing.T) {
+	store := newMockWebhookStore()
+	redeliverer := &mockRedeliverer{}
+	api := NewWebhookAPI(store, redeliverer)
+
+	w := httptest.NewRecorder()
+	api.HandleDeadLetterByID(w, httptest.NewRequest(http.MethodPost, "/api/webhooks/dead-letters/1/redeliver", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(redeliverer.delivered) != 1 || redeliverer.delivered[0].EventID != "evt-1" {
+		t.Errorf("expected evt-1 to be redelivered, got %+v", redeliverer.delivered)
+	}
+	if len(store.deleted) != 1 || store.deleted[0] != 1 {
+		t.Errorf("expected dead letter to be deleted, got %v", store.deleted)
+	}
+
+	// A failed redelivery keeps the dead letter
+	store = newMockWebhookStore()
+	api = NewWebhookAPI(store, &mockRedeliverer{err: errors.New("connection refused")})
+	w = httptest.NewRecorder()
+	api.HandleDeadLetterByID(w, httptest.NewRequest(http.MethodPost, "/api/webhooks/dead-letters/1/redeliver", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected status 502, got %d", w.Code)
+	}
+	if len(store.deleted) != 0 {
+		t.Error("expected dead letter to be kept after failed redelivery")
+	}
+
+	// Without a dispatcher, dead letters can be listed but not redelivered
+	api = NewWebhookAPI(newMockWebhookStore(), nil)
+	w = httptest.NewRecorder()
+	api.HandleDeadLetterByID(w, httptest.NewRequest(http.MethodPost, "/api/webhooks/dead-letters/1/redeliver", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+}
@@ -0,0 +1,381 @@
+// Package anomaly watches request volume, cost and error rate per provider
+// and per client, and raises an alert when a window of traffic spikes far
+// above its rolling baseline: the signature of a runaway loop burning
+// through a budget, or of a provider starting to fail.
+//
+// Traffic is counted in fixed windows. When a window closes it is compared
+// with the windows before it; a value must be both Sigma standard
+// deviations and MinIncrease above the baseline mean to count, so a flat
+// baseline does not alert on noise. Only increases are reported.
+package anomaly
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+)
+
+// Dimensions a series is keyed by
+const (
+	DimensionProvider = "provider"
+	DimensionClient   = "client"
+)
+
+// Metrics compared against their baselines
+const (
+	MetricRequests  = "requests"
+	MetricCost      = "cost_usd"
+	MetricErrorRate = "error_rate"
+)
+
+// recentAlerts is how many alerts Recent keeps
+const recentAlerts = 100
+
+// Config configures a Detector
+type Config struct {
+	// Window is the length of a counting window (default 1m)
+	Window time.Duration
+	// Baseline is how many closed windows the baseline covers (default 60)
+	Baseline int
+	// MinBaseline is how many closed windows a series needs before it can
+	// alert (default 10)
+	MinBaseline int
+	// Sigma is how many standard deviations above the baseline mean a
+	// value must be (default 3)
+	Sigma float64
+	// MinIncrease is the fraction above the baseline mean a value must
+	// also be, e.g. 0.5 for 50% (default 0.5)
+	MinIncrease float64
+	// MinRequests is the request count below which a window is not judged
+	// (default 20)
+	MinRequests int
+	// MinErrorRate is the error rate a window must reach to alert on its
+	// error rate (default 0.1)
+	MinErrorRate float64
+	// Cooldown is the quiet time between alerts for the same series and
+	// metric (default 15m)
+	Cooldown time.Duration
+
+	// OnAlert is called for each alert, outside the detector's lock
+	OnAlert func(Alert)
+}
+
+// DefaultConfig returns the default detector configuration
+func DefaultConfig() Config {
+	return Config{
+		Window:       time.Minute,
+		Baseline:     60,
+		MinBaseline:  10,
+		Sigma:        3,
+		MinIncrease:  0.5,
+		MinRequests:  20,
+		MinErrorRate: 0.1,
+		Cooldown:     15 * time.Minute,
+	}
+}
+
+// Sample is one finished request
+type Sample struct {
+	Provider string
+	ClientID string
+	Status   int
+	Cost     float64 // USD
+	At       time.Time
+}
+
+// Alert reports a window that spiked above its baseline
+type Alert struct {
+	Dimension   string        `json:"dimension"`
+	Key         string        `json:"key"`
+	Metric      string        `json:"metric"`
+	Value       float64       `json:"value"`
+	Baseline    float64       `json:"baseline"` // Mean over the baseline windows
+	StdDev      float64       `json:"stddev"`
+	Sigma       float64       `json:"sigma,omitempty"` // Standard deviations above the mean; 0 for a flat baseline
+	WindowStart time.Time     `json:"window_start"`
+	Window      time.Duration `json:"window_ns"`
+}
+
+// window holds one window's counts
+type window struct {
+	requests int
+	errors   int
+	cost     float64
+}
+
+type seriesKey struct {
+	dimension string
+	key       string
+}
+
+// series is the current window and baseline of one provider or client
+type series struct {
+	start     time.Time // Of the current window
+	current   window
+	history   []window // Closed windows, oldest first
+	lastAlert map[string]time.Time
+}
+
+// Detector tracks traffic baselines and raises alerts
+type Detector struct {
+	config Config
+
+	mu     sync.Mutex
+	series map[seriesKey]*series
+	recent []Alert // Newest last
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewDetector creates a detector and starts closing windows as they end.
+// Zero config fields take their defaults.
+func NewDetector(cfg Config) *Detector {
+	def := DefaultConfig()
+	if cfg.Window <= 0 {
+		cfg.Window = def.Window
+	}
+	if cfg.Baseline <= 0 {
+		cfg.Baseline = def.Baseline
+	}
+	if cfg.MinBaseline <= 0 {
+		cfg.MinBaseline = def.MinBaseline
+	}
+	if cfg.MinBaseline > cfg.Baseline {
+		cfg.MinBaseline = cfg.Baseline
+	}
+	if cfg.Sigma <= 0 {
+		cfg.Sigma = def.Sigma
+	}
+	if cfg.MinIncrease <= 0 {
+		cfg.MinIncrease = def.MinIncrease
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = def.MinRequests
+	}
+	if cfg.MinErrorRate <= 0 {
+		cfg.MinErrorRate = def.MinErrorRate
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = def.Cooldown
+	}
+
+	d := &Detector{
+		config: cfg,
+		series: make(map[seriesKey]*series),
+		stop:   make(chan struct{}),
+	}
+	d.wg.Add(1)
+	go d.loop()
+	return d
+}
+
+// Observe counts one request against its provider and client
+func (d *Detector) Observe(s Sample) {
+	start := s.At.UTC().Truncate(d.config.Window)
+
+	d.mu.Lock()
+	var alerts []Alert
+	for _, k := range []seriesKey{{DimensionProvider, s.Provider}, {DimensionClient, s.ClientID}} {
+		if k.key == "" {
+			continue
+		}
+		sr, ok := d.series[k]
+		if !ok {
+			sr = &series{start: start, lastAlert: make(map[string]time.Time)}
+			d.series[k] = sr
+		}
+		alerts = append(alerts, d.roll(k, sr, start)...)
+		if start.Before(sr.start) {
+			continue // Too late for its window
+		}
+		sr.current.requests++
+		if failed(s.Status) {
+			sr.current.errors++
+		}
+		sr.current.cost += s.Cost
+	}
+	d.mu.Unlock()
+
+	d.raise(alerts)
+}
+
+// Tick closes the windows that ended by now and judges them; it runs every
+// Window on its own
+func (d *Detector) Tick(now time.Time) {
+	start := now.UTC().Truncate(d.config.Window)
+
+	d.mu.Lock()
+	var alerts []Alert
+	for k, sr := range d.series {
+		alerts = append(alerts, d.roll(k, sr, start)...)
+		if sr.idle() {
+			delete(d.series, k)
+		}
+	}
+	d.mu.Unlock()
+
+	d.raise(alerts)
+}
+
+// Recent returns the latest alerts, newest first
+func (d *Detector) Recent() []Alert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	alerts := make([]Alert, len(d.recent))
+	for i, a := range d.recent {
+		alerts[len(alerts)-1-i] = a
+	}
+	return alerts
+}
+
+// Config returns the detector's configuration with defaults applied
+func (d *Detector) Config() Config {
+	return d.config
+}
+
+// Close stops closing windows
+func (d *Detector) Close() {
+	d.stopOnce.Do(func() { close(d.stop) })
+	d.wg.Wait()
+}
+
+func (d *Detector) loop() {
+	defer d.wg.Done()
+	ticker := time.NewTicker(d.config.Window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case now := <-ticker.C:
+			_ = crash.Do("anomaly detection", func() { d.Tick(now) })
+		}
+	}
+}
+
+// roll closes a series' windows before start, judging each against the
+// windows before it; the caller holds d.mu
+func (d *Detector) roll(k seriesKey, sr *series, start time.Time) []Alert {
+	var alerts []Alert
+	for sr.start.Before(start) {
+		alerts = append(alerts, d.judge(k, sr)...)
+		sr.history = append(sr.history, sr.current)
+		if len(sr.history) > d.config.Baseline {
+			sr.history = sr.history[len(sr.history)-d.config.Baseline:]
+		}
+		sr.current = window{}
+		sr.start = sr.start.Add(d.config.Window)
+
+		// After a long silence the baseline is all empty windows; skip
+		// ahead instead of closing each one
+		if sr.idle() {
+			sr.start = start
+		}
+	}
+	return alerts
+}
+
+// judge compares a series' current window with its baseline; the caller
+// holds d.mu
+func (d *Detector) judge(k seriesKey, sr *series) []Alert {
+	w := sr.current
+	if len(sr.history) < d.config.MinBaseline || w.requests < d.config.MinRequests {
+		return nil
+	}
+
+	var alerts []Alert
+	check := func(metric string, value float64, baseline []float64, floor float64) {
+		if value < floor || len(baseline) < d.config.MinBaseline {
+			return
+		}
+		mean, std := meanStdDev(baseline)
+		if value <= mean+d.config.Sigma*std || value < mean*(1+d.config.MinIncrease) {
+			return
+		}
+		if last, ok := sr.lastAlert[metric]; ok && sr.start.Sub(last) < d.config.Cooldown {
+			return
+		}
+		sr.lastAlert[metric] = sr.start
+		a := Alert{
+			Dimension:   k.dimension,
+			Key:         k.key,
+			Metric:      metric,
+			Value:       value,
+			Baseline:    mean,
+			StdDev:      std,
+			WindowStart: sr.start,
+			Window:      d.config.Window,
+		}
+		if std > 0 {
+			a.Sigma = (value - mean) / std
+		}
+		alerts = append(alerts, a)
+	}
+
+	requests := make([]float64, len(sr.history))
+	costs := make([]float64, len(sr.history))
+	var rates []float64 // Only windows with traffic have an error rate
+	for i, h := range sr.history {
+		requests[i] = float64(h.requests)
+		costs[i] = h.cost
+		if h.requests > 0 {
+			rates = append(rates, float64(h.errors)/float64(h.requests))
+		}
+	}
+	check(MetricRequests, float64(w.requests), requests, 0)
+	if w.cost > 0 {
+		check(MetricCost, w.cost, costs, 0)
+	}
+	check(MetricErrorRate, float64(w.errors)/float64(w.requests), rates, d.config.MinErrorRate)
+
+	d.recent = append(d.recent, alerts...)
+	if len(d.recent) > recentAlerts {
+		d.recent = d.recent[len(d.recent)-recentAlerts:]
+	}
+	return alerts
+}
+
+func (d *Detector) raise(alerts []Alert) {
+	if d.config.OnAlert == nil {
+		return
+	}
+	for _, a := range alerts {
+		d.config.OnAlert(a)
+	}
+}
+
+// idle reports whether a series has seen no traffic for its whole baseline
+func (sr *series) idle() bool {
+	if sr.current.requests > 0 {
+		return false
+	}
+	for _, h := range sr.history {
+		if h.requests > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
+
+// failed reports whether a response status counts as an error
+func failed(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests
+}
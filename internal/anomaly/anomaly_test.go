@@ -0,0 +1,99 @@
+package anomaly
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetector_Spikes(t *testing.T) {
+	var alerts []Alert
+	d := NewDetector(Config{Window: time.Hour, Baseline: 10, MinBaseline: 5, MinRequests: 5, OnAlert: func(a Alert) {
+		alerts = append(alerts, a)
+	}})
+	defer d.Close()
+
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	send := func(w, n, errors int, cost float64) {
+		for i := 0; i < n; i++ {
+			status := 200
+			if i < errors {
+				status = 500
+			}
+			d.Observe(Sample{Provider: "openai", ClientID: "app", Status: status, Cost: cost, At: base.Add(time.Duration(w) * time.Hour)})
+		}
+	}
+
+	// A steady baseline of 10 requests a window, alternating 9 and 11
+	for w := 0; w < 6; w++ {
+		send(w, 10+(w%2)*2-1, 0, 0.01)
+	}
+	d.Tick(base.Add(6 * time.Hour))
+	if len(alerts) != 0 {
+		t.Fatalf("expected no alerts for a steady baseline, got %+v", alerts)
+	}
+
+	// A runaway client: 10x the volume, half of it failing
+	send(6, 100, 50, 0.01)
+	d.Tick(base.Add(7 * time.Hour))
+
+	got := map[string]Alert{}
+	for _, a := range alerts {
+		got[a.Dimension+"/"+a.Metric] = a
+	}
+	for _, want := range []string{"provider/requests", "provider/cost_usd", "provider/error_rate", "client/requests", "client/cost_usd", "client/error_rate"} {
+		if _, ok := got[want]; !ok {
+			t.Errorf("expected a %s alert, got %+v", want, alerts)
+		}
+	}
+	if a := got["client/requests"]; a.Key != "app" || a.Value != 100 || a.Baseline != 10 || a.Sigma < 3 {
+		t.Errorf("unexpected client requests alert %+v", a)
+	}
+	if len(d.Recent()) != len(alerts) {
+		t.Errorf("expected %d recent alerts, got %d", len(alerts), len(d.Recent()))
+	}
+
+	// The same spike again is within the cooldown
+	alerts = nil
+	send(7, 100, 50, 0.01)
+	d.Tick(base.Add(8 * time.Hour))
+	if len(alerts) != 0 {
+		t.Errorf("expected the cooldown to hold back alerts, got %+v", alerts)
+	}
+}
+
+func TestDetector_IgnoresSmallWindows(t *testing.T) {
+	var alerts []Alert
+	d := NewDetector(Config{Window: time.Hour, MinBaseline: 3, OnAlert: func(a Alert) {
+		alerts = append(alerts, a)
+	}})
+	defer d.Close()
+
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for w := 0; w < 4; w++ {
+		d.Observe(Sample{Provider: "openai", Status: 200, At: base.Add(time.Duration(w) * time.Hour)})
+	}
+	// 10x the traffic, but under MinRequests
+	for i := 0; i < 10; i++ {
+		d.Observe(Sample{Provider: "openai", Status: 500, At: base.Add(4 * time.Hour)})
+	}
+	d.Tick(base.Add(5 * time.Hour))
+	if len(alerts) != 0 {
+		t.Errorf("expected no alerts below MinRequests, got %+v", alerts)
+	}
+}
+
+func TestDetector_DropsIdleSeries(t *testing.T) {
+	d := NewDetector(Config{Window: time.Minute, Baseline: 5})
+	defer d.Close()
+
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	d.Observe(Sample{Provider: "openai", ClientID: "app", Status: 200, At: base})
+	d.Tick(base.Add(time.Minute))
+	if len(d.series) != 2 {
+		t.Fatalf("expected 2 series, got %d", len(d.series))
+	}
+	d.Tick(base.Add(time.Hour))
+	if len(d.series) != 0 {
+		t.Errorf("expected idle series to be dropped, got %d", len(d.series))
+	}
+}
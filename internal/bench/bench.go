@@ -0,0 +1,377 @@
+// Package bench load-tests chat completion endpoints with synthetic requests,
+// measuring latency percentiles, throughput and error rates while staying
+// inside provider rate limits.
+package bench
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxErrorSamples bounds the distinct error messages kept per target
+const maxErrorSamples = 5
+
+// Limiter gates requests before they are sent. *ratelimit.RateLimiter
+// satisfies it; limit types it does not know must be allowed.
+type Limiter interface {
+	Acquire(ctx context.Context, limitType string, n int64) error
+}
+
+// Target is one endpoint under test
+type Target struct {
+	Name    string
+	URL     string      // Chat completions endpoint
+	Header  http.Header // Extra request headers (auth, provider override)
+	Limiter Limiter     // Optional; requests are not throttled when nil
+}
+
+// Config controls the synthetic workload sent to each target
+type Config struct {
+	Model       string
+	Prompt      string
+	MaxTokens   int
+	Requests    int
+	Concurrency int
+	Stream      bool
+	Timeout     time.Duration // Per request
+	Client      *http.Client
+}
+
+// DefaultConfig returns a small workload that is cheap to run against
+// paid providers
+func DefaultConfig() Config {
+	return Config{
+		Prompt:      "Reply with the single word: pong",
+		MaxTokens:   16,
+		Requests:    20,
+		Concurrency: 4,
+		Timeout:     60 * time.Second,
+	}
+}
+
+// Percentiles summarizes a latency distribution
+type Percentiles struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// Result is the outcome of benchmarking one target
+type Result struct {
+	Target       string        `json:"target"`
+	Requests     int           `json:"requests"`
+	Succeeded    int           `json:"succeeded"`
+	Errors       int           `json:"errors"`
+	RateLimited  int           `json:"rate_limited"`
+	Duration     time.Duration `json:"duration"`
+	Throughput   float64       `json:"throughput"` // Successful requests per second
+	TokensIn     int           `json:"tokens_in"`
+	TokensOut    int           `json:"tokens_out"`
+	Latency      Percentiles   `json:"latency"`
+	FirstToken   *Percentiles  `json:"first_token,omitempty"` // Streaming only
+	ErrorSamples []string      `json:"error_samples,omitempty"`
+}
+
+// ErrorRate is the fraction of requests that failed, including 429s
+func (r *Result) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors+r.RateLimited) / float64(r.Requests)
+}
+
+// RateLimitRate is the fraction of requests rejected with 429
+func (r *Result) RateLimitRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.RateLimited) / float64(r.Requests)
+}
+
+// sample is the measurement of a single request
+type sample struct {
+	latency    time.Duration
+	firstToken time.Duration
+	tokensIn   int
+	tokensOut  int
+	status     int
+	retryAfter time.Duration
+	err        error
+}
+
+// Run sends cfg.Requests requests to the target from cfg.Concurrency workers
+func Run(ctx context.Context, cfg Config, target Target) (*Result, error) {
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	if cfg.Requests <= 0 {
+		return nil, fmt.Errorf("requests must be positive")
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.Concurrency > cfg.Requests {
+		cfg.Concurrency = cfg.Requests
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{}
+	}
+	body, err := requestBody(cfg)
+	if err != nil {
+		return nil, err
+	}
+	estimate := int64(len(cfg.Prompt)/4 + cfg.MaxTokens)
+
+	jobs := make(chan struct{})
+	samples := make(chan sample, cfg.Requests)
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for range cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				if target.Limiter != nil {
+					if err := acquire(ctx, target.Limiter, estimate); err != nil {
+						samples <- sample{err: fmt.Errorf("rate limiter: %w", err)}
+						continue
+					}
+				}
+				s := send(ctx, cfg, target, body)
+				samples <- s
+
+				// Back off as instructed before this worker sends again
+				if s.status == http.StatusTooManyRequests && s.retryAfter > 0 {
+					select {
+					case <-ctx.Done():
+					case <-time.After(s.retryAfter):
+					}
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for range cfg.Requests {
+		select {
+		case jobs <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	close(samples)
+
+	result := summarize(target.Name, samples, cfg.Stream)
+	result.Duration = time.Since(start)
+	if secs := result.Duration.Seconds(); secs > 0 {
+		result.Throughput = float64(result.Succeeded) / secs
+	}
+	return result, ctx.Err()
+}
+
+// acquire takes one request and the estimated tokens from the limiter
+func acquire(ctx context.Context, l Limiter, tokens int64) error {
+	for _, limit := range []struct {
+		kind string
+		n    int64
+	}{{"rpm", 1}, {"rpd", 1}, {"tpm", tokens}} {
+		if err := l.Acquire(ctx, limit.kind, limit.n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requestBody builds the OpenAI-format request shared by every call
+func requestBody(cfg Config) ([]byte, error) {
+	req := map[string]interface{}{
+		"model":      cfg.Model,
+		"messages":   []map[string]string{{"role": "user", "content": cfg.Prompt}},
+		"max_tokens": cfg.MaxTokens,
+	}
+	if cfg.Stream {
+		req["stream"] = true
+		req["stream_options"] = map[string]bool{"include_usage": true}
+	}
+	return json.Marshal(req)
+}
+
+// usage is the token accounting block of a response or final stream chunk
+type usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// send performs one request and measures it
+func send(ctx context.Context, cfg Config, target Target, body []byte) sample {
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return sample{err: err}
+	}
+	for k, v := range target.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := cfg.Client.Do(req)
+	if err != nil {
+		return sample{latency: time.Since(start), err: err}
+	}
+	defer resp.Body.Close()
+
+	s := sample{status: resp.StatusCode}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		s.latency = time.Since(start)
+		s.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		s.err = fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+		return s
+	}
+
+	var u *usage
+	if cfg.Stream {
+		u, s.firstToken, err = readStream(resp.Body, start)
+	} else {
+		var out struct {
+			Usage *usage `json:"usage"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&out)
+		u = out.Usage
+	}
+	s.latency = time.Since(start)
+	if err != nil {
+		s.err = fmt.Errorf("invalid response: %w", err)
+		return s
+	}
+	if u != nil {
+		s.tokensIn, s.tokensOut = u.PromptTokens, u.CompletionTokens
+	}
+	return s
+}
+
+// readStream drains an SSE response, noting when the first content arrived
+func readStream(body io.Reader, start time.Time) (*usage, time.Duration, error) {
+	var u *usage
+	var firstToken time.Duration
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: error") {
+			return nil, firstToken, fmt.Errorf("stream error")
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok || data == "[DONE]" {
+			continue
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *usage `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		if firstToken == 0 && len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			firstToken = time.Since(start)
+		}
+		if chunk.Usage != nil {
+			u = chunk.Usage
+		}
+	}
+	return u, firstToken, scanner.Err()
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds
+func parseRetryAfter(v string) time.Duration {
+	secs, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// summarize folds request samples into a Result
+func summarize(name string, samples <-chan sample, stream bool) *Result {
+	result := &Result{Target: name}
+	var latencies, firstTokens []time.Duration
+	for s := range samples {
+		result.Requests++
+		switch {
+		case s.status == http.StatusTooManyRequests:
+			result.RateLimited++
+		case s.err != nil:
+			result.Errors++
+		default:
+			result.Succeeded++
+			result.TokensIn += s.tokensIn
+			result.TokensOut += s.tokensOut
+			latencies = append(latencies, s.latency)
+			if s.firstToken > 0 {
+				firstTokens = append(firstTokens, s.firstToken)
+			}
+		}
+		if s.err != nil && len(result.ErrorSamples) < maxErrorSamples && !slices.Contains(result.ErrorSamples, s.err.Error()) {
+			result.ErrorSamples = append(result.ErrorSamples, s.err.Error())
+		}
+	}
+	result.Latency = percentiles(latencies)
+	if stream && len(firstTokens) > 0 {
+		p := percentiles(firstTokens)
+		result.FirstToken = &p
+	}
+	return result
+}
+
+// percentiles computes nearest-rank percentiles
+func percentiles(d []time.Duration) Percentiles {
+	if len(d) == 0 {
+		return Percentiles{}
+	}
+	slices.Sort(d)
+	var total time.Duration
+	for _, v := range d {
+		total += v
+	}
+	rank := func(p float64) time.Duration {
+		i := int(math.Ceil(p*float64(len(d)))) - 1
+		return d[max(0, min(i, len(d)-1))]
+	}
+	return Percentiles{
+		Min:  d[0],
+		Mean: total / time.Duration(len(d)),
+		P50:  rank(0.50),
+		P90:  rank(0.90),
+		P95:  rank(0.95),
+		P99:  rank(0.99),
+		Max:  d[len(d)-1],
+	}
+}
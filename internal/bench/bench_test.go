@@ -0,0 +1,206 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingLimiter records how often each limit type was acquired
+type countingLimiter struct {
+	rpm, tpm atomic.Int64
+}
+
+func (l *countingLimiter) Acquire(ctx context.Context, limitType string, n int64) error {
+	switch limitType {
+	case "rpm":
+		l.rpm.Add(n)
+	case "tpm":
+		l.tpm.Add(n)
+	}
+	return nil
+}
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.Model = "test-model"
+	cfg.Requests = 10
+	cfg.Concurrency = 3
+	return cfg
+}
+
+func TestRun(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req["model"] != "test-model" {
+			t.Errorf("unexpected request %v (%v)", req, err)
+		}
+		if r.Header.Get("X-Test") != "yes" {
+			t.Error("target header not sent")
+		}
+		// Every fifth request is rate limited, every seventh fails
+		n := calls.Add(1)
+		switch {
+		case n%5 == 0:
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		case n%7 == 0:
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":2}}`))
+	}))
+	defer server.Close()
+
+	limiter := &countingLimiter{}
+	target := Target{
+		Name:    "mock",
+		URL:     server.URL,
+		Header:  http.Header{"X-Test": {"yes"}},
+		Limiter: limiter,
+	}
+	result, err := Run(context.Background(), testConfig(), target)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if result.Requests != 10 || result.Succeeded != 7 || result.RateLimited != 2 || result.Errors != 1 {
+		t.Errorf("unexpected counts: %+v", result)
+	}
+	if result.TokensIn != 35 || result.TokensOut != 14 {
+		t.Errorf("tokens = %d/%d, want 35/14", result.TokensIn, result.TokensOut)
+	}
+	if result.Latency.P50 <= 0 || result.Latency.Max < result.Latency.P50 {
+		t.Errorf("unexpected latency: %+v", result.Latency)
+	}
+	if result.Throughput <= 0 {
+		t.Error("expected positive throughput")
+	}
+	if got := result.ErrorRate(); got != 0.3 {
+		t.Errorf("ErrorRate() = %v, want 0.3", got)
+	}
+	if len(result.ErrorSamples) == 0 {
+		t.Error("expected error samples")
+	}
+	if limiter.rpm.Load() != 10 || limiter.tpm.Load() == 0 {
+		t.Errorf("limiter acquired rpm=%d tpm=%d", limiter.rpm.Load(), limiter.tpm.Load())
+	}
+}
+
+func TestRun_Stream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"po\"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"ng\"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":1}}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.Stream = true
+	result, err := Run(context.Background(), cfg, Target{Name: "stream", URL: server.URL})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Succeeded != 10 || result.TokensOut != 10 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.FirstToken == nil || result.FirstToken.P50 <= 0 {
+		t.Errorf("expected time-to-first-token, got %+v", result.FirstToken)
+	}
+}
+
+func TestRun_Validation(t *testing.T) {
+	if _, err := Run(context.Background(), Config{Requests: 1}, Target{}); err == nil {
+		t.Error("expected error without model")
+	}
+	if _, err := Run(context.Background(), Config{Model: "m"}, Target{}); err == nil {
+		t.Error("expected error without requests")
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	var d []time.Duration
+	for i := 100; i >= 1; i-- {
+		d = append(d, time.Duration(i)*time.Millisecond)
+	}
+	p := percentiles(d)
+	want := Percentiles{
+		Min:  time.Millisecond,
+		Mean: 50500 * time.Microsecond,
+		P50:  50 * time.Millisecond,
+		P90:  90 * time.Millisecond,
+		P95:  95 * time.Millisecond,
+		P99:  99 * time.Millisecond,
+		Max:  100 * time.Millisecond,
+	}
+	if p != want {
+		t.Errorf("percentiles = %+v, want %+v", p, want)
+	}
+	if percentiles(nil) != (Percentiles{}) {
+		t.Error("expected zero percentiles for no samples")
+	}
+}
+
+func TestPacer(t *testing.T) {
+	p := NewPacer(1200) // one slot every 50ms
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 3 {
+		if err := p.Acquire(ctx, "rpm", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 requests took %v, expected pacing", elapsed)
+	}
+	if err := p.Acquire(ctx, "tpm", 1000); err != nil {
+		t.Errorf("tpm should not be paced: %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	p.Acquire(ctx, "rpm", 10)
+	if err := p.Acquire(cancelled, "rpm", 1); err == nil {
+		t.Error("expected context error while waiting")
+	}
+}
+
+func TestWriteReports(t *testing.T) {
+	results := []*Result{{
+		Target:       "openai",
+		Requests:     4,
+		Succeeded:    3,
+		RateLimited:  1,
+		Latency:      Percentiles{P50: 120 * time.Millisecond},
+		ErrorSamples: []string{"429 Too Many Requests"},
+	}}
+
+	var text bytes.Buffer
+	if err := WriteText(&text, results); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"TARGET", "openai", "120ms", "25.0", "429 Too Many Requests"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text report missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := WriteJSON(&js, results); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []Result
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil || len(decoded) != 1 || decoded[0].RateLimited != 1 {
+		t.Errorf("unexpected JSON report %s (%v)", js.String(), err)
+	}
+}
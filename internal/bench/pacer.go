@@ -0,0 +1,54 @@
+package bench
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Pacer spaces requests evenly to stay under a requests-per-minute cap. It is
+// the fallback when no published rate limits are known for a provider.
+type Pacer struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+}
+
+// NewPacer creates a pacer allowing rpm requests per minute; rpm <= 0
+// disables pacing
+func NewPacer(rpm int) *Pacer {
+	p := &Pacer{}
+	if rpm > 0 {
+		p.interval = time.Minute / time.Duration(rpm)
+	}
+	return p
+}
+
+// Acquire waits for the next request slot. Only "rpm" is paced.
+func (p *Pacer) Acquire(ctx context.Context, limitType string, n int64) error {
+	if limitType != "rpm" || p.interval == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	slot := p.next
+	if slot.Before(now) {
+		slot = now
+	}
+	p.next = slot.Add(time.Duration(n) * p.interval)
+	p.mu.Unlock()
+
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
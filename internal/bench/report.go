@@ -0,0 +1,56 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteText writes a side-by-side comparison table of the results
+func WriteText(w io.Writer, results []*Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "TARGET\tREQS\tOK\tERR%\t429%\tREQ/S\tP50\tP90\tP95\tP99\tMAX\tTTFT P50\tTOKENS OUT\t")
+	for _, r := range results {
+		ttft := "-"
+		if r.FirstToken != nil {
+			ttft = ms(r.FirstToken.P50)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%.1f\t%.2f\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t\n",
+			r.Target, r.Requests, r.Succeeded,
+			r.ErrorRate()*100, r.RateLimitRate()*100, r.Throughput,
+			ms(r.Latency.P50), ms(r.Latency.P90), ms(r.Latency.P95), ms(r.Latency.P99), ms(r.Latency.Max),
+			ttft, r.TokensOut)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		if len(r.ErrorSamples) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s errors:\n", r.Target)
+		for _, e := range r.ErrorSamples {
+			fmt.Fprintf(w, "  %s\n", e)
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the results as an indented JSON array; durations are
+// in nanoseconds
+func WriteJSON(w io.Writer, results []*Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+// ms formats a duration in milliseconds for the table
+func ms(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0fms", float64(d)/float64(time.Millisecond))
+}
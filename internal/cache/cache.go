@@ -0,0 +1,148 @@
+// Package cache provides read-through caches for hot database reads, with
+// a TTL, explicit invalidation and hit metrics.
+package cache
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of one cache's metrics
+type Stats struct {
+	Name    string  `json:"name"`
+	Entries int     `json:"entries"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	Errors  int64   `json:"errors"` // Loads that failed; errors are not cached
+	HitRate float64 `json:"hit_rate"`
+	// Invalidations counts explicit invalidations, not TTL expiry
+	Invalidations int64 `json:"invalidations"`
+}
+
+type entry[V any] struct {
+	value    V
+	loadedAt time.Time
+}
+
+// Cache is a read-through cache. Get serves a stored value until it is
+// older than the TTL, then loads it again.
+type Cache[K comparable, V any] struct {
+	name string
+	ttl  time.Duration
+	load func(K) (V, error)
+	now  func() time.Time
+
+	mu         sync.Mutex
+	entries    map[K]entry[V]
+	generation uint64 // Bumped by Invalidate so racing loads are not stored
+
+	hits, misses, errors, invalidations atomic.Int64
+}
+
+// New creates a cache loading values with load. A zero ttl keeps values
+// until they are invalidated.
+func New[K comparable, V any](name string, ttl time.Duration, load func(K) (V, error)) *Cache[K, V] {
+	return &Cache[K, V]{
+		name:    name,
+		ttl:     ttl,
+		load:    load,
+		now:     time.Now,
+		entries: make(map[K]entry[V]),
+	}
+}
+
+// Get returns the cached value for key, loading it on a miss. Concurrent
+// misses for the same key may each load it.
+func (c *Cache[K, V]) Get(key K) (V, error) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	gen := c.generation
+	c.mu.Unlock()
+	if ok && (c.ttl <= 0 || c.now().Sub(e.loadedAt) < c.ttl) {
+		c.hits.Add(1)
+		return e.value, nil
+	}
+
+	c.misses.Add(1)
+	value, err := c.load(key)
+	if err != nil {
+		c.errors.Add(1)
+		return value, err
+	}
+	c.mu.Lock()
+	if c.generation == gen {
+		c.entries[key] = entry[V]{value: value, loadedAt: c.now()}
+	}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// Invalidate drops every cached value
+func (c *Cache[K, V]) Invalidate() {
+	c.mu.Lock()
+	c.entries = make(map[K]entry[V])
+	c.generation++
+	c.mu.Unlock()
+	c.invalidations.Add(1)
+}
+
+// Stats returns the cache's metrics
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+	s := Stats{
+		Name:          c.name,
+		Entries:       entries,
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Errors:        c.errors.Load(),
+		Invalidations: c.invalidations.Load(),
+	}
+	if total := s.Hits + s.Misses; total > 0 {
+		s.HitRate = float64(s.Hits) / float64(total)
+	}
+	return s
+}
+
+// Invalidator is a cache a Group can invalidate and report on
+type Invalidator interface {
+	Invalidate()
+	Stats() Stats
+}
+
+// Group invalidates and reports on several caches together
+type Group struct {
+	mu     sync.Mutex
+	caches []Invalidator
+}
+
+// Add adds caches to the group
+func (g *Group) Add(caches ...Invalidator) {
+	g.mu.Lock()
+	g.caches = append(g.caches, caches...)
+	g.mu.Unlock()
+}
+
+// Invalidate drops the values of every cache in the group
+func (g *Group) Invalidate() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, c := range g.caches {
+		c.Invalidate()
+	}
+}
+
+// Stats returns the metrics of every cache, sorted by name
+func (g *Group) Stats() []Stats {
+	g.mu.Lock()
+	stats := make([]Stats, 0, len(g.caches))
+	for _, c := range g.caches {
+		stats = append(stats, c.Stats())
+	}
+	g.mu.Unlock()
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
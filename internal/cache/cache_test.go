@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	loads := 0
+	fail := false
+	c := New("models", time.Minute, func(key string) (int, error) {
+		if fail {
+			return 0, errors.New("database is locked")
+		}
+		loads++
+		return len(key) * loads, nil
+	})
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	if v, err := c.Get("gpt-4o"); err != nil || v != 6 {
+		t.Fatalf("Get = %d, %v", v, err)
+	}
+	if v, _ := c.Get("gpt-4o"); v != 6 || loads != 1 {
+		t.Errorf("expected a hit, got %d after %d loads", v, loads)
+	}
+
+	// Expired values are loaded again
+	now = now.Add(time.Minute)
+	if v, _ := c.Get("gpt-4o"); v != 12 || loads != 2 {
+		t.Errorf("expected a reload after the TTL, got %d after %d loads", v, loads)
+	}
+
+	// Errors are returned and not cached
+	c.Invalidate()
+	fail = true
+	if _, err := c.Get("gpt-4o"); err == nil {
+		t.Error("expected the load error")
+	}
+	fail = false
+	if v, _ := c.Get("gpt-4o"); v != 18 {
+		t.Errorf("expected a fresh load after the error, got %d", v)
+	}
+
+	s := c.Stats()
+	if s.Name != "models" || s.Entries != 1 || s.Hits != 1 || s.Misses != 4 || s.Errors != 1 || s.Invalidations != 1 || s.HitRate != 0.2 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+}
+
+func TestCache_InvalidateDuringLoad(t *testing.T) {
+	var c *Cache[string, string]
+	version := "old"
+	c = New("clients", 0, func(string) (string, error) {
+		v := version
+		// An admin change lands while the old value is being read
+		version = "new"
+		c.Invalidate()
+		return v, nil
+	})
+
+	if v, _ := c.Get("acme"); v != "old" {
+		t.Fatalf("expected the loaded value, got %q", v)
+	}
+	if c.Stats().Entries != 0 {
+		t.Error("expected the value read before the invalidation not to be stored")
+	}
+}
+
+func TestGroup(t *testing.T) {
+	a := New("b", 0, func(k int) (int, error) { return k, nil })
+	b := New("a", 0, func(k int) (int, error) { return k, nil })
+	var g Group
+	g.Add(a, b)
+	a.Get(1)
+	b.Get(2)
+
+	g.Invalidate()
+	stats := g.Stats()
+	if len(stats) != 2 || stats[0].Name != "a" || stats[0].Entries != 0 || stats[1].Invalidations != 1 {
+		t.Errorf("unexpected group stats: %+v", stats)
+	}
+}
@@ -0,0 +1,177 @@
+// Package catalog snapshots each provider's model list and pricing on every
+// discovery run and diffs it against the previous snapshot, so the history
+// of when models appeared, disappeared or changed price is kept.
+package catalog
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// Store persists snapshots and changes
+type Store interface {
+	LatestCatalogSnapshot(providerID string) (*database.CatalogSnapshot, error)
+	CreateCatalogSnapshot(snap *database.CatalogSnapshot, changes []*database.CatalogChange) error
+	TouchCatalogSnapshot(id int, checkedAt time.Time) error
+}
+
+// Update is the outcome of recording a catalog that changed
+type Update struct {
+	Snapshot *database.CatalogSnapshot
+	Changes  []*database.CatalogChange
+}
+
+// Recorder records catalogs as runs see them
+type Recorder struct {
+	store    Store
+	onChange func(*Update)
+	now      func() time.Time
+	mu       sync.Mutex // Serializes diffing against the latest snapshot
+}
+
+// NewRecorder creates a Recorder. onChange, if set, is called for every
+// recorded catalog with at least one change.
+func NewRecorder(store Store, onChange func(*Update)) *Recorder {
+	return &Recorder{store: store, onChange: onChange, now: time.Now}
+}
+
+// Record snapshots a provider's catalog and returns the changes since the
+// previous snapshot. A provider's first snapshot is the baseline and has
+// no changes; a catalog identical to the previous one only marks that
+// snapshot as checked again.
+func (r *Recorder) Record(providerID, source string, models []*database.CatalogModel) ([]*database.CatalogChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	prev, err := r.store.LatestCatalogSnapshot(providerID)
+	if err != nil {
+		return nil, err
+	}
+	models = normalize(models)
+	if prev != nil && Equal(prev.Models, models) {
+		return nil, r.store.TouchCatalogSnapshot(prev.ID, now)
+	}
+
+	var changes []*database.CatalogChange
+	if prev != nil {
+		changes = Diff(prev.Models, models)
+	}
+	snap := &database.CatalogSnapshot{ProviderID: providerID, Source: source, TakenAt: now, Models: models}
+	if err := r.store.CreateCatalogSnapshot(snap, changes); err != nil {
+		return nil, fmt.Errorf("failed to record %s catalog: %w", providerID, err)
+	}
+	if len(changes) > 0 && r.onChange != nil {
+		r.onChange(&Update{Snapshot: snap, Changes: changes})
+	}
+	return changes, nil
+}
+
+// normalize sorts models by ID and drops duplicate and unnamed entries
+func normalize(models []*database.CatalogModel) []*database.CatalogModel {
+	out := make([]*database.CatalogModel, 0, len(models))
+	seen := make(map[string]bool, len(models))
+	for _, m := range models {
+		if m == nil || m.ModelID == "" || seen[m.ModelID] {
+			continue
+		}
+		seen[m.ModelID] = true
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ModelID < out[j].ModelID })
+	return out
+}
+
+// Diff lists the models added to and removed from prev, and those whose
+// input or output price changed. Prices are only compared when both
+// catalogs report them, so a source that omits pricing is not mistaken
+// for a price change.
+func Diff(prev, next []*database.CatalogModel) []*database.CatalogChange {
+	before := byID(prev)
+	after := byID(next)
+
+	var changes []*database.CatalogChange
+	for _, m := range normalize(next) {
+		old, ok := before[m.ModelID]
+		if !ok {
+			changes = append(changes, &database.CatalogChange{
+				ModelID: m.ModelID, Kind: database.CatalogModelAdded,
+				NewCostPer1MIn: m.CostPer1MIn, NewCostPer1MOut: m.CostPer1MOut,
+			})
+			continue
+		}
+		if priceChanged(old.CostPer1MIn, m.CostPer1MIn) || priceChanged(old.CostPer1MOut, m.CostPer1MOut) {
+			changes = append(changes, &database.CatalogChange{
+				ModelID: m.ModelID, Kind: database.CatalogPriceChanged,
+				OldCostPer1MIn: old.CostPer1MIn, OldCostPer1MOut: old.CostPer1MOut,
+				NewCostPer1MIn: m.CostPer1MIn, NewCostPer1MOut: m.CostPer1MOut,
+			})
+		}
+	}
+	for _, m := range normalize(prev) {
+		if _, ok := after[m.ModelID]; !ok {
+			changes = append(changes, &database.CatalogChange{
+				ModelID: m.ModelID, Kind: database.CatalogModelRemoved,
+				OldCostPer1MIn: m.CostPer1MIn, OldCostPer1MOut: m.CostPer1MOut,
+			})
+		}
+	}
+	return changes
+}
+
+// Equal reports whether two catalogs list the same models with the same
+// names, prices and context windows
+func Equal(a, b []*database.CatalogModel) bool {
+	a, b = normalize(a), normalize(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		x, y := a[i], b[i]
+		if x.ModelID != y.ModelID || x.Name != y.Name ||
+			!sameFloat(x.CostPer1MIn, y.CostPer1MIn) || !sameFloat(x.CostPer1MOut, y.CostPer1MOut) ||
+			!sameInt(x.ContextWindow, y.ContextWindow) {
+			return false
+		}
+	}
+	return true
+}
+
+func byID(models []*database.CatalogModel) map[string]*database.CatalogModel {
+	m := make(map[string]*database.CatalogModel, len(models))
+	for _, model := range models {
+		if model != nil {
+			m[model.ModelID] = model
+		}
+	}
+	return m
+}
+
+// priceChanged reports whether two known prices differ
+func priceChanged(old, cur *float64) bool {
+	return old != nil && cur != nil && !nearlyEqual(*old, *cur)
+}
+
+func sameFloat(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return nearlyEqual(*a, *b)
+}
+
+func sameInt(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// nearlyEqual ignores float noise from prices converted between units
+func nearlyEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
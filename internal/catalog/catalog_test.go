@@ -0,0 +1,99 @@
+package catalog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+func price(v float64) *float64 { return &v }
+
+func model(id string, in, out *float64) *database.CatalogModel {
+	return &database.CatalogModel{ModelID: id, CostPer1MIn: in, CostPer1MOut: out}
+}
+
+func TestDiff(t *testing.T) {
+	prev := []*database.CatalogModel{
+		model("gpt-4o", price(2.5), price(10)),
+		model("gpt-4o-mini", price(0.15), price(0.6)),
+		model("o1-mini", price(3), price(12)),
+		model("unpriced", nil, nil),
+	}
+	next := []*database.CatalogModel{
+		model("gpt-4o", price(2.5), price(8)),
+		model("gpt-4o-mini", nil, nil), // Pricing not reported this time
+		model("gpt-4.1", price(2), price(8)),
+		model("unpriced", price(1), price(1)),
+	}
+
+	changes := Diff(prev, next)
+	want := map[string]string{
+		"gpt-4.1": database.CatalogModelAdded,
+		"gpt-4o":  database.CatalogPriceChanged,
+		"o1-mini": database.CatalogModelRemoved,
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), changes)
+	}
+	for _, c := range changes {
+		if want[c.ModelID] != c.Kind {
+			t.Errorf("expected %s to be %s, got %s", c.ModelID, want[c.ModelID], c.Kind)
+		}
+		if c.Kind == database.CatalogPriceChanged && (*c.OldCostPer1MOut != 10 || *c.NewCostPer1MOut != 8 || *c.NewCostPer1MIn != 2.5) {
+			t.Errorf("expected the output price to drop from 10 to 8, got %+v", c)
+		}
+	}
+	if len(Diff(prev, prev)) != 0 {
+		t.Error("expected no changes between identical catalogs")
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "catalog.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var updates []*Update
+	rec := NewRecorder(db, func(u *Update) { updates = append(updates, u) })
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	rec.now = func() time.Time { return now }
+
+	// The first catalog is the baseline
+	first := []*database.CatalogModel{model("gpt-4o", price(2.5), price(10)), model("gpt-4o-mini", price(0.15), price(0.6))}
+	if changes, err := rec.Record("openai", database.CatalogSourceScheduler, first); err != nil || len(changes) != 0 {
+		t.Fatalf("Record = %+v, %v; expected a baseline without changes", changes, err)
+	}
+
+	// The same catalog in another order only moves the check time
+	now = now.Add(time.Hour)
+	same := []*database.CatalogModel{model("gpt-4o-mini", price(0.15), price(0.6)), model("gpt-4o", price(2.5), price(10))}
+	if changes, err := rec.Record("openai", database.CatalogSourceDiscovery, same); err != nil || len(changes) != 0 {
+		t.Fatalf("Record = %+v, %v; expected no changes", changes, err)
+	}
+	snaps, _ := db.ListCatalogSnapshots("openai")
+	if len(snaps) != 1 || !snaps[0].CheckedAt.Equal(now) {
+		t.Fatalf("expected one snapshot checked at %s, got %+v", now, snaps)
+	}
+
+	now = now.Add(time.Hour)
+	next := []*database.CatalogModel{model("gpt-4o", price(2), price(10)), model("gpt-4.1", price(2), price(8))}
+	changes, err := rec.Record("openai", database.CatalogSourceScheduler, next)
+	if err != nil || len(changes) != 3 {
+		t.Fatalf("Record = %+v, %v; expected 3 changes", changes, err)
+	}
+	if len(updates) != 1 || updates[0].Snapshot.ModelCount != 2 || len(updates[0].Changes) != 3 {
+		t.Fatalf("expected one change notification, got %+v", updates)
+	}
+
+	feed, _ := db.ListCatalogChanges(database.CatalogChangeFilter{ProviderID: "openai"})
+	if len(feed) != 3 || !feed[0].DetectedAt.Equal(now) {
+		t.Errorf("expected 3 stored changes detected at %s, got %+v", now, feed)
+	}
+	if snaps, _ := db.ListCatalogSnapshots(""); len(snaps) != 2 {
+		t.Errorf("expected 2 snapshots, got %d", len(snaps))
+	}
+}
@@ -0,0 +1,339 @@
+// Package chaos injects faults into outbound provider HTTP traffic so retry,
+// circuit breaker and failover paths can be exercised in staging.
+//
+// An Injector holds one Fault per provider ("*" applies to every provider
+// without its own fault) and wraps any http.RoundTripper. Each request can be
+// delayed, dropped with a connection error, answered with a synthetic error
+// status, or have its response body cut off part way through:
+//
+//	inj := chaos.New(0)
+//	inj.SetFault(chaos.Fault{Provider: "openai", ErrorRate: 0.2, Status: 503})
+//	client := &http.Client{Transport: inj.Transport("openai", nil)}
+//
+// Faults can be changed at runtime; the transport reads them per request.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// AnyProvider is the Fault.Provider matching providers without their own fault
+const AnyProvider = "*"
+
+// HeaderFault marks synthetic responses and truncated bodies
+const HeaderFault = "X-Chaos-Fault"
+
+// DefaultTruncateAfter is how many body bytes a truncated response delivers
+// when Fault.TruncateAfter is not set
+const DefaultTruncateAfter = 256
+
+// ErrDropped is returned for requests dropped by a fault
+var ErrDropped = errors.New("chaos: connection dropped")
+
+// Fault describes the failures injected for one provider. Rates are
+// fractions between 0 and 1, sampled independently for every request.
+type Fault struct {
+	Provider      string  `json:"provider"`
+	LatencyMs     int     `json:"latency_ms,omitempty"`     // Added before every request
+	JitterMs      int     `json:"jitter_ms,omitempty"`      // Random extra latency up to this much
+	DropRate      float64 `json:"drop_rate,omitempty"`      // Requests failed with ErrDropped
+	ErrorRate     float64 `json:"error_rate,omitempty"`     // Requests answered with Status
+	Status        int     `json:"status,omitempty"`         // Status for ErrorRate (default 503)
+	TruncateRate  float64 `json:"truncate_rate,omitempty"`  // Responses cut off mid-body
+	TruncateAfter int     `json:"truncate_after,omitempty"` // Bytes delivered before the cut (default 256)
+}
+
+// Validate checks rates and status codes
+func (f Fault) Validate() error {
+	if f.Provider == "" {
+		return fmt.Errorf("provider is required (use %q for all providers)", AnyProvider)
+	}
+	for name, rate := range map[string]float64{"drop_rate": f.DropRate, "error_rate": f.ErrorRate, "truncate_rate": f.TruncateRate} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %v", name, rate)
+		}
+	}
+	if f.LatencyMs < 0 || f.JitterMs < 0 || f.TruncateAfter < 0 {
+		return fmt.Errorf("latency_ms, jitter_ms and truncate_after must not be negative")
+	}
+	if f.Status != 0 && (f.Status < 400 || f.Status > 599) {
+		return fmt.Errorf("status must be a 4xx or 5xx code, got %d", f.Status)
+	}
+	return nil
+}
+
+// Stats counts the faults injected for one provider
+type Stats struct {
+	Provider  string `json:"provider"`
+	Requests  int64  `json:"requests"`
+	Delayed   int64  `json:"delayed"`
+	Dropped   int64  `json:"dropped"`
+	Errors    int64  `json:"errors"`
+	Truncated int64  `json:"truncated"`
+}
+
+// Injector holds the active faults and counts what it injected
+type Injector struct {
+	mu     sync.Mutex
+	faults map[string]Fault
+	stats  map[string]*Stats
+	rng    *rand.Rand
+}
+
+// New creates an injector with no faults. The seed makes sampling
+// reproducible; zero uses the current time.
+func New(seed int64) *Injector {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Injector{
+		faults: make(map[string]Fault),
+		stats:  make(map[string]*Stats),
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// SetFault adds or replaces the fault for f.Provider
+func (i *Injector) SetFault(f Fault) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	i.mu.Lock()
+	i.faults[f.Provider] = f
+	i.mu.Unlock()
+	return nil
+}
+
+// RemoveFault removes the fault for a provider, reporting whether one existed
+func (i *Injector) RemoveFault(provider string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	_, ok := i.faults[provider]
+	delete(i.faults, provider)
+	return ok
+}
+
+// Clear removes every fault
+func (i *Injector) Clear() {
+	i.mu.Lock()
+	i.faults = make(map[string]Fault)
+	i.mu.Unlock()
+}
+
+// Faults returns the active faults sorted by provider
+func (i *Injector) Faults() []Fault {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	faults := make([]Fault, 0, len(i.faults))
+	for _, f := range i.faults {
+		faults = append(faults, f)
+	}
+	sort.Slice(faults, func(a, b int) bool { return faults[a].Provider < faults[b].Provider })
+	return faults
+}
+
+// Stats returns the injection counters sorted by provider
+func (i *Injector) Stats() []Stats {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	stats := make([]Stats, 0, len(i.stats))
+	for _, s := range i.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(a, b int) bool { return stats[a].Provider < stats[b].Provider })
+	return stats
+}
+
+// decision is what to do with one request
+type decision struct {
+	delay    time.Duration
+	drop     bool
+	status   int
+	truncate int // Bytes to deliver, or -1 to leave the body alone
+}
+
+// decide samples the fault for a provider and updates the counters
+func (i *Injector) decide(provider string) (decision, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	f, ok := i.faults[provider]
+	if !ok {
+		if f, ok = i.faults[AnyProvider]; !ok {
+			return decision{}, false
+		}
+	}
+
+	s := i.stats[provider]
+	if s == nil {
+		s = &Stats{Provider: provider}
+		i.stats[provider] = s
+	}
+	s.Requests++
+
+	d := decision{truncate: -1, delay: time.Duration(f.LatencyMs) * time.Millisecond}
+	if f.JitterMs > 0 {
+		d.delay += time.Duration(i.rng.Intn(f.JitterMs+1)) * time.Millisecond
+	}
+	if d.delay > 0 {
+		s.Delayed++
+	}
+
+	switch {
+	case f.DropRate > 0 && i.rng.Float64() < f.DropRate:
+		d.drop = true
+		s.Dropped++
+	case f.ErrorRate > 0 && i.rng.Float64() < f.ErrorRate:
+		d.status = f.Status
+		if d.status == 0 {
+			d.status = http.StatusServiceUnavailable
+		}
+		s.Errors++
+	case f.TruncateRate > 0 && i.rng.Float64() < f.TruncateRate:
+		d.truncate = f.TruncateAfter
+		if d.truncate == 0 {
+			d.truncate = DefaultTruncateAfter
+		}
+		s.Truncated++
+	}
+	return d, true
+}
+
+// WithProvider tags a request context with the provider it is sent to, for
+// transports shared by several providers. The tag is shared with the
+// internal/http transport pool.
+func WithProvider(ctx context.Context, provider string) context.Context {
+	return mshttp.WithProvider(ctx, provider)
+}
+
+// ProviderFrom returns the provider set by WithProvider
+func ProviderFrom(ctx context.Context) string {
+	return mshttp.ProviderFrom(ctx)
+}
+
+// Transport wraps base (http.DefaultTransport when nil) with fault
+// injection. An empty provider reads it from each request's context.
+func (i *Injector) Transport(provider string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{injector: i, provider: provider, base: base}
+}
+
+// Middleware returns Transport for a fixed provider as a wrapping function
+func (i *Injector) Middleware(provider string) func(http.RoundTripper) http.RoundTripper {
+	return func(base http.RoundTripper) http.RoundTripper {
+		return i.Transport(provider, base)
+	}
+}
+
+type transport struct {
+	injector *Injector
+	provider string
+	base     http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	provider := t.provider
+	if provider == "" {
+		provider = ProviderFrom(req.Context())
+	}
+	d, ok := t.injector.decide(provider)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	if d.delay > 0 {
+		timer := time.NewTimer(d.delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			closeBody(req)
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	if d.drop {
+		closeBody(req)
+		return nil, ErrDropped
+	}
+	if d.status != 0 {
+		closeBody(req)
+		return errorResponse(req, d.status), nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || d.truncate < 0 {
+		return resp, err
+	}
+	resp.Header.Set(HeaderFault, "truncate")
+	resp.Body = &truncatedBody{body: resp.Body, remaining: d.truncate}
+	resp.ContentLength = -1
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// errorResponse builds an OpenAI-style error response without calling upstream
+func errorResponse(req *http.Request, status int) *http.Response {
+	body := fmt.Sprintf(`{"error":{"message":"chaos: injected %d","type":"chaos_fault","code":%d}}`, status, status)
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(HeaderFault, "error")
+	if status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable {
+		header.Set("Retry-After", "1")
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// truncatedBody delivers a limited number of bytes, then fails as if the
+// connection was lost
+type truncatedBody struct {
+	body      io.ReadCloser
+	remaining int
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if len(p) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= n
+	return n, err
+}
+
+func (b *truncatedBody) Close() error {
+	return b.body.Close()
+}
+
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
@@ -0,0 +1,204 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+func newUpstream(t *testing.T, hits *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		w.Write([]byte(strings.Repeat("x", 1000)))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFaultValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fault   Fault
+		wantErr bool
+	}{
+		{"valid", Fault{Provider: "openai", ErrorRate: 0.5, Status: 429}, false},
+		{"wildcard", Fault{Provider: AnyProvider, LatencyMs: 10}, false},
+		{"no provider", Fault{ErrorRate: 0.5}, true},
+		{"rate above one", Fault{Provider: "openai", DropRate: 1.5}, true},
+		{"negative rate", Fault{Provider: "openai", TruncateRate: -0.1}, true},
+		{"negative latency", Fault{Provider: "openai", LatencyMs: -1}, true},
+		{"success status", Fault{Provider: "openai", ErrorRate: 1, Status: 200}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fault.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTransportFaults(t *testing.T) {
+	var hits int32
+	upstream := newUpstream(t, &hits)
+
+	tests := []struct {
+		name     string
+		fault    Fault
+		check    func(t *testing.T, resp *http.Response, err error)
+		upstream bool
+	}{
+		{
+			name:  "drop",
+			fault: Fault{Provider: "openai", DropRate: 1},
+			check: func(t *testing.T, resp *http.Response, err error) {
+				if !errors.Is(err, ErrDropped) {
+					t.Errorf("error = %v, want ErrDropped", err)
+				}
+			},
+		},
+		{
+			name:  "error status",
+			fault: Fault{Provider: "openai", ErrorRate: 1, Status: 429},
+			check: func(t *testing.T, resp *http.Response, err error) {
+				if err != nil || resp.StatusCode != 429 || resp.Header.Get("Retry-After") != "1" || resp.Header.Get(HeaderFault) != "error" {
+					t.Fatalf("got %v, %v", resp, err)
+				}
+				resp.Body.Close()
+			},
+		},
+		{
+			name:     "truncate",
+			fault:    Fault{Provider: "openai", TruncateRate: 1, TruncateAfter: 100},
+			upstream: true,
+			check: func(t *testing.T, resp *http.Response, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				body, err := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if len(body) != 100 || !errors.Is(err, io.ErrUnexpectedEOF) {
+					t.Errorf("read %d bytes, error %v; want 100 bytes and ErrUnexpectedEOF", len(body), err)
+				}
+			},
+		},
+		{
+			name:     "other provider untouched",
+			fault:    Fault{Provider: "anthropic", DropRate: 1},
+			upstream: true,
+			check: func(t *testing.T, resp *http.Response, err error) {
+				if err != nil || resp.StatusCode != http.StatusOK {
+					t.Fatalf("got %v, %v", resp, err)
+				}
+				resp.Body.Close()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inj := New(1)
+			if err := inj.SetFault(tt.fault); err != nil {
+				t.Fatal(err)
+			}
+			before := atomic.LoadInt32(&hits)
+			client := &http.Client{Transport: inj.Transport("openai", nil)}
+			resp, err := client.Get(upstream.URL)
+			tt.check(t, resp, err)
+			if reached := atomic.LoadInt32(&hits) > before; reached != tt.upstream {
+				t.Errorf("upstream reached = %v, want %v", reached, tt.upstream)
+			}
+		})
+	}
+}
+
+func TestTransportLatencyAndContextProvider(t *testing.T) {
+	var hits int32
+	upstream := newUpstream(t, &hits)
+
+	inj := New(1)
+	inj.SetFault(Fault{Provider: AnyProvider, LatencyMs: 30})
+	client := &http.Client{Transport: inj.Transport("", nil)}
+
+	req, _ := http.NewRequestWithContext(WithProvider(context.Background(), "groq"), http.MethodGet, upstream.URL, nil)
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("request took %v, want at least 30ms", elapsed)
+	}
+
+	// Cancellation interrupts the injected delay
+	inj.SetFault(Fault{Provider: AnyProvider, LatencyMs: 5000})
+	ctx, cancel := context.WithTimeout(WithProvider(context.Background(), "groq"), 20*time.Millisecond)
+	defer cancel()
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want deadline exceeded", err)
+	}
+
+	stats := inj.Stats()
+	if len(stats) != 1 || stats[0].Provider != "groq" || stats[0].Requests != 2 || stats[0].Delayed != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestInjectorFaultManagement(t *testing.T) {
+	inj := New(1)
+	inj.SetFault(Fault{Provider: "openai", ErrorRate: 0.1})
+	inj.SetFault(Fault{Provider: AnyProvider, DropRate: 0.1})
+	inj.SetFault(Fault{Provider: "openai", ErrorRate: 0.2})
+
+	faults := inj.Faults()
+	if len(faults) != 2 || faults[0].Provider != AnyProvider || faults[1].ErrorRate != 0.2 {
+		t.Errorf("unexpected faults: %+v", faults)
+	}
+	if !inj.RemoveFault("openai") || inj.RemoveFault("openai") {
+		t.Error("RemoveFault should report whether a fault existed")
+	}
+	inj.Clear()
+	if len(inj.Faults()) != 0 {
+		t.Error("Clear should remove all faults")
+	}
+}
+
+// The HTTP client's retries recover from injected failures
+func TestClientRetriesInjectedErrors(t *testing.T) {
+	var hits int32
+	upstream := newUpstream(t, &hits)
+
+	inj := New(3)
+	inj.SetFault(Fault{Provider: "openai", ErrorRate: 0.5, Status: 500})
+	client := mshttp.NewClient(mshttp.Config{
+		WrapTransport: inj.Middleware("openai"),
+		Retry:         mshttp.RetryConfig{MaxAttempts: 10, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	})
+
+	for i := 0; i < 20; i++ {
+		req, _ := http.NewRequest(http.MethodGet, upstream.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("status = %d after retries", resp.StatusCode)
+		}
+	}
+	stats := inj.Stats()
+	if stats[0].Errors == 0 || stats[0].Requests != stats[0].Errors+20 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,341 @@
+// Package chargeback builds monthly chargeback reports from the cost
+// ledger, so platform teams can bill the internal consumers of a shared
+// instance: each tenant's spend split by the clients (virtual keys) that
+// ran it, and each client's by model.
+//
+// Reports are written as CSV, one row per tenant, client and model for
+// billing systems and spreadsheets, as Markdown for people, or as JSON.
+// Months are UTC calendar months.
+package chargeback
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// Output formats
+const (
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+)
+
+// Formats lists the output formats
+var Formats = []string{FormatCSV, FormatMarkdown, FormatJSON}
+
+// Ledger reads the cost ledger and the tenants it charges (usually a
+// *database.TenantRepository)
+type Ledger interface {
+	UsageByClient(from, to time.Time) ([]*database.TenantClientUsage, error)
+	List() ([]*database.Tenant, error)
+}
+
+// Clients lists the clients usage is charged to (usually a
+// *database.ClientRepository)
+type Clients interface {
+	List() ([]*database.Client, error)
+}
+
+// Usage is what a tenant, client or model ran up
+type Usage struct {
+	Requests         int     `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CachedTokens     int64   `json:"cached_tokens"`
+	Cost             float64 `json:"cost"`
+	CacheSavings     float64 `json:"cache_savings"`
+}
+
+func (u *Usage) add(o Usage) {
+	u.Requests += o.Requests
+	u.PromptTokens += o.PromptTokens
+	u.CompletionTokens += o.CompletionTokens
+	u.CachedTokens += o.CachedTokens
+	u.Cost += o.Cost
+	u.CacheSavings += o.CacheSavings
+}
+
+// ModelCharge is a client's usage of one model
+type ModelCharge struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Usage
+}
+
+// ClientCharge is one client's usage, by model
+type ClientCharge struct {
+	ClientID string `json:"client_id"` // "" for requests without a client
+	Name     string `json:"name,omitempty"`
+	Usage
+	Models []*ModelCharge `json:"models"`
+}
+
+// TenantCharge is one tenant's usage, by client
+type TenantCharge struct {
+	TenantID string  `json:"tenant_id"`
+	Name     string  `json:"name,omitempty"`   // "" for tenants deleted since
+	Budget   float64 `json:"budget,omitempty"` // Monthly, when set
+	Usage
+	Clients []*ClientCharge `json:"clients"`
+}
+
+// Report is a month's chargeback. Tenants, clients and models are listed
+// by cost, highest first.
+type Report struct {
+	Month   time.Time       `json:"month"`
+	Tenants []*TenantCharge `json:"tenants"`
+	Usage
+}
+
+// MonthOf returns the start of the UTC month holding t
+func MonthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ParseMonth reads a month given as YYYY-MM
+func ParseMonth(s string) (time.Time, error) {
+	t, err := time.Parse("2006-01", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q (want YYYY-MM)", s)
+	}
+	return t, nil
+}
+
+// Build totals the ledger for the month holding month, for one tenant or
+// all of them when tenantID is empty
+func Build(ledger Ledger, clients Clients, month time.Time, tenantID string) (*Report, error) {
+	month = MonthOf(month)
+	usage, err := ledger.UsageByClient(month, month.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+	tenants, err := ledger.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	clientList, err := clients.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clients: %w", err)
+	}
+	tenantByID := make(map[string]*database.Tenant, len(tenants))
+	for _, t := range tenants {
+		tenantByID[t.ID] = t
+	}
+	clientNames := make(map[string]string, len(clientList))
+	for _, c := range clientList {
+		clientNames[c.ID] = c.Name
+	}
+
+	report := &Report{Month: month, Tenants: []*TenantCharge{}}
+	byTenant := make(map[string]*TenantCharge)
+	byClient := make(map[[2]string]*ClientCharge)
+	for _, u := range usage {
+		if tenantID != "" && u.TenantID != tenantID {
+			continue
+		}
+		tc, ok := byTenant[u.TenantID]
+		if !ok {
+			tc = &TenantCharge{TenantID: u.TenantID, Clients: []*ClientCharge{}}
+			if t := tenantByID[u.TenantID]; t != nil {
+				tc.Name, tc.Budget = t.Name, t.MonthlyBudget
+			}
+			byTenant[u.TenantID] = tc
+			report.Tenants = append(report.Tenants, tc)
+		}
+		cc, ok := byClient[[2]string{u.TenantID, u.ClientID}]
+		if !ok {
+			cc = &ClientCharge{ClientID: u.ClientID, Name: clientNames[u.ClientID], Models: []*ModelCharge{}}
+			byClient[[2]string{u.TenantID, u.ClientID}] = cc
+			tc.Clients = append(tc.Clients, cc)
+		}
+		m := &ModelCharge{Provider: u.Provider, Model: u.Model, Usage: Usage{
+			Requests:         u.Requests,
+			PromptTokens:     u.PromptTokens,
+			CompletionTokens: u.CompletionTokens,
+			CachedTokens:     u.CachedTokens,
+			Cost:             u.Cost,
+			CacheSavings:     u.CacheSavings,
+		}}
+		cc.Models = append(cc.Models, m)
+		cc.add(m.Usage)
+		tc.add(m.Usage)
+		report.add(m.Usage)
+	}
+
+	sortByCost(report.Tenants, func(t *TenantCharge) (float64, string) { return t.Cost, t.TenantID })
+	for _, tc := range report.Tenants {
+		sortByCost(tc.Clients, func(c *ClientCharge) (float64, string) { return c.Cost, c.ClientID })
+		for _, cc := range tc.Clients {
+			sortByCost(cc.Models, func(m *ModelCharge) (float64, string) { return m.Cost, m.Provider + "/" + m.Model })
+		}
+	}
+	return report, nil
+}
+
+// sortByCost orders charges by cost, highest first, then by key
+func sortByCost[T any](items []T, by func(T) (float64, string)) {
+	sort.SliceStable(items, func(i, j int) bool {
+		ci, ki := by(items[i])
+		cj, kj := by(items[j])
+		if ci != cj {
+			return ci > cj
+		}
+		return ki < kj
+	})
+}
+
+// ContentType returns the MIME type of a format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	}
+	return "application/json"
+}
+
+// Extension returns the file extension of a format
+func Extension(format string) string {
+	if format == FormatMarkdown {
+		return "md"
+	}
+	return format
+}
+
+// Write writes a report in a format
+func Write(w io.Writer, r *Report, format string) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, r)
+	case FormatMarkdown:
+		return WriteMarkdown(w, r)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+	return fmt.Errorf("unknown format %q (want csv, markdown or json)", format)
+}
+
+// WriteCSV writes one row per tenant, client and model
+func WriteCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"month", "tenant_id", "tenant_name", "client_id", "client_name", "provider", "model",
+		"requests", "prompt_tokens", "completion_tokens", "cached_tokens", "cost_usd", "cache_savings_usd"})
+	month := r.Month.Format("2006-01")
+	for _, tc := range r.Tenants {
+		for _, cc := range tc.Clients {
+			for _, m := range cc.Models {
+				cw.Write([]string{month, tc.TenantID, tc.Name, cc.ClientID, cc.Name, m.Provider, m.Model,
+					strconv.Itoa(m.Requests),
+					strconv.FormatInt(m.PromptTokens, 10),
+					strconv.FormatInt(m.CompletionTokens, 10),
+					strconv.FormatInt(m.CachedTokens, 10),
+					strconv.FormatFloat(m.Cost, 'f', 6, 64),
+					strconv.FormatFloat(m.CacheSavings, 'f', 6, 64),
+				})
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMarkdown writes a summary table of the tenants, then a section per
+// tenant detailing its clients and models
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Chargeback report: %s\n\n", r.Month.Format("January 2006"))
+	if len(r.Tenants) == 0 {
+		b.WriteString("No usage was charged to a tenant this month.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	fmt.Fprintf(&b, "%s across %d tenants: %s requests, %s tokens.\n\n",
+		usd(r.Cost), len(r.Tenants), count(int64(r.Requests)), count(r.PromptTokens+r.CompletionTokens))
+
+	b.WriteString("| Tenant | Clients | Requests | Tokens | Cost | Budget |\n")
+	b.WriteString("|---|---:|---:|---:|---:|---:|\n")
+	for _, tc := range r.Tenants {
+		fmt.Fprintf(&b, "| %s | %d | %s | %s | %s | %s |\n", cell(tenantLabel(tc)), len(tc.Clients),
+			count(int64(tc.Requests)), count(tc.PromptTokens+tc.CompletionTokens), usd(tc.Cost), budget(tc))
+	}
+
+	for _, tc := range r.Tenants {
+		fmt.Fprintf(&b, "\n## %s\n\n", tenantLabel(tc))
+		fmt.Fprintf(&b, "%s for %s requests", usd(tc.Cost), count(int64(tc.Requests)))
+		if tc.CacheSavings > 0 {
+			fmt.Fprintf(&b, ", after %s saved by prompt caching", usd(tc.CacheSavings))
+		}
+		b.WriteString(".\n\n")
+		b.WriteString("| Client | Provider | Model | Requests | Prompt tokens | Completion tokens | Cost |\n")
+		b.WriteString("|---|---|---|---:|---:|---:|---:|\n")
+		for _, cc := range tc.Clients {
+			for _, m := range cc.Models {
+				fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n", cell(clientLabel(cc)), cell(m.Provider), cell(m.Model),
+					count(int64(m.Requests)), count(m.PromptTokens), count(m.CompletionTokens), usd(m.Cost))
+			}
+			if len(cc.Models) > 1 {
+				fmt.Fprintf(&b, "| %s total | | | %s | %s | %s | %s |\n", cell(clientLabel(cc)),
+					count(int64(cc.Requests)), count(cc.PromptTokens), count(cc.CompletionTokens), usd(cc.Cost))
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func tenantLabel(tc *TenantCharge) string {
+	if tc.Name == "" || tc.Name == tc.TenantID {
+		return tc.TenantID
+	}
+	return fmt.Sprintf("%s (%s)", tc.Name, tc.TenantID)
+}
+
+func clientLabel(cc *ClientCharge) string {
+	switch {
+	case cc.ClientID == "":
+		return "(no client)"
+	case cc.Name == "" || cc.Name == cc.ClientID:
+		return cc.ClientID
+	}
+	return fmt.Sprintf("%s (%s)", cc.Name, cc.ClientID)
+}
+
+func budget(tc *TenantCharge) string {
+	if tc.Budget <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%.0f%%)", usd(tc.Budget), tc.Cost/tc.Budget*100)
+}
+
+// cell escapes a value for a Markdown table
+func cell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func usd(v float64) string {
+	return fmt.Sprintf("$%.2f", v)
+}
+
+// count formats a number with thousands separators
+func count(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	if n < 0 {
+		return "-" + count(-n)
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,166 @@
+package chargeback
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+type fakeLedger struct {
+	usage    []*database.TenantClientUsage
+	tenants  []*database.Tenant
+	from, to time.Time
+}
+
+func (l *fakeLedger) UsageByClient(from, to time.Time) ([]*database.TenantClientUsage, error) {
+	l.from, l.to = from, to
+	return l.usage, nil
+}
+
+func (l *fakeLedger) List() ([]*database.Tenant, error) {
+	return l.tenants, nil
+}
+
+type fakeClients []*database.Client
+
+func (c fakeClients) List() ([]*database.Client, error) {
+	return c, nil
+}
+
+func usage(tenant, client, provider, model string, requests int, cost float64) *database.TenantClientUsage {
+	return &database.TenantClientUsage{TenantID: tenant, ClientID: client, TenantModelUsage: database.TenantModelUsage{
+		Provider: provider, Model: model, Requests: requests,
+		PromptTokens: int64(requests) * 1000, CompletionTokens: int64(requests) * 100, Cost: cost,
+	}}
+}
+
+func newLedger() *fakeLedger {
+	return &fakeLedger{
+		usage: []*database.TenantClientUsage{
+			usage("team-a", "", "openai", "gpt-4o-mini", 10, 0.5),
+			usage("team-a", "cli-1", "anthropic", "claude-sonnet-4", 40, 12),
+			usage("team-a", "cli-1", "openai", "gpt-4o", 20, 8),
+			usage("team-b", "cli-2", "openai", "gpt-4o", 5, 30),
+			usage("gone", "cli-9", "openai", "gpt-4o", 1, 1),
+		},
+		tenants: []*database.Tenant{
+			{ID: "team-a", Name: "Search", MonthlyBudget: 100},
+			{ID: "team-b", Name: "Ads | EMEA"},
+		},
+	}
+}
+
+var clients = fakeClients{{ID: "cli-1", Name: "indexer"}, {ID: "cli-2", Name: "cli-2"}}
+
+func TestBuild(t *testing.T) {
+	ledger := newLedger()
+	r, err := Build(ledger, clients, time.Date(2025, time.March, 17, 15, 0, 0, 0, time.FixedZone("PST", -8*3600)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	march := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	if !r.Month.Equal(march) || !ledger.from.Equal(march) || !ledger.to.Equal(march.AddDate(0, 1, 0)) {
+		t.Errorf("expected the UTC calendar month, got %v from %v to %v", r.Month, ledger.from, ledger.to)
+	}
+	if r.Requests != 76 || r.Cost != 51.5 || len(r.Tenants) != 3 {
+		t.Fatalf("unexpected totals: %+v", r)
+	}
+
+	// Highest cost first at every level
+	if r.Tenants[0].TenantID != "team-b" || r.Tenants[1].TenantID != "team-a" || r.Tenants[2].TenantID != "gone" {
+		t.Errorf("expected tenants by cost, got %s, %s, %s", r.Tenants[0].TenantID, r.Tenants[1].TenantID, r.Tenants[2].TenantID)
+	}
+	a := r.Tenants[1]
+	if a.Name != "Search" || a.Budget != 100 || a.Cost != 20.5 || a.Requests != 70 || len(a.Clients) != 2 {
+		t.Fatalf("unexpected team-a charge: %+v", a)
+	}
+	c := a.Clients[0]
+	if c.ClientID != "cli-1" || c.Name != "indexer" || c.Cost != 20 || len(c.Models) != 2 || c.Models[0].Model != "claude-sonnet-4" {
+		t.Errorf("unexpected cli-1 charge: %+v", c)
+	}
+	if a.Clients[1].ClientID != "" || a.Clients[1].Cost != 0.5 {
+		t.Errorf("expected requests without a client charged apart, got %+v", a.Clients[1])
+	}
+	if r.Tenants[2].Name != "" {
+		t.Errorf("expected a deleted tenant without a name, got %+v", r.Tenants[2])
+	}
+
+	// One tenant
+	r, err = Build(newLedger(), clients, march, "team-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Tenants) != 1 || r.Cost != 20.5 {
+		t.Errorf("expected only team-a, got %+v", r)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	r, err := Build(newLedger(), clients, time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, r, FormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 6 || rows[0][0] != "month" || rows[0][11] != "cost_usd" {
+		t.Fatalf("expected a header and a row per model, got %v", rows)
+	}
+	want := []string{"2025-03", "team-a", "Search", "cli-1", "indexer", "anthropic", "claude-sonnet-4", "40", "40000", "4000", "0", "12.000000", "0.000000"}
+	if strings.Join(rows[2], ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected row:\n got %v\nwant %v", rows[2], want)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	r, err := Build(newLedger(), clients, time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, r, FormatMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# Chargeback report: March 2025",
+		"$51.50 across 3 tenants: 76 requests, 83,600 tokens.",
+		"| Search (team-a) | 2 | 70 | 77,000 | $20.50 | $100.00 (20%) |",
+		`| Ads \| EMEA (team-b) | 1 |`,
+		"## Search (team-a)",
+		"| indexer (cli-1) | anthropic | claude-sonnet-4 | 40 | 40,000 | 4,000 | $12.00 |",
+		"| indexer (cli-1) total | | | 60 | 60,000 | 6,000 | $20.00 |",
+		"| (no client) | openai | gpt-4o-mini |",
+		"| cli-2 | openai | gpt-4o |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteMarkdown(&buf, &Report{Month: time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "No usage") {
+		t.Errorf("expected an empty month noted, got %s", buf.String())
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, &Report{}, "xlsx"); err == nil {
+		t.Error("expected an unknown format to fail")
+	}
+	if Extension(FormatMarkdown) != "md" || ContentType(FormatCSV) != "text/csv; charset=utf-8" {
+		t.Error("unexpected format metadata")
+	}
+}
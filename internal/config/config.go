@@ -1,29 +1,120 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the minimal bootstrap configuration
 type Config struct {
-	Database  DatabaseConfig      `yaml:"database"`
-	Server    ServerConfig        `yaml:"server"`
-	APIKeys   map[string][]string `yaml:"api_keys"` // provider -> keys
-	Discovery DiscoveryConfig     `yaml:"discovery"`
+	Database     DatabaseConfig      `yaml:"database"`
+	Server       ServerConfig        `yaml:"server"`
+	APIKeys      map[string][]string `yaml:"api_keys"` // provider -> keys
+	Discovery    DiscoveryConfig     `yaml:"discovery"`
+	Shadow       ShadowConfig        `yaml:"shadow"`
+	Guardrails   GuardrailsConfig    `yaml:"guardrails"`
+	Webhooks     WebhooksConfig      `yaml:"webhooks"`
+	Scheduler    SchedulerConfig     `yaml:"scheduler"`
+	Scraper      ScraperConfig       `yaml:"scraper"`
+	Chaos        ChaosConfig         `yaml:"chaos"`
+	Signing      SigningConfig       `yaml:"signing"`
+	Transport    TransportConfig     `yaml:"transport"`
+	Warmup       WarmupConfig        `yaml:"warmup"`
+	Fallback     FallbackConfig      `yaml:"fallback"`
+	Transforms   TransformsConfig    `yaml:"transforms"`
+	Experiments  ExperimentsConfig   `yaml:"experiments"`
+	Concurrency  ConcurrencyConfig   `yaml:"concurrency"`
+	ModelLimits  ModelLimitsConfig   `yaml:"model_limits"`
+	Vision       VisionConfig        `yaml:"vision"`
+	Embeddings   EmbeddingsConfig    `yaml:"embeddings"`
+	VectorStore  VectorStoreConfig   `yaml:"vector_store"`
+	Completions  CompletionsConfig   `yaml:"completions"`
+	StatusPages  StatusPagesConfig   `yaml:"status_pages"`
+	SLA          SLAConfig           `yaml:"sla"`
+	Heatmap      HeatmapConfig       `yaml:"latency_heatmap"`
+	KeyHealth    KeyHealthConfig     `yaml:"key_health"`
+	Streaming    StreamingConfig     `yaml:"streaming"`
+	Events       EventsConfig        `yaml:"events"`
+	Anomaly      AnomalyConfig       `yaml:"anomaly"`
+	Forecast     ForecastConfig      `yaml:"forecast"`
+	Residency    ResidencyConfig     `yaml:"residency"`
+	Deprecations DeprecationsConfig  `yaml:"deprecations"`
+	Logging      LoggingConfig       `yaml:"logging"`
+	CrashReports CrashReportsConfig  `yaml:"crash_reports"`
+	Watchdog     WatchdogConfig      `yaml:"watchdog"`
 }
 
 // DatabaseConfig holds database settings
 type DatabaseConfig struct {
-	Path string `yaml:"path"`
+	Path          string `yaml:"path"`
+	RateLimitPath string `yaml:"rate_limit_path"` // rate limit and pricing database (scheduler pricing task, scraper)
+	URL           string `yaml:"url"`             // PostgreSQL connection string; replaces path and rate_limit_path
+	JournalMode   string `yaml:"journal_mode"`    // SQLite journal mode (default WAL)
+	Synchronous   string `yaml:"synchronous"`     // SQLite synchronous mode (default NORMAL)
+	BusyTimeoutMs int    `yaml:"busy_timeout_ms"` // wait on a locked database (default 5000)
+
+	CacheTTLSeconds int `yaml:"cache_ttl_seconds"` // hot read cache TTL (default 60)
+
+	ManualMigrations bool                     `yaml:"manual_migrations"` // refuse to start with pending migrations instead of applying them
+	Batch            DatabaseBatchConfig      `yaml:"batch"`
+	Encryption       DatabaseEncryptionConfig `yaml:"encryption"`
+}
+
+// DatabaseBatchConfig holds write-behind batching settings for usage and
+// request log inserts
+type DatabaseBatchConfig struct {
+	Enabled         bool `yaml:"enabled"`
+	MaxBatch        int  `yaml:"max_batch"`         // writes per transaction (default 256)
+	FlushIntervalMs int  `yaml:"flush_interval_ms"` // longest a write waits in the buffer (default 250)
+	QueueSize       int  `yaml:"queue_size"`        // buffered writes before callers block (default 4096)
+}
+
+// DatabaseEncryptionConfig holds encryption at rest settings for API keys
+// and client tokens. The master key is never stored in the database.
+type DatabaseEncryptionConfig struct {
+	Enabled          bool   `yaml:"enabled"`
+	MasterKeyEnv     string `yaml:"master_key_env"`     // env var holding the base64 or hex master key (default MODELSCAN_MASTER_KEY)
+	MasterKeyCommand string `yaml:"master_key_command"` // command printing the master key, e.g. a KMS CLI; replaces master_key_env
 }
 
 // ServerConfig holds server settings
 type ServerConfig struct {
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
+	Host                  string      `yaml:"host"`
+	Port                  int         `yaml:"port"`
+	AccessLog             bool        `yaml:"access_log"`              // log one line per request with its X-Request-ID
+	RequestTimeoutSeconds int         `yaml:"request_timeout_seconds"` // deadline budget cap per proxied request (default 300)
+	CORS                  CORSConfig  `yaml:"cors"`
+	Gzip                  GzipConfig  `yaml:"gzip"`
+	Debug                 DebugConfig `yaml:"debug"`
+}
+
+// DebugConfig exposes pprof, expvar and /debug/status on the server
+type DebugConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	TokenEnv string `yaml:"token_env"` // env var holding the required bearer token (default MODELSCAN_DEBUG_TOKEN)
+}
+
+// CORSConfig lets browser clients call the proxy and admin API directly
+type CORSConfig struct {
+	Enabled          bool     `yaml:"enabled"`
+	AllowedOrigins   []string `yaml:"allowed_origins"`   // exact origins, "https://*.example.com" or "*"
+	AllowedMethods   []string `yaml:"allowed_methods"`   // default GET, POST, PUT, PATCH, DELETE
+	AllowedHeaders   []string `yaml:"allowed_headers"`   // default the auth, content and provider headers
+	ExposedHeaders   []string `yaml:"exposed_headers"`   // default X-Request-ID
+	AllowCredentials bool     `yaml:"allow_credentials"` // allow cookies; the origin is echoed instead of "*"
+	MaxAgeSeconds    int      `yaml:"max_age_seconds"`   // preflight cache (default 600)
+}
+
+// GzipConfig holds response compression settings
+type GzipConfig struct {
+	Enabled      bool `yaml:"enabled"`
+	MinSizeBytes int  `yaml:"min_size_bytes"` // smaller responses are sent as is (default 1024)
+	Level        int  `yaml:"level"`          // 1 (fastest) to 9 (smallest); default 6
 }
 
 // DiscoveryConfig holds discovery agent settings
@@ -35,6 +126,508 @@ type DiscoveryConfig struct {
 	RoutingMode   string `yaml:"routing_mode"`   // routing mode: direct, proxy, embedded
 }
 
+// ShadowConfig holds shadow traffic mirroring settings. Only OpenAI chat
+// completion requests are mirrored.
+type ShadowConfig struct {
+	Enabled    bool    `yaml:"enabled"`
+	SampleRate float64 `yaml:"sample_rate"` // fraction of requests mirrored (0-1)
+	Provider   string  `yaml:"provider"`    // provider receiving mirrored requests
+	Model      string  `yaml:"model"`       // model override for mirrored requests
+}
+
+// GuardrailsConfig holds proxy content filtering settings
+type GuardrailsConfig struct {
+	Enabled bool                             `yaml:"enabled"`
+	Rules   []GuardrailRuleConfig            `yaml:"rules"`
+	Clients map[string]GuardrailClientConfig `yaml:"clients"` // client ID -> policy
+}
+
+// GuardrailRuleConfig defines one guardrail stage
+type GuardrailRuleConfig struct {
+	Name      string            `yaml:"name"`
+	Type      string            `yaml:"type"`       // regex, pii, profanity, injection, webhook
+	Action    string            `yaml:"action"`     // annotate, redact, block, log
+	ApplyTo   []string          `yaml:"apply_to"`   // request, response (default both)
+	Patterns  map[string]string `yaml:"patterns"`   // regex: detector name -> pattern
+	Kinds     []string          `yaml:"kinds"`      // pii: email, phone, ssn, credit_card, ip_address
+	Words     []string          `yaml:"words"`      // profanity: word list (default built in)
+	Threshold float64           `yaml:"threshold"`  // injection: minimum risk score (default 0.5)
+	URL       string            `yaml:"url"`        // webhook: endpoint receiving {"text": ...}
+	TimeoutMs int               `yaml:"timeout_ms"` // webhook: request timeout
+	FailOpen  bool              `yaml:"fail_open"`  // webhook: allow content when the call fails
+}
+
+// GuardrailClientConfig adjusts guardrails for one client
+type GuardrailClientConfig struct {
+	Disabled []string          `yaml:"disabled"` // rule names skipped
+	Actions  map[string]string `yaml:"actions"`  // rule name -> action override
+}
+
+// WebhooksConfig holds event notification settings
+type WebhooksConfig struct {
+	Enabled     bool                    `yaml:"enabled"`
+	MaxAttempts int                     `yaml:"max_attempts"` // delivery attempts before dead-lettering (default 5)
+	TimeoutMs   int                     `yaml:"timeout_ms"`   // per-attempt request timeout (default 10000)
+	Endpoints   []WebhookEndpointConfig `yaml:"endpoints"`
+}
+
+// WebhookEndpointConfig defines one webhook receiver
+type WebhookEndpointConfig struct {
+	Name      string   `yaml:"name"`
+	URL       string   `yaml:"url"`
+	Secret    string   `yaml:"secret"`     // HMAC signing secret
+	SecretEnv string   `yaml:"secret_env"` // environment variable holding the secret
+	Events    []string `yaml:"events"`     // subscribed events (default all)
+}
+
+// SchedulerConfig holds periodic provider re-validation and refresh settings
+type SchedulerConfig struct {
+	Enabled         bool                              `yaml:"enabled"`
+	IntervalMinutes int                               `yaml:"interval_minutes"` // default interval (default 360)
+	Jitter          float64                           `yaml:"jitter"`           // interval randomization fraction (default 0.1)
+	TimeoutMs       int                               `yaml:"timeout_ms"`       // per-task timeout (default 300000)
+	Tasks           []string                          `yaml:"tasks"`            // validate, models, pricing (default all)
+	Providers       map[string]ProviderScheduleConfig `yaml:"providers"`        // provider -> schedule override
+}
+
+// ProviderScheduleConfig overrides the schedule for one provider
+type ProviderScheduleConfig struct {
+	IntervalMinutes int      `yaml:"interval_minutes"`
+	Tasks           []string `yaml:"tasks"`
+}
+
+// ScraperConfig holds provider documentation scraping settings
+type ScraperConfig struct {
+	Enabled   bool `yaml:"enabled"`
+	TimeoutMs int  `yaml:"timeout_ms"` // per-page request timeout (default 30000)
+}
+
+// ChaosConfig holds fault injection settings for upstream provider requests.
+// Faults can also be changed at runtime through /api/chaos while enabled.
+type ChaosConfig struct {
+	Enabled bool               `yaml:"enabled"`
+	Seed    int64              `yaml:"seed"` // random seed for reproducible runs (default time based)
+	Faults  []ChaosFaultConfig `yaml:"faults"`
+}
+
+// ChaosFaultConfig defines the faults injected for one provider
+type ChaosFaultConfig struct {
+	Provider      string  `yaml:"provider"`       // provider ID, or * for all others
+	LatencyMs     int     `yaml:"latency_ms"`     // added to every request
+	JitterMs      int     `yaml:"jitter_ms"`      // random extra latency up to this much
+	DropRate      float64 `yaml:"drop_rate"`      // fraction failed with a connection error
+	ErrorRate     float64 `yaml:"error_rate"`     // fraction answered with status
+	Status        int     `yaml:"status"`         // status for error_rate (default 503)
+	TruncateRate  float64 `yaml:"truncate_rate"`  // fraction of responses and streams cut off
+	TruncateAfter int     `yaml:"truncate_after"` // bytes delivered before the cut (default 256)
+}
+
+// FallbackConfig holds per-model fallback chains for the chat completions
+// proxy. Chains can also be changed at runtime through /api/fallbacks while
+// enabled.
+type FallbackConfig struct {
+	Enabled bool                  `yaml:"enabled"`
+	Chains  []FallbackChainConfig `yaml:"chains"`
+}
+
+// FallbackChainConfig lists the steps tried, in order, for one requested model
+type FallbackChainConfig struct {
+	Model string               `yaml:"model"`
+	Steps []FallbackStepConfig `yaml:"steps"`
+}
+
+// FallbackStepConfig defines one step and how the request is changed for it
+type FallbackStepConfig struct {
+	Provider     string `yaml:"provider"`
+	Model        string `yaml:"model"`         // upstream model (default the requested model)
+	MaxTokens    int    `yaml:"max_tokens"`    // cap on max_tokens / max_completion_tokens
+	DropImages   bool   `yaml:"drop_images"`   // remove image parts for non-vision models
+	SystemPrompt string `yaml:"system_prompt"` // replaces the request's system messages
+	TimeoutMs    int    `yaml:"timeout_ms"`    // time allowed before the response starts (default none)
+}
+
+// TransformsConfig holds declarative request rewrites applied by the proxy
+// before routing and translation, in order
+type TransformsConfig struct {
+	Enabled bool                  `yaml:"enabled"`
+	Rules   []TransformRuleConfig `yaml:"rules"`
+}
+
+// TransformRuleConfig selects requests and says how they are rewritten.
+// Field names with dots reach into nested objects ("metadata.user_id").
+type TransformRuleConfig struct {
+	Name    string                 `yaml:"name"`
+	APIs    []string               `yaml:"apis"`    // openai, anthropic or responses (default all)
+	Models  []string               `yaml:"models"`  // requested model patterns with "*" wildcards (default all)
+	Clients []string               `yaml:"clients"` // client IDs (default all)
+	Headers map[string]string      `yaml:"headers"` // added to the upstream request
+	Strip   []string               `yaml:"strip"`   // body fields removed
+	Set     map[string]interface{} `yaml:"set"`     // body fields forced to a value
+	Clamp   map[string]RangeConfig `yaml:"clamp"`   // numeric body fields held to a range
+}
+
+// RangeConfig bounds a numeric field; a missing bound is open
+type RangeConfig struct {
+	Min *float64 `yaml:"min"`
+	Max *float64 `yaml:"max"`
+}
+
+// ExperimentsConfig holds A/B experiments that split traffic between
+// request parameter variants and compare their outcomes
+type ExperimentsConfig struct {
+	Enabled     bool               `yaml:"enabled"`
+	Experiments []ExperimentConfig `yaml:"experiments"`
+}
+
+// ExperimentConfig selects the requests an experiment enrolls and the
+// variants they are split between
+type ExperimentConfig struct {
+	Name     string          `yaml:"name"`
+	APIs     []string        `yaml:"apis"`     // openai or anthropic (default both)
+	Models   []string        `yaml:"models"`   // requested model patterns with "*" wildcards (default all)
+	Clients  []string        `yaml:"clients"`  // client IDs (default all)
+	SplitBy  string          `yaml:"split_by"` // client (default) or session (X-Session-ID)
+	Percent  float64         `yaml:"percent"`  // share of matching traffic enrolled (default 100)
+	Variants []VariantConfig `yaml:"variants"`
+}
+
+// VariantConfig is one configuration under test
+type VariantConfig struct {
+	Name   string                 `yaml:"name"`
+	Weight int                    `yaml:"weight"` // relative share of enrolled traffic (default 1)
+	Model  string                 `yaml:"model"`  // replaces the requested model
+	System string                 `yaml:"system"` // replaces the client's system prompt
+	Set    map[string]interface{} `yaml:"set"`    // body fields forced to a value
+}
+
+// ConcurrencyConfig caps in-flight upstream proxy requests. Requests over a
+// cap wait in a queue; when the queue is full, or the wait times out, they
+// are shed with 429 and Retry-After. Zero caps are unlimited.
+type ConcurrencyConfig struct {
+	Enabled                bool           `yaml:"enabled"`
+	MaxInFlight            int            `yaml:"max_in_flight"`              // across all providers
+	MaxInFlightPerProvider int            `yaml:"max_in_flight_per_provider"` // default for each provider
+	Providers              map[string]int `yaml:"providers"`                  // provider -> cap override
+	QueueSize              int            `yaml:"queue_size"`                 // waiting requests per cap (default 0: shed at once)
+	QueueTimeoutMs         int            `yaml:"queue_timeout_ms"`           // longest wait in a queue (default 10000)
+	RetryAfterMs           int            `yaml:"retry_after_ms"`             // Retry-After for shed requests (default 1000)
+}
+
+// ModelLimitsConfig caps specific models on top of the provider concurrency
+// caps and key rate limits. Requests over a cap are refused with 429 and
+// Retry-After.
+type ModelLimitsConfig struct {
+	Enabled        bool               `yaml:"enabled"`
+	QueueTimeoutMs int                `yaml:"queue_timeout_ms"` // longest wait for a model's concurrency slot (default 10000)
+	Limits         []ModelLimitConfig `yaml:"limits"`
+}
+
+// ModelLimitConfig caps one model, or the models matching a pattern. Zero
+// caps are unlimited.
+type ModelLimitConfig struct {
+	Model       string `yaml:"model"`         // upstream model, or a pattern with "*" wildcards
+	RPM         int    `yaml:"rpm"`           // requests per minute
+	TPM         int    `yaml:"tpm"`           // tokens per minute (prompt plus max completion tokens)
+	MaxInFlight int    `yaml:"max_in_flight"` // concurrent requests
+}
+
+// VisionConfig checks image content against model capabilities and
+// provider limits before proxy requests are forwarded, and embeds image URLs
+// for providers that only accept base64 images
+type VisionConfig struct {
+	Enabled              bool                         `yaml:"enabled"`
+	InlineProviders      []string                     `yaml:"inline_providers"`       // providers that only accept base64 images
+	Limits               map[string]VisionLimitConfig `yaml:"limits"`                 // provider -> limits, overriding built-in defaults
+	FetchTimeoutMs       int                          `yaml:"fetch_timeout_ms"`       // per image download (default 10000)
+	AllowPrivateNetworks bool                         `yaml:"allow_private_networks"` // let image URLs reach private addresses
+}
+
+// VisionLimitConfig bounds the images in one request. Zero is unlimited.
+type VisionLimitConfig struct {
+	MaxImages     int   `yaml:"max_images"`
+	MaxImageBytes int64 `yaml:"max_image_bytes"` // decoded size of each image
+}
+
+// EmbeddingsConfig caches the vectors of /v1/embeddings inputs by content
+// hash, so identical inputs are embedded once and batches with some cached
+// inputs only send the rest upstream
+type EmbeddingsConfig struct {
+	CacheEnabled bool `yaml:"cache_enabled"`
+	MaxEntries   int  `yaml:"max_entries"` // vectors kept in memory (default 10000)
+	Persist      bool `yaml:"persist"`     // also store vectors in the database
+}
+
+// VectorStoreConfig serves collections of vectors stored in the database
+// with similarity search under /v1/vectors/, for small RAG workloads
+type VectorStoreConfig struct {
+	Enabled        bool `yaml:"enabled"`
+	MaxDimensions  int  `yaml:"max_dimensions"`  // largest vectors a collection takes (default 4096)
+	IndexThreshold int  `yaml:"index_threshold"` // collection size from which searches are approximate (default 5000; -1 never)
+	Probes         int  `yaml:"probes"`          // clusters an approximate search scores (default 8)
+}
+
+// CompletionsConfig runs hooks after each proxied completion, with the
+// response assembled even for streamed requests. Hooks run on background
+// workers so clients never wait for them.
+type CompletionsConfig struct {
+	Enabled   bool `yaml:"enabled"`
+	Workers   int  `yaml:"workers"`    // concurrent hook workers (default 4)
+	QueueSize int  `yaml:"queue_size"` // completions waiting for a worker; more are dropped (default 1024)
+	TimeoutMs int  `yaml:"timeout_ms"` // time hooks get per completion (default 30000)
+	Log       bool `yaml:"log"`        // log final token counts of each completion
+	Webhook   bool `yaml:"webhook"`    // publish a completion.finished webhook event per completion
+}
+
+// StatusPagesConfig polls provider status pages so routing avoids providers
+// with an ongoing incident before their requests start failing
+type StatusPagesConfig struct {
+	Enabled         bool                     `yaml:"enabled"`
+	IntervalSeconds int                      `yaml:"interval_seconds"` // between polls (default 120)
+	MinImpact       string                   `yaml:"min_impact"`       // least severe incident that counts: minor, major or critical (default major)
+	Sources         []StatusPageSourceConfig `yaml:"sources"`          // default OpenAI and Anthropic
+}
+
+// StatusPageSourceConfig is one provider's Statuspage summary JSON
+type StatusPageSourceConfig struct {
+	Provider string `yaml:"provider"`
+	URL      string `yaml:"url"`
+}
+
+// SLAConfig records per-provider availability, error rates and latency
+// target compliance for the /api/sla report. Latency is time to first byte.
+type SLAConfig struct {
+	Enabled          bool           `yaml:"enabled"`
+	PeriodMinutes    int            `yaml:"period_minutes"`     // rollup granularity and unit of uptime; must divide a day (default 5)
+	LatencyTargetMs  int            `yaml:"latency_target_ms"`  // default 10000
+	LatencyTargetsMs map[string]int `yaml:"latency_targets_ms"` // provider -> target, overriding latency_target_ms
+	DownErrorRate    float64        `yaml:"down_error_rate"`    // error rate at which a period counts as downtime (default 0.5)
+}
+
+// HeatmapConfig records provider latency by hour for the
+// /api/latency/heatmap report and the fastest remap strategy. Latency is
+// time to first byte.
+type HeatmapConfig struct {
+	Enabled    bool `yaml:"enabled"`
+	WindowDays int  `yaml:"window_days"` // history the fastest strategy averages over (default 28)
+	MinSamples int  `yaml:"min_samples"` // requests an hour of the week needs before it is trusted (default 20)
+}
+
+// AnomalyConfig watches request volume, cost and error rate per provider
+// and client, alerting when a window spikes above its rolling baseline.
+// Alerts are listed at /api/anomalies and sent as usage.anomaly webhooks
+// and events.
+type AnomalyConfig struct {
+	Enabled             bool    `yaml:"enabled"`
+	WindowSeconds       int     `yaml:"window_seconds"`         // counting window (default 60)
+	BaselineWindows     int     `yaml:"baseline_windows"`       // windows in the rolling baseline (default 60)
+	MinWindows          int     `yaml:"min_windows"`            // history needed before alerting (default 10)
+	Sigma               float64 `yaml:"sigma"`                  // standard deviations above the baseline (default 3)
+	MinIncreasePercent  float64 `yaml:"min_increase_percent"`   // and at least this much above it (default 50)
+	MinRequests         int     `yaml:"min_requests"`           // windows with fewer requests are not judged (default 20)
+	MinErrorRatePercent float64 `yaml:"min_error_rate_percent"` // error rate a window must reach to alert on it (default 10)
+	CooldownMinutes     int     `yaml:"cooldown_minutes"`       // between alerts for the same series and metric (default 15)
+}
+
+// ForecastConfig projects month-end spend per provider, client and tenant
+// from the tenant usage ledger, warning with budget.forecast_exceeded
+// webhooks and events when a projection exceeds its budget before the
+// spend does. Projections are served at /api/forecast. Tenants are held to
+// their own monthly budgets.
+type ForecastConfig struct {
+	Enabled         bool               `yaml:"enabled"`
+	IntervalMinutes int                `yaml:"interval_minutes"` // between budget checks (default 60)
+	Method          string             `yaml:"method"`           // run_rate or linear (default linear)
+	LookbackDays    int                `yaml:"lookback_days"`    // complete days the linear trend fits (default 14)
+	ProviderBudgets map[string]float64 `yaml:"provider_budgets"` // monthly USD by provider
+	ClientBudgets   map[string]float64 `yaml:"client_budgets"`   // monthly USD by client ID
+}
+
+// WatchdogConfig samples goroutines, open files and database connections,
+// warning when one reaches its ceiling or keeps climbing like a leak
+type WatchdogConfig struct {
+	Enabled          bool    `yaml:"enabled"`
+	IntervalSeconds  int     `yaml:"interval_seconds"`   // between samples (default 30)
+	MaxGoroutines    int     `yaml:"max_goroutines"`     // default 10000
+	MaxOpenFiles     int     `yaml:"max_open_files"`     // default 80% of the process's limit
+	MaxDBInUse       int     `yaml:"max_db_in_use"`      // connections in use (default the pool size, if limited)
+	TrendSamples     int     `yaml:"trend_samples"`      // samples a leak trend spans (default 20)
+	MinGrowthPercent float64 `yaml:"min_growth_percent"` // growth across them to count as a leak (default 50)
+	MinIncrease      int     `yaml:"min_increase"`       // and at least this much (default 20)
+	CooldownMinutes  int     `yaml:"cooldown_minutes"`   // between warnings for the same resource (default 15)
+	DumpDir          string  `yaml:"dump_dir"`           // goroutine stacks written on each warning (none when empty)
+	MaxDumps         int     `yaml:"max_dumps"`          // newest dumps kept (default 5)
+}
+
+// ResidencyConfig keeps proxy requests with a data residency requirement on
+// providers that serve from the required region. A client's requirement
+// comes from Clients, or else from the X-Modelscan-Residency request
+// header. Requests no compliant provider can serve fail rather than fall
+// back outside the region.
+type ResidencyConfig struct {
+	Enabled   bool                `yaml:"enabled"`
+	Providers map[string][]string `yaml:"providers"` // provider -> regions its endpoints serve from, e.g. [eu]
+	Clients   map[string]string   `yaml:"clients"`   // client ID -> region its requests must stay in
+}
+
+// DeprecationsConfig checks configured aliases against model retirement
+// dates on an interval, warning through model.retiring webhooks and
+// events. With the scraper enabled, the providers' deprecation pages are
+// read on each check. /api/models/deprecations and `modelscan deprecations`
+// report retirements whether or not checking is enabled.
+type DeprecationsConfig struct {
+	Enabled         bool `yaml:"enabled"`
+	IntervalSeconds int  `yaml:"interval_seconds"` // between checks (default 86400)
+	WarningDays     int  `yaml:"warning_days"`     // flag aliases whose model retires this soon (default 30)
+}
+
+// LoggingConfig holds extra log destinations. Logs always go to stderr (or
+// the daemon log file) as well.
+type LoggingConfig struct {
+	BufferSize int              `yaml:"buffer_size"` // lines queued per sink before new ones are dropped (default 1024)
+	File       LogFileConfig    `yaml:"file"`
+	Syslog     LogSyslogConfig  `yaml:"syslog"`
+	HTTP       LogShipperConfig `yaml:"http"`
+}
+
+// CrashReportsConfig holds where recovered panics are reported
+type CrashReportsConfig struct {
+	Dir            string `yaml:"dir"`             // default crash-reports
+	MaxReports     int    `yaml:"max_reports"`     // newest reports kept (default 20)
+	RecentRequests int    `yaml:"recent_requests"` // latest requests listed in each report (default 50)
+}
+
+// LogFileConfig holds rotating log file settings
+type LogFileConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	Path        string `yaml:"path"`         // default logs/modelscan.log
+	MaxSizeMB   int    `yaml:"max_size_mb"`  // rotate past this size (default 100; 0 with rotate_hours for none)
+	RotateHours int    `yaml:"rotate_hours"` // rotate on this interval (0 for size only)
+	MaxBackups  int    `yaml:"max_backups"`  // rotated files kept (default 7)
+}
+
+// LogSyslogConfig holds syslog settings
+type LogSyslogConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Network  string `yaml:"network"`  // udp or tcp; empty for the local daemon
+	Address  string `yaml:"address"`  // host:port of a remote daemon
+	Tag      string `yaml:"tag"`      // default modelscan
+	Facility string `yaml:"facility"` // user, daemon or local0-local7 (default daemon)
+}
+
+// LogShipperConfig holds HTTP bulk log shipping settings
+type LogShipperConfig struct {
+	Enabled         bool              `yaml:"enabled"`
+	URL             string            `yaml:"url"`
+	Format          string            `yaml:"format"`            // ndjson, loki or elasticsearch (default ndjson)
+	Index           string            `yaml:"index"`             // Elasticsearch index (default modelscan-logs)
+	Labels          map[string]string `yaml:"labels"`            // Loki stream labels, or fields of each document
+	Headers         map[string]string `yaml:"headers"`           // sent with every batch
+	BearerTokenEnv  string            `yaml:"bearer_token_env"`  // env var holding a bearer token
+	BatchSize       int               `yaml:"batch_size"`        // lines per request (default 500)
+	FlushIntervalMs int               `yaml:"flush_interval_ms"` // longest a line waits (default 1000)
+	TimeoutMs       int               `yaml:"timeout_ms"`        // per request (default 10000)
+}
+
+// KeyHealthConfig probes stored API keys with a model list request on an
+// interval. /api/keys/health and `modelscan keys` report the results and
+// key expiry dates whether or not probing is enabled.
+type KeyHealthConfig struct {
+	Enabled           bool                      `yaml:"enabled"`
+	IntervalSeconds   int                       `yaml:"interval_seconds"`    // between probe rounds (default 3600)
+	TimeoutSeconds    int                       `yaml:"timeout_seconds"`     // per probe (default 10)
+	ExpiryWarningDays int                       `yaml:"expiry_warning_days"` // flag keys expiring this soon (default 7)
+	Endpoints         []KeyHealthEndpointConfig `yaml:"endpoints"`           // add to or replace the built-in probe endpoints
+}
+
+// KeyHealthEndpointConfig is how one provider's keys are probed
+type KeyHealthEndpointConfig struct {
+	Provider string `yaml:"provider"`
+	URL      string `yaml:"url"`    // fetched with GET
+	Header   string `yaml:"header"` // header carrying the key (default Authorization)
+	Prefix   string `yaml:"prefix"` // prepended to the key, e.g. "Bearer "
+}
+
+// StreamingConfig holds the timing of proxied SSE streams. Zero keeps the
+// default; a negative value turns the behaviour off.
+type StreamingConfig struct {
+	KeepAliveSeconds    int `yaml:"keep_alive_seconds"`    // quiet period before a keep-alive comment (default 15)
+	WriteTimeoutSeconds int `yaml:"write_timeout_seconds"` // per chunk written to the client (default 30)
+	IdleTimeoutSeconds  int `yaml:"idle_timeout_seconds"`  // upstream silence that ends the stream (default 300)
+}
+
+// EventsConfig holds the live event stream at /api/events: request
+// completions, provider health changes, budget warnings and scheduled task
+// results, as Server-Sent Events
+type EventsConfig struct {
+	Enabled          bool      `yaml:"enabled"`
+	HistorySize      int       `yaml:"history_size"`       // events kept for reconnecting clients (default 256)
+	ClientBuffer     int       `yaml:"client_buffer"`      // events queued per client before it is dropped (default 64)
+	KeepAliveSeconds int       `yaml:"keep_alive_seconds"` // quiet period before a keep-alive comment (default 15)
+	BudgetThresholds []float64 `yaml:"budget_thresholds"`  // fractions of a tenant's monthly budget that raise budget warnings (default [0.8, 1])
+}
+
+// SigningConfig holds HMAC signing of upstream provider requests, so
+// gateways between modelscan and providers can verify where traffic came
+// from. The secret is read from an environment variable, never the file.
+type SigningConfig struct {
+	Enabled   bool     `yaml:"enabled"`
+	KeyID     string   `yaml:"key_id"`     // sent with every signature so gateways can rotate secrets
+	SecretEnv string   `yaml:"secret_env"` // env var holding the secret (default MODELSCAN_SIGNING_SECRET)
+	Providers []string `yaml:"providers"`  // providers to sign for (default all)
+}
+
+// TransportConfig holds upstream connection pool settings. Providers inherit
+// the defaults and override individual fields.
+type TransportConfig struct {
+	Defaults         TransportSettings            `yaml:"defaults"`
+	Providers        map[string]TransportSettings `yaml:"providers"`          // provider -> overrides
+	RetriesPerMinute int                          `yaml:"retries_per_minute"` // upstream retries each provider may spend (default 100)
+}
+
+// WarmupConfig resolves and connects to providers, and loads the key and
+// price caches, before the server starts listening
+type WarmupConfig struct {
+	Enabled        bool              `yaml:"enabled"`
+	TimeoutSeconds int               `yaml:"timeout_seconds"` // bounds the whole warm-up (default 15)
+	Concurrency    int               `yaml:"concurrency"`     // providers warmed at once (default 4)
+	Providers      []string          `yaml:"providers"`       // default every provider with an API key
+	Completions    map[string]string `yaml:"completions"`     // provider -> model of a 1-token completion
+}
+
+// TransportSettings tunes one connection pool (zero values inherit)
+type TransportSettings struct {
+	MaxIdleConns            int                   `yaml:"max_idle_conns"`             // default 100
+	MaxIdleConnsPerHost     int                   `yaml:"max_idle_conns_per_host"`    // default 10
+	MaxConnsPerHost         int                   `yaml:"max_conns_per_host"`         // default unlimited
+	IdleConnTimeoutMs       int                   `yaml:"idle_conn_timeout_ms"`       // default 90000
+	KeepAliveMs             int                   `yaml:"keep_alive_ms"`              // TCP keep-alive interval (default 30000)
+	DialTimeoutMs           int                   `yaml:"dial_timeout_ms"`            // default 30000
+	TLSHandshakeTimeoutMs   int                   `yaml:"tls_handshake_timeout_ms"`   // default 10000
+	ResponseHeaderTimeoutMs int                   `yaml:"response_header_timeout_ms"` // default none
+	HTTP2                   *bool                 `yaml:"http2"`                      // default true
+	KeepAlives              *bool                 `yaml:"keep_alives"`                // reuse connections (default true)
+	TLS                     *TransportTLSSettings `yaml:"tls"`
+	Protocol                string                `yaml:"protocol"`                // auto, http1, http2, h2c or http3 (http3 needs -tags http3)
+	Fallback                *bool                 `yaml:"fallback"`                // retry over auto when a forced protocol fails (default true)
+	FallbackRetryMs         int                   `yaml:"fallback_retry_ms"`       // stay on auto this long after a fallback (default 60000)
+	Proxy                   string                `yaml:"proxy"`                   // http://, https://, socks5:// egress proxy or "direct" (default: HTTP_PROXY etc.)
+	ProxyPasswordEnv        string                `yaml:"proxy_password_env"`      // env var holding the proxy password, kept out of the file
+	NoProxy                 []string              `yaml:"no_proxy"`                // hosts (and subdomains) bypassing the proxy
+	MaxResponseBytes        int64                 `yaml:"max_response_bytes"`      // decoded response body limit (default none)
+	MaxDecompressionRatio   float64               `yaml:"max_decompression_ratio"` // gzip expansion limit, zip bomb protection (default none)
+	ReadTimeoutMs           int                   `yaml:"read_timeout_ms"`         // longest wait for each body read (default none)
+}
+
+// TransportTLSSettings customizes TLS for upstream connections
+type TransportTLSSettings struct {
+	MinVersion         string `yaml:"min_version"` // 1.2 or 1.3
+	CAFile             string `yaml:"ca_file"`     // PEM bundle trusted instead of the system roots
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	CertFile           string `yaml:"cert_file"` // client certificate for mutual TLS
+	KeyFile            string `yaml:"key_file"`
+}
+
 // Load reads config from YAML file with graceful fallback
 // Returns default config if file doesn't exist or is malformed
 func Load(path string) (*Config, error) {
@@ -83,11 +676,41 @@ func DefaultConfig() *Config {
 	return cfg
 }
 
+// Hash returns a SHA-256 hex digest of the effective configuration, after
+// environment overrides and defaults, so two instances can be compared
+// without exposing their settings
+func (c *Config) Hash() string {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 // applyEnvOverrides applies environment variable overrides
 func (c *Config) applyEnvOverrides() {
 	if v := os.Getenv("MODELSCAN_DB_PATH"); v != "" {
 		c.Database.Path = v
 	}
+	if v := os.Getenv("MODELSCAN_DB_URL"); v != "" {
+		c.Database.URL = v
+	}
+	if v := os.Getenv("MODELSCAN_DB_MANUAL_MIGRATIONS"); v != "" {
+		if manual, err := strconv.ParseBool(v); err == nil {
+			c.Database.ManualMigrations = manual
+		}
+	}
+	if v := os.Getenv("MODELSCAN_DB_BATCH_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Database.Batch.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_DB_ENCRYPTION_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Database.Encryption.Enabled = enabled
+		}
+	}
 	if v := os.Getenv("MODELSCAN_HOST"); v != "" {
 		c.Server.Host = v
 	}
@@ -96,6 +719,29 @@ func (c *Config) applyEnvOverrides() {
 			c.Server.Port = port
 		}
 	}
+	if v := os.Getenv("MODELSCAN_ACCESS_LOG"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Server.AccessLog = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_CORS_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Server.CORS.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_CORS_ORIGINS"); v != "" {
+		c.Server.CORS.AllowedOrigins = strings.Split(v, ",")
+	}
+	if v := os.Getenv("MODELSCAN_GZIP_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Server.Gzip.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_DEBUG_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Server.Debug.Enabled = enabled
+		}
+	}
 	if v := os.Getenv("MODELSCAN_AGENT_MODEL"); v != "" {
 		c.Discovery.AgentModel = v
 	}
@@ -115,6 +761,169 @@ func (c *Config) applyEnvOverrides() {
 	if v := os.Getenv("MODELSCAN_ROUTING_MODE"); v != "" {
 		c.Discovery.RoutingMode = v
 	}
+	if v := os.Getenv("MODELSCAN_SHADOW_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Shadow.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_SHADOW_SAMPLE_RATE"); v != "" {
+		if rate, err := strconv.ParseFloat(v, 64); err == nil {
+			c.Shadow.SampleRate = rate
+		}
+	}
+	if v := os.Getenv("MODELSCAN_SHADOW_PROVIDER"); v != "" {
+		c.Shadow.Provider = v
+	}
+	if v := os.Getenv("MODELSCAN_SHADOW_MODEL"); v != "" {
+		c.Shadow.Model = v
+	}
+	if v := os.Getenv("MODELSCAN_GUARDRAILS_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Guardrails.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_WEBHOOKS_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Webhooks.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_SCHEDULER_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Scheduler.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_SCRAPER_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Scraper.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_WARMUP_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Warmup.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_CHAOS_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Chaos.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_FALLBACK_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Fallback.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_TRANSFORMS_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Transforms.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_EXPERIMENTS_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Experiments.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_CONCURRENCY_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Concurrency.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_MODEL_LIMITS_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.ModelLimits.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_VISION_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Vision.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_EMBEDDING_CACHE_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Embeddings.CacheEnabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_VECTOR_STORE_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.VectorStore.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_COMPLETIONS_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Completions.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_STATUS_PAGES_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.StatusPages.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_SLA_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.SLA.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_HEATMAP_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Heatmap.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_KEY_HEALTH_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.KeyHealth.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_SIGNING_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Signing.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_EVENTS_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Events.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_ANOMALY_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Anomaly.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_FORECAST_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Forecast.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_RESIDENCY_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Residency.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_DEPRECATIONS_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Deprecations.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_LOG_FILE"); v != "" {
+		c.Logging.File.Enabled = true
+		c.Logging.File.Path = v
+	}
+	if v := os.Getenv("MODELSCAN_WATCHDOG_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Watchdog.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_CRASH_DIR"); v != "" {
+		c.CrashReports.Dir = v
+	}
+	if v := os.Getenv("MODELSCAN_LOG_SYSLOG_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Logging.Syslog.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("MODELSCAN_LOG_HTTP_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			c.Logging.HTTP.Enabled = enabled
+		}
+	}
 }
 
 // applyDefaults fills in missing values with defaults
@@ -146,6 +955,9 @@ func (c *Config) applyDefaults() {
 	if c.APIKeys == nil {
 		c.APIKeys = make(map[string][]string)
 	}
+	if c.Shadow.Enabled && c.Shadow.SampleRate == 0 {
+		c.Shadow.SampleRate = 0.1
+	}
 }
 
 // getEnv gets environment variable or returns default
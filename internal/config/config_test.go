@@ -234,3 +234,1117 @@ func TestDefaultFallbacks(t *testing.T) {
 		t.Errorf("expected default agent model 'claude-sonnet-4-5', got %s", cfg.Discovery.AgentModel)
 	}
 }
+
+func TestLoadShadowConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+shadow:
+  enabled: true
+  provider: cohere
+  model: command-r
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	os.Setenv("MODELSCAN_SHADOW_MODEL", "command-r-plus")
+	defer os.Unsetenv("MODELSCAN_SHADOW_MODEL")
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !cfg.Shadow.Enabled {
+		t.Error("expected shadow to be enabled")
+	}
+	if cfg.Shadow.Provider != "cohere" {
+		t.Errorf("expected shadow provider 'cohere', got %s", cfg.Shadow.Provider)
+	}
+	if cfg.Shadow.Model != "command-r-plus" {
+		t.Errorf("expected env override shadow model 'command-r-plus', got %s", cfg.Shadow.Model)
+	}
+	if cfg.Shadow.SampleRate != 0.1 {
+		t.Errorf("expected default sample rate 0.1, got %v", cfg.Shadow.SampleRate)
+	}
+}
+
+func TestLoadGuardrailsConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+guardrails:
+  enabled: true
+  rules:
+    - name: pii
+      type: pii
+      action: redact
+      kinds: [email]
+      apply_to: [request]
+  clients:
+    internal:
+      disabled: [pii]
+      actions:
+        pii: annotate
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !cfg.Guardrails.Enabled || len(cfg.Guardrails.Rules) != 1 {
+		t.Fatalf("expected one enabled guardrail rule, got %+v", cfg.Guardrails)
+	}
+	rule := cfg.Guardrails.Rules[0]
+	if rule.Type != "pii" || rule.Action != "redact" || rule.Kinds[0] != "email" || rule.ApplyTo[0] != "request" {
+		t.Errorf("unexpected rule: %+v", rule)
+	}
+	client := cfg.Guardrails.Clients["internal"]
+	if len(client.Disabled) != 1 || client.Actions["pii"] != "annotate" {
+		t.Errorf("unexpected client policy: %+v", client)
+	}
+}
+
+func TestLoadWebhooksConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+webhooks:
+  enabled: true
+  max_attempts: 3
+  endpoints:
+    - name: ops
+      url: https://ops.example.com/hook
+      secret_env: OPS_WEBHOOK_SECRET
+      events: [provider.unhealthy, circuit_breaker.opened]
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !cfg.Webhooks.Enabled || cfg.Webhooks.MaxAttempts != 3 || len(cfg.Webhooks.Endpoints) != 1 {
+		t.Fatalf("unexpected webhooks config: %+v", cfg.Webhooks)
+	}
+	endpoint := cfg.Webhooks.Endpoints[0]
+	if endpoint.Name != "ops" || endpoint.SecretEnv != "OPS_WEBHOOK_SECRET" || len(endpoint.Events) != 2 {
+		t.Errorf("unexpected endpoint: %+v", endpoint)
+	}
+}
+
+func TestLoadSchedulerConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+database:
+  rate_limit_path: rate_limits.db
+scheduler:
+  enabled: true
+  interval_minutes: 120
+  providers:
+    openai:
+      interval_minutes: 30
+      tasks: [validate]
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !cfg.Scheduler.Enabled || cfg.Scheduler.IntervalMinutes != 120 || cfg.Database.RateLimitPath != "rate_limits.db" {
+		t.Fatalf("unexpected scheduler config: %+v", cfg.Scheduler)
+	}
+	openai := cfg.Scheduler.Providers["openai"]
+	if openai.IntervalMinutes != 30 || len(openai.Tasks) != 1 || openai.Tasks[0] != "validate" {
+		t.Errorf("unexpected openai schedule: %+v", openai)
+	}
+}
+
+func TestLoadDatabaseConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+database:
+  path: test.db
+  journal_mode: WAL
+  synchronous: NORMAL
+  busy_timeout_ms: 2000
+  cache_ttl_seconds: 30
+  manual_migrations: true
+  batch:
+    max_batch: 100
+    flush_interval_ms: 50
+  encryption:
+    master_key_command: vault kv get -field=key secret/modelscan
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	t.Setenv("MODELSCAN_DB_BATCH_ENABLED", "true")
+	t.Setenv("MODELSCAN_DB_ENCRYPTION_ENABLED", "true")
+	t.Setenv("MODELSCAN_DB_URL", "postgres://modelscan@db/modelscan")
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	db := cfg.Database
+	if db.JournalMode != "WAL" || db.Synchronous != "NORMAL" || db.BusyTimeoutMs != 2000 || db.CacheTTLSeconds != 30 || db.URL != "postgres://modelscan@db/modelscan" || !db.ManualMigrations {
+		t.Errorf("unexpected database config: %+v", db)
+	}
+	if !db.Batch.Enabled || db.Batch.MaxBatch != 100 || db.Batch.FlushIntervalMs != 50 {
+		t.Errorf("unexpected batch config: %+v", db.Batch)
+	}
+	if !db.Encryption.Enabled || db.Encryption.MasterKeyCommand != "vault kv get -field=key secret/modelscan" {
+		t.Errorf("unexpected encryption config: %+v", db.Encryption)
+	}
+}
+
+func TestLoadChaosConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+chaos:
+  enabled: true
+  seed: 7
+  faults:
+    - provider: openai
+      error_rate: 0.25
+      status: 429
+    - provider: "*"
+      truncate_rate: 0.1
+      truncate_after: 64
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !cfg.Chaos.Enabled || cfg.Chaos.Seed != 7 || len(cfg.Chaos.Faults) != 2 {
+		t.Fatalf("unexpected chaos config: %+v", cfg.Chaos)
+	}
+	if f := cfg.Chaos.Faults[0]; f.Provider != "openai" || f.ErrorRate != 0.25 || f.Status != 429 {
+		t.Errorf("unexpected openai fault: %+v", f)
+	}
+	if f := cfg.Chaos.Faults[1]; f.Provider != "*" || f.TruncateAfter != 64 {
+		t.Errorf("unexpected wildcard fault: %+v", f)
+	}
+}
+
+func TestLoadFallbackConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+fallback:
+  enabled: true
+  chains:
+    - model: gpt-4o
+      steps:
+        - provider: openai
+          timeout_ms: 5000
+        - provider: groq
+          model: llama-3.3-70b-versatile
+          max_tokens: 1024
+          drop_images: true
+          system_prompt: Be brief.
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !cfg.Fallback.Enabled || len(cfg.Fallback.Chains) != 1 || len(cfg.Fallback.Chains[0].Steps) != 2 {
+		t.Fatalf("unexpected fallback config: %+v", cfg.Fallback)
+	}
+	if s := cfg.Fallback.Chains[0].Steps[0]; s.Provider != "openai" || s.TimeoutMs != 5000 {
+		t.Errorf("unexpected first step: %+v", s)
+	}
+	if s := cfg.Fallback.Chains[0].Steps[1]; s.Model != "llama-3.3-70b-versatile" || s.MaxTokens != 1024 || !s.DropImages || s.SystemPrompt != "Be brief." {
+		t.Errorf("unexpected second step: %+v", s)
+	}
+}
+
+func TestLoadTransformsConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+transforms:
+  enabled: false
+  rules:
+    - name: privacy
+      apis: [openai]
+      models: ["gpt-4*"]
+      strip: [user, metadata.user_id]
+      headers:
+        OpenAI-Organization: org-123
+    - name: safe-sampling
+      clients: [batch-jobs]
+      set:
+        seed: 42
+        response_format: {type: json_object}
+      clamp:
+        temperature: {min: 0, max: 1}
+        max_tokens: {max: 4096}
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	t.Setenv("MODELSCAN_TRANSFORMS_ENABLED", "true")
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	tc := cfg.Transforms
+	if !tc.Enabled || len(tc.Rules) != 2 {
+		t.Fatalf("unexpected transforms config: %+v", tc)
+	}
+	if r := tc.Rules[0]; r.Name != "privacy" || r.APIs[0] != "openai" || r.Models[0] != "gpt-4*" || len(r.Strip) != 2 || r.Headers["OpenAI-Organization"] != "org-123" {
+		t.Errorf("unexpected first rule: %+v", r)
+	}
+	r := tc.Rules[1]
+	if r.Clients[0] != "batch-jobs" || r.Set["seed"] != 42 || r.Clamp["temperature"].Max == nil || *r.Clamp["temperature"].Max != 1 || r.Clamp["max_tokens"].Min != nil {
+		t.Errorf("unexpected second rule: %+v", r)
+	}
+	if format, ok := r.Set["response_format"].(map[string]interface{}); !ok || format["type"] != "json_object" {
+		t.Errorf("expected a nested set value, got %#v", r.Set["response_format"])
+	}
+}
+
+func TestLoadExperimentsConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+experiments:
+  enabled: true
+  experiments:
+    - name: tuning
+      apis: [openai]
+      models: ["gpt-4o*"]
+      split_by: session
+      percent: 25
+      variants:
+        - name: control
+        - name: cooler
+          weight: 3
+          model: gpt-4o-mini
+          system: Be brief.
+          set:
+            temperature: 0.2
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ec := cfg.Experiments
+	if !ec.Enabled || len(ec.Experiments) != 1 {
+		t.Fatalf("unexpected experiments config: %+v", ec)
+	}
+	e := ec.Experiments[0]
+	if e.Name != "tuning" || e.APIs[0] != "openai" || e.Models[0] != "gpt-4o*" || e.SplitBy != "session" || e.Percent != 25 || len(e.Variants) != 2 {
+		t.Errorf("unexpected experiment: %+v", e)
+	}
+	if v := e.Variants[1]; v.Name != "cooler" || v.Weight != 3 || v.Model != "gpt-4o-mini" || v.System != "Be brief." || v.Set["temperature"] != 0.2 {
+		t.Errorf("unexpected variant: %+v", v)
+	}
+
+	t.Setenv("MODELSCAN_EXPERIMENTS_ENABLED", "false")
+	if cfg, err = Load(configPath); err != nil || cfg.Experiments.Enabled {
+		t.Errorf("expected the env override to disable experiments, got %v, %v", cfg.Experiments.Enabled, err)
+	}
+}
+
+func TestLoadConcurrencyConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+concurrency:
+  enabled: true
+  max_in_flight: 256
+  max_in_flight_per_provider: 32
+  providers:
+    openai: 64
+  queue_size: 100
+  queue_timeout_ms: 5000
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	c := cfg.Concurrency
+	if !c.Enabled || c.MaxInFlight != 256 || c.MaxInFlightPerProvider != 32 || c.Providers["openai"] != 64 ||
+		c.QueueSize != 100 || c.QueueTimeoutMs != 5000 || c.RetryAfterMs != 0 {
+		t.Errorf("unexpected concurrency config: %+v", c)
+	}
+}
+
+func TestLoadModelLimitsConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+model_limits:
+  enabled: true
+  queue_timeout_ms: 2000
+  limits:
+    - model: o1
+      rpm: 20
+      tpm: 200000
+      max_in_flight: 4
+    - model: "claude-3-opus*"
+      max_in_flight: 8
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ml := cfg.ModelLimits
+	if !ml.Enabled || ml.QueueTimeoutMs != 2000 || len(ml.Limits) != 2 {
+		t.Fatalf("unexpected model limits config: %+v", ml)
+	}
+	if l := ml.Limits[0]; l.Model != "o1" || l.RPM != 20 || l.TPM != 200000 || l.MaxInFlight != 4 {
+		t.Errorf("unexpected first limit: %+v", l)
+	}
+	if l := ml.Limits[1]; l.Model != "claude-3-opus*" || l.RPM != 0 || l.MaxInFlight != 8 {
+		t.Errorf("unexpected second limit: %+v", l)
+	}
+}
+
+func TestLoadVisionConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+vision:
+  enabled: true
+  inline_providers: [bedrock]
+  limits:
+    anthropic:
+      max_images: 20
+      max_image_bytes: 1048576
+  fetch_timeout_ms: 5000
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	v := cfg.Vision
+	if !v.Enabled || len(v.InlineProviders) != 1 || v.InlineProviders[0] != "bedrock" ||
+		v.Limits["anthropic"].MaxImages != 20 || v.Limits["anthropic"].MaxImageBytes != 1<<20 ||
+		v.FetchTimeoutMs != 5000 || v.AllowPrivateNetworks {
+		t.Errorf("unexpected vision config: %+v", v)
+	}
+}
+
+func TestLoadEmbeddingsConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+embeddings:
+  max_entries: 500
+  persist: true
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+	t.Setenv("MODELSCAN_EMBEDDING_CACHE_ENABLED", "true")
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if e := cfg.Embeddings; !e.CacheEnabled || e.MaxEntries != 500 || !e.Persist {
+		t.Errorf("unexpected embeddings config: %+v", e)
+	}
+}
+
+func TestLoadVectorStoreConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+vector_store:
+  max_dimensions: 1536
+  index_threshold: -1
+  probes: 4
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+	t.Setenv("MODELSCAN_VECTOR_STORE_ENABLED", "true")
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if v := cfg.VectorStore; !v.Enabled || v.MaxDimensions != 1536 || v.IndexThreshold != -1 || v.Probes != 4 {
+		t.Errorf("unexpected vector store config: %+v", v)
+	}
+}
+
+func TestLoadCompletionsConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+completions:
+  enabled: true
+  workers: 2
+  queue_size: 64
+  timeout_ms: 5000
+  log: true
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	c := cfg.Completions
+	if !c.Enabled || c.Workers != 2 || c.QueueSize != 64 || c.TimeoutMs != 5000 || !c.Log || c.Webhook {
+		t.Errorf("unexpected completions config: %+v", c)
+	}
+}
+
+func TestLoadStatusPagesConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+status_pages:
+  enabled: true
+  interval_seconds: 60
+  min_impact: minor
+  sources:
+    - provider: groq
+      url: https://groqstatus.com/api/v2/summary.json
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	sp := cfg.StatusPages
+	if !sp.Enabled || sp.IntervalSeconds != 60 || sp.MinImpact != "minor" {
+		t.Errorf("unexpected status pages config: %+v", sp)
+	}
+	if len(sp.Sources) != 1 || sp.Sources[0].Provider != "groq" || sp.Sources[0].URL != "https://groqstatus.com/api/v2/summary.json" {
+		t.Errorf("unexpected status page sources: %+v", sp.Sources)
+	}
+}
+
+func TestLoadSLAConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+sla:
+  enabled: true
+  period_minutes: 15
+  latency_target_ms: 5000
+  latency_targets_ms:
+    anthropic: 8000
+  down_error_rate: 0.25
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	c := cfg.SLA
+	if !c.Enabled || c.PeriodMinutes != 15 || c.LatencyTargetMs != 5000 || c.LatencyTargetsMs["anthropic"] != 8000 || c.DownErrorRate != 0.25 {
+		t.Errorf("unexpected sla config: %+v", c)
+	}
+}
+
+func TestLoadWarmupConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+warmup:
+  enabled: true
+  timeout_seconds: 5
+  providers: [openai, anthropic]
+  completions:
+    openai: gpt-4o-mini
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	c := cfg.Warmup
+	if !c.Enabled || c.TimeoutSeconds != 5 || len(c.Providers) != 2 || c.Completions["openai"] != "gpt-4o-mini" {
+		t.Errorf("unexpected warmup config: %+v", c)
+	}
+
+	t.Setenv("MODELSCAN_WARMUP_ENABLED", "false")
+	if cfg, err := Load(configPath); err != nil || cfg.Warmup.Enabled {
+		t.Errorf("expected the environment to disable warm-up, got %+v, %v", cfg.Warmup, err)
+	}
+}
+
+func TestLoadHeatmapConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+latency_heatmap:
+  enabled: true
+  window_days: 14
+  min_samples: 50
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	c := cfg.Heatmap
+	if !c.Enabled || c.WindowDays != 14 || c.MinSamples != 50 {
+		t.Errorf("unexpected latency heatmap config: %+v", c)
+	}
+}
+
+func TestLoadKeyHealthConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+key_health:
+  enabled: true
+  interval_seconds: 900
+  expiry_warning_days: 14
+  endpoints:
+    - provider: acme
+      url: https://api.acme.test/v1/models
+      header: X-Api-Key
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	c := cfg.KeyHealth
+	if !c.Enabled || c.IntervalSeconds != 900 || c.ExpiryWarningDays != 14 || len(c.Endpoints) != 1 || c.Endpoints[0].Header != "X-Api-Key" {
+		t.Errorf("unexpected key_health config: %+v", c)
+	}
+}
+
+func TestLoadDeprecationsConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+deprecations:
+  enabled: true
+  warning_days: 60
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	c := cfg.Deprecations
+	if !c.Enabled || c.WarningDays != 60 || c.IntervalSeconds != 0 {
+		t.Errorf("unexpected deprecations config: %+v", c)
+	}
+}
+
+func TestLoadLoggingConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+logging:
+  buffer_size: 256
+  file:
+    max_size_mb: 50
+    rotate_hours: 24
+  syslog:
+    network: udp
+    address: logs.internal:514
+    facility: local0
+  http:
+    enabled: true
+    url: http://loki:3100/loki/api/v1/push
+    format: loki
+    labels:
+      app: modelscan
+    bearer_token_env: LOKI_TOKEN
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	t.Setenv("MODELSCAN_LOG_FILE", "/var/log/modelscan.log")
+	t.Setenv("MODELSCAN_LOG_SYSLOG_ENABLED", "true")
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	l := cfg.Logging
+	if l.BufferSize != 256 {
+		t.Errorf("unexpected buffer size: %d", l.BufferSize)
+	}
+	if !l.File.Enabled || l.File.Path != "/var/log/modelscan.log" || l.File.MaxSizeMB != 50 || l.File.RotateHours != 24 {
+		t.Errorf("unexpected file config: %+v", l.File)
+	}
+	if !l.Syslog.Enabled || l.Syslog.Network != "udp" || l.Syslog.Address != "logs.internal:514" || l.Syslog.Facility != "local0" {
+		t.Errorf("unexpected syslog config: %+v", l.Syslog)
+	}
+	if !l.HTTP.Enabled || l.HTTP.Format != "loki" || l.HTTP.Labels["app"] != "modelscan" || l.HTTP.BearerTokenEnv != "LOKI_TOKEN" {
+		t.Errorf("unexpected http config: %+v", l.HTTP)
+	}
+}
+
+func TestLoadWatchdogConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+watchdog:
+  interval_seconds: 10
+  max_goroutines: 5000
+  max_db_in_use: 8
+  trend_samples: 30
+  min_growth_percent: 25
+  dump_dir: /var/lib/modelscan/dumps
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	t.Setenv("MODELSCAN_WATCHDOG_ENABLED", "true")
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	w := cfg.Watchdog
+	if !w.Enabled || w.IntervalSeconds != 10 || w.MaxGoroutines != 5000 || w.MaxDBInUse != 8 || w.TrendSamples != 30 || w.MinGrowthPercent != 25 || w.DumpDir != "/var/lib/modelscan/dumps" {
+		t.Errorf("unexpected watchdog config: %+v", w)
+	}
+}
+
+func TestLoadCrashReportsConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+crash_reports:
+  dir: /var/lib/modelscan/crashes
+  max_reports: 5
+  recent_requests: 100
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	c := cfg.CrashReports
+	if c.Dir != "/var/lib/modelscan/crashes" || c.MaxReports != 5 || c.RecentRequests != 100 {
+		t.Errorf("unexpected crash reports config: %+v", c)
+	}
+
+	t.Setenv("MODELSCAN_CRASH_DIR", "/tmp/crashes")
+	if cfg, _ = Load(configPath); cfg.CrashReports.Dir != "/tmp/crashes" {
+		t.Errorf("MODELSCAN_CRASH_DIR not applied: %q", cfg.CrashReports.Dir)
+	}
+}
+
+func TestLoadServerMiddlewareConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+server:
+  port: 9090
+  access_log: true
+  request_timeout_seconds: 90
+  cors:
+    enabled: true
+    allowed_origins: ["https://app.example.com"]
+    allow_credentials: true
+    max_age_seconds: 300
+  gzip:
+    enabled: true
+    min_size_bytes: 2048
+  debug:
+    token_env: OPS_DEBUG_TOKEN
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	t.Setenv("MODELSCAN_CORS_ORIGINS", "https://a.example.com,https://*.example.org")
+	t.Setenv("MODELSCAN_DEBUG_ENABLED", "true")
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	s := cfg.Server
+	if s.Port != 9090 || !s.AccessLog || s.RequestTimeoutSeconds != 90 || !s.CORS.Enabled || !s.CORS.AllowCredentials || s.CORS.MaxAgeSeconds != 300 {
+		t.Errorf("unexpected server config: %+v", s)
+	}
+	if len(s.CORS.AllowedOrigins) != 2 || s.CORS.AllowedOrigins[1] != "https://*.example.org" {
+		t.Errorf("MODELSCAN_CORS_ORIGINS not applied: %v", s.CORS.AllowedOrigins)
+	}
+	if !s.Gzip.Enabled || s.Gzip.MinSizeBytes != 2048 {
+		t.Errorf("unexpected gzip config: %+v", s.Gzip)
+	}
+	if !s.Debug.Enabled || s.Debug.TokenEnv != "OPS_DEBUG_TOKEN" {
+		t.Errorf("unexpected debug config: %+v", s.Debug)
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	a, b := DefaultConfig(), DefaultConfig()
+	if a.Hash() == "" || a.Hash() != b.Hash() {
+		t.Errorf("expected equal configs to hash alike: %q, %q", a.Hash(), b.Hash())
+	}
+	b.Server.Port++
+	if a.Hash() == b.Hash() {
+		t.Error("expected a changed config to hash differently")
+	}
+}
+
+func TestLoadStreamingConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+streaming:
+  keep_alive_seconds: 20
+  idle_timeout_seconds: -1
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	c := cfg.Streaming
+	if c.KeepAliveSeconds != 20 || c.WriteTimeoutSeconds != 0 || c.IdleTimeoutSeconds != -1 {
+		t.Errorf("unexpected streaming config: %+v", c)
+	}
+}
+
+func TestLoadEventsConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+events:
+  enabled: true
+  history_size: 1000
+  budget_thresholds: [0.5, 0.9]
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	c := cfg.Events
+	if !c.Enabled || c.HistorySize != 1000 || c.ClientBuffer != 0 || len(c.BudgetThresholds) != 2 || c.BudgetThresholds[1] != 0.9 {
+		t.Errorf("unexpected events config: %+v", c)
+	}
+}
+
+func TestLoadAnomalyConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+anomaly:
+  enabled: true
+  window_seconds: 300
+  sigma: 4.5
+  min_increase_percent: 100
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	c := cfg.Anomaly
+	if !c.Enabled || c.WindowSeconds != 300 || c.Sigma != 4.5 || c.MinIncreasePercent != 100 || c.MinRequests != 0 {
+		t.Errorf("unexpected anomaly config: %+v", c)
+	}
+}
+
+func TestLoadForecastConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+forecast:
+  interval_minutes: 30
+  method: run_rate
+  provider_budgets:
+    openai: 5000
+  client_budgets:
+    ci-runner: 250.5
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	t.Setenv("MODELSCAN_FORECAST_ENABLED", "true")
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	f := cfg.Forecast
+	if !f.Enabled || f.IntervalMinutes != 30 || f.Method != "run_rate" || f.LookbackDays != 0 {
+		t.Errorf("unexpected forecast config: %+v", f)
+	}
+	if f.ProviderBudgets["openai"] != 5000 || f.ClientBudgets["ci-runner"] != 250.5 {
+		t.Errorf("unexpected forecast budgets: %+v", f)
+	}
+}
+
+func TestLoadResidencyConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+residency:
+  enabled: true
+  providers:
+    mistral: [eu]
+    azure: [eu, us]
+  clients:
+    eu-app: eu
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	c := cfg.Residency
+	if !c.Enabled || len(c.Providers["azure"]) != 2 || c.Providers["mistral"][0] != "eu" || c.Clients["eu-app"] != "eu" {
+		t.Errorf("unexpected residency config: %+v", c)
+	}
+}
+
+func TestLoadTransportConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+transport:
+  defaults:
+    max_idle_conns_per_host: 20
+    max_response_bytes: 1048576
+    max_decompression_ratio: 50
+    read_timeout_ms: 30000
+  providers:
+    deepseek:
+      http2: false
+      max_conns_per_host: 8
+      tls:
+        min_version: "1.3"
+    anthropic:
+      protocol: http2
+      fallback: false
+      proxy: socks5://egress@gw.corp:1080
+      proxy_password_env: EGRESS_PASSWORD
+      no_proxy: [internal.corp]
+      tls:
+        cert_file: /etc/modelscan/client.pem
+        key_file: /etc/modelscan/client-key.pem
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if d := cfg.Transport.Defaults; d.MaxIdleConnsPerHost != 20 || d.MaxResponseBytes != 1048576 || d.MaxDecompressionRatio != 50 || d.ReadTimeoutMs != 30000 {
+		t.Errorf("unexpected transport defaults: %+v", cfg.Transport.Defaults)
+	}
+	deepseek := cfg.Transport.Providers["deepseek"]
+	if deepseek.HTTP2 == nil || *deepseek.HTTP2 || deepseek.MaxConnsPerHost != 8 || deepseek.KeepAlives != nil {
+		t.Errorf("unexpected deepseek transport: %+v", deepseek)
+	}
+	if deepseek.TLS == nil || deepseek.TLS.MinVersion != "1.3" {
+		t.Errorf("unexpected deepseek TLS: %+v", deepseek.TLS)
+	}
+	anthropic := cfg.Transport.Providers["anthropic"]
+	if anthropic.Protocol != "http2" || anthropic.Fallback == nil || *anthropic.Fallback {
+		t.Errorf("unexpected anthropic transport: %+v", anthropic)
+	}
+	if anthropic.Proxy != "socks5://egress@gw.corp:1080" || anthropic.ProxyPasswordEnv != "EGRESS_PASSWORD" || len(anthropic.NoProxy) != 1 {
+		t.Errorf("unexpected anthropic egress: %+v", anthropic)
+	}
+	if anthropic.TLS == nil || anthropic.TLS.CertFile != "/etc/modelscan/client.pem" || anthropic.TLS.KeyFile != "/etc/modelscan/client-key.pem" {
+		t.Errorf("unexpected anthropic TLS: %+v", anthropic.TLS)
+	}
+}
+
+func TestLoadSigningConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	yaml := `
+signing:
+  key_id: modelscan-prod
+  secret_env: GATEWAY_SECRET
+  providers: [openai]
+`
+
+	if err := os.WriteFile(configPath, []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write test config: %v", err)
+	}
+
+	t.Setenv("MODELSCAN_SIGNING_ENABLED", "true")
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !cfg.Signing.Enabled || cfg.Signing.KeyID != "modelscan-prod" || cfg.Signing.SecretEnv != "GATEWAY_SECRET" {
+		t.Errorf("unexpected signing config: %+v", cfg.Signing)
+	}
+	if len(cfg.Signing.Providers) != 1 || cfg.Signing.Providers[0] != "openai" {
+		t.Errorf("unexpected signing providers: %v", cfg.Signing.Providers)
+	}
+}
@@ -0,0 +1,239 @@
+// Package crash keeps the process alive through panics. A recovered panic
+// is counted, logged with its stack and written to a crash report file
+// together with the requests the server handled just before it.
+//
+// HTTP handlers are covered by middleware.Recover, which calls Track and
+// Capture. Background goroutines run their work through Go or Do:
+//
+//	crash.Go("webhook worker", d.worker)
+//
+//	for range ticker.C {
+//	    crash.Do("cache cleanup", c.evictExpired) // the loop survives a panic
+//	}
+package crash
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
+)
+
+// Defaults
+const (
+	DefaultMaxReports     = 20
+	DefaultRecentRequests = 50
+)
+
+// Crash metrics, served by expvar at /debug/vars
+var (
+	total    = expvar.NewInt("crashes")
+	bySource = expvar.NewMap("crashes_by_source")
+)
+
+// Config configures crash reports
+type Config struct {
+	// Dir receives one JSON report per panic (none are written when empty)
+	Dir string
+	// MaxReports is how many reports are kept, newest first (default 20)
+	MaxReports int
+	// RecentRequests is how many of the latest requests each report lists
+	// (default 50)
+	RecentRequests int
+	// Version is recorded in each report
+	Version string
+}
+
+// Request describes a request handled by the server
+type Request struct {
+	ID      string    `json:"id,omitempty"`
+	Method  string    `json:"method"`
+	Path    string    `json:"path"`
+	Remote  string    `json:"remote,omitempty"`
+	Started time.Time `json:"started"`
+}
+
+// Report is the content of a crash report file
+type Report struct {
+	Time       time.Time `json:"time"`
+	Source     string    `json:"source"` // "http" or the goroutine's name
+	Panic      string    `json:"panic"`
+	Stack      string    `json:"stack"`
+	Request    *Request  `json:"request,omitempty"` // The request that panicked
+	Recent     []Request `json:"recent_requests"`   // Oldest first
+	Goroutines int       `json:"goroutines"`
+	Version    string    `json:"version,omitempty"`
+}
+
+// Reporter records recent requests and writes crash reports
+type Reporter struct {
+	mu     sync.Mutex
+	cfg    Config
+	recent []Request // Ring buffer
+	next   int
+	full   bool
+}
+
+// NewReporter creates a reporter
+func NewReporter(cfg Config) *Reporter {
+	if cfg.MaxReports <= 0 {
+		cfg.MaxReports = DefaultMaxReports
+	}
+	if cfg.RecentRequests <= 0 {
+		cfg.RecentRequests = DefaultRecentRequests
+	}
+	return &Reporter{cfg: cfg, recent: make([]Request, cfg.RecentRequests)}
+}
+
+// std is the reporter used by the package-level functions
+var (
+	stdMu sync.RWMutex
+	std   = NewReporter(Config{})
+)
+
+// Configure replaces the package-level reporter
+func Configure(cfg Config) {
+	stdMu.Lock()
+	defer stdMu.Unlock()
+	std = NewReporter(cfg)
+}
+
+func reporter() *Reporter {
+	stdMu.RLock()
+	defer stdMu.RUnlock()
+	return std
+}
+
+// Track remembers a request for later crash reports
+func Track(req Request) { reporter().Track(req) }
+
+// Capture counts a recovered panic and writes its report, returning the
+// report's path ("" when none was written). stack is debug.Stack() taken
+// in the deferred recover.
+func Capture(source string, v any, stack []byte, req *Request) string {
+	return reporter().Capture(source, v, stack, req)
+}
+
+// Count returns how many panics have been recovered
+func Count() int64 { return total.Value() }
+
+// Do runs fn, recovering a panic in it: the panic is logged, counted and
+// reported, and returned as an error
+func Do(name string, fn func()) (err error) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		stack := debug.Stack()
+		path := Capture(name, v, stack, nil)
+		log.Printf("panic in %s: %v%s\n%s", name, v, reportNote(path), stack)
+		err = fmt.Errorf("panic in %s: %v", name, v)
+	}()
+	fn()
+	return nil
+}
+
+// Go runs fn in a new goroutine under Do
+func Go(name string, fn func()) {
+	go func() { _ = Do(name, fn) }()
+}
+
+// reportNote mentions a written report in a log line
+func reportNote(path string) string {
+	if path == "" {
+		return ""
+	}
+	return " (crash report: " + path + ")"
+}
+
+// Track remembers a request for later crash reports
+func (r *Reporter) Track(req Request) {
+	r.mu.Lock()
+	r.recent[r.next] = req
+	r.next = (r.next + 1) % len(r.recent)
+	if r.next == 0 {
+		r.full = true
+	}
+	r.mu.Unlock()
+}
+
+// Recent returns the remembered requests, oldest first
+func (r *Reporter) Recent() []Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]Request(nil), r.recent[:r.next]...)
+	}
+	return append(append([]Request(nil), r.recent[r.next:]...), r.recent[:r.next]...)
+}
+
+// Capture counts a recovered panic and writes its report, returning the
+// report's path ("" when none was written). Credentials in the panic value
+// and stack are masked.
+func (r *Reporter) Capture(source string, v any, stack []byte, req *Request) string {
+	total.Add(1)
+	bySource.Add(source, 1)
+	if r.cfg.Dir == "" {
+		return ""
+	}
+	report := Report{
+		Time:       time.Now().UTC(),
+		Source:     source,
+		Panic:      redact.String(fmt.Sprint(v)),
+		Stack:      redact.String(string(stack)),
+		Request:    req,
+		Recent:     r.Recent(),
+		Goroutines: runtime.NumGoroutine(),
+		Version:    r.cfg.Version,
+	}
+	path, err := r.write(report)
+	if err != nil {
+		log.Printf("Warning: failed to write crash report: %v", err)
+		return ""
+	}
+	return path
+}
+
+// unsafeName matches characters kept out of report file names
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// write saves a report and removes the oldest beyond MaxReports
+func (r *Reporter) write(report Report) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := os.MkdirAll(r.cfg.Dir, 0o755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	// Timestamps sort chronologically; nanoseconds keep names unique
+	name := fmt.Sprintf("crash-%s-%s.json", report.Time.Format("20060102-150405.000000000"), unsafeName.ReplaceAllString(report.Source, "-"))
+	path := filepath.Join(r.cfg.Dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", err
+	}
+
+	reports, err := filepath.Glob(filepath.Join(r.cfg.Dir, "crash-*.json"))
+	if err != nil {
+		return path, nil
+	}
+	sort.Strings(reports)
+	for len(reports) > r.cfg.MaxReports {
+		_ = os.Remove(reports[0])
+		reports = reports[1:]
+	}
+	return path, nil
+}
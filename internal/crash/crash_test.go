@@ -0,0 +1,125 @@
+package crash
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// quietLogs captures log output for the test
+func quietLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func TestDo(t *testing.T) {
+	logs := quietLogs(t)
+	dir := t.TempDir()
+	Configure(Config{Dir: dir, Version: "1.2.3"})
+	t.Cleanup(func() { Configure(Config{}) })
+
+	Track(Request{ID: "req-1", Method: "GET", Path: "/v1/models", Started: time.Now()})
+	before, source := Count(), bySource.Get("cleanup")
+
+	if err := Do("cleanup", func() {}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	err := Do("cleanup", func() { panic("nil map") })
+	if err == nil || !strings.Contains(err.Error(), "nil map") {
+		t.Fatalf("expected the panic as an error, got %v", err)
+	}
+	if Count() != before+1 || source == bySource.Get("cleanup") {
+		t.Errorf("expected the crash to be counted")
+	}
+	if !strings.Contains(logs.String(), "panic in cleanup: nil map (crash report: ") || !strings.Contains(logs.String(), "goroutine") {
+		t.Errorf("expected the panic logged with its stack and report, got %q", logs.String())
+	}
+
+	reports, _ := filepath.Glob(filepath.Join(dir, "crash-*-cleanup.json"))
+	if len(reports) != 1 {
+		t.Fatalf("expected one report, got %v", reports)
+	}
+	data, _ := os.ReadFile(reports[0])
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Source != "cleanup" || report.Panic != "nil map" || report.Version != "1.2.3" || !strings.Contains(report.Stack, "TestDo") {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if len(report.Recent) != 1 || report.Recent[0].ID != "req-1" || report.Request != nil {
+		t.Errorf("expected the recent request in the report, got %+v", report.Recent)
+	}
+}
+
+func TestGo(t *testing.T) {
+	quietLogs(t)
+	before := Count()
+	done := make(chan struct{})
+	Go("worker", func() {
+		defer close(done)
+		panic("boom")
+	})
+	<-done
+	// The deferred recover runs after close
+	for deadline := time.Now().Add(time.Second); Count() == before && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if Count() != before+1 {
+		t.Error("expected the goroutine's panic to be recovered and counted")
+	}
+}
+
+func TestReporter(t *testing.T) {
+	quietLogs(t)
+	dir := t.TempDir()
+	r := NewReporter(Config{Dir: dir, MaxReports: 2, RecentRequests: 3})
+
+	for _, id := range []string{"a", "b", "c", "d"} {
+		r.Track(Request{ID: id})
+	}
+	var ids []string
+	for _, req := range r.Recent() {
+		ids = append(ids, req.ID)
+	}
+	if strings.Join(ids, ",") != "b,c,d" {
+		t.Errorf("expected the three latest requests, oldest first, got %v", ids)
+	}
+
+	var paths []string
+	for i := 0; i < 3; i++ {
+		paths = append(paths, r.Capture("http", "boom", nil, &Request{ID: "d"}))
+	}
+	reports, _ := filepath.Glob(filepath.Join(dir, "crash-*.json"))
+	if len(reports) != 2 || reports[0] != paths[1] || reports[1] != paths[2] {
+		t.Errorf("expected the two newest reports, got %v", reports)
+	}
+
+	// Credentials in the panic and stack never reach the disk
+	key := "sk-proj-" + strings.Repeat("a", 32)
+	path := r.Capture("http", "upstream rejected "+key, []byte("main.call(\"Bearer "+key+"\")"), nil)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if strings.Contains(string(data), key) || !strings.Contains(report.Panic, "sk-proj-[REDACTED]") || !strings.Contains(report.Stack, "Bearer [REDACTED]") {
+		t.Errorf("expected the key masked, got panic %q and stack %q", report.Panic, report.Stack)
+	}
+
+	// Without a directory only the metric is kept
+	if path := NewReporter(Config{}).Capture("http", "boom", nil, nil); path != "" {
+		t.Errorf("expected no report, got %s", path)
+	}
+}
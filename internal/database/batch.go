@@ -0,0 +1,252 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+	"github.com/jeffersonwarrior/modelscan/internal/database/dialect"
+)
+
+// ErrBatchWriterClosed is returned for writes after the batch writer is closed
+var ErrBatchWriterClosed = errors.New("batch writer closed")
+
+// BatchConfig controls write-behind batching
+type BatchConfig struct {
+	MaxBatch      int           // Writes per transaction
+	FlushInterval time.Duration // Longest a write waits in the buffer
+	QueueSize     int           // Buffered writes; callers block when full
+}
+
+// DefaultBatchConfig returns sensible batching defaults
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{
+		MaxBatch:      256,
+		FlushInterval: 250 * time.Millisecond,
+		QueueSize:     4096,
+	}
+}
+
+// BatchStats counts batch writer activity
+type BatchStats struct {
+	Written uint64 `json:"written"`
+	Failed  uint64 `json:"failed"`
+	Batches uint64 `json:"batches"`
+}
+
+// batchWrite is one buffered insert
+type batchWrite struct {
+	query string
+	args  []interface{}
+}
+
+// BatchWriter buffers usage and request log inserts and writes them in
+// transactions, so that per-request writes do not serialize on SQLite's
+// write lock. Writes are fire-and-forget: failures are logged and counted
+// rather than returned to the caller.
+type BatchWriter struct {
+	db  *DB
+	cfg BatchConfig
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan batchWrite
+	flush  chan chan struct{}
+	done   chan struct{}
+
+	written atomic.Uint64
+	failed  atomic.Uint64
+	batches atomic.Uint64
+}
+
+// NewBatchWriter starts a batch writer for the database. Zero config
+// values fall back to DefaultBatchConfig.
+func NewBatchWriter(db *DB, cfg BatchConfig) *BatchWriter {
+	defaults := DefaultBatchConfig()
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = defaults.MaxBatch
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaults.FlushInterval
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaults.QueueSize
+	}
+
+	w := &BatchWriter{
+		db:    db,
+		cfg:   cfg,
+		queue: make(chan batchWrite, cfg.QueueSize),
+		flush: make(chan chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// RecordUsage buffers a usage event
+func (w *BatchWriter) RecordUsage(u *UsageRecord) error {
+	return w.enqueue(insertUsageQuery, usageArgs(u))
+}
+
+// LogRequest buffers a request log entry. Unlike DB.CreateRequestLog the
+// entry's ID is not set.
+func (w *BatchWriter) LogRequest(log *RequestLog) error {
+	return w.enqueue(insertRequestLogQuery, requestLogArgs(log))
+}
+
+func (w *BatchWriter) enqueue(query string, args []interface{}) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return ErrBatchWriterClosed
+	}
+	w.queue <- batchWrite{query: query, args: args}
+	return nil
+}
+
+// Flush writes everything buffered so far and waits for it to commit
+func (w *BatchWriter) Flush() {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return
+	}
+	ack := make(chan struct{})
+	w.flush <- ack
+	<-ack
+}
+
+// Close writes any buffered entries and stops the writer. Safe to call
+// more than once.
+func (w *BatchWriter) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+	<-w.done
+}
+
+// Stats returns counts of written and failed entries and committed batches
+func (w *BatchWriter) Stats() BatchStats {
+	return BatchStats{
+		Written: w.written.Load(),
+		Failed:  w.failed.Load(),
+		Batches: w.batches.Load(),
+	}
+}
+
+// run collects writes until the batch is full or the flush interval passes
+func (w *BatchWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]batchWrite, 0, w.cfg.MaxBatch)
+	for {
+		select {
+		case item, ok := <-w.queue:
+			if !ok {
+				w.write(batch)
+				return
+			}
+			batch = append(batch, item)
+			if len(batch) >= w.cfg.MaxBatch {
+				batch = w.write(batch)
+			}
+		case <-ticker.C:
+			batch = w.write(batch)
+		case ack := <-w.flush:
+			// Flush holds the read lock, so the queue cannot be closed here
+			for n := len(w.queue); n > 0; n-- {
+				batch = append(batch, <-w.queue)
+			}
+			batch = w.write(batch)
+			close(ack)
+		}
+	}
+}
+
+// write commits the batch in transactions of at most MaxBatch entries and
+// returns the emptied batch for reuse
+func (w *BatchWriter) write(batch []batchWrite) []batchWrite {
+	for start := 0; start < len(batch); start += w.cfg.MaxBatch {
+		end := min(start+w.cfg.MaxBatch, len(batch))
+		// A panic loses only this transaction, not the writer
+		if err := crash.Do("database batch write", func() { w.commit(batch[start:end]) }); err != nil {
+			w.failed.Add(uint64(end - start))
+		}
+	}
+	return batch[:0]
+}
+
+// commit writes entries in one transaction. Each entry runs in its own
+// savepoint, so a failing entry is rolled back and skipped without
+// aborting the others; PostgreSQL refuses every later statement in a
+// transaction once one fails.
+func (w *BatchWriter) commit(items []batchWrite) {
+	tx, err := w.db.conn.Begin()
+	if err != nil {
+		log.Printf("database: batch write of %d entries failed: %v", len(items), err)
+		w.failed.Add(uint64(len(items)))
+		return
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmts := make(map[string]*sql.Stmt)
+	var written, failed uint64
+	for _, item := range items {
+		stmt, ok := stmts[item.query]
+		if !ok {
+			prepared, err := w.db.prepare(item.query)
+			if err != nil {
+				log.Printf("database: batch write failed: %v", err)
+				failed++
+				continue
+			}
+			stmt = tx.Stmt(prepared)
+			stmts[item.query] = stmt
+		}
+		if err := execSavepoint(tx, stmt, item.args); err != nil {
+			log.Printf("database: batch write failed: %v", err)
+			failed++
+			continue
+		}
+		written++
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("database: batch commit of %d entries failed: %v", len(items), err)
+		w.failed.Add(uint64(len(items)))
+		return
+	}
+	w.written.Add(written)
+	w.failed.Add(failed)
+	w.batches.Add(1)
+}
+
+// execSavepoint runs stmt inside a savepoint, rolling back to it if stmt
+// fails so the transaction can go on
+func execSavepoint(tx *dialect.Tx, stmt *sql.Stmt, args []interface{}) error {
+	if _, err := tx.Exec("SAVEPOINT batch_entry"); err != nil {
+		return err
+	}
+	if _, err := stmt.Exec(args...); err != nil {
+		if _, rerr := tx.Exec("ROLLBACK TO SAVEPOINT batch_entry"); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+		_, _ = tx.Exec("RELEASE SAVEPOINT batch_entry")
+		return err
+	}
+	_, err := tx.Exec("RELEASE SAVEPOINT batch_entry")
+	return err
+}
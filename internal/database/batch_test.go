@@ -0,0 +1,137 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func countRequestLogs(t testing.TB, db *DB) int {
+	t.Helper()
+	var n int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM request_logs").Scan(&n); err != nil {
+		t.Fatalf("count failed: %v", err)
+	}
+	return n
+}
+
+func testRequestLog(i int) *RequestLog {
+	return &RequestLog{
+		Provider:       "openai",
+		Model:          "gpt-4o",
+		Endpoint:       "/v1/chat/completions",
+		RequestTokens:  i,
+		ResponseTokens: i * 2,
+		LatencyMs:      120,
+		CreatedAt:      time.Now(),
+	}
+}
+
+func TestBatchWriter(t *testing.T) {
+	db, err := OpenWithOptions(filepath.Join(t.TempDir(), "batch.db"), DefaultOptions())
+	if err != nil {
+		t.Fatalf("OpenWithOptions failed: %v", err)
+	}
+	defer db.Close()
+
+	w := NewBatchWriter(db, BatchConfig{MaxBatch: 10, FlushInterval: time.Hour})
+
+	// A full batch is written without waiting for the interval
+	for i := 0; i < 25; i++ {
+		if err := w.LogRequest(testRequestLog(i)); err != nil {
+			t.Fatalf("LogRequest failed: %v", err)
+		}
+	}
+	w.Flush()
+	if n := countRequestLogs(t, db); n != 25 {
+		t.Errorf("expected 25 request logs after flush, got %d", n)
+	}
+	if stats := w.Stats(); stats.Written != 25 || stats.Failed != 0 || stats.Batches != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// A failing entry does not abort the rest of its batch
+	if err := w.RecordUsage(&UsageRecord{ModelID: "missing-model", Timestamp: time.Now(), Requests: 1}); err != nil {
+		t.Fatalf("RecordUsage failed: %v", err)
+	}
+	if err := w.LogRequest(testRequestLog(1)); err != nil {
+		t.Fatalf("LogRequest failed: %v", err)
+	}
+
+	// Close drains the buffer
+	w.Close()
+	w.Close()
+	if n := countRequestLogs(t, db); n != 26 {
+		t.Errorf("expected 26 request logs after close, got %d", n)
+	}
+	if stats := w.Stats(); stats.Failed != 1 {
+		t.Errorf("expected 1 failed write, got %+v", stats)
+	}
+	if err := w.LogRequest(testRequestLog(1)); err != ErrBatchWriterClosed {
+		t.Errorf("expected ErrBatchWriterClosed, got %v", err)
+	}
+	w.Flush()
+}
+
+func TestBatchWriter_Interval(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "batch.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	w := NewBatchWriter(db, BatchConfig{MaxBatch: 100, FlushInterval: 10 * time.Millisecond})
+	defer w.Close()
+
+	if err := w.LogRequest(testRequestLog(1)); err != nil {
+		t.Fatalf("LogRequest failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for countRequestLogs(t, db) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the buffered entry to be written on the interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// BenchmarkRequestLogWrites compares one insert per request with SQLite's
+// default pragmas against WAL plus write-behind batching
+func BenchmarkRequestLogWrites(b *testing.B) {
+	b.Run("direct", func(b *testing.B) {
+		db, err := Open(filepath.Join(b.TempDir(), "direct.db"))
+		if err != nil {
+			b.Fatalf("Open failed: %v", err)
+		}
+		defer db.Close()
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := db.CreateRequestLog(testRequestLog(i)); err != nil {
+				b.Fatalf("CreateRequestLog failed: %v", err)
+			}
+		}
+	})
+
+	b.Run("batched", func(b *testing.B) {
+		db, err := OpenWithOptions(filepath.Join(b.TempDir(), "batched.db"), DefaultOptions())
+		if err != nil {
+			b.Fatalf("OpenWithOptions failed: %v", err)
+		}
+		defer db.Close()
+		w := NewBatchWriter(db, DefaultBatchConfig())
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := w.LogRequest(testRequestLog(i)); err != nil {
+				b.Fatalf("LogRequest failed: %v", err)
+			}
+		}
+		w.Close()
+		b.StopTimer()
+
+		if n := countRequestLogs(b, db); n != b.N {
+			b.Fatalf("expected %d request logs, got %d", b.N, n)
+		}
+	})
+}
@@ -0,0 +1,401 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database/dialect"
+)
+
+// ConfigBundleVersion is the bundle format ExportConfig writes and
+// ImportConfig reads
+const ConfigBundleVersion = 1
+
+// ConfigBundle is an instance's routing configuration: providers, key
+// references, aliases and remap rules, for promoting it from one instance
+// to another. Keys are referenced by hash and never carry their secret.
+type ConfigBundle struct {
+	Version    int               `json:"version" yaml:"version"`
+	ExportedAt time.Time         `json:"exported_at" yaml:"exported_at"`
+	Providers  []BundleProvider  `json:"providers" yaml:"providers"`
+	Keys       []BundleKey       `json:"keys" yaml:"keys"`
+	Aliases    []BundleAlias     `json:"aliases" yaml:"aliases"`
+	RemapRules []BundleRemapRule `json:"remap_rules" yaml:"remap_rules"`
+}
+
+// BundleProvider is a provider's static configuration; its status and SDK
+// are left to the target instance
+type BundleProvider struct {
+	ID                string `json:"id" yaml:"id"`
+	Name              string `json:"name" yaml:"name"`
+	BaseURL           string `json:"base_url" yaml:"base_url"`
+	AuthMethod        string `json:"auth_method" yaml:"auth_method"`
+	AuthHeader        string `json:"auth_header,omitempty" yaml:"auth_header,omitempty"`
+	PricingModel      string `json:"pricing_model" yaml:"pricing_model"`
+	SubscriptionTiers string `json:"subscription_tiers,omitempty" yaml:"subscription_tiers,omitempty"`
+}
+
+// BundleKey references an API key by hash. Importing never creates keys:
+// a reference with no matching key is reported missing, to be added with
+// its secret on the target.
+type BundleKey struct {
+	Provider string `json:"provider" yaml:"provider"`
+	Hash     string `json:"hash" yaml:"hash"`
+	Prefix   string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Tier     string `json:"tier,omitempty" yaml:"tier,omitempty"`
+}
+
+// BundleAlias is a model alias; Client is empty for global aliases
+type BundleAlias struct {
+	Name   string `json:"name" yaml:"name"`
+	Model  string `json:"model" yaml:"model"`
+	Client string `json:"client,omitempty" yaml:"client,omitempty"`
+}
+
+// BundleRemapRule is a remap rule, identified across instances by client,
+// from_model and priority since rule IDs differ
+type BundleRemapRule struct {
+	Client     string   `json:"client,omitempty" yaml:"client,omitempty"`
+	FromModel  string   `json:"from_model" yaml:"from_model"`
+	ToModel    string   `json:"to_model,omitempty" yaml:"to_model,omitempty"`
+	ToProvider string   `json:"to_provider,omitempty" yaml:"to_provider,omitempty"`
+	Strategy   string   `json:"strategy" yaml:"strategy"`
+	Candidates []string `json:"candidates,omitempty" yaml:"candidates,omitempty"`
+	Priority   int      `json:"priority" yaml:"priority"`
+	Enabled    bool     `json:"enabled" yaml:"enabled"`
+}
+
+func (r BundleRemapRule) name() string {
+	name := fmt.Sprintf("%s@%d", r.FromModel, r.Priority)
+	if r.Client != "" {
+		name = r.Client + ":" + name
+	}
+	return name
+}
+
+// Config change actions
+const (
+	ConfigCreate    = "create"
+	ConfigUpdate    = "update"
+	ConfigUnchanged = "unchanged"
+	ConfigMissing   = "missing" // Key reference with no matching key
+)
+
+// ConfigChange is what importing a bundle does to one item
+type ConfigChange struct {
+	Kind   string `json:"kind"` // provider, key, alias or remap_rule
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	Detail string `json:"detail,omitempty"` // Changed fields, as "field: old -> new"
+}
+
+// ConfigDiff lists the changes importing a bundle makes, or would make on
+// a dry run
+type ConfigDiff struct {
+	DryRun  bool           `json:"dry_run"`
+	Changes []ConfigChange `json:"changes"`
+	Counts  map[string]int `json:"counts"` // By action
+}
+
+func (d *ConfigDiff) add(kind, name, action string, detail ...string) {
+	d.Changes = append(d.Changes, ConfigChange{Kind: kind, Name: name, Action: action, Detail: strings.Join(detail, "; ")})
+	d.Counts[action]++
+}
+
+// fieldChange describes a changed field, or returns "" when it is unchanged
+func fieldChange(field string, old, new interface{}) string {
+	o, n := fmt.Sprint(old), fmt.Sprint(new)
+	if o == n {
+		return ""
+	}
+	return fmt.Sprintf("%s: %q -> %q", field, o, n)
+}
+
+// compactChanges drops the unchanged fields from fieldChange results
+func compactChanges(changes ...string) []string {
+	return slices.DeleteFunc(changes, func(s string) bool { return s == "" })
+}
+
+// ExportConfig returns the instance's configuration as a bundle
+func (db *DB) ExportConfig() (*ConfigBundle, error) {
+	b := &ConfigBundle{Version: ConfigBundleVersion, ExportedAt: time.Now().UTC()}
+
+	providers, err := db.ListProviders()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export providers: %w", err)
+	}
+	for _, p := range providers {
+		b.Providers = append(b.Providers, BundleProvider{
+			ID: p.ID, Name: p.Name, BaseURL: p.BaseURL, AuthMethod: p.AuthMethod,
+			AuthHeader: deref(p.AuthHeader), PricingModel: p.PricingModel,
+			SubscriptionTiers: deref(p.SubscriptionTiers),
+		})
+	}
+
+	keys, err := db.ListAPIKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export keys: %w", err)
+	}
+	for _, k := range keys {
+		b.Keys = append(b.Keys, BundleKey{Provider: k.ProviderID, Hash: k.KeyHash, Prefix: deref(k.KeyPrefix), Tier: k.Tier})
+	}
+
+	aliases, err := db.ListAllAliases()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export aliases: %w", err)
+	}
+	for _, a := range aliases {
+		b.Aliases = append(b.Aliases, BundleAlias{Name: a.Name, Model: a.ModelID, Client: deref(a.ClientID)})
+	}
+
+	rules, err := NewRemapRuleRepository(db).List(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export remap rules: %w", err)
+	}
+	for _, r := range rules {
+		b.RemapRules = append(b.RemapRules, BundleRemapRule{
+			Client: r.ClientID, FromModel: r.FromModel, ToModel: r.ToModel, ToProvider: r.ToProvider,
+			Strategy: r.Strategy, Candidates: r.Candidates, Priority: r.Priority, Enabled: r.Enabled,
+		})
+	}
+	return b, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// ImportConfig creates and updates the bundle's items, matching them to
+// existing ones by provider ID, key hash, alias name and client, and remap
+// rule client, from_model and priority. Items the bundle does not mention
+// are left alone. The import is atomic; with dryRun it is rolled back, so
+// the diff shows exactly what a real import would do, errors included.
+func (db *DB) ImportConfig(b *ConfigBundle, dryRun bool) (*ConfigDiff, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to import config: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	diff := &ConfigDiff{DryRun: dryRun, Changes: []ConfigChange{}, Counts: map[string]int{}}
+	for _, step := range []func(*dialect.Tx, *ConfigBundle, *ConfigDiff) error{
+		importProviders, importKeys, importAliases, importRemapRules,
+	} {
+		if err := step(tx, b, diff); err != nil {
+			return nil, err
+		}
+	}
+
+	if dryRun {
+		return diff, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to import config: %w", err)
+	}
+	return diff, nil
+}
+
+// validate checks the bundle's version and each item's required fields
+func (b *ConfigBundle) validate() error {
+	if b.Version != ConfigBundleVersion {
+		return fmt.Errorf("unsupported config bundle version %d (want %d)", b.Version, ConfigBundleVersion)
+	}
+	for i, p := range b.Providers {
+		if p.ID == "" || p.Name == "" || p.BaseURL == "" {
+			return fmt.Errorf("provider %d: id, name and base_url are required", i)
+		}
+	}
+	for i, k := range b.Keys {
+		if k.Provider == "" || k.Hash == "" {
+			return fmt.Errorf("key %d: provider and hash are required", i)
+		}
+	}
+	for i, a := range b.Aliases {
+		if a.Name == "" || a.Model == "" {
+			return fmt.Errorf("alias %d: name and model are required", i)
+		}
+	}
+	for i, r := range b.RemapRules {
+		switch {
+		case r.FromModel == "":
+			return fmt.Errorf("remap rule %d: from_model is required", i)
+		case normalizeStrategy(r.Strategy) == RemapStrategyDirect && (r.ToModel == "" || r.ToProvider == ""):
+			return fmt.Errorf("remap rule %d: to_model and to_provider are required", i)
+		case normalizeStrategy(r.Strategy) != RemapStrategyDirect && r.Strategy != RemapStrategyCheapest && r.Strategy != RemapStrategyFastest:
+			return fmt.Errorf("remap rule %d: strategy must be direct, cheapest or fastest", i)
+		case normalizeStrategy(r.Strategy) != RemapStrategyDirect && len(r.Candidates) == 0:
+			return fmt.Errorf("remap rule %d: candidates are required for %s strategy", i, r.Strategy)
+		}
+	}
+	return nil
+}
+
+func importProviders(tx *dialect.Tx, b *ConfigBundle, diff *ConfigDiff) error {
+	for _, p := range b.Providers {
+		var cur BundleProvider
+		var authHeader, tiers sql.NullString
+		err := tx.QueryRow(`
+			SELECT id, name, base_url, auth_method, auth_header, pricing_model, subscription_tiers
+			FROM providers WHERE id = ?
+		`, p.ID).Scan(&cur.ID, &cur.Name, &cur.BaseURL, &cur.AuthMethod, &authHeader, &cur.PricingModel, &tiers)
+		cur.AuthHeader, cur.SubscriptionTiers = authHeader.String, tiers.String
+
+		switch {
+		case err == sql.ErrNoRows:
+			_, err = tx.Exec(`
+				INSERT INTO providers (id, name, base_url, auth_method, auth_header, pricing_model, subscription_tiers)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
+			`, p.ID, p.Name, p.BaseURL, p.AuthMethod, nullable(p.AuthHeader), p.PricingModel, nullable(p.SubscriptionTiers))
+			if err != nil {
+				return fmt.Errorf("failed to create provider %s: %w", p.ID, err)
+			}
+			diff.add("provider", p.ID, ConfigCreate)
+		case err != nil:
+			return fmt.Errorf("failed to read provider %s: %w", p.ID, err)
+		case cur == p:
+			diff.add("provider", p.ID, ConfigUnchanged)
+		default:
+			_, err = tx.Exec(`
+				UPDATE providers SET name = ?, base_url = ?, auth_method = ?, auth_header = ?, pricing_model = ?, subscription_tiers = ?
+				WHERE id = ?
+			`, p.Name, p.BaseURL, p.AuthMethod, nullable(p.AuthHeader), p.PricingModel, nullable(p.SubscriptionTiers), p.ID)
+			if err != nil {
+				return fmt.Errorf("failed to update provider %s: %w", p.ID, err)
+			}
+			diff.add("provider", p.ID, ConfigUpdate, compactChanges(
+				fieldChange("name", cur.Name, p.Name),
+				fieldChange("base_url", cur.BaseURL, p.BaseURL),
+				fieldChange("auth_method", cur.AuthMethod, p.AuthMethod),
+				fieldChange("auth_header", cur.AuthHeader, p.AuthHeader),
+				fieldChange("pricing_model", cur.PricingModel, p.PricingModel),
+				fieldChange("subscription_tiers", cur.SubscriptionTiers, p.SubscriptionTiers),
+			)...)
+		}
+	}
+	return nil
+}
+
+func importKeys(tx *dialect.Tx, b *ConfigBundle, diff *ConfigDiff) error {
+	for _, k := range b.Keys {
+		name := k.Provider + "/" + k.Prefix
+		if k.Prefix == "" {
+			name = k.Provider + "/" + k.Hash[:min(len(k.Hash), 12)]
+		}
+		var provider string
+		err := tx.QueryRow(`SELECT provider_id FROM api_keys WHERE key_hash = ?`, k.Hash).Scan(&provider)
+		switch {
+		case err == sql.ErrNoRows:
+			diff.add("key", name, ConfigMissing, "add the key with its secret on this instance")
+		case err != nil:
+			return fmt.Errorf("failed to read key %s: %w", name, err)
+		case provider != k.Provider:
+			diff.add("key", name, ConfigMissing, fieldChange("provider", provider, k.Provider))
+		default:
+			diff.add("key", name, ConfigUnchanged)
+		}
+	}
+	return nil
+}
+
+func importAliases(tx *dialect.Tx, b *ConfigBundle, diff *ConfigDiff) error {
+	for _, a := range b.Aliases {
+		name := a.Name
+		if a.Client != "" {
+			name = a.Client + ":" + a.Name
+		}
+		match := `name = ? AND client_id IS NULL`
+		args := []interface{}{a.Name}
+		if a.Client != "" {
+			match = `name = ? AND client_id = ?`
+			args = append(args, a.Client)
+		}
+		var model string
+		err := tx.QueryRow(`SELECT model_id FROM aliases WHERE `+match, args...).Scan(&model)
+		switch {
+		case err == sql.ErrNoRows:
+			if _, err := tx.Exec(`INSERT INTO aliases (name, model_id, client_id) VALUES (?, ?, ?)`, a.Name, a.Model, nullable(a.Client)); err != nil {
+				return fmt.Errorf("failed to create alias %s: %w", name, err)
+			}
+			diff.add("alias", name, ConfigCreate)
+		case err != nil:
+			return fmt.Errorf("failed to read alias %s: %w", name, err)
+		case model == a.Model:
+			diff.add("alias", name, ConfigUnchanged)
+		default:
+			if _, err := tx.Exec(`UPDATE aliases SET model_id = ? WHERE `+match, append([]interface{}{a.Model}, args...)...); err != nil {
+				return fmt.Errorf("failed to update alias %s: %w", name, err)
+			}
+			diff.add("alias", name, ConfigUpdate, fieldChange("model", model, a.Model))
+		}
+	}
+	return nil
+}
+
+func importRemapRules(tx *dialect.Tx, b *ConfigBundle, diff *ConfigDiff) error {
+	for _, r := range b.RemapRules {
+		r.Strategy = normalizeStrategy(r.Strategy)
+		match := `client_id IS NULL AND from_model = ? AND priority = ?`
+		args := []interface{}{r.FromModel, r.Priority}
+		if r.Client != "" {
+			match = `client_id = ? AND from_model = ? AND priority = ?`
+			args = []interface{}{r.Client, r.FromModel, r.Priority}
+		}
+		candidates, err := encodeCandidates(r.Candidates)
+		if err != nil {
+			return err
+		}
+
+		cur, err := scanRemapRule(tx.QueryRow(`SELECT `+remapRuleColumns+` FROM remap_rules WHERE `+match+` ORDER BY id LIMIT 1`, args...))
+		switch {
+		case err == sql.ErrNoRows:
+			_, err = tx.Exec(`
+				INSERT INTO remap_rules (client_id, from_model, to_model, to_provider, strategy, candidates, priority, enabled)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			`, nullableClientID(r.Client), r.FromModel, r.ToModel, r.ToProvider, r.Strategy, candidates, r.Priority, r.Enabled)
+			if err != nil {
+				return fmt.Errorf("failed to create remap rule %s: %w", r.name(), err)
+			}
+			diff.add("remap_rule", r.name(), ConfigCreate)
+		case err != nil:
+			return fmt.Errorf("failed to read remap rule %s: %w", r.name(), err)
+		default:
+			changes := compactChanges(
+				fieldChange("to_model", cur.ToModel, r.ToModel),
+				fieldChange("to_provider", cur.ToProvider, r.ToProvider),
+				fieldChange("strategy", cur.Strategy, r.Strategy),
+				fieldChange("candidates", strings.Join(cur.Candidates, ","), strings.Join(r.Candidates, ",")),
+				fieldChange("enabled", cur.Enabled, r.Enabled),
+			)
+			if len(changes) == 0 {
+				diff.add("remap_rule", r.name(), ConfigUnchanged)
+				continue
+			}
+			_, err = tx.Exec(`
+				UPDATE remap_rules SET to_model = ?, to_provider = ?, strategy = ?, candidates = ?, enabled = ?
+				WHERE id = ?
+			`, r.ToModel, r.ToProvider, r.Strategy, candidates, r.Enabled, cur.ID)
+			if err != nil {
+				return fmt.Errorf("failed to update remap rule %s: %w", r.name(), err)
+			}
+			diff.add("remap_rule", r.name(), ConfigUpdate, changes...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigBundle_ExportImport(t *testing.T) {
+	staging, err := Open(filepath.Join(t.TempDir(), "staging.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer staging.Close()
+	prod, err := Open(filepath.Join(t.TempDir(), "prod.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer prod.Close()
+
+	for _, db := range []*DB{staging, prod} {
+		if err := db.CreateProvider(&Provider{ID: "openai", Name: "OpenAI", BaseURL: "https://api.openai.com", AuthMethod: "bearer", PricingModel: "usage"}); err != nil {
+			t.Fatalf("CreateProvider failed: %v", err)
+		}
+		if err := db.CreateAlias(&Alias{Name: "fast", ModelID: "gpt-4o-mini"}); err != nil {
+			t.Fatalf("CreateAlias failed: %v", err)
+		}
+	}
+	staging.CreateProvider(&Provider{ID: "groq", Name: "Groq", BaseURL: "https://api.groq.com", AuthMethod: "bearer", PricingModel: "usage"})
+	staging.UpdateAlias("fast", nil, "llama-3.3-70b")
+	if _, err := staging.CreateAPIKey("groq", "gsk-staging-secret-key"); err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	NewRemapRuleRepository(staging).Create(&RemapRule{FromModel: "gpt-4*", ToModel: "llama-3.3-70b", ToProvider: "groq", Priority: 10, Enabled: true})
+
+	bundle, err := staging.ExportConfig()
+	if err != nil {
+		t.Fatalf("ExportConfig failed: %v", err)
+	}
+	if len(bundle.Providers) != 2 || len(bundle.Keys) != 1 || len(bundle.Aliases) != len(DefaultAliases)+1 || len(bundle.RemapRules) != 1 {
+		t.Fatalf("unexpected bundle %+v", bundle)
+	}
+	if bundle.Keys[0].Hash != HashAPIKey("gsk-staging-secret-key") || strings.Contains(bundle.Keys[0].Prefix, "secret") {
+		t.Errorf("expected the key by hash and short prefix only, got %+v", bundle.Keys[0])
+	}
+
+	// A dry run reports the changes without making them
+	diff, err := prod.ImportConfig(bundle, true)
+	if err != nil {
+		t.Fatalf("ImportConfig dry run failed: %v", err)
+	}
+	// openai and the seeded default aliases match; fast differs
+	want := map[string]int{ConfigCreate: 2, ConfigUpdate: 1, ConfigUnchanged: len(DefaultAliases) + 1, ConfigMissing: 1}
+	for action, n := range want {
+		if diff.Counts[action] != n {
+			t.Errorf("expected %d %s changes, got %d: %+v", n, action, diff.Counts[action], diff.Changes)
+		}
+	}
+	if model, _ := prod.ResolveAlias("fast", nil); model != "gpt-4o-mini" {
+		t.Errorf("expected the dry run to change nothing, alias resolves to %s", model)
+	}
+
+	if _, err := prod.ImportConfig(bundle, false); err != nil {
+		t.Fatalf("ImportConfig failed: %v", err)
+	}
+	if model, _ := prod.ResolveAlias("fast", nil); model != "llama-3.3-70b" {
+		t.Errorf("expected the alias to be updated, resolves to %s", model)
+	}
+	if p, _ := prod.GetProvider("groq"); p == nil {
+		t.Error("expected groq to be created")
+	}
+
+	// Importing again changes nothing but the still missing key
+	diff, err = prod.ImportConfig(bundle, false)
+	if err != nil {
+		t.Fatalf("ImportConfig failed: %v", err)
+	}
+	if diff.Counts[ConfigUnchanged] != len(diff.Changes)-1 || diff.Counts[ConfigMissing] != 1 {
+		t.Errorf("expected an idempotent import, got %+v", diff.Changes)
+	}
+}
+
+func TestConfigBundle_ImportRejectsInvalid(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "bundle.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.ImportConfig(&ConfigBundle{Version: 99}, true); err == nil {
+		t.Error("expected an unsupported version to be rejected")
+	}
+	bad := &ConfigBundle{Version: ConfigBundleVersion, RemapRules: []BundleRemapRule{{FromModel: "gpt-4*"}}}
+	if _, err := db.ImportConfig(bad, true); err == nil || !strings.Contains(err.Error(), "to_model") {
+		t.Errorf("expected a direct rule without a target to be rejected, got %v", err)
+	}
+
+	// An error part way through leaves nothing behind
+	partial := &ConfigBundle{Version: ConfigBundleVersion,
+		Aliases:    []BundleAlias{{Name: "fast", Model: "gpt-4o-mini"}},
+		RemapRules: []BundleRemapRule{{Client: "no-such-client", FromModel: "gpt-4*", ToModel: "m", ToProvider: "p"}},
+	}
+	if _, err := db.ImportConfig(partial, false); err == nil {
+		t.Fatal("expected a rule for an unknown client to fail")
+	}
+	if alias, _ := db.GetAlias("fast", nil); alias != nil {
+		t.Errorf("expected the failed import to be rolled back, found %+v", alias)
+	}
+}
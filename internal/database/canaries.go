@@ -0,0 +1,222 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CanaryResult is a persisted snapshot of a canary rollout decision
+type CanaryResult struct {
+	ID             int
+	PolicyID       string
+	ModelPattern   string
+	CanaryModel    string
+	CanaryProvider string
+	Percent        float64
+	Status         string // running, promoted, rolled_back, stopped
+	Reason         string
+
+	BaselineRequests     int
+	BaselineErrors       int
+	BaselineAvgLatencyMs float64
+	BaselineAvgQuality   *float64
+
+	CanaryRequests     int
+	CanaryErrors       int
+	CanaryAvgLatencyMs float64
+	CanaryAvgQuality   *float64
+
+	CreatedAt time.Time
+}
+
+const canaryResultColumns = `id, policy_id, model_pattern, canary_model, canary_provider, percent, status, reason,
+	baseline_requests, baseline_errors, baseline_avg_latency_ms, baseline_avg_quality,
+	canary_requests, canary_errors, canary_avg_latency_ms, canary_avg_quality, created_at`
+
+// CanaryResultRepository provides persistence for canary results
+type CanaryResultRepository struct {
+	db *DB
+}
+
+// NewCanaryResultRepository creates a new CanaryResultRepository
+func NewCanaryResultRepository(db *DB) *CanaryResultRepository {
+	return &CanaryResultRepository{db: db}
+}
+
+// Create inserts a new canary result
+func (r *CanaryResultRepository) Create(result *CanaryResult) error {
+	query := `
+		INSERT INTO canary_results (policy_id, model_pattern, canary_model, canary_provider, percent, status, reason,
+			baseline_requests, baseline_errors, baseline_avg_latency_ms, baseline_avg_quality,
+			canary_requests, canary_errors, canary_avg_latency_ms, canary_avg_quality)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	id, err := r.db.conn.Insert(query,
+		result.PolicyID, result.ModelPattern, result.CanaryModel, result.CanaryProvider, result.Percent,
+		result.Status, result.Reason,
+		result.BaselineRequests, result.BaselineErrors, result.BaselineAvgLatencyMs, result.BaselineAvgQuality,
+		result.CanaryRequests, result.CanaryErrors, result.CanaryAvgLatencyMs, result.CanaryAvgQuality,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create canary result: %w", err)
+	}
+	result.ID = int(id)
+	if result.CreatedAt.IsZero() {
+		result.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+// Get retrieves a canary result by ID
+func (r *CanaryResultRepository) Get(id int) (*CanaryResult, error) {
+	query := `SELECT ` + canaryResultColumns + ` FROM canary_results WHERE id = ?`
+	result, err := scanCanaryResult(r.db.conn.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get canary result: %w", err)
+	}
+	return result, nil
+}
+
+// List returns canary results, newest first. An empty policyID lists all policies.
+// A limit of zero or less returns every result.
+func (r *CanaryResultRepository) List(policyID string, limit int) ([]*CanaryResult, error) {
+	query := `SELECT ` + canaryResultColumns + ` FROM canary_results`
+	var args []interface{}
+	if policyID != "" {
+		query += ` WHERE policy_id = ?`
+		args = append(args, policyID)
+	}
+	query += ` ORDER BY created_at DESC, id DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list canary results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*CanaryResult
+	for rows.Next() {
+		result, err := scanCanaryResult(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan canary result: %w", err)
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// scanCanaryResult scans a row selected with canaryResultColumns
+func scanCanaryResult(row rowScanner) (*CanaryResult, error) {
+	result := &CanaryResult{}
+	var reason sql.NullString
+	var baselineQuality, canaryQuality sql.NullFloat64
+	err := row.Scan(
+		&result.ID, &result.PolicyID, &result.ModelPattern, &result.CanaryModel, &result.CanaryProvider,
+		&result.Percent, &result.Status, &reason,
+		&result.BaselineRequests, &result.BaselineErrors, &result.BaselineAvgLatencyMs, &baselineQuality,
+		&result.CanaryRequests, &result.CanaryErrors, &result.CanaryAvgLatencyMs, &canaryQuality,
+		&result.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	result.Reason = reason.String
+	if baselineQuality.Valid {
+		result.BaselineAvgQuality = &baselineQuality.Float64
+	}
+	if canaryQuality.Valid {
+		result.CanaryAvgQuality = &canaryQuality.Float64
+	}
+	return result, nil
+}
+
+// CanaryPolicy is a canary's policy and lifecycle status, kept so running
+// and promoted canaries survive restarts
+type CanaryPolicy struct {
+	PolicyID             string
+	ModelPattern         string
+	CanaryModel          string
+	CanaryProvider       string
+	Percent              float64
+	MinSamples           int
+	PromoteAfter         int
+	MaxErrorRateIncrease float64
+	MaxLatencyRatio      float64
+	MaxQualityDrop       float64
+	Status               string // running, promoted, rolled_back, stopped
+	Reason               string
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+const canaryPolicyColumns = `policy_id, model_pattern, canary_model, canary_provider, percent, min_samples, promote_after,
+	max_error_rate_increase, max_latency_ratio, max_quality_drop, status, reason, created_at, updated_at`
+
+// SavePolicy creates or replaces a canary policy
+func (r *CanaryResultRepository) SavePolicy(p *CanaryPolicy) error {
+	query := `
+		INSERT INTO canary_policies (policy_id, model_pattern, canary_model, canary_provider, percent, min_samples, promote_after,
+			max_error_rate_increase, max_latency_ratio, max_quality_drop, status, reason, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(policy_id) DO UPDATE SET
+			model_pattern = excluded.model_pattern,
+			canary_model = excluded.canary_model,
+			canary_provider = excluded.canary_provider,
+			percent = excluded.percent,
+			min_samples = excluded.min_samples,
+			promote_after = excluded.promote_after,
+			max_error_rate_increase = excluded.max_error_rate_increase,
+			max_latency_ratio = excluded.max_latency_ratio,
+			max_quality_drop = excluded.max_quality_drop,
+			status = excluded.status,
+			reason = excluded.reason,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := r.db.conn.Exec(query,
+		p.PolicyID, p.ModelPattern, p.CanaryModel, p.CanaryProvider, p.Percent, p.MinSamples, p.PromoteAfter,
+		p.MaxErrorRateIncrease, p.MaxLatencyRatio, p.MaxQualityDrop, p.Status, p.Reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save canary policy: %w", err)
+	}
+	return nil
+}
+
+// ListPolicies returns every stored canary policy, oldest first
+func (r *CanaryResultRepository) ListPolicies() ([]*CanaryPolicy, error) {
+	rows, err := r.db.conn.Query(`SELECT ` + canaryPolicyColumns + ` FROM canary_policies ORDER BY created_at, policy_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list canary policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []*CanaryPolicy
+	for rows.Next() {
+		p := &CanaryPolicy{}
+		err := rows.Scan(
+			&p.PolicyID, &p.ModelPattern, &p.CanaryModel, &p.CanaryProvider, &p.Percent, &p.MinSamples, &p.PromoteAfter,
+			&p.MaxErrorRateIncrease, &p.MaxLatencyRatio, &p.MaxQualityDrop, &p.Status, &p.Reason, &p.CreatedAt, &p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan canary policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// DeletePolicy deletes a canary policy; its results are kept
+func (r *CanaryResultRepository) DeletePolicy(policyID string) error {
+	if _, err := r.db.conn.Exec(`DELETE FROM canary_policies WHERE policy_id = ?`, policyID); err != nil {
+		return fmt.Errorf("failed to delete canary policy: %w", err)
+	}
+	return nil
+}
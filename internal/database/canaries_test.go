@@ -0,0 +1,157 @@
+package database
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCanaryResultRepository(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "modelscan-test-*.db")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	tmpFile.Close()
+
+	db, err := Open(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewCanaryResultRepository(db)
+
+	quality := 0.82
+	first := &CanaryResult{
+		PolicyID:             "gpt5",
+		ModelPattern:         "gpt-4*",
+		CanaryModel:          "gpt-5",
+		CanaryProvider:       "openai",
+		Percent:              10,
+		Status:               "rolled_back",
+		Reason:               "error rate 12.0% vs baseline 1.0%",
+		BaselineRequests:     100,
+		BaselineErrors:       1,
+		BaselineAvgLatencyMs: 850,
+		CanaryRequests:       25,
+		CanaryErrors:         3,
+		CanaryAvgLatencyMs:   910,
+		CanaryAvgQuality:     &quality,
+	}
+	if err := repo.Create(first); err != nil {
+		t.Fatalf("failed to create result: %v", err)
+	}
+	if first.ID == 0 {
+		t.Error("expected ID to be set")
+	}
+
+	second := &CanaryResult{PolicyID: "other", ModelPattern: "claude-*", CanaryModel: "claude-opus-4-5", Percent: 5, Status: "promoted"}
+	if err := repo.Create(second); err != nil {
+		t.Fatalf("failed to create result: %v", err)
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		got, err := repo.Get(first.ID)
+		if err != nil {
+			t.Fatalf("failed to get result: %v", err)
+		}
+		if got.Reason != first.Reason || got.CanaryErrors != 3 || got.BaselineAvgLatencyMs != 850 {
+			t.Errorf("unexpected result: %+v", got)
+		}
+		if got.CanaryAvgQuality == nil || *got.CanaryAvgQuality != quality {
+			t.Errorf("expected canary quality %v, got %v", quality, got.CanaryAvgQuality)
+		}
+		if got.BaselineAvgQuality != nil {
+			t.Errorf("expected nil baseline quality, got %v", *got.BaselineAvgQuality)
+		}
+	})
+
+	t.Run("Get missing", func(t *testing.T) {
+		got, err := repo.Get(9999)
+		if err != nil || got != nil {
+			t.Errorf("expected nil, nil; got %v, %v", got, err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		all, err := repo.List("", 0)
+		if err != nil {
+			t.Fatalf("failed to list results: %v", err)
+		}
+		if len(all) != 2 || all[0].ID != second.ID {
+			t.Errorf("expected 2 results newest first, got %d", len(all))
+		}
+
+		filtered, err := repo.List("gpt5", 10)
+		if err != nil {
+			t.Fatalf("failed to list results: %v", err)
+		}
+		if len(filtered) != 1 || filtered[0].PolicyID != "gpt5" {
+			t.Errorf("expected only gpt5 results, got %d", len(filtered))
+		}
+
+		limited, err := repo.List("", 1)
+		if err != nil {
+			t.Fatalf("failed to list results: %v", err)
+		}
+		if len(limited) != 1 {
+			t.Errorf("expected 1 result with limit, got %d", len(limited))
+		}
+	})
+}
+
+func TestCanaryPolicies(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "modelscan-test-*.db")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	tmpFile.Close()
+
+	db, err := Open(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewCanaryResultRepository(db)
+
+	policy := &CanaryPolicy{
+		PolicyID:             "gpt5",
+		ModelPattern:         "gpt-4*",
+		CanaryModel:          "gpt-5",
+		CanaryProvider:       "openai",
+		Percent:              10,
+		MinSamples:           50,
+		PromoteAfter:         200,
+		MaxErrorRateIncrease: 0.05,
+		MaxLatencyRatio:      1.5,
+		Status:               "running",
+	}
+	if err := repo.SavePolicy(policy); err != nil {
+		t.Fatalf("failed to save policy: %v", err)
+	}
+	policy.Status, policy.Reason = "promoted", "healthy after 200 canary requests"
+	if err := repo.SavePolicy(policy); err != nil {
+		t.Fatalf("failed to update policy: %v", err)
+	}
+
+	policies, err := repo.ListPolicies()
+	if err != nil {
+		t.Fatalf("failed to list policies: %v", err)
+	}
+	if len(policies) != 1 {
+		t.Fatalf("expected 1 policy, got %d", len(policies))
+	}
+	got := policies[0]
+	if got.Status != "promoted" || got.Reason != policy.Reason || got.PromoteAfter != 200 || got.MaxLatencyRatio != 1.5 || got.CanaryProvider != "openai" {
+		t.Errorf("unexpected policy: %+v", got)
+	}
+
+	if err := repo.DeletePolicy("gpt5"); err != nil {
+		t.Fatalf("failed to delete policy: %v", err)
+	}
+	if policies, _ := repo.ListPolicies(); len(policies) != 0 {
+		t.Errorf("expected no policies after delete, got %d", len(policies))
+	}
+}
@@ -0,0 +1,246 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Sources of a catalog snapshot
+const (
+	CatalogSourceDiscovery = "discovery" // A discovery agent run
+	CatalogSourceScheduler = "scheduler" // A scheduled model list refresh
+)
+
+// Kinds of catalog change
+const (
+	CatalogModelAdded   = "model_added"
+	CatalogModelRemoved = "model_removed"
+	CatalogPriceChanged = "price_changed"
+)
+
+// CatalogModel is one model in a catalog snapshot. Prices and the context
+// window are nil when the source did not report them.
+type CatalogModel struct {
+	ModelID       string   `json:"model_id"`
+	Name          string   `json:"name,omitempty"`
+	CostPer1MIn   *float64 `json:"cost_per_1m_in,omitempty"`
+	CostPer1MOut  *float64 `json:"cost_per_1m_out,omitempty"`
+	ContextWindow *int     `json:"context_window,omitempty"`
+}
+
+// CatalogSnapshot is a provider's model list and pricing as one run saw
+// it. Runs that see the same catalog only move CheckedAt forward.
+type CatalogSnapshot struct {
+	ID         int             `json:"id"`
+	ProviderID string          `json:"provider_id"`
+	Source     string          `json:"source"`
+	ModelCount int             `json:"model_count"`
+	TakenAt    time.Time       `json:"taken_at"`   // First run that saw this catalog
+	CheckedAt  time.Time       `json:"checked_at"` // Latest run that saw it
+	Models     []*CatalogModel `json:"models,omitempty"`
+}
+
+// CatalogChange is a model appearing, disappearing or changing price
+// between two snapshots
+type CatalogChange struct {
+	ID              int       `json:"id"`
+	SnapshotID      int       `json:"snapshot_id"`
+	ProviderID      string    `json:"provider_id"`
+	ModelID         string    `json:"model_id"`
+	Kind            string    `json:"kind"`
+	OldCostPer1MIn  *float64  `json:"old_cost_per_1m_in,omitempty"`
+	OldCostPer1MOut *float64  `json:"old_cost_per_1m_out,omitempty"`
+	NewCostPer1MIn  *float64  `json:"new_cost_per_1m_in,omitempty"`
+	NewCostPer1MOut *float64  `json:"new_cost_per_1m_out,omitempty"`
+	DetectedAt      time.Time `json:"detected_at"`
+}
+
+// CatalogChangeFilter selects changes to list. Empty fields match
+// everything.
+type CatalogChangeFilter struct {
+	ProviderID string
+	Since      time.Time // Detected at or after
+	AfterID    int       // ID greater than, for polling the feed
+}
+
+const catalogSnapshotColumns = `id, provider_id, source, model_count, taken_at, checked_at`
+
+const catalogChangeColumns = `id, snapshot_id, provider_id, model_id, kind, old_cost_per_1m_in, old_cost_per_1m_out, new_cost_per_1m_in, new_cost_per_1m_out, detected_at`
+
+// CreateCatalogSnapshot stores a snapshot, its models and the changes
+// that led to it in one transaction, setting their IDs
+func (db *DB) CreateCatalogSnapshot(snap *CatalogSnapshot, changes []*CatalogChange) error {
+	if snap.TakenAt.IsZero() {
+		snap.TakenAt = time.Now()
+	}
+	if snap.CheckedAt.IsZero() {
+		snap.CheckedAt = snap.TakenAt
+	}
+	snap.ModelCount = len(snap.Models)
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to create catalog snapshot: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	id, err := tx.Insert(`
+		INSERT INTO catalog_snapshots (provider_id, source, model_count, taken_at, checked_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, snap.ProviderID, snap.Source, snap.ModelCount, snap.TakenAt.UTC(), snap.CheckedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create catalog snapshot: %w", err)
+	}
+	snap.ID = int(id)
+
+	for _, m := range snap.Models {
+		_, err := tx.Exec(`
+			INSERT INTO catalog_snapshot_models (snapshot_id, model_id, name, cost_per_1m_in, cost_per_1m_out, context_window)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, snap.ID, m.ModelID, m.Name, m.CostPer1MIn, m.CostPer1MOut, m.ContextWindow)
+		if err != nil {
+			return fmt.Errorf("failed to store catalog model %s: %w", m.ModelID, err)
+		}
+	}
+
+	for _, c := range changes {
+		c.SnapshotID, c.ProviderID = snap.ID, snap.ProviderID
+		if c.DetectedAt.IsZero() {
+			c.DetectedAt = snap.TakenAt
+		}
+		id, err := tx.Insert(`
+			INSERT INTO catalog_changes (snapshot_id, provider_id, model_id, kind, old_cost_per_1m_in, old_cost_per_1m_out, new_cost_per_1m_in, new_cost_per_1m_out, detected_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, c.SnapshotID, c.ProviderID, c.ModelID, c.Kind, c.OldCostPer1MIn, c.OldCostPer1MOut, c.NewCostPer1MIn, c.NewCostPer1MOut, c.DetectedAt.UTC())
+		if err != nil {
+			return fmt.Errorf("failed to record catalog change: %w", err)
+		}
+		c.ID = int(id)
+	}
+	return tx.Commit()
+}
+
+// TouchCatalogSnapshot records that a run saw the snapshot's catalog again
+func (db *DB) TouchCatalogSnapshot(id int, checkedAt time.Time) error {
+	if _, err := db.conn.Exec("UPDATE catalog_snapshots SET checked_at = ? WHERE id = ?", checkedAt.UTC(), id); err != nil {
+		return fmt.Errorf("failed to update catalog snapshot: %w", err)
+	}
+	return nil
+}
+
+// LatestCatalogSnapshot retrieves a provider's newest snapshot with its
+// models, or nil if it has none
+func (db *DB) LatestCatalogSnapshot(providerID string) (*CatalogSnapshot, error) {
+	row := db.conn.QueryRow(`SELECT `+catalogSnapshotColumns+` FROM catalog_snapshots WHERE provider_id = ? ORDER BY id DESC LIMIT 1`, providerID)
+	return db.catalogSnapshotWithModels(row)
+}
+
+// GetCatalogSnapshot retrieves a snapshot with its models, or nil if it
+// does not exist
+func (db *DB) GetCatalogSnapshot(id int) (*CatalogSnapshot, error) {
+	row := db.conn.QueryRow(`SELECT `+catalogSnapshotColumns+` FROM catalog_snapshots WHERE id = ?`, id)
+	return db.catalogSnapshotWithModels(row)
+}
+
+func (db *DB) catalogSnapshotWithModels(row rowScanner) (*CatalogSnapshot, error) {
+	snap, err := scanCatalogSnapshot(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catalog snapshot: %w", err)
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT model_id, name, cost_per_1m_in, cost_per_1m_out, context_window
+		FROM catalog_snapshot_models WHERE snapshot_id = ? ORDER BY model_id
+	`, snap.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get catalog models: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		m := &CatalogModel{}
+		if err := rows.Scan(&m.ModelID, &m.Name, &m.CostPer1MIn, &m.CostPer1MOut, &m.ContextWindow); err != nil {
+			return nil, fmt.Errorf("failed to scan catalog model: %w", err)
+		}
+		snap.Models = append(snap.Models, m)
+	}
+	return snap, rows.Err()
+}
+
+// ListCatalogSnapshots retrieves snapshots without their models, newest
+// first. An empty providerID lists every provider's.
+func (db *DB) ListCatalogSnapshots(providerID string) ([]*CatalogSnapshot, error) {
+	query := `SELECT ` + catalogSnapshotColumns + ` FROM catalog_snapshots`
+	var args []interface{}
+	if providerID != "" {
+		query += ` WHERE provider_id = ?`
+		args = append(args, providerID)
+	}
+	query += ` ORDER BY id DESC`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list catalog snapshots: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var snaps []*CatalogSnapshot
+	for rows.Next() {
+		snap, err := scanCatalogSnapshot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan catalog snapshot: %w", err)
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, rows.Err()
+}
+
+// ListCatalogChanges retrieves changes in the order they were detected
+func (db *DB) ListCatalogChanges(filter CatalogChangeFilter) ([]*CatalogChange, error) {
+	query := `SELECT ` + catalogChangeColumns + ` FROM catalog_changes WHERE 1=1`
+	var args []interface{}
+	if filter.ProviderID != "" {
+		query += ` AND provider_id = ?`
+		args = append(args, filter.ProviderID)
+	}
+	if !filter.Since.IsZero() {
+		query += ` AND detected_at >= ?`
+		args = append(args, filter.Since.UTC())
+	}
+	if filter.AfterID > 0 {
+		query += ` AND id > ?`
+		args = append(args, filter.AfterID)
+	}
+	query += ` ORDER BY id`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list catalog changes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var changes []*CatalogChange
+	for rows.Next() {
+		c := &CatalogChange{}
+		if err := rows.Scan(&c.ID, &c.SnapshotID, &c.ProviderID, &c.ModelID, &c.Kind,
+			&c.OldCostPer1MIn, &c.OldCostPer1MOut, &c.NewCostPer1MIn, &c.NewCostPer1MOut, &c.DetectedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan catalog change: %w", err)
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// scanCatalogSnapshot scans a row selected with catalogSnapshotColumns
+func scanCatalogSnapshot(row rowScanner) (*CatalogSnapshot, error) {
+	snap := &CatalogSnapshot{}
+	err := row.Scan(&snap.ID, &snap.ProviderID, &snap.Source, &snap.ModelCount, &snap.TakenAt, &snap.CheckedAt)
+	if err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
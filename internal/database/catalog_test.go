@@ -0,0 +1,85 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCatalogSnapshots(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "catalog.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if snap, err := db.LatestCatalogSnapshot("openai"); err != nil || snap != nil {
+		t.Fatalf("expected no snapshot yet, got %+v, %v", snap, err)
+	}
+
+	price, window := 2.5, 128000
+	taken := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	first := &CatalogSnapshot{ProviderID: "openai", Source: CatalogSourceScheduler, TakenAt: taken, Models: []*CatalogModel{
+		{ModelID: "gpt-4o", Name: "GPT-4o", CostPer1MIn: &price, ContextWindow: &window},
+		{ModelID: "gpt-4o-mini"},
+	}}
+	if err := db.CreateCatalogSnapshot(first, nil); err != nil {
+		t.Fatalf("CreateCatalogSnapshot failed: %v", err)
+	}
+
+	newPrice := 2.0
+	second := &CatalogSnapshot{ProviderID: "openai", Source: CatalogSourceDiscovery, TakenAt: taken.Add(time.Hour), Models: []*CatalogModel{
+		{ModelID: "gpt-4o", Name: "GPT-4o", CostPer1MIn: &newPrice},
+	}}
+	changes := []*CatalogChange{
+		{ModelID: "gpt-4o", Kind: CatalogPriceChanged, OldCostPer1MIn: &price, NewCostPer1MIn: &newPrice},
+		{ModelID: "gpt-4o-mini", Kind: CatalogModelRemoved},
+	}
+	if err := db.CreateCatalogSnapshot(second, changes); err != nil {
+		t.Fatalf("CreateCatalogSnapshot failed: %v", err)
+	}
+	if changes[0].ID == 0 || changes[0].SnapshotID != second.ID || changes[0].ProviderID != "openai" {
+		t.Errorf("expected the change to be linked to the snapshot, got %+v", changes[0])
+	}
+	if err := db.TouchCatalogSnapshot(second.ID, taken.Add(2*time.Hour)); err != nil {
+		t.Fatalf("TouchCatalogSnapshot failed: %v", err)
+	}
+
+	latest, err := db.LatestCatalogSnapshot("openai")
+	if err != nil || latest == nil {
+		t.Fatalf("LatestCatalogSnapshot = %+v, %v", latest, err)
+	}
+	if latest.ID != second.ID || latest.ModelCount != 1 || !latest.CheckedAt.Equal(taken.Add(2*time.Hour)) {
+		t.Errorf("expected the touched second snapshot, got %+v", latest)
+	}
+	if len(latest.Models) != 1 || *latest.Models[0].CostPer1MIn != 2.0 || latest.Models[0].CostPer1MOut != nil {
+		t.Errorf("expected gpt-4o at the new price with no output price, got %+v", latest.Models)
+	}
+
+	stored, err := db.GetCatalogSnapshot(first.ID)
+	if err != nil || stored == nil || len(stored.Models) != 2 || *stored.Models[0].ContextWindow != 128000 || stored.Models[1].CostPer1MIn != nil {
+		t.Errorf("GetCatalogSnapshot = %+v, %v", stored, err)
+	}
+	if missing, err := db.GetCatalogSnapshot(999); err != nil || missing != nil {
+		t.Errorf("expected no snapshot 999, got %+v, %v", missing, err)
+	}
+
+	snaps, err := db.ListCatalogSnapshots("openai")
+	if err != nil || len(snaps) != 2 || snaps[0].ID != second.ID || snaps[0].Models != nil {
+		t.Errorf("expected both snapshots newest first without models, got %+v, %v", snaps, err)
+	}
+	if snaps, _ := db.ListCatalogSnapshots("anthropic"); len(snaps) != 0 {
+		t.Errorf("expected no anthropic snapshots, got %d", len(snaps))
+	}
+
+	feed, err := db.ListCatalogChanges(CatalogChangeFilter{ProviderID: "openai"})
+	if err != nil || len(feed) != 2 || feed[0].Kind != CatalogPriceChanged || *feed[0].OldCostPer1MIn != 2.5 || feed[0].NewCostPer1MOut != nil {
+		t.Fatalf("ListCatalogChanges = %+v, %v", feed, err)
+	}
+	if after, _ := db.ListCatalogChanges(CatalogChangeFilter{AfterID: feed[0].ID}); len(after) != 1 || after[0].Kind != CatalogModelRemoved {
+		t.Errorf("expected only the removal after the first change, got %+v", after)
+	}
+	if later, _ := db.ListCatalogChanges(CatalogChangeFilter{Since: taken.Add(90 * time.Minute)}); len(later) != 0 {
+		t.Errorf("expected no changes since the second snapshot, got %+v", later)
+	}
+}
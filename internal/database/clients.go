@@ -21,11 +21,12 @@ type Client struct {
 
 // ClientConfig holds client-specific configuration
 type ClientConfig struct {
-	DefaultModel     string   `json:"default_model,omitempty"`
-	ThinkingModel    string   `json:"thinking_model,omitempty"`
-	MaxOutputTokens  int      `json:"max_output_tokens,omitempty"`
-	TimeoutMs        int      `json:"timeout_ms,omitempty"`
-	ProviderPriority []string `json:"provider_priority,omitempty"`
+	DefaultModel     string   `json:"default_model,omitempty" yaml:"default_model,omitempty"`
+	ThinkingModel    string   `json:"thinking_model,omitempty" yaml:"thinking_model,omitempty"`
+	MaxOutputTokens  int      `json:"max_output_tokens,omitempty" yaml:"max_output_tokens,omitempty"`
+	TimeoutMs        int      `json:"timeout_ms,omitempty" yaml:"timeout_ms,omitempty"`
+	ProviderPriority []string `json:"provider_priority,omitempty" yaml:"provider_priority,omitempty"`
+	ContextOverflow  string   `json:"context_overflow,omitempty" yaml:"context_overflow,omitempty"` // reject, truncate or clamp
 }
 
 // ClientRepository provides CRUD operations for clients
@@ -50,12 +51,17 @@ func (r *ClientRepository) Create(c *Client) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
+	token, err := r.db.sealSecret(c.Token)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt token: %w", err)
+	}
+
 	query := `
-		INSERT INTO clients (id, name, version, token, capabilities, config, created_at, last_seen_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO clients (id, name, version, token, token_hash, capabilities, config, created_at, last_seen_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	_, err = r.db.conn.Exec(query,
-		c.ID, c.Name, c.Version, c.Token, string(capabilitiesJSON), string(configJSON),
+		c.ID, c.Name, c.Version, token, HashAPIKey(c.Token), string(capabilitiesJSON), string(configJSON),
 		c.CreatedAt, c.LastSeenAt,
 	)
 	if err != nil {
@@ -66,70 +72,63 @@ func (r *ClientRepository) Create(c *Client) error {
 
 // Get retrieves a client by ID
 func (r *ClientRepository) Get(id string) (*Client, error) {
-	query := `
-		SELECT id, name, version, token, capabilities, config, created_at, last_seen_at
-		FROM clients WHERE id = ?
-	`
-	c := &Client{}
-	var capabilitiesJSON, configJSON string
-	err := r.db.conn.QueryRow(query, id).Scan(
-		&c.ID, &c.Name, &c.Version, &c.Token,
-		&capabilitiesJSON, &configJSON,
-		&c.CreatedAt, &c.LastSeenAt,
-	)
+	query := `SELECT ` + clientColumns + ` FROM clients WHERE id = ?`
+	c, err := r.scan(r.db.conn.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get client: %w", err)
 	}
+	return c, nil
+}
 
-	if err := json.Unmarshal([]byte(capabilitiesJSON), &c.Capabilities); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal capabilities: %w", err)
+// GetByToken retrieves a client by auth token. Tokens are matched by hash;
+// rows written before token hashes existed are matched by value.
+func (r *ClientRepository) GetByToken(token string) (*Client, error) {
+	query := `SELECT ` + clientColumns + ` FROM clients
+		WHERE token_hash = ? OR (token_hash IS NULL AND token = ?)`
+	c, err := r.scan(r.db.conn.QueryRow(query, HashAPIKey(token), token))
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	if err := json.Unmarshal([]byte(configJSON), &c.Config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client by token: %w", err)
 	}
-
 	return c, nil
 }
 
-// GetByToken retrieves a client by auth token
-func (r *ClientRepository) GetByToken(token string) (*Client, error) {
-	query := `
-		SELECT id, name, version, token, capabilities, config, created_at, last_seen_at
-		FROM clients WHERE token = ?
-	`
+// clientColumns lists the clients columns in scan order
+const clientColumns = `id, name, version, token, capabilities, config, created_at, last_seen_at`
+
+// scan reads a clients row selected with clientColumns, decrypting the token
+func (r *ClientRepository) scan(row rowScanner) (*Client, error) {
 	c := &Client{}
 	var capabilitiesJSON, configJSON string
-	err := r.db.conn.QueryRow(query, token).Scan(
+	err := row.Scan(
 		&c.ID, &c.Name, &c.Version, &c.Token,
 		&capabilitiesJSON, &configJSON,
 		&c.CreatedAt, &c.LastSeenAt,
 	)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get client by token: %w", err)
+		return nil, err
 	}
 
+	if c.Token, err = r.db.openSecret(c.Token); err != nil {
+		return nil, fmt.Errorf("failed to decrypt token for client %s: %w", c.ID, err)
+	}
 	if err := json.Unmarshal([]byte(capabilitiesJSON), &c.Capabilities); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal capabilities: %w", err)
 	}
 	if err := json.Unmarshal([]byte(configJSON), &c.Config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
-
 	return c, nil
 }
 
 // List retrieves all clients
 func (r *ClientRepository) List() ([]*Client, error) {
-	query := `
-		SELECT id, name, version, token, capabilities, config, created_at, last_seen_at
-		FROM clients ORDER BY name
-	`
+	query := `SELECT ` + clientColumns + ` FROM clients ORDER BY name`
 	rows, err := r.db.conn.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list clients: %w", err)
@@ -138,24 +137,10 @@ func (r *ClientRepository) List() ([]*Client, error) {
 
 	var clients []*Client
 	for rows.Next() {
-		c := &Client{}
-		var capabilitiesJSON, configJSON string
-		err := rows.Scan(
-			&c.ID, &c.Name, &c.Version, &c.Token,
-			&capabilitiesJSON, &configJSON,
-			&c.CreatedAt, &c.LastSeenAt,
-		)
+		c, err := r.scan(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan client: %w", err)
 		}
-
-		if err := json.Unmarshal([]byte(capabilitiesJSON), &c.Capabilities); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal capabilities: %w", err)
-		}
-		if err := json.Unmarshal([]byte(configJSON), &c.Config); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal config: %w", err)
-		}
-
 		clients = append(clients, c)
 	}
 	return clients, rows.Err()
@@ -173,13 +158,18 @@ func (r *ClientRepository) Update(c *Client) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
+	token, err := r.db.sealSecret(c.Token)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt token: %w", err)
+	}
+
 	query := `
 		UPDATE clients
-		SET name = ?, version = ?, token = ?, capabilities = ?, config = ?, last_seen_at = ?
+		SET name = ?, version = ?, token = ?, token_hash = ?, capabilities = ?, config = ?, last_seen_at = ?
 		WHERE id = ?
 	`
 	result, err := r.db.conn.Exec(query,
-		c.Name, c.Version, c.Token, string(capabilitiesJSON), string(configJSON),
+		c.Name, c.Version, token, HashAPIKey(c.Token), string(capabilitiesJSON), string(configJSON),
 		c.LastSeenAt, c.ID,
 	)
 	if err != nil {
@@ -276,9 +266,9 @@ func (r *ClientRepository) Exists(id string) (bool, error) {
 
 // TokenExists checks if a token is already in use
 func (r *ClientRepository) TokenExists(token string) (bool, error) {
-	query := `SELECT 1 FROM clients WHERE token = ? LIMIT 1`
+	query := `SELECT 1 FROM clients WHERE token_hash = ? OR (token_hash IS NULL AND token = ?) LIMIT 1`
 	var exists int
-	err := r.db.conn.QueryRow(query, token).Scan(&exists)
+	err := r.db.conn.QueryRow(query, HashAPIKey(token), token).Scan(&exists)
 	if err == sql.ErrNoRows {
 		return false, nil
 	}
@@ -578,3 +578,41 @@ func TestForeignKeyConstraints(t *testing.T) {
 		t.Fatalf("Expected success, got error: %v", err)
 	}
 }
+
+// TestOpenWithOptions tests that pragmas apply to pooled connections
+func TestOpenWithOptions(t *testing.T) {
+	dbPath := "test_options.db"
+	defer os.Remove(dbPath)
+	defer os.Remove(dbPath + "-wal")
+	defer os.Remove(dbPath + "-shm")
+
+	db, err := OpenWithOptions(dbPath, Options{JournalMode: "wal", Synchronous: "normal", BusyTimeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("OpenWithOptions failed: %v", err)
+	}
+	defer db.Close()
+	db.conn.SetMaxOpenConns(4)
+
+	var journal string
+	var synchronous, timeout, foreignKeys int
+	for i := 0; i < 4; i++ {
+		conn, err := db.conn.Conn(t.Context())
+		if err != nil {
+			t.Fatalf("Conn failed: %v", err)
+		}
+		defer conn.Close()
+		conn.QueryRowContext(t.Context(), "PRAGMA journal_mode").Scan(&journal)
+		conn.QueryRowContext(t.Context(), "PRAGMA synchronous").Scan(&synchronous)
+		conn.QueryRowContext(t.Context(), "PRAGMA busy_timeout").Scan(&timeout)
+		conn.QueryRowContext(t.Context(), "PRAGMA foreign_keys").Scan(&foreignKeys)
+		if journal != "wal" || synchronous != 1 || timeout != 2000 || foreignKeys != 1 {
+			t.Errorf("connection %d: journal=%s synchronous=%d busy_timeout=%d foreign_keys=%d", i, journal, synchronous, timeout, foreignKeys)
+		}
+	}
+
+	for _, opts := range []Options{{JournalMode: "fast"}, {Synchronous: "sometimes"}, {BusyTimeout: -time.Second}} {
+		if _, err := OpenWithOptions(dbPath, opts); err == nil {
+			t.Errorf("expected error for options %+v", opts)
+		}
+	}
+}
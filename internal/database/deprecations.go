@@ -0,0 +1,66 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Sources of a model deprecation
+const (
+	DeprecationSourceSeed    = "seed"    // Built into the binary
+	DeprecationSourceScraped = "scraped" // Read from the provider's deprecations page
+)
+
+// ModelDeprecation is a provider's announced retirement of a model
+type ModelDeprecation struct {
+	ProviderID  string    `json:"provider_id"`
+	ModelID     string    `json:"model_id"`
+	RetiresAt   time.Time `json:"retires_at"`            // When requests start failing
+	Replacement string    `json:"replacement,omitempty"` // The provider's recommended replacement
+	Source      string    `json:"source"`
+	SourceURL   string    `json:"source_url,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// RecordModelDeprecation creates or replaces a model's deprecation
+func (db *DB) RecordModelDeprecation(d *ModelDeprecation) error {
+	query := `
+		INSERT INTO model_deprecations (provider_id, model_id, retires_at, replacement, source, source_url, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(provider_id, model_id) DO UPDATE SET
+			retires_at = excluded.retires_at,
+			replacement = excluded.replacement,
+			source = excluded.source,
+			source_url = excluded.source_url,
+			updated_at = excluded.updated_at
+	`
+	_, err := db.conn.Exec(query, d.ProviderID, d.ModelID, d.RetiresAt.UTC(), d.Replacement, d.Source, d.SourceURL, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to record model deprecation: %w", err)
+	}
+	return nil
+}
+
+// ListModelDeprecations retrieves every stored deprecation, soonest
+// retirement first
+func (db *DB) ListModelDeprecations() ([]*ModelDeprecation, error) {
+	query := `
+		SELECT provider_id, model_id, retires_at, replacement, source, source_url, updated_at
+		FROM model_deprecations ORDER BY retires_at, provider_id, model_id
+	`
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list model deprecations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var deprecations []*ModelDeprecation
+	for rows.Next() {
+		d := &ModelDeprecation{}
+		if err := rows.Scan(&d.ProviderID, &d.ModelID, &d.RetiresAt, &d.Replacement, &d.Source, &d.SourceURL, &d.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan model deprecation: %w", err)
+		}
+		deprecations = append(deprecations, d)
+	}
+	return deprecations, rows.Err()
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestModelDeprecations(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "deprecations.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	retires := time.Date(2026, 2, 19, 0, 0, 0, 0, time.UTC)
+	records := []*ModelDeprecation{
+		{ProviderID: "openai", ModelID: "o1-mini", RetiresAt: retires.AddDate(0, 1, 0), Source: DeprecationSourceScraped},
+		{ProviderID: "anthropic", ModelID: "claude-3-5-haiku-20241022", RetiresAt: retires, Replacement: "claude-3-haiku", Source: DeprecationSourceScraped},
+		// Recording again replaces the first
+		{ProviderID: "anthropic", ModelID: "claude-3-5-haiku-20241022", RetiresAt: retires, Replacement: "claude-haiku-4-5",
+			Source: DeprecationSourceScraped, SourceURL: "https://docs.anthropic.com/en/docs/about-claude/model-deprecations"},
+	}
+	for _, d := range records {
+		if err := db.RecordModelDeprecation(d); err != nil {
+			t.Fatalf("RecordModelDeprecation failed: %v", err)
+		}
+	}
+
+	list, err := db.ListModelDeprecations()
+	if err != nil || len(list) != 2 {
+		t.Fatalf("ListModelDeprecations = %+v, %v", list, err)
+	}
+	first := list[0]
+	if first.ModelID != "claude-3-5-haiku-20241022" || !first.RetiresAt.Equal(retires) || first.Replacement != "claude-haiku-4-5" || first.SourceURL == "" {
+		t.Errorf("expected the updated haiku deprecation first, got %+v", first)
+	}
+	if list[1].ModelID != "o1-mini" {
+		t.Errorf("expected o1-mini second, got %s", list[1].ModelID)
+	}
+}
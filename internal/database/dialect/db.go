@@ -0,0 +1,152 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DB is a *sql.DB whose query methods rewrite SQLite-style queries for its
+// dialect. Methods not overridden here (Close, Ping, SetMaxOpenConns, ...)
+// come from the embedded *sql.DB.
+type DB struct {
+	*sql.DB
+	dialect Dialect
+}
+
+// Open opens a database with the first registered driver for the dialect
+func Open(d Dialect, dsn string) (*DB, error) {
+	driver, err := driverFor(d)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{DB: conn, dialect: d}, nil
+}
+
+// Dialect returns the database's dialect
+func (db *DB) Dialect() Dialect {
+	return db.dialect
+}
+
+// Exec rebinds and executes a query without returning rows
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.DB.Exec(db.dialect.Rebind(query), args...)
+}
+
+// ExecContext rebinds and executes a query without returning rows
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.DB.ExecContext(ctx, db.dialect.Rebind(query), args...)
+}
+
+// Query rebinds and executes a query that returns rows
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DB.Query(db.dialect.Rebind(query), args...)
+}
+
+// QueryContext rebinds and executes a query that returns rows
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DB.QueryContext(ctx, db.dialect.Rebind(query), args...)
+}
+
+// QueryRow rebinds and executes a query that returns at most one row
+func (db *DB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return db.DB.QueryRow(db.dialect.Rebind(query), args...)
+}
+
+// QueryRowContext rebinds and executes a query that returns at most one row
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.DB.QueryRowContext(ctx, db.dialect.Rebind(query), args...)
+}
+
+// Prepare rebinds and prepares a statement
+func (db *DB) Prepare(query string) (*sql.Stmt, error) {
+	return db.DB.Prepare(db.dialect.Rebind(query))
+}
+
+// Begin starts a transaction
+func (db *DB) Begin() (*Tx, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, dialect: db.dialect}, nil
+}
+
+// Insert executes an INSERT and returns the new row's id column
+func (db *DB) Insert(query string, args ...interface{}) (int64, error) {
+	return insert(db.dialect, db.DB.Exec, db.DB.QueryRow, query, args)
+}
+
+// ExecScript runs a semicolon-separated DDL script, translated for the
+// dialect
+func (db *DB) ExecScript(script string) error {
+	return execScript(db.dialect, db.DB.Exec, script)
+}
+
+// Tx is a *sql.Tx whose query methods rewrite queries for its dialect
+type Tx struct {
+	*sql.Tx
+	dialect Dialect
+}
+
+// Exec rebinds and executes a query without returning rows
+func (tx *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.Exec(tx.dialect.Rebind(query), args...)
+}
+
+// Query rebinds and executes a query that returns rows
+func (tx *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.Tx.Query(tx.dialect.Rebind(query), args...)
+}
+
+// QueryRow rebinds and executes a query that returns at most one row
+func (tx *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.Tx.QueryRow(tx.dialect.Rebind(query), args...)
+}
+
+// Insert executes an INSERT and returns the new row's id column
+func (tx *Tx) Insert(query string, args ...interface{}) (int64, error) {
+	return insert(tx.dialect, tx.Tx.Exec, tx.Tx.QueryRow, query, args)
+}
+
+// ExecScript runs a semicolon-separated DDL script, translated for the
+// dialect
+func (tx *Tx) ExecScript(script string) error {
+	return execScript(tx.dialect, tx.Tx.Exec, script)
+}
+
+type execFunc func(query string, args ...interface{}) (sql.Result, error)
+type queryRowFunc func(query string, args ...interface{}) *sql.Row
+
+func insert(d Dialect, exec execFunc, queryRow queryRowFunc, query string, args []interface{}) (int64, error) {
+	query = d.Rebind(query)
+	var id int64
+	if d.ReturningID() {
+		err := queryRow(query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func execScript(d Dialect, exec execFunc, script string) error {
+	script = d.TranslateDDL(script)
+	if d.MultiStatement() {
+		_, err := exec(script)
+		return err
+	}
+	for _, stmt := range SplitStatements(script) {
+		if _, err := exec(stmt); err != nil {
+			return fmt.Errorf("%w (in %q)", err, stmt)
+		}
+	}
+	return nil
+}
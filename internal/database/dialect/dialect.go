@@ -0,0 +1,176 @@
+// Package dialect abstracts the SQL differences between SQLite and
+// PostgreSQL.
+//
+// Queries and schemas are written once in SQLite syntax with "?"
+// placeholders. A Dialect rewrites them for the target database: placeholder
+// style, column types in DDL, and how the ID of an inserted row is read.
+// Upserts use INSERT ... ON CONFLICT, which both databases accept.
+//
+// Only the SQLite driver is linked by default. PostgreSQL needs a
+// database/sql driver registered as "postgres" (github.com/lib/pq) or "pgx"
+// (github.com/jackc/pgx/v5/stdlib) by a blank import in the main package;
+// modelscan-server links pgx when built with -tags postgres.
+package dialect
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Dialect describes how a database differs from SQLite
+type Dialect interface {
+	// Name returns the dialect name used in configuration
+	Name() string
+	// Drivers returns the database/sql driver names that speak this
+	// dialect, in order of preference
+	Drivers() []string
+	// Rebind rewrites "?" placeholders into the dialect's style
+	Rebind(query string) string
+	// TranslateDDL rewrites SQLite column types and defaults
+	TranslateDDL(ddl string) string
+	// ReturningID reports whether inserted IDs are read with RETURNING
+	// rather than sql.Result.LastInsertId
+	ReturningID() bool
+	// MultiStatement reports whether one Exec may run several statements
+	MultiStatement() bool
+}
+
+// SQLite is the default, embedded dialect
+var SQLite Dialect = sqliteDialect{}
+
+// Postgres is the PostgreSQL dialect
+var Postgres Dialect = postgresDialect{}
+
+// Parse returns the dialect with the given name. An empty name is SQLite.
+func Parse(name string) (Dialect, error) {
+	switch strings.ToLower(name) {
+	case "", "sqlite", "sqlite3":
+		return SQLite, nil
+	case "postgres", "postgresql", "pg":
+		return Postgres, nil
+	default:
+		return nil, fmt.Errorf("unknown database dialect %q (want sqlite or postgres)", name)
+	}
+}
+
+// Detect picks the dialect for a connection string: postgres:// and
+// postgresql:// URLs and "host=..." keyword strings are PostgreSQL;
+// anything else is a SQLite path.
+func Detect(dsn string) Dialect {
+	lower := strings.ToLower(strings.TrimSpace(dsn))
+	if strings.HasPrefix(lower, "postgres://") || strings.HasPrefix(lower, "postgresql://") ||
+		strings.HasPrefix(lower, "host=") {
+		return Postgres
+	}
+	return SQLite
+}
+
+// driverFor returns the first registered driver for the dialect
+func driverFor(d Dialect) (string, error) {
+	registered := sql.Drivers()
+	for _, name := range d.Drivers() {
+		if slices.Contains(registered, name) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no %s driver registered (want one of %s; build modelscan-server with -tags %s)",
+		d.Name(), strings.Join(d.Drivers(), ", "), d.Name())
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string                   { return "sqlite" }
+func (sqliteDialect) Drivers() []string              { return []string{"sqlite3"} }
+func (sqliteDialect) Rebind(query string) string     { return query }
+func (sqliteDialect) TranslateDDL(ddl string) string { return ddl }
+func (sqliteDialect) ReturningID() bool              { return false }
+func (sqliteDialect) MultiStatement() bool           { return true }
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string         { return "postgres" }
+func (postgresDialect) Drivers() []string    { return []string{"postgres", "pgx"} }
+func (postgresDialect) ReturningID() bool    { return true }
+func (postgresDialect) MultiStatement() bool { return false }
+
+// Rebind numbers placeholders as $1, $2, ... skipping quoted text
+func (postgresDialect) Rebind(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	inQuote := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+			b.WriteByte(c)
+		case c == '?' && !inQuote:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+var postgresDDL = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	{regexp.MustCompile(`(?i)\bINTEGER\s+PRIMARY\s+KEY\s+AUTOINCREMENT\b`), "BIGSERIAL PRIMARY KEY"},
+	{regexp.MustCompile(`(?i)\bBOOLEAN\s+DEFAULT\s+1\b`), "BOOLEAN DEFAULT TRUE"},
+	{regexp.MustCompile(`(?i)\bBOOLEAN\s+DEFAULT\s+0\b`), "BOOLEAN DEFAULT FALSE"},
+	{regexp.MustCompile(`(?i)\bDATETIME\b`), "TIMESTAMP"},
+	{regexp.MustCompile(`(?i)\bREAL\b`), "DOUBLE PRECISION"},
+	{regexp.MustCompile(`(?i)\bBLOB\b`), "BYTEA"},
+}
+
+// TranslateDDL maps SQLite types to their PostgreSQL equivalents
+func (postgresDialect) TranslateDDL(ddl string) string {
+	for _, r := range postgresDDL {
+		ddl = r.pattern.ReplaceAllString(ddl, r.replace)
+	}
+	return ddl
+}
+
+// SplitStatements splits a script on semicolons outside quotes and
+// comments, dropping empty statements and comment-only lines
+func SplitStatements(script string) []string {
+	var stmts []string
+	var b strings.Builder
+	inQuote := false
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+			b.WriteByte(c)
+		case !inQuote && c == '-' && i+1 < len(script) && script[i+1] == '-':
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			b.WriteByte('\n')
+		case !inQuote && c == ';':
+			if s := strings.TrimSpace(b.String()); s != "" {
+				stmts = append(stmts, s)
+			}
+			b.Reset()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if s := strings.TrimSpace(b.String()); s != "" {
+		stmts = append(stmts, s)
+	}
+	return stmts
+}
@@ -0,0 +1,221 @@
+package dialect
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestParseAndDetect(t *testing.T) {
+	for name, want := range map[string]Dialect{"": SQLite, "sqlite3": SQLite, "PostgreSQL": Postgres, "pg": Postgres} {
+		if got, err := Parse(name); err != nil || got != want {
+			t.Errorf("Parse(%q) = %v, %v; want %v", name, got, err, want.Name())
+		}
+	}
+	if _, err := Parse("mysql"); err == nil {
+		t.Error("expected error for unknown dialect")
+	}
+
+	for dsn, want := range map[string]Dialect{
+		"modelscan.db":                             SQLite,
+		"file:test.db?cache=shared":                SQLite,
+		"postgres://user:pass@db:5432/modelscan":   Postgres,
+		"postgresql://db/modelscan":                Postgres,
+		"host=db dbname=modelscan sslmode=disable": Postgres,
+	} {
+		if got := Detect(dsn); got != want {
+			t.Errorf("Detect(%q) = %s, want %s", dsn, got.Name(), want.Name())
+		}
+	}
+}
+
+func TestRebind(t *testing.T) {
+	query := "SELECT * FROM t WHERE a = ? AND b = '?' AND c IN (?, ?)"
+	if got := SQLite.Rebind(query); got != query {
+		t.Errorf("SQLite.Rebind() changed the query: %s", got)
+	}
+	want := "SELECT * FROM t WHERE a = $1 AND b = '?' AND c IN ($2, $3)"
+	if got := Postgres.Rebind(query); got != want {
+		t.Errorf("Postgres.Rebind() = %s, want %s", got, want)
+	}
+}
+
+func TestTranslateDDL(t *testing.T) {
+	ddl := `CREATE TABLE t (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		enabled BOOLEAN DEFAULT 1,
+		stream BOOLEAN DEFAULT 0,
+		cost REAL,
+		seen DATETIME DEFAULT CURRENT_TIMESTAMP,
+		data BLOB
+	)`
+	got := Postgres.TranslateDDL(ddl)
+	for _, want := range []string{"id BIGSERIAL PRIMARY KEY", "BOOLEAN DEFAULT TRUE", "BOOLEAN DEFAULT FALSE", "cost DOUBLE PRECISION", "seen TIMESTAMP DEFAULT", "data BYTEA"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("translated DDL missing %q:\n%s", want, got)
+		}
+	}
+	if SQLite.TranslateDDL(ddl) != ddl {
+		t.Error("SQLite.TranslateDDL() changed the DDL")
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `
+	-- Table; with a semicolon in a comment
+	CREATE TABLE a (x TEXT DEFAULT 'a;b');
+
+	CREATE INDEX idx_a ON a(x);
+	`
+	want := []string{"CREATE TABLE a (x TEXT DEFAULT 'a;b')", "CREATE INDEX idx_a ON a(x)"}
+	if got := SplitStatements(script); !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitStatements() = %q, want %q", got, want)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	db, err := Open(SQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	if err := db.ExecScript(`CREATE TABLE t (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT); CREATE INDEX idx_t ON t(name);`); err != nil {
+		t.Fatalf("ExecScript() error = %v", err)
+	}
+	id, err := db.Insert("INSERT INTO t (name) VALUES (?)", "a")
+	if err != nil || id != 1 {
+		t.Fatalf("Insert() = %d, %v", id, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if id, err := tx.Insert("INSERT INTO t (name) VALUES (?)", "b"); err != nil || id != 2 {
+		t.Fatalf("Tx.Insert() = %d, %v", id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	// Without a registered driver PostgreSQL fails with a clear error
+	if _, err := Open(Postgres, "postgres://localhost/test"); err == nil || !strings.Contains(err.Error(), "no postgres driver registered") {
+		t.Errorf("expected missing driver error, got %v", err)
+	}
+}
+
+// stubDriver records the statements it is sent and answers every query
+// with one row holding 7
+type stubDriver struct {
+	mu    sync.Mutex
+	stmts []string
+}
+
+func (d *stubDriver) Open(string) (driver.Conn, error) { return stubConn{d}, nil }
+
+func (d *stubDriver) sent() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.stmts...)
+}
+
+type stubConn struct{ d *stubDriver }
+
+func (c stubConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.mu.Lock()
+	c.d.stmts = append(c.d.stmts, query)
+	c.d.mu.Unlock()
+	return stubStmt{}, nil
+}
+func (c stubConn) Close() error              { return nil }
+func (c stubConn) Begin() (driver.Tx, error) { return c, nil }
+func (c stubConn) Commit() error             { return nil }
+func (c stubConn) Rollback() error           { return nil }
+
+type stubStmt struct{}
+
+func (stubStmt) Close() error                               { return nil }
+func (stubStmt) NumInput() int                              { return -1 }
+func (stubStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (stubStmt) Query([]driver.Value) (driver.Rows, error)  { return &stubRows{}, nil }
+
+type stubRows struct{ done bool }
+
+func (r *stubRows) Columns() []string { return []string{"id"} }
+func (r *stubRows) Close() error      { return nil }
+func (r *stubRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(7)
+	return nil
+}
+
+var pgStub = &stubDriver{}
+
+func init() {
+	sql.Register("pgstub", pgStub)
+}
+
+// stubPostgres is the PostgreSQL dialect served by the stub driver
+type stubPostgres struct{ Dialect }
+
+func (stubPostgres) Drivers() []string { return []string{"pgstub"} }
+
+// TestOpen_PostgresStubDriver checks the SQL a PostgreSQL driver receives
+// through DB and Tx
+func TestOpen_PostgresStubDriver(t *testing.T) {
+	pgStub.mu.Lock()
+	pgStub.stmts = nil
+	pgStub.mu.Unlock()
+
+	db, err := Open(stubPostgres{Postgres}, "postgres://localhost/test")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	if err := db.ExecScript(`CREATE TABLE t (id INTEGER PRIMARY KEY AUTOINCREMENT, at DATETIME, ok BOOLEAN DEFAULT 1); CREATE INDEX idx_t ON t(at);`); err != nil {
+		t.Fatalf("ExecScript() error = %v", err)
+	}
+	if _, err := db.Exec("UPDATE t SET at = ? WHERE note = '?' AND id = ?", "now", 1); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if id, err := db.Insert("INSERT INTO t (at) VALUES (?)", "now"); err != nil || id != 7 {
+		t.Fatalf("Insert() = %d, %v", id, err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if id, err := tx.Insert("INSERT INTO t (at, ok) VALUES (?, ?)", "now", true); err != nil || id != 7 {
+		t.Fatalf("Tx.Insert() = %d, %v", id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	want := []string{
+		"CREATE TABLE t (id BIGSERIAL PRIMARY KEY, at TIMESTAMP, ok BOOLEAN DEFAULT TRUE)",
+		"CREATE INDEX idx_t ON t(at)",
+		"UPDATE t SET at = $1 WHERE note = '?' AND id = $2",
+		"INSERT INTO t (at) VALUES ($1) RETURNING id",
+		"INSERT INTO t (at, ok) VALUES ($1, $2) RETURNING id",
+	}
+	var got []string
+	for _, stmt := range pgStub.sent() {
+		got = append(got, strings.TrimSpace(stmt))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("driver received:\n%q\nwant:\n%q", got, want)
+	}
+}
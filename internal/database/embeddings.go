@@ -0,0 +1,89 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// embeddingLookupBatch bounds the keys read in one query
+const embeddingLookupBatch = 500
+
+// EmbeddingRepository stores cached embedding vectors by key
+type EmbeddingRepository struct {
+	db *DB
+}
+
+// NewEmbeddingRepository creates a new EmbeddingRepository
+func NewEmbeddingRepository(db *DB) *EmbeddingRepository {
+	return &EmbeddingRepository{db: db}
+}
+
+// Get returns the stored vectors among keys
+func (r *EmbeddingRepository) Get(keys []string) (map[string][]byte, error) {
+	vectors := make(map[string][]byte, len(keys))
+	for start := 0; start < len(keys); start += embeddingLookupBatch {
+		batch := keys[start:min(start+embeddingLookupBatch, len(keys))]
+		args := make([]interface{}, len(batch))
+		for i, key := range batch {
+			args[i] = key
+		}
+		query := `SELECT cache_key, vector FROM embedding_cache WHERE cache_key IN (?` + strings.Repeat(", ?", len(batch)-1) + `)`
+		rows, err := r.db.conn.Query(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get embeddings: %w", err)
+		}
+		for rows.Next() {
+			var key string
+			var vector []byte
+			if err := rows.Scan(&key, &vector); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("failed to scan embedding: %w", err)
+			}
+			vectors[key] = vector
+		}
+		err = rows.Err()
+		_ = rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return vectors, nil
+}
+
+// Put stores vectors in one transaction. A key already stored keeps its
+// vector.
+func (r *EmbeddingRepository) Put(vectors map[string][]byte) error {
+	if len(vectors) == 0 {
+		return nil
+	}
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to store embeddings: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for key, vector := range vectors {
+		if _, err := tx.Exec(`INSERT INTO embedding_cache (cache_key, vector) VALUES (?, ?) ON CONFLICT(cache_key) DO NOTHING`, key, vector); err != nil {
+			return fmt.Errorf("failed to store embedding: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Count returns the number of stored vectors
+func (r *EmbeddingRepository) Count() (int, error) {
+	var n int
+	if err := r.db.conn.QueryRow(`SELECT COUNT(*) FROM embedding_cache`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count embeddings: %w", err)
+	}
+	return n, nil
+}
+
+// Purge deletes every stored vector, returning how many there were
+func (r *EmbeddingRepository) Purge() (int64, error) {
+	result, err := r.db.conn.Exec(`DELETE FROM embedding_cache`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge embeddings: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmbeddingRepository(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "embeddings.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewEmbeddingRepository(db)
+	if err := repo.Put(map[string][]byte{"a": {1, 2, 3, 4}, "b": {5, 6, 7, 8}}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	// A stored key keeps its vector
+	if err := repo.Put(map[string][]byte{"a": {9, 9, 9, 9}}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	vectors, err := repo.Get([]string{"a", "c"})
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(vectors) != 1 || string(vectors["a"]) != string([]byte{1, 2, 3, 4}) {
+		t.Errorf("unexpected vectors: %v", vectors)
+	}
+
+	// Lookups larger than a batch are split
+	keys := make([]string, embeddingLookupBatch+10)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("k%d", i)
+	}
+	keys[len(keys)-1] = "b"
+	if vectors, err := repo.Get(keys); err != nil || len(vectors) != 1 || vectors["b"] == nil {
+		t.Errorf("expected b found past the first batch, got %v: %v", vectors, err)
+	}
+
+	if n, err := repo.Count(); err != nil || n != 2 {
+		t.Errorf("expected 2 vectors, got %d: %v", n, err)
+	}
+	if n, err := repo.Purge(); err != nil || n != 2 {
+		t.Errorf("expected 2 vectors purged, got %d: %v", n, err)
+	}
+	if n, _ := repo.Count(); n != 0 {
+		t.Errorf("expected no vectors left, got %d", n)
+	}
+}
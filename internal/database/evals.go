@@ -0,0 +1,177 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
+)
+
+// EvalRun is one run of an evaluation suite against a model
+type EvalRun struct {
+	ID           int           `json:"id"`
+	Suite        string        `json:"suite"`
+	Model        string        `json:"model"`
+	Provider     string        `json:"provider,omitempty"` // Forced provider, empty for the proxy's routing
+	StartedAt    time.Time     `json:"started_at"`
+	DurationMs   int64         `json:"duration_ms"`
+	Cases        int           `json:"cases"`
+	Passed       int           `json:"passed"`
+	Errors       int           `json:"errors"` // Cases whose request failed
+	Score        float64       `json:"score"`  // Fraction of cases passed
+	AvgLatencyMs float64       `json:"avg_latency_ms"`
+	TokensIn     int           `json:"tokens_in"`
+	TokensOut    int           `json:"tokens_out"`
+	Cost         float64       `json:"cost"`
+	JudgeModel   string        `json:"judge_model,omitempty"`
+	Judged       int           `json:"judged,omitempty"`      // Cases scored by the judge
+	JudgeScore   float64       `json:"judge_score,omitempty"` // Mean judge score of the judged cases, 0 to 1
+	Results      []*EvalResult `json:"results,omitempty"`
+}
+
+// EvalResult is the outcome of one case in a run
+type EvalResult struct {
+	ID          int      `json:"id"`
+	RunID       int      `json:"run_id"`
+	Case        string   `json:"case"`
+	Passed      bool     `json:"passed"`
+	Output      string   `json:"output"`
+	Error       string   `json:"error,omitempty"` // Request failure or the check that failed
+	LatencyMs   int64    `json:"latency_ms"`
+	TokensIn    int      `json:"tokens_in"`
+	TokensOut   int      `json:"tokens_out"`
+	Cost        float64  `json:"cost"`
+	JudgeScore  *float64 `json:"judge_score,omitempty"` // 0 to 1, nil when the case was not judged
+	JudgeReason string   `json:"judge_reason,omitempty"`
+}
+
+// EvalRunFilter selects runs to list. Empty fields match everything.
+type EvalRunFilter struct {
+	Suite string
+	Model string
+	Limit int
+}
+
+const evalRunColumns = `id, suite, model, provider, started_at, duration_ms, cases, passed, errors, score, avg_latency_ms, tokens_in, tokens_out, cost, judge_model, judged, judge_score`
+
+const evalResultColumns = `id, run_id, case_name, passed, output, error, latency_ms, tokens_in, tokens_out, cost, judge_score, judge_reason`
+
+// EvalRepository stores evaluation runs and their case results
+type EvalRepository struct {
+	db *DB
+}
+
+// NewEvalRepository creates a new EvalRepository
+func NewEvalRepository(db *DB) *EvalRepository {
+	return &EvalRepository{db: db}
+}
+
+// Create stores a run and its results in one transaction
+func (r *EvalRepository) Create(run *EvalRun) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to create eval run: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		INSERT INTO eval_runs (suite, model, provider, started_at, duration_ms, cases, passed, errors, score, avg_latency_ms, tokens_in, tokens_out, cost,
+			judge_model, judged, judge_score)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	id, err := tx.Insert(query, run.Suite, run.Model, run.Provider, run.StartedAt, run.DurationMs,
+		run.Cases, run.Passed, run.Errors, run.Score, run.AvgLatencyMs, run.TokensIn, run.TokensOut, run.Cost,
+		run.JudgeModel, run.Judged, run.JudgeScore)
+	if err != nil {
+		return fmt.Errorf("failed to create eval run: %w", err)
+	}
+	run.ID = int(id)
+
+	for _, res := range run.Results {
+		res.RunID = run.ID
+		id, err := tx.Insert(`
+			INSERT INTO eval_results (run_id, case_name, passed, output, error, latency_ms, tokens_in, tokens_out, cost, judge_score, judge_reason)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, res.RunID, res.Case, res.Passed, res.Output, redact.String(res.Error), res.LatencyMs, res.TokensIn, res.TokensOut, res.Cost,
+			res.JudgeScore, res.JudgeReason)
+		if err != nil {
+			return fmt.Errorf("failed to create eval result: %w", err)
+		}
+		res.ID = int(id)
+	}
+	return tx.Commit()
+}
+
+// Get retrieves a run with its results in case order, or nil if it does
+// not exist
+func (r *EvalRepository) Get(id int) (*EvalRun, error) {
+	run, err := scanEvalRun(r.db.conn.QueryRow(`SELECT `+evalRunColumns+` FROM eval_runs WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get eval run: %w", err)
+	}
+
+	rows, err := r.db.conn.Query(`SELECT `+evalResultColumns+` FROM eval_results WHERE run_id = ? ORDER BY id ASC`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get eval results: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		res := &EvalResult{}
+		if err := rows.Scan(&res.ID, &res.RunID, &res.Case, &res.Passed, &res.Output, &res.Error,
+			&res.LatencyMs, &res.TokensIn, &res.TokensOut, &res.Cost, &res.JudgeScore, &res.JudgeReason); err != nil {
+			return nil, fmt.Errorf("failed to scan eval result: %w", err)
+		}
+		run.Results = append(run.Results, res)
+	}
+	return run, rows.Err()
+}
+
+// List retrieves runs without their results, newest first
+func (r *EvalRepository) List(filter EvalRunFilter) ([]*EvalRun, error) {
+	query := `SELECT ` + evalRunColumns + ` FROM eval_runs WHERE 1=1`
+	var args []interface{}
+	if filter.Suite != "" {
+		query += ` AND suite = ?`
+		args = append(args, filter.Suite)
+	}
+	if filter.Model != "" {
+		query += ` AND model = ?`
+		args = append(args, filter.Model)
+	}
+	query += ` ORDER BY started_at DESC, id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list eval runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var runs []*EvalRun
+	for rows.Next() {
+		run, err := scanEvalRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan eval run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// scanEvalRun scans a row selected with evalRunColumns
+func scanEvalRun(row rowScanner) (*EvalRun, error) {
+	run := &EvalRun{}
+	if err := row.Scan(&run.ID, &run.Suite, &run.Model, &run.Provider, &run.StartedAt, &run.DurationMs,
+		&run.Cases, &run.Passed, &run.Errors, &run.Score, &run.AvgLatencyMs, &run.TokensIn, &run.TokensOut, &run.Cost,
+		&run.JudgeModel, &run.Judged, &run.JudgeScore); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
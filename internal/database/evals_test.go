@@ -0,0 +1,65 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEvalRepository(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "evals.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewEvalRepository(db)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	first := &EvalRun{Suite: "smoke", Model: "gpt-4o", StartedAt: start, Cases: 2, Passed: 2, Score: 1,
+		Results: []*EvalResult{
+			{Case: "capital", Passed: true, Output: "Paris", LatencyMs: 300, TokensIn: 12, TokensOut: 1},
+			{Case: "json", Passed: true, Output: `{"ok":true}`, LatencyMs: 500},
+		}}
+	judged := 0.8
+	first.JudgeModel, first.Judged, first.JudgeScore = "judge", 1, judged
+	first.Results[0].JudgeScore, first.Results[0].JudgeReason = &judged, "Correct and terse."
+	if err := repo.Create(first); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if first.ID == 0 || first.Results[0].RunID != first.ID || first.Results[1].ID == 0 {
+		t.Errorf("IDs not assigned: %+v", first)
+	}
+	second := &EvalRun{Suite: "smoke", Model: "gpt-4o", StartedAt: start.Add(time.Hour), Cases: 2, Passed: 1, Score: 0.5}
+	other := &EvalRun{Suite: "smoke", Model: "claude-3-5-sonnet", StartedAt: start.Add(2 * time.Hour)}
+	for _, run := range []*EvalRun{second, other} {
+		if err := repo.Create(run); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	got, err := repo.Get(first.ID)
+	if err != nil || got == nil {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+	if len(got.Results) != 2 || got.Results[0].Case != "capital" || !got.Results[0].Passed || got.Results[1].Output != `{"ok":true}` {
+		t.Errorf("unexpected results: %+v", got.Results)
+	}
+	if got.JudgeModel != "judge" || got.JudgeScore != 0.8 || got.Results[0].JudgeScore == nil || *got.Results[0].JudgeScore != 0.8 || got.Results[1].JudgeScore != nil {
+		t.Errorf("judge scores not stored: %+v %+v", got, got.Results)
+	}
+	if missing, err := repo.Get(999); missing != nil || err != nil {
+		t.Errorf("expected nil for a missing run, got %+v, %v", missing, err)
+	}
+
+	runs, err := repo.List(EvalRunFilter{Suite: "smoke", Model: "gpt-4o", Limit: 2})
+	if err != nil || len(runs) != 2 || runs[0].ID != second.ID || runs[1].ID != first.ID {
+		t.Errorf("expected gpt-4o runs newest first, got %+v, %v", runs, err)
+	}
+	if runs[0].Results != nil {
+		t.Error("List should not load results")
+	}
+	if all, err := repo.List(EvalRunFilter{}); err != nil || len(all) != 3 {
+		t.Errorf("List() = %d runs, %v", len(all), err)
+	}
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/export"
+)
+
+// ExportFilter narrows time-based datasets; models ignore it
+type ExportFilter struct {
+	Since *time.Time
+	Until *time.Time
+}
+
+// exportQuery describes a dataset: its query and the column the time
+// filter applies to, if any
+type exportQuery struct {
+	query   string
+	timeCol string
+	groupBy string
+	orderBy string
+}
+
+var exportQueries = map[string]exportQuery{
+	"models": {
+		query: `SELECT m.id, f.provider_id, m.family_id, m.name, m.cost_per_1m_in, m.cost_per_1m_out,
+			m.cost_per_1m_reasoning, m.context_window, m.max_tokens, m.status, m.last_tested
+			FROM models m LEFT JOIN model_families f ON f.id = m.family_id
+			WHERE 1=1`,
+		orderBy: "m.id",
+	},
+	"usage": {
+		query: `SELECT id, model_id, api_key_id, timestamp, tokens_in, tokens_out, tokens_reasoning,
+			requests, cost, latency_ms, success, error
+			FROM usage_tracking WHERE 1=1`,
+		timeCol: "timestamp",
+		orderBy: "id",
+	},
+	"costs": {
+		query: `SELECT DATE(timestamp) AS day, model_id, SUM(requests) AS requests,
+			SUM(tokens_in) AS tokens_in, SUM(tokens_out) AS tokens_out,
+			SUM(tokens_reasoning) AS tokens_reasoning, SUM(cost) AS cost
+			FROM usage_tracking WHERE 1=1`,
+		timeCol: "timestamp",
+		groupBy: "DATE(timestamp), model_id",
+		orderBy: "day, model_id",
+	},
+	"request_logs": {
+		query: `SELECT id, client_id, provider, model, endpoint, request_tokens, response_tokens,
+			latency_ms, status_code, error_message, created_at
+			FROM request_logs WHERE 1=1`,
+		timeCol: "created_at",
+		orderBy: "id",
+	},
+}
+
+// ExportDatasets lists the datasets Export accepts
+func (db *DB) ExportDatasets() []string {
+	return []string{"models", "usage", "costs", "request_logs"}
+}
+
+// Export streams a dataset to w row by row and returns the number of rows
+// written. The caller closes w.
+func (db *DB) Export(dataset string, filter ExportFilter, w export.Writer) (int, error) {
+	q, ok := exportQueries[dataset]
+	if !ok {
+		return 0, fmt.Errorf("unknown export dataset %q", dataset)
+	}
+
+	query := q.query
+	var args []interface{}
+	if q.timeCol != "" {
+		if filter.Since != nil {
+			query += " AND " + q.timeCol + " >= ?"
+			args = append(args, *filter.Since)
+		}
+		if filter.Until != nil {
+			query += " AND " + q.timeCol + " < ?"
+			args = append(args, *filter.Until)
+		}
+	}
+	if q.groupBy != "" {
+		query += " GROUP BY " + q.groupBy
+	}
+	query += " ORDER BY " + q.orderBy
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query %s: %w", dataset, err)
+	}
+	defer rows.Close()
+
+	n, err := export.WriteRows(w, rows)
+	if err != nil {
+		return n, fmt.Errorf("failed to export %s: %w", dataset, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"bytes"
+	"encoding/csv"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/export"
+)
+
+func TestExport(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "export.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	db.CreateProvider(&Provider{ID: "openai", Name: "OpenAI", BaseURL: "https://api.openai.com", AuthMethod: "bearer", PricingModel: "usage", Status: "online"})
+	db.CreateModelFamily(&ModelFamily{ID: "gpt", ProviderID: "openai", Name: "GPT"})
+	db.CreateModel(&Model{ID: "gpt-4", FamilyID: "gpt", Name: "GPT-4", Status: "online"})
+
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, ts := range []time.Time{day, day.Add(time.Hour), day.AddDate(0, 0, 1)} {
+		db.RecordUsage(&UsageRecord{ModelID: "gpt-4", Timestamp: ts, TokensIn: 100, TokensOut: 10 * (i + 1), Requests: 1, Cost: 0.5, Success: true})
+	}
+
+	exportCSV := func(dataset string, filter ExportFilter) [][]string {
+		t.Helper()
+		var buf bytes.Buffer
+		w, _ := export.NewWriter(export.FormatCSV, &buf)
+		if _, err := db.Export(dataset, filter, w); err != nil {
+			t.Fatalf("Export(%s) failed: %v", dataset, err)
+		}
+		w.Close()
+		records, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("invalid CSV: %v", err)
+		}
+		return records
+	}
+
+	models := exportCSV("models", ExportFilter{})
+	if len(models) != 2 || models[1][0] != "gpt-4" || models[1][1] != "openai" {
+		t.Errorf("unexpected models export: %q", models)
+	}
+
+	costs := exportCSV("costs", ExportFilter{})
+	if len(costs) != 3 {
+		t.Fatalf("expected header and 2 days, got %q", costs)
+	}
+	if costs[0][0] != "day" || costs[1][2] != "2" || costs[1][6] != "1" || costs[2][2] != "1" {
+		t.Errorf("unexpected costs export: %q", costs)
+	}
+
+	since := day.AddDate(0, 0, 1)
+	if usage := exportCSV("usage", ExportFilter{Since: &since}); len(usage) != 2 {
+		t.Errorf("expected 1 usage row since %s, got %q", since, usage)
+	}
+
+	var buf bytes.Buffer
+	w, _ := export.NewWriter(export.FormatJSONL, &buf)
+	if _, err := db.Export("secrets", ExportFilter{}, w); err == nil {
+		t.Error("expected error for unknown dataset")
+	}
+}
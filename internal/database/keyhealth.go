@@ -0,0 +1,101 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
+)
+
+// KeyProbe is the outcome of one lightweight request made with an API key
+type KeyProbe struct {
+	KeyID          int
+	At             time.Time
+	OK             bool
+	Status         int    // HTTP status; zero when no response arrived
+	Error          string // Empty when OK
+	QuotaRemaining *int64 // Requests left in the provider's window, when reported
+	QuotaLimit     *int64
+}
+
+// KeyHealth is the latest probe state of an API key
+type KeyHealth struct {
+	KeyID          int        `json:"key_id"`
+	CheckedAt      time.Time  `json:"checked_at"`
+	LastSuccessAt  *time.Time `json:"last_success_at,omitempty"`
+	Status         int        `json:"status"`
+	Error          string     `json:"error,omitempty"`
+	Failures       int        `json:"failures"` // Consecutive failed probes
+	QuotaRemaining *int64     `json:"quota_remaining,omitempty"`
+	QuotaLimit     *int64     `json:"quota_limit,omitempty"`
+}
+
+// RecordKeyProbe stores a probe outcome as the key's health, keeping the
+// last success time across failures and counting consecutive failures
+func (db *DB) RecordKeyProbe(p *KeyProbe) error {
+	var lastSuccess *time.Time
+	failures := 1
+	if p.OK {
+		at := p.At.UTC()
+		lastSuccess = &at
+		failures = 0
+	}
+	query := `
+		INSERT INTO key_health (key_id, checked_at, last_success_at, status, error, failures, quota_remaining, quota_limit)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(key_id) DO UPDATE SET
+			checked_at = excluded.checked_at,
+			last_success_at = COALESCE(excluded.last_success_at, key_health.last_success_at),
+			status = excluded.status,
+			error = excluded.error,
+			failures = CASE WHEN excluded.failures = 0 THEN 0 ELSE key_health.failures + 1 END,
+			quota_remaining = excluded.quota_remaining,
+			quota_limit = excluded.quota_limit
+	`
+	_, err := db.conn.Exec(query, p.KeyID, p.At.UTC(), lastSuccess, p.Status, redact.String(p.Error), failures, p.QuotaRemaining, p.QuotaLimit)
+	if err != nil {
+		return fmt.Errorf("failed to record key probe: %w", err)
+	}
+	return nil
+}
+
+// ListKeyHealth retrieves the health of every probed key
+func (db *DB) ListKeyHealth() ([]*KeyHealth, error) {
+	query := `
+		SELECT key_id, checked_at, last_success_at, status, error, failures, quota_remaining, quota_limit
+		FROM key_health ORDER BY key_id
+	`
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list key health: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var health []*KeyHealth
+	for rows.Next() {
+		h := &KeyHealth{}
+		if err := rows.Scan(&h.KeyID, &h.CheckedAt, &h.LastSuccessAt, &h.Status, &h.Error, &h.Failures, &h.QuotaRemaining, &h.QuotaLimit); err != nil {
+			return nil, fmt.Errorf("failed to scan key health: %w", err)
+		}
+		health = append(health, h)
+	}
+	return health, rows.Err()
+}
+
+// SetAPIKeyExpiry records when a key expires; nil clears it. Keys past
+// their expiry are no longer listed as active.
+func (db *DB) SetAPIKeyExpiry(id int, expiresAt *time.Time) error {
+	var at *time.Time
+	if expiresAt != nil {
+		utc := expiresAt.UTC()
+		at = &utc
+	}
+	result, err := db.conn.Exec(`UPDATE api_keys SET expires_at = ? WHERE id = ?`, at, id)
+	if err != nil {
+		return fmt.Errorf("failed to set key expiry: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("key %d not found", id)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestKeyHealth(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "keyhealth.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.CreateProvider(&Provider{ID: "openai", Name: "OpenAI", BaseURL: "https://api.openai.com", Status: "online"}); err != nil {
+		t.Fatalf("CreateProvider failed: %v", err)
+	}
+	key, err := db.CreateAPIKey("openai", "sk-health-1234567890")
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+
+	t0 := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	remaining, limit := int64(480), int64(500)
+	probes := []*KeyProbe{
+		{KeyID: key.ID, At: t0, OK: true, Status: 200, QuotaRemaining: &remaining, QuotaLimit: &limit},
+		{KeyID: key.ID, At: t0.Add(time.Hour), Status: 500, Error: "500: upstream error"},
+		{KeyID: key.ID, At: t0.Add(2 * time.Hour), Error: "connection refused"},
+	}
+	for _, p := range probes {
+		if err := db.RecordKeyProbe(p); err != nil {
+			t.Fatalf("RecordKeyProbe failed: %v", err)
+		}
+	}
+
+	health, err := db.ListKeyHealth()
+	if err != nil || len(health) != 1 {
+		t.Fatalf("ListKeyHealth = %+v, %v", health, err)
+	}
+	h := health[0]
+	if h.Failures != 2 || h.Status != 0 || h.Error != "connection refused" || !h.CheckedAt.Equal(t0.Add(2*time.Hour)) {
+		t.Errorf("unexpected health after failures: %+v", h)
+	}
+	if h.LastSuccessAt == nil || !h.LastSuccessAt.Equal(t0) {
+		t.Errorf("last success not kept: %v", h.LastSuccessAt)
+	}
+	if h.QuotaRemaining != nil {
+		t.Errorf("quota from an earlier probe kept: %d", *h.QuotaRemaining)
+	}
+
+	// A success resets the failure count
+	if err := db.RecordKeyProbe(&KeyProbe{KeyID: key.ID, At: t0.Add(3 * time.Hour), OK: true, Status: 200}); err != nil {
+		t.Fatalf("RecordKeyProbe failed: %v", err)
+	}
+	if health, _ := db.ListKeyHealth(); health[0].Failures != 0 || !health[0].LastSuccessAt.Equal(t0.Add(3*time.Hour)) {
+		t.Errorf("success not recorded: %+v", health[0])
+	}
+
+	// Expired keys drop out of rotation
+	past := time.Now().Add(-time.Hour)
+	if err := db.SetAPIKeyExpiry(key.ID, &past); err != nil {
+		t.Fatalf("SetAPIKeyExpiry failed: %v", err)
+	}
+	if got, _ := db.GetAPIKey(key.ID); got.ExpiresAt == nil || got.ExpiresAt.Sub(past).Abs() > time.Second {
+		t.Errorf("expiry = %v, want %v", got.ExpiresAt, past)
+	}
+	if active, _ := db.ListActiveAPIKeys("openai"); len(active) != 0 {
+		t.Errorf("expired key still active: %d keys", len(active))
+	}
+	if err := db.SetAPIKeyExpiry(key.ID, nil); err != nil {
+		t.Fatalf("SetAPIKeyExpiry(nil) failed: %v", err)
+	}
+	if active, _ := db.ListActiveAPIKeys("openai"); len(active) != 1 {
+		t.Errorf("key not active after clearing expiry: %d keys", len(active))
+	}
+	if err := db.SetAPIKeyExpiry(key.ID+100, nil); err == nil {
+		t.Error("expected error for an unknown key")
+	}
+
+	// Health goes with the key
+	if err := db.DeleteAPIKey(key.ID); err != nil {
+		t.Fatalf("DeleteAPIKey failed: %v", err)
+	}
+	if health, _ := db.ListKeyHealth(); len(health) != 0 {
+		t.Errorf("health kept after key deletion: %+v", health)
+	}
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// LatencyHour holds one provider's time to first byte over one UTC hour.
+// Samples are successful requests, whose latency is summed; failed
+// requests only count as Errors.
+type LatencyHour struct {
+	Provider       string    `json:"provider"`
+	HourStart      time.Time `json:"hour_start"`
+	Samples        int64     `json:"samples"`
+	Errors         int64     `json:"errors"`
+	LatencyMsTotal int64     `json:"latency_ms_total"`
+	LatencyMsMax   int64     `json:"latency_ms_max"`
+}
+
+const latencyHourColumns = `provider, hour_start, samples, errors, latency_ms_total, latency_ms_max`
+
+// LatencyRepository stores hourly provider latency rollups
+type LatencyRepository struct {
+	db *DB
+}
+
+// NewLatencyRepository creates a new LatencyRepository
+func NewLatencyRepository(db *DB) *LatencyRepository {
+	return &LatencyRepository{db: db}
+}
+
+// Add adds the counts of each hour to the stored rollups in one
+// transaction, creating rollups that do not exist yet
+func (r *LatencyRepository) Add(hours []*LatencyHour) error {
+	if len(hours) == 0 {
+		return nil
+	}
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to record latency hours: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		INSERT INTO provider_latency_hourly (` + latencyHourColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(provider, hour_start) DO UPDATE SET
+			samples = provider_latency_hourly.samples + excluded.samples,
+			errors = provider_latency_hourly.errors + excluded.errors,
+			latency_ms_total = provider_latency_hourly.latency_ms_total + excluded.latency_ms_total,
+			latency_ms_max = CASE WHEN excluded.latency_ms_max > provider_latency_hourly.latency_ms_max
+				THEN excluded.latency_ms_max ELSE provider_latency_hourly.latency_ms_max END
+	`
+	for _, h := range hours {
+		if _, err := tx.Exec(query, h.Provider, h.HourStart.UTC(), h.Samples, h.Errors, h.LatencyMsTotal, h.LatencyMsMax); err != nil {
+			return fmt.Errorf("failed to record latency hour: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// List retrieves the rollups starting at or after since, by provider and
+// hour. An empty provider lists every provider.
+func (r *LatencyRepository) List(since time.Time, provider string) ([]*LatencyHour, error) {
+	query := `SELECT ` + latencyHourColumns + ` FROM provider_latency_hourly WHERE hour_start >= ?`
+	args := []interface{}{since.UTC()}
+	if provider != "" {
+		query += ` AND provider = ?`
+		args = append(args, provider)
+	}
+	query += ` ORDER BY provider ASC, hour_start ASC`
+
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list latency hours: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var hours []*LatencyHour
+	for rows.Next() {
+		h := &LatencyHour{}
+		if err := rows.Scan(&h.Provider, &h.HourStart, &h.Samples, &h.Errors, &h.LatencyMsTotal, &h.LatencyMsMax); err != nil {
+			return nil, fmt.Errorf("failed to scan latency hour: %w", err)
+		}
+		hours = append(hours, h)
+	}
+	return hours, rows.Err()
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLatencyRepository(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "latency.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewLatencyRepository(db)
+	hour := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+
+	if err := repo.Add([]*LatencyHour{
+		{Provider: "openai", HourStart: hour, Samples: 10, Errors: 1, LatencyMsTotal: 9000, LatencyMsMax: 2500},
+		{Provider: "anthropic", HourStart: hour, Samples: 4, LatencyMsTotal: 2000, LatencyMsMax: 700},
+		{Provider: "openai", HourStart: hour.Add(-48 * time.Hour), Samples: 1, LatencyMsTotal: 400, LatencyMsMax: 400},
+	}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	// Counts for an existing hour accumulate and the maximum only grows
+	if err := repo.Add([]*LatencyHour{{Provider: "openai", HourStart: hour, Samples: 5, Errors: 2, LatencyMsTotal: 3000, LatencyMsMax: 1200}}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	hours, err := repo.List(hour.Add(-time.Hour), "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(hours) != 2 || hours[0].Provider != "anthropic" || hours[1].Provider != "openai" {
+		t.Fatalf("unexpected hours: %+v", hours)
+	}
+	if h := hours[1]; h.Samples != 15 || h.Errors != 3 || h.LatencyMsTotal != 12000 || h.LatencyMsMax != 2500 || !h.HourStart.Equal(hour) {
+		t.Errorf("counts not accumulated: %+v", h)
+	}
+
+	if openai, err := repo.List(time.Time{}, "openai"); err != nil || len(openai) != 2 {
+		t.Errorf("List(openai) = %+v, %v", openai, err)
+	}
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// MaintenanceWindow takes a provider out of rotation from StartsAt until
+// EndsAt. A window without an end is a drain that lasts until it is removed.
+type MaintenanceWindow struct {
+	ID        int        `json:"id"`
+	Provider  string     `json:"provider"`
+	Reason    string     `json:"reason,omitempty"`
+	StartsAt  time.Time  `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"` // Nil for an open-ended drain
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// ActiveAt reports whether the window covers t
+func (m *MaintenanceWindow) ActiveAt(t time.Time) bool {
+	return !t.Before(m.StartsAt) && (m.EndsAt == nil || t.Before(*m.EndsAt))
+}
+
+const maintenanceColumns = `id, provider, reason, starts_at, ends_at, created_at`
+
+// MaintenanceRepository provides CRUD operations for provider maintenance windows
+type MaintenanceRepository struct {
+	db *DB
+}
+
+// NewMaintenanceRepository creates a new MaintenanceRepository
+func NewMaintenanceRepository(db *DB) *MaintenanceRepository {
+	return &MaintenanceRepository{db: db}
+}
+
+// Create inserts a new maintenance window. A zero StartsAt starts it now.
+func (r *MaintenanceRepository) Create(m *MaintenanceWindow) error {
+	now := time.Now()
+	if m.StartsAt.IsZero() {
+		m.StartsAt = now
+	}
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = now
+	}
+
+	query := `
+		INSERT INTO provider_maintenance (provider, reason, starts_at, ends_at, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	id, err := r.db.conn.Insert(query, m.Provider, m.Reason, m.StartsAt, m.EndsAt, m.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create maintenance window: %w", err)
+	}
+	m.ID = int(id)
+	return nil
+}
+
+// Get retrieves a maintenance window by ID, or nil if it does not exist
+func (r *MaintenanceRepository) Get(id int) (*MaintenanceWindow, error) {
+	query := `SELECT ` + maintenanceColumns + ` FROM provider_maintenance WHERE id = ?`
+	m, err := scanMaintenanceWindow(r.db.conn.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get maintenance window: %w", err)
+	}
+	return m, nil
+}
+
+// List retrieves all maintenance windows by start time
+func (r *MaintenanceRepository) List() ([]*MaintenanceWindow, error) {
+	return r.queryWindows(`SELECT ` + maintenanceColumns + ` FROM provider_maintenance ORDER BY starts_at ASC, id ASC`)
+}
+
+// ListCurrent retrieves the windows that have not ended by now, including
+// ones scheduled for later. Used to build the in-memory maintenance set.
+func (r *MaintenanceRepository) ListCurrent(now time.Time) ([]*MaintenanceWindow, error) {
+	return r.queryWindows(`SELECT `+maintenanceColumns+` FROM provider_maintenance
+		WHERE ends_at IS NULL OR ends_at > ? ORDER BY starts_at ASC, id ASC`, now)
+}
+
+// End closes a window at the given time, keeping it as history
+func (r *MaintenanceRepository) End(id int, at time.Time) error {
+	result, err := r.db.conn.Exec(`UPDATE provider_maintenance SET ends_at = ? WHERE id = ?`, at, id)
+	if err != nil {
+		return fmt.Errorf("failed to end maintenance window: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("maintenance window not found: %d", id)
+	}
+	return nil
+}
+
+// Delete removes a maintenance window by ID
+func (r *MaintenanceRepository) Delete(id int) error {
+	result, err := r.db.conn.Exec(`DELETE FROM provider_maintenance WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("maintenance window not found: %d", id)
+	}
+	return nil
+}
+
+// queryWindows runs a query returning maintenance window rows
+func (r *MaintenanceRepository) queryWindows(query string, args ...interface{}) ([]*MaintenanceWindow, error) {
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var windows []*MaintenanceWindow
+	for rows.Next() {
+		m, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance window: %w", err)
+		}
+		windows = append(windows, m)
+	}
+	return windows, rows.Err()
+}
+
+// scanMaintenanceWindow scans a row selected with maintenanceColumns
+func scanMaintenanceWindow(row rowScanner) (*MaintenanceWindow, error) {
+	m := &MaintenanceWindow{}
+	if err := row.Scan(&m.ID, &m.Provider, &m.Reason, &m.StartsAt, &m.EndsAt, &m.CreatedAt); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceRepository(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "maintenance.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewMaintenanceRepository(db)
+	now := time.Now()
+
+	drain := &MaintenanceWindow{Provider: "openai", Reason: "key rotation"}
+	if err := repo.Create(drain); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if drain.ID == 0 || drain.StartsAt.IsZero() || drain.EndsAt != nil {
+		t.Errorf("drain not created as open-ended: %+v", drain)
+	}
+
+	start, end := now.Add(time.Hour), now.Add(2*time.Hour)
+	scheduled := &MaintenanceWindow{Provider: "anthropic", StartsAt: start, EndsAt: &end}
+	if err := repo.Create(scheduled); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	past := now.Add(-time.Hour)
+	old := &MaintenanceWindow{Provider: "groq", StartsAt: now.Add(-2 * time.Hour), EndsAt: &past}
+	if err := repo.Create(old); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	got, err := repo.Get(scheduled.ID)
+	if err != nil || got == nil || got.EndsAt == nil || !got.EndsAt.Equal(end) || !got.StartsAt.Equal(start) {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+	if got.ActiveAt(now) || !got.ActiveAt(start.Add(time.Minute)) || got.ActiveAt(end) {
+		t.Errorf("ActiveAt does not match the window %v-%v", start, end)
+	}
+	if missing, err := repo.Get(999); missing != nil || err != nil {
+		t.Errorf("expected nil for a missing window, got %+v, %v", missing, err)
+	}
+
+	if all, err := repo.List(); err != nil || len(all) != 3 || all[0].ID != old.ID {
+		t.Errorf("List = %+v, %v", all, err)
+	}
+	current, err := repo.ListCurrent(now)
+	if err != nil || len(current) != 2 {
+		t.Fatalf("ListCurrent = %+v, %v", current, err)
+	}
+
+	if err := repo.End(drain.ID, now); err != nil {
+		t.Fatalf("End failed: %v", err)
+	}
+	if current, _ := repo.ListCurrent(now.Add(time.Second)); len(current) != 1 || current[0].ID != scheduled.ID {
+		t.Errorf("expected only the scheduled window after ending the drain, got %+v", current)
+	}
+
+	if err := repo.Delete(scheduled.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := repo.Delete(scheduled.ID); err == nil {
+		t.Error("expected error deleting a missing window")
+	}
+}
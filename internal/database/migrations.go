@@ -0,0 +1,1109 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Migration is a versioned schema change and the script that reverses it.
+// Scripts are written in SQLite syntax and translated for the dialect.
+type Migration struct {
+	Version     int
+	Description string
+	Up          string
+	Down        string
+}
+
+// migrations lists every schema change in version order
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "Create the initial schema",
+		Up: `
+	-- Providers table
+	CREATE TABLE providers (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		base_url TEXT NOT NULL,
+		auth_method TEXT NOT NULL,
+		auth_header TEXT,
+		pricing_model TEXT NOT NULL,
+		subscription_tiers JSON,
+		discovered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		last_validated TIMESTAMP,
+		sdk_path TEXT,
+		sdk_hash TEXT,
+		sdk_version TEXT,
+		status TEXT DEFAULT 'offline',
+		last_error TEXT,
+		UNIQUE(base_url)
+	);
+
+	-- Model families table
+	CREATE TABLE model_families (
+		id TEXT PRIMARY KEY,
+		provider_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		description TEXT,
+		FOREIGN KEY (provider_id) REFERENCES providers(id) ON DELETE CASCADE
+	);
+
+	-- Models table
+	CREATE TABLE models (
+		id TEXT PRIMARY KEY,
+		family_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		cost_per_1m_in REAL,
+		cost_per_1m_out REAL,
+		cost_per_1m_reasoning REAL,
+		context_window INTEGER,
+		max_tokens INTEGER,
+		capabilities JSON,
+		status TEXT DEFAULT 'offline',
+		last_tested TIMESTAMP,
+		last_error TEXT,
+		FOREIGN KEY (family_id) REFERENCES model_families(id) ON DELETE CASCADE
+	);
+
+	-- API keys table
+	CREATE TABLE api_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		provider_id TEXT NOT NULL,
+		key_hash TEXT NOT NULL,
+		key_prefix TEXT,
+		tier TEXT DEFAULT 'unknown',
+		rpm_limit INTEGER,
+		tpm_limit INTEGER,
+		daily_limit INTEGER,
+		reset_interval TEXT,
+		last_reset TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		requests_count INTEGER DEFAULT 0,
+		tokens_count INTEGER DEFAULT 0,
+		active BOOLEAN DEFAULT 1,
+		degraded BOOLEAN DEFAULT 0,
+		degraded_until TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (provider_id) REFERENCES providers(id) ON DELETE CASCADE,
+		UNIQUE(provider_id, key_hash)
+	);
+
+	-- Usage tracking table
+	CREATE TABLE usage_tracking (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		model_id TEXT NOT NULL,
+		api_key_id INTEGER,
+		timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		tokens_in INTEGER DEFAULT 0,
+		tokens_out INTEGER DEFAULT 0,
+		tokens_reasoning INTEGER DEFAULT 0,
+		requests INTEGER DEFAULT 1,
+		cost REAL DEFAULT 0,
+		latency_ms INTEGER,
+		success BOOLEAN DEFAULT 1,
+		error TEXT,
+		FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
+		FOREIGN KEY (api_key_id) REFERENCES api_keys(id) ON DELETE SET NULL
+	);
+
+	-- Discovery logs table
+	CREATE TABLE discovery_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		provider_id TEXT,
+		agent_model TEXT,
+		started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		completed_at TIMESTAMP,
+		status TEXT,
+		retry_count INTEGER DEFAULT 0,
+		total_cost REAL DEFAULT 0,
+		sources_scraped JSON,
+		findings JSON,
+		error TEXT,
+		FOREIGN KEY (provider_id) REFERENCES providers(id) ON DELETE CASCADE
+	);
+
+	-- SDK versions table
+	CREATE TABLE sdk_versions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		provider_id TEXT NOT NULL,
+		version TEXT NOT NULL,
+		sdk_path TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		deprecated_at TIMESTAMP,
+		FOREIGN KEY (provider_id) REFERENCES providers(id) ON DELETE CASCADE,
+		UNIQUE(provider_id, version)
+	);
+
+	-- Settings table
+	CREATE TABLE settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Indexes for performance
+	CREATE INDEX idx_usage_timestamp ON usage_tracking(timestamp);
+	CREATE INDEX idx_usage_model ON usage_tracking(model_id);
+	CREATE INDEX idx_usage_key ON usage_tracking(api_key_id);
+	CREATE INDEX idx_models_status ON models(status);
+	CREATE INDEX idx_providers_status ON providers(status);
+	CREATE INDEX idx_api_keys_provider ON api_keys(provider_id);
+	CREATE INDEX idx_api_keys_active ON api_keys(active, degraded);
+	`,
+		Down: `
+	DROP TABLE settings;
+	DROP TABLE sdk_versions;
+	DROP TABLE discovery_logs;
+	DROP TABLE usage_tracking;
+	DROP TABLE api_keys;
+	DROP TABLE models;
+	DROP TABLE model_families;
+	DROP TABLE providers;
+	`,
+	},
+	{
+		Version:     2,
+		Description: "Add discovery_results",
+		Up: `
+	-- Discovery results table (stores complete discovery data)
+	CREATE TABLE discovery_results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		identifier TEXT NOT NULL UNIQUE,
+		provider_data JSON NOT NULL,
+		model_families JSON,
+		models JSON,
+		sdk_data JSON,
+		validated BOOLEAN DEFAULT 0,
+		validation_log TEXT,
+		sources JSON,
+		discovered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		ttl_expires_at TIMESTAMP
+	);
+
+	-- Index for cache lookups
+	CREATE INDEX idx_discovery_identifier ON discovery_results(identifier);
+	CREATE INDEX idx_discovery_ttl ON discovery_results(ttl_expires_at);
+	`,
+		Down: `
+	DROP TABLE discovery_results;
+	`,
+	},
+	{
+		Version:     3,
+		Description: "Add clients and request_logs for MClaude integration",
+		Up: `
+	-- Clients table for MClaude integration
+	CREATE TABLE clients (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		version TEXT NOT NULL,
+		token TEXT NOT NULL UNIQUE,
+		capabilities JSON NOT NULL DEFAULT '[]',
+		config JSON NOT NULL DEFAULT '{}',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		last_seen_at TIMESTAMP
+	);
+
+	-- Index for client token lookups
+	CREATE INDEX idx_clients_token ON clients(token);
+
+	-- Request logs table for observability
+	CREATE TABLE request_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		client_id TEXT,
+		provider TEXT NOT NULL,
+		model TEXT NOT NULL,
+		endpoint TEXT NOT NULL,
+		request_tokens INTEGER DEFAULT 0,
+		response_tokens INTEGER DEFAULT 0,
+		latency_ms INTEGER DEFAULT 0,
+		status_code INTEGER,
+		error_message TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE SET NULL
+	);
+
+	-- Indexes for request log queries
+	CREATE INDEX idx_request_logs_client ON request_logs(client_id);
+	CREATE INDEX idx_request_logs_created ON request_logs(created_at);
+	CREATE INDEX idx_request_logs_provider ON request_logs(provider);
+	`,
+		Down: `
+	DROP TABLE request_logs;
+	DROP TABLE clients;
+	`,
+	},
+	{
+		Version:     4,
+		Description: "Add model name aliases",
+		Up: `
+	-- Aliases table for model name aliases
+	-- Uses unique constraint on (name, client_id) with a workaround for NULL client_id
+	CREATE TABLE aliases (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		model_id TEXT NOT NULL,
+		client_id TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
+	);
+
+	-- Unique index for alias lookups (NULL client_id means global alias)
+	CREATE UNIQUE INDEX idx_aliases_name_client ON aliases(name, client_id);
+	CREATE INDEX idx_aliases_client ON aliases(client_id);
+	CREATE INDEX idx_aliases_name ON aliases(name);
+	`,
+		Down: `
+	DROP TABLE aliases;
+	`,
+	},
+	{
+		Version:     5,
+		Description: "Add remap_rules, client_rate_limits and default aliases",
+		Up: `
+	-- Remap rules table for model remapping
+	CREATE TABLE remap_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		client_id TEXT NOT NULL,
+		from_model TEXT NOT NULL,
+		to_model TEXT NOT NULL,
+		to_provider TEXT NOT NULL,
+		priority INTEGER DEFAULT 0,
+		enabled BOOLEAN DEFAULT 1,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
+	);
+
+	-- Indexes for remap rule lookups
+	CREATE INDEX idx_remap_rules_client ON remap_rules(client_id);
+	CREATE INDEX idx_remap_rules_enabled ON remap_rules(client_id, enabled);
+	CREATE INDEX idx_remap_rules_priority ON remap_rules(client_id, priority DESC);
+
+	-- Client rate limits table
+	CREATE TABLE client_rate_limits (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		client_id TEXT NOT NULL UNIQUE,
+		rpm_limit INTEGER,
+		tpm_limit INTEGER,
+		daily_limit INTEGER,
+		current_rpm INTEGER DEFAULT 0,
+		current_tpm INTEGER DEFAULT 0,
+		current_daily INTEGER DEFAULT 0,
+		last_reset TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
+	);
+
+	-- Index for rate limit lookups
+	CREATE INDEX idx_client_rate_limits_client ON client_rate_limits(client_id);
+
+	-- Default global aliases (client_id = NULL for global)
+	INSERT INTO aliases (name, model_id, client_id) VALUES
+		('sonnet', 'claude-sonnet-4-5-20250929', NULL),
+		('opus', 'claude-opus-4-5-20250929', NULL),
+		('haiku', 'claude-3-5-haiku-20241022', NULL),
+		('gpt4', 'gpt-4o', NULL),
+		('gemini', 'gemini-1.5-pro', NULL)
+	ON CONFLICT DO NOTHING;
+	`,
+		Down: `
+	DELETE FROM aliases WHERE client_id IS NULL AND name IN ('sonnet', 'opus', 'haiku', 'gpt4', 'gemini');
+	DROP TABLE client_rate_limits;
+	DROP TABLE remap_rules;
+	`,
+	},
+	{
+		Version:     6,
+		Description: "Allow global remap rules and add remap strategies",
+		Up: `
+	-- SQLite cannot drop a NOT NULL constraint, so the table is rebuilt
+	CREATE TABLE remap_rules_new (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		client_id TEXT,
+		from_model TEXT NOT NULL,
+		to_model TEXT NOT NULL DEFAULT '',
+		to_provider TEXT NOT NULL DEFAULT '',
+		strategy TEXT NOT NULL DEFAULT 'direct',
+		candidates JSON,
+		priority INTEGER DEFAULT 0,
+		enabled BOOLEAN DEFAULT 1,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
+	);
+
+	INSERT INTO remap_rules_new (id, client_id, from_model, to_model, to_provider, priority, enabled, created_at)
+		SELECT id, client_id, from_model, to_model, to_provider, priority, enabled, created_at FROM remap_rules;
+
+	DROP TABLE remap_rules;
+	ALTER TABLE remap_rules_new RENAME TO remap_rules;
+
+	CREATE INDEX idx_remap_rules_client ON remap_rules(client_id);
+	CREATE INDEX idx_remap_rules_enabled ON remap_rules(client_id, enabled);
+	CREATE INDEX idx_remap_rules_priority ON remap_rules(client_id, priority DESC);
+	`,
+		Down: `
+	-- Global rules and strategies cannot be represented before this migration
+	CREATE TABLE remap_rules_old (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		client_id TEXT NOT NULL,
+		from_model TEXT NOT NULL,
+		to_model TEXT NOT NULL,
+		to_provider TEXT NOT NULL,
+		priority INTEGER DEFAULT 0,
+		enabled BOOLEAN DEFAULT 1,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
+	);
+
+	INSERT INTO remap_rules_old (id, client_id, from_model, to_model, to_provider, priority, enabled, created_at)
+		SELECT id, client_id, from_model, to_model, to_provider, priority, enabled, created_at FROM remap_rules
+		WHERE client_id IS NOT NULL AND strategy = 'direct';
+
+	DROP TABLE remap_rules;
+	ALTER TABLE remap_rules_old RENAME TO remap_rules;
+
+	CREATE INDEX idx_remap_rules_client ON remap_rules(client_id);
+	CREATE INDEX idx_remap_rules_enabled ON remap_rules(client_id, enabled);
+	CREATE INDEX idx_remap_rules_priority ON remap_rules(client_id, priority DESC);
+	`,
+	},
+	{
+		Version:     7,
+		Description: "Add canary_results and canary_policies for canary rollouts",
+		Up: `
+	CREATE TABLE canary_results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		policy_id TEXT NOT NULL,
+		model_pattern TEXT NOT NULL,
+		canary_model TEXT NOT NULL,
+		canary_provider TEXT NOT NULL DEFAULT '',
+		percent REAL NOT NULL,
+		status TEXT NOT NULL,
+		reason TEXT,
+		baseline_requests INTEGER DEFAULT 0,
+		baseline_errors INTEGER DEFAULT 0,
+		baseline_avg_latency_ms REAL DEFAULT 0,
+		baseline_avg_quality REAL,
+		canary_requests INTEGER DEFAULT 0,
+		canary_errors INTEGER DEFAULT 0,
+		canary_avg_latency_ms REAL DEFAULT 0,
+		canary_avg_quality REAL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX idx_canary_results_policy ON canary_results(policy_id, created_at DESC);
+
+	CREATE TABLE canary_policies (
+		policy_id TEXT PRIMARY KEY,
+		model_pattern TEXT NOT NULL,
+		canary_model TEXT NOT NULL,
+		canary_provider TEXT NOT NULL DEFAULT '',
+		percent REAL NOT NULL,
+		min_samples INTEGER NOT NULL,
+		promote_after INTEGER NOT NULL,
+		max_error_rate_increase REAL NOT NULL DEFAULT 0,
+		max_latency_ratio REAL NOT NULL DEFAULT 0,
+		max_quality_drop REAL NOT NULL DEFAULT 0,
+		status TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	`,
+		Down: `
+	DROP TABLE canary_policies;
+	DROP TABLE canary_results;
+	`,
+	},
+	{
+		Version:     8,
+		Description: "Add shadow_results for mirrored traffic comparisons",
+		Up: `
+	CREATE TABLE shadow_results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		client_id TEXT,
+		primary_provider TEXT NOT NULL,
+		primary_model TEXT NOT NULL,
+		primary_status INTEGER,
+		primary_latency_ms INTEGER,
+		primary_response TEXT,
+		primary_prompt_tokens INTEGER,
+		primary_completion_tokens INTEGER,
+		primary_stream BOOLEAN DEFAULT 0,
+		shadow_provider TEXT NOT NULL,
+		shadow_model TEXT NOT NULL,
+		shadow_status INTEGER,
+		shadow_latency_ms INTEGER,
+		shadow_response TEXT,
+		shadow_prompt_tokens INTEGER,
+		shadow_completion_tokens INTEGER,
+		shadow_error TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX idx_shadow_results_models ON shadow_results(primary_model, shadow_model);
+	CREATE INDEX idx_shadow_results_created ON shadow_results(created_at);
+	`,
+		Down: `
+	DROP TABLE shadow_results;
+	`,
+	},
+	{
+		Version:     9,
+		Description: "Add webhook_dead_letters for exhausted event deliveries",
+		Up: `
+	CREATE TABLE webhook_dead_letters (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		endpoint TEXT NOT NULL,
+		url TEXT NOT NULL,
+		event_id TEXT NOT NULL,
+		event_type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_status INTEGER,
+		last_error TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX idx_webhook_dead_letters_created ON webhook_dead_letters(created_at);
+	CREATE INDEX idx_webhook_dead_letters_endpoint ON webhook_dead_letters(endpoint, event_type);
+	`,
+		Down: `
+	DROP TABLE webhook_dead_letters;
+	`,
+	},
+	{
+		Version:     10,
+		Description: "Add scheduled_runs for re-validation and refresh history",
+		Up: `
+	CREATE TABLE scheduled_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		provider TEXT NOT NULL,
+		task TEXT NOT NULL,
+		trigger_type TEXT NOT NULL,
+		status TEXT NOT NULL,
+		details TEXT,
+		error TEXT,
+		started_at TIMESTAMP NOT NULL,
+		duration_ms INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE INDEX idx_scheduled_runs_provider ON scheduled_runs(provider, task, started_at);
+	CREATE INDEX idx_scheduled_runs_started ON scheduled_runs(started_at);
+	`,
+		Down: `
+	DROP TABLE scheduled_runs;
+	`,
+	},
+	{
+		Version:     11,
+		Description: "Add tenants with owned clients and keys and a usage ledger",
+		Up: `
+	-- Tenants share one instance but get their own budget, limits and keys
+	CREATE TABLE tenants (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		monthly_budget REAL NOT NULL DEFAULT 0,
+		rpm_limit INTEGER NOT NULL DEFAULT 0,
+		tpm_limit INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Clients and keys without a tenant stay shared
+	ALTER TABLE clients ADD COLUMN tenant_id TEXT;
+	ALTER TABLE api_keys ADD COLUMN tenant_id TEXT;
+	CREATE INDEX idx_clients_tenant ON clients(tenant_id);
+	CREATE INDEX idx_api_keys_tenant ON api_keys(tenant_id);
+
+	-- Per-request cost ledger for budgets and usage reporting
+	CREATE TABLE tenant_usage (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		tenant_id TEXT NOT NULL,
+		client_id TEXT,
+		provider TEXT NOT NULL,
+		model TEXT NOT NULL,
+		prompt_tokens INTEGER NOT NULL DEFAULT 0,
+		completion_tokens INTEGER NOT NULL DEFAULT 0,
+		cost REAL NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX idx_tenant_usage_tenant ON tenant_usage(tenant_id, created_at);
+	`,
+		Down: `
+	DROP TABLE tenant_usage;
+	DROP INDEX idx_api_keys_tenant;
+	DROP INDEX idx_clients_tenant;
+	ALTER TABLE api_keys DROP COLUMN tenant_id;
+	ALTER TABLE clients DROP COLUMN tenant_id;
+	DROP TABLE tenants;
+	`,
+	},
+	{
+		Version:     12,
+		Description: "Track prompt cache tokens and savings in the tenant usage ledger",
+		Up: `
+	ALTER TABLE tenant_usage ADD COLUMN cached_tokens INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE tenant_usage ADD COLUMN cache_write_tokens INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE tenant_usage ADD COLUMN cache_savings REAL NOT NULL DEFAULT 0;
+	`,
+		Down: `
+	ALTER TABLE tenant_usage DROP COLUMN cache_savings;
+	ALTER TABLE tenant_usage DROP COLUMN cache_write_tokens;
+	ALTER TABLE tenant_usage DROP COLUMN cached_tokens;
+	`,
+	},
+	{
+		Version:     13,
+		Description: "Add system prompt policies",
+		Up: `
+	-- Organization-mandated instructions added to system prompts
+	CREATE TABLE prompt_policies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		client_id TEXT,
+		model_pattern TEXT NOT NULL DEFAULT '*',
+		position TEXT NOT NULL DEFAULT 'prepend',
+		template TEXT NOT NULL,
+		priority INTEGER DEFAULT 0,
+		enabled BOOLEAN DEFAULT 1,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX idx_prompt_policies_enabled ON prompt_policies(enabled, priority DESC);
+	`,
+		Down: `
+	DROP INDEX idx_prompt_policies_enabled;
+	DROP TABLE prompt_policies;
+	`,
+	},
+	{
+		Version:     14,
+		Description: "Add provider maintenance windows",
+		Up: `
+	-- Drains and scheduled maintenance that take a provider out of rotation
+	CREATE TABLE provider_maintenance (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		provider TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		starts_at TIMESTAMP NOT NULL,
+		ends_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX idx_provider_maintenance_provider ON provider_maintenance(provider, starts_at);
+	`,
+		Down: `
+	DROP INDEX idx_provider_maintenance_provider;
+	DROP TABLE provider_maintenance;
+	`,
+	},
+	{
+		Version:     15,
+		Description: "Add provider SLA rollups",
+		Up: `
+	-- Per-provider request outcomes rolled up into fixed periods
+	CREATE TABLE provider_sla (
+		provider TEXT NOT NULL,
+		period_start TIMESTAMP NOT NULL,
+		requests INTEGER NOT NULL DEFAULT 0,
+		errors INTEGER NOT NULL DEFAULT 0,
+		within_target INTEGER NOT NULL DEFAULT 0,
+		latency_ms_total INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (provider, period_start)
+	);
+
+	CREATE INDEX idx_provider_sla_period ON provider_sla(period_start);
+	`,
+		Down: `
+	DROP INDEX idx_provider_sla_period;
+	DROP TABLE provider_sla;
+	`,
+	},
+	{
+		Version:     16,
+		Description: "Add model evaluation runs",
+		Up: `
+	-- One run of an evaluation suite against a model
+	CREATE TABLE eval_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		suite TEXT NOT NULL,
+		model TEXT NOT NULL,
+		provider TEXT NOT NULL DEFAULT '',
+		started_at TIMESTAMP NOT NULL,
+		duration_ms INTEGER NOT NULL DEFAULT 0,
+		cases INTEGER NOT NULL DEFAULT 0,
+		passed INTEGER NOT NULL DEFAULT 0,
+		errors INTEGER NOT NULL DEFAULT 0,
+		score REAL NOT NULL DEFAULT 0,
+		avg_latency_ms REAL NOT NULL DEFAULT 0,
+		tokens_in INTEGER NOT NULL DEFAULT 0,
+		tokens_out INTEGER NOT NULL DEFAULT 0,
+		cost REAL NOT NULL DEFAULT 0
+	);
+
+	CREATE INDEX idx_eval_runs_suite ON eval_runs(suite, model, started_at);
+
+	-- Outcome of each case in a run
+	CREATE TABLE eval_results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		run_id INTEGER NOT NULL,
+		case_name TEXT NOT NULL,
+		passed BOOLEAN DEFAULT 0,
+		output TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		tokens_in INTEGER NOT NULL DEFAULT 0,
+		tokens_out INTEGER NOT NULL DEFAULT 0,
+		cost REAL NOT NULL DEFAULT 0,
+		FOREIGN KEY (run_id) REFERENCES eval_runs(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX idx_eval_results_run ON eval_results(run_id);
+	`,
+		Down: `
+	DROP INDEX idx_eval_results_run;
+	DROP TABLE eval_results;
+	DROP INDEX idx_eval_runs_suite;
+	DROP TABLE eval_runs;
+	`,
+	},
+	{
+		Version:     17,
+		Description: "Add judge scores to evaluation runs",
+		Up: `
+	ALTER TABLE eval_runs ADD COLUMN judge_model TEXT NOT NULL DEFAULT '';
+	ALTER TABLE eval_runs ADD COLUMN judged INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE eval_runs ADD COLUMN judge_score REAL NOT NULL DEFAULT 0;
+	ALTER TABLE eval_results ADD COLUMN judge_score REAL;
+	ALTER TABLE eval_results ADD COLUMN judge_reason TEXT NOT NULL DEFAULT '';
+	`,
+		Down: `
+	ALTER TABLE eval_results DROP COLUMN judge_reason;
+	ALTER TABLE eval_results DROP COLUMN judge_score;
+	ALTER TABLE eval_runs DROP COLUMN judge_score;
+	ALTER TABLE eval_runs DROP COLUMN judged;
+	ALTER TABLE eval_runs DROP COLUMN judge_model;
+	`,
+	},
+	{
+		Version:     18,
+		Description: "Add API key expiry and health probes",
+		Up: `
+	ALTER TABLE api_keys ADD COLUMN expires_at TIMESTAMP;
+
+	CREATE TABLE key_health (
+		key_id INTEGER PRIMARY KEY,
+		checked_at TIMESTAMP NOT NULL,
+		last_success_at TIMESTAMP,
+		status INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		failures INTEGER NOT NULL DEFAULT 0,
+		quota_remaining INTEGER,
+		quota_limit INTEGER,
+		FOREIGN KEY (key_id) REFERENCES api_keys(id) ON DELETE CASCADE
+	);
+	`,
+		Down: `
+	DROP TABLE key_health;
+	ALTER TABLE api_keys DROP COLUMN expires_at;
+	`,
+	},
+	{
+		Version:     19,
+		Description: "Add encrypted secret storage",
+		Up: `
+	CREATE TABLE secret_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		wrapped_key TEXT NOT NULL,
+		master_key_id TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	ALTER TABLE api_keys ADD COLUMN key_secret TEXT;
+	ALTER TABLE clients ADD COLUMN token_hash TEXT;
+	CREATE INDEX idx_clients_token_hash ON clients(token_hash);
+	`,
+		Down: `
+	DROP INDEX idx_clients_token_hash;
+	ALTER TABLE clients DROP COLUMN token_hash;
+	ALTER TABLE api_keys DROP COLUMN key_secret;
+	DROP TABLE secret_keys;
+	`,
+	},
+	{
+		Version:     20,
+		Description: "Track model deprecations",
+		Up: `
+	CREATE TABLE model_deprecations (
+		provider_id TEXT NOT NULL,
+		model_id TEXT NOT NULL,
+		retires_at TIMESTAMP NOT NULL,
+		replacement TEXT NOT NULL DEFAULT '',
+		source TEXT NOT NULL,
+		source_url TEXT NOT NULL DEFAULT '',
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (provider_id, model_id)
+	);
+	`,
+		Down: `
+	DROP TABLE model_deprecations;
+	`,
+	},
+	{
+		Version:     21,
+		Description: "Snapshot provider model catalogs and record changes",
+		Up: `
+	CREATE TABLE catalog_snapshots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		provider_id TEXT NOT NULL,
+		source TEXT NOT NULL,
+		model_count INTEGER NOT NULL DEFAULT 0,
+		taken_at TIMESTAMP NOT NULL,
+		checked_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX idx_catalog_snapshots_provider ON catalog_snapshots(provider_id, id);
+
+	CREATE TABLE catalog_snapshot_models (
+		snapshot_id INTEGER NOT NULL,
+		model_id TEXT NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		cost_per_1m_in REAL,
+		cost_per_1m_out REAL,
+		context_window INTEGER,
+		PRIMARY KEY (snapshot_id, model_id),
+		FOREIGN KEY (snapshot_id) REFERENCES catalog_snapshots(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE catalog_changes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		snapshot_id INTEGER NOT NULL,
+		provider_id TEXT NOT NULL,
+		model_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		old_cost_per_1m_in REAL,
+		old_cost_per_1m_out REAL,
+		new_cost_per_1m_in REAL,
+		new_cost_per_1m_out REAL,
+		detected_at TIMESTAMP NOT NULL,
+		FOREIGN KEY (snapshot_id) REFERENCES catalog_snapshots(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_catalog_changes_provider ON catalog_changes(provider_id, id);
+	`,
+		Down: `
+	DROP TABLE catalog_changes;
+	DROP TABLE catalog_snapshot_models;
+	DROP TABLE catalog_snapshots;
+	`,
+	},
+	{
+		Version:     22,
+		Description: "Add hourly provider latency rollups",
+		Up: `
+	-- Per-provider time to first byte rolled up by UTC hour, for heatmaps
+	CREATE TABLE provider_latency_hourly (
+		provider TEXT NOT NULL,
+		hour_start TIMESTAMP NOT NULL,
+		samples INTEGER NOT NULL DEFAULT 0,
+		errors INTEGER NOT NULL DEFAULT 0,
+		latency_ms_total INTEGER NOT NULL DEFAULT 0,
+		latency_ms_max INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (provider, hour_start)
+	);
+
+	CREATE INDEX idx_provider_latency_hourly_hour ON provider_latency_hourly(hour_start);
+	`,
+		Down: `
+	DROP INDEX idx_provider_latency_hourly_hour;
+	DROP TABLE provider_latency_hourly;
+	`,
+	},
+	{
+		Version:     23,
+		Description: "Cache embedding vectors",
+		Up: `
+	-- Vectors by a hash of provider, model, dimensions and input, as
+	-- little-endian float32s
+	CREATE TABLE embedding_cache (
+		cache_key TEXT PRIMARY KEY,
+		vector BLOB NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	`,
+		Down: `
+	DROP TABLE embedding_cache;
+	`,
+	},
+	{
+		Version:     24,
+		Description: "Add vector store collections",
+		Up: `
+	CREATE TABLE vector_collections (
+		name TEXT PRIMARY KEY,
+		dimensions INTEGER NOT NULL,
+		metric TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Values are little-endian float32s; metadata is a JSON object of strings
+	CREATE TABLE vector_records (
+		collection TEXT NOT NULL,
+		id TEXT NOT NULL,
+		vector_values BLOB NOT NULL,
+		content TEXT NOT NULL DEFAULT '',
+		metadata TEXT NOT NULL DEFAULT '{}',
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (collection, id)
+	);
+	`,
+		Down: `
+	DROP TABLE vector_records;
+	DROP TABLE vector_collections;
+	`,
+	},
+}
+
+var (
+	// ErrDirtySchema means a migration failed part-way. The schema must be
+	// checked by hand and the version set with ForceVersion.
+	ErrDirtySchema = errors.New("database schema is dirty")
+	// ErrSchemaOutdated means migrations are pending and automatic
+	// migration is disabled
+	ErrSchemaOutdated = errors.New("database schema is out of date")
+)
+
+// MigrationState describes one migration and whether it has been applied
+type MigrationState struct {
+	Version     int        `json:"version"`
+	Description string     `json:"description"`
+	AppliedAt   *time.Time `json:"applied_at,omitempty"`
+}
+
+// SchemaStatus describes the database's migration state
+type SchemaStatus struct {
+	Version      int              `json:"version"`                 // Highest applied migration
+	Latest       int              `json:"latest"`                  // Highest known migration
+	Dirty        bool             `json:"dirty"`                   // A migration failed part-way
+	DirtyVersion int              `json:"dirty_version,omitempty"` // The migration that failed
+	Migrations   []MigrationState `json:"migrations"`
+}
+
+// Pending returns the number of known migrations not yet applied
+func (s *SchemaStatus) Pending() int {
+	n := 0
+	for _, m := range s.Migrations {
+		if m.AppliedAt == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Migrations returns every known migration in version order
+func Migrations() []Migration {
+	return append([]Migration(nil), migrations...)
+}
+
+// migrate applies pending migrations, refusing to run on a dirty schema
+func (db *DB) migrate() error {
+	_, err := db.MigrateUp(0)
+	return err
+}
+
+// checkSchema fails unless every migration has been applied
+func (db *DB) checkSchema() error {
+	status, err := db.SchemaStatus()
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("%w at version %d", ErrDirtySchema, status.DirtyVersion)
+	}
+	if pending := status.Pending(); pending > 0 {
+		return fmt.Errorf("%w: %d pending migrations (at version %d, latest %d)", ErrSchemaOutdated, pending, status.Version, status.Latest)
+	}
+	return nil
+}
+
+// ensureMigrationTables creates the bookkeeping tables. schema_version has
+// a row per applied migration; schema_dirty holds the migration in progress
+// and is only left behind when one fails.
+func (db *DB) ensureMigrationTables() error {
+	err := db.conn.ExecScript(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE IF NOT EXISTS schema_dirty (
+			version INTEGER PRIMARY KEY,
+			started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create migration tables: %w", err)
+	}
+	return nil
+}
+
+// SchemaStatus reports the applied, pending and dirty migrations
+func (db *DB) SchemaStatus() (*SchemaStatus, error) {
+	applied := map[int]time.Time{}
+	rows, err := db.conn.Query("SELECT version, applied_at FROM schema_version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema versions: %w", err)
+	}
+	defer rows.Close()
+
+	status := &SchemaStatus{Latest: migrations[len(migrations)-1].Version}
+	for rows.Next() {
+		var version int
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema version: %w", err)
+		}
+		applied[version] = appliedAt.Time
+		status.Version = max(status.Version, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schema versions: %w", err)
+	}
+
+	err = db.conn.QueryRow("SELECT version FROM schema_dirty ORDER BY version LIMIT 1").Scan(&status.DirtyVersion)
+	switch {
+	case err == nil:
+		status.Dirty = true
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("failed to read dirty state: %w", err)
+	}
+
+	for _, m := range migrations {
+		state := MigrationState{Version: m.Version, Description: m.Description}
+		if at, ok := applied[m.Version]; ok {
+			state.AppliedAt = &at
+		}
+		status.Migrations = append(status.Migrations, state)
+	}
+	return status, nil
+}
+
+// MigrateUp applies pending migrations up to and including target, or all
+// of them when target is 0. It returns the versions applied.
+func (db *DB) MigrateUp(target int) ([]int, error) {
+	status, err := db.SchemaStatus()
+	if err != nil {
+		return nil, err
+	}
+	if status.Dirty {
+		return nil, fmt.Errorf("%w at version %d", ErrDirtySchema, status.DirtyVersion)
+	}
+	if target <= 0 {
+		target = status.Latest
+	}
+	if target > status.Latest {
+		return nil, fmt.Errorf("unknown migration version %d (latest is %d)", target, status.Latest)
+	}
+
+	var applied []int
+	for i, m := range migrations {
+		if m.Version > target {
+			break
+		}
+		if status.Migrations[i].AppliedAt != nil {
+			continue
+		}
+		if err := db.applyMigration(m, true); err != nil {
+			return applied, fmt.Errorf("migration %d failed: %w", m.Version, err)
+		}
+		applied = append(applied, m.Version)
+	}
+	return applied, nil
+}
+
+// MigrateDown reverts the latest steps applied migrations and returns the
+// versions reverted
+func (db *DB) MigrateDown(steps int) ([]int, error) {
+	status, err := db.SchemaStatus()
+	if err != nil {
+		return nil, err
+	}
+	if status.Dirty {
+		return nil, fmt.Errorf("%w at version %d", ErrDirtySchema, status.DirtyVersion)
+	}
+	if status.Version > status.Latest {
+		return nil, fmt.Errorf("database schema version %d is newer than this binary (latest %d)", status.Version, status.Latest)
+	}
+
+	var reverted []int
+	for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		if status.Migrations[i].AppliedAt == nil {
+			continue
+		}
+		m := migrations[i]
+		if err := db.applyMigration(m, false); err != nil {
+			return reverted, fmt.Errorf("reverting migration %d failed: %w", m.Version, err)
+		}
+		reverted = append(reverted, m.Version)
+	}
+	return reverted, nil
+}
+
+// ForceVersion records the schema as migrated to exactly version and clears
+// the dirty state, without running any scripts. Use it after repairing a
+// failed migration by hand.
+func (db *DB) ForceVersion(version int) error {
+	if version < 0 || version > migrations[len(migrations)-1].Version {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec("DELETE FROM schema_version WHERE version > ?", version); err != nil {
+		return fmt.Errorf("failed to remove schema versions: %w", err)
+	}
+	for _, m := range migrations {
+		if m.Version > version {
+			break
+		}
+		if _, err := tx.Exec("INSERT INTO schema_version (version) VALUES (?) ON CONFLICT DO NOTHING", m.Version); err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM schema_dirty"); err != nil {
+		return fmt.Errorf("failed to clear dirty state: %w", err)
+	}
+	return tx.Commit()
+}
+
+// applyMigration runs one script in a transaction. The dirty marker is
+// committed first and only cleared by the migration's own transaction, so
+// a failure or crash leaves it behind.
+func (db *DB) applyMigration(m Migration, up bool) error {
+	if _, err := db.conn.Exec("INSERT INTO schema_dirty (version) VALUES (?)", m.Version); err != nil {
+		return fmt.Errorf("failed to mark schema dirty: %w", err)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	script, record := m.Up, "INSERT INTO schema_version (version) VALUES (?)"
+	if !up {
+		script, record = m.Down, "DELETE FROM schema_version WHERE version = ?"
+	}
+	if err := tx.ExecScript(script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(record, m.Version); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM schema_dirty WHERE version = ?", m.Version); err != nil {
+		return fmt.Errorf("failed to clear dirty state: %w", err)
+	}
+	return tx.Commit()
+}
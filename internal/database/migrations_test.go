@@ -0,0 +1,153 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrations_Sequential(t *testing.T) {
+	if len(migrations) != CurrentSchemaVersion {
+		t.Fatalf("expected %d migrations, got %d", CurrentSchemaVersion, len(migrations))
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d has version %d", i+1, m.Version)
+		}
+		if m.Description == "" || m.Up == "" || m.Down == "" {
+			t.Errorf("migration %d needs a description and up and down scripts", m.Version)
+		}
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	// Rules that fit the pre-migration-6 schema survive a round trip
+	client := &Client{ID: "c1", Name: "client", Token: "tok", Version: "1"}
+	if err := NewClientRepository(db).Create(client); err != nil {
+		t.Fatalf("Create client failed: %v", err)
+	}
+	rule := &RemapRule{ClientID: "c1", FromModel: "gpt-4", ToModel: "gpt-4o", ToProvider: "openai", Enabled: true}
+	if err := NewRemapRuleRepository(db).Create(rule); err != nil {
+		t.Fatalf("Create rule failed: %v", err)
+	}
+
+	steps := CurrentSchemaVersion - 5
+	reverted, err := db.MigrateDown(steps)
+	if err != nil {
+		t.Fatalf("MigrateDown failed: %v", err)
+	}
+	if len(reverted) != steps || reverted[0] != CurrentSchemaVersion || reverted[steps-1] != 6 {
+		t.Errorf("expected versions %d..6 reverted, got %v", CurrentSchemaVersion, reverted)
+	}
+	status, err := db.SchemaStatus()
+	if err != nil {
+		t.Fatalf("SchemaStatus failed: %v", err)
+	}
+	if status.Version != 5 || status.Pending() != steps || status.Dirty {
+		t.Errorf("unexpected status after down: %+v", status)
+	}
+
+	// The database now refuses to open without migrating
+	if _, err := OpenWithOptions(db.path, Options{ManualMigrations: true}); !errors.Is(err, ErrSchemaOutdated) {
+		t.Errorf("expected ErrSchemaOutdated, got %v", err)
+	}
+
+	applied, err := db.MigrateUp(0)
+	if err != nil {
+		t.Fatalf("MigrateUp failed: %v", err)
+	}
+	if len(applied) != steps {
+		t.Errorf("expected %d migrations applied, got %v", steps, applied)
+	}
+	rules, err := NewRemapRuleRepository(db).List(nil)
+	if err != nil || len(rules) != 1 || rules[0].ToModel != "gpt-4o" {
+		t.Errorf("expected the remap rule to survive, got %v (%v)", rules, err)
+	}
+
+	// All the way down leaves only the bookkeeping tables
+	if _, err := db.MigrateDown(CurrentSchemaVersion); err != nil {
+		t.Fatalf("MigrateDown failed: %v", err)
+	}
+	var tables int
+	db.conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'schema_%' AND name != 'sqlite_sequence'").Scan(&tables)
+	if tables != 0 {
+		t.Errorf("expected no tables after full rollback, got %d", tables)
+	}
+}
+
+func TestMigrateUp_Target(t *testing.T) {
+	db, err := OpenForMigration(filepath.Join(t.TempDir(), "migrate.db"), Options{})
+	if err != nil {
+		t.Fatalf("OpenForMigration failed: %v", err)
+	}
+	defer db.Close()
+
+	applied, err := db.MigrateUp(3)
+	if err != nil || len(applied) != 3 {
+		t.Fatalf("MigrateUp(3) = %v, %v", applied, err)
+	}
+	if _, err := db.MigrateUp(CurrentSchemaVersion + 1); err == nil {
+		t.Error("expected error for unknown target version")
+	}
+	status, _ := db.SchemaStatus()
+	if status.Version != 3 || status.Latest != CurrentSchemaVersion {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
+
+func TestDirtySchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dirty.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	original := migrations
+	defer func() { migrations = original }()
+	migrations = append(append([]Migration(nil), original...), Migration{
+		Version:     CurrentSchemaVersion + 1,
+		Description: "Broken",
+		Up:          "CREATE TABLE broken_a (id INTEGER); CREATE TABLE broken_b (",
+		Down:        "DROP TABLE broken_a;",
+	})
+
+	if _, err := db.MigrateUp(0); err == nil {
+		t.Fatal("expected the broken migration to fail")
+	}
+	status, _ := db.SchemaStatus()
+	if !status.Dirty || status.DirtyVersion != CurrentSchemaVersion+1 || status.Version != CurrentSchemaVersion {
+		t.Errorf("expected dirty schema at the failed version, got %+v", status)
+	}
+
+	// The failed migration was rolled back as a whole
+	var tables int
+	db.conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'broken_a'").Scan(&tables)
+	if tables != 0 {
+		t.Error("expected the failed migration's transaction to roll back")
+	}
+
+	if _, err := Open(path); !errors.Is(err, ErrDirtySchema) {
+		t.Errorf("expected ErrDirtySchema opening a dirty database, got %v", err)
+	}
+	if _, err := db.MigrateDown(1); !errors.Is(err, ErrDirtySchema) {
+		t.Errorf("expected ErrDirtySchema migrating down, got %v", err)
+	}
+
+	if err := db.ForceVersion(CurrentSchemaVersion); err != nil {
+		t.Fatalf("ForceVersion failed: %v", err)
+	}
+	status, _ = db.SchemaStatus()
+	if status.Dirty || status.Version != CurrentSchemaVersion {
+		t.Errorf("expected clean schema after force, got %+v", status)
+	}
+	if err := db.ForceVersion(-1); err == nil {
+		t.Error("expected error forcing an unknown version")
+	}
+}
@@ -0,0 +1,131 @@
+//go:build postgres
+
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// TestPostgresIntegration runs every migration and a few repositories
+// against a real PostgreSQL server. It needs the postgres build tag and
+// MODELSCAN_TEST_POSTGRES_URL:
+//
+//	MODELSCAN_TEST_POSTGRES_URL=postgres://modelscan@localhost/modelscan?sslmode=disable \
+//	    go test -tags postgres -run Postgres ./internal/database
+func TestPostgresIntegration(t *testing.T) {
+	dsn := os.Getenv("MODELSCAN_TEST_POSTGRES_URL")
+	if dsn == "" {
+		t.Skip("MODELSCAN_TEST_POSTGRES_URL not set")
+	}
+	db, err := Open(isolatedSchema(t, dsn))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	if db.Dialect().Name() != "postgres" {
+		t.Fatalf("expected postgres dialect, got %s", db.Dialect().Name())
+	}
+	status, err := db.SchemaStatus()
+	if err != nil {
+		t.Fatalf("SchemaStatus failed: %v", err)
+	}
+	if status.Version != CurrentSchemaVersion || status.Dirty {
+		t.Fatalf("schema = version %d dirty %v, want %d", status.Version, status.Dirty, CurrentSchemaVersion)
+	}
+
+	// Inserts read their ID with RETURNING and queries use $n placeholders
+	if err := db.CreateProvider(&Provider{ID: "openai", Name: "OpenAI", BaseURL: "https://api.openai.com/v1", AuthMethod: "bearer", PricingModel: "per_token", Status: "online"}); err != nil {
+		t.Fatalf("CreateProvider failed: %v", err)
+	}
+	if p, err := db.GetProvider("openai"); err != nil || p == nil || p.Name != "OpenAI" {
+		t.Fatalf("GetProvider = %+v, %v", p, err)
+	}
+	for i := 1; i <= 2; i++ {
+		if err := db.CreateRequestLog(testRequestLog(i)); err != nil {
+			t.Fatalf("CreateRequestLog failed: %v", err)
+		}
+	}
+	model := "gpt-4o"
+	logs, err := db.ListRequestLogs(&RequestLogFilter{Model: &model, Since: ptrTime(time.Now().Add(-time.Hour))})
+	if err != nil || len(logs) != 2 || logs[0].ID == logs[1].ID || logs[0].ID == 0 {
+		t.Fatalf("ListRequestLogs = %d logs, %v", len(logs), err)
+	}
+
+	// Upserts go through ON CONFLICT
+	canaries := NewCanaryResultRepository(db)
+	policy := &CanaryPolicy{PolicyID: "gpt5", ModelPattern: "gpt-4*", CanaryModel: "gpt-5", Percent: 10, MinSamples: 100, Status: "running"}
+	if err := canaries.SavePolicy(policy); err != nil {
+		t.Fatalf("SavePolicy failed: %v", err)
+	}
+	policy.Status, policy.Reason = "rolled_back", "error rate"
+	if err := canaries.SavePolicy(policy); err != nil {
+		t.Fatalf("SavePolicy update failed: %v", err)
+	}
+	policies, err := canaries.ListPolicies()
+	if err != nil || len(policies) != 1 || policies[0].Status != "rolled_back" {
+		t.Fatalf("ListPolicies = %+v, %v", policies, err)
+	}
+
+	// A failing batch entry is rolled back to its savepoint rather than
+	// aborting the transaction
+	w := NewBatchWriter(db, BatchConfig{MaxBatch: 10, FlushInterval: time.Hour})
+	w.LogRequest(testRequestLog(3))
+	w.RecordUsage(&UsageRecord{ModelID: "missing-model", Timestamp: time.Now(), Requests: 1})
+	w.LogRequest(testRequestLog(4))
+	w.Close()
+	if stats := w.Stats(); stats.Written != 2 || stats.Failed != 1 {
+		t.Fatalf("batch stats = %+v, want 2 written and 1 failed", stats)
+	}
+
+	// Down migrations run on PostgreSQL too
+	if reverted, err := db.MigrateDown(1); err != nil || len(reverted) != 1 {
+		t.Fatalf("MigrateDown = %v, %v", reverted, err)
+	}
+	if applied, err := db.MigrateUp(0); err != nil || len(applied) != 1 {
+		t.Fatalf("MigrateUp = %v, %v", applied, err)
+	}
+}
+
+// isolatedSchema creates a schema for the test, dropped when it ends, and
+// returns dsn with its search_path pointing there
+func isolatedSchema(t *testing.T, dsn string) string {
+	t.Helper()
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("modelscan_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Errorf("failed to drop schema %s: %v", schema, err)
+		}
+	})
+
+	if !strings.Contains(dsn, "://") {
+		return dsn + " search_path=" + schema
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("invalid MODELSCAN_TEST_POSTGRES_URL: %v", err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
@@ -0,0 +1,174 @@
+//go:build !postgres
+
+// The stub registers itself as "postgres", which the dialect prefers over
+// the pgx driver linked by the postgres tag, so it is left out of those
+// builds; postgres_integration_test.go covers a real server there.
+
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingDriver stands in for a PostgreSQL driver: it records every
+// statement and answers the few queries Open needs. Like PostgreSQL, it
+// refuses every statement in a transaction after one fails, until the
+// transaction or a savepoint is rolled back.
+type recordingDriver struct {
+	mu    sync.Mutex
+	stmts []string
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) { return &recordingConn{d: d}, nil }
+
+type recordingConn struct {
+	d       *recordingDriver
+	inTx    bool
+	aborted bool // A statement failed in the open transaction
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.mu.Lock()
+	c.d.stmts = append(c.d.stmts, query)
+	c.d.mu.Unlock()
+	return &recordingStmt{c: c, query: query}, nil
+}
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { c.inTx = true; return c, nil }
+func (c *recordingConn) Rollback() error           { c.inTx, c.aborted = false, false; return nil }
+func (c *recordingConn) Commit() error {
+	aborted := c.aborted
+	c.inTx, c.aborted = false, false
+	if aborted {
+		return errors.New("transaction was aborted and rolled back")
+	}
+	return nil
+}
+
+// rejectedValue is an argument the stub fails on, as a server would on a
+// foreign key violation
+const rejectedValue = "missing-model"
+
+type recordingStmt struct {
+	c     *recordingConn
+	query string
+}
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return -1 }
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.HasPrefix(s.query, "ROLLBACK TO SAVEPOINT") {
+		s.c.aborted = false
+		return driver.RowsAffected(0), nil
+	}
+	if s.c.aborted {
+		return nil, errors.New("current transaction is aborted, commands ignored until end of transaction block")
+	}
+	for _, arg := range args {
+		if arg == rejectedValue {
+			s.c.aborted = s.c.inTx
+			return nil, errors.New("violates foreign key constraint")
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+func (s *recordingStmt) Query([]driver.Value) (driver.Rows, error) {
+	if strings.Contains(s.query, "MAX(version)") || strings.Contains(s.query, "RETURNING id") {
+		return &recordingRows{values: []driver.Value{int64(0)}}, nil
+	}
+	return &recordingRows{}, nil
+}
+
+type recordingRows struct{ values []driver.Value }
+
+func (r *recordingRows) Columns() []string { return []string{"value"} }
+func (r *recordingRows) Close() error      { return nil }
+func (r *recordingRows) Next(dest []driver.Value) error {
+	if r.values == nil {
+		return io.EOF
+	}
+	copy(dest, r.values)
+	r.values = nil
+	return nil
+}
+
+var pgDriver = &recordingDriver{}
+
+func init() {
+	sql.Register("postgres", pgDriver)
+}
+
+// TestOpen_Postgres runs every migration and a few writes through the
+// PostgreSQL dialect and checks the SQL the server would receive
+func TestOpen_Postgres(t *testing.T) {
+	db, err := Open("postgres://modelscan@localhost/modelscan?sslmode=disable")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	if db.Dialect().Name() != "postgres" {
+		t.Fatalf("expected postgres dialect, got %s", db.Dialect().Name())
+	}
+	if err := db.CreateRequestLog(testRequestLog(1)); err != nil {
+		t.Fatalf("CreateRequestLog failed: %v", err)
+	}
+	if err := db.CreateScheduledRun(&ScheduledRun{Provider: "openai", Task: "validate", Trigger: "manual", Status: "success"}); err != nil {
+		t.Fatalf("CreateScheduledRun failed: %v", err)
+	}
+
+	pgDriver.mu.Lock()
+	stmts := append([]string(nil), pgDriver.stmts...)
+	pgDriver.mu.Unlock()
+
+	var tables, returning int
+	for _, stmt := range stmts {
+		for _, bad := range []string{"?", "AUTOINCREMENT", "INSERT OR", "PRAGMA", "DATETIME", "BOOLEAN DEFAULT 1", "BOOLEAN DEFAULT 0"} {
+			if strings.Contains(stmt, bad) {
+				t.Errorf("statement contains %q:\n%s", bad, stmt)
+			}
+		}
+		if strings.Count(strings.TrimRight(strings.TrimSpace(stmt), ";"), ";") > 0 {
+			t.Errorf("expected one statement per Exec:\n%s", stmt)
+		}
+		if strings.HasPrefix(strings.TrimSpace(stmt), "CREATE TABLE") {
+			tables++
+		}
+		if strings.HasSuffix(strings.TrimSpace(stmt), "RETURNING id") {
+			returning++
+		}
+	}
+	if tables < 15 {
+		t.Errorf("expected the full schema to be created, got %d tables", tables)
+	}
+	if returning != 2 {
+		t.Errorf("expected 2 inserts to read their ID with RETURNING, got %d", returning)
+	}
+}
+
+// TestBatchWriter_Postgres checks that a failing entry does not abort the
+// rest of its batch on PostgreSQL, where it would fail the transaction
+func TestBatchWriter_Postgres(t *testing.T) {
+	db, err := Open("postgres://modelscan@localhost/modelscan?sslmode=disable")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	w := NewBatchWriter(db, BatchConfig{MaxBatch: 10, FlushInterval: time.Hour})
+	w.LogRequest(testRequestLog(1))
+	w.RecordUsage(&UsageRecord{ModelID: rejectedValue, Timestamp: time.Now(), Requests: 1})
+	w.LogRequest(testRequestLog(2))
+	w.Close()
+
+	if stats := w.Stats(); stats.Written != 2 || stats.Failed != 1 || stats.Batches != 1 {
+		t.Errorf("unexpected stats %+v, want 2 written and 1 failed in one batch", stats)
+	}
+}
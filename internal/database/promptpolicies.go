@@ -0,0 +1,159 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Prompt policy positions
+const (
+	// PolicyPrepend places the policy text before the client's system prompt
+	PolicyPrepend = "prepend"
+	// PolicyAppend places the policy text after the client's system prompt
+	PolicyAppend = "append"
+)
+
+// PromptPolicy is an organization-mandated instruction added to the system
+// prompt of matching requests
+type PromptPolicy struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	ClientID     string    `json:"client_id"`     // Empty for global policies
+	ModelPattern string    `json:"model_pattern"` // Glob pattern like "gpt-4*"
+	Position     string    `json:"position"`      // "prepend" or "append"
+	Template     string    `json:"template"`      // Go text/template source
+	Priority     int       `json:"priority"`
+	Enabled      bool      `json:"enabled"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+const promptPolicyColumns = `id, name, client_id, model_pattern, position, template, priority, enabled, created_at`
+
+// PromptPolicyRepository provides CRUD operations for prompt policies
+type PromptPolicyRepository struct {
+	db *DB
+}
+
+// NewPromptPolicyRepository creates a new PromptPolicyRepository
+func NewPromptPolicyRepository(db *DB) *PromptPolicyRepository {
+	return &PromptPolicyRepository{db: db}
+}
+
+// Create inserts a new prompt policy
+func (r *PromptPolicyRepository) Create(p *PromptPolicy) error {
+	normalizePromptPolicy(p)
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO prompt_policies (name, client_id, model_pattern, position, template, priority, enabled, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	id, err := r.db.conn.Insert(query,
+		p.Name, nullableClientID(p.ClientID), p.ModelPattern, p.Position, p.Template, p.Priority, p.Enabled, p.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create prompt policy: %w", err)
+	}
+	p.ID = int(id)
+	return nil
+}
+
+// Get retrieves a prompt policy by ID, or nil if it does not exist
+func (r *PromptPolicyRepository) Get(id int) (*PromptPolicy, error) {
+	query := `SELECT ` + promptPolicyColumns + ` FROM prompt_policies WHERE id = ?`
+	p, err := scanPromptPolicy(r.db.conn.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt policy: %w", err)
+	}
+	return p, nil
+}
+
+// List retrieves all prompt policies in the order they are applied
+func (r *PromptPolicyRepository) List() ([]*PromptPolicy, error) {
+	return r.queryPolicies(`SELECT ` + promptPolicyColumns + ` FROM prompt_policies ORDER BY priority DESC, id ASC`)
+}
+
+// ListEnabled retrieves the enabled prompt policies in the order they are
+// applied. Used to build the proxy's in-memory policy set.
+func (r *PromptPolicyRepository) ListEnabled() ([]*PromptPolicy, error) {
+	return r.queryPolicies(`SELECT ` + promptPolicyColumns + ` FROM prompt_policies WHERE enabled = TRUE ORDER BY priority DESC, id ASC`)
+}
+
+// Update replaces a prompt policy
+func (r *PromptPolicyRepository) Update(p *PromptPolicy) error {
+	normalizePromptPolicy(p)
+	query := `
+		UPDATE prompt_policies
+		SET name = ?, client_id = ?, model_pattern = ?, position = ?, template = ?, priority = ?, enabled = ?
+		WHERE id = ?
+	`
+	result, err := r.db.conn.Exec(query,
+		p.Name, nullableClientID(p.ClientID), p.ModelPattern, p.Position, p.Template, p.Priority, p.Enabled, p.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update prompt policy: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("prompt policy not found: %d", p.ID)
+	}
+	return nil
+}
+
+// Delete removes a prompt policy by ID
+func (r *PromptPolicyRepository) Delete(id int) error {
+	result, err := r.db.conn.Exec(`DELETE FROM prompt_policies WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete prompt policy: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("prompt policy not found: %d", id)
+	}
+	return nil
+}
+
+// queryPolicies runs a query returning prompt policy rows
+func (r *PromptPolicyRepository) queryPolicies(query string, args ...interface{}) ([]*PromptPolicy, error) {
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt policies: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var policies []*PromptPolicy
+	for rows.Next() {
+		p, err := scanPromptPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan prompt policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// scanPromptPolicy scans a row selected with promptPolicyColumns
+func scanPromptPolicy(row rowScanner) (*PromptPolicy, error) {
+	p := &PromptPolicy{}
+	var clientID sql.NullString
+	err := row.Scan(&p.ID, &p.Name, &clientID, &p.ModelPattern, &p.Position, &p.Template, &p.Priority, &p.Enabled, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.ClientID = clientID.String
+	return p, nil
+}
+
+// normalizePromptPolicy fills in the default pattern and position
+func normalizePromptPolicy(p *PromptPolicy) {
+	if p.ModelPattern == "" {
+		p.ModelPattern = "*"
+	}
+	if p.Position == "" {
+		p.Position = PolicyPrepend
+	}
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPromptPolicyRepository(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "policies.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPromptPolicyRepository(db)
+	global := &PromptPolicy{Name: "disclaimer", Template: "Not legal advice.", Enabled: true}
+	if err := repo.Create(global); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if global.ID == 0 || global.ModelPattern != "*" || global.Position != PolicyPrepend {
+		t.Errorf("defaults not applied: %+v", global)
+	}
+	scoped := &PromptPolicy{Name: "tools", ClientID: "c1", ModelPattern: "gpt-*", Position: PolicyAppend,
+		Template: "Never call delete_*.", Priority: 10, Enabled: true}
+	if err := repo.Create(scoped); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	got, err := repo.Get(scoped.ID)
+	if err != nil || got == nil || got.ClientID != "c1" || got.Position != PolicyAppend || got.Priority != 10 {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+	if missing, err := repo.Get(999); missing != nil || err != nil {
+		t.Errorf("expected nil for a missing policy, got %+v, %v", missing, err)
+	}
+
+	// Higher priority first
+	if list, err := repo.List(); err != nil || len(list) != 2 || list[0].ID != scoped.ID {
+		t.Errorf("List = %+v, %v", list, err)
+	}
+
+	global.Enabled = false
+	if err := repo.Update(global); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if enabled, err := repo.ListEnabled(); err != nil || len(enabled) != 1 || enabled[0].ID != scoped.ID {
+		t.Errorf("ListEnabled = %+v, %v", enabled, err)
+	}
+
+	if err := repo.Delete(scoped.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := repo.Delete(scoped.ID); err == nil {
+		t.Error("expected error deleting a missing policy")
+	}
+}
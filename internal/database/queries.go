@@ -78,10 +78,39 @@ func (db *DB) ListProviders() ([]*Provider, error) {
 	return providers, rows.Err()
 }
 
+// UpdateProvider replaces a provider's static configuration; its status,
+// SDK and discovery history are kept
+func (db *DB) UpdateProvider(p *Provider) error {
+	query := `
+		UPDATE providers SET name = ?, base_url = ?, auth_method = ?, auth_header = ?, pricing_model = ?
+		WHERE id = ?
+	`
+	result, err := db.conn.Exec(query, p.Name, p.BaseURL, p.AuthMethod, p.AuthHeader, p.PricingModel, p.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update provider: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("provider not found: %s", p.ID)
+	}
+	return nil
+}
+
+// DeleteProvider deletes a provider along with its models and API keys
+func (db *DB) DeleteProvider(id string) error {
+	result, err := db.conn.Exec(`DELETE FROM providers WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete provider: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("provider not found: %s", id)
+	}
+	return nil
+}
+
 // UpdateProviderStatus updates provider status
 func (db *DB) UpdateProviderStatus(id, status string, lastError *string) error {
 	query := `UPDATE providers SET status = ?, last_error = ?, last_validated = ? WHERE id = ?`
-	_, err := db.conn.Exec(query, status, lastError, time.Now(), id)
+	_, err := db.conn.Exec(query, status, maskError(lastError), time.Now(), id)
 	return err
 }
 
@@ -92,6 +121,26 @@ func (db *DB) CreateModelFamily(f *ModelFamily) error {
 	return err
 }
 
+// ListModelFamilies lists all model families
+func (db *DB) ListModelFamilies() ([]*ModelFamily, error) {
+	query := `SELECT id, provider_id, name, description FROM model_families ORDER BY provider_id, name`
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var families []*ModelFamily
+	for rows.Next() {
+		f := &ModelFamily{}
+		if err := rows.Scan(&f.ID, &f.ProviderID, &f.Name, &f.Description); err != nil {
+			return nil, err
+		}
+		families = append(families, f)
+	}
+	return families, rows.Err()
+}
+
 // CreateModel inserts a new model
 func (db *DB) CreateModel(m *Model) error {
 	query := `
@@ -158,7 +207,7 @@ func (db *DB) ListModelsByStatus(status string) ([]*Model, error) {
 // UpdateModelStatus updates model status
 func (db *DB) UpdateModelStatus(id, status string, lastError *string) error {
 	query := `UPDATE models SET status = ?, last_error = ?, last_tested = ? WHERE id = ?`
-	_, err := db.conn.Exec(query, status, lastError, time.Now(), id)
+	_, err := db.conn.Exec(query, status, maskError(lastError), time.Now(), id)
 	return err
 }
 
@@ -177,13 +226,23 @@ func (db *DB) CreateAPIKey(providerID, apiKey string) (*APIKey, error) {
 		keyPrefix = &prefix
 	}
 
+	// The value itself is only stored when it can be encrypted
+	var keySecret *string
+	if db.encrypting() {
+		sealed, err := db.sealSecret(apiKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt key: %w", err)
+		}
+		keySecret = &sealed
+	}
+
 	query := `
-		INSERT INTO api_keys (provider_id, key_hash, key_prefix)
-		VALUES (?, ?, ?)
+		INSERT INTO api_keys (provider_id, key_hash, key_prefix, key_secret)
+		VALUES (?, ?, ?, ?)
 		RETURNING id
 	`
 	var id int
-	err := db.conn.QueryRow(query, providerID, keyHash, keyPrefix).Scan(&id)
+	err := db.conn.QueryRow(query, providerID, keyHash, keyPrefix, keySecret).Scan(&id)
 	if err != nil {
 		return nil, err
 	}
@@ -191,16 +250,28 @@ func (db *DB) CreateAPIKey(providerID, apiKey string) (*APIKey, error) {
 	return db.GetAPIKey(id)
 }
 
-// GetAPIKey retrieves an API key by ID
-func (db *DB) GetAPIKey(id int) (*APIKey, error) {
-	query := `SELECT * FROM api_keys WHERE id = ?`
+// apiKeyColumns lists the api_keys columns in scanAPIKey order
+const apiKeyColumns = `id, provider_id, key_hash, key_prefix, tier,
+	rpm_limit, tpm_limit, daily_limit, reset_interval,
+	last_reset, requests_count, tokens_count,
+	active, degraded, degraded_until, created_at, tenant_id, expires_at`
+
+// scanAPIKey reads an api_keys row selected with apiKeyColumns
+func scanAPIKey(row rowScanner) (*APIKey, error) {
 	k := &APIKey{}
-	err := db.conn.QueryRow(query, id).Scan(
+	err := row.Scan(
 		&k.ID, &k.ProviderID, &k.KeyHash, &k.KeyPrefix, &k.Tier,
 		&k.RPMLimit, &k.TPMLimit, &k.DailyLimit, &k.ResetInterval,
 		&k.LastReset, &k.RequestsCount, &k.TokensCount,
-		&k.Active, &k.Degraded, &k.DegradedUntil, &k.CreatedAt,
+		&k.Active, &k.Degraded, &k.DegradedUntil, &k.CreatedAt, &k.TenantID, &k.ExpiresAt,
 	)
+	return k, err
+}
+
+// GetAPIKey retrieves an API key by ID
+func (db *DB) GetAPIKey(id int) (*APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = ?`
+	k, err := scanAPIKey(db.conn.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -214,14 +285,16 @@ func (db *DB) DeleteAPIKey(id int) error {
 	return err
 }
 
-// ListActiveAPIKeys lists active, non-degraded API keys for a provider
+// ListActiveAPIKeys lists active, non-degraded, unexpired API keys for a
+// provider
 func (db *DB) ListActiveAPIKeys(providerID string) ([]*APIKey, error) {
 	query := `
-		SELECT * FROM api_keys
-		WHERE provider_id = ? AND active = 1 AND degraded = 0
+		SELECT ` + apiKeyColumns + ` FROM api_keys
+		WHERE provider_id = ? AND active = TRUE AND degraded = FALSE
+		  AND (expires_at IS NULL OR expires_at > ?)
 		ORDER BY requests_count ASC, tokens_count ASC
 	`
-	rows, err := db.conn.Query(query, providerID)
+	rows, err := db.conn.Query(query, providerID, time.Now())
 	if err != nil {
 		return nil, err
 	}
@@ -229,13 +302,27 @@ func (db *DB) ListActiveAPIKeys(providerID string) ([]*APIKey, error) {
 
 	var keys []*APIKey
 	for rows.Next() {
-		k := &APIKey{}
-		err := rows.Scan(
-			&k.ID, &k.ProviderID, &k.KeyHash, &k.KeyPrefix, &k.Tier,
-			&k.RPMLimit, &k.TPMLimit, &k.DailyLimit, &k.ResetInterval,
-			&k.LastReset, &k.RequestsCount, &k.TokensCount,
-			&k.Active, &k.Degraded, &k.DegradedUntil, &k.CreatedAt,
-		)
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// ListAPIKeys lists every API key, including inactive and degraded ones
+func (db *DB) ListAPIKeys() ([]*APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY provider_id, id`
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var keys []*APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -246,19 +333,22 @@ func (db *DB) ListActiveAPIKeys(providerID string) ([]*APIKey, error) {
 
 // IncrementKeyUsage increments request and token counts for an API key
 func (db *DB) IncrementKeyUsage(keyID int, tokens int) error {
-	query := `
+	stmt, err := db.prepare(`
 		UPDATE api_keys
 		SET requests_count = requests_count + 1,
 		    tokens_count = tokens_count + ?
 		WHERE id = ?
-	`
-	_, err := db.conn.Exec(query, tokens, keyID)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(tokens, keyID)
 	return err
 }
 
 // MarkKeyDegraded marks an API key as degraded until a specific time
 func (db *DB) MarkKeyDegraded(keyID int, until time.Time) error {
-	query := `UPDATE api_keys SET degraded = 1, degraded_until = ? WHERE id = ?`
+	query := `UPDATE api_keys SET degraded = TRUE, degraded_until = ? WHERE id = ?`
 	_, err := db.conn.Exec(query, until, keyID)
 	return err
 }
@@ -269,7 +359,7 @@ func (db *DB) ResetKeyLimits(keyID int) error {
 		UPDATE api_keys
 		SET requests_count = 0,
 		    tokens_count = 0,
-		    degraded = 0,
+		    degraded = FALSE,
 		    degraded_until = NULL,
 		    last_reset = ?
 		WHERE id = ?
@@ -289,18 +379,29 @@ func (db *DB) CountAPIKeys() (int, error) {
 	return count, nil
 }
 
+// insertUsageQuery inserts a usage_tracking row; shared with BatchWriter
+const insertUsageQuery = `
+	INSERT INTO usage_tracking (
+		model_id, api_key_id, timestamp, tokens_in, tokens_out, tokens_reasoning,
+		requests, cost, latency_ms, success, error
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+// usageArgs returns the insertUsageQuery arguments for a record
+func usageArgs(u *UsageRecord) []interface{} {
+	return []interface{}{
+		u.ModelID, u.APIKeyID, u.Timestamp, u.TokensIn, u.TokensOut, u.TokensReasoning,
+		u.Requests, u.Cost, u.LatencyMS, u.Success, maskError(u.Error),
+	}
+}
+
 // RecordUsage records a usage event
 func (db *DB) RecordUsage(u *UsageRecord) error {
-	query := `
-		INSERT INTO usage_tracking (
-			model_id, api_key_id, timestamp, tokens_in, tokens_out, tokens_reasoning,
-			requests, cost, latency_ms, success, error
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-	_, err := db.conn.Exec(query,
-		u.ModelID, u.APIKeyID, u.Timestamp, u.TokensIn, u.TokensOut, u.TokensReasoning,
-		u.Requests, u.Cost, u.LatencyMS, u.Success, u.Error,
-	)
+	stmt, err := db.prepare(insertUsageQuery)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(usageArgs(u)...)
 	return err
 }
 
@@ -328,7 +429,7 @@ func (db *DB) UpdateDiscoveryLog(id int, status string, findings, sourcesScraped
 		    error = ?
 		WHERE id = ?
 	`
-	_, execErr := db.conn.Exec(query, time.Now(), status, findings, sourcesScraped, totalCost, err, id)
+	_, execErr := db.conn.Exec(query, time.Now(), status, findings, sourcesScraped, totalCost, maskError(err), id)
 	return execErr
 }
 
@@ -419,7 +520,7 @@ func (db *DB) GetUsageStats(modelID string, since time.Time) (map[string]interfa
 			SUM(tokens_out) as total_tokens_out,
 			SUM(cost) as total_cost,
 			AVG(latency_ms) as avg_latency_ms,
-			SUM(CASE WHEN success = 1 THEN 1 ELSE 0 END) as successful_requests
+			SUM(CASE WHEN success = TRUE THEN 1 ELSE 0 END) as successful_requests
 		FROM usage_tracking
 		WHERE model_id = ? AND timestamp >= ?
 	`
@@ -22,18 +22,13 @@ func (r *ClientRateLimitRepository) Create(rl *ClientRateLimit) error {
 		INSERT INTO client_rate_limits (client_id, rpm_limit, tpm_limit, daily_limit, current_rpm, current_tpm, current_daily, last_reset)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	result, err := r.db.conn.Exec(query,
+	id, err := r.db.conn.Insert(query,
 		rl.ClientID, rl.RPMLimit, rl.TPMLimit, rl.DailyLimit,
 		rl.CurrentRPM, rl.CurrentTPM, rl.CurrentDaily, rl.LastReset,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create client rate limit: %w", err)
 	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("failed to get last insert id: %w", err)
-	}
 	rl.ID = int(id)
 	return nil
 }
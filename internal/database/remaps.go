@@ -2,23 +2,44 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 )
 
+// Remap strategies
+const (
+	// RemapStrategyDirect rewrites to ToModel/ToProvider
+	RemapStrategyDirect = "direct"
+	// RemapStrategyCheapest picks the lowest-priced entry from Candidates
+	RemapStrategyCheapest = "cheapest"
+	// RemapStrategyFastest picks the entry from Candidates whose provider
+	// is usually fastest at the current hour of the week
+	RemapStrategyFastest = "fastest"
+)
+
 // RemapRule represents a model remapping rule in the database
 type RemapRule struct {
 	ID         int
-	ClientID   string
+	ClientID   string // Empty for global rules that apply to every client
 	FromModel  string // Supports glob patterns like "claude-*"
 	ToModel    string
 	ToProvider string
+	Strategy   string   // "direct" (default), "cheapest" or "fastest"
+	Candidates []string // "provider/model" entries considered by the cheapest and fastest strategies
 	Priority   int
 	Enabled    bool
 	CreatedAt  time.Time
 }
 
+// IsGlobal reports whether the rule applies to all clients
+func (r *RemapRule) IsGlobal() bool {
+	return r.ClientID == ""
+}
+
+const remapRuleColumns = `id, client_id, from_model, to_model, to_provider, strategy, candidates, priority, enabled, created_at`
+
 // RemapRuleRepository provides CRUD operations for remap rules
 type RemapRuleRepository struct {
 	db *DB
@@ -31,37 +52,32 @@ func NewRemapRuleRepository(db *DB) *RemapRuleRepository {
 
 // Create inserts a new remap rule
 func (r *RemapRuleRepository) Create(rule *RemapRule) error {
+	candidates, err := encodeCandidates(rule.Candidates)
+	if err != nil {
+		return err
+	}
+
 	query := `
-		INSERT INTO remap_rules (client_id, from_model, to_model, to_provider, priority, enabled)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO remap_rules (client_id, from_model, to_model, to_provider, strategy, candidates, priority, enabled)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	result, err := r.db.conn.Exec(query,
-		rule.ClientID, rule.FromModel, rule.ToModel, rule.ToProvider, rule.Priority, rule.Enabled,
+	id, err := r.db.conn.Insert(query,
+		nullableClientID(rule.ClientID), rule.FromModel, rule.ToModel, rule.ToProvider,
+		normalizeStrategy(rule.Strategy), candidates, rule.Priority, rule.Enabled,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create remap rule: %w", err)
 	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		return fmt.Errorf("failed to get last insert id: %w", err)
-	}
 	rule.ID = int(id)
+	rule.Strategy = normalizeStrategy(rule.Strategy)
 
 	return nil
 }
 
 // Get retrieves a remap rule by ID
 func (r *RemapRuleRepository) Get(id int) (*RemapRule, error) {
-	query := `
-		SELECT id, client_id, from_model, to_model, to_provider, priority, enabled, created_at
-		FROM remap_rules WHERE id = ?
-	`
-	rule := &RemapRule{}
-	err := r.db.conn.QueryRow(query, id).Scan(
-		&rule.ID, &rule.ClientID, &rule.FromModel, &rule.ToModel,
-		&rule.ToProvider, &rule.Priority, &rule.Enabled, &rule.CreatedAt,
-	)
+	query := `SELECT ` + remapRuleColumns + ` FROM remap_rules WHERE id = ?`
+	rule, err := scanRemapRule(r.db.conn.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -71,44 +87,23 @@ func (r *RemapRuleRepository) Get(id int) (*RemapRule, error) {
 	return rule, nil
 }
 
-// List retrieves all remap rules, optionally filtered by client ID
+// List retrieves all remap rules, optionally filtered by client ID.
+// An empty client ID filter returns only global rules.
 func (r *RemapRuleRepository) List(clientID *string) ([]*RemapRule, error) {
 	var query string
 	var args []interface{}
 
-	if clientID == nil {
-		query = `
-			SELECT id, client_id, from_model, to_model, to_provider, priority, enabled, created_at
-			FROM remap_rules ORDER BY priority DESC, id ASC
-		`
-	} else {
-		query = `
-			SELECT id, client_id, from_model, to_model, to_provider, priority, enabled, created_at
-			FROM remap_rules WHERE client_id = ?
-			ORDER BY priority DESC, id ASC
-		`
+	switch {
+	case clientID == nil:
+		query = `SELECT ` + remapRuleColumns + ` FROM remap_rules ORDER BY priority DESC, id ASC`
+	case *clientID == "":
+		query = `SELECT ` + remapRuleColumns + ` FROM remap_rules WHERE client_id IS NULL ORDER BY priority DESC, id ASC`
+	default:
+		query = `SELECT ` + remapRuleColumns + ` FROM remap_rules WHERE client_id = ? ORDER BY priority DESC, id ASC`
 		args = []interface{}{*clientID}
 	}
 
-	rows, err := r.db.conn.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list remap rules: %w", err)
-	}
-	defer func() { _ = rows.Close() }()
-
-	var rules []*RemapRule
-	for rows.Next() {
-		rule := &RemapRule{}
-		err := rows.Scan(
-			&rule.ID, &rule.ClientID, &rule.FromModel, &rule.ToModel,
-			&rule.ToProvider, &rule.Priority, &rule.Enabled, &rule.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan remap rule: %w", err)
-		}
-		rules = append(rules, rule)
-	}
-	return rules, rows.Err()
+	return r.queryRules(query, args...)
 }
 
 // ListByClientID retrieves all remap rules for a specific client
@@ -116,16 +111,28 @@ func (r *RemapRuleRepository) ListByClientID(clientID string) ([]*RemapRule, err
 	return r.List(&clientID)
 }
 
+// ListEnabled retrieves every enabled rule, client-specific and global,
+// ordered by priority. Used to build the in-memory remap table.
+func (r *RemapRuleRepository) ListEnabled() ([]*RemapRule, error) {
+	query := `SELECT ` + remapRuleColumns + ` FROM remap_rules WHERE enabled = TRUE ORDER BY priority DESC, id ASC`
+	return r.queryRules(query)
+}
+
 // Update updates an existing remap rule
 func (r *RemapRuleRepository) Update(rule *RemapRule) error {
+	candidates, err := encodeCandidates(rule.Candidates)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE remap_rules
-		SET client_id = ?, from_model = ?, to_model = ?, to_provider = ?, priority = ?, enabled = ?
+		SET client_id = ?, from_model = ?, to_model = ?, to_provider = ?, strategy = ?, candidates = ?, priority = ?, enabled = ?
 		WHERE id = ?
 	`
 	result, err := r.db.conn.Exec(query,
-		rule.ClientID, rule.FromModel, rule.ToModel, rule.ToProvider,
-		rule.Priority, rule.Enabled, rule.ID,
+		nullableClientID(rule.ClientID), rule.FromModel, rule.ToModel, rule.ToProvider,
+		normalizeStrategy(rule.Strategy), candidates, rule.Priority, rule.Enabled, rule.ID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update remap rule: %w", err)
@@ -162,37 +169,105 @@ func (r *RemapRuleRepository) Delete(id int) error {
 }
 
 // FindMatching finds the highest-priority enabled remap rule that matches the given model
-// for the specified client. Returns nil if no matching rule is found.
+// for the specified client. Client-specific rules take precedence over global rules.
+// Returns nil if no matching rule is found.
 func (r *RemapRuleRepository) FindMatching(model string, clientID string) (*RemapRule, error) {
-	// Fetch all enabled rules for this client, ordered by priority descending
+	// Client rules sort before global rules, then by priority descending
 	query := `
-		SELECT id, client_id, from_model, to_model, to_provider, priority, enabled, created_at
+		SELECT ` + remapRuleColumns + `
 		FROM remap_rules
-		WHERE client_id = ? AND enabled = 1
-		ORDER BY priority DESC, id ASC
+		WHERE (client_id = ? OR client_id IS NULL) AND enabled = TRUE
+		ORDER BY client_id IS NULL, priority DESC, id ASC
 	`
-	rows, err := r.db.conn.Query(query, clientID)
+	rules, err := r.queryRules(query, clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		if matchGlob(rule.FromModel, model) {
+			return rule, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// MatchGlob reports whether s matches the remap glob pattern
+func MatchGlob(pattern, s string) bool {
+	return matchGlob(pattern, s)
+}
+
+// queryRules runs a query returning remap rule rows
+func (r *RemapRuleRepository) queryRules(query string, args ...interface{}) ([]*RemapRule, error) {
+	rows, err := r.db.conn.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query remap rules: %w", err)
+		return nil, fmt.Errorf("failed to list remap rules: %w", err)
 	}
 	defer func() { _ = rows.Close() }()
 
+	var rules []*RemapRule
 	for rows.Next() {
-		rule := &RemapRule{}
-		err := rows.Scan(
-			&rule.ID, &rule.ClientID, &rule.FromModel, &rule.ToModel,
-			&rule.ToProvider, &rule.Priority, &rule.Enabled, &rule.CreatedAt,
-		)
+		rule, err := scanRemapRule(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan remap rule: %w", err)
 		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
 
-		if matchGlob(rule.FromModel, model) {
-			return rule, nil
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanRemapRule scans a row selected with remapRuleColumns
+func scanRemapRule(row rowScanner) (*RemapRule, error) {
+	rule := &RemapRule{}
+	var clientID, candidates sql.NullString
+	err := row.Scan(
+		&rule.ID, &clientID, &rule.FromModel, &rule.ToModel, &rule.ToProvider,
+		&rule.Strategy, &candidates, &rule.Priority, &rule.Enabled, &rule.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rule.ClientID = clientID.String
+	if candidates.Valid && candidates.String != "" {
+		if err := json.Unmarshal([]byte(candidates.String), &rule.Candidates); err != nil {
+			return nil, fmt.Errorf("failed to decode candidates: %w", err)
 		}
 	}
+	return rule, nil
+}
+
+// nullableClientID stores global rules with a NULL client_id
+func nullableClientID(clientID string) interface{} {
+	if clientID == "" {
+		return nil
+	}
+	return clientID
+}
+
+// normalizeStrategy defaults an empty strategy to direct
+func normalizeStrategy(strategy string) string {
+	if strategy == "" {
+		return RemapStrategyDirect
+	}
+	return strategy
+}
 
-	return nil, rows.Err()
+// encodeCandidates serializes candidates to JSON, storing NULL when empty
+func encodeCandidates(candidates []string) (interface{}, error) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(candidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode candidates: %w", err)
+	}
+	return string(data), nil
 }
 
 // matchGlob performs glob pattern matching with support for:
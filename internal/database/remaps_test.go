@@ -402,3 +402,118 @@ func TestFindMatching(t *testing.T) {
 		}
 	})
 }
+
+func TestRemapRuleRepository_GlobalAndCheapest(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "modelscan-test-*.db")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	tmpFile.Close()
+
+	db, err := Open(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	clientRepo := NewClientRepository(db)
+	if err := clientRepo.Create(&Client{
+		ID:           "client-a",
+		Name:         "Client A",
+		Version:      "1.0.0",
+		Token:        "token-a",
+		Capabilities: []string{"chat"},
+		Config:       ClientConfig{},
+	}); err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	repo := NewRemapRuleRepository(db)
+
+	global := &RemapRule{
+		FromModel:  "gpt-4*",
+		Strategy:   RemapStrategyCheapest,
+		Candidates: []string{"openai/gpt-4o-mini", "together/meta-llama/Llama-3.3-70B-Instruct-Turbo"},
+		Priority:   100,
+		Enabled:    true,
+	}
+	if err := repo.Create(global); err != nil {
+		t.Fatalf("failed to create global rule: %v", err)
+	}
+
+	client := &RemapRule{
+		ClientID:   "client-a",
+		FromModel:  "gpt-4o",
+		ToModel:    "claude-sonnet-4-5",
+		ToProvider: "anthropic",
+		Priority:   1,
+		Enabled:    true,
+	}
+	if err := repo.Create(client); err != nil {
+		t.Fatalf("failed to create client rule: %v", err)
+	}
+	if client.Strategy != RemapStrategyDirect {
+		t.Errorf("expected default strategy %q, got %q", RemapStrategyDirect, client.Strategy)
+	}
+
+	t.Run("global rule round-trip", func(t *testing.T) {
+		got, err := repo.Get(global.ID)
+		if err != nil {
+			t.Fatalf("failed to get rule: %v", err)
+		}
+		if !got.IsGlobal() {
+			t.Errorf("expected global rule, got client_id %q", got.ClientID)
+		}
+		if got.Strategy != RemapStrategyCheapest {
+			t.Errorf("expected strategy %q, got %q", RemapStrategyCheapest, got.Strategy)
+		}
+		if len(got.Candidates) != 2 || got.Candidates[1] != "together/meta-llama/Llama-3.3-70B-Instruct-Turbo" {
+			t.Errorf("unexpected candidates: %v", got.Candidates)
+		}
+	})
+
+	t.Run("list global only", func(t *testing.T) {
+		empty := ""
+		rules, err := repo.List(&empty)
+		if err != nil {
+			t.Fatalf("failed to list rules: %v", err)
+		}
+		if len(rules) != 1 || rules[0].ID != global.ID {
+			t.Errorf("expected only the global rule, got %d rules", len(rules))
+		}
+	})
+
+	t.Run("find matching prefers client rules", func(t *testing.T) {
+		rule, err := repo.FindMatching("gpt-4o", "client-a")
+		if err != nil {
+			t.Fatalf("failed to find matching: %v", err)
+		}
+		if rule == nil || rule.ID != client.ID {
+			t.Errorf("expected client rule despite lower priority, got %+v", rule)
+		}
+	})
+
+	t.Run("find matching falls back to global", func(t *testing.T) {
+		rule, err := repo.FindMatching("gpt-4-turbo", "other-client")
+		if err != nil {
+			t.Fatalf("failed to find matching: %v", err)
+		}
+		if rule == nil || rule.ID != global.ID {
+			t.Errorf("expected global rule, got %+v", rule)
+		}
+	})
+
+	t.Run("list enabled", func(t *testing.T) {
+		if err := repo.SetEnabled(client.ID, false); err != nil {
+			t.Fatalf("failed to disable rule: %v", err)
+		}
+		rules, err := repo.ListEnabled()
+		if err != nil {
+			t.Fatalf("failed to list enabled: %v", err)
+		}
+		if len(rules) != 1 || rules[0].ID != global.ID {
+			t.Errorf("expected only the global rule enabled, got %d rules", len(rules))
+		}
+	})
+}
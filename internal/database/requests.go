@@ -49,24 +49,27 @@ type RequestLogStats struct {
 	RequestsByClient map[string]int
 }
 
-// CreateRequestLog inserts a new request log entry
-func (db *DB) CreateRequestLog(log *RequestLog) error {
-	query := `
-		INSERT INTO request_logs (client_id, provider, model, endpoint, request_tokens, response_tokens, latency_ms, status_code, error_message, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-	result, err := db.conn.Exec(query,
+// insertRequestLogQuery inserts a request_logs row; shared with BatchWriter
+const insertRequestLogQuery = `
+	INSERT INTO request_logs (client_id, provider, model, endpoint, request_tokens, response_tokens, latency_ms, status_code, error_message, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+// requestLogArgs returns the insertRequestLogQuery arguments for a log
+// entry, masking credentials echoed in the error message
+func requestLogArgs(log *RequestLog) []interface{} {
+	return []interface{}{
 		log.ClientID, log.Provider, log.Model, log.Endpoint,
 		log.RequestTokens, log.ResponseTokens, log.LatencyMs,
-		log.StatusCode, log.ErrorMessage, log.CreatedAt,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create request log: %w", err)
+		log.StatusCode, maskError(log.ErrorMessage), log.CreatedAt,
 	}
+}
 
-	id, err := result.LastInsertId()
+// CreateRequestLog inserts a new request log entry
+func (db *DB) CreateRequestLog(log *RequestLog) error {
+	id, err := db.insertPrepared(insertRequestLogQuery, requestLogArgs(log)...)
 	if err != nil {
-		return fmt.Errorf("failed to get last insert id: %w", err)
+		return fmt.Errorf("failed to create request log: %w", err)
 	}
 	log.ID = int(id)
 
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// ScheduledRun is one execution of a scheduled provider task
+type ScheduledRun struct {
+	ID         int
+	Provider   string
+	Task       string
+	Trigger    string
+	Status     string
+	Details    *string
+	Error      *string
+	StartedAt  time.Time
+	DurationMs int64
+}
+
+// ScheduledRunFilter defines filtering options for listing scheduled runs
+type ScheduledRunFilter struct {
+	Provider *string
+	Task     *string
+	Since    *time.Time
+	Limit    int
+	Offset   int
+}
+
+// CreateScheduledRun records a scheduled or manually triggered task run
+func (db *DB) CreateScheduledRun(run *ScheduledRun) error {
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO scheduled_runs (
+			provider, task, trigger_type, status, details, error, started_at, duration_ms
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	id, err := db.conn.Insert(query,
+		run.Provider, run.Task, run.Trigger, run.Status,
+		run.Details, maskError(run.Error), run.StartedAt, run.DurationMs,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create scheduled run: %w", err)
+	}
+	run.ID = int(id)
+
+	return nil
+}
+
+// ListScheduledRuns retrieves run history with optional filtering, newest first
+func (db *DB) ListScheduledRuns(filter *ScheduledRunFilter) ([]*ScheduledRun, error) {
+	query := `
+		SELECT id, provider, task, trigger_type, status, details, error, started_at, duration_ms
+		FROM scheduled_runs WHERE 1=1`
+	var args []interface{}
+
+	if filter != nil {
+		if filter.Provider != nil {
+			query += " AND provider = ?"
+			args = append(args, *filter.Provider)
+		}
+		if filter.Task != nil {
+			query += " AND task = ?"
+			args = append(args, *filter.Task)
+		}
+		if filter.Since != nil {
+			query += " AND started_at >= ?"
+			args = append(args, *filter.Since)
+		}
+	}
+	query += " ORDER BY started_at DESC, id DESC"
+
+	if filter != nil && filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+		if filter.Offset > 0 {
+			query += " OFFSET ?"
+			args = append(args, filter.Offset)
+		}
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var runs []*ScheduledRun
+	for rows.Next() {
+		run := &ScheduledRun{}
+		err := rows.Scan(
+			&run.ID, &run.Provider, &run.Task, &run.Trigger, &run.Status,
+			&run.Details, &run.Error, &run.StartedAt, &run.DurationMs,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating scheduled runs: %w", err)
+	}
+
+	return runs, nil
+}
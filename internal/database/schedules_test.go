@@ -0,0 +1,70 @@
+package database
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestScheduledRuns(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "modelscan-test-*.db")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	tmpFile.Close()
+
+	db, err := Open(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	details := "11 rate limits, 3 prices (1 changed)"
+	runErr := "401 unauthorized"
+	runs := []*ScheduledRun{
+		{Provider: "openai", Task: "validate", Trigger: "scheduled", Status: "failed", Error: &runErr,
+			StartedAt: time.Now().Add(-2 * time.Hour), DurationMs: 120},
+		{Provider: "openai", Task: "pricing", Trigger: "manual", Status: "success", Details: &details,
+			StartedAt: time.Now().Add(-time.Hour), DurationMs: 8},
+		{Provider: "anthropic", Task: "validate", Trigger: "scheduled", Status: "success"},
+	}
+	for _, run := range runs {
+		if err := db.CreateScheduledRun(run); err != nil {
+			t.Fatalf("CreateScheduledRun() error = %v", err)
+		}
+		if run.ID == 0 {
+			t.Error("expected ID to be set")
+		}
+	}
+
+	all, err := db.ListScheduledRuns(nil)
+	if err != nil {
+		t.Fatalf("ListScheduledRuns() error = %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 runs, got %d", len(all))
+	}
+	if all[0].Provider != "anthropic" || all[2].Task != "validate" || all[2].Error == nil || *all[2].Error != runErr {
+		t.Errorf("expected newest run first, got %+v", all)
+	}
+
+	provider := "openai"
+	task := "pricing"
+	filtered, err := db.ListScheduledRuns(&ScheduledRunFilter{Provider: &provider, Task: &task})
+	if err != nil {
+		t.Fatalf("ListScheduledRuns() error = %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].Trigger != "manual" || filtered[0].DurationMs != 8 ||
+		filtered[0].Details == nil || *filtered[0].Details != details {
+		t.Errorf("unexpected filtered runs %+v", filtered)
+	}
+
+	limited, err := db.ListScheduledRuns(&ScheduledRunFilter{Provider: &provider, Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("ListScheduledRuns() error = %v", err)
+	}
+	if len(limited) != 1 || limited[0].Task != "validate" {
+		t.Errorf("unexpected paginated runs %+v", limited)
+	}
+}
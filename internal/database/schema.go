@@ -3,411 +3,233 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/jeffersonwarrior/modelscan/internal/database/dialect"
+	"github.com/jeffersonwarrior/modelscan/internal/secrets"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 const (
-	CurrentSchemaVersion = 5
+	CurrentSchemaVersion = 24
 )
 
-// DB wraps the SQLite database
+// DB wraps the SQLite or PostgreSQL database
 type DB struct {
-	conn *sql.DB
+	conn *dialect.DB
 	path string
+
+	// Prepared statements for hot write paths, keyed by query
+	stmtMu sync.Mutex
+	stmts  map[string]*sql.Stmt
+
+	// Secret encryption, set by EnableEncryption
+	secretMu sync.RWMutex
+	master   *secrets.MasterKey
+	keyring  *secrets.Keyring
 }
 
-// Open opens or creates the SQLite database
-func Open(path string) (*DB, error) {
-	conn, err := sql.Open("sqlite3", path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
-	}
+// Options holds connection settings. Empty pragma values keep SQLite's
+// defaults; PostgreSQL connections ignore the pragmas.
+type Options struct {
+	// JournalMode is DELETE, TRUNCATE, PERSIST, MEMORY, WAL or OFF
+	JournalMode string
+	// Synchronous is OFF, NORMAL, FULL or EXTRA
+	Synchronous string
+	// BusyTimeout is how long a writer waits on a locked database
+	BusyTimeout time.Duration
+
+	// ManualMigrations stops Open from applying pending migrations; it
+	// fails with ErrSchemaOutdated instead. Applies to every dialect.
+	ManualMigrations bool
+
+	// MasterKey enables encryption of stored secrets; see EnableEncryption
+	MasterKey *secrets.MasterKey
+}
 
-	// Enable foreign keys
-	if _, err := conn.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+// DefaultOptions returns pragmas suited to concurrent writers: WAL
+// journaling with NORMAL sync, which is durable across application crashes
+// and only loses the last transactions on power loss
+func DefaultOptions() Options {
+	return Options{
+		JournalMode: "WAL",
+		Synchronous: "NORMAL",
+		BusyTimeout: 5 * time.Second,
 	}
+}
 
-	db := &DB{
-		conn: conn,
-		path: path,
-	}
+var (
+	journalModes = map[string]bool{"DELETE": true, "TRUNCATE": true, "PERSIST": true, "MEMORY": true, "WAL": true, "OFF": true}
+	syncModes    = map[string]bool{"OFF": true, "NORMAL": true, "FULL": true, "EXTRA": true}
+)
 
-	// Run migrations
-	if err := db.migrate(); err != nil {
-		return nil, fmt.Errorf("migration failed: %w", err)
+// dsn appends the pragmas as connection parameters so that every pooled
+// connection gets them, not just the first one
+func (o Options) dsn(path string) (string, error) {
+	params := url.Values{}
+	params.Set("_foreign_keys", "on")
+	if o.JournalMode != "" {
+		mode := strings.ToUpper(o.JournalMode)
+		if !journalModes[mode] {
+			return "", fmt.Errorf("invalid journal mode %q", o.JournalMode)
+		}
+		params.Set("_journal_mode", mode)
+	}
+	if o.Synchronous != "" {
+		mode := strings.ToUpper(o.Synchronous)
+		if !syncModes[mode] {
+			return "", fmt.Errorf("invalid synchronous mode %q", o.Synchronous)
+		}
+		params.Set("_synchronous", mode)
+	}
+	if o.BusyTimeout < 0 {
+		return "", fmt.Errorf("busy timeout must not be negative")
+	}
+	if o.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(o.BusyTimeout.Milliseconds(), 10))
 	}
 
-	return db, nil
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + params.Encode(), nil
 }
 
-// Close closes the database connection
-func (db *DB) Close() error {
-	return db.conn.Close()
+// Open opens or creates the database. path is a SQLite file path or a
+// PostgreSQL connection string (postgres://...).
+func Open(path string) (*DB, error) {
+	return OpenWithOptions(path, Options{})
 }
 
-// migrate runs database migrations
-func (db *DB) migrate() error {
-	// Create schema_version table if not exists
-	_, err := db.conn.Exec(`
-		CREATE TABLE IF NOT EXISTS schema_version (
-			version INTEGER PRIMARY KEY,
-			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)
-	`)
+// OpenWithOptions opens or creates the database with the given options
+func OpenWithOptions(path string, opts Options) (*DB, error) {
+	db, err := OpenForMigration(path, opts)
 	if err != nil {
-		return fmt.Errorf("failed to create schema_version table: %w", err)
+		return nil, err
 	}
 
-	// Check current version
-	var currentVersion int
-	err = db.conn.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&currentVersion)
+	if opts.ManualMigrations {
+		err = db.checkSchema()
+	} else {
+		err = db.migrate()
+	}
 	if err != nil {
-		return fmt.Errorf("failed to get current version: %w", err)
+		db.Close()
+		return nil, fmt.Errorf("migration failed: %w", err)
 	}
 
-	// Run migrations
-	for version := currentVersion + 1; version <= CurrentSchemaVersion; version++ {
-		if err := db.runMigration(version); err != nil {
-			return fmt.Errorf("migration %d failed: %w", version, err)
+	if opts.MasterKey != nil {
+		if err := db.EnableEncryption(opts.MasterKey); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to enable encryption: %w", err)
 		}
 	}
 
-	return nil
+	return db, nil
 }
 
-// runMigration runs a specific migration version
-func (db *DB) runMigration(version int) error {
-	tx, err := db.conn.Begin()
+// OpenForMigration opens the database without applying or checking
+// migrations, for migration tooling
+func OpenForMigration(path string, opts Options) (*DB, error) {
+	d := dialect.Detect(path)
+	dsn := path
+	if d == dialect.SQLite {
+		var err error
+		if dsn, err = opts.dsn(path); err != nil {
+			return nil, fmt.Errorf("invalid database options: %w", err)
+		}
+	}
+
+	conn, err := dialect.Open(d, dsn)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
-	defer func() { _ = tx.Rollback() }()
 
-	switch version {
-	case 1:
-		if err = db.migration1(tx); err != nil {
-			return err
-		}
-	case 2:
-		if err = db.migration2(tx); err != nil {
-			return err
-		}
-	case 3:
-		if err = db.migration3(tx); err != nil {
-			return err
-		}
-	case 4:
-		if err = db.migration4(tx); err != nil {
-			return err
-		}
-	case 5:
-		if err = db.migration5(tx); err != nil {
-			return err
+	// Enable foreign keys
+	if d == dialect.SQLite {
+		if _, err := conn.Exec("PRAGMA foreign_keys = ON"); err != nil {
+			return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 		}
-	default:
-		return fmt.Errorf("unknown migration version: %d", version)
 	}
 
-	// Record migration
-	_, err = tx.Exec("INSERT INTO schema_version (version) VALUES (?)", version)
-	if err != nil {
-		return fmt.Errorf("failed to record migration: %w", err)
+	db := &DB{
+		conn:  conn,
+		path:  path,
+		stmts: make(map[string]*sql.Stmt),
 	}
 
-	return tx.Commit()
-}
+	if err := db.ensureMigrationTables(); err != nil {
+		db.Close()
+		return nil, err
+	}
 
-// migration1 creates the initial schema
-func (db *DB) migration1(tx *sql.Tx) error {
-	schema := `
-	-- Providers table
-	CREATE TABLE providers (
-		id TEXT PRIMARY KEY,
-		name TEXT NOT NULL,
-		base_url TEXT NOT NULL,
-		auth_method TEXT NOT NULL,
-		auth_header TEXT,
-		pricing_model TEXT NOT NULL,
-		subscription_tiers JSON,
-		discovered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		last_validated TIMESTAMP,
-		sdk_path TEXT,
-		sdk_hash TEXT,
-		sdk_version TEXT,
-		status TEXT DEFAULT 'offline',
-		last_error TEXT,
-		UNIQUE(base_url)
-	);
-
-	-- Model families table
-	CREATE TABLE model_families (
-		id TEXT PRIMARY KEY,
-		provider_id TEXT NOT NULL,
-		name TEXT NOT NULL,
-		description TEXT,
-		FOREIGN KEY (provider_id) REFERENCES providers(id) ON DELETE CASCADE
-	);
-
-	-- Models table
-	CREATE TABLE models (
-		id TEXT PRIMARY KEY,
-		family_id TEXT NOT NULL,
-		name TEXT NOT NULL,
-		cost_per_1m_in REAL,
-		cost_per_1m_out REAL,
-		cost_per_1m_reasoning REAL,
-		context_window INTEGER,
-		max_tokens INTEGER,
-		capabilities JSON,
-		status TEXT DEFAULT 'offline',
-		last_tested TIMESTAMP,
-		last_error TEXT,
-		FOREIGN KEY (family_id) REFERENCES model_families(id) ON DELETE CASCADE
-	);
-
-	-- API keys table
-	CREATE TABLE api_keys (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		provider_id TEXT NOT NULL,
-		key_hash TEXT NOT NULL,
-		key_prefix TEXT,
-		tier TEXT DEFAULT 'unknown',
-		rpm_limit INTEGER,
-		tpm_limit INTEGER,
-		daily_limit INTEGER,
-		reset_interval TEXT,
-		last_reset TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		requests_count INTEGER DEFAULT 0,
-		tokens_count INTEGER DEFAULT 0,
-		active BOOLEAN DEFAULT 1,
-		degraded BOOLEAN DEFAULT 0,
-		degraded_until TIMESTAMP,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (provider_id) REFERENCES providers(id) ON DELETE CASCADE,
-		UNIQUE(provider_id, key_hash)
-	);
-
-	-- Usage tracking table
-	CREATE TABLE usage_tracking (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		model_id TEXT NOT NULL,
-		api_key_id INTEGER,
-		timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		tokens_in INTEGER DEFAULT 0,
-		tokens_out INTEGER DEFAULT 0,
-		tokens_reasoning INTEGER DEFAULT 0,
-		requests INTEGER DEFAULT 1,
-		cost REAL DEFAULT 0,
-		latency_ms INTEGER,
-		success BOOLEAN DEFAULT 1,
-		error TEXT,
-		FOREIGN KEY (model_id) REFERENCES models(id) ON DELETE CASCADE,
-		FOREIGN KEY (api_key_id) REFERENCES api_keys(id) ON DELETE SET NULL
-	);
-
-	-- Discovery logs table
-	CREATE TABLE discovery_logs (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		provider_id TEXT,
-		agent_model TEXT,
-		started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		completed_at TIMESTAMP,
-		status TEXT,
-		retry_count INTEGER DEFAULT 0,
-		total_cost REAL DEFAULT 0,
-		sources_scraped JSON,
-		findings JSON,
-		error TEXT,
-		FOREIGN KEY (provider_id) REFERENCES providers(id) ON DELETE CASCADE
-	);
-
-	-- SDK versions table
-	CREATE TABLE sdk_versions (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		provider_id TEXT NOT NULL,
-		version TEXT NOT NULL,
-		sdk_path TEXT NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		deprecated_at TIMESTAMP,
-		FOREIGN KEY (provider_id) REFERENCES providers(id) ON DELETE CASCADE,
-		UNIQUE(provider_id, version)
-	);
-
-	-- Settings table
-	CREATE TABLE settings (
-		key TEXT PRIMARY KEY,
-		value TEXT NOT NULL,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-
-	-- Indexes for performance
-	CREATE INDEX idx_usage_timestamp ON usage_tracking(timestamp);
-	CREATE INDEX idx_usage_model ON usage_tracking(model_id);
-	CREATE INDEX idx_usage_key ON usage_tracking(api_key_id);
-	CREATE INDEX idx_models_status ON models(status);
-	CREATE INDEX idx_providers_status ON providers(status);
-	CREATE INDEX idx_api_keys_provider ON api_keys(provider_id);
-	CREATE INDEX idx_api_keys_active ON api_keys(active, degraded);
-	`
-
-	_, err := tx.Exec(schema)
-	return err
+	return db, nil
 }
 
-// migration2 adds discovery_results table
-func (db *DB) migration2(tx *sql.Tx) error {
-	schema := `
-	-- Discovery results table (stores complete discovery data)
-	CREATE TABLE discovery_results (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		identifier TEXT NOT NULL UNIQUE,
-		provider_data JSON NOT NULL,
-		model_families JSON,
-		models JSON,
-		sdk_data JSON,
-		validated BOOLEAN DEFAULT 0,
-		validation_log TEXT,
-		sources JSON,
-		discovered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		ttl_expires_at TIMESTAMP
-	);
-
-	-- Index for cache lookups
-	CREATE INDEX idx_discovery_identifier ON discovery_results(identifier);
-	CREATE INDEX idx_discovery_ttl ON discovery_results(ttl_expires_at);
-	`
-
-	_, err := tx.Exec(schema)
-	return err
+// Dialect returns the SQL dialect of the underlying database
+func (db *DB) Dialect() dialect.Dialect {
+	return db.conn.Dialect()
 }
 
-// migration3 creates clients and request_logs tables for MClaude integration
-func (db *DB) migration3(tx *sql.Tx) error {
-	schema := `
-	-- Clients table for MClaude integration
-	CREATE TABLE clients (
-		id TEXT PRIMARY KEY,
-		name TEXT NOT NULL,
-		version TEXT NOT NULL,
-		token TEXT NOT NULL UNIQUE,
-		capabilities JSON NOT NULL DEFAULT '[]',
-		config JSON NOT NULL DEFAULT '{}',
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		last_seen_at TIMESTAMP
-	);
-
-	-- Index for client token lookups
-	CREATE INDEX idx_clients_token ON clients(token);
-
-	-- Request logs table for observability
-	CREATE TABLE request_logs (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		client_id TEXT,
-		provider TEXT NOT NULL,
-		model TEXT NOT NULL,
-		endpoint TEXT NOT NULL,
-		request_tokens INTEGER DEFAULT 0,
-		response_tokens INTEGER DEFAULT 0,
-		latency_ms INTEGER DEFAULT 0,
-		status_code INTEGER,
-		error_message TEXT,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE SET NULL
-	);
-
-	-- Indexes for request log queries
-	CREATE INDEX idx_request_logs_client ON request_logs(client_id);
-	CREATE INDEX idx_request_logs_created ON request_logs(created_at);
-	CREATE INDEX idx_request_logs_provider ON request_logs(provider);
-	`
-
-	_, err := tx.Exec(schema)
-	return err
+// PoolStats returns the connection pool's statistics
+func (db *DB) PoolStats() sql.DBStats {
+	return db.conn.Stats()
 }
 
-// migration4 creates aliases table for model name aliases
-func (db *DB) migration4(tx *sql.Tx) error {
-	schema := `
-	-- Aliases table for model name aliases
-	-- Uses unique constraint on (name, client_id) with a workaround for NULL client_id
-	CREATE TABLE aliases (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL,
-		model_id TEXT NOT NULL,
-		client_id TEXT,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
-	);
-
-	-- Unique index for alias lookups (NULL client_id means global alias)
-	CREATE UNIQUE INDEX idx_aliases_name_client ON aliases(name, client_id);
-	CREATE INDEX idx_aliases_client ON aliases(client_id);
-	CREATE INDEX idx_aliases_name ON aliases(name);
-	`
-
-	_, err := tx.Exec(schema)
-	return err
+// Close closes the database connection
+func (db *DB) Close() error {
+	db.stmtMu.Lock()
+	for query, stmt := range db.stmts {
+		stmt.Close()
+		delete(db.stmts, query)
+	}
+	db.stmtMu.Unlock()
+	return db.conn.Close()
 }
 
-// migration5 creates remap_rules, client_rate_limits tables and default aliases
-func (db *DB) migration5(tx *sql.Tx) error {
-	schema := `
-	-- Remap rules table for model remapping
-	CREATE TABLE remap_rules (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		client_id TEXT NOT NULL,
-		from_model TEXT NOT NULL,
-		to_model TEXT NOT NULL,
-		to_provider TEXT NOT NULL,
-		priority INTEGER DEFAULT 0,
-		enabled BOOLEAN DEFAULT 1,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
-	);
-
-	-- Indexes for remap rule lookups
-	CREATE INDEX idx_remap_rules_client ON remap_rules(client_id);
-	CREATE INDEX idx_remap_rules_enabled ON remap_rules(client_id, enabled);
-	CREATE INDEX idx_remap_rules_priority ON remap_rules(client_id, priority DESC);
-
-	-- Client rate limits table
-	CREATE TABLE client_rate_limits (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		client_id TEXT NOT NULL UNIQUE,
-		rpm_limit INTEGER,
-		tpm_limit INTEGER,
-		daily_limit INTEGER,
-		current_rpm INTEGER DEFAULT 0,
-		current_tpm INTEGER DEFAULT 0,
-		current_daily INTEGER DEFAULT 0,
-		last_reset TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (client_id) REFERENCES clients(id) ON DELETE CASCADE
-	);
-
-	-- Index for rate limit lookups
-	CREATE INDEX idx_client_rate_limits_client ON client_rate_limits(client_id);
-	`
-
-	_, err := tx.Exec(schema)
+// prepare returns a cached prepared statement for the query, preparing it
+// on first use
+func (db *DB) prepare(query string) (*sql.Stmt, error) {
+	db.stmtMu.Lock()
+	defer db.stmtMu.Unlock()
+
+	if stmt, ok := db.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := db.conn.Prepare(query)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
+	db.stmts[query] = stmt
+	return stmt, nil
+}
 
-	// Insert default global aliases (client_id = NULL for global)
-	defaultAliases := `
-	INSERT OR IGNORE INTO aliases (name, model_id, client_id) VALUES
-		('sonnet', 'claude-sonnet-4-5-20250929', NULL),
-		('opus', 'claude-opus-4-5-20250929', NULL),
-		('haiku', 'claude-3-5-haiku-20241022', NULL),
-		('gpt4', 'gpt-4o', NULL),
-		('gemini', 'gemini-1.5-pro', NULL);
-	`
-
-	_, err = tx.Exec(defaultAliases)
-	return err
+// insertPrepared runs a cached INSERT statement and returns the new row ID
+func (db *DB) insertPrepared(query string, args ...interface{}) (int64, error) {
+	if !db.conn.Dialect().ReturningID() {
+		stmt, err := db.prepare(query)
+		if err != nil {
+			return 0, err
+		}
+		result, err := stmt.Exec(args...)
+		if err != nil {
+			return 0, err
+		}
+		return result.LastInsertId()
+	}
+
+	stmt, err := db.prepare(query + " RETURNING id")
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = stmt.QueryRow(args...).Scan(&id)
+	return id, err
 }
 
 // Provider represents a provider in the database
@@ -470,6 +292,8 @@ type APIKey struct {
 	Degraded      bool
 	DegradedUntil *time.Time
 	CreatedAt     time.Time
+	TenantID      *string    // Owning tenant; nil for keys shared by everyone
+	ExpiresAt     *time.Time // When the provider stops accepting the key; nil when unknown
 }
 
 // UsageRecord represents a usage record in the database
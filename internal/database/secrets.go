@@ -0,0 +1,285 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
+	"github.com/jeffersonwarrior/modelscan/internal/secrets"
+)
+
+// ErrNoMasterKey means an encrypted secret was read from a database opened
+// without a master key
+var ErrNoMasterKey = errors.New("secret is encrypted but no master key is configured")
+
+// SecretStatus describes how secrets are stored
+type SecretStatus struct {
+	Encrypted       bool   `json:"encrypted"`               // A master key is loaded
+	MasterKeyID     string `json:"master_key_id,omitempty"` // Fingerprint of the loaded master key
+	DataKeys        int    `json:"data_keys"`
+	EncryptedTokens int    `json:"encrypted_tokens"`
+	PlaintextTokens int    `json:"plaintext_tokens"`
+	StoredKeys      int    `json:"stored_keys"`   // API keys whose value is stored encrypted
+	UnstoredKeys    int    `json:"unstored_keys"` // API keys known only by hash
+}
+
+// EnableEncryption loads the data keys wrapped by master, creating the first
+// one on a new database. From then on API key values and client tokens are
+// written encrypted and decrypted transparently on read. It fails if the
+// data keys were wrapped by a different master key.
+func (db *DB) EnableEncryption(master *secrets.MasterKey) error {
+	rows, err := db.conn.Query(`SELECT id, wrapped_key, master_key_id FROM secret_keys ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("failed to load data keys: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	ring := secrets.NewKeyring()
+	count := 0
+	for rows.Next() {
+		var id int
+		var wrapped, masterID string
+		if err := rows.Scan(&id, &wrapped, &masterID); err != nil {
+			return fmt.Errorf("failed to scan data key: %w", err)
+		}
+		if masterID != master.ID() {
+			return fmt.Errorf("%w: data key %d was wrapped by %s, loaded key is %s", secrets.ErrWrongMasterKey, id, masterID, master.ID())
+		}
+		key, err := master.Unwrap(wrapped)
+		if err != nil {
+			return fmt.Errorf("data key %d: %w", id, err)
+		}
+		if err := ring.Add(id, key); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_ = rows.Close()
+
+	if count == 0 {
+		key, err := secrets.GenerateKey()
+		if err != nil {
+			return err
+		}
+		wrapped, err := master.Wrap(key)
+		if err != nil {
+			return err
+		}
+		id, err := db.conn.Insert(`INSERT INTO secret_keys (wrapped_key, master_key_id) VALUES (?, ?)`, wrapped, master.ID())
+		if err != nil {
+			return fmt.Errorf("failed to store data key: %w", err)
+		}
+		if err := ring.Add(int(id), key); err != nil {
+			return err
+		}
+	}
+
+	db.secretMu.Lock()
+	db.master, db.keyring = master, ring
+	db.secretMu.Unlock()
+	return nil
+}
+
+// RotateMasterKey rewraps every data key with next. Stored secrets are not
+// re-encrypted, so rotation is quick and atomic; the old master key stops
+// working once it commits.
+func (db *DB) RotateMasterKey(next *secrets.MasterKey) error {
+	db.secretMu.RLock()
+	current := db.master
+	db.secretMu.RUnlock()
+	if current == nil {
+		return errors.New("encryption is not enabled")
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.Query(`SELECT id, wrapped_key FROM secret_keys ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("failed to load data keys: %w", err)
+	}
+	rewrapped := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var wrapped string
+		if err := rows.Scan(&id, &wrapped); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("failed to scan data key: %w", err)
+		}
+		key, err := current.Unwrap(wrapped)
+		if err == nil {
+			wrapped, err = next.Wrap(key)
+		}
+		if err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("data key %d: %w", id, err)
+		}
+		rewrapped[id] = wrapped
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, wrapped := range rewrapped {
+		if _, err := tx.Exec(`UPDATE secret_keys SET wrapped_key = ?, master_key_id = ? WHERE id = ?`, wrapped, next.ID(), id); err != nil {
+			return fmt.Errorf("failed to update data key %d: %w", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	db.secretMu.Lock()
+	db.master = next
+	db.secretMu.Unlock()
+	return nil
+}
+
+// EncryptPlaintextSecrets encrypts client tokens stored before encryption
+// was enabled and returns how many rows it changed
+func (db *DB) EncryptPlaintextSecrets() (int, error) {
+	if !db.encrypting() {
+		return 0, errors.New("encryption is not enabled")
+	}
+
+	rows, err := db.conn.Query(`SELECT id, token FROM clients WHERE token NOT LIKE 'enc:v1:%' OR token_hash IS NULL`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list client tokens: %w", err)
+	}
+	tokens := make(map[string]string)
+	for rows.Next() {
+		var id, token string
+		if err := rows.Scan(&id, &token); err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("failed to scan client token: %w", err)
+		}
+		tokens[id] = token
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for id, stored := range tokens {
+		token, err := db.openSecret(stored)
+		if err != nil {
+			return 0, fmt.Errorf("client %s: %w", id, err)
+		}
+		sealed, err := db.sealSecret(token)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := db.conn.Exec(`UPDATE clients SET token = ?, token_hash = ? WHERE id = ?`, sealed, HashAPIKey(token), id); err != nil {
+			return 0, fmt.Errorf("failed to encrypt token for client %s: %w", id, err)
+		}
+	}
+	return len(tokens), nil
+}
+
+// SecretStatus reports whether encryption is enabled and how many secrets
+// are stored encrypted or in plaintext
+func (db *DB) SecretStatus() (*SecretStatus, error) {
+	status := &SecretStatus{}
+	db.secretMu.RLock()
+	if db.master != nil {
+		status.Encrypted = true
+		status.MasterKeyID = db.master.ID()
+	}
+	db.secretMu.RUnlock()
+
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM secret_keys`).Scan(&status.DataKeys)
+	if err == nil {
+		err = db.conn.QueryRow(`
+			SELECT
+				COALESCE(SUM(CASE WHEN token LIKE 'enc:v1:%' THEN 1 ELSE 0 END), 0),
+				COALESCE(SUM(CASE WHEN token LIKE 'enc:v1:%' THEN 0 ELSE 1 END), 0)
+			FROM clients
+		`).Scan(&status.EncryptedTokens, &status.PlaintextTokens)
+	}
+	if err == nil {
+		err = db.conn.QueryRow(`
+			SELECT
+				COALESCE(SUM(CASE WHEN key_secret IS NULL THEN 0 ELSE 1 END), 0),
+				COALESCE(SUM(CASE WHEN key_secret IS NULL THEN 1 ELSE 0 END), 0)
+			FROM api_keys
+		`).Scan(&status.StoredKeys, &status.UnstoredKeys)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret status: %w", err)
+	}
+	return status, nil
+}
+
+// ListAPIKeySecrets decrypts the stored API key values, keyed by key hash.
+// Keys created while encryption was disabled have no stored value.
+func (db *DB) ListAPIKeySecrets() (map[string]string, error) {
+	rows, err := db.conn.Query(`SELECT key_hash, key_secret FROM api_keys WHERE key_secret IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list key secrets: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	values := make(map[string]string)
+	for rows.Next() {
+		var hash, sealed string
+		if err := rows.Scan(&hash, &sealed); err != nil {
+			return nil, fmt.Errorf("failed to scan key secret: %w", err)
+		}
+		value, err := db.openSecret(sealed)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", hash[:8], err)
+		}
+		values[hash] = value
+	}
+	return values, rows.Err()
+}
+
+// encrypting reports whether secrets are written encrypted
+func (db *DB) encrypting() bool {
+	db.secretMu.RLock()
+	defer db.secretMu.RUnlock()
+	return db.keyring != nil
+}
+
+// sealSecret encrypts a value when encryption is enabled and returns it
+// unchanged otherwise
+func (db *DB) sealSecret(value string) (string, error) {
+	db.secretMu.RLock()
+	ring := db.keyring
+	db.secretMu.RUnlock()
+	if ring == nil {
+		return value, nil
+	}
+	return ring.Seal(value)
+}
+
+// openSecret decrypts a stored value; plaintext values are returned as is
+func (db *DB) openSecret(value string) (string, error) {
+	if !secrets.IsSealed(value) {
+		return value, nil
+	}
+	db.secretMu.RLock()
+	ring := db.keyring
+	db.secretMu.RUnlock()
+	if ring == nil {
+		return "", ErrNoMasterKey
+	}
+	return ring.Open(value)
+}
+
+// maskError masks credentials in an optional error message before it is
+// stored; provider errors often echo the key that was rejected
+func maskError(msg *string) *string {
+	if msg == nil {
+		return nil
+	}
+	masked := redact.String(*msg)
+	return &masked
+}
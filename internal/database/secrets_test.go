@@ -0,0 +1,167 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/secrets"
+)
+
+func newMasterKey(t *testing.T) *secrets.MasterKey {
+	t.Helper()
+	raw, err := secrets.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := secrets.NewMasterKey(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return master
+}
+
+func TestEncryptedSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.CreateProvider(&Provider{ID: "openai", Name: "OpenAI", BaseURL: "https://api.openai.com", Status: "online"}); err != nil {
+		t.Fatalf("CreateProvider failed: %v", err)
+	}
+	clients := NewClientRepository(db)
+	if err := clients.Create(&Client{ID: "c1", Name: "client", Version: "1", Token: "tok-plain"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	// A row from before token hashes existed
+	if _, err := db.conn.Exec(`UPDATE clients SET token_hash = NULL`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateAPIKey("openai", "sk-unencrypted-123"); err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	db.Close()
+
+	master := newMasterKey(t)
+	db, err = OpenWithOptions(path, Options{MasterKey: master})
+	if err != nil {
+		t.Fatalf("failed to open with master key: %v", err)
+	}
+	clients = NewClientRepository(db)
+
+	status, err := db.SecretStatus()
+	if err != nil {
+		t.Fatalf("SecretStatus failed: %v", err)
+	}
+	if !status.Encrypted || status.MasterKeyID != master.ID() || status.DataKeys != 1 || status.PlaintextTokens != 1 || status.UnstoredKeys != 1 {
+		t.Errorf("unexpected status before encrypting: %+v", status)
+	}
+	if c, err := clients.GetByToken("tok-plain"); err != nil || c == nil {
+		t.Fatalf("legacy token lookup = %v, %v", c, err)
+	}
+
+	if n, err := db.EncryptPlaintextSecrets(); err != nil || n != 1 {
+		t.Fatalf("EncryptPlaintextSecrets = %d, %v", n, err)
+	}
+	var stored string
+	db.conn.QueryRow(`SELECT token FROM clients WHERE id = 'c1'`).Scan(&stored)
+	if !secrets.IsSealed(stored) {
+		t.Errorf("token not encrypted: %q", stored)
+	}
+	if c, err := clients.GetByToken("tok-plain"); err != nil || c == nil || c.Token != "tok-plain" {
+		t.Fatalf("GetByToken after encrypting = %+v, %v", c, err)
+	}
+	if exists, _ := clients.TokenExists("tok-plain"); !exists {
+		t.Error("TokenExists should find the encrypted token")
+	}
+
+	key, err := db.CreateAPIKey("openai", "sk-encrypted-456")
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	db.conn.QueryRow(`SELECT key_secret FROM api_keys WHERE id = ?`, key.ID).Scan(&stored)
+	if !secrets.IsSealed(stored) || strings.Contains(stored, "sk-encrypted") {
+		t.Errorf("key value not encrypted: %q", stored)
+	}
+
+	// Rotating the master key keeps every secret readable with the new key only
+	next := newMasterKey(t)
+	if err := db.RotateMasterKey(next); err != nil {
+		t.Fatalf("RotateMasterKey failed: %v", err)
+	}
+	db.Close()
+
+	if _, err := OpenWithOptions(path, Options{MasterKey: master}); !errors.Is(err, secrets.ErrWrongMasterKey) {
+		t.Errorf("expected ErrWrongMasterKey for the old key, got %v", err)
+	}
+
+	db, err = OpenWithOptions(path, Options{MasterKey: next})
+	if err != nil {
+		t.Fatalf("failed to open with rotated key: %v", err)
+	}
+	values, err := db.ListAPIKeySecrets()
+	if err != nil || len(values) != 1 || values[key.KeyHash] != "sk-encrypted-456" {
+		t.Errorf("ListAPIKeySecrets = %v, %v", values, err)
+	}
+	if c, err := NewClientRepository(db).Get("c1"); err != nil || c.Token != "tok-plain" {
+		t.Errorf("Get after rotation = %+v, %v", c, err)
+	}
+	db.Close()
+
+	// Without a master key encrypted values cannot be read
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if _, err := NewClientRepository(db).Get("c1"); !errors.Is(err, ErrNoMasterKey) {
+		t.Errorf("expected ErrNoMasterKey, got %v", err)
+	}
+	if _, err := db.EncryptPlaintextSecrets(); err == nil {
+		t.Error("expected an error encrypting without a master key")
+	}
+}
+
+func TestStoredErrorsAreMasked(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "masked.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	leak := "401: Incorrect API key provided: sk-proj-AbCdEf0123456789AbCdEf"
+	if err := db.CreateProvider(&Provider{ID: "openai", Name: "OpenAI", BaseURL: "https://api.openai.com", Status: "online"}); err != nil {
+		t.Fatalf("CreateProvider failed: %v", err)
+	}
+	if err := db.UpdateProviderStatus("openai", "error", &leak); err != nil {
+		t.Fatalf("UpdateProviderStatus failed: %v", err)
+	}
+	if err := db.CreateRequestLog(&RequestLog{Provider: "openai", Model: "gpt-4o", Endpoint: "/v1/chat/completions", ErrorMessage: &leak, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateRequestLog failed: %v", err)
+	}
+	if err := db.CreateWebhookDeadLetter(&WebhookDeadLetter{Endpoint: "ops", URL: "https://hooks", EventID: "e1", EventType: "key.degraded", Payload: `{"error":"` + leak + `"}`, LastError: &leak}); err != nil {
+		t.Fatalf("CreateWebhookDeadLetter failed: %v", err)
+	}
+	key, _ := db.CreateAPIKey("openai", "sk-masked-0123456789")
+	if err := db.RecordKeyProbe(&KeyProbe{KeyID: key.ID, At: time.Now(), Status: 401, Error: leak}); err != nil {
+		t.Fatalf("RecordKeyProbe failed: %v", err)
+	}
+
+	for _, query := range []string{
+		`SELECT last_error FROM providers`,
+		`SELECT error_message FROM request_logs`,
+		`SELECT payload || last_error FROM webhook_dead_letters`,
+		`SELECT error FROM key_health`,
+	} {
+		var stored string
+		if err := db.conn.QueryRow(query).Scan(&stored); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		if strings.Contains(stored, "AbCdEf0123456789") || !strings.Contains(stored, "sk-proj-[REDACTED]") {
+			t.Errorf("%s stored %q", query, stored)
+		}
+	}
+}
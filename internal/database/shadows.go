@@ -0,0 +1,224 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ShadowResult pairs a primary response with a mirrored shadow response
+type ShadowResult struct {
+	ID       int
+	ClientID *string
+
+	PrimaryProvider         string
+	PrimaryModel            string
+	PrimaryStatus           int
+	PrimaryLatencyMs        int
+	PrimaryResponse         string
+	PrimaryPromptTokens     *int
+	PrimaryCompletionTokens *int
+	PrimaryStream           bool
+
+	ShadowProvider         string
+	ShadowModel            string
+	ShadowStatus           int
+	ShadowLatencyMs        int
+	ShadowResponse         string
+	ShadowPromptTokens     *int
+	ShadowCompletionTokens *int
+	ShadowError            *string
+
+	CreatedAt time.Time
+}
+
+// ShadowResultFilter defines filtering options for listing shadow results
+type ShadowResultFilter struct {
+	PrimaryModel *string
+	ShadowModel  *string
+	Since        *time.Time
+	Limit        int
+	Offset       int
+}
+
+// ShadowSummary aggregates shadow results for one primary/shadow pair
+type ShadowSummary struct {
+	PrimaryProvider string
+	PrimaryModel    string
+	ShadowProvider  string
+	ShadowModel     string
+	Count           int
+
+	PrimaryErrors           int
+	PrimaryAvgLatencyMs     float64
+	PrimaryPromptTokens     int64
+	PrimaryCompletionTokens int64
+
+	ShadowErrors           int
+	ShadowAvgLatencyMs     float64
+	ShadowPromptTokens     int64
+	ShadowCompletionTokens int64
+}
+
+// CreateShadowResult inserts a new shadow result
+func (db *DB) CreateShadowResult(r *ShadowResult) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO shadow_results (
+			client_id,
+			primary_provider, primary_model, primary_status, primary_latency_ms, primary_response,
+			primary_prompt_tokens, primary_completion_tokens, primary_stream,
+			shadow_provider, shadow_model, shadow_status, shadow_latency_ms, shadow_response,
+			shadow_prompt_tokens, shadow_completion_tokens, shadow_error,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	id, err := db.conn.Insert(query,
+		r.ClientID,
+		r.PrimaryProvider, r.PrimaryModel, r.PrimaryStatus, r.PrimaryLatencyMs, r.PrimaryResponse,
+		r.PrimaryPromptTokens, r.PrimaryCompletionTokens, r.PrimaryStream,
+		r.ShadowProvider, r.ShadowModel, r.ShadowStatus, r.ShadowLatencyMs, r.ShadowResponse,
+		r.ShadowPromptTokens, r.ShadowCompletionTokens, maskError(r.ShadowError),
+		r.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create shadow result: %w", err)
+	}
+	r.ID = int(id)
+
+	return nil
+}
+
+// ListShadowResults retrieves shadow results with optional filtering, newest first
+func (db *DB) ListShadowResults(filter *ShadowResultFilter) ([]*ShadowResult, error) {
+	query := `
+		SELECT id, client_id,
+			primary_provider, primary_model, primary_status, primary_latency_ms, primary_response,
+			primary_prompt_tokens, primary_completion_tokens, primary_stream,
+			shadow_provider, shadow_model, shadow_status, shadow_latency_ms, shadow_response,
+			shadow_prompt_tokens, shadow_completion_tokens, shadow_error,
+			created_at
+		FROM shadow_results`
+	where, args := shadowResultWhere(filter)
+	query += where + " ORDER BY created_at DESC, id DESC"
+
+	if filter != nil && filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+		if filter.Offset > 0 {
+			query += " OFFSET ?"
+			args = append(args, filter.Offset)
+		}
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shadow results: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var results []*ShadowResult
+	for rows.Next() {
+		r := &ShadowResult{}
+		var primaryStatus, primaryLatency, shadowStatus, shadowLatency sql.NullInt64
+		var primaryResponse, shadowResponse sql.NullString
+		err := rows.Scan(
+			&r.ID, &r.ClientID,
+			&r.PrimaryProvider, &r.PrimaryModel, &primaryStatus, &primaryLatency, &primaryResponse,
+			&r.PrimaryPromptTokens, &r.PrimaryCompletionTokens, &r.PrimaryStream,
+			&r.ShadowProvider, &r.ShadowModel, &shadowStatus, &shadowLatency, &shadowResponse,
+			&r.ShadowPromptTokens, &r.ShadowCompletionTokens, &r.ShadowError,
+			&r.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shadow result: %w", err)
+		}
+		r.PrimaryStatus = int(primaryStatus.Int64)
+		r.PrimaryLatencyMs = int(primaryLatency.Int64)
+		r.PrimaryResponse = primaryResponse.String
+		r.ShadowStatus = int(shadowStatus.Int64)
+		r.ShadowLatencyMs = int(shadowLatency.Int64)
+		r.ShadowResponse = shadowResponse.String
+		results = append(results, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating shadow results: %w", err)
+	}
+
+	return results, nil
+}
+
+// GetShadowSummaries aggregates shadow results per primary/shadow model pair.
+// A response counts as an error when its status is missing or >= 400, or the
+// shadow request failed outright.
+func (db *DB) GetShadowSummaries(filter *ShadowResultFilter) ([]*ShadowSummary, error) {
+	where, args := shadowResultWhere(filter)
+	query := `
+		SELECT
+			primary_provider, primary_model, shadow_provider, shadow_model,
+			COUNT(*),
+			COALESCE(SUM(CASE WHEN primary_status IS NULL OR primary_status >= 400 THEN 1 ELSE 0 END), 0),
+			COALESCE(AVG(primary_latency_ms), 0),
+			COALESCE(SUM(primary_prompt_tokens), 0),
+			COALESCE(SUM(primary_completion_tokens), 0),
+			COALESCE(SUM(CASE WHEN shadow_error IS NOT NULL OR shadow_status IS NULL OR shadow_status >= 400 THEN 1 ELSE 0 END), 0),
+			COALESCE(AVG(shadow_latency_ms), 0),
+			COALESCE(SUM(shadow_prompt_tokens), 0),
+			COALESCE(SUM(shadow_completion_tokens), 0)
+		FROM shadow_results` + where + `
+		GROUP BY primary_provider, primary_model, shadow_provider, shadow_model
+		ORDER BY COUNT(*) DESC`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize shadow results: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var summaries []*ShadowSummary
+	for rows.Next() {
+		s := &ShadowSummary{}
+		err := rows.Scan(
+			&s.PrimaryProvider, &s.PrimaryModel, &s.ShadowProvider, &s.ShadowModel,
+			&s.Count,
+			&s.PrimaryErrors, &s.PrimaryAvgLatencyMs, &s.PrimaryPromptTokens, &s.PrimaryCompletionTokens,
+			&s.ShadowErrors, &s.ShadowAvgLatencyMs, &s.ShadowPromptTokens, &s.ShadowCompletionTokens,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shadow summary: %w", err)
+		}
+		summaries = append(summaries, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating shadow summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// shadowResultWhere builds the WHERE clause for a shadow result filter
+func shadowResultWhere(filter *ShadowResultFilter) (string, []interface{}) {
+	where := " WHERE 1=1"
+	var args []interface{}
+	if filter == nil {
+		return where, args
+	}
+	if filter.PrimaryModel != nil {
+		where += " AND primary_model = ?"
+		args = append(args, *filter.PrimaryModel)
+	}
+	if filter.ShadowModel != nil {
+		where += " AND shadow_model = ?"
+		args = append(args, *filter.ShadowModel)
+	}
+	if filter.Since != nil {
+		where += " AND created_at >= ?"
+		args = append(args, *filter.Since)
+	}
+	return where, args
+}
@@ -0,0 +1,123 @@
+package database
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestShadowResults(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "modelscan-test-*.db")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	tmpFile.Close()
+
+	db, err := Open(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	intPtr := func(v int) *int { return &v }
+	clientID := "client-1"
+	shadowErr := "upstream request failed: timeout"
+
+	results := []*ShadowResult{
+		{
+			ClientID:        &clientID,
+			PrimaryProvider: "openai", PrimaryModel: "gpt-4o", PrimaryStatus: 200, PrimaryLatencyMs: 800,
+			PrimaryResponse: `{"id":"a"}`, PrimaryPromptTokens: intPtr(10), PrimaryCompletionTokens: intPtr(20),
+			ShadowProvider: "together", ShadowModel: "llama-3.3-70b", ShadowStatus: 200, ShadowLatencyMs: 400,
+			ShadowResponse: `{"id":"b"}`, ShadowPromptTokens: intPtr(12), ShadowCompletionTokens: intPtr(25),
+			CreatedAt: time.Now().Add(-2 * time.Hour),
+		},
+		{
+			PrimaryProvider: "openai", PrimaryModel: "gpt-4o", PrimaryStatus: 200, PrimaryLatencyMs: 1200,
+			PrimaryStream:  true,
+			ShadowProvider: "together", ShadowModel: "llama-3.3-70b", ShadowLatencyMs: 30000,
+			ShadowError: &shadowErr,
+		},
+		{
+			PrimaryProvider: "openai", PrimaryModel: "gpt-4o-mini", PrimaryStatus: 500, PrimaryLatencyMs: 100,
+			ShadowProvider: "together", ShadowModel: "llama-3.3-70b", ShadowStatus: 200, ShadowLatencyMs: 300,
+		},
+	}
+	for _, r := range results {
+		if err := db.CreateShadowResult(r); err != nil {
+			t.Fatalf("CreateShadowResult() error = %v", err)
+		}
+		if r.ID == 0 {
+			t.Error("expected ID to be set")
+		}
+	}
+
+	t.Run("List all newest first", func(t *testing.T) {
+		list, err := db.ListShadowResults(nil)
+		if err != nil {
+			t.Fatalf("ListShadowResults() error = %v", err)
+		}
+		if len(list) != 3 {
+			t.Fatalf("expected 3 results, got %d", len(list))
+		}
+		if list[len(list)-1].ID != results[0].ID {
+			t.Errorf("expected oldest result last, got ID %d", list[len(list)-1].ID)
+		}
+	})
+
+	t.Run("List filtered", func(t *testing.T) {
+		model := "gpt-4o"
+		list, err := db.ListShadowResults(&ShadowResultFilter{PrimaryModel: &model, Limit: 1})
+		if err != nil {
+			t.Fatalf("ListShadowResults() error = %v", err)
+		}
+		if len(list) != 1 {
+			t.Fatalf("expected 1 result, got %d", len(list))
+		}
+		got := list[0]
+		if !got.PrimaryStream || got.ShadowError == nil || *got.ShadowError != shadowErr {
+			t.Errorf("unexpected result: %+v", got)
+		}
+		if got.ShadowPromptTokens != nil {
+			t.Errorf("expected nil shadow tokens, got %v", *got.ShadowPromptTokens)
+		}
+
+		since := time.Now().Add(-time.Hour)
+		list, err = db.ListShadowResults(&ShadowResultFilter{PrimaryModel: &model, Since: &since})
+		if err != nil {
+			t.Fatalf("ListShadowResults() error = %v", err)
+		}
+		if len(list) != 1 {
+			t.Errorf("expected 1 recent result, got %d", len(list))
+		}
+	})
+
+	t.Run("Summaries", func(t *testing.T) {
+		summaries, err := db.GetShadowSummaries(nil)
+		if err != nil {
+			t.Fatalf("GetShadowSummaries() error = %v", err)
+		}
+		if len(summaries) != 2 {
+			t.Fatalf("expected 2 model pairs, got %d", len(summaries))
+		}
+
+		s := summaries[0]
+		if s.PrimaryModel != "gpt-4o" || s.Count != 2 {
+			t.Fatalf("expected gpt-4o pair with 2 results first, got %s (%d)", s.PrimaryModel, s.Count)
+		}
+		if s.PrimaryErrors != 0 || s.ShadowErrors != 1 {
+			t.Errorf("expected 0 primary and 1 shadow errors, got %d and %d", s.PrimaryErrors, s.ShadowErrors)
+		}
+		if s.PrimaryAvgLatencyMs != 1000 {
+			t.Errorf("expected primary avg latency 1000, got %v", s.PrimaryAvgLatencyMs)
+		}
+		if s.PrimaryCompletionTokens != 20 || s.ShadowCompletionTokens != 25 {
+			t.Errorf("unexpected token sums: primary=%d shadow=%d", s.PrimaryCompletionTokens, s.ShadowCompletionTokens)
+		}
+
+		if summaries[1].PrimaryErrors != 1 {
+			t.Errorf("expected primary error for gpt-4o-mini, got %d", summaries[1].PrimaryErrors)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// SLAPeriod holds one provider's request outcomes over a fixed period.
+// Latency counts only successful requests.
+type SLAPeriod struct {
+	Provider       string    `json:"provider"`
+	PeriodStart    time.Time `json:"period_start"`
+	Requests       int64     `json:"requests"`
+	Errors         int64     `json:"errors"`
+	WithinTarget   int64     `json:"within_target"` // Successful requests that met the latency target
+	LatencyMsTotal int64     `json:"latency_ms_total"`
+}
+
+const slaColumns = `provider, period_start, requests, errors, within_target, latency_ms_total`
+
+// SLARepository stores per-provider SLA rollups
+type SLARepository struct {
+	db *DB
+}
+
+// NewSLARepository creates a new SLARepository
+func NewSLARepository(db *DB) *SLARepository {
+	return &SLARepository{db: db}
+}
+
+// Add adds the counts of each period to the stored rollups in one
+// transaction, creating rollups that do not exist yet
+func (r *SLARepository) Add(periods []*SLAPeriod) error {
+	if len(periods) == 0 {
+		return nil
+	}
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to record SLA periods: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		INSERT INTO provider_sla (` + slaColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(provider, period_start) DO UPDATE SET
+			requests = provider_sla.requests + excluded.requests,
+			errors = provider_sla.errors + excluded.errors,
+			within_target = provider_sla.within_target + excluded.within_target,
+			latency_ms_total = provider_sla.latency_ms_total + excluded.latency_ms_total
+	`
+	for _, p := range periods {
+		if _, err := tx.Exec(query, p.Provider, p.PeriodStart.UTC(), p.Requests, p.Errors, p.WithinTarget, p.LatencyMsTotal); err != nil {
+			return fmt.Errorf("failed to record SLA period: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// List retrieves the rollups starting at or after since, by provider and
+// period. An empty provider lists every provider.
+func (r *SLARepository) List(since time.Time, provider string) ([]*SLAPeriod, error) {
+	query := `SELECT ` + slaColumns + ` FROM provider_sla WHERE period_start >= ?`
+	args := []interface{}{since.UTC()}
+	if provider != "" {
+		query += ` AND provider = ?`
+		args = append(args, provider)
+	}
+	query += ` ORDER BY provider ASC, period_start ASC`
+
+	rows, err := r.db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SLA periods: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var periods []*SLAPeriod
+	for rows.Next() {
+		p := &SLAPeriod{}
+		if err := rows.Scan(&p.Provider, &p.PeriodStart, &p.Requests, &p.Errors, &p.WithinTarget, &p.LatencyMsTotal); err != nil {
+			return nil, fmt.Errorf("failed to scan SLA period: %w", err)
+		}
+		periods = append(periods, p)
+	}
+	return periods, rows.Err()
+}
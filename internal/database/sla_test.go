@@ -0,0 +1,45 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSLARepository(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "sla.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewSLARepository(db)
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	if err := repo.Add([]*SLAPeriod{
+		{Provider: "openai", PeriodStart: day, Requests: 10, Errors: 1, WithinTarget: 8, LatencyMsTotal: 9000},
+		{Provider: "anthropic", PeriodStart: day, Requests: 4, WithinTarget: 4, LatencyMsTotal: 2000},
+		{Provider: "openai", PeriodStart: day.Add(-48 * time.Hour), Requests: 1},
+	}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	// Counts for an existing period accumulate
+	if err := repo.Add([]*SLAPeriod{{Provider: "openai", PeriodStart: day, Requests: 5, Errors: 2, WithinTarget: 3, LatencyMsTotal: 3000}}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	periods, err := repo.List(day.Add(-time.Hour), "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(periods) != 2 || periods[0].Provider != "anthropic" || periods[1].Provider != "openai" {
+		t.Fatalf("unexpected periods: %+v", periods)
+	}
+	if p := periods[1]; p.Requests != 15 || p.Errors != 3 || p.WithinTarget != 11 || p.LatencyMsTotal != 12000 || !p.PeriodStart.Equal(day) {
+		t.Errorf("counts not accumulated: %+v", p)
+	}
+
+	if openai, err := repo.List(time.Time{}, "openai"); err != nil || len(openai) != 2 {
+		t.Errorf("List(openai) = %+v, %v", openai, err)
+	}
+}
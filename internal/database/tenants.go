@@ -0,0 +1,436 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Tenant is a team served in isolation on a shared instance. Zero limits
+// mean unlimited.
+type Tenant struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	MonthlyBudget float64   `json:"monthly_budget"` // USD per calendar month
+	RPMLimit      int       `json:"rpm_limit"`
+	TPMLimit      int       `json:"tpm_limit"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TenantUsage is one request charged to a tenant
+type TenantUsage struct {
+	ID               int
+	TenantID         string
+	ClientID         *string
+	Provider         string
+	Model            string
+	PromptTokens     int // Including cached prompt tokens
+	CompletionTokens int
+	CachedTokens     int // Prompt tokens read from the provider's cache
+	CacheWriteTokens int // Prompt tokens written to the provider's cache
+	Cost             float64
+	CacheSavings     float64
+	CreatedAt        time.Time
+}
+
+// TenantModelUsage aggregates a tenant's usage of one model
+type TenantModelUsage struct {
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	Requests         int     `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CachedTokens     int64   `json:"cached_tokens"`
+	CacheWriteTokens int64   `json:"cache_write_tokens"`
+	Cost             float64 `json:"cost"`
+	CacheSavings     float64 `json:"cache_savings"`
+}
+
+// TenantClientUsage aggregates the usage of one model by one of a
+// tenant's clients
+type TenantClientUsage struct {
+	TenantID string `json:"tenant_id"`
+	ClientID string `json:"client_id"` // "" for requests without a client
+	TenantModelUsage
+}
+
+// TenantUsageReport summarizes a tenant's usage since a point in time
+type TenantUsageReport struct {
+	TenantID         string              `json:"tenant_id"`
+	Since            time.Time           `json:"since"`
+	Requests         int                 `json:"requests"`
+	PromptTokens     int64               `json:"prompt_tokens"`
+	CompletionTokens int64               `json:"completion_tokens"`
+	CachedTokens     int64               `json:"cached_tokens"`
+	CacheWriteTokens int64               `json:"cache_write_tokens"`
+	Cost             float64             `json:"cost"`
+	CacheSavings     float64             `json:"cache_savings"`
+	Models           []*TenantModelUsage `json:"models"`
+}
+
+const tenantColumns = `id, name, monthly_budget, rpm_limit, tpm_limit, created_at`
+
+// TenantRepository provides persistence for tenants, the clients and keys
+// they own, and their usage ledger
+type TenantRepository struct {
+	db *DB
+}
+
+// NewTenantRepository creates a new TenantRepository
+func NewTenantRepository(db *DB) *TenantRepository {
+	return &TenantRepository{db: db}
+}
+
+// Create inserts a new tenant
+func (r *TenantRepository) Create(t *Tenant) error {
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	query := `
+		INSERT INTO tenants (id, name, monthly_budget, rpm_limit, tpm_limit, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.db.conn.Exec(query, t.ID, t.Name, t.MonthlyBudget, t.RPMLimit, t.TPMLimit, t.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a tenant by ID, or nil if it does not exist
+func (r *TenantRepository) Get(id string) (*Tenant, error) {
+	t, err := scanTenant(r.db.conn.QueryRow(`SELECT `+tenantColumns+` FROM tenants WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	return t, nil
+}
+
+// List retrieves all tenants
+func (r *TenantRepository) List() ([]*Tenant, error) {
+	rows, err := r.db.conn.Query(`SELECT ` + tenantColumns + ` FROM tenants ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	var tenants []*Tenant
+	for rows.Next() {
+		t, err := scanTenant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenants = append(tenants, t)
+	}
+	return tenants, rows.Err()
+}
+
+// Update replaces a tenant's name, budget and limits
+func (r *TenantRepository) Update(t *Tenant) error {
+	query := `
+		UPDATE tenants SET name = ?, monthly_budget = ?, rpm_limit = ?, tpm_limit = ?
+		WHERE id = ?
+	`
+	result, err := r.db.conn.Exec(query, t.Name, t.MonthlyBudget, t.RPMLimit, t.TPMLimit, t.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update tenant: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("tenant not found: %s", t.ID)
+	}
+	return nil
+}
+
+// Delete removes a tenant. Its clients become shared again, but its keys
+// are deleted so they never leak into the shared pool. The usage ledger is
+// kept for reporting.
+func (r *TenantRepository) Delete(id string) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to delete tenant: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec(`DELETE FROM tenants WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tenant: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("tenant not found: %s", id)
+	}
+	if _, err := tx.Exec(`UPDATE clients SET tenant_id = NULL WHERE tenant_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to release tenant clients: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM api_keys WHERE tenant_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete tenant keys: %w", err)
+	}
+	return tx.Commit()
+}
+
+// AssignClient moves a client to a tenant; an empty tenant ID makes the
+// client shared again
+func (r *TenantRepository) AssignClient(clientID, tenantID string) error {
+	return r.assign("clients", clientID, tenantID)
+}
+
+// AssignKey gives a tenant exclusive use of an API key; an empty tenant ID
+// returns the key to the shared pool
+func (r *TenantRepository) AssignKey(keyID int, tenantID string) error {
+	return r.assign("api_keys", keyID, tenantID)
+}
+
+// assign sets the tenant_id of a clients or api_keys row
+func (r *TenantRepository) assign(table string, id interface{}, tenantID string) error {
+	var tenant *string
+	if tenantID != "" {
+		tenant = &tenantID
+	}
+	result, err := r.db.conn.Exec(`UPDATE `+table+` SET tenant_id = ? WHERE id = ?`, tenant, id)
+	if err != nil {
+		return fmt.Errorf("failed to assign tenant: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("%s row not found: %v", table, id)
+	}
+	return nil
+}
+
+// ReleaseClient makes a tenant's client shared again, reporting whether
+// the client belonged to the tenant
+func (r *TenantRepository) ReleaseClient(clientID, tenantID string) (bool, error) {
+	return r.release("clients", clientID, tenantID)
+}
+
+// ReleaseKey returns a tenant's API key to the shared pool, reporting
+// whether the key belonged to the tenant
+func (r *TenantRepository) ReleaseKey(keyID int, tenantID string) (bool, error) {
+	return r.release("api_keys", keyID, tenantID)
+}
+
+// release clears the tenant_id of a clients or api_keys row owned by tenantID
+func (r *TenantRepository) release(table string, id interface{}, tenantID string) (bool, error) {
+	result, err := r.db.conn.Exec(`UPDATE `+table+` SET tenant_id = NULL WHERE id = ? AND tenant_id = ?`, id, tenantID)
+	if err != nil {
+		return false, fmt.Errorf("failed to release from tenant: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// TenantForClient returns the ID of the tenant owning a client, or "" if
+// the client is shared or unknown
+func (r *TenantRepository) TenantForClient(clientID string) (string, error) {
+	var tenantID sql.NullString
+	err := r.db.conn.QueryRow(`SELECT tenant_id FROM clients WHERE id = ?`, clientID).Scan(&tenantID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get client tenant: %w", err)
+	}
+	return tenantID.String, nil
+}
+
+// ListClients returns the IDs of a tenant's clients
+func (r *TenantRepository) ListClients(tenantID string) ([]string, error) {
+	rows, err := r.db.conn.Query(`SELECT id FROM clients WHERE tenant_id = ? ORDER BY id`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenant clients: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// RecordUsage appends a request to the tenant's usage ledger
+func (r *TenantRepository) RecordUsage(u *TenantUsage) error {
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = time.Now()
+	}
+	query := `
+		INSERT INTO tenant_usage (tenant_id, client_id, provider, model, prompt_tokens, completion_tokens,
+			cached_tokens, cache_write_tokens, cost, cache_savings, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	id, err := r.db.conn.Insert(query, u.TenantID, u.ClientID, u.Provider, u.Model,
+		u.PromptTokens, u.CompletionTokens, u.CachedTokens, u.CacheWriteTokens, u.Cost, u.CacheSavings, u.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record tenant usage: %w", err)
+	}
+	u.ID = int(id)
+	return nil
+}
+
+// Spend returns a tenant's total cost since a point in time
+func (r *TenantRepository) Spend(tenantID string, since time.Time) (float64, error) {
+	var spend float64
+	err := r.db.conn.QueryRow(
+		`SELECT COALESCE(SUM(cost), 0) FROM tenant_usage WHERE tenant_id = ? AND created_at >= ?`,
+		tenantID, since,
+	).Scan(&spend)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tenant spend: %w", err)
+	}
+	return spend, nil
+}
+
+// Usage reports a tenant's usage since a point in time, by model
+func (r *TenantRepository) Usage(tenantID string, since time.Time) (*TenantUsageReport, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT provider, model, COUNT(*), COALESCE(SUM(prompt_tokens), 0),
+		       COALESCE(SUM(completion_tokens), 0), COALESCE(SUM(cached_tokens), 0),
+		       COALESCE(SUM(cache_write_tokens), 0), COALESCE(SUM(cost), 0), COALESCE(SUM(cache_savings), 0)
+		FROM tenant_usage
+		WHERE tenant_id = ? AND created_at >= ?
+		GROUP BY provider, model
+		ORDER BY SUM(cost) DESC, provider, model
+	`, tenantID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant usage: %w", err)
+	}
+	defer rows.Close()
+
+	report := &TenantUsageReport{TenantID: tenantID, Since: since, Models: []*TenantModelUsage{}}
+	for rows.Next() {
+		m := &TenantModelUsage{}
+		err := rows.Scan(&m.Provider, &m.Model, &m.Requests, &m.PromptTokens, &m.CompletionTokens,
+			&m.CachedTokens, &m.CacheWriteTokens, &m.Cost, &m.CacheSavings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tenant usage: %w", err)
+		}
+		report.Requests += m.Requests
+		report.PromptTokens += m.PromptTokens
+		report.CompletionTokens += m.CompletionTokens
+		report.CachedTokens += m.CachedTokens
+		report.CacheWriteTokens += m.CacheWriteTokens
+		report.Cost += m.Cost
+		report.CacheSavings += m.CacheSavings
+		report.Models = append(report.Models, m)
+	}
+	return report, rows.Err()
+}
+
+// UsageByClient reports every tenant's usage from one point in time up to
+// another by client and model, ordered by tenant, client, provider and
+// model
+func (r *TenantRepository) UsageByClient(from, to time.Time) ([]*TenantClientUsage, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT tenant_id, COALESCE(client_id, ''), provider, model, COUNT(*), COALESCE(SUM(prompt_tokens), 0),
+		       COALESCE(SUM(completion_tokens), 0), COALESCE(SUM(cached_tokens), 0),
+		       COALESCE(SUM(cache_write_tokens), 0), COALESCE(SUM(cost), 0), COALESCE(SUM(cache_savings), 0)
+		FROM tenant_usage
+		WHERE created_at >= ? AND created_at < ?
+		GROUP BY tenant_id, COALESCE(client_id, ''), provider, model
+		ORDER BY tenant_id, COALESCE(client_id, ''), provider, model
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant usage by client: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []*TenantClientUsage
+	for rows.Next() {
+		u := &TenantClientUsage{}
+		err := rows.Scan(&u.TenantID, &u.ClientID, &u.Provider, &u.Model, &u.Requests, &u.PromptTokens,
+			&u.CompletionTokens, &u.CachedTokens, &u.CacheWriteTokens, &u.Cost, &u.CacheSavings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tenant usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// TenantDailySpend is the ledger's cost for one day, tenant, client,
+// provider and model
+type TenantDailySpend struct {
+	Day      time.Time // Midnight starting the day
+	TenantID string
+	ClientID string // "" for requests without a client
+	Provider string
+	Model    string
+	Cost     float64
+}
+
+// DailySpend totals the ledger by day, tenant, client, provider and model
+// since a point in time. Days are midnights in since's location, bucketed
+// here because SQL date functions differ between databases. Rows are
+// ordered by day, tenant, client, provider and model.
+func (r *TenantRepository) DailySpend(since time.Time) ([]*TenantDailySpend, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT tenant_id, COALESCE(client_id, ''), provider, model, cost, created_at
+		FROM tenant_usage WHERE created_at >= ?
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant spend: %w", err)
+	}
+	defer rows.Close()
+
+	type key struct {
+		day                             time.Time
+		tenant, client, provider, model string
+	}
+	totals := make(map[key]*TenantDailySpend)
+	var out []*TenantDailySpend
+	for rows.Next() {
+		var (
+			k    key
+			cost float64
+			at   time.Time
+		)
+		if err := rows.Scan(&k.tenant, &k.client, &k.provider, &k.model, &cost, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant spend: %w", err)
+		}
+		at = at.In(since.Location())
+		k.day = time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, since.Location())
+		d, ok := totals[k]
+		if !ok {
+			d = &TenantDailySpend{Day: k.day, TenantID: k.tenant, ClientID: k.client, Provider: k.provider, Model: k.model}
+			totals[k] = d
+			out = append(out, d)
+		}
+		d.Cost += cost
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if !a.Day.Equal(b.Day) {
+			return a.Day.Before(b.Day)
+		}
+		if a.TenantID != b.TenantID {
+			return a.TenantID < b.TenantID
+		}
+		if a.ClientID != b.ClientID {
+			return a.ClientID < b.ClientID
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Model < b.Model
+	})
+	return out, nil
+}
+
+// scanTenant scans a row selected with tenantColumns
+func scanTenant(row rowScanner) (*Tenant, error) {
+	t := &Tenant{}
+	err := row.Scan(&t.ID, &t.Name, &t.MonthlyBudget, &t.RPMLimit, &t.TPMLimit, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
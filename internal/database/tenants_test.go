@@ -0,0 +1,189 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTenantRepository(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "tenants.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewTenantRepository(db)
+	if err := repo.Create(&Tenant{ID: "team-a", Name: "Team A", MonthlyBudget: 100, RPMLimit: 60}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repo.Create(&Tenant{ID: "team-b", Name: "Team B"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	tenant, err := repo.Get("team-a")
+	if err != nil || tenant == nil || tenant.MonthlyBudget != 100 || tenant.RPMLimit != 60 {
+		t.Fatalf("Get = %+v, %v", tenant, err)
+	}
+	tenant.TPMLimit = 50000
+	if err := repo.Update(tenant); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got, _ := repo.Get("team-a"); got.TPMLimit != 50000 {
+		t.Errorf("update not saved: %+v", got)
+	}
+	if tenants, err := repo.List(); err != nil || len(tenants) != 2 {
+		t.Errorf("List = %v, %v", tenants, err)
+	}
+
+	// Clients and keys
+	if err := NewClientRepository(db).Create(&Client{ID: "c1", Name: "cli", Version: "1", Token: "tok"}); err != nil {
+		t.Fatalf("Create client failed: %v", err)
+	}
+	if err := repo.AssignClient("c1", "team-a"); err != nil {
+		t.Fatalf("AssignClient failed: %v", err)
+	}
+	if id, err := repo.TenantForClient("c1"); err != nil || id != "team-a" {
+		t.Errorf("TenantForClient = %q, %v", id, err)
+	}
+	if id, _ := repo.TenantForClient("unknown"); id != "" {
+		t.Errorf("unknown client has tenant %q", id)
+	}
+	if err := repo.AssignClient("missing", "team-a"); err == nil {
+		t.Error("expected error assigning a missing client")
+	}
+
+	db.CreateProvider(&Provider{ID: "openai", Name: "OpenAI", BaseURL: "https://api.openai.com", AuthMethod: "bearer", PricingModel: "usage", Status: "online"})
+	owned, _ := db.CreateAPIKey("openai", "sk-team-a-0000000000")
+	shared, _ := db.CreateAPIKey("openai", "sk-shared-0000000000")
+	if err := repo.AssignKey(owned.ID, "team-a"); err != nil {
+		t.Fatalf("AssignKey failed: %v", err)
+	}
+	if key, _ := db.GetAPIKey(owned.ID); key.TenantID == nil || *key.TenantID != "team-a" {
+		t.Errorf("key not assigned: %+v", key)
+	}
+
+	if ok, err := repo.ReleaseKey(owned.ID, "team-b"); ok || err != nil {
+		t.Errorf("ReleaseKey by another tenant = %v, %v", ok, err)
+	}
+	if ok, err := repo.ReleaseKey(owned.ID, "team-a"); !ok || err != nil {
+		t.Errorf("ReleaseKey = %v, %v", ok, err)
+	}
+	repo.AssignKey(owned.ID, "team-a")
+
+	// Ledger
+	client := "c1"
+	now := time.Now()
+	repo.RecordUsage(&TenantUsage{TenantID: "team-a", ClientID: &client, Provider: "openai", Model: "gpt-4o", PromptTokens: 100, CompletionTokens: 50, Cost: 0.75})
+	repo.RecordUsage(&TenantUsage{TenantID: "team-a", Provider: "openai", Model: "gpt-4o", PromptTokens: 10, CompletionTokens: 5, CachedTokens: 8, Cost: 0.25, CacheSavings: 0.04})
+	repo.RecordUsage(&TenantUsage{TenantID: "team-a", Provider: "openai", Model: "gpt-4o-mini", Cost: 0.5, CreatedAt: now.AddDate(0, -2, 0)})
+	repo.RecordUsage(&TenantUsage{TenantID: "team-b", Provider: "openai", Model: "gpt-4o", Cost: 9})
+
+	since := now.Add(-time.Hour)
+	if spend, err := repo.Spend("team-a", since); err != nil || spend != 1.0 {
+		t.Errorf("Spend = %v, %v", spend, err)
+	}
+	report, err := repo.Usage("team-a", since)
+	if err != nil || report.Requests != 2 || report.PromptTokens != 110 || len(report.Models) != 1 {
+		t.Errorf("Usage = %+v, %v", report, err)
+	}
+	if report.CachedTokens != 8 || report.CacheSavings != 0.04 || report.Models[0].CachedTokens != 8 {
+		t.Errorf("cache usage not reported: %+v", report)
+	}
+
+	// Deleting the tenant releases its clients and drops its keys
+	if err := repo.Delete("team-a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if id, _ := repo.TenantForClient("c1"); id != "" {
+		t.Errorf("client still owned by %q", id)
+	}
+	if key, _ := db.GetAPIKey(owned.ID); key != nil {
+		t.Error("tenant key survived deletion")
+	}
+	if key, _ := db.GetAPIKey(shared.ID); key == nil {
+		t.Error("shared key deleted")
+	}
+	if err := repo.Delete("team-a"); err == nil {
+		t.Error("expected error deleting a missing tenant")
+	}
+}
+
+func TestTenantDailySpend(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "spend.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewTenantRepository(db)
+	client := "c1"
+	day := func(d, hour int) time.Time { return time.Date(2025, time.March, d, hour, 0, 0, 0, time.UTC) }
+	repo.RecordUsage(&TenantUsage{TenantID: "team-a", ClientID: &client, Provider: "openai", Model: "gpt-4o", Cost: 1, CreatedAt: day(2, 9)})
+	repo.RecordUsage(&TenantUsage{TenantID: "team-a", ClientID: &client, Provider: "openai", Model: "gpt-4o", Cost: 0.5, CreatedAt: day(2, 18)})
+	repo.RecordUsage(&TenantUsage{TenantID: "team-a", Provider: "openai", Model: "gpt-4o", Cost: 2, CreatedAt: day(2, 10)})
+	repo.RecordUsage(&TenantUsage{TenantID: "team-a", ClientID: &client, Provider: "anthropic", Model: "claude-sonnet-4", Cost: 3, CreatedAt: day(3, 1)})
+	repo.RecordUsage(&TenantUsage{TenantID: "team-a", ClientID: &client, Provider: "openai", Model: "gpt-4o", Cost: 7, CreatedAt: day(1, 12)})
+
+	spend, err := repo.DailySpend(day(2, 0))
+	if err != nil {
+		t.Fatalf("DailySpend failed: %v", err)
+	}
+	if len(spend) != 3 {
+		t.Fatalf("expected 3 daily totals, got %d", len(spend))
+	}
+	if s := spend[0]; !s.Day.Equal(day(2, 0)) || s.ClientID != "" || s.Cost != 2 {
+		t.Errorf("unexpected first total: %+v", s)
+	}
+	if s := spend[1]; !s.Day.Equal(day(2, 0)) || s.ClientID != "c1" || s.Provider != "openai" || s.Model != "gpt-4o" || s.Cost != 1.5 {
+		t.Errorf("expected a day's requests summed, got %+v", s)
+	}
+	if s := spend[2]; !s.Day.Equal(day(3, 0)) || s.Provider != "anthropic" || s.Cost != 3 {
+		t.Errorf("unexpected last total: %+v", s)
+	}
+
+	// Days follow since's location: 1am UTC on the 3rd is still the 2nd
+	// five hours west
+	west := time.FixedZone("UTC-5", -5*3600)
+	spend, err = repo.DailySpend(time.Date(2025, time.March, 2, 0, 0, 0, 0, west))
+	if err != nil {
+		t.Fatalf("DailySpend failed: %v", err)
+	}
+	if len(spend) != 3 || spend[1].Day.Day() != 2 || spend[1].Provider != "anthropic" {
+		t.Errorf("expected the late request on the 2nd, got %+v", spend)
+	}
+}
+
+func TestTenantUsageByClient(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewTenantRepository(db)
+	c1, c2 := "c1", "c2"
+	march := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	repo.RecordUsage(&TenantUsage{TenantID: "team-a", ClientID: &c1, Provider: "openai", Model: "gpt-4o", PromptTokens: 100, CompletionTokens: 20, Cost: 1, CreatedAt: march.Add(time.Hour)})
+	repo.RecordUsage(&TenantUsage{TenantID: "team-a", ClientID: &c1, Provider: "openai", Model: "gpt-4o", PromptTokens: 50, CompletionTokens: 10, CachedTokens: 40, Cost: 0.5, CacheSavings: 0.1, CreatedAt: march.AddDate(0, 0, 30)})
+	repo.RecordUsage(&TenantUsage{TenantID: "team-a", ClientID: &c2, Provider: "anthropic", Model: "claude-sonnet-4", Cost: 2, CreatedAt: march.Add(time.Hour)})
+	repo.RecordUsage(&TenantUsage{TenantID: "team-a", Provider: "openai", Model: "gpt-4o", Cost: 0.25, CreatedAt: march.Add(time.Hour)})
+	repo.RecordUsage(&TenantUsage{TenantID: "team-b", ClientID: &c1, Provider: "openai", Model: "gpt-4o", Cost: 9, CreatedAt: march.AddDate(0, 1, 0)})
+
+	usage, err := repo.UsageByClient(march, march.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("UsageByClient failed: %v", err)
+	}
+	if len(usage) != 3 {
+		t.Fatalf("expected 3 client and model totals in March, got %d", len(usage))
+	}
+	if u := usage[0]; u.TenantID != "team-a" || u.ClientID != "" || u.Cost != 0.25 {
+		t.Errorf("expected requests without a client first, got %+v", u)
+	}
+	if u := usage[1]; u.ClientID != "c1" || u.Requests != 2 || u.PromptTokens != 150 || u.CachedTokens != 40 || u.Cost != 1.5 || u.CacheSavings != 0.1 {
+		t.Errorf("unexpected c1 usage: %+v", u)
+	}
+	if u := usage[2]; u.ClientID != "c2" || u.Provider != "anthropic" || u.Cost != 2 {
+		t.Errorf("unexpected c2 usage: %+v", u)
+	}
+}
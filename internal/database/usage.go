@@ -0,0 +1,120 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// UsageBucket aggregates usage over one time interval
+type UsageBucket struct {
+	Start     time.Time
+	Requests  int
+	TokensIn  int
+	TokensOut int
+	Cost      float64
+	Failures  int
+}
+
+// ModelUsage aggregates usage for one model
+type ModelUsage struct {
+	ModelID   string
+	Requests  int
+	TokensIn  int
+	TokensOut int
+	Cost      float64
+	Failures  int
+}
+
+// UsageSeries aggregates usage since a time into buckets of the given
+// width, oldest first. Empty buckets are included so the series can be
+// charted directly. Bucketing happens here rather than in SQL because the
+// date functions differ between SQLite and PostgreSQL.
+func (db *DB) UsageSeries(since time.Time, bucket time.Duration) ([]*UsageBucket, error) {
+	if bucket <= 0 {
+		return nil, fmt.Errorf("bucket width must be positive")
+	}
+
+	query := `
+		SELECT timestamp, requests, tokens_in, tokens_out, cost, success
+		FROM usage_tracking WHERE timestamp >= ?
+	`
+	rows, err := db.conn.Query(query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	start := since.UTC().Truncate(bucket)
+	n := int(time.Since(start)/bucket) + 1
+	series := make([]*UsageBucket, n)
+	for i := range series {
+		series[i] = &UsageBucket{Start: start.Add(time.Duration(i) * bucket)}
+	}
+
+	for rows.Next() {
+		var (
+			ts                         time.Time
+			requests, tokensIn, tokOut int
+			cost                       float64
+			success                    bool
+		)
+		if err := rows.Scan(&ts, &requests, &tokensIn, &tokOut, &cost, &success); err != nil {
+			return nil, fmt.Errorf("failed to scan usage: %w", err)
+		}
+		i := int(ts.UTC().Sub(start) / bucket)
+		if i < 0 {
+			continue
+		}
+		if i >= len(series) {
+			// Clock skew between writers; grow rather than drop the row
+			for j := len(series); j <= i; j++ {
+				series = append(series, &UsageBucket{Start: start.Add(time.Duration(j) * bucket)})
+			}
+		}
+		b := series[i]
+		b.Requests += requests
+		b.TokensIn += tokensIn
+		b.TokensOut += tokOut
+		b.Cost += cost
+		if !success {
+			b.Failures += requests
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating usage: %w", err)
+	}
+
+	return series, nil
+}
+
+// UsageByModel aggregates usage since a time per model, costliest first
+func (db *DB) UsageByModel(since time.Time) ([]*ModelUsage, error) {
+	query := `
+		SELECT model_id, COALESCE(SUM(requests), 0), COALESCE(SUM(tokens_in), 0),
+			COALESCE(SUM(tokens_out), 0), COALESCE(SUM(cost), 0),
+			COALESCE(SUM(CASE WHEN success = TRUE THEN 0 ELSE requests END), 0)
+		FROM usage_tracking
+		WHERE timestamp >= ?
+		GROUP BY model_id
+		ORDER BY 5 DESC, model_id
+	`
+	rows, err := db.conn.Query(query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage by model: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var usage []*ModelUsage
+	for rows.Next() {
+		u := &ModelUsage{}
+		if err := rows.Scan(&u.ModelID, &u.Requests, &u.TokensIn, &u.TokensOut, &u.Cost, &u.Failures); err != nil {
+			return nil, fmt.Errorf("failed to scan usage by model: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating usage by model: %w", err)
+	}
+
+	return usage, nil
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUsageSeriesAndByModel(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	db.CreateProvider(&Provider{ID: "openai", Name: "OpenAI", BaseURL: "https://api.openai.com", AuthMethod: "bearer", PricingModel: "usage", Status: "online"})
+	db.CreateModelFamily(&ModelFamily{ID: "gpt", ProviderID: "openai", Name: "GPT"})
+	db.CreateModel(&Model{ID: "gpt-4", FamilyID: "gpt", Name: "GPT-4", Status: "online"})
+	db.CreateModel(&Model{ID: "gpt-4o", FamilyID: "gpt", Name: "GPT-4o", Status: "online"})
+
+	now := time.Now().UTC()
+	records := []*UsageRecord{
+		{ModelID: "gpt-4", Timestamp: now.Add(-150 * time.Minute), TokensIn: 10, Requests: 1, Cost: 1, Success: true},
+		{ModelID: "gpt-4", Timestamp: now.Add(-5 * time.Minute), TokensIn: 20, Requests: 1, Cost: 2, Success: false},
+		{ModelID: "gpt-4o", Timestamp: now.Add(-4 * time.Minute), TokensIn: 30, Requests: 2, Cost: 0.5, Success: true},
+		{ModelID: "gpt-4o", Timestamp: now.Add(-48 * time.Hour), TokensIn: 99, Requests: 1, Cost: 9, Success: true},
+	}
+	for _, r := range records {
+		if err := db.RecordUsage(r); err != nil {
+			t.Fatalf("RecordUsage failed: %v", err)
+		}
+	}
+
+	since := now.Add(-3 * time.Hour)
+	series, err := db.UsageSeries(since, time.Hour)
+	if err != nil {
+		t.Fatalf("UsageSeries failed: %v", err)
+	}
+	if len(series) < 3 || len(series) > 5 {
+		t.Fatalf("expected about 4 hourly buckets, got %d", len(series))
+	}
+	var requests, failures int
+	for i, b := range series {
+		if i > 0 && b.Start.Sub(series[i-1].Start) != time.Hour {
+			t.Errorf("bucket %d is not an hour after the previous one", i)
+		}
+		requests += b.Requests
+		failures += b.Failures
+	}
+	if requests != 4 || failures != 1 {
+		t.Errorf("expected 4 requests and 1 failure in the window, got %d and %d", requests, failures)
+	}
+	if last := series[len(series)-1]; last.Requests != 3 || last.TokensIn != 50 {
+		t.Errorf("unexpected latest bucket %+v", last)
+	}
+
+	byModel, err := db.UsageByModel(since)
+	if err != nil {
+		t.Fatalf("UsageByModel failed: %v", err)
+	}
+	if len(byModel) != 2 || byModel[0].ModelID != "gpt-4" || byModel[0].Cost != 3 || byModel[0].Failures != 1 || byModel[1].Requests != 2 {
+		t.Errorf("unexpected usage by model: %+v %+v", byModel[0], byModel[1])
+	}
+
+	if _, err := db.UsageSeries(since, 0); err == nil {
+		t.Error("expected error for zero bucket width")
+	}
+}
+
+func TestListAPIKeysAndFamilies(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "keys.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	db.CreateProvider(&Provider{ID: "openai", Name: "OpenAI", BaseURL: "https://api.openai.com", AuthMethod: "bearer", PricingModel: "usage", Status: "online"})
+	db.CreateModelFamily(&ModelFamily{ID: "gpt", ProviderID: "openai", Name: "GPT"})
+	first, _ := db.CreateAPIKey("openai", "sk-first-key-value")
+	db.CreateAPIKey("openai", "sk-second-key-value")
+	db.MarkKeyDegraded(first.ID, time.Now().Add(time.Hour))
+
+	keys, err := db.ListAPIKeys()
+	if err != nil {
+		t.Fatalf("ListAPIKeys failed: %v", err)
+	}
+	if len(keys) != 2 || !keys[0].Degraded {
+		t.Errorf("expected both keys including the degraded one, got %d", len(keys))
+	}
+
+	families, err := db.ListModelFamilies()
+	if err != nil || len(families) != 1 || families[0].ProviderID != "openai" {
+		t.Errorf("unexpected families %v (%v)", families, err)
+	}
+}
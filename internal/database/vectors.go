@@ -0,0 +1,195 @@
+package database
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// VectorCollection is a named set of vectors of one size, compared by one
+// metric
+type VectorCollection struct {
+	Name       string
+	Dimensions int
+	Metric     string
+	CreatedAt  time.Time
+}
+
+// VectorRecord is one vector in a collection with the text and metadata
+// it was stored with
+type VectorRecord struct {
+	ID        string
+	Values    []float32
+	Content   string
+	Metadata  map[string]string
+	UpdatedAt time.Time
+}
+
+// VectorRepository stores vector store collections and their vectors
+type VectorRepository struct {
+	db *DB
+}
+
+// NewVectorRepository creates a new VectorRepository
+func NewVectorRepository(db *DB) *VectorRepository {
+	return &VectorRepository{db: db}
+}
+
+// CreateCollection creates a collection
+func (r *VectorRepository) CreateCollection(c *VectorCollection) error {
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now().UTC()
+	}
+	_, err := r.db.conn.Exec(`INSERT INTO vector_collections (name, dimensions, metric, created_at) VALUES (?, ?, ?, ?)`,
+		c.Name, c.Dimensions, c.Metric, c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create vector collection: %w", err)
+	}
+	return nil
+}
+
+// ListCollections retrieves every collection by name
+func (r *VectorRepository) ListCollections() ([]*VectorCollection, error) {
+	rows, err := r.db.conn.Query(`SELECT name, dimensions, metric, created_at FROM vector_collections ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vector collections: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var collections []*VectorCollection
+	for rows.Next() {
+		c := &VectorCollection{}
+		if err := rows.Scan(&c.Name, &c.Dimensions, &c.Metric, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan vector collection: %w", err)
+		}
+		collections = append(collections, c)
+	}
+	return collections, rows.Err()
+}
+
+// DeleteCollection deletes a collection and its vectors
+func (r *VectorRepository) DeleteCollection(name string) error {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to delete vector collection: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(`DELETE FROM vector_records WHERE collection = ?`, name); err != nil {
+		return fmt.Errorf("failed to delete vectors: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM vector_collections WHERE name = ?`, name); err != nil {
+		return fmt.Errorf("failed to delete vector collection: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ListRecords retrieves every vector in a collection by ID
+func (r *VectorRepository) ListRecords(collection string) ([]*VectorRecord, error) {
+	rows, err := r.db.conn.Query(`
+		SELECT id, vector_values, content, metadata, updated_at
+		FROM vector_records WHERE collection = ? ORDER BY id
+	`, collection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vectors: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var records []*VectorRecord
+	for rows.Next() {
+		rec := &VectorRecord{}
+		var values []byte
+		var metadata string
+		if err := rows.Scan(&rec.ID, &values, &rec.Content, &metadata, &rec.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan vector: %w", err)
+		}
+		rec.Values = decodeVector(values)
+		if err := json.Unmarshal([]byte(metadata), &rec.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata of vector %s: %w", rec.ID, err)
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// Upsert stores records in a collection in one transaction, replacing
+// those with the same IDs
+func (r *VectorRepository) Upsert(collection string, records []*VectorRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to store vectors: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		INSERT INTO vector_records (collection, id, vector_values, content, metadata, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(collection, id) DO UPDATE SET
+			vector_values = excluded.vector_values,
+			content = excluded.content,
+			metadata = excluded.metadata,
+			updated_at = excluded.updated_at
+	`
+	now := time.Now().UTC()
+	for _, rec := range records {
+		metadata := rec.Metadata
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+		metadataJSON, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata of vector %s: %w", rec.ID, err)
+		}
+		if rec.UpdatedAt.IsZero() {
+			rec.UpdatedAt = now
+		}
+		if _, err := tx.Exec(query, collection, rec.ID, encodeVector(rec.Values), rec.Content, string(metadataJSON), rec.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to store vector %s: %w", rec.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteRecords deletes vectors from a collection by ID, returning how
+// many existed
+func (r *VectorRepository) DeleteRecords(collection string, ids []string) (int64, error) {
+	tx, err := r.db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete vectors: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var deleted int64
+	for _, id := range ids {
+		result, err := tx.Exec(`DELETE FROM vector_records WHERE collection = ? AND id = ?`, collection, id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete vector %s: %w", id, err)
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+	return deleted, tx.Commit()
+}
+
+// encodeVector packs values as little-endian float32s
+func encodeVector(values []float32) []byte {
+	b := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(v))
+	}
+	return b
+}
+
+// decodeVector unpacks little-endian float32s
+func decodeVector(b []byte) []float32 {
+	values := make([]float32, len(b)/4)
+	for i := range values {
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return values
+}
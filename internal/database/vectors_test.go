@@ -0,0 +1,67 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestVectorRepository(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "vectors.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewVectorRepository(db)
+	for _, c := range []*VectorCollection{{Name: "docs", Dimensions: 3, Metric: "cosine"}, {Name: "faq", Dimensions: 2, Metric: "dot"}} {
+		if err := repo.CreateCollection(c); err != nil {
+			t.Fatalf("CreateCollection failed: %v", err)
+		}
+	}
+	if err := repo.CreateCollection(&VectorCollection{Name: "docs", Dimensions: 3, Metric: "cosine"}); err == nil {
+		t.Error("expected a duplicate collection to fail")
+	}
+
+	if err := repo.Upsert("docs", []*VectorRecord{
+		{ID: "a", Values: []float32{1, 0.5, -0.25}, Content: "first", Metadata: map[string]string{"source": "a.md"}},
+		{ID: "b", Values: []float32{0, 1, 0}},
+	}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	// A stored ID is replaced
+	if err := repo.Upsert("docs", []*VectorRecord{{ID: "b", Values: []float32{0, 0, 1}, Content: "second"}}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if err := repo.Upsert("faq", []*VectorRecord{{ID: "a", Values: []float32{1, 1}}}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+
+	records, err := repo.ListRecords("docs")
+	if err != nil {
+		t.Fatalf("ListRecords failed: %v", err)
+	}
+	if len(records) != 2 || records[0].ID != "a" || records[0].Values[2] != -0.25 || records[0].Metadata["source"] != "a.md" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+	if b := records[1]; b.Content != "second" || b.Values[2] != 1 || b.Metadata == nil || b.UpdatedAt.IsZero() {
+		t.Errorf("expected b replaced, got %+v", b)
+	}
+
+	if n, err := repo.DeleteRecords("docs", []string{"a", "missing"}); err != nil || n != 1 {
+		t.Errorf("expected one vector deleted, got %d: %v", n, err)
+	}
+
+	if err := repo.DeleteCollection("docs"); err != nil {
+		t.Fatalf("DeleteCollection failed: %v", err)
+	}
+	collections, err := repo.ListCollections()
+	if err != nil || len(collections) != 1 || collections[0].Name != "faq" || collections[0].Dimensions != 2 {
+		t.Errorf("expected only faq left, got %+v: %v", collections, err)
+	}
+	if records, _ := repo.ListRecords("docs"); len(records) != 0 {
+		t.Errorf("expected the collection's vectors deleted, got %+v", records)
+	}
+	if records, _ := repo.ListRecords("faq"); len(records) != 1 {
+		t.Errorf("expected other collections untouched, got %+v", records)
+	}
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
+)
+
+// WebhookDeadLetter is a webhook delivery that exhausted its retries
+type WebhookDeadLetter struct {
+	ID         int
+	Endpoint   string
+	URL        string
+	EventID    string
+	EventType  string
+	Payload    string
+	Attempts   int
+	LastStatus *int
+	LastError  *string
+	CreatedAt  time.Time
+}
+
+// WebhookDeadLetterFilter defines filtering options for listing dead letters
+type WebhookDeadLetterFilter struct {
+	Endpoint  *string
+	EventType *string
+	Limit     int
+	Offset    int
+}
+
+// CreateWebhookDeadLetter inserts a failed delivery, masking credentials
+// in the payload and error
+func (db *DB) CreateWebhookDeadLetter(dl *WebhookDeadLetter) error {
+	if dl.CreatedAt.IsZero() {
+		dl.CreatedAt = time.Now()
+	}
+	dl.Payload = redact.String(dl.Payload)
+	dl.LastError = maskError(dl.LastError)
+
+	query := `
+		INSERT INTO webhook_dead_letters (
+			endpoint, url, event_id, event_type, payload, attempts, last_status, last_error, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	id, err := db.conn.Insert(query,
+		dl.Endpoint, dl.URL, dl.EventID, dl.EventType, dl.Payload,
+		dl.Attempts, dl.LastStatus, dl.LastError, dl.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook dead letter: %w", err)
+	}
+	dl.ID = int(id)
+
+	return nil
+}
+
+// GetWebhookDeadLetter retrieves a dead letter by ID
+func (db *DB) GetWebhookDeadLetter(id int) (*WebhookDeadLetter, error) {
+	query := `
+		SELECT id, endpoint, url, event_id, event_type, payload, attempts, last_status, last_error, created_at
+		FROM webhook_dead_letters WHERE id = ?
+	`
+	dl, err := scanWebhookDeadLetter(db.conn.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook dead letter: %w", err)
+	}
+	return dl, nil
+}
+
+// ListWebhookDeadLetters retrieves dead letters with optional filtering, newest first
+func (db *DB) ListWebhookDeadLetters(filter *WebhookDeadLetterFilter) ([]*WebhookDeadLetter, error) {
+	query := `
+		SELECT id, endpoint, url, event_id, event_type, payload, attempts, last_status, last_error, created_at
+		FROM webhook_dead_letters WHERE 1=1`
+	var args []interface{}
+
+	if filter != nil {
+		if filter.Endpoint != nil {
+			query += " AND endpoint = ?"
+			args = append(args, *filter.Endpoint)
+		}
+		if filter.EventType != nil {
+			query += " AND event_type = ?"
+			args = append(args, *filter.EventType)
+		}
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	if filter != nil && filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+		if filter.Offset > 0 {
+			query += " OFFSET ?"
+			args = append(args, filter.Offset)
+		}
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook dead letters: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var letters []*WebhookDeadLetter
+	for rows.Next() {
+		dl, err := scanWebhookDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook dead letter: %w", err)
+		}
+		letters = append(letters, dl)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook dead letters: %w", err)
+	}
+
+	return letters, nil
+}
+
+// DeleteWebhookDeadLetter removes a dead letter, e.g. after a successful redelivery
+func (db *DB) DeleteWebhookDeadLetter(id int) error {
+	result, err := db.conn.Exec("DELETE FROM webhook_dead_letters WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook dead letter: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("webhook dead letter not found: %d", id)
+	}
+
+	return nil
+}
+
+// scanWebhookDeadLetter reads one dead letter row
+func scanWebhookDeadLetter(row rowScanner) (*WebhookDeadLetter, error) {
+	dl := &WebhookDeadLetter{}
+	err := row.Scan(
+		&dl.ID, &dl.Endpoint, &dl.URL, &dl.EventID, &dl.EventType, &dl.Payload,
+		&dl.Attempts, &dl.LastStatus, &dl.LastError, &dl.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return dl, nil
+}
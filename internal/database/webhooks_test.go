@@ -0,0 +1,120 @@
+package database
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWebhookDeadLetters(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "modelscan-test-*.db")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	tmpFile.Close()
+
+	db, err := Open(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	status := 503
+	lastErr := "webhook returned status 503"
+	letters := []*WebhookDeadLetter{
+		{
+			Endpoint: "ops", URL: "https://ops.example.com/hook", EventID: "evt-1", EventType: "provider.unhealthy",
+			Payload: `{"id":"evt-1"}`, Attempts: 5, LastStatus: &status, LastError: &lastErr,
+			CreatedAt: time.Now().Add(-time.Hour),
+		},
+		{
+			Endpoint: "ops", URL: "https://ops.example.com/hook", EventID: "evt-2", EventType: "discovery.completed",
+			Payload: `{"id":"evt-2"}`, Attempts: 1,
+		},
+		{
+			Endpoint: "billing", URL: "https://billing.example.com/hook", EventID: "evt-3", EventType: "budget.threshold_crossed",
+			Payload: `{"id":"evt-3"}`, Attempts: 5, LastError: &lastErr,
+		},
+	}
+	for _, dl := range letters {
+		if err := db.CreateWebhookDeadLetter(dl); err != nil {
+			t.Fatalf("CreateWebhookDeadLetter() error = %v", err)
+		}
+		if dl.ID == 0 {
+			t.Error("expected ID to be set")
+		}
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		got, err := db.GetWebhookDeadLetter(letters[0].ID)
+		if err != nil {
+			t.Fatalf("GetWebhookDeadLetter() error = %v", err)
+		}
+		if got == nil || got.EventID != "evt-1" || got.Attempts != 5 ||
+			got.LastStatus == nil || *got.LastStatus != 503 || got.LastError == nil || *got.LastError != lastErr {
+			t.Errorf("unexpected dead letter %+v", got)
+		}
+
+		missing, err := db.GetWebhookDeadLetter(9999)
+		if err != nil || missing != nil {
+			t.Errorf("expected nil, nil for missing dead letter, got %+v, %v", missing, err)
+		}
+	})
+
+	t.Run("List newest first", func(t *testing.T) {
+		list, err := db.ListWebhookDeadLetters(nil)
+		if err != nil {
+			t.Fatalf("ListWebhookDeadLetters() error = %v", err)
+		}
+		if len(list) != 3 {
+			t.Fatalf("expected 3 dead letters, got %d", len(list))
+		}
+		if list[2].EventID != "evt-1" {
+			t.Errorf("expected oldest dead letter last, got %s", list[2].EventID)
+		}
+		if list[0].LastStatus != nil {
+			t.Errorf("expected nil last status, got %v", *list[0].LastStatus)
+		}
+	})
+
+	t.Run("List filtered", func(t *testing.T) {
+		endpoint := "ops"
+		eventType := "provider.unhealthy"
+		list, err := db.ListWebhookDeadLetters(&WebhookDeadLetterFilter{Endpoint: &endpoint})
+		if err != nil {
+			t.Fatalf("ListWebhookDeadLetters() error = %v", err)
+		}
+		if len(list) != 2 {
+			t.Errorf("expected 2 dead letters for ops, got %d", len(list))
+		}
+
+		list, err = db.ListWebhookDeadLetters(&WebhookDeadLetterFilter{Endpoint: &endpoint, EventType: &eventType})
+		if err != nil {
+			t.Fatalf("ListWebhookDeadLetters() error = %v", err)
+		}
+		if len(list) != 1 || list[0].EventID != "evt-1" {
+			t.Errorf("unexpected filtered list %+v", list)
+		}
+
+		list, err = db.ListWebhookDeadLetters(&WebhookDeadLetterFilter{Limit: 1, Offset: 1})
+		if err != nil {
+			t.Fatalf("ListWebhookDeadLetters() error = %v", err)
+		}
+		if len(list) != 1 {
+			t.Errorf("expected 1 dead letter with limit, got %d", len(list))
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := db.DeleteWebhookDeadLetter(letters[1].ID); err != nil {
+			t.Fatalf("DeleteWebhookDeadLetter() error = %v", err)
+		}
+		if got, _ := db.GetWebhookDeadLetter(letters[1].ID); got != nil {
+			t.Error("expected dead letter to be deleted")
+		}
+		if err := db.DeleteWebhookDeadLetter(letters[1].ID); err == nil {
+			t.Error("expected error deleting missing dead letter")
+		}
+	})
+}
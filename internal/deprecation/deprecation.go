@@ -0,0 +1,421 @@
+// Package deprecation tracks when providers retire models, warns when a
+// configured alias points at a model that is retired or retires soon, and
+// suggests replacements: the provider's recommended one, then other models
+// of the same family.
+//
+// Retirements come from a list built into the binary and, when the
+// documentation scraper is enabled, from the providers' deprecation pages;
+// a scraped entry replaces the built-in one for the same model.
+package deprecation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/scraper"
+)
+
+// DefaultWarning is how long before retirement aliases are flagged when not
+// configured
+const DefaultWarning = 30 * 24 * time.Hour
+
+// maxSuggestions bounds the replacements suggested for one model
+const maxSuggestions = 3
+
+// Store holds scraped retirements, the aliases to check and the model
+// catalog replacements are suggested from
+type Store interface {
+	ListModelDeprecations() ([]*database.ModelDeprecation, error)
+	RecordModelDeprecation(d *database.ModelDeprecation) error
+	ListAllAliases() ([]*database.Alias, error)
+	ListModelFamilies() ([]*database.ModelFamily, error)
+	ListModels() ([]*database.Model, error)
+}
+
+// Scraper reads retirements from providers' deprecation pages (usually a
+// *scraper.Scraper)
+type Scraper interface {
+	Providers() []string
+	ScrapeDeprecations(ctx context.Context, provider string) ([]scraper.Deprecation, error)
+}
+
+// State summarizes how close a model is to retirement
+type State string
+
+// Retirement states, from most to least urgent
+const (
+	StateRetired   State = "retired"
+	StateRetiring  State = "retiring" // Within the warning window
+	StateScheduled State = "scheduled"
+)
+
+// Deprecation is a tracked retirement with the aliases that point at the
+// model and the suggested replacements
+type Deprecation struct {
+	database.ModelDeprecation
+	State       State    `json:"state"`
+	Suggestions []string `json:"suggestions,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"` // client:name for client aliases
+}
+
+// AliasWarning flags an alias pointing at a retired or soon-retiring model
+type AliasWarning struct {
+	Alias       string    `json:"alias"`
+	ClientID    string    `json:"client_id,omitempty"`
+	ModelID     string    `json:"model_id"`
+	ProviderID  string    `json:"provider_id"`
+	State       State     `json:"state"`
+	RetiresAt   time.Time `json:"retires_at"`
+	Warning     string    `json:"warning"`
+	Suggestions []string  `json:"suggestions,omitempty"`
+}
+
+// Key identifies the alias
+func (w *AliasWarning) Key() string {
+	if w.ClientID == "" {
+		return w.Alias
+	}
+	return w.ClientID + ":" + w.Alias
+}
+
+// Report lists every tracked retirement, soonest first, and the aliases
+// that need attention, most urgent first
+type Report struct {
+	Deprecations []*Deprecation  `json:"deprecations"`
+	Warnings     []*AliasWarning `json:"warnings"`
+}
+
+// Check builds a report. Models retiring within warn are flagged as
+// retiring.
+func Check(store Store, now time.Time, warn time.Duration) (*Report, error) {
+	stored, err := store.ListModelDeprecations()
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := store.ListAllAliases()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases: %w", err)
+	}
+	catalog, err := newCatalog(store)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scraped entries replace the seeded ones for the same model
+	byModel := make(map[string]*Deprecation)
+	for _, list := range [][]*database.ModelDeprecation{Seeded(), stored} {
+		for _, d := range list {
+			dep := &Deprecation{ModelDeprecation: *d, State: assess(d.RetiresAt, now, warn)}
+			byModel[strings.ToLower(d.ModelID)] = dep
+		}
+	}
+
+	report := &Report{Deprecations: make([]*Deprecation, 0, len(byModel)), Warnings: []*AliasWarning{}}
+	for _, dep := range byModel {
+		dep.Suggestions = suggest(dep, byModel, catalog)
+		report.Deprecations = append(report.Deprecations, dep)
+	}
+	sort.Slice(report.Deprecations, func(i, j int) bool {
+		a, b := report.Deprecations[i], report.Deprecations[j]
+		if !a.RetiresAt.Equal(b.RetiresAt) {
+			return a.RetiresAt.Before(b.RetiresAt)
+		}
+		if a.ProviderID != b.ProviderID {
+			return a.ProviderID < b.ProviderID
+		}
+		return a.ModelID < b.ModelID
+	})
+
+	for _, a := range aliases {
+		dep, ok := byModel[strings.ToLower(a.ModelID)]
+		if !ok {
+			continue
+		}
+		w := &AliasWarning{
+			Alias:       a.Name,
+			ModelID:     a.ModelID,
+			ProviderID:  dep.ProviderID,
+			State:       dep.State,
+			RetiresAt:   dep.RetiresAt,
+			Suggestions: dep.Suggestions,
+		}
+		if a.ClientID != nil {
+			w.ClientID = *a.ClientID
+		}
+		dep.Aliases = append(dep.Aliases, w.Key())
+		if dep.State == StateScheduled {
+			continue
+		}
+		w.Warning = warning(w, now)
+		report.Warnings = append(report.Warnings, w)
+	}
+	sort.SliceStable(report.Warnings, func(i, j int) bool {
+		return report.Warnings[i].RetiresAt.Before(report.Warnings[j].RetiresAt)
+	})
+	return report, nil
+}
+
+// assess decides how close a retirement is
+func assess(retiresAt, now time.Time, warn time.Duration) State {
+	switch {
+	case !now.Before(retiresAt):
+		return StateRetired
+	case retiresAt.Sub(now) <= warn:
+		return StateRetiring
+	default:
+		return StateScheduled
+	}
+}
+
+// warning describes an alias's retirement and what to move it to
+func warning(w *AliasWarning, now time.Time) string {
+	var msg string
+	if w.State == StateRetired {
+		msg = fmt.Sprintf("alias %s points at %s, retired %s", w.Key(), w.ModelID, w.RetiresAt.Format("2006-01-02"))
+	} else {
+		in := "less than a day"
+		if days := int(math.Round(w.RetiresAt.Sub(now).Hours() / 24)); days == 1 {
+			in = "1 day"
+		} else if days > 1 {
+			in = fmt.Sprintf("%d days", days)
+		}
+		msg = fmt.Sprintf("alias %s points at %s, which retires in %s (%s)", w.Key(), w.ModelID, in, w.RetiresAt.Format("2006-01-02"))
+	}
+	if len(w.Suggestions) > 0 {
+		msg += "; consider " + strings.Join(w.Suggestions, " or ")
+	}
+	return msg
+}
+
+// catalog indexes the discovered models by family
+type catalog struct {
+	familyOf map[string]string   // Model ID -> family ID
+	provider map[string]string   // Family ID -> provider ID
+	members  map[string][]string // Family ID -> model IDs, newest-looking first
+}
+
+func newCatalog(store Store) (*catalog, error) {
+	families, err := store.ListModelFamilies()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list model families: %w", err)
+	}
+	models, err := store.ListModels()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	c := &catalog{familyOf: make(map[string]string), provider: make(map[string]string), members: make(map[string][]string)}
+	for _, f := range families {
+		c.provider[f.ID] = f.ProviderID
+	}
+	for _, m := range models {
+		key := strings.ToLower(m.ID)
+		c.familyOf[key] = m.FamilyID
+		c.members[m.FamilyID] = append(c.members[m.FamilyID], m.ID)
+	}
+	// Model IDs end in versions and dates, so the highest sorts newest
+	for _, ids := range c.members {
+		sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	}
+	return c, nil
+}
+
+// suggest lists replacements for a model: the provider's recommendation,
+// followed past replacements that are retiring themselves, then the other
+// models of its family that are not retiring
+func suggest(dep *Deprecation, byModel map[string]*Deprecation, c *catalog) []string {
+	var suggestions []string
+	seen := map[string]bool{strings.ToLower(dep.ModelID): true}
+	usable := func(id string) bool {
+		next, tracked := byModel[strings.ToLower(id)]
+		return !tracked || next.State == StateScheduled
+	}
+
+	for next := dep.Replacement; next != "" && !seen[strings.ToLower(next)]; {
+		seen[strings.ToLower(next)] = true
+		if usable(next) {
+			suggestions = append(suggestions, next)
+			break
+		}
+		next = byModel[strings.ToLower(next)].Replacement
+	}
+
+	family, ok := c.familyOf[strings.ToLower(dep.ModelID)]
+	if !ok || c.provider[family] != dep.ProviderID {
+		return suggestions
+	}
+	for _, id := range c.members[family] {
+		if len(suggestions) >= maxSuggestions {
+			break
+		}
+		if seen[strings.ToLower(id)] || !usable(id) {
+			continue
+		}
+		seen[strings.ToLower(id)] = true
+		suggestions = append(suggestions, id)
+	}
+	return suggestions
+}
+
+// Config configures a Tracker
+type Config struct {
+	// Interval between refreshes (default 24h)
+	Interval time.Duration
+	// Warning is how long before retirement aliases are flagged (default 30 days)
+	Warning time.Duration
+	// Scraper reads the providers' deprecation pages (optional; only the
+	// built-in list is used without it)
+	Scraper Scraper
+	// OnWarning is called when an alias's model becomes retiring or
+	// retired (optional)
+	OnWarning func(w *AliasWarning)
+}
+
+// Tracker refreshes retirements on an interval and warns about aliases
+// that point at retiring models
+type Tracker struct {
+	config Config
+	store  Store
+
+	mu     sync.Mutex
+	warned map[string]State // Alias key -> state last warned about
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewTracker creates a tracker; call Start to begin refreshing
+func NewTracker(store Store, cfg Config) *Tracker {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 24 * time.Hour
+	}
+	if cfg.Warning <= 0 {
+		cfg.Warning = DefaultWarning
+	}
+	return &Tracker{
+		config: cfg,
+		store:  store,
+		warned: make(map[string]State),
+		stop:   make(chan struct{}),
+	}
+}
+
+// Warning returns how long before retirement aliases are flagged
+func (t *Tracker) Warning() time.Duration {
+	return t.config.Warning
+}
+
+// Start begins refreshing in the background
+func (t *Tracker) Start() {
+	t.startOnce.Do(func() {
+		t.wg.Add(1)
+		go t.loop()
+	})
+}
+
+// Stop halts refreshing and waits for an in-progress refresh to finish
+func (t *Tracker) Stop() {
+	t.stopOnce.Do(func() { close(t.stop) })
+	t.wg.Wait()
+}
+
+// loop refreshes until stopped, logging failures
+func (t *Tracker) loop() {
+	defer t.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-t.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+	for {
+		_ = crash.Do("deprecation refresh", func() {
+			if err := t.Refresh(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("deprecation: %v", err)
+			}
+		})
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh scrapes every provider's deprecation page, when a scraper is
+// configured, then raises warnings for aliases that newly need attention.
+// A page that fails to load is logged and skipped.
+func (t *Tracker) Refresh(ctx context.Context) error {
+	if t.config.Scraper != nil {
+		for _, provider := range t.config.Scraper.Providers() {
+			scraped, err := t.config.Scraper.ScrapeDeprecations(ctx, provider)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				log.Printf("deprecation: %s: %v", provider, err)
+				continue
+			}
+			for _, d := range scraped {
+				err := t.store.RecordModelDeprecation(&database.ModelDeprecation{
+					ProviderID:  d.Provider,
+					ModelID:     d.ModelID,
+					RetiresAt:   d.RetiresAt,
+					Replacement: d.Replacement,
+					Source:      database.DeprecationSourceScraped,
+					SourceURL:   d.SourceURL,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return t.warn(time.Now())
+}
+
+// warn calls OnWarning for each alias whose state needs attention and has
+// changed since it was last warned about
+func (t *Tracker) warn(now time.Time) error {
+	report, err := Check(t.store, now, t.config.Warning)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	current := make(map[string]bool, len(report.Warnings))
+	for _, w := range report.Warnings {
+		current[w.Key()] = true
+		if t.warned[w.Key()] == w.State {
+			continue
+		}
+		t.warned[w.Key()] = w.State
+		if t.config.OnWarning != nil {
+			t.config.OnWarning(w)
+		}
+	}
+	// An alias moved to another model is warned about again if it ever
+	// points at a retiring one
+	for key := range t.warned {
+		if !current[key] {
+			delete(t.warned, key)
+		}
+	}
+	return nil
+}
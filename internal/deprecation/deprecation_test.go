@@ -0,0 +1,165 @@
+package deprecation
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/scraper"
+)
+
+// openDB opens a database with the default aliases, which include haiku
+// (claude-3-5-haiku-20241022, retiring 2026-02-19) and gemini
+// (gemini-1.5-pro, retired 2025-09-24), and an Anthropic haiku family
+func openDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.Open(filepath.Join(t.TempDir(), "deprecation.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.CreateProvider(&database.Provider{ID: "anthropic", Name: "Anthropic", BaseURL: "https://api.anthropic.com", Status: "online"}); err != nil {
+		t.Fatalf("CreateProvider failed: %v", err)
+	}
+	if err := db.CreateModelFamily(&database.ModelFamily{ID: "claude-haiku", ProviderID: "anthropic", Name: "Claude Haiku"}); err != nil {
+		t.Fatalf("CreateModelFamily failed: %v", err)
+	}
+	for _, id := range []string{"claude-3-haiku-20240307", "claude-3-5-haiku-20241022", "claude-haiku-4-5-20251001"} {
+		if err := db.CreateModel(&database.Model{ID: id, FamilyID: "claude-haiku", Name: id, Status: "online"}); err != nil {
+			t.Fatalf("CreateModel failed: %v", err)
+		}
+	}
+	return db
+}
+
+func TestCheck(t *testing.T) {
+	db := openDB(t)
+	now := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	report, err := Check(db, now, DefaultWarning)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(report.Deprecations) != len(Seeded()) {
+		t.Fatalf("expected the %d seeded deprecations, got %d", len(Seeded()), len(report.Deprecations))
+	}
+	for i := 1; i < len(report.Deprecations); i++ {
+		if report.Deprecations[i].RetiresAt.Before(report.Deprecations[i-1].RetiresAt) {
+			t.Fatal("expected deprecations soonest first")
+		}
+	}
+
+	if len(report.Warnings) != 2 {
+		t.Fatalf("expected warnings for gemini and haiku, got %+v", report.Warnings)
+	}
+	gemini, haiku := report.Warnings[0], report.Warnings[1]
+	if gemini.Alias != "gemini" || gemini.State != StateRetired || gemini.ProviderID != "google" {
+		t.Errorf("unexpected gemini warning %+v", gemini)
+	}
+	if haiku.Alias != "haiku" || haiku.State != StateRetiring || !strings.Contains(haiku.Warning, "retires in 18 days") {
+		t.Errorf("unexpected haiku warning %+v", haiku)
+	}
+	// The recommended replacement first, then the rest of the family
+	want := []string{"claude-haiku-4-5-20251001", "claude-3-haiku-20240307"}
+	if !reflect.DeepEqual(haiku.Suggestions, want) {
+		t.Errorf("expected suggestions %v, got %v", want, haiku.Suggestions)
+	}
+
+	// Outside the warning window the model is only scheduled
+	report, _ = Check(db, now, 7*24*time.Hour)
+	if len(report.Warnings) != 1 || report.Warnings[0].Alias != "gemini" {
+		t.Errorf("expected only gemini with a 7 day window, got %+v", report.Warnings)
+	}
+}
+
+func TestCheck_ScrapedReplacesSeed(t *testing.T) {
+	db := openDB(t)
+	now := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	// The retirement moved, and the replacement for Claude 2 now retires
+	// too, so its suggestion follows the chain
+	records := []*database.ModelDeprecation{
+		{ProviderID: "anthropic", ModelID: "claude-3-5-haiku-20241022", RetiresAt: now.AddDate(0, 6, 0), Source: database.DeprecationSourceScraped},
+		{ProviderID: "anthropic", ModelID: "claude-sonnet-4-20250514", RetiresAt: now.AddDate(0, 0, 10), Replacement: "claude-sonnet-4-5-20250929", Source: database.DeprecationSourceScraped},
+	}
+	for _, d := range records {
+		if err := db.RecordModelDeprecation(d); err != nil {
+			t.Fatalf("RecordModelDeprecation failed: %v", err)
+		}
+	}
+
+	report, err := Check(db, now, DefaultWarning)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(report.Deprecations) != len(Seeded())+1 {
+		t.Errorf("expected one deprecation beyond the seeded ones, got %d", len(report.Deprecations))
+	}
+	for _, d := range report.Deprecations {
+		switch d.ModelID {
+		case "claude-3-5-haiku-20241022":
+			if d.State != StateScheduled || d.Source != database.DeprecationSourceScraped || !reflect.DeepEqual(d.Aliases, []string{"haiku"}) {
+				t.Errorf("expected the scraped haiku retirement, got %+v", d)
+			}
+		case "claude-2.1":
+			if !reflect.DeepEqual(d.Suggestions, []string{"claude-sonnet-4-5-20250929"}) {
+				t.Errorf("expected the replacement chain to be followed, got %v", d.Suggestions)
+			}
+		}
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0].Alias != "gemini" {
+		t.Errorf("expected only gemini to warn, got %+v", report.Warnings)
+	}
+}
+
+// fakeScraper serves fixed deprecations for one provider
+type fakeScraper struct {
+	deprecations []scraper.Deprecation
+}
+
+func (f *fakeScraper) Providers() []string { return []string{"openai"} }
+
+func (f *fakeScraper) ScrapeDeprecations(ctx context.Context, provider string) ([]scraper.Deprecation, error) {
+	return f.deprecations, nil
+}
+
+func TestTracker_Refresh(t *testing.T) {
+	db := openDB(t)
+	if err := db.CreateAlias(&database.Alias{Name: "small", ModelID: "gpt-4o-mini"}); err != nil {
+		t.Fatalf("CreateAlias failed: %v", err)
+	}
+
+	var warned []string
+	tracker := NewTracker(db, Config{
+		Scraper: &fakeScraper{deprecations: []scraper.Deprecation{{
+			Provider: "openai", ModelID: "gpt-4o-mini", RetiresAt: time.Now().Add(72 * time.Hour), Replacement: "gpt-4.1-mini",
+		}}},
+		OnWarning: func(w *AliasWarning) { warned = append(warned, w.Alias) },
+	})
+
+	if err := tracker.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	stored, _ := db.ListModelDeprecations()
+	if len(stored) != 1 || stored[0].Source != database.DeprecationSourceScraped || stored[0].Replacement != "gpt-4.1-mini" {
+		t.Errorf("expected the scraped deprecation to be stored, got %+v", stored)
+	}
+	// haiku and gemini are retired by now; small retires in three days
+	if len(warned) != 3 {
+		t.Fatalf("expected 3 warnings, got %v", warned)
+	}
+
+	// Nothing changed, so nothing is warned about again
+	warned = nil
+	if err := tracker.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if len(warned) != 0 {
+		t.Errorf("expected no repeated warnings, got %v", warned)
+	}
+}
@@ -0,0 +1,51 @@
+package deprecation
+
+import (
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// seed lists retirements announced by the built-in providers. Scraped
+// entries for the same model take precedence, so dates a provider moves are
+// picked up without a new release.
+var seed = []struct {
+	provider, model, retires, replacement string
+}{
+	{"openai", "gpt-4-32k", "2025-06-06", "gpt-4o"},
+	{"openai", "gpt-4-32k-0613", "2025-06-06", "gpt-4o"},
+	{"openai", "gpt-4.5-preview", "2025-07-14", "gpt-4.1"},
+	{"openai", "o1-preview", "2025-07-28", "o3"},
+	{"openai", "o1-mini", "2025-10-27", "o4-mini"},
+	{"anthropic", "claude-2.0", "2025-07-21", "claude-sonnet-4-20250514"},
+	{"anthropic", "claude-2.1", "2025-07-21", "claude-sonnet-4-20250514"},
+	{"anthropic", "claude-3-sonnet-20240229", "2025-07-21", "claude-sonnet-4-20250514"},
+	{"anthropic", "claude-3-5-sonnet-20240620", "2025-10-22", "claude-sonnet-4-5-20250929"},
+	{"anthropic", "claude-3-5-sonnet-20241022", "2025-10-22", "claude-sonnet-4-5-20250929"},
+	{"anthropic", "claude-3-opus-20240229", "2026-01-05", "claude-opus-4-1-20250805"},
+	{"anthropic", "claude-3-5-haiku-20241022", "2026-02-19", "claude-haiku-4-5-20251001"},
+	{"anthropic", "claude-3-7-sonnet-20250219", "2026-02-19", "claude-sonnet-4-5-20250929"},
+	{"google", "gemini-1.5-pro", "2025-09-24", "gemini-2.5-pro"},
+	{"google", "gemini-1.5-flash", "2025-09-24", "gemini-2.5-flash"},
+	{"groq", "gemma-7b-it", "2024-12-18", "gemma2-9b-it"},
+	{"groq", "mixtral-8x7b-32768", "2025-03-20", "mistral-saba-24b"},
+}
+
+// Seeded returns the built-in retirements
+func Seeded() []*database.ModelDeprecation {
+	deprecations := make([]*database.ModelDeprecation, 0, len(seed))
+	for _, s := range seed {
+		retires, err := time.Parse("2006-01-02", s.retires)
+		if err != nil {
+			panic("deprecation: invalid seed date " + s.retires)
+		}
+		deprecations = append(deprecations, &database.ModelDeprecation{
+			ProviderID:  s.provider,
+			ModelID:     s.model,
+			RetiresAt:   retires,
+			Replacement: s.replacement,
+			Source:      database.DeprecationSourceSeed,
+		})
+	}
+	return deprecations
+}
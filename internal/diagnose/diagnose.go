@@ -0,0 +1,335 @@
+// Package diagnose tests the connection to a provider stage by stage (DNS,
+// TCP, TLS, authentication, model listing and one tiny completion) and
+// reports the timing of each and the stage that failed, so onboarding
+// problems can be debugged without reading server logs.
+package diagnose
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Stages, in the order they run
+const (
+	StageDNS        = "dns"
+	StageTCP        = "tcp"
+	StageTLS        = "tls"
+	StageAuth       = "auth"
+	StageModels     = "models"
+	StageCompletion = "completion"
+)
+
+// Stages lists every stage in order
+var Stages = []string{StageDNS, StageTCP, StageTLS, StageAuth, StageModels, StageCompletion}
+
+// Target is the provider to test
+type Target struct {
+	Provider   string
+	BaseURL    string // API root, with or without a /v1 suffix
+	AuthMethod string // "bearer" prefixes the key with "Bearer "
+	AuthHeader string // Header carrying the key (default Authorization)
+	APIKey     string // Empty fails the auth stage
+	Model      string // Model for the completion (default the first one listed)
+}
+
+// anthropic reports whether the target speaks the Anthropic Messages API
+func (t *Target) anthropic() bool {
+	return t.Provider == "anthropic" || strings.EqualFold(t.AuthHeader, "x-api-key")
+}
+
+// Options tune a run; the zero value uses the defaults
+type Options struct {
+	Timeout   time.Duration // Per stage (default 10s)
+	Client    *http.Client  // For the HTTP stages (default one with Timeout)
+	TLSConfig *tls.Config   // For the TLS stage (default system roots)
+}
+
+// Step is the outcome of one stage
+type Step struct {
+	Stage      string  `json:"stage"`
+	OK         bool    `json:"ok"`
+	Skipped    bool    `json:"skipped,omitempty"` // An earlier stage failed
+	DurationMs float64 `json:"duration_ms"`
+	Detail     string  `json:"detail,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of a run
+type Report struct {
+	Provider    string    `json:"provider"`
+	BaseURL     string    `json:"base_url"`
+	OK          bool      `json:"ok"`
+	FailedStage string    `json:"failed_stage,omitempty"`
+	Steps       []*Step   `json:"steps"`
+	DurationMs  float64   `json:"duration_ms"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// run holds the state stages pass along
+type run struct {
+	target  Target
+	opts    Options
+	base    *url.URL
+	addrs   []string
+	apiRoot string // BaseURL joined with the prefix the model list answered on
+	models  []string
+}
+
+// Run tests target stage by stage. Once a stage fails the rest are
+// skipped, since they depend on it.
+func Run(ctx context.Context, target Target, opts Options) *Report {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: opts.Timeout}
+	}
+
+	report := &Report{Provider: target.Provider, BaseURL: target.BaseURL, OK: true, StartedAt: time.Now()}
+	r := &run{target: target, opts: opts}
+	stages := map[string]func(context.Context) (string, error){
+		StageDNS:        r.dns,
+		StageTCP:        r.tcp,
+		StageTLS:        r.tls,
+		StageAuth:       r.auth,
+		StageModels:     r.listModels,
+		StageCompletion: r.completion,
+	}
+
+	for _, stage := range Stages {
+		step := &Step{Stage: stage}
+		report.Steps = append(report.Steps, step)
+		if !report.OK {
+			step.Skipped = true
+			continue
+		}
+
+		stageCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+		start := time.Now()
+		detail, err := stages[stage](stageCtx)
+		cancel()
+		step.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+		step.Detail = detail
+		if err != nil {
+			step.Error = err.Error()
+			report.OK = false
+			report.FailedStage = stage
+			continue
+		}
+		step.OK = true
+	}
+	report.DurationMs = float64(time.Since(report.StartedAt).Microseconds()) / 1000
+	return report
+}
+
+func (r *run) dns(ctx context.Context) (string, error) {
+	base, err := url.Parse(r.target.BaseURL)
+	if err != nil || base.Host == "" || (base.Scheme != "http" && base.Scheme != "https") {
+		return "", fmt.Errorf("invalid base URL %q", r.target.BaseURL)
+	}
+	r.base = base
+	r.addrs, err = net.DefaultResolver.LookupHost(ctx, base.Hostname())
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(r.addrs, ", "), nil
+}
+
+// hostPort is the address the base URL connects to
+func (r *run) hostPort() string {
+	port := r.base.Port()
+	if port == "" {
+		port = "443"
+		if r.base.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(r.base.Hostname(), port)
+}
+
+func (r *run) tcp(ctx context.Context) (string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", r.hostPort())
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	return "connected to " + conn.RemoteAddr().String(), nil
+}
+
+func (r *run) tls(ctx context.Context) (string, error) {
+	if r.base.Scheme != "https" {
+		return "not used (plain http)", nil
+	}
+	cfg := &tls.Config{}
+	if r.opts.TLSConfig != nil {
+		cfg = r.opts.TLSConfig.Clone()
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = r.base.Hostname()
+	}
+	d := tls.Dialer{Config: cfg}
+	conn, err := d.DialContext(ctx, "tcp", r.hostPort())
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	detail := tls.VersionName(state.Version)
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		detail += fmt.Sprintf(", certificate for %s expires %s", cert.Subject.CommonName, cert.NotAfter.Format("2006-01-02"))
+	}
+	return detail, nil
+}
+
+// request makes a request carrying the key
+func (r *run) request(ctx context.Context, method, target string, body []byte) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	header := r.target.AuthHeader
+	if header == "" {
+		header = "Authorization"
+	}
+	if strings.EqualFold(r.target.AuthMethod, "bearer") || (r.target.AuthMethod == "" && header == "Authorization") {
+		req.Header.Set(header, "Bearer "+r.target.APIKey)
+	} else {
+		req.Header.Set(header, r.target.APIKey)
+	}
+	if r.target.anthropic() {
+		req.Header.Set("anthropic-version", "2023-06-01")
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.opts.Client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	return resp.StatusCode, data, err
+}
+
+// auth lists models with the key, trying /v1/models and then /models as
+// discovery does; anything but 401 and 403 means the key was accepted
+func (r *run) auth(ctx context.Context) (string, error) {
+	if r.target.APIKey == "" {
+		return "", fmt.Errorf("no API key stored for %s", r.target.Provider)
+	}
+	root := strings.TrimRight(r.target.BaseURL, "/")
+	var lastStatus int
+	for _, prefix := range []string{"/v1", ""} {
+		if prefix == "/v1" && strings.HasSuffix(root, "/v1") {
+			continue
+		}
+		status, body, err := r.request(ctx, http.MethodGet, root+prefix+"/models", nil)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case status == http.StatusUnauthorized || status == http.StatusForbidden:
+			return "", fmt.Errorf("key rejected with %d: %s", status, snippet(body))
+		case status == http.StatusOK:
+			r.apiRoot = root + prefix
+			r.models = modelIDs(body)
+			return fmt.Sprintf("key accepted by %s/models", r.apiRoot), nil
+		}
+		lastStatus = status
+	}
+	// The key was not rejected, but no model list answered; the
+	// completion still tells whether the provider serves requests
+	r.apiRoot = root
+	if !strings.HasSuffix(root, "/v1") {
+		r.apiRoot = root + "/v1"
+	}
+	return fmt.Sprintf("key not rejected (model list returned %d)", lastStatus), nil
+}
+
+func (r *run) listModels(context.Context) (string, error) {
+	if len(r.models) == 0 {
+		if r.target.Model == "" {
+			return "", fmt.Errorf("no models listed; set a model to test the completion")
+		}
+		return "no models listed", nil
+	}
+	if r.target.Model != "" && !contains(r.models, r.target.Model) {
+		return "", fmt.Errorf("%d models listed, but not %s", len(r.models), r.target.Model)
+	}
+	return fmt.Sprintf("%d models listed", len(r.models)), nil
+}
+
+func (r *run) completion(ctx context.Context) (string, error) {
+	model := r.target.Model
+	if model == "" {
+		model = r.models[0]
+	}
+	path, body := "/chat/completions", map[string]interface{}{
+		"model":      model,
+		"max_tokens": 1,
+		"messages":   []map[string]string{{"role": "user", "content": "ping"}},
+	}
+	if r.target.anthropic() {
+		path = "/messages"
+	}
+	data, _ := json.Marshal(body)
+
+	status, resp, err := r.request(ctx, http.MethodPost, r.apiRoot+path, data)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("%s returned %d: %s", model, status, snippet(resp))
+	}
+	return "1-token completion from " + model, nil
+}
+
+// modelIDs reads the IDs from an OpenAI or Anthropic style model list
+func modelIDs(body []byte) []string {
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(body, &list) != nil {
+		return nil
+	}
+	ids := make([]string, 0, len(list.Data))
+	for _, m := range list.Data {
+		if m.ID != "" {
+			ids = append(ids, m.ID)
+		}
+	}
+	return ids
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// snippet shortens an error body for a report
+func snippet(body []byte) string {
+	s := strings.TrimSpace(string(body))
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
@@ -0,0 +1,123 @@
+package diagnose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeProvider serves an OpenAI style model list and completion, accepting
+// only the key "sk-good"
+func fakeProvider(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	authed := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer sk-good" {
+				http.Error(w, `{"error":"invalid api key"}`, http.StatusUnauthorized)
+				return
+			}
+			h(w, r)
+		}
+	}
+	mux.HandleFunc("/v1/models", authed(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"id":"tiny-1"},{"id":"tiny-2"}]}`))
+	}))
+	mux.HandleFunc("/v1/chat/completions", authed(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model     string `json:"model"`
+			MaxTokens int    `json:"max_tokens"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "tiny-1" || req.MaxTokens != 1 {
+			http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"content":"p"}}]}`))
+	}))
+	srv := httptest.NewTLSServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func runAgainst(srv *httptest.Server, target Target) *Report {
+	target.BaseURL = srv.URL
+	return Run(context.Background(), target, Options{
+		Client:    srv.Client(),
+		TLSConfig: srv.Client().Transport.(*http.Transport).TLSClientConfig,
+	})
+}
+
+func TestRun_AllStagesPass(t *testing.T) {
+	srv := fakeProvider(t)
+	report := runAgainst(srv, Target{Provider: "fake", AuthMethod: "bearer", APIKey: "sk-good"})
+
+	if !report.OK || report.FailedStage != "" {
+		t.Fatalf("expected a passing report, failed at %q: %+v", report.FailedStage, report.Steps)
+	}
+	if len(report.Steps) != len(Stages) {
+		t.Fatalf("expected %d steps, got %d", len(Stages), len(report.Steps))
+	}
+	for i, step := range report.Steps {
+		if step.Stage != Stages[i] || !step.OK || step.Skipped {
+			t.Errorf("step %d: expected %s to pass, got %+v", i, Stages[i], step)
+		}
+	}
+	if got := report.Steps[4].Detail; got != "2 models listed" {
+		t.Errorf("expected 2 models listed, got %q", got)
+	}
+}
+
+func TestRun_FailedStage(t *testing.T) {
+	srv := fakeProvider(t)
+	tests := []struct {
+		name   string
+		target Target
+		stage  string
+	}{
+		{"rejected key", Target{Provider: "fake", AuthMethod: "bearer", APIKey: "sk-bad"}, StageAuth},
+		{"missing key", Target{Provider: "fake", AuthMethod: "bearer"}, StageAuth},
+		{"unlisted model", Target{Provider: "fake", AuthMethod: "bearer", APIKey: "sk-good", Model: "huge-9"}, StageModels},
+		{"failed completion", Target{Provider: "fake", AuthMethod: "bearer", APIKey: "sk-good", Model: "tiny-2"}, StageCompletion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := runAgainst(srv, tt.target)
+			if report.OK || report.FailedStage != tt.stage {
+				t.Fatalf("expected a failure at %s, got %q", tt.stage, report.FailedStage)
+			}
+			failed := false
+			for _, step := range report.Steps {
+				switch {
+				case step.Stage == tt.stage:
+					failed = true
+					if step.OK || step.Error == "" {
+						t.Errorf("expected %s to fail with an error, got %+v", step.Stage, step)
+					}
+				case failed && !step.Skipped:
+					t.Errorf("expected %s after the failure to be skipped", step.Stage)
+				case !failed && !step.OK:
+					t.Errorf("expected %s before the failure to pass, got %+v", step.Stage, step)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_Unreachable(t *testing.T) {
+	srv := fakeProvider(t)
+	url := srv.URL
+	srv.Close()
+
+	report := Run(context.Background(), Target{Provider: "fake", BaseURL: url, APIKey: "sk-good"}, Options{})
+	if report.FailedStage != StageTCP {
+		t.Fatalf("expected a failure at tcp, got %q: %+v", report.FailedStage, report.Steps)
+	}
+
+	report = Run(context.Background(), Target{Provider: "fake", BaseURL: "not a url"}, Options{})
+	if report.FailedStage != StageDNS {
+		t.Fatalf("expected an invalid base URL to fail at dns, got %q", report.FailedStage)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"log"
 	"strings"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
 )
 
 // Agent discovers provider information using LLM-powered analysis
@@ -20,6 +22,7 @@ type Agent struct {
 	cacheTTL      time.Duration
 	parallelBatch int // Concurrent scraping limit
 	maxRetries    int // Max validation retries
+	onDiscovered  func(*DiscoveryResult)
 }
 
 // DB interface for database operations
@@ -34,6 +37,9 @@ type Config struct {
 	CacheDays     int
 	MaxRetries    int
 	DB            DB // Database for persistent cache
+
+	// OnDiscovered is called with every fresh (uncached) result
+	OnDiscovered func(*DiscoveryResult)
 }
 
 // DiscoveryRequest represents a request to discover a provider
@@ -143,6 +149,7 @@ func NewAgent(cfg Config) (*Agent, error) {
 		cacheTTL:      cacheTTL,
 		parallelBatch: cfg.ParallelBatch,
 		maxRetries:    cfg.MaxRetries,
+		onDiscovered:  cfg.OnDiscovered,
 	}, nil
 }
 
@@ -171,7 +178,15 @@ func (a *Agent) Discover(ctx context.Context, req DiscoveryRequest) (*DiscoveryR
 	for _, source := range a.sources {
 		go func(s Source) {
 			start := time.Now()
-			result, err := s.Fetch(ctx, req.Identifier)
+			var result SourceResult
+			var err error
+			// A panicking source fails like any other, so collection below
+			// still hears from every source
+			if perr := crash.Do("discovery source "+s.Name(), func() {
+				result, err = s.Fetch(ctx, req.Identifier)
+			}); perr != nil {
+				err = perr
+			}
 			latency := time.Since(start).Milliseconds()
 
 			if err != nil {
@@ -234,6 +249,10 @@ func (a *Agent) Discover(ctx context.Context, req DiscoveryRequest) (*DiscoveryR
 	// Cache result in memory
 	a.cache.Set(req.Identifier, result)
 
+	if a.onDiscovered != nil {
+		a.onDiscovered(result)
+	}
+
 	return result, nil
 }
 
@@ -3,6 +3,8 @@ package discovery
 import (
 	"sync"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
 )
 
 // CacheStats tracks cache performance metrics
@@ -99,7 +101,7 @@ func (c *Cache) cleanup() {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		c.removeExpired()
+		_ = crash.Do("discovery cache cleanup", c.removeExpired)
 	}
 }
 
@@ -0,0 +1,67 @@
+package eval
+
+import (
+	"sort"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// Comparison describes how a run changed from a baseline run
+type Comparison struct {
+	Base         *database.EvalRun `json:"base"`
+	Head         *database.EvalRun `json:"head"`
+	ScoreDelta   float64           `json:"score_delta"`           // Head minus base, as a fraction
+	LatencyDelta float64           `json:"latency_delta"`         // Change in average latency, ms
+	CostDelta    float64           `json:"cost_delta"`            // Change in total cost, dollars
+	JudgeDelta   *float64          `json:"judge_delta,omitempty"` // Change in mean judge score when both runs were judged
+	Regressions  []string          `json:"regressions"`           // Cases that passed in base and fail in head
+	Fixed        []string          `json:"fixed"`                 // Cases that failed in base and pass in head
+	Added        []string          `json:"added,omitempty"`       // Cases only in head
+	Removed      []string          `json:"removed,omitempty"`
+}
+
+// Regressed reports whether any case that passed in the baseline fails now
+func (c *Comparison) Regressed() bool {
+	return len(c.Regressions) > 0
+}
+
+// Compare matches the cases of two runs by name. Both runs must have
+// their results loaded.
+func Compare(base, head *database.EvalRun) *Comparison {
+	c := &Comparison{
+		Base:         base,
+		Head:         head,
+		ScoreDelta:   head.Score - base.Score,
+		LatencyDelta: head.AvgLatencyMs - base.AvgLatencyMs,
+		CostDelta:    head.Cost - base.Cost,
+		Regressions:  []string{},
+		Fixed:        []string{},
+	}
+
+	if base.Judged > 0 && head.Judged > 0 {
+		delta := head.JudgeScore - base.JudgeScore
+		c.JudgeDelta = &delta
+	}
+
+	before := make(map[string]bool, len(base.Results))
+	for _, res := range base.Results {
+		before[res.Case] = res.Passed
+	}
+	for _, res := range head.Results {
+		passed, ok := before[res.Case]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, res.Case)
+		case passed && !res.Passed:
+			c.Regressions = append(c.Regressions, res.Case)
+		case !passed && res.Passed:
+			c.Fixed = append(c.Fixed, res.Case)
+		}
+		delete(before, res.Case)
+	}
+	for name := range before {
+		c.Removed = append(c.Removed, name)
+	}
+	sort.Strings(c.Removed)
+	return c
+}
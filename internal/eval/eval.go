@@ -0,0 +1,259 @@
+// Package eval runs suites of prompts with answer checks against models
+// through the chat completions endpoint, scoring each run so results can be
+// stored and compared over time to catch regressions after provider updates.
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// maxStoredOutput bounds the answer text kept per case
+const maxStoredOutput = 4096
+
+// Pricer returns a model's price in dollars per million input and output
+// tokens; zero prices leave the cost at zero
+type Pricer interface {
+	Price(model string) (inPer1M, outPer1M float64)
+}
+
+// Config controls how a suite is run
+type Config struct {
+	URL         string      // Chat completions endpoint
+	Header      http.Header // Extra request headers
+	Provider    string      // Forces the upstream provider when set
+	Concurrency int         // Cases in flight at once (default 1)
+	Timeout     time.Duration
+	Client      *http.Client
+	Pricer      Pricer // Optional
+}
+
+// Run sends every case of the suite to model and scores the answers,
+// having the suite's judge grade the cases with a rubric. A failed request
+// fails its case rather than the run; only a cancelled context stops it
+// early, returning the cases finished so far.
+func Run(ctx context.Context, cfg Config, suite *Suite, model string) (*database.EvalRun, error) {
+	if model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{}
+	}
+	judgeModel := ""
+	if suite.judged() {
+		if suite.Judge == nil || suite.Judge.Model == "" {
+			return nil, fmt.Errorf("suite %s has rubrics but no judge model", suite.Name)
+		}
+		judgeModel = suite.Judge.Model
+	}
+
+	run := &database.EvalRun{
+		Suite:      suite.Name,
+		Model:      model,
+		Provider:   cfg.Provider,
+		JudgeModel: judgeModel,
+		StartedAt:  time.Now().UTC(),
+		Results:    make([]*database.EvalResult, len(suite.Cases)),
+	}
+	failed := make([]bool, len(suite.Cases)) // Requests that got no answer
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(cfg.Concurrency, len(suite.Cases)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				run.Results[i], failed[i] = runCase(ctx, cfg, suite, suite.Cases[i], model)
+			}
+		}()
+	}
+dispatch:
+	for i := range suite.Cases {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	finished := run.Results[:0]
+	for i, res := range run.Results {
+		if res == nil {
+			continue
+		}
+		finished = append(finished, res)
+		if failed[i] {
+			run.Errors++
+		}
+	}
+	run.Results = finished
+	score(run)
+	run.DurationMs = time.Since(run.StartedAt).Milliseconds()
+	return run, ctx.Err()
+}
+
+// score fills in the run totals from its results
+func score(run *database.EvalRun) {
+	var latency int64
+	var judged float64
+	run.Cases = len(run.Results)
+	for _, res := range run.Results {
+		if res.Passed {
+			run.Passed++
+		}
+		if res.JudgeScore != nil {
+			run.Judged++
+			judged += *res.JudgeScore
+		}
+		latency += res.LatencyMs
+		run.TokensIn += res.TokensIn
+		run.TokensOut += res.TokensOut
+		run.Cost += res.Cost
+	}
+	if run.Cases > 0 {
+		run.Score = float64(run.Passed) / float64(run.Cases)
+		run.AvgLatencyMs = float64(latency) / float64(run.Cases)
+	}
+	if run.Judged > 0 {
+		run.JudgeScore = judged / float64(run.Judged)
+	}
+}
+
+// chatResponse is the part of a chat completion the runner reads
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// completion is the answer to one chat request
+type completion struct {
+	Answer    string
+	TokensIn  int
+	TokensOut int
+	Cost      float64
+}
+
+// chat sends one chat completion request and returns the answer
+func chat(ctx context.Context, cfg Config, model, system, prompt string, maxTokens int) (*completion, error) {
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	var messages []map[string]string
+	if system != "" {
+		messages = append(messages, map[string]string{"role": "system", "content": system})
+	}
+	messages = append(messages, map[string]string{"role": "user", "content": prompt})
+	body, err := json.Marshal(map[string]interface{}{
+		"model":       model,
+		"messages":    messages,
+		"max_tokens":  maxTokens,
+		"temperature": 0,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range cfg.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.Provider != "" {
+		req.Header.Set(proxy.HeaderProvider, cfg.Provider)
+	}
+
+	resp, err := cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data[:min(len(data), 512)])))
+	}
+
+	var out chatResponse
+	if err := json.Unmarshal(data, &out); err != nil || len(out.Choices) == 0 {
+		return nil, fmt.Errorf("invalid response")
+	}
+	c := &completion{
+		Answer:    out.Choices[0].Message.Content,
+		TokensIn:  out.Usage.PromptTokens,
+		TokensOut: out.Usage.CompletionTokens,
+	}
+	if cfg.Pricer != nil {
+		priced := resp.Header.Get(proxy.HeaderModel)
+		if priced == "" {
+			priced = model
+		}
+		in, outPrice := cfg.Pricer.Price(priced)
+		c.Cost = (float64(c.TokensIn)*in + float64(c.TokensOut)*outPrice) / 1e6
+	}
+	return c, nil
+}
+
+// runCase sends one case and checks the answer, reporting whether the
+// request itself, or the judge's grading of it, failed
+func runCase(ctx context.Context, cfg Config, suite *Suite, c *Case, model string) (*database.EvalResult, bool) {
+	res := &database.EvalResult{Case: c.Name}
+	start := time.Now()
+	out, err := chat(ctx, cfg, model, suite.system(c), c.Prompt, suite.maxTokens(c))
+	res.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		res.Error = err.Error()
+		return res, true
+	}
+	res.Output = out.Answer
+	if len(res.Output) > maxStoredOutput {
+		res.Output = res.Output[:maxStoredOutput]
+	}
+	res.TokensIn, res.TokensOut, res.Cost = out.TokensIn, out.TokensOut, out.Cost
+
+	passed, reason := c.Expect.Evaluate(out.Answer)
+	if suite.rubric(c) != "" {
+		score, explanation, err := judge(ctx, cfg, suite, c, out.Answer)
+		if err != nil {
+			res.Error = "judge: " + err.Error()
+			return res, true
+		}
+		res.JudgeScore, res.JudgeReason = &score, explanation
+		if pass := suite.Judge.PassScore; passed && score*suite.Judge.scale() < pass {
+			passed, reason = false, fmt.Sprintf("judge scored %.1f, below %g", score*suite.Judge.scale(), pass)
+		}
+	}
+	res.Passed = passed
+	res.Error = reason
+	return res, false
+}
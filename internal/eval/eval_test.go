@@ -0,0 +1,260 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+const testSuite = `
+name: smoke
+system: Answer tersely.
+cases:
+  - name: capital
+    prompt: What is the capital of France?
+    expect:
+      equals: Paris
+  - name: count
+    prompt: Count to three.
+    expect:
+      regex: '1\D+2\D+3'
+      not_contains: [four]
+  - name: json
+    prompt: Reply with JSON.
+    max_tokens: 64
+    expect:
+      json_fields: {ok: true, n: 2}
+  - name: broken
+    prompt: fail
+`
+
+type fixedPricer map[string][2]float64
+
+func (p fixedPricer) Price(model string) (float64, float64) {
+	return p[model][0], p[model][1]
+}
+
+func writeSuite(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "smoke.yaml")
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatalf("failed to write suite: %v", err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	answers := map[string]string{
+		"What is the capital of France?": " paris.",
+		"Count to three.":                "1, 2, 3",
+		"Reply with JSON.":               "```json\n{\"ok\": true, \"n\": 2.0}\n```",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model     string              `json:"model"`
+			Messages  []map[string]string `json:"messages"`
+			MaxTokens int                 `json:"max_tokens"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "test-model" || req.Messages[0]["content"] != "Answer tersely." || r.Header.Get(proxy.HeaderProvider) != "groq" {
+			t.Errorf("unexpected request: %+v %v", req, r.Header)
+		}
+		prompt := req.Messages[len(req.Messages)-1]["content"]
+		answer, ok := answers[prompt]
+		if !ok {
+			http.Error(w, "upstream down", http.StatusBadGateway)
+			return
+		}
+		if prompt == "Reply with JSON." && req.MaxTokens != 64 {
+			t.Errorf("max_tokens = %d, want the case's 64", req.MaxTokens)
+		}
+		w.Header().Set(proxy.HeaderModel, "upstream-model")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": answer}}},
+			"usage":   map[string]int{"prompt_tokens": 1000, "completion_tokens": 500},
+		})
+	}))
+	defer server.Close()
+
+	suite, err := LoadSuite(writeSuite(t, testSuite))
+	if err != nil {
+		t.Fatalf("LoadSuite failed: %v", err)
+	}
+	run, err := Run(context.Background(), Config{
+		URL:         server.URL,
+		Provider:    "groq",
+		Concurrency: 2,
+		Pricer:      fixedPricer{"upstream-model": {1, 2}},
+	}, suite, "test-model")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if run.Suite != "smoke" || run.Cases != 4 || run.Passed != 3 || run.Errors != 1 || run.Score != 0.75 {
+		t.Errorf("unexpected run totals: %+v", run)
+	}
+	if len(run.Results) != 4 || run.Results[0].Case != "capital" || run.Results[3].Case != "broken" {
+		t.Fatalf("results not in case order: %+v", run.Results)
+	}
+	if broken := run.Results[3]; broken.Passed || !strings.Contains(broken.Error, "502") {
+		t.Errorf("expected the failed request to fail its case, got %+v", broken)
+	}
+	if run.TokensIn != 3000 || run.Cost != 0.006 {
+		t.Errorf("expected tokens and cost of the three answers, got %d tokens $%v", run.TokensIn, run.Cost)
+	}
+}
+
+func TestCheck_Evaluate(t *testing.T) {
+	suite := &Suite{Name: "s", Cases: []*Case{{Name: "c", Prompt: "p", Expect: Check{Regex: `^\d+$`}}}}
+	if err := suite.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	check := suite.Cases[0].Expect
+
+	tests := []struct {
+		check  Check
+		answer string
+		pass   bool
+	}{
+		{check, "42", true},
+		{check, "forty-two", false},
+		{Check{Contains: []string{"PARIS"}}, "It is Paris.", true},
+		{Check{NotContains: []string{"sorry"}}, "Sorry, I can't", false},
+		{Check{JSON: true}, "not json", false},
+		{Check{JSONFields: map[string]interface{}{"answer": "Paris"}}, `{"answer": "Lyon"}`, false},
+		{Check{JSONFields: map[string]interface{}{"answer": "Paris"}}, `["Paris"]`, false},
+		{Check{}, "anything", true},
+	}
+	for _, tt := range tests {
+		if pass, reason := tt.check.Evaluate(tt.answer); pass != tt.pass {
+			t.Errorf("Evaluate(%q) with %+v = %v (%s), want %v", tt.answer, tt.check, pass, reason, tt.pass)
+		}
+	}
+}
+
+func TestLoadSuite_Invalid(t *testing.T) {
+	for _, body := range []string{
+		"cases: []",
+		"cases: [{name: a}]",
+		"cases: [{name: a, prompt: x}, {name: a, prompt: y}]",
+		"cases: [{name: a, prompt: x, expect: {regex: '('}}]",
+	} {
+		if _, err := LoadSuite(writeSuite(t, body)); err == nil {
+			t.Errorf("expected error for %s", body)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := &database.EvalRun{Score: 1, AvgLatencyMs: 100, Judged: 3, JudgeScore: 0.75, Results: []*database.EvalResult{
+		{Case: "a", Passed: true}, {Case: "b", Passed: false}, {Case: "c", Passed: true},
+	}}
+	head := &database.EvalRun{Score: 0.5, AvgLatencyMs: 150, Judged: 3, JudgeScore: 0.5, Results: []*database.EvalResult{
+		{Case: "a", Passed: false}, {Case: "b", Passed: true}, {Case: "d", Passed: true},
+	}}
+
+	c := Compare(base, head)
+	if !c.Regressed() || len(c.Regressions) != 1 || c.Regressions[0] != "a" {
+		t.Errorf("expected a to regress, got %+v", c.Regressions)
+	}
+	if len(c.Fixed) != 1 || c.Fixed[0] != "b" || len(c.Added) != 1 || len(c.Removed) != 1 || c.Removed[0] != "c" {
+		t.Errorf("unexpected comparison: %+v", c)
+	}
+	if c.ScoreDelta != -0.5 || c.LatencyDelta != 50 || c.JudgeDelta == nil || *c.JudgeDelta != -0.25 {
+		t.Errorf("unexpected deltas: %v %v %v", c.ScoreDelta, c.LatencyDelta, c.JudgeDelta)
+	}
+}
+
+func TestRun_Judge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model    string              `json:"model"`
+			Messages []map[string]string `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt := req.Messages[len(req.Messages)-1]["content"]
+		answer := "A haiku about rain."
+		if req.Model == "judge" {
+			if r.Header.Get(proxy.HeaderProvider) != "" {
+				t.Error("the judge should take the proxy's routing")
+			}
+			if !strings.Contains(prompt, "Rubric:\nMust be a haiku.") || !strings.Contains(prompt, answer) {
+				t.Errorf("unexpected judge prompt: %s", prompt)
+			}
+			answer = "Mostly fine.\n**Score:** 3/5"
+			if strings.Contains(prompt, "Question:\nbad") {
+				answer = "SCORE: 2"
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": answer}}},
+		})
+	}))
+	defer server.Close()
+
+	suite, err := LoadSuite(writeSuite(t, `
+judge:
+  rubric: Must be a haiku.
+  pass_score: 5
+cases:
+  - name: good
+    prompt: good
+  - name: bad
+    prompt: bad
+  - name: custom
+    prompt: custom
+    rubric: Must be a haiku.
+`))
+	if err != nil {
+		t.Fatalf("LoadSuite failed: %v", err)
+	}
+	cfg := Config{URL: server.URL, Provider: "groq"}
+	if _, err := Run(context.Background(), cfg, suite, "test-model"); err == nil {
+		t.Error("expected an error without a judge model")
+	}
+
+	suite.Judge.Model = "judge"
+	run, err := Run(context.Background(), cfg, suite, "test-model")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	good, bad := run.Results[0], run.Results[1]
+	if !good.Passed || good.JudgeScore == nil || *good.JudgeScore != 0.6 || !strings.Contains(good.JudgeReason, "Mostly fine") {
+		t.Errorf("expected good to pass with 6/10, got %+v", good)
+	}
+	if bad.Passed || !strings.Contains(bad.Error, "below 5") {
+		t.Errorf("expected bad to fail below the pass score, got %+v", bad)
+	}
+	if run.JudgeModel != "judge" || run.Judged != 3 || run.Errors != 0 {
+		t.Errorf("unexpected judge totals: %+v", run)
+	}
+}
+
+func TestExtractScore(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  float64
+		ok    bool
+	}{
+		{"Good answer.\nSCORE: 8", 8, true},
+		{"score: 2 at first, but on reflection Score = 9.5", 9.5, true},
+		{"**Score:** 4/5", 8, true},
+		{"7", 7, true},
+		{"SCORE: 11", 0, false},
+		{"no idea", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := extractScore(tt.reply, 10)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("extractScore(%q) = %v, %v; want %v", tt.reply, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// DefaultJudgeTemplate is the judge prompt used when a suite sets none. It
+// sees the case's Prompt, Reference, Rubric and Scale and the model's Answer.
+const DefaultJudgeTemplate = `You are grading an AI assistant's answer to a question.
+
+Question:
+{{.Prompt}}
+{{if .Reference}}
+Reference answer:
+{{.Reference}}
+{{end}}
+Answer to grade:
+{{.Answer}}
+
+Rubric:
+{{.Rubric}}
+
+Rate the answer against the rubric from 0 to {{.Scale}}. Explain your reasoning in a sentence or two, then finish with a line of the form "SCORE: <number>".`
+
+// maxJudgeReason bounds the judge explanation kept per case
+const maxJudgeReason = 1024
+
+var defaultJudgeTemplate = template.Must(template.New("judge").Parse(DefaultJudgeTemplate))
+
+// scorePattern finds "SCORE: 7", "**Score:** 7/10" and similar in a judge's reply
+var scorePattern = regexp.MustCompile(`(?i)score\**\s*[:=]\s*\**\s*(\d+(?:\.\d+)?)(?:\s*/\s*(\d+(?:\.\d+)?))?`)
+
+// Judge grades answers with a model against a rubric. Cases with a rubric
+// of their own, or every case when the suite sets one, are judged.
+type Judge struct {
+	Model     string  `yaml:"model"`      // Judge model, routed through the proxy like the model under test
+	Rubric    string  `yaml:"rubric"`     // Default rubric for every case
+	Template  string  `yaml:"template"`   // Prompt template (default DefaultJudgeTemplate)
+	Scale     float64 `yaml:"scale"`      // Highest score (default 10)
+	PassScore float64 `yaml:"pass_score"` // Judged cases scoring below this fail (default 0)
+	MaxTokens int     `yaml:"max_tokens"` // Judge reply limit (default 512)
+
+	tmpl *template.Template
+}
+
+// judgePrompt is the data the judge template is executed with
+type judgePrompt struct {
+	Prompt    string
+	Reference string
+	Rubric    string
+	Answer    string
+	Scale     float64
+}
+
+// validate checks the scores and parses the template
+func (j *Judge) validate() error {
+	if j.Scale < 0 || j.PassScore < 0 || j.PassScore > j.scale() {
+		return fmt.Errorf("judge: pass_score must be between 0 and the scale (%g)", j.scale())
+	}
+	if j.Template != "" {
+		tmpl, err := template.New("judge").Parse(j.Template)
+		if err != nil {
+			return fmt.Errorf("judge: invalid template: %w", err)
+		}
+		j.tmpl = tmpl
+	}
+	return nil
+}
+
+func (j *Judge) scale() float64 {
+	if j.Scale > 0 {
+		return j.Scale
+	}
+	return 10
+}
+
+func (j *Judge) maxTokens() int {
+	if j.MaxTokens > 0 {
+		return j.MaxTokens
+	}
+	return 512
+}
+
+// rubric returns the rubric a case is judged against, empty when it is not
+// judged
+func (s *Suite) rubric(c *Case) string {
+	if c.Rubric != "" {
+		return c.Rubric
+	}
+	if s.Judge != nil {
+		return s.Judge.Rubric
+	}
+	return ""
+}
+
+// judged reports whether any case of the suite is judged
+func (s *Suite) judged() bool {
+	for _, c := range s.Cases {
+		if s.rubric(c) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// judge asks the judge model to grade an answer, returning the score
+// scaled to 0-1 and the judge's explanation
+func judge(ctx context.Context, cfg Config, suite *Suite, c *Case, answer string) (float64, string, error) {
+	j := suite.Judge
+	tmpl := j.tmpl
+	if tmpl == nil {
+		tmpl = defaultJudgeTemplate
+	}
+	var prompt strings.Builder
+	if err := tmpl.Execute(&prompt, judgePrompt{
+		Prompt:    c.Prompt,
+		Reference: c.Reference,
+		Rubric:    suite.rubric(c),
+		Answer:    answer,
+		Scale:     j.scale(),
+	}); err != nil {
+		return 0, "", fmt.Errorf("failed to render prompt: %w", err)
+	}
+
+	// The judge takes the proxy's routing rather than the forced provider
+	judgeCfg := cfg
+	judgeCfg.Provider = ""
+	out, err := chat(ctx, judgeCfg, j.Model, "", prompt.String(), j.maxTokens())
+	if err != nil {
+		return 0, "", err
+	}
+	score, err := extractScore(out.Answer, j.scale())
+	if err != nil {
+		return 0, "", err
+	}
+	reason := strings.TrimSpace(out.Answer)
+	if len(reason) > maxJudgeReason {
+		reason = reason[:maxJudgeReason]
+	}
+	return score / j.scale(), reason, nil
+}
+
+// extractScore reads the last score in a judge's reply. A score given out
+// of another maximum ("4/5") is rescaled to scale.
+func extractScore(reply string, scale float64) (float64, error) {
+	var score float64
+	if matches := scorePattern.FindAllStringSubmatch(reply, -1); len(matches) > 0 {
+		m := matches[len(matches)-1]
+		score, _ = strconv.ParseFloat(m[1], 64)
+		if m[2] != "" {
+			if outOf, _ := strconv.ParseFloat(m[2], 64); outOf > 0 {
+				score = score * scale / outOf
+			}
+		}
+	} else {
+		n, err := strconv.ParseFloat(strings.TrimSpace(reply), 64)
+		if err != nil {
+			return 0, fmt.Errorf("no score in judge reply")
+		}
+		score = n
+	}
+	if score < 0 || score > scale {
+		return 0, fmt.Errorf("judge score %g outside 0-%g", score, scale)
+	}
+	return score, nil
+}
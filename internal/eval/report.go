@@ -0,0 +1,100 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// WriteRuns writes a summary table of runs
+func WriteRuns(w io.Writer, runs []*database.EvalRun) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSUITE\tMODEL\tPROVIDER\tSTARTED\tPASSED\tSCORE\tJUDGE\tERRORS\tAVG LATENCY\tCOST")
+	for _, r := range runs {
+		provider := r.Provider
+		if provider == "" {
+			provider = "-"
+		}
+		judge := "-"
+		if r.Judged > 0 {
+			judge = fmt.Sprintf("%.1f%%", r.JudgeScore*100)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%d/%d\t%.1f%%\t%s\t%d\t%.0fms\t$%.4f\n",
+			r.ID, r.Suite, r.Model, provider, r.StartedAt.Local().Format("2006-01-02 15:04"),
+			r.Passed, r.Cases, r.Score*100, judge, r.Errors, r.AvgLatencyMs, r.Cost)
+	}
+	return tw.Flush()
+}
+
+// WriteRun writes a run's summary followed by each case's outcome
+func WriteRun(w io.Writer, run *database.EvalRun) error {
+	if err := WriteRuns(w, []*database.EvalRun{run}); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CASE\tRESULT\tJUDGE\tLATENCY\tTOKENS\tDETAIL")
+	for _, res := range run.Results {
+		result := "pass"
+		if !res.Passed {
+			result = "FAIL"
+		}
+		detail := res.Error
+		if !res.Passed && res.Output != "" {
+			detail += ": got " + excerpt(res.Output)
+		}
+		judge := "-"
+		if res.JudgeScore != nil {
+			judge = fmt.Sprintf("%.0f%%", *res.JudgeScore*100)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%dms\t%d+%d\t%s\n", res.Case, result, judge, res.LatencyMs, res.TokensIn, res.TokensOut, detail)
+	}
+	return tw.Flush()
+}
+
+// WriteComparison writes how head changed from base
+func WriteComparison(w io.Writer, c *Comparison) error {
+	if err := WriteRuns(w, []*database.EvalRun{c.Base, c.Head}); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nScore %+.1f points, latency %+.0fms, cost %+.4f$\n", c.ScoreDelta*100, c.LatencyDelta, c.CostDelta)
+	if c.JudgeDelta != nil {
+		fmt.Fprintf(w, "Judge score %+.1f points\n", *c.JudgeDelta*100)
+	}
+	for _, section := range []struct {
+		title string
+		cases []string
+	}{{"Regressions", c.Regressions}, {"Fixed", c.Fixed}, {"Added", c.Added}, {"Removed", c.Removed}} {
+		if len(section.cases) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s (%d):\n", section.title, len(section.cases))
+		for _, name := range section.cases {
+			fmt.Fprintf(w, "  %s\n", name)
+		}
+	}
+	if !c.Regressed() {
+		fmt.Fprintln(w, "No regressions")
+	}
+	return nil
+}
+
+// WriteJSON writes v as indented JSON
+func WriteJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// excerpt shortens an answer to one line for the table
+func excerpt(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > 60 {
+		s = s[:57] + "..."
+	}
+	return fmt.Sprintf("%q", s)
+}
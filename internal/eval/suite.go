@@ -0,0 +1,201 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is a named set of prompts with checks on the answers
+type Suite struct {
+	Name        string  `yaml:"name"`
+	Description string  `yaml:"description"`
+	System      string  `yaml:"system"`     // Default system prompt for every case
+	MaxTokens   int     `yaml:"max_tokens"` // Default per case (default 256)
+	Judge       *Judge  `yaml:"judge"`      // Optional model grading of the answers
+	Cases       []*Case `yaml:"cases"`
+}
+
+// Case is one prompt and the checks its answer must pass
+type Case struct {
+	Name      string `yaml:"name"`
+	Prompt    string `yaml:"prompt"`
+	System    string `yaml:"system"`
+	MaxTokens int    `yaml:"max_tokens"`
+	Expect    Check  `yaml:"expect"`
+	Rubric    string `yaml:"rubric"`    // Has the judge grade this case, overriding the suite's rubric
+	Reference string `yaml:"reference"` // Reference answer shown to the judge
+}
+
+// Check validates an answer. Every field that is set must hold; a case
+// with no checks passes whenever the request succeeds.
+type Check struct {
+	Equals      string                 `yaml:"equals"`       // Whole answer, ignoring case, surrounding space and a trailing period
+	Contains    []string               `yaml:"contains"`     // Substrings, ignoring case
+	NotContains []string               `yaml:"not_contains"` // Substrings that must not appear, ignoring case
+	Regex       string                 `yaml:"regex"`        // Must match somewhere in the answer
+	JSON        bool                   `yaml:"json"`         // Answer must be JSON, optionally inside a code fence
+	JSONFields  map[string]interface{} `yaml:"json_fields"`  // Top-level fields the JSON answer must have, with these values
+
+	regex *regexp.Regexp
+}
+
+// LoadSuite reads and validates a suite from a YAML file. The suite name
+// defaults to the file name.
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suite: %w", err)
+	}
+	var s Suite
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse suite: %w", err)
+	}
+	if s.Name == "" {
+		base := path[strings.LastIndexAny(path, `/\`)+1:]
+		s.Name = strings.TrimSuffix(strings.TrimSuffix(base, ".yaml"), ".yml")
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Validate checks every case has a unique name and a prompt, and compiles
+// the regex checks
+func (s *Suite) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("suite name is required")
+	}
+	if len(s.Cases) == 0 {
+		return fmt.Errorf("suite %s has no cases", s.Name)
+	}
+	seen := make(map[string]bool, len(s.Cases))
+	for i, c := range s.Cases {
+		if c.Name == "" {
+			return fmt.Errorf("case %d: name is required", i+1)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("case %s: duplicate name", c.Name)
+		}
+		seen[c.Name] = true
+		if c.Prompt == "" {
+			return fmt.Errorf("case %s: prompt is required", c.Name)
+		}
+		if c.Rubric != "" && s.Judge == nil {
+			s.Judge = &Judge{}
+		}
+		if c.Expect.Regex != "" {
+			re, err := regexp.Compile(c.Expect.Regex)
+			if err != nil {
+				return fmt.Errorf("case %s: invalid regex: %w", c.Name, err)
+			}
+			c.Expect.regex = re
+		}
+	}
+	if s.Judge != nil {
+		return s.Judge.validate()
+	}
+	return nil
+}
+
+// system returns the case's system prompt, falling back to the suite's
+func (s *Suite) system(c *Case) string {
+	if c.System != "" {
+		return c.System
+	}
+	return s.System
+}
+
+// maxTokens returns the case's token limit, falling back to the suite's
+func (s *Suite) maxTokens(c *Case) int {
+	switch {
+	case c.MaxTokens > 0:
+		return c.MaxTokens
+	case s.MaxTokens > 0:
+		return s.MaxTokens
+	}
+	return 256
+}
+
+// Evaluate applies the check to an answer, describing the first failure
+func (c *Check) Evaluate(answer string) (bool, string) {
+	lower := strings.ToLower(answer)
+	if c.Equals != "" && normalize(answer) != normalize(c.Equals) {
+		return false, fmt.Sprintf("expected %q", c.Equals)
+	}
+	for _, want := range c.Contains {
+		if !strings.Contains(lower, strings.ToLower(want)) {
+			return false, fmt.Sprintf("missing %q", want)
+		}
+	}
+	for _, banned := range c.NotContains {
+		if strings.Contains(lower, strings.ToLower(banned)) {
+			return false, fmt.Sprintf("contains %q", banned)
+		}
+	}
+	if c.Regex != "" {
+		re := c.regex
+		if re == nil {
+			re = regexp.MustCompile(c.Regex)
+		}
+		if !re.MatchString(answer) {
+			return false, fmt.Sprintf("does not match /%s/", c.Regex)
+		}
+	}
+	if c.JSON || len(c.JSONFields) > 0 {
+		var v interface{}
+		if err := json.Unmarshal([]byte(stripFence(answer)), &v); err != nil {
+			return false, "not valid JSON"
+		}
+		if len(c.JSONFields) > 0 {
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return false, "JSON answer is not an object"
+			}
+			for field, want := range c.JSONFields {
+				if got, ok := obj[field]; !ok || !jsonEqual(got, want) {
+					return false, fmt.Sprintf("JSON field %q is %v, expected %v", field, got, want)
+				}
+			}
+		}
+	}
+	return true, ""
+}
+
+// normalize prepares an answer for an Equals comparison
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(s), "."))
+}
+
+// stripFence removes a Markdown code fence around a JSON answer
+func stripFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if nl := strings.IndexByte(s, '\n'); nl >= 0 {
+		s = s[nl+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
+
+// jsonEqual compares a decoded JSON value with one from YAML by their JSON
+// encodings, so 1 and 1.0 are equal
+func jsonEqual(got, want interface{}) bool {
+	a, errA := json.Marshal(got)
+	b, errB := json.Marshal(want)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(got, want)
+	}
+	var x, y interface{}
+	_ = json.Unmarshal(a, &x)
+	_ = json.Unmarshal(b, &y)
+	return reflect.DeepEqual(x, y)
+}
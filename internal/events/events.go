@@ -0,0 +1,268 @@
+// Package events fans live system events out to Server-Sent Events
+// streams, so dashboards and other consumers see request completions,
+// provider health changes, budget warnings, usage anomalies, model
+// retirements and task status changes as they happen instead of polling.
+//
+// Events are numbered. A client that reconnects with Last-Event-ID gets
+// the events it missed, as long as they are still in the hub's history.
+// A client that falls too far behind is disconnected rather than slowing
+// publishers; reconnecting catches it up the same way.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types published by the service
+const (
+	TypeRequestCompleted = "request.completed"
+	TypeProviderHealth   = "provider.health_changed"
+	TypeBudgetWarning    = "budget.warning"
+	TypeTaskStatus       = "task.status_changed"
+	TypeUsageAnomaly     = "usage.anomaly"
+	TypeModelRetiring    = "model.retiring"
+	TypeCatalogChanged   = "catalog.changed"
+	TypeResourceWarning  = "resource.warning"
+	TypeBudgetForecast   = "budget.forecast"
+)
+
+// Defaults for a zero Config
+const (
+	DefaultHistory      = 256
+	DefaultClientBuffer = 64
+	DefaultKeepAlive    = 15 * time.Second
+)
+
+// Event is one live event
+type Event struct {
+	ID        uint64                 `json:"id"`
+	Type      string                 `json:"type"`
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// Config configures a Hub
+type Config struct {
+	History      int           // Recent events kept for reconnecting clients
+	ClientBuffer int           // Events queued per client before it is dropped
+	KeepAlive    time.Duration // Quiet period before a keep-alive comment
+}
+
+// Hub publishes events to subscribed streams
+type Hub struct {
+	cfg Config
+
+	mu      sync.Mutex
+	nextID  uint64
+	history []Event // Ring of the last cfg.History events, oldest first
+	clients map[*client]struct{}
+	closed  bool
+}
+
+type client struct {
+	filter []string
+	ch     chan Event
+}
+
+// NewHub creates a hub
+func NewHub(cfg Config) *Hub {
+	if cfg.History <= 0 {
+		cfg.History = DefaultHistory
+	}
+	if cfg.ClientBuffer <= 0 {
+		cfg.ClientBuffer = DefaultClientBuffer
+	}
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = DefaultKeepAlive
+	}
+	return &Hub{cfg: cfg, nextID: 1, clients: make(map[*client]struct{})}
+}
+
+// Publish sends an event to every subscribed stream. It never blocks.
+func (h *Hub) Publish(eventType string, data map[string]interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+
+	e := Event{ID: h.nextID, Type: eventType, Timestamp: time.Now().UTC(), Data: data}
+	h.nextID++
+	if len(h.history) == h.cfg.History {
+		copy(h.history, h.history[1:])
+		h.history = h.history[:len(h.history)-1]
+	}
+	h.history = append(h.history, e)
+
+	for c := range h.clients {
+		if !matches(c.filter, e.Type) {
+			continue
+		}
+		select {
+		case c.ch <- e:
+		default:
+			// Too far behind; it reconnects and catches up from history
+			delete(h.clients, c)
+			close(c.ch)
+		}
+	}
+}
+
+// Subscribe registers a stream for the event types in filter (all when
+// empty; "provider.*" matches by prefix) and returns the events after
+// lastID still in history. The channel is closed when the stream falls
+// behind or the hub closes.
+func (h *Hub) Subscribe(filter []string, lastID uint64) ([]Event, <-chan Event, func()) {
+	c := &client{filter: filter, ch: make(chan Event, h.cfg.ClientBuffer)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var missed []Event
+	if lastID > 0 {
+		for _, e := range h.history {
+			if e.ID > lastID && matches(filter, e.Type) {
+				missed = append(missed, e)
+			}
+		}
+	}
+	if h.closed {
+		close(c.ch)
+		return missed, c.ch, func() {}
+	}
+	h.clients[c] = struct{}{}
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.clients[c]; ok {
+			delete(h.clients, c)
+			close(c.ch)
+		}
+	}
+	return missed, c.ch, unsubscribe
+}
+
+// Clients returns the number of connected streams
+func (h *Hub) Clients() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// Close ends every stream; later publishes are dropped
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for c := range h.clients {
+		close(c.ch)
+	}
+	h.clients = make(map[*client]struct{})
+}
+
+// ServeHTTP streams events as SSE. The optional types query parameter is a
+// comma-separated filter; Last-Event-ID (header, or last_event_id query
+// parameter for clients that cannot set headers) resumes a stream.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var filter []string
+	for _, t := range strings.Split(r.URL.Query().Get("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			filter = append(filter, t)
+		}
+	}
+	lastParam := r.Header.Get("Last-Event-ID")
+	if lastParam == "" {
+		lastParam = r.URL.Query().Get("last_event_id")
+	}
+	var lastID uint64
+	if lastParam != "" {
+		id, err := strconv.ParseUint(lastParam, 10, 64)
+		if err != nil {
+			http.Error(w, "Last-Event-ID must be an event ID", http.StatusBadRequest)
+			return
+		}
+		lastID = id
+	}
+
+	missed, ch, unsubscribe := h.Subscribe(filter, lastID)
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx buffering the stream
+	w.WriteHeader(http.StatusOK)
+	// Tells EventSource how long to wait before reconnecting
+	fmt.Fprint(w, "retry: 3000\n\n")
+	for _, e := range missed {
+		if writeEvent(w, e) != nil {
+			return
+		}
+	}
+	if rc.Flush() != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(h.cfg.KeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+			if writeEvent(w, e) != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		if rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+// matches reports whether an event type passes a filter
+func matches(filter []string, eventType string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if prefix, ok := strings.CutSuffix(f, "*"); ok {
+			if strings.HasPrefix(eventType, prefix) {
+				return true
+			}
+		} else if f == eventType {
+			return true
+		}
+	}
+	return false
+}
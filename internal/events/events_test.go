@@ -0,0 +1,155 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHub_SubscribeFilter(t *testing.T) {
+	h := NewHub(Config{})
+	defer h.Close()
+
+	_, ch, unsubscribe := h.Subscribe([]string{"provider.*", TypeBudgetWarning}, 0)
+	defer unsubscribe()
+
+	h.Publish(TypeRequestCompleted, nil)
+	h.Publish(TypeProviderHealth, map[string]interface{}{"provider_id": "openai"})
+	h.Publish(TypeBudgetWarning, nil)
+
+	for _, want := range []string{TypeProviderHealth, TypeBudgetWarning} {
+		select {
+		case e := <-ch:
+			if e.Type != want {
+				t.Errorf("expected %s, got %s", want, e.Type)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+	select {
+	case e := <-ch:
+		t.Errorf("unexpected event %s", e.Type)
+	default:
+	}
+}
+
+func TestHub_Replay(t *testing.T) {
+	h := NewHub(Config{History: 3})
+	defer h.Close()
+
+	for i := 0; i < 5; i++ {
+		h.Publish(TypeTaskStatus, nil)
+	}
+
+	// Events 1 and 2 have left the history
+	missed, _, unsubscribe := h.Subscribe(nil, 1)
+	defer unsubscribe()
+	if len(missed) != 3 || missed[0].ID != 3 || missed[2].ID != 5 {
+		t.Errorf("expected events 3-5, got %+v", missed)
+	}
+
+	missed, _, unsubscribe2 := h.Subscribe(nil, 4)
+	defer unsubscribe2()
+	if len(missed) != 1 || missed[0].ID != 5 {
+		t.Errorf("expected event 5, got %+v", missed)
+	}
+}
+
+func TestHub_DropsSlowClient(t *testing.T) {
+	h := NewHub(Config{ClientBuffer: 2})
+	defer h.Close()
+
+	_, ch, unsubscribe := h.Subscribe(nil, 0)
+	defer unsubscribe()
+	for i := 0; i < 3; i++ {
+		h.Publish(TypeRequestCompleted, nil)
+	}
+
+	if h.Clients() != 0 {
+		t.Errorf("expected the slow client to be dropped, %d connected", h.Clients())
+	}
+	received := 0
+	for range ch {
+		received++
+	}
+	if received != 2 {
+		t.Errorf("expected the 2 buffered events before the channel closed, got %d", received)
+	}
+}
+
+func TestHub_ServeHTTP(t *testing.T) {
+	h := NewHub(Config{})
+	h.Publish(TypeBudgetWarning, map[string]interface{}{"tenant_id": "acme"})
+
+	server := httptest.NewServer(h)
+	defer server.Close()
+	defer h.Close()
+
+	resp, err := http.Get(server.URL + "?last_event_id=bad")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid event ID, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"?types=budget.*", nil)
+	req.Header.Set("Last-Event-ID", "0")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	for h.Clients() == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	h.Publish(TypeRequestCompleted, nil) // Filtered out
+	h.Publish(TypeBudgetWarning, map[string]interface{}{"tenant_id": "globex"})
+
+	// A zero Last-Event-ID replays nothing, so only the live warning arrives
+	frames := make(chan map[string]string)
+	go func() {
+		frame := map[string]string{}
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				if frame["event"] != "" {
+					frames <- frame
+				}
+				frame = map[string]string{}
+				continue
+			}
+			if field, value, ok := strings.Cut(line, ": "); ok {
+				frame[field] = value
+			}
+		}
+		close(frames)
+	}()
+
+	select {
+	case frame := <-frames:
+		if frame["id"] != "3" || frame["event"] != TypeBudgetWarning {
+			t.Errorf("unexpected frame %v", frame)
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(frame["data"]), &e); err != nil {
+			t.Fatalf("invalid event data: %v", err)
+		}
+		if e.Data["tenant_id"] != "globex" {
+			t.Errorf("unexpected event %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+}
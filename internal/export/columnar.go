@@ -0,0 +1,262 @@
+package export
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The columnar format stores rows in groups, and each group column by
+// column so similar values sit together and compress well:
+//
+//	magic "MSCOL\x01"
+//	uvarint column count, then each column name (uvarint length + bytes)
+//	row groups: uvarint row count (> 0), then for each column the group's
+//	            values, each a type tag byte followed by its payload
+//	uvarint 0 terminates the stream
+//
+// Payloads: null and bools have none (the tag carries the value), ints are
+// zigzag varints, floats are 8 bytes little-endian and strings are a uvarint
+// length followed by the bytes.
+
+var columnarMagic = []byte("MSCOL\x01")
+
+// DefaultRowGroupSize is the number of rows the columnar writer buffers
+// before encoding a group
+const DefaultRowGroupSize = 1024
+
+// maxStringLen bounds allocations when reading corrupt input
+const maxStringLen = 1 << 30
+
+const (
+	tagNull byte = iota
+	tagFalse
+	tagTrue
+	tagInt
+	tagFloat
+	tagString
+)
+
+// ColumnarWriter writes the columnar binary format
+type ColumnarWriter struct {
+	w         *bufio.Writer
+	groupSize int
+	columns   int
+	group     [][]interface{}
+	scratch   [binary.MaxVarintLen64]byte
+}
+
+// NewColumnarWriter creates a columnar writer that buffers up to groupSize
+// rows at a time
+func NewColumnarWriter(w io.Writer, groupSize int) *ColumnarWriter {
+	if groupSize <= 0 {
+		groupSize = DefaultRowGroupSize
+	}
+	return &ColumnarWriter{w: bufio.NewWriter(w), groupSize: groupSize}
+}
+
+// WriteHeader writes the magic and column names
+func (c *ColumnarWriter) WriteHeader(columns []string) error {
+	c.columns = len(columns)
+	c.w.Write(columnarMagic)
+	c.writeUvarint(uint64(len(columns)))
+	for _, col := range columns {
+		c.writeString(col)
+	}
+	return nil
+}
+
+// WriteRow buffers a row, encoding the group when it is full
+func (c *ColumnarWriter) WriteRow(values []interface{}) error {
+	if len(values) != c.columns {
+		return fmt.Errorf("row has %d values, header has %d columns", len(values), c.columns)
+	}
+	row := make([]interface{}, len(values))
+	for i, v := range values {
+		row[i] = normalize(v)
+	}
+	c.group = append(c.group, row)
+	if len(c.group) >= c.groupSize {
+		return c.flushGroup()
+	}
+	return nil
+}
+
+// Close writes any buffered rows and the terminator
+func (c *ColumnarWriter) Close() error {
+	if err := c.flushGroup(); err != nil {
+		return err
+	}
+	c.writeUvarint(0)
+	return c.w.Flush()
+}
+
+func (c *ColumnarWriter) flushGroup() error {
+	if len(c.group) == 0 {
+		return nil
+	}
+	c.writeUvarint(uint64(len(c.group)))
+	for col := 0; col < c.columns; col++ {
+		for _, row := range c.group {
+			c.writeValue(row[col])
+		}
+	}
+	c.group = c.group[:0]
+	// bufio reports the first write error on every later call
+	_, err := c.w.Write(nil)
+	return err
+}
+
+func (c *ColumnarWriter) writeValue(v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		c.w.WriteByte(tagNull)
+	case bool:
+		if v {
+			c.w.WriteByte(tagTrue)
+		} else {
+			c.w.WriteByte(tagFalse)
+		}
+	case int64:
+		c.w.WriteByte(tagInt)
+		n := binary.PutVarint(c.scratch[:], v)
+		c.w.Write(c.scratch[:n])
+	case float64:
+		c.w.WriteByte(tagFloat)
+		binary.LittleEndian.PutUint64(c.scratch[:8], math.Float64bits(v))
+		c.w.Write(c.scratch[:8])
+	case string:
+		c.w.WriteByte(tagString)
+		c.writeString(v)
+	}
+}
+
+func (c *ColumnarWriter) writeUvarint(v uint64) {
+	n := binary.PutUvarint(c.scratch[:], v)
+	c.w.Write(c.scratch[:n])
+}
+
+func (c *ColumnarWriter) writeString(s string) {
+	c.writeUvarint(uint64(len(s)))
+	c.w.WriteString(s)
+}
+
+// ColumnarReader reads the columnar binary format one row at a time
+type ColumnarReader struct {
+	r       *bufio.Reader
+	columns []string
+	group   [][]interface{}
+	next    int
+	done    bool
+}
+
+// NewColumnarReader reads the header of a columnar stream
+func NewColumnarReader(r io.Reader) (*ColumnarReader, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(columnarMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != string(columnarMagic) {
+		return nil, errors.New("not a columnar export")
+	}
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read column count: %w", err)
+	}
+	c := &ColumnarReader{r: br, columns: make([]string, n)}
+	for i := range c.columns {
+		if c.columns[i], err = c.readString(); err != nil {
+			return nil, fmt.Errorf("failed to read column name: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// Columns returns the column names
+func (c *ColumnarReader) Columns() []string {
+	return c.columns
+}
+
+// Next returns the next row, or io.EOF after the last one
+func (c *ColumnarReader) Next() ([]interface{}, error) {
+	if c.next >= len(c.group) {
+		if c.done {
+			return nil, io.EOF
+		}
+		if err := c.readGroup(); err != nil {
+			return nil, err
+		}
+		if c.done {
+			return nil, io.EOF
+		}
+	}
+	row := c.group[c.next]
+	c.next++
+	return row, nil
+}
+
+func (c *ColumnarReader) readGroup() error {
+	n, err := binary.ReadUvarint(c.r)
+	if err != nil {
+		return fmt.Errorf("failed to read row group: %w", err)
+	}
+	if n == 0 {
+		c.done = true
+		return nil
+	}
+	c.group = make([][]interface{}, n)
+	for i := range c.group {
+		c.group[i] = make([]interface{}, len(c.columns))
+	}
+	for col := range c.columns {
+		for _, row := range c.group {
+			if row[col], err = c.readValue(); err != nil {
+				return fmt.Errorf("failed to read column %s: %w", c.columns[col], err)
+			}
+		}
+	}
+	c.next = 0
+	return nil
+}
+
+func (c *ColumnarReader) readValue() (interface{}, error) {
+	tag, err := c.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch tag {
+	case tagNull:
+		return nil, nil
+	case tagFalse:
+		return false, nil
+	case tagTrue:
+		return true, nil
+	case tagInt:
+		return binary.ReadVarint(c.r)
+	case tagFloat:
+		var buf [8]byte
+		if _, err := io.ReadFull(c.r, buf[:]); err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(buf[:])), nil
+	case tagString:
+		return c.readString()
+	}
+	return nil, fmt.Errorf("unknown value tag %d", tag)
+}
+
+func (c *ColumnarReader) readString() (string, error) {
+	n, err := binary.ReadUvarint(c.r)
+	if err != nil {
+		return "", err
+	}
+	if n > maxStringLen {
+		return "", fmt.Errorf("string length %d exceeds limit", n)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(c.r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,149 @@
+// Package export writes tabular data as CSV, JSON Lines or a compact
+// columnar binary format. Writers stream: rows are encoded as they arrive and
+// only the columnar format holds a bounded row group in memory.
+package export
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
+)
+
+// Format identifies an export encoding
+type Format string
+
+const (
+	FormatCSV      Format = "csv"
+	FormatJSONL    Format = "jsonl"
+	FormatColumnar Format = "columnar"
+)
+
+// Formats lists the supported formats
+var Formats = []Format{FormatCSV, FormatJSONL, FormatColumnar}
+
+// ParseFormat parses a format name
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "csv":
+		return FormatCSV, nil
+	case "jsonl", "ndjson":
+		return FormatJSONL, nil
+	case "columnar", "col", "mscol":
+		return FormatColumnar, nil
+	}
+	return "", fmt.Errorf("unknown export format %q (want csv, jsonl or columnar)", name)
+}
+
+// Extension returns the file extension for the format, without a dot
+func (f Format) Extension() string {
+	if f == FormatColumnar {
+		return "mscol"
+	}
+	return string(f)
+}
+
+// ContentType returns the MIME type for the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	}
+	return "application/octet-stream"
+}
+
+// Writer encodes a header followed by any number of rows. Values may be
+// nil, bool, int64, float64, string, []byte or time.Time; other integer and
+// float types are widened.
+type Writer interface {
+	WriteHeader(columns []string) error
+	WriteRow(values []interface{}) error
+	// Close flushes buffered output. It does not close the underlying
+	// io.Writer.
+	Close() error
+}
+
+// NewWriter returns a Writer for the format
+func NewWriter(f Format, w io.Writer) (Writer, error) {
+	switch f {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatJSONL:
+		return newJSONLWriter(w), nil
+	case FormatColumnar:
+		return NewColumnarWriter(w, DefaultRowGroupSize), nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", f)
+}
+
+// WriteRows streams every row of a query result, using the result's column
+// names as the header, and returns the number of rows written
+func WriteRows(w Writer, rows *sql.Rows) (int, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read columns: %w", err)
+	}
+	if err := w.WriteHeader(columns); err != nil {
+		return 0, err
+	}
+
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	n := 0
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return n, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err := w.WriteRow(values); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
+// normalize reduces a value to nil, bool, int64, float64 or string, masking
+// credentials in strings
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool, int64, float64:
+		return v
+	case string:
+		return redact.String(v)
+	case []byte:
+		return redact.String(string(v))
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	}
+	return redact.String(fmt.Sprint(v))
+}
+
+// ParseTime parses an RFC 3339 timestamp or a YYYY-MM-DD date (midnight
+// UTC), as accepted by export time filters
+func ParseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (want RFC 3339 or YYYY-MM-DD)", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,186 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var (
+	testColumns = []string{"id", "model", "cost", "stream", "note", "created_at"}
+	testRows    = [][]interface{}{
+		{int64(1), "gpt-4o", 0.25, true, nil, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{2, "claude, \"sonnet\"", float32(1.5), false, []byte("line\nbreak"), "2026-01-03"},
+	}
+)
+
+func writeAll(t *testing.T, f Format) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(f, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter(%s) failed: %v", f, err)
+	}
+	if err := w.WriteHeader(testColumns); err != nil {
+		t.Fatalf("WriteHeader failed: %v", err)
+	}
+	for _, row := range testRows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatalf("WriteRow failed: %v", err)
+		}
+	}
+	if err := w.WriteRow([]interface{}{1}); err == nil {
+		t.Error("expected error for a short row")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, want := range map[string]Format{"csv": FormatCSV, "JSONL": FormatJSONL, "ndjson": FormatJSONL, "columnar": FormatColumnar} {
+		if got, err := ParseFormat(name); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %s, %v", name, got, err)
+		}
+	}
+	if _, err := ParseFormat("parquet"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if ts, err := ParseTime("2026-03-01"); err != nil || !ts.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseTime(date) = %v, %v", ts, err)
+	}
+	if _, err := ParseTime("2026-03-01T10:00:00+02:00"); err != nil {
+		t.Errorf("ParseTime(RFC 3339) failed: %v", err)
+	}
+	if _, err := ParseTime("yesterday"); err == nil {
+		t.Error("expected error for invalid time")
+	}
+	if FormatColumnar.Extension() != "mscol" || FormatCSV.ContentType() != "text/csv; charset=utf-8" {
+		t.Error("unexpected extension or content type")
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeAll(t, FormatCSV))).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+	want := [][]string{
+		testColumns,
+		{"1", "gpt-4o", "0.25", "true", "", "2026-01-02T03:04:05Z"},
+		{"2", "claude, \"sonnet\"", "1.5", "false", "line\nbreak", "2026-01-03"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("CSV records = %q, want %q", records, want)
+	}
+}
+
+func TestJSONLWriter(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(string(writeAll(t, FormatJSONL))), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	// Keys keep column order
+	if !strings.HasPrefix(lines[0], `{"id":1,"model":"gpt-4o","cost":0.25,"stream":true,"note":null,`) {
+		t.Errorf("unexpected first line: %s", lines[0])
+	}
+	var row map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &row); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	if row["model"] != "claude, \"sonnet\"" || row["note"] != "line\nbreak" || row["stream"] != false {
+		t.Errorf("unexpected second row: %v", row)
+	}
+}
+
+func TestWritersMaskCredentials(t *testing.T) {
+	for _, f := range []Format{FormatCSV, FormatJSONL, FormatColumnar} {
+		var buf bytes.Buffer
+		w, _ := NewWriter(f, &buf)
+		w.WriteHeader([]string{"error", "raw"})
+		w.WriteRow([]interface{}{"401: invalid key sk-ant-REDACTED", []byte("Bearer abcdefghijklmnopqrstuvwxyz")})
+		w.Close()
+		if out := buf.String(); strings.Contains(out, "AbCdEf0123456789") || strings.Contains(out, "abcdefghijklmnop") {
+			t.Errorf("%s export not masked: %q", f, out)
+		}
+	}
+}
+
+func TestColumnarRoundTrip(t *testing.T) {
+	// A group size smaller than the row count exercises multiple groups
+	var buf bytes.Buffer
+	w := NewColumnarWriter(&buf, 2)
+	w.WriteHeader([]string{"n", "name", "ok", "score", "missing"})
+	for i := 0; i < 5; i++ {
+		if err := w.WriteRow([]interface{}{int64(i - 2), strings.Repeat("x", i), i%2 == 0, float64(i) / 4, nil}); err != nil {
+			t.Fatalf("WriteRow failed: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewColumnarReader(&buf)
+	if err != nil {
+		t.Fatalf("NewColumnarReader failed: %v", err)
+	}
+	if !reflect.DeepEqual(r.Columns(), []string{"n", "name", "ok", "score", "missing"}) {
+		t.Errorf("unexpected columns %v", r.Columns())
+	}
+	for i := 0; i < 5; i++ {
+		row, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next failed at row %d: %v", i, err)
+		}
+		want := []interface{}{int64(i - 2), strings.Repeat("x", i), i%2 == 0, float64(i) / 4, nil}
+		if !reflect.DeepEqual(row, want) {
+			t.Errorf("row %d = %v, want %v", i, row, want)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	if _, err := NewColumnarReader(strings.NewReader("id,name\n")); err == nil {
+		t.Error("expected error reading a non-columnar stream")
+	}
+}
+
+func TestWriteRows(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "export.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	db.Exec(`CREATE TABLE t (id INTEGER, name TEXT, cost REAL)`)
+	db.Exec(`INSERT INTO t VALUES (1, 'a', 0.5), (2, NULL, 1.25)`)
+
+	rows, err := db.Query(`SELECT id, name, cost FROM t ORDER BY id`)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	w, _ := NewWriter(FormatJSONL, &buf)
+	n, err := WriteRows(w, rows)
+	if err != nil || n != 2 {
+		t.Fatalf("WriteRows = %d, %v", n, err)
+	}
+	w.Close()
+
+	want := "{\"id\":1,\"name\":\"a\",\"cost\":0.5}\n{\"id\":2,\"name\":null,\"cost\":1.25}\n"
+	if buf.String() != want {
+		t.Errorf("WriteRows output = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,97 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteHeader(columns []string) error {
+	c.record = make([]string, len(columns))
+	return c.w.Write(columns)
+}
+
+func (c *csvWriter) WriteRow(values []interface{}) error {
+	if len(values) != len(c.record) {
+		return fmt.Errorf("row has %d values, header has %d columns", len(values), len(c.record))
+	}
+	for i, v := range values {
+		switch v := normalize(v).(type) {
+		case nil:
+			c.record[i] = ""
+		case bool:
+			c.record[i] = strconv.FormatBool(v)
+		case int64:
+			c.record[i] = strconv.FormatInt(v, 10)
+		case float64:
+			c.record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case string:
+			c.record[i] = v
+		}
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonlWriter writes one JSON object per row with keys in column order
+type jsonlWriter struct {
+	w    *bufio.Writer
+	keys [][]byte
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	return &jsonlWriter{w: bufio.NewWriter(w)}
+}
+
+func (j *jsonlWriter) WriteHeader(columns []string) error {
+	j.keys = make([][]byte, len(columns))
+	for i, col := range columns {
+		key, err := json.Marshal(col)
+		if err != nil {
+			return err
+		}
+		j.keys[i] = key
+	}
+	return nil
+}
+
+func (j *jsonlWriter) WriteRow(values []interface{}) error {
+	if len(values) != len(j.keys) {
+		return fmt.Errorf("row has %d values, header has %d columns", len(values), len(j.keys))
+	}
+	j.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			j.w.WriteByte(',')
+		}
+		j.w.Write(j.keys[i])
+		j.w.WriteByte(':')
+		data, err := json.Marshal(normalize(v))
+		if err != nil {
+			return err
+		}
+		j.w.Write(data)
+	}
+	j.w.WriteByte('}')
+	return j.w.WriteByte('\n')
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.w.Flush()
+}
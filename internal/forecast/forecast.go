@@ -0,0 +1,423 @@
+// Package forecast projects each provider's, client's and tenant's spend
+// to the end of the month from the usage ledger, and warns when a
+// projection runs over its budget while the spend is still under it, so
+// there is time to act before the hard limit refuses requests.
+//
+// Two trend models are offered. run_rate carries the month-to-date average
+// daily spend to the end of the month. linear fits a least-squares line to
+// the daily spend of the last Lookback complete days, so spend that is
+// climbing is projected to keep climbing; a series with less than two days
+// of history falls back to run_rate. Months are calendar months in the
+// location of the time passed in, as tenant budgets are.
+package forecast
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+)
+
+// Dimensions spend is projected by
+const (
+	DimensionProvider = "provider"
+	DimensionClient   = "client"
+	DimensionTenant   = "tenant"
+)
+
+// Trend models
+const (
+	MethodRunRate = "run_rate"
+	MethodLinear  = "linear"
+)
+
+// recentWarnings is how many warnings Recent keeps
+const recentWarnings = 100
+
+// Spend is the ledger's spend for one day, tenant, client and provider
+type Spend struct {
+	Day      time.Time // Midnight starting the day
+	Tenant   string
+	Client   string // "" for requests without a client
+	Provider string
+	Cost     float64
+}
+
+// Store reads the usage ledger and the budgets kept with it
+type Store interface {
+	// DailySpend returns the spend per day since a midnight, bucketed in
+	// its location
+	DailySpend(since time.Time) ([]Spend, error)
+	// TenantBudgets returns each tenant's monthly budget by ID
+	TenantBudgets() (map[string]float64, error)
+}
+
+// Config configures a Forecaster
+type Config struct {
+	// Interval is the time between budget checks (default 1h)
+	Interval time.Duration
+	// Method is the trend model checks use and reports default to
+	// (default linear)
+	Method string
+	// Lookback is how many complete days the linear model fits (default 14)
+	Lookback int
+	// ProviderBudgets are monthly budgets in USD by provider
+	ProviderBudgets map[string]float64
+	// ClientBudgets are monthly budgets in USD by client ID
+	ClientBudgets map[string]float64
+
+	// OnWarning is called for each forecast newly projected over its
+	// budget, outside the forecaster's lock
+	OnWarning func(Forecast)
+}
+
+// DefaultConfig returns the default forecast configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval: time.Hour,
+		Method:   MethodLinear,
+		Lookback: 14,
+	}
+}
+
+// ValidMethod reports whether method names a trend model
+func ValidMethod(method string) bool {
+	return method == MethodRunRate || method == MethodLinear
+}
+
+// Forecast is the projected month-end spend of one provider, client or
+// tenant
+type Forecast struct {
+	Dimension   string     `json:"dimension"`
+	Key         string     `json:"key"`
+	Method      string     `json:"method"` // The model used, run_rate where linear lacked history
+	MonthToDate float64    `json:"month_to_date"`
+	DailyRate   float64    `json:"daily_rate"` // Projected spend today
+	Projected   float64    `json:"projected"`  // At the end of the month
+	Budget      float64    `json:"budget,omitempty"`
+	Exceeded    bool       `json:"exceeded,omitempty"`   // Spend already reached the budget
+	AtRisk      bool       `json:"at_risk,omitempty"`    // Projected over a budget not yet reached
+	ExceedsOn   *time.Time `json:"exceeds_on,omitempty"` // Day the projection reaches the budget
+	At          time.Time  `json:"at"`
+}
+
+// Report is the forecast of every provider, client and tenant with spend
+// or a budget
+type Report struct {
+	Method      string     `json:"method"`
+	Month       time.Time  `json:"month"`
+	At          time.Time  `json:"at"`
+	DaysElapsed float64    `json:"days_elapsed"`
+	DaysInMonth int        `json:"days_in_month"`
+	Forecasts   []Forecast `json:"forecasts"` // By dimension, highest projection first
+}
+
+// Forecaster projects spend and warns on budgets at risk
+type Forecaster struct {
+	store  Store
+	config Config
+
+	mu     sync.Mutex
+	warned map[string]time.Time // Month last warned, by dimension and key
+	recent []Forecast           // Newest last
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// New creates a forecaster and starts checking budgets every Interval,
+// beginning now. Zero config fields take their defaults.
+func New(store Store, cfg Config) *Forecaster {
+	f := newForecaster(store, cfg)
+	f.wg.Add(1)
+	go f.loop()
+	return f
+}
+
+// newForecaster creates a forecaster without starting its checks
+func newForecaster(store Store, cfg Config) *Forecaster {
+	def := DefaultConfig()
+	if cfg.Interval <= 0 {
+		cfg.Interval = def.Interval
+	}
+	if cfg.Method == "" {
+		cfg.Method = def.Method
+	}
+	if cfg.Lookback < 2 {
+		cfg.Lookback = def.Lookback
+	}
+
+	return &Forecaster{
+		store:  store,
+		config: cfg,
+		warned: make(map[string]time.Time),
+		stop:   make(chan struct{}),
+	}
+}
+
+// series is one dimension and key's daily spend
+type series struct {
+	dimension, key string
+	days           []float64 // From the start of the report's history
+}
+
+// Report projects spend to the end of now's month with a trend model,
+// the configured one when method is empty
+func (f *Forecaster) Report(now time.Time, method string) (*Report, error) {
+	if method == "" {
+		method = f.config.Method
+	}
+	if !ValidMethod(method) {
+		return nil, fmt.Errorf("unknown method %q (want run_rate or linear)", method)
+	}
+
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	end := month.AddDate(0, 1, 0)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	since := today.AddDate(0, 0, -f.config.Lookback)
+	if month.Before(since) {
+		since = month
+	}
+	spend, err := f.store.DailySpend(since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spend: %w", err)
+	}
+	tenantBudgets, err := f.store.TenantBudgets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenant budgets: %w", err)
+	}
+	budgets := map[string]map[string]float64{
+		DimensionProvider: f.config.ProviderBudgets,
+		DimensionClient:   f.config.ClientBudgets,
+		DimensionTenant:   tenantBudgets,
+	}
+
+	todayIdx := dayIndex(since, today)
+	all := make(map[[2]string]*series)
+	get := func(dimension, key string) *series {
+		k := [2]string{dimension, key}
+		s, ok := all[k]
+		if !ok {
+			s = &series{dimension: dimension, key: key, days: make([]float64, todayIdx+1)}
+			all[k] = s
+		}
+		return s
+	}
+	for _, sp := range spend {
+		// Clock skew between writers can date a row after today
+		i := min(dayIndex(since, sp.Day), todayIdx)
+		if i < 0 {
+			continue
+		}
+		get(DimensionProvider, sp.Provider).days[i] += sp.Cost
+		if sp.Client != "" {
+			get(DimensionClient, sp.Client).days[i] += sp.Cost
+		}
+		get(DimensionTenant, sp.Tenant).days[i] += sp.Cost
+	}
+	for dimension, byKey := range budgets {
+		for key, budget := range byKey {
+			if budget > 0 {
+				get(dimension, key)
+			}
+		}
+	}
+
+	report := &Report{
+		Method:      method,
+		Month:       month,
+		At:          now,
+		DaysElapsed: now.Sub(month).Hours() / 24,
+		DaysInMonth: dayIndex(month, end),
+		Forecasts:   []Forecast{},
+	}
+	p := projection{
+		now:      now,
+		today:    today,
+		end:      end,
+		todayIdx: todayIdx,
+		monthIdx: dayIndex(since, month),
+		lookback: f.config.Lookback,
+		elapsed:  report.DaysElapsed,
+	}
+	for _, s := range all {
+		fc := p.project(s.days, method, budgets[s.dimension][s.key])
+		fc.Dimension, fc.Key = s.dimension, s.key
+		report.Forecasts = append(report.Forecasts, fc)
+	}
+	order := map[string]int{DimensionProvider: 0, DimensionClient: 1, DimensionTenant: 2}
+	sort.Slice(report.Forecasts, func(i, j int) bool {
+		a, b := report.Forecasts[i], report.Forecasts[j]
+		if a.Dimension != b.Dimension {
+			return order[a.Dimension] < order[b.Dimension]
+		}
+		if a.Projected != b.Projected {
+			return a.Projected > b.Projected
+		}
+		return a.Key < b.Key
+	})
+	return report, nil
+}
+
+// dayIndex counts the days from one midnight to another, rounding so a
+// daylight saving change does not shift it
+func dayIndex(from, to time.Time) int {
+	return int(math.Floor(to.Sub(from).Hours()/24 + 0.5))
+}
+
+// projection holds the calendar a report's series are projected over
+type projection struct {
+	now, today, end    time.Time
+	todayIdx, monthIdx int
+	lookback           int
+	elapsed            float64 // Days since the month began
+}
+
+// project forecasts one series' month-end spend against its budget
+func (p projection) project(days []float64, method string, budget float64) Forecast {
+	var mtd float64
+	for _, cost := range days[max(p.monthIdx, 0):] {
+		mtd += cost
+	}
+	fc := Forecast{Method: method, MonthToDate: mtd, Budget: budget, At: p.now}
+
+	// The run rate is taken over at least a day so the first hours of a
+	// month do not project wildly
+	rate := mtd / math.Max(p.elapsed, 1)
+	daily := func(int) float64 { return rate }
+	if method == MethodLinear {
+		if a, b, ok := p.fit(days); ok {
+			daily = func(i int) float64 { return math.Max(a+b*float64(i), 0) }
+		} else {
+			fc.Method = MethodRunRate
+		}
+	}
+	fc.DailyRate = daily(p.todayIdx)
+
+	// Today is partly spent already; only its remainder is projected
+	tomorrow := p.today.AddDate(0, 0, 1)
+	fc.Projected = mtd
+	fc.Exceeded = budget > 0 && mtd >= budget
+	reach := func(day time.Time) {
+		if budget > 0 && !fc.Exceeded && fc.ExceedsOn == nil && fc.Projected >= budget {
+			d := day
+			fc.ExceedsOn = &d
+		}
+	}
+	fc.Projected += daily(p.todayIdx) * tomorrow.Sub(p.now).Hours() / 24
+	reach(p.today)
+	for day, i := tomorrow, p.todayIdx+1; day.Before(p.end); day, i = day.AddDate(0, 0, 1), i+1 {
+		fc.Projected += daily(i)
+		reach(day)
+	}
+	fc.AtRisk = fc.ExceedsOn != nil
+	return fc
+}
+
+// fit returns the least-squares line through the complete days of the
+// lookback window, starting at the series' first spend so a newcomer's
+// empty days before it do not steepen its trend. ok is false with less
+// than two days to fit.
+func (p projection) fit(days []float64) (a, b float64, ok bool) {
+	first := max(p.todayIdx-p.lookback, 0)
+	for first < p.todayIdx && days[first] == 0 {
+		first++
+	}
+	n := p.todayIdx - first
+	if n < 2 {
+		return 0, 0, false
+	}
+	var sx, sy float64
+	for i := first; i < p.todayIdx; i++ {
+		sx += float64(i)
+		sy += days[i]
+	}
+	mx, my := sx/float64(n), sy/float64(n)
+	var sxy, sxx float64
+	for i := first; i < p.todayIdx; i++ {
+		dx := float64(i) - mx
+		sxy += dx * (days[i] - my)
+		sxx += dx * dx
+	}
+	b = sxy / sxx
+	return my - b*mx, b, true
+}
+
+// Check projects spend with the configured method and warns, once per
+// month, for each forecast newly at risk of exceeding its budget; it runs
+// every Interval on its own
+func (f *Forecaster) Check(now time.Time) ([]Forecast, error) {
+	report, err := f.Report(now, "")
+	if err != nil {
+		return nil, err
+	}
+	var warnings []Forecast
+	f.mu.Lock()
+	for _, fc := range report.Forecasts {
+		key := fc.Dimension + "/" + fc.Key
+		if !fc.AtRisk || f.warned[key].Equal(report.Month) {
+			continue
+		}
+		f.warned[key] = report.Month
+		warnings = append(warnings, fc)
+	}
+	f.recent = append(f.recent, warnings...)
+	if len(f.recent) > recentWarnings {
+		f.recent = f.recent[len(f.recent)-recentWarnings:]
+	}
+	f.mu.Unlock()
+
+	if f.config.OnWarning != nil {
+		for _, fc := range warnings {
+			f.config.OnWarning(fc)
+		}
+	}
+	return warnings, nil
+}
+
+// Recent returns the latest warnings, newest first
+func (f *Forecaster) Recent() []Forecast {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]Forecast, len(f.recent))
+	for i, fc := range f.recent {
+		out[len(f.recent)-1-i] = fc
+	}
+	return out
+}
+
+// Config returns the forecaster's configuration with defaults applied
+func (f *Forecaster) Config() Config {
+	return f.config
+}
+
+// Close stops checking budgets
+func (f *Forecaster) Close() {
+	f.stopOnce.Do(func() { close(f.stop) })
+	f.wg.Wait()
+}
+
+func (f *Forecaster) loop() {
+	defer f.wg.Done()
+	check := func(now time.Time) {
+		if _, err := f.Check(now); err != nil {
+			log.Printf("forecast: budget check failed: %v", err)
+		}
+	}
+	_ = crash.Do("spend forecast", func() { check(time.Now()) })
+	ticker := time.NewTicker(f.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case now := <-ticker.C:
+			_ = crash.Do("spend forecast", func() { check(now) })
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+type fakeStore struct {
+	spend   []Spend
+	budgets map[string]float64
+}
+
+func (s *fakeStore) DailySpend(since time.Time) ([]Spend, error) {
+	var out []Spend
+	for _, sp := range s.spend {
+		if !sp.Day.Before(since) {
+			out = append(out, sp)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) TenantBudgets() (map[string]float64, error) {
+	return s.budgets, nil
+}
+
+func day(d int) time.Time {
+	return time.Date(2025, time.March, d, 0, 0, 0, 0, time.UTC)
+}
+
+// newTestStore holds half a month of spend up to noon on March 16th:
+// openai at a flat $10 a day without a client, and cli-a on anthropic
+// climbing by $1 a day
+func newTestStore() *fakeStore {
+	s := &fakeStore{budgets: map[string]float64{"team-a": 100}}
+	for d := 1; d <= 15; d++ {
+		s.spend = append(s.spend,
+			Spend{Day: day(d), Tenant: "team-a", Provider: "openai", Cost: 10},
+			Spend{Day: day(d), Tenant: "team-a", Client: "cli-a", Provider: "anthropic", Cost: float64(d)},
+		)
+	}
+	s.spend = append(s.spend,
+		Spend{Day: day(16), Tenant: "team-a", Provider: "openai", Cost: 5},
+		Spend{Day: day(16), Tenant: "team-a", Client: "cli-a", Provider: "anthropic", Cost: 8},
+		Spend{Day: day(16), Tenant: "team-b", Client: "cli-new", Provider: "openai", Cost: 4},
+	)
+	return s
+}
+
+var now = day(16).Add(12 * time.Hour)
+
+func find(t *testing.T, r *Report, dimension, key string) Forecast {
+	t.Helper()
+	for _, fc := range r.Forecasts {
+		if fc.Dimension == dimension && fc.Key == key {
+			return fc
+		}
+	}
+	t.Fatalf("no forecast for %s %s in %+v", dimension, key, r.Forecasts)
+	return Forecast{}
+}
+
+func TestReport_RunRate(t *testing.T) {
+	f := newForecaster(newTestStore(), Config{})
+	r, err := f.Report(now, MethodRunRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.DaysInMonth != 31 || r.DaysElapsed != 15.5 || !r.Month.Equal(day(1)) {
+		t.Errorf("unexpected calendar: %+v", r)
+	}
+	// Halfway through the month the run rate doubles the spend so far
+	fc := find(t, r, DimensionProvider, "openai")
+	if fc.MonthToDate != 159 || fc.Method != MethodRunRate || math.Abs(fc.Projected-318) > 1e-9 {
+		t.Errorf("unexpected openai forecast: %+v", fc)
+	}
+	if fc := find(t, r, DimensionClient, "cli-a"); math.Abs(fc.DailyRate-128/15.5) > 1e-9 || math.Abs(fc.Projected-256) > 1e-9 {
+		t.Errorf("unexpected cli-a forecast: %+v", fc)
+	}
+}
+
+func TestReport_Linear(t *testing.T) {
+	store := newTestStore()
+	f := newForecaster(store, Config{ClientBudgets: map[string]float64{"cli-a": 300}, ProviderBudgets: map[string]float64{"mistral": 50}})
+	r, err := f.Report(now, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Method != MethodLinear {
+		t.Errorf("expected linear by default, got %s", r.Method)
+	}
+
+	// March 2nd to 15th rise by $1 a day: $16 today, $17 tomorrow and so on
+	fc := find(t, r, DimensionClient, "cli-a")
+	if fc.Method != MethodLinear || fc.DailyRate != 16 || fc.Projected != 128+8+360 {
+		t.Errorf("unexpected cli-a forecast: %+v", fc)
+	}
+	if !fc.AtRisk || fc.Exceeded || fc.ExceedsOn == nil || !fc.ExceedsOn.Equal(day(24)) {
+		t.Errorf("expected cli-a to reach its $300 budget on the 24th, got %+v", fc)
+	}
+
+	// A provider sums its clients' spend, and a flat trend stays flat
+	if fc := find(t, r, DimensionProvider, "anthropic"); fc.Projected != 496 {
+		t.Errorf("expected the anthropic provider to match its only client, got %+v", fc)
+	}
+	if fc := find(t, r, DimensionProvider, "openai"); fc.DailyRate != 10 || fc.Projected != 159+5+150 {
+		t.Errorf("unexpected openai forecast: %+v", fc)
+	}
+
+	// A day of history is too little for a trend
+	if fc := find(t, r, DimensionClient, "cli-new"); fc.Method != MethodRunRate || fc.MonthToDate != 4 {
+		t.Errorf("expected cli-new to fall back to the run rate, got %+v", fc)
+	}
+
+	// Spend already past the budget is no early warning
+	if fc := find(t, r, DimensionTenant, "team-a"); !fc.Exceeded || fc.AtRisk || fc.ExceedsOn != nil {
+		t.Errorf("expected team-a over its budget, got %+v", fc)
+	}
+
+	// A budget without spend is still listed
+	if fc := find(t, r, DimensionProvider, "mistral"); fc.Projected != 0 || fc.Budget != 50 || fc.AtRisk {
+		t.Errorf("unexpected mistral forecast: %+v", fc)
+	}
+
+	if r.Forecasts[0].Dimension != DimensionProvider || r.Forecasts[len(r.Forecasts)-1].Dimension != DimensionTenant {
+		t.Errorf("expected forecasts ordered by dimension, got %+v", r.Forecasts)
+	}
+	if r.Forecasts[0].Key != "anthropic" {
+		t.Errorf("expected the highest provider projection first, got %s", r.Forecasts[0].Key)
+	}
+
+	if _, err := f.Report(now, "exponential"); err == nil {
+		t.Error("expected an unknown method to fail")
+	}
+}
+
+func TestLinearFitClampsAtZero(t *testing.T) {
+	// Spend falling by $2 a day from $20 runs out on the 11th
+	s := &fakeStore{}
+	for d := 1; d <= 5; d++ {
+		s.spend = append(s.spend, Spend{Day: day(d), Tenant: "team-a", Provider: "openai", Cost: float64(22 - 2*d)})
+	}
+	f := newForecaster(s, Config{})
+	r, err := f.Report(day(6), MethodLinear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc := find(t, r, DimensionProvider, "openai")
+	if fc.Projected != 20+18+16+14+12+10+8+6+4+2 {
+		t.Errorf("expected no negative spend projected, got %+v", fc)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var warned []Forecast
+	f := newForecaster(newTestStore(), Config{
+		ClientBudgets: map[string]float64{"cli-a": 300},
+		OnWarning:     func(fc Forecast) { warned = append(warned, fc) },
+	})
+
+	warnings, err := f.Check(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || warnings[0].Key != "cli-a" || len(warned) != 1 {
+		t.Fatalf("expected one warning for cli-a, got %+v", warnings)
+	}
+
+	// Once a month
+	if warnings, _ := f.Check(now.Add(time.Hour)); len(warnings) != 0 {
+		t.Errorf("expected no repeat warning, got %+v", warnings)
+	}
+	if recent := f.Recent(); len(recent) != 1 || recent[0].Key != "cli-a" {
+		t.Errorf("unexpected recent warnings: %+v", recent)
+	}
+}
+
+func TestNewAndClose(t *testing.T) {
+	f := New(&fakeStore{}, Config{Interval: time.Millisecond})
+	time.Sleep(5 * time.Millisecond)
+	f.Close()
+	f.Close()
+
+	cfg := f.Config()
+	if cfg.Method != MethodLinear || cfg.Lookback != 14 {
+		t.Errorf("expected defaults, got %+v", cfg)
+	}
+}
@@ -2,6 +2,7 @@ package generator
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -29,6 +30,7 @@ type GenerateRequest struct {
 	SDKType      string // openai-compatible, anthropic-compatible, custom
 	Endpoints    []Endpoint
 	Models       []Model
+	Parameters   []Parameter // Observed request parameters, typed by GenerateTyped
 }
 
 // Endpoint represents an API endpoint
@@ -36,6 +38,17 @@ type Endpoint struct {
 	Path    string
 	Method  string
 	Purpose string
+
+	// ResponseSample is an observed response body; GenerateTyped derives
+	// the endpoint's response struct from it
+	ResponseSample json.RawMessage
+}
+
+// Parameter is a request parameter observed during discovery
+type Parameter struct {
+	Name     string
+	Type     string // string, integer, number, boolean, array or object
+	Required bool
 }
 
 // Model represents a model
@@ -143,14 +156,25 @@ func (g *Generator) GenerateBatch(requests []GenerateRequest) []*GenerateResult
 	return results
 }
 
-// Delete removes generated SDK file
+// Delete removes a provider's generated SDK file and typed client package
 func (g *Generator) Delete(providerID string) error {
 	filename := fmt.Sprintf("%s_generated.go", providerID)
 	filePath := filepath.Join(g.outputDir, filename)
-	return os.Remove(filePath)
+	err := os.Remove(filePath)
+
+	typedDir := filepath.Join(g.outputDir, packageName(providerID))
+	if _, statErr := os.Stat(filepath.Join(typedDir, typedClientFile)); statErr == nil {
+		if rmErr := os.RemoveAll(typedDir); rmErr != nil {
+			return rmErr
+		}
+		if os.IsNotExist(err) {
+			return nil
+		}
+	}
+	return err
 }
 
-// List lists all generated SDK files
+// List lists all generated SDK files, typed clients as <package>/client.go
 func (g *Generator) List() ([]string, error) {
 	entries, err := os.ReadDir(g.outputDir)
 	if err != nil {
@@ -162,6 +186,13 @@ func (g *Generator) List() ([]string, error) {
 		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".go" {
 			files = append(files, entry.Name())
 		}
+		// Typed client packages (GenerateTyped)
+		if entry.IsDir() {
+			typed := filepath.Join(entry.Name(), typedClientFile)
+			if _, err := os.Stat(filepath.Join(g.outputDir, typed)); err == nil {
+				files = append(files, typed)
+			}
+		}
 	}
 
 	return files, nil
@@ -9,6 +9,7 @@ type Templates struct {
 	OpenAICompatible    *template.Template
 	AnthropicCompatible *template.Template
 	Custom              *template.Template
+	Typed               *template.Template // Fully typed clients (GenerateTyped)
 }
 
 // LoadTemplates loads all code generation templates
@@ -28,10 +29,16 @@ func LoadTemplates() (*Templates, error) {
 		return nil, err
 	}
 
+	typedTmpl, err := template.New("typed").Parse(typedClientTemplate)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Templates{
 		OpenAICompatible:    openaiTmpl,
 		AnthropicCompatible: anthropicTmpl,
 		Custom:              customTmpl,
+		Typed:               typedTmpl,
 	}, nil
 }
 
@@ -313,3 +320,460 @@ func (p *{{.ProviderName}}Provider) ListModels(ctx context.Context) ([]string, e
 // - {{.Method}} {{.Path}} ({{.Purpose}})
 {{- end}}
 `
+
+// typedClientTemplate generates a provider's typed client package
+// (GenerateTyped); the output is gofmt'd afterwards
+const typedClientTemplate = `// Code generated by modelscan; DO NOT EDIT.
+
+// Package {{.Package}} is a typed client for the {{.ProviderName}} API.
+package {{.Package}}
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the {{.ProviderName}} API base URL
+const DefaultBaseURL = {{printf "%q" .BaseURL}}
+{{- if .Models}}
+
+// Models discovered for {{.ProviderName}}
+const (
+{{- range .Models}}
+	{{.Const}} = {{printf "%q" .ID}}
+{{- end}}
+)
+{{- end}}
+
+// Client calls the {{.ProviderName}} API
+type Client struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithBaseURL overrides DefaultBaseURL
+func WithBaseURL(url string) Option {
+	return func(c *Client) { c.baseURL = url }
+}
+
+// WithHTTPClient replaces the default HTTP client, whose 60s timeout also
+// bounds streams
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// NewClient creates a Client authenticating with apiKey
+func NewClient(apiKey string, opts ...Option) *Client {
+	c := &Client{
+		apiKey:     apiKey,
+		baseURL:    DefaultBaseURL,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// APIError is an error response from the API
+type APIError struct {
+	StatusCode int
+	Type       string        // Error type or code reported by the API
+	Message    string        // Error message reported by the API
+	RetryAfter time.Duration // From the Retry-After header
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Body
+	}
+	return fmt.Sprintf("{{.Package}}: API error (status %d): %s", e.StatusCode, msg)
+}
+
+// Temporary reports whether retrying may succeed (rate limits and server
+// errors)
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// newAPIError parses the {"error": {"type", "code", "message"}} and
+// {"error": "message"} error bodies
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	e := &APIError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		e.RetryAfter = time.Duration(secs) * time.Second
+	}
+
+	var parsed struct {
+		Error   json.RawMessage ` + "`json:\"error\"`" + `
+		Message string          ` + "`json:\"message\"`" + `
+	}
+	if json.Unmarshal(body, &parsed) != nil {
+		return e
+	}
+	var detail struct {
+		Type    string ` + "`json:\"type\"`" + `
+		Code    any    ` + "`json:\"code\"`" + `
+		Message string ` + "`json:\"message\"`" + `
+	}
+	if json.Unmarshal(parsed.Error, &detail) == nil {
+		e.Type, e.Message = detail.Type, detail.Message
+		if code, ok := detail.Code.(string); ok && code != "" {
+			e.Type = code
+		}
+	} else {
+		json.Unmarshal(parsed.Error, &e.Message)
+	}
+	if e.Message == "" {
+		e.Message = parsed.Message
+	}
+	return e
+}
+
+// send sends a request, returning the response of a 2xx answer and an
+// *APIError otherwise. body is sent as JSON unless nil.
+func (c *Client) send(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.baseURL, "/")+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set({{printf "%q" .AuthHeader}}, {{if .AuthPrefix}}{{printf "%q" .AuthPrefix}}+{{end}}c.apiKey)
+{{- if .Anthropic}}
+	req.Header.Set("anthropic-version", "2023-06-01")
+{{- end}}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError(resp, data)
+	}
+	return resp, nil
+}
+
+// do sends a request and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// Stream reads server-sent events, decoding each data line as a T
+type Stream[T any] struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	current T
+	err     error
+}
+
+func newStream[T any](body io.ReadCloser) *Stream[T] {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return &Stream[T]{body: body, scanner: scanner}
+}
+
+// Next advances to the next event, returning false at the end of the
+// stream or on an error (see Err)
+func (s *Stream[T]) Next() bool {
+	for s.err == nil && s.scanner.Scan() {
+		line := s.scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return false
+		}
+		var event T
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			s.err = fmt.Errorf("failed to parse event: %w", err)
+			return false
+		}
+		s.current = event
+		return true
+	}
+	if s.err == nil {
+		s.err = s.scanner.Err()
+	}
+	return false
+}
+
+// Current returns the event read by the last call to Next
+func (s *Stream[T]) Current() T {
+	return s.current
+}
+
+// Err returns the error that ended the stream, if any
+func (s *Stream[T]) Err() error {
+	return s.err
+}
+
+// Close releases the connection
+func (s *Stream[T]) Close() error {
+	return s.body.Close()
+}
+{{- if .Custom}}
+
+// OpenStream sends a request to an endpoint answering with server-sent
+// events
+func (c *Client) OpenStream(ctx context.Context, method, path string, body any) (*Stream[json.RawMessage], error) {
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	return newStream[json.RawMessage](resp.Body), nil
+}
+{{- range .Endpoints}}
+
+// {{.Method}} calls {{.HTTPMethod}} {{.Path}}{{if .Purpose}} ({{.Purpose}}){{end}}
+func (c *Client) {{.Method}}(ctx context.Context, body any) (*{{.Response}}, error) {
+	var out {{.Response}}
+	if err := c.do(ctx, {{printf "%q" .HTTPMethod}}, {{printf "%q" .Path}}, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+{{- end}}
+{{- else if .Anthropic}}
+
+// Message is one conversation turn
+type Message struct {
+	Role    string ` + "`json:\"role\"`" + `
+	Content string ` + "`json:\"content\"`" + `
+}
+
+// MessageRequest is a Messages API request
+type MessageRequest struct {
+	Model     string    ` + "`json:\"model\"`" + `
+	Messages  []Message ` + "`json:\"messages\"`" + `
+	System    string    ` + "`json:\"system,omitempty\"`" + `
+	MaxTokens int       ` + "`json:\"max_tokens\"`" + `
+{{- range .RequestFields}}
+	{{.Name}} {{.Type}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+	Stream bool ` + "`json:\"stream,omitempty\"`" + `
+}
+
+// ContentBlock is one block of message content
+type ContentBlock struct {
+	Type  string          ` + "`json:\"type\"`" + `
+	Text  string          ` + "`json:\"text,omitempty\"`" + `
+	ID    string          ` + "`json:\"id,omitempty\"`" + `
+	Name  string          ` + "`json:\"name,omitempty\"`" + `
+	Input json.RawMessage ` + "`json:\"input,omitempty\"`" + `
+}
+
+// Usage counts a message's tokens
+type Usage struct {
+	InputTokens  int ` + "`json:\"input_tokens\"`" + `
+	OutputTokens int ` + "`json:\"output_tokens\"`" + `
+}
+{{- if not .InferredChat}}
+
+// MessageResponse is a complete message
+type MessageResponse struct {
+	ID         string         ` + "`json:\"id\"`" + `
+	Type       string         ` + "`json:\"type\"`" + `
+	Role       string         ` + "`json:\"role\"`" + `
+	Content    []ContentBlock ` + "`json:\"content\"`" + `
+	Model      string         ` + "`json:\"model\"`" + `
+	StopReason string         ` + "`json:\"stop_reason\"`" + `
+	Usage      Usage          ` + "`json:\"usage\"`" + `
+}
+{{- end}}
+
+// StreamEvent is one event of a streamed message
+type StreamEvent struct {
+	Type         string           ` + "`json:\"type\"`" + `
+	Index        int              ` + "`json:\"index\"`" + `
+	Message      *MessageResponse ` + "`json:\"message,omitempty\"`" + `
+	ContentBlock *ContentBlock    ` + "`json:\"content_block,omitempty\"`" + `
+	Delta        *StreamDelta     ` + "`json:\"delta,omitempty\"`" + `
+	Usage        *Usage           ` + "`json:\"usage,omitempty\"`" + `
+}
+
+// StreamDelta is the incremental content of a StreamEvent
+type StreamDelta struct {
+	Type        string ` + "`json:\"type\"`" + `
+	Text        string ` + "`json:\"text,omitempty\"`" + `
+	PartialJSON string ` + "`json:\"partial_json,omitempty\"`" + `
+	StopReason  string ` + "`json:\"stop_reason,omitempty\"`" + `
+}
+
+// CreateMessage sends a Messages API request
+func (c *Client) CreateMessage(ctx context.Context, req MessageRequest) (*MessageResponse, error) {
+	req.Stream = false
+	var out MessageResponse
+	if err := c.do(ctx, http.MethodPost, {{printf "%q" .ChatPath}}, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateMessageStream sends a streamed Messages API request
+func (c *Client) CreateMessageStream(ctx context.Context, req MessageRequest) (*Stream[StreamEvent], error) {
+	req.Stream = true
+	resp, err := c.send(ctx, http.MethodPost, {{printf "%q" .ChatPath}}, req)
+	if err != nil {
+		return nil, err
+	}
+	return newStream[StreamEvent](resp.Body), nil
+}
+{{- else}}
+
+// Message is one chat turn
+type Message struct {
+	Role    string ` + "`json:\"role\"`" + `
+	Content string ` + "`json:\"content\"`" + `
+}
+
+// ChatRequest is a chat completion request
+type ChatRequest struct {
+	Model    string    ` + "`json:\"model\"`" + `
+	Messages []Message ` + "`json:\"messages\"`" + `
+{{- range .RequestFields}}
+	{{.Name}} {{.Type}} ` + "`json:\"{{.JSON}}\"`" + `
+{{- end}}
+	Stream bool ` + "`json:\"stream,omitempty\"`" + `
+}
+
+// Usage counts a request's tokens
+type Usage struct {
+	PromptTokens     int ` + "`json:\"prompt_tokens\"`" + `
+	CompletionTokens int ` + "`json:\"completion_tokens\"`" + `
+	TotalTokens      int ` + "`json:\"total_tokens\"`" + `
+}
+{{- if not .InferredChat}}
+
+// ChatResponse is a chat completion
+type ChatResponse struct {
+	ID      string       ` + "`json:\"id\"`" + `
+	Object  string       ` + "`json:\"object\"`" + `
+	Created int64        ` + "`json:\"created\"`" + `
+	Model   string       ` + "`json:\"model\"`" + `
+	Choices []ChatChoice ` + "`json:\"choices\"`" + `
+	Usage   Usage        ` + "`json:\"usage\"`" + `
+}
+
+// ChatChoice is one completion choice
+type ChatChoice struct {
+	Index        int     ` + "`json:\"index\"`" + `
+	Message      Message ` + "`json:\"message\"`" + `
+	FinishReason string  ` + "`json:\"finish_reason\"`" + `
+}
+{{- end}}
+
+// ChatChunk is one event of a streamed chat completion
+type ChatChunk struct {
+	ID      string            ` + "`json:\"id\"`" + `
+	Model   string            ` + "`json:\"model\"`" + `
+	Choices []ChatChunkChoice ` + "`json:\"choices\"`" + `
+	Usage   *Usage            ` + "`json:\"usage,omitempty\"`" + `
+}
+
+// ChatChunkChoice is one choice's increment
+type ChatChunkChoice struct {
+	Index        int       ` + "`json:\"index\"`" + `
+	Delta        ChatDelta ` + "`json:\"delta\"`" + `
+	FinishReason string    ` + "`json:\"finish_reason\"`" + `
+}
+
+// ChatDelta is the text added by a ChatChunk
+type ChatDelta struct {
+	Role    string ` + "`json:\"role,omitempty\"`" + `
+	Content string ` + "`json:\"content,omitempty\"`" + `
+}
+
+// Chat sends a chat completion request
+func (c *Client) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	req.Stream = false
+	var out ChatResponse
+	if err := c.do(ctx, http.MethodPost, {{printf "%q" .ChatPath}}, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ChatStream sends a streamed chat completion request
+func (c *Client) ChatStream(ctx context.Context, req ChatRequest) (*Stream[ChatChunk], error) {
+	req.Stream = true
+	resp, err := c.send(ctx, http.MethodPost, {{printf "%q" .ChatPath}}, req)
+	if err != nil {
+		return nil, err
+	}
+	return newStream[ChatChunk](resp.Body), nil
+}
+{{- if .EmbedPath}}
+
+// EmbeddingsRequest asks for one embedding per input
+type EmbeddingsRequest struct {
+	Model string   ` + "`json:\"model\"`" + `
+	Input []string ` + "`json:\"input\"`" + `
+}
+{{- if not .InferredEmbed}}
+
+// EmbeddingsResponse holds embeddings by input index
+type EmbeddingsResponse struct {
+	Object string      ` + "`json:\"object\"`" + `
+	Data   []Embedding ` + "`json:\"data\"`" + `
+	Model  string      ` + "`json:\"model\"`" + `
+	Usage  Usage       ` + "`json:\"usage\"`" + `
+}
+
+// Embedding is one input's vector
+type Embedding struct {
+	Index     int       ` + "`json:\"index\"`" + `
+	Embedding []float64 ` + "`json:\"embedding\"`" + `
+}
+{{- end}}
+
+// Embeddings creates embeddings
+func (c *Client) Embeddings(ctx context.Context, req EmbeddingsRequest) (*EmbeddingsResponse, error) {
+	var out EmbeddingsResponse
+	if err := c.do(ctx, http.MethodPost, {{printf "%q" .EmbedPath}}, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+{{- end}}
+{{- end}}
+{{- if .Types}}
+
+{{.Types}}
+{{- end}}
+`
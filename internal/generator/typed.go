@@ -0,0 +1,488 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jeffersonwarrior/modelscan/internal/discovery"
+)
+
+// typedClientFile is the file GenerateTyped writes in a provider's package
+// directory
+const typedClientFile = "client.go"
+
+// typedView is what the typed client template renders
+type typedView struct {
+	Package      string
+	ProviderName string
+	BaseURL      string
+	AuthHeader   string
+	AuthPrefix   string // "Bearer " for bearer tokens
+	Anthropic    bool
+	Custom       bool
+
+	Models        []typedModel
+	ChatPath      string
+	EmbedPath     string // OpenAI-compatible only; empty without an embeddings endpoint
+	RequestFields []typedField
+	Endpoints     []typedEndpoint // Custom providers
+
+	// Response types derived from observed samples replace the standard
+	// declarations
+	InferredChat  bool
+	InferredEmbed bool
+	Types         string
+}
+
+type typedModel struct {
+	Const string
+	ID    string
+}
+
+type typedField struct {
+	Name string
+	Type string
+	JSON string
+}
+
+type typedEndpoint struct {
+	Method     string
+	HTTPMethod string
+	Path       string
+	Purpose    string
+	Response   string
+}
+
+// GenerateTyped writes a fully typed Go client for a provider to
+// OutputDir/<package>/client.go, where the package is named after the
+// provider ID. Unlike Generate's stubs it has request and response
+// structs (from req.Parameters and observed Endpoint.ResponseSample
+// bodies, else the provider type's standard shapes), a streaming helper
+// and an APIError type.
+func (g *Generator) GenerateTyped(req GenerateRequest) (*GenerateResult, error) {
+	result := &GenerateResult{Provider: req.ProviderID}
+
+	src, err := renderTyped(g.templates, req)
+	if err != nil {
+		result.Error = err
+		return result, err
+	}
+
+	dir := filepath.Join(g.outputDir, packageName(req.ProviderID))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		result.Error = fmt.Errorf("failed to create package directory: %w", err)
+		return result, result.Error
+	}
+	filePath := filepath.Join(dir, typedClientFile)
+	if err := os.WriteFile(filePath, src, 0644); err != nil {
+		result.Error = fmt.Errorf("failed to write file: %w", err)
+		return result, result.Error
+	}
+
+	result.FilePath = filePath
+	result.Success = true
+	return result, nil
+}
+
+// FromDiscovery builds a GenerateRequest from a discovery result
+func FromDiscovery(result *discovery.DiscoveryResult) GenerateRequest {
+	req := GenerateRequest{
+		ProviderID:   result.Provider.ID,
+		ProviderName: result.Provider.Name,
+		BaseURL:      result.Provider.BaseURL,
+		AuthMethod:   result.Provider.AuthMethod,
+		AuthHeader:   result.Provider.AuthHeader,
+		SDKType:      result.SDK.Type,
+	}
+	for _, e := range result.SDK.Endpoints {
+		req.Endpoints = append(req.Endpoints, Endpoint{Path: e.Path, Method: e.Method, Purpose: e.Purpose})
+	}
+	for _, m := range result.Models {
+		req.Models = append(req.Models, Model{ID: m.ID, Name: m.Name})
+	}
+	for key, p := range result.SDK.Parameters {
+		name := p.Name
+		if name == "" {
+			name = key
+		}
+		req.Parameters = append(req.Parameters, Parameter{Name: name, Type: p.Type, Required: p.Required})
+	}
+	sort.Slice(req.Parameters, func(i, j int) bool { return req.Parameters[i].Name < req.Parameters[j].Name })
+	return req
+}
+
+// renderTyped renders and gofmts the typed client for req
+func renderTyped(templates *Templates, req GenerateRequest) ([]byte, error) {
+	view, err := newTypedView(req)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := templates.Typed.Execute(&buf, view); err != nil {
+		return nil, fmt.Errorf("template execution failed: %w", err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code does not parse: %w", err)
+	}
+	return src, nil
+}
+
+// newTypedView derives the template data for req
+func newTypedView(req GenerateRequest) (*typedView, error) {
+	if req.ProviderID == "" {
+		return nil, fmt.Errorf("provider ID is required")
+	}
+	name := req.ProviderName
+	if name == "" {
+		name = req.ProviderID
+	}
+	v := &typedView{
+		Package:      packageName(req.ProviderID),
+		ProviderName: name,
+		BaseURL:      req.BaseURL,
+		Anthropic:    req.SDKType == "anthropic-compatible",
+		Custom:       req.SDKType == "custom",
+	}
+
+	v.AuthHeader = req.AuthHeader
+	if v.AuthHeader == "" {
+		switch {
+		case v.Anthropic:
+			v.AuthHeader = "x-api-key"
+		case req.AuthMethod == "api-key":
+			v.AuthHeader = "X-API-Key"
+		default:
+			v.AuthHeader = "Authorization"
+		}
+	}
+	if strings.EqualFold(v.AuthHeader, "Authorization") {
+		v.AuthPrefix = "Bearer "
+	}
+
+	consts := make(map[string]bool)
+	for _, m := range req.Models {
+		v.Models = append(v.Models, typedModel{Const: uniqueName("Model"+goName(m.ID), consts), ID: m.ID})
+	}
+
+	types := newSchemaBuilder()
+	switch {
+	case v.Custom:
+		methods := map[string]bool{"OpenStream": true}
+		for _, e := range req.Endpoints {
+			label := e.Purpose
+			if label == "" {
+				label = e.Path[strings.LastIndex(e.Path, "/")+1:]
+			}
+			method := uniqueName(goName(label), methods)
+			httpMethod := strings.ToUpper(e.Method)
+			if httpMethod == "" {
+				httpMethod = "POST"
+			}
+			response, err := types.response(method+"Response", e.ResponseSample)
+			if err != nil {
+				return nil, fmt.Errorf("endpoint %s: %w", e.Path, err)
+			}
+			v.Endpoints = append(v.Endpoints, typedEndpoint{
+				Method:     method,
+				HTTPMethod: httpMethod,
+				Path:       e.Path,
+				Purpose:    e.Purpose,
+				Response:   response,
+			})
+		}
+	default:
+		chat, embed := findEndpoint(req.Endpoints, "chat", "message", "completion"), findEndpoint(req.Endpoints, "embed")
+		responseName := "ChatResponse"
+		v.ChatPath = "/v1/chat/completions"
+		if v.Anthropic {
+			responseName = "MessageResponse"
+			v.ChatPath = "/v1/messages"
+		}
+		if chat != nil {
+			v.ChatPath = chat.Path
+			if len(chat.ResponseSample) > 0 {
+				if _, err := types.response(responseName, chat.ResponseSample); err != nil {
+					return nil, fmt.Errorf("chat endpoint: %w", err)
+				}
+				v.InferredChat = true
+			}
+		}
+		if embed != nil && !v.Anthropic {
+			v.EmbedPath = embed.Path
+			if len(embed.ResponseSample) > 0 {
+				if _, err := types.response("EmbeddingsResponse", embed.ResponseSample); err != nil {
+					return nil, fmt.Errorf("embeddings endpoint: %w", err)
+				}
+				v.InferredEmbed = true
+			}
+		}
+		v.RequestFields = requestFields(v.Anthropic, req.Parameters)
+	}
+	v.Types = types.String()
+	return v, nil
+}
+
+// findEndpoint returns the first endpoint whose purpose or path mentions
+// one of the words
+func findEndpoint(endpoints []Endpoint, words ...string) *Endpoint {
+	for i, e := range endpoints {
+		text := strings.ToLower(e.Purpose + " " + e.Path)
+		for _, w := range words {
+			if strings.Contains(text, w) {
+				return &endpoints[i]
+			}
+		}
+	}
+	return nil
+}
+
+// standardParameters are the optional request fields every typed client
+// has, whether or not discovery observed them
+var standardParameters = map[bool][]Parameter{
+	false: { // OpenAI-compatible
+		{Name: "max_tokens", Type: "integer"},
+		{Name: "stop", Type: "array"},
+		{Name: "temperature", Type: "number"},
+		{Name: "top_p", Type: "number"},
+	},
+	true: { // Anthropic-compatible
+		{Name: "stop_sequences", Type: "array"},
+		{Name: "temperature", Type: "number"},
+		{Name: "top_p", Type: "number"},
+	},
+}
+
+// fixedFields are declared by the template itself
+var fixedFields = map[bool]map[string]bool{
+	false: {"model": true, "messages": true, "stream": true},
+	true:  {"model": true, "messages": true, "stream": true, "system": true, "max_tokens": true},
+}
+
+// requestFields types the chat request parameters beyond the fixed ones
+func requestFields(anthropic bool, observed []Parameter) []typedField {
+	params := make(map[string]Parameter)
+	for _, p := range standardParameters[anthropic] {
+		params[p.Name] = p
+	}
+	for _, p := range observed {
+		if p.Name != "" && !strings.ContainsAny(p.Name, "`\"") {
+			params[p.Name] = p
+		}
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		if !fixedFields[anthropic][name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	used := map[string]bool{"Model": true, "Messages": true, "Stream": true, "System": true, "MaxTokens": anthropic}
+	fields := make([]typedField, 0, len(names))
+	for _, name := range names {
+		p := params[name]
+		goType := parameterType(p.Type)
+		tag := name
+		if !p.Required {
+			tag += ",omitempty"
+			if !strings.HasPrefix(goType, "[]") && !strings.HasPrefix(goType, "map[") && goType != "any" {
+				goType = "*" + goType
+			}
+		}
+		fields = append(fields, typedField{Name: uniqueName(goName(name), used), Type: goType, JSON: tag})
+	}
+	return fields
+}
+
+// parameterType maps a discovered parameter type to a Go type
+func parameterType(t string) string {
+	switch strings.ToLower(t) {
+	case "string":
+		return "string"
+	case "integer", "int":
+		return "int"
+	case "number", "float", "double":
+		return "float64"
+	case "boolean", "bool":
+		return "bool"
+	case "array":
+		return "[]string"
+	case "object":
+		return "map[string]any"
+	}
+	return "any"
+}
+
+// schemaBuilder derives struct declarations from sample JSON
+type schemaBuilder struct {
+	decls []string
+	names map[string]bool
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	// Names the template declares
+	names := make(map[string]bool)
+	for _, n := range []string{
+		"Client", "Option", "APIError", "Stream", "Message", "Usage", "ChatRequest",
+		"ChatChoice", "ChatChunk", "ChatChunkChoice", "ChatDelta", "EmbeddingsRequest",
+		"Embedding", "MessageRequest", "ContentBlock", "StreamEvent", "StreamDelta",
+	} {
+		names[n] = true
+	}
+	return &schemaBuilder{names: names}
+}
+
+// response declares the response type called name from an observed body,
+// or as raw JSON without one, and returns the name actually used
+func (b *schemaBuilder) response(name string, sample json.RawMessage) (string, error) {
+	name = uniqueName(name, b.names)
+	if len(sample) == 0 {
+		b.decls = append(b.decls, fmt.Sprintf("// %s is the raw JSON response; none was observed during discovery\ntype %s = json.RawMessage\n", name, name))
+		return name, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(sample))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return "", fmt.Errorf("invalid response sample: %w", err)
+	}
+	if obj, ok := value.(map[string]interface{}); ok {
+		b.structType(name, obj)
+		return name, nil
+	}
+	slot := len(b.decls)
+	b.decls = append(b.decls, "")
+	b.decls[slot] = fmt.Sprintf("// %s was derived from an observed response\ntype %s %s\n", name, name, b.typeOf(name+"Item", value))
+	return name, nil
+}
+
+// typeOf returns the Go type for a sample value, declaring structs for
+// objects under names derived from name
+func (b *schemaBuilder) typeOf(name string, v interface{}) string {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return b.structType(uniqueName(name, b.names), x)
+	case []interface{}:
+		if len(x) == 0 {
+			return "[]json.RawMessage"
+		}
+		return "[]" + b.typeOf(singular(name), x[0])
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case json.Number:
+		if _, err := x.Int64(); err == nil {
+			return "int64"
+		}
+		return "float64"
+	}
+	return "json.RawMessage" // null: type unknown
+}
+
+// structType declares a struct with one field per sample key, parents
+// before the structs nested in them
+func (b *schemaBuilder) structType(name string, obj map[string]interface{}) string {
+	b.names[name] = true
+	slot := len(b.decls)
+	b.decls = append(b.decls, "")
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		if k != "" && !strings.ContainsAny(k, "`\"") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var body strings.Builder
+	fields := make(map[string]bool)
+	for _, k := range keys {
+		field := uniqueName(goName(k), fields)
+		fmt.Fprintf(&body, "\t%s %s `json:\"%s,omitempty\"`\n", field, b.typeOf(name+field, obj[k]), k)
+	}
+	b.decls[slot] = fmt.Sprintf("// %s was derived from an observed response\ntype %s struct {\n%s}\n", name, name, body.String())
+	return name
+}
+
+func (b *schemaBuilder) String() string {
+	return strings.Join(b.decls, "\n")
+}
+
+// initialisms are kept upper case in Go names
+var initialisms = map[string]bool{
+	"api": true, "id": true, "url": true, "uri": true, "http": true,
+	"json": true, "ip": true, "uuid": true, "tts": true, "llm": true,
+}
+
+// goName turns an identifier like "prompt_tokens" or "gpt-4o" into an
+// exported Go name ("PromptTokens", "Gpt4o")
+func goName(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, p := range parts {
+		if initialisms[strings.ToLower(p)] {
+			b.WriteString(strings.ToUpper(p))
+			continue
+		}
+		r := []rune(p)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// packageName turns a provider ID into a Go package name
+func packageName(id string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(id) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	name := b.String()
+	if name == "" || name[0] < 'a' {
+		name = "p" + name
+	}
+	return name
+}
+
+// singular names an array's element type
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "ss"):
+		return name + "Item"
+	case strings.HasSuffix(name, "s"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return name + "Item"
+}
+
+// uniqueName returns name, or name with a numeric suffix if taken, and
+// marks it taken
+func uniqueName(name string, taken map[string]bool) string {
+	candidate := name
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	taken[candidate] = true
+	return candidate
+}
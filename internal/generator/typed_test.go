@@ -0,0 +1,197 @@
+package generator
+
+import (
+	"encoding/json"
+	"flag"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/discovery"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// typedFixtures are the requests behind the golden files
+var typedFixtures = map[string]GenerateRequest{
+	"openai": {
+		ProviderID:   "acme-ai",
+		ProviderName: "Acme",
+		BaseURL:      "https://api.acme.ai",
+		SDKType:      "openai-compatible",
+		Endpoints: []Endpoint{
+			{Path: "/v1/chat/completions", Method: "POST", Purpose: "chat"},
+			{Path: "/v1/embeddings", Method: "POST", Purpose: "embeddings",
+				ResponseSample: json.RawMessage(`{"data":[{"embedding":[0.1,0.2],"index":0}],"model":"acme-embed","usage":{"prompt_tokens":3}}`)},
+		},
+		Models: []Model{{ID: "acme-large-2"}, {ID: "acme-embed"}},
+		Parameters: []Parameter{
+			{Name: "seed", Type: "integer"},
+			{Name: "response_format", Type: "object"},
+			{Name: "safe_mode", Type: "boolean", Required: true},
+		},
+	},
+	"anthropic": {
+		ProviderID:   "claudeish",
+		ProviderName: "Claudeish",
+		BaseURL:      "https://api.claudeish.dev",
+		SDKType:      "anthropic-compatible",
+		Models:       []Model{{ID: "claudeish-3.5"}},
+		Parameters:   []Parameter{{Name: "top_k", Type: "integer"}},
+	},
+	"custom": {
+		ProviderID:   "imagegen",
+		ProviderName: "ImageGen",
+		BaseURL:      "https://imagegen.example.com",
+		AuthMethod:   "api-key",
+		SDKType:      "custom",
+		Endpoints: []Endpoint{
+			{Path: "/v2/images", Method: "post", Purpose: "image generation",
+				ResponseSample: json.RawMessage(`{"id":"img_1","status":"queued","images":[{"url":"https://x","width":1024}],"meta":null}`)},
+			{Path: "/v2/jobs", Method: "GET"},
+		},
+	},
+}
+
+func TestGenerateTyped_Golden(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+
+	for name, req := range typedFixtures {
+		t.Run(name, func(t *testing.T) {
+			src, err := renderTyped(templates, req)
+			if err != nil {
+				t.Fatalf("renderTyped: %v", err)
+			}
+			typecheck(t, src)
+
+			golden := filepath.Join("testdata", "typed_"+name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, src, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run go test -update): %v", err)
+			}
+			if string(src) != string(want) {
+				t.Errorf("generated code differs from %s (run go test -update to accept):\n%s", golden, src)
+			}
+		})
+	}
+}
+
+// stdlib imports standard library packages from source, shared so each
+// package is only type-checked once
+var (
+	fset   = token.NewFileSet()
+	stdlib = importer.ForCompiler(fset, "source", nil)
+)
+
+// typecheck fails the test unless src compiles against the standard library
+func typecheck(t *testing.T, src []byte) {
+	t.Helper()
+	file, err := parser.ParseFile(fset, "client.go", src, 0)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	conf := types.Config{Importer: stdlib}
+	if _, err := conf.Check(file.Name.Name, fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("generated code does not compile: %v\n%s", err, src)
+	}
+}
+
+func TestGenerateTyped_WritesPackage(t *testing.T) {
+	gen, err := NewGenerator(Config{OutputDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewGenerator: %v", err)
+	}
+
+	result, err := gen.GenerateTyped(typedFixtures["openai"])
+	if err != nil || !result.Success {
+		t.Fatalf("GenerateTyped: %v", err)
+	}
+	if want := filepath.Join(gen.outputDir, "acmeai", "client.go"); result.FilePath != want {
+		t.Errorf("FilePath = %s, want %s", result.FilePath, want)
+	}
+
+	files, err := gen.List()
+	if err != nil || len(files) != 1 || files[0] != filepath.Join("acmeai", "client.go") {
+		t.Errorf("List = %v, %v", files, err)
+	}
+
+	if err := gen.Delete("acme-ai"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(result.FilePath)); !os.IsNotExist(err) {
+		t.Error("typed package was not deleted")
+	}
+	if err := gen.Delete("acme-ai"); err == nil {
+		t.Error("expected error deleting a missing provider")
+	}
+}
+
+func TestGenerateTyped_InvalidSample(t *testing.T) {
+	gen, err := NewGenerator(Config{OutputDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewGenerator: %v", err)
+	}
+	req := GenerateRequest{
+		ProviderID: "broken",
+		SDKType:    "custom",
+		Endpoints:  []Endpoint{{Path: "/x", ResponseSample: json.RawMessage(`{"a":`)}},
+	}
+	if result, err := gen.GenerateTyped(req); err == nil || result.Success {
+		t.Error("expected error for an invalid response sample")
+	}
+}
+
+func TestFromDiscovery(t *testing.T) {
+	req := FromDiscovery(&discovery.DiscoveryResult{
+		Provider: discovery.ProviderInfo{ID: "acme", Name: "Acme", BaseURL: "https://api.acme.ai", AuthMethod: "bearer"},
+		Models:   []discovery.ModelInfo{{ID: "acme-1", Name: "Acme 1"}},
+		SDK: discovery.SDKInfo{
+			Type:       "openai-compatible",
+			Endpoints:  []discovery.EndpointInfo{{Path: "/v1/chat/completions", Method: "POST", Purpose: "chat"}},
+			Parameters: map[string]discovery.ParameterInfo{"temperature": {Type: "number"}, "seed": {Name: "seed", Type: "integer"}},
+		},
+	})
+
+	if req.ProviderID != "acme" || req.SDKType != "openai-compatible" || len(req.Models) != 1 || len(req.Endpoints) != 1 {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if len(req.Parameters) != 2 || req.Parameters[0].Name != "seed" || req.Parameters[1].Name != "temperature" {
+		t.Errorf("Parameters = %+v", req.Parameters)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"prompt_tokens": "PromptTokens",
+		"id":            "ID",
+		"image_url":     "ImageURL",
+		"gpt-4o-mini":   "Gpt4oMini",
+		"3d":            "X3d",
+		"":              "X",
+	}
+	for in, want := range tests {
+		if got := goName(in); got != want {
+			t.Errorf("goName(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := packageName("Acme-AI.v2"); got != "acmeaiv2" {
+		t.Errorf("packageName = %q", got)
+	}
+	if !strings.HasPrefix(packageName("42"), "p") {
+		t.Error("package name must not start with a digit")
+	}
+}
@@ -0,0 +1,380 @@
+package gitops
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// Operations a plan performs on a resource
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Change is one operation of a plan
+type Change struct {
+	Op     string
+	Kind   string
+	Name   string
+	Detail string // Changed fields, as "field: old -> new"
+
+	apply func() (note string, err error)
+}
+
+// Plan is the set of changes that makes the database match the declared
+// resources. Deletes come first, dependents before what they depend on;
+// creates and updates follow in Kinds order.
+type Plan struct {
+	Changes []*Change
+}
+
+// Empty reports whether the database already matches
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Count returns the number of changes performing op
+func (p *Plan) Count(op string) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Op == op {
+			n++
+		}
+	}
+	return n
+}
+
+// Apply performs the plan's changes in order, calling done after each
+// with any note it produced, such as a new client's token. It stops at
+// the first failure; changes already made are kept, and planning again
+// picks up from there.
+func (p *Plan) Apply(done func(c *Change, note string)) error {
+	for _, c := range p.Changes {
+		note, err := c.apply()
+		if err != nil {
+			return fmt.Errorf("%s %s %s: %w", c.Op, c.Kind, c.Name, err)
+		}
+		if done != nil {
+			done(c, note)
+		}
+	}
+	return nil
+}
+
+// planner accumulates a plan's deletes and upserts separately, so deletes
+// can run first
+type planner struct {
+	db      *database.DB
+	res     *Resources
+	deletes map[string][]*Change // By kind
+	upserts []*Change
+}
+
+func (p *planner) upsert(op, kind, name string, apply func() (string, error), detail ...string) {
+	p.upserts = append(p.upserts, &Change{Op: op, Kind: kind, Name: name, Detail: strings.Join(detail, "; "), apply: apply})
+}
+
+func (p *planner) delete(kind, name string, apply func() error, detail string) {
+	if !p.res.Manages(kind) {
+		return
+	}
+	p.deletes[kind] = append(p.deletes[kind], &Change{Op: OpDelete, Kind: kind, Name: name, Detail: detail,
+		apply: func() (string, error) { return "", apply() }})
+}
+
+// NewPlan compares the declared resources with the database
+func NewPlan(db *database.DB, res *Resources) (*Plan, error) {
+	p := &planner{db: db, res: res, deletes: make(map[string][]*Change)}
+	for _, step := range []func() error{p.tenants, p.providers, p.clients, p.aliases, p.remapRules} {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+
+	plan := &Plan{}
+	for _, kind := range slices.Backward(Kinds) {
+		plan.Changes = append(plan.Changes, p.deletes[kind]...)
+	}
+	plan.Changes = append(plan.Changes, p.upserts...)
+	return plan, nil
+}
+
+// fieldDiff is a field's current and declared values
+type fieldDiff struct {
+	name     string
+	old, new interface{}
+}
+
+func field(name string, old, new interface{}) fieldDiff {
+	return fieldDiff{name, old, new}
+}
+
+// changed describes the fields that differ, as "field: old -> new"
+func changed(fields ...fieldDiff) []string {
+	var changes []string
+	for _, f := range fields {
+		old, new := fmt.Sprint(f.old), fmt.Sprint(f.new)
+		if old != new {
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", f.name, old, new))
+		}
+	}
+	return changes
+}
+
+func (p *planner) tenants() error {
+	repo := database.NewTenantRepository(p.db)
+	current, err := repo.List()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]*database.Tenant, len(current))
+	for _, t := range current {
+		existing[t.ID] = t
+	}
+
+	for _, t := range p.res.Tenants {
+		want := &database.Tenant{ID: t.ID, Name: t.Name, MonthlyBudget: t.MonthlyBudget, RPMLimit: t.RPMLimit, TPMLimit: t.TPMLimit}
+		cur, ok := existing[t.ID]
+		delete(existing, t.ID)
+		if !ok {
+			p.upsert(OpCreate, KindTenant, t.ID, func() (string, error) { return "", repo.Create(want) })
+			continue
+		}
+		if changes := changed(
+			field("name", cur.Name, t.Name),
+			field("monthly_budget", cur.MonthlyBudget, t.MonthlyBudget),
+			field("rpm_limit", cur.RPMLimit, t.RPMLimit),
+			field("tpm_limit", cur.TPMLimit, t.TPMLimit),
+		); len(changes) > 0 {
+			p.upsert(OpUpdate, KindTenant, t.ID, func() (string, error) { return "", repo.Update(want) }, changes...)
+		}
+	}
+	for _, t := range current {
+		if _, undeclared := existing[t.ID]; undeclared {
+			p.delete(KindTenant, t.ID, func() error { return repo.Delete(t.ID) }, "also deletes its API keys; its clients become shared")
+		}
+	}
+	return nil
+}
+
+func (p *planner) providers() error {
+	current, err := p.db.ListProviders()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]*database.Provider, len(current))
+	for _, pr := range current {
+		existing[pr.ID] = pr
+	}
+
+	for _, pr := range p.res.Providers {
+		want := &database.Provider{
+			ID: pr.ID, Name: pr.Name, BaseURL: pr.BaseURL, AuthMethod: pr.AuthMethod,
+			AuthHeader: nullable(pr.AuthHeader), PricingModel: pr.PricingModel, Status: "offline",
+		}
+		cur, ok := existing[pr.ID]
+		delete(existing, pr.ID)
+		if !ok {
+			p.upsert(OpCreate, KindProvider, pr.ID, func() (string, error) { return "", p.db.CreateProvider(want) })
+			continue
+		}
+		if changes := changed(
+			field("name", cur.Name, pr.Name),
+			field("base_url", cur.BaseURL, pr.BaseURL),
+			field("auth_method", cur.AuthMethod, pr.AuthMethod),
+			field("auth_header", deref(cur.AuthHeader), pr.AuthHeader),
+			field("pricing_model", cur.PricingModel, pr.PricingModel),
+		); len(changes) > 0 {
+			p.upsert(OpUpdate, KindProvider, pr.ID, func() (string, error) { return "", p.db.UpdateProvider(want) }, changes...)
+		}
+	}
+	for _, pr := range current {
+		if _, undeclared := existing[pr.ID]; undeclared {
+			p.delete(KindProvider, pr.ID, func() error { return p.db.DeleteProvider(pr.ID) }, "also deletes its models and API keys")
+		}
+	}
+	return nil
+}
+
+func (p *planner) clients() error {
+	repo := database.NewClientRepository(p.db)
+	tenants := database.NewTenantRepository(p.db)
+	current, err := repo.List()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]*database.Client, len(current))
+	for _, c := range current {
+		existing[c.ID] = c
+	}
+
+	for _, c := range p.res.Clients {
+		cur, ok := existing[c.ID]
+		delete(existing, c.ID)
+		if !ok {
+			p.upsert(OpCreate, KindClient, c.ID, func() (string, error) {
+				token, err := generateToken()
+				if err != nil {
+					return "", err
+				}
+				client := &database.Client{ID: c.ID, Name: c.Name, Token: token, Capabilities: capabilities(c.Capabilities), Config: c.Config, CreatedAt: time.Now()}
+				if err := repo.Create(client); err != nil {
+					return "", err
+				}
+				if c.Tenant != "" {
+					if err := tenants.AssignClient(c.ID, c.Tenant); err != nil {
+						return "", err
+					}
+				}
+				return "token " + token, nil
+			})
+			continue
+		}
+
+		tenant, err := tenants.TenantForClient(c.ID)
+		if err != nil {
+			return err
+		}
+		changes := changed(
+			field("name", cur.Name, c.Name),
+			field("tenant", tenant, c.Tenant),
+			field("capabilities", strings.Join(cur.Capabilities, ","), strings.Join(c.Capabilities, ",")),
+			field("config", fmt.Sprintf("%+v", cur.Config), fmt.Sprintf("%+v", c.Config)),
+		)
+		if len(changes) == 0 {
+			continue
+		}
+		p.upsert(OpUpdate, KindClient, c.ID, func() (string, error) {
+			cur.Name, cur.Capabilities, cur.Config = c.Name, capabilities(c.Capabilities), c.Config
+			if err := repo.Update(cur); err != nil {
+				return "", err
+			}
+			return "", tenants.AssignClient(c.ID, c.Tenant)
+		}, changes...)
+	}
+	for _, c := range current {
+		if _, undeclared := existing[c.ID]; undeclared {
+			p.delete(KindClient, c.ID, func() error { return repo.Delete(c.ID) }, "revokes its token and deletes its aliases, rules and limits")
+		}
+	}
+	return nil
+}
+
+func (p *planner) aliases() error {
+	current, err := p.db.ListAllAliases()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]*database.Alias, len(current))
+	for _, a := range current {
+		existing[(&Alias{Name: a.Name, Client: deref(a.ClientID)}).key()] = a
+	}
+
+	for _, a := range p.res.Aliases {
+		cur, ok := existing[a.key()]
+		delete(existing, a.key())
+		switch {
+		case !ok:
+			p.upsert(OpCreate, KindAlias, a.key(), func() (string, error) {
+				return "", p.db.CreateAlias(&database.Alias{Name: a.Name, ModelID: a.Model, ClientID: nullable(a.Client)})
+			})
+		case cur.ModelID != a.Model:
+			p.upsert(OpUpdate, KindAlias, a.key(), func() (string, error) {
+				return "", p.db.UpdateAlias(a.Name, nullable(a.Client), a.Model)
+			}, changed(field("model", cur.ModelID, a.Model))...)
+		}
+	}
+	for _, a := range current {
+		key := (&Alias{Name: a.Name, Client: deref(a.ClientID)}).key()
+		if _, undeclared := existing[key]; undeclared {
+			p.delete(KindAlias, key, func() error { return p.db.DeleteAlias(a.Name, a.ClientID) }, "")
+		}
+	}
+	return nil
+}
+
+func (p *planner) remapRules() error {
+	repo := database.NewRemapRuleRepository(p.db)
+	current, err := repo.List(nil)
+	if err != nil {
+		return err
+	}
+	// Rules sharing an identity are duplicates; the first is kept
+	existing := make(map[string]*database.RemapRule, len(current))
+	for _, r := range current {
+		key := remapKey(r.ClientID, r.FromModel, r.Priority)
+		if _, dup := existing[key]; dup {
+			p.delete(KindRemapRule, fmt.Sprintf("%s (#%d)", key, r.ID), func() error { return repo.Delete(r.ID) }, "duplicate rule")
+			continue
+		}
+		existing[key] = r
+	}
+
+	for _, r := range p.res.RemapRules {
+		want := &database.RemapRule{
+			ClientID: r.Client, FromModel: r.FromModel, ToModel: r.ToModel, ToProvider: r.ToProvider,
+			Strategy: r.Strategy, Candidates: r.Candidates, Priority: r.Priority, Enabled: *r.Enabled,
+		}
+		cur, ok := existing[r.key()]
+		delete(existing, r.key())
+		if !ok {
+			p.upsert(OpCreate, KindRemapRule, r.key(), func() (string, error) { return "", repo.Create(want) })
+			continue
+		}
+		if changes := changed(
+			field("to_model", cur.ToModel, r.ToModel),
+			field("to_provider", cur.ToProvider, r.ToProvider),
+			field("strategy", cur.Strategy, r.Strategy),
+			field("candidates", strings.Join(cur.Candidates, ","), strings.Join(r.Candidates, ",")),
+			field("enabled", cur.Enabled, *r.Enabled),
+		); len(changes) > 0 {
+			want.ID = cur.ID
+			p.upsert(OpUpdate, KindRemapRule, r.key(), func() (string, error) { return "", repo.Update(want) }, changes...)
+		}
+	}
+	for _, r := range current {
+		key := remapKey(r.ClientID, r.FromModel, r.Priority)
+		if existing[key] == r {
+			p.delete(KindRemapRule, key, func() error { return repo.Delete(r.ID) }, "")
+		}
+	}
+	return nil
+}
+
+// capabilities stores a client without capabilities as [] rather than null
+func capabilities(c []string) []string {
+	if c == nil {
+		return []string{}
+	}
+	return c
+}
+
+// generateToken creates a client token, as client registration does
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
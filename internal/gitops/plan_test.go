@@ -0,0 +1,160 @@
+package gitops
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+const testResources = `kind: Tenant
+id: team-a
+name: Team A
+monthly_budget: 250
+---
+kind: Provider
+id: groq
+name: Groq
+base_url: https://api.groq.com/openai/v1
+auth_method: bearer
+pricing_model: usage
+---
+kind: Client
+id: checkout
+name: Checkout
+tenant: team-a
+config:
+  default_model: fast
+`
+
+const testRouting = `kind: Alias
+name: fast
+model: llama-3.3-70b
+---
+kind: RemapRule
+client: checkout
+from_model: gpt-4*
+to_model: llama-3.3-70b
+to_provider: groq
+priority: 10
+`
+
+func writeConfigDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func planFor(t *testing.T, db *database.DB, dir string) *Plan {
+	t.Helper()
+	res, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	plan, err := NewPlan(db, res)
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+	return plan
+}
+
+func TestPlanApply(t *testing.T) {
+	db, err := database.Open(filepath.Join(t.TempDir(), "gitops.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	db.CreateProvider(&database.Provider{ID: "openai", Name: "OpenAI", BaseURL: "https://api.openai.com", AuthMethod: "bearer", PricingModel: "usage"})
+
+	dir := writeConfigDir(t, map[string]string{"resources.yaml": testResources, "routing.yml": testRouting})
+	plan := planFor(t, db, dir)
+
+	// The seeded aliases are not declared, so managing aliases deletes
+	// them; the discovered openai provider goes the same way
+	if plan.Count(OpCreate) != 5 || plan.Count(OpDelete) != len(database.DefaultAliases)+1 || plan.Count(OpUpdate) != 0 {
+		for _, c := range plan.Changes {
+			t.Logf("%s %s %s", c.Op, c.Kind, c.Name)
+		}
+		t.Fatalf("unexpected plan")
+	}
+	if plan.Changes[0].Op != OpDelete || plan.Changes[len(plan.Changes)-1].Kind != KindRemapRule {
+		t.Errorf("expected deletes first and remap rules created last")
+	}
+
+	var token string
+	if err := plan.Apply(func(c *Change, note string) {
+		if c.Kind == KindClient {
+			token = strings.TrimPrefix(note, "token ")
+		}
+	}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	client, err := database.NewClientRepository(db).GetByToken(token)
+	if err != nil || client == nil || client.Config.DefaultModel != "fast" {
+		t.Fatalf("expected the client's token to authenticate it, got %+v, %v", client, err)
+	}
+	if tenant, _ := database.NewTenantRepository(db).TenantForClient("checkout"); tenant != "team-a" {
+		t.Errorf("expected checkout in team-a, got %q", tenant)
+	}
+
+	if plan := planFor(t, db, dir); !plan.Empty() {
+		t.Errorf("expected nothing left to do, got %d changes", len(plan.Changes))
+	}
+
+	// Editing the files updates and deletes
+	dir = writeConfigDir(t, map[string]string{
+		"resources.yaml": strings.Replace(testResources, "monthly_budget: 250", "monthly_budget: 500", 1),
+		"routing.yml":    strings.Replace(testRouting, "model: llama-3.3-70b\n---", "model: llama-3.1-8b\n---", 1) + "\n---\nkind: Alias\nname: smart\nmodel: gpt-4o\n",
+	})
+	plan = planFor(t, db, dir)
+	if plan.Count(OpUpdate) != 2 || plan.Count(OpCreate) != 1 || plan.Count(OpDelete) != 0 {
+		for _, c := range plan.Changes {
+			t.Logf("%s %s %s %s", c.Op, c.Kind, c.Name, c.Detail)
+		}
+		t.Fatalf("unexpected plan")
+	}
+	if err := plan.Apply(nil); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if tenant, _ := database.NewTenantRepository(db).Get("team-a"); tenant.MonthlyBudget != 500 {
+		t.Errorf("expected the budget to be raised, got %v", tenant.MonthlyBudget)
+	}
+
+	// Kinds the directory does not declare are left alone
+	dir = writeConfigDir(t, map[string]string{"aliases.yaml": "kind: Alias\nname: fast\nmodel: llama-3.1-8b\n"})
+	plan = planFor(t, db, dir)
+	if len(plan.Changes) != 1 || plan.Changes[0].Op != OpDelete || plan.Changes[0].Name != "smart" {
+		t.Errorf("expected only the smart alias to be deleted, got %+v", plan.Changes)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"missing kind", "name: fast\nmodel: x\n", "missing kind"},
+		{"unknown kind", "kind: Budget\nid: a\n", "unknown kind"},
+		{"invalid alias", "kind: Alias\nname: fast\n", "needs a name and a model"},
+		{"invalid rule", "kind: RemapRule\nfrom_model: gpt-4*\nstrategy: cheapest\n", "candidates"},
+		{"duplicate", "kind: Alias\nname: fast\nmodel: a\n---\nkind: Alias\nname: fast\nmodel: b\n", "already declared"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfigDir(t, map[string]string{"config.yaml": tt.content}))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Error("expected an empty directory to be rejected")
+	}
+}
@@ -0,0 +1,292 @@
+// Package gitops reconciles declarative YAML resources against the
+// database, so an instance's configuration can live and be reviewed in git.
+//
+// A configuration directory holds YAML files, each with one or more
+// documents naming their kind:
+//
+//	kind: Alias
+//	name: fast
+//	model: llama-3.3-70b
+//
+// Resources are matched to database rows by identity (an ID, or a name and
+// client) and created, updated or deleted to match. A kind is only managed,
+// and so pruned of undeclared rows, once the directory declares at least
+// one resource of it.
+package gitops
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// Resource kinds
+const (
+	KindTenant    = "Tenant"    // Budgets and rate limits shared by a team's clients
+	KindProvider  = "Provider"  // Upstream LLM providers
+	KindClient    = "Client"    // Virtual keys: the tokens applications authenticate with
+	KindAlias     = "Alias"     // Model aliases
+	KindRemapRule = "RemapRule" // Routing policies
+)
+
+// Kinds lists the resource kinds in the order they are created and
+// updated; deletes run in reverse so nothing is deleted while still in use
+var Kinds = []string{KindTenant, KindProvider, KindClient, KindAlias, KindRemapRule}
+
+// Tenant declares a team's monthly budget and rate limits; zero means
+// unlimited
+type Tenant struct {
+	ID            string  `yaml:"id"`
+	Name          string  `yaml:"name"`
+	MonthlyBudget float64 `yaml:"monthly_budget"`
+	RPMLimit      int     `yaml:"rpm_limit"`
+	TPMLimit      int     `yaml:"tpm_limit"`
+}
+
+// Provider declares an upstream provider's static configuration
+type Provider struct {
+	ID           string `yaml:"id"`
+	Name         string `yaml:"name"`
+	BaseURL      string `yaml:"base_url"`
+	AuthMethod   string `yaml:"auth_method"`
+	AuthHeader   string `yaml:"auth_header"`
+	PricingModel string `yaml:"pricing_model"`
+}
+
+// Client declares a virtual key. Its token is generated when the client
+// is created and never appears in the configuration.
+type Client struct {
+	ID           string                `yaml:"id"`
+	Name         string                `yaml:"name"`
+	Tenant       string                `yaml:"tenant"`
+	Capabilities []string              `yaml:"capabilities"`
+	Config       database.ClientConfig `yaml:"config"`
+}
+
+// Alias declares a model alias; Client is empty for a global alias
+type Alias struct {
+	Name   string `yaml:"name"`
+	Model  string `yaml:"model"`
+	Client string `yaml:"client"`
+}
+
+func (a *Alias) key() string {
+	if a.Client == "" {
+		return a.Name
+	}
+	return a.Client + ":" + a.Name
+}
+
+// RemapRule declares a routing policy, identified by its client,
+// from_model and priority. Enabled defaults to true.
+type RemapRule struct {
+	Client     string   `yaml:"client"`
+	FromModel  string   `yaml:"from_model"`
+	ToModel    string   `yaml:"to_model"`
+	ToProvider string   `yaml:"to_provider"`
+	Strategy   string   `yaml:"strategy"`
+	Candidates []string `yaml:"candidates"`
+	Priority   int      `yaml:"priority"`
+	Enabled    *bool    `yaml:"enabled"`
+}
+
+func (r *RemapRule) key() string {
+	return remapKey(r.Client, r.FromModel, r.Priority)
+}
+
+func remapKey(client, fromModel string, priority int) string {
+	key := fmt.Sprintf("%s@%d", fromModel, priority)
+	if client != "" {
+		key = client + ":" + key
+	}
+	return key
+}
+
+// Resources is the desired state declared by a configuration directory
+type Resources struct {
+	Tenants    []*Tenant
+	Providers  []*Provider
+	Clients    []*Client
+	Aliases    []*Alias
+	RemapRules []*RemapRule
+
+	sources map[string]string // kind/key -> file that declared it
+}
+
+// Manages reports whether the resources declare any of kind, which puts
+// that kind's undeclared rows up for deletion
+func (r *Resources) Manages(kind string) bool {
+	switch kind {
+	case KindTenant:
+		return len(r.Tenants) > 0
+	case KindProvider:
+		return len(r.Providers) > 0
+	case KindClient:
+		return len(r.Clients) > 0
+	case KindAlias:
+		return len(r.Aliases) > 0
+	case KindRemapRule:
+		return len(r.RemapRules) > 0
+	}
+	return false
+}
+
+// Load reads every .yaml and .yml file under dir, in lexical order
+func Load(dir string) (*Resources, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ext := filepath.Ext(path); !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config directory: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .yaml or .yml files in %s", dir)
+	}
+	slices.Sort(files)
+
+	res := &Resources{sources: make(map[string]string)}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := res.parse(file, data); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// parse adds the documents of one file
+func (r *Resources) parse(file string, data []byte) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for doc := 1; ; doc++ {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if len(node.Content) == 0 {
+			continue // Empty document
+		}
+		kind, key, err := r.add(&node)
+		if err != nil {
+			return fmt.Errorf("%s, document %d: %w", file, doc, err)
+		}
+		if prev, dup := r.sources[kind+"/"+key]; dup {
+			return fmt.Errorf("%s, document %d: %s %s is already declared in %s", file, doc, kind, key, prev)
+		}
+		r.sources[kind+"/"+key] = file
+	}
+}
+
+// add decodes a document by its kind and validates it, returning the
+// kind and the resource's identity
+func (r *Resources) add(node *yaml.Node) (string, string, error) {
+	var meta struct {
+		Kind string `yaml:"kind"`
+	}
+	if err := node.Decode(&meta); err != nil {
+		return "", "", err
+	}
+
+	switch meta.Kind {
+	case KindTenant:
+		var t Tenant
+		if err := node.Decode(&t); err != nil {
+			return "", "", err
+		}
+		if t.ID == "" || t.Name == "" {
+			return "", "", fmt.Errorf("tenant needs an id and a name")
+		}
+		r.Tenants = append(r.Tenants, &t)
+		return meta.Kind, t.ID, nil
+	case KindProvider:
+		var p Provider
+		if err := node.Decode(&p); err != nil {
+			return "", "", err
+		}
+		if p.ID == "" || p.Name == "" || p.BaseURL == "" {
+			return "", "", fmt.Errorf("provider needs an id, a name and a base_url")
+		}
+		r.Providers = append(r.Providers, &p)
+		return meta.Kind, p.ID, nil
+	case KindClient:
+		var c Client
+		if err := node.Decode(&c); err != nil {
+			return "", "", err
+		}
+		if c.ID == "" || c.Name == "" {
+			return "", "", fmt.Errorf("client needs an id and a name")
+		}
+		r.Clients = append(r.Clients, &c)
+		return meta.Kind, c.ID, nil
+	case KindAlias:
+		var a Alias
+		if err := node.Decode(&a); err != nil {
+			return "", "", err
+		}
+		if a.Name == "" || a.Model == "" {
+			return "", "", fmt.Errorf("alias needs a name and a model")
+		}
+		r.Aliases = append(r.Aliases, &a)
+		return meta.Kind, a.key(), nil
+	case KindRemapRule:
+		var rule RemapRule
+		if err := node.Decode(&rule); err != nil {
+			return "", "", err
+		}
+		if err := rule.validate(); err != nil {
+			return "", "", err
+		}
+		r.RemapRules = append(r.RemapRules, &rule)
+		return meta.Kind, rule.key(), nil
+	case "":
+		return "", "", fmt.Errorf("missing kind")
+	default:
+		return "", "", fmt.Errorf("unknown kind %q (want one of %s)", meta.Kind, strings.Join(Kinds, ", "))
+	}
+}
+
+// validate checks the strategy-specific required fields and defaults
+// Strategy and Enabled
+func (r *RemapRule) validate() error {
+	if r.Strategy == "" {
+		r.Strategy = database.RemapStrategyDirect
+	}
+	if r.Enabled == nil {
+		enabled := true
+		r.Enabled = &enabled
+	}
+	switch {
+	case r.FromModel == "":
+		return fmt.Errorf("remap rule needs a from_model")
+	case r.Strategy == database.RemapStrategyDirect && (r.ToModel == "" || r.ToProvider == ""):
+		return fmt.Errorf("remap rule %s needs a to_model and a to_provider", r.key())
+	case r.Strategy != database.RemapStrategyDirect && r.Strategy != database.RemapStrategyCheapest && r.Strategy != database.RemapStrategyFastest:
+		return fmt.Errorf("remap rule %s: strategy must be direct, cheapest or fastest", r.key())
+	case r.Strategy != database.RemapStrategyDirect && len(r.Candidates) == 0:
+		return fmt.Errorf("remap rule %s needs candidates for the %s strategy", r.key(), r.Strategy)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RegexGuard flags text matching any of a set of named patterns
+type RegexGuard struct {
+	names    []string
+	patterns []*regexp.Regexp
+}
+
+// NewRegexGuard compiles a denylist of patterns keyed by detector name
+func NewRegexGuard(patterns map[string]string) (*RegexGuard, error) {
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("at least one pattern is required")
+	}
+
+	g := &RegexGuard{}
+	for _, name := range sortedKeys(patterns) {
+		re, err := regexp.Compile(patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", name, err)
+		}
+		g.names = append(g.names, name)
+		g.patterns = append(g.patterns, re)
+	}
+	return g, nil
+}
+
+// Inspect implements Guard
+func (g *RegexGuard) Inspect(ctx context.Context, text string) ([]Match, error) {
+	var matches []Match
+	for i, re := range g.patterns {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if loc[1] > loc[0] {
+				matches = append(matches, Match{Detector: g.names[i], Start: loc[0], End: loc[1]})
+			}
+		}
+	}
+	return matches, nil
+}
+
+// PII detector names
+const (
+	PIIEmail      = "email"
+	PIIPhone      = "phone"
+	PIISSN        = "ssn"
+	PIICreditCard = "credit_card"
+	PIIIPAddress  = "ip_address"
+)
+
+var piiPatterns = map[string]*regexp.Regexp{
+	PIIEmail:      regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	PIIPhone:      regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{3}\)|\b\d{3})[\s.\-]\d{3}[\s.\-]\d{4}\b`),
+	PIISSN:        regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+	PIICreditCard: regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+	PIIIPAddress:  regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`),
+}
+
+// PIIGuard flags personal data: emails, phone numbers, SSNs, card numbers and IP addresses
+type PIIGuard struct {
+	kinds []string
+}
+
+// NewPIIGuard creates a PII detector for the given kinds; none means all
+func NewPIIGuard(kinds ...string) (*PIIGuard, error) {
+	if len(kinds) == 0 {
+		kinds = []string{PIIEmail, PIIPhone, PIISSN, PIICreditCard, PIIIPAddress}
+	}
+	for _, k := range kinds {
+		if _, ok := piiPatterns[k]; !ok {
+			return nil, fmt.Errorf("unknown PII kind %q", k)
+		}
+	}
+	return &PIIGuard{kinds: kinds}, nil
+}
+
+// Inspect implements Guard
+func (g *PIIGuard) Inspect(ctx context.Context, text string) ([]Match, error) {
+	var matches []Match
+	for _, kind := range g.kinds {
+		for _, loc := range piiPatterns[kind].FindAllStringIndex(text, -1) {
+			// Card-like digit runs only count when they pass the Luhn check
+			if kind == PIICreditCard && !luhnValid(text[loc[0]:loc[1]]) {
+				continue
+			}
+			matches = append(matches, Match{Detector: kind, Start: loc[0], End: loc[1]})
+		}
+	}
+	return matches, nil
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// defaultProfanity is a small starter list; deployments usually supply their own
+var defaultProfanity = []string{
+	"fuck", "motherfucker", "shit", "bullshit", "bitch", "bastard",
+	"asshole", "cunt", "dickhead", "wanker",
+}
+
+// ProfanityGuard flags whole words from a word list, case-insensitively.
+// Common suffixes (s, es, ed, er, ing) are matched as well.
+type ProfanityGuard struct {
+	re *regexp.Regexp
+}
+
+// NewProfanityGuard creates a profanity filter; an empty list uses a default set
+func NewProfanityGuard(words ...string) *ProfanityGuard {
+	if len(words) == 0 {
+		words = defaultProfanity
+	}
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = regexp.QuoteMeta(strings.ToLower(w))
+	}
+	pattern := `(?i)\b(?:` + strings.Join(quoted, "|") + `)(?:s|es|ed|er|ing)?\b`
+	return &ProfanityGuard{re: regexp.MustCompile(pattern)}
+}
+
+// Inspect implements Guard
+func (g *ProfanityGuard) Inspect(ctx context.Context, text string) ([]Match, error) {
+	var matches []Match
+	for _, loc := range g.re.FindAllStringIndex(text, -1) {
+		matches = append(matches, Match{Detector: "profanity", Start: loc[0], End: loc[1]})
+	}
+	return matches, nil
+}
+
+// sortedKeys returns map keys in a stable order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
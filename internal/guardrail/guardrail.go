@@ -0,0 +1,310 @@
+// Package guardrail inspects request and response text with configurable
+// content filters that can block, redact, or annotate proxied traffic.
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Action is what a rule does when its guard matches
+type Action string
+
+const (
+	// ActionAnnotate reports the finding but leaves the text untouched
+	ActionAnnotate Action = "annotate"
+	// ActionRedact replaces matched spans with a placeholder
+	ActionRedact Action = "redact"
+	// ActionBlock rejects the request or response
+	ActionBlock Action = "block"
+	// ActionLog records the finding in the server log only
+	ActionLog Action = "log"
+)
+
+// ParseAction validates an action name
+func ParseAction(s string) (Action, error) {
+	switch Action(s) {
+	case ActionAnnotate, ActionRedact, ActionBlock, ActionLog:
+		return Action(s), nil
+	default:
+		return "", fmt.Errorf("invalid guardrail action %q (want annotate, redact, block or log)", s)
+	}
+}
+
+// Direction identifies which side of the exchange is being inspected
+type Direction string
+
+const (
+	// Request is text sent by the client to the model
+	Request Direction = "request"
+	// Response is text returned by the model
+	Response Direction = "response"
+)
+
+// Match is a span of text flagged by a guard.
+// A match with End <= Start covers the whole text.
+type Match struct {
+	Detector string  `json:"detector"` // e.g. "email", "credit_card"
+	Start    int     `json:"start"`
+	End      int     `json:"end"`
+	Score    float64 `json:"score,omitempty"` // Optional risk score (0-1)
+}
+
+// Guard detects unwanted content in text
+type Guard interface {
+	Inspect(ctx context.Context, text string) ([]Match, error)
+}
+
+// Rule attaches an action to a guard
+type Rule struct {
+	Name   string
+	Guard  Guard
+	Action Action
+	// Directions the rule applies to; empty means both
+	Directions []Direction
+}
+
+// appliesTo reports whether the rule inspects the given direction
+func (r *Rule) appliesTo(dir Direction) bool {
+	if len(r.Directions) == 0 {
+		return true
+	}
+	for _, d := range r.Directions {
+		if d == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientPolicy adjusts the pipeline for a single client
+type ClientPolicy struct {
+	Disabled []string          // Rule names skipped for this client
+	Actions  map[string]Action // Per-rule action overrides
+}
+
+// Finding records a triggered rule
+type Finding struct {
+	Rule      string    `json:"rule"`
+	Detector  string    `json:"detector"`
+	Action    Action    `json:"action"`
+	Direction Direction `json:"direction"`
+}
+
+// Result is the outcome of running the pipeline over a piece of text
+type Result struct {
+	Text     string    // Text after redaction
+	Blocked  bool      // A block rule matched; Text is unchanged
+	Findings []Finding // Every triggered rule, in pipeline order
+	Score    float64   // Highest risk score reported by a non-log rule
+}
+
+// RuleStats counts how often a rule was triggered
+type RuleStats struct {
+	Rule      string    `json:"rule"`
+	Detector  string    `json:"detector"`
+	Direction Direction `json:"direction"`
+	Action    Action    `json:"action"`
+	Count     int64     `json:"count"`
+}
+
+// Pipeline runs text through an ordered list of rules
+type Pipeline struct {
+	rules []Rule
+
+	mu       sync.RWMutex
+	policies map[string]ClientPolicy
+	stats    map[Finding]int64
+}
+
+// NewPipeline creates a pipeline. Rule names must be unique.
+func NewPipeline(rules ...Rule) (*Pipeline, error) {
+	seen := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("guardrail rule name is required")
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("duplicate guardrail rule %q", r.Name)
+		}
+		seen[r.Name] = true
+		if r.Guard == nil {
+			return nil, fmt.Errorf("guardrail rule %q has no guard", r.Name)
+		}
+		if _, err := ParseAction(string(r.Action)); err != nil {
+			return nil, fmt.Errorf("guardrail rule %q: %w", r.Name, err)
+		}
+	}
+
+	return &Pipeline{
+		rules:    rules,
+		policies: make(map[string]ClientPolicy),
+		stats:    make(map[Finding]int64),
+	}, nil
+}
+
+// SetClientPolicy installs a per-client policy, replacing any previous one
+func (p *Pipeline) SetClientPolicy(clientID string, policy ClientPolicy) error {
+	for name, action := range policy.Actions {
+		if _, err := ParseAction(string(action)); err != nil {
+			return fmt.Errorf("client %s rule %q: %w", clientID, name, err)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.policies[clientID] = policy
+	return nil
+}
+
+// Apply runs the rules for the client over text. Redactions are applied in
+// order so later rules see redacted text; the first block stops the pipeline.
+func (p *Pipeline) Apply(ctx context.Context, clientID string, dir Direction, text string) (*Result, error) {
+	p.mu.RLock()
+	policy, hasPolicy := p.policies[clientID]
+	p.mu.RUnlock()
+
+	result := &Result{Text: text}
+	for i := range p.rules {
+		rule := &p.rules[i]
+		if !rule.appliesTo(dir) || (hasPolicy && contains(policy.Disabled, rule.Name)) {
+			continue
+		}
+
+		matches, err := rule.Guard.Inspect(ctx, result.Text)
+		if err != nil {
+			return nil, fmt.Errorf("guardrail %s failed: %w", rule.Name, err)
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		action := rule.Action
+		if override, ok := policy.Actions[rule.Name]; ok {
+			action = override
+		}
+
+		for _, detector := range detectors(matches) {
+			finding := Finding{Rule: rule.Name, Detector: detector, Action: action, Direction: dir}
+			result.Findings = append(result.Findings, finding)
+			p.record(finding)
+		}
+
+		if action != ActionLog {
+			for _, m := range matches {
+				if m.Score > result.Score {
+					result.Score = m.Score
+				}
+			}
+		}
+
+		switch action {
+		case ActionLog:
+			log.Printf("guardrail: rule %s matched %s in %s (client: %s)",
+				rule.Name, strings.Join(detectors(matches), ","), dir, clientID)
+		case ActionBlock:
+			result.Blocked = true
+			result.Text = text
+			return result, nil
+		case ActionRedact:
+			result.Text = Redact(result.Text, matches)
+		}
+	}
+
+	return result, nil
+}
+
+// Stats returns trigger counts per rule, detector, direction and action
+func (p *Pipeline) Stats() []RuleStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := make([]RuleStats, 0, len(p.stats))
+	for f, count := range p.stats {
+		stats = append(stats, RuleStats{
+			Rule:      f.Rule,
+			Detector:  f.Detector,
+			Direction: f.Direction,
+			Action:    f.Action,
+			Count:     count,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		if stats[i].Rule != stats[j].Rule {
+			return stats[i].Rule < stats[j].Rule
+		}
+		return stats[i].Detector < stats[j].Detector
+	})
+	return stats
+}
+
+// record increments the trigger counter for a finding
+func (p *Pipeline) record(f Finding) {
+	p.mu.Lock()
+	p.stats[f]++
+	p.mu.Unlock()
+}
+
+// Redact replaces matched spans with [REDACTED:<detector>] placeholders.
+// Overlapping spans are merged; a whole-text match redacts everything.
+func Redact(text string, matches []Match) string {
+	spans := make([]Match, 0, len(matches))
+	for _, m := range matches {
+		if m.End <= m.Start {
+			m.Start, m.End = 0, len(text)
+		}
+		if m.Start < 0 || m.End > len(text) {
+			continue
+		}
+		spans = append(spans, m)
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+
+	var merged []Match
+	for _, m := range spans {
+		if n := len(merged); n > 0 && m.Start < merged[n-1].End {
+			if m.End > merged[n-1].End {
+				merged[n-1].End = m.End
+			}
+			continue
+		}
+		merged = append(merged, m)
+	}
+
+	var b strings.Builder
+	pos := 0
+	for _, m := range merged {
+		b.WriteString(text[pos:m.Start])
+		b.WriteString("[REDACTED:" + m.Detector + "]")
+		pos = m.End
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// detectors returns the distinct detectors in matches, in first-seen order
+func detectors(matches []Match) []string {
+	var out []string
+	for _, m := range matches {
+		if !contains(out, m.Detector) {
+			out = append(out, m.Detector)
+		}
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
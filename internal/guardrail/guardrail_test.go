@@ -0,0 +1,230 @@
+package guardrail
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// guardFunc adapts a function to the Guard interface
+type guardFunc func(ctx context.Context, text string) ([]Match, error)
+
+func (f guardFunc) Inspect(ctx context.Context, text string) ([]Match, error) {
+	return f(ctx, text)
+}
+
+func mustPipeline(t *testing.T, rules ...Rule) *Pipeline {
+	t.Helper()
+	p, err := NewPipeline(rules...)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+	return p
+}
+
+func TestNewPipeline_Validation(t *testing.T) {
+	pii, _ := NewPIIGuard()
+	tests := []struct {
+		name  string
+		rules []Rule
+	}{
+		{"missing name", []Rule{{Guard: pii, Action: ActionBlock}}},
+		{"missing guard", []Rule{{Name: "pii", Action: ActionBlock}}},
+		{"bad action", []Rule{{Name: "pii", Guard: pii, Action: "drop"}}},
+		{"duplicate", []Rule{{Name: "pii", Guard: pii, Action: ActionBlock}, {Name: "pii", Guard: pii, Action: ActionRedact}}},
+	}
+	for _, tt := range tests {
+		if _, err := NewPipeline(tt.rules...); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestPipeline_Redact(t *testing.T) {
+	pii, _ := NewPIIGuard(PIIEmail)
+	p := mustPipeline(t, Rule{Name: "pii", Guard: pii, Action: ActionRedact})
+
+	result, err := p.Apply(context.Background(), "", Request, "mail alice@example.com or bob@example.org")
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if result.Text != "mail [REDACTED:email] or [REDACTED:email]" {
+		t.Errorf("unexpected redaction: %s", result.Text)
+	}
+	if result.Blocked || len(result.Findings) != 1 || result.Findings[0].Detector != PIIEmail {
+		t.Errorf("unexpected findings: %+v", result.Findings)
+	}
+}
+
+func TestPipeline_BlockStopsPipeline(t *testing.T) {
+	secrets, _ := NewRegexGuard(map[string]string{"api_key": `sk-[a-z0-9]{8,}`})
+	called := false
+	later := guardFunc(func(ctx context.Context, text string) ([]Match, error) {
+		called = true
+		return nil, nil
+	})
+	p := mustPipeline(t,
+		Rule{Name: "secrets", Guard: secrets, Action: ActionBlock},
+		Rule{Name: "later", Guard: later, Action: ActionAnnotate},
+	)
+
+	result, _ := p.Apply(context.Background(), "", Request, "my key is sk-abcdef123456")
+	if !result.Blocked || result.Findings[0].Rule != "secrets" {
+		t.Errorf("expected block by secrets rule, got %+v", result)
+	}
+	if called {
+		t.Error("expected later rules to be skipped after a block")
+	}
+}
+
+func TestPipeline_Annotate(t *testing.T) {
+	p := mustPipeline(t, Rule{Name: "profanity", Guard: NewProfanityGuard("darn"), Action: ActionAnnotate})
+
+	text := "Darn it, this darned thing"
+	result, _ := p.Apply(context.Background(), "", Response, text)
+	if result.Text != text || result.Blocked {
+		t.Errorf("expected text unchanged, got %q", result.Text)
+	}
+	if len(result.Findings) != 1 || result.Findings[0].Direction != Response {
+		t.Errorf("expected one response finding, got %+v", result.Findings)
+	}
+}
+
+func TestPipeline_Directions(t *testing.T) {
+	pii, _ := NewPIIGuard(PIISSN)
+	p := mustPipeline(t, Rule{Name: "pii", Guard: pii, Action: ActionBlock, Directions: []Direction{Response}})
+
+	if result, _ := p.Apply(context.Background(), "", Request, "ssn 123-45-6789"); result.Blocked {
+		t.Error("expected response-only rule to skip requests")
+	}
+	if result, _ := p.Apply(context.Background(), "", Response, "ssn 123-45-6789"); !result.Blocked {
+		t.Error("expected response-only rule to block responses")
+	}
+}
+
+func TestPipeline_ClientPolicy(t *testing.T) {
+	pii, _ := NewPIIGuard(PIIEmail)
+	p := mustPipeline(t,
+		Rule{Name: "pii", Guard: pii, Action: ActionBlock},
+		Rule{Name: "profanity", Guard: NewProfanityGuard("darn"), Action: ActionBlock},
+	)
+
+	if err := p.SetClientPolicy("bad", ClientPolicy{Actions: map[string]Action{"pii": "drop"}}); err == nil {
+		t.Error("expected error for invalid action override")
+	}
+	p.SetClientPolicy("internal", ClientPolicy{
+		Disabled: []string{"profanity"},
+		Actions:  map[string]Action{"pii": ActionRedact},
+	})
+
+	text := "darn, email a@b.io"
+	result, _ := p.Apply(context.Background(), "internal", Request, text)
+	if result.Blocked || result.Text != "darn, email [REDACTED:email]" {
+		t.Errorf("expected client policy to redact and skip profanity, got %+v", result)
+	}
+
+	result, _ = p.Apply(context.Background(), "other", Request, text)
+	if !result.Blocked {
+		t.Error("expected default policy to block for other clients")
+	}
+}
+
+func TestPipeline_GuardError(t *testing.T) {
+	failing := guardFunc(func(ctx context.Context, text string) ([]Match, error) {
+		return nil, errors.New("unavailable")
+	})
+	p := mustPipeline(t, Rule{Name: "remote", Guard: failing, Action: ActionBlock})
+
+	if _, err := p.Apply(context.Background(), "", Request, "hello"); err == nil || !strings.Contains(err.Error(), "remote") {
+		t.Errorf("expected wrapped guard error, got %v", err)
+	}
+}
+
+func TestPipeline_Stats(t *testing.T) {
+	pii, _ := NewPIIGuard(PIIEmail, PIIPhone)
+	p := mustPipeline(t, Rule{Name: "pii", Guard: pii, Action: ActionRedact})
+
+	p.Apply(context.Background(), "", Request, "a@b.io")
+	p.Apply(context.Background(), "", Request, "c@d.io, call 555-123-4567")
+	p.Apply(context.Background(), "", Request, "nothing here")
+
+	stats := p.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 stat rows, got %+v", stats)
+	}
+	if stats[0].Detector != PIIEmail || stats[0].Count != 2 || stats[1].Detector != PIIPhone || stats[1].Count != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestRedact_MergesOverlaps(t *testing.T) {
+	text := "0123456789"
+	got := Redact(text, []Match{
+		{Detector: "a", Start: 2, End: 5},
+		{Detector: "b", Start: 4, End: 7},
+		{Detector: "c", Start: 8, End: 9},
+	})
+	if got != "01[REDACTED:a]7[REDACTED:c]9" {
+		t.Errorf("unexpected redaction: %s", got)
+	}
+	if got := Redact(text, []Match{{Detector: "all"}}); got != "[REDACTED:all]" {
+		t.Errorf("expected whole-text redaction, got %s", got)
+	}
+}
+
+func TestPIIGuard(t *testing.T) {
+	g, _ := NewPIIGuard()
+	tests := []struct {
+		text     string
+		detector string
+	}{
+		{"contact jane.doe@corp.example.com", PIIEmail},
+		{"call (555) 123-4567", PIIPhone},
+		{"call +1 555.123.4567", PIIPhone},
+		{"ssn 078-05-1120", PIISSN},
+		{"card 4111 1111 1111 1111", PIICreditCard},
+		{"host 192.168.1.20", PIIIPAddress},
+	}
+	for _, tt := range tests {
+		matches, _ := g.Inspect(context.Background(), tt.text)
+		found := false
+		for _, m := range matches {
+			if m.Detector == tt.detector {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected %s in %q, got %+v", tt.detector, tt.text, matches)
+		}
+	}
+
+	// Digit runs failing the Luhn check are not card numbers
+	matches, _ := g.Inspect(context.Background(), "order 4111 1111 1111 1112")
+	for _, m := range matches {
+		if m.Detector == PIICreditCard {
+			t.Errorf("expected invalid card number to be ignored, got %+v", m)
+		}
+	}
+
+	if _, err := NewPIIGuard("passport"); err == nil {
+		t.Error("expected error for unknown PII kind")
+	}
+}
+
+func TestProfanityGuard_WordBoundaries(t *testing.T) {
+	g := NewProfanityGuard("ass")
+	matches, _ := g.Inspect(context.Background(), "a classic assessment")
+	if len(matches) != 0 {
+		t.Errorf("expected no matches inside other words, got %+v", matches)
+	}
+}
+
+func TestNewRegexGuard_InvalidPattern(t *testing.T) {
+	if _, err := NewRegexGuard(map[string]string{"bad": "("}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	if _, err := NewRegexGuard(nil); err == nil {
+		t.Error("expected error for empty pattern set")
+	}
+}
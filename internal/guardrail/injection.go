@@ -0,0 +1,200 @@
+package guardrail
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Prompt-injection signal detectors
+const (
+	SignalOverride     = "instruction_override" // "ignore previous instructions" and similar
+	SignalExfiltration = "exfiltration"         // attempts to leak prompts or send data out
+	SignalRoleSpoof    = "role_spoofing"        // fake chat-template or system markers
+	SignalHiddenText   = "hidden_text"          // zero-width and Unicode tag characters
+	SignalEncoded      = "encoded_payload"      // base64 blobs that decode to text
+)
+
+// signalWeights is the probability-like contribution of each signal to the risk score
+var signalWeights = map[string]float64{
+	SignalOverride:     0.6,
+	SignalExfiltration: 0.5,
+	SignalRoleSpoof:    0.5,
+	SignalHiddenText:   0.4,
+	SignalEncoded:      0.3,
+}
+
+// injectionPatterns pairs each signal with a regexp and the lowercase literals
+// at least one of which must appear before the regexp is worth running
+var injectionPatterns = []struct {
+	signal   string
+	keywords []string
+	re       *regexp.Regexp
+}{
+	{
+		SignalOverride,
+		[]string{"ignore", "disregard", "forget", "override", "bypass", "you are now", "instructions", "do anything now", "pretend"},
+		regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override|bypass)\s+(?:(?:all|any|the|your|my|these|those|previous|prior|above|earlier|preceding|system)\s+)*(?:instructions|prompts?|rules|directions|guidelines|context)\b` +
+			`|\byou\s+are\s+now\s+(?:in\s+)?(?:dan\b|developer\s+mode|jailbroken|unrestricted|unfiltered)` +
+			`|\b(?:new|updated|revised)\s+(?:system\s+)?instructions\s*:` +
+			`|\bdo\s+anything\s+now\b` +
+			`|\bpretend\s+(?:that\s+)?you\s+(?:have\s+no|are\s+not\s+bound)`),
+	},
+	{
+		SignalExfiltration,
+		[]string{"http", "system prompt", "instructions", "api key", "secret", "credential"},
+		regexp.MustCompile(`(?i)\b(?:reveal|print|show|repeat|output|leak|display|dump)\s+(?:me\s+)?(?:your|the)\s+(?:system\s+prompt|(?:initial|hidden|original)\s+instructions|api\s+keys?|secrets?|credentials)` +
+			`|!\[[^\]]*\]\(https?://[^)\s]*\?[^)\s]*=` +
+			`|\b(?:send|post|forward|upload|exfiltrate)\b[^.\n]{0,60}\bhttps?://`),
+	},
+	{
+		SignalRoleSpoof,
+		[]string{"<|", "[inst]", "[/inst]", "<<sys>>", "<</sys>>", "##", "system>", "instructions>"},
+		regexp.MustCompile(`(?i)<\|(?:im_start|im_end|system|endoftext)\|>` +
+			`|\[/?INST\]|<</?SYS>>` +
+			`|(?m:^\s*#{2,}\s*(?:system|assistant)\s*:)` +
+			`|</?(?:system|instructions)>`),
+	},
+	{
+		SignalHiddenText,
+		[]string{"\u200b", "\u200c", "\u200e", "\u200f", "\u2060", "\ufeff", "\u202a", "\u202b", "\u202c", "\u202d", "\u202e", "\xf3\xa0\x80", "\xf3\xa0\x81"},
+		regexp.MustCompile(`[\x{200B}\x{200C}\x{200E}\x{200F}\x{2060}\x{FEFF}\x{202A}-\x{202E}]{2,}|[\x{E0000}-\x{E007F}]+`),
+	},
+}
+
+// minBase64Run is the shortest base64 run considered an encoded payload
+const minBase64Run = 40
+
+// InjectionReport is the outcome of scanning text for prompt injection
+type InjectionReport struct {
+	Score   float64 // 0 (clean) to 1 (almost certainly an injection attempt)
+	Matches []Match // One match per signal occurrence
+}
+
+// ScanInjection looks for prompt-injection signals in text. Each regexp only
+// runs when one of its keywords appears, so clean text costs a handful of
+// substring searches and stays cheap enough to scan inline.
+func ScanInjection(text string) InjectionReport {
+	var report InjectionReport
+	lower := strings.ToLower(text)
+	for _, p := range injectionPatterns {
+		if !containsAny(lower, p.keywords) {
+			continue
+		}
+		for _, loc := range p.re.FindAllStringIndex(text, -1) {
+			report.Matches = append(report.Matches, Match{Detector: p.signal, Start: loc[0], End: loc[1]})
+		}
+	}
+
+	// Encoded payloads count once, and as an override if they hide one
+	for _, loc := range base64Runs(text) {
+		decoded, ok := decodeText(text[loc[0]:loc[1]])
+		if !ok {
+			continue
+		}
+		report.Matches = append(report.Matches, Match{Detector: SignalEncoded, Start: loc[0], End: loc[1]})
+		for _, p := range injectionPatterns[:2] {
+			if p.re.MatchString(decoded) {
+				report.Matches = append(report.Matches, Match{Detector: p.signal, Start: loc[0], End: loc[1]})
+			}
+		}
+	}
+
+	// Combine distinct signals as independent evidence: 1 - Π(1 - w)
+	clean := 1.0
+	for _, signal := range detectors(report.Matches) {
+		clean *= 1 - signalWeights[signal]
+	}
+	report.Score = 1 - clean
+	return report
+}
+
+// containsAny reports whether s contains any of the substrings
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// base64Runs returns the [start, end) offsets of base64 runs of at least
+// minBase64Run characters, including trailing padding
+func base64Runs(text string) [][2]int {
+	var runs [][2]int
+	start := -1
+	for i := 0; i <= len(text); i++ {
+		if i < len(text) && isBase64Char(text[i]) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start >= minBase64Run {
+			end := i
+			for end < len(text) && end-i < 2 && text[end] == '=' {
+				end++
+			}
+			runs = append(runs, [2]int{start, end})
+		}
+		start = -1
+	}
+	return runs
+}
+
+func isBase64Char(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '+' || c == '/'
+}
+
+// decodeText decodes base64 and reports whether the result is mostly printable text
+func decodeText(s string) (string, bool) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		data, err = base64.RawStdEncoding.DecodeString(s)
+		if err != nil {
+			return "", false
+		}
+	}
+	if len(data) < 24 {
+		return "", false
+	}
+
+	printable := 0
+	for _, b := range data {
+		if (b >= 0x20 && b < 0x7f) || b == '\n' || b == '\r' || b == '\t' {
+			printable++
+		}
+	}
+	return string(data), printable*10 >= len(data)*9
+}
+
+// InjectionGuard flags text whose prompt-injection risk score reaches a threshold
+type InjectionGuard struct {
+	threshold float64
+}
+
+// NewInjectionGuard creates a prompt-injection guard; zero uses a threshold of 0.5
+func NewInjectionGuard(threshold float64) (*InjectionGuard, error) {
+	if threshold == 0 {
+		threshold = 0.5
+	}
+	if threshold < 0 || threshold > 1 {
+		return nil, fmt.Errorf("injection threshold must be between 0 and 1")
+	}
+	return &InjectionGuard{threshold: threshold}, nil
+}
+
+// Inspect implements Guard. Every match carries the overall risk score.
+func (g *InjectionGuard) Inspect(ctx context.Context, text string) ([]Match, error) {
+	report := ScanInjection(text)
+	if len(report.Matches) == 0 || report.Score < g.threshold {
+		return nil, nil
+	}
+	for i := range report.Matches {
+		report.Matches[i].Score = report.Score
+	}
+	return report.Matches, nil
+}
@@ -0,0 +1,98 @@
+package guardrail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// WebhookRequest is the payload POSTed to a webhook guard
+type WebhookRequest struct {
+	Text string `json:"text"`
+}
+
+// WebhookResponse is the expected webhook reply. Matches without offsets
+// flag the whole text.
+type WebhookResponse struct {
+	Matches []Match `json:"matches"`
+}
+
+// WebhookGuard delegates inspection to an external HTTP service
+type WebhookGuard struct {
+	url      string
+	failOpen bool
+	client   *http.Client
+}
+
+// NewWebhookGuard creates a guard that POSTs text to url.
+// With failOpen, webhook errors are logged and the text passes.
+func NewWebhookGuard(url string, timeout time.Duration, failOpen bool) (*WebhookGuard, error) {
+	if url == "" {
+		return nil, fmt.Errorf("webhook url is required")
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &WebhookGuard{
+		url:      url,
+		failOpen: failOpen,
+		client:   &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Inspect implements Guard
+func (g *WebhookGuard) Inspect(ctx context.Context, text string) ([]Match, error) {
+	matches, err := g.call(ctx, text)
+	if err != nil && g.failOpen {
+		log.Printf("guardrail: webhook %s failed, allowing content: %v", g.url, err)
+		return nil, nil
+	}
+	return matches, err
+}
+
+// call sends the text to the webhook and validates the reply
+func (g *WebhookGuard) call(ctx context.Context, text string) ([]Match, error) {
+	body, err := json.Marshal(WebhookRequest{Text: text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	var result WebhookResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook response: %w", err)
+	}
+
+	matches := result.Matches[:0]
+	for _, m := range result.Matches {
+		if m.Start < 0 || m.End > len(text) {
+			continue
+		}
+		if m.Detector == "" {
+			m.Detector = "webhook"
+		}
+		matches = append(matches, m)
+	}
+	return matches, nil
+}
@@ -0,0 +1,67 @@
+package guardrail
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookGuard(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req WebhookRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var resp WebhookResponse
+		if i := strings.Index(req.Text, "forbidden"); i >= 0 {
+			resp.Matches = append(resp.Matches,
+				Match{Detector: "topic", Start: i, End: i + len("forbidden")},
+				Match{Start: 0, End: 1000}, // out of range, dropped
+			)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	g, err := NewWebhookGuard(server.URL, time.Second, false)
+	if err != nil {
+		t.Fatalf("NewWebhookGuard() error = %v", err)
+	}
+
+	matches, err := g.Inspect(context.Background(), "a forbidden topic")
+	if err != nil {
+		t.Fatalf("Inspect() error = %v", err)
+	}
+	if len(matches) != 1 || matches[0].Detector != "topic" || matches[0].Start != 2 {
+		t.Errorf("unexpected matches: %+v", matches)
+	}
+
+	matches, _ = g.Inspect(context.Background(), "fine")
+	if len(matches) != 0 {
+		t.Errorf("expected no matches, got %+v", matches)
+	}
+}
+
+func TestWebhookGuard_Failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	closed, _ := NewWebhookGuard(server.URL, time.Second, false)
+	if _, err := closed.Inspect(context.Background(), "text"); err == nil {
+		t.Error("expected error from fail-closed webhook")
+	}
+
+	open, _ := NewWebhookGuard(server.URL, time.Second, true)
+	if matches, err := open.Inspect(context.Background(), "text"); err != nil || matches != nil {
+		t.Errorf("expected fail-open webhook to pass, got %v, %v", matches, err)
+	}
+
+	if _, err := NewWebhookGuard("", 0, false); err == nil {
+		t.Error("expected error for missing url")
+	}
+}
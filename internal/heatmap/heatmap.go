@@ -0,0 +1,330 @@
+// Package heatmap records provider latency by hour and aggregates it into
+// day-of-week by hour-of-day heatmaps, so capacity planners can see when
+// providers slow down and routing can prefer the provider that is usually
+// fastest at the current hour.
+package heatmap
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// Store persists hourly latency rollups
+type Store interface {
+	Add(hours []*database.LatencyHour) error
+	List(since time.Time, provider string) ([]*database.LatencyHour, error)
+}
+
+// Config configures a Collector
+type Config struct {
+	// FlushInterval is how often samples are written to the store
+	// (default 1m)
+	FlushInterval time.Duration
+	// Window is the history Expected averages over (default 28 days)
+	Window time.Duration
+	// MinSamples is how many samples an hour of the week needs before
+	// Expected trusts it (default 20)
+	MinSamples int64
+}
+
+// DefaultConfig returns the default collector configuration
+func DefaultConfig() Config {
+	return Config{
+		FlushInterval: time.Minute,
+		Window:        28 * 24 * time.Hour,
+		MinSamples:    20,
+	}
+}
+
+// key identifies an in-memory rollup
+type key struct {
+	provider string
+	hour     time.Time
+}
+
+// Collector counts request latency per provider and UTC hour
+type Collector struct {
+	config Config
+	store  Store
+	now    func() time.Time
+
+	mu      sync.Mutex
+	pending map[key]*database.LatencyHour
+
+	// UTC heatmaps of the window, rebuilt after each flush
+	history atomic.Pointer[map[string]*Heatmap]
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewCollector creates a collector, loads the history and starts flushing
+// samples to store. Zero config fields take their defaults.
+func NewCollector(store Store, cfg Config) (*Collector, error) {
+	if store == nil {
+		return nil, fmt.Errorf("store is required")
+	}
+	def := DefaultConfig()
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = def.FlushInterval
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = def.Window
+	}
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = def.MinSamples
+	}
+
+	c := &Collector{
+		config:  cfg,
+		store:   store,
+		now:     time.Now,
+		pending: make(map[key]*database.LatencyHour),
+		stop:    make(chan struct{}),
+	}
+	if err := c.refresh(); err != nil {
+		log.Printf("heatmap: failed to load latency history: %v", err)
+	}
+	c.wg.Add(1)
+	go c.loop()
+	return c, nil
+}
+
+// Window returns the history Expected averages over
+func (c *Collector) Window() time.Duration {
+	return c.config.Window
+}
+
+// Observe counts one request. Server errors and rate limiting count as
+// errors; other responses are latency samples. latency is the time to
+// first byte.
+func (c *Collector) Observe(provider string, status int, latency time.Duration, at time.Time) {
+	if provider == "" {
+		return
+	}
+	k := key{provider: provider, hour: at.UTC().Truncate(time.Hour)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.pending[k]
+	if !ok {
+		h = &database.LatencyHour{Provider: provider, HourStart: k.hour}
+		c.pending[k] = h
+	}
+	if status >= 500 || status == http.StatusTooManyRequests {
+		h.Errors++
+		return
+	}
+	ms := latency.Milliseconds()
+	h.Samples++
+	h.LatencyMsTotal += ms
+	h.LatencyMsMax = max(h.LatencyMsMax, ms)
+}
+
+// Flush writes pending samples to the store and reloads the history. On
+// error the samples are kept for the next flush.
+func (c *Collector) Flush() error {
+	c.mu.Lock()
+	batch := c.pending
+	c.pending = make(map[key]*database.LatencyHour)
+	c.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	hours := make([]*database.LatencyHour, 0, len(batch))
+	for _, h := range batch {
+		hours = append(hours, h)
+	}
+	if err := c.store.Add(hours); err != nil {
+		c.mu.Lock()
+		for k, h := range batch {
+			if cur, ok := c.pending[k]; ok {
+				cur.Samples += h.Samples
+				cur.Errors += h.Errors
+				cur.LatencyMsTotal += h.LatencyMsTotal
+				cur.LatencyMsMax = max(cur.LatencyMsMax, h.LatencyMsMax)
+			} else {
+				c.pending[k] = h
+			}
+		}
+		c.mu.Unlock()
+		return err
+	}
+	return c.refresh()
+}
+
+// refresh rebuilds the UTC history Expected reads
+func (c *Collector) refresh() error {
+	hours, err := c.store.List(c.now().Add(-c.config.Window), "")
+	if err != nil {
+		return err
+	}
+	history := make(map[string]*Heatmap)
+	for _, m := range Build(hours, time.UTC, c.config.MinSamples) {
+		history[m.Provider] = m
+	}
+	c.history.Store(&history)
+	return nil
+}
+
+// Close stops the flush loop and writes the remaining samples
+func (c *Collector) Close() {
+	c.stopOnce.Do(func() { close(c.stop) })
+	c.wg.Wait()
+	if err := c.Flush(); err != nil {
+		log.Printf("heatmap: final flush failed: %v", err)
+	}
+}
+
+// loop flushes pending samples every FlushInterval
+func (c *Collector) loop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			_ = crash.Do("heatmap flush", func() {
+				if err := c.Flush(); err != nil {
+					log.Printf("heatmap: flush failed: %v", err)
+				}
+			})
+		}
+	}
+}
+
+// Heatmaps aggregates the stored samples since a time into heatmaps in
+// loc's days and hours. An empty provider covers every provider.
+func (c *Collector) Heatmaps(since time.Time, provider string, loc *time.Location) ([]*Heatmap, error) {
+	hours, err := c.store.List(since, provider)
+	if err != nil {
+		return nil, err
+	}
+	return Build(hours, loc, c.config.MinSamples), nil
+}
+
+// Expected returns a provider's average latency at the same hour of the
+// week as at, over the window. It reports false when that hour has fewer
+// than MinSamples samples.
+func (c *Collector) Expected(provider string, at time.Time) (time.Duration, bool) {
+	history := c.history.Load()
+	if history == nil {
+		return 0, false
+	}
+	m, ok := (*history)[provider]
+	if !ok {
+		return 0, false
+	}
+	at = at.UTC()
+	cell := m.grid[at.Weekday()][at.Hour()]
+	if cell == nil || cell.Samples < c.config.MinSamples {
+		return 0, false
+	}
+	return time.Duration(cell.AvgLatencyMs * float64(time.Millisecond)), true
+}
+
+// Cell is one hour of the week
+type Cell struct {
+	Weekday      time.Weekday `json:"weekday"` // 0 is Sunday
+	Day          string       `json:"day"`
+	Hour         int          `json:"hour"`
+	Samples      int64        `json:"samples"`
+	Errors       int64        `json:"errors"`
+	ErrorRate    float64      `json:"error_rate"`     // Percent of requests
+	AvgLatencyMs float64      `json:"avg_latency_ms"` // Of the samples
+	MaxLatencyMs int64        `json:"max_latency_ms"`
+
+	latencyMsTotal int64
+}
+
+// Heatmap is a provider's latency by day of week and hour of day
+type Heatmap struct {
+	Provider     string  `json:"provider"`
+	Samples      int64   `json:"samples"`
+	Errors       int64   `json:"errors"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	// Cells lists the hours of the week with requests, Sunday 00:00 first
+	Cells []*Cell `json:"cells"`
+	// Slowest and Fastest are the hours with the highest and lowest
+	// average among those with enough samples to judge
+	Slowest *Cell `json:"slowest,omitempty"`
+	Fastest *Cell `json:"fastest,omitempty"`
+
+	grid [7][24]*Cell
+}
+
+// Build aggregates hourly rollups into one heatmap per provider, placing
+// each hour in loc's day of week and hour of day. Cells need minSamples
+// samples to be the slowest or fastest.
+func Build(hours []*database.LatencyHour, loc *time.Location, minSamples int64) []*Heatmap {
+	if loc == nil {
+		loc = time.UTC
+	}
+	byProvider := make(map[string]*Heatmap)
+	for _, h := range hours {
+		m, ok := byProvider[h.Provider]
+		if !ok {
+			m = &Heatmap{Provider: h.Provider}
+			byProvider[h.Provider] = m
+		}
+		local := h.HourStart.In(loc)
+		cell := m.grid[local.Weekday()][local.Hour()]
+		if cell == nil {
+			cell = &Cell{Weekday: local.Weekday(), Day: local.Weekday().String(), Hour: local.Hour()}
+			m.grid[local.Weekday()][local.Hour()] = cell
+		}
+		cell.Samples += h.Samples
+		cell.Errors += h.Errors
+		cell.latencyMsTotal += h.LatencyMsTotal
+		cell.MaxLatencyMs = max(cell.MaxLatencyMs, h.LatencyMsMax)
+	}
+
+	maps := make([]*Heatmap, 0, len(byProvider))
+	for _, m := range byProvider {
+		var latencyMsTotal int64
+		for day := range m.grid {
+			for _, cell := range m.grid[day] {
+				if cell == nil {
+					continue
+				}
+				if cell.Samples > 0 {
+					cell.AvgLatencyMs = float64(cell.latencyMsTotal) / float64(cell.Samples)
+				}
+				if requests := cell.Samples + cell.Errors; requests > 0 {
+					cell.ErrorRate = float64(cell.Errors) * 100 / float64(requests)
+				}
+				m.Samples += cell.Samples
+				m.Errors += cell.Errors
+				latencyMsTotal += cell.latencyMsTotal
+				m.Cells = append(m.Cells, cell)
+
+				if cell.Samples >= minSamples && cell.Samples > 0 {
+					if m.Slowest == nil || cell.AvgLatencyMs > m.Slowest.AvgLatencyMs {
+						m.Slowest = cell
+					}
+					if m.Fastest == nil || cell.AvgLatencyMs < m.Fastest.AvgLatencyMs {
+						m.Fastest = cell
+					}
+				}
+			}
+		}
+		if m.Samples > 0 {
+			m.AvgLatencyMs = float64(latencyMsTotal) / float64(m.Samples)
+		}
+		maps = append(maps, m)
+	}
+	sort.Slice(maps, func(i, j int) bool { return maps[i].Provider < maps[j].Provider })
+	return maps
+}
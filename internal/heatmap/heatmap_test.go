@@ -0,0 +1,162 @@
+package heatmap
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// memStore accumulates rollups like the database does
+type memStore struct {
+	mu    sync.Mutex
+	hours map[string]*database.LatencyHour
+	err   error
+}
+
+func newMemStore() *memStore {
+	return &memStore{hours: make(map[string]*database.LatencyHour)}
+}
+
+func (m *memStore) Add(hours []*database.LatencyHour) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	for _, h := range hours {
+		k := h.Provider + h.HourStart.String()
+		cur, ok := m.hours[k]
+		if !ok {
+			cp := *h
+			m.hours[k] = &cp
+			continue
+		}
+		cur.Samples += h.Samples
+		cur.Errors += h.Errors
+		cur.LatencyMsTotal += h.LatencyMsTotal
+		cur.LatencyMsMax = max(cur.LatencyMsMax, h.LatencyMsMax)
+	}
+	return nil
+}
+
+func (m *memStore) List(since time.Time, provider string) ([]*database.LatencyHour, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*database.LatencyHour
+	for _, h := range m.hours {
+		if !h.HourStart.Before(since) && (provider == "" || h.Provider == provider) {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+func TestCollector_ObserveAndHeatmaps(t *testing.T) {
+	store := newMemStore()
+	c, err := NewCollector(store, Config{FlushInterval: time.Hour, MinSamples: 2})
+	if err != nil {
+		t.Fatalf("NewCollector failed: %v", err)
+	}
+	defer c.Close()
+
+	// Monday 2 March 2026, 14:00 and 15:00 UTC
+	mon := time.Date(2026, 3, 2, 14, 10, 0, 0, time.UTC)
+	c.now = func() time.Time { return mon.Add(2 * time.Hour) }
+
+	c.Observe("openai", http.StatusOK, 800*time.Millisecond, mon)
+	c.Observe("openai", http.StatusOK, 1200*time.Millisecond, mon.Add(20*time.Minute))
+	c.Observe("openai", http.StatusBadGateway, 5*time.Second, mon) // Errors carry no latency
+	c.Observe("openai", http.StatusOK, 200*time.Millisecond, mon.Add(time.Hour))
+	c.Observe("openai", http.StatusOK, 400*time.Millisecond, mon.Add(time.Hour))
+	c.Observe("anthropic", http.StatusTooManyRequests, 0, mon)
+	c.Observe("", http.StatusOK, time.Second, mon) // Unattributed requests are dropped
+
+	if _, ok := c.Expected("openai", mon); ok {
+		t.Error("expected no history before the first flush")
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if len(store.hours) != 3 {
+		t.Fatalf("expected 3 stored hours, got %d", len(store.hours))
+	}
+
+	maps, err := c.Heatmaps(time.Time{}, "", time.UTC)
+	if err != nil {
+		t.Fatalf("Heatmaps failed: %v", err)
+	}
+	if len(maps) != 2 || maps[0].Provider != "anthropic" || maps[1].Provider != "openai" {
+		t.Fatalf("unexpected heatmaps: %+v", maps)
+	}
+	if a := maps[0]; a.Samples != 0 || a.Errors != 1 || a.Slowest != nil || a.Cells[0].ErrorRate != 100 {
+		t.Errorf("unexpected anthropic heatmap: %+v", a)
+	}
+	o := maps[1]
+	if o.Samples != 4 || o.Errors != 1 || o.AvgLatencyMs != 650 || len(o.Cells) != 2 {
+		t.Fatalf("unexpected openai heatmap: %+v", o)
+	}
+	if s := o.Slowest; s == nil || s.Weekday != time.Monday || s.Day != "Monday" || s.Hour != 14 || s.AvgLatencyMs != 1000 || s.MaxLatencyMs != 1200 {
+		t.Errorf("unexpected slowest cell: %+v", s)
+	}
+	if f := o.Fastest; f == nil || f.Hour != 15 || f.AvgLatencyMs != 300 {
+		t.Errorf("unexpected fastest cell: %+v", f)
+	}
+
+	// The same hours in another time zone move to its day and hour
+	tokyo := time.FixedZone("JST", 9*60*60)
+	maps, err = c.Heatmaps(time.Time{}, "openai", tokyo)
+	if err != nil || len(maps) != 1 {
+		t.Fatalf("Heatmaps(openai) = %+v, %v", maps, err)
+	}
+	if s := maps[0].Slowest; s.Weekday != time.Monday || s.Hour != 23 {
+		t.Errorf("expected Monday 23:00 in Tokyo, got %+v", s)
+	}
+	if f := maps[0].Fastest; f.Weekday != time.Tuesday || f.Hour != 0 {
+		t.Errorf("expected Tuesday 00:00 in Tokyo, got %+v", f)
+	}
+
+	// A week later the same hour of the week is expected to be as slow
+	if d, ok := c.Expected("openai", mon.Add(7*24*time.Hour)); !ok || d != time.Second {
+		t.Errorf("Expected(openai) = %v, %v", d, ok)
+	}
+	if _, ok := c.Expected("openai", mon.Add(24*time.Hour)); ok {
+		t.Error("expected no history for Tuesday")
+	}
+	if _, ok := c.Expected("anthropic", mon); ok {
+		t.Error("expected too few samples for anthropic")
+	}
+}
+
+func TestCollector_FlushErrorKeepsSamples(t *testing.T) {
+	store := newMemStore()
+	c, err := NewCollector(store, Config{FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewCollector failed: %v", err)
+	}
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	store.err = errors.New("disk full")
+	c.Observe("openai", http.StatusOK, 300*time.Millisecond, at)
+	if err := c.Flush(); err == nil {
+		t.Fatal("expected flush error")
+	}
+	c.Observe("openai", http.StatusOK, 900*time.Millisecond, at)
+
+	store.err = nil
+	c.Close()
+
+	hours, _ := store.List(time.Time{}, "")
+	if len(hours) != 1 || hours[0].Samples != 2 || hours[0].LatencyMsTotal != 1200 || hours[0].LatencyMsMax != 900 {
+		t.Errorf("expected samples kept across the failed flush, got %+v", hours)
+	}
+}
+
+func TestNewCollector_RequiresStore(t *testing.T) {
+	if _, err := NewCollector(nil, Config{}); err == nil {
+		t.Error("expected error without a store")
+	}
+}
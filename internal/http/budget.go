@@ -0,0 +1,127 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Reasons Do stops retrying before MaxAttempts, reported in
+// Response.RetryStopped and wrapped into errors
+var (
+	ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+	ErrRetryPastDeadline    = errors.New("retry backoff exceeds request deadline")
+)
+
+// RetryBudgetConfig sizes a RetryBudget: each provider may retry Retries
+// times per Window, refilled continuously
+type RetryBudgetConfig struct {
+	Retries int           // Retries allowed per window (default: 100)
+	Window  time.Duration // Refill window (default: 1m)
+}
+
+func (c *RetryBudgetConfig) setDefaults() {
+	if c.Retries == 0 {
+		c.Retries = 100
+	}
+	if c.Window == 0 {
+		c.Window = time.Minute
+	}
+}
+
+// RetryBudget caps retries per provider with a token bucket, so a failing
+// provider cannot multiply its load through retries. One budget is usually
+// shared by every Client talking to the same providers.
+type RetryBudget struct {
+	mu      sync.Mutex
+	cfg     RetryBudgetConfig
+	buckets map[string]*budgetBucket
+	now     func() time.Time
+}
+
+type budgetBucket struct {
+	tokens  float64
+	updated time.Time
+	allowed int64
+	denied  int64
+}
+
+// RetryBudgetStats reports one provider's budget
+type RetryBudgetStats struct {
+	Provider  string  `json:"provider"`
+	Remaining float64 `json:"remaining"`
+	Capacity  int     `json:"capacity"`
+	Allowed   int64   `json:"allowed"` // Retries granted
+	Denied    int64   `json:"denied"`  // Retries refused for lack of budget
+}
+
+// NewRetryBudget creates a budget with every provider's bucket full
+func NewRetryBudget(cfg RetryBudgetConfig) (*RetryBudget, error) {
+	if cfg.Retries < 0 || cfg.Window < 0 {
+		return nil, fmt.Errorf("retry budget retries and window must not be negative")
+	}
+	cfg.setDefaults()
+	return &RetryBudget{cfg: cfg, buckets: make(map[string]*budgetBucket), now: time.Now}, nil
+}
+
+// bucket returns the provider's bucket, refilled to now. Callers hold b.mu.
+func (b *RetryBudget) bucket(provider string) *budgetBucket {
+	now := b.now()
+	bk := b.buckets[provider]
+	if bk == nil {
+		bk = &budgetBucket{tokens: float64(b.cfg.Retries), updated: now}
+		b.buckets[provider] = bk
+		return bk
+	}
+	rate := float64(b.cfg.Retries) / float64(b.cfg.Window)
+	bk.tokens += float64(now.Sub(bk.updated)) * rate
+	if bk.tokens > float64(b.cfg.Retries) {
+		bk.tokens = float64(b.cfg.Retries)
+	}
+	bk.updated = now
+	return bk
+}
+
+// Allow spends one retry from the provider's budget, reporting false when
+// none is left
+func (b *RetryBudget) Allow(provider string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bk := b.bucket(provider)
+	if bk.tokens < 1 {
+		bk.denied++
+		return false
+	}
+	bk.tokens--
+	bk.allowed++
+	return true
+}
+
+// Remaining returns the retries the provider can currently spend
+func (b *RetryBudget) Remaining(provider string) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.bucket(provider).tokens
+}
+
+// Stats returns the budget of every provider that has retried, sorted by
+// provider
+func (b *RetryBudget) Stats() []RetryBudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := make([]RetryBudgetStats, 0, len(b.buckets))
+	for provider := range b.buckets {
+		bk := b.bucket(provider)
+		stats = append(stats, RetryBudgetStats{
+			Provider:  provider,
+			Remaining: bk.tokens,
+			Capacity:  b.cfg.Retries,
+			Allowed:   bk.allowed,
+			Denied:    bk.denied,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Provider < stats[j].Provider })
+	return stats
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	budget, err := NewRetryBudget(RetryBudgetConfig{Retries: 2, Window: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000, 0)
+	budget.now = func() time.Time { return now }
+
+	if !budget.Allow("openai") || !budget.Allow("openai") {
+		t.Fatal("expected two retries to be allowed")
+	}
+	if budget.Allow("openai") {
+		t.Error("expected third retry to be refused")
+	}
+	if !budget.Allow("anthropic") {
+		t.Error("budgets should be per provider")
+	}
+
+	// Half a window refills one retry, and refills stop at capacity
+	now = now.Add(500 * time.Millisecond)
+	if got := budget.Remaining("openai"); got != 1 {
+		t.Errorf("Remaining = %v, want 1", got)
+	}
+	now = now.Add(time.Hour)
+	if got := budget.Remaining("openai"); got != 2 {
+		t.Errorf("Remaining = %v, want 2", got)
+	}
+
+	stats := budget.Stats()
+	if len(stats) != 2 || stats[0].Provider != "anthropic" || stats[1].Provider != "openai" {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	if s := stats[1]; s.Allowed != 2 || s.Denied != 1 || s.Capacity != 2 {
+		t.Errorf("unexpected openai stats %+v", s)
+	}
+}
+
+func TestRetryBudgetConfig(t *testing.T) {
+	if _, err := NewRetryBudget(RetryBudgetConfig{Retries: -1}); err == nil {
+		t.Error("expected error for negative retries")
+	}
+	budget, err := NewRetryBudget(RetryBudgetConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budget.cfg.Retries != 100 || budget.cfg.Window != time.Minute {
+		t.Errorf("unexpected defaults %+v", budget.cfg)
+	}
+}
+
+func TestClientDoRetryBudgetExhausted(t *testing.T) {
+	attempts := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	budget, _ := NewRetryBudget(RetryBudgetConfig{Retries: 1, Window: time.Hour})
+	client := NewClient(Config{
+		BaseURL:     server.URL,
+		Retry:       RetryConfig{MaxAttempts: 5, BaseDelay: time.Millisecond},
+		RetryBudget: budget,
+	})
+
+	req, _ := http.NewRequestWithContext(WithProvider(context.Background(), "openai"), "GET", server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if attempts.Load() != 2 {
+		t.Errorf("attempts = %d, want 2 (one budgeted retry)", attempts.Load())
+	}
+	if !errors.Is(resp.RetryStopped, ErrRetryBudgetExhausted) {
+		t.Errorf("RetryStopped = %v, want ErrRetryBudgetExhausted", resp.RetryStopped)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("StatusCode = %d, want 503", resp.StatusCode)
+	}
+	if s := budget.Stats(); len(s) != 1 || s[0].Provider != "openai" || s[0].Denied != 1 {
+		t.Errorf("unexpected budget stats %+v", s)
+	}
+}
+
+func TestClientDoRetryPastDeadline(t *testing.T) {
+	attempts := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	budget, _ := NewRetryBudget(RetryBudgetConfig{})
+	client := NewClient(Config{
+		BaseURL:     server.URL,
+		Retry:       RetryConfig{MaxAttempts: 3, BaseDelay: 10 * time.Second},
+		RetryBudget: budget,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if time.Since(start) > time.Second {
+		t.Errorf("Do() waited %v for a retry it could not make", time.Since(start))
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("attempts = %d, want 1", attempts.Load())
+	}
+	if !errors.Is(resp.RetryStopped, ErrRetryPastDeadline) {
+		t.Errorf("RetryStopped = %v, want ErrRetryPastDeadline", resp.RetryStopped)
+	}
+	// Refused retries do not spend budget
+	if got := budget.Remaining(server.Listener.Addr().String()); got != 100 {
+		t.Errorf("Remaining = %v, want 100", got)
+	}
+}
+
+func TestClientDoRetryWaitCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL: server.URL,
+		Retry:   RetryConfig{MaxAttempts: 3, BaseDelay: 10 * time.Second},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	if _, err := client.Do(req); !errors.Is(err, context.Canceled) {
+		t.Errorf("Do() error = %v, want context.Canceled", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Do() kept waiting after cancellation")
+	}
+}
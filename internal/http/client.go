@@ -15,6 +15,7 @@ type Client struct {
 	baseURL    string
 	apiKey     string
 	config     Config
+	counters   *poolCounters
 }
 
 // NewClient creates a new HTTP client with the given configuration.
@@ -23,11 +24,22 @@ func NewClient(cfg Config) *Client {
 	cfg.setDefaults()
 
 	// Configure transport with connection pooling
-	transport := &http.Transport{
-		MaxIdleConns:        cfg.MaxIdleConns,
-		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
-		MaxConnsPerHost:     cfg.MaxConnsPerHost,
-		IdleConnTimeout:     cfg.IdleConnTimeout,
+	counters := &poolCounters{}
+	poolCfg := DefaultTransportConfig()
+	poolCfg.MaxIdleConns = cfg.MaxIdleConns
+	poolCfg.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	poolCfg.MaxConnsPerHost = cfg.MaxConnsPerHost
+	poolCfg.IdleConnTimeoutMs = int(cfg.IdleConnTimeout / time.Millisecond)
+	var transport http.RoundTripper
+	transport, _ = poolCfg.build(counters) // Only TLS settings can fail and none are set
+	if cfg.Transport != nil {
+		transport = cfg.Transport
+	}
+	if cfg.ResponseLimits.enabled() {
+		transport = LimitTransport(transport, cfg.ResponseLimits)
+	}
+	if cfg.WrapTransport != nil {
+		transport = cfg.WrapTransport(transport)
 	}
 
 	return &Client{
@@ -35,12 +47,20 @@ func NewClient(cfg Config) *Client {
 			Transport: transport,
 			Timeout:   cfg.Timeout,
 		},
-		baseURL: cfg.BaseURL,
-		apiKey:  cfg.APIKey,
-		config:  cfg,
+		baseURL:  cfg.BaseURL,
+		apiKey:   cfg.APIKey,
+		config:   cfg,
+		counters: counters,
 	}
 }
 
+// PoolStats returns connection pool metrics: in-flight requests, open and
+// idle connections (unless Config.Transport replaced the pool), reuse and
+// DNS, connect, TLS and first byte timings
+func (c *Client) PoolStats() PoolStats {
+	return c.counters.snapshot("")
+}
+
 // Do executes an HTTP request with automatic retry logic, rate limit parsing,
 // and hook execution.
 //
@@ -52,8 +72,13 @@ func NewClient(cfg Config) *Client {
 //   - Retries on 429, 500, 502, 503, 504
 //   - Does NOT retry on 4xx client errors (except 429)
 //   - Does NOT retry on context cancellation
+//   - Does NOT retry when the backoff would outlast the context deadline
+//   - Does NOT retry when Config.RetryBudget is exhausted for the provider
 //   - Uses exponential backoff with jitter
 //
+// When retries stop early on a retryable status, the response is returned
+// with RetryStopped set to ErrRetryPastDeadline or ErrRetryBudgetExhausted.
+//
 // Hooks are executed in this order:
 //  1. BeforeRequest (before each attempt, including retries)
 //  2. Do HTTP request
@@ -68,11 +93,19 @@ func (c *Client) Do(req *http.Request) (*Response, error) {
 	// Preserve request body for retries
 	var bodyBytes []byte
 	if req.Body != nil {
-		bodyBytes, lastErr = io.ReadAll(req.Body)
+		bodyBytes, lastErr = readRequestBody(req)
 		if lastErr != nil {
 			return nil, fmt.Errorf("failed to read request body: %w", lastErr)
 		}
-		req.Body.Close()
+	}
+
+	// Retries of a non-idempotent request share one idempotency key
+	if h := c.config.IdempotencyHeader; h != "" && (req.Method == http.MethodPost || req.Method == http.MethodPatch) && req.Header.Get(h) == "" {
+		key := IdempotencyKeyFrom(req.Context())
+		if key == "" {
+			key = NewIdempotencyKey()
+		}
+		req.Header.Set(h, key)
 	}
 
 	// Execute request with retries
@@ -94,13 +127,20 @@ func (c *Client) Do(req *http.Request) (*Response, error) {
 			}
 		}
 
+		// Sign the final request
+		if c.config.Signer != nil {
+			if err := c.config.Signer(req, bodyBytes); err != nil {
+				return nil, err
+			}
+		}
+
 		// Log request if logger is set
 		if c.config.Logger != nil {
 			c.logRequest(req, attempt)
 		}
 
 		// Execute the HTTP request
-		resp, err := c.httpClient.Do(req)
+		resp, err := c.counters.end(c.httpClient.Do(c.counters.begin(req)))
 
 		// Handle errors
 		if err != nil {
@@ -119,16 +159,13 @@ func (c *Client) Do(req *http.Request) (*Response, error) {
 
 			// Retry if not the last attempt
 			if attempt < c.config.Retry.MaxAttempts-1 {
-				delay := calculateBackoff(&c.config.Retry, attempt)
-
-				// Execute OnRetry hook
-				if c.config.OnRetry != nil {
-					if hookErr := c.config.OnRetry(req, attempt+1, delay); hookErr != nil {
-						return nil, hookErr
-					}
+				delay, stop := c.retryDelay(req, attempt)
+				if stop != nil {
+					return nil, fmt.Errorf("%w: %w", stop, err)
+				}
+				if waitErr := c.waitRetry(req, attempt, delay); waitErr != nil {
+					return nil, waitErr
 				}
-
-				time.Sleep(delay)
 			}
 			continue
 		}
@@ -148,22 +185,19 @@ func (c *Client) Do(req *http.Request) (*Response, error) {
 		}
 
 		// Check if we should retry based on status code
+		var stop error
 		if shouldRetry(resp, nil) && attempt < c.config.Retry.MaxAttempts-1 {
-			// Close the response body before retrying
-			io.Copy(io.Discard, resp.Body)
-			resp.Body.Close()
-
-			delay := calculateBackoff(&c.config.Retry, attempt)
-
-			// Execute OnRetry hook
-			if c.config.OnRetry != nil {
-				if hookErr := c.config.OnRetry(req, attempt+1, delay); hookErr != nil {
-					return nil, hookErr
+			var delay time.Duration
+			if delay, stop = c.retryDelay(req, attempt); stop == nil {
+				// Close the response body before retrying
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+
+				if waitErr := c.waitRetry(req, attempt, delay); waitErr != nil {
+					return nil, waitErr
 				}
+				continue
 			}
-
-			time.Sleep(delay)
-			continue
 		}
 
 		// Parse rate limit headers
@@ -171,9 +205,10 @@ func (c *Client) Do(req *http.Request) (*Response, error) {
 
 		// Return wrapped response
 		return &Response{
-			Response:  resp,
-			RateLimit: rateLimit,
-			Attempt:   attempt,
+			Response:     resp,
+			RateLimit:    rateLimit,
+			Attempt:      attempt,
+			RetryStopped: stop,
 		}, nil
 	}
 
@@ -191,6 +226,60 @@ func (c *Client) Do(req *http.Request) (*Response, error) {
 	}, nil
 }
 
+// readRequestBody reads and closes req's body so retries can replay it. A
+// known ContentLength sizes the copy in one allocation instead of
+// io.ReadAll's repeated growth.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	defer req.Body.Close()
+	if req.ContentLength <= 0 {
+		return io.ReadAll(req.Body)
+	}
+	body := make([]byte, req.ContentLength)
+	if _, err := io.ReadFull(req.Body, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// retryDelay returns the backoff before the next attempt, or why no retry
+// may be scheduled: the backoff would end after the request's deadline, or
+// the provider's retry budget is spent
+func (c *Client) retryDelay(req *http.Request, attempt int) (time.Duration, error) {
+	delay := calculateBackoff(&c.config.Retry, attempt)
+	if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) <= delay {
+		return 0, ErrRetryPastDeadline
+	}
+	if c.config.RetryBudget != nil {
+		provider := ProviderFrom(req.Context())
+		if provider == "" {
+			provider = req.URL.Host
+		}
+		if !c.config.RetryBudget.Allow(provider) {
+			return 0, ErrRetryBudgetExhausted
+		}
+	}
+	return delay, nil
+}
+
+// waitRetry runs the OnRetry hook and waits out the backoff, returning
+// early if the request's context ends
+func (c *Client) waitRetry(req *http.Request, attempt int, delay time.Duration) error {
+	if c.config.OnRetry != nil {
+		if err := c.config.OnRetry(req, attempt+1, delay); err != nil {
+			return err
+		}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 // logRequest logs the outgoing request with sanitized API key.
 func (c *Client) logRequest(req *http.Request, attempt int) {
 	auth := req.Header.Get("Authorization")
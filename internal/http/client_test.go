@@ -1084,3 +1084,55 @@ func TestClientDoLoggerWithRetryAndFailure(t *testing.T) {
 		t.Errorf("Log should contain sanitized API key (sk-***st12345), got: %s", logOutput)
 	}
 }
+
+func TestClientWrapTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var wrapped int32
+	client := NewClient(Config{
+		WrapTransport: func(base http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				atomic.AddInt32(&wrapped, 1)
+				return base.RoundTrip(req)
+			})
+		},
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+	if atomic.LoadInt32(&wrapped) != 1 {
+		t.Errorf("wrapped transport called %d times, want 1", wrapped)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// BenchmarkClientDoBody measures sending a request body that is kept for
+// retries
+func BenchmarkClientDoBody(b *testing.B) {
+	client := NewClient(Config{})
+	client.httpClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		_, _ = io.Copy(io.Discard, req.Body)
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+	body := bytes.Repeat([]byte(`{"role":"user","content":"hello"},`), 500)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		req, _ := http.NewRequest(http.MethodPost, "http://upstream.test/v1/chat/completions", bytes.NewReader(body))
+		resp, err := client.Do(req)
+		if err != nil {
+			b.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+}
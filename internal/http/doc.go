@@ -8,10 +8,20 @@
 // Key features:
 //   - Connection pooling (configurable idle connections and per-host limits)
 //   - Retry logic with exponential backoff and jitter (429, 500, 502, 503, 504)
+//   - Per-provider retry budgets (RetryBudget); retries never outlast the
+//     context deadline
 //   - Rate limit header parsing (OpenAI, Anthropic, Google formats)
 //   - Context propagation and cancellation support
 //   - API key sanitization in logs
 //   - Request/response hooks for interception
+//   - VCR-style record/replay of sanitized interactions (Recorder)
+//   - Transport wrapping for fault injection (WrapTransport)
+//   - Per-provider transport pools with httptrace metrics (Pool)
+//   - Protocol selection (HTTP/1.1, HTTP/2, h2c, experimental HTTP/3 behind
+//     the http3 build tag) with automatic fallback
+//   - Egress proxies (HTTP CONNECT, SOCKS5) and mutual TLS per transport
+//   - Idempotency keys shared across retries and HMAC request signing
+//   - Response size, decompression ratio and read deadline limits (LimitError)
 //   - Thread-safe operations verified by race detector
 //
 // Example usage:
@@ -65,4 +65,8 @@ type Response struct {
 	*http.Response
 	RateLimit *RateLimitInfo // Parsed rate limit information (nil if not available)
 	Attempt   int            // Number of attempts made (0-indexed)
+
+	// RetryStopped is why a retryable response was returned before
+	// MaxAttempts (ErrRetryPastDeadline or ErrRetryBudgetExhausted), or nil
+	RetryStopped error
 }
@@ -0,0 +1,57 @@
+//go:build http3
+
+package http
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const http3Available = true
+
+// HTTP3Factory builds an HTTP/3 round tripper for a transport config. The
+// TLS config already reflects cfg.TLS.
+type HTTP3Factory func(cfg TransportConfig, tlsConfig *tls.Config) (http.RoundTripper, error)
+
+var (
+	http3Mu      sync.RWMutex
+	http3Factory HTTP3Factory
+)
+
+// RegisterHTTP3 installs the HTTP/3 implementation used for ProtocolHTTP3.
+// The standard library has no QUIC client, so builds with the http3 tag
+// register one from a file of their own, for example:
+//
+//	//go:build http3
+//
+//	func init() {
+//	    mshttp.RegisterHTTP3(func(cfg mshttp.TransportConfig, tlsConfig *tls.Config) (http.RoundTripper, error) {
+//	        return &http3.Transport{TLSClientConfig: tlsConfig}, nil
+//	    })
+//	}
+func RegisterHTTP3(f HTTP3Factory) {
+	http3Mu.Lock()
+	http3Factory = f
+	http3Mu.Unlock()
+}
+
+// newHTTP3Transport builds the HTTP/3 transport with the registered factory
+func newHTTP3Transport(c TransportConfig) (http.RoundTripper, error) {
+	http3Mu.RLock()
+	factory := http3Factory
+	http3Mu.RUnlock()
+	if factory == nil {
+		return nil, fmt.Errorf("no HTTP/3 transport registered (see RegisterHTTP3)")
+	}
+
+	tlsConfig := &tls.Config{}
+	if c.TLS != nil {
+		var err error
+		if tlsConfig, err = c.TLS.build(); err != nil {
+			return nil, err
+		}
+	}
+	return factory(c, tlsConfig)
+}
@@ -0,0 +1,15 @@
+//go:build !http3
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const http3Available = false
+
+// newHTTP3Transport reports that HTTP/3 was not compiled in
+func newHTTP3Transport(TransportConfig) (http.RoundTripper, error) {
+	return nil, fmt.Errorf("protocol http3 requires a build with -tags http3")
+}
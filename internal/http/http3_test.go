@@ -0,0 +1,45 @@
+//go:build http3
+
+package http
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHTTP3Fallback(t *testing.T) {
+	h2Server, h2TLS := newProtoServer(t, true)
+
+	RegisterHTTP3(nil)
+	if _, err := NewRoundTripper(TransportConfig{Protocol: ProtocolHTTP3}); err == nil || !strings.Contains(err.Error(), "RegisterHTTP3") {
+		t.Errorf("expected missing factory error, got %v", err)
+	}
+
+	var gotTLS *tls.Config
+	RegisterHTTP3(func(cfg TransportConfig, tlsConfig *tls.Config) (http.RoundTripper, error) {
+		gotTLS = tlsConfig
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			req.Body.Close()
+			return nil, errors.New("quic: no route")
+		}), nil
+	})
+	defer RegisterHTTP3(nil)
+
+	rt, err := NewRoundTripper(TransportConfig{Protocol: ProtocolHTTP3, TLS: h2TLS})
+	if err != nil {
+		t.Fatalf("NewRoundTripper() error = %v", err)
+	}
+	if gotTLS == nil || gotTLS.RootCAs == nil {
+		t.Error("expected factory to receive the configured TLS settings")
+	}
+	got, err := post(t, rt, h2Server.URL, "hi")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got != "HTTP/2.0 hi" {
+		t.Errorf("got %q, want fallback over HTTP/2", got)
+	}
+}
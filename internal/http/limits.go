@@ -0,0 +1,193 @@
+package http
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Errors wrapped by LimitError
+var (
+	ErrBodyTooLarge        = errors.New("response body too large")
+	ErrDecompressionRatio  = errors.New("response decompression ratio exceeded")
+	ErrResponseReadTimeout = errors.New("response read timed out")
+)
+
+// LimitError is returned from a response body read that exceeded a
+// ResponseLimits limit. Use errors.Is with the Err sentinels to tell them
+// apart.
+type LimitError struct {
+	Err   error  // ErrBodyTooLarge, ErrDecompressionRatio or ErrResponseReadTimeout
+	Limit string // The limit that was hit, e.g. "1048576 bytes"
+	Read  int64  // Body bytes delivered before the limit was hit
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v (limit %s, read %d bytes)", e.Err, e.Limit, e.Read)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// ResponseLimits bounds what a response body may cost. Zero values disable
+// the corresponding limit.
+type ResponseLimits struct {
+	MaxBodyBytes          int64         // Decoded body size
+	MaxDecompressionRatio float64       // Decoded to compressed size for gzip bodies (zip bomb protection)
+	ReadTimeout           time.Duration // Longest wait for each body read, independent of the overall timeout
+}
+
+// enabled reports whether any limit is set
+func (l ResponseLimits) enabled() bool {
+	return l.MaxBodyBytes > 0 || l.MaxDecompressionRatio > 0 || l.ReadTimeout > 0
+}
+
+// LimitTransport wraps base (http.DefaultTransport when nil) so response
+// bodies fail with a *LimitError once they exceed limits. When a
+// decompression ratio is set and the request does not choose its own
+// Accept-Encoding, gzip is requested and decoded here instead of by base, so
+// the ratio can be measured.
+func LimitTransport(base http.RoundTripper, limits ResponseLimits) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &limitTransport{base: base, limits: limits}
+}
+
+type limitTransport struct {
+	base   http.RoundTripper
+	limits ResponseLimits
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	decode := false
+	if t.limits.MaxDecompressionRatio > 0 && req.Header.Get("Accept-Encoding") == "" && req.Method != http.MethodHead {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", "gzip")
+		decode = true
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &limitedBody{body: resp.Body, limits: t.limits}
+	if decode && strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		body.compressed = &countingReader{r: resp.Body}
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+	resp.Body = body
+	return resp, nil
+}
+
+// CloseIdleConnections closes idle connections of the underlying transport
+func (t *limitTransport) CloseIdleConnections() {
+	if ci, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		ci.CloseIdleConnections()
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// limitedBody enforces ResponseLimits while a body is read
+type limitedBody struct {
+	body       io.ReadCloser
+	limits     ResponseLimits
+	compressed *countingReader // Set when the body is gzip decoded here
+	decoder    *gzip.Reader
+	read       int64
+	err        error // Sticky limit error
+
+	timedOut  atomic.Bool
+	closeOnce sync.Once
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.limits.MaxBodyBytes > 0 && int64(len(p)) > b.limits.MaxBodyBytes-b.read+1 {
+		// Read at most one byte past the limit to detect overflow
+		p = p[:b.limits.MaxBodyBytes-b.read+1]
+	}
+
+	if b.limits.ReadTimeout > 0 {
+		timer := time.AfterFunc(b.limits.ReadTimeout, func() {
+			b.timedOut.Store(true)
+			b.closeBody()
+		})
+		defer timer.Stop()
+	}
+
+	n, err := b.readDecoded(p)
+	if b.timedOut.Load() {
+		return 0, b.fail(ErrResponseReadTimeout, b.limits.ReadTimeout.String())
+	}
+	b.read += int64(n)
+
+	if b.limits.MaxBodyBytes > 0 && b.read > b.limits.MaxBodyBytes {
+		n -= int(b.read - b.limits.MaxBodyBytes)
+		b.read = b.limits.MaxBodyBytes
+		return n, b.fail(ErrBodyTooLarge, fmt.Sprintf("%d bytes", b.limits.MaxBodyBytes))
+	}
+	if b.compressed != nil && b.limits.MaxDecompressionRatio > 0 && b.compressed.n > 0 &&
+		float64(b.read)/float64(b.compressed.n) > b.limits.MaxDecompressionRatio {
+		return n, b.fail(ErrDecompressionRatio, fmt.Sprintf("%gx", b.limits.MaxDecompressionRatio))
+	}
+	return n, err
+}
+
+// readDecoded reads from the body, through the gzip decoder if there is one
+func (b *limitedBody) readDecoded(p []byte) (int, error) {
+	if b.compressed == nil {
+		return b.body.Read(p)
+	}
+	if b.decoder == nil {
+		decoder, err := gzip.NewReader(b.compressed)
+		if err != nil {
+			return 0, err
+		}
+		b.decoder = decoder
+	}
+	return b.decoder.Read(p)
+}
+
+// fail records a limit error and closes the connection so the rest of the
+// body is not downloaded
+func (b *limitedBody) fail(err error, limit string) error {
+	b.err = &LimitError{Err: err, Limit: limit, Read: b.read}
+	b.closeBody()
+	return b.err
+}
+
+func (b *limitedBody) closeBody() {
+	b.closeOnce.Do(func() { b.body.Close() })
+}
+
+func (b *limitedBody) Close() error {
+	var err error
+	b.closeOnce.Do(func() { err = b.body.Close() })
+	return err
+}
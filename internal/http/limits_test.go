@@ -0,0 +1,179 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimitTransportMaxBodyBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		max     int64
+		wantLen int
+		wantErr bool
+	}{
+		{"under limit", 200, 100, false},
+		{"exact limit", 100, 100, false},
+		{"over limit", 40, 40, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: LimitTransport(nil, ResponseLimits{MaxBodyBytes: tt.max})}
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+
+			if len(body) != tt.wantLen {
+				t.Errorf("read %d bytes, want %d", len(body), tt.wantLen)
+			}
+			var limitErr *LimitError
+			if tt.wantErr != errors.As(err, &limitErr) {
+				t.Fatalf("ReadAll() error = %v, want limit error %v", err, tt.wantErr)
+			}
+			if tt.wantErr && (!errors.Is(err, ErrBodyTooLarge) || limitErr.Read != tt.max) {
+				t.Errorf("unexpected limit error: %+v", limitErr)
+			}
+		})
+	}
+}
+
+func TestLimitTransportDecompressionRatio(t *testing.T) {
+	gzipped := func(data []byte) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(data)
+		zw.Close()
+		return buf.Bytes()
+	}
+	bomb := gzipped(bytes.Repeat([]byte{0}, 10<<20)) // 10 MiB of zeros, ~10 KiB compressed
+	normal := gzipped([]byte(`{"id":"chatcmpl-1","choices":[{"message":{"content":"hello there"}}]}`))
+
+	var acceptEncoding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Encoding", "gzip")
+		if r.URL.Path == "/bomb" {
+			w.Write(bomb)
+			return
+		}
+		w.Write(normal)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: LimitTransport(nil, ResponseLimits{MaxDecompressionRatio: 100})}
+
+	resp, err := client.Get(server.URL + "/normal")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || !strings.Contains(string(body), "hello there") {
+		t.Errorf("normal body = %q, %v", body, err)
+	}
+	if acceptEncoding != "gzip" || resp.Header.Get("Content-Encoding") != "" || !resp.Uncompressed {
+		t.Errorf("expected gzip to be requested and decoded by the transport (accept %q, encoding %q)", acceptEncoding, resp.Header.Get("Content-Encoding"))
+	}
+
+	resp, err = client.Get(server.URL + "/bomb")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	n, err := io.Copy(io.Discard, resp.Body)
+	if !errors.Is(err, ErrDecompressionRatio) {
+		t.Fatalf("expected ErrDecompressionRatio, got %v after %d bytes", err, n)
+	}
+	if n >= 10<<20 {
+		t.Errorf("read the whole bomb (%d bytes) before failing", n)
+	}
+}
+
+func TestLimitTransportReadTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first chunk"))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := &http.Client{Transport: LimitTransport(nil, ResponseLimits{ReadTimeout: 50 * time.Millisecond})}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	start := time.Now()
+	body, err := io.ReadAll(resp.Body)
+	if !errors.Is(err, ErrResponseReadTimeout) {
+		t.Fatalf("expected ErrResponseReadTimeout, got %v", err)
+	}
+	if string(body) != "first chunk" {
+		t.Errorf("body before the stall = %q", body)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("read timeout took %v", elapsed)
+	}
+}
+
+func TestClientResponseLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 1024)))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL, ResponseLimits: ResponseLimits{MaxBodyBytes: 512}})
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("expected ErrBodyTooLarge, got %v", err)
+	}
+}
+
+func TestPoolResponseLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 1024)))
+	}))
+	defer server.Close()
+
+	pool, err := NewPool(TransportConfig{}, map[string]TransportConfig{"test": {MaxResponseBytes: 16}})
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	req, _ := http.NewRequestWithContext(WithProvider(context.Background(), "test"), http.MethodGet, server.URL, nil)
+	resp, err := pool.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("expected the provider override to limit the body, got %v", err)
+	}
+
+	if err := (TransportConfig{MaxDecompressionRatio: 0.5}).Validate(); err == nil {
+		t.Error("expected error for a decompression ratio below 1")
+	}
+}
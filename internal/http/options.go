@@ -2,6 +2,7 @@ package http
 
 import (
 	"log"
+	"net/http"
 	"time"
 )
 
@@ -22,9 +23,36 @@ type Config struct {
 	MaxConnsPerHost     int           // Maximum total connections per host (default: 10)
 	IdleConnTimeout     time.Duration // How long idle connections stay open (default: 90s)
 
+	// Transport replaces the pooled transport when set (e.g. a Recorder
+	// for record/replay); the connection pool settings are then ignored.
+	Transport http.RoundTripper
+
+	// ResponseLimits bounds response body size, gzip expansion and the wait
+	// for each body read; reads past a limit fail with *LimitError.
+	ResponseLimits ResponseLimits
+
+	// WrapTransport, when set, wraps the final transport, e.g. with
+	// chaos.Injector.Middleware for fault injection.
+	WrapTransport func(http.RoundTripper) http.RoundTripper
+
 	// Retry configuration
 	Retry RetryConfig
 
+	// RetryBudget, when set, caps retries per provider (the provider tag
+	// from WithProvider, else the request host). Share one budget between
+	// clients to bound retries globally.
+	RetryBudget *RetryBudget
+
+	// IdempotencyHeader, when set (usually DefaultIdempotencyHeader), adds
+	// an idempotency key to POST and PATCH requests. One key is used for all
+	// attempts of a Do call; a key already in the header or set with
+	// WithIdempotencyKey is kept.
+	IdempotencyHeader string
+
+	// Signer, when set, signs every attempt after BeforeRequest runs, e.g.
+	// NewHMACSigner so gateways can verify the request came from modelscan.
+	Signer SignHook
+
 	// Hooks for request/response interception
 	BeforeRequest BeforeRequestHook // Called before each request attempt
 	AfterResponse AfterResponseHook // Called after each successful response
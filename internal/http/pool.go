@@ -0,0 +1,418 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultPoolName is the Pool entry used for requests without a provider
+const DefaultPoolName = "default"
+
+// TimingStats summarizes one connection phase
+type TimingStats struct {
+	Count int64   `json:"count"`
+	AvgMs float64 `json:"avg_ms"`
+	MaxMs float64 `json:"max_ms"`
+}
+
+// PoolStats is a snapshot of a transport's connection pool
+type PoolStats struct {
+	Name        string           `json:"name,omitempty"`
+	InFlight    int64            `json:"in_flight"`           // Requests waiting for or reading a response
+	OpenConns   int64            `json:"open_conns"`          // Connections dialed and not yet closed
+	IdleConns   int64            `json:"idle_conns"`          // Open connections not serving a request (approximate with HTTP/2)
+	Requests    int64            `json:"requests"`            // Requests started
+	Errors      int64            `json:"errors"`              // Requests failing without a response
+	ConnsDialed int64            `json:"conns_dialed"`        // New connections
+	ConnsReused int64            `json:"conns_reused"`        // Requests served by an existing connection
+	Fallbacks   int64            `json:"fallbacks"`           // Requests retried over auto after a forced protocol failed
+	Protocols   map[string]int64 `json:"protocols,omitempty"` // Responses by protocol ("HTTP/2.0", ...)
+	DNS         TimingStats      `json:"dns"`
+	Connect     TimingStats      `json:"connect"`
+	TLS         TimingStats      `json:"tls_handshake"`
+	FirstByte   TimingStats      `json:"first_byte"` // Request written to first response byte
+}
+
+// timing accumulates durations for one phase
+type timing struct {
+	count int64
+	total time.Duration
+	max   time.Duration
+}
+
+func (t *timing) add(d time.Duration) {
+	t.count++
+	t.total += d
+	if d > t.max {
+		t.max = d
+	}
+}
+
+func (t *timing) stats() TimingStats {
+	s := TimingStats{Count: t.count, MaxMs: float64(t.max) / float64(time.Millisecond)}
+	if t.count > 0 {
+		s.AvgMs = float64(t.total) / float64(t.count) / float64(time.Millisecond)
+	}
+	return s
+}
+
+// poolCounters is shared by the transports serving one pool entry, so
+// counts survive a transport being replaced
+type poolCounters struct {
+	inFlight  atomic.Int64
+	open      atomic.Int64
+	requests  atomic.Int64
+	errors    atomic.Int64
+	dialed    atomic.Int64
+	reused    atomic.Int64
+	fallbacks atomic.Int64
+
+	mu        sync.Mutex
+	dns       timing
+	connect   timing
+	tls       timing
+	firstByte timing
+	protocols map[string]int64
+}
+
+func (c *poolCounters) record(t *timing, d time.Duration) {
+	c.mu.Lock()
+	t.add(d)
+	c.mu.Unlock()
+}
+
+func (c *poolCounters) snapshot(name string) PoolStats {
+	s := PoolStats{
+		Name:        name,
+		InFlight:    c.inFlight.Load(),
+		OpenConns:   c.open.Load(),
+		Requests:    c.requests.Load(),
+		Errors:      c.errors.Load(),
+		ConnsDialed: c.dialed.Load(),
+		ConnsReused: c.reused.Load(),
+		Fallbacks:   c.fallbacks.Load(),
+	}
+	if idle := s.OpenConns - s.InFlight; idle > 0 {
+		s.IdleConns = idle
+	}
+	c.mu.Lock()
+	s.DNS = c.dns.stats()
+	s.Connect = c.connect.stats()
+	s.TLS = c.tls.stats()
+	s.FirstByte = c.firstByte.stats()
+	if len(c.protocols) > 0 {
+		s.Protocols = make(map[string]int64, len(c.protocols))
+		for proto, n := range c.protocols {
+			s.Protocols[proto] = n
+		}
+	}
+	c.mu.Unlock()
+	return s
+}
+
+// countDials wraps a dial function to track open connections
+func (c *poolCounters) countDials(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		c.dialed.Add(1)
+		c.open.Add(1)
+		return &countedConn{Conn: conn, counters: c}, nil
+	}
+}
+
+// countedConn decrements the open count once when closed
+type countedConn struct {
+	net.Conn
+	counters *poolCounters
+	once     sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(func() { c.counters.open.Add(-1) })
+	return c.Conn.Close()
+}
+
+// InstrumentedTransport records pool and httptrace timing metrics for the
+// requests it carries
+type InstrumentedTransport struct {
+	base     http.RoundTripper
+	counters *poolCounters
+}
+
+// Instrument wraps base with pool metrics. Open and idle connection counts
+// are only tracked for transports built by a Pool.
+func Instrument(base http.RoundTripper) *InstrumentedTransport {
+	return &InstrumentedTransport{base: base, counters: &poolCounters{}}
+}
+
+// Stats returns a snapshot of the transport's metrics
+func (t *InstrumentedTransport) Stats() PoolStats {
+	return t.counters.snapshot("")
+}
+
+// CloseIdleConnections closes idle connections of the underlying transport
+func (t *InstrumentedTransport) CloseIdleConnections() {
+	if ci, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		ci.CloseIdleConnections()
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *InstrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(t.counters.begin(req))
+	return t.counters.end(resp, err)
+}
+
+// begin counts a request and attaches an httptrace recording connection
+// phase timings
+func (c *poolCounters) begin(req *http.Request) *http.Request {
+	c.requests.Add(1)
+	c.inFlight.Add(1)
+
+	var dnsStart, connectStart, tlsStart time.Time
+	// The request is written on the transport's write goroutine and the
+	// response read on another
+	var wrote atomic.Int64
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			if !dnsStart.IsZero() {
+				c.record(&c.dns, time.Since(dnsStart))
+			}
+		},
+		ConnectStart: func(string, string) { connectStart = time.Now() },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil && !connectStart.IsZero() {
+				c.record(&c.connect, time.Since(connectStart))
+			}
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil && !tlsStart.IsZero() {
+				c.record(&c.tls, time.Since(tlsStart))
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				c.reused.Add(1)
+			}
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) { wrote.Store(time.Now().UnixNano()) },
+		GotFirstResponseByte: func() {
+			if at := wrote.Load(); at != 0 {
+				c.record(&c.firstByte, time.Since(time.Unix(0, at)))
+			}
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// end finishes a request started with begin. The request stays in flight
+// until the response body is closed.
+func (c *poolCounters) end(resp *http.Response, err error) (*http.Response, error) {
+	if err != nil {
+		c.errors.Add(1)
+		c.inFlight.Add(-1)
+		return resp, err
+	}
+	c.mu.Lock()
+	if c.protocols == nil {
+		c.protocols = make(map[string]int64)
+	}
+	c.protocols[resp.Proto]++
+	c.mu.Unlock()
+	resp.Body = &inFlightBody{ReadCloser: resp.Body, counters: c}
+	return resp, nil
+}
+
+// inFlightBody ends a request's in-flight period when the body is closed
+type inFlightBody struct {
+	io.ReadCloser
+	counters *poolCounters
+	once     sync.Once
+}
+
+func (b *inFlightBody) Close() error {
+	b.once.Do(func() { b.counters.inFlight.Add(-1) })
+	return b.ReadCloser.Close()
+}
+
+type providerKey struct{}
+
+// WithProvider tags a request context with the provider it is sent to, so
+// transports shared across providers (Pool, fault injection) can tell them
+// apart
+func WithProvider(ctx context.Context, provider string) context.Context {
+	return context.WithValue(ctx, providerKey{}, provider)
+}
+
+// ProviderFrom returns the provider set by WithProvider
+func ProviderFrom(ctx context.Context) string {
+	provider, _ := ctx.Value(providerKey{}).(string)
+	return provider
+}
+
+// Pool is an http.RoundTripper keeping one instrumented transport per
+// provider, each built from the defaults plus that provider's overrides.
+// The provider is read from the request context (see WithProvider).
+type Pool struct {
+	mu         sync.RWMutex
+	defaults   TransportConfig
+	overrides  map[string]TransportConfig
+	transports map[string]*InstrumentedTransport
+	counters   map[string]*poolCounters
+}
+
+// NewPool creates a pool. Zero fields in defaults use DefaultTransportConfig;
+// zero fields in an override inherit from defaults.
+func NewPool(defaults TransportConfig, overrides map[string]TransportConfig) (*Pool, error) {
+	p := &Pool{
+		defaults:   DefaultTransportConfig().Overlay(defaults),
+		overrides:  make(map[string]TransportConfig),
+		transports: make(map[string]*InstrumentedTransport),
+		counters:   make(map[string]*poolCounters),
+	}
+	if err := p.defaults.Validate(); err != nil {
+		return nil, err
+	}
+	for provider, o := range overrides {
+		if err := p.defaults.Overlay(o).Validate(); err != nil {
+			return nil, fmt.Errorf("transport override for %s: %w", provider, err)
+		}
+		p.overrides[provider] = o
+	}
+	return p, nil
+}
+
+// RoundTrip implements http.RoundTripper using the request's provider
+func (p *Pool) RoundTrip(req *http.Request) (*http.Response, error) {
+	t, err := p.transport(ProviderFrom(req.Context()))
+	if err != nil {
+		return nil, err
+	}
+	return t.RoundTrip(req)
+}
+
+// Defaults returns the settings providers inherit
+func (p *Pool) Defaults() TransportConfig {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.defaults
+}
+
+// Config returns the effective transport settings for a provider
+func (p *Pool) Config(provider string) TransportConfig {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.defaults.Overlay(p.overrides[provider])
+}
+
+// Overrides returns a copy of the per-provider overrides
+func (p *Pool) Overrides() map[string]TransportConfig {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	out := make(map[string]TransportConfig, len(p.overrides))
+	for k, v := range p.overrides {
+		out[k] = v
+	}
+	return out
+}
+
+// SetOverride replaces a provider's overrides. The provider's transport is
+// rebuilt; requests already running finish on the old one.
+func (p *Pool) SetOverride(provider string, o TransportConfig) error {
+	if provider == "" {
+		return fmt.Errorf("provider is required")
+	}
+	if err := p.defaults.Overlay(o).Validate(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.overrides[provider] = o
+	old := p.transports[provider]
+	delete(p.transports, provider)
+	p.mu.Unlock()
+	if old != nil {
+		old.CloseIdleConnections()
+	}
+	return nil
+}
+
+// RemoveOverride drops a provider's overrides, reporting whether it had any
+func (p *Pool) RemoveOverride(provider string) bool {
+	p.mu.Lock()
+	_, ok := p.overrides[provider]
+	delete(p.overrides, provider)
+	old := p.transports[provider]
+	delete(p.transports, provider)
+	p.mu.Unlock()
+	if old != nil {
+		old.CloseIdleConnections()
+	}
+	return ok
+}
+
+// Stats returns pool metrics for every provider that sent requests, sorted
+// by name
+func (p *Pool) Stats() []PoolStats {
+	p.mu.RLock()
+	stats := make([]PoolStats, 0, len(p.counters))
+	for name, c := range p.counters {
+		stats = append(stats, c.snapshot(name))
+	}
+	p.mu.RUnlock()
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// CloseIdleConnections closes idle connections of every transport
+func (p *Pool) CloseIdleConnections() {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, t := range p.transports {
+		t.CloseIdleConnections()
+	}
+}
+
+// transport returns the provider's transport, building it on first use
+func (p *Pool) transport(provider string) (*InstrumentedTransport, error) {
+	if provider == "" {
+		provider = DefaultPoolName
+	}
+	p.mu.RLock()
+	t := p.transports[provider]
+	p.mu.RUnlock()
+	if t != nil {
+		return t, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t := p.transports[provider]; t != nil {
+		return t, nil
+	}
+	counters := p.counters[provider]
+	if counters == nil {
+		counters = &poolCounters{}
+		p.counters[provider] = counters
+	}
+	base, err := p.defaults.Overlay(p.overrides[provider]).roundTripper(counters)
+	if err != nil {
+		return nil, err
+	}
+	t = &InstrumentedTransport{base: base, counters: counters}
+	p.transports[provider] = t
+	return t, nil
+}
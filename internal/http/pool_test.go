@@ -0,0 +1,227 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPoolStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	pool, err := NewPool(TransportConfig{}, nil)
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+	client := &http.Client{Transport: pool}
+
+	get := func(provider string) {
+		ctx := context.Background()
+		if provider != "" {
+			ctx = WithProvider(ctx, provider)
+		}
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	for i := 0; i < 3; i++ {
+		get("openai")
+	}
+	get("")
+
+	stats := pool.Stats()
+	if len(stats) != 2 || stats[0].Name != DefaultPoolName || stats[1].Name != "openai" {
+		t.Fatalf("unexpected pools: %+v", stats)
+	}
+	openai := stats[1]
+	if openai.Requests != 3 || openai.InFlight != 0 || openai.Errors != 0 {
+		t.Errorf("unexpected counters: %+v", openai)
+	}
+	if openai.ConnsDialed != 1 || openai.ConnsReused != 2 {
+		t.Errorf("dialed = %d, reused = %d, want 1 and 2", openai.ConnsDialed, openai.ConnsReused)
+	}
+	if openai.OpenConns != 1 || openai.IdleConns != 1 {
+		t.Errorf("open = %d, idle = %d, want 1 and 1", openai.OpenConns, openai.IdleConns)
+	}
+	if openai.Connect.Count != 1 || openai.FirstByte.Count != 3 {
+		t.Errorf("unexpected timings: connect %+v, first byte %+v", openai.Connect, openai.FirstByte)
+	}
+
+	pool.CloseIdleConnections()
+	if s := pool.Stats()[1]; s.OpenConns != 0 {
+		t.Errorf("open conns after close = %d, want 0", s.OpenConns)
+	}
+}
+
+func TestPoolStatsInFlight(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	transport := Instrument(http.DefaultTransport)
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := transport.Stats().InFlight; got != 1 {
+		t.Errorf("in flight while reading = %d, want 1", got)
+	}
+	resp.Body.Close()
+	if got := transport.Stats().InFlight; got != 0 {
+		t.Errorf("in flight after close = %d, want 0", got)
+	}
+}
+
+// A request body is written on the transport's write goroutine while the
+// response is read on another, so the first byte timing is shared between
+// them; run with -race
+func TestInstrumentedTransportRequestBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	transport := Instrument(http.DefaultTransport.(*http.Transport).Clone())
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport}
+
+	body := strings.Repeat("context ", 5000)
+	const requests = 3
+	for i := 0; i < requests; i++ {
+		resp, err := client.Post(server.URL, "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	stats := transport.Stats()
+	if stats.Requests != requests || stats.InFlight != 0 || stats.FirstByte.Count != requests {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestPoolErrors(t *testing.T) {
+	pool, _ := NewPool(TransportConfig{DialTimeoutMs: 100}, nil)
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1:1", nil)
+	if _, err := pool.RoundTrip(req); err == nil {
+		t.Fatal("expected connection error")
+	}
+	s := pool.Stats()[0]
+	if s.Errors != 1 || s.InFlight != 0 {
+		t.Errorf("unexpected counters: %+v", s)
+	}
+}
+
+func TestPoolOverrides(t *testing.T) {
+	off := false
+	pool, err := NewPool(TransportConfig{MaxIdleConnsPerHost: 20}, map[string]TransportConfig{
+		"deepseek": {HTTP2: &off, MaxConnsPerHost: 4},
+	})
+	if err != nil {
+		t.Fatalf("NewPool() error = %v", err)
+	}
+
+	cfg := pool.Config("deepseek")
+	if cfg.MaxConnsPerHost != 4 || cfg.MaxIdleConnsPerHost != 20 || cfg.MaxIdleConns != 100 {
+		t.Errorf("unexpected effective config: %+v", cfg)
+	}
+	if cfg := pool.Config("openai"); cfg.MaxConnsPerHost != 0 || cfg.HTTP2 != nil {
+		t.Errorf("provider without override got %+v", cfg)
+	}
+
+	tr, err := pool.transport("deepseek")
+	if err != nil {
+		t.Fatalf("transport() error = %v", err)
+	}
+	base := tr.base.(*http.Transport)
+	if base.TLSNextProto == nil || base.ForceAttemptHTTP2 || base.MaxConnsPerHost != 4 {
+		t.Errorf("HTTP/2 override not applied: %+v", base)
+	}
+
+	if err := pool.SetOverride("deepseek", TransportConfig{MaxConnsPerHost: -1}); err == nil {
+		t.Error("expected error for negative max_conns_per_host")
+	}
+	if err := pool.SetOverride("deepseek", TransportConfig{MaxConnsPerHost: 8}); err != nil {
+		t.Fatalf("SetOverride() error = %v", err)
+	}
+	if rebuilt, _ := pool.transport("deepseek"); rebuilt == tr || rebuilt.base.(*http.Transport).MaxConnsPerHost != 8 {
+		t.Error("expected transport to be rebuilt with the new override")
+	}
+
+	if !pool.RemoveOverride("deepseek") || pool.RemoveOverride("deepseek") {
+		t.Error("RemoveOverride() should report the override only once")
+	}
+	if len(pool.Overrides()) != 0 {
+		t.Errorf("overrides = %v, want none", pool.Overrides())
+	}
+}
+
+func TestTransportConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     TransportConfig
+		wantErr string
+	}{
+		{"defaults", DefaultTransportConfig(), ""},
+		{"negative", TransportConfig{IdleConnTimeoutMs: -1}, "idle_conn_timeout_ms"},
+		{"tls 1.3", TransportConfig{TLS: &TLSConfig{MinVersion: "1.3"}}, ""},
+		{"bad tls version", TransportConfig{TLS: &TLSConfig{MinVersion: "1.1"}}, "min_version"},
+		{"missing ca file", TransportConfig{TLS: &TLSConfig{CAFile: "/nonexistent/ca.pem"}}, "CA file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientPoolStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL})
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	s := client.PoolStats()
+	if s.Requests != 2 || s.InFlight != 0 || s.ConnsDialed != 1 {
+		t.Errorf("unexpected pool stats: %+v", s)
+	}
+}
@@ -0,0 +1,354 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
+)
+
+// RecorderMode selects whether a Recorder captures live traffic or serves
+// previously captured responses.
+type RecorderMode int
+
+const (
+	// ModeRecord forwards requests upstream and captures each interaction.
+	ModeRecord RecorderMode = iota + 1
+
+	// ModeReplay serves responses from the cassette without touching the
+	// network. Requests with no recorded match fail with ErrNoInteraction.
+	ModeReplay
+)
+
+// ErrNoInteraction is returned in replay mode when a request has no match
+// in the cassette.
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// redacted replaces secrets in recorded interactions.
+const redacted = "REDACTED"
+
+// sensitiveHeaders are replaced with redacted before an interaction is stored.
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"Api-Key",
+	"X-Goog-Api-Key",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// sensitiveParams are query parameters replaced with redacted before an
+// interaction is stored or matched.
+var sensitiveParams = []string{"key", "api_key", "apikey", "access_token", "token"}
+
+// RecordedRequest is the sanitized request half of an interaction.
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the sanitized response half of an interaction.
+// Binary bodies are stored base64 encoded.
+type RecordedResponse struct {
+	Status       int         `json:"status"`
+	Headers      http.Header `json:"headers,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+// Interaction is one request/response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+
+	// DurationMs is how long the live request took, headers to full body.
+	DurationMs int64 `json:"duration_ms,omitempty"`
+}
+
+// Cassette is the on-disk collection of interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// RecorderConfig configures a Recorder.
+type RecorderConfig struct {
+	// Path is the cassette file (JSON).
+	Path string
+
+	// Mode is ModeRecord or ModeReplay.
+	Mode RecorderMode
+
+	// Transport performs real requests in record mode (default: http.DefaultTransport).
+	Transport http.RoundTripper
+
+	// Secrets are literal values (such as API keys) scrubbed from URLs,
+	// headers and bodies in addition to the well-known auth headers.
+	Secrets []string
+}
+
+// Recorder is an http.RoundTripper that records interactions to a cassette
+// or replays them from one, VCR style. Use it as Config.Transport or as
+// the Transport of any http.Client.
+//
+// Example:
+//
+//	rec, _ := http.NewRecorder(http.RecorderConfig{
+//	    Path: "testdata/cassettes/openai.json",
+//	    Mode: http.ModeReplay,
+//	})
+//	client := http.NewClient(http.Config{BaseURL: "https://api.openai.com/v1", Transport: rec})
+type Recorder struct {
+	config   RecorderConfig
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder creates a recorder. Replay mode loads the cassette, which must
+// exist; record mode starts an empty cassette that Save writes to disk.
+func NewRecorder(cfg RecorderConfig) (*Recorder, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("cassette path is required")
+	}
+	if cfg.Transport == nil {
+		cfg.Transport = http.DefaultTransport
+	}
+
+	r := &Recorder{config: cfg}
+	switch cfg.Mode {
+	case ModeRecord:
+	case ModeReplay:
+		data, err := os.ReadFile(cfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("invalid cassette %s: %w", cfg.Path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	default:
+		return nil, fmt.Errorf("invalid recorder mode %d", cfg.Mode)
+	}
+	return r, nil
+}
+
+// RoundTrip records or replays a single request.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+	recorded := r.recordRequest(req, body)
+
+	if r.config.Mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+
+	// Send a copy so the caller's request is left untouched
+	out := req.Clone(req.Context())
+	if req.Body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	start := time.Now()
+	resp, err := r.config.Transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request:    recorded,
+		Response:   r.recordResponse(resp, respBody),
+		DurationMs: time.Since(start).Milliseconds(),
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// replay serves the first unused interaction matching the request, falling
+// back to the last used match so repeated calls keep working.
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, in := range r.cassette.Interactions {
+		if !sameRequest(in.Request, recorded) {
+			continue
+		}
+		match = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("%w for %s %s", ErrNoInteraction, recorded.Method, recorded.URL)
+	}
+	r.used[match] = true
+
+	rec := r.cassette.Interactions[match].Response
+	body := []byte(rec.Body)
+	if rec.BodyEncoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(rec.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recorded body: %w", err)
+		}
+		body = decoded
+	}
+	header := rec.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Save writes the recorded interactions to the cassette file. It is a
+// no-op in replay mode.
+func (r *Recorder) Save() error {
+	if r.config.Mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.config.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	return os.WriteFile(r.config.Path, append(data, '\n'), 0o644)
+}
+
+// Interactions returns a copy of the recorded or loaded interactions.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// recordRequest builds the sanitized form of a request used for both
+// storage and matching.
+func (r *Recorder) recordRequest(req *http.Request, body []byte) RecordedRequest {
+	return RecordedRequest{
+		Method:  req.Method,
+		URL:     r.scrub(sanitizeURL(req.URL)),
+		Headers: r.sanitizeHeaders(req.Header),
+		Body:    r.scrub(string(body)),
+	}
+}
+
+// recordResponse builds the sanitized form of a response.
+func (r *Recorder) recordResponse(resp *http.Response, body []byte) RecordedResponse {
+	rec := RecordedResponse{
+		Status:  resp.StatusCode,
+		Headers: r.sanitizeHeaders(resp.Header),
+	}
+	rec.Headers.Del("Content-Length")
+	if utf8.Valid(body) {
+		rec.Body = r.scrub(string(body))
+	} else {
+		rec.Body = base64.StdEncoding.EncodeToString(body)
+		rec.BodyEncoding = "base64"
+	}
+	return rec
+}
+
+// sanitizeHeaders copies h with credentials redacted.
+func (r *Recorder) sanitizeHeaders(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, values := range h {
+		out[k] = make([]string, len(values))
+		for i, v := range values {
+			out[k][i] = r.scrub(v)
+		}
+	}
+	for _, k := range sensitiveHeaders {
+		if out.Get(k) != "" {
+			out.Set(k, redacted)
+		}
+	}
+	return out
+}
+
+// scrub replaces configured secret values in s, then masks anything else
+// shaped like a credential.
+func (r *Recorder) scrub(s string) string {
+	for _, secret := range r.config.Secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	return redact.String(s)
+}
+
+// sanitizeURL returns u with credential query parameters redacted.
+func sanitizeURL(u *url.URL) string {
+	clean := *u
+	q := clean.Query()
+	changed := false
+	for _, p := range sensitiveParams {
+		if q.Has(p) {
+			q.Set(p, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		clean.RawQuery = q.Encode()
+	}
+	return clean.String()
+}
+
+// sameRequest reports whether two sanitized requests match. JSON bodies are
+// compared semantically so key order does not matter.
+func sameRequest(a, b RecordedRequest) bool {
+	if a.Method != b.Method || a.URL != b.URL {
+		return false
+	}
+	if a.Body == b.Body {
+		return true
+	}
+	var ja, jb interface{}
+	if json.Unmarshal([]byte(a.Body), &ja) != nil || json.Unmarshal([]byte(b.Body), &jb) != nil {
+		return false
+	}
+	ca, _ := json.Marshal(ja)
+	cb, _ := json.Marshal(jb)
+	return bytes.Equal(ca, cb)
+}
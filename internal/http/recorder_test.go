@@ -0,0 +1,175 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Header().Set("X-Ratelimit-Remaining-Requests", "99")
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(`{"echo":` + string(body) + `,"path":"` + r.URL.Path + `"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassettes", "test.json")
+	rec, err := NewRecorder(RecorderConfig{Path: path, Mode: ModeRecord, Secrets: []string{"sk-secret-123"}})
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	client := NewClient(Config{APIKey: "sk-secret-123", Transport: rec})
+
+	do := func(c *Client, path, body string) (*Response, error) {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL+path+"?key=sk-secret-123", strings.NewReader(body))
+		return c.Do(req)
+	}
+
+	resp, err := do(client, "/v1/chat", `{"a":1,"b":"sk-secret-123"}`)
+	if err != nil {
+		t.Fatalf("record Do() error = %v", err)
+	}
+	live, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if _, err := do(client, "/v1/models", `{}`); err != nil {
+		t.Fatalf("record Do() error = %v", err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-secret-123") || strings.Contains(string(data), "session=abc") {
+		t.Errorf("cassette contains secrets:\n%s", data)
+	}
+	if !strings.Contains(string(data), "X-Ratelimit-Remaining-Requests") {
+		t.Error("cassette should keep non-sensitive headers")
+	}
+
+	// Replay serves the same responses without touching the server
+	server.Close()
+	replayer, err := NewRecorder(RecorderConfig{Path: path, Mode: ModeReplay, Secrets: []string{"sk-secret-123"}})
+	if err != nil {
+		t.Fatalf("NewRecorder(replay) error = %v", err)
+	}
+	client = NewClient(Config{APIKey: "sk-secret-123", Transport: replayer})
+
+	// Key order in JSON bodies does not matter
+	resp, err = do(client, "/v1/chat", `{"b":"sk-secret-123","a":1}`)
+	if err != nil {
+		t.Fatalf("replay Do() error = %v", err)
+	}
+	replayed, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.RateLimit == nil {
+		t.Errorf("replayed status %d, rate limit %v", resp.StatusCode, resp.RateLimit)
+	}
+	if want := strings.ReplaceAll(string(live), "sk-secret-123", redacted); string(replayed) != want {
+		t.Errorf("replayed body = %s, want %s", replayed, want)
+	}
+
+	// Repeated requests reuse the last match
+	for range 2 {
+		if _, err := do(client, "/v1/models", `{}`); err != nil {
+			t.Errorf("repeat replay error = %v", err)
+		}
+	}
+
+	_, err = do(client, "/v1/unknown", `{}`)
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("unrecorded request error = %v, want ErrNoInteraction", err)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("server hit %d times, want 2", hits.Load())
+	}
+}
+
+func TestRecorderMasksUnlistedKeys(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"Incorrect API key provided: sk-proj-Unlisted0123456789abcd"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "unlisted.json")
+	rec, err := NewRecorder(RecorderConfig{Path: path, Mode: ModeRecord})
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/v1/models", nil)
+	if resp, err := NewClient(Config{Transport: rec}).Do(req); err == nil {
+		resp.Body.Close()
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "Unlisted0123456789") || !strings.Contains(string(data), "sk-proj-[REDACTED]") {
+		t.Errorf("cassette not masked:\n%s", data)
+	}
+}
+
+func TestRecorderBinaryBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte{0xff, 0x00, 0xfe})
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "bin.json")
+	rec, _ := NewRecorder(RecorderConfig{Path: path, Mode: ModeRecord})
+	resp, err := (&http.Client{Transport: rec}).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := rec.Interactions()[0].Response.BodyEncoding; got != "base64" {
+		t.Errorf("BodyEncoding = %q, want base64", got)
+	}
+	rec.Save()
+
+	replayer, err := NewRecorder(RecorderConfig{Path: path, Mode: ModeReplay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = (&http.Client{Transport: replayer}).Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "\xff\x00\xfe" {
+		t.Errorf("replayed body = %q", body)
+	}
+}
+
+func TestNewRecorderErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  RecorderConfig
+	}{
+		{"no path", RecorderConfig{Mode: ModeRecord}},
+		{"bad mode", RecorderConfig{Path: "x.json"}},
+		{"missing cassette", RecorderConfig{Path: filepath.Join(t.TempDir(), "none.json"), Mode: ModeReplay}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRecorder(tt.cfg); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
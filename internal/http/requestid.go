@@ -0,0 +1,21 @@
+package http
+
+import "context"
+
+// HeaderRequestID carries the ID that ties a client request to the server's
+// logs and to the upstream requests made for it
+const HeaderRequestID = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID attaches a request ID to a context, so upstream requests
+// made with it carry the same ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns the ID set by WithRequestID
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
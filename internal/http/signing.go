@@ -0,0 +1,151 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultIdempotencyHeader is the idempotency key header understood by most
+// providers and gateways
+const DefaultIdempotencyHeader = "Idempotency-Key"
+
+// Signature headers set by NewHMACSigner
+const (
+	HeaderSignature          = "X-Modelscan-Signature"
+	HeaderSignatureTimestamp = "X-Modelscan-Timestamp"
+)
+
+// ErrInvalidSignature is returned by VerifyHMAC for missing, stale or
+// mismatched signatures
+var ErrInvalidSignature = errors.New("invalid request signature")
+
+// NewIdempotencyKey returns a random key suitable for an idempotency header
+func NewIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type idempotencyKey struct{}
+
+// WithIdempotencyKey attaches a caller-supplied idempotency key to a
+// context, so requests made with it reuse the key instead of generating one
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// IdempotencyKeyFrom returns the key set by WithIdempotencyKey
+func IdempotencyKeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
+
+// SignHook signs an outgoing request. It receives the request body (nil when
+// there is none) and adds its signature as headers.
+type SignHook func(req *http.Request, body []byte) error
+
+// NewHMACSigner signs requests with HMAC-SHA256 over the method, path and
+// query, a Unix timestamp and the SHA-256 of the body, so a downstream
+// gateway holding the secret can verify the request came from modelscan:
+//
+//	X-Modelscan-Timestamp: 1760620800
+//	X-Modelscan-Signature: keyId=prod,algorithm=hmac-sha256,signature=<base64>
+func NewHMACSigner(keyID string, secret []byte) SignHook {
+	return func(req *http.Request, body []byte) error {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HeaderSignatureTimestamp, ts)
+		req.Header.Set(HeaderSignature, fmt.Sprintf("keyId=%s,algorithm=hmac-sha256,signature=%s",
+			keyID, signHMAC(secret, req, ts, body)))
+		return nil
+	}
+}
+
+// VerifyHMAC checks a signature made by NewHMACSigner. secret returns the
+// secret for a key ID (nil for unknown keys); timestamps further than maxSkew
+// from now are rejected.
+func VerifyHMAC(req *http.Request, body []byte, secret func(keyID string) []byte, maxSkew time.Duration) error {
+	ts := req.Header.Get(HeaderSignatureTimestamp)
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing or malformed timestamp", ErrInvalidSignature)
+	}
+	if skew := time.Since(time.Unix(unix, 0)); skew > maxSkew || skew < -maxSkew {
+		return fmt.Errorf("%w: timestamp outside allowed skew", ErrInvalidSignature)
+	}
+
+	params := make(map[string]string)
+	for _, part := range strings.Split(req.Header.Get(HeaderSignature), ",") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+			params[k] = v
+		}
+	}
+	if params["algorithm"] != "hmac-sha256" {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, params["algorithm"])
+	}
+	key := secret(params["keyId"])
+	if key == nil {
+		return fmt.Errorf("%w: unknown key %q", ErrInvalidSignature, params["keyId"])
+	}
+	if !hmac.Equal([]byte(params["signature"]), []byte(signHMAC(key, req, ts, body))) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// signHMAC computes the base64 signature for a request
+func signHMAC(secret []byte, req *http.Request, ts string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s", req.Method, req.URL.RequestURI(), ts, hex.EncodeToString(bodyHash[:]))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SigningTransport wraps base (http.DefaultTransport when nil) so every
+// request is signed just before it is sent, including retries
+func SigningTransport(base http.RoundTripper, sign SignHook) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &signingTransport{base: base, sign: sign}
+}
+
+type signingTransport struct {
+	base http.RoundTripper
+	sign SignHook
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body for signing: %w", err)
+		}
+	}
+
+	// RoundTrippers must not modify the caller's request
+	signed := req.Clone(req.Context())
+	if body != nil {
+		signed.Body = io.NopCloser(bytes.NewReader(body))
+		signed.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	}
+	if err := t.sign(signed, body); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(signed)
+}
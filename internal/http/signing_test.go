@@ -0,0 +1,193 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHMACSignAndVerify(t *testing.T) {
+	secret := []byte("shared-secret")
+	secrets := func(keyID string) []byte {
+		if keyID == "prod" {
+			return secret
+		}
+		return nil
+	}
+	body := []byte(`{"model":"gpt-4"}`)
+
+	newSigned := func() *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "https://gw.corp/v1/chat/completions?x=1", nil)
+		NewHMACSigner("prod", secret)(req, body)
+		return req
+	}
+
+	if err := VerifyHMAC(newSigned(), body, secrets, time.Minute); err != nil {
+		t.Errorf("VerifyHMAC() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(req *http.Request) []byte
+	}{
+		{"body changed", func(req *http.Request) []byte { return []byte(`{"model":"gpt-5"}`) }},
+		{"path changed", func(req *http.Request) []byte { req.URL.Path = "/v1/embeddings"; return body }},
+		{"unknown key", func(req *http.Request) []byte {
+			req.Header.Set(HeaderSignature, strings.Replace(req.Header.Get(HeaderSignature), "keyId=prod", "keyId=old", 1))
+			return body
+		}},
+		{"stale", func(req *http.Request) []byte {
+			req.Header.Set(HeaderSignatureTimestamp, "1000")
+			return body
+		}},
+		{"unsigned", func(req *http.Request) []byte { req.Header = http.Header{}; return body }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newSigned()
+			b := tt.tamper(req)
+			if err := VerifyHMAC(req, b, secrets, time.Minute); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("VerifyHMAC() error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestSigningTransport(t *testing.T) {
+	secret := []byte("shared-secret")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := VerifyHMAC(r, body, func(string) []byte { return secret }, time.Minute); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: SigningTransport(nil, NewHMACSigner("prod", secret))}
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/messages", strings.NewReader("payload"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	got, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(got) != "payload" {
+		t.Errorf("got %d %q, want the verified body echoed", resp.StatusCode, got)
+	}
+	if req.Header.Get(HeaderSignature) != "" {
+		t.Error("SigningTransport must not modify the caller's request")
+	}
+}
+
+func TestClientIdempotencyKey(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get(DefaultIdempotencyHeader))
+		attempt := len(keys)
+		mu.Unlock()
+		if attempt == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL:           server.URL,
+		IdempotencyHeader: DefaultIdempotencyHeader,
+		Retry:             RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond},
+	})
+
+	send := func(req *http.Request) []string {
+		mu.Lock()
+		keys = nil
+		mu.Unlock()
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		return keys
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{}`))
+	got := send(req)
+	if len(got) != 2 || got[0] == "" || got[0] != got[1] {
+		t.Errorf("retries should share one generated key, got %q", got)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{}`))
+	req.Header.Set(DefaultIdempotencyHeader, "caller-key")
+	if got := send(req); got[0] != "caller-key" || got[1] != "caller-key" {
+		t.Errorf("caller key not kept: %q", got)
+	}
+
+	req, _ = http.NewRequestWithContext(WithIdempotencyKey(req.Context(), "ctx-key"), http.MethodPost, server.URL, strings.NewReader(`{}`))
+	if got := send(req); got[0] != "ctx-key" {
+		t.Errorf("context key not used: %q", got)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	if got := send(req); got[0] != "" {
+		t.Errorf("GET should not get an idempotency key, got %q", got)
+	}
+}
+
+func TestClientSigner(t *testing.T) {
+	var mu sync.Mutex
+	var signed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		signed = append(signed, r.Header.Get("X-Test-Signature"))
+		attempt := len(signed)
+		mu.Unlock()
+		if attempt == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	calls := 0
+	client := NewClient(Config{
+		BaseURL: server.URL,
+		Retry:   RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond},
+		Signer: func(req *http.Request, body []byte) error {
+			calls++
+			req.Header.Set("X-Test-Signature", string(body))
+			return nil
+		},
+	})
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("body"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if calls != 2 || signed[0] != "body" || signed[1] != "body" {
+		t.Errorf("every attempt should be signed with the body, calls = %d, signed = %q", calls, signed)
+	}
+
+	failing := NewClient(Config{
+		BaseURL: server.URL,
+		Signer:  func(*http.Request, []byte) error { return errors.New("no signing key") },
+	})
+	req, _ = http.NewRequest(http.MethodPost, server.URL, strings.NewReader("body"))
+	if _, err := failing.Do(req); err == nil || !strings.Contains(err.Error(), "no signing key") {
+		t.Errorf("expected signer error, got %v", err)
+	}
+}
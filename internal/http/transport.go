@@ -0,0 +1,475 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TransportConfig tunes the connection pool of a transport. Zero values fall
+// back to DefaultTransportConfig when used with a Pool.
+type TransportConfig struct {
+	MaxIdleConns            int        `json:"max_idle_conns,omitempty"`             // Idle connections across all hosts
+	MaxIdleConnsPerHost     int        `json:"max_idle_conns_per_host,omitempty"`    // Idle connections per host
+	MaxConnsPerHost         int        `json:"max_conns_per_host,omitempty"`         // Total connections per host (0 = unlimited)
+	IdleConnTimeoutMs       int        `json:"idle_conn_timeout_ms,omitempty"`       // How long idle connections stay open
+	KeepAliveMs             int        `json:"keep_alive_ms,omitempty"`              // TCP keep-alive probe interval
+	DialTimeoutMs           int        `json:"dial_timeout_ms,omitempty"`            // TCP connect timeout
+	TLSHandshakeTimeoutMs   int        `json:"tls_handshake_timeout_ms,omitempty"`   // TLS handshake timeout
+	ResponseHeaderTimeoutMs int        `json:"response_header_timeout_ms,omitempty"` // Wait for response headers (0 = none)
+	HTTP2                   *bool      `json:"http2,omitempty"`                      // Negotiate HTTP/2 (default true)
+	KeepAlives              *bool      `json:"keep_alives,omitempty"`                // Reuse connections (default true)
+	TLS                     *TLSConfig `json:"tls,omitempty"`
+	Protocol                string     `json:"protocol,omitempty"`                // One of the Protocol constants (default auto)
+	Fallback                *bool      `json:"fallback,omitempty"`                // Retry over auto when a forced protocol fails (default true)
+	FallbackRetryMs         int        `json:"fallback_retry_ms,omitempty"`       // How long to stay on auto after a fallback (default 60000)
+	Proxy                   string     `json:"proxy,omitempty"`                   // Egress proxy URL, ProxyDirect, or empty for the environment
+	NoProxy                 []string   `json:"no_proxy,omitempty"`                // Hosts (and subdomains) reached without Proxy
+	MaxResponseBytes        int64      `json:"max_response_bytes,omitempty"`      // Decoded response body limit (0 = none)
+	MaxDecompressionRatio   float64    `json:"max_decompression_ratio,omitempty"` // Gzip expansion limit (0 = none)
+	ReadTimeoutMs           int        `json:"read_timeout_ms,omitempty"`         // Longest wait for each body read (0 = none)
+}
+
+// ProxyDirect as TransportConfig.Proxy connects without a proxy, ignoring
+// HTTP_PROXY and friends
+const ProxyDirect = "direct"
+
+// Upstream protocols for TransportConfig.Protocol
+const (
+	ProtocolAuto  = "auto"  // HTTP/2 when the server offers it over TLS, else HTTP/1.1
+	ProtocolHTTP1 = "http1" // HTTP/1.1 only
+	ProtocolHTTP2 = "http2" // HTTP/2 only, negotiated over TLS
+	ProtocolH2C   = "h2c"   // HTTP/2 only, without TLS (prior knowledge)
+	ProtocolHTTP3 = "http3" // HTTP/3 over QUIC, experimental (requires the http3 build tag)
+)
+
+// DefaultFallbackRetry is how long a transport uses the auto protocol after
+// its forced protocol failed, when FallbackRetryMs is not set
+const DefaultFallbackRetry = time.Minute
+
+// TLSConfig customizes TLS for upstream connections
+type TLSConfig struct {
+	MinVersion         string `json:"min_version,omitempty"` // "1.2" or "1.3"
+	CAFile             string `json:"ca_file,omitempty"`     // PEM bundle trusted instead of the system roots
+	ServerName         string `json:"server_name,omitempty"` // Overrides SNI and certificate verification name
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+	CertFile           string `json:"cert_file,omitempty"` // PEM client certificate for mutual TLS
+	KeyFile            string `json:"key_file,omitempty"`  // PEM private key for CertFile
+}
+
+// DefaultTransportConfig returns the pool settings used when nothing is
+// configured
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeoutMs:     90000,
+		KeepAliveMs:           30000,
+		DialTimeoutMs:         30000,
+		TLSHandshakeTimeoutMs: 10000,
+	}
+}
+
+// Overlay returns c with every non-zero field of o applied on top
+func (c TransportConfig) Overlay(o TransportConfig) TransportConfig {
+	if o.MaxIdleConns != 0 {
+		c.MaxIdleConns = o.MaxIdleConns
+	}
+	if o.MaxIdleConnsPerHost != 0 {
+		c.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+	}
+	if o.MaxConnsPerHost != 0 {
+		c.MaxConnsPerHost = o.MaxConnsPerHost
+	}
+	if o.IdleConnTimeoutMs != 0 {
+		c.IdleConnTimeoutMs = o.IdleConnTimeoutMs
+	}
+	if o.KeepAliveMs != 0 {
+		c.KeepAliveMs = o.KeepAliveMs
+	}
+	if o.DialTimeoutMs != 0 {
+		c.DialTimeoutMs = o.DialTimeoutMs
+	}
+	if o.TLSHandshakeTimeoutMs != 0 {
+		c.TLSHandshakeTimeoutMs = o.TLSHandshakeTimeoutMs
+	}
+	if o.ResponseHeaderTimeoutMs != 0 {
+		c.ResponseHeaderTimeoutMs = o.ResponseHeaderTimeoutMs
+	}
+	if o.HTTP2 != nil {
+		c.HTTP2 = o.HTTP2
+	}
+	if o.KeepAlives != nil {
+		c.KeepAlives = o.KeepAlives
+	}
+	if o.TLS != nil {
+		c.TLS = o.TLS
+	}
+	if o.Protocol != "" {
+		c.Protocol = o.Protocol
+	}
+	if o.Fallback != nil {
+		c.Fallback = o.Fallback
+	}
+	if o.FallbackRetryMs != 0 {
+		c.FallbackRetryMs = o.FallbackRetryMs
+	}
+	if o.Proxy != "" {
+		c.Proxy = o.Proxy
+	}
+	if o.NoProxy != nil {
+		c.NoProxy = o.NoProxy
+	}
+	if o.MaxResponseBytes != 0 {
+		c.MaxResponseBytes = o.MaxResponseBytes
+	}
+	if o.MaxDecompressionRatio != 0 {
+		c.MaxDecompressionRatio = o.MaxDecompressionRatio
+	}
+	if o.ReadTimeoutMs != 0 {
+		c.ReadTimeoutMs = o.ReadTimeoutMs
+	}
+	return c
+}
+
+// Limits returns the response limits set in c
+func (c TransportConfig) Limits() ResponseLimits {
+	return ResponseLimits{
+		MaxBodyBytes:          c.MaxResponseBytes,
+		MaxDecompressionRatio: c.MaxDecompressionRatio,
+		ReadTimeout:           ms(c.ReadTimeoutMs),
+	}
+}
+
+// Redacted returns c with any proxy password masked, for display
+func (c TransportConfig) Redacted() TransportConfig {
+	if u, err := url.Parse(c.Proxy); err == nil && u.User != nil {
+		c.Proxy = u.Redacted()
+	}
+	return c
+}
+
+// Validate checks for negative limits and unusable TLS settings
+func (c TransportConfig) Validate() error {
+	for name, v := range map[string]int{
+		"max_idle_conns":             c.MaxIdleConns,
+		"max_idle_conns_per_host":    c.MaxIdleConnsPerHost,
+		"max_conns_per_host":         c.MaxConnsPerHost,
+		"idle_conn_timeout_ms":       c.IdleConnTimeoutMs,
+		"keep_alive_ms":              c.KeepAliveMs,
+		"dial_timeout_ms":            c.DialTimeoutMs,
+		"tls_handshake_timeout_ms":   c.TLSHandshakeTimeoutMs,
+		"response_header_timeout_ms": c.ResponseHeaderTimeoutMs,
+		"fallback_retry_ms":          c.FallbackRetryMs,
+		"read_timeout_ms":            c.ReadTimeoutMs,
+	} {
+		if v < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	if c.MaxResponseBytes < 0 {
+		return fmt.Errorf("max_response_bytes must not be negative")
+	}
+	if c.MaxDecompressionRatio != 0 && c.MaxDecompressionRatio < 1 {
+		return fmt.Errorf("max_decompression_ratio must be at least 1, got %v", c.MaxDecompressionRatio)
+	}
+	switch c.Protocol {
+	case "", ProtocolAuto, ProtocolHTTP1:
+	case ProtocolHTTP2, ProtocolH2C:
+		if c.HTTP2 != nil && !*c.HTTP2 {
+			return fmt.Errorf("protocol %s conflicts with http2: false", c.Protocol)
+		}
+	case ProtocolHTTP3:
+		if !http3Available {
+			return fmt.Errorf("protocol http3 requires a build with -tags http3")
+		}
+	default:
+		return fmt.Errorf("unsupported protocol %q (use auto, http1, http2, h2c or http3)", c.Protocol)
+	}
+	if c.TLS != nil {
+		if _, err := c.TLS.build(); err != nil {
+			return err
+		}
+	}
+	if _, err := c.proxyFunc(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// NewTransport builds an *http.Transport from the config. HTTP/3 and
+// protocol fallback need NewRoundTripper.
+func NewTransport(cfg TransportConfig) (*http.Transport, error) {
+	if cfg.Protocol == ProtocolHTTP3 {
+		return nil, fmt.Errorf("protocol http3 is not served by *http.Transport, use NewRoundTripper")
+	}
+	return cfg.build(nil)
+}
+
+// NewRoundTripper builds a transport for any protocol, falling back to auto
+// when a forced protocol fails (unless Fallback is false) and enforcing the
+// response limits
+func NewRoundTripper(cfg TransportConfig) (http.RoundTripper, error) {
+	return cfg.roundTripper(nil)
+}
+
+// roundTripper builds the transport for c.Protocol, wrapped with protocol
+// fallback when the protocol is forced and with response limits when set
+func (c TransportConfig) roundTripper(counters *poolCounters) (http.RoundTripper, error) {
+	rt, err := c.protocolRoundTripper(counters)
+	if err != nil {
+		return nil, err
+	}
+	if limits := c.Limits(); limits.enabled() {
+		rt = LimitTransport(rt, limits)
+	}
+	return rt, nil
+}
+
+// protocolRoundTripper builds the transport for c.Protocol with fallback
+func (c TransportConfig) protocolRoundTripper(counters *poolCounters) (http.RoundTripper, error) {
+	var primary http.RoundTripper
+	var err error
+	if c.Protocol == ProtocolHTTP3 {
+		primary, err = newHTTP3Transport(c)
+	} else {
+		primary, err = c.build(counters)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	forced := c.Protocol == ProtocolHTTP2 || c.Protocol == ProtocolH2C || c.Protocol == ProtocolHTTP3
+	if !forced || (c.Fallback != nil && !*c.Fallback) {
+		return primary, nil
+	}
+
+	auto := c
+	auto.Protocol = ProtocolAuto
+	fallback, err := auto.build(counters)
+	if err != nil {
+		return nil, err
+	}
+	retry := ms(c.FallbackRetryMs)
+	if retry == 0 {
+		retry = DefaultFallbackRetry
+	}
+	return &protocolFallback{primary: primary, fallback: fallback, retry: retry, counters: counters}, nil
+}
+
+// build creates an *http.Transport for any protocol but HTTP/3 (which it
+// treats as auto), reporting dialed connections to counters when it is not
+// nil
+func (c TransportConfig) build(counters *poolCounters) (*http.Transport, error) {
+	dialer := &net.Dialer{
+		Timeout:   ms(c.DialTimeoutMs),
+		KeepAlive: ms(c.KeepAliveMs),
+	}
+	http2 := c.HTTP2 == nil || *c.HTTP2
+
+	proxy, err := c.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          c.MaxIdleConns,
+		MaxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       c.MaxConnsPerHost,
+		IdleConnTimeout:       ms(c.IdleConnTimeoutMs),
+		TLSHandshakeTimeout:   ms(c.TLSHandshakeTimeoutMs),
+		ResponseHeaderTimeout: ms(c.ResponseHeaderTimeoutMs),
+		DisableKeepAlives:     c.KeepAlives != nil && !*c.KeepAlives,
+		ForceAttemptHTTP2:     http2,
+	}
+	switch c.Protocol {
+	case ProtocolHTTP1:
+		t.Protocols = new(http.Protocols)
+		t.Protocols.SetHTTP1(true)
+	case ProtocolHTTP2:
+		t.Protocols = new(http.Protocols)
+		t.Protocols.SetHTTP2(true)
+		t.ForceAttemptHTTP2 = true
+	case ProtocolH2C:
+		t.Protocols = new(http.Protocols)
+		t.Protocols.SetUnencryptedHTTP2(true)
+	default:
+		if !http2 {
+			// A non-nil empty map disables the bundled HTTP/2 support
+			t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+	}
+	if c.TLS != nil {
+		tlsConfig, err := c.TLS.build()
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig = tlsConfig
+	}
+	if counters != nil {
+		t.DialContext = counters.countDials(dialer.DialContext)
+	}
+	return t, nil
+}
+
+// build converts the settings to a *tls.Config
+func (c *TLSConfig) build() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	switch c.MinVersion {
+	case "":
+	case "1.2":
+		tlsConfig.MinVersion = tls.VersionTLS12
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unsupported TLS min_version %q (use 1.2 or 1.3)", c.MinVersion)
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, fmt.Errorf("cert_file and key_file must be set together")
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// proxyFunc returns the http.Transport Proxy function for c.Proxy. HTTP and
+// HTTPS proxies are reached with CONNECT for TLS upstreams; SOCKS5 proxies
+// resolve the upstream host themselves. Credentials go in the URL userinfo.
+func (c TransportConfig) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	switch c.Proxy {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case ProxyDirect:
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(c.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use http, https, socks5 or socks5h)", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("proxy URL %s has no host", proxyURL.Redacted())
+	}
+
+	noProxy := c.NoProxy
+	return func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL.Hostname(), noProxy) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}, nil
+}
+
+// bypassProxy reports whether host equals or is a subdomain of an entry in
+// noProxy. "*" bypasses every host.
+func bypassProxy(host string, noProxy []string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(entry), "."))
+		if entry == "*" || host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// protocolFallback sends requests over a forced protocol and retries them on
+// the auto transport when the forced one fails before the request was
+// written (a failed ALPN negotiation, QUIC blocked by a firewall). After a
+// fallback it stays on auto for the retry period, then tries again.
+type protocolFallback struct {
+	primary  http.RoundTripper
+	fallback http.RoundTripper
+	retry    time.Duration
+	counters *poolCounters
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *protocolFallback) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	fallingBack := time.Now().Before(t.until)
+	t.mu.Unlock()
+	if fallingBack {
+		return t.fallback.RoundTrip(req)
+	}
+
+	var wrote atomic.Bool
+	trace := &httptrace.ClientTrace{WroteRequest: func(httptrace.WroteRequestInfo) { wrote.Store(true) }}
+	resp, err := t.primary.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err == nil || wrote.Load() || req.Context().Err() != nil {
+		return resp, err
+	}
+
+	// The failed attempt consumed the body; retry only if it can be rebuilt
+	retry := req
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, err
+		}
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			return nil, err
+		}
+		retry = req.Clone(req.Context())
+		retry.Body = body
+	}
+
+	t.mu.Lock()
+	t.until = time.Now().Add(t.retry)
+	t.mu.Unlock()
+	if t.counters != nil {
+		t.counters.fallbacks.Add(1)
+	}
+	return t.fallback.RoundTrip(retry)
+}
+
+// CloseIdleConnections closes idle connections of both transports
+func (t *protocolFallback) CloseIdleConnections() {
+	for _, rt := range []http.RoundTripper{t.primary, t.fallback} {
+		if ci, ok := rt.(interface{ CloseIdleConnections() }); ok {
+			ci.CloseIdleConnections()
+		}
+	}
+}
+
+func ms(n int) time.Duration {
+	return time.Duration(n) * time.Millisecond
+}
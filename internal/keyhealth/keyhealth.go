@@ -0,0 +1,424 @@
+// Package keyhealth probes stored API keys with a lightweight request
+// (listing the provider's models), records when each key last worked and
+// the request quota the provider reports, and flags keys that are failing
+// or close to expiry.
+package keyhealth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// Store holds keys and their probe results
+type Store interface {
+	ListAPIKeys() ([]*database.APIKey, error)
+	ListKeyHealth() ([]*database.KeyHealth, error)
+	RecordKeyProbe(p *database.KeyProbe) error
+}
+
+// KeySource returns the value of a stored key by its hash. Keys whose
+// value is not known are not probed.
+type KeySource interface {
+	ActualKey(keyHash string) (string, bool)
+}
+
+// Endpoint is how a provider's keys are probed
+type Endpoint struct {
+	URL    string            // Fetched with GET
+	Header string            // Header carrying the key (default Authorization)
+	Prefix string            // Prepended to the key, e.g. "Bearer "
+	Extra  map[string]string // Other headers the provider requires
+}
+
+// DefaultEndpoints returns the model list endpoints of the built-in
+// providers
+func DefaultEndpoints() map[string]Endpoint {
+	bearer := func(url string) Endpoint {
+		return Endpoint{URL: url, Header: "Authorization", Prefix: "Bearer "}
+	}
+	return map[string]Endpoint{
+		"openai":     bearer("https://api.openai.com/v1/models"),
+		"groq":       bearer("https://api.groq.com/openai/v1/models"),
+		"together":   bearer("https://api.together.xyz/v1/models"),
+		"fireworks":  bearer("https://api.fireworks.ai/inference/v1/models"),
+		"deepseek":   bearer("https://api.deepseek.com/v1/models"),
+		"deepinfra":  bearer("https://api.deepinfra.com/v1/openai/models"),
+		"openrouter": bearer("https://openrouter.ai/api/v1/auth/key"),
+		"xai":        bearer("https://api.x.ai/v1/models"),
+		"mistral":    bearer("https://api.mistral.ai/v1/models"),
+		"cohere":     bearer("https://api.cohere.com/v1/models"),
+		"anthropic": {
+			URL:    "https://api.anthropic.com/v1/models",
+			Header: "x-api-key",
+			Extra:  map[string]string{"anthropic-version": "2023-06-01"},
+		},
+		"google": {
+			URL:    "https://generativelanguage.googleapis.com/v1beta/models",
+			Header: "x-goog-api-key",
+		},
+	}
+}
+
+// Config configures a Prober
+type Config struct {
+	// Interval between probe rounds (default 1h)
+	Interval time.Duration
+	// Timeout for one probe (default 10s)
+	Timeout time.Duration
+	// ExpiryWarning is how long before expiry a key is flagged (default 7 days)
+	ExpiryWarning time.Duration
+	// Endpoints adds to or replaces DefaultEndpoints by provider
+	Endpoints map[string]Endpoint
+	// Client sends the probes (default http.DefaultClient)
+	Client *http.Client
+
+	// OnAuthFailure is called for a key a probe was refused with 401 or
+	// 403, e.g. to take it out of rotation (optional)
+	OnAuthFailure func(providerID, apiKey string, status int)
+	// OnWarning is called when a key becomes failing, expiring or expired
+	// (optional)
+	OnWarning func(h *Health)
+}
+
+// DefaultExpiryWarning is how long before expiry keys are flagged when not
+// configured
+const DefaultExpiryWarning = 7 * 24 * time.Hour
+
+// State summarizes a key's health
+type State string
+
+// Key states, from most to least urgent
+const (
+	StateExpired  State = "expired"
+	StateFailing  State = "failing"
+	StateExpiring State = "expiring"
+	StateInactive State = "inactive"
+	StateUnknown  State = "unknown" // Never probed
+	StateOK       State = "ok"
+)
+
+// Health is the state of one stored key
+type Health struct {
+	KeyID          int        `json:"key_id"`
+	ProviderID     string     `json:"provider_id"`
+	KeyPrefix      string     `json:"key_prefix,omitempty"`
+	State          State      `json:"state"`
+	Warning        string     `json:"warning,omitempty"`
+	Degraded       bool       `json:"degraded"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	CheckedAt      *time.Time `json:"checked_at,omitempty"`
+	LastSuccessAt  *time.Time `json:"last_success_at,omitempty"`
+	LastStatus     int        `json:"last_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	Failures       int        `json:"failures"`
+	QuotaRemaining *int64     `json:"quota_remaining,omitempty"`
+	QuotaLimit     *int64     `json:"quota_limit,omitempty"`
+}
+
+// Report combines every stored key with its latest probe, most urgent
+// first. Keys expiring within warn are flagged as expiring.
+func Report(store Store, now time.Time, warn time.Duration) ([]*Health, error) {
+	keys, err := store.ListAPIKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+	probed, err := store.ListKeyHealth()
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[int]*database.KeyHealth, len(probed))
+	for _, h := range probed {
+		byKey[h.KeyID] = h
+	}
+
+	report := make([]*Health, 0, len(keys))
+	for _, k := range keys {
+		h := &Health{
+			KeyID:      k.ID,
+			ProviderID: k.ProviderID,
+			Degraded:   k.Degraded,
+			ExpiresAt:  k.ExpiresAt,
+		}
+		if k.KeyPrefix != nil {
+			h.KeyPrefix = *k.KeyPrefix
+		}
+		if p, ok := byKey[k.ID]; ok {
+			checked := p.CheckedAt
+			h.CheckedAt = &checked
+			h.LastSuccessAt = p.LastSuccessAt
+			h.LastStatus = p.Status
+			h.LastError = p.Error
+			h.Failures = p.Failures
+			h.QuotaRemaining = p.QuotaRemaining
+			h.QuotaLimit = p.QuotaLimit
+		}
+		h.State, h.Warning = assess(h, k.Active, now, warn)
+		report = append(report, h)
+	}
+	sort.SliceStable(report, func(i, j int) bool {
+		return report[i].State.rank() < report[j].State.rank()
+	})
+	return report, nil
+}
+
+// rank orders states by urgency
+func (s State) rank() int {
+	switch s {
+	case StateExpired:
+		return 0
+	case StateFailing:
+		return 1
+	case StateExpiring:
+		return 2
+	case StateInactive:
+		return 3
+	case StateUnknown:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// assess decides a key's state and the warning to show for it
+func assess(h *Health, active bool, now time.Time, warn time.Duration) (State, string) {
+	switch {
+	case !active:
+		return StateInactive, ""
+	case h.ExpiresAt != nil && !now.Before(*h.ExpiresAt):
+		return StateExpired, fmt.Sprintf("expired %s", h.ExpiresAt.Format("2006-01-02"))
+	case h.Failures > 0:
+		since := "never succeeded"
+		if h.LastSuccessAt != nil {
+			since = "last succeeded " + h.LastSuccessAt.Format(time.RFC3339)
+		}
+		return StateFailing, fmt.Sprintf("%d failed probes (%s): %s", h.Failures, since, h.LastError)
+	case h.ExpiresAt != nil && h.ExpiresAt.Sub(now) <= warn:
+		in := "less than a day"
+		if days := int(math.Round(h.ExpiresAt.Sub(now).Hours() / 24)); days == 1 {
+			in = "1 day"
+		} else if days > 1 {
+			in = fmt.Sprintf("%d days", days)
+		}
+		return StateExpiring, fmt.Sprintf("expires in %s (%s)", in, h.ExpiresAt.Format("2006-01-02"))
+	case h.CheckedAt == nil:
+		return StateUnknown, ""
+	default:
+		return StateOK, ""
+	}
+}
+
+// Prober probes every active key on an interval
+type Prober struct {
+	config Config
+	store  Store
+	keys   KeySource
+
+	mu     sync.Mutex
+	warned map[int]State // Key ID -> state last warned about
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewProber creates a prober; call Start to begin probing
+func NewProber(store Store, keys KeySource, cfg Config) *Prober {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.ExpiryWarning <= 0 {
+		cfg.ExpiryWarning = DefaultExpiryWarning
+	}
+	endpoints := DefaultEndpoints()
+	for provider, ep := range cfg.Endpoints {
+		endpoints[provider] = ep
+	}
+	cfg.Endpoints = endpoints
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &Prober{
+		config: cfg,
+		store:  store,
+		keys:   keys,
+		warned: make(map[int]State),
+		stop:   make(chan struct{}),
+	}
+}
+
+// ExpiryWarning returns how long before expiry keys are flagged
+func (p *Prober) ExpiryWarning() time.Duration {
+	return p.config.ExpiryWarning
+}
+
+// Start begins probing in the background
+func (p *Prober) Start() {
+	p.startOnce.Do(func() {
+		p.wg.Add(1)
+		go p.loop()
+	})
+}
+
+// Stop halts probing and waits for an in-progress round to finish
+func (p *Prober) Stop() {
+	p.stopOnce.Do(func() { close(p.stop) })
+	p.wg.Wait()
+}
+
+// loop probes until stopped, logging failures
+func (p *Prober) loop() {
+	defer p.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+	for {
+		_ = crash.Do("key health probe", func() {
+			if err := p.ProbeAll(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("keyhealth: %v", err)
+			}
+		})
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProbeAll probes every active key whose provider has an endpoint and whose
+// value is known, then raises warnings for keys that newly need attention
+func (p *Prober) ProbeAll(ctx context.Context) error {
+	keys, err := p.store.ListAPIKeys()
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+	for _, k := range keys {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		ep, ok := p.config.Endpoints[k.ProviderID]
+		if !k.Active || !ok {
+			continue
+		}
+		apiKey, ok := p.keys.ActualKey(k.KeyHash)
+		if !ok {
+			continue
+		}
+		probe := p.probe(ctx, ep, apiKey)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		probe.KeyID = k.ID
+		if err := p.store.RecordKeyProbe(probe); err != nil {
+			return err
+		}
+		if (probe.Status == http.StatusUnauthorized || probe.Status == http.StatusForbidden) && p.config.OnAuthFailure != nil {
+			p.config.OnAuthFailure(k.ProviderID, apiKey, probe.Status)
+		}
+	}
+	return p.warn(time.Now())
+}
+
+// warn calls OnWarning for each key whose state needs attention and has
+// changed since it was last warned about
+func (p *Prober) warn(now time.Time) error {
+	report, err := Report(p.store, now, p.config.ExpiryWarning)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, h := range report {
+		if h.State.rank() > StateExpiring.rank() {
+			delete(p.warned, h.KeyID)
+			continue
+		}
+		if p.warned[h.KeyID] == h.State {
+			continue
+		}
+		p.warned[h.KeyID] = h.State
+		if p.config.OnWarning != nil {
+			p.config.OnWarning(h)
+		}
+	}
+	return nil
+}
+
+// probe makes one request with a key
+func (p *Prober) probe(ctx context.Context, ep Endpoint, apiKey string) *database.KeyProbe {
+	result := &database.KeyProbe{At: time.Now()}
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ep.URL, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	header := ep.Header
+	if header == "" {
+		header = "Authorization"
+	}
+	req.Header.Set(header, ep.Prefix+apiKey)
+	for k, v := range ep.Extra {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.config.Client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	result.Status = resp.StatusCode
+	result.OK = resp.StatusCode == http.StatusOK
+	if !result.OK {
+		result.Error = strings.TrimSpace(fmt.Sprintf("%d: %s", resp.StatusCode, body))
+	}
+	result.QuotaRemaining, result.QuotaLimit = quota(resp.Header)
+	return result
+}
+
+// quota reads the request quota from the rate limit headers of OpenAI-style
+// and Anthropic responses
+func quota(h http.Header) (remaining, limit *int64) {
+	parse := func(names ...string) *int64 {
+		for _, name := range names {
+			if n, err := strconv.ParseInt(h.Get(name), 10, 64); err == nil {
+				return &n
+			}
+		}
+		return nil
+	}
+	remaining = parse("x-ratelimit-remaining-requests", "anthropic-ratelimit-requests-remaining")
+	limit = parse("x-ratelimit-limit-requests", "anthropic-ratelimit-requests-limit")
+	return remaining, limit
+}
@@ -0,0 +1,128 @@
+package keyhealth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+type vault map[string]string
+
+func (v vault) ActualKey(keyHash string) (string, bool) {
+	key, ok := v[keyHash]
+	return key, ok
+}
+
+func TestProber(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-good-key-0001" {
+			http.Error(w, "invalid api key", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("x-ratelimit-remaining-requests", "4999")
+		w.Header().Set("x-ratelimit-limit-requests", "5000")
+		w.Write([]byte(`{"data": []}`))
+	}))
+	defer server.Close()
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "keys.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.CreateProvider(&database.Provider{ID: "openai", Name: "OpenAI", BaseURL: server.URL, Status: "online"}); err != nil {
+		t.Fatalf("CreateProvider failed: %v", err)
+	}
+	keys := vault{}
+	var ids []int
+	for _, value := range []string{"sk-good-key-0001", "sk-revoked-key-02", "sk-unregistered-3"} {
+		key, err := db.CreateAPIKey("openai", value)
+		if err != nil {
+			t.Fatalf("CreateAPIKey failed: %v", err)
+		}
+		if !strings.Contains(value, "unregistered") {
+			keys[key.KeyHash] = value
+		}
+		ids = append(ids, key.ID)
+	}
+	soon := time.Now().Add(48 * time.Hour)
+	if err := db.SetAPIKeyExpiry(ids[0], &soon); err != nil {
+		t.Fatalf("SetAPIKeyExpiry failed: %v", err)
+	}
+
+	var rejected []string
+	var warnings []*Health
+	p := NewProber(db, keys, Config{
+		Endpoints:     map[string]Endpoint{"openai": {URL: server.URL + "/v1/models", Prefix: "Bearer "}},
+		OnAuthFailure: func(provider, apiKey string, status int) { rejected = append(rejected, apiKey) },
+		OnWarning:     func(h *Health) { warnings = append(warnings, h) },
+	})
+	for range 2 {
+		if err := p.ProbeAll(context.Background()); err != nil {
+			t.Fatalf("ProbeAll failed: %v", err)
+		}
+	}
+
+	report, err := Report(db, time.Now(), DefaultExpiryWarning)
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	states := map[int]*Health{}
+	for _, h := range report {
+		states[h.KeyID] = h
+	}
+	good, revoked, unknown := states[ids[0]], states[ids[1]], states[ids[2]]
+	if good.State != StateExpiring || good.LastSuccessAt == nil || good.QuotaRemaining == nil || *good.QuotaRemaining != 4999 || *good.QuotaLimit != 5000 {
+		t.Errorf("unexpected state for the working key: %+v", good)
+	}
+	if revoked.State != StateFailing || revoked.Failures != 2 || revoked.LastStatus != 401 || revoked.LastSuccessAt != nil {
+		t.Errorf("unexpected state for the revoked key: %+v", revoked)
+	}
+	if unknown.State != StateUnknown || unknown.CheckedAt != nil {
+		t.Errorf("unregistered key should not be probed: %+v", unknown)
+	}
+	if report[0].KeyID != ids[1] {
+		t.Errorf("failing key should be listed first, got key %d", report[0].KeyID)
+	}
+
+	if len(rejected) != 2 || rejected[0] != "sk-revoked-key-02" {
+		t.Errorf("auth failures = %v", rejected)
+	}
+	// Each key is warned about once per state
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %d", len(warnings))
+	}
+	if !strings.Contains(warnings[0].Warning+warnings[1].Warning, "expires in 2 days") {
+		t.Errorf("missing expiry warning: %q, %q", warnings[0].Warning, warnings[1].Warning)
+	}
+}
+
+func TestAssess(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	past, later := now.Add(-time.Hour), now.Add(30*24*time.Hour)
+	tests := []struct {
+		name   string
+		h      Health
+		active bool
+		want   State
+	}{
+		{"inactive", Health{ExpiresAt: &past}, false, StateInactive},
+		{"expired beats failing", Health{ExpiresAt: &past, Failures: 3}, true, StateExpired},
+		{"failing", Health{Failures: 1, CheckedAt: &now}, true, StateFailing},
+		{"expiry far off", Health{ExpiresAt: &later, CheckedAt: &now}, true, StateOK},
+		{"never probed", Health{}, true, StateUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := assess(&tt.h, tt.active, now, DefaultExpiryWarning); got != tt.want {
+				t.Errorf("assess = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,9 +2,15 @@ package keymanager
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"log"
 	"sync"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+	"github.com/jeffersonwarrior/modelscan/internal/tenant"
 )
 
 // KeyManager manages API keys with round-robin selection and degradation tracking
@@ -15,6 +21,10 @@ type KeyManager struct {
 	keyVault map[string]string    // keyHash -> actualKey (SECURITY: plaintext in memory, no TTL)
 	cacheTTL time.Duration
 	stopCh   chan struct{} // Signal to stop background refresh
+
+	badKeys        map[string]time.Time // keyHash -> when an auth failure stops counting
+	authFailureTTL time.Duration
+	onAuthFailure  func(AuthFailure)
 }
 
 // Database interface for key storage
@@ -44,13 +54,28 @@ type APIKey struct {
 	Degraded      bool
 	DegradedUntil *time.Time
 	CreatedAt     time.Time
-	actualKey     string // Stored temporarily, not persisted
+	TenantID      *string // Owning tenant; nil for shared keys
+	actualKey     string  // Stored temporarily, not persisted
 }
 
 // Config holds key manager configuration
 type Config struct {
 	CacheTTL        time.Duration
 	DegradeDuration time.Duration // How long to mark key as degraded on error
+	AuthFailureTTL  time.Duration // How long a key rejected with 401/403 is skipped (default 1h)
+
+	// OnAuthFailure is called once per rejected key and TTL, e.g. to raise
+	// an alert (optional)
+	OnAuthFailure func(AuthFailure)
+}
+
+// AuthFailure describes a key an upstream rejected
+type AuthFailure struct {
+	ProviderID string
+	KeyID      int    // Zero when the key is not in the cache
+	KeyPrefix  string // Empty when unknown
+	Status     int
+	Until      time.Time // When the key is tried again
 }
 
 // NewKeyManager creates a new key manager
@@ -61,13 +86,19 @@ func NewKeyManager(db Database, cfg Config) *KeyManager {
 	if cfg.DegradeDuration == 0 {
 		cfg.DegradeDuration = 15 * time.Minute
 	}
+	if cfg.AuthFailureTTL == 0 {
+		cfg.AuthFailureTTL = time.Hour
+	}
 
 	km := &KeyManager{
-		db:       db,
-		cache:    make(map[string][]*APIKey),
-		keyVault: make(map[string]string),
-		cacheTTL: cfg.CacheTTL,
-		stopCh:   make(chan struct{}),
+		db:             db,
+		cache:          make(map[string][]*APIKey),
+		keyVault:       make(map[string]string),
+		cacheTTL:       cfg.CacheTTL,
+		stopCh:         make(chan struct{}),
+		badKeys:        make(map[string]time.Time),
+		authFailureTTL: cfg.AuthFailureTTL,
+		onAuthFailure:  cfg.OnAuthFailure,
 	}
 
 	// Start background refresh
@@ -76,7 +107,10 @@ func NewKeyManager(db Database, cfg Config) *KeyManager {
 	return km
 }
 
-// GetKey selects the best API key for a provider using round-robin (lowest usage)
+// GetKey selects the best API key for a provider using round-robin (lowest usage).
+// Requests for a tenant (see tenant.WithTenant) use the tenant's own keys
+// for the provider when it has any and shared keys otherwise; other
+// tenants' keys are never used.
 func (km *KeyManager) GetKey(ctx context.Context, providerID string) (*APIKey, error) {
 	km.mu.RLock()
 	keys, ok := km.cache[providerID]
@@ -97,6 +131,11 @@ func (km *KeyManager) GetKey(ctx context.Context, providerID string) (*APIKey, e
 		}
 	}
 
+	keys = keysForTenant(keys, tenant.FromContext(ctx))
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no active keys for provider %s", providerID)
+	}
+
 	// Find key with lowest usage (round-robin)
 	// Note: We don't modify keys in-place to avoid race conditions.
 	// Degraded keys that have expired will be re-enabled on next cache refresh.
@@ -104,7 +143,14 @@ func (km *KeyManager) GetKey(ctx context.Context, providerID string) (*APIKey, e
 	minUsage := int(^uint(0) >> 1) // Max int
 	now := time.Now()
 
+	km.mu.RLock()
+	defer km.mu.RUnlock()
 	for _, key := range keys {
+		// Skip keys an upstream rejected, even if marking them degraded failed
+		if until, bad := km.badKeys[key.KeyHash]; bad && now.Before(until) {
+			continue
+		}
+
 		// Skip degraded keys (unless they've expired)
 		if key.Degraded {
 			if key.DegradedUntil == nil || !now.After(*key.DegradedUntil) {
@@ -140,6 +186,32 @@ func (km *KeyManager) GetKey(ctx context.Context, providerID string) (*APIKey, e
 	return bestKey, nil
 }
 
+// keysForTenant narrows keys to those a tenant may use: its own if it has
+// any, otherwise the shared ones
+func keysForTenant(keys []*APIKey, tenantID string) []*APIKey {
+	var owned, shared []*APIKey
+	for _, key := range keys {
+		switch {
+		case key.TenantID == nil:
+			shared = append(shared, key)
+		case tenantID != "" && *key.TenantID == tenantID:
+			owned = append(owned, key)
+		}
+	}
+	if len(owned) > 0 {
+		return owned
+	}
+	return shared
+}
+
+// Invalidate drops the cached keys of every provider so the next GetKey
+// reloads them, e.g. after keys move between tenants
+func (km *KeyManager) Invalidate() {
+	km.mu.Lock()
+	km.cache = make(map[string][]*APIKey)
+	km.mu.Unlock()
+}
+
 // RecordUsage records API key usage
 func (km *KeyManager) RecordUsage(ctx context.Context, keyID int, tokens int) error {
 	return km.db.IncrementKeyUsage(keyID, tokens)
@@ -176,6 +248,57 @@ func (km *KeyManager) MarkDegraded(ctx context.Context, keyID int, duration time
 	return nil
 }
 
+// ReportAuthFailure records that an upstream rejected a key with 401 or
+// 403. The key is skipped by GetKey and marked degraded for the auth
+// failure TTL so requests fail over to the provider's other keys. Repeat
+// reports within the TTL are ignored, so a burst of rejected requests
+// costs one database write and one alert; it reports whether this call
+// was the first.
+func (km *KeyManager) ReportAuthFailure(ctx context.Context, providerID, actualKey string, status int) bool {
+	hash := hashKey(actualKey)
+	now := time.Now()
+	until := now.Add(km.authFailureTTL)
+
+	km.mu.Lock()
+	if known, bad := km.badKeys[hash]; bad && now.Before(known) {
+		km.mu.Unlock()
+		return false
+	}
+	for h, expires := range km.badKeys {
+		if !now.Before(expires) {
+			delete(km.badKeys, h)
+		}
+	}
+	km.badKeys[hash] = until
+	failure := AuthFailure{ProviderID: providerID, Status: status, Until: until}
+	for _, key := range km.cache[providerID] {
+		if key.KeyHash == hash {
+			failure.KeyID = key.ID
+			if key.KeyPrefix != nil {
+				failure.KeyPrefix = *key.KeyPrefix
+			}
+			break
+		}
+	}
+	km.mu.Unlock()
+
+	if failure.KeyID != 0 {
+		if err := km.MarkDegraded(ctx, failure.KeyID, km.authFailureTTL); err != nil {
+			log.Printf("keymanager: failed to mark key %d degraded: %v", failure.KeyID, err)
+		}
+	}
+	if km.onAuthFailure != nil {
+		km.onAuthFailure(failure)
+	}
+	return true
+}
+
+// hashKey hashes a key value the way stored key hashes are made
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
 // ResetLimits resets rate limits for a key (called on interval)
 func (km *KeyManager) ResetLimits(ctx context.Context, keyID int) error {
 	return km.db.ResetKeyLimits(keyID)
@@ -212,7 +335,9 @@ func (km *KeyManager) refreshLoop() {
 
 			// Refresh each provider's keys
 			for _, provider := range providers {
-				_ = km.refreshCache(provider) // Best-effort refresh, errors logged internally
+				_ = crash.Do("key refresh", func() {
+					_ = km.refreshCache(provider) // Best-effort refresh, errors logged internally
+				})
 			}
 		case <-km.stopCh:
 			return
@@ -239,6 +364,14 @@ func (km *KeyManager) RegisterActualKey(keyHash, actualKey string) {
 	km.keyVault[keyHash] = actualKey
 }
 
+// ActualKey returns the registered value of the key with the given hash
+func (km *KeyManager) ActualKey(keyHash string) (string, bool) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+	actualKey, ok := km.keyVault[keyHash]
+	return actualKey, ok
+}
+
 // GetActualKey retrieves the actual API key string for a provider.
 // Uses round-robin selection to choose the best key, then returns its actual value.
 func (km *KeyManager) GetActualKey(ctx context.Context, providerID string) (string, error) {
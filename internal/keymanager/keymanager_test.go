@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/tenant"
 )
 
 // MockDatabase implements Database interface for testing
@@ -174,6 +176,46 @@ func TestGetKeyAllDegraded(t *testing.T) {
 	}
 }
 
+func TestGetKeyTenant(t *testing.T) {
+	db := NewMockDatabase()
+
+	teamA, teamB := "team-a", "team-b"
+	db.keys["testprovider"] = []*APIKey{
+		{ID: 1, ProviderID: "testprovider", RequestsCount: 1},
+		{ID: 2, ProviderID: "testprovider", RequestsCount: 5, TenantID: &teamA},
+		{ID: 3, ProviderID: "testprovider", RequestsCount: 0, TenantID: &teamB},
+	}
+
+	km := NewKeyManager(db, Config{})
+
+	// Shared requests never use tenant keys
+	key, err := km.GetKey(context.Background(), "testprovider")
+	if err != nil || key.ID != 1 {
+		t.Errorf("expected shared key 1, got %+v, %v", key, err)
+	}
+
+	// Tenants prefer their own keys
+	key, err = km.GetKey(tenant.WithTenant(context.Background(), teamA), "testprovider")
+	if err != nil || key.ID != 2 {
+		t.Errorf("expected team-a key 2, got %+v, %v", key, err)
+	}
+
+	// Tenants without keys fall back to shared ones
+	key, err = km.GetKey(tenant.WithTenant(context.Background(), "team-c"), "testprovider")
+	if err != nil || key.ID != 1 {
+		t.Errorf("expected shared key 1, got %+v, %v", key, err)
+	}
+
+	// Reassigned keys apply once the cache is invalidated
+	db.keys["testprovider"] = []*APIKey{
+		{ID: 1, ProviderID: "testprovider", TenantID: &teamB},
+	}
+	km.Invalidate()
+	if _, err := km.GetKey(context.Background(), "testprovider"); err == nil {
+		t.Error("expected no shared keys after reassignment")
+	}
+}
+
 func TestRecordUsage(t *testing.T) {
 	db := NewMockDatabase()
 	km := NewKeyManager(db, Config{})
@@ -253,3 +295,52 @@ func TestListKeys(t *testing.T) {
 		t.Errorf("expected 2 keys, got %d", len(keys))
 	}
 }
+
+func TestReportAuthFailure(t *testing.T) {
+	db := NewMockDatabase()
+	prefix := "sk-bad..."
+	db.keys["openai"] = []*APIKey{
+		{ID: 1, ProviderID: "openai", KeyHash: hashKey("sk-bad"), KeyPrefix: &prefix},
+		{ID: 2, ProviderID: "openai", KeyHash: hashKey("sk-good"), RequestsCount: 50},
+	}
+
+	var alerts []AuthFailure
+	km := NewKeyManager(db, Config{
+		AuthFailureTTL: time.Minute,
+		OnAuthFailure:  func(f AuthFailure) { alerts = append(alerts, f) },
+	})
+	defer km.Close()
+	km.RegisterActualKey(hashKey("sk-bad"), "sk-bad")
+	km.RegisterActualKey(hashKey("sk-good"), "sk-good")
+	ctx := context.Background()
+
+	if key, _ := km.GetActualKey(ctx, "openai"); key != "sk-bad" {
+		t.Fatalf("expected the least used key first, got %q", key)
+	}
+	if !km.ReportAuthFailure(ctx, "openai", "sk-bad", 401) {
+		t.Error("expected the first report to count")
+	}
+	if km.ReportAuthFailure(ctx, "openai", "sk-bad", 401) {
+		t.Error("expected a repeat report within the TTL to be ignored")
+	}
+	if len(alerts) != 1 || alerts[0].KeyID != 1 || alerts[0].KeyPrefix != prefix || alerts[0].Status != 401 {
+		t.Errorf("expected one alert for key 1, got %+v", alerts)
+	}
+	if _, ok := db.degraded[1]; !ok {
+		t.Error("expected key 1 to be marked degraded")
+	}
+	if key, err := km.GetActualKey(ctx, "openai"); err != nil || key != "sk-good" {
+		t.Errorf("expected failover to the other key, got %q, %v", key, err)
+	}
+
+	// Once the TTL passes the key is tried again and a new failure alerts again
+	km.mu.Lock()
+	km.badKeys[hashKey("sk-bad")] = time.Now().Add(-time.Second)
+	km.mu.Unlock()
+	if key, _ := km.GetActualKey(ctx, "openai"); key != "sk-bad" {
+		t.Errorf("expected the key back after the TTL, got %q", key)
+	}
+	if !km.ReportAuthFailure(ctx, "openai", "sk-bad", 403) || len(alerts) != 2 {
+		t.Errorf("expected a new alert after the TTL, got %+v", alerts)
+	}
+}
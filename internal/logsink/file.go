@@ -0,0 +1,148 @@
+package logsink
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileConfig configures a rotating log file
+type FileConfig struct {
+	Path string
+	// MaxSize rotates the file before it grows past this many bytes
+	// (zero for no limit)
+	MaxSize int64
+	// RotateEvery rotates the file at each multiple of this interval, e.g.
+	// at midnight UTC for 24h (zero for never)
+	RotateEvery time.Duration
+	// MaxBackups is how many rotated files are kept (zero keeps all)
+	MaxBackups int
+}
+
+// backupLayout stamps rotated files; it sorts chronologically
+const backupLayout = "20060102-150405"
+
+// RotatingFile is a log file that is renamed aside, with a timestamp
+// suffix, once it reaches its size limit or rotation time
+type RotatingFile struct {
+	cfg  FileConfig
+	now  func() time.Time
+	f    *os.File
+	size int64
+	next time.Time // Next time-based rotation
+}
+
+// OpenFile opens or creates the log file at cfg.Path, appending to it
+func OpenFile(cfg FileConfig) (*RotatingFile, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+		return nil, err
+	}
+	r := &RotatingFile{cfg: cfg, now: time.Now}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the file, picking up the size and age of an existing one
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	if r.cfg.RotateEvery > 0 {
+		// A file left from before a restart rotates on schedule
+		started := info.ModTime()
+		if info.Size() == 0 {
+			started = r.now()
+		}
+		r.next = started.Truncate(r.cfg.RotateEvery).Add(r.cfg.RotateEvery)
+	}
+	return nil
+}
+
+// Write appends p, rotating first when p would pass the size limit or the
+// rotation time has come
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	if r.size > 0 && r.due(len(p)) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) due(incoming int) bool {
+	if r.cfg.MaxSize > 0 && r.size+int64(incoming) > r.cfg.MaxSize {
+		return true
+	}
+	return r.cfg.RotateEvery > 0 && !r.now().Before(r.next)
+}
+
+// rotate renames the file aside, opens a fresh one and removes the oldest
+// backups
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	backup := r.cfg.Path + "." + r.now().UTC().Format(backupLayout)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			break
+		}
+		backup = fmt.Sprintf("%s.%s-%d", r.cfg.Path, r.now().UTC().Format(backupLayout), i)
+	}
+	if err := os.Rename(r.cfg.Path, backup); err != nil {
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	return r.prune()
+}
+
+// prune removes backups beyond MaxBackups, oldest first
+func (r *RotatingFile) prune() error {
+	if r.cfg.MaxBackups <= 0 {
+		return nil
+	}
+	backups, err := r.Backups()
+	if err != nil {
+		return err
+	}
+	for len(backups) > r.cfg.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// Backups returns the rotated files, oldest first
+func (r *RotatingFile) Backups() ([]string, error) {
+	matches, err := filepath.Glob(r.cfg.Path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// Close closes the file
+func (r *RotatingFile) Close() error {
+	return r.f.Close()
+}
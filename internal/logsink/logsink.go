@@ -0,0 +1,197 @@
+// Package logsink ships log output to extra destinations: size and time
+// rotated files, syslog and HTTP bulk ingestion endpoints (Loki,
+// Elasticsearch or plain NDJSON).
+//
+// Every sink is fed from its own bounded queue. Writing a line only queues
+// it; when a sink falls behind its queue fills up and new lines are dropped
+// and counted, so logging never blocks the request path.
+package logsink
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBufferSize is how many lines each sink queues by default
+const DefaultBufferSize = 1024
+
+// Config selects the sinks; nil sinks are disabled
+type Config struct {
+	File   *FileConfig
+	Syslog *SyslogConfig
+	HTTP   *HTTPConfig
+	// BufferSize is how many lines each sink queues before new lines are
+	// dropped (default 1024)
+	BufferSize int
+}
+
+// SyslogConfig configures the syslog sink
+type SyslogConfig struct {
+	// Network and Address of the daemon, e.g. "udp" and "logs.internal:514";
+	// both empty use the local daemon
+	Network  string
+	Address  string
+	Tag      string // Program name on each message (default modelscan)
+	Facility string // user, daemon or local0-local7 (default daemon)
+}
+
+// Stats reports one sink's deliveries
+type Stats struct {
+	Sink    string `json:"sink"`
+	Written int64  `json:"written"`
+	Dropped int64  `json:"dropped"` // Lines that did not fit the queue
+	Failed  int64  `json:"failed"`  // Writes the sink failed; a failed HTTP batch counts once
+}
+
+// errOut receives sink failures; they cannot go through the log package,
+// which may be writing to the failing sink
+var errOut io.Writer = os.Stderr
+
+// queue feeds one sink from its own goroutine
+type queue struct {
+	name  string
+	sink  io.WriteCloser
+	lines chan []byte
+	done  chan struct{}
+
+	written, dropped, failed atomic.Int64
+}
+
+func newQueue(name string, sink io.WriteCloser, size int) *queue {
+	q := &queue{
+		name:  name,
+		sink:  sink,
+		lines: make(chan []byte, size),
+		done:  make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// write queues a copy of p, dropping it when the queue is full
+func (q *queue) write(p []byte) {
+	select {
+	case q.lines <- append([]byte(nil), p...):
+	default:
+		q.dropped.Add(1)
+	}
+}
+
+func (q *queue) run() {
+	defer close(q.done)
+	for line := range q.lines {
+		if _, err := q.sink.Write(line); err != nil {
+			// Report the first failure and then every thousandth
+			if n := q.failed.Add(1); n%1000 == 1 {
+				fmt.Fprintf(errOut, "logsink: %s: %v (%d lines failed)\n", q.name, err, n)
+			}
+			continue
+		}
+		q.written.Add(1)
+	}
+}
+
+// close writes the queued lines and closes the sink
+func (q *queue) close() error {
+	close(q.lines)
+	<-q.done
+	return q.sink.Close()
+}
+
+func (q *queue) stats() Stats {
+	return Stats{Sink: q.name, Written: q.written.Load(), Dropped: q.dropped.Load(), Failed: q.failed.Load()}
+}
+
+// Sinks fans log lines out to the configured sinks. It is an io.Writer for
+// log.SetOutput; writes never block and never fail.
+type Sinks struct {
+	mu     sync.RWMutex
+	queues []*queue
+	closed bool
+}
+
+// Open opens the configured sinks
+func Open(cfg Config) (*Sinks, error) {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultBufferSize
+	}
+	type opener struct {
+		name string
+		open func() (io.WriteCloser, error)
+	}
+	var openers []opener
+	if cfg.File != nil {
+		openers = append(openers, opener{"file", func() (io.WriteCloser, error) { return OpenFile(*cfg.File) }})
+	}
+	if cfg.Syslog != nil {
+		openers = append(openers, opener{"syslog", func() (io.WriteCloser, error) { return DialSyslog(*cfg.Syslog) }})
+	}
+	if cfg.HTTP != nil {
+		openers = append(openers, opener{"http", func() (io.WriteCloser, error) { return NewShipper(*cfg.HTTP) }})
+	}
+
+	s := &Sinks{}
+	for _, o := range openers {
+		sink, err := o.open()
+		if err != nil {
+			_ = s.Close()
+			return nil, fmt.Errorf("%s sink: %w", o.name, err)
+		}
+		s.queues = append(s.queues, newQueue(o.name, sink, cfg.BufferSize))
+	}
+	return s, nil
+}
+
+// Write queues p for every sink
+func (s *Sinks) Write(p []byte) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.closed {
+		for _, q := range s.queues {
+			q.write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// Names returns the names of the open sinks
+func (s *Sinks) Names() []string {
+	names := make([]string, len(s.queues))
+	for i, q := range s.queues {
+		names[i] = q.name
+	}
+	return names
+}
+
+// Stats returns each sink's deliveries
+func (s *Sinks) Stats() []Stats {
+	stats := make([]Stats, len(s.queues))
+	for i, q := range s.queues {
+		stats[i] = q.stats()
+	}
+	return stats
+}
+
+// Close writes the queued lines and closes every sink. Later writes are
+// discarded.
+func (s *Sinks) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	var errs []error
+	for _, q := range s.queues {
+		if err := q.close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s sink: %w", q.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,243 @@
+package logsink
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "modelscan.log")
+	f, err := OpenFile(FileConfig{Path: path, MaxSize: 20, RotateEvery: time.Hour, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+	f.next = now.Truncate(time.Hour).Add(time.Hour)
+
+	write := func(line string) {
+		t.Helper()
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("first line\n")
+	write("second\n")
+	// Past the size limit: rotated before writing
+	write("third line\n")
+	if backups, _ := f.Backups(); len(backups) != 1 || !strings.HasSuffix(backups[0], ".20260302-103000") {
+		t.Fatalf("expected one backup, got %v", backups)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "third line\n" {
+		t.Errorf("expected a fresh file, got %q", data)
+	}
+
+	// On the hour: rotated by time
+	now = now.Add(30 * time.Minute)
+	write("fourth\n")
+	// Same second again: the backup name is made unique, and the oldest pruned
+	write("fifth line is longer\n")
+	backups, _ := f.Backups()
+	if len(backups) != 2 || !strings.HasSuffix(backups[0], ".20260302-110000") || !strings.HasSuffix(backups[1], ".20260302-110000-1") {
+		t.Errorf("expected the two newest backups, got %v", backups)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopening appends and keeps the size
+	f, err = OpenFile(FileConfig{Path: path, MaxSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if f.size != int64(len("fifth line is longer\n")) {
+		t.Errorf("expected the existing size, got %d", f.size)
+	}
+}
+
+// blockingSink holds writes until released
+type blockingSink struct {
+	release chan struct{}
+	mu      sync.Mutex
+	lines   []string
+	fail    bool
+}
+
+func (b *blockingSink) Write(p []byte) (int, error) {
+	<-b.release
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.fail {
+		return 0, errors.New("disk full")
+	}
+	b.lines = append(b.lines, string(p))
+	return len(p), nil
+}
+
+func (b *blockingSink) Close() error { return nil }
+
+func TestSinks_NeverBlock(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{})}
+	s := &Sinks{queues: []*queue{newQueue("slow", sink, 2)}}
+
+	// The sink is stuck: writes still return at once, dropping the overflow
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			if n, err := s.Write([]byte("line\n")); n != 5 || err != nil {
+				t.Errorf("Write = %d, %v", n, err)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected writes not to block on a stuck sink")
+	}
+
+	close(sink.release)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	st := s.Stats()[0]
+	// One line is being written, two are queued
+	if st.Written+st.Dropped != 10 || st.Dropped < 7 || len(sink.lines) != int(st.Written) {
+		t.Errorf("unexpected stats: %+v, %d lines written", st, len(sink.lines))
+	}
+	if _, err := s.Write([]byte("after close\n")); err != nil {
+		t.Errorf("expected writes after Close to be discarded, got %v", err)
+	}
+}
+
+func TestSinks_Failures(t *testing.T) {
+	var reported strings.Builder
+	errOut = &reported
+	defer func() { errOut = os.Stderr }()
+
+	sink := &blockingSink{release: make(chan struct{}), fail: true}
+	close(sink.release)
+	s := &Sinks{queues: []*queue{newQueue("file", sink, 10)}}
+	s.Write([]byte("a\n"))
+	s.Write([]byte("b\n"))
+	s.Close()
+	if st := s.Stats()[0]; st.Failed != 2 || st.Written != 0 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+	if strings.Count(reported.String(), "disk full") != 1 {
+		t.Errorf("expected the first failure to be reported once, got %q", reported.String())
+	}
+
+	if _, err := Open(Config{HTTP: &HTTPConfig{URL: "loki:3100"}}); err == nil {
+		t.Error("expected an invalid URL to fail")
+	}
+	if _, err := Open(Config{HTTP: &HTTPConfig{URL: "http://loki:3100", Format: "gelf"}}); err == nil {
+		t.Error("expected an unknown format to fail")
+	}
+}
+
+func TestShipper(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []*http.Request
+		bodies   []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		if strings.Contains(string(body), "reject") {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("loki", func(t *testing.T) {
+		requests, bodies = nil, nil
+		s, err := NewShipper(HTTPConfig{
+			URL:           server.URL + "/loki/api/v1/push",
+			Format:        FormatLoki,
+			Labels:        map[string]string{"app": "modelscan"},
+			Headers:       map[string]string{"X-Scope-OrgID": "ops"},
+			BatchSize:     2,
+			FlushInterval: time.Hour,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Write([]byte("one\n"))
+		s.Write([]byte("two\n")) // Fills the batch
+		s.Write([]byte("three\n"))
+		if err := s.Close(); err != nil { // Sends the rest
+			t.Fatal(err)
+		}
+
+		if len(bodies) != 2 || requests[0].Header.Get("X-Scope-OrgID") != "ops" || requests[0].Header.Get("Content-Type") != "application/json" {
+			t.Fatalf("expected two batches with the headers, got %d", len(bodies))
+		}
+		var push struct {
+			Streams []struct {
+				Stream map[string]string `json:"stream"`
+				Values [][2]string       `json:"values"`
+			} `json:"streams"`
+		}
+		if err := json.Unmarshal([]byte(bodies[0]), &push); err != nil {
+			t.Fatal(err)
+		}
+		st := push.Streams[0]
+		if st.Stream["app"] != "modelscan" || len(st.Values) != 2 || st.Values[0][1] != "one" || st.Values[1][1] != "two" {
+			t.Errorf("unexpected push: %s", bodies[0])
+		}
+	})
+
+	t.Run("elasticsearch", func(t *testing.T) {
+		requests, bodies = nil, nil
+		s, _ := NewShipper(HTTPConfig{URL: server.URL + "/_bulk", Format: FormatElasticsearch, Labels: map[string]string{"host": "gw-1"}, FlushInterval: 10 * time.Millisecond})
+		s.Write([]byte("starting\n"))
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			mu.Lock()
+			n := len(bodies)
+			mu.Unlock()
+			if n > 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		s.Close()
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(bodies) != 1 {
+			t.Fatalf("expected the flush interval to send the line, got %d batches", len(bodies))
+		}
+		lines := strings.Split(strings.TrimSpace(bodies[0]), "\n")
+		var doc map[string]string
+		if len(lines) != 2 || lines[0] != `{"index":{"_index":"modelscan-logs"}}` || json.Unmarshal([]byte(lines[1]), &doc) != nil {
+			t.Fatalf("unexpected bulk body: %s", bodies[0])
+		}
+		if doc["message"] != "starting" || doc["host"] != "gw-1" || doc["@timestamp"] == "" {
+			t.Errorf("unexpected document: %v", doc)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		s, _ := NewShipper(HTTPConfig{URL: server.URL, BatchSize: 1, FlushInterval: time.Hour})
+		defer s.Close()
+		if _, err := s.Write([]byte("reject me\n")); err == nil || !strings.Contains(err.Error(), "status 400") {
+			t.Errorf("expected the rejected batch to fail, got %v", err)
+		}
+	})
+}
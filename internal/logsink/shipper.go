@@ -0,0 +1,213 @@
+package logsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Shipper formats
+const (
+	FormatNDJSON        = "ndjson"        // One JSON object per line
+	FormatLoki          = "loki"          // Loki push API
+	FormatElasticsearch = "elasticsearch" // Elasticsearch bulk API
+)
+
+// HTTPConfig configures the HTTP bulk shipper
+type HTTPConfig struct {
+	// URL receives batches, e.g. http://loki:3100/loki/api/v1/push or
+	// http://elasticsearch:9200/_bulk
+	URL    string
+	Format string // ndjson, loki or elasticsearch (default ndjson)
+	// Index receives the lines in Elasticsearch (default modelscan-logs)
+	Index string
+	// Labels are added to every line: as the Loki stream's labels, or as
+	// fields of NDJSON and Elasticsearch documents
+	Labels map[string]string
+	// Headers are sent with every batch, e.g. Authorization
+	Headers map[string]string
+	// BatchSize sends a batch once this many lines are waiting (default 500)
+	BatchSize int
+	// FlushInterval sends waiting lines at least this often (default 1s)
+	FlushInterval time.Duration
+	// Timeout bounds one batch request (default 10s)
+	Timeout time.Duration
+	// Client sends the batches (default a client with Timeout)
+	Client *http.Client
+}
+
+// entry is one shipped line
+type entry struct {
+	at   time.Time
+	line string
+}
+
+// Shipper posts log lines in batches to an ingestion endpoint. A batch
+// that fails is dropped; lines are never retried, so an outage costs the
+// lines logged during it rather than memory.
+type Shipper struct {
+	cfg HTTPConfig
+
+	mu      sync.Mutex
+	pending []entry
+	sending sync.Mutex // Serializes batches so they arrive in order
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewShipper creates a shipper and starts its flush loop
+func NewShipper(cfg HTTPConfig) (*Shipper, error) {
+	if u, err := url.Parse(cfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url %q", cfg.URL)
+	}
+	switch cfg.Format {
+	case "":
+		cfg.Format = FormatNDJSON
+	case FormatNDJSON, FormatLoki, FormatElasticsearch:
+	default:
+		return nil, fmt.Errorf("unknown format %q (want ndjson, loki or elasticsearch)", cfg.Format)
+	}
+	if cfg.Index == "" {
+		cfg.Index = "modelscan-logs"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	s := &Shipper{cfg: cfg, stop: make(chan struct{}), done: make(chan struct{})}
+	go s.loop()
+	return s, nil
+}
+
+// Write adds a line to the next batch, sending it when it is full
+func (s *Shipper) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	s.pending = append(s.pending, entry{at: time.Now(), line: string(bytes.TrimRight(p, "\n"))})
+	full := len(s.pending) >= s.cfg.BatchSize
+	s.mu.Unlock()
+	if full {
+		if err := s.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends the waiting lines
+func (s *Shipper) Flush() error {
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	body, contentType, err := s.encode(batch)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("shipping %d lines: %w", len(batch), err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("shipping %d lines: status %d", len(batch), resp.StatusCode)
+	}
+	return nil
+}
+
+// encode renders a batch in the configured format
+func (s *Shipper) encode(batch []entry) ([]byte, string, error) {
+	var buf bytes.Buffer
+	switch s.cfg.Format {
+	case FormatLoki:
+		values := make([][2]string, len(batch))
+		for i, e := range batch {
+			values[i] = [2]string{strconv.FormatInt(e.at.UnixNano(), 10), e.line}
+		}
+		labels := s.cfg.Labels
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		err := json.NewEncoder(&buf).Encode(map[string]interface{}{
+			"streams": []map[string]interface{}{{"stream": labels, "values": values}},
+		})
+		return buf.Bytes(), "application/json", err
+
+	default:
+		enc := json.NewEncoder(&buf)
+		for _, e := range batch {
+			if s.cfg.Format == FormatElasticsearch {
+				if err := enc.Encode(map[string]map[string]string{"index": {"_index": s.cfg.Index}}); err != nil {
+					return nil, "", err
+				}
+			}
+			doc := make(map[string]string, len(s.cfg.Labels)+2)
+			for k, v := range s.cfg.Labels {
+				doc[k] = v
+			}
+			doc["@timestamp"] = e.at.UTC().Format(time.RFC3339Nano)
+			doc["message"] = e.line
+			if err := enc.Encode(doc); err != nil {
+				return nil, "", err
+			}
+		}
+		return buf.Bytes(), "application/x-ndjson", nil
+	}
+}
+
+// loop sends waiting lines every flush interval
+func (s *Shipper) loop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				fmt.Fprintf(errOut, "logsink: http: %v\n", err)
+			}
+		}
+	}
+}
+
+// Close stops the flush loop and sends the waiting lines
+func (s *Shipper) Close() error {
+	close(s.stop)
+	<-s.done
+	return s.Flush()
+}
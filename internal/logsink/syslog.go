@@ -0,0 +1,38 @@
+//go:build !windows && !plan9
+
+package logsink
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+)
+
+// facilities maps facility names to syslog facilities
+var facilities = map[string]syslog.Priority{
+	"user":   syslog.LOG_USER,
+	"daemon": syslog.LOG_DAEMON,
+	"local0": syslog.LOG_LOCAL0,
+	"local1": syslog.LOG_LOCAL1,
+	"local2": syslog.LOG_LOCAL2,
+	"local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4,
+	"local5": syslog.LOG_LOCAL5,
+	"local6": syslog.LOG_LOCAL6,
+	"local7": syslog.LOG_LOCAL7,
+}
+
+// DialSyslog connects to a syslog daemon; lines are sent at info severity
+func DialSyslog(cfg SyslogConfig) (io.WriteCloser, error) {
+	if cfg.Facility == "" {
+		cfg.Facility = "daemon"
+	}
+	facility, ok := facilities[cfg.Facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown facility %q", cfg.Facility)
+	}
+	if cfg.Tag == "" {
+		cfg.Tag = "modelscan"
+	}
+	return syslog.Dial(cfg.Network, cfg.Address, facility|syslog.LOG_INFO, cfg.Tag)
+}
@@ -0,0 +1,42 @@
+//go:build !windows && !plan9
+
+package logsink
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDialSyslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("udp unavailable: %v", err)
+	}
+	defer conn.Close()
+
+	w, err := DialSyslog(SyslogConfig{Network: "udp", Address: conn.LocalAddr().String(), Facility: "local3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte("proxy started\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// local3 (19) * 8 + info (6)
+	if msg := string(buf[:n]); !strings.HasPrefix(msg, "<158>") || !strings.Contains(msg, "modelscan") || !strings.Contains(msg, "proxy started") {
+		t.Errorf("unexpected message: %q", msg)
+	}
+
+	if _, err := DialSyslog(SyslogConfig{Facility: "kernel"}); err == nil {
+		t.Error("expected an unknown facility to fail")
+	}
+}
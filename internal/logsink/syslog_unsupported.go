@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package logsink
+
+import (
+	"errors"
+	"io"
+)
+
+// DialSyslog is not supported on this platform
+func DialSyslog(cfg SyslogConfig) (io.WriteCloser, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,90 @@
+// Package maintenance tracks providers that operators have drained or
+// scheduled maintenance for, from windows stored in the database, so the
+// proxy and scheduler can leave them alone until the window ends.
+package maintenance
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// Database interface for loading maintenance windows
+type Database interface {
+	// ListCurrentMaintenance returns the windows that have not ended by now
+	ListCurrentMaintenance(now time.Time) ([]*database.MaintenanceWindow, error)
+}
+
+// Tracker answers availability questions from an in-memory copy of the
+// current and upcoming windows. Scheduled windows take effect on time
+// without a reload. It implements proxy.ProviderAvailability.
+type Tracker struct {
+	db       Database
+	current  atomic.Pointer[[]*database.MaintenanceWindow]
+	reloadMu sync.Mutex
+	now      func() time.Time
+}
+
+// NewTracker creates a tracker and performs the initial load
+func NewTracker(db Database) (*Tracker, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is required")
+	}
+	t := &Tracker{db: db, now: time.Now}
+	t.current.Store(&[]*database.MaintenanceWindow{})
+	if err := t.Reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Reload re-reads the windows and atomically swaps them in. On error the
+// previous windows stay in effect.
+func (t *Tracker) Reload() error {
+	t.reloadMu.Lock()
+	defer t.reloadMu.Unlock()
+
+	windows, err := t.db.ListCurrentMaintenance(t.now())
+	if err != nil {
+		return fmt.Errorf("failed to load maintenance windows: %w", err)
+	}
+	t.current.Store(&windows)
+	return nil
+}
+
+// Active returns the windows in effect now, ordered by provider
+func (t *Tracker) Active() []*database.MaintenanceWindow {
+	now := t.now()
+	var active []*database.MaintenanceWindow
+	for _, w := range *t.current.Load() {
+		if w.ActiveAt(now) {
+			active = append(active, w)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool { return active[i].Provider < active[j].Provider })
+	return active
+}
+
+// Unavailable reports whether provider is in a window now and when the
+// last of its windows ends; zero means it is drained until an operator
+// ends the drain
+func (t *Tracker) Unavailable(provider string) (until time.Time, unavailable bool) {
+	now := t.now()
+	for _, w := range *t.current.Load() {
+		if w.Provider != provider || !w.ActiveAt(now) {
+			continue
+		}
+		if w.EndsAt == nil {
+			return time.Time{}, true
+		}
+		if !unavailable || w.EndsAt.After(until) {
+			until = *w.EndsAt
+		}
+		unavailable = true
+	}
+	return until, unavailable
+}
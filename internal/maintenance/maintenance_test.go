@@ -0,0 +1,67 @@
+package maintenance
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+type fakeDB struct {
+	windows []*database.MaintenanceWindow
+	err     error
+}
+
+func (f *fakeDB) ListCurrentMaintenance(now time.Time) ([]*database.MaintenanceWindow, error) {
+	return f.windows, f.err
+}
+
+func TestTracker(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time { v := now.Add(d); return &v }
+
+	db := &fakeDB{windows: []*database.MaintenanceWindow{
+		{ID: 1, Provider: "openai", StartsAt: now.Add(-time.Hour)},                                     // Drain
+		{ID: 2, Provider: "anthropic", StartsAt: now.Add(-time.Minute), EndsAt: at(time.Hour)},         // In progress
+		{ID: 3, Provider: "anthropic", StartsAt: now.Add(30 * time.Minute), EndsAt: at(2 * time.Hour)}, // Overlaps, ends later
+		{ID: 4, Provider: "groq", StartsAt: now.Add(time.Hour), EndsAt: at(2 * time.Hour)},             // Upcoming
+	}}
+	tr, err := NewTracker(db)
+	if err != nil {
+		t.Fatalf("NewTracker failed: %v", err)
+	}
+	tr.now = func() time.Time { return now }
+
+	if until, down := tr.Unavailable("openai"); !down || !until.IsZero() {
+		t.Errorf("expected openai drained without an end, got %v %v", until, down)
+	}
+	if until, down := tr.Unavailable("anthropic"); !down || !until.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected anthropic back in an hour, got %v %v", until, down)
+	}
+	if _, down := tr.Unavailable("groq"); down {
+		t.Error("upcoming window should not be in effect yet")
+	}
+	if active := tr.Active(); len(active) != 2 || active[0].Provider != "anthropic" || active[1].Provider != "openai" {
+		t.Errorf("unexpected active windows: %+v", active)
+	}
+
+	// Scheduled windows start and stop on time without a reload
+	tr.now = func() time.Time { return now.Add(45 * time.Minute) }
+	if until, _ := tr.Unavailable("anthropic"); !until.Equal(now.Add(2 * time.Hour)) {
+		t.Errorf("expected the later overlapping window to win, got %v", until)
+	}
+	tr.now = func() time.Time { return now.Add(90 * time.Minute) }
+	if _, down := tr.Unavailable("groq"); !down {
+		t.Error("expected groq window to be in effect")
+	}
+
+	// A failed reload keeps the previous windows
+	db.err = errors.New("db down")
+	if err := tr.Reload(); err == nil {
+		t.Error("expected reload error")
+	}
+	if _, down := tr.Unavailable("openai"); !down {
+		t.Error("expected previous windows to stay in effect")
+	}
+}
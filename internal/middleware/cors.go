@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// Defaults for CORSConfig fields left empty
+var (
+	DefaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	DefaultCORSHeaders = []string{
+		"Authorization", "Content-Type", "X-Client-Token", mshttp.HeaderRequestID,
+		"x-api-key", "anthropic-version", "anthropic-beta", mshttp.DefaultIdempotencyHeader,
+		"X-Request-Timeout", "X-Session-ID",
+	}
+	DefaultCORSExposedHeaders = []string{mshttp.HeaderRequestID}
+)
+
+// DefaultCORSMaxAge is how long browsers may cache a preflight response
+const DefaultCORSMaxAge = 10 * time.Minute
+
+// CORSConfig lists which browser origins may call the server
+type CORSConfig struct {
+	// AllowedOrigins are exact origins ("https://app.example.com"),
+	// subdomain wildcards ("https://*.example.com") or "*" for any origin
+	AllowedOrigins   []string
+	AllowedMethods   []string // default DefaultCORSMethods
+	AllowedHeaders   []string // default DefaultCORSHeaders
+	ExposedHeaders   []string // response headers scripts may read (default DefaultCORSExposedHeaders)
+	AllowCredentials bool     // allow cookies and HTTP auth; the origin is echoed instead of "*"
+	MaxAge           time.Duration
+}
+
+// CORS answers preflight requests and adds CORS headers to responses for
+// allowed origins. Requests from other origins are served without CORS
+// headers, so browsers refuse to hand the response to the page.
+func CORS(cfg CORSConfig) Middleware {
+	methods := strings.Join(orDefault(cfg.AllowedMethods, DefaultCORSMethods), ", ")
+	headers := strings.Join(orDefault(cfg.AllowedHeaders, DefaultCORSHeaders), ", ")
+	exposed := strings.Join(orDefault(cfg.ExposedHeaders, DefaultCORSExposedHeaders), ", ")
+	maxAge := cfg.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultCORSMaxAge
+	}
+	anyOrigin := false
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			anyOrigin = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if origin == "" || !originAllowed(cfg.AllowedOrigins, origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin && !cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+				h.Set("Access-Control-Allow-Methods", methods)
+				h.Set("Access-Control-Allow-Headers", headers)
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge.Seconds())))
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if exposed != "" {
+				h.Set("Access-Control-Expose-Headers", exposed)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// originAllowed reports whether origin matches one of the allowed patterns
+func originAllowed(allowed []string, origin string) bool {
+	for _, pattern := range allowed {
+		switch {
+		case pattern == "*" || strings.EqualFold(pattern, origin):
+			return true
+		case strings.Contains(pattern, "://*."):
+			// https://*.example.com matches https://app.example.com but not
+			// https://example.com or https://evilexample.com
+			scheme, domain, _ := strings.Cut(pattern, "://*")
+			rest, ok := strings.CutPrefix(strings.ToLower(origin), strings.ToLower(scheme)+"://")
+			if ok && strings.HasSuffix(rest, strings.ToLower(domain)) && len(rest) > len(domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func orDefault(values, def []string) []string {
+	if len(values) == 0 {
+		return def
+	}
+	return values
+}
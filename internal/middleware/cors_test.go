@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORSPreflight(t *testing.T) {
+	called := false
+	h := CORS(CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, MaxAge: time.Hour})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+	req := httptest.NewRequest(http.MethodOptions, "/v1/chat/completions", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if called {
+		t.Error("preflight should not reach the handler")
+	}
+	hdr := rec.Header()
+	if rec.Code != http.StatusNoContent || hdr.Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		hdr.Get("Access-Control-Max-Age") != "3600" || hdr.Get("Access-Control-Allow-Methods") == "" {
+		t.Errorf("unexpected preflight response %d %v", rec.Code, hdr)
+	}
+}
+
+func TestCORSOrigins(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         CORSConfig
+		origin      string
+		allowOrigin string
+	}{
+		{"exact", CORSConfig{AllowedOrigins: []string{"https://app.example.com"}}, "https://app.example.com", "https://app.example.com"},
+		{"not listed", CORSConfig{AllowedOrigins: []string{"https://app.example.com"}}, "https://evil.test", ""},
+		{"subdomain wildcard", CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}, "https://a.b.example.com", "https://a.b.example.com"},
+		{"wildcard needs a subdomain", CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}, "https://evilexample.com", ""},
+		{"wildcard scheme", CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}, "http://app.example.com", ""},
+		{"any", CORSConfig{AllowedOrigins: []string{"*"}}, "http://localhost:3000", "*"},
+		{"any with credentials echoes", CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}, "http://localhost:3000", "http://localhost:3000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := CORS(tt.cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+			req.Header.Set("Origin", tt.origin)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.allowOrigin)
+			}
+			if tt.allowOrigin != "" && rec.Header().Get("Access-Control-Expose-Headers") != "X-Request-ID" {
+				t.Errorf("X-Request-ID not exposed: %v", rec.Header())
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// DefaultGzipMinSize is the smallest response worth compressing
+const DefaultGzipMinSize = 1024
+
+// GzipConfig controls response compression
+type GzipConfig struct {
+	MinSize int // responses smaller than this are sent as is (default DefaultGzipMinSize)
+	Level   int // gzip.BestSpeed to gzip.BestCompression (default gzip.DefaultCompression)
+}
+
+// Gzip compresses responses for clients that accept gzip. Server-sent
+// event streams, responses that are already encoded, protocol upgrades
+// and responses under MinSize are passed through unchanged.
+func Gzip(cfg GzipConfig) Middleware {
+	if cfg.MinSize <= 0 {
+		cfg.MinSize = DefaultGzipMinSize
+	}
+	if cfg.Level == 0 {
+		cfg.Level = gzip.DefaultCompression
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if !acceptsGzip(r) || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// Not deferred: after a panic Recover must still be able to send
+			// its own response instead of the buffered partial one
+			gw := &gzipWriter{ResponseWriter: w, cfg: cfg}
+			next.ServeHTTP(gw, r)
+			_ = gw.Close()
+		})
+	}
+}
+
+// acceptsGzip reports whether the client listed gzip in Accept-Encoding
+// without refusing it with q=0
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			q := strings.ReplaceAll(params, " ", "")
+			return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+		}
+	}
+	return false
+}
+
+// gzipWriter buffers the start of a response until it knows whether to
+// compress it: once MinSize bytes are written, on Flush, or on Close
+type gzipWriter struct {
+	http.ResponseWriter
+	cfg GzipConfig
+
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer // nil when passing through
+}
+
+func (gw *gzipWriter) WriteHeader(status int) {
+	if gw.decided || gw.status != 0 {
+		return
+	}
+	// Informational responses are sent straight away
+	if status >= 100 && status < 200 {
+		gw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	gw.status = status
+	// Bodyless responses need no decision
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		gw.decide(false)
+	}
+}
+
+func (gw *gzipWriter) Write(b []byte) (int, error) {
+	if gw.status == 0 {
+		gw.status = http.StatusOK
+	}
+	if !gw.decided {
+		if !gw.compressible() {
+			if err := gw.start(false); err != nil {
+				return 0, err
+			}
+		} else {
+			gw.buf = append(gw.buf, b...)
+			if len(gw.buf) < gw.cfg.MinSize {
+				return len(b), nil
+			}
+			if err := gw.start(true); err != nil {
+				return 0, err
+			}
+			return len(b), nil
+		}
+	}
+	if gw.gz != nil {
+		return gw.gz.Write(b)
+	}
+	return gw.ResponseWriter.Write(b)
+}
+
+// Flush compresses what is buffered and flushes it to the client
+func (gw *gzipWriter) Flush() {
+	if !gw.decided {
+		if gw.status == 0 {
+			gw.status = http.StatusOK
+		}
+		if err := gw.start(gw.compressible()); err != nil {
+			return
+		}
+	}
+	if gw.gz != nil {
+		_ = gw.gz.Flush()
+	}
+	if f, ok := gw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close sends a response that never reached MinSize uncompressed and
+// finishes the gzip stream otherwise
+func (gw *gzipWriter) Close() error {
+	if !gw.decided {
+		if gw.status == 0 && len(gw.buf) == 0 {
+			// Nothing was written; leave the default response to the server
+			return nil
+		}
+		if gw.status == 0 {
+			gw.status = http.StatusOK
+		}
+		if err := gw.start(false); err != nil {
+			return err
+		}
+	}
+	if gw.gz != nil {
+		return gw.gz.Close()
+	}
+	return nil
+}
+
+// Hijack lets protocol upgrades take over the connection
+func (gw *gzipWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := gw.ResponseWriter.(http.Hijacker); ok {
+		gw.decided = true
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Unwrap lets http.ResponseController reach the client connection
+func (gw *gzipWriter) Unwrap() http.ResponseWriter {
+	return gw.ResponseWriter
+}
+
+// compressible reports whether the handler's headers allow compression
+func (gw *gzipWriter) compressible() bool {
+	h := gw.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	ct := h.Get("Content-Type")
+	return !strings.HasPrefix(ct, "text/event-stream") && !strings.HasPrefix(ct, "image/") &&
+		!strings.HasPrefix(ct, "audio/") && !strings.HasPrefix(ct, "video/")
+}
+
+// start decides, sends the headers and writes the buffered body
+func (gw *gzipWriter) start(compress bool) error {
+	gw.decide(compress)
+	if len(gw.buf) == 0 {
+		return nil
+	}
+	buf := gw.buf
+	gw.buf = nil
+	var err error
+	if gw.gz != nil {
+		_, err = gw.gz.Write(buf)
+	} else {
+		_, err = gw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// decide sends the headers, setting up compression if compress is true
+func (gw *gzipWriter) decide(compress bool) {
+	gw.decided = true
+	if compress {
+		h := gw.Header()
+		// Sniff before the body is compressed, as the server would after
+		if h.Get("Content-Type") == "" && len(gw.buf) > 0 {
+			h.Set("Content-Type", http.DetectContentType(gw.buf))
+		}
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		gz, err := gzip.NewWriterLevel(gw.ResponseWriter, gw.cfg.Level)
+		if err != nil {
+			gz = gzip.NewWriter(gw.ResponseWriter)
+		}
+		gw.gz = gz
+	}
+	gw.ResponseWriter.WriteHeader(gw.status)
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGzip(t *testing.T) {
+	large := strings.Repeat(`{"model":"gpt-4o","object":"model"},`, 100)
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		wantGzip       bool
+	}{
+		{"large JSON", "gzip, deflate", "application/json", large, true},
+		{"small JSON", "gzip", "application/json", `{"ok":true}`, false},
+		{"not accepted", "", "application/json", large, false},
+		{"refused", "gzip;q=0, br", "application/json", large, false},
+		{"event stream", "gzip", "text/event-stream", large, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Gzip(GzipConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				// Written in pieces to exercise buffering up to MinSize
+				for i := 0; i < len(tt.body); i += 100 {
+					w.Write([]byte(tt.body[i:min(i+100, len(tt.body))]))
+				}
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			gotGzip := rec.Header().Get("Content-Encoding") == "gzip"
+			if gotGzip != tt.wantGzip {
+				t.Fatalf("Content-Encoding = %q, want gzip %v", rec.Header().Get("Content-Encoding"), tt.wantGzip)
+			}
+			body := rec.Body.String()
+			if gotGzip {
+				zr, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, _ := io.ReadAll(zr)
+				body = string(b)
+			}
+			if body != tt.body {
+				t.Errorf("body mismatch: got %d bytes, want %d", len(body), len(tt.body))
+			}
+			if rec.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("Vary = %q", rec.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestGzipFlushAndStatus(t *testing.T) {
+	h := Gzip(GzipConfig{MinSize: 4096})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(`{"id":2}`))
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/api/aliases", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated || !rec.Flushed || rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("got %d, flushed %v, encoding %q", rec.Code, rec.Flushed, rec.Header().Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(zr); string(b) != `{"id":1}{"id":2}` {
+		t.Errorf("body = %q", b)
+	}
+}
+
+func TestGzipPanicLeavesResponseToRecover(t *testing.T) {
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("boom")
+	}), RequestID, Recover, Gzip(GzipConfig{}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	quietLogs(t)
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "partial") {
+		t.Errorf("got %d %q, want the recovered 500", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,178 @@
+// Package middleware provides the HTTP middleware stack wrapped around the
+// proxy and admin API: request IDs, panic recovery, access logging, CORS
+// and gzip compression.
+//
+// A typical stack, outermost first:
+//
+//	handler := middleware.Chain(mux,
+//	    middleware.RequestID,
+//	    middleware.Recover,
+//	    middleware.CORS(middleware.CORSConfig{AllowedOrigins: []string{"*"}}),
+//	    middleware.Gzip(middleware.GzipConfig{}),
+//	)
+package middleware
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// Middleware wraps a handler
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h with mws; the first middleware is the outermost and sees
+// the request first
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// validRequestID limits client-supplied IDs to what is safe to log and
+// forward upstream
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:\-]{1,128}$`)
+
+// RequestID keeps a valid X-Request-ID from the client or generates one,
+// echoes it in the response and stores it in the request context, where
+// the proxies forward it upstream and RequestIDFrom reads it
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(mshttp.HeaderRequestID)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(mshttp.HeaderRequestID, id)
+		next.ServeHTTP(w, r.WithContext(mshttp.WithRequestID(r.Context(), id)))
+	})
+}
+
+// RequestIDFrom returns the request's ID, or "" outside RequestID
+func RequestIDFrom(r *http.Request) string {
+	return mshttp.RequestIDFrom(r.Context())
+}
+
+// Recover turns a panic in a handler into a JSON 500 carrying the request
+// ID, and logs the panic with its stack. If the response has already
+// started the connection is closed instead, since the status can no longer
+// change. Each request is tracked for crash reports, and a panic is
+// counted and reported through the crash package.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := crash.Request{
+			ID:      RequestIDFrom(r),
+			Method:  r.Method,
+			Path:    r.URL.Path,
+			Remote:  r.RemoteAddr,
+			Started: time.Now(),
+		}
+		crash.Track(req)
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			// The server's own signal to abort a response quietly
+			if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(v)
+			}
+			id := RequestIDFrom(r)
+			stack := debug.Stack()
+			note := ""
+			if path := crash.Capture("http", v, stack, &req); path != "" {
+				note = " (crash report: " + path + ")"
+			}
+			log.Printf("panic serving %s %s (request_id=%s): %v%s\n%s", r.Method, r.URL.Path, id, v, note, stack)
+			if sw.status != 0 {
+				panic(http.ErrAbortHandler)
+			}
+			h := w.Header()
+			h.Del("Content-Length")
+			h.Del("Content-Encoding")
+			h.Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":      http.StatusText(http.StatusInternalServerError),
+				"message":    "internal server error",
+				"request_id": id,
+			})
+		}()
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// AccessLog logs one line per request with its status, size, duration and
+// request ID
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() {
+			status := sw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			log.Printf("%s %s %d %dB %s request_id=%s", r.Method, r.URL.Path, status, sw.bytes, time.Since(start).Round(time.Millisecond), RequestIDFrom(r))
+		}()
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// statusWriter records the status and size of a response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	if sw.status == 0 {
+		sw.status = status
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	n, err := sw.ResponseWriter.Write(b)
+	sw.bytes += int64(n)
+	return n, err
+}
+
+// Flush forwards flushes so streaming keeps working through the middleware
+func (sw *statusWriter) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack lets protocol upgrades take over the connection
+func (sw *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := sw.ResponseWriter.(http.Hijacker); ok {
+		sw.status = http.StatusSwitchingProtocols
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Unwrap lets http.ResponseController reach the client connection
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// quietLogs captures log output for the rest of the test
+func quietLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFrom(r)
+	}))
+
+	// A valid client ID is kept and echoed
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set(mshttp.HeaderRequestID, "trace-123.abc")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if seen != "trace-123.abc" || rec.Header().Get(mshttp.HeaderRequestID) != "trace-123.abc" {
+		t.Errorf("client ID not kept: context %q, header %q", seen, rec.Header().Get(mshttp.HeaderRequestID))
+	}
+
+	// Missing or unsafe IDs are replaced
+	for _, incoming := range []string{"", "bad id\nwith newline", strings.Repeat("a", 200)} {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Header.Set(mshttp.HeaderRequestID, incoming)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		got := rec.Header().Get(mshttp.HeaderRequestID)
+		if got == "" || got == incoming || got != seen {
+			t.Errorf("incoming %q: header %q, context %q", incoming, got, seen)
+		}
+	}
+}
+
+func TestRecover(t *testing.T) {
+	logs := quietLogs(t)
+
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		panic("nil map")
+	}), RequestID, Recover)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set(mshttp.HeaderRequestID, "req-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("got %d %q, want a JSON 500", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body["request_id"] != "req-1" || body["error"] != "Internal Server Error" {
+		t.Errorf("unexpected body %v", body)
+	}
+	if !strings.Contains(logs.String(), "request_id=req-1") || !strings.Contains(logs.String(), "nil map") {
+		t.Errorf("panic not logged with its request ID: %q", logs.String())
+	}
+}
+
+func TestRecoverAfterResponseStarted(t *testing.T) {
+	quietLogs(t)
+
+	h := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("late")
+	}))
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("expected http.ErrAbortHandler, got %v", v)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestAccessLog(t *testing.T) {
+	logs := quietLogs(t)
+
+	h := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	}), RequestID, AccessLog)
+	req := httptest.NewRequest(http.MethodGet, "/api/providers", nil)
+	req.Header.Set(mshttp.HeaderRequestID, "req-2")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if line := logs.String(); !strings.Contains(line, "GET /api/providers 418 15B") || !strings.Contains(line, "request_id=req-2") {
+		t.Errorf("unexpected access log %q", line)
+	}
+}
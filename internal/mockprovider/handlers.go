@@ -0,0 +1,381 @@
+package mockprovider
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Models advertised by GET /v1/models
+var (
+	chatModels      = []string{"mock-gpt", "mock-gpt-mini"}
+	anthropicModels = []string{"mock-claude", "mock-claude-haiku"}
+	embeddingModels = []string{"mock-embedding"}
+)
+
+// defaultDimensions is the embedding size when the request does not ask
+const defaultDimensions = 8
+
+// message is the part of an OpenAI or Anthropic message the mock reads.
+// Content may be a string or a list of content blocks.
+type message struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// text flattens the message content to plain text
+func (m message) text() string {
+	var s string
+	if json.Unmarshal(m.Content, &s) == nil {
+		return s
+	}
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	json.Unmarshal(m.Content, &blocks)
+	parts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Text != "" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// chatRequest covers both chat completions and messages requests
+type chatRequest struct {
+	Model         string    `json:"model"`
+	Messages      []message `json:"messages"`
+	System        string    `json:"system,omitempty"`
+	MaxTokens     int       `json:"max_tokens,omitempty"`
+	Stream        bool      `json:"stream,omitempty"`
+	StreamOptions *struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options,omitempty"`
+}
+
+// completion is the deterministic answer to a chat request
+type completion struct {
+	id           string
+	model        string
+	words        []string
+	promptTokens int
+	finish       string
+}
+
+// complete builds the reply: the configured or requested text, or an echo of
+// the last user message, truncated to max_tokens words
+func (s *Server) complete(r *http.Request, req *chatRequest, prefix string) completion {
+	prompt := req.System
+	last := ""
+	for _, m := range req.Messages {
+		text := m.text()
+		prompt += " " + text
+		if m.Role == "user" {
+			last = text
+		}
+	}
+
+	reply := "Mock response to: " + last
+	if s.config.Reply != "" {
+		reply = s.config.Reply
+	}
+	if v := r.URL.Query().Get("reply"); v != "" {
+		reply = v
+	}
+
+	c := completion{
+		model:        req.Model,
+		words:        strings.Fields(reply),
+		promptTokens: countTokens(prompt),
+		finish:       "stop",
+	}
+	if req.MaxTokens > 0 && len(c.words) > req.MaxTokens {
+		c.words = c.words[:req.MaxTokens]
+		c.finish = "length"
+	}
+	sum := sha256.Sum256([]byte(req.Model + prompt))
+	c.id = fmt.Sprintf("%s%x", prefix, sum[:8])
+	return c
+}
+
+// handleChatCompletions emulates POST /v1/chat/completions
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req chatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model == "" || len(req.Messages) == 0 {
+		writeError(w, apiOpenAI, http.StatusBadRequest, "model and messages are required", 0)
+		return
+	}
+	c := s.complete(r, &req, "chatcmpl-mock-")
+	usage := map[string]int{
+		"prompt_tokens":     c.promptTokens,
+		"completion_tokens": len(c.words),
+		"total_tokens":      c.promptTokens + len(c.words),
+	}
+	created := time.Now().Unix()
+
+	if !req.Stream {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"id":      c.id,
+			"object":  "chat.completion",
+			"created": created,
+			"model":   c.model,
+			"choices": []map[string]interface{}{{
+				"index":         0,
+				"message":       map[string]string{"role": "assistant", "content": strings.Join(c.words, " ")},
+				"finish_reason": c.finish,
+			}},
+			"usage": usage,
+		})
+		return
+	}
+
+	stream, ok := s.newStream(w, r)
+	if !ok {
+		return
+	}
+	chunk := func(delta map[string]string, finish interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"id":      c.id,
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   c.model,
+			"choices": []map[string]interface{}{{"index": 0, "delta": delta, "finish_reason": finish}},
+		}
+	}
+
+	stream.data(chunk(map[string]string{"role": "assistant"}, nil))
+	for i, word := range c.words {
+		if i > 0 {
+			word = " " + word
+		}
+		stream.data(chunk(map[string]string{"content": word}, nil))
+	}
+	stream.data(chunk(map[string]string{}, c.finish))
+	if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+		stream.data(map[string]interface{}{
+			"id":      c.id,
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   c.model,
+			"choices": []interface{}{},
+			"usage":   usage,
+		})
+	}
+	stream.raw("data: [DONE]\n\n")
+}
+
+// handleMessages emulates POST /v1/messages
+func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
+	var req chatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model == "" || len(req.Messages) == 0 {
+		writeError(w, apiAnthropic, http.StatusBadRequest, "model and messages are required", 0)
+		return
+	}
+	if req.MaxTokens <= 0 {
+		writeError(w, apiAnthropic, http.StatusBadRequest, "max_tokens is required", 0)
+		return
+	}
+	c := s.complete(r, &req, "msg_mock_")
+	stopReason := "end_turn"
+	if c.finish == "length" {
+		stopReason = "max_tokens"
+	}
+
+	if !req.Stream {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"id":          c.id,
+			"type":        "message",
+			"role":        "assistant",
+			"model":       c.model,
+			"content":     []map[string]string{{"type": "text", "text": strings.Join(c.words, " ")}},
+			"stop_reason": stopReason,
+			"usage":       map[string]int{"input_tokens": c.promptTokens, "output_tokens": len(c.words)},
+		})
+		return
+	}
+
+	stream, ok := s.newStream(w, r)
+	if !ok {
+		return
+	}
+	stream.event("message_start", map[string]interface{}{
+		"type": "message_start",
+		"message": map[string]interface{}{
+			"id": c.id, "type": "message", "role": "assistant", "model": c.model,
+			"content": []interface{}{},
+			"usage":   map[string]int{"input_tokens": c.promptTokens, "output_tokens": 0},
+		},
+	})
+	stream.event("content_block_start", map[string]interface{}{
+		"type": "content_block_start", "index": 0,
+		"content_block": map[string]string{"type": "text", "text": ""},
+	})
+	for i, word := range c.words {
+		if i > 0 {
+			word = " " + word
+		}
+		stream.event("content_block_delta", map[string]interface{}{
+			"type": "content_block_delta", "index": 0,
+			"delta": map[string]string{"type": "text_delta", "text": word},
+		})
+	}
+	stream.event("content_block_stop", map[string]interface{}{"type": "content_block_stop", "index": 0})
+	stream.event("message_delta", map[string]interface{}{
+		"type":  "message_delta",
+		"delta": map[string]string{"stop_reason": stopReason},
+		"usage": map[string]int{"output_tokens": len(c.words)},
+	})
+	stream.event("message_stop", map[string]string{"type": "message_stop"})
+}
+
+// handleEmbeddings emulates POST /v1/embeddings
+func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Model      string          `json:"model"`
+		Input      json.RawMessage `json:"input"`
+		Dimensions int             `json:"dimensions,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model == "" {
+		writeError(w, apiOpenAI, http.StatusBadRequest, "model and input are required", 0)
+		return
+	}
+	var inputs []string
+	var single string
+	if json.Unmarshal(req.Input, &single) == nil {
+		inputs = []string{single}
+	} else if json.Unmarshal(req.Input, &inputs) != nil || len(inputs) == 0 {
+		writeError(w, apiOpenAI, http.StatusBadRequest, "input must be a string or an array of strings", 0)
+		return
+	}
+	dims := req.Dimensions
+	if dims <= 0 {
+		dims = defaultDimensions
+	}
+	if dims > 4096 {
+		writeError(w, apiOpenAI, http.StatusBadRequest, "dimensions must be at most 4096", 0)
+		return
+	}
+
+	data := make([]map[string]interface{}, len(inputs))
+	tokens := 0
+	for i, in := range inputs {
+		tokens += countTokens(in)
+		data[i] = map[string]interface{}{"object": "embedding", "index": i, "embedding": embed(in, dims)}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"object": "list",
+		"model":  req.Model,
+		"data":   data,
+		"usage":  map[string]int{"prompt_tokens": tokens, "total_tokens": tokens},
+	})
+}
+
+// handleModels emulates GET /v1/models in the OpenAI format, or the Anthropic
+// format when an anthropic-version header is present
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get("anthropic-version") != "" {
+		data := make([]map[string]string, len(anthropicModels))
+		for i, id := range anthropicModels {
+			data[i] = map[string]string{"id": id, "type": "model", "display_name": id}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": data, "has_more": false})
+		return
+	}
+	var data []map[string]interface{}
+	for _, id := range append(append([]string{}, chatModels...), embeddingModels...) {
+		data = append(data, map[string]interface{}{"id": id, "object": "model", "owned_by": "mockprovider"})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"object": "list", "data": data})
+}
+
+// sseStream writes server-sent events, optionally aborting after N chunks
+type sseStream struct {
+	w          http.ResponseWriter
+	flusher    http.Flusher
+	delay      time.Duration
+	abortAfter int
+	sent       int
+	server     *Server
+}
+
+func (s *Server) newStream(w http.ResponseWriter, r *http.Request) (*sseStream, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return nil, false
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &sseStream{w: w, flusher: flusher, delay: s.config.ChunkDelay, server: s}
+	if n, err := strconv.Atoi(r.URL.Query().Get("abort_after")); err == nil && n >= 0 {
+		stream.abortAfter = n
+	} else {
+		stream.abortAfter = -1
+	}
+	return stream, true
+}
+
+// data writes a "data:" event
+func (st *sseStream) data(v interface{}) {
+	b, _ := json.Marshal(v)
+	st.raw("data: " + string(b) + "\n\n")
+}
+
+// event writes a named event
+func (st *sseStream) event(name string, v interface{}) {
+	b, _ := json.Marshal(v)
+	st.raw("event: " + name + "\ndata: " + string(b) + "\n\n")
+}
+
+// raw writes one chunk, dropping the connection once abort_after is reached
+func (st *sseStream) raw(chunk string) {
+	if st.abortAfter >= 0 && st.sent >= st.abortAfter {
+		st.server.mu.Lock()
+		st.server.stats.Aborted++
+		st.server.mu.Unlock()
+		panic(http.ErrAbortHandler)
+	}
+	if st.sent > 0 && st.delay > 0 {
+		time.Sleep(st.delay)
+	}
+	st.w.Write([]byte(chunk))
+	st.flusher.Flush()
+	st.sent++
+}
+
+// countTokens approximates tokens as whitespace-separated words
+func countTokens(s string) int {
+	return len(strings.Fields(s))
+}
+
+// embed derives a unit vector of the given size from a hash of the input
+func embed(input string, dims int) []float64 {
+	vec := make([]float64, dims)
+	var norm float64
+	seed := sha256.Sum256([]byte(input))
+	for i := range vec {
+		block := sha256.Sum256(append(seed[:], byte(i), byte(i>>8)))
+		v := float64(binary.BigEndian.Uint32(block[:4]))/math.MaxUint32*2 - 1
+		vec[i] = v
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	for i := range vec {
+		vec[i] = math.Round(vec[i]/norm*1e6) / 1e6
+	}
+	return vec
+}
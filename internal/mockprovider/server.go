@@ -0,0 +1,358 @@
+// Package mockprovider emulates the OpenAI and Anthropic HTTP APIs for
+// deterministic end-to-end tests of routing, retries and failover.
+//
+// Responses echo the last user message, token counts are word counts and
+// embeddings are derived from a hash of the input, so identical requests
+// always get identical answers. Faults can be induced per request with
+// query flags or queued for the next requests through the control API:
+//
+//	?status=503          respond with this error status
+//	?delay=250ms         add latency before responding
+//	?fail_rate=0.2       fail this fraction of requests with 500
+//	?abort_after=3       drop a streamed response after N chunks
+//	?reply=text          reply with this text instead of the echo
+//
+//	POST   /mock/faults  {"status": 503, "count": 2} fails the next 2 requests
+//	DELETE /mock/faults  clears queued faults
+//	GET    /mock/stats   request and fault counters
+package mockprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config controls the default behavior of a mock server
+type Config struct {
+	// Latency is added before every response
+	Latency time.Duration
+
+	// ChunkDelay is the pause between streamed chunks
+	ChunkDelay time.Duration
+
+	// RPM is the requests-per-minute limit advertised in rate limit headers
+	// and enforced with 429s. Zero disables both.
+	RPM int
+
+	// FailRate is the fraction of requests failing with 500
+	FailRate float64
+
+	// APIKey, when set, is required as a Bearer token or x-api-key
+	APIKey string
+
+	// Seed makes FailRate sampling reproducible
+	Seed int64
+
+	// Reply replaces the default echo reply
+	Reply string
+}
+
+// Fault is a queued failure applied to the next Count requests
+type Fault struct {
+	Status     int    `json:"status"`
+	Count      int    `json:"count"`
+	Message    string `json:"message,omitempty"`
+	RetryAfter int    `json:"retry_after,omitempty"` // Seconds, sent with 429/503
+}
+
+// Stats counts what the server has seen
+type Stats struct {
+	Requests    map[string]int `json:"requests"`
+	Faults      int            `json:"faults"`
+	RateLimited int            `json:"rate_limited"`
+	Aborted     int            `json:"aborted"`
+}
+
+// Server is an http.Handler emulating provider endpoints
+type Server struct {
+	config Config
+	mux    *http.ServeMux
+
+	mu          sync.Mutex
+	rng         *rand.Rand
+	faults      []Fault
+	window      time.Time
+	windowCount int
+	stats       Stats
+}
+
+// New creates a mock provider server
+func New(cfg Config) *Server {
+	s := &Server{
+		config: cfg,
+		mux:    http.NewServeMux(),
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+		stats:  Stats{Requests: make(map[string]int)},
+	}
+	s.mux.HandleFunc("/v1/chat/completions", s.api(apiOpenAI, s.handleChatCompletions))
+	s.mux.HandleFunc("/v1/embeddings", s.api(apiOpenAI, s.handleEmbeddings))
+	s.mux.HandleFunc("/v1/messages", s.api(apiAnthropic, s.handleMessages))
+	s.mux.HandleFunc("/v1/models", s.handleModels)
+	s.mux.HandleFunc("/mock/faults", s.handleFaults)
+	s.mux.HandleFunc("/mock/stats", s.handleStats)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// QueueFault fails the next fault.Count API requests with fault.Status
+func (s *Server) QueueFault(fault Fault) {
+	if fault.Count <= 0 {
+		fault.Count = 1
+	}
+	s.mu.Lock()
+	s.faults = append(s.faults, fault)
+	s.mu.Unlock()
+}
+
+// ClearFaults drops all queued faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	s.faults = nil
+	s.mu.Unlock()
+}
+
+// Stats returns a snapshot of the counters
+func (s *Server) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot := s.stats
+	snapshot.Requests = make(map[string]int, len(s.stats.Requests))
+	for k, v := range s.stats.Requests {
+		snapshot.Requests[k] = v
+	}
+	return snapshot
+}
+
+// apiFlavor selects the error and rate limit header format
+type apiFlavor int
+
+const (
+	apiOpenAI apiFlavor = iota
+	apiAnthropic
+)
+
+// api wraps an endpoint with method and auth checks, rate limiting, latency
+// and fault injection
+func (s *Server) api(flavor apiFlavor, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query()
+
+		s.mu.Lock()
+		s.stats.Requests[r.URL.Path]++
+		s.mu.Unlock()
+
+		if !s.authorized(r) {
+			writeError(w, flavor, http.StatusUnauthorized, "invalid API key", 0)
+			return
+		}
+
+		delay := s.config.Latency
+		if d, err := time.ParseDuration(q.Get("delay")); err == nil {
+			delay += d
+		}
+		if delay > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+
+		if !s.allow(w, flavor) {
+			writeError(w, flavor, http.StatusTooManyRequests, "rate limit exceeded", 1)
+			return
+		}
+
+		if fault, ok := s.nextFault(q); ok {
+			msg := fault.Message
+			if msg == "" {
+				msg = fmt.Sprintf("induced %d error", fault.Status)
+			}
+			writeError(w, flavor, fault.Status, msg, fault.RetryAfter)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// authorized checks the configured API key
+func (s *Server) authorized(r *http.Request) bool {
+	if s.config.APIKey == "" {
+		return true
+	}
+	if r.Header.Get("x-api-key") == s.config.APIKey {
+		return true
+	}
+	return r.Header.Get("Authorization") == "Bearer "+s.config.APIKey
+}
+
+// allow applies the per-minute window and writes rate limit headers
+func (s *Server) allow(w http.ResponseWriter, flavor apiFlavor) bool {
+	if s.config.RPM <= 0 {
+		return true
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	if now.Sub(s.window) >= time.Minute {
+		s.window = now
+		s.windowCount = 0
+	}
+	allowed := s.windowCount < s.config.RPM
+	if allowed {
+		s.windowCount++
+	} else {
+		s.stats.RateLimited++
+	}
+	remaining := s.config.RPM - s.windowCount
+	reset := s.window.Add(time.Minute)
+	s.mu.Unlock()
+
+	h := w.Header()
+	switch flavor {
+	case apiAnthropic:
+		h.Set("anthropic-ratelimit-requests-limit", strconv.Itoa(s.config.RPM))
+		h.Set("anthropic-ratelimit-requests-remaining", strconv.Itoa(remaining))
+		h.Set("anthropic-ratelimit-requests-reset", reset.UTC().Format(time.RFC3339))
+	default:
+		h.Set("x-ratelimit-limit-requests", strconv.Itoa(s.config.RPM))
+		h.Set("x-ratelimit-remaining-requests", strconv.Itoa(remaining))
+		h.Set("x-ratelimit-reset-requests", fmt.Sprintf("%ds", int(time.Until(reset).Seconds()+0.5)))
+	}
+	return allowed
+}
+
+// nextFault returns the fault to apply to this request, if any: an explicit
+// status flag, then queued faults, then random failures
+func (s *Server) nextFault(q url.Values) (Fault, bool) {
+	if status, err := strconv.Atoi(first(q, "status")); err == nil && status >= 400 {
+		s.countFault()
+		return Fault{Status: status, RetryAfter: 1}, true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.faults) > 0 {
+		fault := s.faults[0]
+		s.faults[0].Count--
+		if s.faults[0].Count <= 0 {
+			s.faults = s.faults[1:]
+		}
+		s.stats.Faults++
+		return fault, true
+	}
+
+	rate := s.config.FailRate
+	if f, err := strconv.ParseFloat(first(q, "fail_rate"), 64); err == nil {
+		rate = f
+	}
+	if rate > 0 && s.rng.Float64() < rate {
+		s.stats.Faults++
+		return Fault{Status: http.StatusInternalServerError, Message: "induced random failure"}, true
+	}
+	return Fault{}, false
+}
+
+func (s *Server) countFault() {
+	s.mu.Lock()
+	s.stats.Faults++
+	s.mu.Unlock()
+}
+
+// handleFaults queues (POST) or clears (DELETE) faults
+func (s *Server) handleFaults(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var fault Fault
+		if err := json.NewDecoder(r.Body).Decode(&fault); err != nil || fault.Status < 400 {
+			http.Error(w, "body must be {\"status\": >=400, \"count\": n}", http.StatusBadRequest)
+			return
+		}
+		s.QueueFault(fault)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		s.ClearFaults()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleStats reports request and fault counters
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Stats())
+}
+
+// writeError writes an error in the flavor's format
+func writeError(w http.ResponseWriter, flavor apiFlavor, status int, message string, retryAfter int) {
+	if retryAfter > 0 && (status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable) {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	if flavor == apiAnthropic {
+		writeJSON(w, status, map[string]interface{}{
+			"type":  "error",
+			"error": map[string]string{"type": anthropicErrorType(status), "message": message},
+		})
+		return
+	}
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{"message": message, "type": openAIErrorType(status), "code": status},
+	})
+}
+
+func openAIErrorType(status int) string {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return "rate_limit_exceeded"
+	case status == http.StatusUnauthorized:
+		return "invalid_api_key"
+	case status < 500:
+		return "invalid_request_error"
+	default:
+		return "server_error"
+	}
+}
+
+func anthropicErrorType(status int) string {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case status == http.StatusUnauthorized:
+		return "authentication_error"
+	case status == 529:
+		return "overloaded_error"
+	case status < 500:
+		return "invalid_request_error"
+	default:
+		return "api_error"
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func first(q url.Values, key string) string {
+	if v := q[key]; len(v) > 0 {
+		return strings.TrimSpace(v[0])
+	}
+	return ""
+}
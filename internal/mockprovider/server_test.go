@@ -0,0 +1,379 @@
+package mockprovider
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func post(t *testing.T, url string, body string, header map[string]string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST %s: %v", url, err)
+	}
+	return resp
+}
+
+func decode(t *testing.T, resp *http.Response, v interface{}) {
+	t.Helper()
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+}
+
+// sseData collects the data lines of an SSE response
+func sseData(t *testing.T, resp *http.Response) []string {
+	t.Helper()
+	defer resp.Body.Close()
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			lines = append(lines, data)
+		}
+	}
+	return lines
+}
+
+const chatBody = `{"model":"mock-gpt","messages":[{"role":"user","content":"hello there"}]}`
+
+func TestChatCompletions(t *testing.T) {
+	srv := httptest.NewServer(New(Config{}))
+	defer srv.Close()
+
+	resp := post(t, srv.URL+"/v1/chat/completions", chatBody, nil)
+	var out struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message      struct{ Content string } `json:"message"`
+			FinishReason string                   `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	decode(t, resp, &out)
+
+	if out.Model != "mock-gpt" || len(out.Choices) != 1 {
+		t.Fatalf("unexpected response: %+v", out)
+	}
+	if got := out.Choices[0].Message.Content; got != "Mock response to: hello there" {
+		t.Errorf("content = %q", got)
+	}
+	if out.Usage.PromptTokens != 2 || out.Usage.CompletionTokens != 5 {
+		t.Errorf("usage = %+v", out.Usage)
+	}
+
+	// max_tokens truncates and reports length
+	resp = post(t, srv.URL+"/v1/chat/completions?reply=one+two+three",
+		`{"model":"mock-gpt","max_tokens":2,"messages":[{"role":"user","content":"hi"}]}`, nil)
+	decode(t, resp, &out)
+	if out.Choices[0].Message.Content != "one two" || out.Choices[0].FinishReason != "length" {
+		t.Errorf("truncated choice = %+v", out.Choices[0])
+	}
+}
+
+func TestChatCompletionsStream(t *testing.T) {
+	srv := httptest.NewServer(New(Config{Reply: "a b c"}))
+	defer srv.Close()
+
+	resp := post(t, srv.URL+"/v1/chat/completions",
+		`{"model":"mock-gpt","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"hi"}]}`, nil)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	lines := sseData(t, resp)
+	if len(lines) == 0 || lines[len(lines)-1] != "[DONE]" {
+		t.Fatalf("stream must end with [DONE]: %v", lines)
+	}
+
+	var text strings.Builder
+	var usage int
+	for _, line := range lines[:len(lines)-1] {
+		var chunk struct {
+			Choices []struct {
+				Delta struct{ Content string } `json:"delta"`
+			} `json:"choices"`
+			Usage *struct {
+				CompletionTokens int `json:"completion_tokens"`
+			} `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			t.Fatalf("bad chunk %q: %v", line, err)
+		}
+		for _, c := range chunk.Choices {
+			text.WriteString(c.Delta.Content)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.CompletionTokens
+		}
+	}
+	if text.String() != "a b c" || usage != 3 {
+		t.Errorf("streamed %q with %d tokens", text.String(), usage)
+	}
+}
+
+func TestMessages(t *testing.T) {
+	srv := httptest.NewServer(New(Config{}))
+	defer srv.Close()
+
+	body := `{"model":"mock-claude","max_tokens":64,"system":"be brief","messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`
+	resp := post(t, srv.URL+"/v1/messages", body, nil)
+	var out struct {
+		Type    string `json:"type"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+		Usage      struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"usage"`
+	}
+	decode(t, resp, &out)
+	if out.Type != "message" || out.Content[0].Text != "Mock response to: hi" || out.StopReason != "end_turn" {
+		t.Errorf("unexpected message: %+v", out)
+	}
+	if out.Usage.InputTokens != 3 {
+		t.Errorf("input_tokens = %d, want 3", out.Usage.InputTokens)
+	}
+
+	// max_tokens is required like the real API
+	resp = post(t, srv.URL+"/v1/messages", `{"model":"mock-claude","messages":[{"role":"user","content":"hi"}]}`, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("missing max_tokens status = %d", resp.StatusCode)
+	}
+}
+
+func TestMessagesStream(t *testing.T) {
+	srv := httptest.NewServer(New(Config{Reply: "x y"}))
+	defer srv.Close()
+
+	resp := post(t, srv.URL+"/v1/messages",
+		`{"model":"mock-claude","max_tokens":8,"stream":true,"messages":[{"role":"user","content":"hi"}]}`, nil)
+	var types []string
+	var text strings.Builder
+	for _, line := range sseData(t, resp) {
+		var ev struct {
+			Type  string `json:"type"`
+			Delta struct {
+				Text string `json:"text"`
+			} `json:"delta"`
+		}
+		json.Unmarshal([]byte(line), &ev)
+		types = append(types, ev.Type)
+		text.WriteString(ev.Delta.Text)
+	}
+	want := []string{"message_start", "content_block_start", "content_block_delta", "content_block_delta",
+		"content_block_stop", "message_delta", "message_stop"}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("event types = %v, want %v", types, want)
+	}
+	if text.String() != "x y" {
+		t.Errorf("streamed text = %q", text.String())
+	}
+}
+
+func TestEmbeddingsDeterministic(t *testing.T) {
+	srv := httptest.NewServer(New(Config{}))
+	defer srv.Close()
+
+	embedding := func(body string) [][]float64 {
+		var out struct {
+			Data []struct {
+				Embedding []float64 `json:"embedding"`
+			} `json:"data"`
+		}
+		decode(t, post(t, srv.URL+"/v1/embeddings", body, nil), &out)
+		vecs := make([][]float64, len(out.Data))
+		for i, d := range out.Data {
+			vecs[i] = d.Embedding
+		}
+		return vecs
+	}
+
+	a := embedding(`{"model":"mock-embedding","input":"hello"}`)
+	b := embedding(`{"model":"mock-embedding","input":["hello","world"],"dimensions":16}`)
+	if len(a) != 1 || len(a[0]) != defaultDimensions {
+		t.Fatalf("single input embedding = %v", a)
+	}
+	if len(b) != 2 || len(b[0]) != 16 {
+		t.Fatalf("batch embedding sizes wrong: %d", len(b))
+	}
+	if !reflect.DeepEqual(a, embedding(`{"model":"mock-embedding","input":"hello"}`)) {
+		t.Error("embeddings should be deterministic")
+	}
+	if reflect.DeepEqual(b[0], b[1]) {
+		t.Error("different inputs should have different embeddings")
+	}
+}
+
+func TestInducedErrors(t *testing.T) {
+	s := New(Config{Seed: 1})
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	status := func(path string) int {
+		resp := post(t, srv.URL+path, chatBody, nil)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if got := status("/v1/chat/completions?status=503"); got != http.StatusServiceUnavailable {
+		t.Errorf("status flag = %d, want 503", got)
+	}
+
+	// Queued faults apply to the next N requests, then clear
+	resp := post(t, srv.URL+"/mock/faults", `{"status":429,"count":2}`, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("queue fault status = %d", resp.StatusCode)
+	}
+	got := []int{status("/v1/chat/completions"), status("/v1/chat/completions"), status("/v1/chat/completions")}
+	if !reflect.DeepEqual(got, []int{429, 429, 200}) {
+		t.Errorf("queued fault statuses = %v", got)
+	}
+
+	if got := status("/v1/chat/completions?fail_rate=1"); got != http.StatusInternalServerError {
+		t.Errorf("fail_rate=1 status = %d, want 500", got)
+	}
+	if got := status("/v1/chat/completions?fail_rate=0"); got != http.StatusOK {
+		t.Errorf("fail_rate=0 status = %d, want 200", got)
+	}
+
+	stats := s.Stats()
+	if stats.Faults != 4 || stats.Requests["/v1/chat/completions"] != 6 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestErrorFormats(t *testing.T) {
+	srv := httptest.NewServer(New(Config{APIKey: "secret"}))
+	defer srv.Close()
+
+	var openai struct {
+		Error struct {
+			Type string `json:"type"`
+		} `json:"error"`
+	}
+	resp := post(t, srv.URL+"/v1/chat/completions", chatBody, nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("missing key status = %d", resp.StatusCode)
+	}
+	decode(t, resp, &openai)
+	if openai.Error.Type != "invalid_api_key" {
+		t.Errorf("openai error type = %q", openai.Error.Type)
+	}
+
+	var anthropic struct {
+		Type  string `json:"type"`
+		Error struct {
+			Type string `json:"type"`
+		} `json:"error"`
+	}
+	resp = post(t, srv.URL+"/v1/messages?status=529", `{}`, map[string]string{"x-api-key": "secret"})
+	decode(t, resp, &anthropic)
+	if anthropic.Type != "error" || anthropic.Error.Type != "overloaded_error" {
+		t.Errorf("anthropic error = %+v", anthropic)
+	}
+
+	resp = post(t, srv.URL+"/v1/chat/completions", chatBody, map[string]string{"Authorization": "Bearer secret"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("valid key status = %d", resp.StatusCode)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	s := New(Config{RPM: 2})
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	var last *http.Response
+	for i := 0; i < 3; i++ {
+		last = post(t, srv.URL+"/v1/chat/completions", chatBody, nil)
+		last.Body.Close()
+		if i == 0 && last.Header.Get("x-ratelimit-remaining-requests") != "1" {
+			t.Errorf("remaining = %q, want 1", last.Header.Get("x-ratelimit-remaining-requests"))
+		}
+	}
+	if last.StatusCode != http.StatusTooManyRequests || last.Header.Get("Retry-After") == "" {
+		t.Errorf("third request status = %d, Retry-After %q", last.StatusCode, last.Header.Get("Retry-After"))
+	}
+
+	resp := post(t, srv.URL+"/v1/messages", `{}`, nil)
+	resp.Body.Close()
+	if resp.Header.Get("anthropic-ratelimit-requests-limit") != "2" {
+		t.Error("anthropic endpoint should send anthropic-ratelimit headers")
+	}
+	if s.Stats().RateLimited != 2 {
+		t.Errorf("RateLimited = %d, want 2", s.Stats().RateLimited)
+	}
+}
+
+func TestStreamAbort(t *testing.T) {
+	s := New(Config{Reply: "a b c d e"})
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	resp := post(t, srv.URL+"/v1/chat/completions?abort_after=2",
+		`{"model":"mock-gpt","stream":true,"messages":[{"role":"user","content":"hi"}]}`, nil)
+	_, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err == nil {
+		t.Error("expected the stream to be cut off")
+	}
+	if s.Stats().Aborted != 1 {
+		t.Errorf("Aborted = %d, want 1", s.Stats().Aborted)
+	}
+}
+
+func TestModels(t *testing.T) {
+	srv := httptest.NewServer(New(Config{}))
+	defer srv.Close()
+
+	var openai struct {
+		Object string `json:"object"`
+		Data   []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	resp, err := http.Get(srv.URL + "/v1/models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decode(t, resp, &openai)
+	if openai.Object != "list" || len(openai.Data) != len(chatModels)+len(embeddingModels) {
+		t.Errorf("openai models = %+v", openai)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/models", nil)
+	req.Header.Set("anthropic-version", "2023-06-01")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var anthropic struct {
+		Data []struct {
+			Type string `json:"type"`
+		} `json:"data"`
+	}
+	decode(t, resp, &anthropic)
+	if len(anthropic.Data) != len(anthropicModels) || anthropic.Data[0].Type != "model" {
+		t.Errorf("anthropic models = %+v", anthropic)
+	}
+}
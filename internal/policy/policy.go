@@ -0,0 +1,146 @@
+// Package policy renders the organization-mandated system prompt text that
+// the proxy adds to requests, from prompt policies stored in the database.
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// Positions a policy's text can take in the system prompt
+const (
+	Prepend = database.PolicyPrepend
+	Append  = database.PolicyAppend
+)
+
+// Database interface for loading prompt policies
+type Database interface {
+	// ListEnabledPromptPolicies returns enabled policies by descending priority
+	ListEnabledPromptPolicies() ([]*database.PromptPolicy, error)
+}
+
+// Data is what policy templates can refer to, e.g. {{.ClientID}}
+type Data struct {
+	ClientID string
+	Model    string
+	Date     string // Current UTC date, YYYY-MM-DD
+}
+
+// compiled is a policy with its parsed template
+type compiled struct {
+	*database.PromptPolicy
+	tmpl *template.Template
+}
+
+// Engine renders policies from an in-memory copy of the policy table.
+// It implements proxy.PromptPolicy.
+type Engine struct {
+	db       Database
+	current  atomic.Pointer[[]compiled]
+	reloadMu sync.Mutex
+	now      func() time.Time
+}
+
+// NewEngine creates an engine and performs the initial load
+func NewEngine(db Database) (*Engine, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is required")
+	}
+	e := &Engine{db: db, now: time.Now}
+	e.current.Store(&[]compiled{})
+	if err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Reload re-reads policies and atomically swaps them in. On error the
+// previous policies stay active.
+func (e *Engine) Reload() error {
+	e.reloadMu.Lock()
+	defer e.reloadMu.Unlock()
+
+	policies, err := e.db.ListEnabledPromptPolicies()
+	if err != nil {
+		return fmt.Errorf("failed to load prompt policies: %w", err)
+	}
+	set := make([]compiled, 0, len(policies))
+	for _, p := range policies {
+		tmpl, err := parse(p)
+		if err != nil {
+			return fmt.Errorf("prompt policy %d: %w", p.ID, err)
+		}
+		set = append(set, compiled{PromptPolicy: p, tmpl: tmpl})
+	}
+	e.current.Store(&set)
+	return nil
+}
+
+// SystemPrompt renders the policies that apply to a client and model.
+// Client policies apply alongside global ones; within each position,
+// higher-priority policies come first.
+func (e *Engine) SystemPrompt(model, clientID string) (prepend, appendText string, err error) {
+	data := Data{ClientID: clientID, Model: model, Date: e.now().UTC().Format("2006-01-02")}
+
+	var before, after []string
+	for _, p := range *e.current.Load() {
+		if p.ClientID != "" && p.ClientID != clientID {
+			continue
+		}
+		if !database.MatchGlob(p.ModelPattern, model) {
+			continue
+		}
+		var sb strings.Builder
+		if err := p.tmpl.Execute(&sb, data); err != nil {
+			return "", "", fmt.Errorf("prompt policy %q: %w", p.Name, err)
+		}
+		text := strings.TrimSpace(sb.String())
+		if text == "" {
+			continue
+		}
+		if p.Position == Append {
+			after = append(after, text)
+		} else {
+			before = append(before, text)
+		}
+	}
+	return strings.Join(before, "\n\n"), strings.Join(after, "\n\n"), nil
+}
+
+// Validate checks a policy before it is stored: its position and a
+// template that renders against sample data
+func Validate(p *database.PromptPolicy) error {
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if p.Position != "" && p.Position != Prepend && p.Position != Append {
+		return fmt.Errorf("position must be prepend or append")
+	}
+	if strings.TrimSpace(p.Template) == "" {
+		return fmt.Errorf("template is required")
+	}
+	tmpl, err := parse(p)
+	if err != nil {
+		return err
+	}
+	sample := Data{ClientID: "client", Model: "model", Date: "2006-01-02"}
+	if err := tmpl.Execute(&strings.Builder{}, sample); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	return nil
+}
+
+// parse compiles a policy's template
+func parse(p *database.PromptPolicy) (*template.Template, error) {
+	tmpl, err := template.New(p.Name).Option("missingkey=error").Parse(p.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
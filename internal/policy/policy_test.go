@@ -0,0 +1,90 @@
+package policy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+type fakeDB struct {
+	policies []*database.PromptPolicy
+	err      error
+}
+
+func (f *fakeDB) ListEnabledPromptPolicies() ([]*database.PromptPolicy, error) {
+	return f.policies, f.err
+}
+
+func TestEngine_SystemPrompt(t *testing.T) {
+	db := &fakeDB{policies: []*database.PromptPolicy{
+		{ID: 1, Name: "tools", ClientID: "acme", ModelPattern: "gpt-*", Position: Append, Template: "Ask before calling tools."},
+		{ID: 2, Name: "disclaimer", ModelPattern: "*", Position: Prepend, Template: "Client {{.ClientID}} on {{.Model}}, {{.Date}}."},
+		{ID: 3, Name: "empty", ModelPattern: "*", Position: Prepend, Template: "{{if eq .ClientID \"vip\"}}VIP{{end}}"},
+	}}
+	e, err := NewEngine(db)
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	e.now = func() time.Time { return time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC) }
+
+	prepend, appendText, err := e.SystemPrompt("gpt-4o", "acme")
+	if err != nil {
+		t.Fatalf("SystemPrompt failed: %v", err)
+	}
+	if prepend != "Client acme on gpt-4o, 2026-03-01." || appendText != "Ask before calling tools." {
+		t.Errorf("unexpected policy text: %q / %q", prepend, appendText)
+	}
+
+	// Client and model scoping
+	if _, appendText, _ := e.SystemPrompt("claude-3-5-sonnet", "acme"); appendText != "" {
+		t.Errorf("model pattern ignored: %q", appendText)
+	}
+	if _, appendText, _ := e.SystemPrompt("gpt-4o", "other"); appendText != "" {
+		t.Errorf("client scope ignored: %q", appendText)
+	}
+	if prepend, _, _ := e.SystemPrompt("gpt-4o", "vip"); prepend != "Client vip on gpt-4o, 2026-03-01.\n\nVIP" {
+		t.Errorf("unexpected prepend: %q", prepend)
+	}
+
+	// A failed reload keeps the previous policies
+	db.err = errors.New("database locked")
+	if err := e.Reload(); err == nil {
+		t.Error("expected reload error")
+	}
+	if prepend, _, _ := e.SystemPrompt("gpt-4o", ""); prepend == "" {
+		t.Error("previous policies lost after a failed reload")
+	}
+	db.err, db.policies = nil, nil
+	if err := e.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if prepend, appendText, _ := e.SystemPrompt("gpt-4o", "acme"); prepend != "" || appendText != "" {
+		t.Errorf("expected no policies, got %q / %q", prepend, appendText)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		policy database.PromptPolicy
+		want   string
+	}{
+		{database.PromptPolicy{Name: "ok", Template: "Model {{.Model}}"}, ""},
+		{database.PromptPolicy{Template: "x"}, "name is required"},
+		{database.PromptPolicy{Name: "p", Position: "middle", Template: "x"}, "position"},
+		{database.PromptPolicy{Name: "p", Template: "  "}, "template is required"},
+		{database.PromptPolicy{Name: "p", Template: "{{.Model"}, "invalid template"},
+		{database.PromptPolicy{Name: "p", Template: "{{.Tenant}}"}, "invalid template"},
+	}
+	for _, tt := range tests {
+		err := Validate(&tt.policy)
+		if tt.want == "" && err != nil {
+			t.Errorf("Validate(%+v) = %v", tt.policy, err)
+		}
+		if tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("Validate(%+v) = %v, want %q", tt.policy, err, tt.want)
+		}
+	}
+}
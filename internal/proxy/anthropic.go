@@ -3,7 +3,6 @@
 package proxy
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -11,8 +10,12 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"strings"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/chaos"
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+	"github.com/jeffersonwarrior/modelscan/internal/tenant"
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
 )
 
 // AnthropicProxyConfig holds configuration for the Anthropic proxy
@@ -25,6 +28,15 @@ type AnthropicProxyConfig struct {
 	AnthropicBaseURL string
 	// AnthropicAPIVersion is the API version header value
 	AnthropicAPIVersion string
+	// StreamWriteTimeout bounds each SSE write to the client; a client that
+	// stops reading for longer is dropped and the upstream stream closed
+	StreamWriteTimeout time.Duration
+	// StreamKeepAlive is how long a stream may be quiet before a keep-alive
+	// comment is sent; zero disables keep-alives
+	StreamKeepAlive time.Duration
+	// StreamIdleTimeout ends a stream, upstream and client, with an error
+	// event when the provider sends nothing for this long; zero disables it
+	StreamIdleTimeout time.Duration
 }
 
 // DefaultAnthropicProxyConfig returns sensible defaults
@@ -34,6 +46,9 @@ func DefaultAnthropicProxyConfig() AnthropicProxyConfig {
 		DefaultMaxTokens:    4096,
 		AnthropicBaseURL:    "https://api.anthropic.com",
 		AnthropicAPIVersion: "2023-06-01",
+		StreamWriteTimeout:  DefaultStreamWriteTimeout,
+		StreamKeepAlive:     DefaultStreamKeepAlive,
+		StreamIdleTimeout:   DefaultStreamIdleTimeout,
 	}
 }
 
@@ -53,7 +68,24 @@ type AnthropicProxy struct {
 	keyProvider     KeyProvider
 	remapper        ModelRemapper
 	httpClient      *http.Client
-	streamingClient *http.Client // Dedicated client for streaming (no timeout)
+	streamingClient *http.Client         // Dedicated client for streaming (no timeout)
+	limits          ContextLimits        // Optional context window enforcement
+	guardrails      Guardrails           // Optional content filtering
+	faults          *chaos.Injector      // Optional fault injection for upstream requests
+	tenants         *tenant.Manager      // Optional per-tenant budgets, limits and usage
+	concurrency     *ConcurrencyLimiter  // Optional in-flight request caps
+	vision          *Vision              // Optional image validation and inlining
+	promptPolicy    PromptPolicy         // Optional mandated system prompt text
+	completionHooks *CompletionHooks     // Optional hooks run after each response
+	availability    ProviderAvailability // Optional drains and maintenance windows
+	pricer          tenant.Pricer        // Optional model prices for cost estimates
+	residency       *Residency           // Optional data residency policy
+	timeouts        RequestTimeouts      // Optional per-client request timeouts
+	transforms      *TransformRules      // Optional request rewriting
+	experiments     *Experiments         // Optional A/B parameter experiments
+	modelLimits     *ModelLimits         // Optional per-model request caps
+	canaries        CanaryRouting        // Optional canary traffic splitting
+	retryBudget     *mshttp.RetryBudget  // Optional cap on key failover resends
 }
 
 // NewAnthropicProxy creates a new Anthropic proxy handler
@@ -79,20 +111,43 @@ func (p *AnthropicProxy) HandleMessages(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Create context with timeout for the entire request
-	ctx, cancel := context.WithTimeout(r.Context(), p.config.Timeout)
+	// Cancel upstream work as soon as the client goes away
+	r, stopWatching := watchClient(r)
+	defer stopWatching()
+
+	// Bound the entire request by the client's deadline budget
+	ctx, cancel, err := withDeadline(r, p.timeouts, p.config.Timeout)
+	if err != nil {
+		p.writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	defer cancel()
 
 	// Parse request body
-	body, err := io.ReadAll(r.Body)
+	body, err := readBody(r.Body)
 	if err != nil {
 		p.writeError(w, "failed to read request body", http.StatusBadRequest)
 		return
 	}
 	defer func() { _ = r.Body.Close() }()
+	defer putBuffer(body)
+
+	// Rewrite the request as the matching transformation rules say
+	ctx, raw, err := transformRequest(ctx, p.transforms, w, TransformAPIAnthropic, r.Header.Get("X-Client-ID"), body.Bytes())
+	if err != nil {
+		p.writeError(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Apply the variant of any experiment the client is enrolled in
+	raw, err = applyExperiment(p.experiments, w, r, TransformAPIAnthropic, raw)
+	if err != nil {
+		p.writeError(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
 
 	var req AnthropicRequest
-	if err := json.Unmarshal(body, &req); err != nil {
+	if err := json.Unmarshal(raw, &req); err != nil {
 		p.writeError(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
 		return
 	}
@@ -114,15 +169,17 @@ func (p *AnthropicProxy) HandleMessages(w http.ResponseWriter, r *http.Request)
 
 	// Extract client ID from header (optional)
 	clientID := r.Header.Get("X-Client-ID")
+	requested, dryRun := req.Model, isDryRun(r)
 
-	// Apply model remapping if remapper is available
+	// Apply model remapping if remapper is available.
+	// Requests without a client ID still get global rules.
 	targetProvider := "anthropic"
-	if p.remapper != nil && clientID != "" {
+	if p.remapper != nil {
 		remapped, provider, err := p.remapper.RemapModel(ctx, req.Model, clientID)
 		if err != nil {
 			log.Printf("proxy: remap error for model %s: %v", req.Model, err)
 			// Continue with original model on remap error
-		} else if remapped != "" {
+		} else if remapped != "" && (remapped != req.Model || provider != "") {
 			log.Printf("proxy: remapped %s -> %s (provider: %s)", req.Model, remapped, provider)
 			req.Model = remapped
 			if provider != "" {
@@ -131,32 +188,166 @@ func (p *AnthropicProxy) HandleMessages(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	// An explicit provider header wins over remap rules
+	if provider := r.Header.Get(HeaderProvider); provider != "" {
+		targetProvider = provider
+	}
+
+	// Divert a share of the model's traffic to any canary of it
+	var canary *CanaryAssignment
+	if !dryRun {
+		if canary = assignCanary(p.canaries, p.speaks, w, r, req.Model, targetProvider); canary != nil {
+			req.Model, targetProvider = canary.Model, canary.Provider
+		}
+	}
+
+	// Keep requests with a residency requirement in their region; a
+	// request no compliant provider can serve fails rather than leaving it
+	region, err := p.residency.Required(clientID, r.Header.Get(HeaderResidency))
+	if err != nil {
+		p.writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if region != "" {
+		if !p.residency.Allows(targetProvider, region) {
+			rerr := &ResidencyError{Region: region, Provider: targetProvider, Model: req.Model}
+			p.writeError(w, rerr.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	// Explain the routing decision when the client asks for it
+	if wantsExplanation(r) {
+		setRoute(w, p.ExplainRoute(ctx, requested, clientID, r.Header.Get(HeaderProvider)))
+	}
+
+	// Drained providers take no new requests; a dry run reports it instead
+	if merr := checkAvailable(p.availability, targetProvider); merr != nil && !dryRun {
+		p.writeError(w, merr.Error(), maintenanceStatus(w, merr))
+		return
+	}
+
+	// Run request content through the guardrails
+	if p.guardrails != nil && !dryRun && !p.guardRequest(ctx, w, &req, clientID) {
+		return
+	}
+
+	// Add the organization's mandated instructions to the system prompt
+	if err := applyAnthropicPolicy(p.promptPolicy, &req, clientID); err != nil {
+		p.writeError(w, fmt.Sprintf("failed to apply prompt policy: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Price the request
+	promptTokens := CountAnthropicRequestTokens(&req)
+	route := []DryRunStep{{
+		Provider: targetProvider,
+		Model:    req.Model,
+		Estimate: estimateCost(p.pricer, req.Model, promptTokens, req.MaxTokens),
+	}}
+	if dryRun {
+		runner := dryRunner{keys: p.keyProvider, availability: p.availability, tenants: p.tenants, concurrency: p.concurrency, modelLimits: p.modelLimits}
+		writeDryRun(w, runner.run(ctx, "anthropic", requested, clientID, promptTokens, route))
+		return
+	}
+	setEstimatedCost(w, route[0].Estimate)
+
+	// Hold the client's tenant to its budget and rate limits and meter its usage
+	ctx, w, finishTenant, err := meterTenant(ctx, p.tenants, w, clientID, promptTokens)
+	if err != nil {
+		status, _ := tenantErrorStatus(err)
+		p.writeError(w, err.Error(), status)
+		return
+	}
+	defer finishTenant()
+
+	// Assemble the response for the AfterCompletion hooks
+	w, finishCompletion := trackCompletion(ctx, p.completionHooks, w, "anthropic", clientID, promptTokens)
+	defer finishCompletion()
+
+	// Make sure the request fits the target model's context window
+	if err := p.fitContext(ctx, &req, clientID); err != nil {
+		setErrorCode(w, err)
+		p.writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Make sure the target model can take the request's images
+	if err := p.vision.PrepareAnthropic(ctx, &req, targetProvider); err != nil {
+		p.writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Report how the upstream call went to the canary
+	w, finishCanary := canary.observe(ctx, w)
+	defer finishCanary()
+
 	// Get API key for target provider
 	apiKey, err := p.keyProvider.GetKey(ctx, targetProvider)
 	if err != nil {
 		p.writeError(w, fmt.Sprintf("no API key available for provider %s", targetProvider), http.StatusServiceUnavailable)
 		return
 	}
+	setAttribution(w, targetProvider, req.Model, apiKey)
+	ctx = withUpstreamKey(mshttp.WithProvider(ctx, targetProvider), apiKey, w)
+	if key := r.Header.Get(mshttp.DefaultIdempotencyHeader); key != "" {
+		ctx = mshttp.WithIdempotencyKey(ctx, key)
+	}
+
+	// Hold the model to its caps, whatever the provider allows
+	releaseModel, err := p.modelLimits.Acquire(ctx, req.Model, promptTokens+req.MaxTokens)
+	if err != nil {
+		err = deadlineError(ctx, err)
+		status := shedStatus(w, err)
+		p.writeError(w, err.Error(), status)
+		return
+	}
+	defer releaseModel()
+
+	// Wait for a concurrency slot, shedding the request when the queue is full
+	release, err := p.concurrency.Acquire(ctx, targetProvider)
+	if err != nil {
+		err = deadlineError(ctx, err)
+		status := shedStatus(w, err)
+		p.writeError(w, err.Error(), status)
+		return
+	}
+	defer release()
+
+	// Nothing is sent upstream once the budget has run out
+	if err := checkDeadline(ctx); err != nil {
+		p.writeError(w, err.Error(), http.StatusGatewayTimeout)
+		return
+	}
 
 	// Forward request to upstream
 	if req.Stream {
 		p.handleStreamingRequest(ctx, w, &req, apiKey, targetProvider)
-	} else {
-		p.handleNonStreamingRequest(ctx, w, &req, apiKey, targetProvider)
+		return
 	}
+
+	// Buffer the response so guardrails see it before the client does
+	if p.guardrails != nil {
+		buf := newBufferedResponse()
+		p.handleNonStreamingRequest(ctx, buf, &req, apiKey, targetProvider)
+		p.writeGuardedResponse(ctx, w, buf, clientID)
+		return
+	}
+	p.handleNonStreamingRequest(ctx, w, &req, apiKey, targetProvider)
 }
 
 // handleNonStreamingRequest handles non-streaming Anthropic requests
 func (p *AnthropicProxy) handleNonStreamingRequest(ctx context.Context, w http.ResponseWriter, req *AnthropicRequest, apiKey, provider string) {
 	// Build upstream request
-	reqBody, err := json.Marshal(req)
+	reqBody, err := marshalJSON(req)
 	if err != nil {
 		p.writeError(w, "failed to marshal request", http.StatusInternalServerError)
 		return
 	}
+	// Deferred before resp.Body.Close, so it runs after the transport is done
+	defer reqBody.release()
 
 	upstreamURL := p.getUpstreamURL(provider)
-	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, upstreamURL, bytes.NewReader(reqBody))
+	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, upstreamURL, bytes.NewReader(reqBody.Bytes()))
 	if err != nil {
 		p.writeError(w, "failed to create upstream request", http.StatusInternalServerError)
 		return
@@ -168,11 +359,25 @@ func (p *AnthropicProxy) handleNonStreamingRequest(ctx context.Context, w http.R
 	// Execute request
 	resp, err := p.httpClient.Do(upstreamReq)
 	if err != nil {
-		p.writeError(w, fmt.Sprintf("upstream request failed: %v", err), http.StatusBadGateway)
+		message, _, status := upstreamFailure(ctx, err)
+		p.writeError(w, message, status)
 		return
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	// Read the whole body first so a broken transport limit can still be
+	// reported with an error status
+	body, err := readBody(resp.Body)
+	if err != nil {
+		message, _, status := upstreamReadError(err)
+		p.writeError(w, message, status)
+		return
+	}
+	defer putBuffer(body)
+	if resp.StatusCode >= 400 {
+		setErrorCode(w, providererr.Classify(provider, resp.StatusCode, resp.Header, body.Bytes()))
+	}
+
 	// Copy response headers
 	for key, values := range resp.Header {
 		for _, value := range values {
@@ -182,9 +387,8 @@ func (p *AnthropicProxy) handleNonStreamingRequest(ctx context.Context, w http.R
 
 	// Copy status code and body
 	w.WriteHeader(resp.StatusCode)
-	if _, err := io.Copy(w, resp.Body); err != nil {
-		log.Printf("proxy: error copying response body: %v", err)
-		// Response already started, can't change status code
+	if _, err := w.Write(body.Bytes()); err != nil {
+		log.Printf("proxy: error writing response body: %v", err)
 	}
 }
 
@@ -196,16 +400,21 @@ func (p *AnthropicProxy) handleStreamingRequest(ctx context.Context, w http.Resp
 		p.writeError(w, "streaming not supported", http.StatusInternalServerError)
 		return
 	}
+	sw.SetWriteTimeout(p.config.StreamWriteTimeout)
+	sw.KeepAlive(p.config.StreamKeepAlive)
+	defer sw.StopKeepAlive()
 
 	// Build upstream request
-	reqBody, err := json.Marshal(req)
+	reqBody, err := marshalJSON(req)
 	if err != nil {
 		_ = sw.WriteError(fmt.Errorf("failed to marshal request: %w", err))
 		return
 	}
+	// Deferred before resp.Body.Close, so it runs after the transport is done
+	defer reqBody.release()
 
 	upstreamURL := p.getUpstreamURL(provider)
-	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, upstreamURL, bytes.NewReader(reqBody))
+	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, upstreamURL, bytes.NewReader(reqBody.Bytes()))
 	if err != nil {
 		_ = sw.WriteError(fmt.Errorf("failed to create upstream request: %w", err))
 		return
@@ -220,6 +429,8 @@ func (p *AnthropicProxy) handleStreamingRequest(ctx context.Context, w http.Resp
 		_ = sw.WriteError(fmt.Errorf("upstream request failed: %w", err))
 		return
 	}
+	// A provider that goes quiet mid-stream is cut off rather than waited on
+	resp.Body = watchIdle(resp.Body, p.config.StreamIdleTimeout)
 	defer func() { _ = resp.Body.Close() }()
 
 	// Check for non-2xx status
@@ -229,77 +440,13 @@ func (p *AnthropicProxy) handleStreamingRequest(ctx context.Context, w http.Resp
 			_ = sw.WriteError(fmt.Errorf("upstream error (status %d): failed to read error body: %w", resp.StatusCode, err))
 			return
 		}
-		_ = sw.WriteError(fmt.Errorf("upstream error (status %d): %s", resp.StatusCode, string(body)))
+		apiErr := providererr.Classify(provider, resp.StatusCode, resp.Header, body)
+		_ = sw.WriteError(withKind(fmt.Errorf("upstream error (status %d): %s", resp.StatusCode, string(body)), apiErr))
 		return
 	}
 
-	// Stream SSE events from upstream to client
-	p.streamSSEEvents(ctx, sw, resp.Body)
-}
-
-// streamSSEEvents reads SSE events from upstream and forwards to client
-func (p *AnthropicProxy) streamSSEEvents(ctx context.Context, sw *StreamWriter, reader io.Reader) {
-	scanner := bufio.NewScanner(reader)
-	// Increase buffer size for large events (pre-allocate 64KB initial buffer)
-	buf := make([]byte, 64*1024)
-	scanner.Buffer(buf, 1024*1024) // 1MB max
-
-	var eventType string
-	var dataLines []string
-
-	for scanner.Scan() {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-		}
-
-		line := scanner.Text()
-
-		if line == "" {
-			// Empty line means end of event
-			if len(dataLines) > 0 {
-				data := strings.Join(dataLines, "\n")
-
-				// Check for [DONE] marker
-				if data == "[DONE]" {
-					_ = sw.Close()
-					return
-				}
-
-				// Forward the event
-				if eventType != "" {
-					_ = sw.WriteEventWithType(eventType, []byte(data))
-				} else {
-					sw.WriteEvent([]byte(data))
-				}
-
-				// Reset for next event
-				eventType = ""
-				dataLines = nil
-			}
-			continue
-		}
-
-		// Parse SSE line
-		if strings.HasPrefix(line, "event:") {
-			eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
-		} else if strings.HasPrefix(line, "data:") {
-			data := strings.TrimPrefix(line, "data:")
-			if strings.HasPrefix(data, " ") {
-				data = data[1:]
-			}
-			dataLines = append(dataLines, data)
-		}
-		// Ignore other lines (comments starting with :, retry:, id:, etc.)
-	}
-
-	if err := scanner.Err(); err != nil {
-		_ = sw.WriteError(fmt.Errorf("stream read error: %w", err))
-	}
-
-	// Close stream
-	_ = sw.Close()
+	// The stream is already in the client's format: relay it as is
+	relaySSE(ctx, sw, resp.Body)
 }
 
 // getUpstreamURL returns the upstream URL for a provider
@@ -314,9 +461,26 @@ func (p *AnthropicProxy) getUpstreamURL(provider string) string {
 	}
 }
 
-// setUpstreamHeaders sets the required headers for upstream requests
+// speaks reports whether getUpstreamURL knows provider; requests for any
+// other provider would be sent to Anthropic
+func (p *AnthropicProxy) speaks(provider string) bool {
+	return provider == "anthropic"
+}
+
+// setUpstreamHeaders sets the required headers for upstream requests,
+// passing on the client's idempotency key and adding the headers of the
+// transformation rules applied. It is called just before a request is
+// sent, which is noted for disconnect handling.
 func (p *AnthropicProxy) setUpstreamHeaders(req *http.Request, apiKey, provider string) {
 	req.Header.Set("Content-Type", "application/json")
+	markSent(req.Context())
+	setTransformHeaders(req)
+	if key := mshttp.IdempotencyKeyFrom(req.Context()); key != "" {
+		req.Header.Set(mshttp.DefaultIdempotencyHeader, key)
+	}
+	if id := mshttp.RequestIDFrom(req.Context()); id != "" {
+		req.Header.Set(mshttp.HeaderRequestID, id)
+	}
 
 	switch provider {
 	case "anthropic":
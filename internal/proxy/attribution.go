@@ -0,0 +1,42 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// Request and response headers that let operators see how a request was routed
+const (
+	// HeaderProvider on a request forces the upstream provider, overriding
+	// the provider chosen by remap rules. On a response it names the
+	// provider that served the request.
+	HeaderProvider = "X-Modelscan-Provider"
+
+	// HeaderModel names the model sent upstream after remapping
+	HeaderModel = "X-Modelscan-Model"
+
+	// HeaderKey carries a fingerprint of the API key that served the
+	// request: the first KeyFingerprintLen hex digits of its SHA-256 hash,
+	// matching the start of api_keys.key_hash
+	HeaderKey = "X-Modelscan-Key"
+)
+
+// KeyFingerprintLen is the number of hex digits reported in HeaderKey
+const KeyFingerprintLen = 12
+
+// KeyFingerprint returns the short, non-reversible identifier of an API key
+// reported in HeaderKey
+func KeyFingerprint(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])[:KeyFingerprintLen]
+}
+
+// setAttribution records which provider, model and key serve a request.
+// It must be called before the response header is written.
+func setAttribution(w http.ResponseWriter, provider, model, apiKey string) {
+	h := w.Header()
+	h.Set(HeaderProvider, provider)
+	h.Set(HeaderModel, model)
+	h.Set(HeaderKey, KeyFingerprint(apiKey))
+}
@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordingKeyProvider remembers which provider a key was requested for
+type recordingKeyProvider struct {
+	key      string
+	provider string
+}
+
+func (m *recordingKeyProvider) GetKey(ctx context.Context, providerID string) (string, error) {
+	m.provider = providerID
+	return m.key, nil
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	fp := KeyFingerprint("sk-test-key")
+	if len(fp) != KeyFingerprintLen {
+		t.Fatalf("expected %d hex digits, got %q", KeyFingerprintLen, fp)
+	}
+	if fp != KeyFingerprint("sk-test-key") {
+		t.Error("fingerprint is not stable")
+	}
+	if fp == KeyFingerprint("sk-other-key") {
+		t.Error("different keys share a fingerprint")
+	}
+}
+
+func TestOpenAIProxy_Attribution(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"x","object":"chat.completion","choices":[]}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	cfg.CohereBaseURL = upstream.URL
+
+	tests := []struct {
+		name     string
+		header   string
+		provider string
+	}{
+		{"default provider", "", "openai"},
+		{"forced provider", "cohere", "cohere"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := &recordingKeyProvider{key: "sk-test-key"}
+			p := NewOpenAIProxy(cfg, keys, nil)
+
+			body := `{"model": "gpt-4", "messages": [{"role": "user", "content": "hello"}]}`
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+			if tt.header != "" {
+				req.Header.Set(HeaderProvider, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			p.HandleChatCompletions(w, req)
+
+			if keys.provider != tt.provider {
+				t.Errorf("key requested for %q, want %q", keys.provider, tt.provider)
+			}
+			if got := w.Header().Get(HeaderProvider); got != tt.provider {
+				t.Errorf("%s = %q, want %q", HeaderProvider, got, tt.provider)
+			}
+			if got := w.Header().Get(HeaderModel); got != "gpt-4" {
+				t.Errorf("%s = %q, want gpt-4", HeaderModel, got)
+			}
+			if got := w.Header().Get(HeaderKey); got != KeyFingerprint("sk-test-key") {
+				t.Errorf("%s = %q, want %q", HeaderKey, got, KeyFingerprint("sk-test-key"))
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// Request and response bodies, upstream request encoding and stream scan
+// buffers are pooled; allocating them afresh dominated the proxy's
+// per-request garbage.
+
+// maxPooledBuffer caps the buffers kept for reuse so one huge body does not
+// pin its memory for the life of the process
+const maxPooledBuffer = 1 << 20
+
+// scanBufferSize is the initial buffer of an SSE scanner
+const scanBufferSize = 64 * 1024
+
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// getBuffer returns an empty pooled buffer
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns buf to the pool. The caller must not keep any slice of
+// its contents.
+func putBuffer(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// readBody reads r to the end into a pooled buffer, released with putBuffer
+func readBody(r io.Reader) (*bytes.Buffer, error) {
+	buf := getBuffer()
+	if _, err := buf.ReadFrom(r); err != nil {
+		putBuffer(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+// jsonBuffer is a pooled buffer with an encoder writing to it
+type jsonBuffer struct {
+	bytes.Buffer
+	enc *json.Encoder
+}
+
+var jsonPool = sync.Pool{New: func() any {
+	b := new(jsonBuffer)
+	b.enc = json.NewEncoder(&b.Buffer)
+	return b
+}}
+
+// marshalJSON encodes v exactly as json.Marshal does, into a pooled buffer
+// released with release. A buffer sent upstream may only be released once
+// the response body is closed: until then the transport can still be
+// writing it.
+func marshalJSON(v any) (*jsonBuffer, error) {
+	b := jsonPool.Get().(*jsonBuffer)
+	if err := b.enc.Encode(v); err != nil {
+		b.release()
+		return nil, err
+	}
+	// Encode terminates the value with a newline, Marshal does not
+	b.Truncate(b.Len() - 1)
+	return b, nil
+}
+
+// release returns b to the pool
+func (b *jsonBuffer) release() {
+	if b.Cap() > maxPooledBuffer {
+		return
+	}
+	b.Reset()
+	jsonPool.Put(b)
+}
+
+var scanPool = sync.Pool{New: func() any {
+	buf := make([]byte, scanBufferSize)
+	return &buf
+}}
+
+// newEventScanner returns a line scanner for an SSE stream using a pooled
+// buffer, and a func returning the buffer once scanning is done. Events of
+// up to 1MB are accepted.
+func newEventScanner(r io.Reader) (*bufio.Scanner, func()) {
+	buf := scanPool.Get().(*[]byte)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(*buf, 1024*1024)
+	return scanner, func() { scanPool.Put(buf) }
+}
+
+var readerPool = sync.Pool{New: func() any { return bufio.NewReaderSize(nil, scanBufferSize) }}
+
+// newLineReader returns a pooled buffered reader over r, and a func
+// returning it once reading is done
+func newLineReader(r io.Reader) (*bufio.Reader, func()) {
+	br := readerPool.Get().(*bufio.Reader)
+	br.Reset(r)
+	return br, func() {
+		br.Reset(nil)
+		readerPool.Put(br)
+	}
+}
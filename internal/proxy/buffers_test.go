@@ -0,0 +1,185 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func benchmarkRequest() *OpenAIRequest {
+	maxTokens := 1024
+	return &OpenAIRequest{
+		Model:     "gpt-4o",
+		MaxTokens: &maxTokens,
+		Messages: []OpenAIMessage{
+			{Role: "system", Content: "You are a helpful assistant. <Answer> briefly & precisely."},
+			{Role: "user", Content: strings.Repeat("Summarise the quarterly report. ", 200)},
+		},
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	for _, v := range []any{benchmarkRequest(), map[string]string{"html": "<a href=\"x\">&</a>"}, "text", nil} {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := marshalJSON(v)
+		if err != nil {
+			t.Fatalf("marshalJSON(%v): %v", v, err)
+		}
+		if !bytes.Equal(got.Bytes(), want) {
+			t.Errorf("marshalJSON = %s, want %s", got.Bytes(), want)
+		}
+		got.release()
+	}
+
+	if _, err := marshalJSON(func() {}); err == nil {
+		t.Error("expected an error for an unsupported value")
+	}
+}
+
+func TestReadBody(t *testing.T) {
+	body, err := readBody(strings.NewReader("hello"))
+	if err != nil || body.String() != "hello" {
+		t.Fatalf("readBody = %q, %v", body, err)
+	}
+	putBuffer(body)
+
+	if _, err := readBody(io.MultiReader(strings.NewReader("partial"), errReader{})); err == nil {
+		t.Error("expected the read error")
+	}
+
+	// Oversized buffers are left to the garbage collector
+	big := bytes.NewBuffer(make([]byte, 0, maxPooledBuffer+1))
+	putBuffer(big)
+	if b := getBuffer(); b == big {
+		t.Error("expected an oversized buffer not to be pooled")
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, io.ErrUnexpectedEOF }
+
+// TestPooledBuffers_Concurrent runs requests through the pooled paths at
+// once; under the race detector it fails if a buffer is reused while
+// still referenced.
+func TestPooledBuffers_Concurrent(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OpenAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(OpenAIResponse{
+			ID:      "chatcmpl-" + req.Messages[0].Content.(string),
+			Object:  "chat.completion",
+			Model:   req.Model,
+			Choices: []OpenAIChoice{{Message: OpenAIMessage{Role: "assistant", Content: req.Messages[0].Content}}},
+		})
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	proxy := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-api-key"}, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := strings.Repeat(string(rune('a'+i)), 100+i)
+			body := `{"model": "gpt-4", "messages": [{"role": "user", "content": "` + id + `"}]}`
+			w := httptest.NewRecorder()
+			proxy.HandleChatCompletions(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+
+			var resp OpenAIResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Errorf("request %d: %v", i, err)
+				return
+			}
+			if resp.ID != "chatcmpl-"+id {
+				t.Errorf("request %d got another request's response: %s", i, resp.ID)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// BenchmarkMarshalRequest compares encoding an upstream request with
+// json.Marshal and with the pooled encoder
+func BenchmarkMarshalRequest(b *testing.B) {
+	req := benchmarkRequest()
+	b.Run("json.Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = json.Marshal(req)
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf, _ := marshalJSON(req)
+			buf.release()
+		}
+	})
+}
+
+// BenchmarkReadBody compares reading a request body with io.ReadAll and
+// into a pooled buffer
+func BenchmarkReadBody(b *testing.B) {
+	data, _ := json.Marshal(benchmarkRequest())
+	b.Run("io.ReadAll", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = io.ReadAll(bytes.NewReader(data))
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf, _ := readBody(bytes.NewReader(data))
+			putBuffer(buf)
+		}
+	})
+}
+
+// BenchmarkHandleChatCompletions measures a whole non-streaming request
+// through the proxy
+func BenchmarkHandleChatCompletions(b *testing.B) {
+	response, _ := json.Marshal(OpenAIResponse{
+		ID:      "chatcmpl-123",
+		Object:  "chat.completion",
+		Model:   "gpt-4o",
+		Choices: []OpenAIChoice{{Message: OpenAIMessage{Role: "assistant", Content: strings.Repeat("The report shows growth. ", 100)}}},
+	})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(response)
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	proxy := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-api-key"}, nil)
+	body, _ := json.Marshal(benchmarkRequest())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		proxy.HandleChatCompletions(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			b.Fatalf("status %d: %s", w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package proxy
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Headers tagging a response with the canary covering its model and the
+// arm that served it
+const (
+	HeaderCanary    = "X-Modelscan-Canary"
+	HeaderCanaryArm = "X-Modelscan-Canary-Arm"
+)
+
+// Canary arms
+const (
+	CanaryArmBaseline = "baseline"
+	CanaryArmCanary   = "canary"
+)
+
+// CanaryRouting diverts a share of a model's traffic to a canary model or
+// provider and learns from the outcomes whether to keep it
+type CanaryRouting interface {
+	// AssignCanary picks the arm of the canary covering model for a request
+	// bound for provider. ok is false when no canary covers the model.
+	AssignCanary(model, provider string) (a CanaryAssignment, ok bool)
+}
+
+// CanaryAssignment is the arm of a canary a request was assigned to
+type CanaryAssignment struct {
+	Policy   string
+	Canary   bool   // Sent to the canary rather than the baseline
+	Model    string // The model the request goes to
+	Provider string // The provider the request goes to
+	// Observe reports whether the request failed and how long it took
+	Observe func(failed bool, latency time.Duration)
+}
+
+// SetCanaries diverts a share of each canaried model's traffic to its
+// canary
+func (p *OpenAIProxy) SetCanaries(c CanaryRouting) {
+	p.canaries = c
+}
+
+// SetCanaries diverts a share of each canaried model's traffic to its
+// canary
+func (p *AnthropicProxy) SetCanaries(c CanaryRouting) {
+	p.canaries = c
+}
+
+// assignCanary assigns a request for model on provider to an arm of the
+// canary covering the model and tags the response with it. It returns nil
+// when no canary applies; a client pinning a provider with HeaderProvider
+// is never diverted, and neither is a request whose arm is on a provider
+// the proxy does not speak to.
+func assignCanary(c CanaryRouting, speaks func(provider string) bool, w http.ResponseWriter, r *http.Request, model, provider string) *CanaryAssignment {
+	if c == nil || r.Header.Get(HeaderProvider) != "" {
+		return nil
+	}
+	a, ok := c.AssignCanary(model, provider)
+	if !ok {
+		return nil
+	}
+	if !speaks(a.Provider) {
+		log.Printf("proxy: canary %s: provider %s is not supported here, keeping %s on %s", a.Policy, a.Provider, model, provider)
+		return nil
+	}
+	arm := CanaryArmBaseline
+	if a.Canary {
+		arm = CanaryArmCanary
+	}
+	w.Header().Set(HeaderCanary, a.Policy)
+	w.Header().Set(HeaderCanaryArm, arm)
+	return &a
+}
+
+// observe wraps w to report the request's outcome to the canary when the
+// returned func is called. Error statuses and error events on a stream that
+// already answered 200 count as failures; requests the client abandoned are
+// not counted at all. A nil assignment returns w
+// unchanged.
+func (a *CanaryAssignment) observe(ctx context.Context, w http.ResponseWriter) (http.ResponseWriter, func()) {
+	if a == nil || a.Observe == nil {
+		return w, func() {}
+	}
+	start := time.Now()
+	cw := &canaryWriter{ResponseWriter: w, status: http.StatusOK}
+	return cw, func() {
+		if gone, _ := clientGone(ctx); gone {
+			return
+		}
+		a.Observe(cw.failed || cw.status >= http.StatusBadRequest, time.Since(start))
+	}
+}
+
+// canaryWriter records the status of a response and whether a stream
+// reported an error
+type canaryWriter struct {
+	http.ResponseWriter
+	status int
+	failed bool
+}
+
+func (cw *canaryWriter) WriteHeader(status int) {
+	cw.status = status
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+// streamFailed is called by StreamWriter.WriteError
+func (cw *canaryWriter) streamFailed() {
+	cw.failed = true
+}
+
+// Flush forwards flushes so streaming keeps working
+func (cw *canaryWriter) Flush() {
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the client connection
+func (cw *canaryWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,331 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/routing"
+)
+
+// routerCanaries splits traffic with a routing.CanaryRouter, as the service
+// does
+type routerCanaries struct {
+	router *routing.CanaryRouter
+}
+
+func (c routerCanaries) AssignCanary(model, provider string) (CanaryAssignment, bool) {
+	arm, ok := c.router.Assign(model, provider)
+	if !ok {
+		return CanaryAssignment{}, false
+	}
+	return CanaryAssignment{
+		Policy:   arm.PolicyID,
+		Canary:   arm.Canary,
+		Model:    arm.Model,
+		Provider: arm.Provider,
+		Observe: func(failed bool, latency time.Duration) {
+			c.router.Observe(arm, failed, latency)
+		},
+	}, true
+}
+
+// canaryUpstream serves chat completions, streamed or not, failing those for
+// the failing model, and counts the requests for each model
+type canaryUpstream struct {
+	*httptest.Server
+	mu     sync.Mutex
+	models map[string]int
+}
+
+func newCanaryUpstream(t *testing.T, failing string) *canaryUpstream {
+	u := &canaryUpstream{models: make(map[string]int)}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model  string `json:"model"`
+			Stream bool   `json:"stream"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		u.mu.Lock()
+		u.models[req.Model]++
+		u.mu.Unlock()
+		if req.Model == failing {
+			http.Error(w, `{"error": {"message": "overloaded"}}`, http.StatusInternalServerError)
+			return
+		}
+		if req.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: {\"id\": \"chatcmpl-1\", \"model\": \"" + req.Model + "\", \"choices\": []}\n\ndata: [DONE]\n\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "chatcmpl-1", "object": "chat.completion", "model": "` + req.Model + `", "choices": []}`))
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func (u *canaryUpstream) count(model string) int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.models[model]
+}
+
+func chatThrough(p *OpenAIProxy, model string, header http.Header) *httptest.ResponseRecorder {
+	return postChat(p, `{"model": "`+model+`", "messages": [{"role": "user", "content": "hi"}]}`, header)
+}
+
+func postChat(p *OpenAIProxy, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	p.HandleChatCompletions(w, req)
+	return w
+}
+
+func TestCanary_RollsBackFailingCanary(t *testing.T) {
+	upstream := newCanaryUpstream(t, "gpt-5")
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-api-key"}, nil)
+
+	router := routing.NewCanaryRouter(nil, nil)
+	if err := router.AddPolicy(routing.CanaryPolicy{ID: "gpt5", ModelPattern: "gpt-4*", CanaryModel: "gpt-5", Percent: 50, MinSamples: 5, MaxErrorRateIncrease: 0.1}); err != nil {
+		t.Fatal(err)
+	}
+	p.SetCanaries(routerCanaries{router: router})
+
+	// A client pinning its provider is never diverted
+	pinned := chatThrough(p, "gpt-4o", http.Header{HeaderProvider: {"openai"}})
+	if pinned.Header().Get(HeaderCanary) != "" || pinned.Code != http.StatusOK {
+		t.Fatalf("pinned request = %d with canary %q, want it left alone", pinned.Code, pinned.Header().Get(HeaderCanary))
+	}
+
+	arms := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		w := chatThrough(p, "gpt-4o", nil)
+		arm := w.Header().Get(HeaderCanaryArm)
+		arms[arm]++
+		if arm == CanaryArmCanary && (w.Code != http.StatusInternalServerError || w.Header().Get(HeaderModel) != "gpt-5") {
+			t.Errorf("canary request = %d from %q, want the failing gpt-5", w.Code, w.Header().Get(HeaderModel))
+		}
+		if arm == CanaryArmBaseline && w.Code != http.StatusOK {
+			t.Errorf("baseline request = %d, want 200", w.Code)
+		}
+	}
+
+	result, _ := router.Get("gpt5")
+	if result.Status != routing.CanaryRolledBack {
+		t.Fatalf("canary status = %s, want rolled back", result.Status)
+	}
+	if diverted := upstream.count("gpt-5"); diverted < 5 || diverted != arms[CanaryArmCanary] {
+		t.Errorf("upstream saw %d gpt-5 requests, %d were tagged canary", diverted, arms[CanaryArmCanary])
+	}
+	if result.Canary.Errors != result.Canary.Requests || result.Baseline.Errors != 0 {
+		t.Errorf("unexpected arm stats: canary %+v, baseline %+v", result.Canary, result.Baseline)
+	}
+
+	// Once rolled back every request goes to the requested model
+	before := upstream.count("gpt-5")
+	for i := 0; i < 20; i++ {
+		if w := chatThrough(p, "gpt-4o", nil); w.Code != http.StatusOK || w.Header().Get(HeaderCanary) != "" {
+			t.Fatalf("request after rollback = %d with canary %q", w.Code, w.Header().Get(HeaderCanary))
+		}
+	}
+	if upstream.count("gpt-5") != before {
+		t.Error("requests were diverted after the rollback")
+	}
+}
+
+func TestCanary_RollsBackFailingStreamingCanary(t *testing.T) {
+	upstream := newCanaryUpstream(t, "gpt-5")
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-api-key"}, nil)
+
+	router := routing.NewCanaryRouter(nil, nil)
+	router.AddPolicy(routing.CanaryPolicy{ID: "gpt5", ModelPattern: "gpt-4*", CanaryModel: "gpt-5", Percent: 50, MinSamples: 5, MaxErrorRateIncrease: 0.1})
+	p.SetCanaries(routerCanaries{router: router})
+
+	// A stream has already answered 200 when the upstream fails, so the
+	// failure only shows as an error event
+	body := `{"model": "gpt-4o", "stream": true, "messages": [{"role": "user", "content": "hi"}]}`
+	for i := 0; i < 100; i++ {
+		w := postChat(p, body, nil)
+		if w.Header().Get(HeaderCanaryArm) == CanaryArmCanary && (w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "event: error")) {
+			t.Errorf("canary stream = %d %q, want an error event", w.Code, w.Body.String())
+		}
+	}
+
+	result, _ := router.Get("gpt5")
+	if result.Status != routing.CanaryRolledBack {
+		t.Fatalf("canary status = %s, want rolled back", result.Status)
+	}
+	if result.Canary.Errors != result.Canary.Requests || result.Baseline.Errors != 0 {
+		t.Errorf("unexpected arm stats: canary %+v, baseline %+v", result.Canary, result.Baseline)
+	}
+}
+
+func TestCanary_RollsBackCanaryStreamingUpstreamErrors(t *testing.T) {
+	// The canary model's upstream answers 200 and then streams an error
+	// event, which is relayed as is
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"id\": \"chatcmpl-1\", \"model\": \"" + req.Model + "\", \"choices\": []}\n\n"))
+		if req.Model == "gpt-5" {
+			w.Write([]byte("data: {\"error\": {\"message\": \"overloaded\", \"type\": \"server_error\"}}\n\n"))
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-api-key"}, nil)
+
+	router := routing.NewCanaryRouter(nil, nil)
+	router.AddPolicy(routing.CanaryPolicy{ID: "gpt5", ModelPattern: "gpt-4*", CanaryModel: "gpt-5", Percent: 50, MinSamples: 5, MaxErrorRateIncrease: 0.1})
+	p.SetCanaries(routerCanaries{router: router})
+
+	body := `{"model": "gpt-4o", "stream": true, "messages": [{"role": "user", "content": "hi"}]}`
+	for i := 0; i < 100; i++ {
+		w := postChat(p, body, nil)
+		if w.Header().Get(HeaderCanaryArm) == CanaryArmCanary && !strings.Contains(w.Body.String(), "overloaded") {
+			t.Errorf("canary stream = %q, want the upstream error relayed", w.Body.String())
+		}
+	}
+
+	result, _ := router.Get("gpt5")
+	if result.Status != routing.CanaryRolledBack {
+		t.Fatalf("canary status = %s, want rolled back", result.Status)
+	}
+	if result.Canary.Errors == 0 || result.Canary.Errors != result.Canary.Requests || result.Baseline.Errors != 0 {
+		t.Errorf("unexpected arm stats: canary %+v, baseline %+v", result.Canary, result.Baseline)
+	}
+}
+
+func TestCanary_PromotesHealthyCanary(t *testing.T) {
+	upstream := newCanaryUpstream(t, "")
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-api-key"}, nil)
+
+	router := routing.NewCanaryRouter(nil, nil)
+	router.AddPolicy(routing.CanaryPolicy{ID: "gpt5", ModelPattern: "gpt-4*", CanaryModel: "gpt-5", Percent: 50, MinSamples: 5})
+	p.SetCanaries(routerCanaries{router: router})
+
+	for i := 0; i < 100; i++ {
+		chatThrough(p, "gpt-4o", nil)
+	}
+	if result, _ := router.Get("gpt5"); result.Status != routing.CanaryPromoted {
+		t.Fatalf("canary status = %s, want promoted", result.Status)
+	}
+
+	// A promoted canary takes all of the model's traffic
+	before := upstream.count("gpt-4o")
+	for i := 0; i < 10; i++ {
+		if w := chatThrough(p, "gpt-4o", nil); w.Header().Get(HeaderCanaryArm) != CanaryArmCanary {
+			t.Fatalf("arm after promotion = %q, want canary", w.Header().Get(HeaderCanaryArm))
+		}
+	}
+	if upstream.count("gpt-4o") != before {
+		t.Error("requests reached the baseline after promotion")
+	}
+}
+
+func TestCanary_AnthropicProxy(t *testing.T) {
+	var mu sync.Mutex
+	var models []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		models = append(models, req.Model)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "msg_1", "type": "message", "role": "assistant", "model": "` + req.Model + `", "content": []}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultAnthropicProxyConfig()
+	cfg.AnthropicBaseURL = upstream.URL
+	p := NewAnthropicProxy(cfg, &mockKeyProvider{key: "test-api-key"}, nil)
+
+	router := routing.NewCanaryRouter(nil, nil)
+	router.AddPolicy(routing.CanaryPolicy{ID: "next", ModelPattern: "claude-*", CanaryModel: "claude-next", Percent: 100, MinSamples: 50})
+	p.SetCanaries(routerCanaries{router: router})
+
+	body := `{"model": "claude-3-haiku", "max_tokens": 16, "messages": [{"role": "user", "content": [{"type": "text", "text": "hi"}]}]}`
+	w := httptest.NewRecorder()
+	p.HandleMessages(w, httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK || w.Header().Get(HeaderCanary) != "next" || w.Header().Get(HeaderCanaryArm) != CanaryArmCanary {
+		t.Fatalf("response = %d, canary %q arm %q", w.Code, w.Header().Get(HeaderCanary), w.Header().Get(HeaderCanaryArm))
+	}
+	if len(models) != 1 || models[0] != "claude-next" {
+		t.Errorf("upstream saw %v, want the canary model", models)
+	}
+	if result, _ := router.Get("next"); result.Canary.Requests != 1 || result.Canary.Errors != 0 {
+		t.Errorf("canary stats = %+v, want one successful request", result.Canary)
+	}
+}
+
+func TestCanary_SkipsUnsupportedProvider(t *testing.T) {
+	var mu sync.Mutex
+	var models []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		models = append(models, req.Model)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "msg_1", "type": "message", "role": "assistant", "model": "` + req.Model + `", "content": []}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultAnthropicProxyConfig()
+	cfg.AnthropicBaseURL = upstream.URL
+	p := NewAnthropicProxy(cfg, &mockKeyProvider{key: "test-api-key"}, nil)
+
+	// The Anthropic proxy cannot send a request to OpenAI, so the canary
+	// is skipped rather than sending gpt-5 to Anthropic with an OpenAI key
+	router := routing.NewCanaryRouter(nil, nil)
+	router.AddPolicy(routing.CanaryPolicy{ID: "gpt5", ModelPattern: "claude-*", CanaryModel: "gpt-5", CanaryProvider: "openai", Percent: 100, MinSamples: 50})
+	p.SetCanaries(routerCanaries{router: router})
+
+	body := `{"model": "claude-3-haiku", "max_tokens": 16, "messages": [{"role": "user", "content": [{"type": "text", "text": "hi"}]}]}`
+	w := httptest.NewRecorder()
+	p.HandleMessages(w, httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK || w.Header().Get(HeaderCanary) != "" {
+		t.Fatalf("response = %d with canary %q, want the baseline untagged", w.Code, w.Header().Get(HeaderCanary))
+	}
+	if len(models) != 1 || models[0] != "claude-3-haiku" {
+		t.Errorf("upstream saw %v, want the requested model", models)
+	}
+	if result, _ := router.Get("gpt5"); result.Canary.Requests != 0 || result.Baseline.Requests != 0 {
+		t.Errorf("skipped request was observed: canary %+v, baseline %+v", result.Canary, result.Baseline)
+	}
+
+	// The OpenAI proxy speaks to OpenAI-compatible providers but not to
+	// Anthropic
+	op := NewOpenAIProxy(DefaultOpenAIProxyConfig(), &mockKeyProvider{key: "test-api-key"}, nil)
+	if !op.speaks("groq") || op.speaks("anthropic") || !p.speaks("anthropic") || p.speaks("openai") {
+		t.Error("unexpected supported providers")
+	}
+}
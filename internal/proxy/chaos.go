@@ -0,0 +1,21 @@
+package proxy
+
+import "github.com/jeffersonwarrior/modelscan/internal/chaos"
+
+// SetFaultInjector routes upstream requests through a fault injector so
+// retries and failover can be tested against injected latency, drops, error
+// statuses and truncated streams
+func (p *OpenAIProxy) SetFaultInjector(inj *chaos.Injector) {
+	p.faults = inj
+	p.httpClient.Transport = inj.Transport("", p.httpClient.Transport)
+	p.streamingClient.Transport = inj.Transport("", p.streamingClient.Transport)
+}
+
+// SetFaultInjector routes upstream requests through a fault injector so
+// retries and failover can be tested against injected latency, drops, error
+// statuses and truncated streams
+func (p *AnthropicProxy) SetFaultInjector(inj *chaos.Injector) {
+	p.faults = inj
+	p.httpClient.Transport = inj.Transport("", p.httpClient.Transport)
+	p.streamingClient.Transport = inj.Transport("", p.streamingClient.Transport)
+}
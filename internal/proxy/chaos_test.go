@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/chaos"
+)
+
+func TestOpenAIProxy_FaultInjection(t *testing.T) {
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 20; i++ {
+			w.Write([]byte(`data: {"id":"x","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"hello"}}]}` + "\n\n"))
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	inj := chaos.New(1)
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "sk-test"}, nil)
+	p.SetFaultInjector(inj)
+
+	send := func(stream bool) *httptest.ResponseRecorder {
+		body := `{"model": "gpt-4", "messages": [{"role": "user", "content": "hi"}], "stream": ` + map[bool]string{true: "true", false: "false"}[stream] + `}`
+		w := httptest.NewRecorder()
+		p.HandleChatCompletions(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+		return w
+	}
+
+	// Injected status codes reach the client without calling upstream
+	inj.SetFault(chaos.Fault{Provider: "openai", ErrorRate: 1, Status: 429})
+	if w := send(false); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d", w.Code)
+	}
+	if atomic.LoadInt32(&hits) != 0 {
+		t.Error("upstream should not be called for injected errors")
+	}
+
+	// Truncated streams stop early with a stream error
+	inj.SetFault(chaos.Fault{Provider: "openai", TruncateRate: 1, TruncateAfter: 300})
+	w := send(true)
+	if !strings.Contains(w.Body.String(), "stream read error") || strings.Count(w.Body.String(), "hello") >= 20 {
+		t.Errorf("expected truncated stream, got:\n%s", w.Body.String())
+	}
+
+	// Faults for other providers leave openai alone
+	inj.Clear()
+	inj.SetFault(chaos.Fault{Provider: "groq", DropRate: 1})
+	if w := send(false); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
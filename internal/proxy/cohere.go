@@ -0,0 +1,436 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+)
+
+// ====== Cohere v2 Chat Types ======
+
+// CohereRequest represents a Cohere v2 chat request.
+type CohereRequest struct {
+	Model            string          `json:"model"`
+	Messages         []CohereMessage `json:"messages"`
+	MaxTokens        *int            `json:"max_tokens,omitempty"`
+	Temperature      *float64        `json:"temperature,omitempty"`
+	P                *float64        `json:"p,omitempty"`
+	StopSequences    []string        `json:"stop_sequences,omitempty"`
+	FrequencyPenalty *float64        `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64        `json:"presence_penalty,omitempty"`
+	Stream           bool            `json:"stream,omitempty"`
+	Tools            []OpenAITool    `json:"tools,omitempty"` // v2 uses the OpenAI function tool shape
+}
+
+// CohereMessage represents a message in Cohere v2 format.
+// Content is a plain string for requests, or text and image_url blocks when
+// a user message carries images; responses use content blocks.
+type CohereMessage struct {
+	Role       string           `json:"role"`
+	Content    interface{}      `json:"content,omitempty"`
+	ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// CohereResponse represents a Cohere v2 chat response.
+type CohereResponse struct {
+	ID           string `json:"id"`
+	FinishReason string `json:"finish_reason"`
+	Message      struct {
+		Role    string `json:"role"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		ToolCalls []OpenAIToolCall `json:"tool_calls,omitempty"`
+	} `json:"message"`
+	Usage *CohereUsage `json:"usage,omitempty"`
+}
+
+// CohereUsage reports billed and actual token counts.
+type CohereUsage struct {
+	BilledUnits *Usage `json:"billed_units,omitempty"`
+	Tokens      *Usage `json:"tokens,omitempty"`
+}
+
+// CohereStreamEvent represents a Cohere v2 streaming event.
+type CohereStreamEvent struct {
+	Type  string `json:"type"`
+	ID    string `json:"id,omitempty"`
+	Index int    `json:"index,omitempty"`
+	Delta *struct {
+		Message *struct {
+			Role    string `json:"role,omitempty"`
+			Content *struct {
+				Text string `json:"text,omitempty"`
+			} `json:"content,omitempty"`
+			ToolCalls *OpenAIToolCall `json:"tool_calls,omitempty"`
+		} `json:"message,omitempty"`
+		FinishReason string       `json:"finish_reason,omitempty"`
+		Usage        *CohereUsage `json:"usage,omitempty"`
+	} `json:"delta,omitempty"`
+}
+
+// ====== Translation Functions ======
+
+// ToCohere converts an OpenAI request to Cohere v2 chat format.
+func ToCohere(req *OpenAIRequest) (*CohereRequest, error) {
+	if req == nil {
+		return nil, fmt.Errorf("nil openai request")
+	}
+
+	cohereReq := &CohereRequest{
+		Model:            req.Model,
+		Temperature:      req.Temperature,
+		P:                req.TopP,
+		StopSequences:    req.Stop,
+		FrequencyPenalty: req.FrequencyPenalty,
+		PresencePenalty:  req.PresencePenalty,
+		Stream:           req.Stream,
+		Tools:            req.Tools,
+	}
+
+	// Prefer max_completion_tokens if set
+	if req.MaxCompletionTokens != nil {
+		cohereReq.MaxTokens = req.MaxCompletionTokens
+	} else {
+		cohereReq.MaxTokens = req.MaxTokens
+	}
+
+	cohereReq.Messages = make([]CohereMessage, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		var content interface{}
+		if s, ok := getStringContent(msg.Content); ok && s != "" {
+			content = s
+		} else if msg.Role == "user" && hasImageBlocks(msg.Content) {
+			blocks, err := cohereImageContent(msg.Content)
+			if err != nil {
+				return nil, err
+			}
+			content = blocks
+		} else if text := extractTextBlocks(msg.Content); text != "" {
+			content = text
+		}
+		cohereReq.Messages = append(cohereReq.Messages, CohereMessage{
+			Role:       msg.Role,
+			Content:    content,
+			ToolCalls:  msg.ToolCalls,
+			ToolCallID: msg.ToolCallID,
+		})
+	}
+
+	return cohereReq, nil
+}
+
+// extractTextBlocks joins the text parts of an OpenAI content block array.
+func extractTextBlocks(content interface{}) string {
+	blocks, ok := content.([]interface{})
+	if !ok {
+		return ""
+	}
+
+	var texts []string
+	for _, block := range blocks {
+		b, ok := block.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if b["type"] == "text" {
+			if text, ok := b["text"].(string); ok {
+				texts = append(texts, text)
+			}
+		}
+	}
+	return joinStrings(texts, "\n")
+}
+
+// hasImageBlocks reports whether OpenAI array content has image_url parts
+func hasImageBlocks(content interface{}) bool {
+	blocks, _ := content.([]interface{})
+	for _, b := range blocks {
+		if block, ok := b.(map[string]interface{}); ok && block["type"] == "image_url" {
+			return true
+		}
+	}
+	return false
+}
+
+// cohereImageContent converts OpenAI text and image_url parts to Cohere
+// v2 content blocks, which take the same shape without OpenAI's extras
+func cohereImageContent(content interface{}) ([]interface{}, error) {
+	parts, err := openAIContentParts(content)
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		if part.Type == "image" {
+			blocks = append(blocks, map[string]interface{}{
+				"type":      "image_url",
+				"image_url": map[string]interface{}{"url": part.Source.imageURL()},
+			})
+		} else {
+			blocks = append(blocks, map[string]interface{}{"type": "text", "text": part.Text})
+		}
+	}
+	return blocks, nil
+}
+
+// TranslateCohereResponseToOpenAI converts a Cohere v2 response to OpenAI format.
+func TranslateCohereResponseToOpenAI(resp *CohereResponse, model string) *OpenAIResponse {
+	if resp == nil {
+		return nil
+	}
+
+	openaiResp := &OpenAIResponse{
+		ID:      resp.ID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []OpenAIChoice{
+			{
+				Index: 0,
+				Message: OpenAIMessage{
+					Role: "assistant",
+				},
+				FinishReason: mapCohereFinishReason(resp.FinishReason),
+			},
+		},
+	}
+
+	var textParts []string
+	for _, part := range resp.Message.Content {
+		if part.Type == "text" {
+			textParts = append(textParts, part.Text)
+		}
+	}
+	if len(textParts) > 0 {
+		openaiResp.Choices[0].Message.Content = joinStrings(textParts, "\n")
+	}
+	if len(resp.Message.ToolCalls) > 0 {
+		openaiResp.Choices[0].Message.ToolCalls = resp.Message.ToolCalls
+	}
+
+	if usage := cohereUsageToOpenAI(resp.Usage); usage != nil {
+		openaiResp.Usage = usage
+	}
+
+	return openaiResp
+}
+
+// TranslateCohereStreamEventToOpenAI converts a Cohere stream event to an OpenAI chunk.
+// Returns nil for events that have no OpenAI equivalent.
+func TranslateCohereStreamEventToOpenAI(event *CohereStreamEvent, id, model string) *OpenAIStreamChunk {
+	if event == nil {
+		return nil
+	}
+
+	chunk := &OpenAIStreamChunk{
+		ID:      id,
+		Object:  "chat.completion.chunk",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []OpenAIStreamChoice{
+			{Index: 0},
+		},
+	}
+
+	switch event.Type {
+	case "message-start":
+		chunk.Choices[0].Delta = OpenAIStreamDelta{Role: "assistant"}
+
+	case "content-delta":
+		if event.Delta == nil || event.Delta.Message == nil || event.Delta.Message.Content == nil {
+			return nil
+		}
+		chunk.Choices[0].Delta = OpenAIStreamDelta{Content: event.Delta.Message.Content.Text}
+
+	case "tool-call-start", "tool-call-delta":
+		if event.Delta == nil || event.Delta.Message == nil || event.Delta.Message.ToolCalls == nil {
+			return nil
+		}
+		tc := event.Delta.Message.ToolCalls
+		toolDelta := OpenAIToolCallDelta{
+			Index: event.Index,
+			ID:    tc.ID,
+			Type:  tc.Type,
+		}
+		toolDelta.Function.Name = tc.Function.Name
+		toolDelta.Function.Arguments = tc.Function.Arguments
+		chunk.Choices[0].Delta = OpenAIStreamDelta{ToolCalls: []OpenAIToolCallDelta{toolDelta}}
+
+	case "message-end":
+		if event.Delta == nil {
+			return nil
+		}
+		reason := mapCohereFinishReason(event.Delta.FinishReason)
+		chunk.Choices[0].FinishReason = &reason
+		chunk.Usage = cohereUsageToOpenAI(event.Delta.Usage)
+
+	default:
+		// content-start, content-end, tool-plan-delta, tool-call-end, citations
+		return nil
+	}
+
+	return chunk
+}
+
+// mapCohereFinishReason maps Cohere finish_reason to OpenAI finish_reason.
+func mapCohereFinishReason(reason string) string {
+	switch reason {
+	case "COMPLETE", "STOP_SEQUENCE":
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "TOOL_CALL":
+		return "tool_calls"
+	case "ERROR":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+// cohereUsageToOpenAI converts Cohere token usage, preferring actual tokens over billed units.
+func cohereUsageToOpenAI(usage *CohereUsage) *OpenAIUsage {
+	if usage == nil {
+		return nil
+	}
+
+	tokens := usage.Tokens
+	if tokens == nil {
+		tokens = usage.BilledUnits
+	}
+	if tokens == nil {
+		return nil
+	}
+
+	return &OpenAIUsage{
+		PromptTokens:     tokens.InputTokens,
+		CompletionTokens: tokens.OutputTokens,
+		TotalTokens:      tokens.InputTokens + tokens.OutputTokens,
+	}
+}
+
+// streamCohereEvents reads Cohere SSE events and forwards them as OpenAI chunks
+func (p *OpenAIProxy) streamCohereEvents(ctx context.Context, sw *StreamWriter, reader io.Reader, model string) {
+	scanner, release := newEventScanner(reader)
+	defer release()
+
+	streamID := fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
+
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			// Ignore event:, id:, comments and blank separators
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" {
+			continue
+		}
+
+		var event CohereStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			continue
+		}
+		if event.Type == "message-start" && event.ID != "" {
+			streamID = event.ID
+		}
+
+		chunk := TranslateCohereStreamEventToOpenAI(&event, streamID, model)
+		if chunk != nil {
+			chunkJSON, err := json.Marshal(chunk)
+			if err != nil {
+				continue
+			}
+			if err := sw.WriteEvent(chunkJSON); err != nil {
+				log.Printf("proxy: abandoning stream: %v", err)
+				return
+			}
+		}
+
+		if event.Type == "message-end" {
+			sw.Close()
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		sw.WriteError(fmt.Errorf("stream read error: %w", err))
+	}
+
+	sw.Close()
+}
+
+// handleCohereRequest translates a non-streaming request to Cohere v2 chat
+// and converts the response back to OpenAI format
+func (p *OpenAIProxy) handleCohereRequest(ctx context.Context, w http.ResponseWriter, req *OpenAIRequest, apiKey string) {
+	cohereReq, err := ToCohere(req)
+	if err != nil {
+		p.writeError(w, fmt.Sprintf("failed to translate request: %v", err), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+
+	reqBody, err := marshalJSON(cohereReq)
+	if err != nil {
+		p.writeError(w, "failed to marshal request", "server_error", http.StatusInternalServerError)
+		return
+	}
+	// Deferred before resp.Body.Close, so it runs after the transport is done
+	defer reqBody.release()
+
+	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.getUpstreamURL("cohere"), bytes.NewReader(reqBody.Bytes()))
+	if err != nil {
+		p.writeError(w, "failed to create upstream request", "server_error", http.StatusInternalServerError)
+		return
+	}
+	p.setUpstreamHeaders(upstreamReq, apiKey, "cohere")
+
+	resp, err := p.httpClient.Do(upstreamReq)
+	if err != nil {
+		message, errType, status := upstreamFailure(ctx, err)
+		p.writeError(w, message, errType, status)
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := readBody(resp.Body)
+	if err != nil {
+		message, errType, status := upstreamReadError(err)
+		p.writeError(w, message, errType, status)
+		return
+	}
+	defer putBuffer(body)
+
+	if resp.StatusCode >= 400 {
+		setErrorCode(w, providererr.Classify("cohere", resp.StatusCode, resp.Header, body.Bytes()))
+		p.writeError(w, fmt.Sprintf("upstream error: %s", sanitizeErrorMessage(body.String())), "upstream_error", resp.StatusCode)
+		return
+	}
+
+	var cohereResp CohereResponse
+	if err := json.Unmarshal(body.Bytes(), &cohereResp); err != nil {
+		p.writeError(w, "failed to parse upstream response", "server_error", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(TranslateCohereResponseToOpenAI(&cohereResp, req.Model)); err != nil {
+		log.Printf("proxy: error encoding cohere response: %v", err)
+	}
+}
@@ -0,0 +1,341 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestToCohere(t *testing.T) {
+	maxTokens := 100
+	temp := 0.5
+	topP := 0.9
+	req := &OpenAIRequest{
+		Model: "command-r",
+		Messages: []OpenAIMessage{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: []interface{}{
+				map[string]interface{}{"type": "text", "text": "Hello"},
+				map[string]interface{}{"type": "text", "text": "there"},
+			}},
+			{Role: "assistant", ToolCalls: []OpenAIToolCall{
+				{ID: "call_1", Type: "function", Function: OpenAIFunction{Name: "lookup", Arguments: `{"q":"x"}`}},
+			}},
+			{Role: "tool", Content: "result", ToolCallID: "call_1"},
+		},
+		MaxTokens:   &maxTokens,
+		Temperature: &temp,
+		TopP:        &topP,
+		Stop:        []string{"END"},
+		Tools: []OpenAITool{
+			{Type: "function", Function: OpenAIFunctionDef{Name: "lookup"}},
+		},
+	}
+
+	got, err := ToCohere(req)
+	if err != nil {
+		t.Fatalf("ToCohere failed: %v", err)
+	}
+
+	if got.Model != "command-r" {
+		t.Errorf("expected model command-r, got %s", got.Model)
+	}
+	if got.MaxTokens == nil || *got.MaxTokens != 100 {
+		t.Errorf("expected max_tokens 100, got %v", got.MaxTokens)
+	}
+	if got.P == nil || *got.P != 0.9 {
+		t.Errorf("expected p 0.9, got %v", got.P)
+	}
+	if len(got.StopSequences) != 1 || got.StopSequences[0] != "END" {
+		t.Errorf("expected stop_sequences [END], got %v", got.StopSequences)
+	}
+	if len(got.Messages) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(got.Messages))
+	}
+	if got.Messages[0].Role != "system" || got.Messages[0].Content != "Be brief." {
+		t.Errorf("unexpected system message: %+v", got.Messages[0])
+	}
+	if got.Messages[1].Content != "Hello\nthere" {
+		t.Errorf("expected joined text blocks, got %q", got.Messages[1].Content)
+	}
+	if len(got.Messages[2].ToolCalls) != 1 {
+		t.Errorf("expected assistant tool call to be preserved")
+	}
+	if got.Messages[3].ToolCallID != "call_1" {
+		t.Errorf("expected tool_call_id call_1, got %s", got.Messages[3].ToolCallID)
+	}
+	if len(got.Tools) != 1 {
+		t.Errorf("expected 1 tool, got %d", len(got.Tools))
+	}
+}
+
+func TestToCohere_Nil(t *testing.T) {
+	if _, err := ToCohere(nil); err == nil {
+		t.Error("expected error for nil request")
+	}
+}
+
+func TestToCohere_PrefersMaxCompletionTokens(t *testing.T) {
+	maxTokens := 100
+	maxCompletion := 50
+	got, err := ToCohere(&OpenAIRequest{
+		Model:               "command-r",
+		Messages:            []OpenAIMessage{{Role: "user", Content: "hi"}},
+		MaxTokens:           &maxTokens,
+		MaxCompletionTokens: &maxCompletion,
+	})
+	if err != nil {
+		t.Fatalf("ToCohere failed: %v", err)
+	}
+	if *got.MaxTokens != 50 {
+		t.Errorf("expected max_tokens 50, got %d", *got.MaxTokens)
+	}
+}
+
+func TestTranslateCohereResponseToOpenAI(t *testing.T) {
+	var resp CohereResponse
+	data := `{
+		"id": "c-123",
+		"finish_reason": "COMPLETE",
+		"message": {
+			"role": "assistant",
+			"content": [{"type": "text", "text": "Hi there"}]
+		},
+		"usage": {
+			"billed_units": {"input_tokens": 5, "output_tokens": 2},
+			"tokens": {"input_tokens": 70, "output_tokens": 2}
+		}
+	}`
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	got := TranslateCohereResponseToOpenAI(&resp, "command-r")
+	if got.ID != "c-123" || got.Model != "command-r" {
+		t.Errorf("unexpected id/model: %s/%s", got.ID, got.Model)
+	}
+	if got.Choices[0].Message.Content != "Hi there" {
+		t.Errorf("unexpected content: %v", got.Choices[0].Message.Content)
+	}
+	if got.Choices[0].FinishReason != "stop" {
+		t.Errorf("expected finish_reason stop, got %s", got.Choices[0].FinishReason)
+	}
+	if got.Usage == nil || got.Usage.PromptTokens != 70 || got.Usage.TotalTokens != 72 {
+		t.Errorf("unexpected usage: %+v", got.Usage)
+	}
+
+	if TranslateCohereResponseToOpenAI(nil, "x") != nil {
+		t.Error("expected nil for nil response")
+	}
+}
+
+func TestTranslateCohereResponseToOpenAI_ToolCalls(t *testing.T) {
+	var resp CohereResponse
+	data := `{
+		"id": "c-456",
+		"finish_reason": "TOOL_CALL",
+		"message": {
+			"role": "assistant",
+			"tool_plan": "I will look this up",
+			"tool_calls": [{"id": "tc_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":\"x\"}"}}]
+		},
+		"usage": {"billed_units": {"input_tokens": 10, "output_tokens": 4}}
+	}`
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	got := TranslateCohereResponseToOpenAI(&resp, "command-r")
+	if got.Choices[0].FinishReason != "tool_calls" {
+		t.Errorf("expected finish_reason tool_calls, got %s", got.Choices[0].FinishReason)
+	}
+	if len(got.Choices[0].Message.ToolCalls) != 1 || got.Choices[0].Message.ToolCalls[0].Function.Name != "lookup" {
+		t.Errorf("unexpected tool calls: %+v", got.Choices[0].Message.ToolCalls)
+	}
+	// Falls back to billed units when token counts are absent
+	if got.Usage == nil || got.Usage.PromptTokens != 10 {
+		t.Errorf("unexpected usage: %+v", got.Usage)
+	}
+}
+
+func TestMapCohereFinishReason(t *testing.T) {
+	tests := map[string]string{
+		"COMPLETE":      "stop",
+		"STOP_SEQUENCE": "stop",
+		"MAX_TOKENS":    "length",
+		"TOOL_CALL":     "tool_calls",
+		"ERROR":         "content_filter",
+		"":              "stop",
+	}
+	for in, want := range tests {
+		if got := mapCohereFinishReason(in); got != want {
+			t.Errorf("mapCohereFinishReason(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTranslateCohereStreamEventToOpenAI(t *testing.T) {
+	parse := func(data string) *CohereStreamEvent {
+		var event CohereStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("failed to unmarshal event: %v", err)
+		}
+		return &event
+	}
+
+	start := TranslateCohereStreamEventToOpenAI(parse(`{"type":"message-start","id":"c-1","delta":{"message":{"role":"assistant"}}}`), "c-1", "command-r")
+	if start == nil || start.Choices[0].Delta.Role != "assistant" {
+		t.Errorf("expected role delta, got %+v", start)
+	}
+
+	delta := TranslateCohereStreamEventToOpenAI(parse(`{"type":"content-delta","index":0,"delta":{"message":{"content":{"text":"Hel"}}}}`), "c-1", "command-r")
+	if delta == nil || delta.Choices[0].Delta.Content != "Hel" {
+		t.Errorf("expected content delta, got %+v", delta)
+	}
+
+	tool := TranslateCohereStreamEventToOpenAI(parse(`{"type":"tool-call-start","index":0,"delta":{"message":{"tool_calls":{"id":"tc_1","type":"function","function":{"name":"lookup","arguments":""}}}}}`), "c-1", "command-r")
+	if tool == nil || len(tool.Choices[0].Delta.ToolCalls) != 1 || tool.Choices[0].Delta.ToolCalls[0].Function.Name != "lookup" {
+		t.Errorf("expected tool call delta, got %+v", tool)
+	}
+
+	end := TranslateCohereStreamEventToOpenAI(parse(`{"type":"message-end","delta":{"finish_reason":"MAX_TOKENS","usage":{"tokens":{"input_tokens":3,"output_tokens":4}}}}`), "c-1", "command-r")
+	if end == nil || end.Choices[0].FinishReason == nil || *end.Choices[0].FinishReason != "length" {
+		t.Errorf("expected length finish reason, got %+v", end)
+	}
+	if end.Usage == nil || end.Usage.TotalTokens != 7 {
+		t.Errorf("unexpected usage: %+v", end.Usage)
+	}
+
+	if got := TranslateCohereStreamEventToOpenAI(parse(`{"type":"content-start","index":0}`), "c-1", "command-r"); got != nil {
+		t.Errorf("expected nil for content-start, got %+v", got)
+	}
+	if TranslateCohereStreamEventToOpenAI(nil, "c-1", "command-r") != nil {
+		t.Error("expected nil for nil event")
+	}
+}
+
+func TestOpenAIProxy_Cohere_NonStreaming(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/chat" {
+			t.Errorf("expected /v2/chat, got %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer cohere-key" {
+			t.Errorf("unexpected Authorization header: %s", auth)
+		}
+
+		var req CohereRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "command-r" {
+			t.Errorf("expected model command-r, got %s", req.Model)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"c-1","finish_reason":"COMPLETE","message":{"role":"assistant","content":[{"type":"text","text":"Hello!"}]},"usage":{"tokens":{"input_tokens":3,"output_tokens":2}}}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.CohereBaseURL = upstream.URL
+	proxy := NewOpenAIProxy(cfg, &mockKeyProvider{key: "cohere-key"}, &mockRemapper{model: "command-r", provider: "cohere"})
+
+	body := `{"model": "gpt-4", "messages": [{"role": "user", "content": "hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("X-Client-ID", "test-client")
+	w := httptest.NewRecorder()
+
+	proxy.HandleChatCompletions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp OpenAIResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Object != "chat.completion" || resp.Choices[0].Message.Content != "Hello!" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 5 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+func TestOpenAIProxy_Cohere_UpstreamError(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"message":"rate limited"}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.CohereBaseURL = upstream.URL
+	proxy := NewOpenAIProxy(cfg, &mockKeyProvider{key: "cohere-key"}, &mockRemapper{model: "command-r", provider: "cohere"})
+
+	body := `{"model": "gpt-4", "messages": [{"role": "user", "content": "hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("X-Client-ID", "test-client")
+	w := httptest.NewRecorder()
+
+	proxy.HandleChatCompletions(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "rate limited") {
+		t.Errorf("expected upstream message in error, got %s", w.Body.String())
+	}
+}
+
+func TestOpenAIProxy_Cohere_Streaming(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req CohereRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Errorf("expected stream: true")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher, _ := w.(http.Flusher)
+
+		events := []string{
+			`{"type":"message-start","id":"c-stream","delta":{"message":{"role":"assistant"}}}`,
+			`{"type":"content-start","index":0,"delta":{"message":{"content":{"type":"text","text":""}}}}`,
+			`{"type":"content-delta","index":0,"delta":{"message":{"content":{"text":"Hello"}}}}`,
+			`{"type":"content-end","index":0}`,
+			`{"type":"message-end","delta":{"finish_reason":"COMPLETE","usage":{"tokens":{"input_tokens":3,"output_tokens":1}}}}`,
+		}
+		for _, e := range events {
+			w.Write([]byte("event: x\ndata: " + e + "\n\n"))
+			flusher.Flush()
+		}
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.CohereBaseURL = upstream.URL
+	proxy := NewOpenAIProxy(cfg, &mockKeyProvider{key: "cohere-key"}, &mockRemapper{model: "command-r", provider: "cohere"})
+
+	body := `{"model": "gpt-4", "stream": true, "messages": [{"role": "user", "content": "hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("X-Client-ID", "test-client")
+	w := httptest.NewRecorder()
+
+	proxy.HandleChatCompletions(w, req)
+
+	bodyStr := w.Body.String()
+	if !strings.Contains(bodyStr, `"id":"c-stream"`) {
+		t.Errorf("expected stream id in chunks, got %s", bodyStr)
+	}
+	if !strings.Contains(bodyStr, `"content":"Hello"`) {
+		t.Errorf("expected translated content delta, got %s", bodyStr)
+	}
+	if !strings.Contains(bodyStr, `"finish_reason":"stop"`) {
+		t.Errorf("expected stop finish reason, got %s", bodyStr)
+	}
+	if strings.Count(bodyStr, "[DONE]") != 1 {
+		t.Errorf("expected exactly one [DONE] marker, got %s", bodyStr)
+	}
+}
@@ -0,0 +1,213 @@
+package proxy
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+)
+
+// Completion is a finished proxied response as seen by AfterCompletion
+// hooks. Streamed responses are assembled from the events sent to the
+// client.
+type Completion struct {
+	API              string // "openai" (chat completions) or "anthropic" (messages)
+	ClientID         string
+	Provider         string
+	Model            string
+	Stream           bool
+	Status           int
+	Content          string // Generated text
+	FinishReason     string // finish_reason or stop_reason, as the client saw it
+	PromptTokens     int    // Reported by the provider, or estimated
+	CompletionTokens int    // Reported by the provider, or counted from Content
+	CachedTokens     int
+	Body             []byte // Non-streaming response body, up to 1MB
+	StartedAt        time.Time
+	Duration         time.Duration
+	FirstByte        time.Duration // Until the response started; for streams, the first event
+	Disconnected     bool          // The client went away before the response was finished
+	Experiment       string        // The A/B experiment the request was enrolled in, if any
+	Variant          string        // The experiment variant that served it
+}
+
+// AfterCompletionHook is called once for every finished response, after the
+// client has received all of it
+type AfterCompletionHook func(ctx context.Context, c *Completion)
+
+// CompletionHooksConfig configures how AfterCompletion hooks are run
+type CompletionHooksConfig struct {
+	// Workers run hooks concurrently (default 4)
+	Workers int
+	// QueueSize bounds completions waiting for a worker; completions
+	// arriving at a full queue are dropped (default 1024)
+	QueueSize int
+	// Timeout bounds the context hooks get for one completion (default 30s)
+	Timeout time.Duration
+}
+
+// CompletionHookStats counts completions handed to hooks
+type CompletionHookStats struct {
+	Delivered int64 `json:"delivered"`
+	Dropped   int64 `json:"dropped"`
+}
+
+// CompletionHooks runs AfterCompletion hooks on background workers so slow
+// hooks never hold up a response
+type CompletionHooks struct {
+	config CompletionHooksConfig
+	queue  chan *Completion
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	hooks  []AfterCompletionHook
+	closed bool
+
+	delivered atomic.Int64
+	dropped   atomic.Int64
+}
+
+// NewCompletionHooks starts the hook workers
+func NewCompletionHooks(cfg CompletionHooksConfig) *CompletionHooks {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1024
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	h := &CompletionHooks{config: cfg, queue: make(chan *Completion, cfg.QueueSize)}
+	for i := 0; i < cfg.Workers; i++ {
+		h.wg.Add(1)
+		go h.work()
+	}
+	return h
+}
+
+// Register adds a hook. Hooks run in registration order for each completion.
+func (h *CompletionHooks) Register(hook AfterCompletionHook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, hook)
+}
+
+// Stats returns how many completions were delivered to hooks or dropped
+func (h *CompletionHooks) Stats() CompletionHookStats {
+	return CompletionHookStats{Delivered: h.delivered.Load(), Dropped: h.dropped.Load()}
+}
+
+// Close stops accepting completions and waits for queued ones to be handled
+func (h *CompletionHooks) Close() {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	h.closed = true
+	close(h.queue)
+	h.mu.Unlock()
+	h.wg.Wait()
+}
+
+// SetCompletionHooks runs hooks after every proxied response
+func (p *OpenAIProxy) SetCompletionHooks(h *CompletionHooks) {
+	p.completionHooks = h
+}
+
+// SetCompletionHooks runs hooks after every proxied response
+func (p *AnthropicProxy) SetCompletionHooks(h *CompletionHooks) {
+	p.completionHooks = h
+}
+
+// dispatch queues a completion for the workers without blocking
+func (h *CompletionHooks) dispatch(c *Completion) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed || len(h.hooks) == 0 {
+		return
+	}
+	select {
+	case h.queue <- c:
+	default:
+		if h.dropped.Add(1)%100 == 1 {
+			log.Printf("proxy: completion hook queue full, dropped %d completions so far", h.dropped.Load())
+		}
+	}
+}
+
+// work runs hooks for queued completions until the queue is closed
+func (h *CompletionHooks) work() {
+	defer h.wg.Done()
+	for c := range h.queue {
+		h.mu.RLock()
+		hooks := h.hooks
+		h.mu.RUnlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), h.config.Timeout)
+		for _, hook := range hooks {
+			runHook(ctx, hook, c)
+		}
+		cancel()
+		h.delivered.Add(1)
+	}
+}
+
+// runHook calls one hook, containing and reporting its panics
+func runHook(ctx context.Context, hook AfterCompletionHook, c *Completion) {
+	_ = crash.Do("completion hook", func() { hook(ctx, c) })
+}
+
+// trackCompletion wraps w to assemble the response for the hooks. The
+// returned finish func hands the completion to the hooks once the handler
+// has written all of it, or given up on a client that disconnected: such
+// a completion carries what was sent before, and an error the proxy wrote
+// after the upstream call was cancelled becomes StatusClientClosedRequest.
+// Without hooks w is returned unchanged.
+func trackCompletion(ctx context.Context, h *CompletionHooks, w http.ResponseWriter, api, clientID string, promptEstimate int) (http.ResponseWriter, func()) {
+	if h == nil {
+		return w, func() {}
+	}
+	start := time.Now()
+	mw := &meterWriter{ResponseWriter: w, status: http.StatusOK}
+	finish := func() {
+		prompt, completion := mw.tokens(promptEstimate)
+		gone, _ := clientGone(ctx)
+		status := mw.status
+		if gone && status >= http.StatusInternalServerError {
+			status = StatusClientClosedRequest
+		}
+		c := &Completion{
+			API:              api,
+			ClientID:         clientID,
+			Provider:         mw.Header().Get(HeaderProvider),
+			Model:            mw.Header().Get(HeaderModel),
+			Stream:           mw.stream,
+			Status:           status,
+			Content:          mw.text.String(),
+			FinishReason:     mw.finish,
+			PromptTokens:     prompt,
+			CompletionTokens: completion,
+			CachedTokens:     mw.cacheRead,
+			StartedAt:        start,
+			Duration:         time.Since(start),
+			Disconnected:     gone,
+			Experiment:       mw.Header().Get(HeaderExperiment),
+			Variant:          mw.Header().Get(HeaderVariant),
+		}
+		if !mw.stream {
+			c.Body = mw.body.Bytes()
+		}
+		if !mw.started.IsZero() {
+			c.FirstByte = mw.started.Sub(start)
+		}
+		h.dispatch(c)
+	}
+	return mw, finish
+}
@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAnthropicProxy_CompletionHooks(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher, _ := w.(http.Flusher)
+		for _, event := range []string{
+			`{"type":"message_start","message":{"id":"msg_1","model":"claude-3-5-sonnet","usage":{"input_tokens":12}}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" there"}}`,
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":3}}`,
+			`{"type":"message_stop"}`,
+		} {
+			w.Write([]byte("data: " + event + "\n\n"))
+			flusher.Flush()
+		}
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultAnthropicProxyConfig()
+	cfg.AnthropicBaseURL = upstream.URL
+	p := NewAnthropicProxy(cfg, &mockKeyProvider{key: "test-key"}, nil)
+
+	hooks := NewCompletionHooks(CompletionHooksConfig{Workers: 1})
+	got := make(chan *Completion, 1)
+	hooks.Register(func(ctx context.Context, c *Completion) { got <- c })
+	p.SetCompletionHooks(hooks)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(
+		`{"model":"claude-3-5-sonnet","max_tokens":100,"stream":true,"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`))
+	req.Header.Set("X-Client-ID", "acme")
+	rec := httptest.NewRecorder()
+	p.HandleMessages(rec, req)
+
+	select {
+	case c := <-got:
+		if c.API != "anthropic" || c.ClientID != "acme" || !c.Stream || c.Status != http.StatusOK || c.Provider != "anthropic" {
+			t.Errorf("unexpected completion: %+v", c)
+		}
+		if c.Content != "Hello there" || c.FinishReason != "end_turn" || c.PromptTokens != 12 || c.CompletionTokens != 3 {
+			t.Errorf("stream not assembled: %+v", c)
+		}
+		if c.FirstByte <= 0 || c.FirstByte > c.Duration {
+			t.Errorf("FirstByte = %s, want within the %s duration", c.FirstByte, c.Duration)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("hook was not called")
+	}
+	if !strings.Contains(rec.Body.String(), "Hello") {
+		t.Errorf("client stream changed: %s", rec.Body.String())
+	}
+	hooks.Close()
+	if stats := hooks.Stats(); stats.Delivered != 1 || stats.Dropped != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestCompletionHooks_DoNotBlock(t *testing.T) {
+	hooks := NewCompletionHooks(CompletionHooksConfig{Workers: 1, QueueSize: 1})
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	var mu sync.Mutex
+	var seen []string
+	hooks.Register(func(ctx context.Context, c *Completion) {
+		started <- struct{}{}
+		<-release
+		mu.Lock()
+		seen = append(seen, c.Model)
+		mu.Unlock()
+	})
+	hooks.Register(func(ctx context.Context, c *Completion) { panic("broken hook") })
+
+	// One completion runs, one waits in the queue and the rest are dropped
+	// instead of holding up the caller
+	start := time.Now()
+	hooks.dispatch(&Completion{Model: "a"})
+	<-started
+	for hooks.Stats().Dropped == 0 && time.Since(start) < time.Second {
+		hooks.dispatch(&Completion{Model: "b"})
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("dispatch blocked on a slow hook")
+	}
+
+	close(release)
+	hooks.Close()
+	if stats := hooks.Stats(); stats.Delivered != 2 || len(seen) != 2 {
+		t.Errorf("expected two completions handled despite the panicking hook, got %+v %v", stats, seen)
+	}
+	hooks.dispatch(&Completion{Model: "late"}) // Ignored after Close
+}
+
+func TestOpenAIProxy_CompletionHooks_NonStreaming(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"message":{"role":"assistant","content":"Hi!"},"finish_reason":"stop"}],
+			"usage":{"prompt_tokens":9,"completion_tokens":2,"prompt_tokens_details":{"cached_tokens":4}}}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-key"}, nil)
+	hooks := NewCompletionHooks(CompletionHooksConfig{})
+	got := make(chan *Completion, 1)
+	hooks.Register(func(ctx context.Context, c *Completion) { got <- c })
+	p.SetCompletionHooks(hooks)
+	defer hooks.Close()
+
+	rec := httptest.NewRecorder()
+	p.HandleChatCompletions(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+		strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)))
+
+	c := <-got
+	if c.API != "openai" || c.Stream || c.Model != "gpt-4o" || c.Content != "Hi!" || c.FinishReason != "stop" ||
+		c.PromptTokens != 9 || c.CompletionTokens != 2 || c.CachedTokens != 4 || !strings.Contains(string(c.Body), "chatcmpl-1") {
+		t.Errorf("unexpected completion: %+v", c)
+	}
+}
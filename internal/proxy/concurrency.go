@@ -0,0 +1,259 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+)
+
+// ConcurrencyConfig caps in-flight upstream requests. Zero caps are
+// unlimited.
+type ConcurrencyConfig struct {
+	MaxInFlight            int            // Across all providers
+	MaxInFlightPerProvider int            // Default for each provider
+	Providers              map[string]int // Provider -> cap, overriding the default
+	QueueSize              int            // Requests waiting for each cap before new ones are shed
+	QueueTimeout           time.Duration  // Longest a request waits in a queue (default 10s)
+	RetryAfter             time.Duration  // Sent with shed requests (default 1s)
+}
+
+// OverloadError is returned for requests shed because a concurrency cap and
+// its queue are full. It classifies as providererr.ErrOverloaded.
+type OverloadError struct {
+	Scope      string // Provider ID, or "global"
+	RetryAfter time.Duration
+}
+
+func (e *OverloadError) Error() string {
+	return fmt.Sprintf("too many concurrent requests (%s); retry after %s", e.Scope, e.RetryAfter)
+}
+
+// Unwrap classifies the overload as providererr.ErrOverloaded
+func (e *OverloadError) Unwrap() error {
+	return providererr.ErrOverloaded
+}
+
+// ConcurrencyStats describes one concurrency cap
+type ConcurrencyStats struct {
+	Scope    string `json:"scope"` // Provider ID, or "global"
+	Limit    int    `json:"limit"`
+	InFlight int    `json:"in_flight"`
+	Queued   int64  `json:"queued"`
+	Shed     int64  `json:"shed"`
+}
+
+// ConcurrencyLimiter bounds how many upstream requests run at once, globally
+// and per provider, so a burst of traffic queues briefly and is then shed
+// instead of opening unbounded upstream connections
+type ConcurrencyLimiter struct {
+	config ConcurrencyConfig
+	global *gate
+
+	mu        sync.Mutex
+	providers map[string]*gate
+}
+
+// gate is a counting semaphore with a bounded wait queue
+type gate struct {
+	slots  chan struct{}
+	queued atomic.Int64
+	shed   atomic.Int64
+}
+
+// NewConcurrencyLimiter creates a limiter, filling in default timings
+func NewConcurrencyLimiter(cfg ConcurrencyConfig) (*ConcurrencyLimiter, error) {
+	if cfg.MaxInFlight < 0 || cfg.MaxInFlightPerProvider < 0 || cfg.QueueSize < 0 {
+		return nil, fmt.Errorf("concurrency caps and queue size must not be negative")
+	}
+	for provider, limit := range cfg.Providers {
+		if limit < 0 {
+			return nil, fmt.Errorf("concurrency cap for %s must not be negative", provider)
+		}
+	}
+	if cfg.QueueTimeout <= 0 {
+		cfg.QueueTimeout = 10 * time.Second
+	}
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = time.Second
+	}
+
+	l := &ConcurrencyLimiter{config: cfg, providers: make(map[string]*gate)}
+	if cfg.MaxInFlight > 0 {
+		l.global = newGate(cfg.MaxInFlight)
+	}
+	return l, nil
+}
+
+func newGate(limit int) *gate {
+	return &gate{slots: make(chan struct{}, limit)}
+}
+
+// Acquire takes a slot for a request to provider, waiting in the queue when
+// the caps are reached. It returns an *OverloadError when the queue is full
+// or the wait times out, and the context's error when it ends first. The
+// returned func releases the slot.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, provider string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	deadline := time.NewTimer(l.config.QueueTimeout)
+	defer deadline.Stop()
+
+	pg := l.providerGate(provider)
+	if pg != nil {
+		if err := l.enter(ctx, pg, provider, deadline.C); err != nil {
+			return nil, err
+		}
+	}
+	if l.global != nil {
+		if err := l.enter(ctx, l.global, "global", deadline.C); err != nil {
+			if pg != nil {
+				<-pg.slots
+			}
+			return nil, err
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if l.global != nil {
+				<-l.global.slots
+			}
+			if pg != nil {
+				<-pg.slots
+			}
+		})
+	}, nil
+}
+
+// enter takes a slot in g, queueing until one frees up or the deadline passes
+func (l *ConcurrencyLimiter) enter(ctx context.Context, g *gate, scope string, deadline <-chan time.Time) error {
+	select {
+	case g.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if g.queued.Add(1) > int64(l.config.QueueSize) {
+		g.queued.Add(-1)
+		g.shed.Add(1)
+		return &OverloadError{Scope: scope, RetryAfter: l.config.RetryAfter}
+	}
+	defer g.queued.Add(-1)
+
+	select {
+	case g.slots <- struct{}{}:
+		return nil
+	case <-deadline:
+		g.shed.Add(1)
+		return &OverloadError{Scope: scope, RetryAfter: l.config.RetryAfter}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// providerGate returns the gate for a provider, or nil when it is unlimited
+func (l *ConcurrencyLimiter) providerGate(provider string) *gate {
+	limit, ok := l.config.Providers[provider]
+	if !ok {
+		limit = l.config.MaxInFlightPerProvider
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	g, ok := l.providers[provider]
+	if !ok {
+		g = newGate(limit)
+		l.providers[provider] = g
+	}
+	return g
+}
+
+// Full returns the scope, a provider ID or "global", whose cap a request
+// to provider would queue behind, or "" when a slot is free
+func (l *ConcurrencyLimiter) Full(provider string) string {
+	if l == nil {
+		return ""
+	}
+	if pg := l.providerGate(provider); pg != nil && len(pg.slots) == cap(pg.slots) {
+		return provider
+	}
+	if l.global != nil && len(l.global.slots) == cap(l.global.slots) {
+		return "global"
+	}
+	return ""
+}
+
+// Stats reports the global cap first, then each provider seen so far
+func (l *ConcurrencyLimiter) Stats() []ConcurrencyStats {
+	var stats []ConcurrencyStats
+	if l.global != nil {
+		stats = append(stats, l.global.stats("global"))
+	}
+
+	l.mu.Lock()
+	providers := make([]string, 0, len(l.providers))
+	for provider := range l.providers {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	for _, provider := range providers {
+		stats = append(stats, l.providers[provider].stats(provider))
+	}
+	l.mu.Unlock()
+	return stats
+}
+
+func (g *gate) stats(scope string) ConcurrencyStats {
+	return ConcurrencyStats{
+		Scope:    scope,
+		Limit:    cap(g.slots),
+		InFlight: len(g.slots),
+		Queued:   g.queued.Load(),
+		Shed:     g.shed.Load(),
+	}
+}
+
+// SetConcurrencyLimiter caps in-flight upstream requests; requests beyond
+// the caps queue and are shed with 429 and Retry-After when the queue is full
+func (p *OpenAIProxy) SetConcurrencyLimiter(l *ConcurrencyLimiter) {
+	p.concurrency = l
+}
+
+// SetConcurrencyLimiter caps in-flight upstream requests; requests beyond
+// the caps queue and are shed with 429 and Retry-After when the queue is full
+func (p *AnthropicProxy) SetConcurrencyLimiter(l *ConcurrencyLimiter) {
+	p.concurrency = l
+}
+
+// shedStatus tags a response for a request that could not get a
+// concurrency slot or was over a model cap and returns its status.
+// Overloads and caps tell the client when to retry; a cancelled wait is
+// reported as a timeout.
+func shedStatus(w http.ResponseWriter, err error) int {
+	var retryAfter time.Duration
+	switch e := err.(type) {
+	case *OverloadError:
+		retryAfter = e.RetryAfter
+	case *ModelLimitError:
+		retryAfter = e.RetryAfter
+	default:
+		return http.StatusGatewayTimeout
+	}
+	secs := int((retryAfter + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	setErrorCode(w, err)
+	return http.StatusTooManyRequests
+}
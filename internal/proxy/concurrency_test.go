@@ -0,0 +1,153 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+)
+
+func TestConcurrencyLimiter_Queue(t *testing.T) {
+	l, err := NewConcurrencyLimiter(ConcurrencyConfig{
+		MaxInFlightPerProvider: 1,
+		QueueSize:              1,
+		QueueTimeout:           time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewConcurrencyLimiter() error = %v", err)
+	}
+	ctx := context.Background()
+
+	release, err := l.Acquire(ctx, "openai")
+	if err != nil {
+		t.Fatalf("first Acquire failed: %v", err)
+	}
+
+	// The second request queues; the third finds the queue full
+	queued := make(chan error, 1)
+	go func() {
+		release, err := l.Acquire(ctx, "openai")
+		if err == nil {
+			release()
+		}
+		queued <- err
+	}()
+	for l.Stats()[0].Queued == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	_, err = l.Acquire(ctx, "openai")
+	var oe *OverloadError
+	if !errors.As(err, &oe) || oe.Scope != "openai" || !errors.Is(err, providererr.ErrOverloaded) {
+		t.Fatalf("expected an OverloadError for openai, got %v", err)
+	}
+
+	// Other providers have their own cap
+	if release, err := l.Acquire(ctx, "anthropic"); err != nil {
+		t.Errorf("anthropic should not wait on openai: %v", err)
+	} else {
+		release()
+	}
+
+	release()
+	release() // Releasing twice is harmless
+	if err := <-queued; err != nil {
+		t.Errorf("queued request should get the freed slot: %v", err)
+	}
+
+	stats := l.Stats()
+	if len(stats) != 2 || stats[1].Scope != "openai" || stats[1].Shed != 1 || stats[1].InFlight != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestConcurrencyLimiter_GlobalAndTimeout(t *testing.T) {
+	l, _ := NewConcurrencyLimiter(ConcurrencyConfig{
+		MaxInFlight:  1,
+		Providers:    map[string]int{"groq": 5},
+		QueueSize:    10,
+		QueueTimeout: 20 * time.Millisecond,
+	})
+	ctx := context.Background()
+
+	release, err := l.Acquire(ctx, "openai")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer release()
+
+	// The global cap applies across providers, and queued requests give up
+	// at the queue timeout
+	start := time.Now()
+	_, err = l.Acquire(ctx, "groq")
+	var oe *OverloadError
+	if !errors.As(err, &oe) || oe.Scope != "global" || oe.RetryAfter != time.Second {
+		t.Fatalf("expected a global OverloadError, got %v", err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Error("expected the request to wait for the queue timeout")
+	}
+	if groq := l.Stats()[1]; groq.Scope != "groq" || groq.InFlight != 0 {
+		t.Errorf("provider slot not returned after a global timeout: %+v", groq)
+	}
+
+	// Cancelled waits report the context's error
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := l.Acquire(cctx, "openai"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	if _, err := NewConcurrencyLimiter(ConcurrencyConfig{Providers: map[string]int{"x": -1}}); err == nil {
+		t.Error("expected error for a negative cap")
+	}
+}
+
+func TestOpenAIProxy_LoadShedding(t *testing.T) {
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-unblock
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"chatcmpl-1","choices":[]}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-key"}, nil)
+	l, _ := NewConcurrencyLimiter(ConcurrencyConfig{MaxInFlightPerProvider: 1, RetryAfter: 1500 * time.Millisecond})
+	p.SetConcurrencyLimiter(l)
+
+	send := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		p.HandleChatCompletions(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+			strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)))
+		return rec
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- send() }()
+	<-started
+
+	rec := send()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 while the cap is reached, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("expected Retry-After rounded up to 2, got %q", got)
+	}
+	if got := rec.Header().Get(providererr.HeaderCode); got != "overloaded" {
+		t.Errorf("expected overloaded error code, got %q", got)
+	}
+
+	close(unblock)
+	if rec := <-first; rec.Code != http.StatusOK {
+		t.Errorf("expected the first request to succeed, got %d", rec.Code)
+	}
+}
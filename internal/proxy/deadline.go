@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeaderRequestTimeout carries how long the client will wait for a
+// response, in milliseconds ("30000") or as a duration ("30s")
+const HeaderRequestTimeout = "X-Request-Timeout"
+
+// ErrorTypeDeadlineExceeded is reported when a request's deadline budget
+// runs out before an upstream response arrives
+const ErrorTypeDeadlineExceeded = "deadline_exceeded"
+
+// RequestTimeouts provides per-client request timeouts
+type RequestTimeouts interface {
+	// RequestTimeout returns the client's configured timeout, or 0 for none
+	RequestTimeout(ctx context.Context, clientID string) time.Duration
+}
+
+// DeadlineError reports a request whose deadline budget ran out
+type DeadlineError struct {
+	Budget  time.Duration // The request's whole budget
+	Elapsed time.Duration // Time spent when it ran out
+}
+
+func (e *DeadlineError) Error() string {
+	return fmt.Sprintf("request deadline of %dms exceeded after %dms", e.Budget.Milliseconds(), e.Elapsed.Milliseconds())
+}
+
+// Unwrap lets errors.Is match context.DeadlineExceeded
+func (e *DeadlineError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// budget is the time a request may take, counted from its arrival
+type budget struct {
+	start time.Time
+	total time.Duration
+}
+
+type budgetKey struct{}
+
+// budgetFrom returns the request's deadline budget, if it has one
+func budgetFrom(ctx context.Context) (budget, bool) {
+	b, ok := ctx.Value(budgetKey{}).(budget)
+	return b, ok
+}
+
+// parseRequestTimeout reads an X-Request-Timeout value
+func parseRequestTimeout(v string) (time.Duration, error) {
+	v = strings.TrimSpace(v)
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		ms, merr := strconv.ParseInt(v, 10, 64)
+		if merr != nil {
+			return 0, fmt.Errorf("invalid %s %q (want milliseconds or a duration such as 30s)", HeaderRequestTimeout, v)
+		}
+		d = time.Duration(ms) * time.Millisecond
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q (must be positive)", HeaderRequestTimeout, v)
+	}
+	return d, nil
+}
+
+// withDeadline bounds a request by its deadline budget: the client's
+// X-Request-Timeout header, else its configured timeout, never more than
+// max. The budget runs from the request's arrival, so time spent routing,
+// metering and queueing for a concurrency slot is taken from what the
+// upstream calls, their retries and any fallback steps have left. A request
+// handed on from another handler keeps the budget it arrived with.
+func withDeadline(r *http.Request, timeouts RequestTimeouts, max time.Duration) (context.Context, context.CancelFunc, error) {
+	ctx := r.Context()
+	if b, ok := budgetFrom(ctx); ok {
+		ctx, cancel := context.WithDeadline(ctx, b.start.Add(b.total))
+		return ctx, cancel, nil
+	}
+
+	b := budget{start: time.Now(), total: max}
+	limit := func(d time.Duration) {
+		if d > 0 && (b.total <= 0 || d < b.total) {
+			b.total = d
+		}
+	}
+	if v := r.Header.Get(HeaderRequestTimeout); v != "" {
+		d, err := parseRequestTimeout(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		limit(d)
+	} else if timeouts != nil {
+		limit(timeouts.RequestTimeout(ctx, r.Header.Get("X-Client-ID")))
+	}
+	if b.total <= 0 {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, nil
+	}
+	ctx, cancel := context.WithDeadline(context.WithValue(ctx, budgetKey{}, b), b.start.Add(b.total))
+	return ctx, cancel, nil
+}
+
+// checkDeadline returns a DeadlineError when the request's budget has run
+// out, so nothing more is sent upstream on its behalf
+func checkDeadline(ctx context.Context) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil
+	}
+	return deadlineError(ctx, ctx.Err())
+}
+
+// deadlineError describes err as an exhausted budget when the request's
+// deadline caused it, and returns it unchanged otherwise
+func deadlineError(ctx context.Context, err error) error {
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	b, ok := budgetFrom(ctx)
+	if !ok {
+		return err
+	}
+	return &DeadlineError{Budget: b.total, Elapsed: time.Since(b.start)}
+}
+
+// upstreamFailure describes a failed upstream call for the client: the
+// message, error type and status to report
+func upstreamFailure(ctx context.Context, err error) (string, string, int) {
+	var de *DeadlineError
+	if errors.As(deadlineError(ctx, err), &de) {
+		return de.Error(), ErrorTypeDeadlineExceeded, http.StatusGatewayTimeout
+	}
+	return fmt.Sprintf("upstream request failed: %v", err), "server_error", http.StatusBadGateway
+}
+
+// SetRequestTimeouts enables per-client request timeouts
+func (p *OpenAIProxy) SetRequestTimeouts(timeouts RequestTimeouts) {
+	p.timeouts = timeouts
+}
+
+// SetRequestTimeouts enables per-client request timeouts
+func (p *AnthropicProxy) SetRequestTimeouts(timeouts RequestTimeouts) {
+	p.timeouts = timeouts
+}
@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixedTimeouts gives every client the same configured timeout
+type fixedTimeouts time.Duration
+
+func (f fixedTimeouts) RequestTimeout(ctx context.Context, clientID string) time.Duration {
+	if clientID == "" {
+		return 0
+	}
+	return time.Duration(f)
+}
+
+func TestParseRequestTimeout(t *testing.T) {
+	for v, want := range map[string]time.Duration{"1500": 1500 * time.Millisecond, "30s": 30 * time.Second, " 250ms ": 250 * time.Millisecond} {
+		if got, err := parseRequestTimeout(v); err != nil || got != want {
+			t.Errorf("parseRequestTimeout(%q) = %v, %v; want %v", v, got, err, want)
+		}
+	}
+	for _, v := range []string{"0", "-5s", "soon"} {
+		if _, err := parseRequestTimeout(v); err == nil {
+			t.Errorf("expected %q to be rejected", v)
+		}
+	}
+}
+
+func TestWithDeadline(t *testing.T) {
+	budgetOf := func(header, clientID string, timeouts RequestTimeouts) time.Duration {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		if header != "" {
+			r.Header.Set(HeaderRequestTimeout, header)
+		}
+		if clientID != "" {
+			r.Header.Set("X-Client-ID", clientID)
+		}
+		ctx, cancel, err := withDeadline(r, timeouts, time.Minute)
+		if err != nil {
+			t.Fatalf("withDeadline: %v", err)
+		}
+		defer cancel()
+		b, _ := budgetFrom(ctx)
+		return b.total
+	}
+
+	if got := budgetOf("", "", nil); got != time.Minute {
+		t.Errorf("expected the proxy timeout by default, got %v", got)
+	}
+	if got := budgetOf("2s", "", nil); got != 2*time.Second {
+		t.Errorf("expected the client's header, got %v", got)
+	}
+	if got := budgetOf("1h", "", nil); got != time.Minute {
+		t.Errorf("expected the proxy timeout to cap the header, got %v", got)
+	}
+	if got := budgetOf("", "client-1", fixedTimeouts(5*time.Second)); got != 5*time.Second {
+		t.Errorf("expected the client's configured timeout, got %v", got)
+	}
+	if got := budgetOf("10s", "client-1", fixedTimeouts(5*time.Second)); got != 10*time.Second {
+		t.Errorf("expected the header to win over the configured timeout, got %v", got)
+	}
+
+	// A request handed on keeps its original budget and start
+	r := httptest.NewRequest(http.MethodPost, "/v1/responses", nil)
+	r.Header.Set(HeaderRequestTimeout, "3s")
+	outer, cancel, _ := withDeadline(r, nil, time.Minute)
+	defer cancel()
+	inner := r.Clone(outer)
+	inner.Header.Set(HeaderRequestTimeout, "30s")
+	ctx, cancelInner, _ := withDeadline(inner, nil, time.Minute)
+	defer cancelInner()
+	want, _ := outer.Deadline()
+	if got, _ := ctx.Deadline(); !got.Equal(want) {
+		t.Errorf("expected the outer deadline %v, got %v", want, got)
+	}
+}
+
+func TestCheckDeadline(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	r.Header.Set(HeaderRequestTimeout, "1")
+	ctx, cancel, _ := withDeadline(r, nil, time.Minute)
+	defer cancel()
+	<-ctx.Done()
+
+	err := checkDeadline(ctx)
+	var de *DeadlineError
+	if !errors.As(err, &de) || de.Budget != time.Millisecond || de.Elapsed < time.Millisecond || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+	if msg, errType, status := upstreamFailure(ctx, context.DeadlineExceeded); status != http.StatusGatewayTimeout || errType != ErrorTypeDeadlineExceeded || !strings.Contains(msg, "deadline of 1ms") {
+		t.Errorf("unexpected upstream failure: %s %s %d", msg, errType, status)
+	}
+
+	// A cancelled request is not a deadline
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := checkDeadline(ctx); err != nil {
+		t.Errorf("expected no deadline error, got %v", err)
+	}
+}
+
+func TestOpenAIProxy_DeadlineBudget(t *testing.T) {
+	p, upstream := newFallbackProxy(t, FallbackChain{
+		Model: "gpt-4o",
+		Steps: []FallbackStep{{Provider: "openai", Model: "slow", TimeoutMs: 1000}, {Provider: "groq", Model: "llama"}},
+	})
+	body := `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`
+
+	// The budget cuts the first step short and leaves nothing for the next
+	start := time.Now()
+	w := sendChat(p, body, http.Header{HeaderRequestTimeout: {"100"}})
+	if w.Code != http.StatusGatewayTimeout || !strings.Contains(w.Body.String(), ErrorTypeDeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %d: %s", w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("the budget was not enforced (took %v)", elapsed)
+	}
+	if got := strings.Join(upstream.models(), ","); got != "slow" {
+		t.Errorf("upstream models = %s, want only the first step", got)
+	}
+
+	// A client's configured timeout applies without the header
+	p.SetRequestTimeouts(fixedTimeouts(100 * time.Millisecond))
+	if w := sendChat(p, body, http.Header{"X-Client-Id": {"client-1"}}); w.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected the configured timeout to apply, got %d", w.Code)
+	}
+
+	if w := sendChat(p, body, http.Header{HeaderRequestTimeout: {"soon"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected an invalid timeout to be rejected, got %d", w.Code)
+	}
+}
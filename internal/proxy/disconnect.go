@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// StatusClientClosedRequest is reported to completion hooks for requests
+// the client abandoned before a response could reach it (nginx's 499)
+const StatusClientClosedRequest = 499
+
+// clientConn follows the client of a proxied request
+type clientConn struct {
+	client context.Context // Cancelled when the client disconnects
+	start  time.Time
+	sent   atomic.Bool // The request reached a provider
+}
+
+type clientConnKey struct{}
+
+// watchClient notices when the client of r goes away. Upstream calls use
+// contexts derived from the request's, so they are cancelled at once; the
+// disconnect is also noted so the usage of the abandoned request is
+// still recorded and hooks can tell it from a provider failure. stop must
+// be called when the handler returns. A request handed on from another
+// handler is already watched.
+func watchClient(r *http.Request) (*http.Request, func()) {
+	if _, ok := r.Context().Value(clientConnKey{}).(*clientConn); ok {
+		return r, func() {}
+	}
+	c := &clientConn{client: r.Context(), start: time.Now()}
+	method, path := r.Method, r.URL.Path
+	stop := context.AfterFunc(r.Context(), func() {
+		if c.sent.Load() {
+			log.Printf("proxy: client disconnected from %s %s after %s, cancelling the upstream request", method, path, time.Since(c.start).Round(time.Millisecond))
+		}
+	})
+	return r.WithContext(context.WithValue(r.Context(), clientConnKey{}, c)), func() { stop() }
+}
+
+// markSent records that the request was sent to a provider
+func markSent(ctx context.Context) {
+	if c, ok := ctx.Value(clientConnKey{}).(*clientConn); ok {
+		c.sent.Store(true)
+	}
+}
+
+// clientGone reports whether the client disconnected, and whether that
+// happened after the request was sent to a provider
+func clientGone(ctx context.Context) (gone, sent bool) {
+	c, ok := ctx.Value(clientConnKey{}).(*clientConn)
+	if !ok {
+		return false, false
+	}
+	return c.client.Err() != nil, c.sent.Load()
+}
@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/tenant"
+)
+
+// hangingUpstream sends the first stream event, or nothing for plain
+// requests, then waits for the proxy to give up on it
+func hangingUpstream(cancelled chan<- struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"stream":true`) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte(`data: {"choices":[{"index":0,"delta":{"content":"Once upon"}}]}` + "\n\n"))
+			w.(http.Flusher).Flush()
+		}
+		select {
+		case <-r.Context().Done():
+			close(cancelled)
+		case <-time.After(5 * time.Second):
+		}
+	})
+}
+
+func newDisconnectProxy(t *testing.T, cancelled chan struct{}) (*httptest.Server, chan *Completion, *testTenantStore) {
+	t.Helper()
+	upstream := httptest.NewServer(hangingUpstream(cancelled))
+	t.Cleanup(upstream.Close)
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-key"}, nil)
+	hooks := NewCompletionHooks(CompletionHooksConfig{Workers: 1})
+	t.Cleanup(hooks.Close)
+	got := make(chan *Completion, 1)
+	hooks.Register(func(ctx context.Context, c *Completion) { got <- c })
+	p.SetCompletionHooks(hooks)
+	store := &testTenantStore{}
+	p.SetTenants(tenant.NewManager(store, testPricer{}, time.Hour))
+
+	server := httptest.NewServer(http.HandlerFunc(p.HandleChatCompletions))
+	t.Cleanup(server.Close)
+	return server, got, store
+}
+
+// sendAndDrop sends a chat request and disconnects once ready returns
+func sendAndDrop(t *testing.T, url, body string, ready func(*http.Response)) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(body))
+	req.Header.Set("X-Client-ID", "cli-a")
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return
+		}
+		ready(resp)
+		cancel()
+		resp.Body.Close()
+	}()
+	t.Cleanup(cancel)
+}
+
+func waitCompletion(t *testing.T, got chan *Completion, cancelled chan struct{}) *Completion {
+	t.Helper()
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the upstream request was not cancelled")
+	}
+	select {
+	case c := <-got:
+		return c
+	case <-time.After(2 * time.Second):
+		t.Fatal("no completion after the disconnect")
+	}
+	return nil
+}
+
+func TestOpenAIProxy_DisconnectStreaming(t *testing.T) {
+	cancelled := make(chan struct{})
+	server, got, store := newDisconnectProxy(t, cancelled)
+
+	sendAndDrop(t, server.URL, `{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":"tell me a story"}]}`, func(resp *http.Response) {
+		// Leave after the first event
+		r := bufio.NewReader(resp.Body)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil || strings.Contains(line, "Once upon") {
+				return
+			}
+		}
+	})
+
+	c := waitCompletion(t, got, cancelled)
+	if !c.Disconnected || c.Status != http.StatusOK || c.Content != "Once upon" || c.CompletionTokens == 0 {
+		t.Errorf("expected the partial stream in the completion, got %+v", c)
+	}
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		store.mu.Lock()
+		n := len(store.usage)
+		store.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the partial usage to be recorded")
+		}
+	}
+}
+
+func TestOpenAIProxy_DisconnectWaiting(t *testing.T) {
+	cancelled := make(chan struct{})
+	server, got, store := newDisconnectProxy(t, cancelled)
+
+	// The client gives up while the provider is still working
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`))
+	req.Header.Set("X-Client-ID", "cli-a")
+	if _, err := http.DefaultClient.Do(req); err == nil {
+		t.Fatal("expected the client request to be abandoned")
+	}
+
+	c := waitCompletion(t, got, cancelled)
+	if !c.Disconnected || c.Status != StatusClientClosedRequest {
+		t.Errorf("expected a closed request, got status %d, disconnected %t", c.Status, c.Disconnected)
+	}
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		store.mu.Lock()
+		usage := append([]tenant.Usage(nil), store.usage...)
+		store.mu.Unlock()
+		if len(usage) == 1 {
+			if usage[0].PromptTokens == 0 || usage[0].CompletionTokens != 0 {
+				t.Errorf("expected the prompt to be recorded, got %+v", usage[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the prompt usage to be recorded")
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package proxy
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jeffersonwarrior/modelscan/internal/cache"
+)
+
+// DefaultEmbeddingCacheEntries is how many vectors are kept in memory when
+// the configuration does not say
+const DefaultEmbeddingCacheEntries = 10000
+
+// EmbeddingStore persists cached vectors by key. Vectors are little-endian
+// float32s, the layout of OpenAI's base64 encoding.
+type EmbeddingStore interface {
+	// GetEmbeddings returns the stored vectors among keys
+	GetEmbeddings(keys []string) (map[string][]byte, error)
+	// PutEmbeddings stores vectors by key
+	PutEmbeddings(vectors map[string][]byte) error
+	// PurgeEmbeddings deletes every stored vector
+	PurgeEmbeddings() error
+}
+
+// EmbeddingCacheConfig configures an EmbeddingCache
+type EmbeddingCacheConfig struct {
+	// MaxEntries bounds the vectors kept in memory, least recently used
+	// first out (default 10000)
+	MaxEntries int
+}
+
+// EmbeddingCache keeps the vectors of embedded inputs keyed by a hash of
+// the provider, model, dimensions and input, so identical inputs are never
+// embedded twice. Recent vectors are kept in memory in front of an
+// optional persistent store.
+type EmbeddingCache struct {
+	config EmbeddingCacheConfig
+	store  EmbeddingStore
+
+	mu      sync.Mutex
+	lru     *list.List // Of *embeddingEntry, most recently used first
+	entries map[string]*list.Element
+
+	hits, misses, errors, purges atomic.Int64
+}
+
+type embeddingEntry struct {
+	key    string
+	vector []byte
+}
+
+// NewEmbeddingCache creates an embedding cache. store may be nil to keep
+// vectors in memory only.
+func NewEmbeddingCache(cfg EmbeddingCacheConfig, store EmbeddingStore) *EmbeddingCache {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = DefaultEmbeddingCacheEntries
+	}
+	return &EmbeddingCache{
+		config:  cfg,
+		store:   store,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// SetEmbeddingCache serves repeated /v1/embeddings inputs from the cache
+// and sends only the rest upstream
+func (p *OpenAIProxy) SetEmbeddingCache(c *EmbeddingCache) {
+	p.embeddings = c
+}
+
+// embeddingKey identifies the vector of one input: a string or a token
+// array, as raw JSON
+func embeddingKey(provider, model string, dimensions int, input json.RawMessage) string {
+	h := sha256.New()
+	h.Write([]byte(provider + "\x00" + model + "\x00" + strconv.Itoa(dimensions) + "\x00"))
+	// Strings are hashed by content so escaping does not change the key
+	var s string
+	if err := json.Unmarshal(input, &s); err == nil {
+		h.Write([]byte("s\x00" + s))
+	} else {
+		h.Write([]byte("t\x00"))
+		h.Write(compactJSON(input))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Persistent reports whether vectors are stored beyond memory
+func (c *EmbeddingCache) Persistent() bool {
+	return c.store != nil
+}
+
+// MaxEntries returns how many vectors are kept in memory
+func (c *EmbeddingCache) MaxEntries() int {
+	return c.config.MaxEntries
+}
+
+// Lookup returns the cached vectors among keys, counting a hit or miss for
+// each. Vectors only found in the store are kept in memory again.
+func (c *EmbeddingCache) Lookup(keys []string) map[string][]byte {
+	found := make(map[string][]byte, len(keys))
+	var missing []string
+	c.mu.Lock()
+	for _, key := range keys {
+		if el, ok := c.entries[key]; ok {
+			c.lru.MoveToFront(el)
+			found[key] = el.Value.(*embeddingEntry).vector
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(missing) > 0 && c.store != nil {
+		stored, err := c.store.GetEmbeddings(missing)
+		if err != nil {
+			// A broken store only costs the hits it would have served
+			c.errors.Add(1)
+			log.Printf("proxy: failed to read cached embeddings: %v", err)
+		}
+		c.remember(stored)
+		for key, vector := range stored {
+			found[key] = vector
+		}
+	}
+	c.hits.Add(int64(len(found)))
+	c.misses.Add(int64(len(keys) - len(found)))
+	return found
+}
+
+// Store caches new vectors in memory and the store
+func (c *EmbeddingCache) Store(vectors map[string][]byte) {
+	if len(vectors) == 0 {
+		return
+	}
+	c.remember(vectors)
+	if c.store != nil {
+		if err := c.store.PutEmbeddings(vectors); err != nil {
+			c.errors.Add(1)
+			log.Printf("proxy: failed to store embeddings: %v", err)
+		}
+	}
+}
+
+// remember keeps vectors in memory, evicting the least recently used
+func (c *EmbeddingCache) remember(vectors map[string][]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, vector := range vectors {
+		if el, ok := c.entries[key]; ok {
+			c.lru.MoveToFront(el)
+			continue
+		}
+		c.entries[key] = c.lru.PushFront(&embeddingEntry{key: key, vector: vector})
+		if c.lru.Len() > c.config.MaxEntries {
+			oldest := c.lru.Back()
+			c.lru.Remove(oldest)
+			delete(c.entries, oldest.Value.(*embeddingEntry).key)
+		}
+	}
+}
+
+// Purge drops every cached vector, in memory and in the store
+func (c *EmbeddingCache) Purge() error {
+	c.mu.Lock()
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+	c.mu.Unlock()
+	c.purges.Add(1)
+	if c.store != nil {
+		return c.store.PurgeEmbeddings()
+	}
+	return nil
+}
+
+// Stats returns the cache's metrics. Hits and misses count inputs, not
+// requests; entries are those in memory.
+func (c *EmbeddingCache) Stats() cache.Stats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+	s := cache.Stats{
+		Name:          "embeddings",
+		Entries:       entries,
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Errors:        c.errors.Load(),
+		Invalidations: c.purges.Load(),
+	}
+	if total := s.Hits + s.Misses; total > 0 {
+		s.HitRate = float64(s.Hits) / float64(total)
+	}
+	return s
+}
@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// fakeEmbeddingStore is an in-memory EmbeddingStore
+type fakeEmbeddingStore struct {
+	vectors map[string][]byte
+	err     error
+	reads   int
+}
+
+func newFakeEmbeddingStore() *fakeEmbeddingStore {
+	return &fakeEmbeddingStore{vectors: make(map[string][]byte)}
+}
+
+func (s *fakeEmbeddingStore) GetEmbeddings(keys []string) (map[string][]byte, error) {
+	s.reads++
+	if s.err != nil {
+		return nil, s.err
+	}
+	found := make(map[string][]byte)
+	for _, key := range keys {
+		if v, ok := s.vectors[key]; ok {
+			found[key] = v
+		}
+	}
+	return found, nil
+}
+
+func (s *fakeEmbeddingStore) PutEmbeddings(vectors map[string][]byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	for key, v := range vectors {
+		s.vectors[key] = v
+	}
+	return nil
+}
+
+func (s *fakeEmbeddingStore) PurgeEmbeddings() error {
+	s.vectors = make(map[string][]byte)
+	return s.err
+}
+
+func TestEmbeddingCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewEmbeddingCache(EmbeddingCacheConfig{MaxEntries: 2}, nil)
+	c.Store(map[string][]byte{"a": {1}})
+	c.Store(map[string][]byte{"b": {2}})
+	c.Lookup([]string{"a"}) // b is now the least recently used
+	c.Store(map[string][]byte{"c": {3}})
+
+	found := c.Lookup([]string{"a", "b", "c"})
+	if len(found) != 2 || found["b"] != nil {
+		t.Errorf("expected b evicted, got %v", found)
+	}
+	s := c.Stats()
+	if s.Name != "embeddings" || s.Entries != 2 || s.Hits != 3 || s.Misses != 1 || s.HitRate != 0.75 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+	if c.Persistent() || c.MaxEntries() != 2 {
+		t.Error("expected a memory-only cache of 2 entries")
+	}
+}
+
+func TestEmbeddingCache_Store(t *testing.T) {
+	store := newFakeEmbeddingStore()
+	c := NewEmbeddingCache(EmbeddingCacheConfig{}, store)
+	c.Store(map[string][]byte{"a": {1}})
+	if store.vectors["a"] == nil || c.MaxEntries() != DefaultEmbeddingCacheEntries {
+		t.Fatalf("expected the vector stored, got %v", store.vectors)
+	}
+
+	// A new cache over the same store serves it, and keeps it in memory
+	c = NewEmbeddingCache(EmbeddingCacheConfig{}, store)
+	if found := c.Lookup([]string{"a"}); found["a"] == nil {
+		t.Fatal("expected the stored vector served")
+	}
+	reads := store.reads
+	c.Lookup([]string{"a"})
+	if store.reads != reads {
+		t.Error("expected the second lookup served from memory")
+	}
+
+	// A failing store costs hits, not requests
+	store.err = errors.New("database is locked")
+	if found := c.Lookup([]string{"a", "b"}); len(found) != 1 {
+		t.Errorf("expected the memory hit despite the store, got %v", found)
+	}
+	c.Store(map[string][]byte{"c": {3}})
+	if s := c.Stats(); s.Errors != 2 {
+		t.Errorf("expected 2 store errors, got %+v", s)
+	}
+
+	store.err = nil
+	if err := c.Purge(); err != nil {
+		t.Fatal(err)
+	}
+	if found := c.Lookup([]string{"a", "c"}); len(found) != 0 || len(store.vectors) != 0 {
+		t.Errorf("expected everything purged, got %v", found)
+	}
+	if s := c.Stats(); s.Invalidations != 1 || s.Entries != 0 {
+		t.Errorf("unexpected stats after purge: %+v", s)
+	}
+}
+
+func TestEmbeddingKey(t *testing.T) {
+	a := embeddingKey("openai", "text-embedding-3-small", 0, json.RawMessage(`"café"`))
+	if b := embeddingKey("openai", "text-embedding-3-small", 0, json.RawMessage(`"caf\u00e9"`)); a != b {
+		t.Error("expected escaping not to change the key")
+	}
+	for _, other := range []string{
+		embeddingKey("openai", "text-embedding-3-large", 0, json.RawMessage(`"café"`)),
+		embeddingKey("mistral", "text-embedding-3-small", 0, json.RawMessage(`"café"`)),
+		embeddingKey("openai", "text-embedding-3-small", 256, json.RawMessage(`"café"`)),
+	} {
+		if other == a {
+			t.Error("expected the provider, model and dimensions in the key")
+		}
+	}
+	if embeddingKey("openai", "m", 0, json.RawMessage(`[1, 2]`)) != embeddingKey("openai", "m", 0, json.RawMessage(`[1,2]`)) {
+		t.Error("expected token arrays keyed without whitespace")
+	}
+}
@@ -0,0 +1,463 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+)
+
+// HeaderEmbeddingCache reports how much of an embeddings request the cache
+// served: "hit" for all of it, "partial" or "miss"
+const HeaderEmbeddingCache = "X-Modelscan-Embedding-Cache"
+
+// EmbeddingRequest is the part of an OpenAI embeddings request the proxy
+// reads; other fields are passed upstream unchanged
+type EmbeddingRequest struct {
+	Model          string          `json:"model"`
+	Input          json.RawMessage `json:"input"` // A string, token array, or array of either
+	EncodingFormat string          `json:"encoding_format,omitempty"`
+	Dimensions     int             `json:"dimensions,omitempty"`
+}
+
+// EmbeddingResponse is an OpenAI embeddings response
+type EmbeddingResponse struct {
+	Object string          `json:"object"`
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  EmbeddingUsage  `json:"usage"`
+}
+
+// EmbeddingData is the vector of one input, as a float array or base64
+type EmbeddingData struct {
+	Object    string          `json:"object"`
+	Index     int             `json:"index"`
+	Embedding json.RawMessage `json:"embedding"`
+}
+
+// EmbeddingUsage is the usage of an embeddings request
+type EmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// HandleEmbeddings handles POST /v1/embeddings requests. With a cache set,
+// each distinct input is looked up by content hash and only the misses are
+// sent upstream, in one request; the response is assembled in input order.
+func (p *OpenAIProxy) HandleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Cancel upstream work as soon as the client goes away
+	r, stopWatching := watchClient(r)
+	defer stopWatching()
+
+	ctx, cancel, err := withDeadline(r, p.timeouts, p.config.Timeout)
+	if err != nil {
+		p.writeError(w, err.Error(), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+
+	body, err := readBody(r.Body)
+	if err != nil {
+		p.writeError(w, "failed to read request body", "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+	defer putBuffer(body)
+
+	var fields map[string]json.RawMessage
+	var req EmbeddingRequest
+	if err := json.Unmarshal(body.Bytes(), &fields); err == nil {
+		err = json.Unmarshal(body.Bytes(), &req)
+	}
+	if err != nil {
+		p.writeError(w, fmt.Sprintf("invalid request body: %v", err), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	if req.Model == "" {
+		p.writeError(w, "model is required", "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	inputs, single, err := splitEmbeddingInput(req.Input)
+	if err != nil {
+		p.writeError(w, err.Error(), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+
+	// Route the model as chat requests are
+	clientID := r.Header.Get("X-Client-ID")
+	targetProvider := "openai"
+	if p.remapper != nil {
+		remapped, provider, err := p.remapper.RemapModel(ctx, req.Model, clientID)
+		if err != nil {
+			log.Printf("proxy: remap error for model %s: %v", req.Model, err)
+		} else if remapped != "" && (remapped != req.Model || provider != "") {
+			req.Model = remapped
+			if provider != "" {
+				targetProvider = provider
+			}
+		}
+	}
+	if provider := r.Header.Get(HeaderProvider); provider != "" {
+		targetProvider = provider
+	}
+	upstreamURL := p.getEmbeddingsURL(targetProvider)
+	if upstreamURL == "" {
+		p.writeError(w, fmt.Sprintf("provider %s does not serve embeddings", targetProvider), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	region, err := p.residency.Required(clientID, r.Header.Get(HeaderResidency))
+	if err != nil {
+		p.writeError(w, err.Error(), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	if region != "" && !p.residency.Allows(targetProvider, region) {
+		rerr := &ResidencyError{Region: region, Provider: targetProvider, Model: req.Model}
+		p.writeError(w, rerr.Error(), "permission_error", http.StatusForbidden)
+		return
+	}
+
+	// Find the distinct inputs the cache lacks; those alone go upstream
+	keys := make([]string, len(inputs))
+	pending := make([]int, 0, len(inputs)) // First position of each input to embed
+	vectors := make(map[string][]byte)
+	if p.embeddings != nil {
+		var distinct []string
+		first := make(map[string]int, len(inputs))
+		for i, in := range inputs {
+			keys[i] = embeddingKey(targetProvider, req.Model, req.Dimensions, in)
+			if _, ok := first[keys[i]]; !ok {
+				first[keys[i]] = i
+				distinct = append(distinct, keys[i])
+			}
+		}
+		vectors = p.embeddings.Lookup(distinct)
+		for _, key := range distinct {
+			if _, ok := vectors[key]; !ok {
+				pending = append(pending, first[key])
+			}
+		}
+		switch {
+		case len(pending) == 0:
+			w.Header().Set(HeaderEmbeddingCache, "hit")
+		case len(pending) < len(distinct):
+			w.Header().Set(HeaderEmbeddingCache, "partial")
+		default:
+			w.Header().Set(HeaderEmbeddingCache, "miss")
+		}
+	} else {
+		for i := range inputs {
+			pending = append(pending, i)
+		}
+	}
+
+	if len(pending) > 0 {
+		if merr := checkAvailable(p.availability, targetProvider); merr != nil {
+			p.writeError(w, merr.Error(), "server_error", maintenanceStatus(w, merr))
+			return
+		}
+	}
+
+	// Tenants are charged for what is embedded, not what the cache serves
+	var promptTokens int
+	for _, i := range pending {
+		promptTokens += embeddingInputTokens(inputs[i])
+	}
+	ctx, w, finishTenant, err := meterTenant(ctx, p.tenants, w, clientID, promptTokens)
+	if err != nil {
+		status, errType := tenantErrorStatus(err)
+		p.writeError(w, err.Error(), errType, status)
+		return
+	}
+	defer finishTenant()
+
+	w.Header().Set(HeaderProvider, targetProvider)
+	w.Header().Set(HeaderModel, req.Model)
+	if len(pending) == 0 {
+		p.writeEmbeddings(w, &EmbeddingResponse{Model: req.Model}, keys, vectors, req.EncodingFormat)
+		return
+	}
+
+	apiKey, err := p.keyProvider.GetKey(ctx, targetProvider)
+	if err != nil {
+		p.writeError(w, fmt.Sprintf("no API key available for provider %s", targetProvider), "server_error", http.StatusServiceUnavailable)
+		return
+	}
+	setAttribution(w, targetProvider, req.Model, apiKey)
+	ctx = withUpstreamKey(mshttp.WithProvider(ctx, targetProvider), apiKey, w)
+	if key := r.Header.Get(mshttp.DefaultIdempotencyHeader); key != "" {
+		ctx = mshttp.WithIdempotencyKey(ctx, key)
+	}
+
+	fields["model"], _ = json.Marshal(req.Model)
+	if p.embeddings != nil && !single {
+		batch := make([]json.RawMessage, len(pending))
+		for j, i := range pending {
+			batch[j] = inputs[i]
+		}
+		fields["input"], _ = json.Marshal(batch)
+	}
+	reqBody, err := marshalJSON(fields)
+	if err != nil {
+		p.writeError(w, "failed to marshal request", "server_error", http.StatusInternalServerError)
+		return
+	}
+	defer reqBody.release()
+
+	respBody, header, ok := p.sendEmbeddings(ctx, w, upstreamURL, reqBody.Bytes(), apiKey, targetProvider, req.Model, promptTokens)
+	if !ok {
+		return
+	}
+	defer putBuffer(respBody)
+
+	// Without a cache the response is relayed as the provider sent it
+	if p.embeddings == nil {
+		for key, values := range header {
+			for _, value := range values {
+				w.Header().Add(key, value)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(respBody.Bytes()); err != nil {
+			log.Printf("proxy: error writing response body: %v", err)
+		}
+		return
+	}
+
+	var upstream EmbeddingResponse
+	if err := json.Unmarshal(respBody.Bytes(), &upstream); err != nil {
+		p.writeError(w, fmt.Sprintf("invalid upstream response: %v", err), "server_error", http.StatusBadGateway)
+		return
+	}
+	embedded := make(map[string][]byte, len(pending))
+	for _, d := range upstream.Data {
+		if d.Index < 0 || d.Index >= len(pending) {
+			continue
+		}
+		vector, err := decodeEmbedding(d.Embedding)
+		if err != nil {
+			p.writeError(w, fmt.Sprintf("invalid upstream embedding: %v", err), "server_error", http.StatusBadGateway)
+			return
+		}
+		embedded[keys[pending[d.Index]]] = vector
+	}
+	if len(embedded) != len(pending) {
+		p.writeError(w, fmt.Sprintf("upstream returned %d embeddings for %d inputs", len(embedded), len(pending)), "server_error", http.StatusBadGateway)
+		return
+	}
+	p.embeddings.Store(embedded)
+	for key, vector := range embedded {
+		vectors[key] = vector
+	}
+	if upstream.Model == "" {
+		upstream.Model = req.Model
+	}
+	p.writeEmbeddings(w, &upstream, keys, vectors, req.EncodingFormat)
+}
+
+// sendEmbeddings posts an embeddings request upstream once the model's
+// caps admit it and a concurrency slot is free, returning the body of a
+// successful response. Failures are written to w.
+func (p *OpenAIProxy) sendEmbeddings(ctx context.Context, w http.ResponseWriter, url string, body []byte, apiKey, provider, model string, tokens int) (*bytes.Buffer, http.Header, bool) {
+	releaseModel, err := p.modelLimits.Acquire(ctx, model, tokens)
+	if err == nil {
+		defer releaseModel()
+		var release func()
+		if release, err = p.concurrency.Acquire(ctx, provider); err == nil {
+			defer release()
+		}
+	}
+	if err != nil {
+		if err = deadlineError(ctx, err); errors.Is(err, context.DeadlineExceeded) {
+			p.writeError(w, err.Error(), ErrorTypeDeadlineExceeded, http.StatusGatewayTimeout)
+			return nil, nil, false
+		}
+		status := shedStatus(w, err)
+		p.writeError(w, err.Error(), "rate_limit_exceeded", status)
+		return nil, nil, false
+	}
+	if err := checkDeadline(ctx); err != nil {
+		p.writeError(w, err.Error(), ErrorTypeDeadlineExceeded, http.StatusGatewayTimeout)
+		return nil, nil, false
+	}
+
+	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		p.writeError(w, "failed to create upstream request", "server_error", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	p.setUpstreamHeaders(upstreamReq, apiKey, provider)
+	resp, err := p.httpClient.Do(upstreamReq)
+	if err != nil {
+		message, errType, status := upstreamFailure(ctx, err)
+		p.writeError(w, message, errType, status)
+		return nil, nil, false
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := readBody(resp.Body)
+	if err != nil {
+		message, errType, status := upstreamReadError(err)
+		p.writeError(w, message, errType, status)
+		return nil, nil, false
+	}
+	if resp.StatusCode >= 400 {
+		defer putBuffer(respBody)
+		setErrorCode(w, providererr.Classify(provider, resp.StatusCode, resp.Header, respBody.Bytes()))
+		for key, values := range resp.Header {
+			for _, value := range values {
+				w.Header().Add(key, value)
+			}
+		}
+		w.WriteHeader(resp.StatusCode)
+		if _, err := w.Write(respBody.Bytes()); err != nil {
+			log.Printf("proxy: error writing response body: %v", err)
+		}
+		return nil, nil, false
+	}
+	return respBody, resp.Header, true
+}
+
+// writeEmbeddings writes the vector of every input, in input order, in the
+// encoding the client asked for
+func (p *OpenAIProxy) writeEmbeddings(w http.ResponseWriter, resp *EmbeddingResponse, keys []string, vectors map[string][]byte, format string) {
+	resp.Object = "list"
+	resp.Data = make([]EmbeddingData, len(keys))
+	for i, key := range keys {
+		resp.Data[i] = EmbeddingData{Object: "embedding", Index: i, Embedding: encodeEmbedding(vectors[key], format)}
+	}
+	out, err := marshalJSON(resp)
+	if err != nil {
+		p.writeError(w, "failed to marshal response", "server_error", http.StatusInternalServerError)
+		return
+	}
+	defer out.release()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(out.Bytes()); err != nil {
+		log.Printf("proxy: error writing response body: %v", err)
+	}
+}
+
+// getEmbeddingsURL returns the embeddings endpoint of a provider, or ""
+// for providers without an OpenAI-compatible one
+func (p *OpenAIProxy) getEmbeddingsURL(provider string) string {
+	switch provider {
+	case "openai":
+		return p.config.OpenAIBaseURL + "/v1/embeddings"
+	case "together":
+		return "https://api.together.xyz/v1/embeddings"
+	case "fireworks":
+		return "https://api.fireworks.ai/inference/v1/embeddings"
+	case "deepinfra":
+		return "https://api.deepinfra.com/v1/openai/embeddings"
+	case "mistral":
+		return "https://api.mistral.ai/v1/embeddings"
+	}
+	return ""
+}
+
+// splitEmbeddingInput returns the inputs of a request: one for a string or
+// token array, one per element of an array of either. single reports that
+// the request held one input rather than an array of them.
+func splitEmbeddingInput(raw json.RawMessage) (inputs []json.RawMessage, single bool, err error) {
+	raw = bytes.TrimSpace(raw)
+	invalid := errors.New("input must be a string, a token array or an array of either")
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, false, errors.New("input is required")
+	}
+	switch raw[0] {
+	case '"':
+		return []json.RawMessage{raw}, true, nil
+	case '[':
+	default:
+		return nil, false, invalid
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, false, invalid
+	}
+	if len(items) == 0 {
+		return nil, false, errors.New("input must not be empty")
+	}
+	if first := bytes.TrimSpace(items[0]); len(first) > 0 && (first[0] == '-' || first[0] >= '0' && first[0] <= '9') {
+		return []json.RawMessage{raw}, true, nil
+	}
+	for i, item := range items {
+		item = bytes.TrimSpace(item)
+		if len(item) == 0 || item[0] != '"' && item[0] != '[' {
+			return nil, false, invalid
+		}
+		items[i] = item
+	}
+	return items, false, nil
+}
+
+// embeddingInputTokens estimates the tokens of one input
+func embeddingInputTokens(input json.RawMessage) int {
+	var s string
+	if err := json.Unmarshal(input, &s); err == nil {
+		return CountTokens(s)
+	}
+	var tokens []json.RawMessage
+	_ = json.Unmarshal(input, &tokens)
+	return len(tokens)
+}
+
+// compactJSON returns raw without insignificant whitespace
+func compactJSON(raw json.RawMessage) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return raw
+	}
+	return buf.Bytes()
+}
+
+// decodeEmbedding reads a vector sent as a float array or base64 into
+// little-endian float32s
+func decodeEmbedding(raw json.RawMessage) ([]byte, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return base64.StdEncoding.DecodeString(s)
+	}
+	var floats []float32
+	if err := json.Unmarshal(raw, &floats); err != nil {
+		return nil, err
+	}
+	vector := make([]byte, 4*len(floats))
+	for i, f := range floats {
+		binary.LittleEndian.PutUint32(vector[4*i:], math.Float32bits(f))
+	}
+	return vector, nil
+}
+
+// encodeEmbedding writes a vector as base64 when the client asked for it,
+// and as a float array otherwise
+func encodeEmbedding(vector []byte, format string) json.RawMessage {
+	if format == "base64" {
+		return json.RawMessage(strconv.Quote(base64.StdEncoding.EncodeToString(vector)))
+	}
+	out := make([]byte, 0, 2+12*len(vector)/4)
+	out = append(out, '[')
+	for i := 0; i+4 <= len(vector); i += 4 {
+		if i > 0 {
+			out = append(out, ',')
+		}
+		out = strconv.AppendFloat(out, float64(math.Float32frombits(binary.LittleEndian.Uint32(vector[i:]))), 'g', -1, 32)
+	}
+	return append(out, ']')
+}
@@ -0,0 +1,236 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// embeddingUpstream serves embeddings whose first value is the input's
+// length, recording the inputs of each request
+type embeddingUpstream struct {
+	*httptest.Server
+	inputs [][]string
+}
+
+func newEmbeddingUpstream(t *testing.T) *embeddingUpstream {
+	u := &embeddingUpstream{}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req struct {
+			Model string          `json:"model"`
+			Input json.RawMessage `json:"input"`
+			User  string          `json:"user"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.User != "u-1" {
+			t.Errorf("expected other fields passed through, got %+v", req)
+		}
+		var inputs []string
+		if json.Unmarshal(req.Input, &inputs) != nil {
+			var s string
+			json.Unmarshal(req.Input, &s)
+			inputs = []string{s}
+		}
+		u.inputs = append(u.inputs, inputs)
+		if strings.Contains(string(req.Input), "fail") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"bad input","type":"invalid_request_error"}}`))
+			return
+		}
+		resp := EmbeddingResponse{Object: "list", Model: req.Model + "-v2", Usage: EmbeddingUsage{PromptTokens: len(inputs), TotalTokens: len(inputs)}}
+		for i, in := range inputs {
+			resp.Data = append(resp.Data, EmbeddingData{Object: "embedding", Index: i, Embedding: json.RawMessage(`[` + strings.Repeat("1", len(in)) + `,0.5,-0.25]`)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func newEmbeddingProxy(upstream *embeddingUpstream, c *EmbeddingCache) *OpenAIProxy {
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-key"}, nil)
+	if c != nil {
+		p.SetEmbeddingCache(c)
+	}
+	return p
+}
+
+func embed(p *OpenAIProxy, body string, header ...string) (*httptest.ResponseRecorder, *EmbeddingResponse) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/embeddings", strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	p.HandleEmbeddings(w, req)
+	var resp EmbeddingResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, &resp
+}
+
+func firstValue(t *testing.T, d EmbeddingData) float64 {
+	t.Helper()
+	var v []float64
+	if err := json.Unmarshal(d.Embedding, &v); err != nil || len(v) != 3 {
+		t.Fatalf("unexpected embedding %s: %v", d.Embedding, err)
+	}
+	return v[0]
+}
+
+func TestHandleEmbeddings_Cache(t *testing.T) {
+	upstream := newEmbeddingUpstream(t)
+	c := NewEmbeddingCache(EmbeddingCacheConfig{}, nil)
+	p := newEmbeddingProxy(upstream, c)
+
+	// Repeated inputs are embedded once
+	w, resp := embed(p, `{"model":"text-embedding-3-small","input":["a","bb","a"],"user":"u-1"}`)
+	if w.Code != http.StatusOK || w.Header().Get(HeaderEmbeddingCache) != "miss" {
+		t.Fatalf("unexpected response %d %q: %s", w.Code, w.Header().Get(HeaderEmbeddingCache), w.Body.String())
+	}
+	if len(upstream.inputs) != 1 || strings.Join(upstream.inputs[0], ",") != "a,bb" {
+		t.Errorf("expected the distinct inputs sent, got %v", upstream.inputs)
+	}
+	if len(resp.Data) != 3 || firstValue(t, resp.Data[0]) != 1 || firstValue(t, resp.Data[1]) != 11 || firstValue(t, resp.Data[2]) != 1 || resp.Data[2].Index != 2 {
+		t.Errorf("expected a vector per input in order, got %+v", resp.Data)
+	}
+	if resp.Model != "text-embedding-3-small-v2" || resp.Usage.PromptTokens != 2 || resp.Object != "list" {
+		t.Errorf("expected the upstream model and usage, got %+v", resp)
+	}
+
+	// Only misses go upstream, and the response keeps input order
+	w, resp = embed(p, `{"model":"text-embedding-3-small","input":["ccc","bb"],"user":"u-1"}`)
+	if w.Header().Get(HeaderEmbeddingCache) != "partial" || len(upstream.inputs) != 2 || strings.Join(upstream.inputs[1], ",") != "ccc" {
+		t.Errorf("expected only ccc sent, got %q and %v", w.Header().Get(HeaderEmbeddingCache), upstream.inputs)
+	}
+	if firstValue(t, resp.Data[0]) != 111 || firstValue(t, resp.Data[1]) != 11 || resp.Usage.PromptTokens != 1 {
+		t.Errorf("unexpected partial response: %+v", resp)
+	}
+
+	// A full hit needs no upstream, and is served in the encoding asked for
+	w, resp = embed(p, `{"model":"text-embedding-3-small","input":"bb","encoding_format":"base64","user":"u-1"}`)
+	if w.Code != http.StatusOK || w.Header().Get(HeaderEmbeddingCache) != "hit" || len(upstream.inputs) != 2 {
+		t.Fatalf("expected a cache hit, got %d %q", w.Code, w.Header().Get(HeaderEmbeddingCache))
+	}
+	var s string
+	json.Unmarshal(resp.Data[0].Embedding, &s)
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(raw) != 12 || math.Float32frombits(binary.LittleEndian.Uint32(raw[4:])) != 0.5 {
+		t.Errorf("unexpected base64 embedding %q: %v", s, err)
+	}
+	if resp.Model != "text-embedding-3-small" || resp.Usage.PromptTokens != 0 || w.Header().Get(HeaderModel) != "text-embedding-3-small" {
+		t.Errorf("unexpected hit response: %+v", resp)
+	}
+
+	// Other models and dimensions are embedded apart
+	embed(p, `{"model":"text-embedding-3-small","input":"bb","dimensions":256,"user":"u-1"}`)
+	if len(upstream.inputs) != 3 {
+		t.Errorf("expected other dimensions to miss, got %v", upstream.inputs)
+	}
+
+	if st := c.Stats(); st.Hits != 2 || st.Misses != 4 || st.Entries != 4 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
+
+func TestHandleEmbeddings_Persistent(t *testing.T) {
+	upstream := newEmbeddingUpstream(t)
+	store := newFakeEmbeddingStore()
+	embed(newEmbeddingProxy(upstream, NewEmbeddingCache(EmbeddingCacheConfig{}, store)), `{"model":"m","input":["a","bb"],"user":"u-1"}`)
+
+	// A restarted proxy finds the vectors in the store
+	w, resp := embed(newEmbeddingProxy(upstream, NewEmbeddingCache(EmbeddingCacheConfig{}, store)), `{"model":"m","input":["bb","a"],"user":"u-1"}`)
+	if w.Header().Get(HeaderEmbeddingCache) != "hit" || len(upstream.inputs) != 1 || firstValue(t, resp.Data[0]) != 11 {
+		t.Errorf("expected the stored vectors served, got %q: %s", w.Header().Get(HeaderEmbeddingCache), w.Body.String())
+	}
+}
+
+func TestHandleEmbeddings_WithoutCache(t *testing.T) {
+	upstream := newEmbeddingUpstream(t)
+	p := newEmbeddingProxy(upstream, nil)
+	for i := 0; i < 2; i++ {
+		w, resp := embed(p, `{"model":"m","input":["a","a"],"user":"u-1"}`)
+		if w.Code != http.StatusOK || len(resp.Data) != 2 || w.Header().Get(HeaderEmbeddingCache) != "" {
+			t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if len(upstream.inputs) != 2 || len(upstream.inputs[1]) != 2 {
+		t.Errorf("expected requests passed through whole, got %v", upstream.inputs)
+	}
+}
+
+func TestHandleEmbeddings_Errors(t *testing.T) {
+	upstream := newEmbeddingUpstream(t)
+	c := NewEmbeddingCache(EmbeddingCacheConfig{}, nil)
+	p := newEmbeddingProxy(upstream, c)
+
+	for name, body := range map[string]string{
+		"invalid json":   `{"model":`,
+		"no model":       `{"input":"a"}`,
+		"no input":       `{"model":"m"}`,
+		"empty input":    `{"model":"m","input":[]}`,
+		"object input":   `{"model":"m","input":{"text":"a"}}`,
+		"mixed elements": `{"model":"m","input":["a",{"text":"b"}]}`,
+	} {
+		if w, _ := embed(p, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, w.Code)
+		}
+	}
+	if w, _ := embed(p, `{"model":"m","input":"a"}`, HeaderProvider, "anthropic"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected a provider without embeddings refused, got %d", w.Code)
+	}
+
+	// Upstream errors reach the client and nothing is cached
+	w, _ := embed(p, `{"model":"m","input":["ok","fail"],"user":"u-1"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "bad input") {
+		t.Errorf("expected the upstream error relayed, got %d: %s", w.Code, w.Body.String())
+	}
+	if st := c.Stats(); st.Entries != 0 {
+		t.Errorf("expected nothing cached, got %+v", st)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/embeddings", nil)
+	rec := httptest.NewRecorder()
+	p.HandleEmbeddings(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestSplitEmbeddingInput(t *testing.T) {
+	for input, want := range map[string]int{
+		`"a"`:              1,
+		`[1, 2, 3]`:        1,
+		`["a", "b"]`:       2,
+		`[[1, 2], [3, 4]]`: 2,
+	} {
+		inputs, single, err := splitEmbeddingInput(json.RawMessage(input))
+		if err != nil || len(inputs) != want || single != (want == 1) {
+			t.Errorf("%s: got %d inputs (single %v): %v", input, len(inputs), single, err)
+		}
+	}
+}
+
+func TestEncodeEmbeddingRoundTrip(t *testing.T) {
+	vector, err := decodeEmbedding(json.RawMessage(`[0.1, -2.5e-7, 3]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := decodeEmbedding(encodeEmbedding(vector, "float"))
+	if err != nil || string(again) != string(vector) {
+		t.Errorf("expected floats to round-trip, got %s", encodeEmbedding(vector, "float"))
+	}
+	again, err = decodeEmbedding(encodeEmbedding(vector, "base64"))
+	if err != nil || string(again) != string(vector) {
+		t.Errorf("expected base64 to round-trip: %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+)
+
+// setErrorCode tags an error response with the providererr code for err, so
+// clients can branch on the failure without parsing provider bodies
+func setErrorCode(w http.ResponseWriter, err error) {
+	if code := providererr.Code(err); code != "" {
+		w.Header().Set(providererr.HeaderCode, code)
+	}
+}
+
+// classifiedError keeps an error's message while making its providererr
+// kind visible to errors.Is
+type classifiedError struct {
+	error
+	kind error
+}
+
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.error, e.kind}
+}
+
+// withKind attaches the kind of a classified upstream failure to err
+func withKind(err error, apiErr *providererr.Error) error {
+	if apiErr.Kind == nil {
+		return err
+	}
+	return &classifiedError{error: err, kind: apiErr.Kind}
+}
@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+)
+
+func TestOpenAIProxy_UpstreamErrorCode(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	proxy := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-api-key"}, nil)
+
+	body := `{"model": "gpt-4", "messages": [{"role": "user", "content": "hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	proxy.HandleChatCompletions(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status 429, got %d", w.Code)
+	}
+	if code := w.Header().Get(providererr.HeaderCode); code != "rate_limited" {
+		t.Errorf("expected error code rate_limited, got %q", code)
+	}
+
+	// A client classifying the proxy's response gets the same kind
+	e := providererr.Classify("proxy", w.Code, w.Header(), w.Body.Bytes())
+	if !errors.Is(e, providererr.ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", e)
+	}
+}
+
+func TestOpenAIProxy_StreamingUpstreamErrorCode(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"maximum context length is 8192 tokens","code":"context_length_exceeded"}}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	proxy := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-api-key"}, nil)
+
+	body := `{"model": "gpt-4", "stream": true, "messages": [{"role": "user", "content": "hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	proxy.HandleChatCompletions(w, req)
+
+	if !strings.Contains(w.Body.String(), `"code":"context_too_long"`) {
+		t.Errorf("expected context_too_long code in stream error, got %s", w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "upstream error (status 400)") {
+		t.Errorf("expected upstream status in stream error, got %s", w.Body.String())
+	}
+	if code := w.Header().Get(providererr.HeaderCode); code != "context_too_long" {
+		t.Errorf("expected error code header context_too_long, got %q", code)
+	}
+}
+
+func TestStreamWriter_WriteErrorUnclassified(t *testing.T) {
+	w := httptest.NewRecorder()
+	sw, err := NewStreamWriter(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sw.WriteError(errors.New("boom"))
+	if strings.Contains(w.Body.String(), `"code"`) {
+		t.Errorf("unexpected code for unclassified error: %s", w.Body.String())
+	}
+	if w.Header().Get(providererr.HeaderCode) != "" {
+		t.Error("unexpected error code header")
+	}
+}
+
+func TestContextOverflowError_Kind(t *testing.T) {
+	var err error = &ContextOverflowError{}
+	if !errors.Is(err, providererr.ErrContextTooLong) {
+		t.Error("expected ContextOverflowError to be ErrContextTooLong")
+	}
+	if providererr.Code(err) != "context_too_long" {
+		t.Errorf("unexpected code %q", providererr.Code(err))
+	}
+}
@@ -0,0 +1,197 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/jeffersonwarrior/modelscan/internal/tenant"
+)
+
+// Headers for cost estimates and dry runs
+const (
+	// HeaderEstimatedCost on a response carries the estimated USD cost of
+	// the request: its prompt tokens at the model's input price plus its
+	// max_tokens at the output price, so it is an upper bound. It is only
+	// set for models with known prices.
+	HeaderEstimatedCost = "X-Modelscan-Estimated-Cost"
+
+	// HeaderDryRun set to true on a request (or the dry_run=true query
+	// parameter) returns the routing decision, cost estimate and limit
+	// checks as JSON without calling the upstream provider
+	HeaderDryRun = "X-Modelscan-Dry-Run"
+)
+
+// CostEstimate prices a request before it is sent
+type CostEstimate struct {
+	Model               string  `json:"model"`
+	PromptTokens        int     `json:"prompt_tokens"`
+	MaxCompletionTokens int     `json:"max_completion_tokens"`
+	InputCost           float64 `json:"input_cost_usd"`
+	MaxOutputCost       float64 `json:"max_output_cost_usd"`
+	Cost                float64 `json:"estimated_cost_usd"`
+	Priced              bool    `json:"priced"` // False when the model has no prices
+}
+
+// DryRunCheck is one limit a dry run checked
+type DryRunCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// DryRunStep is one target of a dry run's route: the provider and model
+// the request would be sent to, and the checks for it
+type DryRunStep struct {
+	Provider string        `json:"provider"`
+	Model    string        `json:"model"`
+	Estimate *CostEstimate `json:"estimate,omitempty"`
+	Checks   []DryRunCheck `json:"checks"`
+	Feasible bool          `json:"feasible"`
+}
+
+// DryRunResult is the response to a dry run. Route has one step, or one
+// per step of a fallback chain; the request is feasible when any step is.
+type DryRunResult struct {
+	DryRun   bool         `json:"dry_run"`
+	API      string       `json:"api"`
+	Model    string       `json:"model"` // As requested
+	ClientID string       `json:"client_id,omitempty"`
+	TenantID string       `json:"tenant_id,omitempty"`
+	Route    []DryRunStep `json:"route"`
+	Feasible bool         `json:"feasible"`
+}
+
+// SetPricing prices requests for the estimated cost header and dry runs
+func (p *OpenAIProxy) SetPricing(pricer tenant.Pricer) {
+	p.pricer = pricer
+}
+
+// SetPricing prices requests for the estimated cost header and dry runs
+func (p *AnthropicProxy) SetPricing(pricer tenant.Pricer) {
+	p.pricer = pricer
+}
+
+// openAIMaxTokens returns the completion token cap of a request
+func openAIMaxTokens(req *OpenAIRequest) int {
+	if req.MaxCompletionTokens != nil {
+		return *req.MaxCompletionTokens
+	}
+	if req.MaxTokens != nil {
+		return *req.MaxTokens
+	}
+	return 0
+}
+
+// isDryRun reports whether a request asks for a dry run
+func isDryRun(r *http.Request) bool {
+	v := r.Header.Get(HeaderDryRun)
+	if v == "" {
+		v = r.URL.Query().Get("dry_run")
+	}
+	dry, _ := strconv.ParseBool(v)
+	return dry
+}
+
+// estimateCost prices a request, or returns nil without a pricer
+func estimateCost(pricer tenant.Pricer, model string, promptTokens, maxCompletionTokens int) *CostEstimate {
+	if pricer == nil {
+		return nil
+	}
+	in, out := pricer.Price(model)
+	e := &CostEstimate{
+		Model:               model,
+		PromptTokens:        promptTokens,
+		MaxCompletionTokens: maxCompletionTokens,
+		InputCost:           float64(promptTokens) * in / 1_000_000,
+		MaxOutputCost:       float64(maxCompletionTokens) * out / 1_000_000,
+		Priced:              in > 0 || out > 0,
+	}
+	e.Cost = e.InputCost + e.MaxOutputCost
+	return e
+}
+
+// setEstimatedCost sets HeaderEstimatedCost for a priced estimate. It
+// must be called before the response header is written.
+func setEstimatedCost(w http.ResponseWriter, e *CostEstimate) {
+	if e != nil && e.Priced {
+		w.Header().Set(HeaderEstimatedCost, strconv.FormatFloat(e.Cost, 'f', 6, 64))
+	}
+}
+
+// dryRunner holds what a dry run checks a route against
+type dryRunner struct {
+	keys         KeyProvider
+	availability ProviderAvailability
+	tenants      *tenant.Manager
+	concurrency  *ConcurrencyLimiter
+	modelLimits  *ModelLimits
+}
+
+// run checks each step of a route, whose estimates the caller has set.
+// Nothing is charged to rate limits or sent upstream.
+func (d dryRunner) run(ctx context.Context, api, model, clientID string, promptTokens int, route []DryRunStep) *DryRunResult {
+	result := &DryRunResult{DryRun: true, API: api, Model: model, ClientID: clientID, Route: route}
+
+	tenantCheck := DryRunCheck{Name: "tenant_limits", OK: true}
+	if d.tenants != nil {
+		tenantID, err := d.tenants.Resolve(clientID)
+		if err == nil {
+			err = d.tenants.Check(tenantID, promptTokens)
+		}
+		if err != nil {
+			tenantCheck = DryRunCheck{Name: "tenant_limits", Reason: err.Error()}
+		}
+		result.TenantID = tenantID
+		ctx = tenant.WithTenant(ctx, tenantID)
+	}
+
+	for i := range result.Route {
+		step := &result.Route[i]
+		step.Checks = append(step.Checks, tenantCheck)
+
+		availability := DryRunCheck{Name: "availability", OK: true}
+		if merr := checkAvailable(d.availability, step.Provider); merr != nil {
+			availability = DryRunCheck{Name: "availability", Reason: merr.Error()}
+		}
+		step.Checks = append(step.Checks, availability)
+
+		key := DryRunCheck{Name: "api_key", OK: true}
+		if _, err := d.keys.GetKey(ctx, step.Provider); err != nil {
+			key = DryRunCheck{Name: "api_key", Reason: "no API key available for provider " + step.Provider}
+		}
+		step.Checks = append(step.Checks, key)
+
+		// A full cap only queues the request, so it stays feasible
+		concurrency := DryRunCheck{Name: "concurrency", OK: true}
+		if scope := d.concurrency.Full(step.Provider); scope != "" {
+			concurrency.Reason = "at the " + scope + " in-flight cap; the request would queue"
+		}
+		step.Checks = append(step.Checks, concurrency)
+
+		tokens := promptTokens
+		if step.Estimate != nil {
+			tokens += step.Estimate.MaxCompletionTokens
+		}
+		modelCaps := DryRunCheck{Name: "model_limits", OK: true}
+		if err := d.modelLimits.check(step.Model, tokens); err != nil {
+			modelCaps = DryRunCheck{Name: "model_limits", Reason: err.Error()}
+		}
+		step.Checks = append(step.Checks, modelCaps)
+
+		step.Feasible = true
+		for _, c := range step.Checks {
+			step.Feasible = step.Feasible && c.OK
+		}
+		result.Feasible = result.Feasible || step.Feasible
+	}
+	return result
+}
+
+// writeDryRun writes a dry run's result
+func writeDryRun(w http.ResponseWriter, result *DryRunResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(result)
+}
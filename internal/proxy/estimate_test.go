@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestOpenAIProxy_EstimatedCostHeader(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"message":{"role":"assistant","content":"hi"}}]}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-key"}, nil)
+	p.SetPricing(testPricer{})
+
+	body := `{"model":"gpt-4o","max_tokens":100,"messages":[{"role":"user","content":"hello"}]}`
+	var req OpenAIRequest
+	json.Unmarshal([]byte(body), &req)
+	want := (float64(CountOpenAIRequestTokens(&req))*10 + 100*30) / 1_000_000
+
+	rec := httptest.NewRecorder()
+	p.HandleChatCompletions(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	got, err := strconv.ParseFloat(rec.Header().Get(HeaderEstimatedCost), 64)
+	if err != nil || got != want {
+		t.Errorf("expected an estimated cost of %.6f, got %q", want, rec.Header().Get(HeaderEstimatedCost))
+	}
+}
+
+func TestOpenAIProxy_DryRun(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a dry run must not call the upstream provider")
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-key"}, nil)
+	p.SetPricing(testPricer{})
+	p.SetAvailability(maintenanceSet{"openai": {}})
+	chains := NewFallbackChains()
+	chains.SetChain(FallbackChain{Model: "smart", Steps: []FallbackStep{
+		{Provider: "openai", Model: "gpt-4o"},
+		{Provider: "groq", Model: "llama-3.3-70b", MaxTokens: 50},
+	}})
+	p.SetFallbackChains(chains)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+		strings.NewReader(`{"model":"smart","max_tokens":100,"messages":[{"role":"user","content":"hello"}]}`))
+	req.Header.Set(HeaderDryRun, "true")
+	rec := httptest.NewRecorder()
+	p.HandleChatCompletions(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result DryRunResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode dry run: %v", err)
+	}
+	if !result.DryRun || result.Model != "smart" || len(result.Route) != 2 || !result.Feasible {
+		t.Fatalf("unexpected dry run %+v", result)
+	}
+	if first := result.Route[0]; first.Provider != "openai" || first.Feasible || first.Estimate.MaxCompletionTokens != 100 {
+		t.Errorf("expected the drained first step to be infeasible, got %+v", first)
+	}
+	if second := result.Route[1]; second.Model != "llama-3.3-70b" || !second.Feasible || second.Estimate.MaxCompletionTokens != 50 || !second.Estimate.Priced {
+		t.Errorf("unexpected second step %+v", second)
+	}
+}
+
+func TestAnthropicProxy_DryRunWithoutKey(t *testing.T) {
+	p := NewAnthropicProxy(DefaultAnthropicProxyConfig(), &mockKeyProvider{err: errors.New("no keys")}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages?dry_run=true",
+		strings.NewReader(`{"model":"claude-sonnet-4","max_tokens":100,"messages":[{"role":"user","content":[{"type":"text","text":"hello"}]}]}`))
+	rec := httptest.NewRecorder()
+	p.HandleMessages(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result DryRunResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode dry run: %v", err)
+	}
+	if result.Feasible || len(result.Route) != 1 || result.Route[0].Estimate != nil {
+		t.Fatalf("expected an infeasible, unpriced route, got %+v", result)
+	}
+	for _, c := range result.Route[0].Checks {
+		if c.Name == "api_key" && (c.OK || c.Reason == "") {
+			t.Errorf("expected the api_key check to fail, got %+v", c)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/tenant"
+)
+
+// Headers tagging a response with the experiment variant that served it
+const (
+	HeaderExperiment = "X-Modelscan-Experiment"
+	HeaderVariant    = "X-Modelscan-Variant"
+)
+
+// HeaderSession identifies a client's session for experiments split by
+// session
+const HeaderSession = "X-Session-ID"
+
+// What an experiment's traffic is split by
+const (
+	SplitByClient  = "client"
+	SplitBySession = "session"
+)
+
+// Experiment splits matching requests between variants that change their
+// parameters, so the outcomes of each can be compared. A request is
+// assigned by a stable hash of its client or session, so the same caller
+// always sees the same variant; requests without one are left alone.
+type Experiment struct {
+	Name     string    `json:"name"`
+	APIs     []string  `json:"apis,omitempty"`     // openai or anthropic (default both)
+	Models   []string  `json:"models,omitempty"`   // Requested model patterns with "*" wildcards (default all)
+	Clients  []string  `json:"clients,omitempty"`  // Client IDs (default all)
+	SplitBy  string    `json:"split_by,omitempty"` // client (default) or session
+	Percent  float64   `json:"percent,omitempty"`  // Share of matching traffic enrolled (default 100)
+	Variants []Variant `json:"variants"`
+}
+
+// Variant is one configuration under test. A variant changing nothing is
+// the control.
+type Variant struct {
+	Name   string                     `json:"name"`
+	Weight int                        `json:"weight,omitempty"` // Relative share of enrolled traffic (default 1)
+	Model  string                     `json:"model,omitempty"`  // Replaces the requested model
+	System string                     `json:"system,omitempty"` // Replaces the client's system prompt
+	Set    map[string]json.RawMessage `json:"set,omitempty"`    // Body fields forced to a value
+}
+
+// Validate checks the experiment has a name, known APIs and split, a
+// percentage in range, and at least two distinct, usable variants
+func (e Experiment) Validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	for _, api := range e.APIs {
+		if api != TransformAPIOpenAI && api != TransformAPIAnthropic {
+			return fmt.Errorf("unknown api %q (want openai or anthropic)", api)
+		}
+	}
+	if e.SplitBy != "" && e.SplitBy != SplitByClient && e.SplitBy != SplitBySession {
+		return fmt.Errorf("unknown split_by %q (want client or session)", e.SplitBy)
+	}
+	if e.Percent < 0 || e.Percent > 100 {
+		return fmt.Errorf("percent must be between 0 and 100")
+	}
+	if len(e.Variants) < 2 {
+		return fmt.Errorf("at least two variants are required")
+	}
+	seen := make(map[string]bool)
+	for _, v := range e.Variants {
+		if v.Name == "" {
+			return fmt.Errorf("variant name is required")
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("duplicate variant %s", v.Name)
+		}
+		seen[v.Name] = true
+		if v.Weight < 0 {
+			return fmt.Errorf("variant %s: weight must not be negative", v.Name)
+		}
+		for field, value := range v.Set {
+			if !json.Valid(value) {
+				return fmt.Errorf("variant %s: set %s: invalid JSON value", v.Name, field)
+			}
+			if field == "" || slices.Contains(strings.Split(field, "."), "") {
+				return fmt.Errorf("variant %s: invalid field %q", v.Name, field)
+			}
+			if slices.Contains(reservedFields, strings.Split(field, ".")[0]) || field == "system" {
+				return fmt.Errorf("variant %s: field %s cannot be set (use model or system)", v.Name, field)
+			}
+		}
+	}
+	return nil
+}
+
+// assign picks the variant for a request, or nil when it is not enrolled
+func (e Experiment) assign(api, model, clientID, session string) *Variant {
+	key := clientID
+	if e.SplitBy == SplitBySession {
+		key = session
+	}
+	if key == "" {
+		return nil
+	}
+	if len(e.APIs) > 0 && !slices.Contains(e.APIs, api) {
+		return nil
+	}
+	if len(e.Clients) > 0 && !slices.Contains(e.Clients, clientID) {
+		return nil
+	}
+	if len(e.Models) > 0 && !slices.ContainsFunc(e.Models, func(pattern string) bool { return matchWildcard(pattern, model) }) {
+		return nil
+	}
+
+	// The experiment's name is hashed in so callers land in independent
+	// buckets across experiments
+	h := fnv.New64a()
+	h.Write([]byte(e.Name))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	if percent := e.Percent; percent > 0 && float64(sum%10000) >= percent*100 {
+		return nil
+	}
+	total := 0
+	for _, v := range e.Variants {
+		total += v.weight()
+	}
+	if total == 0 {
+		return nil
+	}
+	pick := int((sum / 10000) % uint64(total))
+	for i := range e.Variants {
+		if pick -= e.Variants[i].weight(); pick < 0 {
+			return &e.Variants[i]
+		}
+	}
+	return nil
+}
+
+func (v Variant) weight() int {
+	if v.Weight == 0 {
+		return 1
+	}
+	return v.Weight
+}
+
+// VariantResult sums the outcomes of the requests served by a variant
+type VariantResult struct {
+	Variant          string  `json:"variant"`
+	Requests         int64   `json:"requests"`
+	Errors           int64   `json:"errors"`       // Responses with an error status
+	Disconnected     int64   `json:"disconnected"` // Abandoned by the client
+	Truncated        int64   `json:"truncated"`    // Stopped at the token limit
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"` // At list prices, when known
+	ErrorRate        float64 `json:"error_rate"`
+	AvgLatencyMs     float64 `json:"avg_latency_ms"`
+	AvgFirstByteMs   float64 `json:"avg_first_byte_ms"`
+	AvgCost          float64 `json:"avg_cost"`
+
+	latency   time.Duration
+	firstByte time.Duration
+}
+
+// ExperimentResults is what an experiment's variants have achieved since
+// it was last defined
+type ExperimentResults struct {
+	Experiment string          `json:"experiment"`
+	Since      time.Time       `json:"since"`
+	Variants   []VariantResult `json:"variants"`
+}
+
+// experimentState is an experiment and its tallies
+type experimentState struct {
+	Experiment
+	since   time.Time
+	results map[string]*VariantResult
+}
+
+// Experiments holds the running experiments and tallies their results from
+// completions. Experiments are tried in order and a request joins at most
+// one, so variants of different experiments never mix.
+type Experiments struct {
+	pricer tenant.Pricer
+
+	mu          sync.RWMutex
+	experiments []*experimentState
+}
+
+// NewExperiments creates an empty experiment set. The pricer, if any,
+// prices the tokens each variant used.
+func NewExperiments(pricer tenant.Pricer) *Experiments {
+	return &Experiments{pricer: pricer}
+}
+
+// SetExperiment replaces the experiment with the same name, or adds it at
+// the end. Either way its results start over.
+func (x *Experiments) SetExperiment(e Experiment) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	s := &experimentState{Experiment: e, since: time.Now(), results: make(map[string]*VariantResult)}
+	for _, v := range e.Variants {
+		s.results[v.Name] = &VariantResult{Variant: v.Name}
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if i := x.index(e.Name); i >= 0 {
+		x.experiments[i] = s
+	} else {
+		x.experiments = append(x.experiments, s)
+	}
+	return nil
+}
+
+// RemoveExperiment stops an experiment, reporting whether it existed
+func (x *Experiments) RemoveExperiment(name string) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	i := x.index(name)
+	if i < 0 {
+		return false
+	}
+	x.experiments = slices.Delete(x.experiments, i, i+1)
+	return true
+}
+
+// Experiment returns an experiment by name
+func (x *Experiments) Experiment(name string) (Experiment, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if i := x.index(name); i >= 0 {
+		return x.experiments[i].Experiment, true
+	}
+	return Experiment{}, false
+}
+
+// Experiments returns the experiments in the order they are tried
+func (x *Experiments) Experiments() []Experiment {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	out := make([]Experiment, len(x.experiments))
+	for i, s := range x.experiments {
+		out[i] = s.Experiment
+	}
+	return out
+}
+
+// Results returns an experiment's results per variant, in the order the
+// variants are defined
+func (x *Experiments) Results(name string) (ExperimentResults, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	i := x.index(name)
+	if i < 0 {
+		return ExperimentResults{}, false
+	}
+	s := x.experiments[i]
+	out := ExperimentResults{Experiment: s.Name, Since: s.since}
+	for _, v := range s.Variants {
+		r := *s.results[v.Name]
+		if r.Requests > 0 {
+			n := float64(r.Requests)
+			r.ErrorRate = float64(r.Errors) / n
+			r.AvgLatencyMs = float64(r.latency) / float64(time.Millisecond) / n
+			r.AvgFirstByteMs = float64(r.firstByte) / float64(time.Millisecond) / n
+			r.AvgCost = r.Cost / n
+		}
+		out.Variants = append(out.Variants, r)
+	}
+	return out, true
+}
+
+// Observe counts a completion toward the variant that served it. It is
+// meant to be registered as an AfterCompletion hook.
+func (x *Experiments) Observe(c *Completion) {
+	if c.Experiment == "" {
+		return
+	}
+	var cost float64
+	if x.pricer != nil {
+		in, out := x.pricer.Price(c.Model)
+		cost = (float64(c.PromptTokens)*in + float64(c.CompletionTokens)*out) / 1_000_000
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	i := x.index(c.Experiment)
+	if i < 0 || c.StartedAt.Before(x.experiments[i].since) {
+		return // Stopped or redefined since
+	}
+	r, ok := x.experiments[i].results[c.Variant]
+	if !ok {
+		return
+	}
+	r.Requests++
+	if c.Status >= http.StatusBadRequest {
+		r.Errors++
+	}
+	if c.Disconnected {
+		r.Disconnected++
+	}
+	if c.FinishReason == "length" || c.FinishReason == "max_tokens" {
+		r.Truncated++
+	}
+	r.PromptTokens += int64(c.PromptTokens)
+	r.CompletionTokens += int64(c.CompletionTokens)
+	r.Cost += cost
+	r.latency += c.Duration
+	r.firstByte += c.FirstByte
+}
+
+// index returns the position of an experiment, or -1. Callers hold x.mu.
+func (x *Experiments) index(name string) int {
+	return slices.IndexFunc(x.experiments, func(s *experimentState) bool { return s.Name == name })
+}
+
+// assign finds the first experiment enrolling a request and its variant
+func (x *Experiments) assign(api, model, clientID, session string) (string, *Variant) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	for _, s := range x.experiments {
+		if v := s.assign(api, model, clientID, session); v != nil {
+			return s.Name, v
+		}
+	}
+	return "", nil
+}
+
+// applyExperiment rewrites a request body for the variant it is assigned,
+// if any, and tags the response with the experiment and variant. It runs
+// after the transformation rules, so a variant's settings win.
+func applyExperiment(x *Experiments, w http.ResponseWriter, r *http.Request, api string, body []byte) ([]byte, error) {
+	if x == nil {
+		return body, nil
+	}
+	var head struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(body, &head); err != nil {
+		return nil, err
+	}
+	name, v := x.assign(api, head.Model, r.Header.Get("X-Client-ID"), r.Header.Get(HeaderSession))
+	if v == nil {
+		return body, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	if v.Model != "" {
+		fields["model"] = v.Model
+	}
+	if v.System != "" {
+		setSystemPrompt(fields, api, v.System)
+	}
+	for field, value := range v.Set {
+		setField(fields, field, value)
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	w.Header().Set(HeaderExperiment, name)
+	w.Header().Set(HeaderVariant, v.Name)
+	return b, nil
+}
+
+// setSystemPrompt replaces a request's system prompt: the system parameter
+// for Anthropic, the leading system or developer messages for OpenAI
+func setSystemPrompt(fields map[string]any, api, prompt string) {
+	if api == TransformAPIAnthropic {
+		fields["system"] = prompt
+		return
+	}
+	messages, ok := fields["messages"].([]any)
+	if !ok {
+		return
+	}
+	end := 0
+	for ; end < len(messages); end++ {
+		m, _ := messages[end].(map[string]any)
+		if role := m["role"]; role != "system" && role != "developer" {
+			break
+		}
+	}
+	fields["messages"] = append([]any{map[string]any{"role": "system", "content": prompt}}, messages[end:]...)
+}
+
+// SetExperiments enables A/B experiments
+func (p *OpenAIProxy) SetExperiments(x *Experiments) {
+	p.experiments = x
+}
+
+// SetExperiments enables A/B experiments
+func (p *AnthropicProxy) SetExperiments(x *Experiments) {
+	p.experiments = x
+}
@@ -0,0 +1,176 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExperiment_Validate(t *testing.T) {
+	two := []Variant{{Name: "a"}, {Name: "b"}}
+	tests := []struct {
+		name string
+		exp  Experiment
+		want string
+	}{
+		{"no name", Experiment{Variants: two}, "name is required"},
+		{"responses api", Experiment{Name: "e", APIs: []string{"responses"}, Variants: two}, "unknown api"},
+		{"unknown split", Experiment{Name: "e", SplitBy: "user", Variants: two}, "unknown split_by"},
+		{"percent", Experiment{Name: "e", Percent: 150, Variants: two}, "percent"},
+		{"one variant", Experiment{Name: "e", Variants: two[:1]}, "at least two"},
+		{"duplicate variant", Experiment{Name: "e", Variants: []Variant{{Name: "a"}, {Name: "a"}}}, "duplicate variant"},
+		{"negative weight", Experiment{Name: "e", Variants: []Variant{{Name: "a", Weight: -1}, {Name: "b"}}}, "weight"},
+		{"reserved field", Experiment{Name: "e", Variants: []Variant{{Name: "a", Set: map[string]json.RawMessage{"model": json.RawMessage(`"x"`)}}, {Name: "b"}}}, "use model or system"},
+		{"invalid value", Experiment{Name: "e", Variants: []Variant{{Name: "a", Set: map[string]json.RawMessage{"top_p": json.RawMessage("{")}}, {Name: "b"}}}, "invalid JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.exp.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestExperiment_Assign(t *testing.T) {
+	e := Experiment{Name: "e", Percent: 50, Variants: []Variant{{Name: "a"}, {Name: "b", Weight: 3}}}
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		client := fmt.Sprintf("client-%d", i)
+		v := e.assign(TransformAPIOpenAI, "gpt-4o", client, "")
+		if v == nil {
+			counts[""]++
+			continue
+		}
+		counts[v.Name]++
+		if again := e.assign(TransformAPIOpenAI, "gpt-4o", client, ""); again.Name != v.Name {
+			t.Fatalf("%s moved from %s to %s", client, v.Name, again.Name)
+		}
+	}
+	if n := counts[""]; math.Abs(float64(n)-2000) > 200 {
+		t.Errorf("expected about half the clients enrolled, %d were not", n)
+	}
+	if ratio := float64(counts["b"]) / float64(counts["a"]); ratio < 2.4 || ratio > 3.6 {
+		t.Errorf("expected variants split 1:3, got %v", counts)
+	}
+
+	e = Experiment{Name: "e", SplitBy: SplitBySession, APIs: []string{TransformAPIAnthropic}, Models: []string{"claude-*"}, Variants: []Variant{{Name: "a"}, {Name: "b"}}}
+	if e.assign(TransformAPIAnthropic, "claude-3-haiku", "client-1", "") != nil {
+		t.Error("expected a request without a session to stay out")
+	}
+	if e.assign(TransformAPIOpenAI, "claude-3-haiku", "client-1", "s-1") != nil || e.assign(TransformAPIAnthropic, "gpt-4o", "client-1", "s-1") != nil {
+		t.Error("expected other APIs and models to stay out")
+	}
+	if e.assign(TransformAPIAnthropic, "claude-3-haiku", "", "s-1") == nil {
+		t.Error("expected the session to enroll the request")
+	}
+}
+
+func TestApplyExperiment(t *testing.T) {
+	// Both variants make the same changes, whichever the client lands in
+	brief := Variant{Model: "gpt-4o-mini", System: "Be brief.", Set: map[string]json.RawMessage{"temperature": json.RawMessage("0.2")}}
+	v1, v2 := brief, brief
+	v1.Name, v2.Name = "v1", "v2"
+	x := NewExperiments(nil)
+	x.SetExperiment(Experiment{Name: "prompt", Variants: []Variant{v1, v2}})
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	r.Header.Set("X-Client-ID", "client-1")
+	w := httptest.NewRecorder()
+	body := `{"model":"gpt-4o","temperature":1,"messages":[{"role":"system","content":"Be thorough."},{"role":"developer","content":"Cite sources."},{"role":"user","content":"hi"}]}`
+	out, err := applyExperiment(x, w, r, TransformAPIOpenAI, []byte(body))
+	if err != nil {
+		t.Fatalf("applyExperiment: %v", err)
+	}
+	var req OpenAIRequest
+	json.Unmarshal(out, &req)
+	if req.Model != "gpt-4o-mini" || req.Temperature == nil || *req.Temperature != 0.2 {
+		t.Errorf("unexpected request: %s", out)
+	}
+	if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[0].Content != "Be brief." || req.Messages[1].Role != "user" {
+		t.Errorf("expected the system prompt to be replaced, got %s", out)
+	}
+	if w.Header().Get(HeaderExperiment) != "prompt" || w.Header().Get(HeaderVariant) == "" {
+		t.Errorf("expected the response to be tagged, got %v", w.Header())
+	}
+
+	out, _ = applyExperiment(x, httptest.NewRecorder(), r, TransformAPIAnthropic, []byte(`{"model":"claude-3-haiku","system":"Be thorough.","messages":[]}`))
+	if !strings.Contains(string(out), `"system":"Be brief."`) {
+		t.Errorf("expected the Anthropic system prompt to be replaced, got %s", out)
+	}
+
+	// Without a client ID nothing changes
+	anon := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	w = httptest.NewRecorder()
+	if out, _ := applyExperiment(x, w, anon, TransformAPIOpenAI, []byte(body)); string(out) != body || w.Header().Get(HeaderExperiment) != "" {
+		t.Errorf("expected an anonymous request to be left alone, got %s", out)
+	}
+}
+
+func TestOpenAIProxy_Experiment(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OpenAIRequest
+		b, _ := io.ReadAll(r.Body)
+		json.Unmarshal(b, &req)
+		if req.Model == "broken" {
+			http.Error(w, `{"error":{"message":"down"}}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"chatcmpl-1","object":"chat.completion","model":%q,"choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`, req.Model)
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "sk-test"}, nil)
+	x := NewExperiments(testPricer{})
+	if err := x.SetExperiment(Experiment{Name: "models", Variants: []Variant{{Name: "control"}, {Name: "broken", Model: "broken"}}}); err != nil {
+		t.Fatalf("SetExperiment: %v", err)
+	}
+	p.SetExperiments(x)
+	hooks := NewCompletionHooks(CompletionHooksConfig{Workers: 1})
+	hooks.Register(func(_ context.Context, c *Completion) { x.Observe(c) })
+	p.SetCompletionHooks(hooks)
+
+	variants := map[string]int{}
+	for i := 0; i < 20; i++ {
+		w := sendChat(p, `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`, http.Header{"X-Client-Id": {fmt.Sprintf("client-%d", i)}})
+		variant := w.Header().Get(HeaderVariant)
+		if w.Header().Get(HeaderExperiment) != "models" || (variant == "broken") != (w.Code != http.StatusOK) {
+			t.Fatalf("unexpected response for variant %q: %d", variant, w.Code)
+		}
+		variants[variant]++
+	}
+	hooks.Close()
+
+	results, ok := x.Results("models")
+	if !ok || len(results.Variants) != 2 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	control, broken := results.Variants[0], results.Variants[1]
+	if control.Requests != int64(variants["control"]) || broken.Requests != int64(variants["broken"]) || control.Requests+broken.Requests != 20 {
+		t.Errorf("expected %v requests, got %+v", variants, results.Variants)
+	}
+	if control.Errors != 0 || control.PromptTokens != 10*control.Requests || math.Abs(control.AvgCost-(10*10+5*30)/1e6) > 1e-12 || control.AvgLatencyMs <= 0 {
+		t.Errorf("unexpected control results: %+v", control)
+	}
+	if broken.Errors != broken.Requests || broken.ErrorRate != 1 {
+		t.Errorf("unexpected broken results: %+v", broken)
+	}
+
+	// Redefining an experiment starts its results over
+	x.SetExperiment(Experiment{Name: "models", Variants: []Variant{{Name: "control"}, {Name: "broken", Model: "broken"}}})
+	x.Observe(&Completion{Experiment: "models", Variant: "control", Status: http.StatusOK, StartedAt: time.Now().Add(-time.Minute)})
+	if results, _ := x.Results("models"); results.Variants[0].Requests != 0 {
+		t.Errorf("expected the results to start over, got %+v", results.Variants[0])
+	}
+}
@@ -0,0 +1,176 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jeffersonwarrior/modelscan/internal/remap"
+)
+
+// Headers for routing explanations
+const (
+	// HeaderExplain set to true on a request asks for HeaderRoute on the
+	// response
+	HeaderExplain = "X-Modelscan-Explain"
+
+	// HeaderRoute on a response carries the routing decision's trace,
+	// its steps separated by "; "
+	HeaderRoute = "X-Modelscan-Route"
+)
+
+// RemapExplainer is a ModelRemapper that can explain its decisions, such
+// as *remap.Engine
+type RemapExplainer interface {
+	Explain(model, clientID string) *remap.Explanation
+}
+
+// RouteDecision records how a request is routed: the candidates the
+// proxy weighed, why each was passed over, and the steps that led to the
+// chosen target
+type RouteDecision struct {
+	API           string             `json:"api"`
+	Model         string             `json:"model"` // As requested
+	ClientID      string             `json:"client_id,omitempty"`
+	Residency     string             `json:"residency,omitempty"` // Region the client's policy requires
+	Provider      string             `json:"provider"`            // Empty when no candidate can serve the request
+	UpstreamModel string             `json:"upstream_model"`
+	Remap         *remap.Explanation `json:"remap,omitempty"`
+	Fallback      *FallbackChain     `json:"fallback,omitempty"`
+	Candidates    []remap.Candidate  `json:"candidates"`
+	Trace         []string           `json:"trace"`
+}
+
+// ExplainRoute decides where a request for model would go, without
+// sending it. provider is the value of an X-Modelscan-Provider header.
+func (p *OpenAIProxy) ExplainRoute(ctx context.Context, model, clientID, provider string) *RouteDecision {
+	d := newRouteDecision("openai", model, clientID, p.residency)
+	if p.fallbacks != nil && provider == "" {
+		if chain, ok := p.fallbacks.Chain(model); ok {
+			d.Fallback = &chain
+			d.explainChain(p.availability, p.residency, chain)
+			return d
+		}
+	}
+	d.explain(ctx, p.remapper, p.availability, p.residency, "openai", provider)
+	return d
+}
+
+// ExplainRoute decides where a request for model would go, without
+// sending it. provider is the value of an X-Modelscan-Provider header.
+func (p *AnthropicProxy) ExplainRoute(ctx context.Context, model, clientID, provider string) *RouteDecision {
+	d := newRouteDecision("anthropic", model, clientID, p.residency)
+	d.explain(ctx, p.remapper, p.availability, p.residency, "anthropic", provider)
+	return d
+}
+
+func newRouteDecision(api, model, clientID string, residency *Residency) *RouteDecision {
+	d := &RouteDecision{API: api, Model: model, ClientID: clientID}
+	d.Residency, _ = residency.Required(clientID, "")
+	if d.Residency != "" {
+		d.tracef("client %s must stay in data residency region %s", clientID, d.Residency)
+	}
+	return d
+}
+
+func (d *RouteDecision) tracef(format string, args ...interface{}) {
+	d.Trace = append(d.Trace, fmt.Sprintf(format, args...))
+}
+
+// explainChain weighs the steps of a fallback chain in order; the first
+// available one serves the request
+func (d *RouteDecision) explainChain(availability ProviderAvailability, residency *Residency, chain FallbackChain) {
+	d.tracef("fallback chain for %s with %d steps; remap rules are skipped", chain.Model, len(chain.Steps))
+	for _, step := range chain.Steps {
+		c := remap.Candidate{Provider: step.Provider, Model: step.Model}
+		if c.Model == "" {
+			c.Model = d.Model
+		}
+		switch merr := checkAvailable(availability, step.Provider); {
+		case !residency.Allows(step.Provider, d.Residency):
+			c.Eliminated = "outside data residency region " + d.Residency
+		case merr != nil:
+			c.Eliminated = merr.Error()
+		case d.Provider != "":
+			c.Eliminated = "fallback if " + d.Provider + " fails"
+		default:
+			c.Selected = true
+			d.Provider, d.UpstreamModel = c.Provider, c.Model
+		}
+		d.Candidates = append(d.Candidates, c)
+	}
+	if d.Provider == "" {
+		d.tracef("no step is available and compliant; the request would be rejected")
+	} else {
+		d.tracef("first available step is %s on %s", d.UpstreamModel, d.Provider)
+	}
+}
+
+// explain follows the remap rules, the provider header and provider
+// availability, as the proxy does for a request without a fallback chain
+func (d *RouteDecision) explain(ctx context.Context, remapper ModelRemapper, availability ProviderAvailability, residency *Residency, defaultProvider, override string) {
+	provider, model := defaultProvider, d.Model
+	d.tracef("default provider is %s", defaultProvider)
+
+	if explainer, ok := remapper.(RemapExplainer); ok {
+		d.Remap = explainer.Explain(d.Model, d.ClientID)
+		d.Trace = append(d.Trace, d.Remap.Trace...)
+		model = d.Remap.Model
+		if d.Remap.Provider != "" {
+			provider = d.Remap.Provider
+		}
+		d.Candidates = d.Remap.Candidates
+	} else if remapper != nil {
+		if remapped, target, err := remapper.RemapModel(ctx, d.Model, d.ClientID); err != nil {
+			d.tracef("remap failed, keeping %s: %v", d.Model, err)
+		} else if remapped != "" {
+			model = remapped
+			if target != "" {
+				provider = target
+			}
+			d.tracef("remapped to %s on %s", model, provider)
+		}
+	}
+
+	if override != "" {
+		d.tracef("%s header forces provider %s", HeaderProvider, override)
+		provider = override
+		d.Candidates = nil
+	}
+
+	if len(d.Candidates) == 0 {
+		d.Candidates = []remap.Candidate{{Provider: provider, Model: model, Selected: true}}
+	}
+	d.UpstreamModel = model
+	var reason string
+	if !residency.Allows(provider, d.Residency) {
+		reason = (&ResidencyError{Region: d.Residency, Provider: provider, Model: model}).Error()
+	} else if merr := checkAvailable(availability, provider); merr != nil {
+		reason = merr.Error()
+	}
+	if reason != "" {
+		for i := range d.Candidates {
+			if d.Candidates[i].Selected {
+				d.Candidates[i].Selected = false
+				d.Candidates[i].Eliminated = reason
+			}
+		}
+		d.tracef("%s; the request would be rejected", reason)
+		return
+	}
+	d.Provider = provider
+}
+
+// wantsExplanation reports whether a request asks for HeaderRoute
+func wantsExplanation(r *http.Request) bool {
+	explain, _ := strconv.ParseBool(r.Header.Get(HeaderExplain))
+	return explain
+}
+
+// setRoute sets HeaderRoute from a decision. It must be called before the
+// response header is written.
+func setRoute(w http.ResponseWriter, d *RouteDecision) {
+	w.Header().Set(HeaderRoute, strings.Join(d.Trace, "; "))
+}
@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/remap"
+)
+
+// explainingRemapper is a mockRemapper that explains its choice
+type explainingRemapper struct {
+	mockRemapper
+}
+
+func (m *explainingRemapper) Explain(model, clientID string) *remap.Explanation {
+	return &remap.Explanation{
+		Resolution: remap.Resolution{Model: m.model, Provider: m.provider},
+		Trace:      []string{"rule matches " + model},
+		Candidates: []remap.Candidate{
+			{Provider: m.provider, Model: m.model, Selected: true},
+			{Provider: "openai", Model: "gpt-4o", Eliminated: "more expensive than " + m.model},
+		},
+	}
+}
+
+func TestOpenAIProxy_ExplainRoute(t *testing.T) {
+	remapper := &explainingRemapper{mockRemapper{model: "llama-3.3-70b", provider: "groq"}}
+	p := NewOpenAIProxy(DefaultOpenAIProxyConfig(), &mockKeyProvider{key: "test-key"}, remapper)
+
+	d := p.ExplainRoute(context.Background(), "cheap", "app", "")
+	if d.Provider != "groq" || d.UpstreamModel != "llama-3.3-70b" || d.Remap == nil {
+		t.Fatalf("unexpected decision %+v", d)
+	}
+	if len(d.Candidates) != 2 || !d.Candidates[0].Selected || d.Candidates[1].Eliminated == "" {
+		t.Errorf("unexpected candidates %+v", d.Candidates)
+	}
+
+	// The selected provider going down eliminates it
+	p.SetAvailability(maintenanceSet{"groq": {}})
+	d = p.ExplainRoute(context.Background(), "cheap", "app", "")
+	if d.Provider != "" || d.Candidates[0].Selected || !strings.Contains(d.Candidates[0].Eliminated, "groq") {
+		t.Errorf("expected the drained provider to be eliminated, got %+v", d)
+	}
+
+	// The provider header collapses the candidates to one
+	d = p.ExplainRoute(context.Background(), "cheap", "app", "openai")
+	if d.Provider != "openai" || len(d.Candidates) != 1 || !d.Candidates[0].Selected {
+		t.Errorf("expected the header to force openai, got %+v", d)
+	}
+}
+
+func TestOpenAIProxy_ExplainRouteFallbackChain(t *testing.T) {
+	p := NewOpenAIProxy(DefaultOpenAIProxyConfig(), &mockKeyProvider{key: "test-key"}, nil)
+	p.SetAvailability(maintenanceSet{"openai": {}})
+	chains := NewFallbackChains()
+	chains.SetChain(FallbackChain{Model: "smart", Steps: []FallbackStep{
+		{Provider: "openai", Model: "gpt-4o"},
+		{Provider: "groq", Model: "llama-3.3-70b"},
+		{Provider: "together"},
+	}})
+	p.SetFallbackChains(chains)
+
+	d := p.ExplainRoute(context.Background(), "smart", "", "")
+	if d.Provider != "groq" || d.UpstreamModel != "llama-3.3-70b" || d.Fallback == nil || len(d.Candidates) != 3 {
+		t.Fatalf("unexpected decision %+v", d)
+	}
+	if d.Candidates[0].Eliminated == "" || !d.Candidates[1].Selected || d.Candidates[2].Model != "smart" || d.Candidates[2].Eliminated == "" {
+		t.Errorf("unexpected candidates %+v", d.Candidates)
+	}
+}
+
+func TestAnthropicProxy_ExplainHeader(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"hi"}]}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultAnthropicProxyConfig()
+	cfg.AnthropicBaseURL = upstream.URL
+	p := NewAnthropicProxy(cfg, &mockKeyProvider{key: "test-key"}, nil)
+
+	send := func(explain bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/messages",
+			strings.NewReader(`{"model":"claude-sonnet-4","max_tokens":100,"messages":[{"role":"user","content":[{"type":"text","text":"hello"}]}]}`))
+		if explain {
+			req.Header.Set(HeaderExplain, "true")
+		}
+		rec := httptest.NewRecorder()
+		p.HandleMessages(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		return rec
+	}
+
+	if got := send(false).Header().Get(HeaderRoute); got != "" {
+		t.Errorf("expected no route header without opting in, got %q", got)
+	}
+	if got := send(true).Header().Get(HeaderRoute); !strings.Contains(got, "default provider is anthropic") {
+		t.Errorf("expected the route trace, got %q", got)
+	}
+}
@@ -0,0 +1,434 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// FallbackStep is one target in a fallback chain. The transformations are
+// applied to a copy of the client's request before it is sent to this step.
+type FallbackStep struct {
+	Provider     string `json:"provider"`
+	Model        string `json:"model,omitempty"`         // Upstream model (default the requested model)
+	MaxTokens    int    `json:"max_tokens,omitempty"`    // Caps max_tokens / max_completion_tokens
+	DropImages   bool   `json:"drop_images,omitempty"`   // Removes image parts for non-vision models
+	SystemPrompt string `json:"system_prompt,omitempty"` // Replaces the request's system messages
+	TimeoutMs    int    `json:"timeout_ms,omitempty"`    // Time allowed before the response starts
+}
+
+// FallbackChain lists the targets tried, in order, for requests naming Model.
+// A step that fails (an error status, a connection error, a stream that
+// errors before its first event) or does not start responding within its
+// timeout hands the request to the next step. The last step's failure is
+// returned to the client.
+type FallbackChain struct {
+	Model string         `json:"model"`
+	Steps []FallbackStep `json:"steps"`
+}
+
+// Validate checks the chain has a model and every step a provider
+func (c FallbackChain) Validate() error {
+	if c.Model == "" {
+		return fmt.Errorf("model is required")
+	}
+	if len(c.Steps) == 0 {
+		return fmt.Errorf("at least one step is required")
+	}
+	for i, step := range c.Steps {
+		if step.Provider == "" {
+			return fmt.Errorf("step %d: provider is required", i+1)
+		}
+		if step.MaxTokens < 0 || step.TimeoutMs < 0 {
+			return fmt.Errorf("step %d: max_tokens and timeout_ms must not be negative", i+1)
+		}
+	}
+	return nil
+}
+
+// FallbackChains holds the active chains keyed by requested model.
+// Chains can be changed at runtime; each request reads its chain once.
+type FallbackChains struct {
+	mu     sync.RWMutex
+	chains map[string]FallbackChain
+}
+
+// NewFallbackChains creates an empty chain set
+func NewFallbackChains() *FallbackChains {
+	return &FallbackChains{chains: make(map[string]FallbackChain)}
+}
+
+// SetChain adds or replaces the chain for c.Model
+func (f *FallbackChains) SetChain(c FallbackChain) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	c.Steps = append([]FallbackStep(nil), c.Steps...)
+	f.mu.Lock()
+	f.chains[c.Model] = c
+	f.mu.Unlock()
+	return nil
+}
+
+// RemoveChain removes the chain for a model, reporting whether one existed
+func (f *FallbackChains) RemoveChain(model string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.chains[model]; !ok {
+		return false
+	}
+	delete(f.chains, model)
+	return true
+}
+
+// Chain returns the chain for a model
+func (f *FallbackChains) Chain(model string) (FallbackChain, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	c, ok := f.chains[model]
+	return c, ok
+}
+
+// Chains returns all chains sorted by model
+func (f *FallbackChains) Chains() []FallbackChain {
+	f.mu.RLock()
+	chains := make([]FallbackChain, 0, len(f.chains))
+	for _, c := range f.chains {
+		chains = append(chains, c)
+	}
+	f.mu.RUnlock()
+	sort.Slice(chains, func(i, j int) bool { return chains[i].Model < chains[j].Model })
+	return chains
+}
+
+// SetFallbackChains enables fallback chains. Requests that name a chained
+// model bypass model remapping; an explicit provider header bypasses the chain.
+func (p *OpenAIProxy) SetFallbackChains(chains *FallbackChains) {
+	p.fallbacks = chains
+}
+
+// fallbackChain returns the chain serving the request, if any
+func (p *OpenAIProxy) fallbackChain(r *http.Request, model string) (FallbackChain, bool) {
+	if p.fallbacks == nil || r.Header.Get(HeaderProvider) != "" {
+		return FallbackChain{}, false
+	}
+	return p.fallbacks.Chain(model)
+}
+
+// forwardChain tries each step of the chain until one starts a successful
+// response. Failed attempts are held back so the client only sees the
+// response of the step that served it, or the last step's failure.
+func (p *OpenAIProxy) forwardChain(ctx context.Context, w http.ResponseWriter, req *OpenAIRequest, chain FallbackChain, clientID string) {
+	out := w
+	var buf *bufferedResponse
+	if p.guardrails != nil && !req.Stream {
+		buf = newBufferedResponse()
+		out = buf
+	}
+
+	for i, step := range chain.Steps {
+		last := i == len(chain.Steps)-1
+		stepReq := step.apply(req)
+
+		// A step never outlives the client's deadline budget
+		if err := checkDeadline(ctx); err != nil {
+			p.writeError(out, err.Error(), ErrorTypeDeadlineExceeded, http.StatusGatewayTimeout)
+			break
+		}
+
+		if merr := checkAvailable(p.availability, step.Provider); merr != nil {
+			if last {
+				p.writeError(out, merr.Error(), "server_error", maintenanceStatus(out, merr))
+				break
+			}
+			log.Printf("proxy: fallback %s step %d (%s/%s) skipped: %v", chain.Model, i+1, step.Provider, stepReq.Model, merr)
+			continue
+		}
+		if reason, degraded := p.incidentFor(step.Provider); degraded && !last {
+			log.Printf("proxy: fallback %s step %d (%s/%s) skipped: %s", chain.Model, i+1, step.Provider, stepReq.Model, reason)
+			continue
+		}
+
+		err := p.fitContext(ctx, stepReq, clientID)
+		if err == nil {
+			err = p.vision.PrepareOpenAI(ctx, stepReq, step.Provider)
+		}
+		if err != nil {
+			if last {
+				setErrorCode(out, err)
+				p.writeError(out, err.Error(), "invalid_request_error", http.StatusBadRequest)
+				break
+			}
+			log.Printf("proxy: fallback %s step %d (%s/%s) skipped: %v", chain.Model, i+1, step.Provider, stepReq.Model, err)
+			continue
+		}
+
+		apiKey, err := p.keyProvider.GetKey(ctx, step.Provider)
+		if err != nil {
+			if last {
+				p.writeError(out, fmt.Sprintf("no API key available for provider %s", step.Provider), "server_error", http.StatusServiceUnavailable)
+				break
+			}
+			log.Printf("proxy: fallback %s step %d (%s/%s) skipped: no API key", chain.Model, i+1, step.Provider, stepReq.Model)
+			continue
+		}
+
+		attempt := newAttemptWriter(out)
+		setAttribution(attempt, step.Provider, stepReq.Model, apiKey)
+		stepCtx, cancel := context.WithCancel(withUpstreamKey(mshttp.WithProvider(ctx, step.Provider), apiKey, attempt))
+		if step.TimeoutMs > 0 {
+			timer := time.AfterFunc(time.Duration(step.TimeoutMs)*time.Millisecond, func() {
+				if attempt.abort() {
+					cancel()
+				}
+			})
+			p.forward(stepCtx, attempt, stepReq, apiKey, step.Provider)
+			timer.Stop()
+		} else {
+			p.forward(stepCtx, attempt, stepReq, apiKey, step.Provider)
+		}
+		cancel()
+
+		if attempt.committed() {
+			break
+		}
+		if last || ctx.Err() != nil {
+			derr := checkDeadline(ctx)
+			switch {
+			case derr != nil:
+				p.writeError(out, derr.Error(), ErrorTypeDeadlineExceeded, http.StatusGatewayTimeout)
+			case attempt.timedOut():
+				p.writeError(out, fmt.Sprintf("%s on %s did not respond within %dms", stepReq.Model, step.Provider, step.TimeoutMs), "server_error", http.StatusGatewayTimeout)
+			case attempt.failed:
+				attempt.release()
+			default:
+				p.writeError(out, "upstream returned no response", "server_error", http.StatusBadGateway)
+			}
+			break
+		}
+		log.Printf("proxy: fallback %s step %d (%s/%s) failed: %s", chain.Model, i+1, step.Provider, stepReq.Model, attempt.failure())
+	}
+
+	if buf != nil {
+		p.writeGuardedResponse(ctx, w, buf, clientID)
+	}
+}
+
+// apply returns a copy of req transformed for this step
+func (s FallbackStep) apply(req *OpenAIRequest) *OpenAIRequest {
+	out := *req
+	out.Messages = append([]OpenAIMessage(nil), req.Messages...)
+	if s.Model != "" {
+		out.Model = s.Model
+	}
+
+	// Copied even when not capped: fitting the context may lower them in place
+	out.MaxTokens = capTokens(req.MaxTokens, s.MaxTokens)
+	out.MaxCompletionTokens = capTokens(req.MaxCompletionTokens, s.MaxTokens)
+
+	if s.DropImages {
+		for i := range out.Messages {
+			out.Messages[i].Content = dropImageParts(out.Messages[i].Content)
+		}
+	}
+
+	if s.SystemPrompt != "" {
+		messages := []OpenAIMessage{{Role: "system", Content: s.SystemPrompt}}
+		for _, m := range out.Messages {
+			if m.Role != "system" && m.Role != "developer" {
+				messages = append(messages, m)
+			}
+		}
+		out.Messages = messages
+	}
+	return &out
+}
+
+// capTokens returns a copy of v lowered to limit (no limit when zero)
+func capTokens(v *int, limit int) *int {
+	if v == nil {
+		return nil
+	}
+	n := *v
+	if limit > 0 && n > limit {
+		n = limit
+	}
+	return &n
+}
+
+// dropImageParts removes image parts from message content. Content left
+// with only text is kept as parts; content left empty becomes "".
+func dropImageParts(content interface{}) interface{} {
+	parts, ok := content.([]interface{})
+	if !ok {
+		return content
+	}
+	kept := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		if m, ok := part.(map[string]interface{}); ok {
+			if t, _ := m["type"].(string); t == "image_url" || t == "image" || t == "input_image" {
+				continue
+			}
+		}
+		kept = append(kept, part)
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+	return kept
+}
+
+// attemptWriter holds back one fallback step's response until it is known
+// to be a success. A status below 400, or a first stream write that is not
+// an error event, commits the attempt and passes everything through to the
+// client. Anything else is buffered so the next step can take over.
+type attemptWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+
+	mu     sync.Mutex
+	state  int // attemptPending, attemptCommitted or attemptAborted
+	failed bool
+}
+
+const (
+	attemptPending = iota
+	attemptCommitted
+	attemptAborted
+)
+
+func newAttemptWriter(w http.ResponseWriter) *attemptWriter {
+	return &attemptWriter{w: w, header: make(http.Header)}
+}
+
+func (a *attemptWriter) Header() http.Header {
+	return a.header
+}
+
+func (a *attemptWriter) WriteHeader(status int) {
+	if a.status != 0 {
+		return
+	}
+	a.status = status
+	if status >= 400 {
+		a.failed = true
+		return
+	}
+	a.commit()
+}
+
+func (a *attemptWriter) Write(p []byte) (int, error) {
+	// A keep-alive comment says nothing about the step's outcome; dropping
+	// it keeps a step that may still fail from being committed
+	if a.status == 0 && bytes.HasPrefix(p, []byte(":")) {
+		return len(p), nil
+	}
+	if a.status == 0 {
+		a.status = http.StatusOK
+		if bytes.HasPrefix(p, []byte("event: error\n")) {
+			a.failed = true
+		} else {
+			a.commit()
+		}
+	}
+	if a.failed {
+		return a.body.Write(p)
+	}
+	if !a.committed() {
+		return 0, context.Canceled
+	}
+	return a.w.Write(p)
+}
+
+// Flush passes through once committed; held responses are flushed by release
+func (a *attemptWriter) Flush() {
+	if a.committed() {
+		if f, ok := a.w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
+// Unwrap lets http.ResponseController reach the client connection
+func (a *attemptWriter) Unwrap() http.ResponseWriter {
+	return a.w
+}
+
+// commit copies the staged headers to the client unless the step timed out
+func (a *attemptWriter) commit() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.state != attemptPending {
+		return
+	}
+	a.state = attemptCommitted
+	copyHeader(a.w.Header(), a.header)
+	a.header = a.w.Header()
+	a.w.WriteHeader(a.status)
+}
+
+// abort marks a pending attempt as timed out, reporting whether it was
+// pending (a committed response is never cut off)
+func (a *attemptWriter) abort() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.state != attemptPending {
+		return false
+	}
+	a.state = attemptAborted
+	return true
+}
+
+func (a *attemptWriter) committed() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.state == attemptCommitted
+}
+
+func (a *attemptWriter) timedOut() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.state == attemptAborted
+}
+
+// failure describes why an uncommitted attempt failed
+func (a *attemptWriter) failure() string {
+	switch {
+	case a.timedOut():
+		return "timed out"
+	case a.status >= 400:
+		return fmt.Sprintf("status %d", a.status)
+	case a.body.Len() > 0:
+		return strings.TrimSpace(a.body.String())
+	default:
+		return "no response"
+	}
+}
+
+// release sends a failed attempt's held response to the client
+func (a *attemptWriter) release() {
+	copyHeader(a.w.Header(), a.header)
+	a.w.WriteHeader(a.status)
+	if _, err := a.w.Write(a.body.Bytes()); err != nil {
+		log.Printf("proxy: error writing response body: %v", err)
+	}
+}
+
+// copyHeader adds every value in src to dst
+func copyHeader(dst, src http.Header) {
+	for key, values := range src {
+		for _, value := range values {
+			dst.Add(key, value)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// redirectTransport sends every upstream request to one test server
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t redirectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// fallbackUpstream answers by model: "broken" fails with 503 ("stalled"
+// after a pause), "limited" with 429, "slow" after a delay, anything else
+// succeeds. It records the
+// requests it received.
+type fallbackUpstream struct {
+	mu       sync.Mutex
+	requests []OpenAIRequest
+	hosts    []string
+}
+
+func (u *fallbackUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req OpenAIRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	u.mu.Lock()
+	u.requests = append(u.requests, req)
+	u.hosts = append(u.hosts, r.Host)
+	u.mu.Unlock()
+
+	switch req.Model {
+	case "stalled":
+		time.Sleep(100 * time.Millisecond)
+		http.Error(w, `{"error":{"message":"overloaded"}}`, http.StatusServiceUnavailable)
+		return
+	case "broken":
+		http.Error(w, `{"error":{"message":"overloaded"}}`, http.StatusServiceUnavailable)
+		return
+	case "limited":
+		http.Error(w, `{"error":{"message":"rate limited"}}`, http.StatusTooManyRequests)
+		return
+	case "slow":
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if req.Stream {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(`data: {"id":"c","object":"chat.completion.chunk","model":"` + req.Model + `","choices":[{"index":0,"delta":{"content":"hi"}}]}` + "\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"id":"c","object":"chat.completion","model":"` + req.Model + `","choices":[]}`))
+}
+
+func (u *fallbackUpstream) models() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	models := make([]string, len(u.requests))
+	for i, req := range u.requests {
+		models[i] = req.Model
+	}
+	return models
+}
+
+func newFallbackProxy(t *testing.T, chains ...FallbackChain) (*OpenAIProxy, *fallbackUpstream) {
+	t.Helper()
+	upstream := &fallbackUpstream{}
+	server := httptest.NewServer(upstream)
+	t.Cleanup(server.Close)
+	target, _ := url.Parse(server.URL)
+
+	p := NewOpenAIProxy(DefaultOpenAIProxyConfig(), &mockKeyProvider{key: "sk-test"}, &mockRemapper{model: "remapped"})
+	p.SetTransport(redirectTransport{target: target})
+	set := NewFallbackChains()
+	for _, c := range chains {
+		if err := set.SetChain(c); err != nil {
+			t.Fatalf("SetChain: %v", err)
+		}
+	}
+	p.SetFallbackChains(set)
+	return p, upstream
+}
+
+func sendChat(p *OpenAIProxy, body string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	p.HandleChatCompletions(w, r)
+	return w
+}
+
+func TestFallbackChain_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		chain FallbackChain
+	}{
+		{"no model", FallbackChain{Steps: []FallbackStep{{Provider: "openai"}}}},
+		{"no steps", FallbackChain{Model: "gpt-4o"}},
+		{"no provider", FallbackChain{Model: "gpt-4o", Steps: []FallbackStep{{Model: "x"}}}},
+		{"negative timeout", FallbackChain{Model: "gpt-4o", Steps: []FallbackStep{{Provider: "openai", TimeoutMs: -1}}}},
+	}
+	for _, tt := range tests {
+		if err := tt.chain.Validate(); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+
+	chains := NewFallbackChains()
+	chains.SetChain(FallbackChain{Model: "b", Steps: []FallbackStep{{Provider: "groq"}}})
+	chains.SetChain(FallbackChain{Model: "a", Steps: []FallbackStep{{Provider: "openai"}}})
+	if got := chains.Chains(); len(got) != 2 || got[0].Model != "a" {
+		t.Errorf("Chains = %+v", got)
+	}
+	if !chains.RemoveChain("a") || chains.RemoveChain("a") {
+		t.Error("RemoveChain should succeed once")
+	}
+}
+
+func TestFallbackStep_Apply(t *testing.T) {
+	var req OpenAIRequest
+	json.Unmarshal([]byte(`{
+		"model": "gpt-4o",
+		"max_tokens": 4096,
+		"messages": [
+			{"role": "system", "content": "be verbose"},
+			{"role": "user", "content": [
+				{"type": "text", "text": "what is this?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,AAAA"}}
+			]},
+			{"role": "user", "content": [{"type": "image_url", "image_url": {"url": "https://x/y.png"}}]}
+		]
+	}`), &req)
+
+	step := FallbackStep{Provider: "groq", Model: "llama-3.1-70b", MaxTokens: 1024, DropImages: true, SystemPrompt: "be brief"}
+	out := step.apply(&req)
+
+	if out.Model != "llama-3.1-70b" || *out.MaxTokens != 1024 {
+		t.Errorf("model/max_tokens = %s/%d", out.Model, *out.MaxTokens)
+	}
+	if len(out.Messages) != 3 || out.Messages[0].Content != "be brief" || out.Messages[1].Role != "user" {
+		t.Fatalf("messages = %+v", out.Messages)
+	}
+	if parts := out.Messages[1].Content.([]interface{}); len(parts) != 1 {
+		t.Errorf("image part not dropped: %+v", parts)
+	}
+	if out.Messages[2].Content != "" {
+		t.Errorf("image-only content = %+v, want empty", out.Messages[2].Content)
+	}
+
+	// The client's request is left alone for later steps
+	if req.Model != "gpt-4o" || *req.MaxTokens != 4096 || len(req.Messages) != 3 || req.Messages[0].Content != "be verbose" {
+		t.Errorf("original request modified: %+v", req)
+	}
+	if len(req.Messages[1].Content.([]interface{})) != 2 {
+		t.Error("original content parts modified")
+	}
+
+	// A cap above the requested limit keeps the request's value
+	if out := (FallbackStep{MaxTokens: 8192}).apply(&req); *out.MaxTokens != 4096 || out.MaxTokens == req.MaxTokens {
+		t.Errorf("max_tokens = %d, want an independent 4096", *out.MaxTokens)
+	}
+}
+
+func TestOpenAIProxy_FallbackChain(t *testing.T) {
+	p, upstream := newFallbackProxy(t, FallbackChain{
+		Model: "gpt-4o",
+		Steps: []FallbackStep{
+			{Provider: "openai", Model: "broken"},
+			{Provider: "anthropic-compat", Model: "limited"},
+			{Provider: "groq", Model: "llama-3.1-70b", MaxTokens: 512, SystemPrompt: "be brief"},
+		},
+	})
+
+	w := sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if got := strings.Join(upstream.models(), ","); got != "broken,limited,llama-3.1-70b" {
+		t.Errorf("upstream models = %s", got)
+	}
+	if w.Header().Get(HeaderProvider) != "groq" || w.Header().Get(HeaderModel) != "llama-3.1-70b" {
+		t.Errorf("attribution = %s/%s", w.Header().Get(HeaderProvider), w.Header().Get(HeaderModel))
+	}
+	if strings.Contains(w.Body.String(), "overloaded") {
+		t.Errorf("failed step leaked into response: %s", w.Body.String())
+	}
+	if upstream.hosts[2] != "api.groq.com" {
+		t.Errorf("last step sent to %s", upstream.hosts[2])
+	}
+
+	last := upstream.requests[2]
+	if *last.MaxTokens != 512 || last.Messages[0].Role != "system" || last.Messages[0].Content != "be brief" {
+		t.Errorf("step transformation not applied: %+v", last)
+	}
+}
+
+func TestOpenAIProxy_FallbackChainExhausted(t *testing.T) {
+	p, _ := newFallbackProxy(t, FallbackChain{
+		Model: "gpt-4o",
+		Steps: []FallbackStep{{Provider: "openai", Model: "broken"}, {Provider: "groq", Model: "limited"}},
+	})
+
+	w := sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, nil)
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "rate limited") {
+		t.Errorf("expected the last step's 429, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get(HeaderProvider) != "groq" {
+		t.Errorf("provider = %s, want groq", w.Header().Get(HeaderProvider))
+	}
+}
+
+func TestOpenAIProxy_FallbackChainTimeout(t *testing.T) {
+	p, upstream := newFallbackProxy(t, FallbackChain{
+		Model: "gpt-4o",
+		Steps: []FallbackStep{{Provider: "openai", Model: "slow", TimeoutMs: 50}, {Provider: "groq", Model: "llama"}},
+	})
+
+	start := time.Now()
+	w := sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, nil)
+	if w.Code != http.StatusOK || w.Header().Get(HeaderModel) != "llama" {
+		t.Fatalf("status = %d, model = %s", w.Code, w.Header().Get(HeaderModel))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("slow step was not cut off (took %v)", elapsed)
+	}
+	if got := strings.Join(upstream.models(), ","); got != "slow,llama" {
+		t.Errorf("upstream models = %s", got)
+	}
+
+	// A timeout on the last step is reported as such
+	p, _ = newFallbackProxy(t, FallbackChain{Model: "gpt-4o", Steps: []FallbackStep{{Provider: "openai", Model: "slow", TimeoutMs: 50}}})
+	if w := sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, nil); w.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want 504", w.Code)
+	}
+}
+
+func TestOpenAIProxy_FallbackChainStreaming(t *testing.T) {
+	p, _ := newFallbackProxy(t, FallbackChain{
+		Model: "gpt-4o",
+		Steps: []FallbackStep{{Provider: "openai", Model: "broken"}, {Provider: "groq", Model: "llama"}},
+	})
+
+	w := sendChat(p, `{"model": "gpt-4o", "stream": true, "messages": [{"role": "user", "content": "hi"}]}`, nil)
+	body, _ := io.ReadAll(w.Body)
+	if strings.Contains(string(body), "event: error") || !strings.Contains(string(body), `"model":"llama"`) || !strings.Contains(string(body), "[DONE]") {
+		t.Errorf("unexpected stream:\n%s", body)
+	}
+	if w.Header().Get("Content-Type") != "text/event-stream" || w.Header().Get(HeaderProvider) != "groq" {
+		t.Errorf("headers = %v", w.Header())
+	}
+
+	// The last step's stream error is passed on
+	p, _ = newFallbackProxy(t, FallbackChain{Model: "gpt-4o", Steps: []FallbackStep{{Provider: "openai", Model: "broken"}}})
+	w = sendChat(p, `{"model": "gpt-4o", "stream": true, "messages": [{"role": "user", "content": "hi"}]}`, nil)
+	if !strings.Contains(w.Body.String(), "event: error") || !strings.Contains(w.Body.String(), "503") {
+		t.Errorf("expected stream error, got:\n%s", w.Body.String())
+	}
+
+	// Keep-alives sent while a step is pending do not commit it
+	p, _ = newFallbackProxy(t, FallbackChain{
+		Model: "gpt-4o",
+		Steps: []FallbackStep{{Provider: "openai", Model: "stalled"}, {Provider: "groq", Model: "llama"}},
+	})
+	p.config.StreamKeepAlive = 20 * time.Millisecond
+	w = sendChat(p, `{"model": "gpt-4o", "stream": true, "messages": [{"role": "user", "content": "hi"}]}`, nil)
+	if strings.Contains(w.Body.String(), "event: error") || !strings.Contains(w.Body.String(), `"model":"llama"`) {
+		t.Errorf("keep-alive committed a failing step:\n%s", w.Body.String())
+	}
+}
+
+func TestOpenAIProxy_FallbackChainBypass(t *testing.T) {
+	p, upstream := newFallbackProxy(t, FallbackChain{Model: "gpt-4o", Steps: []FallbackStep{{Provider: "openai", Model: "broken"}}})
+
+	// Models without a chain are remapped as usual
+	if w := sendChat(p, `{"model": "gpt-4", "messages": [{"role": "user", "content": "hi"}]}`, nil); w.Code != http.StatusOK {
+		t.Errorf("status = %d", w.Code)
+	}
+	// An explicit provider skips the chain
+	header := http.Header{HeaderProvider: {"groq"}}
+	if w := sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, header); w.Code != http.StatusOK {
+		t.Errorf("status = %d", w.Code)
+	}
+	if got := strings.Join(upstream.models(), ","); got != "remapped,remapped" {
+		t.Errorf("upstream models = %s", got)
+	}
+}
@@ -0,0 +1,290 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jeffersonwarrior/modelscan/internal/guardrail"
+)
+
+// Guardrail response headers
+const (
+	// GuardrailHeader lists the guardrail rules triggered by a request or response
+	GuardrailHeader = "X-Guardrail-Findings"
+	// RiskScoreHeader carries the highest risk score (e.g. prompt injection) reported
+	RiskScoreHeader = "X-Guardrail-Risk-Score"
+)
+
+// Guardrails filters request and response text (usually a *guardrail.Pipeline)
+type Guardrails interface {
+	Apply(ctx context.Context, clientID string, dir guardrail.Direction, text string) (*guardrail.Result, error)
+}
+
+// SetGuardrails enables content filtering of requests and non-streaming responses
+func (p *OpenAIProxy) SetGuardrails(g Guardrails) {
+	p.guardrails = g
+}
+
+// SetGuardrails enables content filtering of requests and non-streaming responses
+func (p *AnthropicProxy) SetGuardrails(g Guardrails) {
+	p.guardrails = g
+}
+
+// guardScan runs every text field of a message through the guardrails,
+// stopping at the first block or error
+type guardScan struct {
+	ctx      context.Context
+	g        Guardrails
+	clientID string
+	dir      guardrail.Direction
+
+	findings []guardrail.Finding
+	score    float64
+	blocked  *guardrail.Finding
+	err      error
+}
+
+// text filters one piece of text and returns its replacement
+func (s *guardScan) text(text string) string {
+	if text == "" || s.blocked != nil || s.err != nil {
+		return text
+	}
+
+	result, err := s.g.Apply(s.ctx, s.clientID, s.dir, text)
+	if err != nil {
+		s.err = err
+		return text
+	}
+	s.findings = append(s.findings, result.Findings...)
+	if result.Score > s.score {
+		s.score = result.Score
+	}
+	if result.Blocked {
+		s.blocked = &result.Findings[len(result.Findings)-1]
+		return text
+	}
+	return result.Text
+}
+
+// blockedMessage describes why content was blocked
+func (s *guardScan) blockedMessage() string {
+	return fmt.Sprintf("%s blocked by guardrail %q (%s)", s.dir, s.blocked.Rule, s.blocked.Detector)
+}
+
+// annotate adds the triggered rule:detector pairs and the risk score to the
+// response headers. Log-only findings are not exposed to the client.
+func (s *guardScan) annotate(h http.Header) {
+	if s.score > 0 {
+		if existing, err := strconv.ParseFloat(h.Get(RiskScoreHeader), 64); err != nil || s.score > existing {
+			h.Set(RiskScoreHeader, strconv.FormatFloat(s.score, 'f', 2, 64))
+		}
+	}
+
+	var rules []string
+	if existing := h.Get(GuardrailHeader); existing != "" {
+		rules = strings.Split(existing, ",")
+	}
+	for _, f := range s.findings {
+		if f.Action == guardrail.ActionLog {
+			continue
+		}
+		rule := f.Rule + ":" + f.Detector
+		if !containsString(rules, rule) {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) > 0 {
+		h.Set(GuardrailHeader, strings.Join(rules, ","))
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// guardOpenAIRequest filters message text in place
+func guardOpenAIRequest(s *guardScan, req *OpenAIRequest) {
+	for i := range req.Messages {
+		msg := &req.Messages[i]
+		switch content := msg.Content.(type) {
+		case string:
+			msg.Content = s.text(content)
+		case []interface{}:
+			for _, part := range content {
+				if block, ok := part.(map[string]interface{}); ok {
+					if text, ok := block["text"].(string); ok {
+						block["text"] = s.text(text)
+					}
+				}
+			}
+		}
+		for j := range msg.ToolCalls {
+			msg.ToolCalls[j].Function.Arguments = s.text(msg.ToolCalls[j].Function.Arguments)
+		}
+	}
+}
+
+// guardAnthropicRequest filters the system prompt and message text in place
+func guardAnthropicRequest(s *guardScan, req *AnthropicRequest) {
+	if len(req.SystemBlocks) > 0 {
+		for i := range req.SystemBlocks {
+			req.SystemBlocks[i].Text = s.text(req.SystemBlocks[i].Text)
+		}
+		req.System = convertContentToString(req.SystemBlocks)
+	} else {
+		req.System = s.text(req.System)
+	}
+	for i := range req.Messages {
+		for j := range req.Messages[i].Content {
+			part := &req.Messages[i].Content[j]
+			part.Text = s.text(part.Text)
+			part.Content = s.text(part.Content)
+		}
+	}
+}
+
+// guardOpenAIResponse filters choices[].message.content of a decoded response
+func guardOpenAIResponse(s *guardScan, body map[string]interface{}) {
+	choices, _ := body["choices"].([]interface{})
+	for _, c := range choices {
+		choice, _ := c.(map[string]interface{})
+		msg, _ := choice["message"].(map[string]interface{})
+		if text, ok := msg["content"].(string); ok {
+			msg["content"] = s.text(text)
+		}
+	}
+}
+
+// guardAnthropicResponse filters content[].text of a decoded response
+func guardAnthropicResponse(s *guardScan, body map[string]interface{}) {
+	parts, _ := body["content"].([]interface{})
+	for _, p := range parts {
+		part, _ := p.(map[string]interface{})
+		if text, ok := part["text"].(string); ok {
+			part["text"] = s.text(text)
+		}
+	}
+}
+
+// bufferedResponse holds a complete upstream response so it can be
+// filtered before anything reaches the client
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+
+func (b *bufferedResponse) WriteHeader(status int) { b.status = status }
+
+// guardResponse filters a buffered successful JSON response and writes it to w.
+// Error responses and bodies that are not JSON objects pass through unchanged.
+// On a block or guard error nothing is written, so the caller can report it
+// in its own error format.
+func guardResponse(s *guardScan, w http.ResponseWriter, buf *bufferedResponse, filter func(*guardScan, map[string]interface{})) {
+	body := buf.body.Bytes()
+
+	var decoded map[string]interface{}
+	if buf.status == http.StatusOK && json.Unmarshal(body, &decoded) == nil {
+		filter(s, decoded)
+		if s.blocked != nil || s.err != nil {
+			return
+		}
+		if encoded, err := json.Marshal(decoded); err == nil {
+			body = encoded
+		}
+	}
+
+	for key, values := range buf.header {
+		if key == "Content-Length" {
+			continue
+		}
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	s.annotate(w.Header())
+	w.WriteHeader(buf.status)
+	_, _ = w.Write(body)
+}
+
+// guardRequest filters the request in place. It returns false after writing
+// an error response when the request is blocked or a guard fails.
+func (p *OpenAIProxy) guardRequest(ctx context.Context, w http.ResponseWriter, req *OpenAIRequest, clientID string) bool {
+	s := &guardScan{ctx: ctx, g: p.guardrails, clientID: clientID, dir: guardrail.Request}
+	guardOpenAIRequest(s, req)
+	s.annotate(w.Header())
+
+	switch {
+	case s.err != nil:
+		p.writeError(w, s.err.Error(), "server_error", http.StatusBadGateway)
+		return false
+	case s.blocked != nil:
+		p.writeError(w, s.blockedMessage(), "invalid_request_error", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeGuardedResponse filters a buffered response and writes it to the client
+func (p *OpenAIProxy) writeGuardedResponse(ctx context.Context, w http.ResponseWriter, buf *bufferedResponse, clientID string) {
+	s := &guardScan{ctx: ctx, g: p.guardrails, clientID: clientID, dir: guardrail.Response}
+	guardResponse(s, w, buf, guardOpenAIResponse)
+
+	switch {
+	case s.err != nil:
+		p.writeError(w, s.err.Error(), "server_error", http.StatusBadGateway)
+	case s.blocked != nil:
+		s.annotate(w.Header())
+		p.writeError(w, s.blockedMessage(), "invalid_request_error", http.StatusBadRequest)
+	}
+}
+
+// guardRequest filters the request in place. It returns false after writing
+// an error response when the request is blocked or a guard fails.
+func (p *AnthropicProxy) guardRequest(ctx context.Context, w http.ResponseWriter, req *AnthropicRequest, clientID string) bool {
+	s := &guardScan{ctx: ctx, g: p.guardrails, clientID: clientID, dir: guardrail.Request}
+	guardAnthropicRequest(s, req)
+	s.annotate(w.Header())
+
+	switch {
+	case s.err != nil:
+		p.writeError(w, s.err.Error(), http.StatusBadGateway)
+		return false
+	case s.blocked != nil:
+		p.writeError(w, s.blockedMessage(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// writeGuardedResponse filters a buffered response and writes it to the client
+func (p *AnthropicProxy) writeGuardedResponse(ctx context.Context, w http.ResponseWriter, buf *bufferedResponse, clientID string) {
+	s := &guardScan{ctx: ctx, g: p.guardrails, clientID: clientID, dir: guardrail.Response}
+	guardResponse(s, w, buf, guardAnthropicResponse)
+
+	switch {
+	case s.err != nil:
+		p.writeError(w, s.err.Error(), http.StatusBadGateway)
+	case s.blocked != nil:
+		s.annotate(w.Header())
+		p.writeError(w, s.blockedMessage(), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,22 @@
+package proxy
+
+// ProviderIncidents reports providers with an ongoing incident on their
+// status page. Unlike a drain, an incident does not turn requests away: it
+// only moves traffic that has somewhere else to go.
+type ProviderIncidents interface {
+	Degraded(provider string) (reason string, degraded bool)
+}
+
+// SetIncidents makes fallback chains skip steps whose provider has an
+// ongoing incident, unless the step is the last one
+func (p *OpenAIProxy) SetIncidents(i ProviderIncidents) {
+	p.incidents = i
+}
+
+// incidentFor returns the provider's ongoing incident, if any
+func (p *OpenAIProxy) incidentFor(provider string) (string, bool) {
+	if p.incidents == nil {
+		return "", false
+	}
+	return p.incidents.Degraded(provider)
+}
@@ -0,0 +1,42 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// incidentSet reports fixed providers as having an ongoing incident
+type incidentSet map[string]string
+
+func (s incidentSet) Degraded(provider string) (string, bool) {
+	reason, ok := s[provider]
+	return reason, ok
+}
+
+func TestOpenAIProxy_FallbackChainSkipsIncidentSteps(t *testing.T) {
+	p, upstream := newFallbackProxy(t, FallbackChain{
+		Model: "gpt-4o",
+		Steps: []FallbackStep{{Provider: "openai", Model: "gpt-4o"}, {Provider: "groq", Model: "llama"}},
+	})
+	p.SetIncidents(incidentSet{"openai": "major incident: elevated errors (investigating)"})
+
+	w := sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, nil)
+	if w.Code != http.StatusOK || w.Header().Get(HeaderProvider) != "groq" {
+		t.Fatalf("expected the chain to skip the step with an incident, got %d from %s", w.Code, w.Header().Get(HeaderProvider))
+	}
+
+	// The last step is still tried, as are requests that name a provider
+	p.SetIncidents(incidentSet{"openai": "major", "groq": "major"})
+	w = sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, nil)
+	if w.Code != http.StatusOK || w.Header().Get(HeaderProvider) != "groq" {
+		t.Errorf("expected the last step to serve despite its incident, got %d from %s", w.Code, w.Header().Get(HeaderProvider))
+	}
+	w = sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, http.Header{HeaderProvider: {"openai"}})
+	if w.Code != http.StatusOK {
+		t.Errorf("expected a direct request to be served, got %d", w.Code)
+	}
+	if got := strings.Join(upstream.models(), ","); got != "llama,llama,remapped" {
+		t.Errorf("upstream models = %s", got)
+	}
+}
@@ -0,0 +1,147 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// maxKeyFailovers bounds how many other keys one request tries after an
+// authentication error
+const maxKeyFailovers = 3
+
+// KeyFailureReporter is told when an upstream rejects a key with 401 or
+// 403, so the key is taken out of rotation
+type KeyFailureReporter interface {
+	ReportAuthFailure(ctx context.Context, providerID, apiKey string, status int) bool
+}
+
+// SetKeyFailover retries upstream requests rejected with 401 or 403 with
+// another key for the same provider, reporting the rejected key. Call it
+// after SetTransport, SetFaultInjector and SetRetryBudget.
+func (p *OpenAIProxy) SetKeyFailover(reporter KeyFailureReporter) {
+	p.httpClient.Transport = newKeyFailover(p.httpClient.Transport, p.keyProvider, reporter, p.retryBudget)
+	p.streamingClient.Transport = newKeyFailover(p.streamingClient.Transport, p.keyProvider, reporter, p.retryBudget)
+}
+
+// SetRetryBudget caps the resends of key failover per provider; once a
+// provider's budget is spent its 401 and 403 responses are returned as is
+func (p *OpenAIProxy) SetRetryBudget(budget *mshttp.RetryBudget) {
+	p.retryBudget = budget
+}
+
+// SetKeyFailover retries upstream requests rejected with 401 or 403 with
+// another key for the same provider, reporting the rejected key. Call it
+// after SetTransport, SetFaultInjector and SetRetryBudget.
+func (p *AnthropicProxy) SetKeyFailover(reporter KeyFailureReporter) {
+	p.httpClient.Transport = newKeyFailover(p.httpClient.Transport, p.keyProvider, reporter, p.retryBudget)
+	p.streamingClient.Transport = newKeyFailover(p.streamingClient.Transport, p.keyProvider, reporter, p.retryBudget)
+}
+
+// SetRetryBudget caps the resends of key failover per provider; once a
+// provider's budget is spent its 401 and 403 responses are returned as is
+func (p *AnthropicProxy) SetRetryBudget(budget *mshttp.RetryBudget) {
+	p.retryBudget = budget
+}
+
+type upstreamKeyContext struct{}
+
+// upstreamKey is the key a request is sent with and where to record a
+// replacement
+type upstreamKey struct {
+	key      string
+	switched func(newKey string)
+}
+
+// withUpstreamKey tags ctx with the key an upstream request carries so the
+// key failover transport can replace it. The attribution header on w, when
+// given, follows the replacement.
+func withUpstreamKey(ctx context.Context, apiKey string, w http.ResponseWriter) context.Context {
+	uk := &upstreamKey{key: apiKey}
+	if w != nil {
+		uk.switched = func(newKey string) { w.Header().Set(HeaderKey, KeyFingerprint(newKey)) }
+	}
+	return context.WithValue(ctx, upstreamKeyContext{}, uk)
+}
+
+// keyFailover is an http.RoundTripper that resends requests rejected for
+// their key with the provider's next key
+type keyFailover struct {
+	next     http.RoundTripper
+	keys     KeyProvider
+	reporter KeyFailureReporter
+	budget   *mshttp.RetryBudget // nil: resends unbudgeted
+}
+
+func newKeyFailover(next http.RoundTripper, keys KeyProvider, reporter KeyFailureReporter, budget *mshttp.RetryBudget) *keyFailover {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &keyFailover{next: next, keys: keys, reporter: reporter, budget: budget}
+}
+
+func (t *keyFailover) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	ctx := req.Context()
+	provider := mshttp.ProviderFrom(ctx)
+	uk, _ := ctx.Value(upstreamKeyContext{}).(*upstreamKey)
+	if provider == "" || uk == nil || uk.key == "" {
+		return resp, err
+	}
+
+	for attempt := 0; err == nil && attempt < maxKeyFailovers; attempt++ {
+		if resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden {
+			break
+		}
+		if t.reporter.ReportAuthFailure(ctx, provider, uk.key, resp.StatusCode) {
+			log.Printf("proxy: %s rejected key %s with %d; failing over", provider, KeyFingerprint(uk.key), resp.StatusCode)
+		}
+		newKey, kerr := t.keys.GetKey(ctx, provider)
+		if kerr != nil || newKey == uk.key || (req.Body != nil && req.GetBody == nil) {
+			break
+		}
+		if t.budget != nil && !t.budget.Allow(provider) {
+			log.Printf("proxy: %s retry budget exhausted; not failing over", provider)
+			break
+		}
+		retry, rerr := withKey(req, uk.key, newKey)
+		if rerr != nil {
+			break
+		}
+
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		_ = resp.Body.Close()
+		uk.key = newKey
+		if uk.switched != nil {
+			uk.switched(newKey)
+		}
+		req = retry
+		resp, err = t.next.RoundTrip(req)
+	}
+	return resp, err
+}
+
+// withKey clones req with every occurrence of oldKey in its headers and
+// query replaced, which covers bearer, API key header and query parameter
+// authentication alike
+func withKey(req *http.Request, oldKey, newKey string) (*http.Request, error) {
+	out := req.Clone(req.Context())
+	for name, values := range out.Header {
+		for i, v := range values {
+			out.Header[name][i] = strings.ReplaceAll(v, oldKey, newKey)
+		}
+	}
+	out.URL.RawQuery = strings.ReplaceAll(out.URL.RawQuery, oldKey, newKey)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		out.Body = body
+	}
+	return out, nil
+}
@@ -0,0 +1,123 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// failoverKeys hands out the first key not reported as rejected
+type failoverKeys struct {
+	mu       sync.Mutex
+	keys     []string
+	rejected map[string]bool
+	reports  []string
+}
+
+func (k *failoverKeys) GetKey(ctx context.Context, providerID string) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, key := range k.keys {
+		if !k.rejected[key] {
+			return key, nil
+		}
+	}
+	return k.keys[0], nil
+}
+
+func (k *failoverKeys) ReportAuthFailure(ctx context.Context, providerID, apiKey string, status int) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.reports = append(k.reports, apiKey)
+	first := !k.rejected[apiKey]
+	k.rejected[apiKey] = true
+	return first
+}
+
+func TestOpenAIProxy_KeyFailover(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get("Authorization"))
+		mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer sk-good" {
+			http.Error(w, `{"error": {"message": "invalid api key"}}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "hi"}}]}`))
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+
+	keys := &failoverKeys{keys: []string{"sk-revoked", "sk-bad", "sk-good"}, rejected: map[string]bool{}}
+	p := NewOpenAIProxy(DefaultOpenAIProxyConfig(), keys, &mockRemapper{model: "gpt-4o"})
+	p.SetTransport(redirectTransport{target: target})
+	p.SetKeyFailover(keys)
+
+	body := `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`
+	w := sendChat(p, body, http.Header{HeaderProvider: {"openai"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected failover to the working key, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(HeaderKey); got != KeyFingerprint("sk-good") {
+		t.Errorf("attribution = %s, want the key that served", got)
+	}
+	if len(keys.reports) != 2 || keys.reports[0] != "sk-revoked" || keys.reports[1] != "sk-bad" {
+		t.Errorf("reports = %v", keys.reports)
+	}
+
+	// Later requests start from a working key
+	seen = nil
+	if w := sendChat(p, body, http.Header{HeaderProvider: {"openai"}}); w.Code != http.StatusOK || len(seen) != 1 {
+		t.Errorf("expected one upstream request, got %d: %v", w.Code, seen)
+	}
+
+	// With no other key left the rejection reaches the client
+	keys.keys = []string{"sk-expired"}
+	seen = nil
+	if w := sendChat(p, body, http.Header{HeaderProvider: {"openai"}}); w.Code != http.StatusUnauthorized || len(seen) != 1 {
+		t.Errorf("expected the 401 after one attempt, got %d: %v", w.Code, seen)
+	}
+}
+
+func TestOpenAIProxy_KeyFailoverRetryBudget(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get("Authorization"))
+		mu.Unlock()
+		http.Error(w, `{"error": {"message": "invalid api key"}}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+
+	budget, err := mshttp.NewRetryBudget(mshttp.RetryBudgetConfig{Retries: 1, Window: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := &failoverKeys{keys: []string{"sk-revoked", "sk-bad", "sk-good"}, rejected: map[string]bool{}}
+	p := NewOpenAIProxy(DefaultOpenAIProxyConfig(), keys, &mockRemapper{model: "gpt-4o"})
+	p.SetTransport(redirectTransport{target: target})
+	p.SetRetryBudget(budget)
+	p.SetKeyFailover(keys)
+
+	// The budget covers one resend; the rejection of the second key is
+	// returned rather than trying the third
+	body := `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`
+	if w := sendChat(p, body, http.Header{HeaderProvider: {"openai"}}); w.Code != http.StatusUnauthorized || len(seen) != 2 {
+		t.Errorf("expected the 401 after two attempts, got %d: %v", w.Code, seen)
+	}
+	stats := budget.Stats()
+	if len(stats) != 1 || stats[0].Provider != "openai" || stats[0].Allowed != 1 || stats[0].Denied != 1 {
+		t.Errorf("budget stats = %+v", stats)
+	}
+}
@@ -0,0 +1,28 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// Error types reported when an upstream response breaks a transport limit
+const (
+	ErrorTypeResponseTooLarge = "upstream_response_too_large"
+	ErrorTypeUpstreamTimeout  = "upstream_timeout"
+)
+
+// upstreamReadError describes a failed upstream body read for the client:
+// the message, error type and status to report
+func upstreamReadError(err error) (string, string, int) {
+	var limitErr *mshttp.LimitError
+	if !errors.As(err, &limitErr) {
+		return "failed to read upstream response", "server_error", http.StatusBadGateway
+	}
+	if errors.Is(err, mshttp.ErrResponseReadTimeout) {
+		return fmt.Sprintf("upstream response stalled: %v", limitErr), ErrorTypeUpstreamTimeout, http.StatusGatewayTimeout
+	}
+	return fmt.Sprintf("upstream response rejected: %v", limitErr), ErrorTypeResponseTooLarge, http.StatusBadGateway
+}
@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ProviderAvailability reports providers an operator has taken out of
+// rotation, by draining them or for a maintenance window. Requests already
+// in flight are left to finish; only new requests are turned away.
+type ProviderAvailability interface {
+	// Unavailable reports whether new requests must not be sent to the
+	// provider and, when the window has an end, when it is back
+	Unavailable(provider string) (until time.Time, unavailable bool)
+}
+
+// SetAvailability keeps new requests away from drained providers.
+// Fallback chains skip them; other requests get a 503.
+func (p *OpenAIProxy) SetAvailability(a ProviderAvailability) {
+	p.availability = a
+}
+
+// SetAvailability keeps new requests away from drained providers, which
+// get a 503
+func (p *AnthropicProxy) SetAvailability(a ProviderAvailability) {
+	p.availability = a
+}
+
+// MaintenanceError reports a request turned away from a provider that is
+// out of rotation
+type MaintenanceError struct {
+	Provider string
+	Until    time.Time // Zero for an open-ended drain
+}
+
+func (e *MaintenanceError) Error() string {
+	if e.Until.IsZero() {
+		return fmt.Sprintf("provider %s is draining for maintenance", e.Provider)
+	}
+	return fmt.Sprintf("provider %s is under maintenance until %s", e.Provider, e.Until.UTC().Format(time.RFC3339))
+}
+
+// checkAvailable returns a *MaintenanceError when provider is out of rotation
+func checkAvailable(a ProviderAvailability, provider string) *MaintenanceError {
+	if a == nil {
+		return nil
+	}
+	if until, down := a.Unavailable(provider); down {
+		return &MaintenanceError{Provider: provider, Until: until}
+	}
+	return nil
+}
+
+// maintenanceStatus tags a response for a request turned away by
+// maintenance and returns its status, telling the client when to retry if
+// the window has an end
+func maintenanceStatus(w http.ResponseWriter, err *MaintenanceError) int {
+	if !err.Until.IsZero() {
+		if secs := int(time.Until(err.Until)/time.Second) + 1; secs > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+		}
+	}
+	return http.StatusServiceUnavailable
+}
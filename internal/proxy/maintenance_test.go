@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// maintenanceSet marks providers unavailable until the given time
+type maintenanceSet map[string]time.Time
+
+func (m maintenanceSet) Unavailable(provider string) (time.Time, bool) {
+	until, ok := m[provider]
+	return until, ok
+}
+
+func TestOpenAIProxy_DrainedProvider(t *testing.T) {
+	p, upstream := newFallbackProxy(t)
+	p.SetAvailability(maintenanceSet{
+		"openai": time.Now().Add(10 * time.Minute),
+		"groq":   {},
+	})
+
+	w := sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, nil)
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "under maintenance until") {
+		t.Errorf("expected 503 for a provider in maintenance, got %d: %s", w.Code, w.Body.String())
+	}
+	if retry := w.Header().Get("Retry-After"); retry != "600" && retry != "601" {
+		t.Errorf("Retry-After = %q, want about 600", retry)
+	}
+
+	w = sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, http.Header{HeaderProvider: {"groq"}})
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "draining") || w.Header().Get("Retry-After") != "" {
+		t.Errorf("expected 503 without Retry-After for a drain, got %d %q: %s", w.Code, w.Header().Get("Retry-After"), w.Body.String())
+	}
+
+	w = sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, http.Header{HeaderProvider: {"together"}})
+	if w.Code != http.StatusOK {
+		t.Errorf("expected other providers to serve, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(upstream.models()) != 1 {
+		t.Errorf("drained providers were sent requests: %v", upstream.models())
+	}
+}
+
+func TestOpenAIProxy_FallbackChainSkipsDrainedSteps(t *testing.T) {
+	p, upstream := newFallbackProxy(t, FallbackChain{
+		Model: "gpt-4o",
+		Steps: []FallbackStep{{Provider: "openai", Model: "gpt-4o"}, {Provider: "groq", Model: "llama"}},
+	})
+	p.SetAvailability(maintenanceSet{"openai": {}})
+
+	w := sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, nil)
+	if w.Code != http.StatusOK || w.Header().Get(HeaderProvider) != "groq" {
+		t.Fatalf("expected the chain to skip the drained step, got %d from %s", w.Code, w.Header().Get(HeaderProvider))
+	}
+	if got := strings.Join(upstream.models(), ","); got != "llama" {
+		t.Errorf("upstream models = %s", got)
+	}
+
+	p.SetAvailability(maintenanceSet{"openai": {}, "groq": {}})
+	w = sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, nil)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 when every step is drained, got %d", w.Code)
+	}
+}
+
+func TestAnthropicProxy_DrainedProvider(t *testing.T) {
+	p := NewAnthropicProxy(DefaultAnthropicProxyConfig(), &mockKeyProvider{key: "test-key"}, nil)
+	p.SetAvailability(maintenanceSet{"anthropic": {}})
+
+	rec := httptest.NewRecorder()
+	p.HandleMessages(rec, httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(
+		`{"model":"claude-3-5-sonnet","max_tokens":100,"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`)))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "draining") {
+		t.Errorf("expected 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,296 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+)
+
+// ModelLimit caps the traffic sent to a model whatever its provider and
+// API keys would allow, e.g. to hold an expensive model to an internal
+// policy. Zero caps are unlimited.
+type ModelLimit struct {
+	Model       string `json:"model"`                   // Upstream model, or a pattern with "*" wildcards
+	RPM         int    `json:"rpm,omitempty"`           // Requests per minute
+	TPM         int    `json:"tpm,omitempty"`           // Tokens per minute, counting prompt and max completion tokens
+	MaxInFlight int    `json:"max_in_flight,omitempty"` // Concurrent requests
+}
+
+// Validate checks the limit names a model and sets a cap
+func (l ModelLimit) Validate() error {
+	if l.Model == "" {
+		return fmt.Errorf("model is required")
+	}
+	if l.RPM < 0 || l.TPM < 0 || l.MaxInFlight < 0 {
+		return fmt.Errorf("caps must not be negative")
+	}
+	if l.RPM == 0 && l.TPM == 0 && l.MaxInFlight == 0 {
+		return fmt.Errorf("at least one of rpm, tpm or max_in_flight is required")
+	}
+	return nil
+}
+
+// ModelLimitError is returned for requests refused because a model cap is
+// reached. It classifies as providererr.ErrRateLimited.
+type ModelLimitError struct {
+	Model      string // The cap's model or pattern
+	Cap        string // "rpm", "tpm" or "concurrency"
+	RetryAfter time.Duration
+}
+
+func (e *ModelLimitError) Error() string {
+	return fmt.Sprintf("model %s is at its %s cap; retry after %s", e.Model, e.Cap, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap classifies the refusal as providererr.ErrRateLimited
+func (e *ModelLimitError) Unwrap() error {
+	return providererr.ErrRateLimited
+}
+
+// ModelLimitStats describes one model cap and its use in the current minute
+type ModelLimitStats struct {
+	ModelLimit
+	Requests int   `json:"requests"` // This minute
+	Tokens   int   `json:"tokens"`   // This minute
+	InFlight int   `json:"in_flight"`
+	Rejected int64 `json:"rejected"`
+}
+
+// modelCap is a limit and what has been charged against it
+type modelCap struct {
+	limit ModelLimit
+	slots chan struct{} // nil without a concurrency cap
+
+	// Guarded by ModelLimits.mu
+	windowStart time.Time
+	requests    int
+	tokens      int
+
+	rejected atomic.Int64
+}
+
+// ModelLimits holds per-model caps, applied on top of the provider
+// concurrency caps and key rate limits. A request counts against every cap
+// whose model matches the model sent upstream.
+type ModelLimits struct {
+	queueTimeout time.Duration
+
+	mu   sync.Mutex
+	caps []*modelCap
+}
+
+// NewModelLimits creates an empty set of model caps. Requests over a
+// concurrency cap wait up to queueTimeout (default 10s) for a slot.
+func NewModelLimits(queueTimeout time.Duration) *ModelLimits {
+	if queueTimeout <= 0 {
+		queueTimeout = 10 * time.Second
+	}
+	return &ModelLimits{queueTimeout: queueTimeout}
+}
+
+// SetLimit replaces the cap for the same model or pattern, or adds it.
+// Its counts start over.
+func (m *ModelLimits) SetLimit(l ModelLimit) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+	c := &modelCap{limit: l}
+	if l.MaxInFlight > 0 {
+		c.slots = make(chan struct{}, l.MaxInFlight)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i := m.index(l.Model); i >= 0 {
+		m.caps[i] = c
+	} else {
+		m.caps = append(m.caps, c)
+	}
+	return nil
+}
+
+// RemoveLimit removes the cap for a model or pattern, reporting whether it
+// existed
+func (m *ModelLimits) RemoveLimit(model string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.index(model)
+	if i < 0 {
+		return false
+	}
+	m.caps = append(m.caps[:i], m.caps[i+1:]...)
+	return true
+}
+
+// Limits returns the caps sorted by model
+func (m *ModelLimits) Limits() []ModelLimit {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	limits := make([]ModelLimit, len(m.caps))
+	for i, c := range m.caps {
+		limits[i] = c.limit
+	}
+	sort.Slice(limits, func(i, j int) bool { return limits[i].Model < limits[j].Model })
+	return limits
+}
+
+// Stats returns each cap with its use, sorted by model
+func (m *ModelLimits) Stats() []ModelLimitStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	stats := make([]ModelLimitStats, len(m.caps))
+	for i, c := range m.caps {
+		c.roll(now)
+		stats[i] = ModelLimitStats{
+			ModelLimit: c.limit,
+			Requests:   c.requests,
+			Tokens:     c.tokens,
+			InFlight:   len(c.slots),
+			Rejected:   c.rejected.Load(),
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Model < stats[j].Model })
+	return stats
+}
+
+// index returns the position of a cap, or -1. Callers hold m.mu.
+func (m *ModelLimits) index(model string) int {
+	for i, c := range m.caps {
+		if c.limit.Model == model {
+			return i
+		}
+	}
+	return -1
+}
+
+// matching returns the caps applying to model
+func (m *ModelLimits) matching(model string) []*modelCap {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var caps []*modelCap
+	for _, c := range m.caps {
+		if matchWildcard(c.limit.Model, model) {
+			caps = append(caps, c)
+		}
+	}
+	return caps
+}
+
+// roll starts a new one-minute window once the current one is over.
+// Callers hold ModelLimits.mu.
+func (c *modelCap) roll(now time.Time) {
+	if now.Sub(c.windowStart) >= time.Minute {
+		c.windowStart, c.requests, c.tokens = now, 0, 0
+	}
+}
+
+// over returns the rate cap a request of tokens would exceed, and when
+// the window frees up. A request larger than the whole TPM cap goes
+// through in an otherwise empty window rather than never. Callers hold
+// ModelLimits.mu.
+func (c *modelCap) over(now time.Time, tokens int) (string, time.Duration) {
+	c.roll(now)
+	wait := c.windowStart.Add(time.Minute).Sub(now)
+	if c.limit.RPM > 0 && c.requests >= c.limit.RPM {
+		return "rpm", wait
+	}
+	if c.limit.TPM > 0 && c.tokens > 0 && c.tokens+tokens > c.limit.TPM {
+		return "tpm", wait
+	}
+	return "", 0
+}
+
+// Acquire admits a request of tokens (prompt plus max completion tokens,
+// as providers count them) to model. It waits for a slot under any
+// concurrency cap, then charges the request to each cap's minute. It
+// returns a *ModelLimitError when a cap is reached and the context's error
+// when it ends first. The returned func releases the slots.
+func (m *ModelLimits) Acquire(ctx context.Context, model string, tokens int) (func(), error) {
+	if m == nil {
+		return func() {}, nil
+	}
+	caps := m.matching(model)
+	if len(caps) == 0 {
+		return func() {}, nil
+	}
+
+	var held []*modelCap
+	release := func() {
+		for _, c := range held {
+			<-c.slots
+		}
+		held = nil
+	}
+	timeout := time.NewTimer(m.queueTimeout)
+	defer timeout.Stop()
+	for _, c := range caps {
+		if c.slots == nil {
+			continue
+		}
+		select {
+		case c.slots <- struct{}{}:
+		case <-timeout.C:
+			release()
+			c.rejected.Add(1)
+			return nil, &ModelLimitError{Model: c.limit.Model, Cap: "concurrency", RetryAfter: time.Second}
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+		held = append(held, c)
+	}
+
+	m.mu.Lock()
+	now := time.Now()
+	for _, c := range caps {
+		if name, wait := c.over(now, tokens); name != "" {
+			m.mu.Unlock()
+			release()
+			c.rejected.Add(1)
+			return nil, &ModelLimitError{Model: c.limit.Model, Cap: name, RetryAfter: wait}
+		}
+	}
+	for _, c := range caps {
+		c.requests++
+		c.tokens += tokens
+	}
+	m.mu.Unlock()
+
+	var once sync.Once
+	return func() { once.Do(release) }, nil
+}
+
+// check reports the cap a request would be refused by, without charging
+// it. A full concurrency cap only makes the request wait.
+func (m *ModelLimits) check(model string, tokens int) error {
+	if m == nil {
+		return nil
+	}
+	caps := m.matching(model)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for _, c := range caps {
+		if name, wait := c.over(now, tokens); name != "" {
+			return &ModelLimitError{Model: c.limit.Model, Cap: name, RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// SetModelLimits caps requests per model on top of the provider limits;
+// requests over a cap are refused with 429 and Retry-After
+func (p *OpenAIProxy) SetModelLimits(m *ModelLimits) {
+	p.modelLimits = m
+}
+
+// SetModelLimits caps requests per model on top of the provider limits;
+// requests over a cap are refused with 429 and Retry-After
+func (p *AnthropicProxy) SetModelLimits(m *ModelLimits) {
+	p.modelLimits = m
+}
@@ -0,0 +1,161 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+)
+
+func TestModelLimit_Validate(t *testing.T) {
+	for _, l := range []ModelLimit{{RPM: 1}, {Model: "o1"}, {Model: "o1", TPM: -1}} {
+		if err := l.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", l)
+		}
+	}
+	if err := (ModelLimit{Model: "o1*", MaxInFlight: 2}).Validate(); err != nil {
+		t.Errorf("expected a valid limit, got %v", err)
+	}
+}
+
+func TestModelLimits_Rates(t *testing.T) {
+	m := NewModelLimits(0)
+	m.SetLimit(ModelLimit{Model: "o1*", RPM: 3})
+	m.SetLimit(ModelLimit{Model: "o1-preview", TPM: 1000})
+	ctx := context.Background()
+
+	// Both caps apply to o1-preview
+	for _, tokens := range []int{600, 400} {
+		release, err := m.Acquire(ctx, "o1-preview", tokens)
+		if err != nil {
+			t.Fatalf("Acquire(%d) failed: %v", tokens, err)
+		}
+		release()
+	}
+	_, err := m.Acquire(ctx, "o1-preview", 1)
+	var le *ModelLimitError
+	if !errors.As(err, &le) || le.Model != "o1-preview" || le.Cap != "tpm" || le.RetryAfter <= 0 || !errors.Is(err, providererr.ErrRateLimited) {
+		t.Fatalf("expected the TPM cap, got %v", err)
+	}
+
+	// o1-mini only has the pattern's RPM cap, with one request left
+	if release, err := m.Acquire(ctx, "o1-mini", 50000); err != nil {
+		t.Fatalf("o1-mini should be under its caps: %v", err)
+	} else {
+		release()
+	}
+	if _, err := m.Acquire(ctx, "o1-mini", 1); !errors.As(err, &le) || le.Cap != "rpm" {
+		t.Errorf("expected the RPM cap, got %v", err)
+	}
+	if release, err := m.Acquire(ctx, "gpt-4o", 1); err != nil {
+		t.Errorf("uncapped models should pass: %v", err)
+	} else {
+		release()
+	}
+
+	stats := m.Stats()
+	if len(stats) != 2 || stats[0].Model != "o1*" || stats[0].Requests != 3 || stats[0].Rejected != 1 || stats[1].Tokens != 1000 || stats[1].Rejected != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// Replacing a cap starts its counts over; removing it lifts it
+	m.SetLimit(ModelLimit{Model: "o1*", RPM: 10})
+	if release, err := m.Acquire(ctx, "o1-mini", 1); err != nil {
+		t.Errorf("expected the new cap to admit the request: %v", err)
+	} else {
+		release()
+	}
+	if !m.RemoveLimit("o1-preview") || m.RemoveLimit("o1-preview") {
+		t.Error("expected the cap to be removed once")
+	}
+	if err := m.check("o1-preview", 5000); err != nil {
+		t.Errorf("expected no TPM cap after removal, got %v", err)
+	}
+}
+
+func TestModelLimits_Concurrency(t *testing.T) {
+	m := NewModelLimits(20 * time.Millisecond)
+	m.SetLimit(ModelLimit{Model: "o1", MaxInFlight: 1})
+	ctx := context.Background()
+
+	release, err := m.Acquire(ctx, "o1", 1)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if m.Stats()[0].InFlight != 1 {
+		t.Errorf("expected one request in flight, got %+v", m.Stats())
+	}
+
+	// The next request waits, then gives up
+	start := time.Now()
+	_, err = m.Acquire(ctx, "o1", 1)
+	var le *ModelLimitError
+	if !errors.As(err, &le) || le.Cap != "concurrency" || time.Since(start) < 20*time.Millisecond {
+		t.Fatalf("expected the concurrency cap after waiting, got %v", err)
+	}
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := m.Acquire(cctx, "o1", 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the context's error, got %v", err)
+	}
+
+	// A waiting request gets the freed slot
+	done := make(chan error, 1)
+	m2 := NewModelLimits(time.Second)
+	m2.SetLimit(ModelLimit{Model: "o1", MaxInFlight: 1})
+	held, _ := m2.Acquire(ctx, "o1", 1)
+	go func() {
+		release, err := m2.Acquire(ctx, "o1", 1)
+		if err == nil {
+			release()
+		}
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	held()
+	held() // Releasing twice is harmless
+	if err := <-done; err != nil {
+		t.Errorf("queued request should get the freed slot: %v", err)
+	}
+	release()
+}
+
+func TestOpenAIProxy_ModelLimits(t *testing.T) {
+	p, upstream := newFallbackProxy(t, FallbackChain{
+		Model: "gpt-4o",
+		Steps: []FallbackStep{{Provider: "openai", Model: "o1"}, {Provider: "groq", Model: "llama"}},
+	})
+	m := NewModelLimits(0)
+	m.SetLimit(ModelLimit{Model: "o1", RPM: 1})
+	m.SetLimit(ModelLimit{Model: "remapped", RPM: 1})
+	p.SetModelLimits(m)
+
+	// Once o1 is at its cap, the chain moves on
+	chained := `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`
+	for i := 0; i < 2; i++ {
+		if w := sendChat(p, chained, nil); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d: %s", i, w.Code, w.Body.String())
+		}
+	}
+	if got := strings.Join(upstream.models(), ","); got != "o1,llama" {
+		t.Errorf("upstream models = %s, want o1,llama", got)
+	}
+
+	// Without a chain the client is told when to retry
+	plain := `{"model": "gpt-4", "messages": [{"role": "user", "content": "hi"}]}`
+	sendChat(p, plain, nil)
+	w := sendChat(p, plain, nil)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" || !strings.Contains(w.Body.String(), "rpm cap") {
+		t.Errorf("expected 429 with Retry-After, got %d %v: %s", w.Code, w.Header(), w.Body.String())
+	}
+
+	// A dry run reports the cap
+	w = sendChat(p, plain, http.Header{HeaderDryRun: {"true"}})
+	if !strings.Contains(w.Body.String(), `"model_limits"`) || !strings.Contains(w.Body.String(), "rpm cap") {
+		t.Errorf("expected the dry run to report the cap, got %s", w.Body.String())
+	}
+}
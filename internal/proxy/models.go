@@ -0,0 +1,147 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ModelEntry is one model clients can request through the proxy
+type ModelEntry struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"` // always "model"
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"` // provider serving the model
+}
+
+// ModelCatalog lists the models a client can request, including aliases
+// and names remap rules resolve
+type ModelCatalog interface {
+	ListModels(ctx context.Context, clientID string) ([]ModelEntry, error)
+}
+
+// SetModelCatalog enables GET /v1/models
+func (p *OpenAIProxy) SetModelCatalog(c ModelCatalog) {
+	p.models = c
+}
+
+// HandleModels serves GET /v1/models and GET /v1/models/{id}, so SDKs can
+// list and look up models as they would upstream. Requests carrying an
+// anthropic-version header get Anthropic's list format.
+func (p *OpenAIProxy) HandleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if p.models == nil {
+		p.writeError(w, "model listing is not configured", "server_error", http.StatusServiceUnavailable)
+		return
+	}
+
+	models, err := p.models.ListModels(r.Context(), r.Header.Get("X-Client-ID"))
+	if err != nil {
+		log.Printf("proxy: failed to list models: %v", err)
+		p.writeError(w, "failed to list models", "server_error", http.StatusInternalServerError)
+		return
+	}
+	anthropic := r.Header.Get("anthropic-version") != ""
+
+	w.Header().Set("Content-Type", "application/json")
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/v1/models"), "/")
+	if id == "" {
+		if anthropic {
+			json.NewEncoder(w).Encode(anthropicModelList(models))
+			return
+		}
+		if models == nil {
+			models = []ModelEntry{}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"object": "list", "data": models})
+		return
+	}
+
+	for _, m := range models {
+		if m.ID != id {
+			continue
+		}
+		if anthropic {
+			json.NewEncoder(w).Encode(anthropicModel(m))
+		} else {
+			json.NewEncoder(w).Encode(m)
+		}
+		return
+	}
+	p.writeError(w, fmt.Sprintf("The model '%s' does not exist", id), "invalid_request_error", http.StatusNotFound)
+}
+
+// anthropicModel converts an entry to Anthropic's model object
+func anthropicModel(m ModelEntry) map[string]interface{} {
+	return map[string]interface{}{
+		"type":         "model",
+		"id":           m.ID,
+		"display_name": m.ID,
+		"created_at":   time.Unix(m.Created, 0).UTC().Format(time.RFC3339),
+	}
+}
+
+// anthropicModelList converts entries to Anthropic's paged list format,
+// returned as a single page
+func anthropicModelList(models []ModelEntry) map[string]interface{} {
+	data := make([]map[string]interface{}, len(models))
+	for i, m := range models {
+		data[i] = anthropicModel(m)
+	}
+	list := map[string]interface{}{"data": data, "has_more": false, "first_id": nil, "last_id": nil}
+	if len(models) > 0 {
+		list["first_id"], list["last_id"] = models[0].ID, models[len(models)-1].ID
+	}
+	return list
+}
+
+// HandleCountTokens serves POST /v1/messages/count_tokens. The count is
+// estimated locally for the request as it would be sent upstream, after
+// remapping and prompt policies, so nothing is spent on the provider.
+func (p *AnthropicProxy) HandleCountTokens(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		p.writeError(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	var req AnthropicRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		p.writeError(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Model == "" {
+		p.writeError(w, "model is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Messages) == 0 {
+		p.writeError(w, "messages array is required", http.StatusBadRequest)
+		return
+	}
+
+	clientID := r.Header.Get("X-Client-ID")
+	if p.remapper != nil {
+		if remapped, _, err := p.remapper.RemapModel(r.Context(), req.Model, clientID); err == nil && remapped != "" {
+			req.Model = remapped
+		}
+	}
+	if err := applyAnthropicPolicy(p.promptPolicy, &req, clientID); err != nil {
+		p.writeError(w, fmt.Sprintf("failed to apply prompt policy: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"input_tokens": CountAnthropicRequestTokens(&req)})
+}
@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testCatalog struct {
+	clientID string
+}
+
+func (c *testCatalog) ListModels(ctx context.Context, clientID string) ([]ModelEntry, error) {
+	c.clientID = clientID
+	return []ModelEntry{
+		{ID: "gpt-4o", Object: "model", Created: 1700000000, OwnedBy: "openai"},
+		{ID: "meta-llama/Llama-3.3-70B", Object: "model", OwnedBy: "together"},
+		{ID: "fast", Object: "model", OwnedBy: "groq"},
+	}, nil
+}
+
+func TestOpenAIProxy_HandleModels(t *testing.T) {
+	p := NewOpenAIProxy(DefaultOpenAIProxyConfig(), &mockKeyProvider{}, nil)
+	w := httptest.NewRecorder()
+	p.HandleModels(w, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a catalog, got %d", w.Code)
+	}
+
+	catalog := &testCatalog{}
+	p.SetModelCatalog(catalog)
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	r.Header.Set("X-Client-ID", "cli")
+	w = httptest.NewRecorder()
+	p.HandleModels(w, r)
+	var list struct {
+		Object string       `json:"object"`
+		Data   []ModelEntry `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("invalid list: %v", err)
+	}
+	if list.Object != "list" || len(list.Data) != 3 || list.Data[2].ID != "fast" || catalog.clientID != "cli" {
+		t.Errorf("unexpected list %+v (client %q)", list, catalog.clientID)
+	}
+
+	// Model IDs may contain slashes
+	w = httptest.NewRecorder()
+	p.HandleModels(w, httptest.NewRequest(http.MethodGet, "/v1/models/meta-llama/Llama-3.3-70B", nil))
+	var model ModelEntry
+	json.Unmarshal(w.Body.Bytes(), &model)
+	if w.Code != http.StatusOK || model.OwnedBy != "together" {
+		t.Errorf("retrieve = %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	p.HandleModels(w, httptest.NewRequest(http.MethodGet, "/v1/models/unknown", nil))
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "does not exist") {
+		t.Errorf("expected 404, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Anthropic SDKs get Anthropic's format
+	r = httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	r.Header.Set("anthropic-version", "2023-06-01")
+	w = httptest.NewRecorder()
+	p.HandleModels(w, r)
+	var anthropic struct {
+		Data []struct {
+			Type      string `json:"type"`
+			ID        string `json:"id"`
+			CreatedAt string `json:"created_at"`
+		} `json:"data"`
+		HasMore bool   `json:"has_more"`
+		FirstID string `json:"first_id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &anthropic)
+	if len(anthropic.Data) != 3 || anthropic.Data[0].Type != "model" || anthropic.Data[0].CreatedAt != "2023-11-14T22:13:20Z" || anthropic.FirstID != "gpt-4o" {
+		t.Errorf("unexpected Anthropic list %s", w.Body.String())
+	}
+}
+
+type testPolicy struct{}
+
+func (testPolicy) SystemPrompt(model, clientID string) (string, string, error) {
+	if model != "claude-sonnet-4-5" {
+		return "", "", nil
+	}
+	return "Follow the company style guide in every answer you give.", "", nil
+}
+
+func TestAnthropicProxy_HandleCountTokens(t *testing.T) {
+	body := `{"model": "sonnet", "messages": [{"role": "user", "content": [{"type": "text", "text": "Hello, how are you today?"}]}]}`
+	count := func(p *AnthropicProxy) int {
+		t.Helper()
+		w := httptest.NewRecorder()
+		p.HandleCountTokens(w, httptest.NewRequest(http.MethodPost, "/v1/messages/count_tokens", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			InputTokens int `json:"input_tokens"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.InputTokens
+	}
+
+	plain := count(NewAnthropicProxy(DefaultAnthropicProxyConfig(), &mockKeyProvider{}, nil))
+	if plain <= 0 {
+		t.Fatalf("expected a positive count, got %d", plain)
+	}
+
+	// The policy for the remapped model is counted as it would be sent
+	p := NewAnthropicProxy(DefaultAnthropicProxyConfig(), &mockKeyProvider{}, &mockRemapper{model: "claude-sonnet-4-5"})
+	p.SetPromptPolicy(testPolicy{})
+	if withPolicy := count(p); withPolicy <= plain {
+		t.Errorf("policy text not counted: %d <= %d", withPolicy, plain)
+	}
+
+	w := httptest.NewRecorder()
+	p.HandleCountTokens(w, httptest.NewRequest(http.MethodPost, "/v1/messages/count_tokens", strings.NewReader(`{"model": "sonnet"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without messages, got %d", w.Code)
+	}
+}
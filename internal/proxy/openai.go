@@ -3,16 +3,20 @@
 package proxy
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"strings"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/chaos"
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+	"github.com/jeffersonwarrior/modelscan/internal/tenant"
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
 )
 
 // OpenAIProxyConfig holds configuration for the OpenAI proxy
@@ -23,14 +27,29 @@ type OpenAIProxyConfig struct {
 	DefaultMaxTokens int
 	// OpenAIBaseURL is the upstream OpenAI API URL
 	OpenAIBaseURL string
+	// CohereBaseURL is the upstream Cohere API URL (v2 chat is translated)
+	CohereBaseURL string
+	// StreamWriteTimeout bounds each SSE write to the client; a client that
+	// stops reading for longer is dropped and the upstream stream closed
+	StreamWriteTimeout time.Duration
+	// StreamKeepAlive is how long a stream may be quiet before a keep-alive
+	// comment is sent; zero disables keep-alives
+	StreamKeepAlive time.Duration
+	// StreamIdleTimeout ends a stream, upstream and client, with an error
+	// event when the provider sends nothing for this long; zero disables it
+	StreamIdleTimeout time.Duration
 }
 
 // DefaultOpenAIProxyConfig returns sensible defaults
 func DefaultOpenAIProxyConfig() OpenAIProxyConfig {
 	return OpenAIProxyConfig{
-		Timeout:          5 * time.Minute,
-		DefaultMaxTokens: 4096,
-		OpenAIBaseURL:    "https://api.openai.com",
+		Timeout:            5 * time.Minute,
+		DefaultMaxTokens:   4096,
+		OpenAIBaseURL:      "https://api.openai.com",
+		CohereBaseURL:      "https://api.cohere.com",
+		StreamWriteTimeout: DefaultStreamWriteTimeout,
+		StreamKeepAlive:    DefaultStreamKeepAlive,
+		StreamIdleTimeout:  DefaultStreamIdleTimeout,
 	}
 }
 
@@ -40,7 +59,29 @@ type OpenAIProxy struct {
 	keyProvider     KeyProvider
 	remapper        ModelRemapper
 	httpClient      *http.Client
-	streamingClient *http.Client // Dedicated client for streaming (no timeout)
+	streamingClient *http.Client         // Dedicated client for streaming (no timeout)
+	shadow          *shadowMirror        // Optional traffic mirroring for offline evaluation
+	limits          ContextLimits        // Optional context window enforcement
+	guardrails      Guardrails           // Optional content filtering
+	faults          *chaos.Injector      // Optional fault injection for upstream requests
+	fallbacks       *FallbackChains      // Optional per-model fallback chains
+	tenants         *tenant.Manager      // Optional per-tenant budgets, limits and usage
+	concurrency     *ConcurrencyLimiter  // Optional in-flight request caps
+	vision          *Vision              // Optional image validation and inlining
+	promptPolicy    PromptPolicy         // Optional mandated system prompt text
+	completionHooks *CompletionHooks     // Optional hooks run after each response
+	availability    ProviderAvailability // Optional drains and maintenance windows
+	incidents       ProviderIncidents    // Optional status page incidents
+	models          ModelCatalog         // Optional model listing for /v1/models
+	pricer          tenant.Pricer        // Optional model prices for cost estimates
+	residency       *Residency           // Optional data residency policy
+	timeouts        RequestTimeouts      // Optional per-client request timeouts
+	transforms      *TransformRules      // Optional request rewriting
+	experiments     *Experiments         // Optional A/B parameter experiments
+	modelLimits     *ModelLimits         // Optional per-model request caps
+	embeddings      *EmbeddingCache      // Optional cache of embedded inputs
+	canaries        CanaryRouting        // Optional canary traffic splitting
+	retryBudget     *mshttp.RetryBudget  // Optional cap on key failover resends
 }
 
 // NewOpenAIProxy creates a new OpenAI proxy handler
@@ -66,20 +107,43 @@ func (p *OpenAIProxy) HandleChatCompletions(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Create context with timeout for the entire request
-	ctx, cancel := context.WithTimeout(r.Context(), p.config.Timeout)
+	// Cancel upstream work as soon as the client goes away
+	r, stopWatching := watchClient(r)
+	defer stopWatching()
+
+	// Bound the entire request by the client's deadline budget
+	ctx, cancel, err := withDeadline(r, p.timeouts, p.config.Timeout)
+	if err != nil {
+		p.writeError(w, err.Error(), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
 	defer cancel()
 
 	// Parse request body
-	body, err := io.ReadAll(r.Body)
+	body, err := readBody(r.Body)
 	if err != nil {
 		p.writeError(w, "failed to read request body", "invalid_request_error", http.StatusBadRequest)
 		return
 	}
 	defer func() { _ = r.Body.Close() }()
+	defer putBuffer(body)
+
+	// Rewrite the request as the matching transformation rules say
+	ctx, raw, err := transformRequest(ctx, p.transforms, w, TransformAPIOpenAI, r.Header.Get("X-Client-ID"), body.Bytes())
+	if err != nil {
+		p.writeError(w, fmt.Sprintf("invalid request body: %v", err), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+
+	// Apply the variant of any experiment the client is enrolled in
+	raw, err = applyExperiment(p.experiments, w, r, TransformAPIOpenAI, raw)
+	if err != nil {
+		p.writeError(w, fmt.Sprintf("invalid request body: %v", err), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
 
 	var req OpenAIRequest
-	if err := json.Unmarshal(body, &req); err != nil {
+	if err := json.Unmarshal(raw, &req); err != nil {
 		p.writeError(w, fmt.Sprintf("invalid request body: %v", err), "invalid_request_error", http.StatusBadRequest)
 		return
 	}
@@ -102,15 +166,20 @@ func (p *OpenAIProxy) HandleChatCompletions(w http.ResponseWriter, r *http.Reque
 
 	// Extract client ID from header (optional)
 	clientID := r.Header.Get("X-Client-ID")
+	requested, dryRun := req.Model, isDryRun(r)
+
+	// A fallback chain for the requested model replaces remapping
+	chain, chained := p.fallbackChain(r, req.Model)
 
-	// Apply model remapping if remapper is available
+	// Apply model remapping if remapper is available.
+	// Requests without a client ID still get global rules.
 	targetProvider := "openai"
-	if p.remapper != nil && clientID != "" {
+	if p.remapper != nil && !chained {
 		remapped, provider, err := p.remapper.RemapModel(ctx, req.Model, clientID)
 		if err != nil {
 			log.Printf("proxy: remap error for model %s: %v", req.Model, err)
 			// Continue with original model on remap error
-		} else if remapped != "" {
+		} else if remapped != "" && (remapped != req.Model || provider != "") {
 			log.Printf("proxy: remapped %s -> %s (provider: %s)", req.Model, remapped, provider)
 			req.Model = remapped
 			if provider != "" {
@@ -119,32 +188,219 @@ func (p *OpenAIProxy) HandleChatCompletions(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
+	// An explicit provider header wins over remap rules
+	if provider := r.Header.Get(HeaderProvider); provider != "" {
+		targetProvider = provider
+	}
+
+	// Divert a share of the model's traffic to any canary of it
+	var canary *CanaryAssignment
+	if !chained && !dryRun {
+		if canary = assignCanary(p.canaries, p.speaks, w, r, req.Model, targetProvider); canary != nil {
+			req.Model, targetProvider = canary.Model, canary.Provider
+		}
+	}
+
+	// Keep requests with a residency requirement in their region; a
+	// request no compliant provider can serve fails rather than leaving it
+	region, err := p.residency.Required(clientID, r.Header.Get(HeaderResidency))
+	if err != nil {
+		p.writeError(w, err.Error(), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	if region != "" {
+		if chained {
+			if chain, err = p.residency.filterChain(chain, region); err != nil {
+				p.writeError(w, err.Error(), "permission_error", http.StatusForbidden)
+				return
+			}
+		} else if !p.residency.Allows(targetProvider, region) {
+			rerr := &ResidencyError{Region: region, Provider: targetProvider, Model: req.Model}
+			p.writeError(w, rerr.Error(), "permission_error", http.StatusForbidden)
+			return
+		}
+	}
+
+	// Explain the routing decision when the client asks for it
+	if wantsExplanation(r) {
+		setRoute(w, p.ExplainRoute(ctx, requested, clientID, r.Header.Get(HeaderProvider)))
+	}
+
+	// Drained providers take no new requests; a dry run reports it instead
+	if !chained && !dryRun {
+		if merr := checkAvailable(p.availability, targetProvider); merr != nil {
+			p.writeError(w, merr.Error(), "server_error", maintenanceStatus(w, merr))
+			return
+		}
+	}
+
+	// Run request content through the guardrails
+	if p.guardrails != nil && !dryRun && !p.guardRequest(ctx, w, &req, clientID) {
+		return
+	}
+
+	// Add the organization's mandated instructions to the system prompt
+	if err := applyOpenAIPolicy(p.promptPolicy, &req, clientID); err != nil {
+		p.writeError(w, fmt.Sprintf("failed to apply prompt policy: %v", err), "server_error", http.StatusInternalServerError)
+		return
+	}
+
+	// Price the request for each target it could go to
+	promptTokens := CountOpenAIRequestTokens(&req)
+	route := []DryRunStep{{
+		Provider: targetProvider,
+		Model:    req.Model,
+		Estimate: estimateCost(p.pricer, req.Model, promptTokens, openAIMaxTokens(&req)),
+	}}
+	if chained {
+		route = route[:0]
+		for _, step := range chain.Steps {
+			stepReq := step.apply(&req)
+			route = append(route, DryRunStep{
+				Provider: step.Provider,
+				Model:    stepReq.Model,
+				Estimate: estimateCost(p.pricer, stepReq.Model, promptTokens, openAIMaxTokens(stepReq)),
+			})
+		}
+	}
+	if dryRun {
+		runner := dryRunner{keys: p.keyProvider, availability: p.availability, tenants: p.tenants, concurrency: p.concurrency, modelLimits: p.modelLimits}
+		writeDryRun(w, runner.run(ctx, "openai", requested, clientID, promptTokens, route))
+		return
+	}
+	setEstimatedCost(w, route[0].Estimate)
+
+	// Hold the client's tenant to its budget and rate limits and meter its usage
+	ctx, w, finishTenant, err := meterTenant(ctx, p.tenants, w, clientID, promptTokens)
+	if err != nil {
+		status, errType := tenantErrorStatus(err)
+		p.writeError(w, err.Error(), errType, status)
+		return
+	}
+	defer finishTenant()
+
+	// Assemble the response for the AfterCompletion hooks
+	w, finishCompletion := trackCompletion(ctx, p.completionHooks, w, "openai", clientID, promptTokens)
+	defer finishCompletion()
+
+	if chained {
+		if key := r.Header.Get(mshttp.DefaultIdempotencyHeader); key != "" {
+			ctx = mshttp.WithIdempotencyKey(ctx, key)
+		}
+		p.forwardChain(ctx, w, &req, chain, clientID)
+		return
+	}
+
+	// Make sure the request fits the target model's context window
+	if err := p.fitContext(ctx, &req, clientID); err != nil {
+		setErrorCode(w, err)
+		p.writeError(w, err.Error(), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+
+	// Make sure the target model can take the request's images
+	if err := p.vision.PrepareOpenAI(ctx, &req, targetProvider); err != nil {
+		p.writeError(w, err.Error(), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	// Report how the upstream call went to the canary
+	w, finishCanary := canary.observe(ctx, w)
+	defer finishCanary()
+
 	// Get API key for target provider
 	apiKey, err := p.keyProvider.GetKey(ctx, targetProvider)
 	if err != nil {
 		p.writeError(w, fmt.Sprintf("no API key available for provider %s", targetProvider), "server_error", http.StatusServiceUnavailable)
 		return
 	}
+	setAttribution(w, targetProvider, req.Model, apiKey)
+	ctx = withUpstreamKey(mshttp.WithProvider(ctx, targetProvider), apiKey, w)
+	if key := r.Header.Get(mshttp.DefaultIdempotencyHeader); key != "" {
+		ctx = mshttp.WithIdempotencyKey(ctx, key)
+	}
+
+	// Buffer non-streaming responses so guardrails see them before the client does
+	out := w
+	var buf *bufferedResponse
+	if p.guardrails != nil && !req.Stream {
+		buf = newBufferedResponse()
+		out = buf
+	}
+
+	// Mirror a sample of traffic to the shadow provider, capturing the primary response
+	if finishShadow := p.startShadow(req, clientID, targetProvider, region); finishShadow != nil {
+		cw := newCaptureWriter(out, p.shadow.config.MaxBodyBytes)
+		start := time.Now()
+		// Release the mirror and its slot even if forwarding panics
+		defer func() { finishShadow(cw, time.Since(start)) }()
+		p.forward(ctx, cw, &req, apiKey, targetProvider)
+	} else {
+		p.forward(ctx, out, &req, apiKey, targetProvider)
+	}
+
+	if buf != nil {
+		p.writeGuardedResponse(ctx, w, buf, clientID)
+	}
+}
+
+// forward sends the request upstream using the streaming or non-streaming
+// path once the model's caps admit it and a concurrency slot is free
+func (p *OpenAIProxy) forward(ctx context.Context, w http.ResponseWriter, req *OpenAIRequest, apiKey, provider string) {
+	releaseModel, err := p.modelLimits.Acquire(ctx, req.Model, CountOpenAIRequestTokens(req)+openAIMaxTokens(req))
+	if err != nil {
+		if err = deadlineError(ctx, err); errors.Is(err, context.DeadlineExceeded) {
+			p.writeError(w, err.Error(), ErrorTypeDeadlineExceeded, http.StatusGatewayTimeout)
+			return
+		}
+		status := shedStatus(w, err)
+		p.writeError(w, err.Error(), "rate_limit_exceeded", status)
+		return
+	}
+	defer releaseModel()
+
+	release, err := p.concurrency.Acquire(ctx, provider)
+	if err != nil {
+		if err = deadlineError(ctx, err); errors.Is(err, context.DeadlineExceeded) {
+			p.writeError(w, err.Error(), ErrorTypeDeadlineExceeded, http.StatusGatewayTimeout)
+			return
+		}
+		status := shedStatus(w, err)
+		p.writeError(w, err.Error(), "rate_limit_exceeded", status)
+		return
+	}
+	defer release()
+
+	// Nothing is sent upstream once the budget has run out
+	if err := checkDeadline(ctx); err != nil {
+		p.writeError(w, err.Error(), ErrorTypeDeadlineExceeded, http.StatusGatewayTimeout)
+		return
+	}
 
-	// Forward request to upstream
 	if req.Stream {
-		p.handleStreamingRequest(ctx, w, &req, apiKey, targetProvider)
+		p.handleStreamingRequest(ctx, w, req, apiKey, provider)
 	} else {
-		p.handleNonStreamingRequest(ctx, w, &req, apiKey, targetProvider)
+		p.handleNonStreamingRequest(ctx, w, req, apiKey, provider)
 	}
 }
 
 // handleNonStreamingRequest handles non-streaming OpenAI requests
 func (p *OpenAIProxy) handleNonStreamingRequest(ctx context.Context, w http.ResponseWriter, req *OpenAIRequest, apiKey, provider string) {
+	if provider == "cohere" {
+		p.handleCohereRequest(ctx, w, req, apiKey)
+		return
+	}
+
 	// Build upstream request
-	reqBody, err := json.Marshal(req)
+	reqBody, err := marshalJSON(req)
 	if err != nil {
 		p.writeError(w, "failed to marshal request", "server_error", http.StatusInternalServerError)
 		return
 	}
+	// Deferred before resp.Body.Close, so it runs after the transport is done
+	defer reqBody.release()
 
 	upstreamURL := p.getUpstreamURL(provider)
-	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, upstreamURL, bytes.NewReader(reqBody))
+	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, upstreamURL, bytes.NewReader(reqBody.Bytes()))
 	if err != nil {
 		p.writeError(w, "failed to create upstream request", "server_error", http.StatusInternalServerError)
 		return
@@ -156,11 +412,25 @@ func (p *OpenAIProxy) handleNonStreamingRequest(ctx context.Context, w http.Resp
 	// Execute request
 	resp, err := p.httpClient.Do(upstreamReq)
 	if err != nil {
-		p.writeError(w, fmt.Sprintf("upstream request failed: %v", err), "server_error", http.StatusBadGateway)
+		message, errType, status := upstreamFailure(ctx, err)
+		p.writeError(w, message, errType, status)
 		return
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	// Read the whole body first so a broken transport limit can still be
+	// reported with an error status
+	body, err := readBody(resp.Body)
+	if err != nil {
+		message, errType, status := upstreamReadError(err)
+		p.writeError(w, message, errType, status)
+		return
+	}
+	defer putBuffer(body)
+	if resp.StatusCode >= 400 {
+		setErrorCode(w, providererr.Classify(provider, resp.StatusCode, resp.Header, body.Bytes()))
+	}
+
 	// Copy response headers
 	for key, values := range resp.Header {
 		for _, value := range values {
@@ -170,9 +440,8 @@ func (p *OpenAIProxy) handleNonStreamingRequest(ctx context.Context, w http.Resp
 
 	// Copy status code and body
 	w.WriteHeader(resp.StatusCode)
-	if _, err := io.Copy(w, resp.Body); err != nil {
-		log.Printf("proxy: error copying response body: %v", err)
-		// Response already started, can't change status code
+	if _, err := w.Write(body.Bytes()); err != nil {
+		log.Printf("proxy: error writing response body: %v", err)
 	}
 }
 
@@ -184,16 +453,31 @@ func (p *OpenAIProxy) handleStreamingRequest(ctx context.Context, w http.Respons
 		p.writeError(w, "streaming not supported", "server_error", http.StatusInternalServerError)
 		return
 	}
-
-	// Build upstream request
-	reqBody, err := json.Marshal(req)
+	sw.SetWriteTimeout(p.config.StreamWriteTimeout)
+	sw.KeepAlive(p.config.StreamKeepAlive)
+	defer sw.StopKeepAlive()
+
+	// Build upstream request, translating for providers with a non-OpenAI format
+	var reqBody *jsonBuffer
+	if provider == "cohere" {
+		cohereReq, terr := ToCohere(req)
+		if terr != nil {
+			sw.WriteError(fmt.Errorf("failed to translate request: %w", terr))
+			return
+		}
+		reqBody, err = marshalJSON(cohereReq)
+	} else {
+		reqBody, err = marshalJSON(req)
+	}
 	if err != nil {
 		sw.WriteError(fmt.Errorf("failed to marshal request: %w", err))
 		return
 	}
+	// Deferred before resp.Body.Close, so it runs after the transport is done
+	defer reqBody.release()
 
 	upstreamURL := p.getUpstreamURL(provider)
-	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, upstreamURL, bytes.NewReader(reqBody))
+	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, upstreamURL, bytes.NewReader(reqBody.Bytes()))
 	if err != nil {
 		sw.WriteError(fmt.Errorf("failed to create upstream request: %w", err))
 		return
@@ -208,6 +492,8 @@ func (p *OpenAIProxy) handleStreamingRequest(ctx context.Context, w http.Respons
 		sw.WriteError(fmt.Errorf("upstream request failed: %w", err))
 		return
 	}
+	// A provider that goes quiet mid-stream is cut off rather than waited on
+	resp.Body = watchIdle(resp.Body, p.config.StreamIdleTimeout)
 	defer func() { _ = resp.Body.Close() }()
 
 	// Check for non-2xx status
@@ -217,70 +503,17 @@ func (p *OpenAIProxy) handleStreamingRequest(ctx context.Context, w http.Respons
 			sw.WriteError(fmt.Errorf("upstream error (status %d): failed to read error body: %w", resp.StatusCode, err))
 			return
 		}
-		sw.WriteError(fmt.Errorf("upstream error (status %d): %s", resp.StatusCode, string(body)))
+		apiErr := providererr.Classify(provider, resp.StatusCode, resp.Header, body)
+		sw.WriteError(withKind(fmt.Errorf("upstream error (status %d): %s", resp.StatusCode, string(body)), apiErr))
 		return
 	}
 
-	// Stream SSE events from upstream to client
-	p.streamSSEEvents(ctx, sw, resp.Body)
-}
-
-// streamSSEEvents reads SSE events from upstream and forwards to client
-func (p *OpenAIProxy) streamSSEEvents(ctx context.Context, sw *StreamWriter, reader io.Reader) {
-	scanner := bufio.NewScanner(reader)
-	// Increase buffer size for large events (pre-allocate 64KB initial buffer)
-	buf := make([]byte, 64*1024)
-	scanner.Buffer(buf, 1024*1024) // 1MB max
-
-	var dataLines []string
-
-	for scanner.Scan() {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-		}
-
-		line := scanner.Text()
-
-		if line == "" {
-			// Empty line means end of event
-			if len(dataLines) > 0 {
-				data := strings.Join(dataLines, "\n")
-
-				// Check for [DONE] marker
-				if data == "[DONE]" {
-					sw.Close()
-					return
-				}
-
-				// Forward the event
-				sw.WriteEvent([]byte(data))
-
-				// Reset for next event
-				dataLines = nil
-			}
-			continue
-		}
-
-		// Parse SSE line
-		if strings.HasPrefix(line, "data:") {
-			data := strings.TrimPrefix(line, "data:")
-			if strings.HasPrefix(data, " ") {
-				data = data[1:]
-			}
-			dataLines = append(dataLines, data)
-		}
-		// OpenAI doesn't use event: prefix, but ignore if present
-		// Ignore other lines (comments starting with :, retry:, id:, etc.)
-	}
-
-	if err := scanner.Err(); err != nil {
-		sw.WriteError(fmt.Errorf("stream read error: %w", err))
+	// Cohere streams are translated; OpenAI-compatible ones are relayed as is
+	if provider == "cohere" {
+		p.streamCohereEvents(ctx, sw, resp.Body, req.Model)
+		return
 	}
-
-	// Close stream
-	sw.Close()
+	relaySSE(ctx, sw, resp.Body)
 }
 
 // getUpstreamURL returns the upstream URL for a provider
@@ -304,18 +537,44 @@ func (p *OpenAIProxy) getUpstreamURL(provider string) string {
 		return "https://api.x.ai/v1/chat/completions"
 	case "perplexity":
 		return "https://api.perplexity.ai/chat/completions"
+	case "mistral":
+		return "https://api.mistral.ai/v1/chat/completions"
+	case "cohere":
+		return p.config.CohereBaseURL + "/v2/chat"
 	default:
 		// Default to OpenAI
 		return p.config.OpenAIBaseURL + "/v1/chat/completions"
 	}
 }
 
-// setUpstreamHeaders sets the required headers for upstream requests
+// speaks reports whether getUpstreamURL knows provider; requests for any
+// other provider would be sent to OpenAI
+func (p *OpenAIProxy) speaks(provider string) bool {
+	switch provider {
+	case "openai", "groq", "together", "fireworks", "deepseek", "deepinfra", "openrouter", "xai", "perplexity", "mistral", "cohere":
+		return true
+	default:
+		return false
+	}
+}
+
+// setUpstreamHeaders sets the required headers for upstream requests,
+// passing on the client's idempotency key and adding the headers of the
+// transformation rules applied. It is called just before a request is
+// sent, which is noted for disconnect handling.
 func (p *OpenAIProxy) setUpstreamHeaders(req *http.Request, apiKey, provider string) {
 	req.Header.Set("Content-Type", "application/json")
+	markSent(req.Context())
+	setTransformHeaders(req)
+	if key := mshttp.IdempotencyKeyFrom(req.Context()); key != "" {
+		req.Header.Set(mshttp.DefaultIdempotencyHeader, key)
+	}
+	if id := mshttp.RequestIDFrom(req.Context()); id != "" {
+		req.Header.Set(mshttp.HeaderRequestID, id)
+	}
 
 	switch provider {
-	case "openai", "groq", "together", "fireworks", "deepseek", "deepinfra", "openrouter", "xai", "perplexity":
+	case "openai", "groq", "together", "fireworks", "deepseek", "deepinfra", "openrouter", "xai", "perplexity", "mistral", "cohere":
 		req.Header.Set("Authorization", "Bearer "+apiKey)
 	default:
 		// Default to Bearer token auth
@@ -318,6 +318,8 @@ func TestOpenAIProxy_GetUpstreamURL(t *testing.T) {
 		{"openrouter", "https://openrouter.ai/api/v1/chat/completions"},
 		{"xai", "https://api.x.ai/v1/chat/completions"},
 		{"perplexity", "https://api.perplexity.ai/chat/completions"},
+		{"mistral", "https://api.mistral.ai/v1/chat/completions"},
+		{"cohere", "https://api.cohere.com/v2/chat"},
 		{"unknown", "https://api.openai.com/v1/chat/completions"}, // defaults to OpenAI
 	}
 
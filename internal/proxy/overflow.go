@@ -0,0 +1,268 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+)
+
+// OverflowPolicy decides what happens when a request does not fit the
+// target model's context window
+type OverflowPolicy string
+
+const (
+	// OverflowReject rejects the request with a context length error (default)
+	OverflowReject OverflowPolicy = "reject"
+	// OverflowTruncate drops the oldest non-system messages until the request fits
+	OverflowTruncate OverflowPolicy = "truncate"
+	// OverflowClamp lowers max_tokens to the space left after the input
+	OverflowClamp OverflowPolicy = "clamp"
+)
+
+// minClampedMaxTokens is the smallest max_tokens OverflowClamp will leave;
+// below this the response would be uselessly short, so the request is rejected
+const minClampedMaxTokens = 64
+
+// ParseOverflowPolicy validates a policy name. Empty means OverflowReject.
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	switch OverflowPolicy(s) {
+	case "", OverflowReject:
+		return OverflowReject, nil
+	case OverflowTruncate, OverflowClamp:
+		return OverflowPolicy(s), nil
+	default:
+		return "", fmt.Errorf("invalid context overflow policy %q (want reject, truncate or clamp)", s)
+	}
+}
+
+// ContextLimits provides context windows and per-client overflow policies
+type ContextLimits interface {
+	// ContextWindow returns the model's context window in tokens, or 0 if unknown
+	ContextWindow(ctx context.Context, model string) int
+	// OverflowPolicy returns the client's overflow policy
+	OverflowPolicy(ctx context.Context, clientID string) OverflowPolicy
+}
+
+// ContextOverflowError reports a request that exceeds the model's context window
+type ContextOverflowError struct {
+	Model        string
+	Window       int
+	InputTokens  int
+	OutputTokens int
+}
+
+// Unwrap classifies the overflow as providererr.ErrContextTooLong
+func (e *ContextOverflowError) Unwrap() error {
+	return providererr.ErrContextTooLong
+}
+
+func (e *ContextOverflowError) Error() string {
+	return fmt.Sprintf("this model's maximum context length is %d tokens, but the request uses ~%d input tokens plus %d max_tokens; shorten the messages or lower max_tokens",
+		e.Window, e.InputTokens, e.OutputTokens)
+}
+
+// ContextAdjustment describes changes made to fit a request
+type ContextAdjustment struct {
+	DroppedMessages  int
+	ClampedMaxTokens int
+}
+
+// fitOpenAIRequest checks the request against the window and applies the policy.
+// It returns a non-nil adjustment when the request was modified.
+func fitOpenAIRequest(req *OpenAIRequest, window int, policy OverflowPolicy) (*ContextAdjustment, error) {
+	maxTokens := req.MaxTokens
+	if req.MaxCompletionTokens != nil {
+		maxTokens = req.MaxCompletionTokens
+	}
+	output := 0
+	if maxTokens != nil {
+		output = *maxTokens
+	}
+
+	input := CountOpenAIRequestTokens(req)
+	if input+output <= window {
+		return nil, nil
+	}
+	overflow := &ContextOverflowError{Model: req.Model, Window: window, InputTokens: input, OutputTokens: output}
+
+	switch policy {
+	case OverflowTruncate:
+		dropped := 0
+		for input+output > window {
+			n := dropOldestOpenAIMessages(req)
+			if n == 0 {
+				overflow.InputTokens = input
+				return nil, overflow
+			}
+			dropped += n
+			input = CountOpenAIRequestTokens(req)
+		}
+		return &ContextAdjustment{DroppedMessages: dropped}, nil
+
+	case OverflowClamp:
+		remaining := window - input
+		if maxTokens == nil || remaining < minClampedMaxTokens {
+			return nil, overflow
+		}
+		*maxTokens = remaining
+		return &ContextAdjustment{ClampedMaxTokens: remaining}, nil
+
+	default:
+		return nil, overflow
+	}
+}
+
+// dropOldestOpenAIMessages removes the oldest non-system message together with
+// any tool results answering it. The final message is never removed.
+// It returns the number of messages removed.
+func dropOldestOpenAIMessages(req *OpenAIRequest) int {
+	start := -1
+	for i, msg := range req.Messages[:len(req.Messages)-1] {
+		if msg.Role != "system" && msg.Role != "developer" {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return 0
+	}
+
+	end := start + 1
+	for end < len(req.Messages)-1 && req.Messages[end].Role == "tool" {
+		end++
+	}
+
+	req.Messages = append(req.Messages[:start], req.Messages[end:]...)
+	return end - start
+}
+
+// fitAnthropicRequest checks the request against the window and applies the policy.
+// It returns a non-nil adjustment when the request was modified.
+func fitAnthropicRequest(req *AnthropicRequest, window int, policy OverflowPolicy) (*ContextAdjustment, error) {
+	input := CountAnthropicRequestTokens(req)
+	if input+req.MaxTokens <= window {
+		return nil, nil
+	}
+	overflow := &ContextOverflowError{Model: req.Model, Window: window, InputTokens: input, OutputTokens: req.MaxTokens}
+
+	switch policy {
+	case OverflowTruncate:
+		dropped := 0
+		for input+req.MaxTokens > window {
+			n := dropOldestAnthropicMessages(req)
+			if n == 0 {
+				overflow.InputTokens = input
+				return nil, overflow
+			}
+			dropped += n
+			input = CountAnthropicRequestTokens(req)
+		}
+		return &ContextAdjustment{DroppedMessages: dropped}, nil
+
+	case OverflowClamp:
+		remaining := window - input
+		if remaining < minClampedMaxTokens {
+			return nil, overflow
+		}
+		req.MaxTokens = remaining
+		return &ContextAdjustment{ClampedMaxTokens: remaining}, nil
+
+	default:
+		return nil, overflow
+	}
+}
+
+// dropOldestAnthropicMessages removes the oldest exchange so the conversation
+// still starts with a user message that does not answer a removed tool call.
+// The final message is never removed. It returns the number of messages removed.
+func dropOldestAnthropicMessages(req *AnthropicRequest) int {
+	if len(req.Messages) < 2 {
+		return 0
+	}
+
+	end := 1
+	for end < len(req.Messages)-1 &&
+		(req.Messages[end].Role != "user" || hasToolResult(req.Messages[end])) {
+		end++
+	}
+	if req.Messages[end].Role != "user" || hasToolResult(req.Messages[end]) {
+		// Only the final message would be left in a valid position
+		if end != len(req.Messages)-1 {
+			return 0
+		}
+	}
+
+	req.Messages = req.Messages[end:]
+	return end
+}
+
+// hasToolResult reports whether a message answers a tool call
+func hasToolResult(msg AnthropicMessage) bool {
+	for _, part := range msg.Content {
+		if part.Type == "tool_result" {
+			return true
+		}
+	}
+	return false
+}
+
+// SetContextLimits enables context window checks before requests are forwarded
+func (p *OpenAIProxy) SetContextLimits(limits ContextLimits) {
+	p.limits = limits
+}
+
+// fitContext applies the client's overflow policy when the request exceeds
+// the model's context window. Models with an unknown window are not checked.
+func (p *OpenAIProxy) fitContext(ctx context.Context, req *OpenAIRequest, clientID string) error {
+	if p.limits == nil {
+		return nil
+	}
+	window := p.limits.ContextWindow(ctx, req.Model)
+	if window <= 0 {
+		return nil
+	}
+
+	adj, err := fitOpenAIRequest(req, window, p.limits.OverflowPolicy(ctx, clientID))
+	if err != nil {
+		return err
+	}
+	logAdjustment(req.Model, clientID, adj)
+	return nil
+}
+
+// SetContextLimits enables context window checks before requests are forwarded
+func (p *AnthropicProxy) SetContextLimits(limits ContextLimits) {
+	p.limits = limits
+}
+
+// fitContext applies the client's overflow policy when the request exceeds
+// the model's context window. Models with an unknown window are not checked.
+func (p *AnthropicProxy) fitContext(ctx context.Context, req *AnthropicRequest, clientID string) error {
+	if p.limits == nil {
+		return nil
+	}
+	window := p.limits.ContextWindow(ctx, req.Model)
+	if window <= 0 {
+		return nil
+	}
+
+	adj, err := fitAnthropicRequest(req, window, p.limits.OverflowPolicy(ctx, clientID))
+	if err != nil {
+		return err
+	}
+	logAdjustment(req.Model, clientID, adj)
+	return nil
+}
+
+// logAdjustment records changes made to fit a request
+func logAdjustment(model, clientID string, adj *ContextAdjustment) {
+	switch {
+	case adj == nil:
+	case adj.DroppedMessages > 0:
+		log.Printf("proxy: dropped %d oldest messages to fit %s context window (client: %s)", adj.DroppedMessages, model, clientID)
+	case adj.ClampedMaxTokens > 0:
+		log.Printf("proxy: clamped max_tokens to %d to fit %s context window (client: %s)", adj.ClampedMaxTokens, model, clientID)
+	}
+}
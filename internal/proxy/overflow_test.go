@@ -0,0 +1,243 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// mockContextLimits serves fixed windows and policies
+type mockContextLimits struct {
+	windows  map[string]int
+	policies map[string]OverflowPolicy
+}
+
+func (m *mockContextLimits) ContextWindow(ctx context.Context, model string) int {
+	return m.windows[model]
+}
+
+func (m *mockContextLimits) OverflowPolicy(ctx context.Context, clientID string) OverflowPolicy {
+	if policy, ok := m.policies[clientID]; ok {
+		return policy
+	}
+	return OverflowReject
+}
+
+func words(n int) string {
+	return strings.TrimSpace(strings.Repeat("word ", n))
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func TestParseOverflowPolicy(t *testing.T) {
+	for _, s := range []string{"", "reject", "truncate", "clamp"} {
+		if _, err := ParseOverflowPolicy(s); err != nil {
+			t.Errorf("ParseOverflowPolicy(%q) error = %v", s, err)
+		}
+	}
+	if policy, _ := ParseOverflowPolicy(""); policy != OverflowReject {
+		t.Errorf("expected empty policy to default to reject, got %q", policy)
+	}
+	if _, err := ParseOverflowPolicy("summarize"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
+
+func TestFitOpenAIRequest_Fits(t *testing.T) {
+	req := &OpenAIRequest{Model: "m", MaxTokens: intPtr(100), Messages: []OpenAIMessage{{Role: "user", Content: "hi"}}}
+	adj, err := fitOpenAIRequest(req, 1000, OverflowReject)
+	if err != nil || adj != nil {
+		t.Errorf("expected request to fit unchanged, got %v, %v", adj, err)
+	}
+}
+
+func TestFitOpenAIRequest_Reject(t *testing.T) {
+	req := &OpenAIRequest{Model: "m", MaxTokens: intPtr(500), Messages: []OpenAIMessage{{Role: "user", Content: words(600)}}}
+	_, err := fitOpenAIRequest(req, 1000, OverflowReject)
+
+	var overflow *ContextOverflowError
+	if !errors.As(err, &overflow) {
+		t.Fatalf("expected ContextOverflowError, got %v", err)
+	}
+	if overflow.Window != 1000 || overflow.OutputTokens != 500 || overflow.InputTokens < 600 {
+		t.Errorf("unexpected overflow details: %+v", overflow)
+	}
+	if !strings.Contains(err.Error(), "maximum context length is 1000") {
+		t.Errorf("unexpected message: %s", err)
+	}
+}
+
+func TestFitOpenAIRequest_Truncate(t *testing.T) {
+	req := &OpenAIRequest{
+		Model:     "m",
+		MaxTokens: intPtr(100),
+		Messages: []OpenAIMessage{
+			{Role: "system", Content: "be brief"},
+			{Role: "user", Content: words(300)},
+			{Role: "assistant", ToolCalls: []OpenAIToolCall{{ID: "call_1"}}},
+			{Role: "tool", ToolCallID: "call_1", Content: words(300)},
+			{Role: "user", Content: words(50)},
+		},
+	}
+
+	adj, err := fitOpenAIRequest(req, 400, OverflowTruncate)
+	if err != nil {
+		t.Fatalf("fitOpenAIRequest() error = %v", err)
+	}
+	if adj == nil || adj.DroppedMessages != 3 {
+		t.Fatalf("expected 3 dropped messages, got %+v", adj)
+	}
+	if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[1].Content != words(50) {
+		t.Errorf("expected system prompt and latest message kept, got %+v", req.Messages)
+	}
+}
+
+func TestFitOpenAIRequest_TruncateCannotFit(t *testing.T) {
+	req := &OpenAIRequest{
+		Model:     "m",
+		MaxTokens: intPtr(100),
+		Messages: []OpenAIMessage{
+			{Role: "user", Content: "hi"},
+			{Role: "user", Content: words(1000)},
+		},
+	}
+	if _, err := fitOpenAIRequest(req, 400, OverflowTruncate); err == nil {
+		t.Error("expected error when the latest message alone overflows")
+	}
+}
+
+func TestFitOpenAIRequest_Clamp(t *testing.T) {
+	req := &OpenAIRequest{Model: "m", MaxCompletionTokens: intPtr(4096), Messages: []OpenAIMessage{{Role: "user", Content: words(100)}}}
+	input := CountOpenAIRequestTokens(req)
+
+	adj, err := fitOpenAIRequest(req, 1000, OverflowClamp)
+	if err != nil {
+		t.Fatalf("fitOpenAIRequest() error = %v", err)
+	}
+	if *req.MaxCompletionTokens != 1000-input || adj.ClampedMaxTokens != 1000-input {
+		t.Errorf("expected max_completion_tokens clamped to %d, got %d", 1000-input, *req.MaxCompletionTokens)
+	}
+
+	// Too little room left is still rejected
+	req = &OpenAIRequest{Model: "m", MaxTokens: intPtr(4096), Messages: []OpenAIMessage{{Role: "user", Content: words(980)}}}
+	if _, err := fitOpenAIRequest(req, 1000, OverflowClamp); err == nil {
+		t.Error("expected error when less than the minimum output would remain")
+	}
+}
+
+func TestFitAnthropicRequest_Truncate(t *testing.T) {
+	text := func(s string) []ContentPart { return []ContentPart{{Type: "text", Text: s}} }
+	req := &AnthropicRequest{
+		Model:     "m",
+		MaxTokens: 100,
+		System:    "be brief",
+		Messages: []AnthropicMessage{
+			{Role: "user", Content: text(words(300))},
+			{Role: "assistant", Content: []ContentPart{{Type: "tool_use", ID: "toolu_1", Name: "search"}}},
+			{Role: "user", Content: []ContentPart{{Type: "tool_result", ToolUseID: "toolu_1", Content: words(300)}}},
+			{Role: "assistant", Content: text("done")},
+			{Role: "user", Content: text(words(50))},
+		},
+	}
+
+	adj, err := fitAnthropicRequest(req, 400, OverflowTruncate)
+	if err != nil {
+		t.Fatalf("fitAnthropicRequest() error = %v", err)
+	}
+	// The tool_result cannot lead the conversation, so the whole exchange is dropped
+	if adj.DroppedMessages != 4 || len(req.Messages) != 1 || req.Messages[0].Role != "user" {
+		t.Errorf("expected only the latest user message kept, got %d dropped, %+v", adj.DroppedMessages, req.Messages)
+	}
+}
+
+func TestFitAnthropicRequest_Clamp(t *testing.T) {
+	req := &AnthropicRequest{
+		Model:     "m",
+		MaxTokens: 4096,
+		Messages:  []AnthropicMessage{{Role: "user", Content: []ContentPart{{Type: "text", Text: words(100)}}}},
+	}
+	input := CountAnthropicRequestTokens(req)
+
+	if _, err := fitAnthropicRequest(req, 1000, OverflowClamp); err != nil {
+		t.Fatalf("fitAnthropicRequest() error = %v", err)
+	}
+	if req.MaxTokens != 1000-input {
+		t.Errorf("expected max_tokens clamped to %d, got %d", 1000-input, req.MaxTokens)
+	}
+}
+
+func TestOpenAIProxy_ContextOverflow(t *testing.T) {
+	var upstreamCalls int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls++
+		var req OpenAIRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"max_tokens": *req.MaxTokens})
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-key"}, nil)
+	p.SetContextLimits(&mockContextLimits{
+		windows:  map[string]int{"small": 1000},
+		policies: map[string]OverflowPolicy{"lenient": OverflowClamp},
+	})
+
+	body := `{"model":"small","messages":[{"role":"user","content":"` + words(100) + `"}]}`
+
+	// Default policy rejects: 4096 default max_tokens does not fit
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	p.HandleChatCompletions(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "maximum context length") || upstreamCalls != 0 {
+		t.Errorf("expected rejection before forwarding, got %s", rec.Body.String())
+	}
+
+	// Clamp policy forwards with a lowered max_tokens
+	req = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("X-Client-ID", "lenient")
+	rec = httptest.NewRecorder()
+	p.HandleChatCompletions(rec, req)
+	if rec.Code != http.StatusOK || upstreamCalls != 1 {
+		t.Fatalf("expected forwarded request, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got map[string]int
+	json.NewDecoder(rec.Body).Decode(&got)
+	if got["max_tokens"] >= 1000 {
+		t.Errorf("expected clamped max_tokens, got %d", got["max_tokens"])
+	}
+
+	// Unknown models are not checked
+	req = httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+		strings.NewReader(`{"model":"other","messages":[{"role":"user","content":"`+words(100)+`"}]}`))
+	rec = httptest.NewRecorder()
+	p.HandleChatCompletions(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected unknown model forwarded, got %d", rec.Code)
+	}
+}
+
+func TestAnthropicProxy_ContextOverflow(t *testing.T) {
+	p := NewAnthropicProxy(DefaultAnthropicProxyConfig(), &mockKeyProvider{key: "test-key"}, nil)
+	p.SetContextLimits(&mockContextLimits{windows: map[string]int{"small": 1000}})
+
+	body := `{"model":"small","max_tokens":2000,"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	p.HandleMessages(rec, req)
+
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "maximum context length") {
+		t.Errorf("expected context length error, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
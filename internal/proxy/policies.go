@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"strings"
+)
+
+// PromptPolicy supplies organization-mandated system prompt text for a
+// client and model (usually a *policy.Engine)
+type PromptPolicy interface {
+	// SystemPrompt returns the text to place before and after the
+	// request's own system prompt; either may be empty
+	SystemPrompt(model, clientID string) (prepend, appendText string, err error)
+}
+
+// SetPromptPolicy adds mandated instructions to request system prompts
+func (p *OpenAIProxy) SetPromptPolicy(pp PromptPolicy) {
+	p.promptPolicy = pp
+}
+
+// SetPromptPolicy adds mandated instructions to request system prompts
+func (p *AnthropicProxy) SetPromptPolicy(pp PromptPolicy) {
+	p.promptPolicy = pp
+}
+
+// applyOpenAIPolicy adds policy text to an OpenAI request as system
+// messages around the client's leading system messages. Translation joins
+// system messages in order, so other formats see the same system prompt.
+func applyOpenAIPolicy(pp PromptPolicy, req *OpenAIRequest, clientID string) error {
+	if pp == nil {
+		return nil
+	}
+	prepend, appendText, err := pp.SystemPrompt(req.Model, clientID)
+	if err != nil || (prepend == "" && appendText == "") {
+		return err
+	}
+
+	// The client's system prompt is its leading system or developer messages
+	end := 0
+	for end < len(req.Messages) && (req.Messages[end].Role == "system" || req.Messages[end].Role == "developer") {
+		end++
+	}
+
+	messages := make([]OpenAIMessage, 0, len(req.Messages)+2)
+	if prepend != "" {
+		messages = append(messages, OpenAIMessage{Role: "system", Content: prepend})
+	}
+	messages = append(messages, req.Messages[:end]...)
+	if appendText != "" {
+		messages = append(messages, OpenAIMessage{Role: "system", Content: appendText})
+	}
+	req.Messages = append(messages, req.Messages[end:]...)
+	return nil
+}
+
+// applyAnthropicPolicy adds policy text to an Anthropic request's system
+// prompt, as separate blocks when the client sent blocks so their
+// cache_control markers keep their place
+func applyAnthropicPolicy(pp PromptPolicy, req *AnthropicRequest, clientID string) error {
+	if pp == nil {
+		return nil
+	}
+	prepend, appendText, err := pp.SystemPrompt(req.Model, clientID)
+	if err != nil || (prepend == "" && appendText == "") {
+		return err
+	}
+
+	if len(req.SystemBlocks) > 0 {
+		blocks := make([]ContentPart, 0, len(req.SystemBlocks)+2)
+		if prepend != "" {
+			blocks = append(blocks, ContentPart{Type: "text", Text: prepend})
+		}
+		blocks = append(blocks, req.SystemBlocks...)
+		if appendText != "" {
+			blocks = append(blocks, ContentPart{Type: "text", Text: appendText})
+		}
+		req.SystemBlocks = blocks
+		req.System = convertContentToString(blocks)
+		return nil
+	}
+
+	var parts []string
+	for _, text := range []string{prepend, req.System, appendText} {
+		if text != "" {
+			parts = append(parts, text)
+		}
+	}
+	req.System = strings.Join(parts, "\n\n")
+	return nil
+}
@@ -0,0 +1,107 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type staticPolicy struct {
+	prepend, appendText string
+	err                 error
+}
+
+func (s staticPolicy) SystemPrompt(model, clientID string) (string, string, error) {
+	return s.prepend, s.appendText, s.err
+}
+
+func TestPromptPolicy_ConsistentAcrossFormats(t *testing.T) {
+	pp := staticPolicy{prepend: "Follow company policy.", appendText: "Cite sources."}
+
+	openaiReq := &OpenAIRequest{Model: "gpt-4o", Messages: []OpenAIMessage{
+		{Role: "system", Content: "You are helpful."},
+		{Role: "user", Content: "Hi"},
+	}}
+	if err := applyOpenAIPolicy(pp, openaiReq, "acme"); err != nil {
+		t.Fatalf("applyOpenAIPolicy failed: %v", err)
+	}
+	roles := []string{}
+	for _, m := range openaiReq.Messages {
+		roles = append(roles, m.Role)
+	}
+	if strings.Join(roles, ",") != "system,system,system,user" || openaiReq.Messages[2].Content != "Cite sources." {
+		t.Fatalf("unexpected messages: %+v", openaiReq.Messages)
+	}
+
+	anthropicReq := &AnthropicRequest{Model: "gpt-4o", System: "You are helpful.",
+		Messages: []AnthropicMessage{{Role: "user", Content: []ContentPart{{Type: "text", Text: "Hi"}}}}}
+	if err := applyAnthropicPolicy(pp, anthropicReq, "acme"); err != nil {
+		t.Fatalf("applyAnthropicPolicy failed: %v", err)
+	}
+
+	// Either path yields the same system prompt once translated
+	translated, err := ToAnthropic(openaiReq)
+	if err != nil {
+		t.Fatalf("ToAnthropic failed: %v", err)
+	}
+	want := "Follow company policy.\n\nYou are helpful.\n\nCite sources."
+	if translated.System != want || anthropicReq.System != want {
+		t.Errorf("system prompts differ:\n%q\n%q", translated.System, anthropicReq.System)
+	}
+
+	// Requests without a system prompt get one
+	bare := &OpenAIRequest{Model: "gpt-4o", Messages: []OpenAIMessage{{Role: "user", Content: "Hi"}}}
+	applyOpenAIPolicy(staticPolicy{appendText: "Cite sources."}, bare, "")
+	if len(bare.Messages) != 2 || bare.Messages[0].Content != "Cite sources." {
+		t.Errorf("unexpected messages: %+v", bare.Messages)
+	}
+
+	// System blocks keep their cache markers in place
+	blocks := &AnthropicRequest{Model: "m", SystemBlocks: []ContentPart{
+		{Type: "text", Text: "Reference", CacheControl: &CacheControl{Type: "ephemeral"}},
+	}}
+	applyAnthropicPolicy(pp, blocks, "")
+	if len(blocks.SystemBlocks) != 3 || blocks.SystemBlocks[0].Text != "Follow company policy." || blocks.SystemBlocks[1].CacheControl == nil {
+		t.Errorf("unexpected system blocks: %+v", blocks.SystemBlocks)
+	}
+}
+
+func TestOpenAIProxy_PromptPolicy(t *testing.T) {
+	var upstreamBody []byte
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"chatcmpl-1","choices":[]}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-key"}, nil)
+	p.SetPromptPolicy(staticPolicy{prepend: "Follow company policy."})
+
+	send := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		p.HandleChatCompletions(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+			strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)))
+		return rec
+	}
+	if rec := send(); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var sent OpenAIRequest
+	json.Unmarshal(upstreamBody, &sent)
+	if len(sent.Messages) != 2 || sent.Messages[0].Role != "system" || sent.Messages[0].Content != "Follow company policy." {
+		t.Errorf("policy not forwarded: %s", upstreamBody)
+	}
+
+	// Mandated instructions that cannot be rendered fail the request
+	p.SetPromptPolicy(staticPolicy{err: errors.New("template failed")})
+	if rec := send(); rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,188 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ====== Prompt Caching ======
+//
+// Anthropic caches a prompt prefix when a block is marked with
+// cache_control; OpenAI caches prefixes automatically and takes a
+// prompt_cache_key hint to route requests sharing a prefix to the same
+// cache. The translators map one onto the other and carry cache hits and
+// writes through the usage fields of both formats.
+
+// UnmarshalJSON accepts the system prompt as a string or as text blocks.
+// Blocks are kept in SystemBlocks so their cache_control markers survive,
+// and their text is joined into System.
+func (r *AnthropicRequest) UnmarshalJSON(data []byte) error {
+	type alias AnthropicRequest
+	aux := struct {
+		*alias
+		System json.RawMessage `json:"system,omitempty"`
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	r.System, r.SystemBlocks = "", nil
+	system := strings.TrimSpace(string(aux.System))
+	switch {
+	case system == "" || system == "null":
+		return nil
+	case strings.HasPrefix(system, `"`):
+		return json.Unmarshal(aux.System, &r.System)
+	}
+	if err := json.Unmarshal(aux.System, &r.SystemBlocks); err != nil {
+		return fmt.Errorf("invalid system prompt: %w", err)
+	}
+	r.System = convertContentToString(r.SystemBlocks)
+	return nil
+}
+
+// MarshalJSON writes the system prompt as SystemBlocks when it has them,
+// and as the System string otherwise
+func (r AnthropicRequest) MarshalJSON() ([]byte, error) {
+	type alias AnthropicRequest
+	aux := struct {
+		alias
+		System interface{} `json:"system,omitempty"`
+	}{alias: alias(r)}
+	if len(r.SystemBlocks) > 0 {
+		aux.System = r.SystemBlocks
+	} else if r.System != "" {
+		aux.System = r.System
+	}
+	return json.Marshal(aux)
+}
+
+// promptCacheKey derives an OpenAI prompt_cache_key from the prefix an
+// Anthropic request marks for caching, so requests sharing that prefix
+// land on the same OpenAI cache. It returns "" when nothing is marked.
+func promptCacheKey(req *AnthropicRequest) string {
+	// The key hashes the prefix up to the last marker, so it stays stable
+	// as the conversation grows past it
+	h := sha256.New()
+	var sum []byte
+	mark := func(cc *CacheControl) {
+		if cc != nil {
+			sum = h.Sum(nil)
+		}
+	}
+	writePart := func(part ContentPart) {
+		h.Write([]byte(part.Type + "\x00" + part.Text + "\x00" + part.Content + "\x00"))
+		mark(part.CacheControl)
+	}
+
+	for _, tool := range req.Tools {
+		h.Write([]byte("tool\x00" + tool.Name + "\x00"))
+		mark(tool.CacheControl)
+	}
+	system := req.SystemBlocks
+	if len(system) == 0 && req.System != "" {
+		system = []ContentPart{{Type: "text", Text: req.System}}
+	}
+	for _, part := range system {
+		writePart(part)
+	}
+	for _, msg := range req.Messages {
+		h.Write([]byte(msg.Role + "\x00"))
+		for _, part := range msg.Content {
+			writePart(part)
+		}
+	}
+
+	if sum == nil {
+		return ""
+	}
+	return "modelscan-" + hex.EncodeToString(sum)[:32]
+}
+
+// markSystemForCaching marks the end of the system prompt, or failing
+// that the tool definitions, for caching. It is used when an OpenAI request
+// asks for caching with prompt_cache_key and carries no markers of its own.
+func markSystemForCaching(req *AnthropicRequest) {
+	if hasCacheControl(req) {
+		return
+	}
+	ephemeral := &CacheControl{Type: "ephemeral"}
+	switch {
+	case len(req.SystemBlocks) > 0:
+		req.SystemBlocks[len(req.SystemBlocks)-1].CacheControl = ephemeral
+	case req.System != "":
+		req.SystemBlocks = []ContentPart{{Type: "text", Text: req.System, CacheControl: ephemeral}}
+	case len(req.Tools) > 0:
+		req.Tools[len(req.Tools)-1].CacheControl = ephemeral
+	}
+}
+
+// hasCacheControl reports whether any block of the request is marked
+func hasCacheControl(req *AnthropicRequest) bool {
+	for _, tool := range req.Tools {
+		if tool.CacheControl != nil {
+			return true
+		}
+	}
+	for _, part := range req.SystemBlocks {
+		if part.CacheControl != nil {
+			return true
+		}
+	}
+	for _, msg := range req.Messages {
+		for _, part := range msg.Content {
+			if part.CacheControl != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cacheControlFromBlock reads the cache_control marker gateways accept on
+// OpenAI content parts
+func cacheControlFromBlock(block map[string]interface{}) *CacheControl {
+	cc, ok := block["cache_control"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	c := &CacheControl{}
+	c.Type, _ = cc["type"].(string)
+	c.TTL, _ = cc["ttl"].(string)
+	return c
+}
+
+// openAIUsage converts Anthropic usage. OpenAI counts cache writes and
+// hits in prompt_tokens and reports hits as cached_tokens.
+func openAIUsage(u *Usage) *OpenAIUsage {
+	if u == nil {
+		return nil
+	}
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	usage := &OpenAIUsage{
+		PromptTokens:     prompt,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      prompt + u.OutputTokens,
+	}
+	if u.CacheCreationInputTokens > 0 || u.CacheReadInputTokens > 0 {
+		usage.PromptTokensDetails = &PromptTokensDetails{CachedTokens: u.CacheReadInputTokens}
+	}
+	return usage
+}
+
+// anthropicUsage converts OpenAI usage. Anthropic reports cache hits
+// separately from input_tokens.
+func anthropicUsage(u *OpenAIUsage) *Usage {
+	if u == nil {
+		return nil
+	}
+	usage := &Usage{InputTokens: u.PromptTokens, OutputTokens: u.CompletionTokens}
+	if d := u.PromptTokensDetails; d != nil && d.CachedTokens > 0 {
+		usage.CacheReadInputTokens = d.CachedTokens
+		usage.InputTokens = max(u.PromptTokens-d.CachedTokens, 0)
+	}
+	return usage
+}
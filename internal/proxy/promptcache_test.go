@@ -0,0 +1,163 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAnthropicRequest_SystemBlocks(t *testing.T) {
+	body := `{"model":"claude-3-5-sonnet","max_tokens":100,
+		"system":[{"type":"text","text":"Be brief."},{"type":"text","text":"Long reference...","cache_control":{"type":"ephemeral"}}],
+		"tools":[{"name":"search","input_schema":{},"cache_control":{"type":"ephemeral","ttl":"1h"}}],
+		"messages":[{"role":"user","content":[{"type":"text","text":"Hi"}]}]}`
+
+	var req AnthropicRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if req.System != "Be brief.\nLong reference..." || len(req.SystemBlocks) != 2 {
+		t.Errorf("unexpected system: %q %+v", req.System, req.SystemBlocks)
+	}
+	if req.Tools[0].CacheControl == nil || req.Tools[0].CacheControl.TTL != "1h" {
+		t.Errorf("tool cache_control lost: %+v", req.Tools[0])
+	}
+
+	// Markers survive being forwarded upstream
+	out, err := json.Marshal(&req)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if strings.Count(string(out), `"cache_control"`) != 2 || !strings.Contains(string(out), `"system":[`) {
+		t.Errorf("cache_control not forwarded: %s", out)
+	}
+
+	// Plain system prompts stay strings
+	var plain AnthropicRequest
+	json.Unmarshal([]byte(`{"model":"m","max_tokens":1,"system":"Be brief.","messages":[]}`), &plain)
+	out, _ = json.Marshal(plain)
+	if plain.System != "Be brief." || !strings.Contains(string(out), `"system":"Be brief."`) {
+		t.Errorf("unexpected plain system: %q %s", plain.System, out)
+	}
+	if err := json.Unmarshal([]byte(`{"system":42}`), &plain); err == nil {
+		t.Error("expected error for an invalid system prompt")
+	}
+}
+
+func TestPromptCacheTranslation(t *testing.T) {
+	req := &AnthropicRequest{
+		Model:     "claude-3-5-sonnet",
+		MaxTokens: 100,
+		SystemBlocks: []ContentPart{
+			{Type: "text", Text: "Long reference...", CacheControl: &CacheControl{Type: "ephemeral"}},
+		},
+		System:   "Long reference...",
+		Messages: []AnthropicMessage{{Role: "user", Content: []ContentPart{{Type: "text", Text: "Q1"}}}},
+	}
+
+	openaiReq, err := ToOpenAI(req)
+	if err != nil {
+		t.Fatalf("ToOpenAI failed: %v", err)
+	}
+	key := openaiReq.PromptCacheKey
+	if !strings.HasPrefix(key, "modelscan-") {
+		t.Fatalf("expected a prompt cache key, got %q", key)
+	}
+
+	// Later turns share the cached prefix and so the key
+	req.Messages = append(req.Messages, AnthropicMessage{Role: "user", Content: []ContentPart{{Type: "text", Text: "Q2"}}})
+	if next, _ := ToOpenAI(req); next.PromptCacheKey != key {
+		t.Errorf("key changed past the cached prefix: %q != %q", next.PromptCacheKey, key)
+	}
+	req.SystemBlocks[0].Text = "Other reference"
+	if other, _ := ToOpenAI(req); other.PromptCacheKey == key {
+		t.Error("expected a different prefix to get a different key")
+	}
+	req.SystemBlocks = nil
+	if unmarked, _ := ToOpenAI(req); unmarked.PromptCacheKey != "" {
+		t.Errorf("expected no key without markers, got %q", unmarked.PromptCacheKey)
+	}
+
+	// A prompt_cache_key marks the system prompt for Anthropic
+	anthropicReq, err := ToAnthropic(&OpenAIRequest{
+		Model:          "claude-3-5-sonnet",
+		PromptCacheKey: "docs",
+		Messages: []OpenAIMessage{
+			{Role: "system", Content: "Long reference..."},
+			{Role: "user", Content: "Q1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ToAnthropic failed: %v", err)
+	}
+	if len(anthropicReq.SystemBlocks) != 1 || anthropicReq.SystemBlocks[0].CacheControl == nil {
+		t.Errorf("expected the system prompt marked for caching, got %+v", anthropicReq.SystemBlocks)
+	}
+
+	// Markers on OpenAI content parts carry over as they are
+	var openaiReq2 OpenAIRequest
+	json.Unmarshal([]byte(`{"model":"m","messages":[
+		{"role":"system","content":[{"type":"text","text":"A"},{"type":"text","text":"B","cache_control":{"type":"ephemeral"}}]},
+		{"role":"user","content":[{"type":"text","text":"Q","cache_control":{"type":"ephemeral","ttl":"5m"}}]}]}`), &openaiReq2)
+	anthropicReq, _ = ToAnthropic(&openaiReq2)
+	if anthropicReq.System != "A\n\nB" || len(anthropicReq.SystemBlocks) != 2 || anthropicReq.SystemBlocks[1].CacheControl == nil {
+		t.Errorf("unexpected system: %q %+v", anthropicReq.System, anthropicReq.SystemBlocks)
+	}
+	if part := anthropicReq.Messages[0].Content[0]; part.Text != "Q" || part.CacheControl == nil || part.CacheControl.TTL != "5m" {
+		t.Errorf("user content marker lost: %+v", part)
+	}
+}
+
+func TestCacheUsageTranslation(t *testing.T) {
+	openaiResp := TranslateResponseToOpenAI(&AnthropicResponse{
+		ID:    "msg_1",
+		Model: "claude-3-5-sonnet",
+		Usage: &Usage{InputTokens: 10, OutputTokens: 5, CacheCreationInputTokens: 200, CacheReadInputTokens: 1000},
+	})
+	u := openaiResp.Usage
+	if u.PromptTokens != 1210 || u.TotalTokens != 1215 || u.PromptTokensDetails == nil || u.PromptTokensDetails.CachedTokens != 1000 {
+		t.Errorf("unexpected OpenAI usage: %+v", u)
+	}
+
+	anthropicResp := TranslateResponseToAnthropic(&OpenAIResponse{
+		Choices: []OpenAIChoice{{Message: OpenAIMessage{Role: "assistant", Content: "hi"}}},
+		Usage:   &OpenAIUsage{PromptTokens: 1200, CompletionTokens: 5, PromptTokensDetails: &PromptTokensDetails{CachedTokens: 1024}},
+	})
+	if u := anthropicResp.Usage; u.InputTokens != 176 || u.CacheReadInputTokens != 1024 || u.OutputTokens != 5 {
+		t.Errorf("unexpected Anthropic usage: %+v", u)
+	}
+
+	// Anthropic streams report prompt and cache usage in message_start
+	chunk := TranslateStreamChunkToOpenAI(&AnthropicStreamEvent{
+		Type:    "message_start",
+		Message: &AnthropicResponse{Model: "claude-3-5-sonnet", Usage: &Usage{InputTokens: 3, CacheReadInputTokens: 900}},
+	}, "chatcmpl-1")
+	if chunk.Usage == nil || chunk.Usage.PromptTokens != 903 || chunk.Usage.PromptTokensDetails.CachedTokens != 900 {
+		t.Errorf("unexpected stream usage: %+v", chunk.Usage)
+	}
+	events := TranslateStreamChunkToAnthropic(&OpenAIStreamChunk{
+		Choices: []OpenAIStreamChoice{{}},
+		Usage:   &OpenAIUsage{PromptTokens: 100, PromptTokensDetails: &PromptTokensDetails{CachedTokens: 64}},
+	}, new(int))
+	if len(events) != 1 || events[0].Usage.CacheReadInputTokens != 64 || events[0].Usage.InputTokens != 36 {
+		t.Errorf("unexpected stream events: %+v", events)
+	}
+}
+
+func TestMeterWriter_CacheUsage(t *testing.T) {
+	rec := httptest.NewRecorder()
+	mw := &meterWriter{ResponseWriter: rec, status: http.StatusOK}
+	mw.Write([]byte(`{"content":[{"type":"text","text":"hi"}],"usage":{"input_tokens":10,"output_tokens":2,"cache_creation_input_tokens":200,"cache_read_input_tokens":1000}}`))
+
+	if prompt, _ := mw.tokens(0); prompt != 1210 || mw.cacheRead != 1000 || mw.cacheWrite != 200 {
+		t.Errorf("unexpected metered usage: prompt %d, read %d, write %d", prompt, mw.cacheRead, mw.cacheWrite)
+	}
+
+	mw = &meterWriter{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+	mw.Write([]byte(`{"choices":[],"usage":{"prompt_tokens":1200,"completion_tokens":5,"prompt_tokens_details":{"cached_tokens":1024}}}`))
+	if prompt, _ := mw.tokens(0); prompt != 1200 || mw.cacheRead != 1024 || mw.cacheWrite != 0 {
+		t.Errorf("unexpected metered usage: prompt %d, read %d", prompt, mw.cacheRead)
+	}
+}
@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+)
+
+// maxEventLine is the longest SSE line relayed, as for scanned streams
+const maxEventLine = 1024 * 1024
+
+var (
+	fieldData  = []byte("data:")
+	fieldEvent = []byte("event:")
+	doneMarker = []byte("[DONE]")
+	eventError = []byte("error")
+	fieldError = []byte(`"error"`)
+)
+
+// relaySSE forwards an upstream SSE stream that is already in the client's
+// format. The event and data lines of each event are copied through as
+// received, without being parsed or re-encoded; comments and other fields
+// are dropped. The upstream [DONE] marker ends the stream, which Close
+// marks with its own. Usage is still metered, from the relayed bytes, and
+// an upstream error event marks the stream failed.
+func relaySSE(ctx context.Context, sw *StreamWriter, reader io.Reader) {
+	r, release := newLineReader(reader)
+	defer release()
+	frame := getBuffer()
+	defer putBuffer(frame)
+
+	var (
+		keep      bool // The line being read is forwarded
+		partial   bool // The line being read did not fit the buffer
+		lineStart int  // Offset of the line being read in frame
+		lineLen   int  // Length of the line being read
+		data      int  // Data lines in the frame
+		done      bool // The frame's only data is the [DONE] marker
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		chunk, err := r.ReadSlice('\n')
+		if !partial {
+			if err == nil && len(bytes.TrimRight(chunk, "\r\n")) == 0 {
+				// Empty line means end of event
+				if data == 1 && done {
+					_ = sw.Close()
+					return
+				}
+				if werr := relayEvent(sw, frame, data); werr != nil {
+					log.Printf("proxy: abandoning stream: %v", werr)
+					return
+				}
+				frame.Reset()
+				data, done = 0, false
+				continue
+			}
+			keep = bytes.HasPrefix(chunk, fieldData) || bytes.HasPrefix(chunk, fieldEvent)
+			lineStart, lineLen = frame.Len(), 0
+		}
+
+		lineLen += len(chunk)
+		if lineLen > maxEventLine {
+			_ = sw.WriteError(fmt.Errorf("stream read error: %w", bufio.ErrTooLong))
+			return
+		}
+		if keep {
+			frame.Write(chunk)
+		}
+		partial = err == bufio.ErrBufferFull
+		if err != nil && !partial && err != io.EOF {
+			_ = sw.WriteError(fmt.Errorf("stream read error: %w", err))
+			break
+		}
+
+		if keep && !partial {
+			// A complete line, or the last one before EOF: normalize its
+			// ending to a bare newline
+			line := bytes.TrimRight(frame.Bytes()[lineStart:], "\r\n")
+			frame.Truncate(lineStart + len(line))
+			frame.WriteByte('\n')
+			if value, ok := bytes.CutPrefix(line, fieldData); ok {
+				data++
+				done = bytes.Equal(bytes.TrimSpace(value), doneMarker)
+			}
+		}
+
+		if err == io.EOF {
+			// Relay the last event even when upstream closes without the
+			// blank line that ends it
+			if !(data == 1 && done) {
+				if werr := relayEvent(sw, frame, data); werr != nil {
+					log.Printf("proxy: abandoning stream: %v", werr)
+					return
+				}
+			}
+			break
+		}
+	}
+
+	// Close stream
+	_ = sw.Close()
+}
+
+// relayEvent writes the event held in frame, if it has any data lines. An
+// upstream error event fails the stream as one of the proxy's own would.
+func relayEvent(sw *StreamWriter, frame *bytes.Buffer, data int) error {
+	if data == 0 {
+		return nil
+	}
+	frame.WriteByte('\n')
+	if err := sw.WriteRaw(frame.Bytes()); err != nil {
+		return err
+	}
+	if isErrorEvent(frame.Bytes()) {
+		notifyStreamFailed(sw.w)
+	}
+	return nil
+}
+
+// isErrorEvent reports whether an event is an upstream error: an "error"
+// event, as Anthropic sends, or data holding an error object, as OpenAI
+// compatible providers send
+func isErrorEvent(frame []byte) bool {
+	for len(frame) > 0 {
+		var line []byte
+		line, frame, _ = bytes.Cut(frame, []byte{'\n'})
+		if value, ok := bytes.CutPrefix(line, fieldEvent); ok && bytes.Equal(bytes.TrimSpace(value), eventError) {
+			return true
+		}
+		value, ok := bytes.CutPrefix(line, fieldData)
+		if !ok || !bytes.Contains(value, fieldError) {
+			continue
+		}
+		var payload struct {
+			Error json.RawMessage `json:"error"`
+		}
+		if json.Unmarshal(value, &payload) == nil && len(payload.Error) > 0 && !bytes.Equal(payload.Error, []byte("null")) {
+			return true
+		}
+	}
+	return false
+}
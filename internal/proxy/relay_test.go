@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRelaySSE(t *testing.T) {
+	upstream := ": OPENROUTER PROCESSING\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n" +
+		"id: 2\r\ndata:{\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\r\n\r\n" +
+		"event: content_block_delta\ndata: {\"a\":1}\ndata: {\"b\":2}\n\n" +
+		"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":2}}\n\n" +
+		"data: [DONE]\n\n" +
+		"data: {\"after\":\"done\"}\n\n"
+
+	w := httptest.NewRecorder()
+	mw := &meterWriter{ResponseWriter: w, status: http.StatusOK}
+	sw, err := NewStreamWriter(mw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	relaySSE(context.Background(), sw, strings.NewReader(upstream))
+
+	want := "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n" +
+		"data:{\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n" +
+		"event: content_block_delta\ndata: {\"a\":1}\ndata: {\"b\":2}\n\n" +
+		"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":2}}\n\n" +
+		"data: [DONE]\n\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("relayed stream:\n%q\nwant:\n%q", got, want)
+	}
+	if !sw.IsClosed() {
+		t.Error("expected [DONE] to close the stream")
+	}
+
+	// Usage is metered from the relayed bytes
+	if prompt, completion := mw.tokens(0); prompt != 12 || completion != 2 || mw.text.String() != "Hello" {
+		t.Errorf("metered %d+%d tokens, text %q", prompt, completion, mw.text.String())
+	}
+}
+
+func TestRelaySSE_Errors(t *testing.T) {
+	// A stream cut off without [DONE] is still closed, after relaying
+	// the event it was cut off in
+	w := httptest.NewRecorder()
+	sw, _ := NewStreamWriter(w)
+	relaySSE(context.Background(), sw, strings.NewReader("data: {\"a\":1}\n\ndata: {\"partial"))
+	if got := w.Body.String(); got != "data: {\"a\":1}\n\ndata: {\"partial\n\ndata: [DONE]\n\n" {
+		t.Errorf("unexpected stream: %q", got)
+	}
+
+	// The last event is relayed when upstream closes without the blank
+	// line, or the newline, that ends it
+	for _, upstream := range []string{
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}\n",
+		"event: message_stop\r\ndata: {\"type\":\"message_stop\"}",
+	} {
+		w = httptest.NewRecorder()
+		sw, _ = NewStreamWriter(w)
+		relaySSE(context.Background(), sw, strings.NewReader(upstream))
+		want := "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\ndata: [DONE]\n\n"
+		if got := w.Body.String(); got != want {
+			t.Errorf("relaying %q: got %q, want %q", upstream, got, want)
+		}
+	}
+
+	// A trailing [DONE] marker without its blank line still ends the stream
+	w = httptest.NewRecorder()
+	sw, _ = NewStreamWriter(w)
+	relaySSE(context.Background(), sw, strings.NewReader("data: {}\n\ndata: [DONE]"))
+	if got := w.Body.String(); got != "data: {}\n\ndata: [DONE]\n\n" {
+		t.Errorf("unexpected stream: %q", got)
+	}
+
+	// Lines longer than the limit end the stream with an error
+	w = httptest.NewRecorder()
+	sw, _ = NewStreamWriter(w)
+	relaySSE(context.Background(), sw, strings.NewReader("data: "+strings.Repeat("x", maxEventLine)+"\n\n"))
+	if !strings.Contains(w.Body.String(), "event: error") || !strings.Contains(w.Body.String(), "token too long") {
+		t.Errorf("expected a too long error, got %.200q", w.Body.String())
+	}
+
+	// Read failures are reported
+	w = httptest.NewRecorder()
+	sw, _ = NewStreamWriter(w)
+	relaySSE(context.Background(), sw, io.MultiReader(strings.NewReader("data: {}\n\n"), errReader{}))
+	if !strings.Contains(w.Body.String(), "stream read error") {
+		t.Errorf("expected a read error, got %q", w.Body.String())
+	}
+}
+
+func TestIsErrorEvent(t *testing.T) {
+	tests := []struct {
+		frame string
+		want  bool
+	}{
+		{"event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\"}}\n\n", true},
+		{"data: {\"error\": {\"message\": \"overloaded\"}}\n\n", true},
+		{"data: {\"error\":null,\"choices\":[]}\n\n", false},
+		{"data: {\"choices\":[{\"delta\":{\"content\":\"\\\"error\\\"\"}}]}\n\n", false},
+		{"data: {\"choices\":[{\"finish_reason\":\"error\"}]}\n\n", false},
+		{"event: content_block_delta\ndata: {\"a\":1}\n\n", false},
+	}
+	for _, tt := range tests {
+		if got := isErrorEvent([]byte(tt.frame)); got != tt.want {
+			t.Errorf("isErrorEvent(%q) = %v, want %v", tt.frame, got, tt.want)
+		}
+	}
+}
+
+// BenchmarkRelaySSE measures relaying a 200 chunk completion stream
+func BenchmarkRelaySSE(b *testing.B) {
+	var upstream strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&upstream, "data: {\"id\":\"chatcmpl-123\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"token %d \"}}]}\n\n", i)
+	}
+	upstream.WriteString("data: [DONE]\n\n")
+	stream := upstream.String()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(stream)))
+	for i := 0; i < b.N; i++ {
+		sw, _ := NewStreamWriter(httptest.NewRecorder())
+		sw.SetWriteTimeout(0)
+		relaySSE(context.Background(), sw, strings.NewReader(stream))
+	}
+}
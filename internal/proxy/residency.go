@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HeaderResidency on a request names the region its data must stay in,
+// such as "eu". It can only narrow a client's residency policy, never
+// lift it.
+const HeaderResidency = "X-Modelscan-Residency"
+
+// Residency pins requests to providers whose endpoints serve from a
+// required region. A request with a requirement never falls back to a
+// provider outside it: when no compliant provider can serve it, it fails.
+type Residency struct {
+	providers map[string]map[string]bool // provider -> regions its endpoints serve from
+	clients   map[string]string          // client ID -> required region
+}
+
+// NewResidency creates a residency policy from the regions each provider
+// serves from and the region each client's requests must stay in. Region
+// names are case-insensitive.
+func NewResidency(providers map[string][]string, clients map[string]string) (*Residency, error) {
+	r := &Residency{
+		providers: make(map[string]map[string]bool, len(providers)),
+		clients:   make(map[string]string, len(clients)),
+	}
+	served := make(map[string]bool)
+	for provider, regions := range providers {
+		if len(regions) == 0 {
+			return nil, fmt.Errorf("provider %s has no regions", provider)
+		}
+		r.providers[provider] = make(map[string]bool, len(regions))
+		for _, region := range regions {
+			region = normalizeRegion(region)
+			if region == "" {
+				return nil, fmt.Errorf("provider %s has an empty region", provider)
+			}
+			r.providers[provider][region] = true
+			served[region] = true
+		}
+	}
+	for clientID, region := range clients {
+		region = normalizeRegion(region)
+		if !served[region] {
+			return nil, fmt.Errorf("client %s requires region %q, which no provider serves", clientID, region)
+		}
+		r.clients[clientID] = region
+	}
+	return r, nil
+}
+
+func normalizeRegion(region string) string {
+	return strings.ToLower(strings.TrimSpace(region))
+}
+
+// Required returns the region a request must stay in, or "" for none: the
+// client's policy, or else the region the request is tagged with. A tag
+// that conflicts with the client's policy, or any tag when residency is
+// not configured, is an error, since the requirement could not be honored.
+func (r *Residency) Required(clientID, tag string) (string, error) {
+	tag = normalizeRegion(tag)
+	if r == nil {
+		if tag != "" {
+			return "", fmt.Errorf("data residency is not configured; cannot keep the request in region %s", tag)
+		}
+		return "", nil
+	}
+	policy := r.clients[clientID]
+	if tag != "" && policy != "" && tag != policy {
+		return "", fmt.Errorf("client %s must stay in region %s; the request cannot be tagged for %s", clientID, policy, tag)
+	}
+	if policy != "" {
+		return policy, nil
+	}
+	return tag, nil
+}
+
+// Allows reports whether provider serves from region. Any provider is
+// allowed when region is empty; none is when residency is not configured
+// or the provider's regions are unknown.
+func (r *Residency) Allows(provider, region string) bool {
+	if region == "" {
+		return true
+	}
+	return r != nil && r.providers[provider][region]
+}
+
+// filterChain keeps the steps of a chain that serve from region
+func (r *Residency) filterChain(chain FallbackChain, region string) (FallbackChain, error) {
+	if region == "" {
+		return chain, nil
+	}
+	filtered := FallbackChain{Model: chain.Model}
+	for _, step := range chain.Steps {
+		if r.Allows(step.Provider, region) {
+			filtered.Steps = append(filtered.Steps, step)
+		}
+	}
+	if len(filtered.Steps) == 0 {
+		return filtered, &ResidencyError{Region: region, Model: chain.Model}
+	}
+	return filtered, nil
+}
+
+// ResidencyError reports a request that no compliant provider can serve
+type ResidencyError struct {
+	Region   string
+	Provider string // Empty when no step of a fallback chain is compliant
+	Model    string
+}
+
+func (e *ResidencyError) Error() string {
+	if e.Provider == "" {
+		return fmt.Sprintf("no provider in the fallback chain for %s serves data residency region %s", e.Model, e.Region)
+	}
+	return fmt.Sprintf("provider %s does not serve data residency region %s; refusing to route %s outside it", e.Provider, e.Region, e.Model)
+}
+
+// SetResidency keeps requests with a residency requirement on compliant
+// providers
+func (p *OpenAIProxy) SetResidency(r *Residency) {
+	p.residency = r
+}
+
+// SetResidency keeps requests with a residency requirement on compliant
+// providers
+func (p *AnthropicProxy) SetResidency(r *Residency) {
+	p.residency = r
+}
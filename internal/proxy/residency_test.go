@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newTestResidency(t *testing.T) *Residency {
+	t.Helper()
+	r, err := NewResidency(
+		map[string][]string{"openai": {"US"}, "mistral": {"eu"}, "azure": {"us", "eu"}},
+		map[string]string{"eu-app": "EU"},
+	)
+	if err != nil {
+		t.Fatalf("NewResidency: %v", err)
+	}
+	return r
+}
+
+func TestNewResidency_Validation(t *testing.T) {
+	if _, err := NewResidency(map[string][]string{"openai": {}}, nil); err == nil {
+		t.Error("expected an error for a provider without regions")
+	}
+	if _, err := NewResidency(map[string][]string{"openai": {" "}}, nil); err == nil {
+		t.Error("expected an error for an empty region")
+	}
+	if _, err := NewResidency(map[string][]string{"openai": {"us"}}, map[string]string{"app": "asia"}); err == nil {
+		t.Error("expected an error for a client region no provider serves")
+	}
+}
+
+func TestResidency_Required(t *testing.T) {
+	r := newTestResidency(t)
+	for _, tc := range []struct {
+		clientID, tag, want string
+		wantErr             bool
+	}{
+		{"eu-app", "", "eu", false},
+		{"eu-app", "eu", "eu", false},
+		{"eu-app", "us", "", true},
+		{"other", "US", "us", false},
+		{"other", "", "", false},
+	} {
+		got, err := r.Required(tc.clientID, tc.tag)
+		if got != tc.want || (err != nil) != tc.wantErr {
+			t.Errorf("Required(%q, %q) = %q, %v", tc.clientID, tc.tag, got, err)
+		}
+	}
+
+	var disabled *Residency
+	if _, err := disabled.Required("app", "eu"); err == nil {
+		t.Error("expected a tagged request to fail without residency configured")
+	}
+	if !disabled.Allows("openai", "") || disabled.Allows("openai", "eu") {
+		t.Error("unexpected Allows without residency configured")
+	}
+}
+
+func TestOpenAIProxy_ResidencyRejectsNonCompliantProvider(t *testing.T) {
+	p, upstream := newFallbackProxy(t)
+	p.SetResidency(newTestResidency(t))
+
+	w := sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, http.Header{"X-Client-Id": {"eu-app"}})
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "region eu") {
+		t.Errorf("expected 403 for openai in the eu, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`,
+		http.Header{"X-Client-Id": {"eu-app"}, HeaderProvider: {"azure"}})
+	if w.Code != http.StatusOK {
+		t.Errorf("expected a compliant provider to serve, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = sendChat(p, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hi"}]}`, http.Header{HeaderResidency: {"asia"}})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected a tagged request with no compliant provider to fail, got %d", w.Code)
+	}
+	if len(upstream.models()) != 1 {
+		t.Errorf("expected only the compliant request upstream, got %v", upstream.models())
+	}
+}
+
+func TestOpenAIProxy_ResidencyFiltersFallbackChain(t *testing.T) {
+	p, upstream := newFallbackProxy(t, FallbackChain{
+		Model: "smart",
+		Steps: []FallbackStep{
+			{Provider: "openai", Model: "gpt-4o"},
+			{Provider: "mistral", Model: "broken"},
+			{Provider: "groq", Model: "llama-3.3-70b"},
+		},
+	})
+	p.SetResidency(newTestResidency(t))
+
+	// The only compliant step fails; the request must not leave the region
+	w := sendChat(p, `{"model": "smart", "messages": [{"role": "user", "content": "hi"}]}`, http.Header{HeaderResidency: {"eu"}})
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected the compliant step's failure, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := strings.Join(upstream.models(), ","); got != "broken" {
+		t.Errorf("expected only the compliant step to be tried, got %s", got)
+	}
+
+	d := p.ExplainRoute(context.Background(), "smart", "eu-app", "")
+	if d.Residency != "eu" || d.Provider != "mistral" || !strings.Contains(d.Candidates[0].Eliminated, "region eu") {
+		t.Errorf("unexpected decision %+v", d)
+	}
+
+	p.SetAvailability(maintenanceSet{"mistral": {}})
+	w = sendChat(p, `{"model": "smart", "messages": [{"role": "user", "content": "hi"}]}`, http.Header{"X-Client-Id": {"eu-app"}})
+	if w.Code != http.StatusServiceUnavailable || len(upstream.models()) != 1 {
+		t.Errorf("expected a hard failure with the compliant provider drained, got %d, upstream %v", w.Code, upstream.models())
+	}
+}
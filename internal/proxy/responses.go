@@ -0,0 +1,693 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ====== Responses API Types ======
+
+// ResponsesRequest represents an OpenAI Responses API request.
+// Input is a plain string or a list of input items.
+type ResponsesRequest struct {
+	Model              string            `json:"model"`
+	Input              json.RawMessage   `json:"input"`
+	Instructions       string            `json:"instructions,omitempty"`
+	MaxOutputTokens    *int              `json:"max_output_tokens,omitempty"`
+	Temperature        *float64          `json:"temperature,omitempty"`
+	TopP               *float64          `json:"top_p,omitempty"`
+	Tools              []ResponsesTool   `json:"tools,omitempty"`
+	ToolChoice         interface{}       `json:"tool_choice,omitempty"`
+	ParallelToolCalls  *bool             `json:"parallel_tool_calls,omitempty"`
+	Stream             bool              `json:"stream,omitempty"`
+	User               string            `json:"user,omitempty"`
+	PreviousResponseID string            `json:"previous_response_id,omitempty"`
+	Store              *bool             `json:"store,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	PromptCacheKey     string            `json:"prompt_cache_key,omitempty"`
+}
+
+// ResponsesInputItem is one item of a Responses API input list: a message,
+// a function call the model made, or the output of one.
+// Content is a plain string or a list of content parts.
+type ResponsesInputItem struct {
+	Type      string          `json:"type,omitempty"` // messages may omit it
+	ID        string          `json:"id,omitempty"`
+	Role      string          `json:"role,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"`
+	CallID    string          `json:"call_id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Arguments string          `json:"arguments,omitempty"`
+	Output    json.RawMessage `json:"output,omitempty"` // string, or content parts
+}
+
+// ResponsesContent is a content part of a Responses API message.
+type ResponsesContent struct {
+	Type        string        `json:"type"`
+	Text        string        `json:"text,omitempty"`
+	ImageURL    string        `json:"image_url,omitempty"`
+	FileID      string        `json:"file_id,omitempty"`
+	Detail      string        `json:"detail,omitempty"`
+	Annotations []interface{} `json:"annotations,omitempty"`
+}
+
+// ResponsesTool represents a tool in Responses API format. Function tools
+// are flat; built-in tools (web_search, file_search, ...) only run on
+// providers with native Responses API support.
+type ResponsesTool struct {
+	Type        string                 `json:"type"`
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	Strict      *bool                  `json:"strict,omitempty"`
+}
+
+// ResponsesResponse represents an OpenAI Responses API response.
+type ResponsesResponse struct {
+	ID                 string                `json:"id"`
+	Object             string                `json:"object"` // always "response"
+	CreatedAt          int64                 `json:"created_at"`
+	Status             string                `json:"status"` // completed, incomplete, in_progress or failed
+	Error              *ResponsesError       `json:"error"`
+	IncompleteDetails  *ResponsesIncomplete  `json:"incomplete_details"`
+	Instructions       *string               `json:"instructions"`
+	MaxOutputTokens    *int                  `json:"max_output_tokens"`
+	Model              string                `json:"model"`
+	Output             []ResponsesOutputItem `json:"output"`
+	ParallelToolCalls  bool                  `json:"parallel_tool_calls"`
+	PreviousResponseID *string               `json:"previous_response_id"`
+	Temperature        *float64              `json:"temperature"`
+	TopP               *float64              `json:"top_p"`
+	ToolChoice         interface{}           `json:"tool_choice"`
+	Tools              []ResponsesTool       `json:"tools"`
+	Usage              *ResponsesUsage       `json:"usage"`
+	Metadata           map[string]string     `json:"metadata"`
+}
+
+// ResponsesError describes why a response failed.
+type ResponsesError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ResponsesIncomplete describes why a response stopped early.
+type ResponsesIncomplete struct {
+	Reason string `json:"reason"` // max_output_tokens or content_filter
+}
+
+// ResponsesOutputItem is a message or function call the model produced.
+type ResponsesOutputItem struct {
+	Type      string             `json:"type"` // message or function_call
+	ID        string             `json:"id"`
+	Status    string             `json:"status"`
+	Role      string             `json:"role,omitempty"`
+	Content   []ResponsesContent `json:"content,omitempty"`
+	CallID    string             `json:"call_id,omitempty"`
+	Name      string             `json:"name,omitempty"`
+	Arguments string             `json:"arguments,omitempty"`
+}
+
+// ResponsesUsage tracks token usage. InputTokens includes cached tokens.
+type ResponsesUsage struct {
+	InputTokens        int `json:"input_tokens"`
+	InputTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"input_tokens_details"`
+	OutputTokens        int `json:"output_tokens"`
+	OutputTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"output_tokens_details"`
+	TotalTokens int `json:"total_tokens"`
+}
+
+// ====== Request Translation ======
+
+// ErrNeedsNativeResponses reports a Responses API request that uses
+// features chat completions cannot express
+type ErrNeedsNativeResponses struct {
+	Feature string
+}
+
+func (e *ErrNeedsNativeResponses) Error() string {
+	return fmt.Sprintf("%s requires a provider with native Responses API support", e.Feature)
+}
+
+// ResponsesToChat converts a Responses API request to a Chat Completions
+// request, for providers without the Responses API. Requests that rely on
+// stored state or built-in tools fail with *ErrNeedsNativeResponses.
+func ResponsesToChat(req *ResponsesRequest) (*OpenAIRequest, error) {
+	if req == nil {
+		return nil, fmt.Errorf("nil responses request")
+	}
+	if req.PreviousResponseID != "" {
+		return nil, &ErrNeedsNativeResponses{Feature: "previous_response_id"}
+	}
+
+	chat := &OpenAIRequest{
+		Model:          req.Model,
+		MaxTokens:      req.MaxOutputTokens,
+		Temperature:    req.Temperature,
+		TopP:           req.TopP,
+		Stream:         req.Stream,
+		User:           req.User,
+		PromptCacheKey: req.PromptCacheKey,
+	}
+	if req.Stream {
+		// Usage is reported on the final response
+		chat.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
+	if req.Instructions != "" {
+		chat.Messages = append(chat.Messages, OpenAIMessage{Role: "system", Content: req.Instructions})
+	}
+
+	messages, err := responsesInputToChat(req.Input)
+	if err != nil {
+		return nil, err
+	}
+	chat.Messages = append(chat.Messages, messages...)
+
+	for _, tool := range req.Tools {
+		if tool.Type != "function" {
+			return nil, &ErrNeedsNativeResponses{Feature: fmt.Sprintf("tool type %q", tool.Type)}
+		}
+		chat.Tools = append(chat.Tools, OpenAITool{
+			Type: "function",
+			Function: OpenAIFunctionDef{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+	chat.ToolChoice, err = responsesToolChoiceToChat(req.ToolChoice)
+	if err != nil {
+		return nil, err
+	}
+	return chat, nil
+}
+
+// responsesInputToChat converts the input string or items to chat messages.
+// Consecutive function calls become one assistant message with several
+// tool calls.
+func responsesInputToChat(input json.RawMessage) ([]OpenAIMessage, error) {
+	var text string
+	if err := json.Unmarshal(input, &text); err == nil {
+		return []OpenAIMessage{{Role: "user", Content: text}}, nil
+	}
+	var items []ResponsesInputItem
+	if err := json.Unmarshal(input, &items); err != nil {
+		return nil, fmt.Errorf("input must be a string or a list of items: %w", err)
+	}
+
+	var messages []OpenAIMessage
+	for i, item := range items {
+		itemType := item.Type
+		if itemType == "" && item.Role != "" {
+			itemType = "message"
+		}
+		switch itemType {
+		case "message":
+			msg, err := responsesMessageToChat(item)
+			if err != nil {
+				return nil, fmt.Errorf("input[%d]: %w", i, err)
+			}
+			messages = append(messages, msg)
+		case "function_call":
+			call := OpenAIToolCall{
+				ID:       item.CallID,
+				Type:     "function",
+				Function: OpenAIFunction{Name: item.Name, Arguments: item.Arguments},
+			}
+			if n := len(messages); n > 0 && messages[n-1].Role == "assistant" && len(messages[n-1].ToolCalls) > 0 {
+				messages[n-1].ToolCalls = append(messages[n-1].ToolCalls, call)
+				continue
+			}
+			messages = append(messages, OpenAIMessage{Role: "assistant", ToolCalls: []OpenAIToolCall{call}})
+		case "function_call_output":
+			messages = append(messages, OpenAIMessage{
+				Role:       "tool",
+				Content:    responsesText(item.Output),
+				ToolCallID: item.CallID,
+			})
+		case "reasoning":
+			// Reasoning from an earlier turn only means something to the
+			// model that produced it
+		default:
+			return nil, &ErrNeedsNativeResponses{Feature: fmt.Sprintf("input item type %q", itemType)}
+		}
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("input is required")
+	}
+	return messages, nil
+}
+
+// responsesMessageToChat converts a message item. Text-only content becomes
+// a string; content with images becomes text and image_url parts.
+func responsesMessageToChat(item ResponsesInputItem) (OpenAIMessage, error) {
+	role := item.Role
+	if role == "developer" {
+		role = "system"
+	}
+	msg := OpenAIMessage{Role: role}
+
+	var text string
+	if err := json.Unmarshal(item.Content, &text); err == nil {
+		msg.Content = text
+		return msg, nil
+	}
+	var parts []ResponsesContent
+	if err := json.Unmarshal(item.Content, &parts); err != nil {
+		return msg, fmt.Errorf("content must be a string or a list of parts: %w", err)
+	}
+
+	var texts []string
+	var chatParts []interface{}
+	hasImage := false
+	for _, part := range parts {
+		switch part.Type {
+		case "input_text", "output_text", "text":
+			texts = append(texts, part.Text)
+			chatParts = append(chatParts, map[string]interface{}{"type": "text", "text": part.Text})
+		case "input_image":
+			if part.ImageURL == "" {
+				return msg, &ErrNeedsNativeResponses{Feature: "input_image by file_id"}
+			}
+			hasImage = true
+			imageURL := map[string]interface{}{"url": part.ImageURL}
+			if part.Detail != "" {
+				imageURL["detail"] = part.Detail
+			}
+			chatParts = append(chatParts, map[string]interface{}{"type": "image_url", "image_url": imageURL})
+		case "refusal":
+			texts = append(texts, part.Text)
+		default:
+			return msg, &ErrNeedsNativeResponses{Feature: fmt.Sprintf("content type %q", part.Type)}
+		}
+	}
+	if hasImage {
+		msg.Content = chatParts
+	} else {
+		msg.Content = joinStrings(texts, "\n")
+	}
+	return msg, nil
+}
+
+// responsesText reads a function call output, a string or text parts
+func responsesText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var parts []ResponsesContent
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return string(raw)
+	}
+	var texts []string
+	for _, part := range parts {
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return joinStrings(texts, "\n")
+}
+
+// responsesToolChoiceToChat converts tool_choice; named functions are flat
+// in the Responses API and nested in chat completions
+func responsesToolChoiceToChat(tc interface{}) (interface{}, error) {
+	switch v := tc.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return v, nil
+	case map[string]interface{}:
+		if v["type"] != "function" {
+			return nil, &ErrNeedsNativeResponses{Feature: fmt.Sprintf("tool_choice type %v", v["type"])}
+		}
+		return map[string]interface{}{
+			"type":     "function",
+			"function": map[string]interface{}{"name": v["name"]},
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid tool_choice")
+	}
+}
+
+// ====== Response Translation ======
+
+// newResponsesResponse starts a response that echoes the request's settings
+func newResponsesResponse(req *ResponsesRequest, id, model string) *ResponsesResponse {
+	resp := &ResponsesResponse{
+		ID:                id,
+		Object:            "response",
+		CreatedAt:         time.Now().Unix(),
+		Status:            "in_progress",
+		MaxOutputTokens:   req.MaxOutputTokens,
+		Model:             model,
+		Output:            []ResponsesOutputItem{},
+		ParallelToolCalls: req.ParallelToolCalls == nil || *req.ParallelToolCalls,
+		Temperature:       req.Temperature,
+		TopP:              req.TopP,
+		ToolChoice:        req.ToolChoice,
+		Tools:             req.Tools,
+		Metadata:          req.Metadata,
+	}
+	if req.Instructions != "" {
+		resp.Instructions = &req.Instructions
+	}
+	if resp.ToolChoice == nil {
+		resp.ToolChoice = "auto"
+	}
+	if resp.Tools == nil {
+		resp.Tools = []ResponsesTool{}
+	}
+	if resp.Metadata == nil {
+		resp.Metadata = map[string]string{}
+	}
+	return resp
+}
+
+// finishResponsesResponse sets the final status from the chat finish reason
+func finishResponsesResponse(resp *ResponsesResponse, finishReason string, usage *OpenAIUsage) {
+	switch finishReason {
+	case "length":
+		resp.Status = "incomplete"
+		resp.IncompleteDetails = &ResponsesIncomplete{Reason: "max_output_tokens"}
+	case "content_filter":
+		resp.Status = "incomplete"
+		resp.IncompleteDetails = &ResponsesIncomplete{Reason: "content_filter"}
+	default:
+		resp.Status = "completed"
+	}
+	resp.Usage = responsesUsage(usage)
+}
+
+// responsesUsage converts chat completions usage
+func responsesUsage(u *OpenAIUsage) *ResponsesUsage {
+	if u == nil {
+		return nil
+	}
+	usage := &ResponsesUsage{
+		InputTokens:  u.PromptTokens,
+		OutputTokens: u.CompletionTokens,
+		TotalTokens:  u.PromptTokens + u.CompletionTokens,
+	}
+	if u.PromptTokensDetails != nil {
+		usage.InputTokensDetails.CachedTokens = u.PromptTokensDetails.CachedTokens
+	}
+	return usage
+}
+
+// ChatToResponses converts a Chat Completions response to a Responses API
+// response for req
+func ChatToResponses(resp *OpenAIResponse, req *ResponsesRequest) *ResponsesResponse {
+	if resp == nil {
+		return nil
+	}
+	id := responsesID("resp", resp.ID)
+	out := newResponsesResponse(req, id, resp.Model)
+	if len(resp.Choices) == 0 {
+		finishResponsesResponse(out, "", resp.Usage)
+		return out
+	}
+
+	choice := resp.Choices[0]
+	if text, _ := getStringContent(choice.Message.Content); text != "" {
+		out.Output = append(out.Output, responsesMessageItem(responsesID("msg", resp.ID), text, "completed"))
+	}
+	for _, call := range choice.Message.ToolCalls {
+		out.Output = append(out.Output, ResponsesOutputItem{
+			Type:      "function_call",
+			ID:        responsesID("fc", call.ID),
+			Status:    "completed",
+			CallID:    call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	finishResponsesResponse(out, choice.FinishReason, resp.Usage)
+	return out
+}
+
+// responsesMessageItem builds an assistant message output item
+func responsesMessageItem(id, text, status string) ResponsesOutputItem {
+	return ResponsesOutputItem{
+		Type:    "message",
+		ID:      id,
+		Status:  status,
+		Role:    "assistant",
+		Content: []ResponsesContent{{Type: "output_text", Text: text, Annotations: []interface{}{}}},
+	}
+}
+
+// responsesID derives a Responses API ID with the given prefix from an
+// upstream ID, or from the clock when there is none
+func responsesID(prefix, upstream string) string {
+	if upstream == "" {
+		return fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
+	}
+	if i := strings.IndexAny(upstream, "-_"); i >= 0 {
+		upstream = upstream[i+1:]
+	}
+	return prefix + "_" + upstream
+}
+
+// ====== Streaming Translation ======
+
+// responsesStream turns chat completion chunks into Responses API stream
+// events: response.created, then output items with their text or argument
+// deltas, then response.completed. emit writes one event.
+type responsesStream struct {
+	emit func(eventType string, data []byte) error
+
+	resp         *ResponsesResponse
+	seq          int
+	started      bool
+	textItem     int // output index of the open message, or -1
+	text         strings.Builder
+	calls        map[int]int  // chat tool call index -> output index
+	done         map[int]bool // output indexes already closed
+	finishReason string
+	usage        *OpenAIUsage
+}
+
+func newResponsesStream(req *ResponsesRequest, emit func(string, []byte) error) *responsesStream {
+	return &responsesStream{
+		emit:     emit,
+		resp:     newResponsesResponse(req, responsesID("resp", ""), req.Model),
+		textItem: -1,
+		calls:    make(map[int]int),
+		done:     make(map[int]bool),
+	}
+}
+
+// send writes an event with its type and sequence number
+func (s *responsesStream) send(eventType string, fields map[string]interface{}) error {
+	fields["type"] = eventType
+	fields["sequence_number"] = s.seq
+	s.seq++
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return s.emit(eventType, data)
+}
+
+// start announces the response
+func (s *responsesStream) start() error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+	if err := s.send("response.created", map[string]interface{}{"response": s.resp}); err != nil {
+		return err
+	}
+	return s.send("response.in_progress", map[string]interface{}{"response": s.resp})
+}
+
+// chunk translates one chat completion chunk
+func (s *responsesStream) chunk(c *OpenAIStreamChunk) error {
+	if c.Model != "" {
+		s.resp.Model = c.Model
+	}
+	if err := s.start(); err != nil {
+		return err
+	}
+	if c.Usage != nil {
+		s.usage = mergeStreamUsage(s.usage, c.Usage)
+	}
+	if len(c.Choices) == 0 {
+		return nil
+	}
+	choice := c.Choices[0]
+	if choice.FinishReason != nil && *choice.FinishReason != "" {
+		s.finishReason = *choice.FinishReason
+	}
+
+	if choice.Delta.Content != "" {
+		if s.textItem < 0 {
+			s.textItem = len(s.resp.Output)
+			item := responsesMessageItem(responsesID("msg", ""), "", "in_progress")
+			item.Content = []ResponsesContent{}
+			s.resp.Output = append(s.resp.Output, item)
+			if err := s.send("response.output_item.added", map[string]interface{}{"output_index": s.textItem, "item": item}); err != nil {
+				return err
+			}
+			part := ResponsesContent{Type: "output_text", Annotations: []interface{}{}}
+			if err := s.send("response.content_part.added", s.partFields(part)); err != nil {
+				return err
+			}
+		}
+		s.text.WriteString(choice.Delta.Content)
+		fields := s.partFields(ResponsesContent{})
+		delete(fields, "part")
+		fields["delta"] = choice.Delta.Content
+		if err := s.send("response.output_text.delta", fields); err != nil {
+			return err
+		}
+	}
+
+	for _, tc := range choice.Delta.ToolCalls {
+		index, ok := s.calls[tc.Index]
+		if !ok {
+			// Text before a tool call is complete
+			if err := s.closeText(); err != nil {
+				return err
+			}
+			index = len(s.resp.Output)
+			s.calls[tc.Index] = index
+			item := ResponsesOutputItem{
+				Type:   "function_call",
+				ID:     responsesID("fc", tc.ID),
+				Status: "in_progress",
+				CallID: tc.ID,
+				Name:   tc.Function.Name,
+			}
+			s.resp.Output = append(s.resp.Output, item)
+			if err := s.send("response.output_item.added", map[string]interface{}{"output_index": index, "item": item}); err != nil {
+				return err
+			}
+			if tc.Function.Arguments == "{}" {
+				// Anthropic starts tool_use blocks with empty input
+				// before streaming the real arguments
+				continue
+			}
+		}
+		if tc.Function.Arguments == "" {
+			continue
+		}
+		item := &s.resp.Output[index]
+		item.Arguments += tc.Function.Arguments
+		if err := s.send("response.function_call_arguments.delta", map[string]interface{}{
+			"item_id":      item.ID,
+			"output_index": index,
+			"delta":        tc.Function.Arguments,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// partFields identifies the text part of the open message
+func (s *responsesStream) partFields(part ResponsesContent) map[string]interface{} {
+	return map[string]interface{}{
+		"item_id":       s.resp.Output[s.textItem].ID,
+		"output_index":  s.textItem,
+		"content_index": 0,
+		"part":          part,
+	}
+}
+
+// closeText completes the open message, if any
+func (s *responsesStream) closeText() error {
+	if s.textItem < 0 || s.done[s.textItem] {
+		return nil
+	}
+	text := s.text.String()
+	fields := s.partFields(ResponsesContent{})
+	delete(fields, "part")
+	fields["text"] = text
+	if err := s.send("response.output_text.done", fields); err != nil {
+		return err
+	}
+	part := ResponsesContent{Type: "output_text", Text: text, Annotations: []interface{}{}}
+	if err := s.send("response.content_part.done", s.partFields(part)); err != nil {
+		return err
+	}
+	item := responsesMessageItem(s.resp.Output[s.textItem].ID, text, "completed")
+	s.resp.Output[s.textItem] = item
+	s.done[s.textItem] = true
+	return s.send("response.output_item.done", map[string]interface{}{"output_index": s.textItem, "item": item})
+}
+
+// finish completes every open item and the response
+func (s *responsesStream) finish() error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	if err := s.closeText(); err != nil {
+		return err
+	}
+	indexes := make([]int, 0, len(s.calls))
+	for _, index := range s.calls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		item := &s.resp.Output[index]
+		if item.Arguments == "" {
+			item.Arguments = "{}"
+		}
+		if err := s.send("response.function_call_arguments.done", map[string]interface{}{
+			"item_id":      item.ID,
+			"output_index": index,
+			"arguments":    item.Arguments,
+		}); err != nil {
+			return err
+		}
+		item.Status = "completed"
+		if err := s.send("response.output_item.done", map[string]interface{}{"output_index": index, "item": *item}); err != nil {
+			return err
+		}
+	}
+
+	finishResponsesResponse(s.resp, s.finishReason, s.usage)
+	event := "response.completed"
+	if s.resp.Status == "incomplete" {
+		event = "response.incomplete"
+	}
+	return s.send(event, map[string]interface{}{"response": s.resp})
+}
+
+// fail ends the response with an error
+func (s *responsesStream) fail(code, message string) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	s.resp.Status = "failed"
+	s.resp.Error = &ResponsesError{Code: code, Message: message}
+	s.resp.Usage = responsesUsage(s.usage)
+	return s.send("response.failed", map[string]interface{}{"response": s.resp})
+}
+
+// mergeStreamUsage combines usage reported across chunks; Anthropic sends
+// prompt tokens at the start and completion tokens at the end
+func mergeStreamUsage(prev, next *OpenAIUsage) *OpenAIUsage {
+	if prev == nil {
+		u := *next
+		return &u
+	}
+	if next.PromptTokens > 0 {
+		prev.PromptTokens = next.PromptTokens
+		prev.PromptTokensDetails = next.PromptTokensDetails
+	}
+	if next.CompletionTokens > 0 {
+		prev.CompletionTokens = next.CompletionTokens
+	}
+	prev.TotalTokens = prev.PromptTokens + prev.CompletionTokens
+	return prev
+}
@@ -0,0 +1,280 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponsesToChat(t *testing.T) {
+	req := &ResponsesRequest{
+		Model:        "gpt-4o",
+		Instructions: "Be brief.",
+		Input: json.RawMessage(`[
+			{"role": "developer", "content": "Answer in English."},
+			{"type": "message", "role": "user", "content": [{"type": "input_text", "text": "Weather?"}, {"type": "input_image", "image_url": "https://example.com/sky.png"}]},
+			{"type": "function_call", "call_id": "call_1", "name": "weather", "arguments": "{\"city\":\"Oslo\"}"},
+			{"type": "function_call", "call_id": "call_2", "name": "weather", "arguments": "{\"city\":\"Bergen\"}"},
+			{"type": "function_call_output", "call_id": "call_1", "output": "rain"},
+			{"type": "function_call_output", "call_id": "call_2", "output": [{"type": "input_text", "text": "sun"}]}
+		]`),
+		Tools:      []ResponsesTool{{Type: "function", Name: "weather", Parameters: map[string]interface{}{"type": "object"}}},
+		ToolChoice: map[string]interface{}{"type": "function", "name": "weather"},
+	}
+	chat, err := ResponsesToChat(req)
+	if err != nil {
+		t.Fatalf("ResponsesToChat: %v", err)
+	}
+
+	roles := make([]string, len(chat.Messages))
+	for i, m := range chat.Messages {
+		roles[i] = m.Role
+	}
+	if got := strings.Join(roles, ","); got != "system,system,user,assistant,tool,tool" {
+		t.Fatalf("roles = %s", got)
+	}
+	if parts, ok := chat.Messages[2].Content.([]interface{}); !ok || len(parts) != 2 {
+		t.Errorf("image message content = %#v", chat.Messages[2].Content)
+	}
+	if calls := chat.Messages[3].ToolCalls; len(calls) != 2 || calls[1].ID != "call_2" {
+		t.Errorf("function calls not merged: %+v", calls)
+	}
+	if chat.Messages[5].Content != "sun" || chat.Messages[5].ToolCallID != "call_2" {
+		t.Errorf("tool output = %+v", chat.Messages[5])
+	}
+	if len(chat.Tools) != 1 || chat.Tools[0].Function.Name != "weather" {
+		t.Errorf("tools = %+v", chat.Tools)
+	}
+	if tc, _ := chat.ToolChoice.(map[string]interface{}); tc["function"].(map[string]interface{})["name"] != "weather" {
+		t.Errorf("tool_choice = %#v", chat.ToolChoice)
+	}
+
+	// Stored state and built-in tools cannot be translated
+	var native *ErrNeedsNativeResponses
+	for _, r := range []*ResponsesRequest{
+		{Model: "gpt-4o", Input: json.RawMessage(`"hi"`), PreviousResponseID: "resp_1"},
+		{Model: "gpt-4o", Input: json.RawMessage(`"hi"`), Tools: []ResponsesTool{{Type: "web_search"}}},
+		{Model: "gpt-4o", Input: json.RawMessage(`[{"type": "item_reference", "id": "msg_1"}]`)},
+	} {
+		if _, err := ResponsesToChat(r); !errors.As(err, &native) {
+			t.Errorf("expected ErrNeedsNativeResponses, got %v", err)
+		}
+	}
+}
+
+func TestResponsesProxy_NonStreaming(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OpenAIRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/v1/chat/completions" || req.Messages[0].Content != "Be brief." || *req.MaxTokens != 50 {
+			t.Errorf("unexpected upstream request %s %+v", r.URL.Path, req)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"chatcmpl-abc","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi there"},"finish_reason":"length"}],"usage":{"prompt_tokens":12,"completion_tokens":50,"total_tokens":62}}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewResponsesProxy(NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-key"}, nil), nil)
+
+	body := `{"model": "gpt-4o", "instructions": "Be brief.", "input": "hello", "max_output_tokens": 50}`
+	w := httptest.NewRecorder()
+	p.HandleResponses(w, httptest.NewRequest(http.MethodPost, "/v1/responses", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp ResponsesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Object != "response" || resp.ID != "resp_abc" || resp.Status != "incomplete" || resp.IncompleteDetails.Reason != "max_output_tokens" {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if len(resp.Output) != 1 || resp.Output[0].Content[0].Text != "Hi there" {
+		t.Errorf("unexpected output %+v", resp.Output)
+	}
+	if resp.Usage == nil || resp.Usage.InputTokens != 12 || resp.Usage.TotalTokens != 62 {
+		t.Errorf("unexpected usage %+v", resp.Usage)
+	}
+}
+
+func TestResponsesProxy_Streaming(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OpenAIRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Errorf("expected a streaming request with usage, got %+v", req)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Let me "}}]}`,
+			`{"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{"content":"check."}}]}`,
+			`{"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"weather","arguments":""}}]}}]}`,
+			`{"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+			`{"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Oslo\"}"}}]}}]}`,
+			`{"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+			`{"id":"c1","model":"gpt-4o","choices":[],"usage":{"prompt_tokens":20,"completion_tokens":9,"total_tokens":29}}`,
+		} {
+			w.Write([]byte("data: " + chunk + "\n\n"))
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewResponsesProxy(NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-key"}, nil), nil)
+
+	body := `{"model": "gpt-4o", "input": "weather in Oslo?", "stream": true, "tools": [{"type": "function", "name": "weather"}]}`
+	w := httptest.NewRecorder()
+	p.HandleResponses(w, httptest.NewRequest(http.MethodPost, "/v1/responses", strings.NewReader(body)))
+
+	var types []string
+	var completed struct {
+		Response ResponsesResponse `json:"response"`
+	}
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if strings.HasPrefix(line, "event: ") {
+			types = append(types, strings.TrimPrefix(line, "event: "))
+		}
+		if strings.HasPrefix(line, "data: {\"response\"") && strings.Contains(line, `"response.completed"`) {
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &completed)
+		}
+	}
+	want := []string{
+		"response.created", "response.in_progress",
+		"response.output_item.added", "response.content_part.added",
+		"response.output_text.delta", "response.output_text.delta",
+		"response.output_text.done", "response.content_part.done", "response.output_item.done",
+		"response.output_item.added", "response.function_call_arguments.delta", "response.function_call_arguments.delta",
+		"response.function_call_arguments.done", "response.output_item.done",
+		"response.completed",
+	}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("events:\n got %v\nwant %v", types, want)
+	}
+
+	out := completed.Response.Output
+	if len(out) != 2 || out[0].Content[0].Text != "Let me check." || out[1].Arguments != `{"city":"Oslo"}` || out[1].CallID != "call_1" {
+		t.Errorf("unexpected output %+v", out)
+	}
+	if completed.Response.Usage == nil || completed.Response.Usage.OutputTokens != 9 {
+		t.Errorf("unexpected usage %+v", completed.Response.Usage)
+	}
+}
+
+func TestResponsesProxy_Anthropic(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req AnthropicRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/v1/messages" || req.Model != "claude-sonnet-4-5" || len(req.Messages) != 1 {
+			t.Errorf("unexpected upstream request %s %+v", r.URL.Path, req)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"msg_01","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"Hello from Claude"}],"stop_reason":"end_turn","usage":{"input_tokens":8,"output_tokens":4}}`))
+	}))
+	defer upstream.Close()
+
+	remapper := &mockRemapper{model: "claude-sonnet-4-5", provider: "anthropic"}
+	cfg := DefaultAnthropicProxyConfig()
+	cfg.AnthropicBaseURL = upstream.URL
+	p := NewResponsesProxy(
+		NewOpenAIProxy(DefaultOpenAIProxyConfig(), &mockKeyProvider{key: "test-key"}, remapper),
+		NewAnthropicProxy(cfg, &mockKeyProvider{key: "test-key"}, remapper),
+	)
+
+	w := httptest.NewRecorder()
+	p.HandleResponses(w, httptest.NewRequest(http.MethodPost, "/v1/responses", strings.NewReader(`{"model": "sonnet", "input": "hi"}`)))
+	var resp ResponsesResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Status != "completed" || len(resp.Output) != 1 || resp.Output[0].Content[0].Text != "Hello from Claude" {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+	if resp.Usage.InputTokens != 8 || resp.Usage.OutputTokens != 4 {
+		t.Errorf("unexpected usage %+v", resp.Usage)
+	}
+}
+
+func TestResponsesProxy_NativeOpenAI(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var fields map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&fields)
+		if r.URL.Path != "/v1/responses" || fields["model"] != "gpt-4.1" || fields["previous_response_id"] != "resp_1" {
+			t.Errorf("unexpected upstream request %s %v", r.URL.Path, fields)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"resp_2","object":"response","status":"completed"}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewResponsesProxy(NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-key"}, &mockRemapper{model: "gpt-4.1"}), nil)
+
+	body := `{"model": "gpt-4", "previous_response_id": "resp_1", "input": "and tomorrow?"}`
+	w := httptest.NewRecorder()
+	p.HandleResponses(w, httptest.NewRequest(http.MethodPost, "/v1/responses", strings.NewReader(body)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "resp_2") {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	// Other providers cannot serve it
+	p = NewResponsesProxy(NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-key"}, &mockRemapper{model: "llama-3.3-70b", provider: "groq"}), nil)
+	w = httptest.NewRecorder()
+	p.HandleResponses(w, httptest.NewRequest(http.MethodPost, "/v1/responses", strings.NewReader(body)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "native Responses API") {
+		t.Errorf("expected 400, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestResponsesProxy_AnthropicStreaming(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range []string{
+			`{"type":"message_start","message":{"id":"msg_01","model":"claude-sonnet-4-5","usage":{"input_tokens":15,"output_tokens":1}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"weather","input":{}}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"city\":\"Oslo\"}"}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}`,
+			`{"type":"message_stop"}`,
+		} {
+			var e struct{ Type string }
+			json.Unmarshal([]byte(event), &e)
+			w.Write([]byte("event: " + e.Type + "\ndata: " + event + "\n\n"))
+		}
+	}))
+	defer upstream.Close()
+
+	remapper := &mockRemapper{model: "claude-sonnet-4-5", provider: "anthropic"}
+	cfg := DefaultAnthropicProxyConfig()
+	cfg.AnthropicBaseURL = upstream.URL
+	p := NewResponsesProxy(
+		NewOpenAIProxy(DefaultOpenAIProxyConfig(), &mockKeyProvider{key: "test-key"}, remapper),
+		NewAnthropicProxy(cfg, &mockKeyProvider{key: "test-key"}, remapper),
+	)
+
+	body := `{"model": "sonnet", "input": "weather?", "stream": true, "tools": [{"type": "function", "name": "weather"}]}`
+	w := httptest.NewRecorder()
+	p.HandleResponses(w, httptest.NewRequest(http.MethodPost, "/v1/responses", strings.NewReader(body)))
+
+	var completed struct {
+		Response ResponsesResponse `json:"response"`
+	}
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if strings.Contains(line, `"response.completed"`) {
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &completed)
+		}
+	}
+	out := completed.Response.Output
+	if len(out) != 1 || out[0].Type != "function_call" || out[0].CallID != "toolu_1" || out[0].Arguments != `{"city":"Oslo"}` {
+		t.Fatalf("unexpected output %+v in %s", out, w.Body.String())
+	}
+	if u := completed.Response.Usage; u == nil || u.InputTokens != 15 || u.OutputTokens != 7 {
+		t.Errorf("unexpected usage %+v", u)
+	}
+}
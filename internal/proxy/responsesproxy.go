@@ -0,0 +1,496 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+)
+
+// ResponsesProxy serves the OpenAI Responses API (POST /v1/responses) for
+// every provider. Requests are translated to chat completions, or on to
+// Anthropic messages, and served by those handlers, so remapping,
+// fallbacks, tenants and guardrails apply as usual. Only requests that need
+// what chat completions cannot express, such as previous_response_id or
+// built-in tools, are forwarded untranslated, and only to OpenAI.
+type ResponsesProxy struct {
+	openAI    *OpenAIProxy
+	anthropic *AnthropicProxy
+}
+
+// NewResponsesProxy creates a Responses API handler on top of the chat
+// completions and messages proxies
+func NewResponsesProxy(openAI *OpenAIProxy, anthropic *AnthropicProxy) *ResponsesProxy {
+	return &ResponsesProxy{openAI: openAI, anthropic: anthropic}
+}
+
+// HandleResponses handles POST /v1/responses requests
+func (p *ResponsesProxy) HandleResponses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Cancel upstream work as soon as the client goes away
+	r, stopWatching := watchClient(r)
+	defer stopWatching()
+
+	// The deadline budget starts here; the handlers serving the translated
+	// request keep it
+	ctx, cancel, err := withDeadline(r, p.openAI.timeouts, p.openAI.config.Timeout)
+	if err != nil {
+		p.openAI.writeError(w, err.Error(), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+	r = r.WithContext(ctx)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		p.openAI.writeError(w, "failed to read request body", "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	var req ResponsesRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		p.openAI.writeError(w, fmt.Sprintf("invalid request body: %v", err), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	if req.Model == "" {
+		p.openAI.writeError(w, "model is required", "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	if len(req.Input) == 0 && req.PreviousResponseID == "" {
+		p.openAI.writeError(w, "input is required", "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+
+	model, provider, chained := p.route(r, req.Model)
+
+	chat, err := ResponsesToChat(&req)
+	var native *ErrNeedsNativeResponses
+	if errors.As(err, &native) && provider == "openai" && !chained {
+		p.forwardNative(w, r, &req, body, model)
+		return
+	}
+	if err != nil {
+		p.openAI.writeError(w, err.Error(), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+
+	// Serve the translated request with the chat completions or messages
+	// handler, converting its response as it is written
+	format, handler, path := "openai", p.openAI.HandleChatCompletions, "/v1/chat/completions"
+	var translated interface{} = chat
+	if provider == "anthropic" && !chained && p.anthropic != nil {
+		anthropicReq, err := ToAnthropic(chat)
+		if err != nil {
+			p.openAI.writeError(w, fmt.Sprintf("failed to translate request: %v", err), "invalid_request_error", http.StatusBadRequest)
+			return
+		}
+		format, handler, path = "anthropic", p.anthropic.HandleMessages, "/v1/messages"
+		translated = anthropicReq
+	}
+	innerBody, err := json.Marshal(translated)
+	if err != nil {
+		p.openAI.writeError(w, "failed to marshal request", "server_error", http.StatusInternalServerError)
+		return
+	}
+	inner := r.Clone(r.Context())
+	inner.URL.Path = path
+	inner.Body = io.NopCloser(bytes.NewReader(innerBody))
+	inner.ContentLength = int64(len(innerBody))
+
+	rw := newResponsesWriter(w, &req, format, p.openAI.config)
+	handler(rw, inner)
+	rw.finish(p.openAI)
+}
+
+// route resolves the provider that will serve model for the request the
+// way the chat completions handler does, reporting whether a fallback
+// chain takes over
+func (p *ResponsesProxy) route(r *http.Request, model string) (string, string, bool) {
+	if _, chained := p.openAI.fallbackChain(r, model); chained {
+		return model, "", true
+	}
+	provider := "openai"
+	if p.openAI.remapper != nil {
+		remapped, target, err := p.openAI.remapper.RemapModel(r.Context(), model, r.Header.Get("X-Client-ID"))
+		if err == nil && remapped != "" {
+			model = remapped
+			if target != "" {
+				provider = target
+			}
+		}
+	}
+	if header := r.Header.Get(HeaderProvider); header != "" {
+		provider = header
+	}
+	return model, provider, false
+}
+
+// forwardNative sends a request to OpenAI's Responses API as it is, with
+// the remapped model, streaming the response straight back
+func (p *ResponsesProxy) forwardNative(w http.ResponseWriter, r *http.Request, req *ResponsesRequest, body []byte, model string) {
+	o := p.openAI
+	ctx, cancel, err := withDeadline(r, o.timeouts, o.config.Timeout)
+	if err != nil {
+		o.writeError(w, err.Error(), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	defer cancel()
+
+	if merr := checkAvailable(o.availability, "openai"); merr != nil {
+		o.writeError(w, merr.Error(), "server_error", maintenanceStatus(w, merr))
+		return
+	}
+
+	clientID := r.Header.Get("X-Client-ID")
+	promptTokens := CountTokens(req.Instructions) + CountTokens(string(req.Input))
+	ctx, w, finishTenant, err := meterTenant(ctx, o.tenants, w, clientID, promptTokens)
+	if err != nil {
+		status, errType := tenantErrorStatus(err)
+		o.writeError(w, err.Error(), errType, status)
+		return
+	}
+	defer finishTenant()
+
+	tokens := promptTokens
+	if req.MaxOutputTokens != nil {
+		tokens += *req.MaxOutputTokens
+	}
+	releaseModel, err := o.modelLimits.Acquire(ctx, model, tokens)
+	if err != nil {
+		if err = deadlineError(ctx, err); errors.Is(err, context.DeadlineExceeded) {
+			o.writeError(w, err.Error(), ErrorTypeDeadlineExceeded, http.StatusGatewayTimeout)
+			return
+		}
+		o.writeError(w, err.Error(), "rate_limit_exceeded", shedStatus(w, err))
+		return
+	}
+	defer releaseModel()
+
+	release, err := o.concurrency.Acquire(ctx, "openai")
+	if err != nil {
+		if err = deadlineError(ctx, err); errors.Is(err, context.DeadlineExceeded) {
+			o.writeError(w, err.Error(), ErrorTypeDeadlineExceeded, http.StatusGatewayTimeout)
+			return
+		}
+		o.writeError(w, err.Error(), "rate_limit_exceeded", shedStatus(w, err))
+		return
+	}
+	defer release()
+	if err := checkDeadline(ctx); err != nil {
+		o.writeError(w, err.Error(), ErrorTypeDeadlineExceeded, http.StatusGatewayTimeout)
+		return
+	}
+
+	apiKey, err := o.keyProvider.GetKey(ctx, "openai")
+	if err != nil {
+		o.writeError(w, "no API key available for provider openai", "server_error", http.StatusServiceUnavailable)
+		return
+	}
+	setAttribution(w, "openai", model, apiKey)
+	ctx = withUpstreamKey(mshttp.WithProvider(ctx, "openai"), apiKey, w)
+	if key := r.Header.Get(mshttp.DefaultIdempotencyHeader); key != "" {
+		ctx = mshttp.WithIdempotencyKey(ctx, key)
+	}
+
+	// Send the remapped model, leaving every other field untouched but for
+	// the transformation rules
+	ctx, body, err = transformRequest(ctx, o.transforms, w, TransformAPIResponses, clientID, body)
+	if err != nil {
+		o.writeError(w, fmt.Sprintf("invalid request body: %v", err), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		o.writeError(w, fmt.Sprintf("invalid request body: %v", err), "invalid_request_error", http.StatusBadRequest)
+		return
+	}
+	fields["model"], _ = json.Marshal(model)
+	reqBody, err := json.Marshal(fields)
+	if err != nil {
+		o.writeError(w, "failed to marshal request", "server_error", http.StatusInternalServerError)
+		return
+	}
+
+	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.config.OpenAIBaseURL+"/v1/responses", bytes.NewReader(reqBody))
+	if err != nil {
+		o.writeError(w, "failed to create upstream request", "server_error", http.StatusInternalServerError)
+		return
+	}
+	o.setUpstreamHeaders(upstreamReq, apiKey, "openai")
+
+	client := o.httpClient
+	if req.Stream {
+		client = o.streamingClient
+	}
+	resp, err := client.Do(upstreamReq)
+	if err != nil {
+		message, errType, status := upstreamFailure(ctx, err)
+		o.writeError(w, message, errType, status)
+		return
+	}
+	if req.Stream {
+		resp.Body = watchIdle(resp.Body, o.config.StreamIdleTimeout)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		errBody, _ := io.ReadAll(resp.Body)
+		setErrorCode(w, providererr.Classify("openai", resp.StatusCode, resp.Header, errBody))
+		copyHeaders(w.Header(), resp.Header)
+		w.WriteHeader(resp.StatusCode)
+		_, _ = w.Write(errBody)
+		return
+	}
+
+	copyHeaders(w.Header(), resp.Header)
+	w.WriteHeader(resp.StatusCode)
+	rc := http.NewResponseController(w)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				log.Printf("proxy: abandoning responses stream: %v", werr)
+				return
+			}
+			if req.Stream {
+				_ = rc.Flush()
+			}
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.Printf("proxy: responses upstream read error: %v", err)
+			return
+		}
+	}
+}
+
+// copyHeaders adds upstream response headers, leaving out the length,
+// which no longer matches once a body is translated or re-chunked
+func copyHeaders(dst, src http.Header) {
+	for key, values := range src {
+		if key == "Content-Length" {
+			continue
+		}
+		for _, value := range values {
+			dst.Add(key, value)
+		}
+	}
+}
+
+// responsesWriter sits between a chat completions or messages handler and
+// the client. Streams are translated event by event as they are written;
+// other responses are buffered and translated once the handler returns.
+type responsesWriter struct {
+	w      http.ResponseWriter
+	req    *ResponsesRequest
+	format string // "openai" or "anthropic", what the handler writes
+	config OpenAIProxyConfig
+
+	header http.Header
+	status int
+	body   bytes.Buffer // buffered response, or stream data not yet parsed
+
+	sw        *StreamWriter
+	stream    *responsesStream
+	ended     bool
+	messageID string // Anthropic message ID, for translated chunks
+}
+
+func newResponsesWriter(w http.ResponseWriter, req *ResponsesRequest, format string, cfg OpenAIProxyConfig) *responsesWriter {
+	return &responsesWriter{w: w, req: req, format: format, config: cfg, header: make(http.Header)}
+}
+
+func (rw *responsesWriter) Header() http.Header {
+	return rw.header
+}
+
+func (rw *responsesWriter) WriteHeader(status int) {
+	if rw.status != 0 {
+		return
+	}
+	rw.status = status
+	if !strings.HasPrefix(rw.header.Get("Content-Type"), "text/event-stream") {
+		return
+	}
+
+	for key, values := range rw.header {
+		rw.w.Header()[key] = values
+	}
+	sw, err := NewStreamWriter(rw.w)
+	if err != nil {
+		return
+	}
+	sw.SetWriteTimeout(rw.config.StreamWriteTimeout)
+	sw.KeepAlive(rw.config.StreamKeepAlive)
+	rw.sw = sw
+	rw.stream = newResponsesStream(rw.req, sw.WriteEventWithType)
+}
+
+func (rw *responsesWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.body.Write(b)
+	if rw.sw == nil {
+		return len(b), nil
+	}
+
+	// Translate each complete SSE frame
+	for {
+		data := rw.body.Bytes()
+		end := bytes.Index(data, []byte("\n\n"))
+		if end < 0 {
+			break
+		}
+		frame := string(data[:end])
+		rw.body.Next(end + 2)
+		if err := rw.translateFrame(frame); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), rw.sw.Err()
+}
+
+// Flush is a no-op: frames are translated and flushed as they are written
+func (rw *responsesWriter) Flush() {}
+
+// translateFrame converts one SSE frame from the handler into Responses
+// API events
+func (rw *responsesWriter) translateFrame(frame string) error {
+	if rw.ended {
+		return nil
+	}
+	var eventType string
+	var dataLines []string
+	for _, line := range strings.Split(frame, "\n") {
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			dataLines = append(dataLines, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if len(dataLines) == 0 {
+		// Keep-alive comments; the client gets its own
+		return nil
+	}
+	data := strings.Join(dataLines, "\n")
+
+	if data == "[DONE]" {
+		rw.ended = true
+		return rw.stream.finish()
+	}
+
+	var streamErr struct {
+		Type  string `json:"type"`
+		Error *struct {
+			Type    string `json:"type"`
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal([]byte(data), &streamErr) == nil && streamErr.Error != nil && (eventType == "error" || streamErr.Type == "error") {
+		rw.ended = true
+		code := streamErr.Error.Code
+		if code == "" {
+			code = streamErr.Error.Type
+		}
+		return rw.stream.fail(code, streamErr.Error.Message)
+	}
+
+	if rw.format == "anthropic" {
+		var event AnthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil
+		}
+		if event.Type == "message_start" && event.Message != nil {
+			rw.messageID = event.Message.ID
+		}
+		if chunk := TranslateStreamChunkToOpenAI(&event, rw.messageID); chunk != nil {
+			return rw.stream.chunk(chunk)
+		}
+		return nil
+	}
+
+	var chunk OpenAIStreamChunk
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return nil
+	}
+	return rw.stream.chunk(&chunk)
+}
+
+// finish completes the translated response once the handler returns
+func (rw *responsesWriter) finish(o *OpenAIProxy) {
+	if rw.sw != nil {
+		if !rw.ended {
+			_ = rw.stream.fail("server_error", "stream ended unexpectedly")
+		}
+		_ = rw.sw.Close()
+		return
+	}
+
+	status := rw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	copyHeaders(rw.w.Header(), rw.header)
+	rw.w.Header().Del("Content-Encoding")
+
+	if status >= http.StatusBadRequest {
+		if rw.format == "openai" {
+			rw.w.WriteHeader(status)
+			_, _ = rw.w.Write(rw.body.Bytes())
+			return
+		}
+		var anthropicErr struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(rw.body.Bytes(), &anthropicErr); err != nil || anthropicErr.Error.Message == "" {
+			anthropicErr.Error.Message = sanitizeErrorMessage(rw.body.String())
+		}
+		o.writeError(rw.w, anthropicErr.Error.Message, anthropicErr.Error.Type, status)
+		return
+	}
+
+	var chat *OpenAIResponse
+	if rw.format == "anthropic" {
+		var resp AnthropicResponse
+		if err := json.Unmarshal(rw.body.Bytes(), &resp); err == nil {
+			chat = TranslateResponseToOpenAI(&resp)
+		}
+	} else {
+		var resp OpenAIResponse
+		if err := json.Unmarshal(rw.body.Bytes(), &resp); err == nil {
+			chat = &resp
+		}
+	}
+	if chat == nil {
+		o.writeError(rw.w, "failed to parse upstream response", "server_error", http.StatusBadGateway)
+		return
+	}
+
+	rw.w.Header().Set("Content-Type", "application/json")
+	rw.w.WriteHeader(status)
+	if err := json.NewEncoder(rw.w).Encode(ChatToResponses(chat, rw.req)); err != nil {
+		log.Printf("proxy: error encoding responses response: %v", err)
+	}
+}
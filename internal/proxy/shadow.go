@@ -0,0 +1,328 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// ShadowConfig configures shadow traffic mirroring.
+// Mirrored requests are sent asynchronously and never affect the client response.
+type ShadowConfig struct {
+	// SampleRate is the fraction of requests to mirror (0-1)
+	SampleRate float64
+	// Provider receives mirrored requests
+	Provider string
+	// Model overrides the model for mirrored requests; empty keeps the primary model
+	Model string
+	// Timeout for each mirrored request
+	Timeout time.Duration
+	// MaxInFlight caps concurrent mirrored requests; extra samples are dropped
+	MaxInFlight int
+	// MaxBodyBytes truncates stored response bodies
+	MaxBodyBytes int
+}
+
+// DefaultShadowConfig returns sensible defaults for the given target
+func DefaultShadowConfig(provider, model string) ShadowConfig {
+	return ShadowConfig{
+		SampleRate:   0.1,
+		Provider:     provider,
+		Model:        model,
+		Timeout:      2 * time.Minute,
+		MaxInFlight:  16,
+		MaxBodyBytes: 64 * 1024,
+	}
+}
+
+// ShadowRecord pairs a primary response with its mirrored counterpart
+type ShadowRecord struct {
+	ClientID        string
+	PrimaryProvider string
+	PrimaryModel    string
+	PrimaryStatus   int
+	PrimaryLatency  time.Duration
+	PrimaryResponse string
+	PrimaryUsage    *OpenAIUsage
+	PrimaryStream   bool
+
+	ShadowProvider string
+	ShadowModel    string
+	ShadowStatus   int
+	ShadowLatency  time.Duration
+	ShadowResponse string
+	ShadowUsage    *OpenAIUsage
+	ShadowError    string
+
+	CreatedAt time.Time
+}
+
+// ShadowRecorder stores shadow comparisons for offline evaluation
+type ShadowRecorder interface {
+	RecordShadow(record *ShadowRecord) error
+}
+
+// shadowMirror sends sampled requests to a second provider
+type shadowMirror struct {
+	config   ShadowConfig
+	recorder ShadowRecorder
+	sem      chan struct{}
+	rand     func() float64
+	wg       sync.WaitGroup
+}
+
+// SetShadow enables shadow mirroring of chat completion requests. Only the
+// OpenAI-compatible /v1/chat/completions endpoint is mirrored; Anthropic
+// /v1/messages requests are not.
+func (p *OpenAIProxy) SetShadow(cfg ShadowConfig, recorder ShadowRecorder) error {
+	if cfg.Provider == "" {
+		return fmt.Errorf("shadow provider is required")
+	}
+	if !p.speaks(cfg.Provider) {
+		return fmt.Errorf("shadow provider %s is not OpenAI-compatible", cfg.Provider)
+	}
+	if cfg.SampleRate <= 0 || cfg.SampleRate > 1 {
+		return fmt.Errorf("shadow sample rate must be between 0 and 1")
+	}
+	if recorder == nil {
+		return fmt.Errorf("shadow recorder is required")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Minute
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = 16
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 64 * 1024
+	}
+
+	p.shadow = &shadowMirror{
+		config:   cfg,
+		recorder: recorder,
+		sem:      make(chan struct{}, cfg.MaxInFlight),
+		rand:     rand.Float64,
+	}
+	return nil
+}
+
+// WaitShadow blocks until in-flight mirrored requests have been recorded
+func (p *OpenAIProxy) WaitShadow() {
+	if p.shadow != nil {
+		p.shadow.wg.Wait()
+	}
+}
+
+// startShadow samples the request and, if selected, mirrors it in the background.
+// The returned function must be called with the captured primary response;
+// calls after the first are ignored, so it can also be deferred to release
+// the mirror when the primary panics. It returns nil when the request is not
+// mirrored, which it never is to a shadow provider outside the request's
+// residency region.
+func (p *OpenAIProxy) startShadow(req OpenAIRequest, clientID, primaryProvider, region string) func(*captureWriter, time.Duration) {
+	m := p.shadow
+	if m == nil || !p.residency.Allows(m.config.Provider, region) || m.rand() >= m.config.SampleRate {
+		return nil
+	}
+
+	// Drop the sample rather than queue when the mirror is saturated
+	select {
+	case m.sem <- struct{}{}:
+	default:
+		return nil
+	}
+
+	shadowReq := req
+	shadowReq.Stream = false
+	shadowReq.StreamOptions = nil
+	if m.config.Model != "" {
+		shadowReq.Model = m.config.Model
+	}
+
+	record := &ShadowRecord{
+		ClientID:        clientID,
+		PrimaryProvider: primaryProvider,
+		PrimaryModel:    req.Model,
+		PrimaryStream:   req.Stream,
+		ShadowProvider:  m.config.Provider,
+		ShadowModel:     shadowReq.Model,
+		CreatedAt:       time.Now(),
+	}
+
+	primaryDone := make(chan struct{})
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() { <-m.sem }()
+
+		if err := crash.Do("shadow request", func() { p.runShadow(&shadowReq, record) }); err != nil {
+			record.ShadowError = err.Error()
+		}
+
+		// Wait for the primary so both sides are recorded together
+		<-primaryDone
+		if err := m.recorder.RecordShadow(record); err != nil {
+			log.Printf("proxy: failed to record shadow result: %v", err)
+		}
+	}()
+
+	var once sync.Once
+	return func(cw *captureWriter, latency time.Duration) {
+		once.Do(func() {
+			record.PrimaryStatus = cw.status
+			record.PrimaryLatency = latency
+			record.PrimaryResponse = truncate(cw.buf.String(), m.config.MaxBodyBytes)
+			if !req.Stream {
+				record.PrimaryUsage = extractUsage(cw.buf.Bytes())
+			}
+			close(primaryDone)
+		})
+	}
+}
+
+// runShadow sends the mirrored request and fills in the shadow side of the record
+func (p *OpenAIProxy) runShadow(req *OpenAIRequest, record *ShadowRecord) {
+	m := p.shadow
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() { record.ShadowLatency = time.Since(start) }()
+
+	apiKey, err := p.keyProvider.GetKey(ctx, m.config.Provider)
+	if err != nil {
+		record.ShadowError = fmt.Sprintf("no API key available for provider %s", m.config.Provider)
+		return
+	}
+
+	status, body, err := p.doUpstream(ctx, req, apiKey, m.config.Provider)
+	record.ShadowStatus = status
+	if err != nil {
+		record.ShadowError = err.Error()
+		return
+	}
+	record.ShadowResponse = truncate(string(body), m.config.MaxBodyBytes)
+	if status < 400 {
+		record.ShadowUsage = extractUsage(body)
+	}
+}
+
+// doUpstream sends a non-streaming request and returns the body in OpenAI format
+func (p *OpenAIProxy) doUpstream(ctx context.Context, req *OpenAIRequest, apiKey, provider string) (int, []byte, error) {
+	var reqBody []byte
+	var err error
+	if provider == "cohere" {
+		cohereReq, terr := ToCohere(req)
+		if terr != nil {
+			return 0, nil, fmt.Errorf("failed to translate request: %w", terr)
+		}
+		reqBody, err = json.Marshal(cohereReq)
+	} else {
+		reqBody, err = json.Marshal(req)
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	ctx = withUpstreamKey(mshttp.WithProvider(ctx, provider), apiKey, nil)
+	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.getUpstreamURL(provider), bytes.NewReader(reqBody))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create upstream request: %w", err)
+	}
+	p.setUpstreamHeaders(upstreamReq, apiKey, provider)
+
+	resp, err := p.httpClient.Do(upstreamReq)
+	if err != nil {
+		return 0, nil, fmt.Errorf("upstream request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("failed to read upstream response: %w", err)
+	}
+
+	if provider == "cohere" && resp.StatusCode < 400 {
+		var cohereResp CohereResponse
+		if err := json.Unmarshal(body, &cohereResp); err != nil {
+			return resp.StatusCode, body, fmt.Errorf("failed to parse upstream response: %w", err)
+		}
+		body, err = json.Marshal(TranslateCohereResponseToOpenAI(&cohereResp, req.Model))
+		if err != nil {
+			return resp.StatusCode, nil, fmt.Errorf("failed to encode translated response: %w", err)
+		}
+	}
+
+	return resp.StatusCode, body, nil
+}
+
+// extractUsage reads the usage block from an OpenAI-format response body
+func extractUsage(body []byte) *OpenAIUsage {
+	var resp struct {
+		Usage *OpenAIUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil
+	}
+	return resp.Usage
+}
+
+// truncate limits s to max bytes
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
+
+// captureWriter records the status and a bounded copy of the body while
+// passing everything through to the client unchanged
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+	limit  int
+}
+
+func newCaptureWriter(w http.ResponseWriter, limit int) *captureWriter {
+	return &captureWriter{ResponseWriter: w, status: http.StatusOK, limit: limit}
+}
+
+func (c *captureWriter) WriteHeader(status int) {
+	c.status = status
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureWriter) Write(b []byte) (int, error) {
+	if remaining := c.limit - c.buf.Len(); remaining > 0 {
+		if len(b) > remaining {
+			c.buf.Write(b[:remaining])
+		} else {
+			c.buf.Write(b)
+		}
+	}
+	return c.ResponseWriter.Write(b)
+}
+
+// Flush forwards flushes so streaming keeps working through the capture
+func (c *captureWriter) Flush() {
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the client's writer to http.ResponseController, so stream
+// write deadlines reach the connection
+func (c *captureWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
@@ -0,0 +1,351 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockShadowRecorder collects shadow records
+type mockShadowRecorder struct {
+	mu      sync.Mutex
+	records []*ShadowRecord
+	err     error
+}
+
+func (m *mockShadowRecorder) RecordShadow(record *ShadowRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, record)
+	return m.err
+}
+
+// modelEchoUpstream answers with the requested model; "slow-*" models are delayed
+// and "broken-*" models fail
+func modelEchoUpstream(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OpenAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode upstream request: %v", err)
+		}
+		if strings.HasPrefix(req.Model, "slow-") {
+			time.Sleep(200 * time.Millisecond)
+		}
+		if strings.HasPrefix(req.Model, "broken-") {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"error":{"message":"boom"}}`)
+			return
+		}
+
+		if req.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"model\":%q,\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n", req.Model)
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(OpenAIResponse{
+			ID:    "chatcmpl-" + req.Model,
+			Model: req.Model,
+			Choices: []OpenAIChoice{{
+				Message:      OpenAIMessage{Role: "assistant", Content: "answer from " + req.Model},
+				FinishReason: "stop",
+			}},
+			Usage: &OpenAIUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		})
+	}))
+}
+
+func newShadowProxy(t *testing.T, upstreamURL string, cfg ShadowConfig, recorder ShadowRecorder) *OpenAIProxy {
+	t.Helper()
+	proxyCfg := DefaultOpenAIProxyConfig()
+	proxyCfg.OpenAIBaseURL = upstreamURL
+	p := NewOpenAIProxy(proxyCfg, &mockKeyProvider{key: "test-key"}, nil)
+	if err := p.SetShadow(cfg, recorder); err != nil {
+		t.Fatalf("SetShadow() error = %v", err)
+	}
+	p.shadow.rand = func() float64 { return 0 }
+	return p
+}
+
+func TestOpenAIProxy_SetShadow_Validation(t *testing.T) {
+	p := NewOpenAIProxy(DefaultOpenAIProxyConfig(), &mockKeyProvider{key: "k"}, nil)
+	recorder := &mockShadowRecorder{}
+
+	if err := p.SetShadow(ShadowConfig{SampleRate: 0.5}, recorder); err == nil {
+		t.Error("expected error for missing provider")
+	}
+	if err := p.SetShadow(ShadowConfig{Provider: "anthropic", SampleRate: 0.5}, recorder); err == nil {
+		t.Error("expected error for a provider without an OpenAI-compatible API")
+	}
+	if err := p.SetShadow(ShadowConfig{Provider: "openai", SampleRate: 0}, recorder); err == nil {
+		t.Error("expected error for zero sample rate")
+	}
+	if err := p.SetShadow(ShadowConfig{Provider: "openai", SampleRate: 1.5}, recorder); err == nil {
+		t.Error("expected error for sample rate above 1")
+	}
+	if err := p.SetShadow(DefaultShadowConfig("openai", ""), nil); err == nil {
+		t.Error("expected error for missing recorder")
+	}
+
+	if err := p.SetShadow(ShadowConfig{Provider: "openai", SampleRate: 1}, recorder); err != nil {
+		t.Fatalf("SetShadow() error = %v", err)
+	}
+	if p.shadow.config.Timeout <= 0 || p.shadow.config.MaxInFlight <= 0 || p.shadow.config.MaxBodyBytes <= 0 {
+		t.Errorf("expected defaults to be applied, got %+v", p.shadow.config)
+	}
+}
+
+func TestOpenAIProxy_Shadow_RecordsBothResponses(t *testing.T) {
+	upstream := modelEchoUpstream(t)
+	defer upstream.Close()
+
+	recorder := &mockShadowRecorder{}
+	p := newShadowProxy(t, upstream.URL, DefaultShadowConfig("openai", "slow-candidate"), recorder)
+
+	body := `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("X-Client-ID", "client-1")
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	p.HandleChatCompletions(w, req)
+	elapsed := time.Since(start)
+
+	// The client only sees the primary response, without waiting for the shadow
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var resp OpenAIResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Model != "gpt-4o" {
+		t.Errorf("expected primary model in client response, got %s", resp.Model)
+	}
+	if elapsed >= 200*time.Millisecond {
+		t.Errorf("primary response waited for shadow (%v)", elapsed)
+	}
+
+	p.WaitShadow()
+
+	if len(recorder.records) != 1 {
+		t.Fatalf("expected 1 shadow record, got %d", len(recorder.records))
+	}
+	rec := recorder.records[0]
+	if rec.ClientID != "client-1" {
+		t.Errorf("expected client ID client-1, got %s", rec.ClientID)
+	}
+	if rec.PrimaryModel != "gpt-4o" || rec.PrimaryStatus != http.StatusOK {
+		t.Errorf("unexpected primary side: model=%s status=%d", rec.PrimaryModel, rec.PrimaryStatus)
+	}
+	if !strings.Contains(rec.PrimaryResponse, "answer from gpt-4o") {
+		t.Errorf("expected primary response body, got %q", rec.PrimaryResponse)
+	}
+	if rec.PrimaryUsage == nil || rec.PrimaryUsage.PromptTokens != 10 {
+		t.Errorf("expected primary usage, got %+v", rec.PrimaryUsage)
+	}
+	if rec.ShadowModel != "slow-candidate" || rec.ShadowStatus != http.StatusOK {
+		t.Errorf("unexpected shadow side: model=%s status=%d", rec.ShadowModel, rec.ShadowStatus)
+	}
+	if !strings.Contains(rec.ShadowResponse, "answer from slow-candidate") {
+		t.Errorf("expected shadow response body, got %q", rec.ShadowResponse)
+	}
+	if rec.ShadowUsage == nil || rec.ShadowUsage.CompletionTokens != 5 {
+		t.Errorf("expected shadow usage, got %+v", rec.ShadowUsage)
+	}
+	if rec.ShadowLatency < 200*time.Millisecond {
+		t.Errorf("expected shadow latency to be measured, got %v", rec.ShadowLatency)
+	}
+	if rec.ShadowError != "" {
+		t.Errorf("unexpected shadow error: %s", rec.ShadowError)
+	}
+}
+
+func TestOpenAIProxy_Shadow_StreamingPrimary(t *testing.T) {
+	upstream := modelEchoUpstream(t)
+	defer upstream.Close()
+
+	recorder := &mockShadowRecorder{}
+	p := newShadowProxy(t, upstream.URL, DefaultShadowConfig("openai", "candidate"), recorder)
+
+	body := `{"model": "gpt-4o", "stream": true, "messages": [{"role": "user", "content": "hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	p.HandleChatCompletions(w, req)
+	p.WaitShadow()
+
+	if !strings.Contains(w.Body.String(), "[DONE]") {
+		t.Errorf("expected streamed response to reach client, got %q", w.Body.String())
+	}
+	if len(recorder.records) != 1 {
+		t.Fatalf("expected 1 shadow record, got %d", len(recorder.records))
+	}
+	rec := recorder.records[0]
+	if !rec.PrimaryStream {
+		t.Error("expected primary to be recorded as streaming")
+	}
+	if !strings.Contains(rec.PrimaryResponse, "[DONE]") {
+		t.Errorf("expected captured stream, got %q", rec.PrimaryResponse)
+	}
+	// Shadow requests are always sent non-streaming
+	if !strings.Contains(rec.ShadowResponse, "answer from candidate") {
+		t.Errorf("expected non-streaming shadow response, got %q", rec.ShadowResponse)
+	}
+}
+
+func TestOpenAIProxy_Shadow_FailureDoesNotAffectClient(t *testing.T) {
+	upstream := modelEchoUpstream(t)
+	defer upstream.Close()
+
+	recorder := &mockShadowRecorder{err: errors.New("database locked")}
+	p := newShadowProxy(t, upstream.URL, DefaultShadowConfig("openai", "broken-candidate"), recorder)
+
+	body := `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	p.HandleChatCompletions(w, req)
+	p.WaitShadow()
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected primary status 200, got %d", w.Code)
+	}
+	if len(recorder.records) != 1 {
+		t.Fatalf("expected 1 shadow record, got %d", len(recorder.records))
+	}
+	if recorder.records[0].ShadowStatus != http.StatusInternalServerError {
+		t.Errorf("expected shadow status 500, got %d", recorder.records[0].ShadowStatus)
+	}
+	if recorder.records[0].ShadowUsage != nil {
+		t.Error("expected no usage for failed shadow response")
+	}
+}
+
+func TestOpenAIProxy_Shadow_Sampling(t *testing.T) {
+	upstream := modelEchoUpstream(t)
+	defer upstream.Close()
+
+	recorder := &mockShadowRecorder{}
+	cfg := DefaultShadowConfig("openai", "candidate")
+	cfg.SampleRate = 0.25
+	p := newShadowProxy(t, upstream.URL, cfg, recorder)
+
+	samples := []float64{0.1, 0.5, 0.9, 0.2}
+	i := 0
+	p.shadow.rand = func() float64 {
+		v := samples[i%len(samples)]
+		i++
+		return v
+	}
+
+	for n := 0; n < len(samples); n++ {
+		body := `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hello"}]}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+		p.HandleChatCompletions(httptest.NewRecorder(), req)
+	}
+	p.WaitShadow()
+
+	if len(recorder.records) != 2 {
+		t.Errorf("expected 2 sampled requests, got %d", len(recorder.records))
+	}
+}
+
+func TestOpenAIProxy_Shadow_DropsWhenSaturated(t *testing.T) {
+	upstream := modelEchoUpstream(t)
+	defer upstream.Close()
+
+	recorder := &mockShadowRecorder{}
+	cfg := DefaultShadowConfig("openai", "candidate")
+	cfg.MaxInFlight = 1
+	p := newShadowProxy(t, upstream.URL, cfg, recorder)
+
+	// Occupy the only slot
+	p.shadow.sem <- struct{}{}
+
+	body := `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	p.HandleChatCompletions(w, req)
+	p.WaitShadow()
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+	if len(recorder.records) != 0 {
+		t.Errorf("expected sample to be dropped, got %d records", len(recorder.records))
+	}
+}
+
+// panicTransport panics on every request, like a bug in the primary path
+type panicTransport struct{}
+
+func (panicTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	panic("primary transport exploded")
+}
+
+func TestOpenAIProxy_Shadow_ReleasedWhenPrimaryPanics(t *testing.T) {
+	upstream := modelEchoUpstream(t)
+	defer upstream.Close()
+
+	recorder := &mockShadowRecorder{}
+	cfg := DefaultShadowConfig("openai", "candidate")
+	cfg.MaxInFlight = 1
+	p := newShadowProxy(t, upstream.URL, cfg, recorder)
+	// Only the streaming primary fails; the mirror is never streamed
+	p.streamingClient.Transport = panicTransport{}
+
+	body := `{"model": "gpt-4o", "stream": true, "messages": [{"role": "user", "content": "hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the primary to panic")
+			}
+		}()
+		p.HandleChatCompletions(httptest.NewRecorder(), req)
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		p.WaitShadow()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("shadow goroutine still waiting on the failed primary")
+	}
+
+	if len(p.shadow.sem) != 0 {
+		t.Errorf("expected the semaphore slot to be released, %d still held", len(p.shadow.sem))
+	}
+	if len(recorder.records) != 1 || recorder.records[0].ShadowStatus != http.StatusOK {
+		t.Fatalf("expected the shadow side to be recorded, got %+v", recorder.records)
+	}
+}
+
+func TestCaptureWriter_TruncatesBody(t *testing.T) {
+	w := httptest.NewRecorder()
+	cw := newCaptureWriter(w, 5)
+
+	cw.WriteHeader(http.StatusCreated)
+	cw.Write([]byte("hello "))
+	cw.Write([]byte("world"))
+
+	if w.Body.String() != "hello world" {
+		t.Errorf("expected full body passed through, got %q", w.Body.String())
+	}
+	if cw.buf.String() != "hello" {
+		t.Errorf("expected captured body truncated to 5 bytes, got %q", cw.buf.String())
+	}
+	if cw.status != http.StatusCreated {
+		t.Errorf("expected captured status 201, got %d", cw.status)
+	}
+}
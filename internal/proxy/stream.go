@@ -4,9 +4,16 @@ package proxy
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
 )
 
 // sanitizeErrorMessage removes characters that could cause format string injection or JSON breaking
@@ -22,21 +29,68 @@ func sanitizeErrorMessage(msg string) string {
 	return msg
 }
 
+// DefaultStreamWriteTimeout bounds how long one SSE write may block on a
+// client that stopped reading
+const DefaultStreamWriteTimeout = 30 * time.Second
+
+// DefaultStreamKeepAlive is how long a stream may go without an event
+// before a keep-alive comment is sent, comfortably below the 60 second idle
+// timeout of common load balancers
+const DefaultStreamKeepAlive = 15 * time.Second
+
+// DefaultStreamIdleTimeout is how long an upstream stream may send nothing
+// before it is given up on. Reasoning models can think silently for
+// minutes, so it is generous.
+const DefaultStreamIdleTimeout = 5 * time.Minute
+
+// ErrStreamIdle means the upstream provider stopped sending on an open
+// stream for longer than the idle timeout
+var ErrStreamIdle = errors.New("upstream stream idle")
+
+// StreamConfig holds the timing of proxied SSE streams; zero disables a
+// limit
+type StreamConfig struct {
+	WriteTimeout time.Duration // per chunk written to the client
+	KeepAlive    time.Duration // quiet period before a keep-alive comment
+	IdleTimeout  time.Duration // upstream silence that ends the stream
+}
+
+// DefaultStreamConfig returns the default stream timing
+func DefaultStreamConfig() StreamConfig {
+	return StreamConfig{
+		WriteTimeout: DefaultStreamWriteTimeout,
+		KeepAlive:    DefaultStreamKeepAlive,
+		IdleTimeout:  DefaultStreamIdleTimeout,
+	}
+}
+
 // StreamWriter wraps http.ResponseWriter with SSE streaming capabilities.
 // It provides methods for writing Server-Sent Events with proper formatting
 // and automatic flushing.
+//
+// Each write must reach the client within the write timeout. Once one
+// fails, every later write fails with the same error, so handlers can stop
+// reading upstream instead of streaming into an abandoned connection.
+// Writes are serialized, so KeepAlive can ping while a handler streams.
 type StreamWriter struct {
-	w       http.ResponseWriter
-	flusher http.Flusher
-	closed  bool
+	w            http.ResponseWriter
+	rc           *http.ResponseController
+	writeTimeout time.Duration
+
+	mu        sync.Mutex
+	closed    bool
+	err       error     // First write failure
+	lastWrite time.Time // When the client last received anything
+
+	stopKeepAlive chan struct{}
+	keepAliveDone chan struct{}
 }
 
 // NewStreamWriter creates a new StreamWriter from an http.ResponseWriter.
 // It sets the required SSE headers and returns an error if the ResponseWriter
 // does not support flushing.
 func NewStreamWriter(w http.ResponseWriter) (*StreamWriter, error) {
-	flusher, ok := w.(http.Flusher)
-	if !ok {
+	if _, ok := w.(http.Flusher); !ok {
 		return nil, fmt.Errorf("response writer does not support flushing")
 	}
 
@@ -47,58 +101,183 @@ func NewStreamWriter(w http.ResponseWriter) (*StreamWriter, error) {
 	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
 
 	return &StreamWriter{
-		w:       w,
-		flusher: flusher,
-		closed:  false,
+		w:            w,
+		rc:           http.NewResponseController(w),
+		writeTimeout: DefaultStreamWriteTimeout,
+		closed:       false,
 	}, nil
 }
 
+// SetWriteTimeout changes how long a write may block before the client is
+// treated as gone; zero disables the limit
+func (sw *StreamWriter) SetWriteTimeout(d time.Duration) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.writeTimeout = d
+}
+
+// Err returns the write failure that ended the stream, or nil
+func (sw *StreamWriter) Err() error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.err
+}
+
+// KeepAlive sends an SSE comment whenever the stream has been quiet for
+// interval, so load balancers between modelscan and the client do not cut
+// a connection that is waiting on a slow generation. Clients ignore
+// comments. It runs until Close or StopKeepAlive; zero does nothing.
+func (sw *StreamWriter) KeepAlive(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	sw.mu.Lock()
+	if sw.stopKeepAlive != nil {
+		sw.mu.Unlock()
+		return
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	sw.stopKeepAlive, sw.keepAliveDone = stop, done
+	if sw.lastWrite.IsZero() {
+		sw.lastWrite = time.Now()
+	}
+	sw.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-timer.C:
+			}
+			sw.mu.Lock()
+			wait := interval - time.Since(sw.lastWrite)
+			if wait <= 0 {
+				if sw.closed || sw.write("keep-alive", ": keep-alive\n\n") != nil {
+					sw.mu.Unlock()
+					return
+				}
+				wait = interval
+			}
+			sw.mu.Unlock()
+			timer.Reset(wait)
+		}
+	}()
+}
+
+// StopKeepAlive stops the keep-alive comments and waits until none is
+// being written, after which the handler may return. It is safe to call
+// more than once.
+func (sw *StreamWriter) StopKeepAlive() {
+	sw.mu.Lock()
+	stop, done := sw.stopKeepAlive, sw.keepAliveDone
+	if stop != nil {
+		select {
+		case <-stop:
+		default:
+			close(stop)
+		}
+	}
+	sw.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// write formats and flushes one SSE frame within the write timeout; the
+// caller holds sw.mu
+func (sw *StreamWriter) write(what, format string, args ...interface{}) error {
+	return sw.writeFrame(what, fmt.Appendf(nil, format, args...))
+}
+
+// writeFrame flushes one SSE frame within the write timeout; the caller
+// holds sw.mu
+func (sw *StreamWriter) writeFrame(what string, frame []byte) error {
+	if sw.err != nil {
+		return sw.err
+	}
+	if sw.writeTimeout > 0 {
+		// Writers without deadline support (e.g. recorders) return
+		// ErrNotSupported and simply write without a limit
+		_ = sw.rc.SetWriteDeadline(time.Now().Add(sw.writeTimeout))
+		defer func() { _ = sw.rc.SetWriteDeadline(time.Time{}) }()
+	}
+
+	if _, err := sw.w.Write(frame); err != nil {
+		sw.err = fmt.Errorf("failed to write %s: %w", what, err)
+		return sw.err
+	}
+	if err := sw.rc.Flush(); err != nil {
+		sw.err = fmt.Errorf("failed to flush %s: %w", what, err)
+		return sw.err
+	}
+	sw.lastWrite = time.Now()
+	return nil
+}
+
 // WriteEvent writes a data event to the SSE stream.
 // The data is formatted as "data: <data>\n\n" per SSE specification.
 func (sw *StreamWriter) WriteEvent(data []byte) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
 	if sw.closed {
 		return fmt.Errorf("stream is closed")
 	}
 
-	// Write SSE data line
-	if _, err := fmt.Fprintf(sw.w, "data: %s\n\n", data); err != nil {
-		return fmt.Errorf("failed to write event: %w", err)
+	return sw.write("event", "data: %s\n\n", data)
+}
+
+// WriteRaw writes complete SSE frames as they are, for upstream streams
+// already in the client's format
+func (sw *StreamWriter) WriteRaw(frame []byte) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.closed {
+		return fmt.Errorf("stream is closed")
 	}
 
-	// Note: http.Flusher.Flush() doesn't return an error
-	// Network errors are caught by the next write operation
-	sw.flusher.Flush()
-	return nil
+	return sw.writeFrame("event", frame)
 }
 
 // WriteEventWithType writes a named event to the SSE stream.
 // The event is formatted as "event: <type>\ndata: <data>\n\n".
 func (sw *StreamWriter) WriteEventWithType(eventType string, data []byte) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
 	if sw.closed {
 		return fmt.Errorf("stream is closed")
 	}
 
-	if _, err := fmt.Fprintf(sw.w, "event: %s\ndata: %s\n\n", eventType, data); err != nil {
-		return fmt.Errorf("failed to write event: %w", err)
-	}
-
-	sw.flusher.Flush()
-	return nil
+	return sw.write("event", "event: %s\ndata: %s\n\n", eventType, data)
 }
 
 // WriteError writes an error event to the SSE stream.
 // The error is formatted as a JSON object with an "error" field.
 func (sw *StreamWriter) WriteError(err error) error {
+	errFields := map[string]string{
+		"type":    "stream_error",
+		"message": sanitizeErrorMessage(err.Error()),
+	}
+	if errors.Is(err, ErrStreamIdle) {
+		errFields["type"] = "stream_timeout"
+	}
+
+	sw.mu.Lock()
 	if sw.closed {
+		sw.mu.Unlock()
 		return fmt.Errorf("stream is closed")
 	}
-
-	errObj := map[string]interface{}{
-		"error": map[string]string{
-			"type":    "stream_error",
-			"message": sanitizeErrorMessage(err.Error()),
-		},
+	if code := providererr.Code(err); code != "" {
+		// Reaches clients as a header when nothing was streamed yet
+		sw.w.Header().Set(providererr.HeaderCode, code)
+		errFields["code"] = code
 	}
+	sw.mu.Unlock()
+	notifyStreamFailed(sw.w)
+	errObj := map[string]interface{}{"error": errFields}
 
 	data, marshalErr := json.Marshal(errObj)
 	if marshalErr != nil {
@@ -110,24 +289,46 @@ func (sw *StreamWriter) WriteError(err error) error {
 	return sw.WriteEventWithType("error", data)
 }
 
+// streamFailureRecorder is implemented by response writers that track a
+// request's outcome, which an error event after a 200 would otherwise hide
+type streamFailureRecorder interface {
+	streamFailed()
+}
+
+// notifyStreamFailed tells every writer wrapped around w that records
+// outcomes that the stream failed
+func notifyStreamFailed(w http.ResponseWriter) {
+	for w != nil {
+		if r, ok := w.(streamFailureRecorder); ok {
+			r.streamFailed()
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = u.Unwrap()
+	}
+}
+
 // WriteComment writes an SSE comment line (for keep-alive pings).
 // Comments start with ":" and are ignored by clients but keep the connection alive.
 func (sw *StreamWriter) WriteComment(comment string) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
 	if sw.closed {
 		return fmt.Errorf("stream is closed")
 	}
 
-	if _, err := fmt.Fprintf(sw.w, ": %s\n", comment); err != nil {
-		return fmt.Errorf("failed to write comment: %w", err)
-	}
-
-	sw.flusher.Flush()
-	return nil
+	return sw.write("comment", ": %s\n", comment)
 }
 
 // Close marks the stream as closed and writes the done event.
 // After Close is called, no more events can be written.
 func (sw *StreamWriter) Close() error {
+	sw.StopKeepAlive()
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
 	if sw.closed {
 		return nil
 	}
@@ -135,15 +336,59 @@ func (sw *StreamWriter) Close() error {
 	sw.closed = true
 
 	// Write the standard SSE done marker (used by OpenAI/Anthropic)
-	if _, err := fmt.Fprint(sw.w, "data: [DONE]\n\n"); err != nil {
-		return fmt.Errorf("failed to write done marker: %w", err)
-	}
-
-	sw.flusher.Flush()
-	return nil
+	return sw.write("done marker", "data: [DONE]\n\n")
 }
 
 // IsClosed returns whether the stream has been closed.
 func (sw *StreamWriter) IsClosed() bool {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
 	return sw.closed
 }
+
+// idleReader fails a read that waits longer than timeout for upstream data
+type idleReader struct {
+	body    io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	idle    atomic.Bool
+}
+
+// watchIdle wraps an upstream stream body so that a read blocked for
+// longer than timeout closes the upstream connection and fails with
+// ErrStreamIdle. Time spent writing to the client does not count. Zero
+// returns body unchanged.
+func watchIdle(body io.ReadCloser, timeout time.Duration) io.ReadCloser {
+	if timeout <= 0 {
+		return body
+	}
+	r := &idleReader{body: body, timeout: timeout}
+	r.timer = time.AfterFunc(timeout, func() {
+		r.idle.Store(true)
+		_ = body.Close()
+	})
+	r.timer.Stop()
+	return r
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	if r.idle.Load() {
+		return 0, r.idleErr()
+	}
+	r.timer.Reset(r.timeout)
+	n, err := r.body.Read(p)
+	r.timer.Stop()
+	if r.idle.Load() {
+		return n, r.idleErr()
+	}
+	return n, err
+}
+
+func (r *idleReader) Close() error {
+	r.timer.Stop()
+	return r.body.Close()
+}
+
+func (r *idleReader) idleErr() error {
+	return fmt.Errorf("%w: nothing received for %s", ErrStreamIdle, r.timeout)
+}
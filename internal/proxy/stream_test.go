@@ -1,12 +1,14 @@
 package proxy
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewStreamWriter(t *testing.T) {
@@ -223,3 +225,144 @@ func (w *nonFlushingWriter) Write(b []byte) (int, error) {
 }
 
 func (w *nonFlushingWriter) WriteHeader(statusCode int) {}
+
+// failingWriter fails every write, like a connection whose client is gone
+type failingWriter struct {
+	httptest.ResponseRecorder
+}
+
+func (f *failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestStreamWriter_WriteFailureIsSticky(t *testing.T) {
+	sw, err := NewStreamWriter(&failingWriter{*httptest.NewRecorder()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sw.WriteEvent([]byte("one")); err == nil {
+		t.Fatal("expected write error")
+	}
+	if sw.Err() == nil {
+		t.Error("expected Err() to report the failure")
+	}
+	if err := sw.WriteComment("ping"); !errors.Is(err, sw.Err()) {
+		t.Errorf("expected later writes to fail with the first error, got %v", err)
+	}
+}
+
+func TestOpenAIProxy_AbandonedStreamClosesUpstream(t *testing.T) {
+	upstreamDone := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(upstreamDone)
+		w.Header().Set("Content-Type", "text/event-stream")
+		event := []byte("data: {\"choices\":[{\"delta\":{\"content\":\"" + strings.Repeat("x", 4096) + "\"}}]}\n\n")
+		for {
+			if _, err := w.Write(event); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	cfg.StreamWriteTimeout = 100 * time.Millisecond
+	proxy := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-api-key"}, nil)
+
+	handlerDone := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(handlerDone)
+		proxy.HandleChatCompletions(w, r)
+	}))
+	defer server.Close()
+
+	body := `{"model": "gpt-4", "stream": true, "messages": [{"role": "user", "content": "hello"}]}`
+	resp, err := http.Post(server.URL+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// Read one line, then stop reading without closing the connection
+	if _, err := bufio.NewReader(resp.Body).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-handlerDone:
+	case <-time.After(10 * time.Second):
+		t.Fatal("proxy kept streaming to a client that stopped reading")
+	}
+	select {
+	case <-upstreamDone:
+	case <-time.After(10 * time.Second):
+		t.Fatal("upstream request was not closed")
+	}
+}
+
+func TestStreamWriter_KeepAlive(t *testing.T) {
+	w := httptest.NewRecorder()
+	sw, _ := NewStreamWriter(w)
+	sw.KeepAlive(20 * time.Millisecond)
+	time.Sleep(70 * time.Millisecond)
+	if err := sw.WriteEvent([]byte(`{"n":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	sw.Close()
+
+	body := w.Body.String()
+	if n := strings.Count(body, ": keep-alive\n\n"); n < 2 {
+		t.Errorf("expected keep-alives while quiet, got %d in %q", n, body)
+	}
+	if !strings.HasSuffix(body, "data: {\"n\":1}\n\ndata: [DONE]\n\n") {
+		t.Errorf("keep-alive after close: %q", body)
+	}
+}
+
+func TestOpenAIProxy_IdleStreamIsClosed(t *testing.T) {
+	upstreamDone := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(upstreamDone)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n"))
+		w.(http.Flusher).Flush()
+		// Stall until the proxy hangs up
+		<-r.Context().Done()
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	cfg.StreamKeepAlive = 20 * time.Millisecond
+	cfg.StreamIdleTimeout = 150 * time.Millisecond
+	proxy := NewOpenAIProxy(cfg, &mockKeyProvider{key: "test-api-key"}, nil)
+	server := httptest.NewServer(http.HandlerFunc(proxy.HandleChatCompletions))
+	defer server.Close()
+
+	body := `{"model": "gpt-4", "stream": true, "messages": [{"role": "user", "content": "hello"}]}`
+	resp, err := http.Post(server.URL+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var out bytes.Buffer
+	if _, err := out.ReadFrom(resp.Body); err != nil {
+		t.Fatalf("client stream did not end cleanly: %v", err)
+	}
+	got := out.String()
+	if !strings.Contains(got, `"content":"hi"`) || !strings.Contains(got, ": keep-alive") {
+		t.Errorf("expected the event and keep-alives, got %q", got)
+	}
+	if !strings.Contains(got, "event: error\n") || !strings.Contains(got, `"type":"stream_timeout"`) || !strings.HasSuffix(got, "data: [DONE]\n\n") {
+		t.Errorf("expected a terminal stream_timeout error, got %q", got)
+	}
+	select {
+	case <-upstreamDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream request was not closed")
+	}
+}
@@ -0,0 +1,270 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/tenant"
+)
+
+// maxMeteredBody bounds how much of a non-streaming response is kept to
+// read its usage
+const maxMeteredBody = 1 << 20
+
+// SetTenants holds requests from tenants' clients to the tenant's budget
+// and rate limits, serves them with the tenant's keys and charges their
+// usage to the tenant's ledger
+func (p *OpenAIProxy) SetTenants(m *tenant.Manager) {
+	p.tenants = m
+}
+
+// SetTenants holds requests from tenants' clients to the tenant's budget
+// and rate limits, serves them with the tenant's keys and charges their
+// usage to the tenant's ledger
+func (p *AnthropicProxy) SetTenants(m *tenant.Manager) {
+	p.tenants = m
+}
+
+// meterTenant admits a request for the client's tenant. For a tenant's
+// client it returns a context that selects the tenant's keys, a writer that
+// meters the response and a finish func that records the usage once the
+// response is written, or what was used when the client disconnected.
+// Shared clients get ctx and w back unchanged.
+func meterTenant(ctx context.Context, m *tenant.Manager, w http.ResponseWriter, clientID string, promptTokens int) (context.Context, http.ResponseWriter, func(), error) {
+	if m == nil {
+		return ctx, w, func() {}, nil
+	}
+	tenantID, err := m.Resolve(clientID)
+	if err == nil {
+		err = m.Admit(tenantID, promptTokens)
+	}
+	if err != nil || tenantID == "" {
+		return ctx, w, func() {}, err
+	}
+
+	mw := &meterWriter{ResponseWriter: w, status: http.StatusOK}
+	finish := func() {
+		// A provider may bill for a request its client abandoned, even
+		// when the proxy's response to the client failed
+		if gone, sent := clientGone(ctx); mw.status >= http.StatusBadRequest && !(gone && sent) {
+			return
+		}
+		prompt, completion := mw.tokens(promptTokens)
+		_, err := m.Record(tenant.Usage{
+			TenantID:         tenantID,
+			ClientID:         clientID,
+			Provider:         mw.Header().Get(HeaderProvider),
+			Model:            mw.Header().Get(HeaderModel),
+			PromptTokens:     prompt,
+			CompletionTokens: completion,
+			CachedTokens:     mw.cacheRead,
+			CacheWriteTokens: mw.cacheWrite,
+		})
+		if err != nil {
+			log.Printf("proxy: failed to record usage for tenant %s: %v", tenantID, err)
+		}
+	}
+	return tenant.WithTenant(ctx, tenantID), mw, finish, nil
+}
+
+// tenantErrorStatus maps a tenant admission error to an HTTP status and
+// OpenAI error type
+func tenantErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, tenant.ErrBudgetExceeded):
+		return http.StatusTooManyRequests, "insufficient_quota"
+	case errors.Is(err, tenant.ErrRateLimited):
+		return http.StatusTooManyRequests, "rate_limit_exceeded"
+	default:
+		return http.StatusServiceUnavailable, "server_error"
+	}
+}
+
+// meteredUsage holds the usage fields of OpenAI and Anthropic responses
+type meteredUsage struct {
+	PromptTokens        int                  `json:"prompt_tokens"`
+	CompletionTokens    int                  `json:"completion_tokens"`
+	PromptTokensDetails *PromptTokensDetails `json:"prompt_tokens_details"`
+
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// meteredPayload is the part of a response body or stream event that
+// carries usage or generated text, in either API's format
+type meteredPayload struct {
+	Usage   *meteredUsage `json:"usage"`
+	Message *struct {
+		Usage *meteredUsage `json:"usage"`
+	} `json:"message"` // Anthropic message_start
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Delta *struct {
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"` // Anthropic message_delta
+	} `json:"delta"` // Anthropic content_block_delta
+	Content []struct {
+		Text string `json:"text"`
+	} `json:"content"` // Anthropic message
+	StopReason string `json:"stop_reason"` // Anthropic message
+}
+
+// meterWriter passes a response through to the client while reading the
+// token usage it reports. When the provider reports none, as OpenAI streams
+// without include_usage do, completion tokens are estimated from the text.
+type meterWriter struct {
+	http.ResponseWriter
+	status  int
+	stream  bool
+	sniffed bool
+	started time.Time // When the response started
+
+	body bytes.Buffer // Non-streaming body, up to maxMeteredBody
+	line []byte       // Incomplete SSE line
+
+	prompt, completion    int
+	cacheRead, cacheWrite int // Prompt tokens read from and written to the provider's cache
+	text                  strings.Builder
+	finish                string // Last finish or stop reason seen
+}
+
+func (mw *meterWriter) WriteHeader(status int) {
+	mw.sniff()
+	mw.status = status
+	mw.ResponseWriter.WriteHeader(status)
+}
+
+func (mw *meterWriter) Write(b []byte) (int, error) {
+	mw.sniff()
+	if mw.stream {
+		mw.scan(b)
+	} else if remaining := maxMeteredBody - mw.body.Len(); remaining > 0 {
+		mw.body.Write(b[:min(len(b), remaining)])
+	}
+	return mw.ResponseWriter.Write(b)
+}
+
+// Flush forwards flushes so streaming keeps working through the meter
+func (mw *meterWriter) Flush() {
+	if f, ok := mw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the client connection
+func (mw *meterWriter) Unwrap() http.ResponseWriter {
+	return mw.ResponseWriter
+}
+
+// sniff decides from the content type whether the response is an SSE stream
+func (mw *meterWriter) sniff() {
+	if !mw.sniffed {
+		mw.sniffed = true
+		mw.started = time.Now()
+		mw.stream = strings.HasPrefix(mw.Header().Get("Content-Type"), "text/event-stream")
+	}
+}
+
+// scan reads the data lines of an SSE stream
+func (mw *meterWriter) scan(b []byte) {
+	mw.line = append(mw.line, b...)
+	for {
+		i := bytes.IndexByte(mw.line, '\n')
+		if i < 0 {
+			return
+		}
+		line := bytes.TrimSpace(mw.line[:i])
+		mw.line = mw.line[i+1:]
+		if data, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			mw.read(bytes.TrimSpace(data))
+		}
+	}
+}
+
+// read takes usage and generated text from a body or stream event
+func (mw *meterWriter) read(data []byte) {
+	var p meteredPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return
+	}
+	mw.apply(p.Usage)
+	if p.Message != nil {
+		mw.apply(p.Message.Usage)
+	}
+	for _, c := range p.Choices {
+		mw.text.WriteString(c.Delta.Content)
+		mw.text.WriteString(c.Message.Content)
+		mw.setFinish(c.FinishReason)
+	}
+	if p.Delta != nil {
+		mw.text.WriteString(p.Delta.Text)
+		mw.setFinish(p.Delta.StopReason)
+	}
+	for _, c := range p.Content {
+		mw.text.WriteString(c.Text)
+	}
+	mw.setFinish(p.StopReason)
+}
+
+// setFinish keeps the latest non-empty finish or stop reason
+func (mw *meterWriter) setFinish(reason string) {
+	if reason != "" {
+		mw.finish = reason
+	}
+}
+
+// apply keeps the latest non-zero counts; Anthropic streams report input
+// tokens at the start and cumulative output tokens at the end. Prompt
+// tokens include cached ones, which Anthropic reports separately.
+func (mw *meterWriter) apply(u *meteredUsage) {
+	if u == nil {
+		return
+	}
+	if n := u.PromptTokens + u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens; n > 0 {
+		mw.prompt = n
+	}
+	cached := u.CacheReadInputTokens
+	if u.PromptTokensDetails != nil {
+		cached += u.PromptTokensDetails.CachedTokens
+	}
+	if cached > 0 {
+		mw.cacheRead = cached
+	}
+	if u.CacheCreationInputTokens > 0 {
+		mw.cacheWrite = u.CacheCreationInputTokens
+	}
+	if n := u.CompletionTokens + u.OutputTokens; n > 0 {
+		mw.completion = n
+	}
+}
+
+// tokens returns the usage of the response, falling back to the prompt
+// estimate and the counted completion text
+func (mw *meterWriter) tokens(promptEstimate int) (prompt, completion int) {
+	if !mw.stream {
+		mw.read(mw.body.Bytes())
+	}
+	prompt, completion = mw.prompt, mw.completion
+	if prompt == 0 {
+		prompt = promptEstimate
+	}
+	if completion == 0 {
+		completion = CountTokens(mw.text.String())
+	}
+	return prompt, completion
+}
@@ -0,0 +1,162 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/tenant"
+)
+
+type testTenantStore struct {
+	mu    sync.Mutex
+	usage []tenant.Usage
+}
+
+func (s *testTenantStore) GetTenant(id string) (*tenant.Tenant, error) {
+	if id != "team-a" {
+		return nil, nil
+	}
+	return &tenant.Tenant{ID: id, MonthlyBudget: 1}, nil
+}
+
+func (s *testTenantStore) TenantForClient(clientID string) (string, error) {
+	if clientID == "cli-a" {
+		return "team-a", nil
+	}
+	return "", nil
+}
+
+func (s *testTenantStore) RecordUsage(u tenant.Usage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage = append(s.usage, u)
+	return nil
+}
+
+func (s *testTenantStore) Spend(string, time.Time) (float64, error) {
+	return 0, nil
+}
+
+type testPricer struct{}
+
+func (testPricer) Price(string) (float64, float64) { return 10, 30 }
+
+// tenantKeyProvider records the tenant each key was requested for
+type tenantKeyProvider struct {
+	tenants []string
+}
+
+func (k *tenantKeyProvider) GetKey(ctx context.Context, providerID string) (string, error) {
+	k.tenants = append(k.tenants, tenant.FromContext(ctx))
+	return "test-key", nil
+}
+
+func TestOpenAIProxy_Tenants(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"chatcmpl-1","choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":40000,"completion_tokens":20000}}`))
+	}))
+	defer upstream.Close()
+
+	store := &testTenantStore{}
+	keys := &tenantKeyProvider{}
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, keys, nil)
+	p.SetTenants(tenant.NewManager(store, testPricer{}, time.Hour))
+
+	send := func(clientID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+			strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello"}]}`))
+		req.Header.Set("X-Client-ID", clientID)
+		rec := httptest.NewRecorder()
+		p.HandleChatCompletions(rec, req)
+		return rec
+	}
+
+	if rec := send("cli-a"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(store.usage) != 1 {
+		t.Fatalf("expected one usage record, got %+v", store.usage)
+	}
+	u := store.usage[0]
+	if u.TenantID != "team-a" || u.ClientID != "cli-a" || u.Provider != "openai" || u.Model != "gpt-4o" ||
+		u.PromptTokens != 40000 || u.CompletionTokens != 20000 || u.Cost != 1.0 {
+		t.Errorf("unexpected usage: %+v", u)
+	}
+	if len(keys.tenants) != 1 || keys.tenants[0] != "team-a" {
+		t.Errorf("expected the key to be selected for team-a, got %v", keys.tenants)
+	}
+
+	// The $1 budget is spent
+	rec := send("cli-a")
+	if rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), "insufficient_quota") {
+		t.Errorf("expected 429 insufficient_quota, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Shared clients are neither limited nor metered
+	if rec := send("shared"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for a shared client, got %d", rec.Code)
+	}
+	if len(store.usage) != 1 || keys.tenants[len(keys.tenants)-1] != "" {
+		t.Errorf("shared request charged to a tenant: %+v %v", store.usage, keys.tenants)
+	}
+}
+
+func TestAnthropicProxy_TenantStreamUsage(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: message_start\n"))
+		w.Write([]byte(`data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":25,"output_tokens":1}}}` + "\n\n"))
+		w.Write([]byte("event: content_block_delta\n"))
+		w.Write([]byte(`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}` + "\n\n"))
+		w.Write([]byte("event: message_delta\n"))
+		w.Write([]byte(`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":12}}` + "\n\n"))
+		w.Write([]byte("event: message_stop\n"))
+		w.Write([]byte(`data: {"type":"message_stop"}` + "\n\n"))
+	}))
+	defer upstream.Close()
+
+	store := &testTenantStore{}
+	cfg := DefaultAnthropicProxyConfig()
+	cfg.AnthropicBaseURL = upstream.URL
+	p := NewAnthropicProxy(cfg, &mockKeyProvider{key: "test-key"}, nil)
+	p.SetTenants(tenant.NewManager(store, testPricer{}, time.Hour))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages",
+		strings.NewReader(`{"model":"claude-3-opus-20240229","max_tokens":100,"stream":true,"messages":[{"role":"user","content":[{"type":"text","text":"Hi"}]}]}`))
+	req.Header.Set("X-Client-ID", "cli-a")
+	rec := httptest.NewRecorder()
+	p.HandleMessages(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(store.usage) != 1 || store.usage[0].PromptTokens != 25 || store.usage[0].CompletionTokens != 12 {
+		t.Errorf("expected 25/12 tokens from the stream, got %+v", store.usage)
+	}
+}
+
+func TestMeterWriter_EstimatesWithoutUsage(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "text/event-stream")
+	mw := &meterWriter{ResponseWriter: rec, status: http.StatusOK}
+
+	// Events may be split across writes
+	mw.Write([]byte(`data: {"choices":[{"delta":{"content":"one two "}}]}` + "\n\ndata: {\"choi"))
+	mw.Write([]byte(`ces":[{"delta":{"content":"three"}}]}` + "\n\ndata: [DONE]\n\n"))
+
+	prompt, completion := mw.tokens(7)
+	if prompt != 7 || completion != CountTokens("one two three") {
+		t.Errorf("tokens() = %d, %d", prompt, completion)
+	}
+	if rec.Body.Len() == 0 {
+		t.Error("expected the stream to reach the client")
+	}
+}
@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"encoding/json"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token accounting overheads, following OpenAI's published chat format costs
+const (
+	tokensPerMessage = 3 // <|start|>role ... <|end|>
+	tokensPerName    = 1
+	tokensPerReply   = 3 // every reply is primed with <|start|>assistant
+	tokensPerImage   = 765
+)
+
+// CountTokens approximates the BPE token count of text.
+// It pre-tokenizes like cl100k (letter runs, digit groups of up to three,
+// individual punctuation, whitespace folded into the next piece) and splits
+// long letter runs into ~5 character sub-words. Scripts without word spacing
+// (CJK and similar) count one token per rune.
+func CountTokens(text string) int {
+	tokens := 0
+	letters, digits := 0, 0
+
+	flush := func() {
+		if letters > 0 {
+			tokens += (letters + 4) / 5
+			letters = 0
+		}
+		if digits > 0 {
+			tokens += (digits + 2) / 3
+			digits = 0
+		}
+	}
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+
+		switch {
+		case isWordlessScript(r):
+			flush()
+			tokens++
+		case unicode.IsLetter(r) || r == '\'':
+			if digits > 0 {
+				flush()
+			}
+			letters++
+		case unicode.IsDigit(r):
+			if letters > 0 {
+				flush()
+			}
+			digits++
+		case unicode.IsSpace(r):
+			flush()
+			// Runs of newlines/indentation are merged; a single space joins the next word
+			if r == '\n' {
+				tokens++
+			}
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// isWordlessScript reports whether r belongs to a script written without spaces
+func isWordlessScript(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Thai)
+}
+
+// CountOpenAIRequestTokens estimates the prompt tokens of a chat completion request
+func CountOpenAIRequestTokens(req *OpenAIRequest) int {
+	tokens := tokensPerReply
+	for i := range req.Messages {
+		tokens += countOpenAIMessageTokens(&req.Messages[i])
+	}
+	for _, tool := range req.Tools {
+		tokens += countJSONTokens(tool.Function)
+	}
+	return tokens
+}
+
+// countOpenAIMessageTokens estimates the tokens of a single chat message
+func countOpenAIMessageTokens(msg *OpenAIMessage) int {
+	tokens := tokensPerMessage + CountTokens(msg.Role)
+	if msg.Name != "" {
+		tokens += tokensPerName + CountTokens(msg.Name)
+	}
+
+	switch content := msg.Content.(type) {
+	case string:
+		tokens += CountTokens(content)
+	case []interface{}:
+		for _, part := range content {
+			block, ok := part.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if text, ok := block["text"].(string); ok {
+				tokens += CountTokens(text)
+			}
+			if block["type"] == "image_url" {
+				tokens += tokensPerImage
+			}
+		}
+	}
+
+	for _, call := range msg.ToolCalls {
+		tokens += CountTokens(call.Function.Name) + CountTokens(call.Function.Arguments)
+	}
+	return tokens
+}
+
+// CountAnthropicRequestTokens estimates the input tokens of a messages request
+func CountAnthropicRequestTokens(req *AnthropicRequest) int {
+	tokens := tokensPerReply
+	if req.System != "" {
+		tokens += tokensPerMessage + CountTokens(req.System)
+	}
+	for i := range req.Messages {
+		tokens += countAnthropicMessageTokens(&req.Messages[i])
+	}
+	for _, tool := range req.Tools {
+		tokens += countJSONTokens(tool)
+	}
+	return tokens
+}
+
+// countAnthropicMessageTokens estimates the tokens of a single Anthropic message
+func countAnthropicMessageTokens(msg *AnthropicMessage) int {
+	tokens := tokensPerMessage
+	for _, part := range msg.Content {
+		switch part.Type {
+		case "image":
+			tokens += tokensPerImage
+		case "tool_use":
+			tokens += CountTokens(part.Name) + countJSONTokens(part.Input)
+		default:
+			tokens += CountTokens(part.Text) + CountTokens(part.Content)
+		}
+	}
+	return tokens
+}
+
+// countJSONTokens estimates the tokens of a value's JSON encoding
+func countJSONTokens(v interface{}) int {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return CountTokens(string(data))
+}
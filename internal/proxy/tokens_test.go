@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello world", 2},
+		{"Hello, world!", 4},
+		{"12345", 2},
+		{"internationalization", 4},
+		{"a\nb", 3},
+		{"你好", 2},
+	}
+	for _, tt := range tests {
+		if got := CountTokens(tt.text); got != tt.want {
+			t.Errorf("CountTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCountTokens_ScalesWithLength(t *testing.T) {
+	short := CountTokens(strings.Repeat("the quick brown fox ", 10))
+	long := CountTokens(strings.Repeat("the quick brown fox ", 100))
+	if long != short*10 {
+		t.Errorf("expected linear scaling, got %d vs %d", short, long)
+	}
+}
+
+func TestCountOpenAIRequestTokens(t *testing.T) {
+	req := &OpenAIRequest{
+		Messages: []OpenAIMessage{
+			{Role: "system", Content: "be brief"},
+			{Role: "user", Content: "hello world"},
+		},
+	}
+	// reply priming + message overhead + roles ("system" is two sub-words) + content
+	want := tokensPerReply + 2*tokensPerMessage + 2 + 1 + 2 + 2
+	if got := CountOpenAIRequestTokens(req); got != want {
+		t.Errorf("CountOpenAIRequestTokens() = %d, want %d", got, want)
+	}
+
+	// Images and multipart text are counted
+	req.Messages = append(req.Messages, OpenAIMessage{
+		Role: "user",
+		Content: []interface{}{
+			map[string]interface{}{"type": "text", "text": "what is this"},
+			map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": "data:..."}},
+		},
+	})
+	if got := CountOpenAIRequestTokens(req); got < want+tokensPerImage+3 {
+		t.Errorf("expected image and text parts counted, got %d", got)
+	}
+}
+
+func TestCountAnthropicRequestTokens(t *testing.T) {
+	req := &AnthropicRequest{
+		System: "be brief",
+		Messages: []AnthropicMessage{
+			{Role: "user", Content: []ContentPart{{Type: "text", Text: "hello world"}}},
+		},
+	}
+	want := tokensPerReply + (tokensPerMessage + 2) + (tokensPerMessage + 2)
+	if got := CountAnthropicRequestTokens(req); got != want {
+		t.Errorf("CountAnthropicRequestTokens() = %d, want %d", got, want)
+	}
+
+	req.Tools = []AnthropicTool{{Name: "get_weather", InputSchema: map[string]interface{}{"type": "object"}}}
+	if got := CountAnthropicRequestTokens(req); got <= want {
+		t.Errorf("expected tools counted, got %d", got)
+	}
+}
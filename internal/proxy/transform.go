@@ -0,0 +1,349 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// HeaderTransforms lists the transformation rules applied to a request
+const HeaderTransforms = "X-Modelscan-Transforms"
+
+// APIs a transformation rule can be limited to
+const (
+	TransformAPIOpenAI    = "openai"    // Chat completions, including translated Responses API requests
+	TransformAPIAnthropic = "anthropic" // Messages
+	TransformAPIResponses = "responses" // Responses API requests forwarded to OpenAI untranslated
+)
+
+// TransformRule rewrites matching requests before they are routed or
+// translated for a provider. Fields are stripped, then set, then clamped;
+// field names with dots reach into nested objects ("metadata.user_id").
+type TransformRule struct {
+	Name    string   `json:"name"`
+	APIs    []string `json:"apis,omitempty"`    // Default every API
+	Models  []string `json:"models,omitempty"`  // Requested model patterns with "*" wildcards (default all)
+	Clients []string `json:"clients,omitempty"` // Client IDs (default all)
+
+	Headers map[string]string          `json:"headers,omitempty"` // Added to the upstream request
+	Strip   []string                   `json:"strip,omitempty"`   // Body fields removed
+	Set     map[string]json.RawMessage `json:"set,omitempty"`     // Body fields forced to a value
+	Clamp   map[string]Range           `json:"clamp,omitempty"`   // Numeric body fields held to a range
+}
+
+// Range bounds a numeric field; a missing bound is open
+type Range struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// reservedFields decide how the proxy handles a request, so rules may not
+// change them
+var reservedFields = []string{"model", "messages", "input", "stream"}
+
+// reservedHeaders are set by the proxy for each upstream request
+var reservedHeaders = []string{"Authorization", "X-Api-Key", "Content-Type", "Content-Length", "Host"}
+
+// Validate checks the rule has a name, known APIs, usable headers and
+// fields, and ranges with min not above max
+func (t TransformRule) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	for _, api := range t.APIs {
+		if api != TransformAPIOpenAI && api != TransformAPIAnthropic && api != TransformAPIResponses {
+			return fmt.Errorf("unknown api %q (want openai, anthropic or responses)", api)
+		}
+	}
+	for name, value := range t.Headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") || strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid header %q", name)
+		}
+		if slices.Contains(reservedHeaders, http.CanonicalHeaderKey(name)) {
+			return fmt.Errorf("header %s is set by the proxy", name)
+		}
+	}
+	fields := slices.Clone(t.Strip)
+	for field, value := range t.Set {
+		if !json.Valid(value) {
+			return fmt.Errorf("set %s: invalid JSON value", field)
+		}
+		fields = append(fields, field)
+	}
+	for field, r := range t.Clamp {
+		if r.Min == nil && r.Max == nil {
+			return fmt.Errorf("clamp %s: min or max is required", field)
+		}
+		if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+			return fmt.Errorf("clamp %s: min is above max", field)
+		}
+		fields = append(fields, field)
+	}
+	for _, field := range fields {
+		if field == "" || slices.Contains(strings.Split(field, "."), "") {
+			return fmt.Errorf("invalid field %q", field)
+		}
+		if slices.Contains(reservedFields, strings.Split(field, ".")[0]) {
+			return fmt.Errorf("field %s cannot be changed by a rule", field)
+		}
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to a request
+func (t TransformRule) matches(api, model, clientID string) bool {
+	if len(t.APIs) > 0 && !slices.Contains(t.APIs, api) {
+		return false
+	}
+	if len(t.Clients) > 0 && !slices.Contains(t.Clients, clientID) {
+		return false
+	}
+	if len(t.Models) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(t.Models, func(pattern string) bool { return matchWildcard(pattern, model) })
+}
+
+// matchWildcard matches s against a pattern where "*" stands for any run
+// of characters, slashes included
+func matchWildcard(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
+
+// TransformRules holds the active rules, applied in order. Rules can be
+// changed at runtime; each request reads them once.
+type TransformRules struct {
+	mu    sync.RWMutex
+	rules []TransformRule
+}
+
+// NewTransformRules creates an empty rule set
+func NewTransformRules() *TransformRules {
+	return &TransformRules{}
+}
+
+// SetRule replaces the rule with the same name, or adds it at the end
+func (t *TransformRules) SetRule(rule TransformRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if i := t.index(rule.Name); i >= 0 {
+		t.rules[i] = rule
+	} else {
+		t.rules = append(t.rules, rule)
+	}
+	return nil
+}
+
+// RemoveRule removes a rule, reporting whether it existed
+func (t *TransformRules) RemoveRule(name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	i := t.index(name)
+	if i < 0 {
+		return false
+	}
+	t.rules = slices.Delete(t.rules, i, i+1)
+	return true
+}
+
+// Rule returns a rule by name
+func (t *TransformRules) Rule(name string) (TransformRule, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if i := t.index(name); i >= 0 {
+		return t.rules[i], true
+	}
+	return TransformRule{}, false
+}
+
+// Rules returns the rules in the order they are applied
+func (t *TransformRules) Rules() []TransformRule {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return slices.Clone(t.rules)
+}
+
+// index returns the position of a rule, or -1. Callers hold t.mu.
+func (t *TransformRules) index(name string) int {
+	return slices.IndexFunc(t.rules, func(r TransformRule) bool { return r.Name == name })
+}
+
+// Transformed is the outcome of applying rules to a request body
+type Transformed struct {
+	Body    []byte
+	Headers http.Header // For the upstream request
+	Applied []string    // Names of the rules applied
+}
+
+// Apply runs the rules matching a request body sent to api by clientID.
+// The body is returned as it is when no rule matches.
+func (t *TransformRules) Apply(api, clientID string, body []byte) (*Transformed, error) {
+	var head struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(body, &head); err != nil {
+		return nil, err
+	}
+	var matched []TransformRule
+	for _, rule := range t.Rules() {
+		if rule.matches(api, head.Model, clientID) {
+			matched = append(matched, rule)
+		}
+	}
+	out := &Transformed{Body: body}
+	if len(matched) == 0 {
+		return out, nil
+	}
+
+	// Numbers stay json.Numbers so values the rules leave alone are
+	// passed on exactly
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	out.Headers = make(http.Header)
+	for _, rule := range matched {
+		for _, field := range rule.Strip {
+			deleteField(fields, field)
+		}
+		for field, value := range rule.Set {
+			setField(fields, field, json.RawMessage(value))
+		}
+		for field, r := range rule.Clamp {
+			clampField(fields, field, r)
+		}
+		for name, value := range rule.Headers {
+			out.Headers.Set(name, value)
+		}
+		out.Applied = append(out.Applied, rule.Name)
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	out.Body = b
+	return out, nil
+}
+
+// parentOf walks to the object holding a dotted field, creating missing
+// objects when create is set
+func parentOf(fields map[string]any, path string, create bool) (map[string]any, string) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := fields[part].(map[string]any)
+		if !ok {
+			if !create {
+				return nil, ""
+			}
+			next = make(map[string]any)
+			fields[part] = next
+		}
+		fields = next
+	}
+	return fields, parts[len(parts)-1]
+}
+
+func deleteField(fields map[string]any, path string) {
+	if parent, key := parentOf(fields, path, false); parent != nil {
+		delete(parent, key)
+	}
+}
+
+func setField(fields map[string]any, path string, value json.RawMessage) {
+	parent, key := parentOf(fields, path, true)
+	parent[key] = value
+}
+
+// clampField holds a numeric field to r; a missing or non-numeric field is
+// left alone
+func clampField(fields map[string]any, path string, r Range) {
+	parent, key := parentOf(fields, path, false)
+	if parent == nil {
+		return
+	}
+	n, ok := parent[key].(json.Number)
+	if !ok {
+		return
+	}
+	v, err := n.Float64()
+	if err != nil {
+		return
+	}
+	clamped := v
+	if r.Min != nil {
+		clamped = math.Max(clamped, *r.Min)
+	}
+	if r.Max != nil {
+		clamped = math.Min(clamped, *r.Max)
+	}
+	if clamped != v {
+		parent[key] = json.Number(strconv.FormatFloat(clamped, 'f', -1, 64))
+	}
+}
+
+type transformHeadersKey struct{}
+
+// transformRequest applies the rules to a request body, noting the rules
+// applied in the response and keeping their headers in the context for
+// the upstream request
+func transformRequest(ctx context.Context, rules *TransformRules, w http.ResponseWriter, api, clientID string, body []byte) (context.Context, []byte, error) {
+	if rules == nil {
+		return ctx, body, nil
+	}
+	out, err := rules.Apply(api, clientID, body)
+	if err != nil {
+		return ctx, nil, err
+	}
+	if len(out.Applied) > 0 {
+		w.Header().Set(HeaderTransforms, strings.Join(out.Applied, ","))
+	}
+	if len(out.Headers) > 0 {
+		ctx = context.WithValue(ctx, transformHeadersKey{}, out.Headers)
+	}
+	return ctx, out.Body, nil
+}
+
+// setTransformHeaders adds the headers of the rules applied to the request
+func setTransformHeaders(req *http.Request) {
+	headers, _ := req.Context().Value(transformHeadersKey{}).(http.Header)
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+}
+
+// SetTransformRules enables request transformation rules
+func (p *OpenAIProxy) SetTransformRules(rules *TransformRules) {
+	p.transforms = rules
+}
+
+// SetTransformRules enables request transformation rules
+func (p *AnthropicProxy) SetTransformRules(rules *TransformRules) {
+	p.transforms = rules
+}
@@ -0,0 +1,162 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func bound(v float64) *float64 { return &v }
+
+func TestTransformRule_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule TransformRule
+		want string
+	}{
+		{"no name", TransformRule{Strip: []string{"user"}}, "name is required"},
+		{"unknown api", TransformRule{Name: "r", APIs: []string{"gemini"}}, "unknown api"},
+		{"reserved header", TransformRule{Name: "r", Headers: map[string]string{"authorization": "Bearer x"}}, "set by the proxy"},
+		{"invalid header", TransformRule{Name: "r", Headers: map[string]string{"X-Team": "a\r\nb"}}, "invalid header"},
+		{"invalid value", TransformRule{Name: "r", Set: map[string]json.RawMessage{"seed": json.RawMessage("{")}}, "invalid JSON"},
+		{"empty range", TransformRule{Name: "r", Clamp: map[string]Range{"temperature": {}}}, "min or max"},
+		{"inverted range", TransformRule{Name: "r", Clamp: map[string]Range{"temperature": {Min: bound(1), Max: bound(0)}}}, "min is above max"},
+		{"empty field", TransformRule{Name: "r", Strip: []string{"metadata."}}, "invalid field"},
+		{"reserved field", TransformRule{Name: "r", Set: map[string]json.RawMessage{"stream": json.RawMessage("false")}}, "cannot be changed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	valid := TransformRule{Name: "r", APIs: []string{"openai"}, Strip: []string{"metadata.user_id"}, Clamp: map[string]Range{"max_tokens": {Max: bound(4096)}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("expected a valid rule, got %v", err)
+	}
+}
+
+func TestMatchWildcard(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"gpt-4o", "gpt-4o", true},
+		{"gpt-4o", "gpt-4o-mini", false},
+		{"gpt-4*", "gpt-4o-mini", true},
+		{"*-mini", "gpt-4o-mini", true},
+		{"meta-llama/*-instruct", "meta-llama/llama-3-8b-instruct", true},
+		{"claude-*-sonnet*", "claude-3-haiku", false},
+		{"*", "anything", true},
+	} {
+		if got := matchWildcard(tc.pattern, tc.s); got != tc.want {
+			t.Errorf("matchWildcard(%q, %q) = %t, want %t", tc.pattern, tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestTransformRules_Apply(t *testing.T) {
+	rules := NewTransformRules()
+	for _, rule := range []TransformRule{
+		{Name: "privacy", Strip: []string{"user", "metadata.user_id"}},
+		{Name: "gpt-4", APIs: []string{TransformAPIOpenAI}, Models: []string{"gpt-4*"}, Headers: map[string]string{"OpenAI-Project": "proj_1"},
+			Set:   map[string]json.RawMessage{"seed": json.RawMessage("42"), "response_format.type": json.RawMessage(`"json_object"`)},
+			Clamp: map[string]Range{"temperature": {Min: bound(0), Max: bound(1)}, "max_tokens": {Max: bound(4096)}}},
+		{Name: "batch", Clients: []string{"batch-jobs"}, Set: map[string]json.RawMessage{"seed": json.RawMessage("7")}},
+	} {
+		if err := rules.SetRule(rule); err != nil {
+			t.Fatalf("SetRule(%s): %v", rule.Name, err)
+		}
+	}
+
+	body := `{"model":"gpt-4o","messages":[],"user":"u-1","metadata":{"user_id":"u-1","team":"a"},"temperature":1.5,"max_tokens":8000,"top_p":0.95}`
+	out, err := rules.Apply(TransformAPIOpenAI, "cli-a", []byte(body))
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if got := strings.Join(out.Applied, ","); got != "privacy,gpt-4" {
+		t.Errorf("applied = %s, want privacy,gpt-4", got)
+	}
+	var got map[string]any
+	json.Unmarshal(out.Body, &got)
+	if _, ok := got["user"]; ok {
+		t.Error("expected user to be stripped")
+	}
+	if meta := got["metadata"].(map[string]any); meta["user_id"] != nil || meta["team"] != "a" {
+		t.Errorf("expected only metadata.user_id to be stripped, got %v", meta)
+	}
+	if got["temperature"] != 1.0 || got["max_tokens"] != 4096.0 || got["seed"] != 42.0 || got["top_p"] != 0.95 {
+		t.Errorf("unexpected sampling fields: %v", got)
+	}
+	if format, _ := got["response_format"].(map[string]any); format["type"] != "json_object" {
+		t.Errorf("expected response_format.type to be set, got %v", got["response_format"])
+	}
+	if out.Headers.Get("OpenAI-Project") != "proj_1" {
+		t.Errorf("expected the rule's header, got %v", out.Headers)
+	}
+
+	// Later rules win
+	out, _ = rules.Apply(TransformAPIOpenAI, "batch-jobs", []byte(body))
+	if !strings.Contains(string(out.Body), `"seed":7`) {
+		t.Errorf("expected the client rule's seed, got %s", out.Body)
+	}
+
+	// Another API or model only gets the general rule
+	out, _ = rules.Apply(TransformAPIAnthropic, "cli-a", []byte(body))
+	if strings.Join(out.Applied, ",") != "privacy" || strings.Contains(string(out.Body), "seed") {
+		t.Errorf("expected only the privacy rule, got %v: %s", out.Applied, out.Body)
+	}
+
+	// An unmatched body passes through byte for byte
+	rules.RemoveRule("privacy")
+	plain := `{"model": "claude-3-haiku",  "max_tokens": 8000}`
+	out, _ = rules.Apply(TransformAPIAnthropic, "cli-a", []byte(plain))
+	if string(out.Body) != plain || len(out.Applied) != 0 {
+		t.Errorf("expected the body unchanged, got %s", out.Body)
+	}
+}
+
+func TestOpenAIProxy_TransformRules(t *testing.T) {
+	var gotBody map[string]any
+	var gotHeader http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		json.Unmarshal(b, &gotBody)
+		gotHeader = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "sk-test"}, nil)
+	rules := NewTransformRules()
+	rules.SetRule(TransformRule{
+		Name:    "privacy",
+		Headers: map[string]string{"OpenAI-Project": "proj_1"},
+		Strip:   []string{"user"},
+		Clamp:   map[string]Range{"max_tokens": {Max: bound(1024)}},
+	})
+	p.SetTransformRules(rules)
+
+	w := sendChat(p, `{"model":"gpt-4o","user":"u-1","max_tokens":8000,"messages":[{"role":"user","content":"hi"}]}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get(HeaderTransforms) != "privacy" {
+		t.Errorf("expected the applied rule in the response, got %q", w.Header().Get(HeaderTransforms))
+	}
+	if _, ok := gotBody["user"]; ok || gotBody["max_tokens"] != 1024.0 {
+		t.Errorf("expected the rewritten body upstream, got %v", gotBody)
+	}
+	if gotHeader.Get("OpenAI-Project") != "proj_1" || gotHeader.Get("Authorization") != "Bearer sk-test" {
+		t.Errorf("unexpected upstream headers: %v", gotHeader)
+	}
+}
@@ -15,6 +15,7 @@ type AnthropicRequest struct {
 	Messages      []AnthropicMessage `json:"messages"`
 	MaxTokens     int                `json:"max_tokens"`
 	System        string             `json:"system,omitempty"`
+	SystemBlocks  []ContentPart      `json:"-"` // System sent as text blocks, e.g. to mark it with cache_control
 	Temperature   *float64           `json:"temperature,omitempty"`
 	TopP          *float64           `json:"top_p,omitempty"`
 	TopK          *int               `json:"top_k,omitempty"`
@@ -41,13 +42,22 @@ type ContentPart struct {
 	ToolUseID string                 `json:"tool_use_id,omitempty"`
 	Content   string                 `json:"content,omitempty"`
 	Source    *ImageSource           `json:"source,omitempty"`
+
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
+}
+
+// CacheControl marks the end of a prompt prefix the provider should cache.
+type CacheControl struct {
+	Type string `json:"type"`          // ephemeral
+	TTL  string `json:"ttl,omitempty"` // 5m or 1h
 }
 
 // ImageSource represents image data for vision requests.
 type ImageSource struct {
-	Type      string `json:"type"`
-	MediaType string `json:"media_type"`
-	Data      string `json:"data"`
+	Type      string `json:"type"` // base64 or url
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
 }
 
 // AnthropicTool represents a tool definition in Anthropic format.
@@ -55,6 +65,8 @@ type AnthropicTool struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description,omitempty"`
 	InputSchema map[string]interface{} `json:"input_schema"`
+
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
 // ToolChoice specifies tool selection behavior.
@@ -75,10 +87,13 @@ type AnthropicResponse struct {
 	Usage        *Usage        `json:"usage,omitempty"`
 }
 
-// Usage tracks token usage for billing.
+// Usage tracks token usage for billing. InputTokens excludes prompt tokens
+// written to or read from the cache.
 type Usage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
 }
 
 // ====== OpenAI Request/Response Types ======
@@ -100,6 +115,7 @@ type OpenAIRequest struct {
 	FrequencyPenalty    *float64        `json:"frequency_penalty,omitempty"`
 	PresencePenalty     *float64        `json:"presence_penalty,omitempty"`
 	User                string          `json:"user,omitempty"`
+	PromptCacheKey      string          `json:"prompt_cache_key,omitempty"`
 }
 
 // StreamOptions configures streaming behavior.
@@ -160,11 +176,17 @@ type OpenAIChoice struct {
 	FinishReason string        `json:"finish_reason,omitempty"`
 }
 
-// OpenAIUsage tracks token usage.
+// OpenAIUsage tracks token usage. PromptTokens includes cached tokens.
 type OpenAIUsage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens        int                  `json:"prompt_tokens"`
+	CompletionTokens    int                  `json:"completion_tokens"`
+	TotalTokens         int                  `json:"total_tokens"`
+	PromptTokensDetails *PromptTokensDetails `json:"prompt_tokens_details,omitempty"`
+}
+
+// PromptTokensDetails breaks down prompt tokens.
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
 }
 
 // ====== Streaming Chunk Types ======
@@ -287,6 +309,10 @@ func ToOpenAI(req *AnthropicRequest) (*OpenAIRequest, error) {
 		openaiReq.ToolChoice = convertToolChoiceToOpenAI(req.ToolChoice)
 	}
 
+	// OpenAI caches prefixes on its own; cache_control markers become a
+	// cache key for the marked prefix
+	openaiReq.PromptCacheKey = promptCacheKey(req)
+
 	return openaiReq, nil
 }
 
@@ -311,7 +337,7 @@ func convertAnthropicMessageToOpenAI(msg AnthropicMessage) ([]OpenAIMessage, err
 					})
 				}
 			}
-			// If there's also text content, add as user message
+			// If there's also text or image content, add as user message
 			if content != "" {
 				openaiMsg.Content = content
 				result = append(result, openaiMsg)
@@ -372,19 +398,33 @@ func convertAnthropicMessageToOpenAI(msg AnthropicMessage) ([]OpenAIMessage, err
 }
 
 // convertContentToOpenAI extracts text content and indicates if tool_result is present.
-func convertContentToOpenAI(content []ContentPart) (string, bool) {
+// Content with images becomes an array of text and image_url parts.
+func convertContentToOpenAI(content []ContentPart) (interface{}, bool) {
 	var texts []string
-	hasToolResult := false
+	var parts []interface{}
+	hasToolResult, hasImage := false, false
 
 	for _, part := range content {
 		switch part.Type {
 		case "text":
 			texts = append(texts, part.Text)
+			parts = append(parts, map[string]interface{}{"type": "text", "text": part.Text})
+		case "image":
+			if part.Source != nil {
+				hasImage = true
+				parts = append(parts, map[string]interface{}{
+					"type":      "image_url",
+					"image_url": map[string]interface{}{"url": part.Source.imageURL()},
+				})
+			}
 		case "tool_result":
 			hasToolResult = true
 		}
 	}
 
+	if hasImage {
+		return parts, hasToolResult
+	}
 	return joinStrings(texts, "\n"), hasToolResult
 }
 
@@ -448,16 +488,22 @@ func ToAnthropic(req *OpenAIRequest) (*AnthropicRequest, error) {
 
 	// Convert messages
 	messages := make([]AnthropicMessage, 0, len(req.Messages))
+	var system []ContentPart
 
 	for _, msg := range req.Messages {
 		if msg.Role == "system" {
 			// System messages become the system parameter
-			content, ok := msg.Content.(string)
-			if ok {
-				if anthropicReq.System != "" {
-					anthropicReq.System += "\n\n" + content
-				} else {
-					anthropicReq.System = content
+			if content, ok := msg.Content.(string); ok {
+				system = append(system, ContentPart{Type: "text", Text: content})
+				continue
+			}
+			parts, err := openAIContentParts(msg.Content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert system message: %w", err)
+			}
+			for _, part := range parts {
+				if part.Type == "text" {
+					system = append(system, part)
 				}
 			}
 			continue
@@ -474,6 +520,18 @@ func ToAnthropic(req *OpenAIRequest) (*AnthropicRequest, error) {
 
 	anthropicReq.Messages = messages
 
+	texts := make([]string, len(system))
+	for i, part := range system {
+		texts[i] = part.Text
+	}
+	anthropicReq.System = joinStrings(texts, "\n\n")
+	for _, part := range system {
+		if part.CacheControl != nil {
+			anthropicReq.SystemBlocks = system
+			break
+		}
+	}
+
 	// Convert tools
 	if len(req.Tools) > 0 {
 		anthropicReq.Tools = make([]AnthropicTool, len(req.Tools))
@@ -489,6 +547,12 @@ func ToAnthropic(req *OpenAIRequest) (*AnthropicRequest, error) {
 	// Convert tool_choice
 	anthropicReq.ToolChoice = convertToolChoiceToAnthropic(req.ToolChoice)
 
+	// A prompt_cache_key asks for caching, which Anthropic only does for
+	// marked prefixes
+	if req.PromptCacheKey != "" {
+		markSystemForCaching(anthropicReq)
+	}
+
 	return anthropicReq, nil
 }
 
@@ -520,6 +584,12 @@ func convertOpenAIMessageToAnthropic(msg OpenAIMessage) (*AnthropicMessage, erro
 			Type: "text",
 			Text: contentStr,
 		})
+	} else if !ok {
+		parts, err := openAIContentParts(msg.Content)
+		if err != nil {
+			return nil, err
+		}
+		result.Content = append(result.Content, parts...)
 	}
 
 	// Convert tool_calls to tool_use content blocks
@@ -640,13 +710,7 @@ func TranslateResponseToOpenAI(resp *AnthropicResponse) *OpenAIResponse {
 	}
 
 	// Convert usage
-	if resp.Usage != nil {
-		openaiResp.Usage = &OpenAIUsage{
-			PromptTokens:     resp.Usage.InputTokens,
-			CompletionTokens: resp.Usage.OutputTokens,
-			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
-		}
-	}
+	openaiResp.Usage = openAIUsage(resp.Usage)
 
 	return openaiResp
 }
@@ -692,12 +756,7 @@ func TranslateResponseToAnthropic(resp *OpenAIResponse) *AnthropicResponse {
 	}
 
 	// Convert usage
-	if resp.Usage != nil {
-		anthropicResp.Usage = &Usage{
-			InputTokens:  resp.Usage.PromptTokens,
-			OutputTokens: resp.Usage.CompletionTokens,
-		}
-	}
+	anthropicResp.Usage = anthropicUsage(resp.Usage)
 
 	return anthropicResp
 }
@@ -724,6 +783,8 @@ func TranslateStreamChunkToOpenAI(event *AnthropicStreamEvent, id string) *OpenA
 		if event.Message != nil {
 			chunk.Model = event.Message.Model
 			chunk.Choices[0].Delta = OpenAIStreamDelta{Role: "assistant"}
+			// Prompt and cache usage is only reported at the start
+			chunk.Usage = openAIUsage(event.Message.Usage)
 		}
 
 	case "content_block_start":
@@ -779,13 +840,7 @@ func TranslateStreamChunkToOpenAI(event *AnthropicStreamEvent, id string) *OpenA
 			reason := mapStopReasonToOpenAI(event.Delta.StopReason)
 			chunk.Choices[0].FinishReason = &reason
 		}
-		if event.Usage != nil {
-			chunk.Usage = &OpenAIUsage{
-				PromptTokens:     event.Usage.InputTokens,
-				CompletionTokens: event.Usage.OutputTokens,
-				TotalTokens:      event.Usage.InputTokens + event.Usage.OutputTokens,
-			}
-		}
+		chunk.Usage = openAIUsage(event.Usage)
 
 	case "message_stop":
 		// End of stream marker - return done
@@ -868,11 +923,8 @@ func TranslateStreamChunkToAnthropic(chunk *OpenAIStreamChunk, eventIndex *int)
 	// Handle usage
 	if chunk.Usage != nil {
 		events = append(events, AnthropicStreamEvent{
-			Type: "message_delta",
-			Usage: &Usage{
-				InputTokens:  chunk.Usage.PromptTokens,
-				OutputTokens: chunk.Usage.CompletionTokens,
-			},
+			Type:  "message_delta",
+			Usage: anthropicUsage(chunk.Usage),
 		})
 	}
 
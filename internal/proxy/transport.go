@@ -0,0 +1,19 @@
+package proxy
+
+import "net/http"
+
+// SetTransport replaces the transport used for upstream requests, usually
+// an internal/http Pool with per-provider connection settings. Call it
+// before SetFaultInjector, which wraps the current transport.
+func (p *OpenAIProxy) SetTransport(rt http.RoundTripper) {
+	p.httpClient.Transport = rt
+	p.streamingClient.Transport = rt
+}
+
+// SetTransport replaces the transport used for upstream requests, usually
+// an internal/http Pool with per-provider connection settings. Call it
+// before SetFaultInjector, which wraps the current transport.
+func (p *AnthropicProxy) SetTransport(rt http.RoundTripper) {
+	p.httpClient.Transport = rt
+	p.streamingClient.Transport = rt
+}
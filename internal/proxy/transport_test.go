@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+func TestOpenAIProxy_SignedTransportAndIdempotencyKey(t *testing.T) {
+	secret := []byte("gateway-secret")
+	var gotKey, gotRequestID string
+	var verifyErr error
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotKey = r.Header.Get(mshttp.DefaultIdempotencyHeader)
+		gotRequestID = r.Header.Get(mshttp.HeaderRequestID)
+		verifyErr = mshttp.VerifyHMAC(r, body, func(string) []byte { return secret }, time.Minute)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"x","object":"chat.completion","choices":[]}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "sk-test-key"}, nil)
+	p.SetTransport(mshttp.SigningTransport(nil, mshttp.NewHMACSigner("test", secret)))
+
+	body := `{"model": "gpt-4", "messages": [{"role": "user", "content": "hello"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set(mshttp.DefaultIdempotencyHeader, "client-key-1")
+	req = req.WithContext(mshttp.WithRequestID(req.Context(), "req-42"))
+	w := httptest.NewRecorder()
+	p.HandleChatCompletions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if verifyErr != nil {
+		t.Errorf("upstream could not verify the signature: %v", verifyErr)
+	}
+	if gotKey != "client-key-1" {
+		t.Errorf("upstream idempotency key = %q, want client-key-1", gotKey)
+	}
+	if gotRequestID != "req-42" {
+		t.Errorf("upstream request ID = %q, want req-42", gotRequestID)
+	}
+}
+
+func TestOpenAIProxy_ResponseLimitError(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"x","object":"chat.completion","choices":[{"message":{"content":"` + strings.Repeat("a", 4096) + `"}}]}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "sk-test-key"}, nil)
+	p.SetTransport(mshttp.LimitTransport(nil, mshttp.ResponseLimits{MaxBodyBytes: 1024}))
+
+	body := `{"model": "gpt-4", "messages": [{"role": "user", "content": "hello"}]}`
+	w := httptest.NewRecorder()
+	p.HandleChatCompletions(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), ErrorTypeResponseTooLarge) {
+		t.Errorf("expected %s error, got %s", ErrorTypeResponseTooLarge, w.Body.String())
+	}
+}
+
+func TestUpstreamReadError(t *testing.T) {
+	tests := []struct {
+		err      error
+		wantType string
+		status   int
+	}{
+		{&mshttp.LimitError{Err: mshttp.ErrBodyTooLarge, Limit: "1 bytes"}, ErrorTypeResponseTooLarge, http.StatusBadGateway},
+		{&mshttp.LimitError{Err: mshttp.ErrDecompressionRatio, Limit: "100x"}, ErrorTypeResponseTooLarge, http.StatusBadGateway},
+		{&mshttp.LimitError{Err: mshttp.ErrResponseReadTimeout, Limit: "1s"}, ErrorTypeUpstreamTimeout, http.StatusGatewayTimeout},
+		{io.ErrUnexpectedEOF, "server_error", http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		if _, errType, status := upstreamReadError(tt.err); errType != tt.wantType || status != tt.status {
+			t.Errorf("upstreamReadError(%v) = %s %d, want %s %d", tt.err, errType, status, tt.wantType, tt.status)
+		}
+	}
+}
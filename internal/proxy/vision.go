@@ -0,0 +1,350 @@
+package proxy
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrImageRejected is returned for image content the target model cannot
+// accept: a model without vision, too many or too large images, or an
+// image that cannot be read
+var ErrImageRejected = errors.New("image rejected")
+
+// maxFetchedImage bounds image downloads for providers without a size limit
+const maxFetchedImage = 20 << 20
+
+// VisionLimits bounds the images in one request. Zero values are unlimited.
+type VisionLimits struct {
+	MaxImages     int   // Images per request
+	MaxImageBytes int64 // Decoded size of each image
+}
+
+// DefaultVisionLimits are the providers' documented image limits, used for
+// providers the configuration does not cover
+var DefaultVisionLimits = map[string]VisionLimits{
+	"anthropic": {MaxImages: 100, MaxImageBytes: 5 << 20},
+	"openai":    {MaxImages: 500, MaxImageBytes: 20 << 20},
+	"cohere":    {MaxImages: 20, MaxImageBytes: 20 << 20},
+}
+
+// VisionCapabilities reports which models accept images
+type VisionCapabilities interface {
+	// SupportsVision reports whether the provider serves the model with
+	// image input; known is false when its capabilities are not recorded
+	SupportsVision(provider, model string) (supported, known bool)
+}
+
+// VisionConfig configures image validation and delivery
+type VisionConfig struct {
+	// InlineProviders only accept base64 images; image URLs sent to them
+	// are fetched and embedded
+	InlineProviders []string
+	// Limits overrides DefaultVisionLimits per provider
+	Limits map[string]VisionLimits
+	// FetchTimeout bounds each image download (default 10s)
+	FetchTimeout time.Duration
+	// AllowPrivateNetworks lets image URLs resolve to loopback and private
+	// addresses. Off by default so clients cannot reach internal services
+	// through the proxy.
+	AllowPrivateNetworks bool
+}
+
+// Vision checks image content against the target model's capabilities and
+// limits before a request is forwarded, and embeds image URLs for providers
+// that only take base64 data
+type Vision struct {
+	config VisionConfig
+	caps   VisionCapabilities
+	inline map[string]bool
+	client *http.Client
+}
+
+// NewVision creates a Vision. caps may be nil to skip capability checks.
+func NewVision(cfg VisionConfig, caps VisionCapabilities) (*Vision, error) {
+	if cfg.FetchTimeout <= 0 {
+		cfg.FetchTimeout = 10 * time.Second
+	}
+	for provider, l := range cfg.Limits {
+		if l.MaxImages < 0 || l.MaxImageBytes < 0 {
+			return nil, fmt.Errorf("image limits for %s must not be negative", provider)
+		}
+	}
+
+	v := &Vision{config: cfg, caps: caps, inline: make(map[string]bool)}
+	for _, provider := range cfg.InlineProviders {
+		v.inline[provider] = true
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.FetchTimeout}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = publicAddressesOnly
+	}
+	v.client = &http.Client{
+		Timeout:   cfg.FetchTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}
+	return v, nil
+}
+
+// SetVision validates image content before requests are forwarded
+func (p *OpenAIProxy) SetVision(v *Vision) {
+	p.vision = v
+}
+
+// SetVision validates image content before requests are forwarded
+func (p *AnthropicProxy) SetVision(v *Vision) {
+	p.vision = v
+}
+
+// PrepareOpenAI checks the images of an OpenAI request for the provider
+// and model, replacing image URLs with data URLs for inline providers
+func (v *Vision) PrepareOpenAI(ctx context.Context, req *OpenAIRequest, provider string) error {
+	if v == nil {
+		return nil
+	}
+	images := openAIImages(req)
+	if len(images) == 0 {
+		return nil
+	}
+	limits, err := v.check(provider, req.Model, len(images))
+	if err != nil {
+		return err
+	}
+
+	for _, img := range images {
+		url, _ := img["url"].(string)
+		src, err := imageSourceFromURL(url)
+		if err != nil {
+			return err
+		}
+		if src.Type == "url" && v.inline[provider] {
+			if src, err = v.fetch(ctx, src.URL, limits); err != nil {
+				return err
+			}
+			img["url"] = src.imageURL()
+		}
+		if err := checkImageSize(src, limits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrepareAnthropic checks the images of an Anthropic request for the
+// provider and model, embedding URL sources for inline providers
+func (v *Vision) PrepareAnthropic(ctx context.Context, req *AnthropicRequest, provider string) error {
+	if v == nil {
+		return nil
+	}
+	var images []*ContentPart
+	for i := range req.Messages {
+		for j := range req.Messages[i].Content {
+			if part := &req.Messages[i].Content[j]; part.Type == "image" {
+				images = append(images, part)
+			}
+		}
+	}
+	if len(images) == 0 {
+		return nil
+	}
+	limits, err := v.check(provider, req.Model, len(images))
+	if err != nil {
+		return err
+	}
+
+	for _, part := range images {
+		if part.Source == nil {
+			return fmt.Errorf("%w: image block without a source", ErrImageRejected)
+		}
+		if part.Source.Type == "url" && v.inline[provider] {
+			src, err := v.fetch(ctx, part.Source.URL, limits)
+			if err != nil {
+				return err
+			}
+			part.Source = src
+		}
+		if err := checkImageSize(part.Source, limits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check validates the image count against the model's capabilities and
+// the provider's limits, returning the limits
+func (v *Vision) check(provider, model string, count int) (VisionLimits, error) {
+	if v.caps != nil {
+		if supported, known := v.caps.SupportsVision(provider, model); known && !supported {
+			return VisionLimits{}, fmt.Errorf("%w: %s on %s does not accept images", ErrImageRejected, model, provider)
+		}
+	}
+	limits, ok := v.config.Limits[provider]
+	if !ok {
+		limits = DefaultVisionLimits[provider]
+	}
+	if limits.MaxImages > 0 && count > limits.MaxImages {
+		return limits, fmt.Errorf("%w: %d images exceed the limit of %d for %s", ErrImageRejected, count, limits.MaxImages, provider)
+	}
+	return limits, nil
+}
+
+// fetch downloads an image and returns it as a base64 source
+func (v *Vision) fetch(ctx context.Context, url string, limits VisionLimits) (*ImageSource, error) {
+	limit := limits.MaxImageBytes
+	if limit <= 0 {
+		limit = maxFetchedImage
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrImageRejected, err)
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to fetch %s: %v", ErrImageRejected, url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: fetching %s returned status %d", ErrImageRejected, url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read %s: %v", ErrImageRejected, url, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrImageRejected, url, limit)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !supportedImageType(mediaType) {
+		mediaType = http.DetectContentType(data)
+	}
+	if !supportedImageType(mediaType) {
+		return nil, fmt.Errorf("%w: %s is not a JPEG, PNG, GIF or WebP image", ErrImageRejected, url)
+	}
+	return &ImageSource{Type: "base64", MediaType: mediaType, Data: base64.StdEncoding.EncodeToString(data)}, nil
+}
+
+// checkImageSize rejects base64 images over the size limit. The size of
+// URL images is left to the provider.
+func checkImageSize(src *ImageSource, limits VisionLimits) error {
+	if src.Type != "base64" || limits.MaxImageBytes <= 0 {
+		return nil
+	}
+	if size := int64(base64.StdEncoding.DecodedLen(len(src.Data))); size > limits.MaxImageBytes {
+		return fmt.Errorf("%w: image of about %d bytes exceeds the limit of %d", ErrImageRejected, size, limits.MaxImageBytes)
+	}
+	return nil
+}
+
+// supportedImageType reports whether providers accept the media type
+func supportedImageType(mediaType string) bool {
+	switch mediaType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// openAIImages returns the image_url objects of a request's messages,
+// normalizing the bare-string form to {"url": ...} so they can be rewritten
+func openAIImages(req *OpenAIRequest) []map[string]interface{} {
+	var images []map[string]interface{}
+	for _, msg := range req.Messages {
+		blocks, _ := msg.Content.([]interface{})
+		for _, b := range blocks {
+			block, _ := b.(map[string]interface{})
+			if block["type"] != "image_url" {
+				continue
+			}
+			switch img := block["image_url"].(type) {
+			case map[string]interface{}:
+				images = append(images, img)
+			case string:
+				obj := map[string]interface{}{"url": img}
+				block["image_url"] = obj
+				images = append(images, obj)
+			}
+		}
+	}
+	return images
+}
+
+// openAIContentParts converts OpenAI array content to Anthropic text and
+// image blocks, keeping cache_control markers
+func openAIContentParts(content interface{}) ([]ContentPart, error) {
+	items, _ := content.([]interface{})
+	var parts []ContentPart
+	for _, item := range items {
+		block, _ := item.(map[string]interface{})
+		switch block["type"] {
+		case "text":
+			text, _ := block["text"].(string)
+			parts = append(parts, ContentPart{Type: "text", Text: text, CacheControl: cacheControlFromBlock(block)})
+		case "image_url":
+			var url string
+			switch img := block["image_url"].(type) {
+			case map[string]interface{}:
+				url, _ = img["url"].(string)
+			case string:
+				url = img
+			}
+			src, err := imageSourceFromURL(url)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, ContentPart{Type: "image", Source: src, CacheControl: cacheControlFromBlock(block)})
+		}
+	}
+	return parts, nil
+}
+
+// imageSourceFromURL maps an OpenAI image URL, either a data URL or an
+// http(s) URL, to an Anthropic image source
+func imageSourceFromURL(url string) (*ImageSource, error) {
+	if rest, ok := strings.CutPrefix(url, "data:"); ok {
+		meta, data, ok := strings.Cut(rest, ",")
+		mediaType, isBase64 := strings.CutSuffix(meta, ";base64")
+		if !ok || !isBase64 || !supportedImageType(mediaType) {
+			return nil, fmt.Errorf("%w: data URLs must hold base64 JPEG, PNG, GIF or WebP data", ErrImageRejected)
+		}
+		return &ImageSource{Type: "base64", MediaType: mediaType, Data: data}, nil
+	}
+	if strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") {
+		return &ImageSource{Type: "url", URL: url}, nil
+	}
+	return nil, fmt.Errorf("%w: image URLs must be http(s) or data URLs", ErrImageRejected)
+}
+
+// imageURL returns the source as an OpenAI image URL
+func (s *ImageSource) imageURL() string {
+	if s.Type == "url" {
+		return s.URL
+	}
+	return "data:" + s.MediaType + ";base64," + s.Data
+}
+
+// publicAddressesOnly refuses connections to loopback, private and
+// link-local addresses
+func publicAddressesOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("image host %s is not a public address", host)
+	}
+	return nil
+}
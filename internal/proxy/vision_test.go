@@ -0,0 +1,190 @@
+package proxy
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// pngHeader is enough of a PNG for content type detection
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+type visionCaps map[string]bool
+
+func (c visionCaps) SupportsVision(provider, model string) (bool, bool) {
+	supported, known := c[provider+"/"+model]
+	return supported, known
+}
+
+func TestImageTranslation(t *testing.T) {
+	openaiReq, err := ToOpenAI(&AnthropicRequest{
+		Model: "claude-3-5-sonnet",
+		Messages: []AnthropicMessage{{Role: "user", Content: []ContentPart{
+			{Type: "text", Text: "Compare"},
+			{Type: "image", Source: &ImageSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="}},
+			{Type: "image", Source: &ImageSource{Type: "url", URL: "https://example.com/b.jpg"}},
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("ToOpenAI failed: %v", err)
+	}
+	parts, ok := openaiReq.Messages[0].Content.([]interface{})
+	if !ok || len(parts) != 3 {
+		t.Fatalf("expected three content parts, got %#v", openaiReq.Messages[0].Content)
+	}
+	first := parts[1].(map[string]interface{})["image_url"].(map[string]interface{})["url"]
+	second := parts[2].(map[string]interface{})["image_url"].(map[string]interface{})["url"]
+	if first != "data:image/png;base64,iVBORw0KGgo=" || second != "https://example.com/b.jpg" {
+		t.Errorf("unexpected image URLs: %v, %v", first, second)
+	}
+
+	// And back, including the bare-string image_url form
+	var req OpenAIRequest
+	json.Unmarshal([]byte(`{"model":"m","messages":[{"role":"user","content":[
+		{"type":"text","text":"Compare"},
+		{"type":"image_url","image_url":{"url":"data:image/jpeg;base64,/9j/4AAQ"}},
+		{"type":"image_url","image_url":"https://example.com/b.jpg"}]}]}`), &req)
+	anthropicReq, err := ToAnthropic(&req)
+	if err != nil {
+		t.Fatalf("ToAnthropic failed: %v", err)
+	}
+	content := anthropicReq.Messages[0].Content
+	if len(content) != 3 || content[1].Source.Type != "base64" || content[1].Source.MediaType != "image/jpeg" ||
+		content[1].Source.Data != "/9j/4AAQ" || content[2].Source.Type != "url" || content[2].Source.URL != "https://example.com/b.jpg" {
+		t.Errorf("unexpected Anthropic content: %+v", content)
+	}
+
+	req.Messages[0].Content = []interface{}{map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": "file:///etc/passwd"}}}
+	if _, err := ToAnthropic(&req); !errors.Is(err, ErrImageRejected) {
+		t.Errorf("expected ErrImageRejected for a file URL, got %v", err)
+	}
+
+	// Cohere keeps images as v2 content blocks
+	json.Unmarshal([]byte(`{"model":"command-a-vision","messages":[{"role":"user","content":[
+		{"type":"text","text":"What is this?"},{"type":"image_url","image_url":{"url":"https://example.com/a.png","detail":"high"}}]}]}`), &req)
+	cohereReq, err := ToCohere(&req)
+	if err != nil {
+		t.Fatalf("ToCohere failed: %v", err)
+	}
+	out, _ := json.Marshal(cohereReq.Messages[0])
+	if !strings.Contains(string(out), `{"image_url":{"url":"https://example.com/a.png"},"type":"image_url"}`) {
+		t.Errorf("image dropped for Cohere: %s", out)
+	}
+}
+
+func TestVision_Checks(t *testing.T) {
+	v, err := NewVision(VisionConfig{Limits: map[string]VisionLimits{"openai": {MaxImages: 2, MaxImageBytes: 10}}},
+		visionCaps{"openai/gpt-4o": true, "openai/gpt-3.5-turbo": false})
+	if err != nil {
+		t.Fatalf("NewVision failed: %v", err)
+	}
+	ctx := context.Background()
+	image := func(url string) map[string]interface{} {
+		return map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": url}}
+	}
+	request := func(model string, images ...map[string]interface{}) *OpenAIRequest {
+		content := []interface{}{map[string]interface{}{"type": "text", "text": "hi"}}
+		for _, img := range images {
+			content = append(content, img)
+		}
+		return &OpenAIRequest{Model: model, Messages: []OpenAIMessage{{Role: "user", Content: content}}}
+	}
+	small := image("data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("tiny")))
+
+	if err := v.PrepareOpenAI(ctx, request("gpt-4o", small, small), "openai"); err != nil {
+		t.Errorf("expected two small images to pass, got %v", err)
+	}
+	if err := v.PrepareOpenAI(ctx, request("gpt-3.5-turbo", small), "openai"); !errors.Is(err, ErrImageRejected) {
+		t.Errorf("expected a non-vision model to be rejected, got %v", err)
+	}
+	if err := v.PrepareOpenAI(ctx, request("unknown-model", small), "openai"); err != nil {
+		t.Errorf("models without recorded capabilities are not checked, got %v", err)
+	}
+	if err := v.PrepareOpenAI(ctx, request("gpt-4o", small, small, small), "openai"); err == nil || !strings.Contains(err.Error(), "limit of 2") {
+		t.Errorf("expected the image count limit, got %v", err)
+	}
+	large := image("data:image/png;base64," + base64.StdEncoding.EncodeToString(make([]byte, 64)))
+	if err := v.PrepareOpenAI(ctx, request("gpt-4o", large), "openai"); !errors.Is(err, ErrImageRejected) {
+		t.Errorf("expected the size limit, got %v", err)
+	}
+	if err := v.PrepareOpenAI(ctx, request("gpt-4o"), "openai"); err != nil {
+		t.Errorf("requests without images always pass, got %v", err)
+	}
+
+	// A nil Vision checks nothing
+	var none *Vision
+	if err := none.PrepareOpenAI(ctx, request("gpt-3.5-turbo", small), "openai"); err != nil {
+		t.Errorf("nil Vision returned %v", err)
+	}
+	if _, err := NewVision(VisionConfig{Limits: map[string]VisionLimits{"x": {MaxImages: -1}}}, nil); err == nil {
+		t.Error("expected error for negative limits")
+	}
+}
+
+func TestVision_Inline(t *testing.T) {
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/page.html" {
+			w.Write([]byte("<html></html>"))
+			return
+		}
+		w.Write(pngHeader)
+	}))
+	defer images.Close()
+	ctx := context.Background()
+
+	v, _ := NewVision(VisionConfig{InlineProviders: []string{"bedrock"}, AllowPrivateNetworks: true}, nil)
+	req := &AnthropicRequest{Model: "claude", Messages: []AnthropicMessage{{Role: "user", Content: []ContentPart{
+		{Type: "image", Source: &ImageSource{Type: "url", URL: images.URL + "/a.png"}},
+	}}}}
+
+	// Providers that take URLs get them as they are
+	if err := v.PrepareAnthropic(ctx, req, "anthropic"); err != nil || req.Messages[0].Content[0].Source.Type != "url" {
+		t.Fatalf("expected the URL kept, got %v %+v", err, req.Messages[0].Content[0].Source)
+	}
+	if err := v.PrepareAnthropic(ctx, req, "bedrock"); err != nil {
+		t.Fatalf("PrepareAnthropic failed: %v", err)
+	}
+	src := req.Messages[0].Content[0].Source
+	if src.Type != "base64" || src.MediaType != "image/png" || src.Data != base64.StdEncoding.EncodeToString(pngHeader) {
+		t.Errorf("image not embedded: %+v", src)
+	}
+
+	openaiReq := &OpenAIRequest{Model: "m", Messages: []OpenAIMessage{{Role: "user", Content: []interface{}{
+		map[string]interface{}{"type": "image_url", "image_url": images.URL + "/page.html"},
+	}}}}
+	if err := v.PrepareOpenAI(ctx, openaiReq, "bedrock"); err == nil || !strings.Contains(err.Error(), "not a JPEG") {
+		t.Errorf("expected a non-image download to be rejected, got %v", err)
+	}
+
+	// Private addresses are refused by default
+	strict, _ := NewVision(VisionConfig{InlineProviders: []string{"bedrock"}}, nil)
+	req.Messages[0].Content[0].Source = &ImageSource{Type: "url", URL: images.URL + "/a.png"}
+	if err := strict.PrepareAnthropic(ctx, req, "bedrock"); err == nil || !strings.Contains(err.Error(), "not a public address") {
+		t.Errorf("expected a loopback download to be refused, got %v", err)
+	}
+}
+
+func TestAnthropicProxy_RejectsImagesForTextModels(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not reach the provider")
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultAnthropicProxyConfig()
+	cfg.AnthropicBaseURL = upstream.URL
+	p := NewAnthropicProxy(cfg, &mockKeyProvider{key: "test-key"}, nil)
+	v, _ := NewVision(VisionConfig{}, visionCaps{"anthropic/claude-3-5-haiku": false})
+	p.SetVision(v)
+
+	rec := httptest.NewRecorder()
+	p.HandleMessages(rec, httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(`{"model":"claude-3-5-haiku","max_tokens":10,
+		"messages":[{"role":"user","content":[{"type":"image","source":{"type":"url","url":"https://example.com/a.png"}}]}]}`)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "does not accept images") {
+		t.Errorf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
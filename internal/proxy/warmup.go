@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// UpstreamURL returns the URL chat completions for provider are sent to
+func (p *OpenAIProxy) UpstreamURL(provider string) string {
+	return p.getUpstreamURL(provider)
+}
+
+// WarmConnection opens a connection to the provider's upstream host through
+// the proxy's transport, leaving it idle for the first request
+func (p *OpenAIProxy) WarmConnection(ctx context.Context, provider string) error {
+	return warmConnection(ctx, p.httpClient, p.getUpstreamURL(provider), provider)
+}
+
+// WarmCompletion sends a 1-token completion for model with the provider's
+// key. It fails unless the provider answers 200.
+func (p *OpenAIProxy) WarmCompletion(ctx context.Context, provider, model string) error {
+	apiKey, err := p.keyProvider.GetKey(ctx, provider)
+	if err != nil {
+		return fmt.Errorf("no API key: %w", err)
+	}
+	return warmCompletion(ctx, p.httpClient, p.getUpstreamURL(provider), provider, pingBody(model), func(req *http.Request) {
+		p.setUpstreamHeaders(req, apiKey, provider)
+	})
+}
+
+// UpstreamURL returns the URL messages for provider are sent to
+func (p *AnthropicProxy) UpstreamURL(provider string) string {
+	return p.getUpstreamURL(provider)
+}
+
+// WarmConnection opens a connection to the provider's upstream host through
+// the proxy's transport, leaving it idle for the first request
+func (p *AnthropicProxy) WarmConnection(ctx context.Context, provider string) error {
+	return warmConnection(ctx, p.httpClient, p.getUpstreamURL(provider), provider)
+}
+
+// WarmCompletion sends a 1-token message for model with the provider's
+// key. It fails unless the provider answers 200.
+func (p *AnthropicProxy) WarmCompletion(ctx context.Context, provider, model string) error {
+	apiKey, err := p.keyProvider.GetKey(ctx, provider)
+	if err != nil {
+		return fmt.Errorf("no API key: %w", err)
+	}
+	return warmCompletion(ctx, p.httpClient, p.getUpstreamURL(provider), provider, pingBody(model), func(req *http.Request) {
+		p.setUpstreamHeaders(req, apiKey, provider)
+	})
+}
+
+// pingBody is a 1-token request both the Chat Completions and Messages
+// APIs accept
+func pingBody(model string) []byte {
+	body, _ := json.Marshal(map[string]interface{}{
+		"model":      model,
+		"max_tokens": 1,
+		"messages":   []map[string]string{{"role": "user", "content": "ping"}},
+	})
+	return body
+}
+
+// warmConnection sends a HEAD request to the root of upstream's host. Any
+// response means DNS, TCP and TLS are done and the connection is pooled.
+func warmConnection(ctx context.Context, client *http.Client, upstream, provider string) error {
+	u, err := url.Parse(upstream)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid upstream URL %q", upstream)
+	}
+	root := u.Scheme + "://" + u.Host + "/"
+	req, err := http.NewRequestWithContext(mshttp.WithProvider(ctx, provider), http.MethodHead, root, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	// Reading to the end returns the connection to the idle pool
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// warmCompletion posts body to upstream with the headers setHeaders adds
+func warmCompletion(ctx context.Context, client *http.Client, upstream, provider string, body []byte, setHeaders func(*http.Request)) error {
+	req, err := http.NewRequestWithContext(mshttp.WithProvider(ctx, provider), http.MethodPost, upstream, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	setHeaders(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode != http.StatusOK {
+		msg := strings.TrimSpace(string(data))
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		return fmt.Errorf("%s returned %d: %s", upstream, resp.StatusCode, msg)
+	}
+	return nil
+}
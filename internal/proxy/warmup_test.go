@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+func TestWarmUp(t *testing.T) {
+	var requests []string
+	var body map[string]interface{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization")+r.Header.Get("x-api-key"))
+		if r.Method == http.MethodPost {
+			json.NewDecoder(r.Body).Decode(&body)
+		}
+		if r.URL.Path == "/" {
+			w.WriteHeader(http.StatusNotFound) // Any answer means the connection is open
+			return
+		}
+		if body["model"] == "retired" {
+			http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	pool, err := mshttp.NewPool(mshttp.TransportConfig{}, nil)
+	if err != nil {
+		t.Fatalf("NewPool failed: %v", err)
+	}
+	cfg := DefaultOpenAIProxyConfig()
+	cfg.OpenAIBaseURL = upstream.URL
+	p := NewOpenAIProxy(cfg, &mockKeyProvider{key: "sk-test"}, nil)
+	p.SetTransport(pool)
+
+	ctx := context.Background()
+	if err := p.WarmConnection(ctx, "openai"); err != nil {
+		t.Fatalf("WarmConnection failed: %v", err)
+	}
+	if err := p.WarmCompletion(ctx, "openai", "gpt-4o-mini"); err != nil {
+		t.Fatalf("WarmCompletion failed: %v", err)
+	}
+	if len(requests) != 2 || requests[0] != "HEAD / " || requests[1] != "POST /v1/chat/completions Bearer sk-test" {
+		t.Errorf("unexpected upstream requests: %q", requests)
+	}
+	if body["model"] != "gpt-4o-mini" || body["max_tokens"] != float64(1) {
+		t.Errorf("unexpected completion body: %v", body)
+	}
+	// The completion reused the warmed connection from the provider's pool
+	if stats := pool.Stats(); len(stats) != 1 || stats[0].Name != "openai" || stats[0].ConnsDialed != 1 || stats[0].ConnsReused != 1 {
+		t.Errorf("expected one reused connection, got %+v", stats)
+	}
+
+	if err := p.WarmCompletion(ctx, "openai", "retired"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected the failed completion to be reported, got %v", err)
+	}
+
+	acfg := DefaultAnthropicProxyConfig()
+	acfg.AnthropicBaseURL = upstream.URL
+	a := NewAnthropicProxy(acfg, &mockKeyProvider{key: "sk-ant"}, nil)
+	requests = nil
+	if err := a.WarmCompletion(ctx, "anthropic", "claude-haiku"); err != nil || len(requests) != 1 || requests[0] != "POST /v1/messages sk-ant" {
+		t.Errorf("unexpected anthropic warm-up: %v %q", err, requests)
+	}
+	if got := a.UpstreamURL("anthropic"); got != upstream.URL+"/v1/messages" {
+		t.Errorf("UpstreamURL = %q", got)
+	}
+}
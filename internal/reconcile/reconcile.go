@@ -0,0 +1,336 @@
+// Package reconcile checks provider invoices against the cost ledger. It
+// reads the usage or cost CSV a provider's console exports, totals it by
+// day and model, and sets each total beside what the ledger recorded for
+// the same provider, flagging the differences too large to be rounding.
+//
+// Exports name their columns differently and change them from time to
+// time, so columns are found by header from a list of known names rather
+// than by position. Models are matched without their date suffixes, since
+// invoices name dated snapshots ("gpt-4o-2024-08-06") where requests
+// often used the alias. Days are UTC days, as providers bill them.
+package reconcile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// Default thresholds a difference must pass both of to be a discrepancy
+const (
+	DefaultThresholdPercent = 5
+	DefaultThresholdUSD     = 0.01
+)
+
+// Format describes a provider's CSV export by the header names its
+// columns go by
+type Format struct {
+	Name     string
+	Provider string // The provider the ledger is read for
+	Date     []string
+	Model    []string
+	Cost     []string
+}
+
+// Formats are the exports that can be imported, by name
+var Formats = map[string]Format{
+	"openai": {
+		Name:     "openai",
+		Provider: "openai",
+		Date:     []string{"date", "start_time_iso", "start_time", "timestamp"},
+		Model:    []string{"model", "line_item", "snapshot_id"},
+		Cost:     []string{"cost", "amount_value", "cost_usd", "amount"},
+	},
+	"anthropic": {
+		Name:     "anthropic",
+		Provider: "anthropic",
+		Date:     []string{"usage_date_utc", "date", "usage_date", "starting_at"},
+		Model:    []string{"model", "model_name"},
+		Cost:     []string{"cost_usd", "cost", "amount_usd", "amount"},
+	},
+}
+
+// Options tune a reconciliation. Zero thresholds take their defaults.
+type Options struct {
+	Provider         string  // Overrides the format's provider, e.g. for a reseller
+	ThresholdPercent float64 // Of the larger amount
+	ThresholdUSD     float64
+}
+
+// Ledger reads the cost ledger (usually a *database.TenantRepository)
+type Ledger interface {
+	DailySpend(since time.Time) ([]*database.TenantDailySpend, error)
+}
+
+// Line compares the invoiced and recorded cost of one model on one day
+type Line struct {
+	Day         time.Time `json:"day"`
+	Model       string    `json:"model"`
+	Invoiced    float64   `json:"invoiced"`
+	Recorded    float64   `json:"recorded"`
+	Difference  float64   `json:"difference"` // Invoiced less recorded
+	Percent     float64   `json:"percent"`    // Of the larger amount
+	Discrepancy bool      `json:"discrepancy"`
+}
+
+// Report is the reconciliation of one invoice export
+type Report struct {
+	Format           string    `json:"format"`
+	Provider         string    `json:"provider"`
+	From             time.Time `json:"from"` // First day invoiced
+	To               time.Time `json:"to"`   // Last day invoiced
+	Invoiced         float64   `json:"invoiced"`
+	Recorded         float64   `json:"recorded"`
+	Difference       float64   `json:"difference"`
+	Percent          float64   `json:"percent"`
+	ThresholdPercent float64   `json:"threshold_percent"`
+	ThresholdUSD     float64   `json:"threshold_usd"`
+	Rows             int       `json:"rows"`    // Invoice rows read
+	Skipped          int       `json:"skipped"` // Rows without a date or cost, such as subtotals
+	Discrepancies    int       `json:"discrepancies"`
+	Lines            []Line    `json:"lines"` // By day, then model
+}
+
+// Parsed is an invoice export totalled by UTC day and model
+type Parsed struct {
+	Costs   map[time.Time]map[string]float64
+	Rows    int
+	Skipped int
+}
+
+// Parse reads a CSV export in a format
+func Parse(r io.Reader, f Format) (*Parsed, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("empty export")
+		}
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	dateCol, modelCol, costCol := column(header, f.Date), column(header, f.Model), column(header, f.Cost)
+	switch {
+	case dateCol < 0:
+		return nil, fmt.Errorf("no date column (want one of %s)", strings.Join(f.Date, ", "))
+	case modelCol < 0:
+		return nil, fmt.Errorf("no model column (want one of %s)", strings.Join(f.Model, ", "))
+	case costCol < 0:
+		return nil, fmt.Errorf("no cost column (want one of %s)", strings.Join(f.Cost, ", "))
+	}
+
+	p := &Parsed{Costs: make(map[time.Time]map[string]float64)}
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		p.Rows++
+		field := func(i int) string {
+			if i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		rawDate, rawCost := field(dateCol), strings.TrimPrefix(field(costCol), "$")
+		if rawDate == "" || rawCost == "" {
+			p.Skipped++
+			continue
+		}
+		day, err := parseDay(rawDate)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		cost, err := strconv.ParseFloat(strings.ReplaceAll(rawCost, ",", ""), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid cost %q", line, rawCost)
+		}
+		model := normalizeModel(field(modelCol))
+		if p.Costs[day] == nil {
+			p.Costs[day] = make(map[string]float64)
+		}
+		p.Costs[day][model] += cost
+	}
+	if len(p.Costs) == 0 {
+		return nil, fmt.Errorf("no billed rows in the export")
+	}
+	return p, nil
+}
+
+// column finds the first of names in a header, ignoring case
+func column(header, names []string) int {
+	for _, name := range names {
+		for i, h := range header {
+			// Excel prepends a byte order mark to the first header
+			if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")), name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// dateLayouts are the date formats exports use
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02", "01/02/2006"}
+
+// parseDay reads a date, date-time or Unix timestamp as its UTC day
+func parseDay(s string) (time.Time, error) {
+	var t time.Time
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		t = time.Unix(secs, 0)
+	} else {
+		var parsed bool
+		for _, layout := range dateLayouts {
+			if t, err = time.Parse(layout, s); err == nil {
+				parsed = true
+				break
+			}
+		}
+		if !parsed {
+			return time.Time{}, fmt.Errorf("invalid date %q", s)
+		}
+	}
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// dateSuffix matches the snapshot date providers append to model names
+var dateSuffix = regexp.MustCompile(`-(\d{4}-\d{2}-\d{2}|\d{8})$`)
+
+// normalizeModel reduces a model or invoice line item to the model it
+// bills: "gpt-4o-2024-08-06, input" and "gpt-4o" are both gpt-4o
+func normalizeModel(s string) string {
+	if i := strings.IndexAny(s, ",|"); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.ToLower(strings.TrimSpace(s))
+	return dateSuffix.ReplaceAllString(s, "")
+}
+
+// Reconcile compares a parsed export with the ledger's spend at the
+// provider over the days it covers
+func Reconcile(p *Parsed, f Format, ledger Ledger, opts Options) (*Report, error) {
+	report := &Report{
+		Format:           f.Name,
+		Provider:         f.Provider,
+		ThresholdPercent: opts.ThresholdPercent,
+		ThresholdUSD:     opts.ThresholdUSD,
+		Rows:             p.Rows,
+		Skipped:          p.Skipped,
+		Lines:            []Line{},
+	}
+	if opts.Provider != "" {
+		report.Provider = opts.Provider
+	}
+	if report.ThresholdPercent <= 0 {
+		report.ThresholdPercent = DefaultThresholdPercent
+	}
+	if report.ThresholdUSD <= 0 {
+		report.ThresholdUSD = DefaultThresholdUSD
+	}
+
+	for day := range p.Costs {
+		if report.From.IsZero() || day.Before(report.From) {
+			report.From = day
+		}
+		if day.After(report.To) {
+			report.To = day
+		}
+	}
+	spend, err := ledger.DailySpend(report.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the ledger: %w", err)
+	}
+	recorded := make(map[time.Time]map[string]float64)
+	for _, s := range spend {
+		day := s.Day.UTC()
+		if s.Provider != report.Provider || day.After(report.To) {
+			continue
+		}
+		if recorded[day] == nil {
+			recorded[day] = make(map[string]float64)
+		}
+		recorded[day][normalizeModel(s.Model)] += s.Cost
+	}
+
+	type key struct {
+		day   time.Time
+		model string
+	}
+	amounts := make(map[key]*Line)
+	add := func(costs map[time.Time]map[string]float64, invoiced bool) {
+		for day, models := range costs {
+			for model, cost := range models {
+				l, ok := amounts[key{day, model}]
+				if !ok {
+					l = &Line{Day: day, Model: model}
+					amounts[key{day, model}] = l
+				}
+				if invoiced {
+					l.Invoiced += cost
+				} else {
+					l.Recorded += cost
+				}
+			}
+		}
+	}
+	add(p.Costs, true)
+	add(recorded, false)
+
+	for _, l := range amounts {
+		l.Difference, l.Percent, l.Discrepancy = report.judge(l.Invoiced, l.Recorded)
+		if l.Discrepancy {
+			report.Discrepancies++
+		}
+		report.Invoiced += l.Invoiced
+		report.Recorded += l.Recorded
+		report.Lines = append(report.Lines, *l)
+	}
+	report.Difference, report.Percent, _ = report.judge(report.Invoiced, report.Recorded)
+	sort.Slice(report.Lines, func(i, j int) bool {
+		a, b := report.Lines[i], report.Lines[j]
+		if !a.Day.Equal(b.Day) {
+			return a.Day.Before(b.Day)
+		}
+		return a.Model < b.Model
+	})
+	return report, nil
+}
+
+// judge works out how far two amounts differ and whether that passes both
+// thresholds
+func (r *Report) judge(invoiced, recorded float64) (difference, percent float64, discrepancy bool) {
+	difference = invoiced - recorded
+	if larger := math.Max(math.Abs(invoiced), math.Abs(recorded)); larger > 0 {
+		percent = math.Abs(difference) / larger * 100
+	}
+	return difference, percent, math.Abs(difference) > r.ThresholdUSD && percent > r.ThresholdPercent
+}
+
+// Import parses an export in the named format and reconciles it with the
+// ledger
+func Import(r io.Reader, format string, ledger Ledger, opts Options) (*Report, error) {
+	f, ok := Formats[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q (want openai or anthropic)", format)
+	}
+	p, err := Parse(r, f)
+	if err != nil {
+		return nil, err
+	}
+	return Reconcile(p, f, ledger, opts)
+}
@@ -0,0 +1,158 @@
+package reconcile
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+type fakeLedger struct {
+	spend []*database.TenantDailySpend
+	since time.Time
+}
+
+func (l *fakeLedger) DailySpend(since time.Time) ([]*database.TenantDailySpend, error) {
+	l.since = since
+	var out []*database.TenantDailySpend
+	for _, s := range l.spend {
+		if !s.Day.Before(since) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func day(d int) time.Time {
+	return time.Date(2025, time.March, d, 0, 0, 0, 0, time.UTC)
+}
+
+func newLedger() *fakeLedger {
+	return &fakeLedger{spend: []*database.TenantDailySpend{
+		{Day: day(1), TenantID: "team-a", Provider: "openai", Model: "gpt-4o", Cost: 6},
+		{Day: day(1), TenantID: "team-b", Provider: "openai", Model: "gpt-4o", Cost: 4},
+		{Day: day(1), TenantID: "team-a", Provider: "openai", Model: "gpt-4o-mini", Cost: 1},
+		{Day: day(1), TenantID: "team-a", Provider: "anthropic", Model: "claude-sonnet-4-20250514", Cost: 3},
+		{Day: day(2), TenantID: "team-a", Provider: "openai", Model: "gpt-4o", Cost: 5},
+		{Day: day(2), TenantID: "team-a", Provider: "openai", Model: "o3", Cost: 2},
+		{Day: day(3), TenantID: "team-a", Provider: "openai", Model: "gpt-4o", Cost: 50}, // After the invoice
+	}}
+}
+
+func find(t *testing.T, r *Report, d int, model string) Line {
+	t.Helper()
+	for _, l := range r.Lines {
+		if l.Day.Equal(day(d)) && l.Model == model {
+			return l
+		}
+	}
+	t.Fatalf("no line for %s on the %d in %+v", model, d, r.Lines)
+	return Line{}
+}
+
+func TestImport_OpenAI(t *testing.T) {
+	export := "\ufeffstart_time,end_time,project_id,line_item,amount_value,amount_currency\n" +
+		"1740787200,1740873600,proj_1,\"gpt-4o-2024-08-06, input\",7.00,usd\n" +
+		"1740787200,1740873600,proj_1,\"gpt-4o-2024-08-06, output\",3.004,usd\n" +
+		"1740787200,1740873600,proj_1,\"gpt-4o-mini-2024-07-18, input\",1.50,usd\n" +
+		"1740873600,1740960000,proj_1,\"gpt-4o-2024-08-06, input\",5.00,usd\n" +
+		"1740873600,1740960000,proj_1,\"gpt-4.1, input\",0.40,usd\n" +
+		",,,Total,16.904,usd\n"
+
+	ledger := newLedger()
+	r, err := Import(strings.NewReader(export), "openai", ledger, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Provider != "openai" || !r.From.Equal(day(1)) || !r.To.Equal(day(2)) || !ledger.since.Equal(day(1)) {
+		t.Errorf("unexpected range: %+v", r)
+	}
+	if r.Rows != 6 || r.Skipped != 1 {
+		t.Errorf("expected the total row skipped, got %d rows, %d skipped", r.Rows, r.Skipped)
+	}
+	if r.ThresholdPercent != DefaultThresholdPercent || r.ThresholdUSD != DefaultThresholdUSD {
+		t.Errorf("expected default thresholds, got %+v", r)
+	}
+
+	// Dated snapshots and line items match the ledger's models, and every
+	// tenant's spend counts
+	if l := find(t, r, 1, "gpt-4o"); l.Invoiced != 10.004 || l.Recorded != 10 || l.Discrepancy {
+		t.Errorf("expected rounding to pass, got %+v", l)
+	}
+	if l := find(t, r, 1, "gpt-4o-mini"); l.Difference != 0.5 || math.Abs(l.Percent-100.0/3) > 1e-9 || !l.Discrepancy {
+		t.Errorf("expected an overcharge, got %+v", l)
+	}
+	if l := find(t, r, 2, "gpt-4.1"); l.Recorded != 0 || !l.Discrepancy {
+		t.Errorf("expected an unrecorded model flagged, got %+v", l)
+	}
+	if l := find(t, r, 2, "o3"); l.Invoiced != 0 || l.Difference != -2 || !l.Discrepancy {
+		t.Errorf("expected an uninvoiced model flagged, got %+v", l)
+	}
+	if r.Discrepancies != 3 || len(r.Lines) != 5 {
+		t.Errorf("expected 3 discrepancies in 5 lines, got %d in %+v", r.Discrepancies, r.Lines)
+	}
+	if math.Abs(r.Invoiced-16.904) > 1e-9 || r.Recorded != 18 {
+		t.Errorf("unexpected totals: invoiced %v, recorded %v", r.Invoiced, r.Recorded)
+	}
+	if r.Lines[0].Day.After(r.Lines[len(r.Lines)-1].Day) || r.Lines[0].Model != "gpt-4o" {
+		t.Errorf("expected lines by day and model, got %+v", r.Lines)
+	}
+}
+
+func TestImport_Anthropic(t *testing.T) {
+	export := "usage_date_utc,model,workspace,api_key,usage_type,token_type,cost_usd\n" +
+		"2025-03-01,claude-sonnet-4-20250514,Default,ci,message,input_no_cache,2.00\n" +
+		"2025-03-01,claude-sonnet-4-20250514,Default,ci,message,output,$1.10\n"
+
+	r, err := Import(strings.NewReader(export), "anthropic", newLedger(), Options{ThresholdPercent: 10, ThresholdUSD: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := find(t, r, 1, "claude-sonnet-4")
+	if l.Invoiced != 3.1 || l.Recorded != 3 || l.Discrepancy || r.Discrepancies != 0 {
+		t.Errorf("expected a match within the thresholds, got %+v", l)
+	}
+
+	// A reseller's export is checked against its own provider
+	r, err = Import(strings.NewReader(export), "anthropic", newLedger(), Options{Provider: "bedrock"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Provider != "bedrock" || r.Recorded != 0 || r.Discrepancies != 1 {
+		t.Errorf("expected nothing recorded for bedrock, got %+v", r)
+	}
+}
+
+func TestImport_Errors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		format, export, want string
+	}{
+		"unknown format": {"azure", "date,model,cost\n", "unknown format"},
+		"empty":          {"openai", "", "empty export"},
+		"no cost column": {"openai", "date,model,tokens\n2025-03-01,gpt-4o,10\n", "no cost column"},
+		"bad date":       {"openai", "date,model,cost\nyesterday,gpt-4o,1\n", `line 2: invalid date "yesterday"`},
+		"bad cost":       {"openai", "date,model,cost\n2025-03-01,gpt-4o,lots\n", `line 2: invalid cost "lots"`},
+		"nothing billed": {"openai", "date,model,cost\n,,\n", "no billed rows"},
+	} {
+		_, err := Import(strings.NewReader(tc.export), tc.format, newLedger(), Options{})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected %q, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestNormalizeModel(t *testing.T) {
+	for in, want := range map[string]string{
+		"gpt-4o-2024-08-06, input":  "gpt-4o",
+		"GPT-4o":                    "gpt-4o",
+		"claude-3-5-haiku-20241022": "claude-3-5-haiku",
+		"o3 | batch":                "o3",
+		"gpt-4.1":                   "gpt-4.1",
+	} {
+		if got := normalizeModel(in); got != want {
+			t.Errorf("normalizeModel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Package redact masks credentials in text before it is logged, exported,
+// sent to webhooks or written to disk.
+//
+// It recognizes key formats by shape rather than by value, so it also
+// catches keys modelscan was never given, such as ones echoed back in
+// provider error messages:
+//
+//	redact.String("Incorrect API key provided: sk-proj-abc...xyz")
+//	// "Incorrect API key provided: sk-proj-[REDACTED]"
+//
+// A recognizable prefix (sk-ant-, AKIA, Bearer) is kept so the kind of
+// credential is still visible.
+package redact
+
+import (
+	"io"
+	"regexp"
+)
+
+// Mask replaces the secret part of a credential
+const Mask = "[REDACTED]"
+
+// pattern matches a credential; the replacement keeps its first submatch
+// (and the second, for key: value pairs) and masks the rest
+type pattern struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+var patterns = []pattern{
+	// Authorization: Bearer <token>
+	{regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9\-._~+/]{12,}=*`), "${1}" + Mask},
+	// OpenAI (sk-, sk-proj-) and Anthropic (sk-ant-api03-) keys
+	{regexp.MustCompile(`\b(sk-(?:ant-|proj-)?)[A-Za-z0-9_\-]{16,}`), "${1}" + Mask},
+	// Groq, xAI, Hugging Face and Google API keys
+	{regexp.MustCompile(`\b(gsk_|xai-|hf_)[A-Za-z0-9]{20,}`), "${1}" + Mask},
+	{regexp.MustCompile(`\b(AIza)[0-9A-Za-z_\-]{35}`), "${1}" + Mask},
+	// AWS access key IDs and secret access keys
+	{regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`), "${1}" + Mask},
+	{regexp.MustCompile(`(?i)\b(aws_secret_access_key|aws_secret_key|secret_access_key|secretaccesskey)(["']?\s*[:=]\s*["']?)[A-Za-z0-9/+=]{40}`), "${1}${2}" + Mask},
+	// api_key=..., "x-api-key": "...", X-Goog-Api-Key: ...
+	{regexp.MustCompile(`(?i)\b(x-api-key|x-goog-api-key|api[_-]?key)(["']?\s*[:=]\s*["']?)[^\s"'&,;]{12,}`), "${1}${2}" + Mask},
+}
+
+// String masks every credential in s
+func String(s string) string {
+	for _, p := range patterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+	return s
+}
+
+// Bytes masks every credential in b. The result is safe to embed in JSON
+// when b is JSON.
+func Bytes(b []byte) []byte {
+	for _, p := range patterns {
+		b = p.re.ReplaceAll(b, []byte(p.repl))
+	}
+	return b
+}
+
+// writer masks credentials in each write
+type writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a writer that masks credentials before writing to w.
+// Each Write is masked on its own, so it suits writers that receive whole
+// lines, such as the log package's output; a credential split across two
+// writes is not recognized.
+func NewWriter(w io.Writer) io.Writer {
+	return &writer{w: w}
+}
+
+// Write masks p and writes it, reporting len(p) on success so callers that
+// check the count are not confused by the shorter masked output
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := w.w.Write(Bytes(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,479 @@
+// Package remap resolves requested model names to concrete upstream models
+// using aliases and remap rules stored in the database.
+package remap
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// Strategies supported by remap rules
+const (
+	StrategyDirect   = database.RemapStrategyDirect
+	StrategyCheapest = database.RemapStrategyCheapest
+	StrategyFastest  = database.RemapStrategyFastest
+)
+
+// Rule is a remap rule as seen by the engine
+type Rule struct {
+	ID         int
+	ClientID   string // Empty for global rules
+	FromModel  string // Exact name or glob pattern ("gpt-4*")
+	ToModel    string
+	ToProvider string
+	Strategy   string
+	Candidates []string // "provider/model" entries for the cheapest and fastest strategies
+	Priority   int
+}
+
+// Alias maps a short model name to a model ID
+type Alias struct {
+	Name     string
+	ModelID  string
+	ClientID string // Empty for global aliases
+}
+
+// Database interface for loading remap configuration
+type Database interface {
+	ListEnabledRemapRules() ([]*Rule, error)
+	ListAliases() ([]*Alias, error)
+	// ListModelPrices returns blended per-1M-token prices keyed by model ID
+	ListModelPrices() (map[string]float64, error)
+}
+
+// Availability reports providers taken out of rotation for maintenance
+type Availability interface {
+	Unavailable(provider string) (until time.Time, unavailable bool)
+}
+
+// LatencyHistory reports a provider's usual latency at a time of the week
+type LatencyHistory interface {
+	Expected(provider string, at time.Time) (latency time.Duration, known bool)
+}
+
+// Config holds engine configuration
+type Config struct {
+	// ReloadInterval is how often rules are re-read from the database.
+	// Zero disables periodic reloads; Reload can still be called directly.
+	ReloadInterval time.Duration
+}
+
+// DefaultConfig returns sensible defaults
+func DefaultConfig() Config {
+	return Config{
+		ReloadInterval: 30 * time.Second,
+	}
+}
+
+// Resolution describes how a requested model was resolved
+type Resolution struct {
+	OriginalModel string `json:"original_model"`
+	Model         string `json:"model"`
+	Provider      string `json:"provider,omitempty"`
+	Alias         string `json:"alias,omitempty"`
+	RuleID        int    `json:"rule_id,omitempty"`
+	Strategy      string `json:"strategy,omitempty"`
+}
+
+// Candidate is an option a cheapest or fastest rule weighed
+type Candidate struct {
+	Provider   string  `json:"provider,omitempty"`
+	Model      string  `json:"model"`
+	Price      float64 `json:"price,omitempty"`      // Blended per-1M-token price; the lowest wins for cheapest
+	LatencyMs  float64 `json:"latency_ms,omitempty"` // Expected time to first byte; the lowest wins for fastest
+	Selected   bool    `json:"selected,omitempty"`
+	Eliminated string  `json:"eliminated,omitempty"` // Why it was passed over
+}
+
+// Explanation is a Resolution with the steps that led to it
+type Explanation struct {
+	Resolution
+	Trace      []string    `json:"trace"`
+	Candidates []Candidate `json:"candidates,omitempty"` // For the cheapest and fastest strategies
+}
+
+// snapshot is an immutable view of the rule set, swapped atomically on reload
+type snapshot struct {
+	clientRules   map[string][]*Rule
+	globalRules   []*Rule
+	clientAliases map[string]map[string]string
+	globalAliases map[string]string
+	prices        map[string]float64
+	loadedAt      time.Time
+}
+
+// Engine resolves models against an in-memory copy of the remap rules.
+// It implements proxy.ModelRemapper.
+type Engine struct {
+	db           Database
+	config       Config
+	current      atomic.Pointer[snapshot]
+	availability Availability   // Optional; skips drained cheapest and fastest candidates
+	latency      LatencyHistory // Optional; required to pick fastest candidates
+	now          func() time.Time
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+	reloadMu sync.Mutex
+}
+
+// NewEngine creates an engine and performs the initial load.
+// A background reload loop is started when ReloadInterval is positive.
+func NewEngine(db Database, cfg Config) (*Engine, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is required")
+	}
+
+	e := &Engine{
+		db:     db,
+		config: cfg,
+		now:    time.Now,
+		stopCh: make(chan struct{}),
+	}
+	e.current.Store(&snapshot{})
+
+	if err := e.Reload(); err != nil {
+		return nil, err
+	}
+
+	if cfg.ReloadInterval > 0 {
+		e.wg.Add(1)
+		go e.reloadLoop()
+	}
+
+	return e, nil
+}
+
+// Reload re-reads rules, aliases and prices and atomically swaps them in.
+// On error the previous rule set stays active.
+func (e *Engine) Reload() error {
+	e.reloadMu.Lock()
+	defer e.reloadMu.Unlock()
+
+	rules, err := e.db.ListEnabledRemapRules()
+	if err != nil {
+		return fmt.Errorf("failed to load remap rules: %w", err)
+	}
+	aliases, err := e.db.ListAliases()
+	if err != nil {
+		return fmt.Errorf("failed to load aliases: %w", err)
+	}
+	prices, err := e.db.ListModelPrices()
+	if err != nil {
+		return fmt.Errorf("failed to load model prices: %w", err)
+	}
+
+	snap := &snapshot{
+		clientRules:   make(map[string][]*Rule),
+		clientAliases: make(map[string]map[string]string),
+		globalAliases: make(map[string]string),
+		prices:        prices,
+		loadedAt:      time.Now(),
+	}
+
+	// Rules arrive ordered by priority; preserve that order per bucket
+	for _, rule := range rules {
+		if rule.ClientID == "" {
+			snap.globalRules = append(snap.globalRules, rule)
+		} else {
+			snap.clientRules[rule.ClientID] = append(snap.clientRules[rule.ClientID], rule)
+		}
+	}
+
+	for _, alias := range aliases {
+		if alias.ClientID == "" {
+			snap.globalAliases[alias.Name] = alias.ModelID
+			continue
+		}
+		if snap.clientAliases[alias.ClientID] == nil {
+			snap.clientAliases[alias.ClientID] = make(map[string]string)
+		}
+		snap.clientAliases[alias.ClientID][alias.Name] = alias.ModelID
+	}
+
+	e.current.Store(snap)
+	return nil
+}
+
+// SetAvailability makes the cheapest and fastest strategies pass over
+// candidates whose provider is drained or in a maintenance window
+func (e *Engine) SetAvailability(a Availability) {
+	e.availability = a
+}
+
+// SetLatencyHistory sets the latency the fastest strategy compares. Without
+// it fastest rules fall back to their target.
+func (e *Engine) SetLatencyHistory(h LatencyHistory) {
+	e.latency = h
+}
+
+// LoadedAt returns when the active rule set was loaded
+func (e *Engine) LoadedAt() time.Time {
+	return e.current.Load().loadedAt
+}
+
+// Resolve applies aliases and then the first matching rule.
+// Client-specific aliases and rules take precedence over global ones.
+func (e *Engine) Resolve(model, clientID string) *Resolution {
+	return &e.resolve(model, clientID, false).Resolution
+}
+
+// Explain resolves a model like Resolve, recording each step and, for the
+// cheapest and fastest strategies, every candidate with its price or
+// latency or why it was skipped
+func (e *Engine) Explain(model, clientID string) *Explanation {
+	return e.resolve(model, clientID, true)
+}
+
+func (e *Engine) resolve(model, clientID string, explain bool) *Explanation {
+	snap := e.current.Load()
+	ex := &Explanation{Resolution: Resolution{OriginalModel: model, Model: model}}
+	res := &ex.Resolution
+	trace := func(format string, args ...interface{}) {
+		if explain {
+			ex.Trace = append(ex.Trace, fmt.Sprintf(format, args...))
+		}
+	}
+
+	// Aliases: client override first, then global
+	if target, ok := snap.clientAliases[clientID][model]; ok {
+		res.Alias = model
+		res.Model = target
+		trace("client alias %s -> %s", model, target)
+	} else if target, ok := snap.globalAliases[model]; ok {
+		res.Alias = model
+		res.Model = target
+		trace("global alias %s -> %s", model, target)
+	}
+
+	resolved := res.Model
+	rule := snap.match(resolved, clientID)
+	if rule == nil {
+		trace("no remap rule matches %s", resolved)
+		return ex
+	}
+
+	res.RuleID = rule.ID
+	res.Strategy = rule.Strategy
+	res.Model, res.Provider = rule.ToModel, rule.ToProvider
+	scope := "global"
+	if rule.ClientID != "" {
+		scope = "client"
+	}
+	trace("%s rule %d (%s, priority %d) matches %s with %q", scope, rule.ID, rule.Strategy, rule.Priority, resolved, rule.FromModel)
+
+	if rule.Strategy == StrategyCheapest {
+		candidates := snap.weigh(rule.Candidates, e.availability)
+		for _, c := range candidates {
+			if c.Selected {
+				res.Model, res.Provider = c.Model, c.Provider
+				trace("cheapest of %d candidates is %s/%s at %g per 1M tokens", len(candidates), c.Provider, c.Model, c.Price)
+			}
+		}
+		if explain {
+			ex.Candidates = candidates
+		}
+	}
+	if rule.Strategy == StrategyFastest {
+		candidates := weighLatency(rule.Candidates, e.latency, e.availability, e.now())
+		for _, c := range candidates {
+			if c.Selected {
+				res.Model, res.Provider = c.Model, c.Provider
+				trace("fastest of %d candidates at this hour is %s/%s at %.0fms", len(candidates), c.Provider, c.Model, c.LatencyMs)
+			}
+		}
+		if explain {
+			ex.Candidates = candidates
+		}
+	}
+
+	// A cheapest or fastest rule without a usable candidate or fallback
+	// leaves the model unchanged
+	if res.Model == "" {
+		res.Model, res.Provider = resolved, ""
+		trace("no usable candidate and no fallback target; keeping %s", resolved)
+	} else if res.Provider != "" {
+		trace("routed to %s on %s", res.Model, res.Provider)
+	} else {
+		trace("routed to %s", res.Model)
+	}
+
+	return ex
+}
+
+// Names returns the names clients can request besides the models
+// themselves: aliases and the exact names remap rules match, including
+// clientID's own. Glob rules are left out since they name no model.
+func (e *Engine) Names(clientID string) []string {
+	snap := e.current.Load()
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if name != "" && !seen[name] && !strings.ContainsAny(name, "*?") {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if clientID != "" {
+		for name := range snap.clientAliases[clientID] {
+			add(name)
+		}
+		for _, rule := range snap.clientRules[clientID] {
+			add(rule.FromModel)
+		}
+	}
+	for name := range snap.globalAliases {
+		add(name)
+	}
+	for _, rule := range snap.globalRules {
+		add(rule.FromModel)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RemapModel implements proxy.ModelRemapper
+func (e *Engine) RemapModel(ctx context.Context, model string, clientID string) (remappedModel, targetProvider string, err error) {
+	res := e.Resolve(model, clientID)
+	return res.Model, res.Provider, nil
+}
+
+// Close stops the background reload loop
+func (e *Engine) Close() error {
+	e.stopOnce.Do(func() { close(e.stopCh) })
+	e.wg.Wait()
+	return nil
+}
+
+// reloadLoop periodically reloads rules so database changes apply without restart
+func (e *Engine) reloadLoop() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.config.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = crash.Do("remap reload", func() {
+				if err := e.Reload(); err != nil {
+					log.Printf("remap: reload failed, keeping previous rules: %v", err)
+				}
+			})
+		case <-e.stopCh:
+			return
+		}
+	}
+}
+
+// match returns the first matching rule, checking client rules before global rules
+func (s *snapshot) match(model, clientID string) *Rule {
+	if clientID != "" {
+		for _, rule := range s.clientRules[clientID] {
+			if database.MatchGlob(rule.FromModel, model) {
+				return rule
+			}
+		}
+	}
+	for _, rule := range s.globalRules {
+		if database.MatchGlob(rule.FromModel, model) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// weigh prices the candidates of a cheapest rule and selects the
+// lowest-priced one. Candidates without a known price or on an unavailable
+// provider are eliminated; ties keep the earlier candidate.
+func (s *snapshot) weigh(candidates []string, availability Availability) []Candidate {
+	weighed := make([]Candidate, len(candidates))
+	best := -1
+	for i, candidate := range candidates {
+		c := &weighed[i]
+		c.Provider, c.Model = splitCandidate(candidate)
+		price, priced := s.prices[c.Model]
+		if !priced {
+			c.Eliminated = "no known price"
+			continue
+		}
+		c.Price = price
+		if availability != nil && c.Provider != "" {
+			if _, down := availability.Unavailable(c.Provider); down {
+				c.Eliminated = "provider unavailable"
+				continue
+			}
+		}
+		if best < 0 || price < weighed[best].Price {
+			best = i
+		}
+	}
+	for i := range weighed {
+		if i == best {
+			weighed[i].Selected = true
+		} else if weighed[i].Eliminated == "" {
+			weighed[i].Eliminated = fmt.Sprintf("more expensive than %s", weighed[best].Model)
+		}
+	}
+	return weighed
+}
+
+// weighLatency selects the candidate of a fastest rule whose provider has
+// the lowest expected latency at the hour of the week of at. Candidates
+// without enough history for that hour or on an unavailable provider are
+// eliminated; ties keep the earlier candidate.
+func weighLatency(candidates []string, history LatencyHistory, availability Availability, at time.Time) []Candidate {
+	weighed := make([]Candidate, len(candidates))
+	best := -1
+	for i, candidate := range candidates {
+		c := &weighed[i]
+		c.Provider, c.Model = splitCandidate(candidate)
+		if history == nil {
+			c.Eliminated = "no latency history"
+			continue
+		}
+		latency, known := history.Expected(c.Provider, at)
+		if !known {
+			c.Eliminated = "no latency history for this hour"
+			continue
+		}
+		c.LatencyMs = float64(latency) / float64(time.Millisecond)
+		if availability != nil && c.Provider != "" {
+			if _, down := availability.Unavailable(c.Provider); down {
+				c.Eliminated = "provider unavailable"
+				continue
+			}
+		}
+		if best < 0 || c.LatencyMs < weighed[best].LatencyMs {
+			best = i
+		}
+	}
+	for i := range weighed {
+		if i == best {
+			weighed[i].Selected = true
+		} else if weighed[i].Eliminated == "" {
+			weighed[i].Eliminated = fmt.Sprintf("slower than %s", weighed[best].Provider)
+		}
+	}
+	return weighed
+}
+
+// splitCandidate splits "provider/model" on the first slash.
+// Model IDs may themselves contain slashes (e.g. "together/meta-llama/Llama-3").
+func splitCandidate(candidate string) (provider, model string) {
+	idx := strings.Index(candidate, "/")
+	if idx < 0 {
+		return "", candidate
+	}
+	return candidate[:idx], candidate[idx+1:]
+}
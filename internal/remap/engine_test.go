@@ -0,0 +1,436 @@
+package remap
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockDatabase is an in-memory Database for testing
+type mockDatabase struct {
+	mu      sync.Mutex
+	rules   []*Rule
+	aliases []*Alias
+	prices  map[string]float64
+	err     error
+	loads   int
+}
+
+func (m *mockDatabase) ListEnabledRemapRules() ([]*Rule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loads++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.rules, nil
+}
+
+func (m *mockDatabase) ListAliases() ([]*Alias, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.aliases, nil
+}
+
+func (m *mockDatabase) ListModelPrices() (map[string]float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.prices, nil
+}
+
+func (m *mockDatabase) setRules(rules []*Rule) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = rules
+}
+
+func (m *mockDatabase) loadCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.loads
+}
+
+func newTestEngine(t *testing.T, db *mockDatabase) *Engine {
+	t.Helper()
+	engine, err := NewEngine(db, Config{})
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	t.Cleanup(func() { engine.Close() })
+	return engine
+}
+
+func TestNewEngine_NilDatabase(t *testing.T) {
+	if _, err := NewEngine(nil, DefaultConfig()); err == nil {
+		t.Error("expected error for nil database")
+	}
+}
+
+func TestNewEngine_InitialLoadError(t *testing.T) {
+	db := &mockDatabase{err: errors.New("no such table")}
+	if _, err := NewEngine(db, Config{}); err == nil {
+		t.Error("expected error when initial load fails")
+	}
+}
+
+func TestEngine_Resolve(t *testing.T) {
+	db := &mockDatabase{
+		rules: []*Rule{
+			{ID: 1, ClientID: "client-a", FromModel: "gpt-4*", ToModel: "claude-sonnet-4-5", ToProvider: "anthropic", Strategy: StrategyDirect, Priority: 1},
+			{ID: 2, FromModel: "gpt-4o", ToModel: "gpt-4o-mini", ToProvider: "openai", Strategy: StrategyDirect, Priority: 20},
+			{ID: 3, FromModel: "gpt-4*", ToModel: "gpt-4.1", ToProvider: "openai", Strategy: StrategyDirect, Priority: 10},
+		},
+		aliases: []*Alias{
+			{Name: "fast", ModelID: "gpt-4o"},
+			{Name: "fast", ModelID: "mistral-small-latest", ClientID: "client-b"},
+		},
+	}
+	engine := newTestEngine(t, db)
+
+	tests := []struct {
+		name         string
+		model        string
+		clientID     string
+		wantModel    string
+		wantProvider string
+		wantRule     int
+	}{
+		{"no match passes through", "claude-opus-4", "", "claude-opus-4", "", 0},
+		{"exact global rule", "gpt-4o", "", "gpt-4o-mini", "openai", 2},
+		{"wildcard global rule", "gpt-4-turbo", "", "gpt-4.1", "openai", 3},
+		{"client rule overrides higher priority global", "gpt-4o", "client-a", "claude-sonnet-4-5", "anthropic", 1},
+		{"other client uses global rules", "gpt-4o", "client-z", "gpt-4o-mini", "openai", 2},
+		{"global alias then rule", "fast", "", "gpt-4o-mini", "openai", 2},
+		{"client alias overrides global alias", "fast", "client-b", "mistral-small-latest", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := engine.Resolve(tt.model, tt.clientID)
+			if res.Model != tt.wantModel {
+				t.Errorf("model = %q, want %q", res.Model, tt.wantModel)
+			}
+			if res.Provider != tt.wantProvider {
+				t.Errorf("provider = %q, want %q", res.Provider, tt.wantProvider)
+			}
+			if res.RuleID != tt.wantRule {
+				t.Errorf("rule = %d, want %d", res.RuleID, tt.wantRule)
+			}
+			if res.OriginalModel != tt.model {
+				t.Errorf("original model = %q, want %q", res.OriginalModel, tt.model)
+			}
+		})
+	}
+}
+
+func TestEngine_ResolveCheapest(t *testing.T) {
+	db := &mockDatabase{
+		rules: []*Rule{
+			{
+				ID:        1,
+				FromModel: "gpt-4*",
+				Strategy:  StrategyCheapest,
+				Candidates: []string{
+					"openai/gpt-4o",
+					"together/meta-llama/Llama-3.3-70B-Instruct-Turbo",
+					"unknown/unpriced-model",
+				},
+			},
+			{
+				ID:         2,
+				FromModel:  "o3*",
+				ToModel:    "o3-mini",
+				ToProvider: "openai",
+				Strategy:   StrategyCheapest,
+				Candidates: []string{"unknown/unpriced-model"},
+			},
+			{
+				ID:         3,
+				FromModel:  "claude-*",
+				Strategy:   StrategyCheapest,
+				Candidates: []string{"unknown/unpriced-model"},
+			},
+		},
+		prices: map[string]float64{
+			"gpt-4o": 12.50,
+			"meta-llama/Llama-3.3-70B-Instruct-Turbo": 1.76,
+		},
+	}
+	engine := newTestEngine(t, db)
+
+	res := engine.Resolve("gpt-4o", "")
+	if res.Model != "meta-llama/Llama-3.3-70B-Instruct-Turbo" || res.Provider != "together" {
+		t.Errorf("expected cheapest together model, got %s/%s", res.Provider, res.Model)
+	}
+	if res.Strategy != StrategyCheapest {
+		t.Errorf("strategy = %q, want %q", res.Strategy, StrategyCheapest)
+	}
+
+	// No priced candidates: fall back to the rule's direct target
+	res = engine.Resolve("o3", "")
+	if res.Model != "o3-mini" || res.Provider != "openai" {
+		t.Errorf("expected fallback to o3-mini, got %s/%s", res.Provider, res.Model)
+	}
+
+	// No priced candidates and no target: leave the model unchanged
+	res = engine.Resolve("claude-haiku-4-5", "")
+	if res.Model != "claude-haiku-4-5" || res.Provider != "" {
+		t.Errorf("expected unchanged model, got %s/%s", res.Provider, res.Model)
+	}
+}
+
+type drained map[string]bool
+
+func (d drained) Unavailable(provider string) (time.Time, bool) {
+	return time.Time{}, d[provider]
+}
+
+func TestEngine_ResolveCheapestSkipsDrainedProviders(t *testing.T) {
+	db := &mockDatabase{
+		rules: []*Rule{{
+			ID:         1,
+			FromModel:  "gpt-4*",
+			Strategy:   StrategyCheapest,
+			Candidates: []string{"openai/gpt-4o", "together/llama"},
+		}},
+		prices: map[string]float64{"gpt-4o": 12.50, "llama": 1.76},
+	}
+	engine := newTestEngine(t, db)
+	engine.SetAvailability(drained{"together": true})
+
+	res := engine.Resolve("gpt-4o", "")
+	if res.Model != "gpt-4o" || res.Provider != "openai" {
+		t.Errorf("expected the drained cheapest provider to be passed over, got %s/%s", res.Provider, res.Model)
+	}
+}
+
+// latencies is a LatencyHistory keyed by provider and UTC hour of day
+type latencies map[string]map[int]time.Duration
+
+func (l latencies) Expected(provider string, at time.Time) (time.Duration, bool) {
+	d, ok := l[provider][at.UTC().Hour()]
+	return d, ok
+}
+
+func TestEngine_ResolveFastest(t *testing.T) {
+	db := &mockDatabase{
+		rules: []*Rule{{
+			ID:         3,
+			FromModel:  "llama-70b",
+			ToModel:    "llama-70b",
+			ToProvider: "together",
+			Strategy:   StrategyFastest,
+			Candidates: []string{"together/llama-70b", "groq/llama-70b", "fireworks/llama-70b"},
+		}},
+	}
+	engine := newTestEngine(t, db)
+
+	// Without history the rule falls back to its target
+	if res := engine.Resolve("llama-70b", ""); res.Provider != "together" {
+		t.Errorf("expected the fallback target, got %+v", res)
+	}
+
+	engine.SetLatencyHistory(latencies{
+		"together":  {9: 900 * time.Millisecond, 14: 400 * time.Millisecond},
+		"groq":      {9: 300 * time.Millisecond, 14: 1500 * time.Millisecond},
+		"fireworks": {9: 200 * time.Millisecond},
+	})
+	engine.SetAvailability(drained{"fireworks": true})
+
+	engine.now = func() time.Time { return time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC) }
+	ex := engine.Explain("llama-70b", "")
+	if ex.Provider != "groq" || ex.Model != "llama-70b" {
+		t.Fatalf("expected groq in the morning, got %+v", ex.Resolution)
+	}
+	want := map[string]string{"together": "slower than groq", "groq": "", "fireworks": "provider unavailable"}
+	for _, c := range ex.Candidates {
+		if c.Eliminated != want[c.Provider] || c.Selected != (c.Provider == "groq") {
+			t.Errorf("unexpected candidate %+v", c)
+		}
+	}
+	if ex.Candidates[1].LatencyMs != 300 {
+		t.Errorf("expected groq at 300ms, got %+v", ex.Candidates[1])
+	}
+
+	// In the afternoon groq slows down and fireworks has no history
+	engine.now = func() time.Time { return time.Date(2026, 3, 2, 14, 5, 0, 0, time.UTC) }
+	if res := engine.Resolve("llama-70b", ""); res.Provider != "together" {
+		t.Errorf("expected together in the afternoon, got %+v", res)
+	}
+}
+
+func TestEngine_Explain(t *testing.T) {
+	db := &mockDatabase{
+		rules: []*Rule{{
+			ID:         7,
+			FromModel:  "gpt-4*",
+			Strategy:   StrategyCheapest,
+			Candidates: []string{"openai/gpt-4o", "together/llama", "groq/mixtral", "openai/gpt-4o-mini"},
+		}},
+		aliases: []*Alias{{Name: "smart", ModelID: "gpt-4o"}},
+		prices:  map[string]float64{"gpt-4o": 12.50, "llama": 1.76, "gpt-4o-mini": 0.75},
+	}
+	engine := newTestEngine(t, db)
+	engine.SetAvailability(drained{"openai": true})
+
+	ex := engine.Explain("smart", "")
+	if ex.Alias != "smart" || ex.RuleID != 7 || ex.Model != "llama" || ex.Provider != "together" {
+		t.Fatalf("unexpected resolution %+v", ex.Resolution)
+	}
+	if *engine.Resolve("smart", "") != ex.Resolution {
+		t.Errorf("expected Explain to resolve like Resolve")
+	}
+
+	want := map[string]string{
+		"gpt-4o":      "provider unavailable",
+		"llama":       "",
+		"mixtral":     "no known price",
+		"gpt-4o-mini": "provider unavailable",
+	}
+	if len(ex.Candidates) != len(want) {
+		t.Fatalf("expected %d candidates, got %+v", len(want), ex.Candidates)
+	}
+	for _, c := range ex.Candidates {
+		if c.Eliminated != want[c.Model] || c.Selected != (c.Model == "llama") {
+			t.Errorf("unexpected candidate %+v", c)
+		}
+	}
+	if len(ex.Trace) != 4 || !strings.Contains(ex.Trace[0], "alias smart -> gpt-4o") || !strings.Contains(ex.Trace[3], "llama on together") {
+		t.Errorf("unexpected trace %q", ex.Trace)
+	}
+
+	if ex := engine.Explain("claude-3", ""); ex.Model != "claude-3" || len(ex.Trace) != 1 {
+		t.Errorf("expected an unmatched model to pass through, got %+v", ex)
+	}
+}
+
+func TestEngine_Names(t *testing.T) {
+	db := &mockDatabase{
+		rules: []*Rule{
+			{ID: 1, FromModel: "gpt-4", ToModel: "gpt-4o"},
+			{ID: 2, FromModel: "claude-*", ToModel: "claude-sonnet-4-5"},
+			{ID: 3, ClientID: "cli", FromModel: "house-model", ToModel: "llama-3.3-70b", ToProvider: "groq"},
+		},
+		aliases: []*Alias{
+			{Name: "fast", ModelID: "llama-3.3-70b"},
+			{Name: "gpt-4", ModelID: "gpt-4o"},
+			{Name: "mine", ModelID: "gpt-4o-mini", ClientID: "cli"},
+		},
+	}
+	engine := newTestEngine(t, db)
+
+	if got := engine.Names(""); !reflect.DeepEqual(got, []string{"fast", "gpt-4"}) {
+		t.Errorf("Names(\"\") = %v", got)
+	}
+	if got := engine.Names("cli"); !reflect.DeepEqual(got, []string{"fast", "gpt-4", "house-model", "mine"}) {
+		t.Errorf("Names(cli) = %v", got)
+	}
+}
+
+func TestEngine_RemapModel(t *testing.T) {
+	db := &mockDatabase{
+		rules: []*Rule{
+			{ID: 1, FromModel: "gpt-4", ToModel: "claude-sonnet-4-5", ToProvider: "anthropic", Strategy: StrategyDirect},
+		},
+	}
+	engine := newTestEngine(t, db)
+
+	model, provider, err := engine.RemapModel(context.Background(), "gpt-4", "")
+	if err != nil {
+		t.Fatalf("RemapModel failed: %v", err)
+	}
+	if model != "claude-sonnet-4-5" || provider != "anthropic" {
+		t.Errorf("got %s/%s, want anthropic/claude-sonnet-4-5", provider, model)
+	}
+}
+
+func TestEngine_Reload(t *testing.T) {
+	db := &mockDatabase{}
+	engine := newTestEngine(t, db)
+
+	if res := engine.Resolve("gpt-4", ""); res.Model != "gpt-4" {
+		t.Fatalf("expected passthrough before reload, got %s", res.Model)
+	}
+
+	db.setRules([]*Rule{
+		{ID: 1, FromModel: "gpt-4", ToModel: "gpt-4.1", ToProvider: "openai", Strategy: StrategyDirect},
+	})
+	before := engine.LoadedAt()
+	if err := engine.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if res := engine.Resolve("gpt-4", ""); res.Model != "gpt-4.1" {
+		t.Errorf("expected reloaded rule to apply, got %s", res.Model)
+	}
+	if engine.LoadedAt().Before(before) {
+		t.Error("expected LoadedAt to advance after reload")
+	}
+
+	// A failed reload keeps the previous rule set
+	db.mu.Lock()
+	db.err = errors.New("database locked")
+	db.mu.Unlock()
+	if err := engine.Reload(); err == nil {
+		t.Error("expected reload error")
+	}
+	if res := engine.Resolve("gpt-4", ""); res.Model != "gpt-4.1" {
+		t.Errorf("expected previous rules after failed reload, got %s", res.Model)
+	}
+}
+
+func TestEngine_ReloadLoop(t *testing.T) {
+	db := &mockDatabase{}
+	engine, err := NewEngine(db, Config{ReloadInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+
+	db.setRules([]*Rule{
+		{ID: 1, FromModel: "gpt-4", ToModel: "gpt-4.1", ToProvider: "openai", Strategy: StrategyDirect},
+	})
+
+	deadline := time.Now().Add(time.Second)
+	for engine.Resolve("gpt-4", "").Model != "gpt-4.1" {
+		if time.Now().After(deadline) {
+			t.Fatal("background reload did not pick up new rule")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := engine.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	loads := db.loadCount()
+	time.Sleep(30 * time.Millisecond)
+	if db.loadCount() != loads {
+		t.Error("expected reloads to stop after Close")
+	}
+
+	// Close is idempotent
+	if err := engine.Close(); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+}
+
+func TestSplitCandidate(t *testing.T) {
+	tests := []struct {
+		candidate    string
+		wantProvider string
+		wantModel    string
+	}{
+		{"openai/gpt-4o", "openai", "gpt-4o"},
+		{"together/meta-llama/Llama-3.3-70B", "together", "meta-llama/Llama-3.3-70B"},
+		{"gpt-4o", "", "gpt-4o"},
+	}
+
+	for _, tt := range tests {
+		provider, model := splitCandidate(tt.candidate)
+		if provider != tt.wantProvider || model != tt.wantModel {
+			t.Errorf("splitCandidate(%q) = %q, %q; want %q, %q", tt.candidate, provider, model, tt.wantProvider, tt.wantModel)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package replay
+
+import "strings"
+
+// maxDiffLines bounds the LCS table; longer texts are shown as a whole
+// replacement
+const maxDiffLines = 2000
+
+// Diff returns a line diff turning a into b. Lines are prefixed with "  "
+// when unchanged, "- " when removed and "+ " when added.
+func Diff(a, b string) []string {
+	x, y := splitLines(a), splitLines(b)
+	if len(x) > maxDiffLines || len(y) > maxDiffLines {
+		return append(prefix("- ", x), prefix("+ ", y)...)
+	}
+
+	// lcs[i][j] is the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			out = append(out, "  "+x[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "- "+x[i])
+			i++
+		default:
+			out = append(out, "+ "+y[j])
+			j++
+		}
+	}
+	out = append(out, prefix("- ", x[i:])...)
+	return append(out, prefix("+ ", y[j:])...)
+}
+
+// splitLines splits text into lines, treating empty text as no lines
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func prefix(p string, lines []string) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = p + l
+	}
+	return out
+}
@@ -0,0 +1,312 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+)
+
+// Exchange is one recorded chat request, converted to the chat completions
+// format, with the outcome that was recorded for it
+type Exchange struct {
+	Index    int             `json:"index"` // Position in the cassette
+	URL      string          `json:"url"`
+	Model    string          `json:"model"`
+	Request  json.RawMessage `json:"-"` // Chat completions body
+	Recorded Outcome         `json:"recorded"`
+}
+
+// Outcome is what one request produced
+type Outcome struct {
+	Model     string  `json:"model,omitempty"`
+	Status    int     `json:"status"`
+	Text      string  `json:"text"`
+	TokensIn  int     `json:"tokens_in"`
+	TokensOut int     `json:"tokens_out"`
+	Cost      float64 `json:"cost"`
+	LatencyMs int64   `json:"latency_ms,omitempty"` // Zero when the cassette has no timing
+	Error     string  `json:"error,omitempty"`
+}
+
+// Load reads the chat interactions of a cassette written by the HTTP
+// recorder. OpenAI-style chat completions and Anthropic messages requests
+// are kept; other interactions, and requests with non-text content, are
+// counted as skipped.
+func Load(path string) ([]*Exchange, int, error) {
+	rec, err := mshttp.NewRecorder(mshttp.RecorderConfig{Path: path, Mode: mshttp.ModeReplay})
+	if err != nil {
+		return nil, 0, err
+	}
+	var exchanges []*Exchange
+	skipped := 0
+	for i, in := range rec.Interactions() {
+		ex, ok := parseInteraction(i, in)
+		if !ok {
+			skipped++
+			continue
+		}
+		exchanges = append(exchanges, ex)
+	}
+	return exchanges, skipped, nil
+}
+
+// parseInteraction converts a recorded chat interaction
+func parseInteraction(index int, in mshttp.Interaction) (*Exchange, bool) {
+	if in.Request.Method != "POST" {
+		return nil, false
+	}
+	u, err := url.Parse(in.Request.URL)
+	if err != nil {
+		return nil, false
+	}
+
+	var (
+		body      []byte
+		model     string
+		anthropic bool
+	)
+	switch {
+	case strings.HasSuffix(u.Path, "/chat/completions"):
+		body, model, err = openAIRequest(in.Request.Body)
+	case strings.HasSuffix(u.Path, "/messages"):
+		body, model, err = anthropicRequest(in.Request.Body)
+		anthropic = true
+	default:
+		return nil, false
+	}
+	if err != nil {
+		return nil, false
+	}
+
+	ex := &Exchange{
+		Index:   index,
+		URL:     in.Request.URL,
+		Model:   model,
+		Request: body,
+		Recorded: Outcome{
+			Model:     model,
+			Status:    in.Response.Status,
+			LatencyMs: in.DurationMs,
+		},
+	}
+	respBody := in.Response.Body
+	if in.Response.BodyEncoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(respBody)
+		if err != nil {
+			return nil, false
+		}
+		respBody = string(decoded)
+	}
+	if in.Response.Status != 200 {
+		ex.Recorded.Error = errorText(in.Response.Status, []byte(respBody))
+		return ex, true
+	}
+	parseResponse(&ex.Recorded, respBody, anthropic)
+	return ex, true
+}
+
+// openAIRequest normalizes a chat completions body for a non-streamed replay
+func openAIRequest(raw string) ([]byte, string, error) {
+	var req map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &req); err != nil {
+		return nil, "", err
+	}
+	model, _ := req["model"].(string)
+	if _, ok := req["messages"].([]interface{}); !ok || model == "" {
+		return nil, "", fmt.Errorf("not a chat request")
+	}
+	delete(req, "stream")
+	delete(req, "stream_options")
+	body, err := json.Marshal(req)
+	return body, model, err
+}
+
+// anthropicRequest converts a text-only messages body to chat completions
+func anthropicRequest(raw string) ([]byte, string, error) {
+	var req struct {
+		Model       string          `json:"model"`
+		System      json.RawMessage `json:"system"`
+		MaxTokens   int             `json:"max_tokens"`
+		Temperature *float64        `json:"temperature"`
+		TopP        *float64        `json:"top_p"`
+		Messages    []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(raw), &req); err != nil {
+		return nil, "", err
+	}
+	if req.Model == "" || len(req.Messages) == 0 {
+		return nil, "", fmt.Errorf("not a messages request")
+	}
+
+	var messages []map[string]string
+	if len(req.System) > 0 {
+		system, err := anthropicText(req.System)
+		if err != nil {
+			return nil, "", err
+		}
+		messages = append(messages, map[string]string{"role": "system", "content": system})
+	}
+	for _, m := range req.Messages {
+		text, err := anthropicText(m.Content)
+		if err != nil {
+			return nil, "", err
+		}
+		messages = append(messages, map[string]string{"role": m.Role, "content": text})
+	}
+	out := map[string]interface{}{"model": req.Model, "messages": messages}
+	if req.MaxTokens > 0 {
+		out["max_tokens"] = req.MaxTokens
+	}
+	if req.Temperature != nil {
+		out["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		out["top_p"] = *req.TopP
+	}
+	body, err := json.Marshal(out)
+	return body, req.Model, err
+}
+
+// anthropicText flattens string or text block content
+func anthropicText(raw json.RawMessage) (string, error) {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s, nil
+	}
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return "", err
+	}
+	parts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Type != "text" {
+			return "", fmt.Errorf("unsupported content block %q", b.Type)
+		}
+		parts = append(parts, b.Text)
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// parseResponse reads the answer text and usage from a chat completions or
+// messages response, streamed or not
+func parseResponse(o *Outcome, body string, anthropic bool) {
+	trimmed := strings.TrimSpace(body)
+	if strings.HasPrefix(trimmed, "data:") || strings.HasPrefix(trimmed, "event:") {
+		parseStream(o, trimmed)
+		return
+	}
+
+	var resp struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage usage `json:"usage"`
+	}
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		o.Error = "invalid response"
+		return
+	}
+	if resp.Model != "" {
+		o.Model = resp.Model
+	}
+	if anthropic {
+		for _, c := range resp.Content {
+			if c.Type == "text" {
+				o.Text += c.Text
+			}
+		}
+	} else if len(resp.Choices) > 0 {
+		o.Text = resp.Choices[0].Message.Content
+	}
+	o.TokensIn, o.TokensOut = resp.Usage.tokens()
+}
+
+// parseStream concatenates the text deltas of a recorded event stream
+func parseStream(o *Outcome, body string) {
+	var text strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var event struct {
+			Model   string `json:"model"`
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Delta struct {
+				Text string `json:"text"`
+			} `json:"delta"`
+			Message struct {
+				Model string `json:"model"`
+				Usage usage  `json:"usage"`
+			} `json:"message"`
+			Usage *usage `json:"usage"`
+		}
+		if json.Unmarshal([]byte(strings.TrimSpace(data)), &event) != nil {
+			continue
+		}
+		for _, model := range []string{event.Model, event.Message.Model} {
+			if model != "" {
+				o.Model = model
+			}
+		}
+		if len(event.Choices) > 0 {
+			text.WriteString(event.Choices[0].Delta.Content)
+		}
+		text.WriteString(event.Delta.Text)
+		// Anthropic reports input tokens at the start and output tokens at the end
+		if in, _ := event.Message.Usage.tokens(); in > 0 {
+			o.TokensIn = in
+		}
+		if event.Usage != nil {
+			in, out := event.Usage.tokens()
+			if in > 0 {
+				o.TokensIn = in
+			}
+			if out > 0 {
+				o.TokensOut = out
+			}
+		}
+	}
+	o.Text = text.String()
+}
+
+// usage covers both the chat completions and messages usage fields
+type usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	InputTokens      int `json:"input_tokens"`
+	OutputTokens     int `json:"output_tokens"`
+}
+
+func (u usage) tokens() (int, int) {
+	return u.PromptTokens + u.InputTokens, u.CompletionTokens + u.OutputTokens
+}
+
+// errorText summarizes a failed response
+func errorText(status int, body []byte) string {
+	return strings.TrimSpace(fmt.Sprintf("%d: %s", status, body[:min(len(body), 512)]))
+}
@@ -0,0 +1,230 @@
+// Package replay re-executes chat requests recorded by the HTTP recorder
+// against another model or provider through the chat completions endpoint,
+// and reports how the answers, token counts, cost and latency changed.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+// Pricer returns a model's price in dollars per million input and output
+// tokens; zero prices leave the cost at zero
+type Pricer interface {
+	Price(model string) (inPer1M, outPer1M float64)
+}
+
+// Config controls a replay
+type Config struct {
+	URL         string // Chat completions endpoint
+	Header      http.Header
+	Model       string // Replaces the recorded model when set
+	Provider    string // Forces the upstream provider when set
+	Concurrency int    // Requests in flight at once (default 1)
+	Timeout     time.Duration
+	Client      *http.Client
+	Pricer      Pricer // Optional
+}
+
+// Result compares the recorded and replayed outcomes of one exchange
+type Result struct {
+	*Exchange
+	Replayed       Outcome  `json:"replayed"`
+	Same           bool     `json:"same"`           // Identical answer text
+	Diff           []string `json:"diff,omitempty"` // Line diff from recorded to replayed text
+	TokensInDelta  int      `json:"tokens_in_delta"`
+	TokensOutDelta int      `json:"tokens_out_delta"`
+	CostDelta      float64  `json:"cost_delta"`
+	LatencyDelta   *int64   `json:"latency_delta_ms,omitempty"` // Nil when the recording has no timing
+}
+
+// Summary totals a replay
+type Summary struct {
+	Requests        int     `json:"requests"`
+	Identical       int     `json:"identical"`
+	Errors          int     `json:"errors"` // Replayed requests that failed
+	RecordedErrors  int     `json:"recorded_errors"`
+	TokensInDelta   int     `json:"tokens_in_delta"`
+	TokensOutDelta  int     `json:"tokens_out_delta"`
+	RecordedCost    float64 `json:"recorded_cost"`
+	ReplayedCost    float64 `json:"replayed_cost"`
+	AvgLatencyDelta float64 `json:"avg_latency_delta_ms"` // Over the exchanges with timing
+}
+
+// Report is the outcome of a replay
+type Report struct {
+	Model    string    `json:"model,omitempty"`
+	Provider string    `json:"provider,omitempty"`
+	Skipped  int       `json:"skipped"` // Cassette interactions that could not be replayed
+	Summary  Summary   `json:"summary"`
+	Results  []*Result `json:"results"`
+}
+
+// Run replays every exchange and compares the outcomes. Only a cancelled
+// context stops it early, returning the exchanges finished so far.
+func Run(ctx context.Context, cfg Config, exchanges []*Exchange) (*Report, error) {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{}
+	}
+
+	results := make([]*Result, len(exchanges))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(cfg.Concurrency, len(exchanges)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = compare(cfg, exchanges[i], send(ctx, cfg, exchanges[i]))
+			}
+		}()
+	}
+dispatch:
+	for i := range exchanges {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	report := &Report{Model: cfg.Model, Provider: cfg.Provider, Results: []*Result{}}
+	for _, res := range results {
+		if res != nil {
+			report.Results = append(report.Results, res)
+		}
+	}
+	report.Summary = summarize(report.Results)
+	return report, ctx.Err()
+}
+
+// send replays one exchange
+func send(ctx context.Context, cfg Config, ex *Exchange) Outcome {
+	out := Outcome{Model: ex.Model}
+	body := []byte(ex.Request)
+	if cfg.Model != "" {
+		var req map[string]interface{}
+		if err := json.Unmarshal(body, &req); err != nil {
+			out.Error = err.Error()
+			return out
+		}
+		req["model"] = cfg.Model
+		body, _ = json.Marshal(req)
+		out.Model = cfg.Model
+	}
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	for k, v := range cfg.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.Provider != "" {
+		req.Header.Set(proxy.HeaderProvider, cfg.Provider)
+	}
+
+	start := time.Now()
+	resp, err := cfg.Client.Do(req)
+	if err != nil {
+		out.LatencyMs = time.Since(start).Milliseconds()
+		out.Error = err.Error()
+		return out
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	out.LatencyMs = time.Since(start).Milliseconds()
+	out.Status = resp.StatusCode
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	if resp.StatusCode != http.StatusOK {
+		out.Error = errorText(resp.StatusCode, data)
+		return out
+	}
+	parseResponse(&out, string(data), false)
+	if served := resp.Header.Get(proxy.HeaderModel); served != "" {
+		out.Model = served
+	}
+	return out
+}
+
+// compare prices both outcomes and diffs the answers
+func compare(cfg Config, ex *Exchange, replayed Outcome) *Result {
+	if cfg.Pricer != nil {
+		price(cfg.Pricer, &ex.Recorded)
+		price(cfg.Pricer, &replayed)
+	}
+	res := &Result{
+		Exchange:       ex,
+		Replayed:       replayed,
+		Same:           ex.Recorded.Error == "" && replayed.Error == "" && ex.Recorded.Text == replayed.Text,
+		TokensInDelta:  replayed.TokensIn - ex.Recorded.TokensIn,
+		TokensOutDelta: replayed.TokensOut - ex.Recorded.TokensOut,
+		CostDelta:      replayed.Cost - ex.Recorded.Cost,
+	}
+	if !res.Same {
+		res.Diff = Diff(ex.Recorded.Text, replayed.Text)
+	}
+	if ex.Recorded.LatencyMs > 0 && replayed.Error == "" {
+		delta := replayed.LatencyMs - ex.Recorded.LatencyMs
+		res.LatencyDelta = &delta
+	}
+	return res
+}
+
+// price fills in an outcome's cost
+func price(p Pricer, o *Outcome) {
+	in, out := p.Price(o.Model)
+	o.Cost = (float64(o.TokensIn)*in + float64(o.TokensOut)*out) / 1e6
+}
+
+// summarize totals the results
+func summarize(results []*Result) Summary {
+	s := Summary{Requests: len(results)}
+	var latency int64
+	timed := 0
+	for _, res := range results {
+		if res.Same {
+			s.Identical++
+		}
+		if res.Replayed.Error != "" {
+			s.Errors++
+		}
+		if res.Recorded.Error != "" {
+			s.RecordedErrors++
+		}
+		s.TokensInDelta += res.TokensInDelta
+		s.TokensOutDelta += res.TokensOutDelta
+		s.RecordedCost += res.Recorded.Cost
+		s.ReplayedCost += res.Replayed.Cost
+		if res.LatencyDelta != nil {
+			latency += *res.LatencyDelta
+			timed++
+		}
+	}
+	if timed > 0 {
+		s.AvgLatencyDelta = float64(latency) / float64(timed)
+	}
+	return s
+}
@@ -0,0 +1,161 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+)
+
+type fixedPricer map[string][2]float64
+
+func (p fixedPricer) Price(model string) (float64, float64) {
+	return p[model][0], p[model][1]
+}
+
+func writeCassette(t *testing.T) string {
+	t.Helper()
+	cassette := mshttp.Cassette{Interactions: []mshttp.Interaction{
+		{
+			Request: mshttp.RecordedRequest{Method: "POST", URL: "https://api.openai.com/v1/chat/completions",
+				Body: `{"model":"gpt-4o","stream":false,"messages":[{"role":"user","content":"same"}]}`},
+			Response: mshttp.RecordedResponse{Status: 200,
+				Body: `{"model":"gpt-4o","choices":[{"message":{"content":"unchanged"}}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`},
+			DurationMs: 400,
+		},
+		{
+			Request:  mshttp.RecordedRequest{Method: "GET", URL: "https://api.openai.com/v1/models"},
+			Response: mshttp.RecordedResponse{Status: 200, Body: `{"data":[]}`},
+		},
+		{
+			Request: mshttp.RecordedRequest{Method: "POST", URL: "https://api.anthropic.com/v1/messages",
+				Body: `{"model":"claude","max_tokens":100,"stream":true,"system":"Be brief.","messages":[{"role":"user","content":[{"type":"text","text":"list"}]}]}`},
+			Response: mshttp.RecordedResponse{Status: 200, Body: "event: message_start\n" +
+				`data: {"type":"message_start","message":{"model":"claude","usage":{"input_tokens":20}}}` + "\n\n" +
+				`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"one\ntwo"}}` + "\n\n" +
+				`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"\nthree"}}` + "\n\n" +
+				`data: {"type":"message_delta","usage":{"output_tokens":8}}` + "\n\n"},
+		},
+		{
+			Request: mshttp.RecordedRequest{Method: "POST", URL: "https://api.anthropic.com/v1/messages",
+				Body: `{"model":"claude","messages":[{"role":"user","content":[{"type":"image","source":{}}]}]}`},
+			Response: mshttp.RecordedResponse{Status: 200, Body: `{}`},
+		},
+	}}
+	data, err := json.Marshal(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "traffic.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	exchanges, skipped, err := Load(writeCassette(t))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(exchanges) != 2 || skipped != 2 {
+		t.Fatalf("expected 2 exchanges and 2 skipped, got %d and %d", len(exchanges), skipped)
+	}
+	streamed := exchanges[1]
+	if streamed.Index != 2 || streamed.Recorded.Text != "one\ntwo\nthree" || streamed.Recorded.TokensIn != 20 || streamed.Recorded.TokensOut != 8 {
+		t.Errorf("unexpected streamed outcome: %+v", streamed.Recorded)
+	}
+	var req map[string]interface{}
+	json.Unmarshal(streamed.Request, &req)
+	messages, _ := req["messages"].([]interface{})
+	if len(messages) != 2 || req["max_tokens"] != float64(100) || req["stream"] != nil {
+		t.Errorf("unexpected converted request: %s", streamed.Request)
+	}
+}
+
+func TestRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model    string              `json:"model"`
+			Stream   bool                `json:"stream"`
+			Messages []map[string]string `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "llama" || req.Stream || r.Header.Get(proxy.HeaderProvider) != "groq" {
+			t.Errorf("unexpected replay request: %+v %v", req, r.Header)
+		}
+		answer := "unchanged"
+		if req.Messages[len(req.Messages)-1]["content"] == "list" {
+			answer = "one\n2\nthree"
+		}
+		w.Header().Set(proxy.HeaderModel, "llama-served")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": answer}}},
+			"usage":   map[string]int{"prompt_tokens": 12, "completion_tokens": 6},
+		})
+	}))
+	defer server.Close()
+
+	exchanges, skipped, err := Load(writeCassette(t))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	report, err := Run(context.Background(), Config{
+		URL:         server.URL,
+		Model:       "llama",
+		Provider:    "groq",
+		Concurrency: 2,
+		Pricer:      fixedPricer{"gpt-4o": {1000, 1000}, "llama-served": {100, 100}},
+	}, exchanges)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	report.Skipped = skipped
+
+	same, changed := report.Results[0], report.Results[1]
+	if !same.Same || same.TokensInDelta != 2 || same.LatencyDelta == nil || same.CostDelta >= 0 {
+		t.Errorf("unexpected unchanged result: %+v", same)
+	}
+	if want := []string{"  one", "- two", "+ 2", "  three"}; changed.Same || !reflect.DeepEqual(changed.Diff, want) {
+		t.Errorf("diff = %q, want %q", changed.Diff, want)
+	}
+	if changed.Replayed.Model != "llama-served" || changed.LatencyDelta != nil {
+		t.Errorf("unexpected changed result: %+v", changed)
+	}
+	if s := report.Summary; s.Requests != 2 || s.Identical != 1 || s.Errors != 0 || s.TokensInDelta != -6 || s.RecordedCost != 0.015 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+
+	var out bytes.Buffer
+	if err := WriteText(&out, report); err != nil {
+		t.Fatal(err)
+	}
+	if text := out.String(); !strings.Contains(text, "#2 claude -> llama-served") || !strings.Contains(text, "(2 interactions skipped)") {
+		t.Errorf("unexpected report:\n%s", text)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want []string
+	}{
+		{"a\nb\nc", "a\nc", []string{"  a", "- b", "  c"}},
+		{"", "new", []string{"+ new"}},
+		{"x\ny", "y\nz", []string{"- x", "  y", "+ z"}},
+	}
+	for _, tt := range tests {
+		if got := Diff(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Diff(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
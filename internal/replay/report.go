@@ -0,0 +1,73 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteText writes a readable diff report: each changed exchange with its
+// answer diff and deltas, followed by the totals
+func WriteText(w io.Writer, r *Report) error {
+	target := r.Model
+	if target == "" {
+		target = "recorded models"
+	}
+	if r.Provider != "" {
+		target += " via " + r.Provider
+	}
+	fmt.Fprintf(w, "Replayed %d requests against %s", r.Summary.Requests, target)
+	if r.Skipped > 0 {
+		fmt.Fprintf(w, " (%d interactions skipped)", r.Skipped)
+	}
+	fmt.Fprintln(w)
+
+	for _, res := range r.Results {
+		if res.Same {
+			continue
+		}
+		fmt.Fprintf(w, "\n#%d %s -> %s", res.Index, res.Recorded.Model, res.Replayed.Model)
+		fmt.Fprintf(w, "  tokens %+d/%+d  cost %+.6f$", res.TokensInDelta, res.TokensOutDelta, res.CostDelta)
+		if res.LatencyDelta != nil {
+			fmt.Fprintf(w, "  latency %+dms", *res.LatencyDelta)
+		}
+		fmt.Fprintln(w)
+		if res.Recorded.Error != "" {
+			fmt.Fprintf(w, "  recorded error: %s\n", res.Recorded.Error)
+		}
+		if res.Replayed.Error != "" {
+			fmt.Fprintf(w, "  replay error: %s\n", res.Replayed.Error)
+			continue
+		}
+		for _, line := range res.Diff {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+
+	s := r.Summary
+	fmt.Fprintf(w, "\n%d identical, %d changed, %d failed", s.Identical, s.Requests-s.Identical-s.Errors, s.Errors)
+	if s.RecordedErrors > 0 {
+		fmt.Fprintf(w, " (%d failed when recorded)", s.RecordedErrors)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Tokens %+d in, %+d out\n", s.TokensInDelta, s.TokensOutDelta)
+	fmt.Fprintf(w, "Cost $%.4f -> $%.4f (%s)\n", s.RecordedCost, s.ReplayedCost, percentChange(s.RecordedCost, s.ReplayedCost))
+	fmt.Fprintf(w, "Latency %+.0fms on average\n", s.AvgLatencyDelta)
+	return nil
+}
+
+// WriteJSON writes the report as indented JSON
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// percentChange describes the change from before to after
+func percentChange(before, after float64) string {
+	if before == 0 {
+		return strings.TrimSpace(fmt.Sprintf("%+.4f$", after-before))
+	}
+	return fmt.Sprintf("%+.1f%%", (after-before)/before*100)
+}
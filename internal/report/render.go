@@ -0,0 +1,128 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// renderer writes report elements in one format. Text passed to it is
+// plain; renderers escape it.
+type renderer interface {
+	begin(title string)
+	heading(level int, text string)
+	text(s string)
+	list(items []string)
+	table(headers []string, rows [][]string)
+	svg(svg string) // A trusted, already escaped SVG element
+	end()
+}
+
+// markdownRenderer writes GitHub flavored Markdown. Charts are embedded as
+// raw SVG, which most Markdown viewers render inline.
+type markdownRenderer struct {
+	w *errWriter
+}
+
+func (m *markdownRenderer) begin(title string) {
+	fmt.Fprintf(m.w, "# %s\n\n", title)
+}
+
+func (m *markdownRenderer) heading(level int, text string) {
+	fmt.Fprintf(m.w, "%s %s\n\n", strings.Repeat("#", level), text)
+}
+
+func (m *markdownRenderer) text(s string) {
+	fmt.Fprintf(m.w, "%s\n\n", s)
+}
+
+func (m *markdownRenderer) list(items []string) {
+	for _, item := range items {
+		fmt.Fprintf(m.w, "- %s\n", item)
+	}
+	fmt.Fprintln(m.w)
+}
+
+func (m *markdownRenderer) table(headers []string, rows [][]string) {
+	fmt.Fprintf(m.w, "| %s |\n", strings.Join(headers, " | "))
+	seps := make([]string, len(headers))
+	for i, h := range headers {
+		seps[i] = strings.Repeat("-", len(h)+2)
+	}
+	fmt.Fprintf(m.w, "|%s|\n", strings.Join(seps, "|"))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			cells[i] = markdownCell(c)
+		}
+		fmt.Fprintf(m.w, "| %s |\n", strings.Join(cells, " | "))
+	}
+	fmt.Fprintln(m.w)
+}
+
+func (m *markdownRenderer) svg(svg string) {
+	fmt.Fprintf(m.w, "%s\n\n", svg)
+}
+
+func (m *markdownRenderer) end() {}
+
+// markdownCell keeps a value on one table row
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// htmlRenderer writes a standalone HTML page with inline styles, so the
+// file can be shared without other assets
+type htmlRenderer struct {
+	w *errWriter
+}
+
+const htmlStyle = `body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif;margin:2em auto;max-width:1100px;padding:0 1em;color:#24292f}
+table{border-collapse:collapse;margin:0 0 1.5em}th,td{border:1px solid #d0d7de;padding:4px 10px;text-align:left}
+th{background:#f6f8fa}h2{border-bottom:1px solid #d0d7de;padding-bottom:.3em;margin-top:2em}`
+
+func (h *htmlRenderer) begin(title string) {
+	t := html.EscapeString(title)
+	fmt.Fprintf(h.w, "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n<h1>%s</h1>\n", t, htmlStyle, t)
+}
+
+func (h *htmlRenderer) heading(level int, text string) {
+	fmt.Fprintf(h.w, "<h%d>%s</h%d>\n", level, html.EscapeString(text), level)
+}
+
+func (h *htmlRenderer) text(s string) {
+	fmt.Fprintf(h.w, "<p>%s</p>\n", html.EscapeString(s))
+}
+
+func (h *htmlRenderer) list(items []string) {
+	fmt.Fprintln(h.w, "<ul>")
+	for _, item := range items {
+		fmt.Fprintf(h.w, "<li>%s</li>\n", html.EscapeString(item))
+	}
+	fmt.Fprintln(h.w, "</ul>")
+}
+
+func (h *htmlRenderer) table(headers []string, rows [][]string) {
+	fmt.Fprint(h.w, "<table>\n<thead><tr>")
+	for _, c := range headers {
+		fmt.Fprintf(h.w, "<th>%s</th>", html.EscapeString(c))
+	}
+	fmt.Fprint(h.w, "</tr></thead>\n<tbody>\n")
+	for _, row := range rows {
+		fmt.Fprint(h.w, "<tr>")
+		for _, c := range row {
+			fmt.Fprintf(h.w, "<td>%s</td>", html.EscapeString(c))
+		}
+		fmt.Fprint(h.w, "</tr>\n")
+	}
+	fmt.Fprint(h.w, "</tbody>\n</table>\n")
+}
+
+func (h *htmlRenderer) svg(svg string) {
+	fmt.Fprintf(h.w, "%s\n", svg)
+}
+
+func (h *htmlRenderer) end() {
+	fmt.Fprint(h.w, "</body>\n</html>\n")
+}
@@ -0,0 +1,379 @@
+// Package report renders validation results as Markdown or standalone HTML:
+// a summary, cost overview, and per provider endpoint status tables,
+// latency charts (inline SVG), model tables and capability matrices.
+// Sections can be selected so a report only carries what its reader needs.
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/providers"
+)
+
+// Format identifies a report encoding
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
+// Formats lists the supported formats
+var Formats = []Format{FormatMarkdown, FormatHTML}
+
+// ParseFormat parses a format name
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "html", "htm":
+		return FormatHTML, nil
+	}
+	return "", fmt.Errorf("unknown report format %q (want markdown or html)", name)
+}
+
+// Extension returns the file extension for the format, without a dot
+func (f Format) Extension() string {
+	if f == FormatHTML {
+		return "html"
+	}
+	return "md"
+}
+
+// Report sections, in the order they are written
+const (
+	SectionSummary      = "summary"      // Totals and one row per provider
+	SectionCosts        = "costs"        // Price ranges and the cheapest model per provider
+	SectionEndpoints    = "endpoints"    // Endpoint status table per provider
+	SectionLatency      = "latency"      // Endpoint latency chart per provider
+	SectionModels       = "models"       // Model tables by category per provider
+	SectionCapabilities = "capabilities" // Capability matrix per provider
+)
+
+// Sections lists every section in order
+var Sections = []string{SectionSummary, SectionCosts, SectionEndpoints, SectionLatency, SectionModels, SectionCapabilities}
+
+// ParseSections parses a comma-separated section list; empty selects all
+func ParseSections(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return Sections, nil
+	}
+	var sections []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !contains(Sections, name) {
+			return nil, fmt.Errorf("unknown report section %q (want %s)", name, strings.Join(Sections, ", "))
+		}
+		sections = append(sections, name)
+	}
+	return sections, nil
+}
+
+// Data is what a report is rendered from
+type Data struct {
+	GeneratedAt time.Time
+	Providers   []*Provider
+}
+
+// Provider is one validated provider's stored results
+type Provider struct {
+	Name      string
+	Models    []providers.Model
+	Endpoints []providers.Endpoint
+	LastRun   *Run // Nil when the provider was never validated
+}
+
+// Run is a validation run's outcome
+type Run struct {
+	At           time.Time
+	Succeeded    int
+	Failed       int
+	TotalLatency time.Duration
+}
+
+// Working counts the provider's working endpoints
+func (p *Provider) Working() int {
+	n := 0
+	for _, e := range p.Endpoints {
+		if e.Status == providers.StatusWorking {
+			n++
+		}
+	}
+	return n
+}
+
+// Options select what a report contains; the zero value is a Markdown
+// report with every section
+type Options struct {
+	Format   Format
+	Sections []string // Default Sections
+	Title    string   // Default "AI Provider Validation Report"
+}
+
+// DefaultTitle heads reports without a title
+const DefaultTitle = "AI Provider Validation Report"
+
+// Write renders a report of data to w
+func Write(w io.Writer, data *Data, opts Options) error {
+	if opts.Format == "" {
+		opts.Format = FormatMarkdown
+	}
+	if len(opts.Sections) == 0 {
+		opts.Sections = Sections
+	}
+	if opts.Title == "" {
+		opts.Title = DefaultTitle
+	}
+
+	ew := &errWriter{w: w}
+	var r renderer
+	switch opts.Format {
+	case FormatMarkdown:
+		r = &markdownRenderer{w: ew}
+	case FormatHTML:
+		r = &htmlRenderer{w: ew}
+	default:
+		return fmt.Errorf("unknown report format %q", opts.Format)
+	}
+
+	ps := append([]*Provider(nil), data.Providers...)
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Name < ps[j].Name })
+	want := func(section string) bool { return contains(opts.Sections, section) }
+
+	r.begin(opts.Title)
+	r.text("Generated on: " + data.GeneratedAt.Format("2006-01-02 15:04:05"))
+	if want(SectionSummary) {
+		writeSummary(r, ps)
+	}
+	if want(SectionCosts) {
+		writeCosts(r, ps)
+	}
+	perProvider := want(SectionEndpoints) || want(SectionLatency) || want(SectionModels) || want(SectionCapabilities)
+	for _, p := range ps {
+		if !perProvider {
+			break
+		}
+		r.heading(2, p.Name)
+		if want(SectionEndpoints) {
+			writeEndpoints(r, p)
+		}
+		if want(SectionLatency) {
+			writeLatency(r, p)
+		}
+		if want(SectionModels) {
+			writeModels(r, p)
+		}
+		if want(SectionCapabilities) {
+			writeCapabilities(r, p)
+		}
+	}
+	r.end()
+	return ew.err
+}
+
+func writeSummary(r renderer, ps []*Provider) {
+	models, endpoints, working := 0, 0, 0
+	rows := make([][]string, 0, len(ps))
+	for _, p := range ps {
+		models += len(p.Models)
+		endpoints += len(p.Endpoints)
+		working += p.Working()
+		validated := "never"
+		if p.LastRun != nil {
+			validated = p.LastRun.At.Format("2006-01-02 15:04")
+		}
+		rows = append(rows, []string{p.Name, fmt.Sprint(len(p.Models)), fmt.Sprintf("%d/%d", p.Working(), len(p.Endpoints)), validated})
+	}
+
+	r.heading(2, "Summary")
+	r.list([]string{
+		fmt.Sprintf("Total Providers: %d", len(ps)),
+		fmt.Sprintf("Total Models: %d", models),
+		fmt.Sprintf("Working Endpoints: %d/%d", working, endpoints),
+	})
+	if len(rows) > 0 {
+		r.table([]string{"Provider", "Models", "Working Endpoints", "Last Validated"}, rows)
+	}
+}
+
+func writeCosts(r renderer, ps []*Provider) {
+	r.heading(2, "Cost Summary")
+	var rows [][]string
+	for _, p := range ps {
+		var priced []providers.Model
+		for _, m := range p.Models {
+			if m.CostPer1MIn > 0 || m.CostPer1MOut > 0 {
+				priced = append(priced, m)
+			}
+		}
+		if len(priced) == 0 {
+			rows = append(rows, []string{p.Name, "0", "N/A", "N/A", "N/A"})
+			continue
+		}
+		sort.SliceStable(priced, func(i, j int) bool {
+			return priced[i].CostPer1MIn+priced[i].CostPer1MOut < priced[j].CostPer1MIn+priced[j].CostPer1MOut
+		})
+		minIn, maxIn := priced[0].CostPer1MIn, priced[0].CostPer1MIn
+		minOut, maxOut := priced[0].CostPer1MOut, priced[0].CostPer1MOut
+		for _, m := range priced[1:] {
+			minIn, maxIn = min(minIn, m.CostPer1MIn), max(maxIn, m.CostPer1MIn)
+			minOut, maxOut = min(minOut, m.CostPer1MOut), max(maxOut, m.CostPer1MOut)
+		}
+		cheapest := priced[0]
+		rows = append(rows, []string{
+			p.Name,
+			fmt.Sprintf("%d/%d", len(priced), len(p.Models)),
+			priceRange(minIn, maxIn),
+			priceRange(minOut, maxOut),
+			fmt.Sprintf("%s ($%.3f/$%.3f)", cheapest.ID, cheapest.CostPer1MIn, cheapest.CostPer1MOut),
+		})
+	}
+	if len(rows) == 0 {
+		r.text("No providers.")
+		return
+	}
+	r.text("Prices are USD per million tokens.")
+	r.table([]string{"Provider", "Priced Models", "Input", "Output", "Cheapest (in/out)"}, rows)
+}
+
+func priceRange(lo, hi float64) string {
+	if lo == hi {
+		return fmt.Sprintf("$%.3f", lo)
+	}
+	return fmt.Sprintf("$%.3f–$%.3f", lo, hi)
+}
+
+func writeEndpoints(r renderer, p *Provider) {
+	r.heading(3, "Endpoint Status")
+	if len(p.Endpoints) == 0 {
+		r.text("No endpoints validated.")
+		return
+	}
+	rows := make([][]string, 0, len(p.Endpoints))
+	for _, e := range p.Endpoints {
+		latency := "N/A"
+		if e.Latency > 0 {
+			latency = e.Latency.Round(time.Millisecond).String()
+		}
+		status := "❌ " + e.Error
+		if e.Status == providers.StatusWorking {
+			status = "✅ Working"
+		}
+		rows = append(rows, []string{e.Path, e.Method, status, latency})
+	}
+	r.table([]string{"Endpoint", "Method", "Status", "Latency"}, rows)
+}
+
+func writeLatency(r renderer, p *Provider) {
+	r.heading(3, "Latency")
+	chart := latencyChart(p)
+	if chart == "" {
+		r.text("No latency measurements.")
+		return
+	}
+	r.svg(chart)
+}
+
+func writeModels(r renderer, p *Provider) {
+	r.heading(3, "Models")
+	if len(p.Models) == 0 {
+		r.text("No models stored.")
+		return
+	}
+	byCategory := make(map[string][]providers.Model)
+	for _, m := range p.Models {
+		categories := m.Categories
+		if len(categories) == 0 {
+			categories = []string{"general"}
+		}
+		for _, c := range categories {
+			byCategory[c] = append(byCategory[c], m)
+		}
+	}
+	categories := make([]string, 0, len(byCategory))
+	for c := range byCategory {
+		categories = append(categories, c)
+	}
+	sort.Strings(categories)
+
+	for _, c := range categories {
+		r.heading(4, c+" Models")
+		rows := make([][]string, 0, len(byCategory[c]))
+		for _, m := range byCategory[c] {
+			rows = append(rows, []string{m.Name, m.ID, fmt.Sprint(m.ContextWindow), fmt.Sprintf("$%.3f/$%.3f", m.CostPer1MIn, m.CostPer1MOut), features(m)})
+		}
+		r.table([]string{"Name", "ID", "Context", "Input/Output Cost", "Features"}, rows)
+	}
+}
+
+// features marks a model's capabilities with icons
+func features(m providers.Model) string {
+	var icons []string
+	if m.SupportsImages {
+		icons = append(icons, "🖼️")
+	}
+	if m.SupportsTools {
+		icons = append(icons, "🔧")
+	}
+	if m.CanReason {
+		icons = append(icons, "🧠")
+	}
+	if m.CanStream {
+		icons = append(icons, "📡")
+	}
+	if len(icons) == 0 {
+		return "N/A"
+	}
+	return strings.Join(icons, " ")
+}
+
+func writeCapabilities(r renderer, p *Provider) {
+	r.heading(3, "Capabilities")
+	if len(p.Models) == 0 {
+		r.text("No models stored.")
+		return
+	}
+	mark := func(b bool) string {
+		if b {
+			return "✓"
+		}
+		return "—"
+	}
+	rows := make([][]string, 0, len(p.Models))
+	for _, m := range p.Models {
+		rows = append(rows, []string{m.ID, mark(m.SupportsImages), mark(m.SupportsTools), mark(m.CanReason), mark(m.CanStream), fmt.Sprint(m.ContextWindow)})
+	}
+	r.table([]string{"Model", "Images", "Tools", "Reasoning", "Streaming", "Context"}, rows)
+}
+
+// errWriter keeps the first write error so renderers need not check each
+// write
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, err := e.w.Write(p)
+	e.err = err
+	return n, err
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
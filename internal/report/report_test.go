@@ -0,0 +1,106 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/providers"
+)
+
+func testData() *Data {
+	return &Data{
+		GeneratedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Providers: []*Provider{
+			{
+				Name: "openai",
+				Models: []providers.Model{
+					{ID: "gpt-4o", Name: "GPT-4o", CostPer1MIn: 2.5, CostPer1MOut: 10, ContextWindow: 128000, SupportsImages: true, SupportsTools: true, Categories: []string{"chat"}},
+					{ID: "gpt-4o-mini", Name: "GPT-4o mini", CostPer1MIn: 0.15, CostPer1MOut: 0.6, ContextWindow: 128000, CanStream: true},
+				},
+				Endpoints: []providers.Endpoint{
+					{Path: "/v1/models", Method: "GET", Status: providers.StatusWorking, Latency: 120 * time.Millisecond},
+					{Path: "/v1/chat/completions", Method: "POST", Status: providers.StatusFailed, Error: "rate | limited", Latency: 480 * time.Millisecond},
+				},
+				LastRun: &Run{At: time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC), Succeeded: 1, Failed: 1},
+			},
+			{Name: "<script>", Models: []providers.Model{{ID: "free", Name: "Free"}}},
+		},
+	}
+}
+
+func TestWrite_Markdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testData(), Options{}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# AI Provider Validation Report",
+		"- Total Models: 3",
+		"- Working Endpoints: 1/2",
+		"| openai | 2 | 1/2 | 2026-03-01 11:00 |",
+		"| openai | 2/2 | $0.150–$2.500 | $0.600–$10.000 | gpt-4o-mini ($0.150/$0.600) |",
+		"| <script> | 0 | N/A | N/A | N/A |",
+		`| /v1/chat/completions | POST | ❌ rate \| limited | 480ms |`,
+		"#### chat Models",
+		"#### general Models",
+		"| gpt-4o | ✓ | ✓ | — | — | 128000 |",
+		`<svg xmlns="http://www.w3.org/2000/svg"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+	// The provider without endpoints has no chart
+	if strings.Count(out, "<svg") != 1 || !strings.Contains(out, "No latency measurements.") {
+		t.Errorf("expected one latency chart")
+	}
+	// Providers are sorted, so the one named <script> comes first
+	if strings.Index(out, "## <script>") > strings.Index(out, "## openai") {
+		t.Error("expected providers in name order")
+	}
+}
+
+func TestWrite_HTMLSections(t *testing.T) {
+	var buf bytes.Buffer
+	sections, err := ParseSections("costs, latency")
+	if err != nil {
+		t.Fatalf("ParseSections failed: %v", err)
+	}
+	if err := Write(&buf, testData(), Options{Format: FormatHTML, Sections: sections, Title: "Weekly <report>"}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "<!DOCTYPE html>") || !strings.HasSuffix(out, "</html>\n") {
+		t.Errorf("expected a complete HTML page:\n%s", out)
+	}
+	for _, want := range []string{"<title>Weekly &lt;report&gt;</title>", "<h2>&lt;script&gt;</h2>", "<h2>Cost Summary</h2>", "<svg", "fill=\"#cf222e\""} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+	for _, unwanted := range []string{"<h2>Summary</h2>", "Endpoint Status", "Capabilities", "<script>"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("report should not contain %q", unwanted)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	if sections, err := ParseSections(""); err != nil || len(sections) != len(Sections) {
+		t.Errorf("expected every section by default, got %v, %v", sections, err)
+	}
+	if _, err := ParseSections("summary,charts"); err == nil {
+		t.Error("expected an error for an unknown section")
+	}
+	if f, err := ParseFormat("HTM"); err != nil || f != FormatHTML || f.Extension() != "html" {
+		t.Errorf("ParseFormat(HTM) = %q, %v", f, err)
+	}
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,64 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/providers"
+)
+
+// Chart layout, in pixels
+const (
+	chartLabelWidth = 240
+	chartBarWidth   = 360
+	chartValueWidth = 80
+	chartBarHeight  = 18
+	chartBarGap     = 6
+)
+
+// Bar colors
+const (
+	chartWorking = "#2da44e"
+	chartFailed  = "#cf222e"
+)
+
+// latencyChart draws a horizontal bar per measured endpoint, scaled to the
+// slowest, with failed endpoints in red. It returns "" when no endpoint
+// has a latency.
+func latencyChart(p *Provider) string {
+	var measured []providers.Endpoint
+	var slowest time.Duration
+	for _, e := range p.Endpoints {
+		if e.Latency > 0 {
+			measured = append(measured, e)
+			slowest = max(slowest, e.Latency)
+		}
+	}
+	if len(measured) == 0 {
+		return ""
+	}
+
+	width := chartLabelWidth + chartBarWidth + chartValueWidth
+	height := len(measured)*(chartBarHeight+chartBarGap) + chartBarGap
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" role="img" font-family="sans-serif" font-size="12">`,
+		width, height, width, height)
+	fmt.Fprintf(&b, `<title>%s endpoint latency</title>`, html.EscapeString(p.Name))
+	for i, e := range measured {
+		y := chartBarGap + i*(chartBarHeight+chartBarGap)
+		textY := y + chartBarHeight - 5
+		bar := max(int(float64(chartBarWidth)*float64(e.Latency)/float64(slowest)), 1)
+		color := chartFailed
+		if e.Status == providers.StatusWorking {
+			color = chartWorking
+		}
+		label := html.EscapeString(strings.TrimSpace(e.Method + " " + e.Path))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`, chartLabelWidth-8, textY, label)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`, chartLabelWidth, y, bar, chartBarHeight, color)
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`, chartLabelWidth+bar+6, textY, e.Latency.Round(time.Millisecond))
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
@@ -0,0 +1,383 @@
+// Package scheduler periodically re-runs provider maintenance tasks such as
+// endpoint validation, model listing and pricing refreshes.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+)
+
+// Task is a maintenance job run against a provider
+type Task string
+
+const (
+	// TaskValidate re-runs endpoint validation
+	TaskValidate Task = "validate"
+	// TaskModels refreshes the provider's model list
+	TaskModels Task = "models"
+	// TaskPricing refreshes rate limits and pricing
+	TaskPricing Task = "pricing"
+)
+
+// AllTasks lists every task in the order they run
+var AllTasks = []Task{TaskValidate, TaskModels, TaskPricing}
+
+// ParseTask validates a task name
+func ParseTask(s string) (Task, error) {
+	for _, t := range AllTasks {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown task %q (want validate, models or pricing)", s)
+}
+
+// ErrUnknownProvider is returned when triggering a provider without a schedule
+var ErrUnknownProvider = errors.New("no schedule for provider")
+
+// Triggers record why a run happened
+const (
+	TriggerScheduled = "scheduled"
+	TriggerManual    = "manual"
+)
+
+// Run statuses
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+// Schedule describes how often a provider's tasks run
+type Schedule struct {
+	Provider string
+	Interval time.Duration
+	// Jitter randomizes each interval by up to this fraction (0-1) so
+	// providers do not all refresh at the same moment
+	Jitter float64
+	Tasks  []Task // Empty means all tasks
+}
+
+// Config holds the default schedule and per-provider overrides
+type Config struct {
+	Interval  time.Duration // Default interval for providers without an override
+	Jitter    float64       // Applied to every schedule
+	Tasks     []Task        // Default tasks; empty means all tasks
+	Timeout   time.Duration // Per-task timeout
+	Schedules []Schedule    // Per-provider overrides
+}
+
+// DefaultConfig returns a config that refreshes every provider every six hours
+func DefaultConfig() Config {
+	return Config{
+		Interval: 6 * time.Hour,
+		Jitter:   0.1,
+		Timeout:  5 * time.Minute,
+	}
+}
+
+// Build returns one schedule per override plus a default schedule for each
+// listed provider without one. Overrides without an interval or tasks
+// inherit the defaults.
+func (c Config) Build(providers ...string) []Schedule {
+	schedules := make([]Schedule, 0, len(c.Schedules)+len(providers))
+	seen := make(map[string]bool, len(c.Schedules))
+	for _, sc := range c.Schedules {
+		if sc.Interval == 0 {
+			sc.Interval = c.Interval
+		}
+		if len(sc.Tasks) == 0 {
+			sc.Tasks = c.Tasks
+		}
+		sc.Jitter = c.Jitter
+		schedules = append(schedules, sc)
+		seen[sc.Provider] = true
+	}
+	for _, provider := range providers {
+		if seen[provider] {
+			continue
+		}
+		seen[provider] = true
+		schedules = append(schedules, Schedule{
+			Provider: provider,
+			Interval: c.Interval,
+			Jitter:   c.Jitter,
+			Tasks:    c.Tasks,
+		})
+	}
+	return schedules
+}
+
+// Run is one execution of a task
+type Run struct {
+	ID        int           `json:"id"`
+	Provider  string        `json:"provider"`
+	Task      Task          `json:"task"`
+	Trigger   string        `json:"trigger"`
+	Status    string        `json:"status"`
+	Details   string        `json:"details,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// Runner performs tasks and returns a short human-readable summary
+type Runner interface {
+	Run(ctx context.Context, provider string, task Task) (string, error)
+}
+
+// RunStore persists run history
+type RunStore interface {
+	RecordRun(run *Run) error
+}
+
+// Status reports a provider schedule and its latest activity
+type Status struct {
+	Provider   string        `json:"provider"`
+	Interval   time.Duration `json:"interval_ns"`
+	Jitter     float64       `json:"jitter"`
+	Tasks      []Task        `json:"tasks"`
+	NextRun    time.Time     `json:"next_run"`
+	LastRun    *time.Time    `json:"last_run,omitempty"`
+	LastStatus string        `json:"last_status,omitempty"`
+	Running    bool          `json:"running"`
+}
+
+// job is the runtime state of one provider schedule
+type job struct {
+	schedule Schedule
+
+	// mu serializes scheduled and manual runs for the provider
+	mu sync.Mutex
+
+	stateMu    sync.Mutex
+	nextRun    time.Time
+	lastRun    *time.Time
+	lastStatus string
+	running    bool
+}
+
+// Scheduler runs each provider's tasks on its own jittered interval
+type Scheduler struct {
+	runner  Runner
+	store   RunStore
+	timeout time.Duration
+	jobs    map[string]*job
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// New creates a scheduler. timeout bounds each task; zero means 5 minutes.
+// A nil store logs runs instead of persisting them.
+func New(runner Runner, store RunStore, timeout time.Duration, schedules ...Schedule) (*Scheduler, error) {
+	if runner == nil {
+		return nil, fmt.Errorf("runner is required")
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+
+	jobs := make(map[string]*job, len(schedules))
+	for _, sc := range schedules {
+		if sc.Provider == "" {
+			return nil, fmt.Errorf("schedule provider is required")
+		}
+		if _, dup := jobs[sc.Provider]; dup {
+			return nil, fmt.Errorf("duplicate schedule for provider %s", sc.Provider)
+		}
+		if sc.Interval < time.Minute {
+			return nil, fmt.Errorf("schedule for %s: interval must be at least 1m", sc.Provider)
+		}
+		if sc.Jitter < 0 || sc.Jitter > 1 {
+			return nil, fmt.Errorf("schedule for %s: jitter must be between 0 and 1", sc.Provider)
+		}
+		if len(sc.Tasks) == 0 {
+			sc.Tasks = AllTasks
+		}
+		for _, t := range sc.Tasks {
+			if _, err := ParseTask(string(t)); err != nil {
+				return nil, fmt.Errorf("schedule for %s: %w", sc.Provider, err)
+			}
+		}
+		jobs[sc.Provider] = &job{schedule: sc}
+	}
+
+	return &Scheduler{
+		runner:  runner,
+		store:   store,
+		timeout: timeout,
+		jobs:    jobs,
+		stop:    make(chan struct{}),
+	}, nil
+}
+
+// Start launches one loop per provider. The first run of each provider is
+// delayed by a random fraction of its interval to spread the load.
+func (s *Scheduler) Start() {
+	s.startOnce.Do(func() {
+		for _, j := range s.jobs {
+			s.wg.Add(1)
+			go s.loop(j)
+		}
+	})
+}
+
+// Stop halts the loops and waits for in-flight runs to finish
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	s.wg.Wait()
+}
+
+// Trigger runs tasks for a provider immediately and returns the runs.
+// No tasks means the provider's scheduled tasks. It waits for any run
+// already in progress for the provider.
+func (s *Scheduler) Trigger(ctx context.Context, provider string, tasks ...Task) ([]*Run, error) {
+	j, ok := s.jobs[provider]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownProvider, provider)
+	}
+	if len(tasks) == 0 {
+		tasks = j.schedule.Tasks
+	}
+	for _, t := range tasks {
+		if _, err := ParseTask(string(t)); err != nil {
+			return nil, err
+		}
+	}
+	return s.runJob(ctx, j, TriggerManual, tasks), nil
+}
+
+// Statuses returns every schedule, ordered by provider
+func (s *Scheduler) Statuses() []Status {
+	statuses := make([]Status, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		j.stateMu.Lock()
+		statuses = append(statuses, Status{
+			Provider:   j.schedule.Provider,
+			Interval:   j.schedule.Interval,
+			Jitter:     j.schedule.Jitter,
+			Tasks:      j.schedule.Tasks,
+			NextRun:    j.nextRun,
+			LastRun:    j.lastRun,
+			LastStatus: j.lastStatus,
+			Running:    j.running,
+		})
+		j.stateMu.Unlock()
+	}
+	sort.Slice(statuses, func(i, k int) bool { return statuses[i].Provider < statuses[k].Provider })
+	return statuses
+}
+
+// loop waits for each jittered interval and runs the provider's tasks
+func (s *Scheduler) loop(j *job) {
+	defer s.wg.Done()
+
+	wait := time.Duration(rand.Int63n(int64(j.schedule.Interval)))
+	for {
+		j.stateMu.Lock()
+		j.nextRun = time.Now().Add(wait)
+		j.stateMu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-s.stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		s.runJob(ctx, j, TriggerScheduled, j.schedule.Tasks)
+		cancel()
+
+		wait = jittered(j.schedule.Interval, j.schedule.Jitter)
+	}
+}
+
+// runJob runs tasks in order, recording each run
+func (s *Scheduler) runJob(ctx context.Context, j *job, trigger string, tasks []Task) []*Run {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.stateMu.Lock()
+	j.running = true
+	j.stateMu.Unlock()
+
+	runs := make([]*Run, 0, len(tasks))
+	status := StatusSuccess
+	for _, task := range tasks {
+		run := s.runTask(ctx, j.schedule.Provider, task, trigger)
+		if run.Status == StatusFailed {
+			status = StatusFailed
+		}
+		runs = append(runs, run)
+	}
+
+	now := time.Now()
+	j.stateMu.Lock()
+	j.running = false
+	j.lastRun = &now
+	j.lastStatus = status
+	j.stateMu.Unlock()
+
+	return runs
+}
+
+// runTask runs a single task with the scheduler timeout and records it
+func (s *Scheduler) runTask(ctx context.Context, provider string, task Task, trigger string) *Run {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	run := &Run{Provider: provider, Task: task, Trigger: trigger, StartedAt: time.Now()}
+	// A panicking task fails its run; the loop and later tasks carry on
+	var details string
+	var err error
+	if perr := crash.Do(fmt.Sprintf("scheduler %s %s", provider, task), func() {
+		details, err = s.runner.Run(ctx, provider, task)
+	}); perr != nil {
+		err = perr
+	}
+	run.Duration = time.Since(run.StartedAt)
+	run.Details = details
+	run.Status = StatusSuccess
+	if err != nil {
+		run.Status = StatusFailed
+		run.Error = err.Error()
+		log.Printf("scheduler: %s %s failed: %v", provider, task, err)
+	}
+
+	if s.store != nil {
+		if err := s.store.RecordRun(run); err != nil {
+			log.Printf("scheduler: failed to record %s %s run: %v", provider, task, err)
+		}
+	}
+	return run
+}
+
+// jittered returns interval randomized by up to ±jitter
+func jittered(interval time.Duration, jitter float64) time.Duration {
+	if jitter == 0 {
+		return interval
+	}
+	delta := (rand.Float64()*2 - 1) * jitter * float64(interval)
+	return interval + time.Duration(delta)
+}
@@ -0,0 +1,229 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRunner records calls, fails the configured task and panics in the
+// panic task
+type fakeRunner struct {
+	mu        sync.Mutex
+	calls     []string
+	failTask  Task
+	panicTask Task
+	block     chan struct{}
+}
+
+func (f *fakeRunner) Run(ctx context.Context, provider string, task Task) (string, error) {
+	if f.block != nil {
+		select {
+		case <-f.block:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	f.mu.Lock()
+	f.calls = append(f.calls, provider+"/"+string(task))
+	f.mu.Unlock()
+	if task == f.failTask {
+		return "", errors.New("endpoint returned 401")
+	}
+	if task == f.panicTask {
+		panic("nil pointer dereference")
+	}
+	return "ok", nil
+}
+
+// memoryRunStore keeps runs in memory
+type memoryRunStore struct {
+	mu   sync.Mutex
+	runs []*Run
+}
+
+func (m *memoryRunStore) RecordRun(run *Run) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run.ID = len(m.runs) + 1
+	m.runs = append(m.runs, run)
+	return nil
+}
+
+func TestNew_Validation(t *testing.T) {
+	runner := &fakeRunner{}
+	tests := []struct {
+		name      string
+		schedules []Schedule
+	}{
+		{"missing provider", []Schedule{{Interval: time.Hour}}},
+		{"short interval", []Schedule{{Provider: "openai", Interval: time.Second}}},
+		{"bad jitter", []Schedule{{Provider: "openai", Interval: time.Hour, Jitter: 1.5}}},
+		{"unknown task", []Schedule{{Provider: "openai", Interval: time.Hour, Tasks: []Task{"deploy"}}}},
+		{"duplicate", []Schedule{{Provider: "openai", Interval: time.Hour}, {Provider: "openai", Interval: 2 * time.Hour}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(runner, nil, 0, tt.schedules...); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	if _, err := New(nil, nil, 0); err == nil {
+		t.Error("expected error for nil runner")
+	}
+}
+
+func TestTrigger(t *testing.T) {
+	runner := &fakeRunner{failTask: TaskValidate}
+	store := &memoryRunStore{}
+	s, err := New(runner, store, time.Second,
+		Schedule{Provider: "openai", Interval: time.Hour},
+		Schedule{Provider: "groq", Interval: time.Hour, Tasks: []Task{TaskPricing}},
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	runs, err := s.Trigger(context.Background(), "openai")
+	if err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("expected 3 runs, got %d", len(runs))
+	}
+	if runs[0].Status != StatusFailed || runs[0].Error == "" || runs[2].Status != StatusSuccess || runs[2].Details != "ok" {
+		t.Errorf("unexpected runs: %+v %+v", runs[0], runs[2])
+	}
+	if runs[0].Trigger != TriggerManual || runs[0].ID == 0 {
+		t.Errorf("expected recorded manual run, got %+v", runs[0])
+	}
+
+	runs, err = s.Trigger(context.Background(), "groq", TaskPricing)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("Trigger() = %v, %v", runs, err)
+	}
+	if len(store.runs) != 4 {
+		t.Errorf("expected 4 recorded runs, got %d", len(store.runs))
+	}
+
+	if _, err := s.Trigger(context.Background(), "acme"); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("expected ErrUnknownProvider, got %v", err)
+	}
+	if _, err := s.Trigger(context.Background(), "openai", "deploy"); err == nil {
+		t.Error("expected error for unknown task")
+	}
+
+	statuses := s.Statuses()
+	if len(statuses) != 2 || statuses[0].Provider != "groq" {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+	if statuses[1].LastRun == nil || statuses[1].LastStatus != StatusFailed {
+		t.Errorf("expected failed last run for openai, got %+v", statuses[1])
+	}
+}
+
+func TestTrigger_Panic(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	runner := &fakeRunner{panicTask: TaskModels}
+	s, err := New(runner, nil, time.Second, Schedule{Provider: "openai", Interval: time.Hour})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	runs, err := s.Trigger(context.Background(), "openai")
+	if err != nil || len(runs) != 3 {
+		t.Fatalf("Trigger() = %v, %v", runs, err)
+	}
+	if runs[1].Status != StatusFailed || !strings.Contains(runs[1].Error, "nil pointer dereference") || runs[2].Status != StatusSuccess {
+		t.Errorf("expected the panicking task to fail and the next to run: %+v %+v", runs[1], runs[2])
+	}
+	if st := s.Statuses()[0]; st.Running || st.LastStatus != StatusFailed {
+		t.Errorf("expected the run to finish as failed, got %+v", st)
+	}
+}
+
+func TestTrigger_Timeout(t *testing.T) {
+	runner := &fakeRunner{block: make(chan struct{})}
+	s, err := New(runner, nil, 10*time.Millisecond, Schedule{Provider: "openai", Interval: time.Hour, Tasks: []Task{TaskModels}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	runs, err := s.Trigger(context.Background(), "openai")
+	if err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+	if runs[0].Status != StatusFailed {
+		t.Errorf("expected timed out run to fail, got %+v", runs[0])
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	runner := &fakeRunner{}
+	s, err := New(runner, nil, time.Second, Schedule{Provider: "openai", Interval: time.Minute, Tasks: []Task{TaskModels}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	s.Start()
+
+	deadline := time.Now().Add(time.Second)
+	for s.Statuses()[0].NextRun.IsZero() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	next := s.Statuses()[0].NextRun
+	if next.IsZero() || next.After(time.Now().Add(time.Minute)) {
+		t.Errorf("expected first run within one interval, got %v", next)
+	}
+
+	// Stop is idempotent
+	s.Stop()
+	s.Stop()
+}
+
+func TestJittered(t *testing.T) {
+	if got := jittered(time.Hour, 0); got != time.Hour {
+		t.Errorf("expected exact interval without jitter, got %v", got)
+	}
+	for i := 0; i < 100; i++ {
+		got := jittered(time.Hour, 0.1)
+		if got < 54*time.Minute || got > 66*time.Minute {
+			t.Fatalf("jittered interval %v outside ±10%%", got)
+		}
+	}
+}
+
+func TestConfigBuild(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Schedules = []Schedule{
+		{Provider: "openai", Interval: time.Hour, Tasks: []Task{TaskPricing}},
+		{Provider: "groq"},
+	}
+
+	schedules := cfg.Build("openai", "anthropic", "anthropic")
+	if len(schedules) != 3 {
+		t.Fatalf("expected 3 schedules, got %+v", schedules)
+	}
+	if schedules[0].Interval != time.Hour || len(schedules[0].Tasks) != 1 || schedules[0].Jitter != 0.1 {
+		t.Errorf("expected override to keep its interval and tasks, got %+v", schedules[0])
+	}
+	if schedules[1].Provider != "groq" || schedules[1].Interval != 6*time.Hour {
+		t.Errorf("expected override to inherit the default interval, got %+v", schedules[1])
+	}
+	if schedules[2].Provider != "anthropic" || schedules[2].Interval != 6*time.Hour {
+		t.Errorf("expected default schedule for anthropic, got %+v", schedules[2])
+	}
+
+	if _, err := New(&fakeRunner{}, nil, cfg.Timeout, schedules...); err != nil {
+		t.Errorf("New() error = %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+// Package secrets encrypts secrets stored in the database with AES-256-GCM.
+//
+// Secrets are sealed with data keys, and the data keys are stored wrapped
+// (encrypted) by a master key that never touches the database. Rotating the
+// master key only rewraps the data keys; sealed values are left as they are.
+//
+//	master, err := secrets.LoadMasterKey("MODELSCAN_MASTER_KEY", "")
+//	ring := secrets.NewKeyring()
+//	ring.Add(1, dataKey)
+//	sealed, err := ring.Seal("sk-...")
+//	plain, err := ring.Open(sealed)
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMasterKeyEnv is the env var LoadMasterKey reads by default
+const DefaultMasterKeyEnv = "MODELSCAN_MASTER_KEY"
+
+// KeySize is the size of master and data keys in bytes (AES-256)
+const KeySize = 32
+
+// sealedPrefix marks a sealed value: enc:v1:<data key id>:<base64 nonce+ciphertext>
+const sealedPrefix = "enc:v1:"
+
+var (
+	// ErrNoKey means a sealed value was read without the key that sealed it
+	ErrNoKey = errors.New("secret is encrypted with an unknown data key")
+	// ErrWrongMasterKey means data keys were wrapped by a different master key
+	ErrWrongMasterKey = errors.New("master key does not match the one that wrapped the data keys")
+)
+
+// GenerateKey returns a new random key
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return key, nil
+}
+
+// IsSealed reports whether a stored value is encrypted
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// MasterKey wraps and unwraps data keys
+type MasterKey struct {
+	aead cipher.AEAD
+	id   string
+}
+
+// NewMasterKey creates a master key from KeySize raw bytes
+func NewMasterKey(raw []byte) (*MasterKey, error) {
+	if len(raw) != KeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", KeySize, len(raw))
+	}
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	return &MasterKey{aead: aead, id: hex.EncodeToString(sum[:8])}, nil
+}
+
+// ParseMasterKey decodes a base64 or hex encoded master key
+func ParseMasterKey(encoded string) (*MasterKey, error) {
+	encoded = strings.TrimSpace(encoded)
+	for _, decode := range []func(string) ([]byte, error){
+		base64.StdEncoding.DecodeString,
+		base64.RawStdEncoding.DecodeString,
+		base64.URLEncoding.DecodeString,
+		base64.RawURLEncoding.DecodeString,
+		hex.DecodeString,
+	} {
+		if raw, err := decode(encoded); err == nil && len(raw) == KeySize {
+			return NewMasterKey(raw)
+		}
+	}
+	return nil, fmt.Errorf("master key must be %d bytes encoded as base64 or hex", KeySize)
+}
+
+// LoadMasterKey reads the master key from an environment variable
+// (default DefaultMasterKeyEnv) or, when command is set, from the output of
+// a command such as a KMS or secret manager CLI. The command runs through
+// sh with a 30s timeout.
+func LoadMasterKey(env, command string) (*MasterKey, error) {
+	if command != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("master key command failed: %w", err)
+		}
+		return ParseMasterKey(string(out))
+	}
+	if env == "" {
+		env = DefaultMasterKeyEnv
+	}
+	value := os.Getenv(env)
+	if value == "" {
+		return nil, fmt.Errorf("master key env %s is not set", env)
+	}
+	return ParseMasterKey(value)
+}
+
+// ID identifies the master key without revealing it
+func (m *MasterKey) ID() string {
+	return m.id
+}
+
+// Wrap encrypts a data key
+func (m *MasterKey) Wrap(dataKey []byte) (string, error) {
+	sealed, err := seal(m.aead, dataKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Unwrap decrypts a data key wrapped by this master key
+func (m *MasterKey) Unwrap(wrapped string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wrapped key: %w", err)
+	}
+	key, err := open(m.aead, data)
+	if err != nil {
+		return nil, ErrWrongMasterKey
+	}
+	return key, nil
+}
+
+// Keyring seals values with the newest data key and opens values sealed
+// with any of its keys. It is safe for concurrent use.
+type Keyring struct {
+	mu     sync.RWMutex
+	keys   map[int]cipher.AEAD
+	active int
+}
+
+// NewKeyring creates an empty keyring
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[int]cipher.AEAD)}
+}
+
+// Add adds a data key; the key with the highest ID seals new values
+func (k *Keyring) Add(id int, dataKey []byte) error {
+	if len(dataKey) != KeySize {
+		return fmt.Errorf("data key %d must be %d bytes, got %d", id, KeySize, len(dataKey))
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = aead
+	if id > k.active {
+		k.active = id
+	}
+	return nil
+}
+
+// Seal encrypts a value
+func (k *Keyring) Seal(plaintext string) (string, error) {
+	k.mu.RLock()
+	id, aead := k.active, k.keys[k.active]
+	k.mu.RUnlock()
+	if aead == nil {
+		return "", errors.New("keyring has no data key")
+	}
+	sealed, err := seal(aead, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return sealedPrefix + strconv.Itoa(id) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a sealed value; values that are not sealed are returned
+// unchanged so plaintext rows stay readable until they are encrypted
+func (k *Keyring) Open(value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+	idText, data, ok := strings.Cut(strings.TrimPrefix(value, sealedPrefix), ":")
+	id, err := strconv.Atoi(idText)
+	if !ok || err != nil {
+		return "", errors.New("malformed encrypted secret")
+	}
+	k.mu.RLock()
+	aead := k.keys[id]
+	k.mu.RUnlock()
+	if aead == nil {
+		return "", ErrNoKey
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted secret: %w", err)
+	}
+	plaintext, err := open(aead, raw)
+	if err != nil {
+		return "", errors.New("failed to decrypt secret: data was modified or the key is wrong")
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts with a random nonce prepended to the ciphertext
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, data []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,111 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeyring(t *testing.T) {
+	ring := NewKeyring()
+	if _, err := ring.Seal("x"); err == nil {
+		t.Error("expected an error sealing without a data key")
+	}
+	k1, _ := GenerateKey()
+	k2, _ := GenerateKey()
+	if err := ring.Add(1, k1); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	old, err := ring.Seal("sk-first")
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if !IsSealed(old) || strings.Contains(old, "sk-first") || !strings.HasPrefix(old, "enc:v1:1:") {
+		t.Errorf("unexpected sealed value %q", old)
+	}
+	if again, _ := ring.Seal("sk-first"); again == old {
+		t.Error("sealing twice should use different nonces")
+	}
+
+	// New values use the newest key; old ones stay readable
+	ring.Add(2, k2)
+	sealed, _ := ring.Seal("sk-second")
+	if !strings.HasPrefix(sealed, "enc:v1:2:") {
+		t.Errorf("expected the newest key, got %q", sealed)
+	}
+	for value, want := range map[string]string{old: "sk-first", sealed: "sk-second", "plain": "plain"} {
+		if got, err := ring.Open(value); err != nil || got != want {
+			t.Errorf("Open(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+
+	tampered := sealed[:len(sealed)-4] + "AAAA"
+	if _, err := ring.Open(tampered); err == nil {
+		t.Error("expected an error for a modified value")
+	}
+	if _, err := NewKeyring().Open(sealed); !errors.Is(err, ErrNoKey) {
+		t.Errorf("expected ErrNoKey, got %v", err)
+	}
+	if _, err := ring.Open("enc:v1:x:abc"); err == nil {
+		t.Error("expected an error for a malformed value")
+	}
+}
+
+func TestMasterKey(t *testing.T) {
+	raw, _ := GenerateKey()
+	for _, encoded := range []string{base64.StdEncoding.EncodeToString(raw), hex.EncodeToString(raw) + "\n"} {
+		if m, err := ParseMasterKey(encoded); err != nil || m.ID() == "" {
+			t.Errorf("ParseMasterKey(%q) = %v", encoded, err)
+		}
+	}
+	if _, err := ParseMasterKey("too-short"); err == nil {
+		t.Error("expected an error for a short key")
+	}
+
+	master, _ := NewMasterKey(raw)
+	dataKey, _ := GenerateKey()
+	wrapped, err := master.Wrap(dataKey)
+	if err != nil {
+		t.Fatalf("Wrap failed: %v", err)
+	}
+	if got, err := master.Unwrap(wrapped); err != nil || string(got) != string(dataKey) {
+		t.Errorf("Unwrap = %x, %v", got, err)
+	}
+
+	otherRaw, _ := GenerateKey()
+	other, _ := NewMasterKey(otherRaw)
+	if other.ID() == master.ID() {
+		t.Error("different keys should have different IDs")
+	}
+	if _, err := other.Unwrap(wrapped); !errors.Is(err, ErrWrongMasterKey) {
+		t.Errorf("expected ErrWrongMasterKey, got %v", err)
+	}
+}
+
+func TestLoadMasterKey(t *testing.T) {
+	raw, _ := GenerateKey()
+	encoded := base64.StdEncoding.EncodeToString(raw)
+	t.Setenv("TEST_MASTER_KEY", encoded)
+
+	fromEnv, err := LoadMasterKey("TEST_MASTER_KEY", "")
+	if err != nil {
+		t.Fatalf("LoadMasterKey(env) failed: %v", err)
+	}
+	fromCommand, err := LoadMasterKey("", "echo "+encoded)
+	if err != nil {
+		t.Fatalf("LoadMasterKey(command) failed: %v", err)
+	}
+	if fromEnv.ID() != fromCommand.ID() {
+		t.Error("env and command should load the same key")
+	}
+
+	if _, err := LoadMasterKey("TEST_MASTER_KEY_UNSET", ""); err == nil {
+		t.Error("expected an error for an unset env var")
+	}
+	if _, err := LoadMasterKey("", "exit 3"); err == nil {
+		t.Error("expected an error for a failing command")
+	}
+}
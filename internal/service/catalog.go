@@ -0,0 +1,53 @@
+package service
+
+import (
+	"log"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/discovery"
+	"github.com/jeffersonwarrior/modelscan/providers"
+)
+
+// recordDiscoveredCatalog snapshots the models a discovery run found
+func (s *Service) recordDiscoveredCatalog(result *discovery.DiscoveryResult) {
+	if s.catalog == nil || result.Provider.ID == "" {
+		return
+	}
+	models := make([]*database.CatalogModel, 0, len(result.Models))
+	for _, m := range result.Models {
+		models = append(models, &database.CatalogModel{
+			ModelID:       m.ID,
+			Name:          m.Name,
+			CostPer1MIn:   m.CostPer1MIn,
+			CostPer1MOut:  m.CostPer1MOut,
+			ContextWindow: m.ContextWindow,
+		})
+	}
+	if _, err := s.catalog.Record(result.Provider.ID, database.CatalogSourceDiscovery, models); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// recordProviderCatalog snapshots a provider's listed models. Providers
+// report unknown prices and context windows as zero, so zeros are stored
+// as unknown rather than as free models.
+func (s *Service) recordProviderCatalog(providerID string, listed []providers.Model) error {
+	if s.catalog == nil {
+		return nil
+	}
+	models := make([]*database.CatalogModel, 0, len(listed))
+	for _, m := range listed {
+		model := &database.CatalogModel{ModelID: m.ID, Name: m.Name}
+		if m.CostPer1MIn > 0 || m.CostPer1MOut > 0 {
+			in, out := m.CostPer1MIn, m.CostPer1MOut
+			model.CostPer1MIn, model.CostPer1MOut = &in, &out
+		}
+		if m.ContextWindow > 0 {
+			window := m.ContextWindow
+			model.ContextWindow = &window
+		}
+		models = append(models, model)
+	}
+	_, err := s.catalog.Record(providerID, database.CatalogSourceScheduler, models)
+	return err
+}
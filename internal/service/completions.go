@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/anomaly"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+	"github.com/jeffersonwarrior/modelscan/internal/webhook"
+)
+
+// OnCompletion registers a hook that runs after every proxied completion,
+// e.g. for quality scoring. It reports false when completion hooks are
+// disabled.
+func (s *Service) OnCompletion(hook proxy.AfterCompletionHook) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.completions == nil {
+		return false
+	}
+	s.completions.Register(hook)
+	return true
+}
+
+// registerCompletionHooks adds the built-in hooks enabled in the config
+func (s *Service) registerCompletionHooks() {
+	if s.config.LogCompletions {
+		s.completions.Register(logCompletion)
+	}
+	// A request its client abandoned says nothing about the provider
+	if s.sla != nil {
+		tracker := s.sla
+		s.completions.Register(func(_ context.Context, c *proxy.Completion) {
+			if c.Status == proxy.StatusClientClosedRequest {
+				return
+			}
+			tracker.Observe(c.Provider, c.Status, c.FirstByte, c.StartedAt)
+		})
+	}
+	if s.latency != nil {
+		collector := s.latency
+		s.completions.Register(func(_ context.Context, c *proxy.Completion) {
+			if c.Status == proxy.StatusClientClosedRequest {
+				return
+			}
+			collector.Observe(c.Provider, c.Status, c.FirstByte, c.StartedAt)
+		})
+	}
+	if s.anomalies != nil {
+		detector, pricer := s.anomalies, s.pricer
+		s.completions.Register(func(_ context.Context, c *proxy.Completion) {
+			// At list prices; cache discounts do not matter for spotting a spike
+			in, out := pricer.Price(c.Model)
+			detector.Observe(anomaly.Sample{
+				Provider: c.Provider,
+				ClientID: c.ClientID,
+				Status:   c.Status,
+				Cost:     (float64(c.PromptTokens)*in + float64(c.CompletionTokens)*out) / 1_000_000,
+				At:       c.StartedAt,
+			})
+		})
+	}
+	if s.experiments != nil {
+		experiments := s.experiments
+		s.completions.Register(func(_ context.Context, c *proxy.Completion) {
+			experiments.Observe(c)
+		})
+	}
+	if s.events != nil {
+		s.completions.Register(s.publishCompletion)
+	}
+	if s.config.NotifyCompletions {
+		s.completions.Register(func(ctx context.Context, c *proxy.Completion) {
+			s.Notify(webhook.EventCompletionFinished, map[string]interface{}{
+				"api":               c.API,
+				"client_id":         c.ClientID,
+				"provider":          c.Provider,
+				"model":             c.Model,
+				"stream":            c.Stream,
+				"status":            c.Status,
+				"finish_reason":     c.FinishReason,
+				"prompt_tokens":     c.PromptTokens,
+				"completion_tokens": c.CompletionTokens,
+				"cached_tokens":     c.CachedTokens,
+				"duration_ms":       c.Duration.Milliseconds(),
+				"disconnected":      c.Disconnected,
+			})
+		})
+	}
+}
+
+// logCompletion logs a completion's final token counts
+func logCompletion(_ context.Context, c *proxy.Completion) {
+	log.Printf("completion: %s %s/%s client=%q status=%d stream=%t finish=%q tokens=%d+%d cached=%d duration=%s disconnected=%t",
+		c.API, c.Provider, c.Model, c.ClientID, c.Status, c.Stream, c.FinishReason,
+		c.PromptTokens, c.CompletionTokens, c.CachedTokens, c.Duration.Round(time.Millisecond), c.Disconnected)
+}
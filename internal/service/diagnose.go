@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/diagnose"
+	"github.com/jeffersonwarrior/modelscan/internal/keymanager"
+)
+
+// providerTester runs connection diagnostics for the admin API against a
+// stored provider with its stored key
+type providerTester struct {
+	db   *database.DB
+	keys *keymanager.KeyManager
+}
+
+// TestProvider implements admin.ProviderTester
+func (t providerTester) TestProvider(ctx context.Context, providerID, model string) (*diagnose.Report, error) {
+	p, err := t.db.GetProvider(providerID)
+	if err != nil || p == nil {
+		return nil, err
+	}
+
+	target := diagnose.Target{
+		Provider:   p.ID,
+		BaseURL:    p.BaseURL,
+		AuthMethod: p.AuthMethod,
+		Model:      model,
+	}
+	if p.AuthHeader != nil {
+		target.AuthHeader = *p.AuthHeader
+	}
+	// A provider without a usable key still gets its network stages
+	// tested; the auth stage reports the missing key
+	target.APIKey, _ = t.keys.GetActualKey(ctx, p.ID)
+
+	return diagnose.Run(ctx, target, diagnose.Options{}), nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/anomaly"
+	"github.com/jeffersonwarrior/modelscan/internal/events"
+	"github.com/jeffersonwarrior/modelscan/internal/forecast"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+	"github.com/jeffersonwarrior/modelscan/internal/scheduler"
+	"github.com/jeffersonwarrior/modelscan/internal/tenant"
+	"github.com/jeffersonwarrior/modelscan/internal/watchdog"
+	"github.com/jeffersonwarrior/modelscan/internal/webhook"
+)
+
+// publish sends an event to the live event stream; it is a no-op when the
+// stream is disabled
+func (s *Service) publish(eventType string, data map[string]interface{}) {
+	if s.events != nil {
+		s.events.Publish(eventType, data)
+	}
+}
+
+// notifyBudget warns that a tenant's spend crossed a budget threshold
+func (s *Service) notifyBudget(a tenant.BudgetAlert) {
+	log.Printf("Warning: tenant %s has spent $%.2f of its $%.2f monthly budget (%.0f%% threshold)",
+		a.TenantID, a.Spend, a.Budget, a.Threshold*100)
+	data := map[string]interface{}{
+		"tenant_id":   a.TenantID,
+		"tenant_name": a.TenantName,
+		"threshold":   a.Threshold,
+		"spend_usd":   a.Spend,
+		"budget_usd":  a.Budget,
+	}
+	s.Notify(webhook.EventBudgetThreshold, data)
+	s.publish(events.TypeBudgetWarning, data)
+}
+
+// notifyForecast warns that spend is projected to exceed a budget before
+// the month is out
+func (s *Service) notifyForecast(f forecast.Forecast) {
+	log.Printf("Warning: %s %s is projected to spend $%.2f this month against a $%.2f budget, reaching it on %s ($%.2f spent so far)",
+		f.Dimension, f.Key, f.Projected, f.Budget, f.ExceedsOn.Format(time.DateOnly), f.MonthToDate)
+	data := map[string]interface{}{
+		"dimension":      f.Dimension,
+		"key":            f.Key,
+		"method":         f.Method,
+		"spend_usd":      f.MonthToDate,
+		"daily_rate_usd": f.DailyRate,
+		"projected_usd":  f.Projected,
+		"budget_usd":     f.Budget,
+		"exceeds_on":     f.ExceedsOn.Format(time.DateOnly),
+	}
+	s.Notify(webhook.EventBudgetForecast, data)
+	s.publish(events.TypeBudgetForecast, data)
+}
+
+// notifyAnomaly alerts on a usage spike
+func (s *Service) notifyAnomaly(a anomaly.Alert) {
+	log.Printf("Warning: %s %s %s is %.4g against a baseline of %.4g in the window from %s",
+		a.Dimension, a.Key, a.Metric, a.Value, a.Baseline, a.WindowStart.Format(time.RFC3339))
+	data := map[string]interface{}{
+		"dimension":      a.Dimension,
+		"key":            a.Key,
+		"metric":         a.Metric,
+		"value":          a.Value,
+		"baseline":       a.Baseline,
+		"stddev":         a.StdDev,
+		"sigma":          a.Sigma,
+		"window_start":   a.WindowStart,
+		"window_seconds": int(a.Window.Seconds()),
+	}
+	s.Notify(webhook.EventUsageAnomaly, data)
+	s.publish(events.TypeUsageAnomaly, data)
+}
+
+// notifyResource warns that a resource reached its ceiling or looks leaked
+func (s *Service) notifyResource(w watchdog.Warning) {
+	msg := "Warning: " + w.String()
+	if w.Dump != "" {
+		msg += " (goroutine dump: " + w.Dump + ")"
+	}
+	log.Print(msg)
+	data := map[string]interface{}{
+		"resource": w.Resource,
+		"kind":     w.Kind,
+		"value":    w.Value,
+		"at":       w.At,
+	}
+	if w.Kind == watchdog.KindTrend {
+		data["baseline"] = w.Baseline
+		data["window_seconds"] = int(w.Window.Seconds())
+	} else {
+		data["limit"] = w.Limit
+	}
+	if w.Dump != "" {
+		data["dump"] = w.Dump
+	}
+	s.Notify(webhook.EventResourceWarning, data)
+	s.publish(events.TypeResourceWarning, data)
+}
+
+// publishCompletion publishes a finished completion to the event stream
+func (s *Service) publishCompletion(_ context.Context, c *proxy.Completion) {
+	s.publish(events.TypeRequestCompleted, map[string]interface{}{
+		"api":               c.API,
+		"client_id":         c.ClientID,
+		"provider":          c.Provider,
+		"model":             c.Model,
+		"stream":            c.Stream,
+		"status":            c.Status,
+		"finish_reason":     c.FinishReason,
+		"prompt_tokens":     c.PromptTokens,
+		"completion_tokens": c.CompletionTokens,
+		"cached_tokens":     c.CachedTokens,
+		"duration_ms":       c.Duration.Milliseconds(),
+		"disconnected":      c.Disconnected,
+	})
+}
+
+// registerEventHooks publishes provider health changes to the event
+// stream. Only changes are published: a provider validated again while
+// healthy raises nothing.
+func (s *Service) registerEventHooks() {
+	var mu sync.Mutex
+	healthy := make(map[string]bool)
+
+	s.hooks.Register(EventProviderValidated, func(data interface{}) error {
+		event, ok := data.(EventData)
+		if !ok {
+			return nil
+		}
+		validated, _ := event.Data["validated"].(bool)
+
+		mu.Lock()
+		was, seen := healthy[event.ProviderID]
+		healthy[event.ProviderID] = validated
+		mu.Unlock()
+		// A provider's first validation is a change only when it fails
+		if (seen && was == validated) || (!seen && validated) {
+			return nil
+		}
+
+		payload := map[string]interface{}{"provider_id": event.ProviderID, "status": "online"}
+		if !validated {
+			payload["status"] = "offline"
+			if reason, ok := event.Data["reason"]; ok {
+				payload["reason"] = reason
+			}
+		}
+		s.publish(events.TypeProviderHealth, payload)
+		return nil
+	})
+}
+
+// runNotifier publishes each finished scheduled task to the event stream
+// as it is recorded
+type runNotifier struct {
+	scheduler.RunStore
+	s *Service
+}
+
+func (n *runNotifier) RecordRun(run *scheduler.Run) error {
+	err := n.RunStore.RecordRun(run)
+	data := map[string]interface{}{
+		"provider":    run.Provider,
+		"task":        run.Task,
+		"trigger":     run.Trigger,
+		"status":      run.Status,
+		"duration_ms": run.Duration.Milliseconds(),
+	}
+	if run.Details != "" {
+		data["details"] = run.Details
+	}
+	if run.Error != "" {
+		data["error"] = run.Error
+	}
+	n.s.publish(events.TypeTaskStatus, data)
+	return err
+}
@@ -87,6 +87,16 @@ func (s *Service) setupHooks() {
 		return s.onProviderValidated(data)
 	})
 
+	// Forward events to webhook subscribers
+	if s.webhooks != nil {
+		s.registerWebhookHooks()
+	}
+
+	// Publish provider health changes to the event stream
+	if s.events != nil {
+		s.registerEventHooks()
+	}
+
 	log.Println("  ✓ Event hooks initialized")
 }
 
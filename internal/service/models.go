@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+	"github.com/jeffersonwarrior/modelscan/internal/remap"
+)
+
+// modelCatalog lists what the proxy can route to for /v1/models: the
+// discovered models of providers with an active key, then the aliases and
+// remapped names that resolve to one of them
+type modelCatalog struct {
+	reads    *readCaches
+	remapper *remap.Engine
+}
+
+// ListModels implements proxy.ModelCatalog
+func (c modelCatalog) ListModels(ctx context.Context, clientID string) ([]proxy.ModelEntry, error) {
+	keyed, err := c.reads.keyed.Get(struct{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	families, err := c.reads.families.Get(struct{}{})
+	if err != nil {
+		return nil, err
+	}
+	providerOf := make(map[string]string, len(families))
+	for _, f := range families {
+		providerOf[f.ID] = f.ProviderID
+	}
+
+	idx, err := c.reads.models.Get(struct{}{})
+	if err != nil {
+		return nil, err
+	}
+	owner := make(map[string]string, len(idx.models))
+	var entries []proxy.ModelEntry
+	for _, m := range idx.models {
+		provider := providerOf[m.FamilyID]
+		if !keyed[provider] {
+			continue
+		}
+		var created int64
+		if m.LastTested != nil {
+			created = m.LastTested.Unix()
+		}
+		owner[m.ID] = provider
+		entries = append(entries, proxy.ModelEntry{ID: m.ID, Object: "model", Created: created, OwnedBy: provider})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	if c.remapper == nil {
+		return entries, nil
+	}
+	for _, name := range c.remapper.Names(clientID) {
+		if _, listed := owner[name]; listed {
+			continue
+		}
+		res := c.remapper.Resolve(name, clientID)
+		provider := res.Provider
+		if provider == "" {
+			provider = owner[res.Model]
+		}
+		if !keyed[provider] {
+			continue
+		}
+		entries = append(entries, proxy.ModelEntry{ID: name, Object: "model", OwnedBy: provider})
+	}
+	return entries, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/cache"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/storage"
+)
+
+// DefaultReadCacheTTL is how long hot database reads are cached when the
+// configuration does not say
+const DefaultReadCacheTTL = time.Minute
+
+// modelIndex is the models table as the request path reads it
+type modelIndex struct {
+	models  []*database.Model
+	prices  map[string][2]float64 // Per-1M-token input and output prices
+	windows map[string]int        // Context windows
+}
+
+// capabilityKey identifies a model served by a provider
+type capabilityKey struct {
+	provider string
+	model    string
+}
+
+// readCaches keeps the database reads of the request path in memory:
+// models with their prices and context windows, model families, the
+// providers with an active key, client configs and model capabilities.
+// They are dropped after admin changes and catalog updates.
+type readCaches struct {
+	cache.Group
+	models       *cache.Cache[struct{}, *modelIndex]
+	families     *cache.Cache[struct{}, []*database.ModelFamily]
+	keyed        *cache.Cache[struct{}, map[string]bool]
+	clients      *cache.Cache[string, *database.Client]
+	capabilities *cache.Cache[capabilityKey, *storage.ModelCapabilities]
+}
+
+// newReadCaches creates the caches in front of db
+func newReadCaches(db *database.DB, ttl time.Duration) *readCaches {
+	clients := database.NewClientRepository(db)
+	r := &readCaches{
+		models: cache.New("models", ttl, func(struct{}) (*modelIndex, error) {
+			models, err := db.ListModels()
+			if err != nil {
+				return nil, err
+			}
+			return indexModels(models), nil
+		}),
+		families: cache.New("model_families", ttl, func(struct{}) ([]*database.ModelFamily, error) {
+			return db.ListModelFamilies()
+		}),
+		keyed: cache.New("keyed_providers", ttl, func(struct{}) (map[string]bool, error) {
+			keys, err := db.ListAPIKeys()
+			if err != nil {
+				return nil, err
+			}
+			keyed := make(map[string]bool)
+			for _, k := range keys {
+				if k.Active {
+					keyed[k.ProviderID] = true
+				}
+			}
+			return keyed, nil
+		}),
+		clients: cache.New("clients", ttl, clients.Get),
+		capabilities: cache.New("model_capabilities", ttl, func(k capabilityKey) (*storage.ModelCapabilities, error) {
+			return storage.GetModelCapabilities(k.provider, k.model)
+		}),
+	}
+	r.Add(r.models, r.families, r.keyed, r.clients, r.capabilities)
+	return r
+}
+
+// indexModels maps model IDs to their prices and context windows
+func indexModels(models []*database.Model) *modelIndex {
+	idx := &modelIndex{
+		models:  models,
+		prices:  make(map[string][2]float64, len(models)),
+		windows: make(map[string]int, len(models)),
+	}
+	for _, m := range models {
+		var p [2]float64
+		if m.CostPer1MIn != nil {
+			p[0] = *m.CostPer1MIn
+		}
+		if m.CostPer1MOut != nil {
+			p[1] = *m.CostPer1MOut
+		}
+		idx.prices[m.ID] = p
+		if m.ContextWindow != nil && *m.ContextWindow > 0 {
+			idx.windows[m.ID] = *m.ContextWindow
+		}
+	}
+	return idx
+}
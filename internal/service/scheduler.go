@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jeffersonwarrior/modelscan/config"
+	"github.com/jeffersonwarrior/modelscan/internal/scheduler"
+	"github.com/jeffersonwarrior/modelscan/providers"
+	"github.com/jeffersonwarrior/modelscan/scraper"
+)
+
+// providerRunner runs scheduled maintenance tasks against registered providers
+type providerRunner struct {
+	s *Service
+
+	// apiKey resolves a provider's key; keys are looked up on every run so
+	// rotated keys are picked up without a restart
+	apiKey func(provider string) (string, error)
+}
+
+func newProviderRunner(s *Service) *providerRunner {
+	return &providerRunner{s: s, apiKey: configAPIKey}
+}
+
+// configAPIKey loads a provider's key from the modelscan configuration
+func configAPIKey(provider string) (string, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg.GetAPIKey(provider)
+}
+
+// keyedProviders returns the registered providers that have an API key
+func keyedProviders() []string {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Printf("Warning: failed to load config for scheduler: %v", err)
+		return nil
+	}
+
+	var names []string
+	for _, name := range providers.ListProviders() {
+		if key, err := cfg.GetAPIKey(name); err == nil && key != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Run implements scheduler.Runner
+func (r *providerRunner) Run(ctx context.Context, provider string, task scheduler.Task) (string, error) {
+	switch task {
+	case scheduler.TaskValidate:
+		return r.validate(ctx, provider)
+	case scheduler.TaskModels:
+		return r.models(ctx, provider)
+	case scheduler.TaskPricing:
+		result, err := scraper.RefreshProvider(provider)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d rate limits, %d prices (%d changed)", result.RateLimits, result.Pricing, result.PriceChanges), nil
+	default:
+		return "", fmt.Errorf("unsupported task %q", task)
+	}
+}
+
+// validate re-runs endpoint validation and raises a provider validation event.
+// Providers in a maintenance window are skipped so expected failures are not
+// reported as outages.
+func (r *providerRunner) validate(ctx context.Context, provider string) (string, error) {
+	if r.s.windows != nil {
+		if _, down := r.s.windows.Unavailable(provider); down {
+			return "skipped: provider in maintenance", nil
+		}
+	}
+
+	p, err := r.provider(provider)
+	if err != nil {
+		return "", err
+	}
+
+	validateErr := p.ValidateEndpoints(ctx, false)
+	working, failed := 0, 0
+	for _, ep := range p.GetEndpoints() {
+		switch ep.Status {
+		case providers.StatusWorking:
+			working++
+		case providers.StatusFailed:
+			failed++
+		}
+	}
+
+	data := map[string]interface{}{"validated": true, "working": working, "failed": failed}
+	switch {
+	case validateErr != nil:
+		data["validated"] = false
+		data["reason"] = validateErr.Error()
+	case working == 0 && failed > 0:
+		data["validated"] = false
+		data["reason"] = fmt.Sprintf("all %d endpoints failed", failed)
+	}
+	r.trigger(EventData{Type: EventProviderValidated, ProviderID: provider, Data: data})
+
+	if validateErr != nil {
+		return "", fmt.Errorf("validation failed: %w", validateErr)
+	}
+	details := fmt.Sprintf("%d endpoints working, %d failed", working, failed)
+	if working == 0 && failed > 0 {
+		return details, fmt.Errorf("all %d endpoints failed", failed)
+	}
+	return details, nil
+}
+
+// models refreshes the provider's model list, drops the cached aggregate
+// and snapshots the catalog
+func (r *providerRunner) models(ctx context.Context, provider string) (string, error) {
+	p, err := r.provider(provider)
+	if err != nil {
+		return "", err
+	}
+
+	models, err := p.ListModels(ctx, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to list models: %w", err)
+	}
+	r.s.InvalidateModelCache()
+	if err := r.s.recordProviderCatalog(provider, models); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return fmt.Sprintf("%d models", len(models)), nil
+}
+
+// provider creates a provider client using the configured API key
+func (r *providerRunner) provider(name string) (providers.Provider, error) {
+	factory, ok := providers.GetProviderFactory(name)
+	if !ok {
+		return nil, fmt.Errorf("provider %s is not registered", name)
+	}
+	key, err := r.apiKey(name)
+	if err != nil {
+		return nil, err
+	}
+	return factory(key), nil
+}
+
+func (r *providerRunner) trigger(event EventData) {
+	if r.s.hooks == nil {
+		return
+	}
+	if err := r.s.TriggerEvent(event); err != nil {
+		log.Printf("Warning: %s event for %s failed: %v", event.Type, event.ProviderID, err)
+	}
+}
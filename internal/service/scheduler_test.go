@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/maintenance"
+	"github.com/jeffersonwarrior/modelscan/internal/scheduler"
+	"github.com/jeffersonwarrior/modelscan/providers"
+)
+
+// fakeProvider implements providers.Provider with fixed endpoint results
+type fakeProvider struct {
+	endpoints   []providers.Endpoint
+	validateErr error
+}
+
+func (p *fakeProvider) ValidateEndpoints(ctx context.Context, verbose bool) error {
+	return p.validateErr
+}
+
+func (p *fakeProvider) ListModels(ctx context.Context, verbose bool) ([]providers.Model, error) {
+	return []providers.Model{{ID: "fake-1"}, {ID: "fake-2"}}, nil
+}
+
+func (p *fakeProvider) GetCapabilities() providers.ProviderCapabilities {
+	return providers.ProviderCapabilities{}
+}
+
+func (p *fakeProvider) GetEndpoints() []providers.Endpoint {
+	return p.endpoints
+}
+
+func (p *fakeProvider) TestModel(ctx context.Context, modelID string, verbose bool) error {
+	return nil
+}
+
+// fixedWindows serves a fixed set of maintenance windows
+type fixedWindows []*database.MaintenanceWindow
+
+func (f fixedWindows) ListCurrentMaintenance(now time.Time) ([]*database.MaintenanceWindow, error) {
+	return f, nil
+}
+
+func TestProviderRunner(t *testing.T) {
+	fake := &fakeProvider{}
+	providers.RegisterProvider("scheduler-fake", func(apiKey string) providers.Provider { return fake })
+
+	s := &Service{modelCache: []ModelWithProvider{{Provider: "scheduler-fake"}}}
+	s.setupHooks()
+	var validations []EventData
+	s.hooks.Register(EventProviderValidated, func(data interface{}) error {
+		validations = append(validations, data.(EventData))
+		return nil
+	})
+
+	runner := newProviderRunner(s)
+	runner.apiKey = func(provider string) (string, error) { return "sk-test", nil }
+	ctx := context.Background()
+
+	fake.endpoints = []providers.Endpoint{{Status: providers.StatusWorking}, {Status: providers.StatusFailed}}
+	details, err := runner.Run(ctx, "scheduler-fake", scheduler.TaskValidate)
+	if err != nil || details != "1 endpoints working, 1 failed" {
+		t.Errorf("Run(validate) = %q, %v", details, err)
+	}
+
+	fake.endpoints = []providers.Endpoint{{Status: providers.StatusFailed}}
+	if _, err := runner.Run(ctx, "scheduler-fake", scheduler.TaskValidate); err == nil {
+		t.Error("expected error when every endpoint fails")
+	}
+
+	fake.validateErr = errors.New("401 unauthorized")
+	if _, err := runner.Run(ctx, "scheduler-fake", scheduler.TaskValidate); err == nil {
+		t.Error("expected validation error")
+	}
+
+	// Health checks are suppressed while the provider is drained
+	windows, err := maintenance.NewTracker(fixedWindows{{Provider: "scheduler-fake", StartsAt: time.Now().Add(-time.Minute)}})
+	if err != nil {
+		t.Fatalf("NewTracker failed: %v", err)
+	}
+	s.windows = windows
+	if details, err := runner.Run(ctx, "scheduler-fake", scheduler.TaskValidate); err != nil || details != "skipped: provider in maintenance" {
+		t.Errorf("Run(validate) in maintenance = %q, %v", details, err)
+	}
+	s.windows = nil
+
+	if len(validations) != 3 {
+		t.Fatalf("expected 3 validation events, got %d", len(validations))
+	}
+	for i, want := range []bool{true, false, false} {
+		if validations[i].Data["validated"] != want {
+			t.Errorf("event %d: expected validated=%v, got %v", i, want, validations[i].Data)
+		}
+	}
+
+	details, err = runner.Run(ctx, "scheduler-fake", scheduler.TaskModels)
+	if err != nil || details != "2 models" {
+		t.Errorf("Run(models) = %q, %v", details, err)
+	}
+	if s.modelCache != nil {
+		t.Error("expected model cache to be invalidated")
+	}
+
+	if _, err := runner.Run(ctx, "not-registered", scheduler.TaskModels); err == nil {
+		t.Error("expected error for unregistered provider")
+	}
+}
@@ -5,31 +5,81 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/jeffersonwarrior/modelscan/config"
 	"github.com/jeffersonwarrior/modelscan/internal/admin"
+	"github.com/jeffersonwarrior/modelscan/internal/anomaly"
+	"github.com/jeffersonwarrior/modelscan/internal/catalog"
+	"github.com/jeffersonwarrior/modelscan/internal/chaos"
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
 	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/deprecation"
 	"github.com/jeffersonwarrior/modelscan/internal/discovery"
+	"github.com/jeffersonwarrior/modelscan/internal/events"
+	"github.com/jeffersonwarrior/modelscan/internal/forecast"
 	"github.com/jeffersonwarrior/modelscan/internal/generator"
+	"github.com/jeffersonwarrior/modelscan/internal/guardrail"
+	"github.com/jeffersonwarrior/modelscan/internal/heatmap"
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+	"github.com/jeffersonwarrior/modelscan/internal/keyhealth"
 	"github.com/jeffersonwarrior/modelscan/internal/keymanager"
+	"github.com/jeffersonwarrior/modelscan/internal/maintenance"
+	"github.com/jeffersonwarrior/modelscan/internal/middleware"
+	"github.com/jeffersonwarrior/modelscan/internal/policy"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+	"github.com/jeffersonwarrior/modelscan/internal/remap"
+	"github.com/jeffersonwarrior/modelscan/internal/scheduler"
+	"github.com/jeffersonwarrior/modelscan/internal/sla"
+	"github.com/jeffersonwarrior/modelscan/internal/tenant"
+	"github.com/jeffersonwarrior/modelscan/internal/vectorstore"
+	"github.com/jeffersonwarrior/modelscan/internal/warmup"
+	"github.com/jeffersonwarrior/modelscan/internal/watchdog"
+	"github.com/jeffersonwarrior/modelscan/internal/webhook"
 	"github.com/jeffersonwarrior/modelscan/providers"
 	"github.com/jeffersonwarrior/modelscan/routing"
+	"github.com/jeffersonwarrior/modelscan/scraper"
+	"github.com/jeffersonwarrior/modelscan/sdk/statuspage"
+	"github.com/jeffersonwarrior/modelscan/storage"
 )
 
 // Service orchestrates all modelscan components
 type Service struct {
 	config     *Config
 	db         *database.DB
+	batch      *database.BatchWriter
 	discovery  *discovery.Agent
 	generator  *generator.Generator
 	keyManager *keymanager.KeyManager
+	remapper   *remap.Engine
+	windows    *maintenance.Tracker
+	statuses   *statuspage.Watcher
+	keyHealth  *keyhealth.Prober
+	retiring   *deprecation.Tracker
+	catalog    *catalog.Recorder
+	openAI     *proxy.OpenAIProxy
+	anthropic  *proxy.AnthropicProxy
 	router     routing.Router
 	adminAPI   *admin.API
+	handler    http.Handler // adminAPI wrapped in the middleware stack
 	httpServer *http.Server
 	hooks      *HookRegistry
+	webhooks   *webhook.Dispatcher
+	events     *events.Hub
+	scheduler  *scheduler.Scheduler
+
+	completions *proxy.CompletionHooks
+	sla         *sla.Tracker
+	latency     *heatmap.Collector
+	anomalies   *anomaly.Detector
+	watchdog    *watchdog.Watchdog
+	forecaster  *forecast.Forecaster
+	pricer      *modelPricerAdapter
+	experiments *proxy.Experiments
+	reads       *readCaches
 
 	mu          sync.RWMutex
 	restarting  atomic.Bool
@@ -58,6 +108,139 @@ type Config struct {
 	CacheDays     int
 	OutputDir     string
 	RoutingMode   string
+
+	// SQLite pragmas (zero values keep SQLite's defaults)
+	DatabaseOptions database.Options
+
+	// Write-behind batching of usage and request log inserts (disabled when nil)
+	DatabaseBatch *database.BatchConfig
+
+	// How long hot database reads are cached (default one minute)
+	ReadCacheTTL time.Duration
+
+	// Shadow traffic mirroring (disabled when ShadowProvider is empty)
+	ShadowProvider   string
+	ShadowModel      string
+	ShadowSampleRate float64
+
+	// Proxy content filtering (disabled when nil)
+	Guardrails *guardrail.Pipeline
+
+	// Webhook event notifications (disabled when nil)
+	Webhooks *webhook.Config
+
+	// Rate limit and pricing database used by the scheduler's pricing task
+	// and the scraper
+	RateLimitDB string
+
+	// Periodic provider re-validation and refresh (disabled when nil)
+	Scheduler *scheduler.Config
+
+	// Provider documentation scraping (disabled when nil; requires RateLimitDB)
+	Scraper *scraper.Config
+
+	// Per-provider upstream connection pools (built-in transports when nil)
+	Transports *mshttp.Pool
+
+	// HMAC signing of upstream provider requests (disabled when nil)
+	Signer mshttp.SignHook
+
+	// Fault injection into upstream provider requests (disabled when nil)
+	Chaos *chaos.Injector
+
+	// Per-model fallback chains for the chat completions proxy (disabled when nil)
+	Fallbacks *proxy.FallbackChains
+
+	// Request transformation rules for both proxies (disabled when nil)
+	Transforms *proxy.TransformRules
+
+	// A/B parameter experiments for both proxies, started with their
+	// results priced from the model catalog (disabled when nil)
+	Experiments []proxy.Experiment
+
+	// Data residency policy for proxy requests (disabled when nil)
+	Residency *proxy.Residency
+
+	// In-flight upstream request caps with load shedding (unlimited when nil)
+	Concurrency *proxy.ConcurrencyLimiter
+
+	// Per-provider cap on the proxies' upstream retries, reported at
+	// /api/retry-budgets (unbudgeted when nil)
+	RetryBudgets *mshttp.RetryBudget
+
+	// Per-model RPM, TPM and in-flight caps for both proxies (disabled when nil)
+	ModelLimits *proxy.ModelLimits
+
+	// Image validation against model capabilities and URL inlining (disabled when nil)
+	Vision *proxy.VisionConfig
+
+	// Cache of embedded inputs for /v1/embeddings (disabled when nil), and
+	// whether its vectors are also stored in the database
+	EmbeddingCache    *proxy.EmbeddingCacheConfig
+	PersistEmbeddings bool
+	VectorStore       *vectorstore.Config
+
+	// Hooks run after each proxied completion (disabled when nil)
+	CompletionHooks *proxy.CompletionHooksConfig
+	// LogCompletions logs the final token counts of each completion
+	LogCompletions bool
+	// NotifyCompletions publishes a completion.finished webhook event per completion
+	NotifyCompletions bool
+
+	// Provider status page polling for incident-aware routing (disabled when nil)
+	StatusPages *statuspage.Config
+
+	// Per-provider SLA recording and reports (disabled when nil)
+	SLA *sla.Config
+
+	// Per-provider latency by hour for heatmaps and the fastest remap
+	// strategy (disabled when nil)
+	Heatmap *heatmap.Config
+
+	// Upstream connection and cache warm-up before the server listens
+	// (disabled when nil)
+	Warmup *warmup.Config
+
+	// Request volume, cost and error rate spike alerts per provider and
+	// client (disabled when nil)
+	Anomaly *anomaly.Config
+
+	// Month-end spend projections with early budget warnings (disabled
+	// when nil)
+	Forecast *forecast.Config
+
+	// Goroutine, open file and database connection leak warnings
+	// (disabled when nil)
+	Watchdog *watchdog.Config
+
+	// Scheduled API key probing (disabled when nil); key health is reported
+	// either way
+	KeyHealth *keyhealth.Config
+
+	// Scheduled alias checks against model retirement dates (disabled when
+	// nil); retirements are reported either way
+	Deprecations *deprecation.Config
+
+	// Keep-alive, write and idle timing of proxied streams (defaults when nil)
+	Streaming *proxy.StreamConfig
+
+	// Longest deadline budget of a proxied request (the proxy default when
+	// zero). Clients ask for less with X-Request-Timeout or timeout_ms.
+	RequestTimeout time.Duration
+
+	// Live event stream at /api/events (disabled when nil)
+	Events *events.Config
+	// Fractions of a tenant's monthly budget that raise budget warnings, as
+	// events and webhooks (tenant.DefaultBudgetThresholds when empty)
+	BudgetThresholds []float64
+
+	// HTTP middleware: request IDs and panic recovery are always on
+	AccessLog bool
+	CORS      *middleware.CORSConfig // browser access (disabled when nil)
+	Gzip      *middleware.GzipConfig // response compression (disabled when nil)
+
+	// Token-protected pprof, expvar and /debug/status (disabled when nil)
+	Debug *admin.DebugConfig
 }
 
 // NewService creates a new service instance
@@ -94,12 +277,30 @@ func (s *Service) Initialize() error {
 	log.Println("Initializing modelscan service...")
 
 	// Initialize database
-	db, err := database.Open(s.config.DatabasePath)
+	db, err := database.OpenWithOptions(s.config.DatabasePath, s.config.DatabaseOptions)
 	if err != nil {
 		return fmt.Errorf("database init failed: %w", err)
 	}
 	s.db = db
 	log.Println("  ✓ Database initialized")
+	if s.config.DatabaseOptions.MasterKey != nil {
+		log.Printf("  ✓ Secrets encrypted at rest (master key %s)", s.config.DatabaseOptions.MasterKey.ID())
+	}
+
+	if s.config.DatabaseBatch != nil {
+		s.batch = database.NewBatchWriter(db, *s.config.DatabaseBatch)
+		log.Println("  ✓ Database write batching enabled")
+	}
+
+	// Hot reads of the request path are cached and dropped on admin changes
+	cacheTTL := s.config.ReadCacheTTL
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultReadCacheTTL
+	}
+	s.reads = newReadCaches(db, cacheTTL)
+
+	// Every discovery run and model list refresh snapshots the catalog
+	s.catalog = catalog.NewRecorder(db, s.notifyCatalogChanged)
 
 	// Initialize discovery agent
 	agent, err := discovery.NewAgent(discovery.Config{
@@ -107,6 +308,7 @@ func (s *Service) Initialize() error {
 		CacheDays:     s.config.CacheDays,
 		MaxRetries:    3,
 		DB:            db,
+		OnDiscovered:  s.recordDiscoveredCatalog,
 	})
 	if err != nil {
 		return fmt.Errorf("discovery agent init failed: %w", err)
@@ -129,10 +331,50 @@ func (s *Service) Initialize() error {
 	keyMgr := keymanager.NewKeyManager(dbAdapter, keymanager.Config{
 		CacheTTL:        5 * time.Minute,
 		DegradeDuration: 15 * time.Minute,
+		OnAuthFailure:   s.notifyKeyDegraded,
 	})
 	s.keyManager = keyMgr
 	log.Println("  ✓ Key manager initialized")
 
+	// Key values are only stored when encryption is enabled
+	storedKeys, err := s.db.ListAPIKeySecrets()
+	if err != nil {
+		return fmt.Errorf("failed to load stored API keys: %w", err)
+	}
+	for keyHash, value := range storedKeys {
+		keyMgr.RegisterActualKey(keyHash, value)
+	}
+	if len(storedKeys) > 0 {
+		log.Printf("  ✓ %d stored API keys decrypted", len(storedKeys))
+	}
+
+	// Initialize model remap engine
+	remapper, err := remap.NewEngine(&remapDatabaseAdapter{db: s.db}, remap.DefaultConfig())
+	if err != nil {
+		return fmt.Errorf("remap engine init failed: %w", err)
+	}
+	s.remapper = remapper
+	log.Println("  ✓ Remap engine initialized")
+
+	// Load provider drains and maintenance windows
+	windows := database.NewMaintenanceRepository(s.db)
+	tracker, err := maintenance.NewTracker(maintenanceDatabaseAdapter{repo: windows})
+	if err != nil {
+		return fmt.Errorf("maintenance tracker init failed: %w", err)
+	}
+	s.windows = tracker
+	log.Println("  ✓ Provider maintenance windows loaded")
+
+	// Cheapest remapping passes over drained providers and, with status
+	// pages enabled, providers with an ongoing incident
+	var availability remap.Availability = tracker
+	if s.config.StatusPages != nil {
+		s.statuses = statuspage.NewWatcher(*s.config.StatusPages)
+		availability = incidentAvailability{windows: tracker, incidents: s.statuses}
+		log.Printf("  ✓ Status page monitoring enabled (%d providers)", len(s.config.StatusPages.Sources))
+	}
+	remapper.SetAvailability(availability)
+
 	// Initialize router
 	routerCfg := routing.DefaultConfig()
 	if s.config.RoutingMode != "" {
@@ -142,19 +384,356 @@ func (s *Service) Initialize() error {
 	if err != nil {
 		return fmt.Errorf("router init failed: %w", err)
 	}
-	s.router = router
+	canaryRouter := routing.NewCanaryRouter(router, &canaryStoreAdapter{repo: database.NewCanaryResultRepository(s.db)})
+	if err := canaryRouter.Restore(); err != nil {
+		return fmt.Errorf("canary restore failed: %w", err)
+	}
+	s.router = canaryRouter
 	log.Println("  ✓ Router initialized")
 
 	// Initialize admin API with adapters
 	s.adminAPI = admin.NewAPI(
 		admin.Config{Host: s.config.ServerHost, Port: s.config.ServerPort},
 		admin.NewDatabaseAdapter(s.db),
-		admin.NewDiscoveryAdapter(s.discovery),
+		&discoveryNotifier{DiscoveryAgent: admin.NewDiscoveryAdapter(s.discovery), s: s},
 		admin.NewGeneratorAdapter(s.generator),
 		admin.NewKeyManagerAdapter(s.keyManager, s.db),
 	)
+
+	aliasAPI := admin.NewAliasAPI(admin.NewDatabaseAliasAdapter(s.db))
+	aliasAPI.SetReloader(s.remapper)
+	s.adminAPI.SetAliasAPI(aliasAPI)
+
+	remapAPI := admin.NewRemapAPI(admin.NewDatabaseRemapAdapter(database.NewRemapRuleRepository(s.db)))
+	remapAPI.SetEngine(s.remapper)
+	s.adminAPI.SetRemapAPI(remapAPI)
+
+	s.adminAPI.SetCanaryAPI(admin.NewCanaryAPI(
+		admin.NewCanaryAdapter(canaryRouter, database.NewCanaryResultRepository(s.db)),
+	))
+	s.adminAPI.SetShadowAPI(admin.NewShadowAPI(admin.NewDatabaseShadowAdapter(s.db)))
+	s.adminAPI.SetExportAPI(admin.NewExportAPI(s.db))
+	bundleAPI := admin.NewBundleAPI(s.db)
+	bundleAPI.SetReloader(s.remapper)
+	s.adminAPI.SetBundleAPI(bundleAPI)
+	s.adminAPI.SetDiagnosticsAPI(admin.NewDiagnosticsAPI(providerTester{db: s.db, keys: s.keyManager}))
+	s.adminAPI.SetDashboardAPI(admin.NewDashboardAPI(admin.NewDatabaseDashboardAdapter(s.db)))
+	s.adminAPI.SetMaintenanceAPI(admin.NewMaintenanceAPI(windows, tracker))
+	s.adminAPI.SetCacheAPI(admin.NewCacheAPI(s.reads))
+	s.adminAPI.SetMutationHook(func(*http.Request) { s.reads.Invalidate() })
+	if s.config.Debug != nil {
+		s.adminAPI.SetDebugAPI(admin.NewDebugAPI(*s.config.Debug))
+		log.Println("  ✓ Debug endpoints enabled at /debug/")
+	}
 	log.Println("  ✓ Admin API initialized")
 
+	// Initialize webhook notifications
+	if s.config.Webhooks != nil {
+		deadLetters := admin.NewDatabaseWebhookAdapter(s.db)
+		dispatcher, err := webhook.NewDispatcher(*s.config.Webhooks, deadLetters)
+		if err != nil {
+			return fmt.Errorf("webhook init failed: %w", err)
+		}
+		s.webhooks = dispatcher
+		s.adminAPI.SetWebhookAPI(admin.NewWebhookAPI(deadLetters, dispatcher))
+		log.Printf("  ✓ Webhooks enabled (%d endpoints)", len(s.config.Webhooks.Endpoints))
+	}
+
+	// Initialize the live event stream
+	if s.config.Events != nil {
+		s.events = events.NewHub(*s.config.Events)
+		s.adminAPI.SetEventStream(s.events)
+		log.Println("  ✓ Event stream enabled at /api/events")
+	}
+
+	// Initialize rate limit and pricing database (process-wide, kept open
+	// across restarts)
+	if s.config.RateLimitDB != "" && storage.GetRateLimitDB() == nil {
+		if err := storage.InitRateLimitDB(s.config.RateLimitDB); err != nil {
+			return fmt.Errorf("rate limit database init failed: %w", err)
+		}
+		log.Println("  ✓ Rate limit database initialized")
+	}
+	if storage.GetRateLimitDB() != nil {
+		s.adminAPI.SetQualityAPI(admin.NewQualityAPI(qualityStoreAdapter{}))
+	}
+
+	// Initialize scheduled provider re-validation and refresh
+	if s.config.Scheduler != nil {
+		runs := admin.NewDatabaseScheduleAdapter(s.db)
+		schedules := s.config.Scheduler.Build(keyedProviders()...)
+		sched, err := scheduler.New(newProviderRunner(s), &runNotifier{RunStore: runs, s: s}, s.config.Scheduler.Timeout, schedules...)
+		if err != nil {
+			return fmt.Errorf("scheduler init failed: %w", err)
+		}
+		s.scheduler = sched
+		s.adminAPI.SetSchedulerAPI(admin.NewSchedulerAPI(sched, runs))
+		log.Printf("  ✓ Scheduler initialized (%d providers)", len(schedules))
+	}
+
+	// Initialize documentation scraper; changes are staged for review
+	if s.config.Scraper != nil {
+		if storage.GetRateLimitDB() == nil {
+			return fmt.Errorf("scraper init failed: rate limit database not configured")
+		}
+		s.adminAPI.SetScraperAPI(admin.NewScraperAPI(scraper.New(*s.config.Scraper)))
+		log.Printf("  ✓ Scraper initialized (%d sources)", len(s.config.Scraper.Sources))
+	}
+
+	// Initialize LLM proxies on the same server
+	openAICfg, anthropicCfg := proxy.DefaultOpenAIProxyConfig(), proxy.DefaultAnthropicProxyConfig()
+	if st := s.config.Streaming; st != nil {
+		openAICfg.StreamWriteTimeout, openAICfg.StreamKeepAlive, openAICfg.StreamIdleTimeout = st.WriteTimeout, st.KeepAlive, st.IdleTimeout
+		anthropicCfg.StreamWriteTimeout, anthropicCfg.StreamKeepAlive, anthropicCfg.StreamIdleTimeout = st.WriteTimeout, st.KeepAlive, st.IdleTimeout
+	}
+	if s.config.RequestTimeout > 0 {
+		openAICfg.Timeout, anthropicCfg.Timeout = s.config.RequestTimeout, s.config.RequestTimeout
+	}
+	s.openAI = proxy.NewOpenAIProxy(openAICfg, s, s.remapper)
+	s.anthropic = proxy.NewAnthropicProxy(anthropicCfg, s, s.remapper)
+	s.openAI.SetCanaries(canaryRoutingAdapter{router: canaryRouter})
+	s.anthropic.SetCanaries(canaryRoutingAdapter{router: canaryRouter})
+	if s.config.ShadowProvider != "" {
+		shadowCfg := proxy.DefaultShadowConfig(s.config.ShadowProvider, s.config.ShadowModel)
+		if s.config.ShadowSampleRate > 0 {
+			shadowCfg.SampleRate = s.config.ShadowSampleRate
+		}
+		if err := s.openAI.SetShadow(shadowCfg, &shadowRecorderAdapter{db: s.db}); err != nil {
+			return fmt.Errorf("shadow mirroring init failed: %w", err)
+		}
+		log.Printf("  ✓ Shadow mirroring of chat completions enabled (%s, %.0f%% sampled)", s.config.ShadowProvider, shadowCfg.SampleRate*100)
+	}
+	s.openAI.SetAvailability(tracker)
+	s.anthropic.SetAvailability(tracker)
+	if s.statuses != nil {
+		s.openAI.SetIncidents(s.statuses)
+	}
+	limits := &contextLimitsAdapter{reads: s.reads}
+	s.openAI.SetContextLimits(limits)
+	s.anthropic.SetContextLimits(limits)
+	timeouts := clientTimeoutsAdapter{reads: s.reads}
+	s.openAI.SetRequestTimeouts(timeouts)
+	s.anthropic.SetRequestTimeouts(timeouts)
+	tenants := database.NewTenantRepository(s.db)
+	s.pricer = &modelPricerAdapter{reads: s.reads}
+	tenantMgr := tenant.NewManager(&tenantStoreAdapter{repo: tenants}, s.pricer, time.Minute)
+	s.openAI.SetTenants(tenantMgr)
+	s.anthropic.SetTenants(tenantMgr)
+	tenantMgr.SetBudgetAlerts(s.config.BudgetThresholds, s.notifyBudget)
+	s.openAI.SetPricing(s.pricer)
+	s.anthropic.SetPricing(s.pricer)
+	s.adminAPI.SetRouteAPI(admin.NewRouteAPI(routeExplainerAdapter{openAI: s.openAI, anthropic: s.anthropic}))
+	s.adminAPI.SetTenantAPI(admin.NewTenantAPI(tenants, tenantCacheAdapter{Manager: tenantMgr, keys: s.keyManager}))
+	s.adminAPI.SetReconcileAPI(admin.NewReconcileAPI(tenants))
+	s.adminAPI.SetChargebackAPI(admin.NewChargebackAPI(tenants, database.NewClientRepository(s.db)))
+	log.Println("  ✓ Tenant isolation enabled")
+	policies := database.NewPromptPolicyRepository(s.db)
+	policyEngine, err := policy.NewEngine(promptPolicyDatabaseAdapter{repo: policies})
+	if err != nil {
+		return fmt.Errorf("prompt policy init failed: %w", err)
+	}
+	s.openAI.SetPromptPolicy(policyEngine)
+	s.anthropic.SetPromptPolicy(policyEngine)
+	s.adminAPI.SetPromptPolicyAPI(admin.NewPromptPolicyAPI(policies, policyEngine))
+	log.Println("  ✓ System prompt policies enabled")
+	if s.config.Guardrails != nil {
+		s.openAI.SetGuardrails(s.config.Guardrails)
+		s.anthropic.SetGuardrails(s.config.Guardrails)
+		s.adminAPI.SetGuardrailAPI(admin.NewGuardrailAPI(s.config.Guardrails))
+		log.Println("  ✓ Guardrails enabled")
+	}
+	var upstream http.RoundTripper
+	if s.config.Transports != nil {
+		upstream = s.config.Transports
+		s.adminAPI.SetTransportAPI(admin.NewTransportAPI(s.config.Transports))
+		log.Printf("  ✓ Upstream connection pools configured (%d provider overrides)", len(s.config.Transports.Overrides()))
+	}
+	if s.config.Signer != nil {
+		upstream = mshttp.SigningTransport(upstream, s.config.Signer)
+		log.Println("  ✓ Upstream request signing enabled")
+	}
+	if upstream != nil {
+		s.openAI.SetTransport(upstream)
+		s.anthropic.SetTransport(upstream)
+	}
+	if s.config.Chaos != nil {
+		s.openAI.SetFaultInjector(s.config.Chaos)
+		s.anthropic.SetFaultInjector(s.config.Chaos)
+		s.adminAPI.SetChaosAPI(admin.NewChaosAPI(s.config.Chaos))
+		log.Printf("  ✓ Fault injection enabled (%d faults; not for production)", len(s.config.Chaos.Faults()))
+	}
+	if s.config.RetryBudgets != nil {
+		s.openAI.SetRetryBudget(s.config.RetryBudgets)
+		s.anthropic.SetRetryBudget(s.config.RetryBudgets)
+		s.adminAPI.SetRetryBudgetAPI(admin.NewRetryBudgetAPI(retryBudgetAdapter{budget: s.config.RetryBudgets}))
+		log.Println("  ✓ Upstream retry budgets enabled")
+	}
+	s.openAI.SetKeyFailover(s)
+	s.anthropic.SetKeyFailover(s)
+	if s.config.Fallbacks != nil {
+		s.openAI.SetFallbackChains(s.config.Fallbacks)
+		s.adminAPI.SetFallbackAPI(admin.NewFallbackAPI(s.config.Fallbacks))
+		log.Printf("  ✓ Fallback chains enabled (%d models)", len(s.config.Fallbacks.Chains()))
+	}
+	if s.config.Transforms != nil {
+		s.openAI.SetTransformRules(s.config.Transforms)
+		s.anthropic.SetTransformRules(s.config.Transforms)
+		s.adminAPI.SetTransformAPI(admin.NewTransformAPI(s.config.Transforms))
+		log.Printf("  ✓ Request transforms enabled (%d rules)", len(s.config.Transforms.Rules()))
+	}
+	if s.config.Experiments != nil {
+		s.experiments = proxy.NewExperiments(s.pricer)
+		for _, e := range s.config.Experiments {
+			if err := s.experiments.SetExperiment(e); err != nil {
+				return fmt.Errorf("experiment %q: %w", e.Name, err)
+			}
+		}
+		s.openAI.SetExperiments(s.experiments)
+		s.anthropic.SetExperiments(s.experiments)
+		s.adminAPI.SetExperimentAPI(admin.NewExperimentAPI(s.experiments))
+		log.Printf("  ✓ A/B experiments enabled (%d running)", len(s.config.Experiments))
+	}
+	if s.config.Residency != nil {
+		s.openAI.SetResidency(s.config.Residency)
+		s.anthropic.SetResidency(s.config.Residency)
+		log.Println("  ✓ Data residency routing enabled")
+	}
+	if s.config.Concurrency != nil {
+		s.openAI.SetConcurrencyLimiter(s.config.Concurrency)
+		s.anthropic.SetConcurrencyLimiter(s.config.Concurrency)
+		s.adminAPI.SetConcurrencyAPI(admin.NewConcurrencyAPI(s.config.Concurrency))
+		log.Println("  ✓ Concurrency limits enabled")
+	}
+	if s.config.ModelLimits != nil {
+		s.openAI.SetModelLimits(s.config.ModelLimits)
+		s.anthropic.SetModelLimits(s.config.ModelLimits)
+		s.adminAPI.SetModelLimitAPI(admin.NewModelLimitAPI(s.config.ModelLimits))
+		log.Printf("  ✓ Model limits enabled (%d models)", len(s.config.ModelLimits.Limits()))
+	}
+	if s.config.Vision != nil {
+		var caps proxy.VisionCapabilities
+		if storage.GetRateLimitDB() != nil {
+			caps = visionCapsAdapter{reads: s.reads}
+		}
+		vision, err := proxy.NewVision(*s.config.Vision, caps)
+		if err != nil {
+			return fmt.Errorf("invalid vision configuration: %w", err)
+		}
+		s.openAI.SetVision(vision)
+		s.anthropic.SetVision(vision)
+		log.Println("  ✓ Image validation enabled")
+	}
+	if s.config.EmbeddingCache != nil {
+		var store proxy.EmbeddingStore
+		if s.config.PersistEmbeddings {
+			store = embeddingStoreAdapter{repo: database.NewEmbeddingRepository(s.db)}
+		}
+		embeddings := proxy.NewEmbeddingCache(*s.config.EmbeddingCache, store)
+		s.openAI.SetEmbeddingCache(embeddings)
+		s.adminAPI.SetEmbeddingCacheAPI(admin.NewEmbeddingCacheAPI(embeddings))
+		log.Printf("  ✓ Embedding cache enabled (%d vectors in memory, persistent: %v)", embeddings.MaxEntries(), embeddings.Persistent())
+	}
+	if s.config.VectorStore != nil {
+		vectors, err := vectorstore.New(database.NewVectorRepository(s.db), *s.config.VectorStore)
+		if err != nil {
+			return fmt.Errorf("vector store init failed: %w", err)
+		}
+		s.adminAPI.HandleFunc(vectorstore.PathPrefix, vectors.ServeHTTP)
+		log.Printf("  ✓ Vector store enabled (%d collections)", len(vectors.Collections()))
+	}
+	if s.config.SLA != nil {
+		tracker, err := sla.NewTracker(database.NewSLARepository(s.db), *s.config.SLA)
+		if err != nil {
+			return fmt.Errorf("sla tracker init failed: %w", err)
+		}
+		s.sla = tracker
+		s.adminAPI.SetSLAAPI(admin.NewSLAAPI(tracker))
+		log.Println("  ✓ Provider SLA tracking enabled")
+	}
+	if s.config.Heatmap != nil {
+		collector, err := heatmap.NewCollector(database.NewLatencyRepository(s.db), *s.config.Heatmap)
+		if err != nil {
+			return fmt.Errorf("latency heatmap init failed: %w", err)
+		}
+		s.latency = collector
+		s.remapper.SetLatencyHistory(collector)
+		s.adminAPI.SetHeatmapAPI(admin.NewHeatmapAPI(collector))
+		log.Println("  ✓ Provider latency heatmaps enabled")
+	}
+	if s.config.Anomaly != nil {
+		cfg := *s.config.Anomaly
+		cfg.OnAlert = s.notifyAnomaly
+		s.anomalies = anomaly.NewDetector(cfg)
+		s.adminAPI.SetAnomalyAPI(admin.NewAnomalyAPI(s.anomalies))
+		log.Println("  ✓ Usage anomaly detection enabled")
+	}
+	if s.config.Watchdog != nil {
+		cfg := *s.config.Watchdog
+		cfg.DBStats = s.db.PoolStats
+		cfg.OnWarning = s.notifyResource
+		s.watchdog = watchdog.New(cfg)
+		s.adminAPI.SetWatchdogAPI(admin.NewWatchdogAPI(s.watchdog))
+		log.Println("  ✓ Resource watchdog enabled")
+	}
+	if s.config.Forecast != nil {
+		cfg := *s.config.Forecast
+		cfg.OnWarning = s.notifyForecast
+		s.forecaster = forecast.New(forecastStoreAdapter{repo: tenants}, cfg)
+		s.adminAPI.SetForecastAPI(admin.NewForecastAPI(s.forecaster))
+		log.Printf("  ✓ Spend forecasting enabled (%s trend)", s.forecaster.Config().Method)
+	}
+	expiryWarning := keyhealth.DefaultExpiryWarning
+	if s.config.KeyHealth != nil {
+		cfg := *s.config.KeyHealth
+		cfg.OnAuthFailure = func(providerID, apiKey string, status int) {
+			s.keyManager.ReportAuthFailure(context.Background(), providerID, apiKey, status)
+		}
+		cfg.OnWarning = s.notifyKeyHealth
+		s.keyHealth = keyhealth.NewProber(s.db, s.keyManager, cfg)
+		expiryWarning = s.keyHealth.ExpiryWarning()
+		log.Println("  ✓ API key health probing enabled")
+	}
+	s.adminAPI.SetKeyHealthAPI(admin.NewKeyHealthAPI(s.db, expiryWarning))
+	retirementWarning := deprecation.DefaultWarning
+	if s.config.Deprecations != nil {
+		cfg := *s.config.Deprecations
+		if s.config.Scraper != nil {
+			cfg.Scraper = scraper.New(*s.config.Scraper)
+		}
+		cfg.OnWarning = s.notifyRetiring
+		s.retiring = deprecation.NewTracker(s.db, cfg)
+		retirementWarning = s.retiring.Warning()
+		log.Println("  ✓ Model retirement checks enabled")
+	}
+	s.adminAPI.SetDeprecationAPI(admin.NewDeprecationAPI(s.db, retirementWarning))
+	s.adminAPI.SetCatalogAPI(admin.NewCatalogAPI(s.db))
+	// SLA tracking, latency heatmaps, anomaly detection, the event stream
+	// and experiment results observe completions through completion hooks,
+	// so they run them with the default settings when they are not
+	// configured
+	if s.config.CompletionHooks != nil || s.sla != nil || s.latency != nil || s.anomalies != nil || s.events != nil || s.experiments != nil {
+		var hooksCfg proxy.CompletionHooksConfig
+		if s.config.CompletionHooks != nil {
+			hooksCfg = *s.config.CompletionHooks
+		}
+		s.completions = proxy.NewCompletionHooks(hooksCfg)
+		s.registerCompletionHooks()
+		s.openAI.SetCompletionHooks(s.completions)
+		s.anthropic.SetCompletionHooks(s.completions)
+		log.Println("  ✓ Completion hooks enabled")
+	}
+	s.openAI.SetModelCatalog(modelCatalog{reads: s.reads, remapper: s.remapper})
+	s.adminAPI.HandleFunc("/v1/chat/completions", s.openAI.HandleChatCompletions)
+	s.adminAPI.HandleFunc("/v1/messages", s.anthropic.HandleMessages)
+	s.adminAPI.HandleFunc("/v1/embeddings", s.openAI.HandleEmbeddings)
+	s.adminAPI.HandleFunc("/v1/responses", proxy.NewResponsesProxy(s.openAI, s.anthropic).HandleResponses)
+	s.adminAPI.HandleFunc("/v1/messages/count_tokens", s.anthropic.HandleCountTokens)
+	s.adminAPI.HandleFunc("/v1/models", s.openAI.HandleModels)
+	s.adminAPI.HandleFunc("/v1/models/", s.openAI.HandleModels)
+	log.Println("  ✓ Proxy endpoints initialized")
+
+	s.handler = s.buildHandler()
+
 	// Setup event hooks
 	s.setupHooks()
 
@@ -184,36 +763,90 @@ func (s *Service) Bootstrap() error {
 		log.Printf("  - %s (%s)", p.Name, p.Status)
 	}
 
+	warn := keyhealth.DefaultExpiryWarning
+	if s.keyHealth != nil {
+		warn = s.keyHealth.ExpiryWarning()
+	}
+	health, err := keyhealth.Report(s.db, time.Now(), warn)
+	if err != nil {
+		return fmt.Errorf("failed to check key health: %w", err)
+	}
+	for _, h := range health {
+		if h.Warning != "" {
+			log.Printf("Warning: %s key %d (%s) is %s: %s", h.ProviderID, h.KeyID, h.KeyPrefix, h.State, h.Warning)
+		}
+	}
+
+	retirementWarning := deprecation.DefaultWarning
+	if s.retiring != nil {
+		retirementWarning = s.retiring.Warning()
+	}
+	retirements, err := deprecation.Check(s.db, time.Now(), retirementWarning)
+	if err != nil {
+		return fmt.Errorf("failed to check model retirements: %w", err)
+	}
+	for _, w := range retirements.Warnings {
+		log.Printf("Warning: %s", w.Warning)
+	}
+
 	return nil
 }
 
 // Start starts the HTTP server
 func (s *Service) Start() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if !s.initialized {
+	s.mu.RLock()
+	initialized := s.initialized
+	s.mu.RUnlock()
+	if !initialized {
 		return fmt.Errorf("service not initialized - call Initialize() first")
 	}
+	// Before taking the lock: warm-up completions look up keys through it
+	s.warmUp()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	addr := fmt.Sprintf("%s:%d", s.config.ServerHost, s.config.ServerPort)
 	s.httpServer = &http.Server{
 		Addr:    addr,
-		Handler: s.adminAPI,
+		Handler: s.handler,
+	}
+
+	if s.scheduler != nil {
+		s.scheduler.Start()
+	}
+	if s.statuses != nil {
+		s.statuses.Start()
+	}
+	if s.keyHealth != nil {
+		s.keyHealth.Start()
+	}
+	if s.retiring != nil {
+		s.retiring.Start()
 	}
 
 	go func() {
 		log.Printf("✓ HTTP server listening on %s", addr)
 		log.Println("")
+		log.Printf("Dashboard: http://%s/dashboard/", addr)
+		log.Println("")
 		log.Println("Admin API endpoints:")
 		log.Printf("  - GET  http://%s/health", addr)
 		log.Printf("  - GET  http://%s/api/providers", addr)
 		log.Printf("  - POST http://%s/api/providers/add", addr)
 		log.Printf("  - GET  http://%s/api/keys?provider=<id>", addr)
 		log.Printf("  - POST http://%s/api/keys/add", addr)
+		log.Printf("  - GET  http://%s/api/keys/health", addr)
 		log.Printf("  - GET  http://%s/api/sdks", addr)
 		log.Printf("  - GET  http://%s/api/stats?model=<id>", addr)
 		log.Println("")
+		log.Println("Proxy endpoints:")
+		log.Printf("  - POST http://%s/v1/chat/completions", addr)
+		log.Printf("  - POST http://%s/v1/messages", addr)
+		log.Printf("  - POST http://%s/v1/messages/count_tokens", addr)
+		log.Printf("  - POST http://%s/v1/responses", addr)
+		log.Printf("  - GET  http://%s/v1/models", addr)
+		log.Println("")
 
 		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Printf("HTTP server error: %v", err)
@@ -234,6 +867,11 @@ func (s *Service) Stop() error {
 
 	log.Println("Stopping service...")
 
+	// End event streams, which would otherwise hold the shutdown open
+	if s.events != nil {
+		s.events.Close()
+	}
+
 	// Shutdown HTTP server with timeout
 	if s.httpServer != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -244,6 +882,32 @@ func (s *Service) Stop() error {
 		}
 	}
 
+	// Let in-flight shadow requests finish recording before the database closes
+	if s.openAI != nil {
+		s.openAI.WaitShadow()
+	}
+
+	// Let in-flight scheduled runs finish while their results can be recorded
+	if s.scheduler != nil {
+		s.scheduler.Stop()
+		s.scheduler = nil
+	}
+
+	if s.statuses != nil {
+		s.statuses.Stop()
+		s.statuses = nil
+	}
+
+	if s.keyHealth != nil {
+		s.keyHealth.Stop()
+		s.keyHealth = nil
+	}
+
+	if s.retiring != nil {
+		s.retiring.Stop()
+		s.retiring = nil
+	}
+
 	// Close all components
 	if s.router != nil {
 		s.router.Close()
@@ -253,10 +917,55 @@ func (s *Service) Stop() error {
 		s.keyManager.Close()
 	}
 
+	if s.remapper != nil {
+		s.remapper.Close()
+	}
+
 	if s.discovery != nil {
 		s.discovery.Close()
 	}
 
+	// Run hooks for finished completions while they can still notify
+	if s.completions != nil {
+		s.completions.Close()
+		s.completions = nil
+	}
+
+	// Write the last SLA counts, including those from the hooks above
+	if s.sla != nil {
+		s.sla.Close()
+		s.sla = nil
+	}
+	if s.latency != nil {
+		s.latency.Close()
+		s.latency = nil
+	}
+
+	if s.anomalies != nil {
+		s.anomalies.Close()
+		s.anomalies = nil
+	}
+	if s.watchdog != nil {
+		s.watchdog.Close()
+		s.watchdog = nil
+	}
+	if s.forecaster != nil {
+		s.forecaster.Close()
+		s.forecaster = nil
+	}
+
+	// Flush webhook deliveries while dead letters can still be recorded
+	if s.webhooks != nil {
+		s.webhooks.Close()
+		s.webhooks = nil
+	}
+
+	// Commit buffered writes before the database closes
+	if s.batch != nil {
+		s.batch.Close()
+		s.batch = nil
+	}
+
 	if s.db != nil {
 		s.db.Close()
 	}
@@ -279,12 +988,23 @@ func (s *Service) Health() map[string]interface{} {
 		status = "not_initialized"
 	}
 
-	return map[string]interface{}{
+	health := map[string]interface{}{
 		"status":      status,
 		"initialized": s.initialized,
 		"restarting":  restarting,
 		"time":        time.Now(),
 	}
+	if s.batch != nil {
+		health["db_batch"] = s.batch.Stats()
+	}
+	if s.statuses != nil {
+		incidents := s.statuses.Incidents()
+		if incidents == nil {
+			incidents = []statuspage.Incident{}
+		}
+		health["incidents"] = incidents
+	}
+	return health
 }
 
 // Restart performs a graceful restart (for SDK reloading)
@@ -374,7 +1094,11 @@ func (s *Service) RegisterClient(providerID string, client routing.Client) error
 	}
 
 	// Check if router is DirectRouter (supports client registration)
-	directRouter, ok := s.router.(*routing.DirectRouter)
+	router := s.router
+	if canaryRouter, ok := router.(*routing.CanaryRouter); ok {
+		router = canaryRouter.Unwrap()
+	}
+	directRouter, ok := router.(*routing.DirectRouter)
 	if !ok {
 		return fmt.Errorf("current router mode does not support client registration")
 	}
@@ -418,6 +1142,7 @@ func (a *keyManagerDatabaseAdapter) ListActiveAPIKeys(providerID string) ([]*key
 			Degraded:      k.Degraded,
 			DegradedUntil: k.DegradedUntil,
 			CreatedAt:     k.CreatedAt,
+			TenantID:      k.TenantID,
 		}
 	}
 	return result, nil
@@ -458,9 +1183,463 @@ func (a *keyManagerDatabaseAdapter) GetAPIKey(id int) (*keymanager.APIKey, error
 		Degraded:      key.Degraded,
 		DegradedUntil: key.DegradedUntil,
 		CreatedAt:     key.CreatedAt,
+		TenantID:      key.TenantID,
 	}, nil
 }
 
+// shadowRecorderAdapter persists proxy shadow comparisons to the database
+type shadowRecorderAdapter struct {
+	db *database.DB
+}
+
+func (a *shadowRecorderAdapter) RecordShadow(record *proxy.ShadowRecord) error {
+	result := &database.ShadowResult{
+		PrimaryProvider:  record.PrimaryProvider,
+		PrimaryModel:     record.PrimaryModel,
+		PrimaryStatus:    record.PrimaryStatus,
+		PrimaryLatencyMs: int(record.PrimaryLatency.Milliseconds()),
+		PrimaryResponse:  record.PrimaryResponse,
+		PrimaryStream:    record.PrimaryStream,
+		ShadowProvider:   record.ShadowProvider,
+		ShadowModel:      record.ShadowModel,
+		ShadowStatus:     record.ShadowStatus,
+		ShadowLatencyMs:  int(record.ShadowLatency.Milliseconds()),
+		ShadowResponse:   record.ShadowResponse,
+		CreatedAt:        record.CreatedAt,
+	}
+	if record.ClientID != "" {
+		result.ClientID = &record.ClientID
+	}
+	if record.PrimaryUsage != nil {
+		result.PrimaryPromptTokens = &record.PrimaryUsage.PromptTokens
+		result.PrimaryCompletionTokens = &record.PrimaryUsage.CompletionTokens
+	}
+	if record.ShadowUsage != nil {
+		result.ShadowPromptTokens = &record.ShadowUsage.PromptTokens
+		result.ShadowCompletionTokens = &record.ShadowUsage.CompletionTokens
+	}
+	if record.ShadowError != "" {
+		result.ShadowError = &record.ShadowError
+	}
+	return a.db.CreateShadowResult(result)
+}
+
+// qualityStoreAdapter serves model quality ratings from the rate limit database
+type qualityStoreAdapter struct{}
+
+func (qualityStoreAdapter) ListModelQuality() ([]storage.ModelQuality, error) {
+	return storage.ListModelQuality()
+}
+
+func (qualityStoreAdapter) GetModelQuality(modelID string) (*storage.ModelQuality, error) {
+	return storage.GetModelQuality(modelID)
+}
+
+func (qualityStoreAdapter) SetModelQuality(q storage.ModelQuality) error {
+	return storage.SetModelQuality(q)
+}
+
+func (qualityStoreAdapter) DeleteModelQuality(modelID string) (bool, error) {
+	return storage.DeleteModelQuality(modelID)
+}
+
+// visionCapsAdapter reads which models accept images from the capability
+// matrix in the rate limit database
+type visionCapsAdapter struct {
+	reads *readCaches
+}
+
+func (a visionCapsAdapter) SupportsVision(provider, model string) (bool, bool) {
+	caps, err := a.reads.capabilities.Get(capabilityKey{provider: provider, model: model})
+	if err != nil || caps == nil {
+		return false, false
+	}
+	return caps.Vision, true
+}
+
+// contextLimitsAdapter serves model context windows from the database and
+// per-client overflow policies from client config
+type contextLimitsAdapter struct {
+	reads *readCaches
+}
+
+func (a *contextLimitsAdapter) ContextWindow(ctx context.Context, model string) int {
+	idx, err := a.reads.models.Get(struct{}{})
+	if err != nil {
+		log.Printf("Warning: failed to load model context windows: %v", err)
+		return 0
+	}
+	return idx.windows[model]
+}
+
+func (a *contextLimitsAdapter) OverflowPolicy(ctx context.Context, clientID string) proxy.OverflowPolicy {
+	if clientID == "" {
+		return proxy.OverflowReject
+	}
+	client, err := a.reads.clients.Get(clientID)
+	if err != nil || client == nil {
+		return proxy.OverflowReject
+	}
+	policy, err := proxy.ParseOverflowPolicy(client.Config.ContextOverflow)
+	if err != nil {
+		return proxy.OverflowReject
+	}
+	return policy
+}
+
+// clientTimeoutsAdapter serves per-client request timeouts from client config
+type clientTimeoutsAdapter struct {
+	reads *readCaches
+}
+
+func (a clientTimeoutsAdapter) RequestTimeout(ctx context.Context, clientID string) time.Duration {
+	if clientID == "" {
+		return 0
+	}
+	client, err := a.reads.clients.Get(clientID)
+	if err != nil || client == nil {
+		return 0
+	}
+	return time.Duration(client.Config.TimeoutMs) * time.Millisecond
+}
+
+// tenantStoreAdapter serves tenants and their usage ledger from the database
+type tenantStoreAdapter struct {
+	repo *database.TenantRepository
+}
+
+func (a *tenantStoreAdapter) GetTenant(id string) (*tenant.Tenant, error) {
+	t, err := a.repo.Get(id)
+	if err != nil || t == nil {
+		return nil, err
+	}
+	return &tenant.Tenant{
+		ID:            t.ID,
+		Name:          t.Name,
+		MonthlyBudget: t.MonthlyBudget,
+		RPMLimit:      t.RPMLimit,
+		TPMLimit:      t.TPMLimit,
+	}, nil
+}
+
+func (a *tenantStoreAdapter) TenantForClient(clientID string) (string, error) {
+	return a.repo.TenantForClient(clientID)
+}
+
+func (a *tenantStoreAdapter) RecordUsage(u tenant.Usage) error {
+	var clientID *string
+	if u.ClientID != "" {
+		clientID = &u.ClientID
+	}
+	return a.repo.RecordUsage(&database.TenantUsage{
+		TenantID:         u.TenantID,
+		ClientID:         clientID,
+		Provider:         u.Provider,
+		Model:            u.Model,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		CachedTokens:     u.CachedTokens,
+		CacheWriteTokens: u.CacheWriteTokens,
+		Cost:             u.Cost,
+		CacheSavings:     u.CacheSavings,
+		CreatedAt:        u.CreatedAt,
+	})
+}
+
+func (a *tenantStoreAdapter) Spend(tenantID string, since time.Time) (float64, error) {
+	return a.repo.Spend(tenantID, since)
+}
+
+// forecastStoreAdapter serves the tenant usage ledger and budgets to the
+// spend forecaster
+type forecastStoreAdapter struct {
+	repo *database.TenantRepository
+}
+
+func (a forecastStoreAdapter) DailySpend(since time.Time) ([]forecast.Spend, error) {
+	rows, err := a.repo.DailySpend(since)
+	if err != nil {
+		return nil, err
+	}
+	spend := make([]forecast.Spend, len(rows))
+	for i, r := range rows {
+		spend[i] = forecast.Spend{Day: r.Day, Tenant: r.TenantID, Client: r.ClientID, Provider: r.Provider, Cost: r.Cost}
+	}
+	return spend, nil
+}
+
+func (a forecastStoreAdapter) TenantBudgets() (map[string]float64, error) {
+	tenants, err := a.repo.List()
+	if err != nil {
+		return nil, err
+	}
+	budgets := make(map[string]float64, len(tenants))
+	for _, t := range tenants {
+		budgets[t.ID] = t.MonthlyBudget
+	}
+	return budgets, nil
+}
+
+// embeddingStoreAdapter stores cached embedding vectors in the database
+type embeddingStoreAdapter struct {
+	repo *database.EmbeddingRepository
+}
+
+func (a embeddingStoreAdapter) GetEmbeddings(keys []string) (map[string][]byte, error) {
+	return a.repo.Get(keys)
+}
+
+func (a embeddingStoreAdapter) PutEmbeddings(vectors map[string][]byte) error {
+	return a.repo.Put(vectors)
+}
+
+func (a embeddingStoreAdapter) PurgeEmbeddings() error {
+	_, err := a.repo.Purge()
+	return err
+}
+
+// modelPricerAdapter prices tenant usage from the database's model costs
+type modelPricerAdapter struct {
+	reads *readCaches
+}
+
+func (a *modelPricerAdapter) Price(model string) (float64, float64) {
+	idx, err := a.reads.models.Get(struct{}{})
+	if err != nil {
+		log.Printf("Warning: failed to load model prices: %v", err)
+		return 0, 0
+	}
+	p := idx.prices[model]
+	return p[0], p[1]
+}
+
+// tenantCacheAdapter drops cached tenant state, and the key manager's
+// cached keys, when an admin changes a tenant
+type tenantCacheAdapter struct {
+	*tenant.Manager
+	keys *keymanager.KeyManager
+}
+
+func (a tenantCacheAdapter) Invalidate(tenantID string) {
+	a.Manager.Invalidate(tenantID)
+	a.keys.Invalidate()
+}
+
+// routeExplainerAdapter explains routing decisions through the proxy that
+// would serve the request
+type routeExplainerAdapter struct {
+	openAI    *proxy.OpenAIProxy
+	anthropic *proxy.AnthropicProxy
+}
+
+func (a routeExplainerAdapter) ExplainRoute(ctx context.Context, api, model, clientID, provider string) (*proxy.RouteDecision, error) {
+	switch api {
+	case "openai":
+		return a.openAI.ExplainRoute(ctx, model, clientID, provider), nil
+	case "anthropic":
+		return a.anthropic.ExplainRoute(ctx, model, clientID, provider), nil
+	}
+	return nil, fmt.Errorf("unknown api %q: must be openai or anthropic", api)
+}
+
+// canaryStoreAdapter persists routing canary results to the database
+type canaryStoreAdapter struct {
+	repo *database.CanaryResultRepository
+}
+
+func (a *canaryStoreAdapter) SaveCanaryResult(result *routing.CanaryResult) error {
+	dbResult := &database.CanaryResult{
+		PolicyID:             result.Policy.ID,
+		ModelPattern:         result.Policy.ModelPattern,
+		CanaryModel:          result.Policy.CanaryModel,
+		CanaryProvider:       result.Policy.CanaryProvider,
+		Percent:              result.Policy.Percent,
+		Status:               string(result.Status),
+		Reason:               result.Reason,
+		BaselineRequests:     result.Baseline.Requests,
+		BaselineErrors:       result.Baseline.Errors,
+		BaselineAvgLatencyMs: float64(result.Baseline.AvgLatency()) / float64(time.Millisecond),
+		CanaryRequests:       result.Canary.Requests,
+		CanaryErrors:         result.Canary.Errors,
+		CanaryAvgLatencyMs:   float64(result.Canary.AvgLatency()) / float64(time.Millisecond),
+	}
+	if quality, ok := result.Baseline.AvgQuality(); ok {
+		dbResult.BaselineAvgQuality = &quality
+	}
+	if quality, ok := result.Canary.AvgQuality(); ok {
+		dbResult.CanaryAvgQuality = &quality
+	}
+	return a.repo.Create(dbResult)
+}
+
+func (a *canaryStoreAdapter) SaveCanaryPolicy(result *routing.CanaryResult) error {
+	p := result.Policy
+	return a.repo.SavePolicy(&database.CanaryPolicy{
+		PolicyID:             p.ID,
+		ModelPattern:         p.ModelPattern,
+		CanaryModel:          p.CanaryModel,
+		CanaryProvider:       p.CanaryProvider,
+		Percent:              p.Percent,
+		MinSamples:           p.MinSamples,
+		PromoteAfter:         p.PromoteAfter,
+		MaxErrorRateIncrease: p.MaxErrorRateIncrease,
+		MaxLatencyRatio:      p.MaxLatencyRatio,
+		MaxQualityDrop:       p.MaxQualityDrop,
+		Status:               string(result.Status),
+		Reason:               result.Reason,
+	})
+}
+
+func (a *canaryStoreAdapter) DeleteCanaryPolicy(id string) error {
+	return a.repo.DeletePolicy(id)
+}
+
+func (a *canaryStoreAdapter) LoadCanaryPolicies() ([]*routing.CanaryResult, error) {
+	policies, err := a.repo.ListPolicies()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*routing.CanaryResult, len(policies))
+	for i, p := range policies {
+		results[i] = &routing.CanaryResult{
+			Policy: routing.CanaryPolicy{
+				ID:                   p.PolicyID,
+				ModelPattern:         p.ModelPattern,
+				CanaryModel:          p.CanaryModel,
+				CanaryProvider:       p.CanaryProvider,
+				Percent:              p.Percent,
+				MinSamples:           p.MinSamples,
+				PromoteAfter:         p.PromoteAfter,
+				MaxErrorRateIncrease: p.MaxErrorRateIncrease,
+				MaxLatencyRatio:      p.MaxLatencyRatio,
+				MaxQualityDrop:       p.MaxQualityDrop,
+			},
+			Status:    routing.CanaryStatus(p.Status),
+			Reason:    p.Reason,
+			UpdatedAt: p.UpdatedAt,
+		}
+	}
+	return results, nil
+}
+
+// canaryRoutingAdapter splits proxied traffic between canaries and their
+// baselines
+type canaryRoutingAdapter struct {
+	router *routing.CanaryRouter
+}
+
+func (a canaryRoutingAdapter) AssignCanary(model, provider string) (proxy.CanaryAssignment, bool) {
+	arm, ok := a.router.Assign(model, provider)
+	if !ok {
+		return proxy.CanaryAssignment{}, false
+	}
+	return proxy.CanaryAssignment{
+		Policy:   arm.PolicyID,
+		Canary:   arm.Canary,
+		Model:    arm.Model,
+		Provider: arm.Provider,
+		Observe: func(failed bool, latency time.Duration) {
+			a.router.Observe(arm, failed, latency)
+		},
+	}, true
+}
+
+// promptPolicyDatabaseAdapter serves enabled prompt policies from the database
+type promptPolicyDatabaseAdapter struct {
+	repo *database.PromptPolicyRepository
+}
+
+func (a promptPolicyDatabaseAdapter) ListEnabledPromptPolicies() ([]*database.PromptPolicy, error) {
+	return a.repo.ListEnabled()
+}
+
+// maintenanceDatabaseAdapter serves current maintenance windows from the database
+type maintenanceDatabaseAdapter struct {
+	repo *database.MaintenanceRepository
+}
+
+func (a maintenanceDatabaseAdapter) ListCurrentMaintenance(now time.Time) ([]*database.MaintenanceWindow, error) {
+	return a.repo.ListCurrent(now)
+}
+
+// retryBudgetAdapter reports the proxies' retry budget to the admin API
+type retryBudgetAdapter struct {
+	budget *mshttp.RetryBudget
+}
+
+func (a retryBudgetAdapter) RetryBudgets() []mshttp.RetryBudgetStats {
+	return a.budget.Stats()
+}
+
+// remapDatabaseAdapter adapts database.DB for remap.Database interface
+type remapDatabaseAdapter struct {
+	db *database.DB
+}
+
+func (a *remapDatabaseAdapter) ListEnabledRemapRules() ([]*remap.Rule, error) {
+	rules, err := database.NewRemapRuleRepository(a.db).ListEnabled()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*remap.Rule, len(rules))
+	for i, r := range rules {
+		result[i] = &remap.Rule{
+			ID:         r.ID,
+			ClientID:   r.ClientID,
+			FromModel:  r.FromModel,
+			ToModel:    r.ToModel,
+			ToProvider: r.ToProvider,
+			Strategy:   r.Strategy,
+			Candidates: r.Candidates,
+			Priority:   r.Priority,
+		}
+	}
+	return result, nil
+}
+
+func (a *remapDatabaseAdapter) ListAliases() ([]*remap.Alias, error) {
+	aliases, err := a.db.ListAllAliases()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*remap.Alias, len(aliases))
+	for i, al := range aliases {
+		result[i] = &remap.Alias{
+			Name:    al.Name,
+			ModelID: al.ModelID,
+		}
+		if al.ClientID != nil {
+			result[i].ClientID = *al.ClientID
+		}
+	}
+	return result, nil
+}
+
+func (a *remapDatabaseAdapter) ListModelPrices() (map[string]float64, error) {
+	models, err := a.db.ListModels()
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64, len(models))
+	for _, m := range models {
+		if m.CostPer1MIn == nil && m.CostPer1MOut == nil {
+			continue
+		}
+		var price float64
+		if m.CostPer1MIn != nil {
+			price += *m.CostPer1MIn
+		}
+		if m.CostPer1MOut != nil {
+			price += *m.CostPer1MOut
+		}
+		prices[m.ID] = price
+	}
+	return prices, nil
+}
+
 // GetKey returns the actual API key string for a provider.
 // Uses the keymanager's round-robin selection to pick the best key.
 func (s *Service) GetKey(ctx context.Context, providerID string) (string, error) {
@@ -474,11 +1653,49 @@ func (s *Service) GetKey(ctx context.Context, providerID string) (string, error)
 	return s.keyManager.GetActualKey(ctx, providerID)
 }
 
+// ReportAuthFailure takes a key a provider rejected with 401 or 403 out of
+// rotation; see keymanager.KeyManager.ReportAuthFailure
+func (s *Service) ReportAuthFailure(ctx context.Context, providerID, apiKey string, status int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return false
+	}
+
+	return s.keyManager.ReportAuthFailure(ctx, providerID, apiKey, status)
+}
+
 // GetProxyURL returns the full proxy URL string (http://host:port)
 func (s *Service) GetProxyURL() string {
 	return fmt.Sprintf("http://%s:%d", s.config.ServerHost, s.config.ServerPort)
 }
 
+// Handler returns the HTTP handler that serves the admin API and the proxy
+// endpoints, for callers that serve the service on their own listener
+func (s *Service) Handler() http.Handler {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.handler
+}
+
+// buildHandler wraps the admin API and proxy routes in the middleware stack
+func (s *Service) buildHandler() http.Handler {
+	mws := []middleware.Middleware{middleware.RequestID, middleware.Recover}
+	if s.config.AccessLog {
+		mws = append(mws, middleware.AccessLog)
+	}
+	if s.config.CORS != nil {
+		mws = append(mws, middleware.CORS(*s.config.CORS))
+		log.Printf("  ✓ CORS enabled for %s", strings.Join(s.config.CORS.AllowedOrigins, ", "))
+	}
+	if s.config.Gzip != nil {
+		mws = append(mws, middleware.Gzip(*s.config.Gzip))
+		log.Println("  ✓ Gzip compression enabled")
+	}
+	return middleware.Chain(s.adminAPI, mws...)
+}
+
 // ListAllModels aggregates models from all providers with caching
 func (s *Service) ListAllModels(ctx context.Context) ([]ModelWithProvider, error) {
 	// Check cache first
@@ -527,14 +1744,20 @@ func (s *Service) ListAllModels(ctx context.Context) ([]ModelWithProvider, error
 		go func(name string, key string, factory providers.ProviderFactory) {
 			defer wg.Done()
 
-			// Create provider instance
-			provider := factory(key)
+			var models []providers.Model
+			var err error
+			if perr := crash.Do("model listing "+name, func() {
+				// Create provider instance
+				provider := factory(key)
 
-			// List models with timeout
-			modelCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-			defer cancel()
+				// List models with timeout
+				modelCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+				defer cancel()
 
-			models, err := provider.ListModels(modelCtx, false)
+				models, err = provider.ListModels(modelCtx, false)
+			}); perr != nil {
+				err = perr
+			}
 			if err != nil {
 				resultsChan <- result{err: fmt.Errorf("%s: %w", name, err)}
 				return
@@ -598,3 +1821,18 @@ func (s *Service) SetModelCacheTTL(ttl time.Duration) {
 	s.modelCacheTTL = ttl
 	s.modelCacheMu.Unlock()
 }
+
+// incidentAvailability treats providers with an ongoing status page
+// incident as unavailable for remapping, alongside maintenance windows
+type incidentAvailability struct {
+	windows   *maintenance.Tracker
+	incidents *statuspage.Watcher
+}
+
+func (a incidentAvailability) Unavailable(provider string) (time.Time, bool) {
+	if until, down := a.windows.Unavailable(provider); down {
+		return until, true
+	}
+	_, degraded := a.incidents.Degraded(provider)
+	return time.Time{}, degraded
+}
@@ -1,11 +1,15 @@
 package service
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/jeffersonwarrior/modelscan/internal/database"
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
 )
 
 func TestNewService(t *testing.T) {
@@ -427,3 +431,46 @@ func TestSetModelCacheTTL(t *testing.T) {
 		t.Errorf("Expected TTL of 10 minutes, got %v", service.modelCacheTTL)
 	}
 }
+
+func TestServiceRetryBudgets(t *testing.T) {
+	dbPath := "test_service_retry_budgets.db"
+	defer os.Remove(dbPath)
+	defer os.RemoveAll("generated_test_retry_budgets")
+
+	budget, err := mshttp.NewRetryBudget(mshttp.RetryBudgetConfig{Retries: 10, Window: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{
+		DatabasePath:  dbPath,
+		ServerHost:    "127.0.0.1",
+		ServerPort:    9997,
+		AgentModel:    "claude-sonnet-4-5",
+		ParallelBatch: 5,
+		CacheDays:     7,
+		OutputDir:     "generated_test_retry_budgets",
+		RoutingMode:   "direct",
+		RetryBudgets:  budget,
+	}
+
+	service := NewService(cfg)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer service.Stop()
+
+	// The proxies' budget is the one reported
+	budget.Allow("openai")
+	rec := httptest.NewRecorder()
+	service.adminAPI.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/retry-budgets", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Budgets []mshttp.RetryBudgetStats `json:"items"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if len(resp.Budgets) != 1 || resp.Budgets[0].Provider != "openai" || resp.Budgets[0].Allowed != 1 || resp.Budgets[0].Capacity != 10 {
+		t.Errorf("unexpected budgets: %+v", resp.Budgets)
+	}
+}
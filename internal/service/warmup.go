@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/jeffersonwarrior/modelscan/internal/warmup"
+)
+
+// warmUp resolves, connects to and optionally sends a tiny completion to
+// each configured provider, and loads the key and price caches, so the
+// first requests after a start do not pay for them
+func (s *Service) warmUp() {
+	if s.config.Warmup == nil {
+		return
+	}
+	cfg := *s.config.Warmup
+	ctx := context.Background()
+
+	providers := cfg.Providers
+	if len(providers) == 0 {
+		stored, err := s.db.ListProviders()
+		if err != nil {
+			log.Printf("Warning: warm-up could not list providers: %v", err)
+		}
+		for _, p := range stored {
+			providers = append(providers, p.ID)
+		}
+	}
+
+	// Looking up each provider's key loads the key cache; providers
+	// without a key could not serve requests, so they are skipped
+	var targets []warmup.Target
+	seen := make(map[string]bool)
+	for _, provider := range providers {
+		if _, err := s.keyManager.GetKey(ctx, provider); err != nil {
+			if len(cfg.Providers) > 0 {
+				log.Printf("  ⚠ Warm-up skips %s: %v", provider, err)
+			}
+			continue
+		}
+		target := s.warmupTarget(provider, cfg.Models[provider])
+		if seen[target.URL] {
+			continue
+		}
+		seen[target.URL] = true
+		targets = append(targets, target)
+	}
+
+	tasks := []warmup.Task{{Name: "model_prices", Run: func(context.Context) error {
+		s.pricer.Price("")
+		return nil
+	}}}
+
+	report := warmup.Run(ctx, targets, tasks, cfg)
+	for _, r := range report.Providers {
+		if step := r.Failed(); step != nil {
+			log.Printf("  ⚠ Warm-up of %s failed at %s: %s", r.Provider, step.Name, step.Error)
+			continue
+		}
+		last := r.Steps[len(r.Steps)-1]
+		log.Printf("  ✓ Warmed up %s (%s done in %.0fms)", r.Provider, last.Name, last.DurationMs)
+	}
+	log.Printf("✓ Warm-up finished in %.0fms", report.DurationMs)
+}
+
+// warmupTarget warms a provider through the proxy that serves it, so the
+// connection lands in the pool its requests use
+func (s *Service) warmupTarget(provider, model string) warmup.Target {
+	type warmer interface {
+		UpstreamURL(provider string) string
+		WarmConnection(ctx context.Context, provider string) error
+		WarmCompletion(ctx context.Context, provider, model string) error
+	}
+	var w warmer = s.openAI
+	if provider == "anthropic" {
+		w = s.anthropic
+	}
+
+	t := warmup.Target{
+		Provider: provider,
+		URL:      w.UpstreamURL(provider),
+		Connect:  func(ctx context.Context) error { return w.WarmConnection(ctx, provider) },
+	}
+	if model != "" {
+		t.Complete = func(ctx context.Context) error { return w.WarmCompletion(ctx, provider, model) }
+	}
+	return t
+}
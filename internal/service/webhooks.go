@@ -0,0 +1,162 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/admin"
+	"github.com/jeffersonwarrior/modelscan/internal/catalog"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+	"github.com/jeffersonwarrior/modelscan/internal/deprecation"
+	"github.com/jeffersonwarrior/modelscan/internal/events"
+	"github.com/jeffersonwarrior/modelscan/internal/keyhealth"
+	"github.com/jeffersonwarrior/modelscan/internal/keymanager"
+	"github.com/jeffersonwarrior/modelscan/internal/webhook"
+)
+
+// Notify publishes a webhook event; it is a no-op when webhooks are disabled
+func (s *Service) Notify(eventType webhook.EventType, data map[string]interface{}) {
+	if s.webhooks != nil {
+		s.webhooks.Publish(eventType, data)
+	}
+}
+
+// notifyKeyDegraded alerts on a key a provider rejected
+func (s *Service) notifyKeyDegraded(f keymanager.AuthFailure) {
+	log.Printf("Warning: %s rejected key %d (%s) with %d; skipping it until %s",
+		f.ProviderID, f.KeyID, f.KeyPrefix, f.Status, f.Until.Format(time.RFC3339))
+	s.Notify(webhook.EventKeyDegraded, map[string]interface{}{
+		"provider_id": f.ProviderID,
+		"key_id":      f.KeyID,
+		"key_prefix":  f.KeyPrefix,
+		"status":      f.Status,
+		"until":       f.Until,
+	})
+}
+
+// notifyKeyHealth warns about a key that is failing probes or close to
+// expiry, alerting on expiry
+func (s *Service) notifyKeyHealth(h *keyhealth.Health) {
+	log.Printf("Warning: %s key %d (%s) is %s: %s", h.ProviderID, h.KeyID, h.KeyPrefix, h.State, h.Warning)
+	if h.State == keyhealth.StateExpiring || h.State == keyhealth.StateExpired {
+		s.Notify(webhook.EventKeyExpiring, map[string]interface{}{
+			"provider_id": h.ProviderID,
+			"key_id":      h.KeyID,
+			"key_prefix":  h.KeyPrefix,
+			"state":       h.State,
+			"expires_at":  h.ExpiresAt,
+		})
+	}
+}
+
+// notifyRetiring warns about an alias pointing at a retired or soon
+// retiring model
+func (s *Service) notifyRetiring(w *deprecation.AliasWarning) {
+	log.Printf("Warning: %s", w.Warning)
+	data := map[string]interface{}{
+		"alias":       w.Alias,
+		"client_id":   w.ClientID,
+		"model_id":    w.ModelID,
+		"provider_id": w.ProviderID,
+		"state":       w.State,
+		"retires_at":  w.RetiresAt,
+		"suggestions": w.Suggestions,
+	}
+	s.Notify(webhook.EventModelRetiring, data)
+	s.publish(events.TypeModelRetiring, data)
+}
+
+// notifyCatalogChanged reports models appearing, disappearing or changing
+// price in a provider's catalog
+func (s *Service) notifyCatalogChanged(u *catalog.Update) {
+	if s.reads != nil {
+		s.reads.Invalidate()
+	}
+	counts := map[string]int{}
+	for _, c := range u.Changes {
+		counts[c.Kind]++
+	}
+	log.Printf("Catalog for %s changed: %d added, %d removed, %d repriced", u.Snapshot.ProviderID,
+		counts[database.CatalogModelAdded], counts[database.CatalogModelRemoved], counts[database.CatalogPriceChanged])
+	data := map[string]interface{}{
+		"provider_id": u.Snapshot.ProviderID,
+		"snapshot_id": u.Snapshot.ID,
+		"source":      u.Snapshot.Source,
+		"model_count": u.Snapshot.ModelCount,
+		"changes":     u.Changes,
+	}
+	s.Notify(webhook.EventCatalogChanged, data)
+	s.publish(events.TypeCatalogChanged, data)
+}
+
+// registerWebhookHooks forwards service events to webhook subscribers
+func (s *Service) registerWebhookHooks() {
+	s.hooks.Register(EventProviderDiscovered, func(data interface{}) error {
+		event, ok := data.(EventData)
+		if !ok {
+			return nil
+		}
+		payload := map[string]interface{}{"provider_id": event.ProviderID}
+		for k, v := range event.Data {
+			payload[k] = v
+		}
+		s.Notify(webhook.EventDiscoveryCompleted, payload)
+		return nil
+	})
+
+	s.hooks.Register(EventProviderValidated, func(data interface{}) error {
+		event, ok := data.(EventData)
+		if !ok {
+			return nil
+		}
+		if validated, _ := event.Data["validated"].(bool); !validated {
+			payload := map[string]interface{}{"provider_id": event.ProviderID, "status": "offline"}
+			if reason, ok := event.Data["reason"]; ok {
+				payload["reason"] = reason
+			}
+			s.Notify(webhook.EventProviderUnhealthy, payload)
+		}
+		return nil
+	})
+}
+
+// discoveryNotifier raises discovery and validation events for discoveries
+// started through the admin API
+type discoveryNotifier struct {
+	admin.DiscoveryAgent
+	s *Service
+}
+
+func (d *discoveryNotifier) Discover(providerID string, apiKey string) (*admin.DiscoveryResult, error) {
+	result, err := d.DiscoveryAgent.Discover(providerID, apiKey)
+
+	event := EventData{Type: EventProviderDiscovered, ProviderID: providerID, Data: map[string]interface{}{}}
+	if err != nil {
+		event.Data["success"] = false
+		event.Data["error"] = err.Error()
+	} else {
+		if result.ProviderID != "" {
+			event.ProviderID = result.ProviderID
+		}
+		event.Data["success"] = result.Success
+		event.Data["provider_name"] = result.ProviderName
+		event.Data["sdk_type"] = result.SDKType
+	}
+	d.trigger(event)
+
+	if err == nil && !result.Success && result.ProviderID != "" {
+		d.trigger(EventData{
+			Type:       EventProviderValidated,
+			ProviderID: result.ProviderID,
+			Data:       map[string]interface{}{"validated": false, "reason": result.Message},
+		})
+	}
+
+	return result, err
+}
+
+func (d *discoveryNotifier) trigger(event EventData) {
+	if err := d.s.TriggerEvent(event); err != nil {
+		log.Printf("Warning: %s event for %s failed: %v", event.Type, event.ProviderID, err)
+	}
+}
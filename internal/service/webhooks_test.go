@@ -0,0 +1,81 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/admin"
+	"github.com/jeffersonwarrior/modelscan/internal/webhook"
+)
+
+// stubDiscovery implements admin.DiscoveryAgent for testing
+type stubDiscovery struct {
+	result *admin.DiscoveryResult
+	err    error
+}
+
+func (d *stubDiscovery) Discover(providerID string, apiKey string) (*admin.DiscoveryResult, error) {
+	return d.result, d.err
+}
+
+func TestDiscoveryNotifier_PublishesWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	var events []webhook.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event webhook.Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("failed to decode webhook: %v", err)
+		}
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	dispatcher, err := webhook.NewDispatcher(webhook.DefaultConfig(webhook.Endpoint{
+		Name: "ops", URL: server.URL, Secret: "s",
+	}), nil)
+	if err != nil {
+		t.Fatalf("NewDispatcher() error = %v", err)
+	}
+
+	s := &Service{webhooks: dispatcher}
+	s.setupHooks()
+
+	notifier := &discoveryNotifier{
+		DiscoveryAgent: &stubDiscovery{result: &admin.DiscoveryResult{
+			ProviderID: "acme", ProviderName: "Acme AI", Success: false, Message: "auth failed",
+		}},
+		s: s,
+	}
+	if _, err := notifier.Discover("acme", "sk-test"); err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	notifier.DiscoveryAgent = &stubDiscovery{err: errors.New("timeout")}
+	if _, err := notifier.Discover("globex", "sk-test"); err == nil {
+		t.Fatal("expected discovery error to be returned")
+	}
+	dispatcher.Close()
+
+	byType := map[webhook.EventType][]webhook.Event{}
+	for _, e := range events {
+		byType[e.Type] = append(byType[e.Type], e)
+	}
+
+	completed := byType[webhook.EventDiscoveryCompleted]
+	if len(completed) != 2 {
+		t.Fatalf("expected 2 discovery.completed events, got %d", len(completed))
+	}
+	unhealthy := byType[webhook.EventProviderUnhealthy]
+	if len(unhealthy) != 1 {
+		t.Fatalf("expected 1 provider.unhealthy event, got %d", len(unhealthy))
+	}
+	if unhealthy[0].Data["provider_id"] != "acme" || unhealthy[0].Data["reason"] != "auth failed" {
+		t.Errorf("unexpected provider.unhealthy data: %v", unhealthy[0].Data)
+	}
+}
@@ -0,0 +1,341 @@
+// Package sla tracks provider availability, error rates and latency target
+// compliance. Request outcomes are counted in memory, flushed to the
+// database as fixed-period rollups and summarized into daily or weekly
+// reports.
+package sla
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// Store persists SLA rollups
+type Store interface {
+	Add(periods []*database.SLAPeriod) error
+	List(since time.Time, provider string) ([]*database.SLAPeriod, error)
+}
+
+// Config configures a Tracker
+type Config struct {
+	// Period is the rollup granularity and the unit of uptime (default 5m)
+	Period time.Duration
+	// FlushInterval is how often counts are written to the store (default 1m)
+	FlushInterval time.Duration
+	// LatencyTarget is the time to first byte a successful request must
+	// meet (default 10s)
+	LatencyTarget time.Duration
+	// Targets overrides LatencyTarget per provider
+	Targets map[string]time.Duration
+	// DownErrorRate is the error rate at which a rollup period counts as
+	// downtime (default 0.5)
+	DownErrorRate float64
+}
+
+// DefaultConfig returns the default tracker configuration
+func DefaultConfig() Config {
+	return Config{
+		Period:        5 * time.Minute,
+		FlushInterval: time.Minute,
+		LatencyTarget: 10 * time.Second,
+		DownErrorRate: 0.5,
+	}
+}
+
+// key identifies an in-memory rollup
+type key struct {
+	provider string
+	start    time.Time
+}
+
+// Tracker counts request outcomes per provider and rollup period
+type Tracker struct {
+	config Config
+	store  Store
+
+	mu      sync.Mutex
+	pending map[key]*database.SLAPeriod
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewTracker creates a tracker and starts flushing counts to store. Zero
+// config fields take their defaults.
+func NewTracker(store Store, cfg Config) (*Tracker, error) {
+	if store == nil {
+		return nil, fmt.Errorf("store is required")
+	}
+	def := DefaultConfig()
+	if cfg.Period <= 0 {
+		cfg.Period = def.Period
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = def.FlushInterval
+	}
+	if cfg.LatencyTarget <= 0 {
+		cfg.LatencyTarget = def.LatencyTarget
+	}
+	if cfg.DownErrorRate <= 0 || cfg.DownErrorRate > 1 {
+		cfg.DownErrorRate = def.DownErrorRate
+	}
+
+	t := &Tracker{
+		config:  cfg,
+		store:   store,
+		pending: make(map[key]*database.SLAPeriod),
+		stop:    make(chan struct{}),
+	}
+	t.wg.Add(1)
+	go t.loop()
+	return t, nil
+}
+
+// Target returns the latency target for a provider
+func (t *Tracker) Target(provider string) time.Duration {
+	if target, ok := t.config.Targets[provider]; ok && target > 0 {
+		return target
+	}
+	return t.config.LatencyTarget
+}
+
+// Observe counts one request. Server errors and rate limiting count
+// against the provider; other client errors count as served. latency is
+// the time to first byte.
+func (t *Tracker) Observe(provider string, status int, latency time.Duration, at time.Time) {
+	if provider == "" {
+		return
+	}
+	k := key{provider: provider, start: at.UTC().Truncate(t.config.Period)}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.pending[k]
+	if !ok {
+		p = &database.SLAPeriod{Provider: provider, PeriodStart: k.start}
+		t.pending[k] = p
+	}
+	p.Requests++
+	if failed(status) {
+		p.Errors++
+		return
+	}
+	p.LatencyMsTotal += latency.Milliseconds()
+	if latency <= t.Target(provider) {
+		p.WithinTarget++
+	}
+}
+
+// failed reports whether a response status counts against the provider
+func failed(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests
+}
+
+// Flush writes pending counts to the store. On error the counts are kept
+// for the next flush.
+func (t *Tracker) Flush() error {
+	t.mu.Lock()
+	if len(t.pending) == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	batch := t.pending
+	t.pending = make(map[key]*database.SLAPeriod)
+	t.mu.Unlock()
+
+	periods := make([]*database.SLAPeriod, 0, len(batch))
+	for _, p := range batch {
+		periods = append(periods, p)
+	}
+	if err := t.store.Add(periods); err != nil {
+		t.mu.Lock()
+		for k, p := range batch {
+			if cur, ok := t.pending[k]; ok {
+				cur.Requests += p.Requests
+				cur.Errors += p.Errors
+				cur.WithinTarget += p.WithinTarget
+				cur.LatencyMsTotal += p.LatencyMsTotal
+			} else {
+				t.pending[k] = p
+			}
+		}
+		t.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Close stops the flush loop and writes the remaining counts
+func (t *Tracker) Close() {
+	t.stopOnce.Do(func() { close(t.stop) })
+	t.wg.Wait()
+	if err := t.Flush(); err != nil {
+		log.Printf("sla: final flush failed: %v", err)
+	}
+}
+
+// loop flushes pending counts every FlushInterval
+func (t *Tracker) loop() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(t.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			_ = crash.Do("sla flush", func() {
+				if err := t.Flush(); err != nil {
+					log.Printf("sla: flush failed: %v", err)
+				}
+			})
+		}
+	}
+}
+
+// Granularity is the length of a report period
+type Granularity string
+
+// Report granularities
+const (
+	Daily  Granularity = "daily"
+	Weekly Granularity = "weekly"
+)
+
+// ParseGranularity validates a granularity name. Empty means Daily.
+func ParseGranularity(s string) (Granularity, error) {
+	switch Granularity(s) {
+	case "", Daily:
+		return Daily, nil
+	case Weekly:
+		return Weekly, nil
+	}
+	return "", fmt.Errorf("invalid period %q (want daily or weekly)", s)
+}
+
+// periodStart returns the start of the report period holding t. Days and
+// weeks are UTC; weeks start on Monday.
+func (g Granularity) periodStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if g == Weekly {
+		day = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return day
+}
+
+// Summary is a provider's SLA figures for one report period or overall.
+// Percentages are 0-100.
+type Summary struct {
+	Start           time.Time `json:"start,omitempty"`
+	Requests        int64     `json:"requests"`
+	Errors          int64     `json:"errors"`
+	ErrorRate       float64   `json:"error_rate"`        // Share of requests that failed
+	Availability    float64   `json:"availability"`      // Share of requests served
+	Uptime          float64   `json:"uptime"`            // Share of rollup periods with traffic that were not down
+	WithinTarget    float64   `json:"within_target"`     // Share of served requests that met the latency target
+	AvgLatencyMs    float64   `json:"avg_latency_ms"`    // Time to first byte of served requests
+	ActivePeriods   int       `json:"active_periods"`    // Rollup periods with traffic
+	DowntimePeriods int       `json:"downtime_periods"`  // Active rollup periods at or above the down error rate
+	LatencyTargetMs int64     `json:"latency_target_ms"` // Current target, for reading WithinTarget
+
+	withinTarget   int64
+	latencyMsTotal int64
+}
+
+// ProviderReport holds a provider's SLA figures per report period
+type ProviderReport struct {
+	Provider string     `json:"provider"`
+	Total    *Summary   `json:"total"`
+	Periods  []*Summary `json:"periods"`
+}
+
+// Report summarizes the rollups since a time per provider and report
+// period. An empty provider reports on every provider.
+func (t *Tracker) Report(since time.Time, g Granularity, provider string) ([]*ProviderReport, error) {
+	periods, err := t.store.List(since, provider)
+	if err != nil {
+		return nil, err
+	}
+	return Summarize(periods, g, t.config.DownErrorRate, t.Target), nil
+}
+
+// Summarize rolls periods up into per-provider reports, by provider name
+// with the oldest period first. target supplies the latency target shown
+// with each provider.
+func Summarize(periods []*database.SLAPeriod, g Granularity, downErrorRate float64, target func(provider string) time.Duration) []*ProviderReport {
+	reports := make(map[string]*ProviderReport)
+	byStart := make(map[string]map[time.Time]*Summary)
+	for _, p := range periods {
+		r, ok := reports[p.Provider]
+		if !ok {
+			r = &ProviderReport{Provider: p.Provider, Total: &Summary{LatencyTargetMs: target(p.Provider).Milliseconds()}}
+			reports[p.Provider] = r
+			byStart[p.Provider] = make(map[time.Time]*Summary)
+		}
+		start := g.periodStart(p.PeriodStart)
+		s, ok := byStart[p.Provider][start]
+		if !ok {
+			s = &Summary{Start: start, LatencyTargetMs: r.Total.LatencyTargetMs}
+			byStart[p.Provider][start] = s
+			r.Periods = append(r.Periods, s)
+		}
+		s.add(p, downErrorRate)
+		r.Total.add(p, downErrorRate)
+	}
+
+	out := make([]*ProviderReport, 0, len(reports))
+	for _, r := range reports {
+		sort.Slice(r.Periods, func(i, j int) bool { return r.Periods[i].Start.Before(r.Periods[j].Start) })
+		for _, s := range r.Periods {
+			s.finish()
+		}
+		r.Total.finish()
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out
+}
+
+// add counts one rollup into the summary
+func (s *Summary) add(p *database.SLAPeriod, downErrorRate float64) {
+	s.Requests += p.Requests
+	s.Errors += p.Errors
+	s.withinTarget += p.WithinTarget
+	s.latencyMsTotal += p.LatencyMsTotal
+	if p.Requests > 0 {
+		s.ActivePeriods++
+		if float64(p.Errors)/float64(p.Requests) >= downErrorRate {
+			s.DowntimePeriods++
+		}
+	}
+}
+
+// finish computes the percentages from the counts. Without traffic every
+// figure reads as met.
+func (s *Summary) finish() {
+	s.Availability, s.Uptime, s.WithinTarget = 100, 100, 100
+	if s.Requests > 0 {
+		s.ErrorRate = percent(s.Errors, s.Requests)
+		s.Availability = 100 - s.ErrorRate
+	}
+	if s.ActivePeriods > 0 {
+		s.Uptime = percent(int64(s.ActivePeriods-s.DowntimePeriods), int64(s.ActivePeriods))
+	}
+	if served := s.Requests - s.Errors; served > 0 {
+		s.WithinTarget = percent(s.withinTarget, served)
+		s.AvgLatencyMs = float64(s.latencyMsTotal) / float64(served)
+	}
+}
+
+// percent returns n as a percentage of total
+func percent(n, total int64) float64 {
+	return float64(n) * 100 / float64(total)
+}
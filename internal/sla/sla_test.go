@@ -0,0 +1,156 @@
+package sla
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// memStore accumulates rollups like the database does
+type memStore struct {
+	mu      sync.Mutex
+	periods map[string]*database.SLAPeriod
+	err     error
+}
+
+func newMemStore() *memStore {
+	return &memStore{periods: make(map[string]*database.SLAPeriod)}
+}
+
+func (m *memStore) Add(periods []*database.SLAPeriod) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	for _, p := range periods {
+		k := p.Provider + p.PeriodStart.String()
+		cur, ok := m.periods[k]
+		if !ok {
+			cp := *p
+			m.periods[k] = &cp
+			continue
+		}
+		cur.Requests += p.Requests
+		cur.Errors += p.Errors
+		cur.WithinTarget += p.WithinTarget
+		cur.LatencyMsTotal += p.LatencyMsTotal
+	}
+	return nil
+}
+
+func (m *memStore) List(since time.Time, provider string) ([]*database.SLAPeriod, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*database.SLAPeriod
+	for _, p := range m.periods {
+		if !p.PeriodStart.Before(since) && (provider == "" || p.Provider == provider) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func TestTracker_ObserveAndReport(t *testing.T) {
+	store := newMemStore()
+	tr, err := NewTracker(store, Config{
+		FlushInterval: time.Hour,
+		LatencyTarget: time.Second,
+		Targets:       map[string]time.Duration{"anthropic": 2 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("NewTracker failed: %v", err)
+	}
+	defer tr.Close()
+
+	// Monday 2 March and Tuesday 3 March 2026
+	mon := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	tue := mon.Add(24 * time.Hour)
+
+	// Monday: one rollup period fully down, one healthy
+	tr.Observe("openai", http.StatusBadGateway, 0, mon)
+	tr.Observe("openai", http.StatusTooManyRequests, 0, mon)
+	tr.Observe("openai", http.StatusOK, 500*time.Millisecond, mon.Add(time.Hour))
+	tr.Observe("openai", http.StatusBadRequest, 3*time.Second, mon.Add(time.Hour)) // Client error, served but slow
+	// Tuesday: healthy
+	tr.Observe("openai", http.StatusOK, 200*time.Millisecond, tue)
+	tr.Observe("anthropic", http.StatusOK, 1500*time.Millisecond, tue)
+	tr.Observe("", http.StatusOK, 0, tue)
+
+	if err := tr.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	reports, err := tr.Report(mon.Add(-time.Hour), Daily, "")
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if len(reports) != 2 || reports[0].Provider != "anthropic" || reports[1].Provider != "openai" {
+		t.Fatalf("unexpected reports: %+v", reports)
+	}
+	if a := reports[0].Total; a.WithinTarget != 100 || a.LatencyTargetMs != 2000 {
+		t.Errorf("anthropic should meet its own target: %+v", a)
+	}
+
+	openai := reports[1]
+	if len(openai.Periods) != 2 || !openai.Periods[0].Start.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected two daily periods, got %+v", openai.Periods)
+	}
+	day := openai.Periods[0]
+	if day.Requests != 4 || day.Errors != 2 || day.Availability != 50 || day.Uptime != 50 || day.WithinTarget != 50 {
+		t.Errorf("unexpected Monday figures: %+v", day)
+	}
+	if day.AvgLatencyMs != 1750 {
+		t.Errorf("AvgLatencyMs = %v, want 1750", day.AvgLatencyMs)
+	}
+	if total := openai.Total; total.Requests != 5 || total.ActivePeriods != 3 || total.DowntimePeriods != 1 {
+		t.Errorf("unexpected total: %+v", total)
+	}
+
+	weekly, err := tr.Report(mon.Add(-time.Hour), Weekly, "openai")
+	if err != nil || len(weekly) != 1 || len(weekly[0].Periods) != 1 || !weekly[0].Periods[0].Start.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected one week starting Monday, got %+v, %v", weekly, err)
+	}
+}
+
+func TestTracker_FlushKeepsCountsOnError(t *testing.T) {
+	store := newMemStore()
+	store.err = errors.New("db down")
+	tr, err := NewTracker(store, Config{FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewTracker failed: %v", err)
+	}
+
+	at := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	tr.Observe("openai", http.StatusOK, time.Second, at)
+	if err := tr.Flush(); err == nil {
+		t.Fatal("expected flush error")
+	}
+	tr.Observe("openai", http.StatusOK, time.Second, at)
+
+	store.mu.Lock()
+	store.err = nil
+	store.mu.Unlock()
+	tr.Close()
+
+	periods, _ := store.List(time.Time{}, "")
+	if len(periods) != 1 || periods[0].Requests != 2 {
+		t.Errorf("expected the failed flush to be retried on close, got %+v", periods)
+	}
+}
+
+func TestParseGranularity(t *testing.T) {
+	if g, err := ParseGranularity(""); err != nil || g != Daily {
+		t.Errorf("ParseGranularity(\"\") = %q, %v", g, err)
+	}
+	if g, err := ParseGranularity("weekly"); err != nil || g != Weekly {
+		t.Errorf("ParseGranularity(weekly) = %q, %v", g, err)
+	}
+	if _, err := ParseGranularity("monthly"); err == nil {
+		t.Error("expected error for an unknown period")
+	}
+}
@@ -0,0 +1,408 @@
+// Package tenant isolates teams that share one modelscan instance.
+//
+// Every client can belong to a tenant. Requests from a tenant's clients are
+// held to the tenant's monthly budget and request and token rate limits,
+// use the tenant's own provider keys when it has any, and are charged to
+// the tenant's usage ledger. Clients without a tenant are unaffected.
+//
+//	m := tenant.NewManager(store, pricer, time.Minute)
+//	id, _ := m.Resolve(clientID)
+//	if err := m.Admit(id, promptTokens); err != nil {
+//		// errors.Is(err, tenant.ErrBudgetExceeded), tenant.ErrRateLimited
+//	}
+//	ctx = tenant.WithTenant(ctx, id) // selects the tenant's keys
+//	...
+//	m.Record(tenant.Usage{TenantID: id, Model: model, PromptTokens: in, CompletionTokens: out})
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/ratelimit"
+)
+
+var (
+	// ErrBudgetExceeded means the tenant has spent its monthly budget
+	ErrBudgetExceeded = errors.New("tenant monthly budget exceeded")
+	// ErrRateLimited means the tenant is over its request or token rate
+	ErrRateLimited = errors.New("tenant rate limit exceeded")
+	// ErrUnknownTenant means a client belongs to a tenant that does not exist
+	ErrUnknownTenant = errors.New("unknown tenant")
+)
+
+// Tenant holds a tenant's budget and limits. Zero values mean unlimited.
+type Tenant struct {
+	ID            string
+	Name          string
+	MonthlyBudget float64 // USD per calendar month
+	RPMLimit      int
+	TPMLimit      int
+}
+
+// Usage is one request charged to a tenant
+type Usage struct {
+	TenantID         string
+	ClientID         string
+	Provider         string
+	Model            string
+	PromptTokens     int // Including cached prompt tokens
+	CompletionTokens int
+	CachedTokens     int     // Prompt tokens read from the provider's cache
+	CacheWriteTokens int     // Prompt tokens written to the provider's cache
+	Cost             float64 // Filled in by Manager.Record when zero
+	CacheSavings     float64 // Cost avoided by caching, net of cache writes; filled in with Cost
+	CreatedAt        time.Time
+}
+
+// Store loads tenants and persists their usage ledger
+type Store interface {
+	// GetTenant returns a tenant, or nil if it does not exist
+	GetTenant(id string) (*Tenant, error)
+	// TenantForClient returns the client's tenant ID, or "" if it has none
+	TenantForClient(clientID string) (string, error)
+	RecordUsage(u Usage) error
+	// Spend returns the tenant's total cost since a point in time
+	Spend(tenantID string, since time.Time) (float64, error)
+}
+
+// DefaultBudgetThresholds are the fractions of a monthly budget whose
+// crossing raises a budget alert
+var DefaultBudgetThresholds = []float64{0.8, 1}
+
+// BudgetAlert reports a tenant's month-to-date spend crossing a fraction
+// of its monthly budget
+type BudgetAlert struct {
+	TenantID   string
+	TenantName string
+	Threshold  float64 // Fraction of the budget, e.g. 0.8
+	Spend      float64
+	Budget     float64
+}
+
+// Pricer prices model usage
+type Pricer interface {
+	// Price returns USD per million input and output tokens, or zeros if
+	// the model's price is unknown
+	Price(model string) (input, output float64)
+}
+
+// Manager enforces tenant budgets and rate limits and records usage.
+// Tenants and client assignments are cached for the manager's TTL;
+// Invalidate applies admin changes immediately.
+type Manager struct {
+	store  Store
+	pricer Pricer
+	ttl    time.Duration
+	now    func() time.Time
+
+	thresholds []float64 // Ascending
+	onBudget   func(BudgetAlert)
+
+	mu      sync.Mutex
+	clients map[string]clientEntry
+	states  map[string]*state
+}
+
+type clientEntry struct {
+	tenantID string
+	loaded   time.Time
+}
+
+// state is a tenant's cached configuration, limiters and spend
+type state struct {
+	tenant Tenant
+	loaded time.Time
+	rpm    *ratelimit.TokenBucket // nil when unlimited
+	tpm    *ratelimit.TokenBucket
+	month  time.Time // Start of the month spend covers
+	spend  float64
+
+	alerted float64 // Highest budget threshold alerted on this month
+}
+
+// NewManager creates a Manager. A zero ttl caches for a minute.
+func NewManager(store Store, pricer Pricer, ttl time.Duration) *Manager {
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	return &Manager{
+		store:   store,
+		pricer:  pricer,
+		ttl:     ttl,
+		now:     time.Now,
+		clients: make(map[string]clientEntry),
+		states:  make(map[string]*state),
+	}
+}
+
+// SetBudgetAlerts calls fn when a tenant's spend crosses a threshold
+// fraction of its monthly budget, at most once per threshold and month.
+// Empty thresholds use DefaultBudgetThresholds. Spend already past a
+// threshold when a tenant is first loaded does not alert again. Call it
+// before the manager is used.
+func (m *Manager) SetBudgetAlerts(thresholds []float64, fn func(BudgetAlert)) {
+	if len(thresholds) == 0 {
+		thresholds = DefaultBudgetThresholds
+	}
+	m.thresholds = append([]float64(nil), thresholds...)
+	sort.Float64s(m.thresholds)
+	m.onBudget = fn
+}
+
+// crossed returns the highest threshold spend has reached, or 0; the
+// caller holds m.mu
+func (m *Manager) crossed(st *state) float64 {
+	var highest float64
+	if st.tenant.MonthlyBudget <= 0 {
+		return 0
+	}
+	for _, t := range m.thresholds {
+		if st.spend >= t*st.tenant.MonthlyBudget {
+			highest = t
+		}
+	}
+	return highest
+}
+
+// Resolve returns the tenant a client belongs to, or "" for shared clients
+func (m *Manager) Resolve(clientID string) (string, error) {
+	if clientID == "" {
+		return "", nil
+	}
+
+	m.mu.Lock()
+	entry, ok := m.clients[clientID]
+	m.mu.Unlock()
+	if ok && m.now().Sub(entry.loaded) < m.ttl {
+		return entry.tenantID, nil
+	}
+
+	tenantID, err := m.store.TenantForClient(clientID)
+	if err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	m.clients[clientID] = clientEntry{tenantID: tenantID, loaded: m.now()}
+	m.mu.Unlock()
+	return tenantID, nil
+}
+
+// Admit checks a request against the tenant's budget and rate limits,
+// charging one request and its prompt tokens to the limits when it is let
+// through
+func (m *Manager) Admit(tenantID string, promptTokens int) error {
+	return m.admit(tenantID, promptTokens, true)
+}
+
+// Check reports whether Admit would let a request through, without
+// charging anything to the limits
+func (m *Manager) Check(tenantID string, promptTokens int) error {
+	return m.admit(tenantID, promptTokens, false)
+}
+
+func (m *Manager) admit(tenantID string, promptTokens int, charge bool) error {
+	if tenantID == "" {
+		return nil
+	}
+	st, err := m.state(tenantID)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	t, spend := st.tenant, st.spend
+	rpm, tpm := st.rpm, st.tpm
+	m.mu.Unlock()
+
+	if t.MonthlyBudget > 0 && spend >= t.MonthlyBudget {
+		return fmt.Errorf("%w: spent $%.2f of $%.2f", ErrBudgetExceeded, spend, t.MonthlyBudget)
+	}
+	if tpm != nil && tpm.GetAvailableTokens() < int64(promptTokens) {
+		return fmt.Errorf("%w: %d tokens per minute", ErrRateLimited, t.TPMLimit)
+	}
+	if !charge {
+		if rpm != nil && rpm.GetAvailableTokens() < 1 {
+			return fmt.Errorf("%w: %d requests per minute", ErrRateLimited, t.RPMLimit)
+		}
+		return nil
+	}
+	if rpm != nil && !rpm.TryAcquire(1) {
+		return fmt.Errorf("%w: %d requests per minute", ErrRateLimited, t.RPMLimit)
+	}
+	if tpm != nil {
+		tpm.Take(int64(promptTokens))
+	}
+	return nil
+}
+
+// Record prices a completed request, appends it to the tenant's ledger and
+// charges its completion tokens to the token rate limit. The prompt tokens
+// were already charged by Admit.
+func (m *Manager) Record(u Usage) (Usage, error) {
+	if u.TenantID == "" {
+		return u, nil
+	}
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = m.now()
+	}
+	if u.Cost == 0 && m.pricer != nil {
+		in, out := m.pricer.Price(u.Model)
+		u.Cost, u.CacheSavings = price(u, in, out)
+	}
+
+	if st, err := m.state(u.TenantID); err == nil {
+		var alert *BudgetAlert
+		m.mu.Lock()
+		if !u.CreatedAt.Before(st.month) {
+			st.spend += u.Cost
+		}
+		if m.onBudget != nil {
+			if t := m.crossed(st); t > st.alerted {
+				st.alerted = t
+				alert = &BudgetAlert{TenantID: st.tenant.ID, TenantName: st.tenant.Name, Threshold: t, Spend: st.spend, Budget: st.tenant.MonthlyBudget}
+			}
+		}
+		tpm := st.tpm
+		m.mu.Unlock()
+		if tpm != nil {
+			tpm.Take(int64(u.CompletionTokens))
+		}
+		if alert != nil {
+			m.onBudget(*alert)
+		}
+	}
+
+	return u, m.store.RecordUsage(u)
+}
+
+// price returns the cost of a request and what prompt caching saved, given
+// USD per million input and output tokens. Cache reads and writes are
+// billed at the provider's multiples of the input price.
+func price(u Usage, in, out float64) (cost, savings float64) {
+	read, write := cacheRates(u.Provider)
+	uncached := max(u.PromptTokens-u.CachedTokens-u.CacheWriteTokens, 0)
+	prompt := float64(uncached)*in +
+		float64(u.CachedTokens)*in*read +
+		float64(u.CacheWriteTokens)*in*write
+	full := float64(uncached+u.CachedTokens+u.CacheWriteTokens) * in
+	return (prompt + float64(u.CompletionTokens)*out) / 1_000_000, (full - prompt) / 1_000_000
+}
+
+// cacheRates returns the multiples of the input price a provider bills for
+// prompt tokens read from and written to its cache
+func cacheRates(provider string) (read, write float64) {
+	if provider == "anthropic" {
+		return 0.1, 1.25
+	}
+	// OpenAI and most OpenAI-compatible providers halve cached input and
+	// do not charge for writes
+	return 0.5, 1
+}
+
+// Invalidate drops cached state for a tenant after its settings, clients
+// or keys change. An empty ID drops everything.
+func (m *Manager) Invalidate(tenantID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if tenantID == "" {
+		m.clients = make(map[string]clientEntry)
+		m.states = make(map[string]*state)
+		return
+	}
+	delete(m.states, tenantID)
+	for id, entry := range m.clients {
+		if entry.tenantID == tenantID {
+			delete(m.clients, id)
+		}
+	}
+}
+
+// InvalidateClient drops a client's cached tenant assignment
+func (m *Manager) InvalidateClient(clientID string) {
+	m.mu.Lock()
+	delete(m.clients, clientID)
+	m.mu.Unlock()
+}
+
+// state returns the tenant's cached state, reloading its settings and
+// month-to-date spend from the store once the TTL has passed. Reloading the
+// spend also picks up usage recorded by other instances. Limiters are kept
+// across reloads unless the limits change.
+func (m *Manager) state(tenantID string) (*state, error) {
+	now := m.now()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	m.mu.Lock()
+	st, ok := m.states[tenantID]
+	fresh := ok && now.Sub(st.loaded) < m.ttl && st.month.Equal(month)
+	m.mu.Unlock()
+	if fresh {
+		return st, nil
+	}
+
+	t, err := m.store.GetTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTenant, tenantID)
+	}
+	spend, err := m.store.Spend(tenantID, month)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok = m.states[tenantID]
+	if !ok {
+		st = &state{}
+		m.states[tenantID] = st
+	}
+	if st.rpm == nil || st.tenant.RPMLimit != t.RPMLimit {
+		st.rpm = minuteBucket(t.RPMLimit)
+	}
+	if st.tpm == nil || st.tenant.TPMLimit != t.TPMLimit {
+		st.tpm = minuteBucket(t.TPMLimit)
+	}
+	newMonth := !st.month.Equal(month)
+	st.tenant = *t
+	st.month, st.spend = month, spend
+	st.loaded = now
+	if newMonth {
+		st.alerted = m.crossed(st)
+	}
+	return st, nil
+}
+
+// minuteBucket returns a bucket refilling limit tokens a minute, or nil
+// for no limit
+func minuteBucket(limit int) *ratelimit.TokenBucket {
+	if limit <= 0 {
+		return nil
+	}
+	return ratelimit.NewTokenBucket(int64(limit), int64(limit), time.Minute)
+}
+
+type tenantKey struct{}
+
+// WithTenant tags a request context with the tenant it is served for, so
+// key selection and downstream accounting stay within the tenant
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	if tenantID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// FromContext returns the tenant set by WithTenant, or ""
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(tenantKey{}).(string)
+	return id
+}
@@ -0,0 +1,241 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memStore struct {
+	mu      sync.Mutex
+	tenants map[string]*Tenant
+	clients map[string]string
+	usage   []Usage
+	lookups int
+}
+
+func (s *memStore) GetTenant(id string) (*Tenant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.tenants[id]; ok {
+		c := *t
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func (s *memStore) TenantForClient(clientID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups++
+	return s.clients[clientID], nil
+}
+
+func (s *memStore) RecordUsage(u Usage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage = append(s.usage, u)
+	return nil
+}
+
+func (s *memStore) Spend(tenantID string, since time.Time) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var total float64
+	for _, u := range s.usage {
+		if u.TenantID == tenantID && !u.CreatedAt.Before(since) {
+			total += u.Cost
+		}
+	}
+	return total, nil
+}
+
+type flatPricer struct{ in, out float64 }
+
+func (p flatPricer) Price(string) (float64, float64) { return p.in, p.out }
+
+func newTestManager() (*Manager, *memStore) {
+	store := &memStore{
+		tenants: map[string]*Tenant{
+			"team-a": {ID: "team-a", MonthlyBudget: 1, RPMLimit: 2},
+			"team-b": {ID: "team-b", TPMLimit: 1000},
+		},
+		clients: map[string]string{"cli-a": "team-a", "cli-b": "team-b", "cli-x": "gone"},
+	}
+	return NewManager(store, flatPricer{in: 10, out: 30}, time.Hour), store
+}
+
+func TestManager_Resolve(t *testing.T) {
+	m, store := newTestManager()
+
+	if id, err := m.Resolve("cli-a"); err != nil || id != "team-a" {
+		t.Fatalf("Resolve = %q, %v", id, err)
+	}
+	m.Resolve("cli-a")
+	if store.lookups != 1 {
+		t.Errorf("expected the assignment to be cached, got %d lookups", store.lookups)
+	}
+	if id, _ := m.Resolve("shared"); id != "" {
+		t.Errorf("shared client resolved to %q", id)
+	}
+
+	store.clients["cli-a"] = "team-b"
+	m.InvalidateClient("cli-a")
+	if id, _ := m.Resolve("cli-a"); id != "team-b" {
+		t.Errorf("expected the new assignment after invalidation, got %q", id)
+	}
+
+	if err := m.Admit("gone", 10); !errors.Is(err, ErrUnknownTenant) {
+		t.Errorf("expected ErrUnknownTenant, got %v", err)
+	}
+	if err := m.Admit("", 10); err != nil {
+		t.Errorf("shared requests are never limited: %v", err)
+	}
+}
+
+func TestManager_RateLimits(t *testing.T) {
+	m, _ := newTestManager()
+
+	// Checking charges nothing
+	for i := 0; i < 3; i++ {
+		if err := m.Check("team-a", 10); err != nil {
+			t.Fatalf("check %d rejected: %v", i, err)
+		}
+	}
+
+	// Requests per minute
+	for i := 0; i < 2; i++ {
+		if err := m.Admit("team-a", 10); err != nil {
+			t.Fatalf("request %d rejected: %v", i, err)
+		}
+	}
+	if err := m.Admit("team-a", 10); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+	if err := m.Check("team-a", 10); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected Check to report ErrRateLimited, got %v", err)
+	}
+
+	// Tokens per minute count prompts at admission and completions after
+	if err := m.Admit("team-b", 600); err != nil {
+		t.Fatalf("Admit failed: %v", err)
+	}
+	m.Record(Usage{TenantID: "team-b", Model: "m", PromptTokens: 600, CompletionTokens: 300})
+	if err := m.Admit("team-b", 200); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited with 100 tokens left, got %v", err)
+	}
+	if err := m.Admit("team-b", 100); err != nil {
+		t.Errorf("expected 100 tokens to fit, got %v", err)
+	}
+}
+
+func TestManager_Budget(t *testing.T) {
+	m, store := newTestManager()
+
+	if err := m.Admit("team-a", 10); err != nil {
+		t.Fatalf("Admit failed: %v", err)
+	}
+	u, err := m.Record(Usage{TenantID: "team-a", ClientID: "cli-a", Provider: "openai", Model: "m", PromptTokens: 40000, CompletionTokens: 20000})
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if u.Cost != 1.0 {
+		t.Errorf("expected $1.00 at $10/$30 per million, got %v", u.Cost)
+	}
+	if len(store.usage) != 1 || store.usage[0].Provider != "openai" {
+		t.Errorf("usage not recorded: %+v", store.usage)
+	}
+	if err := m.Admit("team-a", 10); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("expected ErrBudgetExceeded, got %v", err)
+	}
+
+	// A raised budget applies after invalidation
+	store.tenants["team-a"].MonthlyBudget = 5
+	m.Invalidate("team-a")
+	if err := m.Admit("team-a", 10); err != nil {
+		t.Errorf("expected the raised budget to admit, got %v", err)
+	}
+
+	// Spend resets with the month
+	store.tenants["team-a"].MonthlyBudget = 1
+	m.Invalidate("team-a")
+	m.now = func() time.Time { return time.Now().AddDate(0, 1, 0) }
+	if err := m.Admit("team-a", 10); err != nil {
+		t.Errorf("expected a new month to reset spend, got %v", err)
+	}
+}
+
+func TestManager_BudgetAlerts(t *testing.T) {
+	m, store := newTestManager()
+	var alerts []BudgetAlert
+	m.SetBudgetAlerts([]float64{1, 0.5}, func(a BudgetAlert) { alerts = append(alerts, a) })
+
+	// $0.30 per request against a $1 budget
+	record := func() {
+		m.Record(Usage{TenantID: "team-a", Model: "m", PromptTokens: 30000})
+	}
+	record()
+	if len(alerts) != 0 {
+		t.Fatalf("unexpected alert %+v", alerts)
+	}
+	record()
+	record()
+	if len(alerts) != 1 || alerts[0].Threshold != 0.5 || alerts[0].Budget != 1 {
+		t.Fatalf("expected one 50%% alert, got %+v", alerts)
+	}
+	record()
+	if len(alerts) != 2 || alerts[1].Threshold != 1 || alerts[1].TenantID != "team-a" {
+		t.Fatalf("expected a 100%% alert, got %+v", alerts)
+	}
+
+	// Reloading the tenant does not repeat alerts already crossed
+	m.Invalidate("team-a")
+	record()
+	if len(alerts) != 2 {
+		t.Errorf("expected no repeated alert, got %+v", alerts)
+	}
+
+	// Tenants without a budget never alert
+	store.tenants["team-b"].MonthlyBudget = 0
+	m.Record(Usage{TenantID: "team-b", Model: "m", PromptTokens: 1000000})
+	if len(alerts) != 2 {
+		t.Errorf("unexpected alert %+v", alerts[2:])
+	}
+}
+
+func TestManager_CacheSavings(t *testing.T) {
+	m, _ := newTestManager()
+
+	// At $10 per million input tokens, Anthropic bills cache reads at a
+	// tenth and writes at 1.25x
+	u, err := m.Record(Usage{TenantID: "team-a", Provider: "anthropic", Model: "m",
+		PromptTokens: 300000, CachedTokens: 200000, CacheWriteTokens: 40000})
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if !approx(u.Cost, 0.6+0.2+0.5) || !approx(u.CacheSavings, 1.8-0.1) {
+		t.Errorf("unexpected cost %v and savings %v", u.Cost, u.CacheSavings)
+	}
+
+	// Other providers halve cached input
+	u, _ = m.Record(Usage{TenantID: "team-a", Provider: "openai", Model: "m", PromptTokens: 100000, CachedTokens: 100000})
+	if !approx(u.Cost, 0.5) || !approx(u.CacheSavings, 0.5) {
+		t.Errorf("unexpected cost %v and savings %v", u.Cost, u.CacheSavings)
+	}
+}
+
+func approx(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
+
+func TestWithTenant(t *testing.T) {
+	ctx := WithTenant(context.Background(), "team-a")
+	if got := FromContext(ctx); got != "team-a" {
+		t.Errorf("FromContext = %q", got)
+	}
+	if got := FromContext(WithTenant(context.Background(), "")); got != "" {
+		t.Errorf("empty tenant stored as %q", got)
+	}
+}
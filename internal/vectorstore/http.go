@@ -0,0 +1,177 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// MaxRequestSize bounds the body of one request
+const MaxRequestSize = 32 << 20
+
+// PathPrefix is where the store's endpoints are served
+const PathPrefix = "/v1/vectors/"
+
+// ServeHTTP serves the store's endpoints:
+//
+//	GET    /v1/vectors/collections                lists collections
+//	POST   /v1/vectors/collections                creates {name, dimensions, metric}
+//	GET    /v1/vectors/collections/{name}         describes a collection
+//	DELETE /v1/vectors/collections/{name}         deletes a collection
+//	POST   /v1/vectors/collections/{name}/upsert  stores {vectors: [...]}
+//	POST   /v1/vectors/collections/{name}/query   searches by {vector, top_k, filter, exact, include_values}
+//	POST   /v1/vectors/collections/{name}/delete  deletes {ids: [...]}
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, PathPrefix), "/")
+	parts := strings.Split(path, "/")
+	if parts[0] != "collections" || len(parts) > 3 {
+		writeError(w, "Not found", http.StatusNotFound)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, MaxRequestSize)
+
+	switch len(parts) {
+	case 1:
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, map[string]interface{}{"collections": s.Collections()})
+		case http.MethodPost:
+			s.handleCreate(w, r)
+		default:
+			writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case 2:
+		switch r.Method {
+		case http.MethodGet:
+			c, err := s.Collection(parts[1])
+			if err != nil {
+				writeStoreError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, c)
+		case http.MethodDelete:
+			if err := s.DeleteCollection(parts[1]); err != nil {
+				writeStoreError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		if r.Method != http.MethodPost {
+			writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		switch parts[2] {
+		case "upsert":
+			s.handleUpsert(w, r, parts[1])
+		case "query":
+			s.handleQuery(w, r, parts[1])
+		case "delete":
+			s.handleDelete(w, r, parts[1])
+		default:
+			writeError(w, "Not found", http.StatusNotFound)
+		}
+	}
+}
+
+func (s *Store) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name       string `json:"name"`
+		Dimensions int    `json:"dimensions"`
+		Metric     string `json:"metric"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	c, err := s.CreateCollection(req.Name, req.Dimensions, req.Metric)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, c)
+}
+
+func (s *Store) handleUpsert(w http.ResponseWriter, r *http.Request, name string) {
+	var req struct {
+		Vectors []Record `json:"vectors"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	n, err := s.Upsert(name, req.Vectors)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"upserted": n})
+}
+
+func (s *Store) handleQuery(w http.ResponseWriter, r *http.Request, name string) {
+	var q Query
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		writeError(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	matches, err := s.Search(name, q)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"matches": matches})
+}
+
+func (s *Store) handleDelete(w http.ResponseWriter, r *http.Request, name string) {
+	var req struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	n, err := s.Delete(name, req.IDs)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"deleted": n})
+}
+
+// writeStoreError maps a store error to its status
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrExists):
+		writeError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrInvalid):
+		writeError(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an OpenAI-format error response, like the proxy's
+func writeError(w http.ResponseWriter, message string, status int) {
+	errType := "invalid_request_error"
+	if status >= http.StatusInternalServerError {
+		errType = "server_error"
+	}
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": message,
+			"type":    errType,
+			"code":    status,
+		},
+	})
+}
@@ -0,0 +1,78 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServeHTTP(t *testing.T) {
+	s, _ := newTestStore(t, Config{})
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPost, "/v1/vectors/collections", `{"name":"docs","dimensions":2}`); w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodPost, "/v1/vectors/collections", `{"name":"docs","dimensions":2}`); w.Code != http.StatusConflict {
+		t.Errorf("duplicate status = %d, want 409", w.Code)
+	}
+
+	w := do(http.MethodPost, "/v1/vectors/collections/docs/upsert", `{"vectors":[
+		{"id":"a","values":[1,0],"content":"alpha","metadata":{"lang":"en"}},
+		{"id":"b","values":[0,1],"content":"beta"}]}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"upserted":2`) {
+		t.Fatalf("upsert = %d: %s", w.Code, w.Body)
+	}
+
+	w = do(http.MethodPost, "/v1/vectors/collections/docs/query", `{"vector":[0.9,0.1],"top_k":1}`)
+	var resp struct {
+		Matches []Match `json:"matches"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("query = %d, %v", w.Code, err)
+	}
+	if len(resp.Matches) != 1 || resp.Matches[0].ID != "a" || resp.Matches[0].Content != "alpha" || resp.Matches[0].Metadata["lang"] != "en" {
+		t.Errorf("matches = %+v, want a", resp.Matches)
+	}
+
+	if w := do(http.MethodPost, "/v1/vectors/collections/docs/query", `{"vector":[1,0,0]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("wrong dimensions status = %d, want 400", w.Code)
+	}
+	if w := do(http.MethodPost, "/v1/vectors/collections/docs/query", `{`); w.Code != http.StatusBadRequest {
+		t.Errorf("bad body status = %d, want 400", w.Code)
+	}
+
+	w = do(http.MethodPost, "/v1/vectors/collections/docs/delete", `{"ids":["a"]}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"deleted":1`) {
+		t.Errorf("delete = %d: %s", w.Code, w.Body)
+	}
+
+	w = do(http.MethodGet, "/v1/vectors/collections/docs", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"count":1`) {
+		t.Errorf("describe = %d: %s", w.Code, w.Body)
+	}
+	w = do(http.MethodGet, "/v1/vectors/collections", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"name":"docs"`) {
+		t.Errorf("list = %d: %s", w.Code, w.Body)
+	}
+
+	if w := do(http.MethodDelete, "/v1/vectors/collections/docs", ""); w.Code != http.StatusNoContent {
+		t.Errorf("delete collection status = %d, want 204", w.Code)
+	}
+	if w := do(http.MethodGet, "/v1/vectors/collections/docs", ""); w.Code != http.StatusNotFound {
+		t.Errorf("deleted collection status = %d, want 404", w.Code)
+	}
+	if w := do(http.MethodGet, "/v1/vectors/other", ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown path status = %d, want 404", w.Code)
+	}
+	if w := do(http.MethodPut, "/v1/vectors/collections", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT status = %d, want 405", w.Code)
+	}
+}
@@ -0,0 +1,175 @@
+package vectorstore
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// maxClusters bounds the clusters of one index
+	maxClusters = 1024
+	// kmeansIterations bounds the rounds of k-means when building an index
+	kmeansIterations = 10
+)
+
+// clusterIndex partitions a collection's vectors into clusters around
+// k-means centroids, so a query scores only the clusters nearest to it.
+// Clusters hold slots into the collection's vectors.
+type clusterIndex struct {
+	centroids [][]float32
+	clusters  [][]int
+	assigned  []int // Cluster by slot
+}
+
+// buildClusterIndex clusters vectors into about sqrt(n) clusters. Vectors
+// are compared by euclidean distance whatever the metric, which for the
+// normalized vectors of a cosine collection orders them the same way.
+func buildClusterIndex(vectors [][]float32) *clusterIndex {
+	n := len(vectors)
+	k := int(math.Sqrt(float64(n)))
+	if k > maxClusters {
+		k = maxClusters
+	}
+	if k < 1 {
+		k = 1
+	}
+
+	// Evenly spaced vectors seed the centroids, so builds are repeatable
+	idx := &clusterIndex{centroids: make([][]float32, k)}
+	for i := range idx.centroids {
+		idx.centroids[i] = append([]float32(nil), vectors[i*n/k]...)
+	}
+
+	assignment := make([]int, n)
+	for iter := 0; iter < kmeansIterations; iter++ {
+		changed := 0
+		for slot, v := range vectors {
+			if c := idx.nearest(v); c != assignment[slot] {
+				assignment[slot] = c
+				changed++
+			}
+		}
+		if changed == 0 && iter > 0 {
+			break
+		}
+		idx.recenter(vectors, assignment)
+	}
+
+	idx.clusters = make([][]int, k)
+	for slot, c := range assignment {
+		idx.clusters[c] = append(idx.clusters[c], slot)
+	}
+	idx.assigned = assignment
+	return idx
+}
+
+// recenter moves each centroid to the mean of its vectors. A centroid left
+// without vectors stays where it is.
+func (idx *clusterIndex) recenter(vectors [][]float32, assignment []int) {
+	dims := len(idx.centroids[0])
+	sums := make([][]float64, len(idx.centroids))
+	counts := make([]int, len(idx.centroids))
+	for i := range sums {
+		sums[i] = make([]float64, dims)
+	}
+	for slot, c := range assignment {
+		for d, v := range vectors[slot] {
+			sums[c][d] += float64(v)
+		}
+		counts[c]++
+	}
+	for c, sum := range sums {
+		if counts[c] == 0 {
+			continue
+		}
+		for d := range sum {
+			idx.centroids[c][d] = float32(sum[d] / float64(counts[c]))
+		}
+	}
+}
+
+// nearest returns the cluster whose centroid is nearest v
+func (idx *clusterIndex) nearest(v []float32) int {
+	best, bestDist := 0, math.Inf(1)
+	for c, centroid := range idx.centroids {
+		if d := squaredDistance(v, centroid); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// add assigns the vector of a new last slot to its nearest cluster.
+// Centroids are not moved; the collection rebuilds the index once it has
+// doubled.
+func (idx *clusterIndex) add(slot int, v []float32) {
+	c := idx.nearest(v)
+	idx.clusters[c] = append(idx.clusters[c], slot)
+	idx.assigned = append(idx.assigned, c)
+}
+
+// update reassigns a slot whose vector changed
+func (idx *clusterIndex) update(slot int, v []float32) {
+	idx.detach(slot)
+	c := idx.nearest(v)
+	idx.clusters[c] = append(idx.clusters[c], slot)
+	idx.assigned[slot] = c
+}
+
+// remove drops a slot whose vector was replaced by the last slot's
+func (idx *clusterIndex) remove(slot, last int) {
+	idx.detach(slot)
+	if slot != last {
+		c := idx.assigned[last]
+		for i, s := range idx.clusters[c] {
+			if s == last {
+				idx.clusters[c][i] = slot
+				break
+			}
+		}
+		idx.assigned[slot] = c
+	}
+	idx.assigned = idx.assigned[:last]
+}
+
+// detach takes a slot out of its cluster
+func (idx *clusterIndex) detach(slot int) {
+	c := idx.assigned[slot]
+	members := idx.clusters[c]
+	for i, s := range members {
+		if s == slot {
+			members[i] = members[len(members)-1]
+			idx.clusters[c] = members[:len(members)-1]
+			return
+		}
+	}
+}
+
+// candidates returns the slots of the probes clusters nearest the query
+func (idx *clusterIndex) candidates(query []float32, probes int) []int {
+	order := make([]int, len(idx.centroids))
+	dist := make([]float64, len(idx.centroids))
+	for c, centroid := range idx.centroids {
+		order[c] = c
+		dist[c] = squaredDistance(query, centroid)
+	}
+	sort.Slice(order, func(i, j int) bool { return dist[order[i]] < dist[order[j]] })
+	if probes > len(order) {
+		probes = len(order)
+	}
+
+	var slots []int
+	for _, c := range order[:probes] {
+		slots = append(slots, idx.clusters[c]...)
+	}
+	return slots
+}
+
+func squaredDistance(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+	return sum
+}
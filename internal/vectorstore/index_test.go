@@ -0,0 +1,123 @@
+package vectorstore
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// clusteredRecords returns n vectors scattered around a few distant centers
+func clusteredRecords(rng *rand.Rand, n, dims int) []Record {
+	centers := make([][]float32, 16)
+	for i := range centers {
+		centers[i] = make([]float32, dims)
+		for d := range centers[i] {
+			centers[i][d] = float32(rng.NormFloat64())
+		}
+	}
+	records := make([]Record, n)
+	for i := range records {
+		center := centers[rng.Intn(len(centers))]
+		values := make([]float32, dims)
+		for d := range values {
+			values[d] = center[d] + float32(rng.NormFloat64()*0.1)
+		}
+		records[i] = Record{ID: fmt.Sprintf("v%d", i), Values: values}
+	}
+	return records
+}
+
+func TestClusterIndex_Recall(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	records := clusteredRecords(rng, 2000, 16)
+
+	s, _ := newTestStore(t, Config{IndexThreshold: 1000, Probes: 4})
+	s.CreateCollection("docs", 16, MetricCosine)
+	for i := 0; i < len(records); i += 500 {
+		if _, err := s.Upsert("docs", records[i:i+500]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var found, total int
+	for q := 0; q < 50; q++ {
+		query := records[rng.Intn(len(records))].Values
+		approx, err := s.Search("docs", Query{Vector: query, TopK: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exact, _ := s.Search("docs", Query{Vector: query, TopK: 10, Exact: true})
+		want := make(map[string]bool)
+		for _, m := range exact {
+			want[m.ID] = true
+		}
+		for _, m := range approx {
+			if want[m.ID] {
+				found++
+			}
+		}
+		total += len(exact)
+	}
+	if c, _ := s.Collection("docs"); !c.Indexed {
+		t.Fatal("collection past the threshold is not indexed")
+	}
+	if recall := float64(found) / float64(total); recall < 0.9 {
+		t.Errorf("recall = %.2f, want at least 0.9", recall)
+	}
+}
+
+func TestClusterIndex_Maintenance(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	records := clusteredRecords(rng, 400, 4)
+
+	s, _ := newTestStore(t, Config{IndexThreshold: 100, Probes: 1000})
+	s.CreateCollection("docs", 4, MetricEuclidean)
+	s.Upsert("docs", records[:200])
+	s.Search("docs", Query{Vector: records[0].Values})
+
+	// Every change keeps the index covering each vector once
+	s.Upsert("docs", records[200:300])
+	s.Upsert("docs", []Record{{ID: "v5", Values: records[350].Values}})
+	s.Delete("docs", []string{"v0", "v7", "v299"})
+
+	c := s.collections["docs"]
+	seen := make(map[int]int)
+	for cluster, slots := range c.index.clusters {
+		for _, slot := range slots {
+			seen[slot]++
+			if c.index.assigned[slot] != cluster {
+				t.Errorf("slot %d is in cluster %d, assigned %d", slot, cluster, c.index.assigned[slot])
+			}
+		}
+	}
+	if len(seen) != len(c.ids) || len(c.index.assigned) != len(c.ids) {
+		t.Fatalf("index covers %d slots, assigns %d, collection has %d", len(seen), len(c.index.assigned), len(c.ids))
+	}
+	for slot, n := range seen {
+		if n != 1 {
+			t.Errorf("slot %d indexed %d times", slot, n)
+		}
+	}
+
+	// Probing every cluster finds the same as an exact search
+	matches, _ := s.Search("docs", Query{Vector: records[350].Values, TopK: 2})
+	if len(matches) != 2 || matches[0].Score != 0 {
+		t.Errorf("matches = %+v, want v5 and v350 at distance 0", matches)
+	}
+
+	// Doubling rebuilds the index
+	s.Upsert("docs", records[300:400])
+	s.Search("docs", Query{Vector: records[0].Values})
+	if c.indexedSize != 200 {
+		t.Fatalf("indexed size = %d, want 200 before doubling", c.indexedSize)
+	}
+	more := clusteredRecords(rng, 3, 4)
+	for i := range more {
+		more[i].ID = fmt.Sprintf("w%d", i)
+	}
+	s.Upsert("docs", more)
+	s.Search("docs", Query{Vector: records[0].Values})
+	if c.indexedSize != len(c.ids) {
+		t.Errorf("indexed size = %d, want %d after doubling", c.indexedSize, len(c.ids))
+	}
+}
@@ -0,0 +1,589 @@
+// Package vectorstore keeps collections of vectors in the database and
+// searches them by similarity, so small retrieval-augmented workloads can
+// run against modelscan without an external vector database.
+//
+// A collection's vectors are loaded into memory on first use and searched
+// by brute force, exactly. Collections past IndexThreshold vectors are also
+// clustered with k-means; a query then scores only the vectors of the
+// Probes clusters nearest to it, trading a little recall for speed. A
+// query can always ask for an exact search.
+package vectorstore
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// Similarity metrics
+const (
+	MetricCosine    = "cosine"
+	MetricDot       = "dot"
+	MetricEuclidean = "euclidean"
+)
+
+// Errors returned by the store. Requests that cannot be served wrap
+// ErrInvalid with the reason.
+var (
+	ErrNotFound = errors.New("collection not found")
+	ErrExists   = errors.New("collection already exists")
+	ErrInvalid  = errors.New("invalid request")
+)
+
+// Repository persists collections and their vectors (usually a
+// *database.VectorRepository)
+type Repository interface {
+	CreateCollection(c *database.VectorCollection) error
+	ListCollections() ([]*database.VectorCollection, error)
+	DeleteCollection(name string) error
+	ListRecords(collection string) ([]*database.VectorRecord, error)
+	Upsert(collection string, records []*database.VectorRecord) error
+	DeleteRecords(collection string, ids []string) (int64, error)
+}
+
+// Config configures a Store
+type Config struct {
+	// MaxDimensions bounds the size of a collection's vectors (default 4096)
+	MaxDimensions int
+	// MaxBatch bounds the vectors upserted or deleted in one call
+	// (default 1000)
+	MaxBatch int
+	// MaxTopK bounds the matches one query returns (default 100)
+	MaxTopK int
+	// IndexThreshold is the size from which a collection is clustered for
+	// approximate search (default 5000); negative never clusters
+	IndexThreshold int
+	// Probes is how many clusters an approximate search scores (default 8)
+	Probes int
+}
+
+// DefaultConfig returns the default vector store configuration
+func DefaultConfig() Config {
+	return Config{
+		MaxDimensions:  4096,
+		MaxBatch:       1000,
+		MaxTopK:        100,
+		IndexThreshold: 5000,
+		Probes:         8,
+	}
+}
+
+// Collection describes a collection
+type Collection struct {
+	Name       string    `json:"name"`
+	Dimensions int       `json:"dimensions"`
+	Metric     string    `json:"metric"`
+	Count      int       `json:"count"`   // Vectors, once loaded
+	Loaded     bool      `json:"loaded"`  // Whether the vectors are in memory
+	Indexed    bool      `json:"indexed"` // Whether queries search clusters
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Record is a vector with the text and metadata stored with it
+type Record struct {
+	ID       string            `json:"id"`
+	Values   []float32         `json:"values"`
+	Content  string            `json:"content,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Query searches a collection for the vectors nearest a vector
+type Query struct {
+	Vector        []float32         `json:"vector"`
+	TopK          int               `json:"top_k"`            // Matches to return (default 10)
+	Filter        map[string]string `json:"filter,omitempty"` // Metadata every match must have
+	Exact         bool              `json:"exact,omitempty"`  // Score every vector, even when indexed
+	IncludeValues bool              `json:"include_values,omitempty"`
+}
+
+// Match is one result of a query. Score is the cosine similarity or dot
+// product, higher being nearer, or the euclidean distance, lower being
+// nearer; matches are ordered nearest first.
+type Match struct {
+	ID       string            `json:"id"`
+	Score    float64           `json:"score"`
+	Content  string            `json:"content,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Values   []float32         `json:"values,omitempty"`
+}
+
+// validName matches collection names, which appear in URLs
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,127}$`)
+
+// Store serves collections from memory, writing changes through to the
+// repository
+type Store struct {
+	repo   Repository
+	config Config
+
+	mu          sync.RWMutex
+	collections map[string]*collection
+}
+
+// New creates a store over the collections in repo. Zero config fields
+// take their defaults.
+func New(repo Repository, cfg Config) (*Store, error) {
+	def := DefaultConfig()
+	if cfg.MaxDimensions <= 0 {
+		cfg.MaxDimensions = def.MaxDimensions
+	}
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = def.MaxBatch
+	}
+	if cfg.MaxTopK <= 0 {
+		cfg.MaxTopK = def.MaxTopK
+	}
+	if cfg.IndexThreshold == 0 {
+		cfg.IndexThreshold = def.IndexThreshold
+	}
+	if cfg.Probes <= 0 {
+		cfg.Probes = def.Probes
+	}
+
+	stored, err := repo.ListCollections()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	s := &Store{repo: repo, config: cfg, collections: make(map[string]*collection, len(stored))}
+	for _, c := range stored {
+		s.collections[c.Name] = newCollection(c)
+	}
+	return s, nil
+}
+
+// Config returns the store's configuration with defaults applied
+func (s *Store) Config() Config {
+	return s.config
+}
+
+// CreateCollection creates an empty collection of vectors with dimensions
+// values, compared by metric (default cosine)
+func (s *Store) CreateCollection(name string, dimensions int, metric string) (*Collection, error) {
+	if metric == "" {
+		metric = MetricCosine
+	}
+	switch {
+	case !validName.MatchString(name):
+		return nil, fmt.Errorf("%w: name must be 1-128 letters, digits, '_', '.' or '-'", ErrInvalid)
+	case dimensions <= 0 || dimensions > s.config.MaxDimensions:
+		return nil, fmt.Errorf("%w: dimensions must be between 1 and %d", ErrInvalid, s.config.MaxDimensions)
+	case metric != MetricCosine && metric != MetricDot && metric != MetricEuclidean:
+		return nil, fmt.Errorf("%w: metric must be cosine, dot or euclidean", ErrInvalid)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.collections[name]; ok {
+		return nil, ErrExists
+	}
+	stored := &database.VectorCollection{Name: name, Dimensions: dimensions, Metric: metric, CreatedAt: time.Now().UTC()}
+	if err := s.repo.CreateCollection(stored); err != nil {
+		return nil, err
+	}
+	c := newCollection(stored)
+	c.loaded = true // Nothing to load
+	s.collections[name] = c
+	return c.describe(), nil
+}
+
+// Collections describes every collection by name
+func (s *Store) Collections() []*Collection {
+	s.mu.RLock()
+	out := make([]*Collection, 0, len(s.collections))
+	for _, c := range s.collections {
+		out = append(out, c.describe())
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Collection describes one collection
+func (s *Store) Collection(name string) (*Collection, error) {
+	c, err := s.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.describe(), nil
+}
+
+// DeleteCollection deletes a collection and its vectors
+func (s *Store) DeleteCollection(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.collections[name]; !ok {
+		return ErrNotFound
+	}
+	if err := s.repo.DeleteCollection(name); err != nil {
+		return err
+	}
+	delete(s.collections, name)
+	return nil
+}
+
+// get returns a collection by name
+func (s *Store) get(name string) (*collection, error) {
+	s.mu.RLock()
+	c, ok := s.collections[name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return c, nil
+}
+
+// Upsert stores records in a collection, replacing those with the same
+// IDs, and returns how many were stored
+func (s *Store) Upsert(name string, records []Record) (int, error) {
+	c, err := s.get(name)
+	if err != nil {
+		return 0, err
+	}
+	if len(records) == 0 || len(records) > s.config.MaxBatch {
+		return 0, fmt.Errorf("%w: between 1 and %d vectors can be upserted at once", ErrInvalid, s.config.MaxBatch)
+	}
+	stored := make([]*database.VectorRecord, len(records))
+	for i, r := range records {
+		if r.ID == "" {
+			return 0, fmt.Errorf("%w: vector %d has no id", ErrInvalid, i)
+		}
+		if err := c.check(r.Values); err != nil {
+			return 0, fmt.Errorf("%w: vector %s: %v", ErrInvalid, r.ID, err)
+		}
+		stored[i] = &database.VectorRecord{ID: r.ID, Values: r.Values, Content: r.Content, Metadata: r.Metadata}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.load(s.repo); err != nil {
+		return 0, err
+	}
+	if err := s.repo.Upsert(name, stored); err != nil {
+		return 0, err
+	}
+	for _, r := range records {
+		c.put(r)
+	}
+	return len(records), nil
+}
+
+// Delete removes vectors from a collection by ID and returns how many
+// existed
+func (s *Store) Delete(name string, ids []string) (int, error) {
+	c, err := s.get(name)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 || len(ids) > s.config.MaxBatch {
+		return 0, fmt.Errorf("%w: between 1 and %d vectors can be deleted at once", ErrInvalid, s.config.MaxBatch)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.load(s.repo); err != nil {
+		return 0, err
+	}
+	if _, err := s.repo.DeleteRecords(name, ids); err != nil {
+		return 0, err
+	}
+	var deleted int
+	for _, id := range ids {
+		if c.remove(id) {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Search returns the vectors of a collection nearest the query's
+func (s *Store) Search(name string, q Query) ([]Match, error) {
+	c, err := s.get(name)
+	if err != nil {
+		return nil, err
+	}
+	if q.TopK == 0 {
+		q.TopK = 10
+	}
+	if q.TopK < 0 || q.TopK > s.config.MaxTopK {
+		return nil, fmt.Errorf("%w: top_k must be between 1 and %d", ErrInvalid, s.config.MaxTopK)
+	}
+	if err := c.check(q.Vector); err != nil {
+		return nil, fmt.Errorf("%w: query vector: %v", ErrInvalid, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.load(s.repo); err != nil {
+		return nil, err
+	}
+	c.reindex(s.config.IndexThreshold)
+	query := c.prepare(q.Vector)
+
+	var hits *topK
+	if c.index != nil && !q.Exact {
+		hits = c.scan(query, q, c.index.candidates(query, s.config.Probes))
+		// A filter may leave the probed clusters short of matches
+		if len(q.Filter) > 0 && hits.Len() < q.TopK {
+			hits = nil
+		}
+	}
+	if hits == nil {
+		hits = c.scan(query, q, nil)
+	}
+
+	matches := make([]Match, hits.Len())
+	for i := len(matches) - 1; i >= 0; i-- {
+		h := heap.Pop(hits).(scored)
+		m := Match{ID: c.ids[h.slot], Score: h.score, Content: c.content[h.slot], Metadata: c.metadata[h.slot]}
+		if q.IncludeValues {
+			m.Values = c.original(h.slot)
+		}
+		matches[i] = m
+	}
+	return matches, nil
+}
+
+// collection is one collection's vectors in memory. Cosine collections keep
+// their vectors normalized, with the norms to restore them.
+type collection struct {
+	mu     sync.Mutex
+	info   database.VectorCollection
+	loaded bool
+
+	ids      []string
+	vectors  [][]float32
+	norms    []float32
+	content  []string
+	metadata []map[string]string
+	slots    map[string]int // By ID
+
+	index       *clusterIndex // nil while searches are exact
+	indexedSize int           // Vectors when the index was built
+}
+
+func newCollection(info *database.VectorCollection) *collection {
+	return &collection{info: *info, slots: make(map[string]int)}
+}
+
+// describe reports the collection; the count is only known once loaded
+func (c *collection) describe() *Collection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &Collection{
+		Name:       c.info.Name,
+		Dimensions: c.info.Dimensions,
+		Metric:     c.info.Metric,
+		Count:      len(c.ids),
+		Loaded:     c.loaded,
+		Indexed:    c.index != nil,
+		CreatedAt:  c.info.CreatedAt,
+	}
+}
+
+// check validates a vector for the collection
+func (c *collection) check(values []float32) error {
+	if len(values) != c.info.Dimensions {
+		return fmt.Errorf("has %d values, the collection takes %d", len(values), c.info.Dimensions)
+	}
+	for _, v := range values {
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return errors.New("values must be finite")
+		}
+	}
+	return nil
+}
+
+// load reads the collection's vectors from the repository once
+func (c *collection) load(repo Repository) error {
+	if c.loaded {
+		return nil
+	}
+	records, err := repo.ListRecords(c.info.Name)
+	if err != nil {
+		return fmt.Errorf("failed to load collection %s: %w", c.info.Name, err)
+	}
+	for _, r := range records {
+		c.put(Record{ID: r.ID, Values: r.Values, Content: r.Content, Metadata: r.Metadata})
+	}
+	c.loaded = true
+	return nil
+}
+
+// put adds or replaces a record
+func (c *collection) put(r Record) {
+	vector, norm := c.prepare(r.Values), float32(1)
+	if c.info.Metric == MetricCosine {
+		norm = float32(math.Sqrt(dot(r.Values, r.Values)))
+	}
+	if slot, ok := c.slots[r.ID]; ok {
+		c.vectors[slot], c.norms[slot], c.content[slot], c.metadata[slot] = vector, norm, r.Content, r.Metadata
+		if c.index != nil {
+			c.index.update(slot, vector)
+		}
+		return
+	}
+	c.slots[r.ID] = len(c.ids)
+	c.ids = append(c.ids, r.ID)
+	c.vectors = append(c.vectors, vector)
+	c.norms = append(c.norms, norm)
+	c.content = append(c.content, r.Content)
+	c.metadata = append(c.metadata, r.Metadata)
+	if c.index != nil {
+		c.index.add(len(c.ids)-1, vector)
+	}
+}
+
+// remove deletes a record by moving the last one into its slot
+func (c *collection) remove(id string) bool {
+	slot, ok := c.slots[id]
+	if !ok {
+		return false
+	}
+	last := len(c.ids) - 1
+	if slot != last {
+		c.ids[slot], c.vectors[slot], c.norms[slot] = c.ids[last], c.vectors[last], c.norms[last]
+		c.content[slot], c.metadata[slot] = c.content[last], c.metadata[last]
+		c.slots[c.ids[slot]] = slot
+	}
+	delete(c.slots, id)
+	c.ids, c.vectors, c.norms = c.ids[:last], c.vectors[:last], c.norms[:last]
+	c.content, c.metadata = c.content[:last], c.metadata[:last]
+	if c.index != nil {
+		c.index.remove(slot, last)
+	}
+	return true
+}
+
+// reindex builds the cluster index once the collection reaches threshold
+// vectors, and again when it has doubled since
+func (c *collection) reindex(threshold int) {
+	n := len(c.ids)
+	switch {
+	case threshold < 0 || n < threshold:
+		c.index = nil
+	case c.index == nil || n >= 2*c.indexedSize:
+		c.index = buildClusterIndex(c.vectors)
+		c.indexedSize = n
+	}
+}
+
+// prepare returns a vector as the collection stores it
+func (c *collection) prepare(values []float32) []float32 {
+	if c.info.Metric != MetricCosine {
+		return values
+	}
+	norm := math.Sqrt(dot(values, values))
+	out := make([]float32, len(values))
+	if norm == 0 {
+		return out
+	}
+	for i, v := range values {
+		out[i] = float32(float64(v) / norm)
+	}
+	return out
+}
+
+// original returns a slot's vector as it was stored
+func (c *collection) original(slot int) []float32 {
+	out := make([]float32, len(c.vectors[slot]))
+	for i, v := range c.vectors[slot] {
+		out[i] = v * c.norms[slot]
+	}
+	return out
+}
+
+// score compares two prepared vectors by the collection's metric
+func (c *collection) score(a, b []float32) float64 {
+	if c.info.Metric == MetricEuclidean {
+		var sum float64
+		for i := range a {
+			d := float64(a[i]) - float64(b[i])
+			sum += d * d
+		}
+		return math.Sqrt(sum)
+	}
+	return dot(a, b)
+}
+
+// nearer reports whether score a is nearer than score b
+func (c *collection) nearer(a, b float64) bool {
+	if c.info.Metric == MetricEuclidean {
+		return a < b
+	}
+	return a > b
+}
+
+// scan scores the given slots, or every slot when nil, keeping the top
+// matches that pass the query's filter
+func (c *collection) scan(query []float32, q Query, slots []int) *topK {
+	hits := &topK{nearer: c.nearer}
+	consider := func(slot int) {
+		if !matches(c.metadata[slot], q.Filter) {
+			return
+		}
+		s := scored{slot: slot, score: c.score(query, c.vectors[slot])}
+		if hits.Len() < q.TopK {
+			heap.Push(hits, s)
+		} else if c.nearer(s.score, hits.items[0].score) {
+			hits.items[0] = s
+			heap.Fix(hits, 0)
+		}
+	}
+	if slots == nil {
+		for slot := range c.ids {
+			consider(slot)
+		}
+	} else {
+		for _, slot := range slots {
+			consider(slot)
+		}
+	}
+	return hits
+}
+
+// matches reports whether metadata has every filtered value
+func matches(metadata, filter map[string]string) bool {
+	for k, v := range filter {
+		if metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// scored is a slot with its score against a query
+type scored struct {
+	slot  int
+	score float64
+}
+
+// topK is a heap of the best matches so far with the farthest on top
+type topK struct {
+	items  []scored
+	nearer func(a, b float64) bool
+}
+
+func (h *topK) Len() int           { return len(h.items) }
+func (h *topK) Less(i, j int) bool { return h.nearer(h.items[j].score, h.items[i].score) }
+func (h *topK) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *topK) Push(x any)         { h.items = append(h.items, x.(scored)) }
+func (h *topK) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -0,0 +1,281 @@
+package vectorstore
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database"
+)
+
+// memoryRepo is a Repository in memory
+type memoryRepo struct {
+	collections map[string]*database.VectorCollection
+	records     map[string]map[string]*database.VectorRecord
+	loads       int
+}
+
+func newMemoryRepo() *memoryRepo {
+	return &memoryRepo{
+		collections: make(map[string]*database.VectorCollection),
+		records:     make(map[string]map[string]*database.VectorRecord),
+	}
+}
+
+func (m *memoryRepo) CreateCollection(c *database.VectorCollection) error {
+	m.collections[c.Name] = c
+	m.records[c.Name] = make(map[string]*database.VectorRecord)
+	return nil
+}
+
+func (m *memoryRepo) ListCollections() ([]*database.VectorCollection, error) {
+	var out []*database.VectorCollection
+	for _, c := range m.collections {
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (m *memoryRepo) DeleteCollection(name string) error {
+	delete(m.collections, name)
+	delete(m.records, name)
+	return nil
+}
+
+func (m *memoryRepo) ListRecords(collection string) ([]*database.VectorRecord, error) {
+	m.loads++
+	var out []*database.VectorRecord
+	for _, r := range m.records[collection] {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (m *memoryRepo) Upsert(collection string, records []*database.VectorRecord) error {
+	for _, r := range records {
+		m.records[collection][r.ID] = r
+	}
+	return nil
+}
+
+func (m *memoryRepo) DeleteRecords(collection string, ids []string) (int64, error) {
+	var n int64
+	for _, id := range ids {
+		if _, ok := m.records[collection][id]; ok {
+			delete(m.records[collection], id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func newTestStore(t *testing.T, cfg Config) (*Store, *memoryRepo) {
+	t.Helper()
+	repo := newMemoryRepo()
+	s, err := New(repo, cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return s, repo
+}
+
+func ids(matches []Match) []string {
+	out := make([]string, len(matches))
+	for i, m := range matches {
+		out[i] = m.ID
+	}
+	return out
+}
+
+func TestStore_CreateCollection(t *testing.T) {
+	s, _ := newTestStore(t, Config{MaxDimensions: 8})
+
+	c, err := s.CreateCollection("docs", 3, "")
+	if err != nil {
+		t.Fatalf("CreateCollection() error = %v", err)
+	}
+	if c.Metric != MetricCosine || c.Dimensions != 3 || !c.Loaded {
+		t.Errorf("collection = %+v, want a loaded 3-dimensional cosine collection", c)
+	}
+	if _, err := s.CreateCollection("docs", 3, ""); !errors.Is(err, ErrExists) {
+		t.Errorf("duplicate error = %v, want ErrExists", err)
+	}
+
+	for _, tc := range []struct {
+		name, metric string
+		dims         int
+	}{
+		{"bad/name", MetricCosine, 3},
+		{"", MetricCosine, 3},
+		{"wide", MetricCosine, 9},
+		{"flat", MetricCosine, 0},
+		{"odd", "manhattan", 3},
+	} {
+		if _, err := s.CreateCollection(tc.name, tc.dims, tc.metric); !errors.Is(err, ErrInvalid) {
+			t.Errorf("CreateCollection(%q, %d, %q) error = %v, want ErrInvalid", tc.name, tc.dims, tc.metric, err)
+		}
+	}
+
+	if err := s.DeleteCollection("docs"); err != nil {
+		t.Fatalf("DeleteCollection() error = %v", err)
+	}
+	if err := s.DeleteCollection("docs"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second DeleteCollection() error = %v, want ErrNotFound", err)
+	}
+	if got := s.Collections(); len(got) != 0 {
+		t.Errorf("Collections() = %d, want 0", len(got))
+	}
+}
+
+func TestStore_Search(t *testing.T) {
+	records := []Record{
+		{ID: "x", Values: []float32{1, 0}, Content: "east", Metadata: map[string]string{"lang": "en"}},
+		{ID: "y", Values: []float32{0, 2}, Metadata: map[string]string{"lang": "fr"}},
+		{ID: "xy", Values: []float32{3, 3}, Metadata: map[string]string{"lang": "en"}},
+	}
+	tests := []struct {
+		metric string
+		want   []string
+		score  float64 // Of the first match
+	}{
+		{MetricCosine, []string{"x", "xy", "y"}, 1},
+		{MetricDot, []string{"xy", "x", "y"}, 6},
+		{MetricEuclidean, []string{"x", "y", "xy"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			s, _ := newTestStore(t, Config{})
+			if _, err := s.CreateCollection("docs", 2, tt.metric); err != nil {
+				t.Fatal(err)
+			}
+			if n, err := s.Upsert("docs", records); err != nil || n != 3 {
+				t.Fatalf("Upsert() = %d, %v", n, err)
+			}
+			query := []float32{2, 0}
+			if tt.metric == MetricEuclidean {
+				query = []float32{1, 1}
+			}
+			matches, err := s.Search("docs", Query{Vector: query, TopK: 3})
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			got := ids(matches)
+			if tt.metric == MetricEuclidean {
+				// x and y are equally far from (1, 1)
+				sort.Strings(got[:2])
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("matches = %v, want %v", got, tt.want)
+				}
+			}
+			if math.Abs(matches[0].Score-tt.score) > 1e-6 {
+				t.Errorf("first score = %v, want %v", matches[0].Score, tt.score)
+			}
+		})
+	}
+}
+
+func TestStore_SearchOptions(t *testing.T) {
+	s, _ := newTestStore(t, Config{})
+	s.CreateCollection("docs", 2, MetricCosine)
+	s.Upsert("docs", []Record{
+		{ID: "a", Values: []float32{3, 4}, Content: "alpha", Metadata: map[string]string{"lang": "en"}},
+		{ID: "b", Values: []float32{4, 3}, Metadata: map[string]string{"lang": "fr"}},
+	})
+
+	matches, err := s.Search("docs", Query{Vector: []float32{1, 0}, TopK: 1})
+	if err != nil || len(matches) != 1 || matches[0].ID != "b" {
+		t.Fatalf("top 1 = %v, %v, want b", matches, err)
+	}
+	if matches[0].Values != nil {
+		t.Error("values returned without include_values")
+	}
+
+	matches, _ = s.Search("docs", Query{Vector: []float32{1, 0}, Filter: map[string]string{"lang": "en"}, IncludeValues: true})
+	if len(matches) != 1 || matches[0].ID != "a" || matches[0].Content != "alpha" {
+		t.Fatalf("filtered = %+v, want a", matches)
+	}
+	if v := matches[0].Values; len(v) != 2 || math.Abs(float64(v[0])-3) > 1e-5 || math.Abs(float64(v[1])-4) > 1e-5 {
+		t.Errorf("values = %v, want the stored [3 4]", v)
+	}
+
+	for _, q := range []Query{
+		{Vector: []float32{1}},
+		{Vector: []float32{1, float32(math.NaN())}},
+		{Vector: []float32{1, 0}, TopK: 1000},
+	} {
+		if _, err := s.Search("docs", q); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Search(%v) error = %v, want ErrInvalid", q, err)
+		}
+	}
+	if _, err := s.Search("missing", Query{Vector: []float32{1, 0}}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing collection error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStore_UpsertAndDelete(t *testing.T) {
+	s, repo := newTestStore(t, Config{MaxBatch: 2})
+	s.CreateCollection("docs", 2, MetricDot)
+
+	if _, err := s.Upsert("docs", []Record{{ID: "a", Values: []float32{1, 0}}, {ID: "b", Values: []float32{0, 1}}, {ID: "c", Values: []float32{1, 1}}}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("oversized batch error = %v, want ErrInvalid", err)
+	}
+	if _, err := s.Upsert("docs", []Record{{Values: []float32{1, 0}}}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("missing id error = %v, want ErrInvalid", err)
+	}
+	if _, err := s.Upsert("docs", []Record{{ID: "a", Values: []float32{1, 0, 0}}}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("wrong dimensions error = %v, want ErrInvalid", err)
+	}
+
+	s.Upsert("docs", []Record{{ID: "a", Values: []float32{1, 0}}, {ID: "b", Values: []float32{0, 1}}})
+	// Replacing a moves it away from the query
+	s.Upsert("docs", []Record{{ID: "a", Values: []float32{0, 0.5}}})
+	matches, _ := s.Search("docs", Query{Vector: []float32{0, 1}})
+	if got := ids(matches); len(got) != 2 || got[0] != "b" || got[1] != "a" {
+		t.Errorf("after replace = %v, want [b a]", got)
+	}
+	if len(repo.records["docs"]) != 2 {
+		t.Errorf("stored = %d, want 2", len(repo.records["docs"]))
+	}
+
+	n, err := s.Delete("docs", []string{"a", "missing"})
+	if err != nil || n != 1 {
+		t.Fatalf("Delete() = %d, %v, want 1", n, err)
+	}
+	matches, _ = s.Search("docs", Query{Vector: []float32{0, 1}})
+	if got := ids(matches); len(got) != 1 || got[0] != "b" {
+		t.Errorf("after delete = %v, want [b]", got)
+	}
+	if c, _ := s.Collection("docs"); c.Count != 1 {
+		t.Errorf("count = %d, want 1", c.Count)
+	}
+}
+
+func TestStore_LoadsLazily(t *testing.T) {
+	s, repo := newTestStore(t, Config{})
+	s.CreateCollection("docs", 2, MetricCosine)
+	s.Upsert("docs", []Record{{ID: "a", Values: []float32{1, 0}, Metadata: map[string]string{"k": "v"}}})
+
+	// A new store over the same repository reads vectors on first use
+	reopened, err := New(repo, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := reopened.Collection("docs")
+	if err != nil || c.Loaded {
+		t.Fatalf("Collection() = %+v, %v, want an unloaded collection", c, err)
+	}
+	loads := repo.loads
+	for i := 0; i < 2; i++ {
+		matches, err := reopened.Search("docs", Query{Vector: []float32{1, 0}})
+		if err != nil || len(matches) != 1 || matches[0].Metadata["k"] != "v" {
+			t.Fatalf("Search() = %+v, %v", matches, err)
+		}
+	}
+	if repo.loads-loads != 1 {
+		t.Errorf("loads = %d, want 1", repo.loads-loads)
+	}
+}
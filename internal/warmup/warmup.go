@@ -0,0 +1,184 @@
+// Package warmup prepares upstream connections and caches before the server
+// takes traffic, so the first requests do not pay cold-start costs: DNS
+// lookups, TCP and TLS handshakes and cache loads.
+package warmup
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+)
+
+// Provider steps, in the order they run
+const (
+	StepDNS        = "dns"
+	StepConnect    = "connect"
+	StepCompletion = "completion"
+)
+
+// Config configures a warm-up
+type Config struct {
+	// Timeout bounds the whole warm-up (default 15s)
+	Timeout time.Duration
+	// Concurrency is how many providers warm up at once (default 4)
+	Concurrency int
+	// Providers to warm up; empty warms every provider with an API key
+	Providers []string
+	// Models maps providers to the model of a 1-token completion sent
+	// after connecting; providers without one only connect
+	Models map[string]string
+}
+
+// Target is a provider to warm up
+type Target struct {
+	Provider string
+	URL      string                          // Upstream endpoint, whose host is resolved
+	Connect  func(ctx context.Context) error // Opens a pooled connection
+	Complete func(ctx context.Context) error // Optional tiny completion
+}
+
+// Task primes a cache
+type Task struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Step is the outcome of one step
+type Step struct {
+	Name       string  `json:"name"`
+	OK         bool    `json:"ok"`
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Result is the outcome of warming up one provider. Once a step fails the
+// rest are not run.
+type Result struct {
+	Provider string  `json:"provider"`
+	OK       bool    `json:"ok"`
+	Steps    []*Step `json:"steps"`
+}
+
+// Report is the outcome of a warm-up
+type Report struct {
+	OK         bool      `json:"ok"`
+	Caches     []*Step   `json:"caches"`
+	Providers  []*Result `json:"providers"`
+	DurationMs float64   `json:"duration_ms"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// Run primes the caches and then warms up the targets concurrently. It
+// returns once everything finished or the timeout passed; failures are
+// reported, never fatal.
+func Run(ctx context.Context, targets []Target, tasks []Task, cfg Config) *Report {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 15 * time.Second
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	report := &Report{OK: true, StartedAt: time.Now()}
+	for _, task := range tasks {
+		step := runStep(ctx, task.Name, task.Run)
+		report.Caches = append(report.Caches, step)
+		report.OK = report.OK && step.OK
+	}
+
+	results := make([]*Result, len(targets))
+	sem := make(chan struct{}, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if err := crash.Do("warm-up "+target.Provider, func() { results[i] = warm(ctx, target) }); err != nil {
+				results[i] = &Result{Provider: target.Provider}
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Provider < results[j].Provider })
+	for _, r := range results {
+		report.OK = report.OK && r.OK
+	}
+	report.Providers = results
+	report.DurationMs = msSince(report.StartedAt)
+	return report
+}
+
+// warm runs a target's steps until one fails
+func warm(ctx context.Context, t Target) *Result {
+	steps := []struct {
+		name string
+		run  func(context.Context) error
+	}{
+		{StepDNS, func(ctx context.Context) error { return resolve(ctx, t.URL) }},
+		{StepConnect, t.Connect},
+		{StepCompletion, t.Complete},
+	}
+
+	r := &Result{Provider: t.Provider, OK: true}
+	for _, s := range steps {
+		if s.run == nil {
+			continue
+		}
+		step := runStep(ctx, s.name, s.run)
+		r.Steps = append(r.Steps, step)
+		if !step.OK {
+			r.OK = false
+			break
+		}
+	}
+	return r
+}
+
+// runStep times fn
+func runStep(ctx context.Context, name string, fn func(context.Context) error) *Step {
+	start := time.Now()
+	err := fn(ctx)
+	step := &Step{Name: name, OK: err == nil, DurationMs: msSince(start)}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	return step
+}
+
+// resolve looks up the host of rawURL, warming the resolver's cache
+func resolve(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("invalid upstream URL %q", rawURL)
+	}
+	if net.ParseIP(u.Hostname()) != nil {
+		return nil
+	}
+	_, err = net.DefaultResolver.LookupHost(ctx, u.Hostname())
+	return err
+}
+
+func msSince(t time.Time) float64 {
+	return float64(time.Since(t).Microseconds()) / 1000
+}
+
+// Failed returns the first failed step of r, or nil
+func (r *Result) Failed() *Step {
+	for _, s := range r.Steps {
+		if !s.OK {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package warmup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	var completed []string
+	ok := func(context.Context) error { return nil }
+	targets := []Target{
+		{
+			Provider: "openai",
+			URL:      "http://127.0.0.1:8080/v1/chat/completions",
+			Connect:  ok,
+			Complete: func(context.Context) error { completed = append(completed, "openai"); return nil },
+		},
+		{
+			Provider: "groq",
+			URL:      "http://127.0.0.1:8081/openai/v1/chat/completions",
+			Connect:  func(context.Context) error { return errors.New("connection refused") },
+			Complete: func(context.Context) error { completed = append(completed, "groq"); return nil },
+		},
+		{Provider: "anthropic", URL: "http://127.0.0.1:8082/v1/messages", Connect: ok},
+	}
+	tasks := []Task{{Name: "api_keys", Run: ok}}
+
+	report := Run(context.Background(), targets, tasks, Config{Concurrency: 1})
+	if report.OK {
+		t.Error("expected the failed connection to fail the report")
+	}
+	if len(report.Caches) != 1 || !report.Caches[0].OK || report.Caches[0].Name != "api_keys" {
+		t.Errorf("unexpected caches: %+v", report.Caches)
+	}
+	if len(report.Providers) != 3 || report.Providers[0].Provider != "anthropic" || report.Providers[2].Provider != "openai" {
+		t.Fatalf("expected providers by name, got %+v", report.Providers)
+	}
+
+	if a := report.Providers[0]; !a.OK || len(a.Steps) != 2 || a.Steps[0].Name != StepDNS || a.Steps[1].Name != StepConnect {
+		t.Errorf("expected dns and connect without a completion, got %+v", a.Steps)
+	}
+	g := report.Providers[1]
+	if g.OK || len(g.Steps) != 2 || g.Failed() == nil || g.Failed().Name != StepConnect || g.Failed().Error != "connection refused" {
+		t.Errorf("expected groq to stop at connect, got %+v", g.Steps)
+	}
+	if o := report.Providers[2]; !o.OK || len(o.Steps) != 3 || o.Failed() != nil {
+		t.Errorf("expected every openai step, got %+v", o.Steps)
+	}
+	if len(completed) != 1 || completed[0] != "openai" {
+		t.Errorf("expected only openai to complete, got %v", completed)
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	start := time.Now()
+	report := Run(context.Background(), []Target{{Provider: "slow", URL: "http://10.0.0.1/", Connect: slow}}, nil, Config{Timeout: 50 * time.Millisecond})
+	if time.Since(start) > time.Second {
+		t.Errorf("expected the timeout to bound the warm-up")
+	}
+	if report.OK || report.Providers[0].Failed() == nil {
+		t.Errorf("expected the slow provider to fail, got %+v", report.Providers[0])
+	}
+	if report := Run(context.Background(), []Target{{Provider: "bad", URL: "::", Connect: slow}}, nil, Config{}); report.Providers[0].Failed().Name != StepDNS {
+		t.Errorf("expected an invalid URL to fail at dns, got %+v", report.Providers[0].Steps)
+	}
+}
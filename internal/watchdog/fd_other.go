@@ -0,0 +1,6 @@
+//go:build windows || plan9
+
+package watchdog
+
+// fdLimit returns 0: the platform has no descriptor limit to read
+func fdLimit() int { return 0 }
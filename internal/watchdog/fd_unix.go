@@ -0,0 +1,14 @@
+//go:build !windows && !plan9
+
+package watchdog
+
+import "syscall"
+
+// fdLimit returns the soft limit on open file descriptors, 0 when unlimited
+func fdLimit() int {
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil || rl.Cur > 1<<30 {
+		return 0
+	}
+	return int(rl.Cur)
+}
@@ -0,0 +1,407 @@
+// Package watchdog samples the process's goroutines, open file descriptors
+// and database connections, and warns when one passes its ceiling or keeps
+// climbing: the signature of a leak that will eventually exhaust the
+// process.
+//
+// A resource is trending when, across the last Window samples, it rose at
+// nearly every step, every sample in the newer half of the window is above
+// those in the older half, and it grew by both MinGrowth and MinIncrease,
+// so the ordinary rise and fall of traffic does not warn. Each warning can
+// write the goroutine stacks to a dump file for the postmortem.
+package watchdog
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+)
+
+// Resources sampled
+const (
+	ResourceGoroutines = "goroutines"
+	ResourceOpenFDs    = "open_fds"
+	ResourceDBOpen     = "db_open_connections"
+	ResourceDBInUse    = "db_in_use_connections"
+)
+
+// Warning kinds
+const (
+	KindCeiling = "ceiling" // The value reached its configured ceiling
+	KindTrend   = "trend"   // The value kept rising across the window
+)
+
+// recentWarnings is how many warnings Recent keeps
+const recentWarnings = 100
+
+// Config configures a Watchdog
+type Config struct {
+	// Interval is the time between samples (default 30s)
+	Interval time.Duration
+	// MaxGoroutines is the goroutine ceiling (default 10000)
+	MaxGoroutines int
+	// MaxOpenFDs is the open file descriptor ceiling (default 80% of the
+	// process's soft limit, where known)
+	MaxOpenFDs int
+	// MaxDBInUse is the ceiling for database connections in use (default
+	// the pool's maximum, when it has one)
+	MaxDBInUse int
+	// Window is how many samples a trend covers (default 20)
+	Window int
+	// MinGrowth is the fraction a resource must grow across the window to
+	// be trending, e.g. 0.5 for 50% (default 0.5)
+	MinGrowth float64
+	// MinIncrease is how much a resource must also grow across the window
+	// (default 20)
+	MinIncrease int
+	// Cooldown is the quiet time between warnings for the same resource
+	// and kind (default 15m)
+	Cooldown time.Duration
+	// DumpDir receives the goroutine stacks on each warning (none are
+	// written when empty)
+	DumpDir string
+	// MaxDumps is how many dumps are kept, newest first (default 5)
+	MaxDumps int
+
+	// DBStats reads the database pool's statistics (not sampled when nil)
+	DBStats func() sql.DBStats
+	// OnWarning is called for each warning, outside the watchdog's lock
+	OnWarning func(Warning)
+}
+
+// DefaultConfig returns the default watchdog configuration
+func DefaultConfig() Config {
+	return Config{
+		Interval:      30 * time.Second,
+		MaxGoroutines: 10000,
+		Window:        20,
+		MinGrowth:     0.5,
+		MinIncrease:   20,
+		Cooldown:      15 * time.Minute,
+		MaxDumps:      5,
+	}
+}
+
+// Snapshot is one sample of the process's resources
+type Snapshot struct {
+	At         time.Time   `json:"at"`
+	Goroutines int         `json:"goroutines"`
+	OpenFDs    int         `json:"open_fds"` // -1 where the platform does not report them
+	DB         *DBSnapshot `json:"db,omitempty"`
+}
+
+// DBSnapshot is the database pool part of a sample
+type DBSnapshot struct {
+	Open         int           `json:"open"`
+	InUse        int           `json:"in_use"`
+	Idle         int           `json:"idle"`
+	MaxOpen      int           `json:"max_open"` // 0 for no limit
+	WaitCount    int64         `json:"wait_count"`
+	WaitDuration time.Duration `json:"wait_duration_ns"`
+}
+
+// values returns the sampled resources by name, leaving out unknown ones
+func (s Snapshot) values() map[string]int {
+	v := map[string]int{ResourceGoroutines: s.Goroutines}
+	if s.OpenFDs >= 0 {
+		v[ResourceOpenFDs] = s.OpenFDs
+	}
+	if s.DB != nil {
+		v[ResourceDBOpen] = s.DB.Open
+		v[ResourceDBInUse] = s.DB.InUse
+	}
+	return v
+}
+
+// Warning reports a resource at its ceiling or leaking
+type Warning struct {
+	Resource string        `json:"resource"`
+	Kind     string        `json:"kind"`
+	Value    int           `json:"value"`
+	Limit    int           `json:"limit,omitempty"`    // The ceiling, for ceiling warnings
+	Baseline int           `json:"baseline,omitempty"` // The value a window ago, for trend warnings
+	Window   time.Duration `json:"window_ns,omitempty"`
+	At       time.Time     `json:"at"`
+	Dump     string        `json:"dump,omitempty"` // Goroutine stack dump written for the warning
+}
+
+// String describes the warning for logs
+func (w Warning) String() string {
+	if w.Kind == KindTrend {
+		return fmt.Sprintf("%s rose from %d to %d over %s, a possible leak", w.Resource, w.Baseline, w.Value, w.Window)
+	}
+	return fmt.Sprintf("%s is %d, at or above its ceiling of %d", w.Resource, w.Value, w.Limit)
+}
+
+// Watchdog samples resources and raises warnings
+type Watchdog struct {
+	config Config
+
+	mu       sync.Mutex
+	current  Snapshot
+	history  map[string][]int // Per resource, oldest first
+	lastWarn map[string]time.Time
+	recent   []Warning // Newest last
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// New creates a watchdog and starts sampling every Interval. Zero config
+// fields take their defaults.
+func New(cfg Config) *Watchdog {
+	def := DefaultConfig()
+	if cfg.Interval <= 0 {
+		cfg.Interval = def.Interval
+	}
+	if cfg.MaxGoroutines <= 0 {
+		cfg.MaxGoroutines = def.MaxGoroutines
+	}
+	if cfg.MaxOpenFDs <= 0 {
+		cfg.MaxOpenFDs = fdLimit() * 4 / 5
+	}
+	if cfg.MaxDBInUse <= 0 && cfg.DBStats != nil {
+		cfg.MaxDBInUse = cfg.DBStats().MaxOpenConnections
+	}
+	if cfg.Window < 2 {
+		cfg.Window = def.Window
+	}
+	if cfg.MinGrowth <= 0 {
+		cfg.MinGrowth = def.MinGrowth
+	}
+	if cfg.MinIncrease <= 0 {
+		cfg.MinIncrease = def.MinIncrease
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = def.Cooldown
+	}
+	if cfg.MaxDumps <= 0 {
+		cfg.MaxDumps = def.MaxDumps
+	}
+
+	w := &Watchdog{
+		config:   cfg,
+		history:  make(map[string][]int),
+		lastWarn: make(map[string]time.Time),
+		stop:     make(chan struct{}),
+	}
+	w.Check(time.Now())
+	w.wg.Add(1)
+	go w.loop()
+	return w
+}
+
+// Sample reads the resources now
+func (w *Watchdog) Sample(now time.Time) Snapshot {
+	s := Snapshot{At: now, Goroutines: runtime.NumGoroutine(), OpenFDs: countFDs()}
+	if w.config.DBStats != nil {
+		st := w.config.DBStats()
+		s.DB = &DBSnapshot{
+			Open:         st.OpenConnections,
+			InUse:        st.InUse,
+			Idle:         st.Idle,
+			MaxOpen:      st.MaxOpenConnections,
+			WaitCount:    st.WaitCount,
+			WaitDuration: st.WaitDuration,
+		}
+	}
+	return s
+}
+
+// Check samples the resources and judges them against their ceilings and
+// trends, returning the warnings raised; it runs every Interval on its own
+func (w *Watchdog) Check(now time.Time) []Warning {
+	return w.observe(w.Sample(now))
+}
+
+// observe records a sample and raises its warnings
+func (w *Watchdog) observe(s Snapshot) []Warning {
+	w.mu.Lock()
+	w.current = s
+	var warnings []Warning
+	values := s.values()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := values[name]
+		h := append(w.history[name], value)
+		if len(h) > w.config.Window {
+			h = h[len(h)-w.config.Window:]
+		}
+		w.history[name] = h
+
+		if limit := w.ceiling(name); limit > 0 && value >= limit && w.due(name, KindCeiling, s.At) {
+			warnings = append(warnings, Warning{Resource: name, Kind: KindCeiling, Value: value, Limit: limit, At: s.At})
+		}
+		if w.trending(h) && w.due(name, KindTrend, s.At) {
+			warnings = append(warnings, Warning{
+				Resource: name,
+				Kind:     KindTrend,
+				Value:    value,
+				Baseline: h[0],
+				Window:   time.Duration(len(h)-1) * w.config.Interval,
+				At:       s.At,
+			})
+		}
+	}
+	w.mu.Unlock()
+
+	if len(warnings) == 0 {
+		return nil
+	}
+	if w.config.DumpDir != "" {
+		path, err := w.dump(s.At)
+		if err != nil {
+			log.Printf("watchdog: failed to dump goroutines: %v", err)
+		}
+		for i := range warnings {
+			warnings[i].Dump = path
+		}
+	}
+
+	w.mu.Lock()
+	w.recent = append(w.recent, warnings...)
+	if len(w.recent) > recentWarnings {
+		w.recent = w.recent[len(w.recent)-recentWarnings:]
+	}
+	w.mu.Unlock()
+	if w.config.OnWarning != nil {
+		for _, warning := range warnings {
+			w.config.OnWarning(warning)
+		}
+	}
+	return warnings
+}
+
+// ceiling returns a resource's ceiling, 0 for none
+func (w *Watchdog) ceiling(resource string) int {
+	switch resource {
+	case ResourceGoroutines:
+		return w.config.MaxGoroutines
+	case ResourceOpenFDs:
+		return w.config.MaxOpenFDs
+	case ResourceDBInUse:
+		return w.config.MaxDBInUse
+	}
+	return 0
+}
+
+// trending reports whether a full window rose at nearly every step, its
+// newer half sits above its older half and it grew enough overall
+func (w *Watchdog) trending(h []int) bool {
+	if len(h) < w.config.Window {
+		return false
+	}
+	falls := 0
+	for i := 1; i < len(h); i++ {
+		if h[i] < h[i-1] {
+			falls++
+		}
+	}
+	older, newer := h[:len(h)/2], h[len(h)-len(h)/2:]
+	if slices.Max(older) >= slices.Min(newer) {
+		return false
+	}
+	first, last := h[0], h[len(h)-1]
+	return falls*4 <= len(h)-1 &&
+		last-first >= w.config.MinIncrease &&
+		float64(last) >= float64(first)*(1+w.config.MinGrowth)
+}
+
+// due reports whether a warning is out of its cooldown, starting a new
+// cooldown if so; the caller holds w.mu
+func (w *Watchdog) due(resource, kind string, now time.Time) bool {
+	key := resource + "/" + kind
+	if last, ok := w.lastWarn[key]; ok && now.Sub(last) < w.config.Cooldown {
+		return false
+	}
+	w.lastWarn[key] = now
+	return true
+}
+
+// dump writes every goroutine's stack and removes the oldest dumps beyond
+// MaxDumps
+func (w *Watchdog) dump(now time.Time) (string, error) {
+	if err := os.MkdirAll(w.config.DumpDir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(w.config.DumpDir, "goroutines-"+now.UTC().Format("20060102-150405.000000000")+".txt")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", err
+	}
+	if err := pprof.Lookup("goroutine").WriteTo(f, 2); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	dumps, err := filepath.Glob(filepath.Join(w.config.DumpDir, "goroutines-*.txt"))
+	if err != nil {
+		return path, nil
+	}
+	sort.Strings(dumps)
+	for len(dumps) > w.config.MaxDumps {
+		_ = os.Remove(dumps[0])
+		dumps = dumps[1:]
+	}
+	return path, nil
+}
+
+// Current returns the latest sample
+func (w *Watchdog) Current() Snapshot {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Recent returns the latest warnings, newest first
+func (w *Watchdog) Recent() []Warning {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := make([]Warning, len(w.recent))
+	for i, warning := range w.recent {
+		out[len(w.recent)-1-i] = warning
+	}
+	return out
+}
+
+// Config returns the watchdog's configuration with defaults applied
+func (w *Watchdog) Config() Config {
+	return w.config
+}
+
+// Close stops sampling
+func (w *Watchdog) Close() {
+	w.stopOnce.Do(func() { close(w.stop) })
+	w.wg.Wait()
+}
+
+func (w *Watchdog) loop() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case now := <-ticker.C:
+			_ = crash.Do("watchdog", func() { w.Check(now) })
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package watchdog
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestWatchdog creates a watchdog that samples only when told to
+func newTestWatchdog(t *testing.T, cfg Config) *Watchdog {
+	t.Helper()
+	cfg.Interval = time.Hour
+	w := New(cfg)
+	t.Cleanup(w.Close)
+	// Forget the sample taken by New
+	w.history = make(map[string][]int)
+	w.lastWarn = make(map[string]time.Time)
+	w.recent = nil
+	return w
+}
+
+func TestWatchdog_Trend(t *testing.T) {
+	var raised []Warning
+	w := newTestWatchdog(t, Config{Window: 5, MinIncrease: 20, OnWarning: func(w Warning) { raised = append(raised, w) }})
+	start := time.Now()
+	sample := func(i, goroutines int) []Warning {
+		return w.observe(Snapshot{At: start.Add(time.Duration(i) * time.Minute), Goroutines: goroutines, OpenFDs: -1})
+	}
+
+	// Traffic rising and falling is not a leak
+	for i, n := range []int{100, 180, 90, 200, 120} {
+		if warnings := sample(i, n); len(warnings) != 0 {
+			t.Fatalf("expected no warning for noise, got %v", warnings)
+		}
+	}
+	// Steady growth with one dip across a full window is
+	var warnings []Warning
+	for i, n := range []int{130, 150, 145, 170, 200} {
+		warnings = sample(5+i, n)
+	}
+	if len(warnings) != 1 || warnings[0].Kind != KindTrend || warnings[0].Baseline != 130 || warnings[0].Value != 200 || warnings[0].Window != 4*time.Hour {
+		t.Fatalf("expected a goroutine trend warning, got %+v", warnings)
+	}
+	if !strings.Contains(warnings[0].String(), "rose from 130 to 200") {
+		t.Errorf("unexpected description %q", warnings[0].String())
+	}
+	// Cooldown
+	if warnings := sample(10, 260); len(warnings) != 0 {
+		t.Errorf("expected the cooldown to hold the warning, got %v", warnings)
+	}
+	if len(raised) != 1 || len(w.Recent()) != 1 {
+		t.Errorf("expected one raised warning, got %d and %d recent", len(raised), len(w.Recent()))
+	}
+}
+
+func TestWatchdog_Ceilings(t *testing.T) {
+	w := newTestWatchdog(t, Config{MaxGoroutines: 500, MaxOpenFDs: 100, DBStats: func() sql.DBStats {
+		return sql.DBStats{MaxOpenConnections: 4, OpenConnections: 4, InUse: 4, WaitCount: 12}
+	}})
+	if w.Config().MaxDBInUse != 4 {
+		t.Fatalf("expected the pool size as the in-use ceiling, got %d", w.Config().MaxDBInUse)
+	}
+
+	s := w.Sample(time.Now())
+	if s.DB == nil || s.DB.InUse != 4 || s.DB.WaitCount != 12 || s.Goroutines == 0 {
+		t.Fatalf("unexpected sample: %+v", s)
+	}
+	s.Goroutines, s.OpenFDs = 600, 50
+	warnings := w.observe(s)
+	if len(warnings) != 2 || warnings[0].Resource != ResourceDBInUse || warnings[1].Resource != ResourceGoroutines || warnings[1].Limit != 500 {
+		t.Fatalf("expected goroutine and connection ceiling warnings, got %+v", warnings)
+	}
+	if w.Current().Goroutines != 600 {
+		t.Errorf("expected the latest sample, got %+v", w.Current())
+	}
+}
+
+func TestWatchdog_Dump(t *testing.T) {
+	dir := t.TempDir()
+	w := newTestWatchdog(t, Config{MaxGoroutines: 1, DumpDir: dir, MaxDumps: 2, Cooldown: time.Nanosecond})
+
+	var paths []string
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		warnings := w.observe(Snapshot{At: start.Add(time.Duration(i) * time.Second), Goroutines: 2, OpenFDs: -1})
+		if len(warnings) != 1 || warnings[0].Dump == "" {
+			t.Fatalf("expected a warning with a dump, got %+v", warnings)
+		}
+		paths = append(paths, warnings[0].Dump)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("expected the two newest dumps, got %d", len(entries))
+	}
+	data, err := os.ReadFile(paths[2])
+	if err != nil || !strings.Contains(string(data), "goroutine ") || !strings.Contains(string(data), "TestWatchdog_Dump") {
+		t.Errorf("expected goroutine stacks in the dump, got %v", err)
+	}
+}
+
+func TestCountFDs(t *testing.T) {
+	before := countFDs()
+	if before < 0 {
+		t.Skip("open descriptors are not listed on this platform")
+	}
+	f, err := os.Open(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if after := countFDs(); after != before+1 {
+		t.Errorf("expected one more descriptor, got %d then %d", before, after)
+	}
+}
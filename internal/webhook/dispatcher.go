@@ -0,0 +1,299 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jeffersonwarrior/modelscan/internal/crash"
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
+)
+
+// Config controls webhook delivery
+type Config struct {
+	Endpoints      []Endpoint
+	MaxAttempts    int           // Attempts per delivery before dead-lettering
+	InitialBackoff time.Duration // Wait before the first retry; doubles each time
+	MaxBackoff     time.Duration // Upper bound on the wait between retries
+	Timeout        time.Duration // Per-attempt HTTP timeout
+	QueueSize      int           // Pending deliveries; overflow is dead-lettered
+	Workers        int           // Concurrent deliveries
+}
+
+// DefaultConfig returns sensible delivery defaults for the given endpoints
+func DefaultConfig(endpoints ...Endpoint) Config {
+	return Config{
+		Endpoints:      endpoints,
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		Timeout:        10 * time.Second,
+		QueueSize:      256,
+		Workers:        2,
+	}
+}
+
+// delivery is one event bound for one endpoint
+type delivery struct {
+	endpoint *Endpoint
+	event    Event
+	body     []byte
+}
+
+// Dispatcher delivers events to subscribed endpoints in the background
+type Dispatcher struct {
+	cfg    Config
+	store  DeadLetterStore
+	client *http.Client
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan delivery
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher validates the endpoints and starts the delivery workers.
+// A nil store logs dead letters instead of persisting them.
+func NewDispatcher(cfg Config, store DeadLetterStore) (*Dispatcher, error) {
+	defaults := DefaultConfig()
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaults.MaxAttempts
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = defaults.InitialBackoff
+	}
+	if cfg.MaxBackoff < cfg.InitialBackoff {
+		cfg.MaxBackoff = max(defaults.MaxBackoff, cfg.InitialBackoff)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaults.QueueSize
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaults.Workers
+	}
+
+	seen := make(map[string]bool, len(cfg.Endpoints))
+	for _, e := range cfg.Endpoints {
+		if e.Name == "" {
+			return nil, fmt.Errorf("webhook endpoint name is required")
+		}
+		if seen[e.Name] {
+			return nil, fmt.Errorf("duplicate webhook endpoint %q", e.Name)
+		}
+		seen[e.Name] = true
+		if u, err := url.Parse(e.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("webhook endpoint %q: invalid url %q", e.Name, e.URL)
+		}
+		if e.Secret == "" {
+			return nil, fmt.Errorf("webhook endpoint %q: secret is required", e.Name)
+		}
+		for _, t := range e.Events {
+			if _, err := ParseEventType(string(t)); err != nil {
+				return nil, fmt.Errorf("webhook endpoint %q: %w", e.Name, err)
+			}
+		}
+	}
+
+	d := &Dispatcher{
+		cfg:    cfg,
+		store:  store,
+		client: &http.Client{Timeout: cfg.Timeout},
+		queue:  make(chan delivery, cfg.QueueSize),
+		stop:   make(chan struct{}),
+	}
+	for i := 0; i < cfg.Workers; i++ {
+		d.wg.Add(1)
+		go d.worker()
+	}
+	return d, nil
+}
+
+// Publish queues an event for every subscribed endpoint and returns
+// immediately. Deliveries that do not fit in the queue are dead-lettered.
+func (d *Dispatcher) Publish(eventType EventType, data map[string]interface{}) {
+	event := Event{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("webhook: failed to marshal %s event: %v", eventType, err)
+		return
+	}
+	// Event data often carries provider error messages that echo keys
+	body = redact.Bytes(body)
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for i := range d.cfg.Endpoints {
+		endpoint := &d.cfg.Endpoints[i]
+		if !endpoint.subscribes(eventType) {
+			continue
+		}
+		job := delivery{endpoint: endpoint, event: event, body: body}
+		if d.closed {
+			d.deadLetter(job, 0, 0, errors.New("dispatcher closed"))
+			continue
+		}
+		select {
+		case d.queue <- job:
+		default:
+			d.deadLetter(job, 0, 0, errors.New("delivery queue full"))
+		}
+	}
+}
+
+// Redeliver makes a single synchronous attempt to resend a dead letter using
+// the endpoint's current URL and secret
+func (d *Dispatcher) Redeliver(ctx context.Context, dl *DeadLetter) error {
+	var endpoint *Endpoint
+	for i := range d.cfg.Endpoints {
+		if d.cfg.Endpoints[i].Name == dl.Endpoint {
+			endpoint = &d.cfg.Endpoints[i]
+			break
+		}
+	}
+	if endpoint == nil {
+		return fmt.Errorf("webhook endpoint %q is no longer configured", dl.Endpoint)
+	}
+
+	job := delivery{
+		endpoint: endpoint,
+		event:    Event{ID: dl.EventID, Type: dl.EventType},
+		body:     []byte(dl.Payload),
+	}
+	_, err := d.send(ctx, job)
+	return err
+}
+
+// Close stops accepting events and waits for the workers to exit. Deliveries
+// still waiting to retry are dead-lettered rather than dropped.
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	close(d.stop)
+	close(d.queue)
+	d.mu.Unlock()
+
+	d.wg.Wait()
+}
+
+// worker delivers queued events until the queue is closed
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+	for job := range d.queue {
+		_ = crash.Do("webhook delivery", func() { d.deliver(job) })
+	}
+}
+
+// deliver sends one event, retrying transient failures with exponential backoff
+func (d *Dispatcher) deliver(job delivery) {
+	for attempt := 1; ; attempt++ {
+		status, err := d.send(context.Background(), job)
+		if err == nil {
+			return
+		}
+		if attempt >= d.cfg.MaxAttempts || !retryable(status) {
+			d.deadLetter(job, attempt, status, err)
+			return
+		}
+
+		timer := time.NewTimer(d.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-d.stop:
+			timer.Stop()
+			d.deadLetter(job, attempt, status, err)
+			return
+		}
+	}
+}
+
+// send POSTs a signed delivery and returns the response status
+func (d *Dispatcher) send(ctx context.Context, job delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.endpoint.URL, bytes.NewReader(job.body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "modelscan-webhook")
+	req.Header.Set(EventHeader, string(job.event.Type))
+	req.Header.Set(DeliveryHeader, job.event.ID)
+	req.Header.Set(TimestampHeader, fmt.Sprintf("%d", timestamp))
+	req.Header.Set(SignatureHeader, Sign(job.endpoint.Secret, timestamp, job.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable reports whether a failed attempt is worth retrying: network
+// errors, timeouts, throttling and server errors are; other 4xx are not
+func retryable(status int) bool {
+	return status == 0 || status == http.StatusRequestTimeout ||
+		status == http.StatusTooManyRequests || status >= 500
+}
+
+// backoff returns the wait before the next attempt, with up to 20% jitter
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.cfg.InitialBackoff
+	for i := 1; i < attempt && wait < d.cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, d.cfg.MaxBackoff)
+	return wait + time.Duration(rand.Int63n(int64(wait)/5+1))
+}
+
+// deadLetter records a delivery that will not be retried
+func (d *Dispatcher) deadLetter(job delivery, attempts, status int, cause error) {
+	log.Printf("webhook: giving up on %s event %s for %s after %d attempt(s): %v",
+		job.event.Type, job.event.ID, job.endpoint.Name, attempts, cause)
+	if d.store == nil {
+		return
+	}
+
+	dl := &DeadLetter{
+		Endpoint:   job.endpoint.Name,
+		URL:        job.endpoint.URL,
+		EventID:    job.event.ID,
+		EventType:  job.event.Type,
+		Payload:    string(job.body),
+		Attempts:   attempts,
+		LastStatus: status,
+		LastError:  cause.Error(),
+		CreatedAt:  time.Now(),
+	}
+	if err := d.store.SaveDeadLetter(dl); err != nil {
+		log.Printf("webhook: failed to save dead letter for event %s: %v", job.event.ID, err)
+	}
+}
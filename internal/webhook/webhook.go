@@ -0,0 +1,150 @@
+// Package webhook delivers signed JSON event notifications to external
+// HTTP endpoints, retrying with backoff and dead-lettering failed deliveries.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// EventType identifies a notification
+type EventType string
+
+const (
+	// EventProviderUnhealthy fires when a provider fails validation or health checks
+	EventProviderUnhealthy EventType = "provider.unhealthy"
+	// EventBudgetThreshold fires when spend crosses a configured budget threshold
+	EventBudgetThreshold EventType = "budget.threshold_crossed"
+	// EventKeyExpiring fires when an API key is close to expiry
+	EventKeyExpiring EventType = "key.expiring"
+	// EventKeyDegraded fires when a provider rejects an API key with 401 or
+	// 403 and the key is taken out of rotation
+	EventKeyDegraded EventType = "key.degraded"
+	// EventDiscoveryCompleted fires after a provider discovery run
+	EventDiscoveryCompleted EventType = "discovery.completed"
+	// EventCircuitOpened fires when a circuit breaker trips
+	EventCircuitOpened EventType = "circuit_breaker.opened"
+	// EventCompletionFinished fires after each proxied completion when
+	// completion notifications are enabled
+	EventCompletionFinished EventType = "completion.finished"
+	// EventUsageAnomaly fires when a provider's or client's request volume,
+	// cost or error rate spikes above its baseline
+	EventUsageAnomaly EventType = "usage.anomaly"
+	// EventModelRetiring fires when an alias points at a model that is
+	// retired or retires within the warning window
+	EventModelRetiring EventType = "model.retiring"
+	// EventCatalogChanged fires when a discovery run finds models added to
+	// or removed from a provider's catalog, or prices changed
+	EventCatalogChanged EventType = "catalog.changed"
+	// EventResourceWarning fires when goroutines, open files or database
+	// connections reach their ceiling or keep climbing like a leak
+	EventResourceWarning EventType = "resource.warning"
+	// EventBudgetForecast fires when a provider's, client's or tenant's
+	// spend is projected to exceed its monthly budget before it has
+	EventBudgetForecast EventType = "budget.forecast_exceeded"
+)
+
+// EventTypes lists every event type that can be subscribed to
+var EventTypes = []EventType{
+	EventProviderUnhealthy,
+	EventBudgetThreshold,
+	EventKeyExpiring,
+	EventKeyDegraded,
+	EventDiscoveryCompleted,
+	EventCircuitOpened,
+	EventCompletionFinished,
+	EventUsageAnomaly,
+	EventModelRetiring,
+	EventCatalogChanged,
+	EventResourceWarning,
+	EventBudgetForecast,
+}
+
+// ParseEventType validates an event type name
+func ParseEventType(s string) (EventType, error) {
+	for _, t := range EventTypes {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown webhook event %q", s)
+}
+
+// Event is the JSON body POSTed to webhook endpoints
+type Event struct {
+	ID        string                 `json:"id"`
+	Type      EventType              `json:"type"`
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// Endpoint is a webhook receiver
+type Endpoint struct {
+	Name   string
+	URL    string
+	Secret string      // HMAC-SHA256 signing key
+	Events []EventType // Subscribed events; empty means all
+}
+
+// subscribes reports whether the endpoint wants events of type t
+func (e *Endpoint) subscribes(t EventType) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, s := range e.Events {
+		if s == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery headers
+const (
+	// SignatureHeader carries "sha256=<hex HMAC>" of "<timestamp>.<body>"
+	SignatureHeader = "X-Modelscan-Signature"
+	// TimestampHeader carries the Unix time the delivery was signed
+	TimestampHeader = "X-Modelscan-Timestamp"
+	// EventHeader carries the event type
+	EventHeader = "X-Modelscan-Event"
+	// DeliveryHeader carries the event ID, stable across retries
+	DeliveryHeader = "X-Modelscan-Delivery"
+)
+
+// Sign computes the signature header value for a delivery. Including the
+// timestamp lets receivers reject replays of old deliveries.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header value in constant time
+func Verify(secret, signature string, timestamp int64, body []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
+
+// DeadLetter is a delivery that exhausted its retries
+type DeadLetter struct {
+	ID         int       `json:"id"`
+	Endpoint   string    `json:"endpoint"`
+	URL        string    `json:"url"`
+	EventID    string    `json:"event_id"`
+	EventType  EventType `json:"event_type"`
+	Payload    string    `json:"payload"`
+	Attempts   int       `json:"attempts"`
+	LastStatus int       `json:"last_status,omitempty"`
+	LastError  string    `json:"last_error"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DeadLetterStore persists failed deliveries
+type DeadLetterStore interface {
+	SaveDeadLetter(dl *DeadLetter) error
+}
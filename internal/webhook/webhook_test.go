@@ -0,0 +1,368 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryStore collects dead letters for testing
+type memoryStore struct {
+	mu      sync.Mutex
+	letters []*DeadLetter
+}
+
+func (m *memoryStore) SaveDeadLetter(dl *DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.letters = append(m.letters, dl)
+	return nil
+}
+
+func (m *memoryStore) all() []*DeadLetter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*DeadLetter(nil), m.letters...)
+}
+
+// testConfig retries quickly so tests run fast
+func testConfig(endpoints ...Endpoint) Config {
+	cfg := DefaultConfig(endpoints...)
+	cfg.MaxAttempts = 3
+	cfg.InitialBackoff = time.Millisecond
+	cfg.MaxBackoff = 5 * time.Millisecond
+	cfg.Timeout = time.Second
+	return cfg
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	sig := Sign("secret", 1700000000, body)
+
+	if len(sig) != len("sha256=")+64 || sig[:7] != "sha256=" {
+		t.Fatalf("unexpected signature format %q", sig)
+	}
+	if !Verify("secret", sig, 1700000000, body) {
+		t.Error("expected signature to verify")
+	}
+	if Verify("other", sig, 1700000000, body) {
+		t.Error("expected wrong secret to fail")
+	}
+	if Verify("secret", sig, 1700000001, body) {
+		t.Error("expected wrong timestamp to fail")
+	}
+	if Verify("secret", sig, 1700000000, []byte(`{"id":"2"}`)) {
+		t.Error("expected tampered body to fail")
+	}
+}
+
+func TestNewDispatcher_Validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint Endpoint
+	}{
+		{"missing name", Endpoint{URL: "https://example.com/hook", Secret: "s"}},
+		{"bad scheme", Endpoint{Name: "a", URL: "ftp://example.com", Secret: "s"}},
+		{"missing host", Endpoint{Name: "a", URL: "https://", Secret: "s"}},
+		{"missing secret", Endpoint{Name: "a", URL: "https://example.com/hook"}},
+		{"unknown event", Endpoint{Name: "a", URL: "https://example.com/hook", Secret: "s", Events: []EventType{"nope"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDispatcher(testConfig(tt.endpoint), nil); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	dup := Endpoint{Name: "a", URL: "https://example.com/hook", Secret: "s"}
+	if _, err := NewDispatcher(testConfig(dup, dup), nil); err == nil {
+		t.Error("expected duplicate endpoint error")
+	}
+}
+
+func TestDispatcher_DeliversSignedEvent(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+	}))
+	defer server.Close()
+
+	store := &memoryStore{}
+	d, err := NewDispatcher(testConfig(Endpoint{Name: "ops", URL: server.URL, Secret: "s3cret"}), store)
+	if err != nil {
+		t.Fatalf("NewDispatcher() error = %v", err)
+	}
+	defer d.Close()
+
+	d.Publish(EventProviderUnhealthy, map[string]interface{}{"provider_id": "openai"})
+
+	select {
+	case r := <-received:
+		ts, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		if err != nil {
+			t.Fatalf("invalid timestamp header: %v", err)
+		}
+		if !Verify("s3cret", r.Header.Get(SignatureHeader), ts, body) {
+			t.Error("signature did not verify")
+		}
+		if r.Header.Get(EventHeader) != string(EventProviderUnhealthy) {
+			t.Errorf("unexpected event header %q", r.Header.Get(EventHeader))
+		}
+
+		var event Event
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Fatalf("failed to decode event: %v", err)
+		}
+		if event.ID == "" || event.ID != r.Header.Get(DeliveryHeader) {
+			t.Errorf("unexpected event id %q (header %q)", event.ID, r.Header.Get(DeliveryHeader))
+		}
+		if event.Type != EventProviderUnhealthy || event.Data["provider_id"] != "openai" {
+			t.Errorf("unexpected event %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	if len(store.all()) != 0 {
+		t.Error("expected no dead letters")
+	}
+}
+
+func TestDispatcher_SubscriptionFilter(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+
+	d, err := NewDispatcher(testConfig(
+		Endpoint{Name: "budget", URL: server.URL, Secret: "s", Events: []EventType{EventBudgetThreshold}},
+		Endpoint{Name: "all", URL: server.URL, Secret: "s"},
+	), nil)
+	if err != nil {
+		t.Fatalf("NewDispatcher() error = %v", err)
+	}
+
+	d.Publish(EventBudgetThreshold, nil)
+	d.Publish(EventCircuitOpened, nil)
+	d.Close()
+
+	if got := hits.Load(); got != 3 {
+		t.Errorf("expected 3 deliveries, got %d", got)
+	}
+}
+
+func TestDispatcher_RetriesTransientFailures(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	store := &memoryStore{}
+	d, err := NewDispatcher(testConfig(Endpoint{Name: "ops", URL: server.URL, Secret: "s"}), store)
+	if err != nil {
+		t.Fatalf("NewDispatcher() error = %v", err)
+	}
+	defer d.Close()
+	d.Publish(EventDiscoveryCompleted, nil)
+	waitFor(t, func() bool { return attempts.Load() >= 3 })
+
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+	if len(store.all()) != 0 {
+		t.Error("expected no dead letters after eventual success")
+	}
+}
+
+func TestDispatcher_DeadLetters(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantAttempts int
+	}{
+		{"retries exhausted", http.StatusInternalServerError, 3},
+		{"permanent failure", http.StatusBadRequest, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			store := &memoryStore{}
+			d, err := NewDispatcher(testConfig(Endpoint{Name: "ops", URL: server.URL, Secret: "s"}), store)
+			if err != nil {
+				t.Fatalf("NewDispatcher() error = %v", err)
+			}
+			defer d.Close()
+			d.Publish(EventKeyExpiring, map[string]interface{}{"key_id": 7})
+			waitFor(t, func() bool { return len(store.all()) > 0 })
+
+			if got := int(attempts.Load()); got != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, got)
+			}
+			letters := store.all()
+			if len(letters) != 1 {
+				t.Fatalf("expected 1 dead letter, got %d", len(letters))
+			}
+			dl := letters[0]
+			if dl.Endpoint != "ops" || dl.URL != server.URL || dl.EventType != EventKeyExpiring ||
+				dl.Attempts != tt.wantAttempts || dl.LastStatus != tt.status || dl.EventID == "" {
+				t.Errorf("unexpected dead letter %+v", dl)
+			}
+
+			var event Event
+			if err := json.Unmarshal([]byte(dl.Payload), &event); err != nil || event.ID != dl.EventID {
+				t.Errorf("dead letter payload is not the original event: %v", err)
+			}
+		})
+	}
+}
+
+func TestDispatcher_MasksCredentials(t *testing.T) {
+	var mu sync.Mutex
+	var delivered []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		delivered, _ = io.ReadAll(r.Body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	store := &memoryStore{}
+	d, err := NewDispatcher(testConfig(Endpoint{Name: "ops", URL: server.URL, Secret: "s"}), store)
+	if err != nil {
+		t.Fatalf("NewDispatcher() error = %v", err)
+	}
+	defer d.Close()
+	d.Publish(EventKeyDegraded, map[string]interface{}{"error": "invalid x-api-key sk-ant-REDACTED"})
+	waitFor(t, func() bool { return len(store.all()) > 0 })
+
+	mu.Lock()
+	defer mu.Unlock()
+	for name, body := range map[string]string{"delivery": string(delivered), "dead letter": store.all()[0].Payload} {
+		var event Event
+		if err := json.Unmarshal([]byte(body), &event); err != nil {
+			t.Fatalf("%s is not valid JSON: %v", name, err)
+		}
+		if event.Data["error"] != "invalid x-api-key sk-ant-[REDACTED]" {
+			t.Errorf("%s not masked: %v", name, event.Data["error"])
+		}
+	}
+}
+
+func TestDispatcher_CloseDeadLettersPendingRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	cfg := testConfig(Endpoint{Name: "ops", URL: server.URL, Secret: "s"})
+	cfg.InitialBackoff = time.Hour
+	cfg.MaxBackoff = time.Hour
+	store := &memoryStore{}
+	d, err := NewDispatcher(cfg, store)
+	if err != nil {
+		t.Fatalf("NewDispatcher() error = %v", err)
+	}
+	d.Publish(EventCircuitOpened, nil)
+
+	// Wait for the first attempt to fail and the retry to be scheduled
+	waitFor(t, func() bool { return attempts.Load() == 1 })
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		d.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close() did not interrupt the retry backoff")
+	}
+
+	if letters := store.all(); len(letters) != 1 || letters[0].Attempts != 1 {
+		t.Errorf("expected one dead letter after 1 attempt, got %+v", letters)
+	}
+
+	// Events published after Close are dead-lettered immediately
+	d.Publish(EventCircuitOpened, nil)
+	if letters := store.all(); len(letters) != 2 {
+		t.Errorf("expected 2 dead letters, got %d", len(letters))
+	}
+}
+
+func TestDispatcher_Redeliver(t *testing.T) {
+	var gotBody []byte
+	var gotSig string
+	var gotTS int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSig = r.Header.Get(SignatureHeader)
+		gotTS, _ = strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+	}))
+	defer server.Close()
+
+	d, err := NewDispatcher(testConfig(Endpoint{Name: "ops", URL: server.URL, Secret: "s"}), nil)
+	if err != nil {
+		t.Fatalf("NewDispatcher() error = %v", err)
+	}
+	defer d.Close()
+
+	dl := &DeadLetter{Endpoint: "ops", EventID: "evt-1", EventType: EventProviderUnhealthy, Payload: `{"id":"evt-1"}`}
+	if err := d.Redeliver(context.Background(), dl); err != nil {
+		t.Fatalf("Redeliver() error = %v", err)
+	}
+	if string(gotBody) != dl.Payload || !Verify("s", gotSig, gotTS, gotBody) {
+		t.Errorf("unexpected redelivery body %q / signature %q", gotBody, gotSig)
+	}
+
+	dl.Endpoint = "removed"
+	if err := d.Redeliver(context.Background(), dl); err == nil {
+		t.Error("expected error for unknown endpoint")
+	}
+}
+
+func TestParseEventType(t *testing.T) {
+	for _, et := range EventTypes {
+		if got, err := ParseEventType(string(et)); err != nil || got != et {
+			t.Errorf("ParseEventType(%q) = %q, %v", et, got, err)
+		}
+	}
+	if _, err := ParseEventType("provider.healthy"); err == nil {
+		t.Error("expected error for unknown event")
+	}
+}
@@ -5,24 +5,62 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/jeffersonwarrior/modelscan/config"
+	"github.com/jeffersonwarrior/modelscan/internal/export"
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
+	"github.com/jeffersonwarrior/modelscan/internal/report"
 	"github.com/jeffersonwarrior/modelscan/providers"
 	"github.com/jeffersonwarrior/modelscan/storage"
 )
 
 var (
 	providerName = flag.String("provider", "all", "Provider to validate (mistral, openai, anthropic, all)")
-	outputFormat = flag.String("format", "all", "Output format (sqlite, markdown, all)")
+	outputFormat = flag.String("format", "all", "Output format (sqlite, markdown, html, csv, jsonl, columnar, all)")
+	sections     = flag.String("sections", "", "Report sections for markdown and html (comma-separated: "+strings.Join(report.Sections, ", ")+"; default all)")
 	outputPath   = flag.String("output", ".", "Output directory for results")
 	configFile   = flag.String("config", "", "Path to config file with API keys")
 	verbose      = flag.Bool("verbose", false, "Verbose output")
+	record       = flag.Bool("record", false, "Record sanitized provider HTTP interactions to cassettes")
+	offline      = flag.Bool("offline", false, "Replay provider HTTP interactions from cassettes instead of calling APIs")
+	cassetteDir  = flag.String("cassettes", filepath.Join("testdata", "cassettes"), "Directory of recorded cassettes (one per provider)")
+	concurrency  = flag.Int("concurrency", 4, "Providers validated at once (1 with -record or -offline)")
+	timeout      = flag.Duration("timeout", 2*time.Minute, "Validation timeout per provider (0 for none)")
 )
 
+// storeMu serializes result writes; providers are validated concurrently
+// but SQLite takes one writer at a time
+var storeMu sync.Mutex
+
 func main() {
+	// Provider errors can echo keys; mask them in every log line
+	log.SetOutput(redact.NewWriter(os.Stderr))
 	flag.Parse()
+	if *record && *offline {
+		log.Fatal("-record and -offline are mutually exclusive")
+	}
+
+	reportSections, err := report.ParseSections(*sections)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Tabular formats export the stored models and endpoints
+	var tableFormat export.Format
+	if *outputFormat != "all" && *outputFormat != "sqlite" && *outputFormat != "markdown" && *outputFormat != "html" {
+		f, err := export.ParseFormat(*outputFormat)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tableFormat = f
+	}
 
 	ctx := context.Background()
 
@@ -32,35 +70,47 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Initialize database if SQLite output is requested OR if we need markdown from SQLite
-	if *outputFormat == "all" || *outputFormat == "sqlite" || *outputFormat == "markdown" {
-		dbPath := filepath.Join(*outputPath, "providers.db")
-		if err := storage.InitDB(dbPath); err != nil {
-			log.Printf("Warning: Failed to initialize database: %v", err)
-		}
+	// Initialize database; every output format is generated from it
+	dbPath := filepath.Join(*outputPath, "providers.db")
+	if err := storage.InitDB(dbPath); err != nil {
+		log.Printf("Warning: Failed to initialize database: %v", err)
+	}
+
+	// Cassettes swap the shared default transport, one provider at a time
+	workers := *concurrency
+	if *record || *offline {
+		workers = 1
+	}
+	validate := func(ctx context.Context, name string) (int, error) {
+		return validateProvider(ctx, name, cfg)
 	}
 
 	if *providerName == "all" {
-		// Validate all configured providers
-		for _, name := range cfg.ListProviders() {
-			if err := validateProvider(ctx, name, cfg); err != nil {
-				log.Printf("Error validating provider %s: %v", name, err)
+		// Validate all configured providers (all recorded ones when offline)
+		names := cfg.ListProviders()
+		if *offline {
+			names, err = recordedProviders(*cassetteDir)
+			if err != nil {
+				log.Fatalf("Failed to list cassettes: %v", err)
 			}
 		}
+		fmt.Printf("Validating %d providers, %d at a time\n", len(names), workers)
+		start := time.Now()
+		results := validateAll(ctx, names, workers, *timeout, os.Stdout, validate)
+		summarize(os.Stdout, results, time.Since(start))
 	} else {
 		// Validate specific provider
-		if !cfg.HasProvider(*providerName) {
+		if !*offline && !cfg.HasProvider(*providerName) {
 			log.Fatalf("Provider %s is not configured or missing API key", *providerName)
 		}
 
-		if err := validateProvider(ctx, *providerName, cfg); err != nil {
-			log.Fatalf("Error validating provider %s: %v", *providerName, err)
+		if v := validateAll(ctx, []string{*providerName}, 1, *timeout, os.Stdout, validate)[0]; v.err != nil {
+			log.Fatalf("Error validating provider %s: %v", *providerName, v.err)
 		}
 	}
 
 	// Export results in requested formats
 	if *outputFormat == "all" || *outputFormat == "sqlite" {
-		dbPath := filepath.Join(*outputPath, "providers.db")
 		if err := storage.ExportToSQLite(dbPath); err != nil {
 			log.Printf("Error exporting to SQLite: %v", err)
 		} else {
@@ -68,35 +118,64 @@ func main() {
 		}
 	}
 
-	if *outputFormat == "all" || *outputFormat == "markdown" {
-		mdPath := filepath.Join(*outputPath, "PROVIDERS.md")
+	for _, format := range report.Formats {
+		if *outputFormat != string(format) && (*outputFormat != "all" || format != report.FormatMarkdown) {
+			continue
+		}
+		reportPath := filepath.Join(*outputPath, "PROVIDERS."+format.Extension())
 		// Ensure parent directory exists, not create the file as a directory
 		if err := os.MkdirAll(*outputPath, 0o755); err != nil {
 			log.Printf("Error creating output directory: %v", err)
 		}
-		if err := storage.ExportToMarkdown(mdPath); err != nil {
-			log.Printf("Error exporting to Markdown: %v", err)
+		if err := storage.ExportReport(reportPath, report.Options{Format: format, Sections: reportSections}); err != nil {
+			log.Printf("Error exporting %s report: %v", format, err)
 		} else {
-			fmt.Printf("✓ Saved Markdown report to %s\n", mdPath)
+			fmt.Printf("✓ Saved %s report to %s\n", format, reportPath)
+		}
+	}
+
+	if tableFormat != "" {
+		if err := os.MkdirAll(*outputPath, 0o755); err != nil {
+			log.Printf("Error creating output directory: %v", err)
+		}
+		for _, dataset := range []string{"models", "endpoints"} {
+			path := filepath.Join(*outputPath, dataset+"."+tableFormat.Extension())
+			if n, err := storage.ExportToFile(dataset, tableFormat, path); err != nil {
+				log.Printf("Error exporting %s: %v", dataset, err)
+			} else {
+				fmt.Printf("✓ Saved %d %s to %s\n", n, dataset, path)
+			}
 		}
 	}
 
 	fmt.Println("\nValidation complete!")
 }
 
-func validateProvider(ctx context.Context, name string, cfg *config.Config) error {
-	fmt.Printf("\n=== Validating %s Provider ===\n", name)
-
+// validateProvider validates a provider's endpoints, stores its models and
+// endpoint results, and returns the number of models
+func validateProvider(ctx context.Context, name string, cfg *config.Config) (int, error) {
 	// Get provider factory
 	factory, exists := providers.GetProviderFactory(name)
 	if !exists {
-		return fmt.Errorf("unknown provider: %s", name)
+		return 0, fmt.Errorf("unknown provider: %s", name)
 	}
 
-	// Get API key from config
+	// Get API key from config; replayed requests never reach the provider
 	apiKey, err := cfg.GetAPIKey(name)
 	if err != nil {
-		return err
+		if !*offline {
+			return 0, err
+		}
+		apiKey = "offline"
+	}
+
+	// Route provider HTTP traffic through the cassette recorder
+	if *record || *offline {
+		finish, err := useCassette(name, apiKey)
+		if err != nil {
+			return 0, err
+		}
+		defer finish()
 	}
 
 	// Create provider instance with API key
@@ -104,27 +183,71 @@ func validateProvider(ctx context.Context, name string, cfg *config.Config) erro
 
 	// Validate endpoints
 	if err = provider.ValidateEndpoints(ctx, *verbose); err != nil {
-		return fmt.Errorf("endpoint validation failed: %w", err)
+		return 0, fmt.Errorf("endpoint validation failed: %w", err)
 	}
 
 	// List available models
 	models, err := provider.ListModels(ctx, *verbose)
 	if err != nil {
-		return fmt.Errorf("model listing failed: %w", err)
+		return 0, fmt.Errorf("model listing failed: %w", err)
 	}
 
-	fmt.Printf("Found %d models for %s\n", len(models), name)
-
 	// Store results
+	storeMu.Lock()
+	defer storeMu.Unlock()
 	if err := storage.StoreProviderInfo(name, models, provider.GetCapabilities()); err != nil {
-		return fmt.Errorf("failed to store provider info: %w", err)
+		return 0, fmt.Errorf("failed to store provider info: %w", err)
 	}
 
 	// Also store endpoint results
 	endpoints := provider.GetEndpoints()
 	if err := storage.StoreEndpointResults(name, endpoints); err != nil {
-		return fmt.Errorf("failed to store endpoint info: %w", err)
+		return 0, fmt.Errorf("failed to store endpoint info: %w", err)
 	}
 
-	return nil
+	return len(models), nil
+}
+
+// useCassette installs a recorder for the provider's cassette as the default
+// HTTP transport, which every provider client uses. Providers are validated
+// one at a time, so the swap is not shared between them. The returned
+// function saves recordings and restores the previous transport.
+func useCassette(name, apiKey string) (func(), error) {
+	mode := mshttp.ModeReplay
+	if *record {
+		mode = mshttp.ModeRecord
+	}
+	rec, err := mshttp.NewRecorder(mshttp.RecorderConfig{
+		Path:      filepath.Join(*cassetteDir, name+".json"),
+		Mode:      mode,
+		Transport: http.DefaultTransport,
+		Secrets:   []string{apiKey},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	previous := http.DefaultTransport
+	http.DefaultTransport = rec
+	return func() {
+		http.DefaultTransport = previous
+		if err := rec.Save(); err != nil {
+			log.Printf("Error saving cassette for %s: %v", name, err)
+		} else if *record {
+			fmt.Printf("✓ Recorded %d interactions for %s\n", len(rec.Interactions()), name)
+		}
+	}, nil
+}
+
+// recordedProviders lists the providers that have a cassette in dir
+func recordedProviders(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(matches))
+	for _, m := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(m), ".json"))
+	}
+	return names, nil
 }
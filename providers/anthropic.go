@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
 )
 
 // AnthropicProvider implements the Provider interface for Anthropic Claude
@@ -117,7 +119,7 @@ func (p *AnthropicProvider) ListModels(ctx context.Context, verbose bool) ([]Mod
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, providererr.Classify("anthropic", resp.StatusCode, resp.Header, body)
 	}
 
 	var modelsResp anthropicModelsResponse
@@ -299,7 +301,7 @@ func (p *AnthropicProvider) TestModel(ctx context.Context, modelID string, verbo
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("model test failed with status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("model test failed: %w", providererr.Classify("anthropic", resp.StatusCode, resp.Header, body))
 	}
 
 	if verbose {
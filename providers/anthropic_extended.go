@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
 )
 
 // AnthropicExtendedProvider implements the Provider interface for Anthropic Claude with extended thinking
@@ -200,7 +202,7 @@ func (p *AnthropicExtendedProvider) ListModels(ctx context.Context, verbose bool
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return nil, providererr.Classify("anthropic", resp.StatusCode, resp.Header, body)
 	}
 
 	var modelsResp anthropicExtendedModelsResponse
@@ -377,7 +379,7 @@ func (p *AnthropicExtendedProvider) TestModel(ctx context.Context, modelID strin
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("model test failed: %w", providererr.Classify("anthropic", resp.StatusCode, resp.Header, body))
 	}
 
 	var chatResp anthropicExtendedResponse
@@ -10,6 +10,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
 )
 
 // CerebrasExtendedProvider implements the Provider interface for Cerebras using internal HTTP client
@@ -199,7 +201,7 @@ func (p *CerebrasExtendedProvider) ListModels(ctx context.Context, verbose bool)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return nil, providererr.Classify("cerebras", resp.StatusCode, resp.Header, body)
 	}
 
 	var modelsResp cerebrasModelsResponse
@@ -375,7 +377,7 @@ func (p *CerebrasExtendedProvider) TestModel(ctx context.Context, modelID string
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("model test failed: %w", providererr.Classify("cerebras", resp.StatusCode, resp.Header, body))
 	}
 
 	var chatResp cerebrasChatCompletionResponse
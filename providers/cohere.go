@@ -0,0 +1,416 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+)
+
+// CohereProvider implements the Provider interface for Cohere chat and embeddings.
+// Cohere's v2 API uses its own response shapes (content blocks, billed_units)
+// rather than the OpenAI format, so responses are decoded explicitly.
+type CohereProvider struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewCohereProvider creates a new Cohere provider instance
+func NewCohereProvider(apiKey string) Provider {
+	return &CohereProvider{
+		apiKey:  apiKey,
+		baseURL: "https://api.cohere.com",
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+func init() {
+	RegisterProvider("cohere", NewCohereProvider)
+}
+
+// cohereChatRequest is the Cohere v2 chat request body
+type cohereChatRequest struct {
+	Model     string              `json:"model"`
+	Messages  []cohereChatMessage `json:"messages"`
+	MaxTokens int                 `json:"max_tokens,omitempty"`
+}
+
+type cohereChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// cohereChatResponse is the Cohere v2 chat response body
+type cohereChatResponse struct {
+	ID           string `json:"id"`
+	FinishReason string `json:"finish_reason"`
+	Message      struct {
+		Role    string `json:"role"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"message"`
+	Usage struct {
+		BilledUnits cohereBilledUnits `json:"billed_units"`
+		Tokens      cohereBilledUnits `json:"tokens"`
+	} `json:"usage"`
+}
+
+// cohereEmbedV2Request is the Cohere v2 embed request body
+type cohereEmbedV2Request struct {
+	Model          string   `json:"model"`
+	Texts          []string `json:"texts"`
+	InputType      string   `json:"input_type"`
+	EmbeddingTypes []string `json:"embedding_types"`
+}
+
+// cohereEmbedV2Response is the Cohere v2 embed response body.
+// Embeddings are keyed by type ("float", "int8", ...) instead of a flat array.
+type cohereEmbedV2Response struct {
+	ID         string                 `json:"id"`
+	Embeddings map[string][][]float64 `json:"embeddings"`
+	Texts      []string               `json:"texts"`
+}
+
+func (p *CohereProvider) ValidateEndpoints(ctx context.Context, verbose bool) error {
+	endpoints := p.GetEndpoints()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for i := range endpoints {
+		wg.Add(1)
+		go func(endpoint *Endpoint) {
+			defer wg.Done()
+
+			if verbose {
+				mu.Lock()
+				fmt.Printf("  Testing endpoint: %s %s\n", endpoint.Method, endpoint.Path)
+				mu.Unlock()
+			}
+
+			start := time.Now()
+			err := p.testEndpoint(ctx, endpoint)
+			latency := time.Since(start)
+
+			mu.Lock()
+			endpoint.Latency = latency
+			if err != nil {
+				endpoint.Status = StatusFailed
+				endpoint.Error = err.Error()
+				if verbose {
+					fmt.Printf("    ✗ Failed: %v\n", err)
+				}
+			} else {
+				endpoint.Status = StatusWorking
+				if verbose {
+					fmt.Printf("    ✓ Working (%v)\n", latency)
+				}
+			}
+			mu.Unlock()
+		}(&endpoints[i])
+	}
+	wg.Wait()
+
+	for _, endpoint := range endpoints {
+		if endpoint.Status == StatusWorking {
+			return nil
+		}
+	}
+	for _, endpoint := range endpoints {
+		if endpoint.Error != "" {
+			return fmt.Errorf("all endpoints failed: %s", endpoint.Error)
+		}
+	}
+	return fmt.Errorf("all endpoints failed")
+}
+
+func (p *CohereProvider) testEndpoint(ctx context.Context, endpoint *Endpoint) error {
+	var body io.Reader
+	if endpoint.TestParams != nil {
+		data, err := json.Marshal(endpoint.TestParams)
+		if err != nil {
+			return fmt.Errorf("failed to encode test params: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, endpoint.Method, p.baseURL+endpoint.Path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	p.setHeaders(req)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	// 400 means the endpoint exists but rejected the minimal payload
+	if resp.StatusCode == http.StatusBadRequest {
+		return nil
+	}
+	return fmt.Errorf("endpoint returned status %d: %s", resp.StatusCode, string(respBody))
+}
+
+func (p *CohereProvider) ListModels(ctx context.Context, verbose bool) ([]Model, error) {
+	if verbose {
+		fmt.Println("  Fetching available models from Cohere API...")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/v1/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	p.setHeaders(req)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, providererr.Classify("cohere", resp.StatusCode, resp.Header, body)
+	}
+
+	var modelsResp cohereModelsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&modelsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	models := make([]Model, 0, len(modelsResp.Models))
+	for _, apiModel := range modelsResp.Models {
+		models = append(models, p.toModel(apiModel))
+	}
+
+	if verbose {
+		fmt.Printf("  Found %d models\n", len(models))
+	}
+
+	return models, nil
+}
+
+// toModel converts a Cohere model listing into our Model format,
+// deriving capabilities from the endpoints the model is served on.
+func (p *CohereProvider) toModel(apiModel cohereModel) Model {
+	model := Model{
+		ID:            apiModel.Name,
+		Name:          apiModel.Name,
+		ContextWindow: apiModel.ContextLength,
+		Capabilities:  make(map[string]string),
+	}
+
+	for _, endpoint := range apiModel.Endpoints {
+		switch endpoint {
+		case "chat":
+			model.CanStream = true
+			model.SupportsTools = true
+			model.Categories = append(model.Categories, "chat")
+			model.Capabilities["function_calling"] = "full"
+		case "embed":
+			model.Categories = append(model.Categories, "embedding")
+		case "rerank":
+			model.Categories = append(model.Categories, "rerank")
+		}
+	}
+
+	if containsAny(model.ID, []string{"vision", "aya-vision"}) {
+		model.SupportsImages = true
+		model.Capabilities["vision"] = "high"
+	}
+	if containsAny(model.ID, []string{"reasoning"}) {
+		model.CanReason = true
+		model.Capabilities["reasoning"] = "enabled"
+	}
+	if len(model.Categories) == 0 {
+		model.Categories = []string{"general"}
+	}
+
+	model.CostPer1MIn, model.CostPer1MOut = cohereModelPricing(model.ID)
+	return model
+}
+
+// cohereModelPricing returns known per-1M-token pricing for Cohere models
+func cohereModelPricing(modelID string) (float64, float64) {
+	switch {
+	case strings.HasPrefix(modelID, "command-a"):
+		return 2.50, 10.00
+	case strings.HasPrefix(modelID, "command-r-plus"):
+		return 2.50, 10.00
+	case strings.HasPrefix(modelID, "command-r7b"):
+		return 0.0375, 0.15
+	case strings.HasPrefix(modelID, "command-r"):
+		return 0.15, 0.60
+	case strings.HasPrefix(modelID, "embed-"):
+		return 0.10, 0
+	default:
+		return 0, 0
+	}
+}
+
+func (p *CohereProvider) GetCapabilities() ProviderCapabilities {
+	return ProviderCapabilities{
+		SupportsChat:       true,
+		SupportsEmbeddings: true,
+		SupportsFineTuning: true,
+		SupportsStreaming:  true,
+		SupportsJSONMode:   true,
+		SupportsVision:     true, // aya-vision and command-a-vision models
+		SupportedParameters: []string{
+			"model", "messages", "max_tokens", "temperature", "p", "k",
+			"stop_sequences", "frequency_penalty", "presence_penalty",
+			"seed", "stream", "tools", "response_format", "documents",
+		},
+		SecurityFeatures: []string{
+			"safety_mode",
+		},
+		MaxRequestsPerMinute: 500,
+		MaxTokensPerRequest:  256000,
+	}
+}
+
+func (p *CohereProvider) GetEndpoints() []Endpoint {
+	return []Endpoint{
+		{
+			Path:        "/v1/models",
+			Method:      "GET",
+			Description: "List available models",
+		},
+		{
+			Path:        "/v2/chat",
+			Method:      "POST",
+			Description: "Chat completion endpoint",
+			TestParams: cohereChatRequest{
+				Model:     "command-r7b-12-2024",
+				Messages:  []cohereChatMessage{{Role: "user", Content: "Hello"}},
+				MaxTokens: 10,
+			},
+		},
+		{
+			Path:        "/v2/embed",
+			Method:      "POST",
+			Description: "Create embeddings",
+			TestParams: cohereEmbedV2Request{
+				Model:          "embed-english-v3.0",
+				Texts:          []string{"Test embedding"},
+				InputType:      "search_query",
+				EmbeddingTypes: []string{"float"},
+			},
+		},
+	}
+}
+
+func (p *CohereProvider) TestModel(ctx context.Context, modelID string, verbose bool) error {
+	if verbose {
+		fmt.Printf("  Testing model: %s\n", modelID)
+	}
+
+	if strings.HasPrefix(modelID, "embed-") {
+		return p.testEmbedModel(ctx, modelID, verbose)
+	}
+
+	payload := cohereChatRequest{
+		Model:     modelID,
+		Messages:  []cohereChatMessage{{Role: "user", Content: "Say 'test'"}},
+		MaxTokens: 5,
+	}
+
+	var chatResp cohereChatResponse
+	if err := p.post(ctx, "/v2/chat", payload, &chatResp); err != nil {
+		return fmt.Errorf("model test failed: %w", err)
+	}
+
+	if len(chatResp.Message.Content) == 0 {
+		return fmt.Errorf("model test returned no content")
+	}
+
+	if verbose {
+		fmt.Printf("    ✓ Model responded (%d input / %d output tokens)\n",
+			chatResp.Usage.Tokens.InputTokens, chatResp.Usage.Tokens.OutputTokens)
+	}
+
+	return nil
+}
+
+// testEmbedModel verifies an embedding model using the v2 embed endpoint
+func (p *CohereProvider) testEmbedModel(ctx context.Context, modelID string, verbose bool) error {
+	payload := cohereEmbedV2Request{
+		Model:          modelID,
+		Texts:          []string{"Hello, world!"},
+		InputType:      "search_query",
+		EmbeddingTypes: []string{"float"},
+	}
+
+	var embedResp cohereEmbedV2Response
+	if err := p.post(ctx, "/v2/embed", payload, &embedResp); err != nil {
+		return fmt.Errorf("model test failed: %w", err)
+	}
+
+	vectors := embedResp.Embeddings["float"]
+	if len(vectors) == 0 {
+		return fmt.Errorf("model test returned no embeddings")
+	}
+
+	if verbose {
+		fmt.Printf("    ✓ Generated embedding with dimension %d\n", len(vectors[0]))
+	}
+
+	return nil
+}
+
+// post sends a JSON request and decodes a successful JSON response into out
+func (p *CohereProvider) post(ctx context.Context, path string, payload, out interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	p.setHeaders(req)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return providererr.Classify("cohere", resp.StatusCode, resp.Header, body)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// setHeaders sets auth and content headers for Cohere requests
+func (p *CohereProvider) setHeaders(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if req.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+}
@@ -0,0 +1,212 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestCohereProvider(url string) *CohereProvider {
+	return &CohereProvider{
+		apiKey:  "test-key",
+		baseURL: url,
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func TestNewCohereProvider(t *testing.T) {
+	provider := NewCohereProvider("test-key")
+	cp, ok := provider.(*CohereProvider)
+	if !ok {
+		t.Fatal("Expected *CohereProvider")
+	}
+	if cp.baseURL != "https://api.cohere.com" {
+		t.Errorf("Expected default baseURL, got %s", cp.baseURL)
+	}
+
+	if _, exists := GetProviderFactory("cohere"); !exists {
+		t.Error("Expected cohere provider to be registered")
+	}
+}
+
+func TestCohereProvider_ListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"models": [
+				{"name": "command-r-08-2024", "endpoints": ["chat", "generate"], "context_length": 128000},
+				{"name": "embed-english-v3.0", "endpoints": ["embed"], "context_length": 512},
+				{"name": "c4ai-aya-vision-8b", "endpoints": ["chat"], "context_length": 16000},
+				{"name": "rerank-v3.5", "endpoints": ["rerank"], "context_length": 4096}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	models, err := newTestCohereProvider(server.URL).ListModels(context.Background(), false)
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if len(models) != 4 {
+		t.Fatalf("Expected 4 models, got %d", len(models))
+	}
+
+	chat := models[0]
+	if !chat.CanStream || !chat.SupportsTools {
+		t.Error("Expected chat model to support streaming and tools")
+	}
+	if chat.ContextWindow != 128000 {
+		t.Errorf("Expected context window 128000, got %d", chat.ContextWindow)
+	}
+	if chat.CostPer1MIn != 0.15 || chat.CostPer1MOut != 0.60 {
+		t.Errorf("Unexpected command-r pricing: %v/%v", chat.CostPer1MIn, chat.CostPer1MOut)
+	}
+
+	embed := models[1]
+	if embed.CanStream || embed.SupportsTools {
+		t.Error("Expected embedding model to not support streaming or tools")
+	}
+	if len(embed.Categories) != 1 || embed.Categories[0] != "embedding" {
+		t.Errorf("Expected embedding category, got %v", embed.Categories)
+	}
+
+	if !models[2].SupportsImages {
+		t.Error("Expected aya-vision model to support images")
+	}
+	if models[3].Categories[0] != "rerank" {
+		t.Errorf("Expected rerank category, got %v", models[3].Categories)
+	}
+}
+
+func TestCohereProvider_ListModels_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message": "invalid api token"}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestCohereProvider(server.URL).ListModels(context.Background(), false)
+	if err == nil {
+		t.Fatal("Expected error for unauthorized response")
+	}
+}
+
+func TestCohereProvider_TestModel_Chat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/chat" {
+			t.Errorf("Expected /v2/chat, got %s", r.URL.Path)
+		}
+		var req cohereChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Model != "command-r-08-2024" {
+			t.Errorf("Unexpected model: %s", req.Model)
+		}
+		w.Write([]byte(`{
+			"id": "abc",
+			"finish_reason": "COMPLETE",
+			"message": {"role": "assistant", "content": [{"type": "text", "text": "test"}]},
+			"usage": {"billed_units": {"input_tokens": 3, "output_tokens": 1}, "tokens": {"input_tokens": 70, "output_tokens": 1}}
+		}`))
+	}))
+	defer server.Close()
+
+	if err := newTestCohereProvider(server.URL).TestModel(context.Background(), "command-r-08-2024", true); err != nil {
+		t.Fatalf("TestModel failed: %v", err)
+	}
+}
+
+func TestCohereProvider_TestModel_EmptyContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "abc", "message": {"role": "assistant", "content": []}}`))
+	}))
+	defer server.Close()
+
+	if err := newTestCohereProvider(server.URL).TestModel(context.Background(), "command-r", false); err == nil {
+		t.Fatal("Expected error for empty content")
+	}
+}
+
+func TestCohereProvider_TestModel_Embed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/embed" {
+			t.Errorf("Expected /v2/embed, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"id": "e1", "embeddings": {"float": [[0.1, 0.2, 0.3]]}, "texts": ["Hello, world!"]}`))
+	}))
+	defer server.Close()
+
+	if err := newTestCohereProvider(server.URL).TestModel(context.Background(), "embed-english-v3.0", true); err != nil {
+		t.Fatalf("TestModel failed: %v", err)
+	}
+}
+
+func TestCohereProvider_TestModel_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "model not found"}`))
+	}))
+	defer server.Close()
+
+	if err := newTestCohereProvider(server.URL).TestModel(context.Background(), "missing", false); err == nil {
+		t.Fatal("Expected error for 404 response")
+	}
+}
+
+func TestCohereProvider_ValidateEndpoints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"models": []}`))
+	}))
+	defer server.Close()
+
+	if err := newTestCohereProvider(server.URL).ValidateEndpoints(context.Background(), false); err != nil {
+		t.Fatalf("ValidateEndpoints failed: %v", err)
+	}
+}
+
+func TestCohereProvider_ValidateEndpoints_AllFail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	if err := newTestCohereProvider(server.URL).ValidateEndpoints(context.Background(), false); err == nil {
+		t.Fatal("Expected error when all endpoints fail")
+	}
+}
+
+func TestCohereModelPricing(t *testing.T) {
+	tests := []struct {
+		modelID string
+		in, out float64
+	}{
+		{"command-a-03-2025", 2.50, 10.00},
+		{"command-r-plus-08-2024", 2.50, 10.00},
+		{"command-r7b-12-2024", 0.0375, 0.15},
+		{"command-r-08-2024", 0.15, 0.60},
+		{"embed-english-v3.0", 0.10, 0},
+		{"unknown", 0, 0},
+	}
+
+	for _, tt := range tests {
+		in, out := cohereModelPricing(tt.modelID)
+		if in != tt.in || out != tt.out {
+			t.Errorf("cohereModelPricing(%q) = %v/%v, want %v/%v", tt.modelID, in, out, tt.in, tt.out)
+		}
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
 )
 
 // DeepSeekExtendedProvider implements the Provider interface for DeepSeek using internal HTTP client
@@ -199,7 +201,7 @@ func (p *DeepSeekExtendedProvider) ListModels(ctx context.Context, verbose bool)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return nil, providererr.Classify("deepseek", resp.StatusCode, resp.Header, body)
 	}
 
 	var modelsResp deepseekModelsResponse
@@ -374,7 +376,7 @@ func (p *DeepSeekExtendedProvider) TestModel(ctx context.Context, modelID string
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("model test failed: %w", providererr.Classify("deepseek", resp.StatusCode, resp.Header, body))
 	}
 
 	var chatResp deepseekChatCompletionResponse
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
 )
 
 // GoogleProvider implements the Provider interface for Google Gemini
@@ -116,7 +118,7 @@ func (p *GoogleProvider) ListModels(ctx context.Context, verbose bool) ([]Model,
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, providererr.Classify("google", resp.StatusCode, resp.Header, body)
 	}
 
 	var modelsResp googleModelsResponse
@@ -385,7 +387,7 @@ func (p *GoogleProvider) TestModel(ctx context.Context, modelID string, verbose
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("model test failed with status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("model test failed: %w", providererr.Classify("google", resp.StatusCode, resp.Header, body))
 	}
 
 	if verbose {
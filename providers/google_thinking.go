@@ -10,6 +10,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
 )
 
 // GoogleThinkingProvider implements the Provider interface for Google Gemini with thinking modes
@@ -237,7 +239,7 @@ func (p *GoogleThinkingProvider) ListModels(ctx context.Context, verbose bool) (
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, providererr.Classify("google", resp.StatusCode, resp.Header, body)
 	}
 
 	var modelsResp googleThinkingModelsResponse
@@ -447,7 +449,7 @@ func (p *GoogleThinkingProvider) TestModel(ctx context.Context, modelID string,
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("model test failed with status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("model test failed: %w", providererr.Classify("google", resp.StatusCode, resp.Header, body))
 	}
 
 	if verbose {
@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
 )
 
 // MistralProvider implements the Provider interface for Mistral AI
@@ -118,18 +121,19 @@ func (p *MistralProvider) ListModels(ctx context.Context, verbose bool) ([]Model
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, providererr.Classify("mistral", resp.StatusCode, resp.Header, body)
 	}
 
 	var apiResponse struct {
 		Object string `json:"object"`
 		Data   []struct {
-			ID           string                 `json:"id"`
-			Object       string                 `json:"object"`
-			Created      int64                  `json:"created"`
-			OwnedBy      string                 `json:"owned_by"`
-			Capabilities map[string]interface{} `json:"capabilities"`
-			Description  string                 `json:"description,omitempty"`
+			ID               string                 `json:"id"`
+			Object           string                 `json:"object"`
+			Created          int64                  `json:"created"`
+			OwnedBy          string                 `json:"owned_by"`
+			Capabilities     map[string]interface{} `json:"capabilities"`
+			Description      string                 `json:"description,omitempty"`
+			MaxContextLength int                    `json:"max_context_length,omitempty"`
 		} `json:"data"`
 	}
 
@@ -166,6 +170,14 @@ func (p *MistralProvider) ListModels(ctx context.Context, verbose bool) ([]Model
 			}
 		}
 
+		// Map the capability flags Mistral reports onto our model fields
+		model.CanStream = model.Capabilities["completion_chat"] == "true"
+		model.SupportsTools = model.Capabilities["function_calling"] == "true"
+		model.SupportsImages = model.Capabilities["vision"] == "true"
+		model.CanReason = containsAny(mistralModel.ID, []string{"magistral"})
+		model.ContextWindow = mistralModel.MaxContextLength
+		model.CostPer1MIn, model.CostPer1MOut = mistralModelPricing(mistralModel.ID)
+
 		// Enhance model info in verbose mode
 		if verbose {
 			p.enhanceModelInfo(&model)
@@ -300,7 +312,7 @@ func (p *MistralProvider) TestModel(ctx context.Context, modelID string, verbose
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("model test failed with status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("model test failed: %w", providererr.Classify("mistral", resp.StatusCode, resp.Header, body))
 	}
 
 	return nil
@@ -468,6 +480,34 @@ func (p *MistralProvider) enhanceModelInfo(model *Model) {
 	}
 }
 
+// mistralModelPricing returns known per-1M-token pricing for Mistral models
+func mistralModelPricing(modelID string) (float64, float64) {
+	switch {
+	case strings.HasPrefix(modelID, "mistral-large"):
+		return 2.00, 6.00
+	case strings.HasPrefix(modelID, "mistral-medium"):
+		return 0.40, 2.00
+	case strings.HasPrefix(modelID, "mistral-small"):
+		return 0.10, 0.30
+	case strings.HasPrefix(modelID, "magistral-medium"):
+		return 2.00, 5.00
+	case strings.HasPrefix(modelID, "magistral-small"):
+		return 0.50, 1.50
+	case strings.HasPrefix(modelID, "codestral"):
+		return 0.30, 0.90
+	case strings.HasPrefix(modelID, "pixtral-large"):
+		return 2.00, 6.00
+	case strings.HasPrefix(modelID, "ministral-8b"):
+		return 0.10, 0.10
+	case strings.HasPrefix(modelID, "ministral-3b"):
+		return 0.04, 0.04
+	case strings.HasPrefix(modelID, "mistral-embed"):
+		return 0.10, 0
+	default:
+		return 0, 0
+	}
+}
+
 func guessMistralModelCategories(modelID string) []string {
 	var categories []string
 
@@ -704,3 +704,64 @@ func TestMistralProvider_EnhanceModelInfo_Categories(t *testing.T) {
 		})
 	}
 }
+
+func TestMistralProvider_ListModels_CapabilityDetection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"object": "list",
+			"data": [{
+				"id": "mistral-large-latest",
+				"object": "model",
+				"created": 1234567890,
+				"owned_by": "mistralai",
+				"max_context_length": 131072,
+				"capabilities": {
+					"completion_chat": true,
+					"function_calling": true,
+					"vision": false
+				}
+			}, {
+				"id": "mistral-embed",
+				"object": "model",
+				"created": 1234567890,
+				"owned_by": "mistralai",
+				"capabilities": {
+					"completion_chat": false,
+					"function_calling": false
+				}
+			}]
+		}`))
+	}))
+	defer server.Close()
+
+	provider := &MistralProvider{
+		apiKey:  "test-key",
+		baseURL: server.URL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+
+	models, err := provider.ListModels(context.Background(), false)
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+
+	large := models[0]
+	if !large.CanStream || !large.SupportsTools || large.SupportsImages {
+		t.Errorf("Unexpected capability flags for mistral-large: %+v", large)
+	}
+	if large.ContextWindow != 131072 {
+		t.Errorf("Expected context window 131072, got %d", large.ContextWindow)
+	}
+	if large.CostPer1MIn != 2.00 || large.CostPer1MOut != 6.00 {
+		t.Errorf("Unexpected mistral-large pricing: %v/%v", large.CostPer1MIn, large.CostPer1MOut)
+	}
+
+	embed := models[1]
+	if embed.CanStream || embed.SupportsTools {
+		t.Error("Expected mistral-embed to not support chat features")
+	}
+	if embed.CostPer1MIn != 0.10 || embed.CostPer1MOut != 0 {
+		t.Errorf("Unexpected mistral-embed pricing: %v/%v", embed.CostPer1MIn, embed.CostPer1MOut)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
 )
 
 // OpenAIExtendedProvider implements the Provider interface for OpenAI using internal HTTP client
@@ -199,7 +201,7 @@ func (p *OpenAIExtendedProvider) ListModels(ctx context.Context, verbose bool) (
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return nil, providererr.Classify("openai", resp.StatusCode, resp.Header, body)
 	}
 
 	var modelsResp openaiModelsResponse
@@ -480,7 +482,7 @@ func (p *OpenAIExtendedProvider) TestModel(ctx context.Context, modelID string,
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("model test failed: %w", providererr.Classify("openai", resp.StatusCode, resp.Header, body))
 	}
 
 	var chatResp openaiChatCompletionResponse
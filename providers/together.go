@@ -0,0 +1,329 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+)
+
+// TogetherProvider implements the Provider interface for Together.ai.
+// Chat is OpenAI-compatible, but /models returns a bare JSON array with
+// per-model type, context length and pricing, which we use for detection.
+type TogetherProvider struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// NewTogetherProvider creates a new Together.ai provider instance
+func NewTogetherProvider(apiKey string) Provider {
+	return &TogetherProvider{
+		apiKey:  apiKey,
+		baseURL: "https://api.together.xyz/v1",
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+func init() {
+	RegisterProvider("together", NewTogetherProvider)
+}
+
+// togetherModel is a single entry from the Together /models listing
+type togetherModel struct {
+	ID            string `json:"id"`
+	Object        string `json:"object"`
+	Created       int64  `json:"created"`
+	Type          string `json:"type"` // chat, language, code, image, embedding, rerank, audio, moderation
+	DisplayName   string `json:"display_name"`
+	Organization  string `json:"organization"`
+	ContextLength int    `json:"context_length"`
+	Pricing       struct {
+		Input  float64 `json:"input"`
+		Output float64 `json:"output"`
+	} `json:"pricing"`
+}
+
+func (p *TogetherProvider) ValidateEndpoints(ctx context.Context, verbose bool) error {
+	endpoints := p.GetEndpoints()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for i := range endpoints {
+		wg.Add(1)
+		go func(endpoint *Endpoint) {
+			defer wg.Done()
+
+			if verbose {
+				mu.Lock()
+				fmt.Printf("  Testing endpoint: %s %s\n", endpoint.Method, endpoint.Path)
+				mu.Unlock()
+			}
+
+			start := time.Now()
+			err := p.testEndpoint(ctx, endpoint)
+			latency := time.Since(start)
+
+			mu.Lock()
+			endpoint.Latency = latency
+			if err != nil {
+				endpoint.Status = StatusFailed
+				endpoint.Error = err.Error()
+				if verbose {
+					fmt.Printf("    ✗ Failed: %v\n", err)
+				}
+			} else {
+				endpoint.Status = StatusWorking
+				if verbose {
+					fmt.Printf("    ✓ Working (%v)\n", latency)
+				}
+			}
+			mu.Unlock()
+		}(&endpoints[i])
+	}
+	wg.Wait()
+
+	for _, endpoint := range endpoints {
+		if endpoint.Status == StatusWorking {
+			return nil
+		}
+	}
+	for _, endpoint := range endpoints {
+		if endpoint.Error != "" {
+			return fmt.Errorf("all endpoints failed: %s", endpoint.Error)
+		}
+	}
+	return fmt.Errorf("all endpoints failed")
+}
+
+func (p *TogetherProvider) testEndpoint(ctx context.Context, endpoint *Endpoint) error {
+	var body io.Reader
+	if endpoint.TestParams != nil {
+		data, err := json.Marshal(endpoint.TestParams)
+		if err != nil {
+			return fmt.Errorf("failed to encode test params: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, endpoint.Method, p.baseURL+endpoint.Path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusBadRequest {
+		return nil
+	}
+	return fmt.Errorf("endpoint returned status %d: %s", resp.StatusCode, string(respBody))
+}
+
+func (p *TogetherProvider) ListModels(ctx context.Context, verbose bool) ([]Model, error) {
+	if verbose {
+		fmt.Println("  Fetching available models...")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, providererr.Classify("together", resp.StatusCode, resp.Header, body)
+	}
+
+	var apiModels []togetherModel
+	if err := json.NewDecoder(resp.Body).Decode(&apiModels); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	models := make([]Model, 0, len(apiModels))
+	for _, apiModel := range apiModels {
+		models = append(models, p.toModel(apiModel))
+	}
+
+	if verbose {
+		fmt.Printf("  Found %d models\n", len(models))
+	}
+
+	return models, nil
+}
+
+// toModel converts a Together listing entry into our Model format.
+// Together reports pricing per 1M tokens directly in the listing.
+func (p *TogetherProvider) toModel(apiModel togetherModel) Model {
+	name := apiModel.DisplayName
+	if name == "" {
+		name = apiModel.ID
+	}
+
+	model := Model{
+		ID:            apiModel.ID,
+		Name:          name,
+		CostPer1MIn:   apiModel.Pricing.Input,
+		CostPer1MOut:  apiModel.Pricing.Output,
+		ContextWindow: apiModel.ContextLength,
+		Capabilities:  make(map[string]string),
+	}
+	if apiModel.Created > 0 {
+		model.CreatedAt = time.Unix(apiModel.Created, 0).Format(time.RFC3339)
+	}
+
+	switch apiModel.Type {
+	case "chat", "language":
+		model.CanStream = true
+		model.Categories = []string{"chat"}
+	case "code":
+		model.CanStream = true
+		model.Categories = []string{"coding"}
+	case "embedding":
+		model.Categories = []string{"embedding"}
+	case "image":
+		model.Categories = []string{"image"}
+	case "audio":
+		model.Categories = []string{"audio"}
+	case "rerank":
+		model.Categories = []string{"rerank"}
+	default:
+		model.Categories = []string{"general"}
+	}
+
+	lowerID := strings.ToLower(apiModel.ID)
+	if containsAny(lowerID, []string{"vision", "-vl", "llama-4", "pixtral"}) {
+		model.SupportsImages = true
+		model.Capabilities["vision"] = "high"
+	}
+	if model.CanStream && containsAny(lowerID, []string{"llama-3.1", "llama-3.3", "llama-4", "qwen2.5", "qwen3", "deepseek-v3", "mistral", "mixtral"}) {
+		model.SupportsTools = true
+		model.Capabilities["function_calling"] = "full"
+	}
+	if containsAny(lowerID, []string{"deepseek-r1", "qwq", "thinking"}) {
+		model.CanReason = true
+		model.Capabilities["reasoning"] = "enabled"
+	}
+
+	return model
+}
+
+func (p *TogetherProvider) GetCapabilities() ProviderCapabilities {
+	return ProviderCapabilities{
+		SupportsChat:       true,
+		SupportsEmbeddings: true,
+		SupportsFineTuning: true,
+		SupportsFileUpload: true,
+		SupportsStreaming:  true,
+		SupportsJSONMode:   true,
+		SupportsVision:     true,
+		SupportedParameters: []string{
+			"model", "messages", "max_tokens", "temperature", "top_p", "top_k",
+			"repetition_penalty", "stop", "stream", "tools", "tool_choice",
+			"response_format", "logprobs", "seed",
+		},
+		SecurityFeatures: []string{
+			"safety_model",
+		},
+		MaxRequestsPerMinute: 600,
+		MaxTokensPerRequest:  131072,
+	}
+}
+
+func (p *TogetherProvider) GetEndpoints() []Endpoint {
+	return []Endpoint{
+		{
+			Path:        "/models",
+			Method:      "GET",
+			Description: "List available models",
+		},
+		{
+			Path:        "/chat/completions",
+			Method:      "POST",
+			Description: "Chat completion endpoint",
+			TestParams: map[string]interface{}{
+				"model": "meta-llama/Llama-3.3-70B-Instruct-Turbo",
+				"messages": []map[string]string{
+					{"role": "user", "content": "Hello"},
+				},
+				"max_tokens": 10,
+			},
+		},
+		{
+			Path:        "/embeddings",
+			Method:      "POST",
+			Description: "Create embeddings",
+			TestParams: map[string]interface{}{
+				"model": "BAAI/bge-base-en-v1.5",
+				"input": "Test embedding",
+			},
+		},
+	}
+}
+
+func (p *TogetherProvider) TestModel(ctx context.Context, modelID string, verbose bool) error {
+	if verbose {
+		fmt.Printf("  Testing model: %s\n", modelID)
+	}
+
+	payload := map[string]interface{}{
+		"model": modelID,
+		"messages": []map[string]string{
+			{"role": "user", "content": "Say 'test'"},
+		},
+		"max_tokens": 5,
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to test model: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("model test failed: %w", providererr.Classify("together", resp.StatusCode, resp.Header, body))
+	}
+
+	return nil
+}
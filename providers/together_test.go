@@ -0,0 +1,164 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestTogetherProvider(url string) *TogetherProvider {
+	return &TogetherProvider{
+		apiKey:  "test-key",
+		baseURL: url,
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func TestNewTogetherProvider(t *testing.T) {
+	provider := NewTogetherProvider("test-key")
+	tp, ok := provider.(*TogetherProvider)
+	if !ok {
+		t.Fatal("Expected *TogetherProvider")
+	}
+	if tp.baseURL != "https://api.together.xyz/v1" {
+		t.Errorf("Expected default baseURL, got %s", tp.baseURL)
+	}
+
+	if _, exists := GetProviderFactory("together"); !exists {
+		t.Error("Expected together provider to be registered")
+	}
+}
+
+func TestTogetherProvider_ListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[
+			{"id": "meta-llama/Llama-3.3-70B-Instruct-Turbo", "type": "chat", "display_name": "Llama 3.3 70B", "context_length": 131072, "created": 1733443200, "pricing": {"input": 0.88, "output": 0.88}},
+			{"id": "deepseek-ai/DeepSeek-R1", "type": "chat", "context_length": 163840, "pricing": {"input": 3.0, "output": 7.0}},
+			{"id": "BAAI/bge-base-en-v1.5", "type": "embedding", "context_length": 512, "pricing": {"input": 0.008, "output": 0}},
+			{"id": "Qwen/Qwen2.5-VL-72B-Instruct", "type": "chat", "context_length": 32768, "pricing": {"input": 1.95, "output": 8.0}}
+		]`))
+	}))
+	defer server.Close()
+
+	models, err := newTestTogetherProvider(server.URL).ListModels(context.Background(), false)
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if len(models) != 4 {
+		t.Fatalf("Expected 4 models, got %d", len(models))
+	}
+
+	llama := models[0]
+	if llama.Name != "Llama 3.3 70B" {
+		t.Errorf("Expected display name, got %s", llama.Name)
+	}
+	if !llama.CanStream || !llama.SupportsTools {
+		t.Error("Expected llama to support streaming and tools")
+	}
+	if llama.CostPer1MIn != 0.88 || llama.ContextWindow != 131072 {
+		t.Errorf("Unexpected llama pricing/context: %v/%d", llama.CostPer1MIn, llama.ContextWindow)
+	}
+	if llama.CreatedAt == "" {
+		t.Error("Expected CreatedAt to be set")
+	}
+
+	if !models[1].CanReason {
+		t.Error("Expected DeepSeek-R1 to be a reasoning model")
+	}
+	if models[1].Name != "deepseek-ai/DeepSeek-R1" {
+		t.Errorf("Expected ID as fallback name, got %s", models[1].Name)
+	}
+
+	embed := models[2]
+	if embed.CanStream || embed.Categories[0] != "embedding" {
+		t.Errorf("Unexpected embedding model detection: %+v", embed)
+	}
+
+	if !models[3].SupportsImages {
+		t.Error("Expected Qwen2.5-VL to support images")
+	}
+}
+
+func TestTogetherProvider_ListModels_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"unauthorized", http.StatusUnauthorized, `{"error": "invalid key"}`},
+		{"invalid json", http.StatusOK, `{not json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			if _, err := newTestTogetherProvider(server.URL).ListModels(context.Background(), false); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestTogetherProvider_TestModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("Expected /chat/completions, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"id": "x", "choices": [{"message": {"role": "assistant", "content": "test"}}]}`))
+	}))
+	defer server.Close()
+
+	if err := newTestTogetherProvider(server.URL).TestModel(context.Background(), "meta-llama/Llama-3.3-70B-Instruct-Turbo", true); err != nil {
+		t.Fatalf("TestModel failed: %v", err)
+	}
+}
+
+func TestTogetherProvider_TestModel_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if err := newTestTogetherProvider(server.URL).TestModel(context.Background(), "missing", false); err == nil {
+		t.Fatal("Expected error for 404 response")
+	}
+}
+
+func TestTogetherProvider_ValidateEndpoints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.Header.Get("Content-Type") != "application/json" {
+			t.Error("Expected JSON content type on POST")
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	if err := newTestTogetherProvider(server.URL).ValidateEndpoints(context.Background(), true); err != nil {
+		t.Fatalf("ValidateEndpoints failed: %v", err)
+	}
+}
+
+func TestTogetherProvider_ValidateEndpoints_AllFail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := newTestTogetherProvider(server.URL).ValidateEndpoints(context.Background(), false); err == nil {
+		t.Fatal("Expected error when all endpoints fail")
+	}
+}
@@ -0,0 +1,575 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CanaryStatus is the lifecycle state of a canary policy
+type CanaryStatus string
+
+const (
+	CanaryRunning    CanaryStatus = "running"
+	CanaryPromoted   CanaryStatus = "promoted"
+	CanaryRolledBack CanaryStatus = "rolled_back"
+	CanaryStopped    CanaryStatus = "stopped"
+)
+
+// CanaryPolicy sends a percentage of traffic for matching models to a new
+// model/provider and decides automatically whether to keep it.
+type CanaryPolicy struct {
+	// ID uniquely identifies the policy
+	ID string
+
+	// ModelPattern selects requests by model; supports "*" wildcards ("gpt-4*")
+	ModelPattern string
+
+	// CanaryModel and CanaryProvider receive the diverted traffic.
+	// An empty provider keeps the request's provider.
+	CanaryModel    string
+	CanaryProvider string
+
+	// Percent of matching traffic sent to the canary (0-100)
+	Percent float64
+
+	// MinSamples is the number of requests each arm needs before a decision
+	MinSamples int
+
+	// PromoteAfter is the canary request count at which a healthy canary is
+	// promoted. Defaults to MinSamples.
+	PromoteAfter int
+
+	// MaxErrorRateIncrease rolls back when canary error rate exceeds the
+	// baseline by more than this fraction (0.05 = 5 percentage points)
+	MaxErrorRateIncrease float64
+
+	// MaxLatencyRatio rolls back when canary average latency exceeds the
+	// baseline by this factor. Zero disables the check.
+	MaxLatencyRatio float64
+
+	// MaxQualityDrop rolls back when the canary's average quality score is
+	// lower than the baseline's by more than this amount. It needs a
+	// QualityScorer, which only scores requests sent through Route.
+	MaxQualityDrop float64
+}
+
+// Validate checks the policy for required fields and sane thresholds
+func (p *CanaryPolicy) Validate() error {
+	if p.ID == "" {
+		return fmt.Errorf("canary policy ID is required")
+	}
+	if p.ModelPattern == "" {
+		return fmt.Errorf("model pattern is required")
+	}
+	if p.CanaryModel == "" {
+		return fmt.Errorf("canary model is required")
+	}
+	if p.Percent <= 0 || p.Percent > 100 {
+		return fmt.Errorf("percent must be between 0 and 100")
+	}
+	if p.MinSamples < 1 {
+		return fmt.Errorf("min samples must be at least 1")
+	}
+	if p.MaxErrorRateIncrease < 0 || p.MaxLatencyRatio < 0 || p.MaxQualityDrop < 0 {
+		return fmt.Errorf("thresholds cannot be negative")
+	}
+	return nil
+}
+
+// QualityScorer rates a successful response, typically from 0 to 1.
+// It is called for both baseline and canary responses so they can be compared.
+type QualityScorer func(ctx context.Context, req Request, resp *Response) (float64, error)
+
+// CanaryArmStats aggregates outcomes for one side of a canary
+type CanaryArmStats struct {
+	Requests     int
+	Errors       int
+	TotalLatency time.Duration
+	QualitySum   float64
+	QualityCount int
+}
+
+// ErrorRate returns the fraction of failed requests
+func (s CanaryArmStats) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Requests)
+}
+
+// AvgLatency returns the mean latency of successful requests
+func (s CanaryArmStats) AvgLatency() time.Duration {
+	successes := s.Requests - s.Errors
+	if successes <= 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(successes)
+}
+
+// AvgQuality returns the mean quality score, if any responses were scored
+func (s CanaryArmStats) AvgQuality() (float64, bool) {
+	if s.QualityCount == 0 {
+		return 0, false
+	}
+	return s.QualitySum / float64(s.QualityCount), true
+}
+
+// CanaryResult is the current state of a canary, also used for persistence
+type CanaryResult struct {
+	Policy    CanaryPolicy
+	Status    CanaryStatus
+	Reason    string
+	Baseline  CanaryArmStats
+	Canary    CanaryArmStats
+	UpdatedAt time.Time
+}
+
+// CanaryStore persists canary results for later review, and canary
+// policies with their status so they survive restarts
+type CanaryStore interface {
+	SaveCanaryResult(result *CanaryResult) error
+	// SaveCanaryPolicy stores a result's policy, status and reason
+	SaveCanaryPolicy(result *CanaryResult) error
+	DeleteCanaryPolicy(id string) error
+	// LoadCanaryPolicies returns the stored policies in creation order,
+	// with their status and reason
+	LoadCanaryPolicies() ([]*CanaryResult, error)
+}
+
+// canaryState is the mutable state for one policy, guarded by CanaryRouter.mu
+type canaryState struct {
+	policy   CanaryPolicy
+	status   CanaryStatus
+	reason   string
+	baseline CanaryArmStats
+	canary   CanaryArmStats
+	updated  time.Time
+}
+
+func (s *canaryState) result() *CanaryResult {
+	return &CanaryResult{
+		Policy:    s.policy,
+		Status:    s.status,
+		Reason:    s.reason,
+		Baseline:  s.baseline,
+		Canary:    s.canary,
+		UpdatedAt: s.updated,
+	}
+}
+
+// CanaryRouter wraps a Router and diverts a share of matching traffic to
+// canary models, rolling back or promoting based on observed outcomes.
+type CanaryRouter struct {
+	next   Router
+	store  CanaryStore
+	scorer QualityScorer
+
+	mu       sync.Mutex
+	canaries map[string]*canaryState
+	order    []string
+	rand     func() float64
+}
+
+// NewCanaryRouter wraps next with canary routing. store may be nil.
+func NewCanaryRouter(next Router, store CanaryStore) *CanaryRouter {
+	return &CanaryRouter{
+		next:     next,
+		store:    store,
+		canaries: make(map[string]*canaryState),
+		rand:     rand.Float64,
+	}
+}
+
+// Unwrap returns the underlying router
+func (r *CanaryRouter) Unwrap() Router {
+	return r.next
+}
+
+// SetQualityScorer sets the optional quality hook used in canary decisions
+func (r *CanaryRouter) SetQualityScorer(scorer QualityScorer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scorer = scorer
+}
+
+// Restore reloads the policies saved in the store with their status.
+// Outcomes observed before the restart are not counted again.
+func (r *CanaryRouter) Restore() error {
+	if r.store == nil {
+		return nil
+	}
+	saved, err := r.store.LoadCanaryPolicies()
+	if err != nil {
+		return fmt.Errorf("failed to load canary policies: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, result := range saved {
+		id := result.Policy.ID
+		if _, ok := r.canaries[id]; !ok {
+			r.order = append(r.order, id)
+		}
+		r.canaries[id] = &canaryState{
+			policy:  result.Policy,
+			status:  result.Status,
+			reason:  result.Reason,
+			updated: result.UpdatedAt,
+		}
+	}
+	return nil
+}
+
+// AddPolicy starts a new canary
+func (r *CanaryRouter) AddPolicy(policy CanaryPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if policy.PromoteAfter < policy.MinSamples {
+		policy.PromoteAfter = policy.MinSamples
+	}
+
+	r.mu.Lock()
+	if policy.MaxQualityDrop > 0 && r.scorer == nil {
+		r.mu.Unlock()
+		return fmt.Errorf("max quality drop needs a quality scorer, and none is set")
+	}
+	if existing, ok := r.canaries[policy.ID]; ok && existing.status == CanaryRunning {
+		r.mu.Unlock()
+		return fmt.Errorf("canary %s is already running", policy.ID)
+	}
+	if _, ok := r.canaries[policy.ID]; !ok {
+		r.order = append(r.order, policy.ID)
+	}
+	state := &canaryState{
+		policy:  policy,
+		status:  CanaryRunning,
+		updated: time.Now(),
+	}
+	r.canaries[policy.ID] = state
+	result := state.result()
+	r.mu.Unlock()
+
+	r.savePolicy(result)
+	return nil
+}
+
+// RemovePolicy stops and forgets a canary, persisting its final state
+func (r *CanaryRouter) RemovePolicy(id string) error {
+	r.mu.Lock()
+	state, ok := r.canaries[id]
+	if !ok {
+		r.mu.Unlock()
+		return fmt.Errorf("canary %s not found", id)
+	}
+	if state.status == CanaryRunning {
+		state.status = CanaryStopped
+		state.reason = "removed"
+		state.updated = time.Now()
+	}
+	result := state.result()
+	delete(r.canaries, id)
+	for i, existing := range r.order {
+		if existing == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	r.mu.Unlock()
+
+	r.persist(result)
+	if r.store != nil {
+		if err := r.store.DeleteCanaryPolicy(id); err != nil {
+			log.Printf("routing: failed to delete canary policy %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// Promote manually promotes a running canary
+func (r *CanaryRouter) Promote(id, reason string) error {
+	return r.decide(id, CanaryPromoted, reason)
+}
+
+// Rollback manually rolls back a running canary
+func (r *CanaryRouter) Rollback(id, reason string) error {
+	return r.decide(id, CanaryRolledBack, reason)
+}
+
+// Get returns the current state of a canary
+func (r *CanaryRouter) Get(id string) (*CanaryResult, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.canaries[id]
+	if !ok {
+		return nil, false
+	}
+	return state.result(), true
+}
+
+// List returns the current state of all canaries in creation order
+func (r *CanaryRouter) List() []*CanaryResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := make([]*CanaryResult, 0, len(r.order))
+	for _, id := range r.order {
+		results = append(results, r.canaries[id].result())
+	}
+	return results
+}
+
+// CanaryArm is the side of a canary a request was assigned to, with the
+// model and provider it goes to
+type CanaryArm struct {
+	PolicyID string
+	Canary   bool // Whether the request goes to the canary rather than the baseline
+	Model    string
+	Provider string
+
+	status CanaryStatus
+}
+
+// Assign picks the arm of the first canary covering model for a request
+// bound for provider, rewriting the target for the canary arm. ok is false
+// when no running or promoted canary covers the model. Callers report the
+// outcome with Observe.
+func (r *CanaryRouter) Assign(model, provider string) (arm CanaryArm, ok bool) {
+	policy, status, useCanary, ok := r.selectArm(model)
+	if !ok {
+		return CanaryArm{}, false
+	}
+	arm = CanaryArm{PolicyID: policy.ID, Canary: useCanary, Model: model, Provider: provider, status: status}
+	if useCanary {
+		arm.Model = policy.CanaryModel
+		if policy.CanaryProvider != "" {
+			arm.Provider = policy.CanaryProvider
+		}
+	}
+	return arm, true
+}
+
+// Observe records whether a request assigned with Assign failed and how
+// long it took. Observed requests carry no quality score.
+func (r *CanaryRouter) Observe(arm CanaryArm, failed bool, latency time.Duration) {
+	// Promoted canaries only rewrite traffic; there is nothing left to decide
+	if arm.status == CanaryPromoted {
+		return
+	}
+	r.record(arm.PolicyID, arm.Canary, failed, latency, 0, false)
+}
+
+// Route diverts matching requests to the canary and records the outcome
+func (r *CanaryRouter) Route(ctx context.Context, req Request) (*Response, error) {
+	arm, ok := r.Assign(req.Model, req.Provider)
+	if !ok {
+		return r.next.Route(ctx, req)
+	}
+	req.Model, req.Provider = arm.Model, arm.Provider
+
+	start := time.Now()
+	resp, err := r.next.Route(ctx, req)
+	latency := time.Since(start)
+	if resp != nil && resp.Latency > 0 {
+		latency = resp.Latency
+	}
+
+	if arm.status == CanaryPromoted {
+		return resp, err
+	}
+
+	var score float64
+	var scored bool
+	r.mu.Lock()
+	scorer := r.scorer
+	r.mu.Unlock()
+	if err == nil && scorer != nil {
+		if s, scoreErr := scorer(ctx, req, resp); scoreErr == nil {
+			score, scored = s, true
+		}
+	}
+
+	r.record(arm.PolicyID, arm.Canary, err != nil, latency, score, scored)
+	return resp, err
+}
+
+// Close persists running canaries and closes the underlying router
+func (r *CanaryRouter) Close() error {
+	for _, result := range r.List() {
+		if result.Status == CanaryRunning {
+			r.persist(result)
+		}
+	}
+	return r.next.Close()
+}
+
+// selectArm finds the active policy for a model and decides whether this
+// request goes to the canary. ok is false when no policy applies.
+func (r *CanaryRouter) selectArm(model string) (policy CanaryPolicy, status CanaryStatus, useCanary, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range r.order {
+		state := r.canaries[id]
+		if state.status != CanaryRunning && state.status != CanaryPromoted {
+			continue
+		}
+		if !matchModelPattern(state.policy.ModelPattern, model) {
+			continue
+		}
+		if state.status == CanaryPromoted {
+			return state.policy, state.status, true, true
+		}
+		return state.policy, state.status, r.rand()*100 < state.policy.Percent, true
+	}
+	return CanaryPolicy{}, "", false, false
+}
+
+// record adds an outcome to the right arm and evaluates thresholds
+func (r *CanaryRouter) record(id string, canary, failed bool, latency time.Duration, score float64, scored bool) {
+	r.mu.Lock()
+	state, ok := r.canaries[id]
+	if !ok || state.status != CanaryRunning {
+		r.mu.Unlock()
+		return
+	}
+
+	arm := &state.baseline
+	if canary {
+		arm = &state.canary
+	}
+	arm.Requests++
+	if failed {
+		arm.Errors++
+	} else {
+		arm.TotalLatency += latency
+	}
+	if scored {
+		arm.QualitySum += score
+		arm.QualityCount++
+	}
+	state.updated = time.Now()
+
+	status, reason := evaluateCanary(state)
+	if status == CanaryRunning {
+		r.mu.Unlock()
+		return
+	}
+	state.status = status
+	state.reason = reason
+	result := state.result()
+	r.mu.Unlock()
+
+	log.Printf("routing: canary %s %s: %s", id, status, reason)
+	r.persist(result)
+	r.savePolicy(result)
+}
+
+// decide applies a manual promote/rollback decision
+func (r *CanaryRouter) decide(id string, status CanaryStatus, reason string) error {
+	r.mu.Lock()
+	state, ok := r.canaries[id]
+	if !ok {
+		r.mu.Unlock()
+		return fmt.Errorf("canary %s not found", id)
+	}
+	if state.status != CanaryRunning {
+		r.mu.Unlock()
+		return fmt.Errorf("canary %s is not running (status: %s)", id, state.status)
+	}
+	if reason == "" {
+		reason = "manual"
+	}
+	state.status = status
+	state.reason = reason
+	state.updated = time.Now()
+	result := state.result()
+	r.mu.Unlock()
+
+	r.persist(result)
+	r.savePolicy(result)
+	return nil
+}
+
+// savePolicy stores a canary's policy and status, logging failures
+func (r *CanaryRouter) savePolicy(result *CanaryResult) {
+	if r.store == nil {
+		return
+	}
+	if err := r.store.SaveCanaryPolicy(result); err != nil {
+		log.Printf("routing: failed to save canary policy %s: %v", result.Policy.ID, err)
+	}
+}
+
+// persist saves a result, logging failures so routing is never interrupted
+func (r *CanaryRouter) persist(result *CanaryResult) {
+	if r.store == nil {
+		return
+	}
+	if err := r.store.SaveCanaryResult(result); err != nil {
+		log.Printf("routing: failed to save canary result for %s: %v", result.Policy.ID, err)
+	}
+}
+
+// evaluateCanary compares canary and baseline stats against policy thresholds
+func evaluateCanary(state *canaryState) (CanaryStatus, string) {
+	p := state.policy
+	base, can := state.baseline, state.canary
+
+	if can.Requests < p.MinSamples || base.Requests < p.MinSamples {
+		return CanaryRunning, ""
+	}
+
+	if delta := can.ErrorRate() - base.ErrorRate(); delta > p.MaxErrorRateIncrease {
+		return CanaryRolledBack, fmt.Sprintf("error rate %.1f%% vs baseline %.1f%%",
+			can.ErrorRate()*100, base.ErrorRate()*100)
+	}
+
+	if p.MaxLatencyRatio > 0 && base.AvgLatency() > 0 {
+		ratio := float64(can.AvgLatency()) / float64(base.AvgLatency())
+		if ratio > p.MaxLatencyRatio {
+			return CanaryRolledBack, fmt.Sprintf("latency %v is %.2fx baseline %v",
+				can.AvgLatency(), ratio, base.AvgLatency())
+		}
+	}
+
+	baseQuality, baseOK := base.AvgQuality()
+	canQuality, canOK := can.AvgQuality()
+	if baseOK && canOK && baseQuality-canQuality > p.MaxQualityDrop {
+		return CanaryRolledBack, fmt.Sprintf("quality %.3f vs baseline %.3f", canQuality, baseQuality)
+	}
+
+	if can.Requests >= p.PromoteAfter {
+		return CanaryPromoted, fmt.Sprintf("healthy after %d canary requests", can.Requests)
+	}
+
+	return CanaryRunning, ""
+}
+
+// matchModelPattern matches a model name against a pattern where "*" matches
+// any run of characters, including "/"
+func matchModelPattern(pattern, model string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == model
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(model, parts[0]) {
+		return false
+	}
+	model = model[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(model, part)
+		if idx < 0 {
+			return false
+		}
+		model = model[idx+len(part):]
+	}
+	return strings.HasSuffix(model, last) && len(model) >= len(last)
+}
@@ -0,0 +1,424 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRouter returns canned outcomes per model
+type fakeRouter struct {
+	mu      sync.Mutex
+	fail    map[string]bool
+	latency map[string]time.Duration
+	calls   []Request
+	closed  bool
+}
+
+func (f *fakeRouter) Route(ctx context.Context, req Request) (*Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, req)
+	if f.fail[req.Model] {
+		return nil, errors.New("upstream error")
+	}
+	latency := f.latency[req.Model]
+	if latency == 0 {
+		latency = 100 * time.Millisecond
+	}
+	return &Response{Model: req.Model, Content: "ok", Provider: req.Provider, Latency: latency}, nil
+}
+
+func (f *fakeRouter) Close() error {
+	f.closed = true
+	return nil
+}
+
+// memoryCanaryStore records saved results and policies
+type memoryCanaryStore struct {
+	mu       sync.Mutex
+	results  []*CanaryResult
+	policies []*CanaryResult
+}
+
+func (m *memoryCanaryStore) SaveCanaryResult(result *CanaryResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results = append(m.results, result)
+	return nil
+}
+
+func (m *memoryCanaryStore) SaveCanaryPolicy(result *CanaryResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, p := range m.policies {
+		if p.Policy.ID == result.Policy.ID {
+			m.policies[i] = result
+			return nil
+		}
+	}
+	m.policies = append(m.policies, result)
+	return nil
+}
+
+func (m *memoryCanaryStore) DeleteCanaryPolicy(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, p := range m.policies {
+		if p.Policy.ID == id {
+			m.policies = append(m.policies[:i], m.policies[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (m *memoryCanaryStore) LoadCanaryPolicies() ([]*CanaryResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*CanaryResult(nil), m.policies...), nil
+}
+
+// alternatingRand sends every other request to the canary
+func alternatingRand() func() float64 {
+	n := 0
+	return func() float64 {
+		n++
+		if n%2 == 0 {
+			return 0.0
+		}
+		return 0.99
+	}
+}
+
+func testPolicy() CanaryPolicy {
+	return CanaryPolicy{
+		ID:                   "gpt5-canary",
+		ModelPattern:         "gpt-4*",
+		CanaryModel:          "gpt-5",
+		CanaryProvider:       "openai",
+		Percent:              50,
+		MinSamples:           5,
+		PromoteAfter:         10,
+		MaxErrorRateIncrease: 0.05,
+		MaxLatencyRatio:      1.5,
+	}
+}
+
+func routeN(t *testing.T, r *CanaryRouter, model string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		_, _ = r.Route(context.Background(), Request{Model: model, Provider: "openai"})
+	}
+}
+
+func TestCanaryPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(p *CanaryPolicy)
+		wantErr bool
+	}{
+		{"valid", func(p *CanaryPolicy) {}, false},
+		{"missing id", func(p *CanaryPolicy) { p.ID = "" }, true},
+		{"missing pattern", func(p *CanaryPolicy) { p.ModelPattern = "" }, true},
+		{"missing canary model", func(p *CanaryPolicy) { p.CanaryModel = "" }, true},
+		{"zero percent", func(p *CanaryPolicy) { p.Percent = 0 }, true},
+		{"percent over 100", func(p *CanaryPolicy) { p.Percent = 101 }, true},
+		{"no samples", func(p *CanaryPolicy) { p.MinSamples = 0 }, true},
+		{"negative threshold", func(p *CanaryPolicy) { p.MaxLatencyRatio = -1 }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testPolicy()
+			tt.mutate(&p)
+			if err := p.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCanaryRouter_SplitsMatchingTraffic(t *testing.T) {
+	next := &fakeRouter{}
+	r := NewCanaryRouter(next, nil)
+	r.rand = alternatingRand()
+	if err := r.AddPolicy(testPolicy()); err != nil {
+		t.Fatalf("AddPolicy() error = %v", err)
+	}
+
+	routeN(t, r, "gpt-4o", 4)
+	routeN(t, r, "claude-sonnet-4-5", 2)
+
+	counts := map[string]int{}
+	for _, call := range next.calls {
+		counts[call.Model]++
+	}
+	if counts["gpt-5"] != 2 || counts["gpt-4o"] != 2 {
+		t.Errorf("expected 2/2 split, got %v", counts)
+	}
+	if counts["claude-sonnet-4-5"] != 2 {
+		t.Errorf("non-matching traffic should pass through, got %v", counts)
+	}
+
+	result, ok := r.Get("gpt5-canary")
+	if !ok {
+		t.Fatal("Get() did not find canary")
+	}
+	if result.Baseline.Requests != 2 || result.Canary.Requests != 2 {
+		t.Errorf("unexpected arm counts: baseline=%d canary=%d", result.Baseline.Requests, result.Canary.Requests)
+	}
+}
+
+func TestCanaryRouter_RollsBackOnErrors(t *testing.T) {
+	next := &fakeRouter{fail: map[string]bool{"gpt-5": true}}
+	store := &memoryCanaryStore{}
+	r := NewCanaryRouter(next, store)
+	r.rand = alternatingRand()
+	_ = r.AddPolicy(testPolicy())
+
+	routeN(t, r, "gpt-4o", 10)
+
+	result, _ := r.Get("gpt5-canary")
+	if result.Status != CanaryRolledBack {
+		t.Fatalf("expected rolled_back, got %s", result.Status)
+	}
+	if len(store.results) != 1 || store.results[0].Status != CanaryRolledBack {
+		t.Errorf("expected rollback to be persisted, got %d results", len(store.results))
+	}
+
+	// After rollback all traffic stays on the baseline
+	before := len(next.calls)
+	routeN(t, r, "gpt-4o", 4)
+	for _, call := range next.calls[before:] {
+		if call.Model != "gpt-4o" {
+			t.Errorf("expected baseline model after rollback, got %s", call.Model)
+		}
+	}
+}
+
+func TestCanaryRouter_RollsBackOnLatency(t *testing.T) {
+	next := &fakeRouter{latency: map[string]time.Duration{"gpt-5": 300 * time.Millisecond}}
+	r := NewCanaryRouter(next, nil)
+	r.rand = alternatingRand()
+	_ = r.AddPolicy(testPolicy())
+
+	routeN(t, r, "gpt-4o", 10)
+
+	result, _ := r.Get("gpt5-canary")
+	if result.Status != CanaryRolledBack {
+		t.Errorf("expected rolled_back for slow canary, got %s", result.Status)
+	}
+}
+
+func TestCanaryRouter_RollsBackOnQuality(t *testing.T) {
+	next := &fakeRouter{}
+	r := NewCanaryRouter(next, nil)
+	r.rand = alternatingRand()
+	r.SetQualityScorer(func(ctx context.Context, req Request, resp *Response) (float64, error) {
+		if resp.Model == "gpt-5" {
+			return 0.5, nil
+		}
+		return 0.9, nil
+	})
+	policy := testPolicy()
+	policy.MaxQualityDrop = 0.1
+	_ = r.AddPolicy(policy)
+
+	routeN(t, r, "gpt-4o", 10)
+
+	result, _ := r.Get("gpt5-canary")
+	if result.Status != CanaryRolledBack {
+		t.Errorf("expected rolled_back for low quality, got %s", result.Status)
+	}
+}
+
+func TestCanaryRouter_QualityNeedsScorer(t *testing.T) {
+	r := NewCanaryRouter(&fakeRouter{}, nil)
+	policy := testPolicy()
+	policy.MaxQualityDrop = 0.1
+	if err := r.AddPolicy(policy); err == nil {
+		t.Fatal("expected a quality threshold without a scorer to be rejected")
+	}
+	if _, ok := r.Get(policy.ID); ok {
+		t.Error("expected the rejected policy not to be added")
+	}
+}
+
+func TestCanaryRouter_PromotesHealthyCanary(t *testing.T) {
+	next := &fakeRouter{}
+	store := &memoryCanaryStore{}
+	r := NewCanaryRouter(next, store)
+	r.rand = alternatingRand()
+	_ = r.AddPolicy(testPolicy())
+
+	routeN(t, r, "gpt-4o", 20)
+
+	result, _ := r.Get("gpt5-canary")
+	if result.Status != CanaryPromoted {
+		t.Fatalf("expected promoted, got %s (%s)", result.Status, result.Reason)
+	}
+	if len(store.results) != 1 {
+		t.Errorf("expected promotion to be persisted once, got %d", len(store.results))
+	}
+
+	// Promoted canaries receive all matching traffic
+	before := len(next.calls)
+	routeN(t, r, "gpt-4-turbo", 3)
+	for _, call := range next.calls[before:] {
+		if call.Model != "gpt-5" {
+			t.Errorf("expected canary model after promotion, got %s", call.Model)
+		}
+	}
+}
+
+func TestCanaryRouter_ManualDecisions(t *testing.T) {
+	store := &memoryCanaryStore{}
+	r := NewCanaryRouter(&fakeRouter{}, store)
+	_ = r.AddPolicy(testPolicy())
+
+	if err := r.Rollback("missing", ""); err == nil {
+		t.Error("expected error for unknown canary")
+	}
+	if err := r.Rollback("gpt5-canary", "bad outputs"); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if err := r.Promote("gpt5-canary", ""); err == nil {
+		t.Error("expected error promoting a rolled back canary")
+	}
+
+	// A finished canary can be restarted with the same ID
+	if err := r.AddPolicy(testPolicy()); err != nil {
+		t.Fatalf("AddPolicy() after rollback error = %v", err)
+	}
+	if err := r.AddPolicy(testPolicy()); err == nil {
+		t.Error("expected error adding a duplicate running canary")
+	}
+
+	if err := r.RemovePolicy("gpt5-canary"); err != nil {
+		t.Fatalf("RemovePolicy() error = %v", err)
+	}
+	if len(r.List()) != 0 {
+		t.Errorf("expected no canaries after removal, got %d", len(r.List()))
+	}
+	last := store.results[len(store.results)-1]
+	if last.Status != CanaryStopped {
+		t.Errorf("expected stopped result persisted on removal, got %s", last.Status)
+	}
+}
+
+func TestCanaryRouter_Close(t *testing.T) {
+	next := &fakeRouter{}
+	store := &memoryCanaryStore{}
+	r := NewCanaryRouter(next, store)
+	_ = r.AddPolicy(testPolicy())
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !next.closed {
+		t.Error("expected underlying router to be closed")
+	}
+	if len(store.results) != 1 || store.results[0].Status != CanaryRunning {
+		t.Error("expected running canary snapshot to be persisted on close")
+	}
+}
+
+func TestMatchModelPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		model   string
+		want    bool
+	}{
+		{"gpt-4o", "gpt-4o", true},
+		{"gpt-4o", "gpt-4o-mini", false},
+		{"gpt-4*", "gpt-4o-mini", true},
+		{"*-mini", "gpt-4o-mini", true},
+		{"*", "anything", true},
+		{"meta-llama/*-Turbo", "meta-llama/Llama-3.3-70B-Instruct-Turbo", true},
+		{"claude-*-4*", "claude-sonnet-4-5", true},
+		{"claude-*-4*", "claude-3-5-haiku", false},
+		{"a*a", "a", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchModelPattern(tt.pattern, tt.model); got != tt.want {
+			t.Errorf("matchModelPattern(%q, %q) = %v, want %v", tt.pattern, tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestCanaryRouter_RestoresPolicies(t *testing.T) {
+	store := &memoryCanaryStore{}
+	r := NewCanaryRouter(&fakeRouter{}, store)
+	r.AddPolicy(CanaryPolicy{ID: "running", ModelPattern: "gpt-4*", CanaryModel: "gpt-5", Percent: 10, MinSamples: 5})
+	r.AddPolicy(CanaryPolicy{ID: "promoted", ModelPattern: "claude-*", CanaryModel: "claude-next", Percent: 10, MinSamples: 5})
+	r.AddPolicy(CanaryPolicy{ID: "removed", ModelPattern: "llama-*", CanaryModel: "llama-next", Percent: 10, MinSamples: 5})
+	if err := r.Promote("promoted", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RemovePolicy("removed"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new router over the same store picks up where the last left off
+	restarted := NewCanaryRouter(&fakeRouter{}, store)
+	if err := restarted.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	results := restarted.List()
+	if len(results) != 2 || results[0].Policy.ID != "running" || results[1].Policy.ID != "promoted" {
+		t.Fatalf("restored %+v, want running and promoted", results)
+	}
+	if results[0].Status != CanaryRunning || results[0].Policy.PromoteAfter != 5 {
+		t.Errorf("running canary restored as %+v", results[0])
+	}
+	if results[1].Status != CanaryPromoted || results[1].Reason != "manual" {
+		t.Errorf("promoted canary restored as %+v", results[1])
+	}
+
+	// The promoted canary keeps taking all of its traffic
+	if arm, ok := restarted.Assign("claude-sonnet", "anthropic"); !ok || !arm.Canary || arm.Model != "claude-next" {
+		t.Errorf("Assign() = %+v, %v, want the promoted canary", arm, ok)
+	}
+}
+
+func TestCanaryRouter_AssignAndObserve(t *testing.T) {
+	store := &memoryCanaryStore{}
+	r := NewCanaryRouter(&fakeRouter{}, store)
+	r.rand = alternatingRand()
+	r.AddPolicy(CanaryPolicy{ID: "gpt5", ModelPattern: "gpt-4*", CanaryModel: "gpt-5", CanaryProvider: "azure", Percent: 50, MinSamples: 2})
+
+	if _, ok := r.Assign("claude-sonnet", "anthropic"); ok {
+		t.Error("Assign() matched a model no canary covers")
+	}
+
+	for i := 0; i < 4; i++ {
+		arm, ok := r.Assign("gpt-4o", "openai")
+		if !ok {
+			t.Fatal("Assign() found no canary")
+		}
+		if arm.Canary {
+			if arm.Model != "gpt-5" || arm.Provider != "azure" {
+				t.Fatalf("canary arm = %+v, want gpt-5 on azure", arm)
+			}
+		} else if arm.Model != "gpt-4o" || arm.Provider != "openai" {
+			t.Fatalf("baseline arm = %+v, want gpt-4o on openai", arm)
+		}
+		// The canary fails every request
+		r.Observe(arm, arm.Canary, 10*time.Millisecond)
+	}
+
+	result, _ := r.Get("gpt5")
+	if result.Status != CanaryRolledBack {
+		t.Fatalf("status = %s, want rolled back on canary errors", result.Status)
+	}
+	if len(store.policies) != 1 || store.policies[0].Status != CanaryRolledBack {
+		t.Errorf("stored policies = %+v, want the rollback saved", store.policies)
+	}
+	if _, ok := r.Assign("gpt-4o", "openai"); ok {
+		t.Error("Assign() still diverts after rollback")
+	}
+}
@@ -0,0 +1,128 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Deprecation is a model retirement read from a provider's deprecations
+// page
+type Deprecation struct {
+	Provider    string
+	ModelID     string
+	RetiresAt   time.Time
+	Replacement string // recommended replacement; empty when none is given
+	SourceURL   string
+}
+
+// deprecation table columns
+var (
+	deprecatedModelColumn = Column{Any: []string{"model"}, Not: []string{"replacement", "recommended"}}
+	retirementColumn      = Column{Any: []string{"shutdown", "retire"}}
+	replacementColumn     = Column{Any: []string{"replacement"}}
+)
+
+// ScrapeDeprecations fetches and parses a provider's deprecations page. A
+// provider whose source has no deprecations page has none.
+func (s *Scraper) ScrapeDeprecations(ctx context.Context, provider string) ([]Deprecation, error) {
+	src, ok := s.sources[provider]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownSource, provider)
+	}
+	if src.DeprecationURL == "" {
+		return nil, nil
+	}
+	tables, err := s.fetchTables(ctx, src.DeprecationURL)
+	if err != nil {
+		return nil, err
+	}
+	return parseDeprecations(src, tables), nil
+}
+
+// parseDeprecations reads retirements from every table with a model and a
+// shutdown or retirement date column. Rows without a date, or with only a
+// lower bound such as "Not sooner than ...", are skipped.
+func parseDeprecations(src Source, tables []table) []Deprecation {
+	var deprecations []Deprecation
+	seen := map[string]bool{}
+	for _, t := range tables {
+		if len(t.rows) < 2 {
+			continue
+		}
+		headers := t.rows[0]
+		modelCol, dateCol, replacementCol := deprecatedModelColumn.index(headers), retirementColumn.index(headers), replacementColumn.index(headers)
+		if modelCol < 0 || dateCol < 0 {
+			continue
+		}
+
+		for _, row := range t.rows[1:] {
+			if len(row) <= modelCol || len(row) <= dateCol {
+				continue
+			}
+			retires, ok := parseDate(row[dateCol])
+			if !ok || containsAny(strings.ToLower(row[dateCol]), []string{"not sooner", "not earlier", "no sooner", "no earlier"}) {
+				continue
+			}
+			replacement := ""
+			if replacementCol >= 0 && len(row) > replacementCol {
+				replacement = firstModel(row[replacementCol])
+			}
+			// A cell can list several snapshots of one model
+			for _, name := range strings.Split(row[modelCol], ",") {
+				modelID := normalizeModel(name)
+				if modelID == "" || seen[modelID] {
+					continue
+				}
+				seen[modelID] = true
+				deprecations = append(deprecations, Deprecation{
+					Provider:    src.Provider,
+					ModelID:     modelID,
+					RetiresAt:   retires,
+					Replacement: replacement,
+					SourceURL:   src.DeprecationURL,
+				})
+			}
+		}
+	}
+	return deprecations
+}
+
+// firstModel reads the first model named in a replacement cell, ignoring
+// placeholders such as "—" or "N/A"
+func firstModel(cell string) string {
+	name, _, _ := strings.Cut(cell, ",")
+	name, _, _ = strings.Cut(name, " or ")
+	id := normalizeModel(name)
+	switch id {
+	case "", "-", "—", "–", "n/a", "none", "tbd":
+		return ""
+	}
+	return id
+}
+
+var datePatterns = []struct {
+	pattern *regexp.Regexp
+	layout  string
+}{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}`), "2006-01-02"},
+	{regexp.MustCompile(`\d{4}/\d{2}/\d{2}`), "2006/01/02"},
+	{regexp.MustCompile(`[A-Z][a-z]+ \d{1,2}, \d{4}`), "January 2, 2006"},
+	{regexp.MustCompile(`[A-Z][a-z]{2} \d{1,2}, \d{4}`), "Jan 2, 2006"},
+	{regexp.MustCompile(`\d{1,2} [A-Z][a-z]+ \d{4}`), "2 January 2006"},
+}
+
+// parseDate reads the first date in a cell, e.g. "2025-07-21" or
+// "Tentative: July 21, 2025"
+func parseDate(s string) (time.Time, bool) {
+	for _, p := range datePatterns {
+		for _, match := range p.pattern.FindAllString(s, -1) {
+			if t, err := time.Parse(p.layout, match); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,480 @@
+package scraper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/storage"
+)
+
+// Kinds of staged changes
+const (
+	KindPricing   = "pricing"
+	KindRateLimit = "rate_limit"
+)
+
+var (
+	// ErrUnknownSource is returned when scraping a provider without a source
+	ErrUnknownSource = errors.New("no scraper source for provider")
+	// ErrNotPending is returned when reviewing a change that was already reviewed
+	ErrNotPending = errors.New("change is not pending")
+)
+
+// maxPageSize bounds documentation page downloads
+const maxPageSize = 5 << 20
+
+// Source describes where a provider publishes its pricing and rate limits
+type Source struct {
+	Provider string
+
+	PricingURL  string
+	PricingPlan string // plan type recorded for scraped prices
+	Model       Column // pricing table model column
+	Input       Column // input price per 1M tokens
+	Output      Column // output price per 1M tokens
+	Transposed  bool   // pricing table lists models across the top
+
+	RateLimitURL  string
+	RateLimitPlan string // plan type for tables without a tier heading
+
+	DeprecationURL string // page listing retired and retiring models
+}
+
+// DefaultSources returns the documentation pages of the providers the
+// scraper understands
+func DefaultSources() []Source {
+	model := Column{Any: []string{"model"}}
+	return []Source{
+		{
+			Provider:       "openai",
+			PricingURL:     "https://platform.openai.com/docs/pricing",
+			PricingPlan:    "tier-1",
+			Model:          model,
+			Input:          Column{Any: []string{"input"}, Not: []string{"cached"}},
+			Output:         Column{Any: []string{"output"}},
+			RateLimitURL:   "https://platform.openai.com/docs/guides/rate-limits",
+			RateLimitPlan:  "tier-1",
+			DeprecationURL: "https://platform.openai.com/docs/deprecations",
+		},
+		{
+			Provider:       "anthropic",
+			PricingURL:     "https://docs.anthropic.com/en/docs/about-claude/pricing",
+			PricingPlan:    "tier-1",
+			Model:          model,
+			Input:          Column{Any: []string{"input"}, Not: []string{"cache", "batch"}},
+			Output:         Column{Any: []string{"output"}, Not: []string{"batch"}},
+			RateLimitURL:   "https://docs.anthropic.com/en/api/rate-limits",
+			RateLimitPlan:  "tier-1",
+			DeprecationURL: "https://docs.anthropic.com/en/docs/about-claude/model-deprecations",
+		},
+		{
+			Provider:       "groq",
+			PricingURL:     "https://groq.com/pricing/",
+			PricingPlan:    "pay_per_go",
+			Model:          Column{Any: []string{"model", "ai model"}},
+			Input:          Column{Any: []string{"input"}},
+			Output:         Column{Any: []string{"output"}},
+			RateLimitURL:   "https://console.groq.com/docs/rate-limits",
+			RateLimitPlan:  "free",
+			DeprecationURL: "https://console.groq.com/docs/deprecations",
+		},
+		{
+			// DeepSeek does not publish fixed rate limits
+			Provider:    "deepseek",
+			PricingURL:  "https://api-docs.deepseek.com/quick_start/pricing",
+			PricingPlan: "pay_per_go",
+			Model:       model,
+			Input:       Column{Any: []string{"input"}, Not: []string{"cache hit"}},
+			Output:      Column{Any: []string{"output"}},
+			Transposed:  true,
+		},
+	}
+}
+
+// Config holds scraper settings
+type Config struct {
+	Sources []Source
+	Timeout time.Duration // per-page request timeout
+}
+
+// DefaultConfig returns a config for the default sources
+func DefaultConfig() Config {
+	return Config{Sources: DefaultSources(), Timeout: 30 * time.Second}
+}
+
+// Scraper fetches provider documentation pages and stages differences from
+// the stored rate limits and pricing for admin review
+type Scraper struct {
+	client  *http.Client
+	sources map[string]Source
+}
+
+// New creates a scraper
+func New(cfg Config) *Scraper {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	sources := make(map[string]Source, len(cfg.Sources))
+	for _, src := range cfg.Sources {
+		sources[src.Provider] = src
+	}
+	return &Scraper{client: &http.Client{Timeout: cfg.Timeout}, sources: sources}
+}
+
+// Providers returns the providers with a source, sorted
+func (s *Scraper) Providers() []string {
+	names := make([]string, 0, len(s.sources))
+	for name := range s.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ScrapeResult holds the values parsed from a provider's pages
+type ScrapeResult struct {
+	Provider   string
+	Pricing    []storage.ProviderPricing
+	RateLimits []storage.RateLimit
+}
+
+// Scrape fetches and parses a provider's pricing and rate limit pages
+func (s *Scraper) Scrape(ctx context.Context, provider string) (*ScrapeResult, error) {
+	src, ok := s.sources[provider]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownSource, provider)
+	}
+
+	result := &ScrapeResult{Provider: provider}
+	if src.PricingURL != "" {
+		tables, err := s.fetchTables(ctx, src.PricingURL)
+		if err != nil {
+			return nil, err
+		}
+		result.Pricing = parsePricing(src, tables)
+	}
+	if src.RateLimitURL != "" {
+		tables, err := s.fetchTables(ctx, src.RateLimitURL)
+		if err != nil {
+			return nil, err
+		}
+		result.RateLimits = parseRateLimits(src, tables)
+	}
+	return result, nil
+}
+
+// StageResult summarizes a scrape
+type StageResult struct {
+	Provider   string `json:"provider"`
+	Pricing    int    `json:"pricing"`     // Prices found on the page
+	RateLimits int    `json:"rate_limits"` // Rate limits found on the page
+	Staged     int    `json:"staged"`      // Changes staged for review
+}
+
+// Stage scrapes a provider and stages every value that differs from the
+// database. Nothing is applied until the change is approved.
+func (s *Scraper) Stage(ctx context.Context, provider string) (*StageResult, error) {
+	if storage.GetRateLimitDB() == nil {
+		return nil, fmt.Errorf("rate limit database not initialized")
+	}
+
+	scraped, err := s.Scrape(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StageResult{Provider: provider, Pricing: len(scraped.Pricing), RateLimits: len(scraped.RateLimits)}
+	src := s.sources[provider]
+
+	for _, price := range scraped.Pricing {
+		change, err := diffPricing(price)
+		if err != nil {
+			return nil, err
+		}
+		if change == nil {
+			continue
+		}
+		change.SourceURL = src.PricingURL
+		if _, err := storage.StageChange(*change); err != nil {
+			return nil, fmt.Errorf("failed to stage %s pricing: %w", price.ModelID, err)
+		}
+		result.Staged++
+	}
+
+	for _, limit := range scraped.RateLimits {
+		change, err := diffRateLimit(limit)
+		if err != nil {
+			return nil, err
+		}
+		if change == nil {
+			continue
+		}
+		change.SourceURL = src.RateLimitURL
+		if _, err := storage.StageChange(*change); err != nil {
+			return nil, fmt.Errorf("failed to stage %s rate limit: %w", limit.LimitType, err)
+		}
+		result.Staged++
+	}
+
+	return result, nil
+}
+
+// fetchTables downloads a page and extracts its tables
+func (s *Scraper) fetchTables(ctx context.Context, url string) ([]table, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "modelscan-scraper")
+	req.Header.Set("Accept", "text/html, text/markdown, application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", url, err)
+	}
+
+	tables, err := parseTables(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", url, err)
+	}
+	return tables, nil
+}
+
+// parsePricing reads model prices from every table with model, input and
+// output columns
+func parsePricing(src Source, tables []table) []storage.ProviderPricing {
+	var prices []storage.ProviderPricing
+	seen := map[string]bool{}
+	for _, t := range tables {
+		if src.Transposed {
+			t = t.transpose()
+		}
+		if len(t.rows) < 2 {
+			continue
+		}
+		headers := t.rows[0]
+		modelCol, inputCol, outputCol := src.Model.index(headers), src.Input.index(headers), src.Output.index(headers)
+		if modelCol < 0 || inputCol < 0 || outputCol < 0 {
+			continue
+		}
+
+		for _, row := range t.rows[1:] {
+			if len(row) <= modelCol || len(row) <= inputCol || len(row) <= outputCol {
+				continue
+			}
+			modelID := normalizeModel(row[modelCol])
+			input, okIn := parsePrice(row[inputCol])
+			output, okOut := parsePrice(row[outputCol])
+			if modelID == "" || !okIn || !okOut || seen[modelID] {
+				continue
+			}
+			seen[modelID] = true
+			prices = append(prices, storage.ProviderPricing{
+				ProviderName: src.Provider,
+				ModelID:      modelID,
+				PlanType:     src.PricingPlan,
+				InputCost:    input,
+				OutputCost:   output,
+				UnitType:     "1M tokens",
+				Currency:     "USD",
+			})
+		}
+	}
+	return prices
+}
+
+// rateLimitColumns maps limit types to the headers that report them
+var rateLimitColumns = []struct {
+	limitType string
+	window    int64
+	column    Column
+}{
+	{"rpm", 60, Column{Any: []string{"rpm", "requests per minute"}}},
+	{"rpd", 86400, Column{Any: []string{"rpd", "requests per day"}}},
+	{"tpm", 60, Column{Any: []string{"tpm", "tokens per minute"}, Not: []string{"otpm", "output"}}},
+	{"tpd", 86400, Column{Any: []string{"tpd", "tokens per day"}}},
+}
+
+var tierPattern = regexp.MustCompile(`(?i)tier\s*(\d+)`)
+
+// parseRateLimits reads limits from every table with at least one limit
+// column. Tables under a "Tier N" or "Free" heading use that plan.
+func parseRateLimits(src Source, tables []table) []storage.RateLimit {
+	var limits []storage.RateLimit
+	seen := map[string]bool{}
+	now := time.Now()
+	for _, t := range tables {
+		if len(t.rows) < 2 {
+			continue
+		}
+		headers := t.rows[0]
+		modelCol := Column{Any: []string{"model"}}.index(headers)
+		plan := planFromHeading(t.heading, src.RateLimitPlan)
+
+		for _, lc := range rateLimitColumns {
+			col := lc.column.index(headers)
+			if col < 0 {
+				continue
+			}
+			for _, row := range t.rows[1:] {
+				if len(row) <= col {
+					continue
+				}
+				value, ok := parseQuantity(row[col])
+				if !ok {
+					continue
+				}
+
+				limit := storage.RateLimit{
+					ProviderName:       src.Provider,
+					PlanType:           plan,
+					LimitType:          lc.limitType,
+					LimitValue:         value,
+					ResetWindowSeconds: lc.window,
+					AppliesTo:          "account",
+					SourceURL:          src.RateLimitURL,
+					LastVerified:       now,
+				}
+				if modelCol >= 0 && modelCol < len(row) {
+					modelID := normalizeModel(row[modelCol])
+					if modelID == "" {
+						continue
+					}
+					limit.AppliesTo = "model"
+					limit.ModelID = sql.NullString{String: modelID, Valid: true}
+				}
+
+				key := rateLimitKey(limit)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				limits = append(limits, limit)
+			}
+		}
+	}
+	return limits
+}
+
+// planFromHeading maps headings such as "Tier 2" or "Free tier" to a plan type
+func planFromHeading(heading, fallback string) string {
+	if m := tierPattern.FindStringSubmatch(heading); m != nil {
+		return "tier-" + m[1]
+	}
+	if strings.Contains(strings.ToLower(heading), "free") {
+		return "free"
+	}
+	return fallback
+}
+
+func pricingKey(p storage.ProviderPricing) string {
+	return strings.Join([]string{KindPricing, p.ProviderName, p.PlanType, p.ModelID}, ":")
+}
+
+func rateLimitKey(rl storage.RateLimit) string {
+	return strings.Join([]string{KindRateLimit, rl.ProviderName, rl.PlanType, rl.ModelID.String, rl.LimitType}, ":")
+}
+
+// diffPricing returns a staged change when a scraped price differs from the database
+func diffPricing(price storage.ProviderPricing) (*storage.StagedChange, error) {
+	current, err := storage.GetProviderPricing(price.ProviderName, price.ModelID, price.PlanType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s pricing: %w", price.ModelID, err)
+	}
+	if current != nil && current.InputCost == price.InputCost && current.OutputCost == price.OutputCost {
+		return nil, nil
+	}
+
+	change := &storage.StagedChange{
+		ChangeKey:    pricingKey(price),
+		Kind:         KindPricing,
+		ProviderName: price.ProviderName,
+		PlanType:     price.PlanType,
+		ModelID:      price.ModelID,
+		Summary:      fmt.Sprintf("new price: input $%.2f, output $%.2f per %s", price.InputCost, price.OutputCost, price.UnitType),
+	}
+	if current == nil {
+		return change, encodeChange(change, nil, price)
+	}
+	change.Summary = fmt.Sprintf("input $%.2f -> $%.2f, output $%.2f -> $%.2f per %s",
+		current.InputCost, price.InputCost, current.OutputCost, price.OutputCost, price.UnitType)
+	return change, encodeChange(change, current, price)
+}
+
+// diffRateLimit returns a staged change when a scraped limit differs from the database
+func diffRateLimit(limit storage.RateLimit) (*storage.StagedChange, error) {
+	matches, err := storage.QueryRateLimit(limit.ProviderName, limit.PlanType, limit.LimitType, limit.ModelID.String, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s rate limit: %w", limit.LimitType, err)
+	}
+
+	// QueryRateLimit also returns account-wide limits for a model; only an
+	// exact match is the stored value for this record
+	var current *storage.RateLimit
+	for i := range matches {
+		if matches[i].ModelID.String == limit.ModelID.String && !matches[i].EndpointPath.Valid {
+			current = &matches[i]
+			break
+		}
+	}
+	if current != nil && current.LimitValue == limit.LimitValue {
+		return nil, nil
+	}
+
+	scope := "account"
+	if limit.ModelID.Valid {
+		scope = limit.ModelID.String
+	}
+	change := &storage.StagedChange{
+		ChangeKey:    rateLimitKey(limit),
+		Kind:         KindRateLimit,
+		ProviderName: limit.ProviderName,
+		PlanType:     limit.PlanType,
+		ModelID:      limit.ModelID.String,
+		LimitType:    limit.LimitType,
+		Summary:      fmt.Sprintf("new %s %s limit %d", scope, limit.LimitType, limit.LimitValue),
+	}
+	if current == nil {
+		return change, encodeChange(change, nil, limit)
+	}
+	change.Summary = fmt.Sprintf("%s %s limit %d -> %d", scope, limit.LimitType, current.LimitValue, limit.LimitValue)
+	return change, encodeChange(change, current, limit)
+}
+
+// encodeChange stores the current (nil when new) and proposed records as JSON
+func encodeChange(change *storage.StagedChange, current, proposed interface{}) error {
+	newValue, err := json.Marshal(proposed)
+	if err != nil {
+		return fmt.Errorf("failed to encode proposed value: %w", err)
+	}
+	change.NewValue = string(newValue)
+
+	if current == nil {
+		return nil
+	}
+	oldValue, err := json.Marshal(current)
+	if err != nil {
+		return fmt.Errorf("failed to encode current value: %w", err)
+	}
+	change.OldValue = sql.NullString{String: string(oldValue), Valid: true}
+	return nil
+}
@@ -0,0 +1,272 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/storage"
+)
+
+const anthropicPricingPage = `<h2>Model pricing</h2>
+<table>
+<tr><th>Model</th><th>Base Input Tokens</th><th>5m Cache Writes</th><th>Cache Hits</th><th>Output Tokens</th></tr>
+<tr><td>Claude 3.5 Sonnet (deprecated)</td><td>$3 / MTok</td><td>$3.75 / MTok</td><td>$0.30 / MTok</td><td>$15 / MTok</td></tr>
+<tr><td>Claude 3.5 Haiku</td><td>$1 / MTok</td><td>$1.25 / MTok</td><td>$0.10 / MTok</td><td>$5 / MTok</td></tr>
+</table>`
+
+const anthropicRateLimitPage = `<h3>Tier 1</h3>
+<table>
+<tr><th>Model</th><th>Maximum requests per minute (RPM)</th><th>Maximum input tokens per minute (ITPM)</th><th>Maximum output tokens per minute (OTPM)</th></tr>
+<tr><td>Claude 3.5 Sonnet</td><td>50</td><td>40,000</td><td>8,000</td></tr>
+</table>
+<h3>Tier 2</h3>
+<table>
+<tr><th>Model</th><th>Maximum requests per minute (RPM)</th><th>Maximum input tokens per minute (ITPM)</th><th>Maximum output tokens per minute (OTPM)</th></tr>
+<tr><td>Claude 3.5 Sonnet</td><td>1,000</td><td>80,000</td><td>16,000</td></tr>
+</table>`
+
+const deepseekPricingPage = `| MODEL | deepseek-chat | deepseek-reasoner |
+|---|---|---|
+| CONTEXT LENGTH | 64K | 64K |
+| 1M INPUT TOKENS (CACHE HIT) | $0.07 | $0.14 |
+| 1M INPUT TOKENS (CACHE MISS) | $0.27 | $0.55 |
+| 1M OUTPUT TOKENS | $1.10 | $2.19 |
+`
+
+const anthropicDeprecationsPage = `<h2>Model status</h2>
+<table>
+<tr><th>API Model Name</th><th>Current State</th><th>Tentative Retirement Date</th></tr>
+<tr><td>claude-3-opus-20240229</td><td>Retired</td><td>January 5, 2026</td></tr>
+<tr><td>claude-3-5-haiku-20241022</td><td>Deprecated</td><td>February 19, 2026</td></tr>
+<tr><td>claude-sonnet-4-5-20250929</td><td>Active</td><td>Not sooner than September 29, 2026</td></tr>
+<tr><td>claude-opus-4-1-20250805</td><td>Active</td><td>N/A</td></tr>
+</table>
+<h2>2024-08-23: Claude 2 and Claude Sonnet 3</h2>
+<table>
+<tr><th>Retirement Date</th><th>Deprecated Model</th><th>Recommended Replacement</th></tr>
+<tr><td>2025-07-21</td><td><code>claude-2.0</code>, <code>claude-2.1</code></td><td><code>claude-sonnet-4-20250514</code></td></tr>
+<tr><td>2025-07-21</td><td>claude-3-sonnet-20240229</td><td>—</td></tr>
+</table>`
+
+func newDocsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	pages := map[string]string{
+		"/anthropic/pricing":      anthropicPricingPage,
+		"/anthropic/rate-limits":  anthropicRateLimitPage,
+		"/deepseek/pricing":       deepseekPricingPage,
+		"/anthropic/deprecations": anthropicDeprecationsPage,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/deepseek/pricing" {
+			w.Header().Set("Content-Type", "text/markdown")
+		}
+		w.Write([]byte(page))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testSources points the default sources at the test server
+func testSources(baseURL string) []Source {
+	var sources []Source
+	for _, src := range DefaultSources() {
+		switch src.Provider {
+		case "anthropic":
+			src.PricingURL = baseURL + "/anthropic/pricing"
+			src.RateLimitURL = baseURL + "/anthropic/rate-limits"
+			src.DeprecationURL = baseURL + "/anthropic/deprecations"
+		case "deepseek":
+			src.PricingURL = baseURL + "/deepseek/pricing"
+		case "groq":
+			src.PricingURL = baseURL + "/groq/missing"
+			src.RateLimitURL = ""
+		default:
+			continue
+		}
+		sources = append(sources, src)
+	}
+	return sources
+}
+
+func TestScrape(t *testing.T) {
+	server := newDocsServer(t)
+	s := New(Config{Sources: testSources(server.URL)})
+
+	result, err := s.Scrape(context.Background(), "anthropic")
+	if err != nil {
+		t.Fatalf("Scrape() error = %v", err)
+	}
+	if len(result.Pricing) != 2 {
+		t.Fatalf("expected 2 prices, got %+v", result.Pricing)
+	}
+	sonnet := result.Pricing[0]
+	if sonnet.ModelID != "claude-3.5-sonnet" || sonnet.InputCost != 3 || sonnet.OutputCost != 15 || sonnet.PlanType != "tier-1" {
+		t.Errorf("unexpected sonnet price %+v", sonnet)
+	}
+
+	// rpm and tpm (input tokens) for each tier; OTPM is not a tpm limit
+	if len(result.RateLimits) != 4 {
+		t.Fatalf("expected 4 rate limits, got %+v", result.RateLimits)
+	}
+	byKey := map[string]int64{}
+	for _, rl := range result.RateLimits {
+		byKey[rl.PlanType+"/"+rl.LimitType] = rl.LimitValue
+		if rl.AppliesTo != "model" || rl.ModelID.String != "claude-3.5-sonnet" {
+			t.Errorf("expected model-scoped limit, got %+v", rl)
+		}
+	}
+	if byKey["tier-1/rpm"] != 50 || byKey["tier-2/rpm"] != 1000 || byKey["tier-2/tpm"] != 80000 {
+		t.Errorf("unexpected limits %v", byKey)
+	}
+
+	result, err = s.Scrape(context.Background(), "deepseek")
+	if err != nil {
+		t.Fatalf("Scrape() error = %v", err)
+	}
+	if len(result.Pricing) != 2 || result.Pricing[1].ModelID != "deepseek-reasoner" ||
+		result.Pricing[1].InputCost != 0.55 || result.Pricing[1].OutputCost != 2.19 {
+		t.Errorf("unexpected deepseek prices %+v", result.Pricing)
+	}
+
+	if _, err := s.Scrape(context.Background(), "groq"); err == nil {
+		t.Error("expected error for missing page")
+	}
+	if _, err := s.Scrape(context.Background(), "acme"); !errors.Is(err, ErrUnknownSource) {
+		t.Errorf("expected ErrUnknownSource, got %v", err)
+	}
+}
+
+func TestScrapeDeprecations(t *testing.T) {
+	server := newDocsServer(t)
+	s := New(Config{Sources: testSources(server.URL)})
+
+	deprecations, err := s.ScrapeDeprecations(context.Background(), "anthropic")
+	if err != nil {
+		t.Fatalf("ScrapeDeprecations() error = %v", err)
+	}
+	byModel := map[string]Deprecation{}
+	for _, d := range deprecations {
+		byModel[d.ModelID] = d
+	}
+	// Active models, with no date or only a lower bound, are not listed
+	if len(deprecations) != 5 {
+		t.Fatalf("expected 5 deprecations, got %+v", deprecations)
+	}
+	if _, ok := byModel["claude-sonnet-4-5-20250929"]; ok {
+		t.Error("expected a model retiring not sooner than a date to be skipped")
+	}
+
+	haiku := byModel["claude-3-5-haiku-20241022"]
+	if !haiku.RetiresAt.Equal(time.Date(2026, 2, 19, 0, 0, 0, 0, time.UTC)) || haiku.SourceURL != server.URL+"/anthropic/deprecations" {
+		t.Errorf("unexpected haiku deprecation %+v", haiku)
+	}
+	if c := byModel["claude-2.1"]; c.Replacement != "claude-sonnet-4-20250514" || c.RetiresAt.Format("2006-01-02") != "2025-07-21" {
+		t.Errorf("unexpected claude-2.1 deprecation %+v", c)
+	}
+	if c := byModel["claude-3-sonnet-20240229"]; c.Replacement != "" {
+		t.Errorf("expected a placeholder replacement to be dropped, got %q", c.Replacement)
+	}
+
+	// DeepSeek publishes no deprecations page
+	if deprecations, err := s.ScrapeDeprecations(context.Background(), "deepseek"); err != nil || len(deprecations) != 0 {
+		t.Errorf("expected no deprecations for deepseek, got %+v, %v", deprecations, err)
+	}
+}
+
+func TestStageAndReview(t *testing.T) {
+	if err := storage.InitRateLimitDB(filepath.Join(t.TempDir(), "rate_limits.db")); err != nil {
+		t.Fatalf("failed to init rate limit db: %v", err)
+	}
+	defer storage.CloseRateLimitDB()
+
+	// Seeded values: sonnet unchanged, haiku price changed on the page
+	if err := SeedInitialPricing(); err != nil {
+		t.Fatalf("SeedInitialPricing() error = %v", err)
+	}
+
+	server := newDocsServer(t)
+	s := New(Config{Sources: testSources(server.URL)})
+
+	result, err := s.Stage(context.Background(), "anthropic")
+	if err != nil {
+		t.Fatalf("Stage() error = %v", err)
+	}
+	// haiku price + 4 new rate limits
+	if result.Pricing != 2 || result.RateLimits != 4 || result.Staged != 5 {
+		t.Errorf("unexpected stage result %+v", result)
+	}
+
+	// Nothing is applied before approval
+	haiku, _ := storage.GetProviderPricing("anthropic", "claude-3.5-haiku", "tier-1")
+	if haiku == nil || haiku.InputCost != 0.80 {
+		t.Fatalf("expected stored haiku price to be untouched, got %+v", haiku)
+	}
+
+	// Staging again does not duplicate pending changes
+	if _, err := s.Stage(context.Background(), "anthropic"); err != nil {
+		t.Fatalf("Stage() error = %v", err)
+	}
+	changes, err := s.ListChanges(storage.ChangePending, "anthropic")
+	if err != nil || len(changes) != 5 {
+		t.Fatalf("expected 5 pending changes, got %d (%v)", len(changes), err)
+	}
+
+	var priceChange, limitChange *Change
+	for _, c := range changes {
+		if c.Kind == KindPricing {
+			priceChange = c
+		} else if limitChange == nil {
+			limitChange = c
+		}
+	}
+	if priceChange == nil || priceChange.Current == nil || priceChange.Summary != "input $0.80 -> $1.00, output $4.00 -> $5.00 per 1M tokens" {
+		t.Fatalf("unexpected price change %+v", priceChange)
+	}
+
+	if err := s.ApproveChange(priceChange.ID); err != nil {
+		t.Fatalf("ApproveChange() error = %v", err)
+	}
+	haiku, _ = storage.GetProviderPricing("anthropic", "claude-3.5-haiku", "tier-1")
+	if haiku.InputCost != 1 || haiku.OutputCost != 5 {
+		t.Errorf("expected approved price to be applied, got %+v", haiku)
+	}
+	var history int
+	storage.GetRateLimitDB().QueryRow("SELECT COUNT(*) FROM pricing_history WHERE model_id = 'claude-3.5-haiku'").Scan(&history)
+	if history != 1 {
+		t.Errorf("expected 1 pricing history entry, got %d", history)
+	}
+	if err := s.ApproveChange(priceChange.ID); !errors.Is(err, ErrNotPending) {
+		t.Errorf("expected ErrNotPending approving twice, got %v", err)
+	}
+
+	if err := s.RejectChange(limitChange.ID); err != nil {
+		t.Fatalf("RejectChange() error = %v", err)
+	}
+	rejected, err := s.GetChange(limitChange.ID)
+	if err != nil || rejected.Status != storage.ChangeRejected || rejected.ReviewedAt == nil {
+		t.Errorf("expected rejected change, got %+v (%v)", rejected, err)
+	}
+	limits, _ := storage.QueryRateLimit("anthropic", limitChange.PlanType, limitChange.LimitType, limitChange.ModelID, "")
+	if len(limits) != 0 {
+		t.Errorf("expected rejected limit not to be applied, got %+v", limits)
+	}
+
+	// After approval the page matches the database
+	result, err = s.Stage(context.Background(), "anthropic")
+	if err != nil {
+		t.Fatalf("Stage() error = %v", err)
+	}
+	if result.Staged != 4 {
+		t.Errorf("expected only the 4 unapplied rate limits to be staged, got %d", result.Staged)
+	}
+}
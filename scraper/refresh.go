@@ -0,0 +1,75 @@
+package scraper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/storage"
+)
+
+// RefreshResult summarizes a provider refresh
+type RefreshResult struct {
+	Provider     string `json:"provider"`
+	RateLimits   int    `json:"rate_limits"`   // Rate limits written
+	Pricing      int    `json:"pricing"`       // Pricing entries written
+	PriceChanges int    `json:"price_changes"` // Entries whose cost changed
+}
+
+// RefreshProvider re-applies the known rate limits and pricing for one
+// provider to the rate limit database, marking them verified now. Price
+// changes are recorded in the pricing history. The rate limit database must
+// be initialized with storage.InitRateLimitDB.
+func RefreshProvider(provider string) (*RefreshResult, error) {
+	if storage.GetRateLimitDB() == nil {
+		return nil, fmt.Errorf("rate limit database not initialized")
+	}
+
+	result := &RefreshResult{Provider: provider}
+	now := time.Now()
+
+	for _, limit := range seedRateLimits() {
+		if limit.ProviderName != provider {
+			continue
+		}
+		limit.LastVerified = now
+		if err := storage.InsertRateLimit(limit); err != nil {
+			return nil, fmt.Errorf("failed to refresh %s %s rate limit: %w", limit.PlanType, limit.LimitType, err)
+		}
+		result.RateLimits++
+	}
+
+	for _, price := range seedPricing() {
+		if price.ProviderName != provider {
+			continue
+		}
+
+		current, err := storage.GetProviderPricing(price.ProviderName, price.ModelID, price.PlanType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s pricing: %w", price.ModelID, err)
+		}
+		if current != nil && (current.InputCost != price.InputCost || current.OutputCost != price.OutputCost) {
+			err := storage.InsertPricingHistory(storage.PricingHistory{
+				ProviderName:  price.ProviderName,
+				ModelID:       price.ModelID,
+				PlanType:      price.PlanType,
+				OldInputCost:  current.InputCost,
+				OldOutputCost: current.OutputCost,
+				NewInputCost:  price.InputCost,
+				NewOutputCost: price.OutputCost,
+				ChangeDate:    now,
+				ChangeReason:  "scheduled refresh",
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to record %s price change: %w", price.ModelID, err)
+			}
+			result.PriceChanges++
+		}
+
+		if err := storage.InsertProviderPricing(price); err != nil {
+			return nil, fmt.Errorf("failed to refresh %s pricing: %w", price.ModelID, err)
+		}
+		result.Pricing++
+	}
+
+	return result, nil
+}
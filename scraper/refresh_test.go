@@ -0,0 +1,59 @@
+package scraper
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/storage"
+)
+
+func TestRefreshProvider(t *testing.T) {
+	if err := storage.InitRateLimitDB(filepath.Join(t.TempDir(), "rate_limits.db")); err != nil {
+		t.Fatalf("failed to init rate limit db: %v", err)
+	}
+	defer storage.CloseRateLimitDB()
+
+	// A stale price should be corrected and recorded in the history
+	stale := storage.ProviderPricing{
+		ProviderName: "openai", ModelID: "gpt-4o", PlanType: "tier-1",
+		InputCost: 5.00, OutputCost: 15.00, UnitType: "1M tokens", Currency: "USD",
+	}
+	if err := storage.InsertProviderPricing(stale); err != nil {
+		t.Fatalf("failed to insert pricing: %v", err)
+	}
+
+	result, err := RefreshProvider("openai")
+	if err != nil {
+		t.Fatalf("RefreshProvider() error = %v", err)
+	}
+	if result.RateLimits != 11 || result.Pricing != 3 || result.PriceChanges != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	price, err := storage.GetProviderPricing("openai", "gpt-4o", "tier-1")
+	if err != nil || price == nil || price.InputCost != 2.50 {
+		t.Errorf("expected refreshed gpt-4o input cost 2.50, got %+v (%v)", price, err)
+	}
+
+	var changes int
+	if err := storage.GetRateLimitDB().QueryRow("SELECT COUNT(*) FROM pricing_history WHERE model_id = 'gpt-4o'").Scan(&changes); err != nil {
+		t.Fatalf("failed to count pricing history: %v", err)
+	}
+	if changes != 1 {
+		t.Errorf("expected 1 pricing history entry, got %d", changes)
+	}
+
+	// A second refresh finds nothing to change
+	result, err = RefreshProvider("openai")
+	if err != nil {
+		t.Fatalf("RefreshProvider() error = %v", err)
+	}
+	if result.PriceChanges != 0 {
+		t.Errorf("expected no price changes on second refresh, got %d", result.PriceChanges)
+	}
+
+	result, err = RefreshProvider("unknown")
+	if err != nil || result.RateLimits != 0 || result.Pricing != 0 {
+		t.Errorf("expected empty refresh for unknown provider, got %+v (%v)", result, err)
+	}
+}
@@ -0,0 +1,150 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/storage"
+)
+
+// Change is a staged change as presented for review
+type Change struct {
+	ID         int64           `json:"id"`
+	Kind       string          `json:"kind"`
+	Provider   string          `json:"provider"`
+	PlanType   string          `json:"plan_type"`
+	ModelID    string          `json:"model_id,omitempty"`
+	LimitType  string          `json:"limit_type,omitempty"`
+	Summary    string          `json:"summary"`
+	Current    json.RawMessage `json:"current,omitempty"`
+	Proposed   json.RawMessage `json:"proposed"`
+	SourceURL  string          `json:"source_url,omitempty"`
+	Status     string          `json:"status"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ReviewedAt *time.Time      `json:"reviewed_at,omitempty"`
+}
+
+// ListChanges returns staged changes, newest first. Empty status or
+// provider matches all.
+func (s *Scraper) ListChanges(status, provider string) ([]*Change, error) {
+	staged, err := storage.ListStagedChanges(status, provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged changes: %w", err)
+	}
+
+	changes := make([]*Change, len(staged))
+	for i := range staged {
+		changes[i] = toChange(&staged[i])
+	}
+	return changes, nil
+}
+
+// GetChange returns a staged change, or nil if it does not exist
+func (s *Scraper) GetChange(id int64) (*Change, error) {
+	staged, err := storage.GetStagedChange(id)
+	if err != nil || staged == nil {
+		return nil, err
+	}
+	return toChange(staged), nil
+}
+
+// ApproveChange applies a pending change to the rate limit database. Price
+// changes are recorded in the pricing history.
+func (s *Scraper) ApproveChange(id int64) error {
+	staged, err := s.pending(id)
+	if err != nil {
+		return err
+	}
+
+	switch staged.Kind {
+	case KindPricing:
+		var price storage.ProviderPricing
+		if err := json.Unmarshal([]byte(staged.NewValue), &price); err != nil {
+			return fmt.Errorf("failed to decode proposed price: %w", err)
+		}
+		current, err := storage.GetProviderPricing(price.ProviderName, price.ModelID, price.PlanType)
+		if err != nil {
+			return fmt.Errorf("failed to read %s pricing: %w", price.ModelID, err)
+		}
+		if current != nil {
+			err := storage.InsertPricingHistory(storage.PricingHistory{
+				ProviderName:  price.ProviderName,
+				ModelID:       price.ModelID,
+				PlanType:      price.PlanType,
+				OldInputCost:  current.InputCost,
+				OldOutputCost: current.OutputCost,
+				NewInputCost:  price.InputCost,
+				NewOutputCost: price.OutputCost,
+				ChangeDate:    time.Now(),
+				ChangeReason:  fmt.Sprintf("approved scraped change %d", id),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to record %s price change: %w", price.ModelID, err)
+			}
+		}
+		if err := storage.InsertProviderPricing(price); err != nil {
+			return fmt.Errorf("failed to apply %s pricing: %w", price.ModelID, err)
+		}
+	case KindRateLimit:
+		var limit storage.RateLimit
+		if err := json.Unmarshal([]byte(staged.NewValue), &limit); err != nil {
+			return fmt.Errorf("failed to decode proposed rate limit: %w", err)
+		}
+		limit.LastVerified = time.Now()
+		if err := storage.InsertRateLimit(limit); err != nil {
+			return fmt.Errorf("failed to apply %s rate limit: %w", limit.LimitType, err)
+		}
+	default:
+		return fmt.Errorf("unknown change kind: %s", staged.Kind)
+	}
+
+	return storage.ReviewStagedChange(id, storage.ChangeApproved)
+}
+
+// RejectChange discards a pending change
+func (s *Scraper) RejectChange(id int64) error {
+	if _, err := s.pending(id); err != nil {
+		return err
+	}
+	return storage.ReviewStagedChange(id, storage.ChangeRejected)
+}
+
+// pending loads a change that is still awaiting review
+func (s *Scraper) pending(id int64) (*storage.StagedChange, error) {
+	staged, err := storage.GetStagedChange(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staged change: %w", err)
+	}
+	if staged == nil {
+		return nil, fmt.Errorf("staged change %d not found", id)
+	}
+	if staged.Status != storage.ChangePending {
+		return nil, fmt.Errorf("%w: %d is %s", ErrNotPending, id, staged.Status)
+	}
+	return staged, nil
+}
+
+func toChange(sc *storage.StagedChange) *Change {
+	change := &Change{
+		ID:        sc.ID,
+		Kind:      sc.Kind,
+		Provider:  sc.ProviderName,
+		PlanType:  sc.PlanType,
+		ModelID:   sc.ModelID,
+		LimitType: sc.LimitType,
+		Summary:   sc.Summary,
+		Proposed:  json.RawMessage(sc.NewValue),
+		SourceURL: sc.SourceURL,
+		Status:    sc.Status,
+		CreatedAt: sc.CreatedAt,
+	}
+	if sc.OldValue.Valid {
+		change.Current = json.RawMessage(sc.OldValue.String)
+	}
+	if sc.ReviewedAt.Valid {
+		reviewed := sc.ReviewedAt.Time
+		change.ReviewedAt = &reviewed
+	}
+	return change
+}
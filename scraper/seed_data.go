@@ -12,6 +12,71 @@ import (
 func SeedInitialRateLimits() error {
 	log.Println("Seeding initial rate limits for 15 core providers...")
 
+	allLimits := seedRateLimits()
+
+	// Insert all rate limits
+	for _, limit := range allLimits {
+		if err := storage.InsertRateLimit(limit); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("✅ Inserted %d rate limits for 15 providers", len(allLimits))
+	return nil
+}
+
+// SeedInitialPricing populates the database with known pricing for core providers
+func SeedInitialPricing() error {
+	log.Println("Seeding initial pricing for 15 core providers...")
+
+	pricing := seedPricing()
+
+	for _, p := range pricing {
+		if err := storage.InsertProviderPricing(p); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("✅ Inserted %d pricing entries", len(pricing))
+	return nil
+}
+
+// SeedInitialQuality populates the database with benchmark quality ratings
+// for known chat models. Ratings adjusted by an admin are kept.
+func SeedInitialQuality() error {
+	log.Println("Seeding model quality ratings...")
+
+	ratings := seedQuality()
+
+	for _, q := range ratings {
+		if err := storage.SetModelQuality(q); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("✅ Inserted %d quality ratings", len(ratings))
+	return nil
+}
+
+// SeedInitialCapabilities populates the capability matrix for known chat
+// models
+func SeedInitialCapabilities() error {
+	log.Println("Seeding model capabilities...")
+
+	caps := seedCapabilities()
+
+	for _, c := range caps {
+		if err := storage.SetModelCapabilities(c); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("✅ Inserted %d capability entries", len(caps))
+	return nil
+}
+
+// seedRateLimits returns the known rate limits for core providers
+func seedRateLimits() []storage.RateLimit {
 	// OpenAI Rate Limits (5 tiers)
 	openaiLimits := []storage.RateLimit{
 		// Tier 1 (Free)
@@ -154,21 +219,11 @@ func SeedInitialRateLimits() error {
 	allLimits = append(allLimits, xaiLimits...)
 	allLimits = append(allLimits, ai21Limits...)
 
-	// Insert all rate limits
-	for _, limit := range allLimits {
-		if err := storage.InsertRateLimit(limit); err != nil {
-			return err
-		}
-	}
-
-	log.Printf("✅ Inserted %d rate limits for 15 providers", len(allLimits))
-	return nil
+	return allLimits
 }
 
-// SeedInitialPricing populates the database with known pricing for core providers
-func SeedInitialPricing() error {
-	log.Println("Seeding initial pricing for 15 core providers...")
-
+// seedPricing returns the known pricing for core providers
+func seedPricing() []storage.ProviderPricing {
 	pricing := []storage.ProviderPricing{
 		// OpenAI
 		{ProviderName: "openai", ModelID: "gpt-4o", PlanType: "tier-1", InputCost: 2.50, OutputCost: 10.00, UnitType: "1M tokens", Currency: "USD"},
@@ -204,20 +259,123 @@ func SeedInitialPricing() error {
 
 		// Together.ai
 		{ProviderName: "together", ModelID: "meta-llama/Meta-Llama-3.1-70B", PlanType: "pay_per_go", InputCost: 0.60, OutputCost: 0.60, UnitType: "1M tokens", Currency: "USD"},
+		{ProviderName: "together", ModelID: "meta-llama/Llama-3.3-70B-Instruct-Turbo", PlanType: "pay_per_go", InputCost: 0.88, OutputCost: 0.88, UnitType: "1M tokens", Currency: "USD"},
+		{ProviderName: "together", ModelID: "deepseek-ai/DeepSeek-V3", PlanType: "pay_per_go", InputCost: 1.25, OutputCost: 1.25, UnitType: "1M tokens", Currency: "USD"},
+		{ProviderName: "together", ModelID: "Qwen/Qwen2.5-72B-Instruct-Turbo", PlanType: "pay_per_go", InputCost: 1.20, OutputCost: 1.20, UnitType: "1M tokens", Currency: "USD"},
+		{ProviderName: "together", ModelID: "BAAI/bge-base-en-v1.5", PlanType: "pay_per_go", InputCost: 0.008, OutputCost: 0.00, UnitType: "1M tokens", Currency: "USD"},
 
 		// Cohere
 		{ProviderName: "cohere", ModelID: "command-r-plus", PlanType: "pay_per_go", InputCost: 2.50, OutputCost: 10.00, UnitType: "1M tokens", Currency: "USD"},
+		{ProviderName: "cohere", ModelID: "command-a", PlanType: "pay_per_go", InputCost: 2.50, OutputCost: 10.00, UnitType: "1M tokens", Currency: "USD"},
+		{ProviderName: "cohere", ModelID: "command-r", PlanType: "pay_per_go", InputCost: 0.15, OutputCost: 0.60, UnitType: "1M tokens", Currency: "USD"},
+		{ProviderName: "cohere", ModelID: "command-r7b", PlanType: "pay_per_go", InputCost: 0.0375, OutputCost: 0.15, UnitType: "1M tokens", Currency: "USD"},
+		{ProviderName: "cohere", ModelID: "embed-english-v3.0", PlanType: "pay_per_go", InputCost: 0.10, OutputCost: 0.00, UnitType: "1M tokens", Currency: "USD"},
 
 		// Mistral
 		{ProviderName: "mistral", ModelID: "mistral-large", PlanType: "pay_per_go", InputCost: 2.00, OutputCost: 6.00, UnitType: "1M tokens", Currency: "USD"},
+		{ProviderName: "mistral", ModelID: "mistral-small", PlanType: "pay_per_go", InputCost: 0.10, OutputCost: 0.30, UnitType: "1M tokens", Currency: "USD"},
+		{ProviderName: "mistral", ModelID: "codestral", PlanType: "pay_per_go", InputCost: 0.30, OutputCost: 0.90, UnitType: "1M tokens", Currency: "USD"},
+		{ProviderName: "mistral", ModelID: "mistral-embed", PlanType: "pay_per_go", InputCost: 0.10, OutputCost: 0.00, UnitType: "1M tokens", Currency: "USD"},
 	}
 
-	for _, p := range pricing {
-		if err := storage.InsertProviderPricing(p); err != nil {
-			return err
-		}
+	return pricing
+}
+
+// seedQuality returns composite benchmark scores (0-100, blending general
+// knowledge, reasoning and coding evaluations) for known chat models. Tiers
+// follow from the scores.
+func seedQuality() []storage.ModelQuality {
+	scores := []struct {
+		model string
+		score float64
+	}{
+		// Frontier
+		{"o1", 90},
+		{"deepseek-reasoner", 88},
+		{"claude-3.5-sonnet", 87},
+
+		// Advanced
+		{"gpt-4o", 84},
+		{"deepseek-chat", 82},
+		{"deepseek-ai/DeepSeek-V3", 82},
+		{"gemini-1.5-pro", 80},
+		{"gemini-2.0-flash", 78},
+		{"Qwen/Qwen2.5-72B-Instruct-Turbo", 76},
+		{"mistral-large", 74},
+		{"llama-3.3-70b", 72},
+		{"meta-llama/Llama-3.3-70B-Instruct-Turbo", 72},
+		{"command-a", 71},
+
+		// Standard
+		{"llama3.1-70b", 68},
+		{"meta-llama/Meta-Llama-3.1-70B", 68},
+		{"claude-3.5-haiku", 66},
+		{"gpt-4o-mini", 65},
+		{"codestral", 62},
+		{"command-r-plus", 60},
+		{"mistral-small", 58},
+		{"command-r", 52},
+
+		// Basic
+		{"mixtral-8x7b", 45},
+		{"llama3.1-8b", 38},
+		{"command-r7b", 35},
 	}
 
-	log.Printf("✅ Inserted %d pricing entries", len(pricing))
-	return nil
+	ratings := make([]storage.ModelQuality, len(scores))
+	for i, s := range scores {
+		ratings[i] = storage.ModelQuality{ModelID: s.model, Score: s.score, Source: storage.QualitySourceBenchmark}
+	}
+	return ratings
+}
+
+// seedCapabilities returns what each provider supports for its chat models,
+// from provider documentation. Context sizes are the provider's limits, which
+// can be smaller than the model's own window.
+func seedCapabilities() []storage.ModelCapabilities {
+	us, eu := []string{"us"}, []string{"eu"}
+	usEU := []string{"us", "eu"}
+	return []storage.ModelCapabilities{
+		// OpenAI
+		{ProviderName: "openai", ModelID: "gpt-4o", Vision: true, Tools: true, JSONMode: true, ContextTokens: 128000, Regions: usEU},
+		{ProviderName: "openai", ModelID: "gpt-4o-mini", Vision: true, Tools: true, JSONMode: true, ContextTokens: 128000, Regions: usEU},
+		{ProviderName: "openai", ModelID: "o1", Vision: true, Tools: true, JSONMode: true, ContextTokens: 200000, Regions: usEU},
+
+		// Anthropic (no JSON mode; structured output goes through tools)
+		{ProviderName: "anthropic", ModelID: "claude-3.5-sonnet", Vision: true, Tools: true, ContextTokens: 200000, Regions: usEU},
+		{ProviderName: "anthropic", ModelID: "claude-3.5-haiku", Tools: true, ContextTokens: 200000, Regions: usEU},
+
+		// DeepSeek
+		{ProviderName: "deepseek", ModelID: "deepseek-chat", Tools: true, JSONMode: true, ContextTokens: 64000, Regions: []string{"cn"}},
+		{ProviderName: "deepseek", ModelID: "deepseek-reasoner", ContextTokens: 64000, Regions: []string{"cn"}},
+
+		// Cerebras
+		{ProviderName: "cerebras", ModelID: "llama3.1-8b", Tools: true, JSONMode: true, ContextTokens: 8192, Regions: us},
+		{ProviderName: "cerebras", ModelID: "llama3.1-70b", Tools: true, JSONMode: true, ContextTokens: 8192, Regions: us},
+
+		// Google Gemini
+		{ProviderName: "google-gemini", ModelID: "gemini-2.0-flash", Vision: true, Tools: true, JSONMode: true, ContextTokens: 1048576, Regions: []string{"us", "eu", "asia"}},
+		{ProviderName: "google-gemini", ModelID: "gemini-1.5-pro", Vision: true, Tools: true, JSONMode: true, ContextTokens: 2097152, Regions: []string{"us", "eu", "asia"}},
+
+		// Groq
+		{ProviderName: "groq", ModelID: "llama-3.3-70b", Tools: true, JSONMode: true, ContextTokens: 128000, Regions: us},
+		{ProviderName: "groq", ModelID: "mixtral-8x7b", Tools: true, JSONMode: true, ContextTokens: 32768, Regions: us},
+
+		// Together AI
+		{ProviderName: "together", ModelID: "meta-llama/Meta-Llama-3.1-70B", Tools: true, JSONMode: true, ContextTokens: 131072, Regions: us},
+		{ProviderName: "together", ModelID: "meta-llama/Llama-3.3-70B-Instruct-Turbo", Tools: true, JSONMode: true, ContextTokens: 131072, Regions: us},
+		{ProviderName: "together", ModelID: "deepseek-ai/DeepSeek-V3", Tools: true, JSONMode: true, ContextTokens: 131072, Regions: us},
+		{ProviderName: "together", ModelID: "Qwen/Qwen2.5-72B-Instruct-Turbo", Tools: true, JSONMode: true, ContextTokens: 32768, Regions: us},
+
+		// Cohere
+		{ProviderName: "cohere", ModelID: "command-a", Tools: true, JSONMode: true, ContextTokens: 256000, Regions: usEU},
+		{ProviderName: "cohere", ModelID: "command-r-plus", Tools: true, JSONMode: true, ContextTokens: 128000, Regions: usEU},
+		{ProviderName: "cohere", ModelID: "command-r", Tools: true, JSONMode: true, ContextTokens: 128000, Regions: usEU},
+		{ProviderName: "cohere", ModelID: "command-r7b", Tools: true, JSONMode: true, ContextTokens: 128000, Regions: usEU},
+
+		// Mistral
+		{ProviderName: "mistral", ModelID: "mistral-large", Tools: true, JSONMode: true, ContextTokens: 128000, Regions: eu},
+		{ProviderName: "mistral", ModelID: "mistral-small", Tools: true, JSONMode: true, ContextTokens: 32000, Regions: eu},
+		{ProviderName: "mistral", ModelID: "codestral", Tools: true, JSONMode: true, ContextTokens: 256000, Regions: eu},
+	}
 }
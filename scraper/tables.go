@@ -0,0 +1,338 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// table is a table found in a documentation page, with the heading that
+// preceded it. The first row holds the column headers.
+type table struct {
+	heading string
+	rows    [][]string
+}
+
+var (
+	htmlTagPattern   = regexp.MustCompile(`(?is)<(/?)(h[1-6]|table|tr|td|th)\b[^>]*>`)
+	htmlStripPattern = regexp.MustCompile(`(?s)<[^>]*>`)
+	htmlSkipPattern  = regexp.MustCompile(`(?is)<(script|style)\b.*?</(script|style)>`)
+	pricePattern     = regexp.MustCompile(`\$\s*([0-9][0-9,]*(?:\.[0-9]+)?)`)
+	quantityPattern  = regexp.MustCompile(`(?i)^([0-9][0-9,]*(?:\.[0-9]+)?)\s*([kmb])?$`)
+)
+
+// parseTables extracts tables from an HTML, markdown or JSON document
+func parseTables(body []byte, contentType string) ([]table, error) {
+	trimmed := bytes.TrimSpace(body)
+	switch {
+	case strings.Contains(contentType, "json") || bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("[")):
+		return parseJSONTables(trimmed)
+	case strings.Contains(contentType, "markdown") || (!bytes.Contains(trimmed, []byte("<table")) && bytes.Contains(trimmed, []byte("|-"))):
+		return parseMarkdownTables(string(trimmed)), nil
+	default:
+		return parseHTMLTables(string(trimmed)), nil
+	}
+}
+
+// parseHTMLTables walks table, row, cell and heading tags. Nested tables are
+// flattened into their parent.
+func parseHTMLTables(doc string) []table {
+	doc = htmlSkipPattern.ReplaceAllString(doc, "")
+
+	var (
+		tables  []table
+		current *table
+		row     []string
+		inRow   bool
+		cell    *strings.Builder
+		heading *strings.Builder
+		last    string
+	)
+	endCell := func() {
+		if cell != nil && inRow {
+			row = append(row, cleanText(cell.String()))
+		}
+		cell = nil
+	}
+	endRow := func() {
+		endCell()
+		if inRow && current != nil && len(row) > 0 {
+			current.rows = append(current.rows, row)
+		}
+		row, inRow = nil, false
+	}
+
+	pos := 0
+	for _, m := range htmlTagPattern.FindAllStringSubmatchIndex(doc, -1) {
+		text := doc[pos:m[0]]
+		if cell != nil {
+			cell.WriteString(text)
+		}
+		if heading != nil {
+			heading.WriteString(text)
+		}
+		pos = m[1]
+
+		closing := doc[m[2]:m[3]] == "/"
+		switch name := strings.ToLower(doc[m[4]:m[5]]); name {
+		case "table":
+			if !closing && current == nil {
+				current = &table{heading: last}
+			} else if closing && current != nil {
+				endRow()
+				if len(current.rows) > 0 {
+					tables = append(tables, *current)
+				}
+				current = nil
+			}
+		case "tr":
+			endRow()
+			inRow = !closing
+		case "td", "th":
+			endCell()
+			if !closing {
+				cell = &strings.Builder{}
+			}
+		default: // headings
+			if !closing {
+				heading = &strings.Builder{}
+			} else if heading != nil {
+				last = cleanText(heading.String())
+				heading = nil
+			}
+		}
+	}
+	return tables
+}
+
+// parseMarkdownTables reads pipe tables and the "#" headings above them
+func parseMarkdownTables(doc string) []table {
+	var tables []table
+	var current *table
+	var last string
+
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "|") {
+			if current != nil {
+				tables = append(tables, *current)
+				current = nil
+			}
+			if strings.HasPrefix(line, "#") {
+				last = cleanText(strings.TrimLeft(line, "# "))
+			}
+			continue
+		}
+
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+		if isMarkdownSeparator(cells) {
+			continue
+		}
+		if current == nil {
+			current = &table{heading: last}
+		}
+		row := make([]string, len(cells))
+		for i, c := range cells {
+			row[i] = cleanText(c)
+		}
+		current.rows = append(current.rows, row)
+	}
+	if current != nil {
+		tables = append(tables, *current)
+	}
+	return tables
+}
+
+func isMarkdownSeparator(cells []string) bool {
+	for _, c := range cells {
+		if strings.Trim(strings.TrimSpace(c), ":-") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseJSONTables turns every array of objects in a JSON document into a
+// table whose headers are the object keys and whose heading is the key that
+// held the array
+func parseJSONTables(body []byte) ([]table, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	var tables []table
+	var walk func(name string, v interface{})
+	walk = func(name string, v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(val))
+			for k := range val {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(k, val[k])
+			}
+		case []interface{}:
+			if t, ok := jsonTable(name, val); ok {
+				tables = append(tables, t)
+				return
+			}
+			for _, item := range val {
+				walk(name, item)
+			}
+		}
+	}
+	walk("", doc)
+	return tables, nil
+}
+
+// jsonTable converts an array of flat objects into a table
+func jsonTable(name string, items []interface{}) (table, bool) {
+	var headers []string
+	seen := map[string]bool{}
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return table{}, false
+		}
+		for k := range obj {
+			if !seen[k] {
+				seen[k] = true
+				headers = append(headers, k)
+			}
+		}
+	}
+	if len(headers) == 0 {
+		return table{}, false
+	}
+	sort.Strings(headers)
+
+	t := table{heading: name, rows: [][]string{headers}}
+	for _, item := range items {
+		obj := item.(map[string]interface{})
+		row := make([]string, len(headers))
+		for i, h := range headers {
+			switch v := obj[h].(type) {
+			case nil:
+			case string:
+				row[i] = v
+			case float64:
+				row[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				row[i] = fmt.Sprint(v)
+			}
+		}
+		t.rows = append(t.rows, row)
+	}
+	return t, true
+}
+
+// transpose swaps rows and columns, for pages listing models across the top
+func (t table) transpose() table {
+	width := 0
+	for _, row := range t.rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	out := table{heading: t.heading, rows: make([][]string, width)}
+	for c := 0; c < width; c++ {
+		out.rows[c] = make([]string, len(t.rows))
+		for r, row := range t.rows {
+			if c < len(row) {
+				out.rows[c][r] = row[c]
+			}
+		}
+	}
+	return out
+}
+
+// Column matches a table header containing any of Any and none of Not
+// (case-insensitive)
+type Column struct {
+	Any []string
+	Not []string
+}
+
+// index returns the first header matching the column, or -1
+func (c Column) index(headers []string) int {
+	for i, h := range headers {
+		h = strings.ToLower(h)
+		if containsAny(h, c.Any) && !containsAny(h, c.Not) {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsAny(s string, words []string) bool {
+	for _, w := range words {
+		if strings.Contains(s, strings.ToLower(w)) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanText strips tags, decodes entities and collapses whitespace
+func cleanText(s string) string {
+	s = htmlStripPattern.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "**", "")
+	s = strings.ReplaceAll(s, "`", "")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// parsePrice reads the first dollar amount in a cell. JSON values without
+// a currency sign are accepted as plain numbers.
+func parsePrice(s string) (float64, bool) {
+	raw := ""
+	if m := pricePattern.FindStringSubmatch(s); m != nil {
+		raw = m[1]
+	} else if _, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+		raw = strings.TrimSpace(s)
+	}
+	if raw == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(raw, ",", ""), 64)
+	return v, err == nil
+}
+
+// parseQuantity reads limits such as "500", "10,000", "30K" or "1.5M"
+func parseQuantity(s string) (int64, bool) {
+	m := quantityPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	if err != nil {
+		return 0, false
+	}
+	switch strings.ToLower(m[2]) {
+	case "k":
+		v *= 1e3
+	case "m":
+		v *= 1e6
+	case "b":
+		v *= 1e9
+	}
+	return int64(v), true
+}
+
+var parenPattern = regexp.MustCompile(`\s*\([^)]*\)`)
+
+// normalizeModel turns a display name such as "Claude Sonnet 4.5 (deprecated)"
+// into a model ID such as "claude-sonnet-4.5"
+func normalizeModel(name string) string {
+	name = parenPattern.ReplaceAllString(name, "")
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.Join(strings.Fields(name), "-")
+}
@@ -0,0 +1,118 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHTMLTables(t *testing.T) {
+	doc := `<html><head><style>td { color: red }</style></head><body>
+<h2 id="tier-2">Tier <em>2</em></h2>
+<table>
+  <thead><tr><th>Model</th><th>Requests per minute (RPM)</th></tr></thead>
+  <tbody>
+    <tr><td><code>claude-sonnet-4</code></td><td>1,000</td></tr>
+    <tr><td>Claude Haiku&nbsp;3.5</td><td>1,000</td></tr>
+  </tbody>
+</table>
+<script>var x = "<table><tr><td>ignored</td></tr></table>";</script>
+</body></html>`
+
+	tables := parseHTMLTables(doc)
+	if len(tables) != 1 {
+		t.Fatalf("expected 1 table, got %d", len(tables))
+	}
+	if tables[0].heading != "Tier 2" {
+		t.Errorf("expected heading %q, got %q", "Tier 2", tables[0].heading)
+	}
+	want := [][]string{
+		{"Model", "Requests per minute (RPM)"},
+		{"claude-sonnet-4", "1,000"},
+		{"Claude Haiku 3.5", "1,000"},
+	}
+	if !reflect.DeepEqual(tables[0].rows, want) {
+		t.Errorf("unexpected rows %q", tables[0].rows)
+	}
+}
+
+func TestParseMarkdownTables(t *testing.T) {
+	doc := `# Pricing
+
+## Model pricing
+
+| Model | Base Input Tokens | Output Tokens |
+|:------|------------------:|--------------:|
+| **Claude Opus 4** | $15 / MTok | $75 / MTok |
+
+Some text.
+
+| Other | Table |
+|---|---|
+| a | b |
+`
+	tables, err := parseTables([]byte(doc), "text/markdown")
+	if err != nil {
+		t.Fatalf("parseTables() error = %v", err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("expected 2 tables, got %d", len(tables))
+	}
+	if tables[0].heading != "Model pricing" || tables[0].rows[1][0] != "Claude Opus 4" {
+		t.Errorf("unexpected first table %+v", tables[0])
+	}
+}
+
+func TestParseJSONTables(t *testing.T) {
+	doc := `{"data": {"models": [
+		{"model": "llama-3.3-70b-versatile", "input": 0.59, "output": 0.79},
+		{"model": "llama-3.1-8b-instant", "input": 0.05, "output": 0.08}
+	]}}`
+
+	tables, err := parseTables([]byte(doc), "application/json")
+	if err != nil {
+		t.Fatalf("parseTables() error = %v", err)
+	}
+	if len(tables) != 1 || tables[0].heading != "models" {
+		t.Fatalf("unexpected tables %+v", tables)
+	}
+	want := [][]string{
+		{"input", "model", "output"},
+		{"0.59", "llama-3.3-70b-versatile", "0.79"},
+		{"0.05", "llama-3.1-8b-instant", "0.08"},
+	}
+	if !reflect.DeepEqual(tables[0].rows, want) {
+		t.Errorf("unexpected rows %q", tables[0].rows)
+	}
+
+	if _, err := parseTables([]byte(`{"broken`), "application/json"); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	quantities := map[string]int64{"500": 500, "10,000": 10000, "30K": 30000, "1.5M": 1500000, " 2b ": 2000000000}
+	for in, want := range quantities {
+		if got, ok := parseQuantity(in); !ok || got != want {
+			t.Errorf("parseQuantity(%q) = %d, %v; want %d", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"-", "", "N/A", "$5"} {
+		if _, ok := parseQuantity(in); ok {
+			t.Errorf("parseQuantity(%q) should fail", in)
+		}
+	}
+
+	prices := map[string]float64{"$2.50": 2.5, "$15 / MTok": 15, "$1,000.00": 1000, "0.27": 0.27}
+	for in, want := range prices {
+		if got, ok := parsePrice(in); !ok || got != want {
+			t.Errorf("parsePrice(%q) = %v, %v; want %v", in, got, ok, want)
+		}
+	}
+	if _, ok := parsePrice("Free"); ok {
+		t.Error("parsePrice(Free) should fail")
+	}
+
+	if got := normalizeModel(" Claude Sonnet 4.5 (deprecated) "); got != "claude-sonnet-4.5" {
+		t.Errorf("normalizeModel() = %q", got)
+	}
+}
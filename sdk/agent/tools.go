@@ -38,6 +38,13 @@ func (tr *ToolRegistry) Register(tool Tool) error {
 	return nil
 }
 
+// Unregister removes a tool from the registry
+func (tr *ToolRegistry) Unregister(name string) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	delete(tr.tools, name)
+}
+
 // Get retrieves a tool by name
 func (tr *ToolRegistry) Get(name string) (Tool, error) {
 	tr.mu.RLock()
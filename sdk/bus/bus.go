@@ -0,0 +1,149 @@
+// Package bus is a topic-based publish/subscribe layer for agents and
+// services. Subscribers name a topic, or a prefix ending in "*" such as
+// "team.support.*", and receive every message published on a matching
+// topic.
+//
+// Bus is the abstraction; InProcess implements it with goroutines and
+// channels, and adapters for external brokers implement it as well. The
+// in-process bus delivers at least once: a handler that returns an error
+// gets the message again, so handlers should tolerate duplicates.
+//
+// With a Store, every message is also persisted, and durable subscribers
+// receive the messages they have not acknowledged after a restart:
+//
+//	b := bus.NewInProcess(bus.Options{Store: bus.NewStorageStore(store.Topics)})
+//	defer b.Close()
+//	b.Subscribe("tasks.*", onTask, bus.SubscribeOptions{Durable: "billing-agent"})
+//	b.Publish(ctx, bus.Message{Topic: "tasks.created", From: "triage", Content: "..."})
+package bus
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/storage"
+)
+
+// Message is a message published on a topic
+type Message struct {
+	ID        string                 `json:"id"` // Assigned on publish when empty
+	Topic     string                 `json:"topic"`
+	From      string                 `json:"from,omitempty"`
+	To        string                 `json:"to,omitempty"`
+	TeamID    string                 `json:"team_id,omitempty"`
+	Type      string                 `json:"type,omitempty"`
+	Content   string                 `json:"content"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+
+	// Attempt counts deliveries of the message to the receiving
+	// subscriber, starting at 1
+	Attempt int `json:"attempt,omitempty"`
+}
+
+// Handler receives a message. Returning an error asks for redelivery.
+type Handler func(ctx context.Context, msg Message) error
+
+// SubscribeOptions configures a subscription
+type SubscribeOptions struct {
+	// Durable names the subscriber. With a Store, the messages it has not
+	// acknowledged are redelivered when it subscribes again under the
+	// same name. Only one subscription per name may be active.
+	Durable string
+}
+
+// Subscription is an active subscription
+type Subscription interface {
+	// Unsubscribe stops delivery; a message being handled is abandoned
+	Unsubscribe() error
+}
+
+// Bus publishes messages to the subscribers of their topic
+type Bus interface {
+	Publish(ctx context.Context, msg Message) error
+	Subscribe(pattern string, handler Handler, opts SubscribeOptions) (Subscription, error)
+	Close() error
+}
+
+// Store persists messages and durable subscribers' acknowledgements
+type Store interface {
+	Append(ctx context.Context, msg Message) error
+	Register(ctx context.Context, subscriber, pattern string) error
+	// Unacked returns up to limit unacknowledged messages, oldest first;
+	// a limit of zero returns them all
+	Unacked(ctx context.Context, subscriber string, limit int) ([]Message, error)
+	Ack(ctx context.Context, subscriber, messageID string) error
+}
+
+// Match reports whether topic matches a subscription pattern: the same
+// topic, or any topic starting with the part of pattern before a
+// trailing "*"
+func Match(pattern, topic string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(topic, prefix)
+	}
+	return pattern == topic
+}
+
+// StorageStore keeps messages in the messages table
+type StorageStore struct {
+	repo *storage.TopicRepository
+}
+
+// NewStorageStore creates a store backed by the repository
+func NewStorageStore(repo *storage.TopicRepository) *StorageStore {
+	return &StorageStore{repo: repo}
+}
+
+// Append implements Store
+func (s *StorageStore) Append(ctx context.Context, msg Message) error {
+	stored := &storage.TopicMessage{
+		ID:      msg.ID,
+		Topic:   msg.Topic,
+		From:    msg.From,
+		To:      msg.To,
+		Type:    msg.Type,
+		Content: msg.Content,
+		Data:    msg.Data,
+	}
+	if msg.TeamID != "" {
+		stored.TeamID = &msg.TeamID
+	}
+	return s.repo.Append(ctx, stored)
+}
+
+// Register implements Store
+func (s *StorageStore) Register(ctx context.Context, subscriber, pattern string) error {
+	return s.repo.Register(ctx, subscriber, pattern)
+}
+
+// Unacked implements Store
+func (s *StorageStore) Unacked(ctx context.Context, subscriber string, limit int) ([]Message, error) {
+	stored, err := s.repo.Unacked(ctx, subscriber, limit)
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]Message, len(stored))
+	for i, m := range stored {
+		msgs[i] = Message{
+			ID:        m.ID,
+			Topic:     m.Topic,
+			From:      m.From,
+			To:        m.To,
+			Type:      m.Type,
+			Content:   m.Content,
+			Data:      m.Data,
+			Timestamp: m.CreatedAt,
+		}
+		if m.TeamID != nil {
+			msgs[i].TeamID = *m.TeamID
+		}
+	}
+	return msgs, nil
+}
+
+// Ack implements Store
+func (s *StorageStore) Ack(ctx context.Context, subscriber, messageID string) error {
+	return s.repo.Ack(ctx, subscriber, messageID)
+}
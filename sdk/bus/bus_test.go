@@ -0,0 +1,214 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/storage"
+)
+
+// collector records the messages a handler received
+type collector struct {
+	mu   sync.Mutex
+	msgs []Message
+	got  chan Message
+}
+
+func newCollector() *collector {
+	return &collector{got: make(chan Message, 100)}
+}
+
+func (c *collector) handle(ctx context.Context, msg Message) error {
+	c.mu.Lock()
+	c.msgs = append(c.msgs, msg)
+	c.mu.Unlock()
+	c.got <- msg
+	return nil
+}
+
+func (c *collector) next(t *testing.T) Message {
+	t.Helper()
+	select {
+	case msg := <-c.got:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message delivered")
+		return Message{}
+	}
+}
+
+func (c *collector) none(t *testing.T) {
+	t.Helper()
+	select {
+	case msg := <-c.got:
+		t.Fatalf("unexpected message %+v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func newTestStore(t *testing.T) *storage.Storage {
+	t.Helper()
+	adb, err := storage.NewAgentDB(filepath.Join(t.TempDir(), "agents.db"))
+	if err != nil {
+		t.Fatalf("NewAgentDB: %v", err)
+	}
+	t.Cleanup(func() { adb.Close() })
+	return storage.NewStorage(adb.GetDB(), 24*time.Hour)
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"tasks.created", "tasks.created", true},
+		{"tasks.created", "tasks.completed", false},
+		{"tasks.*", "tasks.completed", true},
+		{"tasks.*", "tasks", false},
+		{"*", "anything", true},
+		{"Tasks.*", "tasks.created", false},
+	}
+	for _, tc := range cases {
+		if got := Match(tc.pattern, tc.topic); got != tc.want {
+			t.Errorf("Match(%q, %q) = %v", tc.pattern, tc.topic, got)
+		}
+	}
+}
+
+func TestInProcess_PublishSubscribe(t *testing.T) {
+	b := NewInProcess(Options{})
+	defer b.Close()
+	ctx := context.Background()
+
+	tasks, all := newCollector(), newCollector()
+	b.Subscribe("tasks.*", tasks.handle, SubscribeOptions{})
+	sub, _ := b.Subscribe("*", all.handle, SubscribeOptions{})
+
+	for _, topic := range []string{"tasks.created", "alerts.budget", "tasks.completed"} {
+		if err := b.Publish(ctx, Message{Topic: topic, From: "triage"}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	if msg := tasks.next(t); msg.Topic != "tasks.created" || msg.ID == "" || msg.Attempt != 1 {
+		t.Errorf("unexpected message %+v", msg)
+	}
+	if msg := tasks.next(t); msg.Topic != "tasks.completed" {
+		t.Errorf("expected publish order, got %s", msg.Topic)
+	}
+	for i := 0; i < 3; i++ {
+		all.next(t)
+	}
+
+	sub.Unsubscribe()
+	b.Publish(ctx, Message{Topic: "tasks.created"})
+	tasks.next(t)
+	all.none(t)
+
+	if err := b.Publish(ctx, Message{}); err == nil {
+		t.Error("expected an error for a message without a topic")
+	}
+	if stats := b.Stats(); stats.Published != 4 || stats.Subscribers != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestInProcess_Redelivery(t *testing.T) {
+	var dropped []Message
+	var mu sync.Mutex
+	b := NewInProcess(Options{MaxAttempts: 3, RetryBackoff: time.Millisecond, OnDrop: func(sub string, msg Message, err error) {
+		mu.Lock()
+		dropped = append(dropped, msg)
+		mu.Unlock()
+	}})
+	defer b.Close()
+	ctx := context.Background()
+
+	attempts := make(chan int, 10)
+	b.Subscribe("jobs", func(ctx context.Context, msg Message) error {
+		attempts <- msg.Attempt
+		if msg.Content == "flaky" && msg.Attempt < 2 {
+			return errors.New("busy")
+		}
+		if msg.Content == "poison" {
+			panic("bad payload")
+		}
+		return nil
+	}, SubscribeOptions{})
+
+	b.Publish(ctx, Message{Topic: "jobs", Content: "flaky"})
+	b.Publish(ctx, Message{Topic: "jobs", Content: "poison"})
+	var got []int
+	for i := 0; i < 5; i++ {
+		select {
+		case a := <-attempts:
+			got = append(got, a)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("only %v deliveries", got)
+		}
+	}
+	if fmt.Sprint(got) != "[1 2 1 2 3]" {
+		t.Errorf("attempts = %v, want [1 2 1 2 3]", got)
+	}
+
+	b.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(dropped) != 1 || dropped[0].Content != "poison" {
+		t.Errorf("unexpected drops %+v", dropped)
+	}
+	if stats := b.Stats(); stats.Delivered != 1 || stats.Redelivered != 3 || stats.Dropped != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestInProcess_DurableReplay(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	if err := store.Agents.Create(ctx, &storage.Agent{ID: "triage", Name: "triage", Status: "idle"}); err != nil {
+		t.Fatalf("Create agent: %v", err)
+	}
+
+	// Messages published before a durable subscriber first registers are
+	// not replayed to it
+	b := NewInProcess(Options{Store: NewStorageStore(store.Topics), RetryBackoff: time.Millisecond})
+	b.Publish(ctx, Message{Topic: "team.support.ticket", Content: "before"})
+
+	first := newCollector()
+	b.Subscribe("team.support.*", first.handle, SubscribeOptions{Durable: "billing"})
+	if _, err := b.Subscribe("team.support.*", first.handle, SubscribeOptions{Durable: "billing"}); err == nil {
+		t.Error("expected a second active subscription under the same name to fail")
+	}
+	b.Publish(ctx, Message{Topic: "team.support.ticket", From: "triage", Content: "one", Data: map[string]interface{}{"priority": "high"}})
+	if msg := first.next(t); msg.Content != "one" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	b.Close()
+
+	// Published while the subscriber is away, on a bus without it
+	b = NewInProcess(Options{Store: NewStorageStore(store.Topics)})
+	b.Publish(ctx, Message{Topic: "team.support.ticket", Content: "two"})
+	b.Publish(ctx, Message{Topic: "team.sales.lead", Content: "elsewhere"})
+	b.Close()
+
+	b = NewInProcess(Options{Store: NewStorageStore(store.Topics)})
+	defer b.Close()
+	second := newCollector()
+	b.Subscribe("team.support.*", second.handle, SubscribeOptions{Durable: "billing"})
+	if msg := second.next(t); msg.Content != "two" {
+		t.Errorf("expected the missed message replayed, got %+v", msg)
+	}
+	second.none(t)
+
+	stored, err := store.Topics.List(ctx, "team.*", 10)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(stored) != 4 || stored[1].Content != "one" || stored[1].From != "triage" || stored[1].Data["priority"] != "high" {
+		t.Errorf("unexpected stored messages %+v", stored)
+	}
+}
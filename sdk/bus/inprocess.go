@@ -0,0 +1,337 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultMaxAttempts is how many times a message is delivered to a
+	// subscriber whose handler keeps failing
+	DefaultMaxAttempts = 5
+	// DefaultRetryBackoff is the wait before the first redelivery; each
+	// further one waits twice as long
+	DefaultRetryBackoff = 100 * time.Millisecond
+	// DefaultMaxBackoff caps the wait between redeliveries
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// ErrClosed is returned by a closed bus
+var ErrClosed = errors.New("bus: closed")
+
+// Options configures an InProcess bus
+type Options struct {
+	Store        Store         // Optional; persists messages and durable acks
+	MaxAttempts  int           // Zero uses DefaultMaxAttempts
+	RetryBackoff time.Duration // Zero uses DefaultRetryBackoff
+	MaxBackoff   time.Duration // Zero uses DefaultMaxBackoff
+
+	// OnDrop is called when a message is given up on after MaxAttempts
+	// failed deliveries; nil logs it
+	OnDrop func(subscriber string, msg Message, err error)
+}
+
+// Stats counts an InProcess bus's traffic
+type Stats struct {
+	Subscribers int `json:"subscribers"`
+	Published   int `json:"published"`
+	Delivered   int `json:"delivered"`
+	Redelivered int `json:"redelivered"`
+	Dropped     int `json:"dropped"`
+}
+
+// InProcess is a Bus within one process. Each subscription has its own
+// unbounded queue and goroutine, so a slow subscriber never blocks
+// publishers or other subscribers, and receives messages in publish order.
+type InProcess struct {
+	opts   Options
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	subs   map[string]*subscription
+	stats  Stats
+	closed bool
+}
+
+var _ Bus = (*InProcess)(nil)
+
+// NewInProcess creates an in-process bus
+func NewInProcess(opts Options) *InProcess {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = DefaultRetryBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &InProcess{opts: opts, ctx: ctx, cancel: cancel, subs: make(map[string]*subscription)}
+}
+
+// Publish stores msg, when the bus has a Store, and queues it for every
+// matching subscriber. It returns once the message is queued, not
+// delivered.
+func (b *InProcess) Publish(ctx context.Context, msg Message) error {
+	if msg.Topic == "" {
+		return fmt.Errorf("bus: message topic cannot be empty")
+	}
+	if msg.ID == "" {
+		msg.ID = uuid.New().String()
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	msg.Attempt = 0
+
+	// Held while storing, so a durable subscriber replaying from the
+	// store sees each message either there or in its queue, never both
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	if b.opts.Store != nil {
+		if err := b.opts.Store.Append(ctx, msg); err != nil {
+			return fmt.Errorf("bus: %w", err)
+		}
+	}
+	b.stats.Published++
+	for _, sub := range b.subs {
+		if Match(sub.pattern, msg.Topic) {
+			sub.enqueue(msg)
+		}
+	}
+	return nil
+}
+
+// Subscribe starts delivering the messages on topics matching pattern to
+// handler
+func (b *InProcess) Subscribe(pattern string, handler Handler, opts SubscribeOptions) (Subscription, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("bus: subscription pattern cannot be empty")
+	}
+	if handler == nil {
+		return nil, fmt.Errorf("bus: handler cannot be nil")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	id := opts.Durable
+	if id == "" {
+		id = uuid.New().String()
+	} else if _, exists := b.subs[id]; exists {
+		return nil, fmt.Errorf("bus: subscriber %s is already active", id)
+	}
+
+	ctx, cancel := context.WithCancel(b.ctx)
+	sub := &subscription{
+		bus:     b,
+		id:      id,
+		pattern: pattern,
+		handler: handler,
+		durable: opts.Durable != "" && b.opts.Store != nil,
+		ctx:     ctx,
+		cancel:  cancel,
+		notify:  make(chan struct{}, 1),
+	}
+
+	if sub.durable {
+		if err := b.opts.Store.Register(ctx, id, pattern); err != nil {
+			cancel()
+			return nil, fmt.Errorf("bus: %w", err)
+		}
+		if err := sub.replay(); err != nil {
+			cancel()
+			return nil, fmt.Errorf("bus: %w", err)
+		}
+	}
+
+	b.subs[id] = sub
+	b.stats.Subscribers = len(b.subs)
+	b.wg.Add(1)
+	go sub.run()
+	return sub, nil
+}
+
+// Stats returns the bus's counters
+func (b *InProcess) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// Close stops all subscriptions and waits for their handlers to return.
+// Queued messages are dropped; durable subscribers get them again from
+// the Store.
+func (b *InProcess) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.subs = make(map[string]*subscription)
+	b.stats.Subscribers = 0
+	b.mu.Unlock()
+
+	b.cancel()
+	b.wg.Wait()
+	return nil
+}
+
+func (b *InProcess) count(field *int) {
+	b.mu.Lock()
+	*field++
+	b.mu.Unlock()
+}
+
+// backoff is the wait after the given number of failed deliveries
+func (b *InProcess) backoff(attempts int) time.Duration {
+	wait := b.opts.RetryBackoff
+	for i := 1; i < attempts && wait < b.opts.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > b.opts.MaxBackoff {
+		wait = b.opts.MaxBackoff
+	}
+	return wait
+}
+
+type subscription struct {
+	bus     *InProcess
+	id      string
+	pattern string
+	handler Handler
+	durable bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mu     sync.Mutex
+	queue  []Message
+	notify chan struct{}
+}
+
+// Unsubscribe implements Subscription
+func (s *subscription) Unsubscribe() error {
+	b := s.bus
+	b.mu.Lock()
+	if b.subs[s.id] == s {
+		delete(b.subs, s.id)
+		b.stats.Subscribers = len(b.subs)
+	}
+	b.mu.Unlock()
+	s.cancel()
+	return nil
+}
+
+func (s *subscription) enqueue(msg Message) {
+	s.mu.Lock()
+	s.queue = append(s.queue, msg)
+	s.mu.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// replay queues the messages the durable subscriber has not acknowledged
+func (s *subscription) replay() error {
+	msgs, err := s.bus.opts.Store.Unacked(s.ctx, s.id, 0)
+	if err != nil {
+		return err
+	}
+	s.queue = append(s.queue, msgs...)
+	return nil
+}
+
+func (s *subscription) run() {
+	defer s.bus.wg.Done()
+	for s.ctx.Err() == nil {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-s.notify:
+				continue
+			}
+		}
+		msg := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		if !s.deliver(msg) {
+			return
+		}
+	}
+}
+
+// deliver hands msg to the handler until it succeeds or runs out of
+// attempts, reporting false if the subscription stopped first
+func (s *subscription) deliver(msg Message) bool {
+	b := s.bus
+	var err error
+	for attempt := 1; attempt <= b.opts.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			b.count(&b.stats.Redelivered)
+			select {
+			case <-s.ctx.Done():
+				return false
+			case <-time.After(b.backoff(attempt - 1)):
+			}
+		}
+		msg.Attempt = attempt
+		if err = s.call(msg); err == nil {
+			b.count(&b.stats.Delivered)
+			s.ack(msg)
+			return true
+		}
+		if s.ctx.Err() != nil {
+			return false
+		}
+	}
+
+	b.count(&b.stats.Dropped)
+	if b.opts.OnDrop != nil {
+		b.opts.OnDrop(s.id, msg, err)
+	} else {
+		log.Printf("bus: dropped message %s on %s for %s after %d attempts: %v", msg.ID, msg.Topic, s.id, msg.Attempt, err)
+	}
+	// Acknowledged too, so it is not replayed forever
+	s.ack(msg)
+	return true
+}
+
+// call runs the handler, turning a panic into a failed delivery
+func (s *subscription) call(msg Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return s.handler(s.ctx, msg)
+}
+
+func (s *subscription) ack(msg Message) {
+	if !s.durable {
+		return
+	}
+	if err := s.bus.opts.Store.Ack(context.WithoutCancel(s.ctx), s.id, msg.ID); err != nil {
+		log.Printf("bus: failed to ack message %s for %s: %v", msg.ID, s.id, err)
+	}
+}
@@ -53,6 +53,8 @@ func (cli *CLI) registerBuiltinCommands() {
 		&AddToTeamCommand{},
 		&ListTasksCommand{},
 		&StatusCommand{},
+		&QueueCommand{},
+		&RequeueTaskCommand{},
 		&CleanupCommand{},
 	}
 
@@ -434,6 +434,8 @@ func ExampleCLI() {
 	// list-agents          List all registered agents
 	// list-tasks           List all tasks
 	// list-teams           List all teams
+	// queue                Show task queue depth and failure reasons
+	// requeue-task         Requeue a failed or dead-lettered task
 	// status               Show system status
 	//
 	// Use 'help <command>' for detailed usage information
@@ -983,3 +985,46 @@ func TestHelpCommand_Execute_WithCommand(t *testing.T) {
 		t.Errorf("Expected unknown command error, got %v", err)
 	}
 }
+
+func TestQueueAndRequeueCommands(t *testing.T) {
+	config := &Config{
+		DatabasePath:  filepath.Join(t.TempDir(), "test.db"),
+		LogLevel:      "info",
+		DataRetention: 24 * time.Hour,
+		StartupAction: "zero-state",
+	}
+	orchestrator, err := NewOrchestrator(config)
+	if err != nil {
+		t.Fatalf("Failed to create orchestrator: %v", err)
+	}
+	defer orchestrator.Stop()
+	if err := orchestrator.Start(); err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+
+	ctx := context.Background()
+	agent := orchestrator.storage.NewAgentWithDefaults("worker", "assistant", nil)
+	orchestrator.storage.Agents.Create(ctx, agent)
+	task := orchestrator.storage.NewTaskWithDefaults(agent.ID, "report", "weekly", 1)
+	orchestrator.storage.Tasks.Create(ctx, task)
+	orchestrator.storage.Tasks.DeadLetter(ctx, task.ID, "template missing")
+
+	if err := (&QueueCommand{}).Execute(ctx, orchestrator, nil); err != nil {
+		t.Errorf("queue failed: %v", err)
+	}
+
+	requeue := &RequeueTaskCommand{}
+	if err := requeue.Execute(ctx, orchestrator, nil); err == nil {
+		t.Error("Expected an error without a task ID")
+	}
+	if err := requeue.Execute(ctx, orchestrator, []string{task.ID}); err != nil {
+		t.Fatalf("requeue failed: %v", err)
+	}
+	got, _ := orchestrator.storage.Tasks.Get(ctx, task.ID)
+	if got.Status != "pending" {
+		t.Errorf("Expected pending, got %s", got.Status)
+	}
+	if err := requeue.Execute(ctx, orchestrator, []string{task.ID}); err == nil {
+		t.Error("Expected pending tasks to be refused")
+	}
+}
@@ -388,6 +388,93 @@ func (c *CleanupCommand) Execute(ctx context.Context, orchestrator *Orchestrator
 	return nil
 }
 
+// QueueCommand shows the task queue: depth by status and type, and why
+// tasks fail
+type QueueCommand struct{}
+
+func (c *QueueCommand) Name() string        { return "queue" }
+func (c *QueueCommand) Description() string { return "Show task queue depth and failure reasons" }
+func (c *QueueCommand) Usage() string       { return "queue" }
+
+func (c *QueueCommand) Execute(ctx context.Context, orchestrator *Orchestrator, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("too many arguments")
+	}
+
+	stats, err := orchestrator.storage.Tasks.QueueStats(ctx, 10)
+	if err != nil {
+		return fmt.Errorf("failed to get queue stats: %w", err)
+	}
+
+	fmt.Println("Task Queue")
+	fmt.Println("==========")
+	for _, status := range []string{"pending", "running", "completed", "failed", "dead_letter", "cancelled"} {
+		fmt.Printf("  %-12s %d\n", status+":", stats.ByStatus[status])
+	}
+	if stats.Delayed > 0 {
+		fmt.Printf("  (%d pending tasks are waiting to be retried)\n", stats.Delayed)
+	}
+
+	if len(stats.PendingByType) > 0 {
+		types := make([]string, 0, len(stats.PendingByType))
+		for t := range stats.PendingByType {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		fmt.Println("\nPending by Type:")
+		for _, t := range types {
+			fmt.Printf("  %-20s %d\n", t, stats.PendingByType[t])
+		}
+	}
+
+	if len(stats.Failures) > 0 {
+		fmt.Println("\nFailure Reasons:")
+		fmt.Printf("  %-6s %-16s %s\n", "Count", "Last Seen", "Reason")
+		for _, f := range stats.Failures {
+			reason := f.Reason
+			if len(reason) > 80 {
+				reason = reason[:78] + ".."
+			}
+			fmt.Printf("  %-6d %-16s %s\n", f.Count, f.LastSeen.Format("2006-01-02 15:04"), reason)
+		}
+	}
+
+	return nil
+}
+
+// RequeueTaskCommand returns a failed or dead-lettered task to the queue
+type RequeueTaskCommand struct{}
+
+func (c *RequeueTaskCommand) Name() string        { return "requeue-task" }
+func (c *RequeueTaskCommand) Description() string { return "Requeue a failed or dead-lettered task" }
+func (c *RequeueTaskCommand) Usage() string       { return "requeue-task <task-id>" }
+
+func (c *RequeueTaskCommand) Execute(ctx context.Context, orchestrator *Orchestrator, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: %s", c.Usage())
+	}
+
+	task, err := orchestrator.storage.Tasks.Get(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if task.Status != "failed" && task.Status != "dead_letter" {
+		return fmt.Errorf("task %s is %s; only failed and dead-lettered tasks can be requeued", task.ID, task.Status)
+	}
+	if err := orchestrator.storage.Tasks.Requeue(ctx, task.ID); err != nil {
+		return fmt.Errorf("failed to requeue task: %w", err)
+	}
+
+	orchestrator.mu.Lock()
+	if t, exists := orchestrator.tasks[task.ID]; exists {
+		t.Status = "pending"
+	}
+	orchestrator.mu.Unlock()
+
+	fmt.Printf("✓ Task %s requeued\n", task.ID)
+	return nil
+}
+
 // HelpCommand shows help
 type HelpCommand struct {
 	commands map[string]Command
@@ -0,0 +1,627 @@
+// Package sdk is the one-import entry point to modelscan's SDK: a Client
+// that talks to several providers through one API and handles routing,
+// rate limiting, retries, cost tracking and request translation itself.
+//
+//	client, err := sdk.New(sdk.Options{
+//		Providers: []sdk.ProviderConfig{
+//			{Name: "openai", Format: sdk.FormatOpenAI, BaseURL: "https://api.openai.com/v1", APIKey: openaiKey},
+//			{Name: "anthropic", Format: sdk.FormatAnthropic, BaseURL: "https://api.anthropic.com/v1", APIKey: anthropicKey,
+//				Models: []string{"claude-sonnet-4-5"}},
+//		},
+//		Strategy: router.StrategyFallback,
+//	})
+//	resp, err := client.Chat(ctx, sdk.ChatRequest{
+//		Model:    "claude-sonnet-4-5",
+//		Messages: []sdk.Message{{Role: "user", Content: "Hello"}},
+//	})
+//
+// The building blocks (router, ratelimit, stream, providererr) remain
+// available for callers that need finer control.
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	mshttp "github.com/jeffersonwarrior/modelscan/internal/http"
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+	"github.com/jeffersonwarrior/modelscan/sdk/ratelimit"
+	"github.com/jeffersonwarrior/modelscan/sdk/router"
+	"github.com/jeffersonwarrior/modelscan/sdk/stream"
+)
+
+// Format is the wire format a provider speaks
+type Format string
+
+const (
+	FormatOpenAI    Format = "openai"    // /chat/completions and /embeddings (OpenAI and compatible APIs)
+	FormatAnthropic Format = "anthropic" // /messages
+)
+
+// ErrNoProvider is returned when no configured provider serves the
+// requested model
+var ErrNoProvider = errors.New("no provider available")
+
+// ErrOverBudget is returned when every provider serving the model could
+// cost more than the request's MaxCost
+var ErrOverBudget = errors.New("no provider within max cost")
+
+// ProviderConfig describes one upstream provider
+type ProviderConfig struct {
+	Name    string // Used in errors, usage stats and routing
+	Format  Format // Default: FormatOpenAI
+	BaseURL string // e.g. "https://api.openai.com/v1"
+	APIKey  string
+
+	// Models the provider serves; empty means any model
+	Models []string
+
+	// Pricing in USD per million tokens, used for cost tracking, MaxCost
+	// and the cheapest and balanced strategies. Pricing overrides the
+	// provider-wide prices for the models it lists.
+	InputCostPer1M  float64
+	OutputCostPer1M float64
+	Pricing         map[string]ModelPricing
+
+	// RequestsPerMinute limits calls to the provider; zero is unlimited.
+	// Calls wait for capacity rather than failing.
+	RequestsPerMinute int
+
+	// SmoothRequests spreads calls evenly across the minute instead of
+	// letting a full minute's worth go out at once, for providers that
+	// answer bursts with 429s even within their published limit
+	SmoothRequests bool
+}
+
+// ModelPricing is one model's price in USD per million tokens
+type ModelPricing struct {
+	InputCostPer1M  float64
+	OutputCostPer1M float64
+}
+
+// Options configures a Client
+type Options struct {
+	Providers []ProviderConfig
+
+	// Strategy orders the providers that serve a model (default
+	// router.StrategyFallback: configuration order). A failed call moves
+	// on to the next provider.
+	Strategy router.RoutingStrategy
+
+	// ExpectedOutputTokens is the completion length assumed when pricing a
+	// chat call for the cheapest and balanced strategies, capped by the
+	// request's MaxTokens (default router.DefaultExpectedOutputTokens)
+	ExpectedOutputTokens int
+
+	// Timeout bounds Chat and Embed calls, retries included (default 60s).
+	// StreamTimeout bounds a ChatStream call until its body is read
+	// (default 10m).
+	Timeout       time.Duration
+	StreamTimeout time.Duration
+
+	// MaxAttempts per provider, retries of 429 and 5xx included (default
+	// 3), and the backoff before the first retry (default 1s)
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+
+	// RetriesPerMinute caps retries per provider so an outage does not
+	// multiply traffic; zero leaves retries unbudgeted
+	RetriesPerMinute int
+
+	// FailureCooldown is how long a provider that failed
+	// UnhealthyAfter calls in a row (default 3) is tried last (default 30s)
+	FailureCooldown time.Duration
+	UnhealthyAfter  int
+
+	// Transport replaces the pooled HTTP transport, e.g. for tests
+	Transport http.RoundTripper
+
+	// Middleware wraps every Chat, ChatStream and Embed call; the first
+	// entry is the outermost
+	Middleware []Middleware
+}
+
+func (o *Options) setDefaults() {
+	if o.Strategy == "" {
+		o.Strategy = router.StrategyFallback
+	}
+	if o.Timeout == 0 {
+		o.Timeout = 60 * time.Second
+	}
+	if o.StreamTimeout == 0 {
+		o.StreamTimeout = 10 * time.Minute
+	}
+	if o.MaxAttempts == 0 {
+		o.MaxAttempts = 3
+	}
+	if o.FailureCooldown == 0 {
+		o.FailureCooldown = 30 * time.Second
+	}
+	if o.UnhealthyAfter == 0 {
+		o.UnhealthyAfter = 3
+	}
+}
+
+// Usage is the token count of one call
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// UsageStats summarizes the calls made to one provider
+type UsageStats struct {
+	Provider     string  `json:"provider"`
+	Requests     int64   `json:"requests"`
+	Failures     int64   `json:"failures"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+// RetryBudgetStats reports how much of one provider's retry budget is left
+type RetryBudgetStats = mshttp.RetryBudgetStats
+
+// Client sends chat and embedding requests to the best available provider
+type Client struct {
+	opts      Options
+	providers []*provider
+	handler   Handler             // dispatch wrapped in Options.Middleware
+	budget    *mshttp.RetryBudget // nil: retries unbudgeted
+
+	mu      sync.Mutex
+	rrIndex int
+}
+
+// provider is one configured upstream with its transport, limiter, health
+// and usage
+type provider struct {
+	cfg     ProviderConfig
+	http    *mshttp.Client
+	limiter *ratelimit.TokenBucket // nil: unlimited
+
+	mu       sync.Mutex
+	latency  time.Duration // Moving average of successful calls
+	fails    int           // Consecutive failures
+	failedAt time.Time
+	usage    UsageStats
+}
+
+// New creates a Client from opts
+func New(opts Options) (*Client, error) {
+	opts.setDefaults()
+	if len(opts.Providers) == 0 {
+		return nil, errors.New("sdk: at least one provider is required")
+	}
+
+	var budget *mshttp.RetryBudget
+	if opts.RetriesPerMinute > 0 {
+		var err error
+		budget, err = mshttp.NewRetryBudget(mshttp.RetryBudgetConfig{Retries: opts.RetriesPerMinute, Window: time.Minute})
+		if err != nil {
+			return nil, fmt.Errorf("sdk: %w", err)
+		}
+	}
+
+	c := &Client{opts: opts, budget: budget}
+	seen := make(map[string]bool)
+	for _, cfg := range opts.Providers {
+		if cfg.Name == "" || cfg.BaseURL == "" {
+			return nil, errors.New("sdk: provider name and base URL are required")
+		}
+		if seen[cfg.Name] {
+			return nil, fmt.Errorf("sdk: duplicate provider %q", cfg.Name)
+		}
+		seen[cfg.Name] = true
+		if cfg.Format == "" {
+			cfg.Format = FormatOpenAI
+		}
+		if cfg.Format != FormatOpenAI && cfg.Format != FormatAnthropic {
+			return nil, fmt.Errorf("sdk: provider %q: unknown format %q", cfg.Name, cfg.Format)
+		}
+
+		httpCfg := mshttp.Config{
+			BaseURL:     cfg.BaseURL,
+			Timeout:     opts.StreamTimeout,
+			Transport:   opts.Transport,
+			RetryBudget: budget,
+			Retry: mshttp.RetryConfig{
+				MaxAttempts: opts.MaxAttempts,
+				BaseDelay:   opts.RetryBaseDelay,
+			},
+		}
+		if cfg.Format == FormatOpenAI {
+			httpCfg.APIKey = cfg.APIKey // Anthropic authenticates with x-api-key instead
+		}
+		p := &provider{cfg: cfg, http: mshttp.NewClient(httpCfg)}
+		p.usage.Provider = cfg.Name
+		if cfg.RequestsPerMinute > 0 {
+			rpm := int64(cfg.RequestsPerMinute)
+			p.limiter = ratelimit.NewTokenBucket(rpm, rpm, time.Minute)
+			p.limiter.SetSmoothing(cfg.SmoothRequests)
+		}
+		c.providers = append(c.providers, p)
+	}
+	c.handler = Chain(opts.Middleware...)(c.dispatch)
+	return c, nil
+}
+
+// Chat sends a chat completion to the first provider (in strategy order)
+// that serves req.Model and answers, translating to and from its format
+func (c *Client) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	result, err := c.call(ctx, &Call{Op: OpChat, Chat: &req})
+	if err != nil {
+		return nil, err
+	}
+	return result.Chat, nil
+}
+
+// ChatStream starts a streamed chat completion. Text arrives as data chunks
+// whatever the provider's format; token usage reported in the stream is
+// added to the provider's usage stats when the stream ends. Only failures
+// before the stream starts move on to another provider.
+func (c *Client) ChatStream(ctx context.Context, req ChatRequest) (*stream.Stream, error) {
+	result, err := c.call(ctx, &Call{Op: OpChatStream, Chat: &req})
+	if err != nil {
+		return nil, err
+	}
+	return result.Stream, nil
+}
+
+// Embed creates embeddings with the first OpenAI-format provider that serves
+// req.Model and answers
+func (c *Client) Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error) {
+	result, err := c.call(ctx, &Call{Op: OpEmbed, Embed: &req})
+	if err != nil {
+		return nil, err
+	}
+	return result.Embed, nil
+}
+
+// call runs a call through the middleware chain
+func (c *Client) call(ctx context.Context, call *Call) (*Result, error) {
+	result, err := c.handler(ctx, call)
+	if err == nil && result == nil {
+		err = fmt.Errorf("sdk: %s call returned no result", call.Op)
+	}
+	return result, err
+}
+
+// dispatch is the innermost Handler, which calls the providers
+func (c *Client) dispatch(ctx context.Context, call *Call) (*Result, error) {
+	switch {
+	case call.Op == OpChat && call.Chat != nil:
+		resp, err := c.chat(ctx, *call.Chat)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{Chat: resp}, nil
+	case call.Op == OpChatStream && call.Chat != nil:
+		s, err := c.chatStream(ctx, *call.Chat)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{Stream: s}, nil
+	case call.Op == OpEmbed && call.Embed != nil:
+		resp, err := c.embed(ctx, *call.Embed)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{Embed: resp}, nil
+	}
+	return nil, fmt.Errorf("sdk: invalid %q call", call.Op)
+}
+
+func (c *Client) chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	var resp *ChatResponse
+	err := c.try(ctx, c.chatRoute(req), func(ctx context.Context, p *provider) (Usage, error) {
+		var err error
+		resp, err = p.chat(ctx, req)
+		if err != nil {
+			return Usage{}, err
+		}
+		resp.Cost = p.cost(req.Model, resp.Usage)
+		return resp.Usage, nil
+	})
+	return resp, err
+}
+
+func (c *Client) chatStream(ctx context.Context, req ChatRequest) (*stream.Stream, error) {
+	var s *stream.Stream
+	err := c.try(ctx, c.chatRoute(req), func(ctx context.Context, p *provider) (Usage, error) {
+		body, err := p.chatStream(ctx, req)
+		if err != nil {
+			return Usage{}, err
+		}
+		var usage Usage
+		s = stream.NewStream(ctx, body, stream.StreamTypeSSE).Map(func(chunk *stream.Chunk) *stream.Chunk {
+			streamUsage(chunk.Metadata, &usage)
+			if chunk.Type == stream.ChunkTypeDone || chunk.Metadata["type"] == "message_stop" {
+				p.record(req.Model, usage)
+			}
+			return chunk
+		})
+		return Usage{}, errStreamStarted
+	})
+	if errors.Is(err, errStreamStarted) {
+		return s, nil
+	}
+	return nil, err
+}
+
+// errStreamStarted tells try that a stream was handed to the caller, which
+// accounts for its usage once it ends
+var errStreamStarted = errors.New("stream started")
+
+func (c *Client) embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	var input int64
+	for _, text := range req.Input {
+		input += int64(proxy.CountTokens(text))
+	}
+	rt := route{
+		model:    req.Model,
+		estimate: router.TokenEstimate{InputTokens: input},
+		eligible: func(p *provider) bool { return p.cfg.Format == FormatOpenAI },
+	}
+	var resp *EmbedResponse
+	err := c.try(ctx, rt, func(ctx context.Context, p *provider) (Usage, error) {
+		var err error
+		resp, err = p.embed(ctx, req)
+		if err != nil {
+			return Usage{}, err
+		}
+		resp.Cost = p.cost(req.Model, resp.Usage)
+		return resp.Usage, nil
+	})
+	return resp, err
+}
+
+// Usage returns per-provider call counts, tokens and cost, by provider name
+func (c *Client) Usage() []UsageStats {
+	stats := make([]UsageStats, 0, len(c.providers))
+	for _, p := range c.providers {
+		p.mu.Lock()
+		stats = append(stats, p.usage)
+		p.mu.Unlock()
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Provider < stats[j].Provider })
+	return stats
+}
+
+// RetryBudgets returns the retry budget left for each provider that has
+// retried, by provider name, or nil when Options.RetriesPerMinute is zero
+func (c *Client) RetryBudgets() []RetryBudgetStats {
+	if c.budget == nil {
+		return nil
+	}
+	return c.budget.Stats()
+}
+
+// TotalCost returns the cost of all calls so far in USD
+func (c *Client) TotalCost() float64 {
+	var total float64
+	for _, s := range c.Usage() {
+		total += s.Cost
+	}
+	return total
+}
+
+// route is what a call needs from a provider
+type route struct {
+	model    string
+	estimate router.TokenEstimate // Expected size, for pricing candidates
+	maxCost  float64              // Worst-case cost limit in USD; zero is none
+	eligible func(*provider) bool // Optional extra filter
+}
+
+// chatRoute prices a chat request from the tokenizer's count of its
+// messages and the expected completion length
+func (c *Client) chatRoute(req ChatRequest) route {
+	count := proxy.OpenAIRequest{Messages: make([]proxy.OpenAIMessage, len(req.Messages))}
+	for i, m := range req.Messages {
+		count.Messages[i] = proxy.OpenAIMessage{Role: m.Role, Content: m.Content}
+	}
+	input := int64(proxy.CountOpenAIRequestTokens(&count))
+	return route{
+		model:    req.Model,
+		estimate: router.NewTokenEstimate(input, int64(c.opts.ExpectedOutputTokens), int64(req.MaxTokens)),
+		maxCost:  req.MaxCost,
+	}
+}
+
+// try calls do on each candidate provider in turn until one succeeds,
+// waiting for rate limit capacity before each call. Errors that another
+// provider would repeat end the search early.
+func (c *Client) try(ctx context.Context, rt route, do func(context.Context, *provider) (Usage, error)) error {
+	candidates, overBudget := c.candidates(rt)
+	if len(candidates) == 0 {
+		if overBudget > 0 {
+			return fmt.Errorf("%w $%.6f for model %q (%d providers excluded)", ErrOverBudget, rt.maxCost, rt.model, overBudget)
+		}
+		return fmt.Errorf("%w for model %q", ErrNoProvider, rt.model)
+	}
+
+	var errs []error
+	for _, p := range candidates {
+		if p.limiter != nil {
+			if err := p.limiter.Acquire(ctx, 1); err != nil {
+				return errors.Join(append(errs, err)...)
+			}
+		}
+
+		start := time.Now()
+		usage, err := do(mshttp.WithProvider(ctx, p.cfg.Name), p)
+		if errors.Is(err, errStreamStarted) {
+			p.succeeded(time.Since(start))
+			return err
+		}
+		if err == nil {
+			p.succeeded(time.Since(start))
+			p.record(rt.model, usage)
+			return nil
+		}
+		p.failed()
+		errs = append(errs, err)
+		if !failover(ctx, err) {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// failover reports whether another provider might succeed where one failed
+func failover(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return !errors.Is(err, providererr.ErrContentFiltered)
+}
+
+// candidates returns the providers serving the route's model in strategy
+// order, with providers in their failure cooldown moved to the end. It also
+// counts the providers dropped because they could exceed the route's max cost.
+func (c *Client) candidates(rt route) ([]*provider, int) {
+	var healthy, cooling []*provider
+	overBudget := 0
+	for _, p := range c.providers {
+		if !p.serves(rt.model) || (rt.eligible != nil && !rt.eligible(p)) {
+			continue
+		}
+		if rt.maxCost > 0 {
+			in, out := p.price(rt.model)
+			if rt.estimate.WorstCaseCost(in, out) > rt.maxCost {
+				overBudget++
+				continue
+			}
+		}
+		if p.cooling(c.opts.UnhealthyAfter, c.opts.FailureCooldown) {
+			cooling = append(cooling, p)
+		} else {
+			healthy = append(healthy, p)
+		}
+	}
+	c.order(healthy, rt)
+	return append(healthy, cooling...), overBudget
+}
+
+// order sorts providers in place according to the routing strategy
+func (c *Client) order(ps []*provider, rt route) {
+	if len(ps) < 2 {
+		return
+	}
+	cost := func(p *provider) float64 {
+		in, out := p.price(rt.model)
+		return rt.estimate.Cost(in, out)
+	}
+	switch c.opts.Strategy {
+	case router.StrategyCheapest:
+		sort.SliceStable(ps, func(i, j int) bool { return cost(ps[i]) < cost(ps[j]) })
+	case router.StrategyFastest:
+		sort.SliceStable(ps, func(i, j int) bool { return ps[i].avgLatency() < ps[j].avgLatency() })
+	case router.StrategyBalanced:
+		// Equal weight to cost and latency, each relative to the slowest
+		// and most expensive candidate
+		var maxCost float64
+		var maxLatency time.Duration
+		for _, p := range ps {
+			maxCost = max(maxCost, cost(p))
+			maxLatency = max(maxLatency, p.avgLatency())
+		}
+		score := func(p *provider) float64 {
+			var s float64
+			if maxCost > 0 {
+				s += cost(p) / maxCost
+			}
+			if maxLatency > 0 {
+				s += float64(p.avgLatency()) / float64(maxLatency)
+			}
+			return s
+		}
+		sort.SliceStable(ps, func(i, j int) bool { return score(ps[i]) < score(ps[j]) })
+	case router.StrategyRoundRobin:
+		c.mu.Lock()
+		start := c.rrIndex % len(ps)
+		c.rrIndex++
+		c.mu.Unlock()
+		rotated := append(append([]*provider(nil), ps[start:]...), ps[:start]...)
+		copy(ps, rotated)
+	}
+}
+
+func (p *provider) serves(model string) bool {
+	if len(p.cfg.Models) == 0 {
+		return true
+	}
+	for _, m := range p.cfg.Models {
+		if m == model {
+			return true
+		}
+	}
+	return false
+}
+
+// price returns the model's input and output prices per million tokens
+func (p *provider) price(model string) (float64, float64) {
+	if mp, ok := p.cfg.Pricing[model]; ok {
+		return mp.InputCostPer1M, mp.OutputCostPer1M
+	}
+	return p.cfg.InputCostPer1M, p.cfg.OutputCostPer1M
+}
+
+func (p *provider) cost(model string, u Usage) float64 {
+	in, out := p.price(model)
+	return (float64(u.InputTokens)*in + float64(u.OutputTokens)*out) / 1_000_000
+}
+
+func (p *provider) avgLatency() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.latency
+}
+
+func (p *provider) cooling(after int, cooldown time.Duration) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.fails >= after && time.Since(p.failedAt) < cooldown
+}
+
+func (p *provider) succeeded(latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.latency == 0 {
+		p.latency = latency
+	} else {
+		p.latency = (p.latency*4 + latency) / 5
+	}
+	p.fails = 0
+	p.usage.Requests++
+}
+
+func (p *provider) failed() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fails++
+	p.failedAt = time.Now()
+	p.usage.Requests++
+	p.usage.Failures++
+}
+
+// record adds a call's tokens and cost to the provider's usage
+func (p *provider) record(model string, u Usage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.usage.InputTokens += int64(u.InputTokens)
+	p.usage.OutputTokens += int64(u.OutputTokens)
+	p.usage.Cost += p.cost(model, u)
+}
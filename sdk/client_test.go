@@ -0,0 +1,502 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+	"github.com/jeffersonwarrior/modelscan/sdk/router"
+)
+
+// openAIServer answers chat completions with reply, counting calls
+func openAIServer(t *testing.T, reply string, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls != nil {
+			calls.Add(1)
+		}
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("path = %s, want /chat/completions", r.URL.Path)
+		}
+		fmt.Fprintf(w, `{"id":"cmpl-1","model":"gpt-4o","choices":[{"message":{"content":%q},"finish_reason":"stop"}],"usage":{"prompt_tokens":1000,"completion_tokens":500}}`, reply)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// failingServer answers every request with status and body
+func failingServer(t *testing.T, status int, body string, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls != nil {
+			calls.Add(1)
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newClient(t *testing.T, opts Options) *Client {
+	t.Helper()
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = 1
+	}
+	c, err := New(opts)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func userMessage(text string) []Message {
+	return []Message{{Role: "user", Content: text}}
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"no providers", Options{}},
+		{"missing base URL", Options{Providers: []ProviderConfig{{Name: "a"}}}},
+		{"duplicate", Options{Providers: []ProviderConfig{{Name: "a", BaseURL: "http://a"}, {Name: "a", BaseURL: "http://b"}}}},
+		{"unknown format", Options{Providers: []ProviderConfig{{Name: "a", BaseURL: "http://a", Format: "soap"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.opts); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestClient_ChatOpenAI(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["model"] != "gpt-4o" || body["max_tokens"] != float64(50) {
+			t.Errorf("unexpected body: %v", body)
+		}
+		io.WriteString(w, `{"id":"cmpl-1","model":"gpt-4o","choices":[{"message":{"content":"Hi!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1000,"completion_tokens":500}}`)
+	}))
+	defer srv.Close()
+
+	c := newClient(t, Options{Providers: []ProviderConfig{{
+		Name: "openai", BaseURL: srv.URL, APIKey: "sk-test",
+		InputCostPer1M: 2, OutputCostPer1M: 10,
+	}}})
+	resp, err := c.Chat(context.Background(), ChatRequest{Model: "gpt-4o", Messages: userMessage("Hello"), MaxTokens: 50})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	if auth != "Bearer sk-test" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if resp.Content != "Hi!" || resp.Provider != "openai" || resp.FinishReason != "stop" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if resp.Usage != (Usage{InputTokens: 1000, OutputTokens: 500}) {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+	if want := 0.007; resp.Cost < want-1e-9 || resp.Cost > want+1e-9 {
+		t.Errorf("Cost = %v, want %v", resp.Cost, want)
+	}
+
+	stats := c.Usage()
+	if len(stats) != 1 || stats[0].Requests != 1 || stats[0].InputTokens != 1000 || stats[0].OutputTokens != 500 {
+		t.Errorf("Usage() = %+v", stats)
+	}
+	if c.TotalCost() != resp.Cost {
+		t.Errorf("TotalCost = %v, want %v", c.TotalCost(), resp.Cost)
+	}
+}
+
+func TestClient_ChatAnthropicTranslation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/messages" {
+			t.Errorf("path = %s, want /messages", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "ant-key" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("missing Anthropic auth headers: %v", r.Header)
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Error("Authorization header sent to Anthropic-format provider")
+		}
+		var body struct {
+			System    string    `json:"system"`
+			Messages  []Message `json:"messages"`
+			MaxTokens int       `json:"max_tokens"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.System != "Be brief." || len(body.Messages) != 1 || body.Messages[0].Role != "user" {
+			t.Errorf("system message not translated: %+v", body)
+		}
+		if body.MaxTokens != defaultMaxTokens {
+			t.Errorf("max_tokens = %d, want %d", body.MaxTokens, defaultMaxTokens)
+		}
+		io.WriteString(w, `{"id":"msg_1","model":"claude","content":[{"type":"text","text":"Hello"},{"type":"text","text":" there"}],"stop_reason":"max_tokens","usage":{"input_tokens":10,"output_tokens":2}}`)
+	}))
+	defer srv.Close()
+
+	c := newClient(t, Options{Providers: []ProviderConfig{{Name: "anthropic", Format: FormatAnthropic, BaseURL: srv.URL, APIKey: "ant-key"}}})
+	resp, err := c.Chat(context.Background(), ChatRequest{
+		Model:    "claude",
+		Messages: []Message{{Role: "system", Content: "Be brief."}, {Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Content != "Hello there" || resp.FinishReason != "length" || resp.Usage.OutputTokens != 2 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestClient_FailsOverToNextProvider(t *testing.T) {
+	var downCalls atomic.Int32
+	down := failingServer(t, http.StatusServiceUnavailable, `{"error":{"message":"overloaded"}}`, &downCalls)
+	up := openAIServer(t, "from backup", nil)
+
+	c := newClient(t, Options{Providers: []ProviderConfig{
+		{Name: "primary", BaseURL: down.URL},
+		{Name: "backup", BaseURL: up.URL},
+	}})
+	resp, err := c.Chat(context.Background(), ChatRequest{Model: "m", Messages: userMessage("Hi")})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Provider != "backup" || resp.Content != "from backup" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if downCalls.Load() != 1 {
+		t.Errorf("primary called %d times, want 1", downCalls.Load())
+	}
+	if stats := c.Usage(); stats[1].Provider != "primary" || stats[1].Failures != 1 {
+		t.Errorf("primary failure not recorded: %+v", stats)
+	}
+}
+
+func TestClient_AllProvidersFail(t *testing.T) {
+	a := failingServer(t, http.StatusTooManyRequests, `{"error":{"message":"slow down"}}`, nil)
+	b := failingServer(t, http.StatusUnauthorized, `{"error":{"message":"bad key"}}`, nil)
+
+	c := newClient(t, Options{Providers: []ProviderConfig{{Name: "a", BaseURL: a.URL}, {Name: "b", BaseURL: b.URL}}})
+	_, err := c.Chat(context.Background(), ChatRequest{Model: "m", Messages: userMessage("Hi")})
+	if !errors.Is(err, providererr.ErrRateLimited) || !errors.Is(err, providererr.ErrInvalidKey) {
+		t.Errorf("err = %v, want both provider errors", err)
+	}
+}
+
+func TestClient_ContentFilterDoesNotFailOver(t *testing.T) {
+	filtered := failingServer(t, http.StatusBadRequest, `{"error":{"code":"content_filter","message":"blocked by content policy"}}`, nil)
+	var backupCalls atomic.Int32
+	backup := openAIServer(t, "unused", &backupCalls)
+
+	c := newClient(t, Options{Providers: []ProviderConfig{{Name: "a", BaseURL: filtered.URL}, {Name: "b", BaseURL: backup.URL}}})
+	_, err := c.Chat(context.Background(), ChatRequest{Model: "m", Messages: userMessage("Hi")})
+	if !errors.Is(err, providererr.ErrContentFiltered) {
+		t.Errorf("err = %v, want ErrContentFiltered", err)
+	}
+	if backupCalls.Load() != 0 {
+		t.Error("filtered request was retried on another provider")
+	}
+}
+
+func TestClient_RoutesByModel(t *testing.T) {
+	c := newClient(t, Options{Providers: []ProviderConfig{
+		{Name: "a", BaseURL: openAIServer(t, "from a", nil).URL, Models: []string{"model-a"}},
+		{Name: "b", BaseURL: openAIServer(t, "from b", nil).URL, Models: []string{"model-b"}},
+	}})
+
+	resp, err := c.Chat(context.Background(), ChatRequest{Model: "model-b", Messages: userMessage("Hi")})
+	if err != nil || resp.Provider != "b" {
+		t.Errorf("Chat(model-b) = %+v, %v; want provider b", resp, err)
+	}
+	if _, err := c.Chat(context.Background(), ChatRequest{Model: "model-c", Messages: userMessage("Hi")}); !errors.Is(err, ErrNoProvider) {
+		t.Errorf("err = %v, want ErrNoProvider", err)
+	}
+}
+
+func TestClient_Strategies(t *testing.T) {
+	providers := func() []ProviderConfig {
+		return []ProviderConfig{
+			{Name: "pricey", BaseURL: openAIServer(t, "", nil).URL, InputCostPer1M: 10, OutputCostPer1M: 30},
+			{Name: "cheap", BaseURL: openAIServer(t, "", nil).URL, InputCostPer1M: 0.1, OutputCostPer1M: 0.4},
+		}
+	}
+
+	c := newClient(t, Options{Providers: providers(), Strategy: router.StrategyCheapest})
+	resp, err := c.Chat(context.Background(), ChatRequest{Model: "m", Messages: userMessage("Hi")})
+	if err != nil || resp.Provider != "cheap" {
+		t.Errorf("cheapest routed to %+v, %v", resp, err)
+	}
+
+	c = newClient(t, Options{Providers: providers(), Strategy: router.StrategyRoundRobin})
+	var got []string
+	for i := 0; i < 4; i++ {
+		resp, err := c.Chat(context.Background(), ChatRequest{Model: "m", Messages: userMessage("Hi")})
+		if err != nil {
+			t.Fatalf("Chat: %v", err)
+		}
+		got = append(got, resp.Provider)
+	}
+	if strings.Join(got, ",") != "pricey,cheap,pricey,cheap" {
+		t.Errorf("round robin order = %v", got)
+	}
+}
+
+func TestClient_CheapestPricesTheRequest(t *testing.T) {
+	c := newClient(t, Options{
+		Providers: []ProviderConfig{
+			// Cheap to read, expensive to write, with a discounted small model
+			{Name: "bulk", BaseURL: openAIServer(t, "", nil).URL, InputCostPer1M: 0.1, OutputCostPer1M: 20,
+				Pricing: map[string]ModelPricing{"small": {InputCostPer1M: 0.05, OutputCostPer1M: 0.1}}},
+			{Name: "even", BaseURL: openAIServer(t, "", nil).URL, InputCostPer1M: 3, OutputCostPer1M: 3},
+		},
+		Strategy: router.StrategyCheapest,
+	})
+	chat := func(req ChatRequest) (*ChatResponse, error) {
+		return c.Chat(context.Background(), req)
+	}
+	longPrompt := userMessage(strings.Repeat("context ", 5000))
+
+	// A long prompt with a short answer favours cheap input
+	if resp, err := chat(ChatRequest{Model: "m", Messages: longPrompt, MaxTokens: 10}); err != nil || resp.Provider != "bulk" {
+		t.Errorf("long prompt routed to %+v, %v", resp, err)
+	}
+	// A short prompt with the expected answer length favours cheap output
+	if resp, err := chat(ChatRequest{Model: "m", Messages: userMessage("Hi")}); err != nil || resp.Provider != "even" {
+		t.Errorf("short prompt routed to %+v, %v", resp, err)
+	}
+	// Per-model prices override the provider's
+	resp, err := chat(ChatRequest{Model: "small", Messages: userMessage("Hi")})
+	if err != nil || resp.Provider != "bulk" {
+		t.Fatalf("small model routed to %+v, %v", resp, err)
+	}
+	if want := (1000*0.05 + 500*0.1) / 1_000_000; resp.Cost != want {
+		t.Errorf("Cost = %v, want %v", resp.Cost, want)
+	}
+}
+
+func TestClient_MaxCost(t *testing.T) {
+	var bulkCalls atomic.Int32
+	c := newClient(t, Options{
+		Providers: []ProviderConfig{
+			{Name: "bulk", BaseURL: openAIServer(t, "", &bulkCalls).URL, InputCostPer1M: 0.1, OutputCostPer1M: 20},
+			{Name: "even", BaseURL: openAIServer(t, "", nil).URL, InputCostPer1M: 3, OutputCostPer1M: 3},
+		},
+	})
+
+	// bulk could cost $0.02 for 1000 output tokens, even $0.003
+	resp, err := c.Chat(context.Background(), ChatRequest{Model: "m", Messages: userMessage("Hi"), MaxTokens: 1000, MaxCost: 0.01})
+	if err != nil || resp.Provider != "even" || bulkCalls.Load() != 0 {
+		t.Errorf("routed to %+v, %v (bulk calls %d)", resp, err, bulkCalls.Load())
+	}
+
+	_, err = c.Chat(context.Background(), ChatRequest{Model: "m", Messages: userMessage("Hi"), MaxTokens: 1000, MaxCost: 0.0001})
+	if !errors.Is(err, ErrOverBudget) {
+		t.Errorf("err = %v, want ErrOverBudget", err)
+	}
+}
+
+func TestClient_FailingProviderCoolsDown(t *testing.T) {
+	var downCalls atomic.Int32
+	down := failingServer(t, http.StatusInternalServerError, `{}`, &downCalls)
+	up := openAIServer(t, "ok", nil)
+
+	c := newClient(t, Options{
+		Providers:      []ProviderConfig{{Name: "down", BaseURL: down.URL}, {Name: "up", BaseURL: up.URL}},
+		UnhealthyAfter: 2,
+	})
+	for i := 0; i < 4; i++ {
+		if _, err := c.Chat(context.Background(), ChatRequest{Model: "m", Messages: userMessage("Hi")}); err != nil {
+			t.Fatalf("Chat: %v", err)
+		}
+	}
+	if downCalls.Load() != 2 {
+		t.Errorf("failing provider called %d times, want 2 before cooling down", downCalls.Load())
+	}
+}
+
+func TestClient_RetriesWithinProvider(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	c := newClient(t, Options{
+		Providers:      []ProviderConfig{{Name: "flaky", BaseURL: srv.URL}},
+		MaxAttempts:    2,
+		RetryBaseDelay: time.Millisecond,
+	})
+	resp, err := c.Chat(context.Background(), ChatRequest{Model: "m", Messages: userMessage("Hi")})
+	if err != nil || resp.Content != "ok" {
+		t.Fatalf("Chat = %+v, %v", resp, err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+}
+
+func TestClient_RetryBudgets(t *testing.T) {
+	srv := failingServer(t, http.StatusServiceUnavailable, "", nil)
+
+	if budgets := newClient(t, Options{Providers: []ProviderConfig{{Name: "down", BaseURL: srv.URL}}}).RetryBudgets(); budgets != nil {
+		t.Errorf("unbudgeted client reported %+v", budgets)
+	}
+
+	c := newClient(t, Options{
+		Providers:        []ProviderConfig{{Name: "down", BaseURL: srv.URL}},
+		MaxAttempts:      3,
+		RetryBaseDelay:   time.Millisecond,
+		RetriesPerMinute: 1,
+	})
+	c.Chat(context.Background(), ChatRequest{Model: "m", Messages: userMessage("Hi")})
+	budgets := c.RetryBudgets()
+	if len(budgets) != 1 || budgets[0].Provider != "down" || budgets[0].Remaining >= 1 || budgets[0].Allowed != 1 || budgets[0].Denied != 1 {
+		t.Errorf("RetryBudgets = %+v, want one spent retry and one denied for down", budgets)
+	}
+}
+
+func TestClient_RateLimitWaits(t *testing.T) {
+	c := newClient(t, Options{Providers: []ProviderConfig{{Name: "a", BaseURL: openAIServer(t, "ok", nil).URL, RequestsPerMinute: 1}}})
+	if _, err := c.Chat(context.Background(), ChatRequest{Model: "m", Messages: userMessage("Hi")}); err != nil {
+		t.Fatalf("first Chat: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.Chat(ctx, ChatRequest{Model: "m", Messages: userMessage("Hi")}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the call to wait for capacity until the deadline", err)
+	}
+}
+
+func TestClient_ChatStream(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    Format
+		events    string
+		wantUsage Usage
+	}{
+		{
+			name:   "openai",
+			format: FormatOpenAI,
+			events: "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n" +
+				"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":7,\"completion_tokens\":2}}\n\n" +
+				"data: [DONE]\n\n",
+			wantUsage: Usage{InputTokens: 7, OutputTokens: 2},
+		},
+		{
+			name:   "anthropic",
+			format: FormatAnthropic,
+			events: "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":7,\"output_tokens\":1}}}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"lo\"}}\n\n" +
+				"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":2}}\n\n" +
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n",
+			wantUsage: Usage{InputTokens: 7, OutputTokens: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&body)
+				if body["stream"] != true {
+					t.Error("stream not requested")
+				}
+				w.Header().Set("Content-Type", "text/event-stream")
+				io.WriteString(w, tt.events)
+			}))
+			defer srv.Close()
+
+			c := newClient(t, Options{Providers: []ProviderConfig{{Name: tt.name, Format: tt.format, BaseURL: srv.URL, InputCostPer1M: 1, OutputCostPer1M: 1}}})
+			s, err := c.ChatStream(context.Background(), ChatRequest{Model: "m", Messages: userMessage("Hi")})
+			if err != nil {
+				t.Fatalf("ChatStream: %v", err)
+			}
+			defer s.Close()
+
+			var text strings.Builder
+			for chunk := range s.Chunks() {
+				text.WriteString(chunk.Data)
+			}
+			if text.String() != "Hello" {
+				t.Errorf("text = %q, want Hello", text.String())
+			}
+			stats := c.Usage()[0]
+			if stats.InputTokens != int64(tt.wantUsage.InputTokens) || stats.OutputTokens != int64(tt.wantUsage.OutputTokens) || stats.Cost == 0 {
+				t.Errorf("usage = %+v, want %+v", stats, tt.wantUsage)
+			}
+		})
+	}
+}
+
+func TestClient_ChatStreamFailsOverBeforeStart(t *testing.T) {
+	down := failingServer(t, http.StatusServiceUnavailable, `{}`, nil)
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer up.Close()
+
+	c := newClient(t, Options{Providers: []ProviderConfig{{Name: "down", BaseURL: down.URL}, {Name: "up", BaseURL: up.URL}}})
+	s, err := c.ChatStream(context.Background(), ChatRequest{Model: "m", Messages: userMessage("Hi")})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	text, err := s.Collect()
+	if err != nil || text != "ok" {
+		t.Errorf("Collect = %q, %v", text, err)
+	}
+}
+
+func TestClient_Embed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			t.Errorf("path = %s, want /embeddings", r.URL.Path)
+		}
+		io.WriteString(w, `{"model":"embed","data":[{"index":1,"embedding":[0.3,0.4]},{"index":0,"embedding":[0.1,0.2]}],"usage":{"prompt_tokens":4}}`)
+	}))
+	defer srv.Close()
+
+	var anthropicCalls atomic.Int32
+	anthropic := failingServer(t, http.StatusNotFound, `{}`, &anthropicCalls)
+
+	c := newClient(t, Options{Providers: []ProviderConfig{
+		{Name: "anthropic", Format: FormatAnthropic, BaseURL: anthropic.URL},
+		{Name: "openai", BaseURL: srv.URL, InputCostPer1M: 0.02},
+	}})
+	resp, err := c.Embed(context.Background(), EmbedRequest{Model: "embed", Input: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if resp.Provider != "openai" || len(resp.Embeddings) != 2 || resp.Embeddings[0][0] != 0.1 || resp.Embeddings[1][0] != 0.3 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if resp.Usage.InputTokens != 4 || resp.Cost == 0 {
+		t.Errorf("usage not tracked: %+v", resp)
+	}
+	if anthropicCalls.Load() != 0 {
+		t.Error("embeddings sent to an Anthropic-format provider")
+	}
+}
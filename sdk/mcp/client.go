@@ -0,0 +1,267 @@
+// Package mcp is a Model Context Protocol client. It connects to MCP
+// servers over stdio or streamable HTTP, lists and calls their tools and
+// reads their resources. A Manager exposes the tools and resources of
+// registered servers to models as agent.Tools, so they run through the
+// same tools.Runtime, and are recorded in tool_executions, like any other
+// tool.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ProtocolVersion is the MCP revision the client speaks
+const ProtocolVersion = "2025-06-18"
+
+// ErrClosed is returned for calls on a closed or broken connection
+var ErrClosed = errors.New("mcp: connection closed")
+
+// rpcMessage is a JSON-RPC 2.0 request, notification or response
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  interface{}     `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is an error returned by an MCP server
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+// transport carries JSON-RPC messages to one server
+type transport interface {
+	// call sends a request and waits for the response with its ID
+	call(ctx context.Context, msg *rpcMessage) (*rpcMessage, error)
+	// notify sends a message that gets no response
+	notify(ctx context.Context, msg *rpcMessage) error
+	close() error
+}
+
+// Tool is a tool an MCP server offers
+type Tool struct {
+	Name        string                 `json:"name"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// Resource is a piece of context an MCP server offers
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// Content is one block of a tool result or resource
+type Content struct {
+	Type     string `json:"type"` // text, image, audio, resource or resource_link
+	Text     string `json:"text,omitempty"`
+	Data     string `json:"data,omitempty"` // base64, for images and audio
+	MimeType string `json:"mimeType,omitempty"`
+	URI      string `json:"uri,omitempty"`
+	Blob     string `json:"blob,omitempty"` // base64 resource contents
+}
+
+// CallResult is the result of a tool call. IsError reports a failure of
+// the tool itself, described in Content.
+type CallResult struct {
+	Content           []Content              `json:"content"`
+	StructuredContent map[string]interface{} `json:"structuredContent,omitempty"`
+	IsError           bool                   `json:"isError,omitempty"`
+}
+
+// Text joins the text blocks of the result
+func (r *CallResult) Text() string {
+	return joinText(r.Content)
+}
+
+// ServerInfo identifies a connected server
+type ServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Client is a connection to one MCP server
+type Client struct {
+	t      transport
+	nextID atomic.Int64
+
+	mu           sync.Mutex
+	server       ServerInfo
+	capabilities map[string]interface{}
+	protocol     string
+}
+
+// clientInfo identifies modelscan to servers
+var clientInfo = map[string]string{"name": "modelscan", "version": "1.0.0"}
+
+// connect runs the initialize handshake over t
+func connect(ctx context.Context, t transport) (*Client, error) {
+	c := &Client{t: t}
+	var init struct {
+		ProtocolVersion string                 `json:"protocolVersion"`
+		Capabilities    map[string]interface{} `json:"capabilities"`
+		ServerInfo      ServerInfo             `json:"serverInfo"`
+	}
+	err := c.request(ctx, "initialize", map[string]interface{}{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      clientInfo,
+	}, &init)
+	if err != nil {
+		_ = t.close()
+		return nil, fmt.Errorf("mcp: initialize failed: %w", err)
+	}
+	c.server, c.capabilities, c.protocol = init.ServerInfo, init.Capabilities, init.ProtocolVersion
+	if ht, ok := t.(*httpTransport); ok {
+		ht.setProtocol(init.ProtocolVersion)
+	}
+
+	if err := t.notify(ctx, &rpcMessage{JSONRPC: "2.0", Method: "notifications/initialized"}); err != nil {
+		_ = t.close()
+		return nil, fmt.Errorf("mcp: initialized notification failed: %w", err)
+	}
+	return c, nil
+}
+
+// Server returns the server's name and version
+func (c *Client) Server() ServerInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.server
+}
+
+// Supports reports whether the server declared a capability, such as
+// "tools" or "resources"
+func (c *Client) Supports(capability string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.capabilities[capability]
+	return ok
+}
+
+// request sends a request and decodes its result into out
+func (c *Client) request(ctx context.Context, method string, params, out interface{}) error {
+	id := c.nextID.Add(1)
+	resp, err := c.t.call(ctx, &rpcMessage{JSONRPC: "2.0", ID: &id, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, out); err != nil {
+		return fmt.Errorf("mcp: invalid %s result: %w", method, err)
+	}
+	return nil
+}
+
+// ListTools returns every tool the server offers, following pagination
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var all []Tool
+	cursor := ""
+	for {
+		var page struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := c.request(ctx, "tools/list", cursorParams(cursor), &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return all, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool runs a tool on the server
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]interface{}) (*CallResult, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	var result CallResult
+	if err := c.request(ctx, "tools/call", map[string]interface{}{"name": name, "arguments": args}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListResources returns every resource the server offers, following
+// pagination
+func (c *Client) ListResources(ctx context.Context) ([]Resource, error) {
+	var all []Resource
+	cursor := ""
+	for {
+		var page struct {
+			Resources  []Resource `json:"resources"`
+			NextCursor string     `json:"nextCursor"`
+		}
+		if err := c.request(ctx, "resources/list", cursorParams(cursor), &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Resources...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return all, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// ReadResource returns the contents of a resource
+func (c *Client) ReadResource(ctx context.Context, uri string) ([]Content, error) {
+	var result struct {
+		Contents []Content `json:"contents"`
+	}
+	if err := c.request(ctx, "resources/read", map[string]string{"uri": uri}, &result); err != nil {
+		return nil, err
+	}
+	return result.Contents, nil
+}
+
+// Ping checks that the server is responsive
+func (c *Client) Ping(ctx context.Context) error {
+	return c.request(ctx, "ping", nil, nil)
+}
+
+// Close ends the connection and, for stdio servers, the server process
+func (c *Client) Close() error {
+	return c.t.close()
+}
+
+func cursorParams(cursor string) interface{} {
+	if cursor == "" {
+		return nil
+	}
+	return map[string]string{"cursor": cursor}
+}
+
+func joinText(content []Content) string {
+	var texts []string
+	for _, c := range content {
+		if c.Text != "" {
+			texts = append(texts, c.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
@@ -0,0 +1,390 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/agent"
+)
+
+// DefaultTimeout bounds HTTP requests to a server
+const DefaultTimeout = 60 * time.Second
+
+// ServerConfig registers an MCP server. Set Command for a server run as a
+// subprocess over stdio, or URL for a streamable HTTP server.
+type ServerConfig struct {
+	Name    string   // Prefix of the server's tool names, e.g. "github"
+	Command string   // stdio: program to run
+	Args    []string // stdio: its arguments
+	Env     []string // stdio: KEY=value; nil inherits the environment
+	Dir     string   // stdio: working directory
+	URL     string   // HTTP: endpoint
+	Headers map[string]string
+	Timeout time.Duration // HTTP: per request; zero uses DefaultTimeout
+}
+
+// Dial connects to a server and completes the initialize handshake
+func Dial(ctx context.Context, cfg ServerConfig) (*Client, error) {
+	switch {
+	case cfg.Command != "" && cfg.URL != "":
+		return nil, fmt.Errorf("mcp server %s: set command or url, not both", cfg.Name)
+	case cfg.Command != "":
+		t, err := startStdio(cfg.Command, cfg.Args, cfg.Env, cfg.Dir)
+		if err != nil {
+			return nil, err
+		}
+		return connect(ctx, t)
+	case cfg.URL != "":
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = DefaultTimeout
+		}
+		return connect(ctx, newHTTPTransport(cfg.URL, cfg.Headers, timeout))
+	default:
+		return nil, fmt.Errorf("mcp server %s: command or url is required", cfg.Name)
+	}
+}
+
+// validName is what providers accept as a function name
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// unsafeChars are replaced in tool names
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// ToolName is the name a server's tool is registered under:
+// "<server>__<tool>", limited to what providers accept
+func ToolName(server, tool string) string {
+	name := unsafeChars.ReplaceAllString(server+"__"+tool, "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// ServerStatus describes a registered server
+type ServerStatus struct {
+	Name      string     `json:"name"`
+	Connected bool       `json:"connected"`
+	Server    ServerInfo `json:"server"`
+	Tools     []string   `json:"tools"` // Registered names
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// Manager keeps connections to registered MCP servers and exposes their
+// tools, and a tool to read their resources, in a tool registry. Broken
+// connections are re-established on the next call.
+type Manager struct {
+	registry *agent.ToolRegistry
+
+	mu      sync.Mutex
+	servers map[string]*server
+}
+
+// server is one registered server and its connection
+type server struct {
+	cfg ServerConfig
+
+	mu      sync.Mutex
+	client  *Client
+	info    ServerInfo
+	tools   []string
+	lastErr error
+}
+
+// NewManager creates a manager that registers tools in registry
+func NewManager(registry *agent.ToolRegistry) *Manager {
+	return &Manager{registry: registry, servers: make(map[string]*server)}
+}
+
+// Add connects to a server and registers its tools. Resources, when the
+// server has any, are readable through "<server>__read_resource".
+func (m *Manager) Add(ctx context.Context, cfg ServerConfig) error {
+	if !validName.MatchString(cfg.Name) || strings.Contains(cfg.Name, "__") {
+		return fmt.Errorf("mcp server name %q must be letters, digits, _ or - without __", cfg.Name)
+	}
+	m.mu.Lock()
+	if _, exists := m.servers[cfg.Name]; exists {
+		m.mu.Unlock()
+		return fmt.Errorf("mcp server %s already registered", cfg.Name)
+	}
+	s := &server{cfg: cfg}
+	m.servers[cfg.Name] = s
+	m.mu.Unlock()
+
+	if err := m.register(ctx, s); err != nil {
+		m.mu.Lock()
+		delete(m.servers, cfg.Name)
+		m.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// register connects and adds the server's tools to the registry
+func (m *Manager) register(ctx context.Context, s *server) error {
+	client, err := s.connection(ctx)
+	if err != nil {
+		return err
+	}
+
+	var tools []agent.Tool
+	if client.Supports("tools") {
+		list, err := client.ListTools(ctx)
+		if err != nil {
+			return fmt.Errorf("mcp server %s: failed to list tools: %w", s.cfg.Name, err)
+		}
+		for _, t := range list {
+			tools = append(tools, &remoteTool{server: s, tool: t, name: ToolName(s.cfg.Name, t.Name)})
+		}
+	}
+	if client.Supports("resources") {
+		resources, err := client.ListResources(ctx)
+		if err != nil {
+			return fmt.Errorf("mcp server %s: failed to list resources: %w", s.cfg.Name, err)
+		}
+		if len(resources) > 0 {
+			tools = append(tools, &resourceTool{server: s, resources: resources, name: ToolName(s.cfg.Name, "read_resource")})
+		}
+	}
+
+	var names []string
+	for _, t := range tools {
+		if err := m.registry.Register(t); err != nil {
+			for _, name := range names {
+				m.registry.Unregister(name)
+			}
+			return fmt.Errorf("mcp server %s: %w", s.cfg.Name, err)
+		}
+		names = append(names, t.Name())
+	}
+	s.mu.Lock()
+	s.tools = names
+	s.mu.Unlock()
+	return nil
+}
+
+// Remove unregisters a server's tools and closes its connection
+func (m *Manager) Remove(name string) error {
+	m.mu.Lock()
+	s, ok := m.servers[name]
+	delete(m.servers, name)
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("mcp server %s not registered", name)
+	}
+	return m.shutdown(s)
+}
+
+func (m *Manager) shutdown(s *server) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, name := range s.tools {
+		m.registry.Unregister(name)
+	}
+	s.tools = nil
+	if s.client == nil {
+		return nil
+	}
+	err := s.client.Close()
+	s.client = nil
+	return err
+}
+
+// Close removes every server
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	servers := m.servers
+	m.servers = make(map[string]*server)
+	m.mu.Unlock()
+
+	var errs []error
+	for _, s := range servers {
+		if err := m.shutdown(s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Servers reports the registered servers, by name
+func (m *Manager) Servers() []ServerStatus {
+	m.mu.Lock()
+	servers := make([]*server, 0, len(m.servers))
+	for _, s := range m.servers {
+		servers = append(servers, s)
+	}
+	m.mu.Unlock()
+
+	statuses := make([]ServerStatus, 0, len(servers))
+	for _, s := range servers {
+		s.mu.Lock()
+		st := ServerStatus{Name: s.cfg.Name, Connected: s.client != nil, Server: s.info, Tools: append([]string(nil), s.tools...)}
+		if s.lastErr != nil {
+			st.LastError = s.lastErr.Error()
+		}
+		s.mu.Unlock()
+		statuses = append(statuses, st)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// connection returns the server's client, connecting if there is none
+func (s *server) connection(ctx context.Context) (*Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil {
+		return s.client, nil
+	}
+	client, err := Dial(ctx, s.cfg)
+	if err != nil {
+		s.lastErr = err
+		return nil, err
+	}
+	s.client, s.info, s.lastErr = client, client.Server(), nil
+	return client, nil
+}
+
+// failed drops a broken connection so the next call reconnects. Calls are
+// not retried, since the server may have acted on them.
+func (s *server) failed(client *Client, err error) {
+	if !errors.Is(err, ErrClosed) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = err
+	if s.client == client {
+		_ = client.Close()
+		s.client = nil
+	}
+}
+
+// remoteTool is an MCP server's tool as an agent.Tool
+type remoteTool struct {
+	server *server
+	tool   Tool
+	name   string
+}
+
+func (t *remoteTool) Name() string { return t.name }
+func (t *remoteTool) Type() string { return "mcp" }
+
+func (t *remoteTool) Description() string {
+	if t.tool.Description != "" {
+		return t.tool.Description
+	}
+	return t.tool.Title
+}
+
+// InputSchema implements agent.ToolWithSchema
+func (t *remoteTool) InputSchema() map[string]interface{} {
+	if t.tool.InputSchema == nil {
+		return map[string]interface{}{"type": "object"}
+	}
+	return t.tool.InputSchema
+}
+
+// OutputSchema implements agent.ToolWithSchema
+func (t *remoteTool) OutputSchema() map[string]interface{} { return nil }
+
+// Execute calls the tool on the server. A result flagged isError fails
+// the run with the server's message.
+func (t *remoteTool) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	client, err := t.server.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.CallTool(ctx, t.tool.Name, input)
+	if err != nil {
+		t.server.failed(client, err)
+		return nil, err
+	}
+	if result.IsError {
+		return nil, &agent.ToolError{Message: result.Text()}
+	}
+	return contentOutput(result.Content, result.StructuredContent), nil
+}
+
+// resourceTool lets the model read a server's resources
+type resourceTool struct {
+	server    *server
+	resources []Resource
+	name      string
+}
+
+func (t *resourceTool) Name() string { return t.name }
+func (t *resourceTool) Type() string { return "mcp" }
+
+func (t *resourceTool) Description() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Reads a resource from the %s MCP server. Available resources:", t.server.cfg.Name)
+	for _, r := range t.resources {
+		fmt.Fprintf(&b, "\n- %s: %s", r.URI, r.Name)
+		if r.Description != "" {
+			fmt.Fprintf(&b, " (%s)", r.Description)
+		}
+	}
+	return b.String()
+}
+
+// InputSchema implements agent.ToolWithSchema
+func (t *resourceTool) InputSchema() map[string]interface{} {
+	uris := make([]interface{}, len(t.resources))
+	for i, r := range t.resources {
+		uris[i] = r.URI
+	}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"uri": map[string]interface{}{"type": "string", "enum": uris},
+		},
+		"required": []interface{}{"uri"},
+	}
+}
+
+// OutputSchema implements agent.ToolWithSchema
+func (t *resourceTool) OutputSchema() map[string]interface{} { return nil }
+
+// Execute reads the requested resource
+func (t *resourceTool) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	uri, _ := input["uri"].(string)
+	if uri == "" {
+		return nil, &agent.ToolError{Message: "missing 'uri' parameter"}
+	}
+	client, err := t.server.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := client.ReadResource(ctx, uri)
+	if err != nil {
+		t.server.failed(client, err)
+		return nil, err
+	}
+	return contentOutput(contents, nil), nil
+}
+
+// contentOutput turns content blocks into tool output: the text, and a
+// note of binary blocks the model cannot see
+func contentOutput(content []Content, structured map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{"content": joinText(content)}
+	var binary []string
+	for _, c := range content {
+		if c.Text == "" && (c.Data != "" || c.Blob != "") {
+			binary = append(binary, c.MimeType)
+		}
+	}
+	if len(binary) > 0 {
+		out["omitted_binary"] = binary
+	}
+	if structured != nil {
+		out["structured"] = structured
+	}
+	return out
+}
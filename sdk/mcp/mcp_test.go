@@ -0,0 +1,237 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/agent"
+	"github.com/jeffersonwarrior/modelscan/sdk/storage"
+	"github.com/jeffersonwarrior/modelscan/sdk/tools"
+)
+
+// TestMain lets the test binary act as a stdio MCP server
+func TestMain(m *testing.M) {
+	if os.Getenv("MCP_FAKE_SERVER") == "1" {
+		serveStdio()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeServer answers MCP requests for a weather server
+func fakeServer(msg rpcMessage) interface{} {
+	var params struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+		Cursor    string                 `json:"cursor"`
+		URI       string                 `json:"uri"`
+	}
+	data, _ := json.Marshal(msg.Params)
+	json.Unmarshal(data, &params)
+
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}, "resources": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "weather", "version": "0.1.0"},
+		}
+	case "tools/list":
+		// Two pages
+		if params.Cursor == "" {
+			return map[string]interface{}{
+				"tools":      []Tool{{Name: "forecast", Description: "Forecast for a city", InputSchema: map[string]interface{}{"type": "object", "required": []string{"city"}}}},
+				"nextCursor": "page2",
+			}
+		}
+		return map[string]interface{}{"tools": []Tool{{Name: "alerts.list", Title: "Weather alerts"}}}
+	case "tools/call":
+		if params.Name == "forecast" {
+			city, _ := params.Arguments["city"].(string)
+			if city == "" {
+				return CallResult{IsError: true, Content: []Content{{Type: "text", Text: "unknown city"}}}
+			}
+			return CallResult{Content: []Content{{Type: "text", Text: "Rain in " + city}}, StructuredContent: map[string]interface{}{"temp": 4}}
+		}
+		return CallResult{Content: []Content{{Type: "text", Text: "no alerts"}}}
+	case "resources/list":
+		return map[string]interface{}{"resources": []Resource{{URI: "weather://stations", Name: "Stations"}}}
+	case "resources/read":
+		return map[string]interface{}{"contents": []Content{{URI: params.URI, Text: "OSL, BGO"}}}
+	case "ping":
+		return map[string]interface{}{}
+	}
+	return nil
+}
+
+func serveStdio() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var msg rpcMessage
+		if json.Unmarshal(scanner.Bytes(), &msg) != nil || msg.ID == nil {
+			continue
+		}
+		result, _ := json.Marshal(fakeServer(msg))
+		out, _ := json.Marshal(rpcMessage{JSONRPC: "2.0", ID: msg.ID, Result: result})
+		fmt.Println(string(out))
+	}
+}
+
+// httpServer serves the fake over streamable HTTP, answering tools/call
+// over SSE. Deleting sessions makes later requests fail with 404.
+type httpServer struct {
+	mu       sync.Mutex
+	sessions map[string]bool
+	next     int
+}
+
+func (s *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session := r.Header.Get("Mcp-Session-Id")
+	if r.Method == http.MethodDelete {
+		delete(s.sessions, session)
+		return
+	}
+
+	var msg rpcMessage
+	json.NewDecoder(r.Body).Decode(&msg)
+	if msg.Method == "initialize" {
+		s.next++
+		session = fmt.Sprintf("session-%d", s.next)
+		s.sessions[session] = true
+		w.Header().Set("Mcp-Session-Id", session)
+	} else if !s.sessions[session] {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if msg.ID == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	result, _ := json.Marshal(fakeServer(msg))
+	out, _ := json.Marshal(rpcMessage{JSONRPC: "2.0", ID: msg.ID, Result: result})
+	if msg.Method == "tools/call" {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\",\"params\":{}}\n\n")
+		fmt.Fprintf(w, "event: message\ndata: %s\n\n", out)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
+func (s *httpServer) dropSessions() {
+	s.mu.Lock()
+	s.sessions = map[string]bool{}
+	s.mu.Unlock()
+}
+
+func TestClient_Stdio(t *testing.T) {
+	client, err := Dial(context.Background(), ServerConfig{
+		Name:    "weather",
+		Command: os.Args[0],
+		Env:     append(os.Environ(), "MCP_FAKE_SERVER=1"),
+	})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+
+	if info := client.Server(); info.Name != "weather" || !client.Supports("tools") {
+		t.Errorf("unexpected server %+v", info)
+	}
+	list, err := client.ListTools(context.Background())
+	if err != nil || len(list) != 2 || list[1].Name != "alerts.list" {
+		t.Fatalf("ListTools = %+v, %v", list, err)
+	}
+	result, err := client.CallTool(context.Background(), "forecast", map[string]interface{}{"city": "Oslo"})
+	if err != nil || result.Text() != "Rain in Oslo" {
+		t.Errorf("CallTool = %+v, %v", result, err)
+	}
+	if err := client.Ping(context.Background()); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+}
+
+func TestManager(t *testing.T) {
+	fake := &httpServer{sessions: map[string]bool{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	adb, err := storage.NewAgentDB(filepath.Join(t.TempDir(), "agents.db"))
+	if err != nil {
+		t.Fatalf("NewAgentDB: %v", err)
+	}
+	defer adb.Close()
+	repo := storage.NewToolExecutionRepository(adb.GetDB())
+
+	registry := agent.NewToolRegistry()
+	manager := NewManager(registry)
+	defer manager.Close()
+	if err := manager.Add(context.Background(), ServerConfig{Name: "weather", URL: srv.URL}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := manager.Add(context.Background(), ServerConfig{Name: "weather", URL: srv.URL}); err == nil {
+		t.Error("expected duplicate servers to be rejected")
+	}
+
+	rt := tools.NewRuntime(registry, tools.Options{Recorder: tools.NewStorageRecorder(repo)})
+	var names []string
+	for _, d := range rt.Definitions() {
+		names = append(names, d.Name)
+	}
+	if got := strings.Join(names, ","); got != "weather__alerts_list,weather__forecast,weather__read_resource" {
+		t.Fatalf("registered tools = %s", got)
+	}
+
+	ctx := tools.WithTask(context.Background(), "task-1", "")
+	results := rt.RunAll(ctx, []tools.Call{
+		{ID: "1", Name: "weather__forecast", Arguments: `{"city": "Oslo"}`},
+		{ID: "2", Name: "weather__forecast", Arguments: `{}`},
+		{ID: "3", Name: "weather__read_resource", Arguments: `{"uri": "weather://stations"}`},
+	})
+	if results[0].Content() != `{"content":"Rain in Oslo","structured":{"temp":4}}` {
+		t.Errorf("forecast = %s", results[0].Content())
+	}
+	if !strings.Contains(results[1].Error, "unknown city") {
+		t.Errorf("expected the tool error, got %+v", results[1])
+	}
+	if results[2].Output["content"] != "OSL, BGO" {
+		t.Errorf("read_resource = %+v", results[2])
+	}
+
+	execs, err := repo.ListByTask(context.Background(), "task-1", 10, 0)
+	if err != nil || len(execs) != 3 || execs[0].ToolType != "mcp" {
+		t.Fatalf("expected 3 mcp executions, got %d (%v)", len(execs), err)
+	}
+
+	// A lost session fails one call, then the manager reconnects
+	fake.dropSessions()
+	if r := rt.Run(ctx, tools.Call{Name: "weather__forecast", Arguments: `{"city": "Oslo"}`}); !strings.Contains(r.Error, "session expired") {
+		t.Errorf("expected the lost session to surface, got %+v", r)
+	}
+	if st := manager.Servers(); st[0].Connected || st[0].LastError == "" {
+		t.Errorf("expected a disconnected server, got %+v", st)
+	}
+	if r := rt.Run(ctx, tools.Call{Name: "weather__forecast", Arguments: `{"city": "Bergen"}`}); r.Error != "" {
+		t.Errorf("expected a reconnect, got %+v", r)
+	}
+
+	if err := manager.Remove("weather"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if len(registry.List()) != 0 {
+		t.Errorf("tools left registered: %v", registry.List())
+	}
+}
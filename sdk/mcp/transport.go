@@ -0,0 +1,379 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxMessageBytes bounds one JSON-RPC message from a server
+const maxMessageBytes = 16 << 20
+
+// ====== stdio ======
+
+// stdioTransport talks to a server process over its stdin and stdout,
+// one JSON message per line
+type stdioTransport struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stderr  *tailBuffer
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[int64]chan *rpcMessage
+	err     error // why the connection ended
+	done    chan struct{}
+	exited  chan struct{}
+}
+
+// startStdio launches a server process
+func startStdio(command string, args, env []string, dir string) (*stdioTransport, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+	if env != nil {
+		cmd.Env = env
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	t := &stdioTransport{
+		cmd:     cmd,
+		stdin:   stdin,
+		stderr:  &tailBuffer{max: 4096},
+		pending: make(map[int64]chan *rpcMessage),
+		done:    make(chan struct{}),
+		exited:  make(chan struct{}),
+	}
+	cmd.Stderr = t.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("mcp: failed to start %s: %w", command, err)
+	}
+	go t.read(stdout)
+	go func() {
+		_ = cmd.Wait()
+		close(t.exited)
+	}()
+	return t, nil
+}
+
+// read delivers responses to their callers and answers server requests
+// until the server's stdout closes
+func (t *stdioTransport) read(stdout io.Reader) {
+	reader := bufio.NewReaderSize(stdout, 64*1024)
+	var readErr error
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			readErr = err
+			break
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var msg rpcMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			continue // Servers may log non-JSON lines; ignore them
+		}
+		switch {
+		case msg.ID != nil && msg.Method != "":
+			t.answer(&msg)
+		case msg.ID != nil:
+			t.mu.Lock()
+			ch := t.pending[*msg.ID]
+			delete(t.pending, *msg.ID)
+			t.mu.Unlock()
+			if ch != nil {
+				ch <- &msg
+			}
+		}
+		// Notifications need no handling
+	}
+
+	t.mu.Lock()
+	t.err = fmt.Errorf("%w: %v", ErrClosed, readErr)
+	if stderr := t.stderr.String(); stderr != "" {
+		t.err = fmt.Errorf("%w (stderr: %s)", t.err, stderr)
+	}
+	t.pending = map[int64]chan *rpcMessage{}
+	t.mu.Unlock()
+	close(t.done)
+}
+
+// answer replies to a request from the server; only ping is supported
+func (t *stdioTransport) answer(req *rpcMessage) {
+	resp := &rpcMessage{JSONRPC: "2.0", ID: req.ID}
+	if req.Method == "ping" {
+		resp.Result = json.RawMessage(`{}`)
+	} else {
+		resp.Error = &RPCError{Code: -32601, Message: "method not found: " + req.Method}
+	}
+	_ = t.write(resp)
+}
+
+func (t *stdioTransport) write(msg *rpcMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	return nil
+}
+
+func (t *stdioTransport) call(ctx context.Context, msg *rpcMessage) (*rpcMessage, error) {
+	ch := make(chan *rpcMessage, 1)
+	t.mu.Lock()
+	if t.err != nil {
+		t.mu.Unlock()
+		return nil, t.err
+	}
+	t.pending[*msg.ID] = ch
+	t.mu.Unlock()
+
+	if err := t.write(msg); err != nil {
+		t.forget(*msg.ID)
+		return nil, err
+	}
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-t.done:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return nil, t.err
+	case <-ctx.Done():
+		t.forget(*msg.ID)
+		// Tell the server to stop working on it
+		_ = t.write(&rpcMessage{JSONRPC: "2.0", Method: "notifications/cancelled", Params: map[string]interface{}{"requestId": *msg.ID}})
+		return nil, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) forget(id int64) {
+	t.mu.Lock()
+	delete(t.pending, id)
+	t.mu.Unlock()
+}
+
+func (t *stdioTransport) notify(ctx context.Context, msg *rpcMessage) error {
+	return t.write(msg)
+}
+
+// close shuts stdin so the server can exit, killing it if it does not
+func (t *stdioTransport) close() error {
+	_ = t.stdin.Close()
+	select {
+	case <-t.exited:
+	case <-time.After(2 * time.Second):
+		_ = t.cmd.Process.Kill()
+		<-t.exited
+	}
+	<-t.done
+	return nil
+}
+
+// readLine reads one newline-terminated message of bounded size
+func readLine(r *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, chunk...)
+		if len(line) > maxMessageBytes {
+			return nil, fmt.Errorf("message larger than %d bytes", maxMessageBytes)
+		}
+		if !isPrefix {
+			return line, nil
+		}
+	}
+}
+
+// tailBuffer keeps the last max bytes written, for error reports
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+	max int
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = b.buf[len(b.buf)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.TrimSpace(string(b.buf))
+}
+
+// ====== Streamable HTTP ======
+
+// httpTransport talks to a server over MCP's streamable HTTP transport:
+// each message is a POST, answered with JSON or an SSE stream
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu       sync.Mutex
+	session  string // Mcp-Session-Id assigned at initialize
+	protocol string
+}
+
+func newHTTPTransport(url string, headers map[string]string, timeout time.Duration) *httpTransport {
+	return &httpTransport{url: url, headers: headers, client: &http.Client{Timeout: timeout}}
+}
+
+func (t *httpTransport) setProtocol(version string) {
+	t.mu.Lock()
+	t.protocol = version
+	t.mu.Unlock()
+}
+
+func (t *httpTransport) newRequest(ctx context.Context, method string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	t.mu.Lock()
+	if t.session != "" {
+		req.Header.Set("Mcp-Session-Id", t.session)
+	}
+	if t.protocol != "" {
+		req.Header.Set("MCP-Protocol-Version", t.protocol)
+	}
+	t.mu.Unlock()
+	return req, nil
+}
+
+// post sends a message, keeping the session ID the server assigns
+func (t *httpTransport) post(ctx context.Context, msg *rpcMessage) (*http.Response, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := t.newRequest(ctx, http.MethodPost, data)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: request failed: %w", err)
+	}
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+		t.mu.Lock()
+		t.session = id
+		t.mu.Unlock()
+	}
+	if resp.StatusCode == http.StatusNotFound && req.Header.Get("Mcp-Session-Id") != "" {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("%w: session expired", ErrClosed)
+	}
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("mcp: server returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) call(ctx context.Context, msg *rpcMessage) (*rpcMessage, error) {
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return readSSEResponse(resp.Body, *msg.ID)
+	}
+	var out rpcMessage
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxMessageBytes)).Decode(&out); err != nil {
+		return nil, fmt.Errorf("mcp: invalid response: %w", err)
+	}
+	return &out, nil
+}
+
+// readSSEResponse reads a response stream until the response to id
+// arrives; progress notifications before it are skipped
+func readSSEResponse(body io.Reader, id int64) (*rpcMessage, error) {
+	reader := bufio.NewReaderSize(body, 64*1024)
+	var data []string
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			return nil, fmt.Errorf("%w: stream ended before the response: %v", ErrClosed, err)
+		}
+		text := string(line)
+		if strings.HasPrefix(text, "data:") {
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(text, "data:"), " "))
+			continue
+		}
+		if text != "" || len(data) == 0 {
+			continue
+		}
+		var msg rpcMessage
+		err = json.Unmarshal([]byte(strings.Join(data, "\n")), &msg)
+		data = nil
+		if err == nil && msg.ID != nil && *msg.ID == id && msg.Method == "" {
+			return &msg, nil
+		}
+	}
+}
+
+func (t *httpTransport) notify(ctx context.Context, msg *rpcMessage) error {
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// close ends the session on the server
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	session := t.session
+	t.mu.Unlock()
+	if session == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := t.newRequest(ctx, http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,110 @@
+package sdk
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/stream"
+)
+
+// Operation names the Client method a Call came from
+type Operation string
+
+const (
+	OpChat       Operation = "chat"
+	OpChatStream Operation = "chat_stream"
+	OpEmbed      Operation = "embed"
+)
+
+// Call is one Client call as seen by middleware. Chat is set for OpChat and
+// OpChatStream, Embed for OpEmbed; middleware may modify the request in
+// place before passing the call on.
+type Call struct {
+	Op    Operation
+	Chat  *ChatRequest
+	Embed *EmbedRequest
+}
+
+// Model returns the model the call asks for
+func (c *Call) Model() string {
+	switch {
+	case c.Chat != nil:
+		return c.Chat.Model
+	case c.Embed != nil:
+		return c.Embed.Model
+	}
+	return ""
+}
+
+// Result is a call's outcome: Chat for OpChat, Stream for OpChatStream and
+// Embed for OpEmbed
+type Result struct {
+	Chat   *ChatResponse
+	Stream *stream.Stream
+	Embed  *EmbedResponse
+}
+
+// Handler serves a call. The innermost Handler routes it to a provider.
+type Handler func(ctx context.Context, call *Call) (*Result, error)
+
+// Middleware wraps a Handler with code that runs around every call, at the
+// level of chat and embedding requests rather than HTTP attempts (see
+// internal/http hooks for those). A middleware may:
+//   - inspect or rewrite call.Chat / call.Embed before calling next
+//   - answer without calling next (a cache hit, a policy refusal)
+//   - inspect or replace the Result and error next returns
+//
+// Example, a policy that refuses a model:
+//
+//	func denyModel(model string) sdk.Middleware {
+//		return func(next sdk.Handler) sdk.Handler {
+//			return func(ctx context.Context, call *sdk.Call) (*sdk.Result, error) {
+//				if call.Model() == model {
+//					return nil, fmt.Errorf("model %s is not allowed", model)
+//				}
+//				return next(ctx, call)
+//			}
+//		}
+//	}
+type Middleware func(next Handler) Handler
+
+// Chain composes middleware into one, the first being the outermost
+func Chain(middleware ...Middleware) Middleware {
+	return func(next Handler) Handler {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			next = middleware[i](next)
+		}
+		return next
+	}
+}
+
+// LogCalls logs each call's operation, model, provider, duration and error
+// to logger (the standard logger when nil). Streamed calls are logged once
+// the stream has started.
+func LogCalls(logger *log.Logger) Middleware {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*Result, error) {
+			start := time.Now()
+			result, err := next(ctx, call)
+			elapsed := time.Since(start).Round(time.Millisecond)
+			if err != nil {
+				logger.Printf("sdk: %s model=%s failed after %v: %v", call.Op, call.Model(), elapsed, err)
+				return result, err
+			}
+
+			switch {
+			case result != nil && result.Chat != nil:
+				logger.Printf("sdk: %s model=%s provider=%s took %v", call.Op, call.Model(), result.Chat.Provider, elapsed)
+			case result != nil && result.Embed != nil:
+				logger.Printf("sdk: %s model=%s provider=%s took %v", call.Op, call.Model(), result.Embed.Provider, elapsed)
+			default:
+				logger.Printf("sdk: %s model=%s took %v", call.Op, call.Model(), elapsed)
+			}
+			return result, nil
+		}
+	}
+}
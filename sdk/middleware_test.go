@@ -0,0 +1,111 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestChain_Order(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) (*Result, error) {
+				order = append(order, name+" in")
+				result, err := next(ctx, call)
+				order = append(order, name+" out")
+				return result, err
+			}
+		}
+	}
+	core := func(ctx context.Context, call *Call) (*Result, error) {
+		order = append(order, "core")
+		return &Result{}, nil
+	}
+
+	Chain(trace("a"), trace("b"))(core)(context.Background(), &Call{Op: OpChat})
+	if got := strings.Join(order, ","); got != "a in,b in,core,b out,a out" {
+		t.Errorf("order = %s", got)
+	}
+}
+
+func TestClient_MiddlewareRewritesRequest(t *testing.T) {
+	c := newClient(t, Options{
+		Providers: []ProviderConfig{{Name: "a", BaseURL: openAIServer(t, "ok", nil).URL, Models: []string{"real-model"}}},
+		Middleware: []Middleware{func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) (*Result, error) {
+				if call.Chat != nil && call.Chat.Model == "alias" {
+					call.Chat.Model = "real-model"
+				}
+				return next(ctx, call)
+			}
+		}},
+	})
+
+	resp, err := c.Chat(context.Background(), ChatRequest{Model: "alias", Messages: userMessage("Hi")})
+	if err != nil || resp.Content != "ok" {
+		t.Errorf("Chat = %+v, %v", resp, err)
+	}
+}
+
+func TestClient_MiddlewareShortCircuits(t *testing.T) {
+	var upstream atomic.Int32
+	cached := &ChatResponse{Content: "cached"}
+	denied := errors.New("denied")
+
+	c := newClient(t, Options{
+		Providers: []ProviderConfig{{Name: "a", BaseURL: openAIServer(t, "fresh", &upstream).URL}},
+		Middleware: []Middleware{func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) (*Result, error) {
+				switch call.Model() {
+				case "cached":
+					return &Result{Chat: cached}, nil
+				case "denied":
+					return nil, denied
+				case "broken":
+					return nil, nil
+				}
+				return next(ctx, call)
+			}
+		}},
+	})
+
+	if resp, err := c.Chat(context.Background(), ChatRequest{Model: "cached"}); err != nil || resp != cached {
+		t.Errorf("cache hit = %+v, %v", resp, err)
+	}
+	if _, err := c.Embed(context.Background(), EmbedRequest{Model: "denied"}); !errors.Is(err, denied) {
+		t.Errorf("err = %v, want denied", err)
+	}
+	if _, err := c.ChatStream(context.Background(), ChatRequest{Model: "broken"}); err == nil {
+		t.Error("expected error for a middleware returning no result")
+	}
+	if upstream.Load() != 0 {
+		t.Errorf("upstream called %d times, want 0", upstream.Load())
+	}
+}
+
+func TestLogCalls(t *testing.T) {
+	var buf bytes.Buffer
+	c := newClient(t, Options{
+		Providers:  []ProviderConfig{{Name: "primary", BaseURL: openAIServer(t, "ok", nil).URL, Models: []string{"m"}}},
+		Middleware: []Middleware{LogCalls(log.New(&buf, "", 0))},
+	})
+
+	c.Chat(context.Background(), ChatRequest{Model: "m", Messages: userMessage("Hi")})
+	c.Chat(context.Background(), ChatRequest{Model: "missing", Messages: userMessage("Hi")})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want 2: %q", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "chat model=m provider=primary took") {
+		t.Errorf("success line = %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "chat model=missing failed") || !strings.Contains(lines[1], ErrNoProvider.Error()) {
+		t.Errorf("failure line = %q", lines[1])
+	}
+}
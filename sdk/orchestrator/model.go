@@ -0,0 +1,167 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+	"github.com/jeffersonwarrior/modelscan/sdk/tools"
+)
+
+// Message is one turn of an agent's conversation. Assistant turns may
+// carry tool calls; tool turns answer one call by ToolCallID.
+type Message struct {
+	Role       string       `json:"role"` // system, user, assistant or tool
+	Content    string       `json:"content"`
+	ToolCalls  []tools.Call `json:"tool_calls,omitempty"`
+	ToolCallID string       `json:"tool_call_id,omitempty"`
+}
+
+// CompletionRequest asks a model for the next assistant turn
+type CompletionRequest struct {
+	Model       string
+	Messages    []Message
+	Tools       []tools.Definition
+	Temperature *float64 // Nil: model default
+	MaxTokens   int      // Zero: model default
+}
+
+// Completion is a model's answer: text, tool calls or both
+type Completion struct {
+	Content      string
+	ToolCalls    []tools.Call
+	FinishReason string
+	InputTokens  int
+	OutputTokens int
+}
+
+// Model produces completions with function calling
+type Model interface {
+	Complete(ctx context.Context, req CompletionRequest) (*Completion, error)
+}
+
+// OpenAIModel calls an OpenAI-compatible /chat/completions endpoint. Point
+// it at the modelscan proxy to reach any configured provider, with its
+// routing, keys and budgets applied.
+type OpenAIModel struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewOpenAIModel creates a model client for baseURL, e.g.
+// "http://localhost:8080/v1"
+func NewOpenAIModel(baseURL, apiKey string) *OpenAIModel {
+	return &OpenAIModel{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    *string          `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// Complete implements Model
+func (m *OpenAIModel) Complete(ctx context.Context, req CompletionRequest) (*Completion, error) {
+	messages := make([]openAIMessage, len(req.Messages))
+	for i, msg := range req.Messages {
+		content := msg.Content
+		out := openAIMessage{Role: msg.Role, Content: &content, ToolCallID: msg.ToolCallID}
+		if len(msg.ToolCalls) > 0 && content == "" {
+			out.Content = nil
+		}
+		for _, call := range msg.ToolCalls {
+			tc := openAIToolCall{ID: call.ID, Type: "function"}
+			tc.Function.Name, tc.Function.Arguments = call.Name, call.Arguments
+			out.ToolCalls = append(out.ToolCalls, tc)
+		}
+		messages[i] = out
+	}
+	body := map[string]interface{}{"model": req.Model, "messages": messages}
+	if len(req.Tools) > 0 {
+		defs := make([]map[string]interface{}, len(req.Tools))
+		for i, def := range req.Tools {
+			defs[i] = map[string]interface{}{"type": "function", "function": def}
+		}
+		body["tools"] = defs
+	}
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.MaxTokens > 0 {
+		body["max_tokens"] = req.MaxTokens
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("model request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return nil, providererr.Classify("modelscan", resp.StatusCode, resp.Header, respBody)
+	}
+
+	var out struct {
+		Choices []struct {
+			Message      openAIMessage `json:"message"`
+			FinishReason string        `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(out.Choices) == 0 {
+		return nil, fmt.Errorf("response has no choices")
+	}
+
+	choice := out.Choices[0]
+	completion := &Completion{
+		FinishReason: choice.FinishReason,
+		InputTokens:  out.Usage.PromptTokens,
+		OutputTokens: out.Usage.CompletionTokens,
+	}
+	if choice.Message.Content != nil {
+		completion.Content = *choice.Message.Content
+	}
+	for _, tc := range choice.Message.ToolCalls {
+		completion.ToolCalls = append(completion.ToolCalls, tools.Call{ID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+	}
+	return completion, nil
+}
@@ -0,0 +1,372 @@
+// Package orchestrator executes the tasks stored in sdk/storage. A Runner
+// claims pending tasks and runs each with its agent: the agent's model is
+// called with the task, the tools the agent's capabilities grant are run
+// through a tools.Runtime and fed back, and the loop repeats until the
+// model answers. The answer, or the failure, is written back to the task.
+//
+// Agents in a team can hand a task to a teammate with the built-in
+// handoff tool, which queues a new task for the teammate.
+//
+// Runner.Execute is a scheduler.Handler; run a scheduler.Scheduler with it
+// for concurrency, retries and dead-lettering.
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/storage"
+	"github.com/jeffersonwarrior/modelscan/sdk/tools"
+)
+
+const (
+	// DefaultMaxTurns bounds the model calls made for one task
+	DefaultMaxTurns = 20
+	// DefaultMaxHandoffs bounds how many times a task is passed on
+	DefaultMaxHandoffs = 5
+	// HandoffTool is the name of the built-in hand-off tool
+	HandoffTool = "handoff"
+)
+
+// AgentConfig is read from an agent's config column. Other keys, such as
+// "type", are ignored.
+type AgentConfig struct {
+	Model        string   `json:"model"`
+	SystemPrompt string   `json:"system_prompt"`
+	MaxTurns     int      `json:"max_turns"`
+	Temperature  *float64 `json:"temperature"`
+	MaxTokens    int      `json:"max_tokens"`
+}
+
+// ParseAgentConfig reads an agent's config; an empty config is valid
+func ParseAgentConfig(raw string) (AgentConfig, error) {
+	var cfg AgentConfig
+	if strings.TrimSpace(raw) == "" {
+		return cfg, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid agent config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Options configures a Runner
+type Options struct {
+	DefaultModel string // For agents whose config names no model
+	MaxHandoffs  int    // Zero uses DefaultMaxHandoffs
+}
+
+// Runner executes pending tasks with their agents
+type Runner struct {
+	store *storage.Storage
+	model Model
+	tools *tools.Runtime
+	opts  Options
+}
+
+// NewRunner creates a runner. tools may be nil for agents without tools.
+func NewRunner(store *storage.Storage, model Model, rt *tools.Runtime, opts Options) *Runner {
+	if opts.MaxHandoffs <= 0 {
+		opts.MaxHandoffs = DefaultMaxHandoffs
+	}
+	return &Runner{store: store, model: model, tools: rt, opts: opts}
+}
+
+// RunNext claims the next pending task and runs it once, reporting
+// whether there was one. Failures of the task are recorded on it; the
+// error reports storage failures only.
+func (r *Runner) RunNext(ctx context.Context) (bool, error) {
+	task, err := r.store.Tasks.ClaimNext(ctx)
+	if err != nil || task == nil {
+		return false, err
+	}
+	return true, r.Execute(ctx, task)
+}
+
+// outcome is how a task ended
+type outcome struct {
+	status    string
+	output    string
+	err       error
+	handoffID string
+}
+
+// Execute runs a claimed task with its agent and records the outcome. It
+// implements scheduler.Handler.
+func (r *Runner) Execute(ctx context.Context, task *storage.Task) error {
+	stats := map[string]interface{}{}
+	out := r.execute(ctx, task, stats)
+
+	if task.Metadata == nil {
+		task.Metadata = map[string]interface{}{}
+	}
+	for k, v := range stats {
+		task.Metadata[k] = v
+	}
+	completed := time.Now()
+	task.Status, task.Output, task.CompletedAt = out.status, out.output, &completed
+	if out.err != nil {
+		task.LastError = out.err.Error()
+		task.Metadata["error"] = out.err.Error()
+	}
+	if out.handoffID != "" {
+		task.Metadata["handed_off_to"] = out.handoffID
+	}
+	// The outcome is recorded even when the runner is shutting down
+	if err := r.store.Tasks.Update(context.WithoutCancel(ctx), task); err != nil {
+		return fmt.Errorf("task %s: %w", task.ID, err)
+	}
+	return nil
+}
+
+// execute runs the model and tool loop for a task
+func (r *Runner) execute(ctx context.Context, task *storage.Task, stats map[string]interface{}) outcome {
+	agent, err := r.store.Agents.Get(ctx, task.AgentID)
+	if err != nil {
+		return outcome{status: "failed", err: err}
+	}
+	cfg, err := ParseAgentConfig(agent.Config)
+	if err != nil {
+		return outcome{status: "failed", err: fmt.Errorf("agent %s: %w", agent.Name, err)}
+	}
+	model := cfg.Model
+	if model == "" {
+		model = r.opts.DefaultModel
+	}
+	if model == "" {
+		return outcome{status: "failed", err: fmt.Errorf("agent %s has no model configured", agent.Name)}
+	}
+	maxTurns := cfg.MaxTurns
+	if maxTurns <= 0 {
+		maxTurns = DefaultMaxTurns
+	}
+	stats["model"] = model
+
+	defs, allowed := r.toolsFor(agent)
+	teammates, err := r.teammates(ctx, task, agent)
+	if err != nil {
+		return outcome{status: "failed", err: err}
+	}
+	if len(teammates) > 0 && handoffDepth(task) < r.opts.MaxHandoffs {
+		defs = append(defs, handoffDefinition(teammates))
+	} else {
+		teammates = nil
+	}
+
+	messages := []Message{
+		{Role: "system", Content: systemPrompt(agent, cfg, teammates)},
+		{Role: "user", Content: task.Input},
+	}
+	ctx = tools.WithTask(ctx, task.ID, agent.ID)
+	var inputTokens, outputTokens, toolCalls int
+	defer func() {
+		stats["input_tokens"], stats["output_tokens"], stats["tool_calls"] = inputTokens, outputTokens, toolCalls
+	}()
+
+	for turn := 1; turn <= maxTurns; turn++ {
+		stats["turns"] = turn
+		completion, err := r.model.Complete(ctx, CompletionRequest{
+			Model:       model,
+			Messages:    messages,
+			Tools:       defs,
+			Temperature: cfg.Temperature,
+			MaxTokens:   cfg.MaxTokens,
+		})
+		if ctx.Err() != nil {
+			return outcome{status: "cancelled", err: ctx.Err()}
+		}
+		if err != nil {
+			return outcome{status: "failed", err: fmt.Errorf("model call failed: %w", err)}
+		}
+		inputTokens += completion.InputTokens
+		outputTokens += completion.OutputTokens
+		if len(completion.ToolCalls) == 0 {
+			return outcome{status: "completed", output: completion.Content}
+		}
+
+		messages = append(messages, Message{Role: "assistant", Content: completion.Content, ToolCalls: completion.ToolCalls})
+		toolCalls += len(completion.ToolCalls)
+
+		// Tools run first, so a hand-off in the same turn sees their effects
+		var run []tools.Call
+		var handoff *tools.Call
+		results := make(map[string]string, len(completion.ToolCalls))
+		for i, call := range completion.ToolCalls {
+			switch {
+			case call.Name == HandoffTool && teammates != nil && handoff == nil:
+				handoff = &completion.ToolCalls[i]
+			case allowed[call.Name] && r.tools != nil:
+				run = append(run, call)
+			default:
+				results[call.ID] = tools.Result{Error: fmt.Sprintf("tool %q is not available to this agent", call.Name)}.Content()
+			}
+		}
+		if len(run) > 0 {
+			for _, res := range r.tools.RunAll(ctx, run) {
+				results[res.CallID] = res.Content()
+			}
+		}
+		if handoff != nil {
+			next, err := r.handoff(ctx, task, agent, teammates, *handoff)
+			if err == nil {
+				return next
+			}
+			results[handoff.ID] = tools.Result{Error: err.Error()}.Content()
+		}
+		if ctx.Err() != nil {
+			return outcome{status: "cancelled", err: ctx.Err()}
+		}
+		for _, call := range completion.ToolCalls {
+			messages = append(messages, Message{Role: "tool", ToolCallID: call.ID, Content: results[call.ID]})
+		}
+	}
+	return outcome{status: "failed", err: fmt.Errorf("no answer after %d turns", maxTurns)}
+}
+
+// toolsFor returns the tools an agent's capabilities grant. A capability
+// grants the tool of that name, every tool of the MCP server of that name
+// ("github" grants "github__create_issue"), or, as "*", every tool.
+func (r *Runner) toolsFor(agent *storage.Agent) ([]tools.Definition, map[string]bool) {
+	allowed := map[string]bool{}
+	if r.tools == nil {
+		return nil, allowed
+	}
+	var defs []tools.Definition
+	for _, def := range r.tools.Definitions() {
+		for _, c := range agent.Capabilities {
+			if c == "*" || c == def.Name || strings.HasPrefix(def.Name, c+"__") {
+				defs = append(defs, def)
+				allowed[def.Name] = true
+				break
+			}
+		}
+	}
+	return defs, allowed
+}
+
+// teammates returns the other members of the task's team, by name
+func (r *Runner) teammates(ctx context.Context, task *storage.Task, agent *storage.Agent) ([]*storage.Agent, error) {
+	if task.TeamID == nil || *task.TeamID == "" {
+		return nil, nil
+	}
+	members, err := r.store.Teams.GetMembers(ctx, *task.TeamID)
+	if err != nil {
+		return nil, err
+	}
+	var agents []*storage.Agent
+	for _, m := range members {
+		if m.AgentID == agent.ID {
+			continue
+		}
+		a, err := r.store.Agents.Get(ctx, m.AgentID)
+		if err != nil {
+			return nil, err
+		}
+		agents = append(agents, a)
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Name < agents[j].Name })
+	return agents, nil
+}
+
+// handoff queues the task for a teammate, ending this agent's part in it
+func (r *Runner) handoff(ctx context.Context, task *storage.Task, agent *storage.Agent, teammates []*storage.Agent, call tools.Call) (outcome, error) {
+	var args struct {
+		AgentID string `json:"agent_id"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+		return outcome{}, fmt.Errorf("arguments are not a JSON object: %w", err)
+	}
+	var target *storage.Agent
+	for _, a := range teammates {
+		if a.ID == args.AgentID {
+			target = a
+		}
+	}
+	if target == nil {
+		return outcome{}, fmt.Errorf("%q is not a teammate", args.AgentID)
+	}
+	if strings.TrimSpace(args.Message) == "" {
+		return outcome{}, fmt.Errorf("message is required")
+	}
+
+	next := r.store.NewTaskWithDefaults(target.ID, task.Type,
+		fmt.Sprintf("%s\n\nHanded off by %s. The original request was:\n%s", args.Message, agent.Name, originalInput(task)),
+		task.Priority)
+	next.TeamID = task.TeamID
+	next.Metadata = map[string]interface{}{
+		"handoff_from_task":  task.ID,
+		"handoff_from_agent": agent.ID,
+		"handoff_depth":      handoffDepth(task) + 1,
+		"original_input":     originalInput(task),
+	}
+	if err := r.store.Tasks.Create(ctx, next); err != nil {
+		return outcome{}, err
+	}
+	return outcome{
+		status:    "completed",
+		output:    fmt.Sprintf("Handed off to %s: %s", target.Name, args.Message),
+		handoffID: next.ID,
+	}, nil
+}
+
+// handoffDepth is how many times a task has been passed on
+func handoffDepth(task *storage.Task) int {
+	switch d := task.Metadata["handoff_depth"].(type) {
+	case float64: // As decoded from the database
+		return int(d)
+	case int:
+		return d
+	}
+	return 0
+}
+
+// originalInput is the request that started a chain of hand-offs
+func originalInput(task *storage.Task) string {
+	if s, ok := task.Metadata["original_input"].(string); ok {
+		return s
+	}
+	return task.Input
+}
+
+func handoffDefinition(teammates []*storage.Agent) tools.Definition {
+	ids := make([]interface{}, len(teammates))
+	for i, a := range teammates {
+		ids[i] = a.ID
+	}
+	return tools.Definition{
+		Name:        HandoffTool,
+		Description: "Passes the task to a teammate better suited to it. Your part ends; the teammate receives your message and the original request.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"agent_id": map[string]interface{}{"type": "string", "enum": ids},
+				"message":  map[string]interface{}{"type": "string", "description": "What the teammate should do, with anything you found out"},
+			},
+			"required": []interface{}{"agent_id", "message"},
+		},
+	}
+}
+
+func systemPrompt(agent *storage.Agent, cfg AgentConfig, teammates []*storage.Agent) string {
+	var b strings.Builder
+	if cfg.SystemPrompt != "" {
+		b.WriteString(cfg.SystemPrompt)
+	} else {
+		fmt.Fprintf(&b, "You are %s, an agent that completes the task you are given and answers with the result.", agent.Name)
+	}
+	if len(teammates) > 0 {
+		b.WriteString("\n\nYour teammates, who can take over with the handoff tool:")
+		for _, a := range teammates {
+			fmt.Fprintf(&b, "\n- %s: %s", a.ID, a.Name)
+			if len(a.Capabilities) > 0 {
+				fmt.Fprintf(&b, " (%s)", strings.Join(a.Capabilities, ", "))
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,229 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/agent"
+	"github.com/jeffersonwarrior/modelscan/sdk/storage"
+	"github.com/jeffersonwarrior/modelscan/sdk/tools"
+)
+
+// scriptedModel answers with the next completion for the agent's model
+type scriptedModel struct {
+	mu       sync.Mutex
+	script   map[string][]*Completion
+	requests []CompletionRequest
+}
+
+func (m *scriptedModel) Complete(ctx context.Context, req CompletionRequest) (*Completion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, req)
+	if len(m.script[req.Model]) == 0 {
+		return nil, errors.New("upstream unavailable")
+	}
+	next := m.script[req.Model][0]
+	m.script[req.Model] = m.script[req.Model][1:]
+	return next, nil
+}
+
+func newTestStore(t *testing.T) *storage.Storage {
+	t.Helper()
+	adb, err := storage.NewAgentDB(filepath.Join(t.TempDir(), "agents.db"))
+	if err != nil {
+		t.Fatalf("NewAgentDB: %v", err)
+	}
+	t.Cleanup(func() { adb.Close() })
+	return storage.NewStorage(adb.GetDB(), 24*time.Hour)
+}
+
+func createAgent(t *testing.T, store *storage.Storage, id, model string, capabilities ...string) {
+	t.Helper()
+	a := &storage.Agent{ID: id, Name: id, Capabilities: capabilities, Config: `{"type": "assistant", "model": "` + model + `"}`, Status: "idle"}
+	if err := store.Agents.Create(context.Background(), a); err != nil {
+		t.Fatalf("Create agent: %v", err)
+	}
+}
+
+func createTask(t *testing.T, store *storage.Storage, agentID, input string, teamID *string) *storage.Task {
+	t.Helper()
+	task := store.NewTaskWithDefaults(agentID, "chat", input, 1)
+	task.TeamID = teamID
+	if err := store.Tasks.Create(context.Background(), task); err != nil {
+		t.Fatalf("Create task: %v", err)
+	}
+	return task
+}
+
+func TestRunner_ToolLoop(t *testing.T) {
+	store := newTestStore(t)
+	createAgent(t, store, "researcher", "gpt-test", "weather")
+
+	registry := agent.NewToolRegistry()
+	registry.Register(tools.NewFunc("weather", "Current weather", nil,
+		func(ctx context.Context, in map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"forecast": "rain in " + in["city"].(string)}, nil
+		}))
+	registry.Register(tools.NewFunc("delete_files", "Not granted", nil,
+		func(ctx context.Context, in map[string]interface{}) (map[string]interface{}, error) {
+			t.Error("ungranted tool ran")
+			return nil, nil
+		}))
+	rt := tools.NewRuntime(registry, tools.Options{Recorder: tools.NewStorageRecorder(store.ToolExecutions)})
+
+	model := &scriptedModel{script: map[string][]*Completion{"gpt-test": {
+		{ToolCalls: []tools.Call{
+			{ID: "call_1", Name: "weather", Arguments: `{"city": "Oslo"}`},
+			{ID: "call_2", Name: "delete_files", Arguments: `{}`},
+		}, InputTokens: 10, OutputTokens: 5},
+		{Content: "Bring an umbrella.", InputTokens: 30, OutputTokens: 4},
+	}}}
+	runner := NewRunner(store, model, rt, Options{})
+	task := createTask(t, store, "researcher", "What should I wear in Oslo?", nil)
+
+	ran, err := runner.RunNext(context.Background())
+	if !ran || err != nil {
+		t.Fatalf("RunNext = %v, %v", ran, err)
+	}
+
+	got, _ := store.Tasks.Get(context.Background(), task.ID)
+	if got.Status != "completed" || got.Output != "Bring an umbrella." || got.CompletedAt == nil {
+		t.Fatalf("unexpected task %+v", got)
+	}
+	if got.Metadata["turns"] != float64(2) || got.Metadata["tool_calls"] != float64(2) || got.Metadata["input_tokens"] != float64(40) {
+		t.Errorf("unexpected stats %v", got.Metadata)
+	}
+
+	// Only granted tools are offered, and results go back by call ID
+	if len(model.requests[0].Tools) != 1 || model.requests[0].Tools[0].Name != "weather" {
+		t.Errorf("offered tools = %+v", model.requests[0].Tools)
+	}
+	second := model.requests[1].Messages
+	if len(second) != 5 || second[3].ToolCallID != "call_1" || !strings.Contains(second[3].Content, "rain in Oslo") {
+		t.Fatalf("unexpected conversation %+v", second)
+	}
+	if !strings.Contains(second[4].Content, "not available") {
+		t.Errorf("expected the ungranted call to be refused, got %s", second[4].Content)
+	}
+
+	execs, _ := store.ToolExecutions.ListByTask(context.Background(), task.ID, 10, 0)
+	if len(execs) != 1 || execs[0].AgentID != "researcher" || execs[0].ToolName != "weather" {
+		t.Errorf("unexpected executions %+v", execs)
+	}
+
+	if ran, _ := runner.RunNext(context.Background()); ran {
+		t.Error("expected no task left")
+	}
+}
+
+func TestRunner_Handoff(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	createAgent(t, store, "triage", "triage-model")
+	createAgent(t, store, "billing", "billing-model")
+	team := store.NewTeamWithDefaults("support", "")
+	store.Teams.Create(ctx, team)
+	store.Teams.AddMember(ctx, team.ID, "triage", "member")
+	store.Teams.AddMember(ctx, team.ID, "billing", "member")
+
+	model := &scriptedModel{script: map[string][]*Completion{
+		"triage-model":  {{ToolCalls: []tools.Call{{ID: "h1", Name: HandoffTool, Arguments: `{"agent_id": "billing", "message": "Refund the duplicate charge"}`}}}},
+		"billing-model": {{Content: "Refunded $20."}},
+	}}
+	runner := NewRunner(store, model, nil, Options{})
+	task := createTask(t, store, "triage", "I was charged twice", &team.ID)
+
+	runner.RunNext(ctx)
+	first, _ := store.Tasks.Get(ctx, task.ID)
+	nextID, _ := first.Metadata["handed_off_to"].(string)
+	if first.Status != "completed" || nextID == "" || !strings.Contains(first.Output, "Handed off to billing") {
+		t.Fatalf("unexpected first task %+v", first)
+	}
+	if !strings.Contains(model.requests[0].Messages[0].Content, "billing") {
+		t.Errorf("expected teammates in the system prompt, got %q", model.requests[0].Messages[0].Content)
+	}
+
+	runner.RunNext(ctx)
+	second, _ := store.Tasks.Get(ctx, nextID)
+	if second.AgentID != "billing" || second.TeamID == nil || *second.TeamID != team.ID {
+		t.Fatalf("unexpected hand-off task %+v", second)
+	}
+	if !strings.Contains(second.Input, "Refund the duplicate charge") || !strings.Contains(second.Input, "I was charged twice") {
+		t.Errorf("unexpected hand-off input %q", second.Input)
+	}
+	if second.Status != "completed" || second.Output != "Refunded $20." || second.Metadata["handoff_depth"] != float64(1) {
+		t.Errorf("unexpected second task %+v", second)
+	}
+}
+
+func TestRunner_Failures(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	createAgent(t, store, "flaky", "down-model")
+	createAgent(t, store, "unconfigured", "")
+
+	runner := NewRunner(store, &scriptedModel{}, nil, Options{})
+	down := createTask(t, store, "flaky", "hello", nil)
+	runner.RunNext(ctx)
+	unconfigured := createTask(t, store, "unconfigured", "hello", nil)
+	runner.RunNext(ctx)
+
+	got, _ := store.Tasks.Get(ctx, down.ID)
+	if got.Status != "failed" || !strings.Contains(got.Metadata["error"].(string), "upstream unavailable") {
+		t.Errorf("unexpected task %+v", got)
+	}
+	got, _ = store.Tasks.Get(ctx, unconfigured.ID)
+	if got.Status != "failed" || !strings.Contains(got.Metadata["error"].(string), "no model configured") {
+		t.Errorf("unexpected task %+v", got)
+	}
+}
+
+func TestOpenAIModel(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_9","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Oslo\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":12,"completion_tokens":7}}`))
+	}))
+	defer srv.Close()
+
+	completion, err := NewOpenAIModel(srv.URL+"/v1/", "key").Complete(context.Background(), CompletionRequest{
+		Model: "gpt-test",
+		Messages: []Message{
+			{Role: "user", Content: "Weather?"},
+			{Role: "assistant", ToolCalls: []tools.Call{{ID: "call_1", Name: "weather", Arguments: `{}`}}},
+			{Role: "tool", ToolCallID: "call_1", Content: `{"error":"city is required"}`},
+		},
+		Tools: []tools.Definition{{Name: "weather", Parameters: map[string]interface{}{"type": "object"}}},
+	})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if len(completion.ToolCalls) != 1 || completion.ToolCalls[0].Arguments != `{"city":"Oslo"}` || completion.InputTokens != 12 {
+		t.Errorf("unexpected completion %+v", completion)
+	}
+
+	messages := body["messages"].([]interface{})
+	assistant := messages[1].(map[string]interface{})
+	if assistant["content"] != nil || len(assistant["tool_calls"].([]interface{})) != 1 {
+		t.Errorf("unexpected assistant message %v", assistant)
+	}
+	if messages[2].(map[string]interface{})["tool_call_id"] != "call_1" {
+		t.Errorf("unexpected tool message %v", messages[2])
+	}
+	tool := body["tools"].([]interface{})[0].(map[string]interface{})
+	if tool["type"] != "function" || tool["function"].(map[string]interface{})["name"] != "weather" {
+		t.Errorf("unexpected tools %v", body["tools"])
+	}
+}
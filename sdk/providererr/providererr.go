@@ -0,0 +1,274 @@
+// Package providererr classifies provider API failures into typed errors so
+// callers can branch with errors.Is and errors.As instead of matching
+// strings:
+//
+//	resp, err := client.Do(req)
+//	...
+//	if apiErr := providererr.FromResponse("openai", resp); apiErr != nil {
+//	    if errors.Is(apiErr, providererr.ErrRateLimited) {
+//	        time.Sleep(apiErr.RetryAfter)
+//	    }
+//	}
+//
+// Classification understands the error bodies of OpenAI-compatible APIs,
+// Anthropic, Google and Cohere, falling back to the HTTP status. Responses
+// from the modelscan proxy carry the classification in HeaderCode.
+package providererr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error kinds. Error.Unwrap returns one of these, or nil when a failure
+// could not be classified.
+var (
+	ErrRateLimited     = errors.New("rate limited")
+	ErrContextTooLong  = errors.New("context too long")
+	ErrContentFiltered = errors.New("content filtered")
+	ErrInvalidKey      = errors.New("invalid API key")
+	ErrModelNotFound   = errors.New("model not found")
+	ErrOverloaded      = errors.New("provider overloaded")
+)
+
+// HeaderCode carries the error code (see Code) on error responses from the
+// modelscan proxy
+const HeaderCode = "X-Modelscan-Error-Code"
+
+// Stable codes for each kind, used in HeaderCode and stream error events
+var codes = map[error]string{
+	ErrRateLimited:     "rate_limited",
+	ErrContextTooLong:  "context_too_long",
+	ErrContentFiltered: "content_filtered",
+	ErrInvalidKey:      "invalid_key",
+	ErrModelNotFound:   "model_not_found",
+	ErrOverloaded:      "overloaded",
+}
+
+// maxBodyBytes bounds how much of an error body FromResponse reads
+const maxBodyBytes = 64 << 10
+
+// Error is a classified provider failure
+type Error struct {
+	Provider   string
+	Status     int           // HTTP status, 0 if unknown
+	Kind       error         // One of the Err* kinds, nil if unclassified
+	Code       string        // The provider's own error type or code, if any
+	Message    string        // The provider's error message
+	RetryAfter time.Duration // From Retry-After, 0 if absent
+}
+
+func (e *Error) Error() string {
+	kind := "API error"
+	if e.Kind != nil {
+		kind = e.Kind.Error()
+	}
+	msg := fmt.Sprintf("%s (status %d)", kind, e.Status)
+	if e.Provider != "" {
+		msg = e.Provider + ": " + msg
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Unwrap returns the error kind
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Retryable reports whether retrying later may succeed
+func (e *Error) Retryable() bool {
+	return e.Kind == ErrRateLimited || e.Kind == ErrOverloaded || e.Status >= 500
+}
+
+// Code returns the stable code for err's kind ("rate_limited", ...), or ""
+// when err is not one of the kinds
+func Code(err error) string {
+	for kind, code := range codes {
+		if errors.Is(err, kind) {
+			return code
+		}
+	}
+	return ""
+}
+
+// KindOf returns the kind for a code returned by Code, or nil
+func KindOf(code string) error {
+	for kind, c := range codes {
+		if c == code {
+			return kind
+		}
+	}
+	return nil
+}
+
+// FromResponse classifies a failed response, reading (and replacing) its
+// body. It returns nil for statuses below 400.
+func FromResponse(provider string, resp *http.Response) *Error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(strings.NewReader(string(body)))
+	return Classify(provider, resp.StatusCode, resp.Header, body)
+}
+
+// Classify builds an Error from a failed response's status, headers and
+// body. It never returns nil.
+func Classify(provider string, status int, header http.Header, body []byte) *Error {
+	e := &Error{Provider: provider, Status: status}
+	if header != nil {
+		e.RetryAfter = parseRetryAfter(header.Get("Retry-After"))
+	}
+	e.Code, e.Message = parseBody(body)
+	if e.Message == "" {
+		e.Message = strings.TrimSpace(string(body))
+		if len(e.Message) > 500 {
+			e.Message = e.Message[:500] + "..."
+		}
+	}
+
+	if header != nil {
+		if kind := KindOf(header.Get(HeaderCode)); kind != nil {
+			e.Kind = kind
+			return e
+		}
+	}
+	e.Kind = classify(status, e.Code, e.Message)
+	return e
+}
+
+// parseBody extracts the error code and message from the error formats used
+// by providers:
+//
+//	OpenAI-compatible: {"error": {"message": "...", "type": "...", "code": "..."}}
+//	Anthropic:         {"type": "error", "error": {"type": "...", "message": "..."}}
+//	Google:            {"error": {"code": 429, "message": "...", "status": "RESOURCE_EXHAUSTED"}}
+//	Cohere, Mistral:   {"message": "...", "type": "...", "code": "..."}
+func parseBody(body []byte) (code, message string) {
+	var parsed struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+		Type    string          `json:"type"`
+		Code    json.RawMessage `json:"code"`
+	}
+	if json.Unmarshal(body, &parsed) != nil {
+		return "", ""
+	}
+
+	var nested struct {
+		Message string          `json:"message"`
+		Type    string          `json:"type"`
+		Code    json.RawMessage `json:"code"`
+		Status  string          `json:"status"`
+	}
+	if len(parsed.Error) > 0 && json.Unmarshal(parsed.Error, &nested) == nil {
+		return firstNonEmpty(nested.Status, rawString(nested.Code), nested.Type), nested.Message
+	}
+	var flat string
+	if json.Unmarshal(parsed.Error, &flat) == nil && flat != "" {
+		return firstNonEmpty(rawString(parsed.Code), parsed.Type), flat
+	}
+	if parsed.Type == "error" {
+		parsed.Type = ""
+	}
+	return firstNonEmpty(rawString(parsed.Code), parsed.Type), parsed.Message
+}
+
+// classify maps a provider code, message and status to a kind. Provider
+// codes win over message text, which wins over the status.
+func classify(status int, code, message string) error {
+	switch strings.ToLower(code) {
+	case "rate_limit_exceeded", "rate_limit_error", "resource_exhausted", "too_many_requests", "rate_limited":
+		return ErrRateLimited
+	case "context_length_exceeded", "context_too_long", "string_above_max_length":
+		return ErrContextTooLong
+	case "content_filter", "content_policy_violation", "content_filtered", "safety":
+		return ErrContentFiltered
+	case "invalid_api_key", "authentication_error", "unauthenticated", "invalid_key", "permission_error", "permission_denied":
+		return ErrInvalidKey
+	case "model_not_found", "not_found_error", "not_found":
+		if status == http.StatusNotFound || strings.Contains(strings.ToLower(message), "model") {
+			return ErrModelNotFound
+		}
+	case "overloaded_error", "server_overloaded", "unavailable", "overloaded":
+		return ErrOverloaded
+	}
+
+	msg := strings.ToLower(message)
+	switch {
+	case containsAny(msg, "context length", "context window", "maximum context", "prompt is too long", "too many tokens", "reduce the length"):
+		return ErrContextTooLong
+	case containsAny(msg, "content filter", "content management policy", "content policy", "safety system", "flagged"):
+		return ErrContentFiltered
+	case containsAny(msg, "invalid api key", "incorrect api key", "invalid x-api-key", "api key not valid"):
+		return ErrInvalidKey
+	case strings.Contains(msg, "model") && containsAny(msg, "does not exist", "not found", "unknown model"):
+		return ErrModelNotFound
+	case containsAny(msg, "overloaded", "over capacity"):
+		return ErrOverloaded
+	}
+
+	switch status {
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrInvalidKey
+	case http.StatusRequestEntityTooLarge:
+		return ErrContextTooLong
+	case http.StatusServiceUnavailable, 529: // 529: Anthropic overloaded
+		return ErrOverloaded
+	}
+	return nil
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or HTTP date form
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// rawString returns a JSON string code. Numeric codes only repeat the HTTP
+// status and are ignored.
+func rawString(raw json.RawMessage) string {
+	var s string
+	json.Unmarshal(raw, &s)
+	return s
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
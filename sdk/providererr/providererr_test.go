@@ -0,0 +1,155 @@
+package providererr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		status   int
+		body     string
+		want     error
+		code     string
+	}{
+		{"openai rate limit", "openai", 429,
+			`{"error":{"message":"Rate limit reached for gpt-4","type":"requests","code":"rate_limit_exceeded"}}`,
+			ErrRateLimited, "rate_limit_exceeded"},
+		{"openai context", "openai", 400,
+			`{"error":{"message":"This model's maximum context length is 8192 tokens","type":"invalid_request_error","code":"context_length_exceeded"}}`,
+			ErrContextTooLong, "context_length_exceeded"},
+		{"openai content filter", "openai", 400,
+			`{"error":{"message":"The response was filtered","type":"invalid_request_error","code":"content_filter"}}`,
+			ErrContentFiltered, "content_filter"},
+		{"openai invalid key", "openai", 401,
+			`{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`,
+			ErrInvalidKey, "invalid_api_key"},
+		{"openai model not found", "openai", 404,
+			`{"error":{"message":"The model gpt-9 does not exist","type":"invalid_request_error","code":"model_not_found"}}`,
+			ErrModelNotFound, "model_not_found"},
+		{"anthropic overloaded", "anthropic", 529,
+			`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			ErrOverloaded, "overloaded_error"},
+		{"anthropic prompt too long", "anthropic", 400,
+			`{"type":"error","error":{"type":"invalid_request_error","message":"prompt is too long: 210000 tokens > 200000 maximum"}}`,
+			ErrContextTooLong, "invalid_request_error"},
+		{"anthropic not found", "anthropic", 404,
+			`{"type":"error","error":{"type":"not_found_error","message":"model: claude-x"}}`,
+			ErrModelNotFound, "not_found_error"},
+		{"google exhausted", "google", 429,
+			`{"error":{"code":429,"message":"Quota exceeded","status":"RESOURCE_EXHAUSTED"}}`,
+			ErrRateLimited, "RESOURCE_EXHAUSTED"},
+		{"google bad key", "google", 400,
+			`{"error":{"code":400,"message":"API key not valid. Please pass a valid API key.","status":"INVALID_ARGUMENT"}}`,
+			ErrInvalidKey, "INVALID_ARGUMENT"},
+		{"cohere flat", "cohere", 429, `{"message":"too many requests"}`, ErrRateLimited, ""},
+		{"mistral flat", "mistral", 401,
+			`{"message":"Unauthorized","type":"authentication_error","code":"1000"}`,
+			ErrInvalidKey, "1000"},
+		{"plain text 503", "together", 503, "service unavailable", ErrOverloaded, ""},
+		{"unclassified", "openai", 500, `{"error":{"message":"boom","type":"server_error"}}`, nil, "server_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Classify(tt.provider, tt.status, nil, []byte(tt.body))
+			if e.Kind != tt.want {
+				t.Errorf("kind = %v, want %v", e.Kind, tt.want)
+			}
+			if e.Code != tt.code {
+				t.Errorf("code = %q, want %q", e.Code, tt.code)
+			}
+			if e.Provider != tt.provider || e.Status != tt.status {
+				t.Errorf("provider/status = %s/%d", e.Provider, e.Status)
+			}
+			if tt.want != nil && !errors.Is(e, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false", e, tt.want)
+			}
+			if !strings.Contains(e.Error(), fmt.Sprintf("status %d", tt.status)) {
+				t.Errorf("message %q does not mention the status", e.Error())
+			}
+		})
+	}
+}
+
+func TestClassifyHeaderCode(t *testing.T) {
+	header := http.Header{}
+	header.Set(HeaderCode, "content_filtered")
+	e := Classify("proxy", 400, header, []byte(`{"error":{"message":"bad request"}}`))
+	if e.Kind != ErrContentFiltered {
+		t.Errorf("kind = %v, want content filtered", e.Kind)
+	}
+}
+
+func TestFromResponse(t *testing.T) {
+	ok := &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("{}"))}
+	if FromResponse("openai", ok) != nil {
+		t.Error("expected nil for a successful response")
+	}
+
+	header := http.Header{}
+	header.Set("Retry-After", "3")
+	resp := &http.Response{
+		StatusCode: 429,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(`{"error":{"message":"slow down","code":"rate_limit_exceeded"}}`)),
+	}
+	e := FromResponse("openai", resp)
+	if e == nil || e.Kind != ErrRateLimited {
+		t.Fatalf("got %v, want rate limited", e)
+	}
+	if e.RetryAfter != 3*time.Second {
+		t.Errorf("RetryAfter = %v, want 3s", e.RetryAfter)
+	}
+	if e.Message != "slow down" {
+		t.Errorf("Message = %q", e.Message)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "slow down") {
+		t.Errorf("body was not replaced: %q", body)
+	}
+
+	var apiErr *Error
+	if !errors.As(fmt.Errorf("calling upstream: %w", e), &apiErr) || apiErr.Status != 429 {
+		t.Error("errors.As did not find the wrapped *Error")
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want bool
+	}{
+		{&Error{Status: 429, Kind: ErrRateLimited}, true},
+		{&Error{Status: 529, Kind: ErrOverloaded}, true},
+		{&Error{Status: 500}, true},
+		{&Error{Status: 401, Kind: ErrInvalidKey}, false},
+		{&Error{Status: 400, Kind: ErrContextTooLong}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Retryable(); got != tt.want {
+			t.Errorf("%v: Retryable() = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestCodeAndKindOf(t *testing.T) {
+	for kind, code := range codes {
+		if got := Code(fmt.Errorf("wrapped: %w", kind)); got != code {
+			t.Errorf("Code(%v) = %q, want %q", kind, got, code)
+		}
+		if got := KindOf(code); got != kind {
+			t.Errorf("KindOf(%q) = %v, want %v", code, got, kind)
+		}
+	}
+	if Code(errors.New("other")) != "" || KindOf("other") != nil {
+		t.Error("expected no code for unknown errors")
+	}
+}
@@ -16,6 +16,10 @@ type TokenBucket struct {
 	refillRate     int64         // Tokens added per refill interval
 	refillInterval time.Duration // How often to refill
 	lastRefill     time.Time     // Last refill timestamp
+	reserved       int64         // Tokens held by outstanding reservations
+	maxReserved    int64         // Cap on reserved tokens (0 = no cap)
+	smooth         bool          // Spread tokens evenly over the interval
+	nextFree       time.Time     // Earliest next acquisition when smoothing
 	mu             sync.Mutex
 }
 
@@ -58,6 +62,19 @@ func NewRateLimiter(providerName, planType string) (*RateLimiter, error) {
 	return rl, nil
 }
 
+// NewTokenBucket creates a full bucket holding capacity tokens that gains
+// refillRate tokens every refillInterval, for callers that configure limits
+// directly instead of loading them from the database
+func NewTokenBucket(capacity, refillRate int64, refillInterval time.Duration) *TokenBucket {
+	return &TokenBucket{
+		capacity:       capacity,
+		tokens:         capacity,
+		refillRate:     refillRate,
+		refillInterval: refillInterval,
+		lastRefill:     time.Now(),
+	}
+}
+
 // Acquire attempts to acquire n tokens from the specified bucket
 func (rl *RateLimiter) Acquire(ctx context.Context, limitType string, tokens int64) error {
 	rl.mu.RLock()
@@ -72,6 +89,17 @@ func (rl *RateLimiter) Acquire(ctx context.Context, limitType string, tokens int
 	return bucket.Acquire(ctx, tokens)
 }
 
+// SetSmoothing turns smoothing on or off for the request (rpm) bucket, so
+// a provider sees requests spread across the minute instead of a burst at
+// its start. Token limits are left to burst.
+func (rl *RateLimiter) SetSmoothing(enabled bool) {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	if bucket, exists := rl.buckets["rpm"]; exists {
+		bucket.SetSmoothing(enabled)
+	}
+}
+
 // Acquire attempts to acquire n tokens from the bucket
 func (tb *TokenBucket) Acquire(ctx context.Context, n int64) error {
 	for {
@@ -85,8 +113,22 @@ func (tb *TokenBucket) Acquire(ctx context.Context, n int64) error {
 		tb.mu.Lock()
 		tb.refill()
 
+		// When smoothing, wait out the spacing left by the last acquisition
+		if wait := tb.smoothingDelay(); wait > 0 {
+			tb.mu.Unlock()
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+			continue
+		}
+
 		if tb.tokens >= n {
 			tb.tokens -= n
+			tb.space(n)
 			tb.mu.Unlock()
 			return nil
 		}
@@ -111,6 +153,63 @@ func (tb *TokenBucket) Acquire(ctx context.Context, n int64) error {
 	}
 }
 
+// TryAcquire takes n tokens if they are available now, without waiting
+func (tb *TokenBucket) TryAcquire(n int64) bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.refill()
+	if tb.tokens < n || tb.smoothingDelay() > 0 {
+		return false
+	}
+	tb.tokens -= n
+	tb.space(n)
+	return true
+}
+
+// Take removes n tokens unconditionally, for usage only known after the
+// fact. The balance may go negative, making later callers wait until the
+// debt has been refilled.
+func (tb *TokenBucket) Take(n int64) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.refill()
+	tb.tokens -= n
+	tb.space(n)
+}
+
+// SetSmoothing switches the bucket between allowing bursts of its full
+// capacity and spreading acquisitions evenly over the refill interval, like
+// a leaky bucket. With smoothing a 60 per minute bucket hands out one token
+// a second, even when it is full.
+func (tb *TokenBucket) SetSmoothing(enabled bool) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.smooth = enabled
+	tb.nextFree = time.Time{}
+}
+
+// smoothingDelay returns how long until the next acquisition is allowed
+// when smoothing. Must be called with tb.mu locked
+func (tb *TokenBucket) smoothingDelay() time.Duration {
+	if !tb.smooth {
+		return 0
+	}
+	return time.Until(tb.nextFree)
+}
+
+// space pushes back the next acquisition by the share of the interval that
+// n tokens represent. Must be called with tb.mu locked
+func (tb *TokenBucket) space(n int64) {
+	if !tb.smooth || tb.refillRate <= 0 || n <= 0 {
+		return
+	}
+	now := time.Now()
+	if tb.nextFree.Before(now) {
+		tb.nextFree = now
+	}
+	tb.nextFree = tb.nextFree.Add(tb.refillInterval * time.Duration(n) / time.Duration(tb.refillRate))
+}
+
 // refill adds tokens to the bucket based on elapsed time
 // Must be called with tb.mu locked
 func (tb *TokenBucket) refill() {
@@ -126,6 +225,13 @@ func (tb *TokenBucket) refill() {
 	}
 }
 
+// smoothed reports whether smoothing is on (thread-safe)
+func (tb *TokenBucket) smoothed() bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return tb.smooth
+}
+
 // GetAvailableTokens returns current available tokens (thread-safe)
 func (tb *TokenBucket) GetAvailableTokens() int64 {
 	tb.mu.Lock()
@@ -222,6 +328,8 @@ func (rl *RateLimiter) GetRateLimitInfo() map[string]map[string]interface{} {
 		info[limitType] = map[string]interface{}{
 			"capacity":  bucket.capacity,
 			"available": bucket.GetAvailableTokens(),
+			"reserved":  bucket.Reserved(),
+			"smoothed":  bucket.smoothed(),
 			"refill":    bucket.refillRate,
 			"interval":  bucket.refillInterval.String(),
 		}
@@ -316,3 +316,57 @@ func TestRateLimiter_NoLimitType_AllowsImmediate(t *testing.T) {
 		t.Errorf("Should allow requests for non-existent limit types, got error: %v", err)
 	}
 }
+
+func TestTokenBucket_TryAcquireAndTake(t *testing.T) {
+	bucket := NewTokenBucket(10, 10, time.Hour)
+
+	if !bucket.TryAcquire(6) {
+		t.Fatal("expected 6 of 10 tokens to be available")
+	}
+	if bucket.TryAcquire(5) {
+		t.Error("expected TryAcquire to fail with 4 tokens left")
+	}
+
+	// Take goes into debt, which later requests have to wait out
+	bucket.Take(7)
+	if got := bucket.GetAvailableTokens(); got != -3 {
+		t.Errorf("expected -3 tokens after Take, got %d", got)
+	}
+	if bucket.TryAcquire(1) {
+		t.Error("expected TryAcquire to fail while in debt")
+	}
+}
+
+func TestTokenBucket_Smoothing(t *testing.T) {
+	// 10 per second, spaced 100ms apart when smoothing
+	bucket := NewTokenBucket(10, 10, time.Second)
+	bucket.SetSmoothing(true)
+
+	if !bucket.TryAcquire(1) {
+		t.Fatal("expected the first token to be available")
+	}
+	if bucket.TryAcquire(1) {
+		t.Error("expected a full bucket not to burst while smoothing")
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := bucket.Acquire(context.Background(), 1); err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("expected acquisitions to be spread out, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bucket.Acquire(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("expected the wait to respect the context, got %v", err)
+	}
+
+	bucket.SetSmoothing(false)
+	if !bucket.TryAcquire(5) {
+		t.Error("expected bursts once smoothing is off")
+	}
+}
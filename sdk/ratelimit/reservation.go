@@ -0,0 +1,176 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrReservationTooLarge is returned for reservations the bucket could
+	// never grant: more than its capacity or its reservation limit
+	ErrReservationTooLarge = errors.New("reservation exceeds bucket limits")
+	// ErrReservationClosed is returned when committing to a released
+	// reservation
+	ErrReservationClosed = errors.New("reservation already released")
+)
+
+// Reservation holds capacity taken from a bucket ahead of time, so a long
+// job can spend it as it goes without competing request by request.
+// Commit records usage against it and Release returns what is left.
+type Reservation struct {
+	bucket *TokenBucket // nil for limits that are not enforced
+
+	mu        sync.Mutex
+	remaining int64
+	used      int64
+	closed    bool
+}
+
+// SetMaxReserved caps the tokens held by outstanding reservations, keeping
+// the rest of the bucket for interactive traffic. Zero removes the cap.
+func (tb *TokenBucket) SetMaxReserved(n int64) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.maxReserved = n
+}
+
+// Reserved returns the tokens held by outstanding reservations
+func (tb *TokenBucket) Reserved() int64 {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return tb.reserved
+}
+
+// Reserve takes n tokens out of the bucket for a reservation, waiting
+// until they are available and within the reservation cap
+func (tb *TokenBucket) Reserve(ctx context.Context, n int64) (*Reservation, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		tb.mu.Lock()
+		if n > tb.capacity || (tb.maxReserved > 0 && n > tb.maxReserved) {
+			tb.mu.Unlock()
+			return nil, fmt.Errorf("%w: %d tokens", ErrReservationTooLarge, n)
+		}
+		tb.refill()
+		if tb.tokens >= n && (tb.maxReserved == 0 || tb.reserved+n <= tb.maxReserved) {
+			tb.tokens -= n
+			tb.reserved += n
+			tb.mu.Unlock()
+			return &Reservation{bucket: tb, remaining: n}, nil
+		}
+		waitTime := tb.refillInterval - time.Since(tb.lastRefill)
+		tb.mu.Unlock()
+
+		// Capacity held by other reservations comes back on Release rather
+		// than on a refill, so poll at least that often
+		if waitTime > time.Second {
+			waitTime = time.Second
+		}
+		if waitTime < 10*time.Millisecond {
+			waitTime = 10 * time.Millisecond
+		}
+
+		timer := time.NewTimer(waitTime)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Reserve takes n tokens of the given limit type for a reservation. Limit
+// types without a bucket are not enforced and get an empty reservation.
+func (rl *RateLimiter) Reserve(ctx context.Context, limitType string, n int64) (*Reservation, error) {
+	rl.mu.RLock()
+	bucket, exists := rl.buckets[limitType]
+	rl.mu.RUnlock()
+
+	if !exists {
+		return &Reservation{remaining: n}, nil
+	}
+	return bucket.Reserve(ctx, n)
+}
+
+// SetMaxReservedFraction caps reservations on every bucket at a fraction
+// of its capacity. Zero removes the caps.
+func (rl *RateLimiter) SetMaxReservedFraction(f float64) {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	for _, bucket := range rl.buckets {
+		bucket.SetMaxReserved(int64(float64(bucket.capacity) * f))
+	}
+}
+
+// Commit records n tokens of actual usage. Usage beyond what is left of
+// the reservation is taken from the bucket, possibly into debt.
+func (r *Reservation) Commit(n int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrReservationClosed
+	}
+
+	covered := min(n, r.remaining)
+	r.remaining -= covered
+	r.used += n
+	if r.bucket == nil {
+		return nil
+	}
+
+	r.bucket.mu.Lock()
+	defer r.bucket.mu.Unlock()
+	r.bucket.reserved -= covered
+	if extra := n - covered; extra > 0 {
+		r.bucket.refill()
+		r.bucket.tokens -= extra
+	}
+	return nil
+}
+
+// Release returns the unused part of the reservation to the bucket and
+// closes it, reporting how many tokens were returned. Releasing again
+// returns nothing.
+func (r *Reservation) Release() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0
+	}
+	r.closed = true
+	unused := r.remaining
+	r.remaining = 0
+	if r.bucket == nil || unused == 0 {
+		return unused
+	}
+
+	r.bucket.mu.Lock()
+	defer r.bucket.mu.Unlock()
+	r.bucket.reserved -= unused
+	r.bucket.refill()
+	r.bucket.tokens = min(r.bucket.capacity, r.bucket.tokens+unused)
+	return unused
+}
+
+// Remaining returns the reserved tokens not yet committed
+func (r *Reservation) Remaining() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.remaining
+}
+
+// Used returns the tokens committed so far
+func (r *Reservation) Used() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.used
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReservation_CommitAndRelease(t *testing.T) {
+	bucket := NewTokenBucket(100, 100, time.Hour)
+
+	r, err := bucket.Reserve(context.Background(), 60)
+	if err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	if got := bucket.GetAvailableTokens(); got != 40 {
+		t.Errorf("Expected 40 tokens left for other traffic, got %d", got)
+	}
+
+	if err := r.Commit(25); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if r.Remaining() != 35 || r.Used() != 25 || bucket.Reserved() != 35 {
+		t.Errorf("Unexpected state after commit: remaining=%d used=%d reserved=%d", r.Remaining(), r.Used(), bucket.Reserved())
+	}
+
+	if returned := r.Release(); returned != 35 {
+		t.Errorf("Expected 35 tokens returned, got %d", returned)
+	}
+	if got := bucket.GetAvailableTokens(); got != 75 {
+		t.Errorf("Expected 75 tokens after release, got %d", got)
+	}
+	if bucket.Reserved() != 0 {
+		t.Errorf("Expected no reserved tokens, got %d", bucket.Reserved())
+	}
+
+	if returned := r.Release(); returned != 0 {
+		t.Errorf("Second release should return nothing, got %d", returned)
+	}
+	if err := r.Commit(1); !errors.Is(err, ErrReservationClosed) {
+		t.Errorf("Expected ErrReservationClosed, got %v", err)
+	}
+}
+
+func TestReservation_CommitOverage(t *testing.T) {
+	bucket := NewTokenBucket(100, 100, time.Hour)
+	r, err := bucket.Reserve(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+
+	// Usage beyond the reservation comes out of the shared bucket
+	r.Commit(30)
+	if got := bucket.GetAvailableTokens(); got != 70 {
+		t.Errorf("Expected 70 tokens after overage, got %d", got)
+	}
+	if r.Release() != 0 || bucket.Reserved() != 0 {
+		t.Errorf("Exhausted reservation should leave nothing reserved")
+	}
+}
+
+func TestReservation_MaxReserved(t *testing.T) {
+	bucket := NewTokenBucket(100, 100, time.Hour)
+	bucket.SetMaxReserved(50)
+
+	if _, err := bucket.Reserve(context.Background(), 80); !errors.Is(err, ErrReservationTooLarge) {
+		t.Errorf("Expected ErrReservationTooLarge, got %v", err)
+	}
+	if _, err := bucket.Reserve(context.Background(), 200); !errors.Is(err, ErrReservationTooLarge) {
+		t.Errorf("Expected ErrReservationTooLarge above capacity, got %v", err)
+	}
+
+	first, err := bucket.Reserve(context.Background(), 40)
+	if err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+
+	// A second reservation has to wait for the first, while interactive
+	// traffic can still use the unreserved half
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := bucket.Reserve(ctx, 20); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected reservation to wait past the cap, got %v", err)
+	}
+	if !bucket.TryAcquire(50) {
+		t.Error("Interactive traffic should not be starved by reservations")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := bucket.Reserve(context.Background(), 15)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	first.Release()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Waiting reservation failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Waiting reservation was not granted after release")
+	}
+}
+
+func TestRateLimiter_ReserveUnknownLimit(t *testing.T) {
+	rl := &RateLimiter{buckets: map[string]*TokenBucket{"rpm": NewTokenBucket(10, 10, time.Minute)}}
+	rl.SetMaxReservedFraction(0.5)
+
+	r, err := rl.Reserve(context.Background(), "tpm", 1000)
+	if err != nil {
+		t.Fatalf("Reserve on unlimited type failed: %v", err)
+	}
+	r.Commit(400)
+	if returned := r.Release(); returned != 600 {
+		t.Errorf("Expected 600 unused, got %d", returned)
+	}
+
+	if _, err := rl.Reserve(context.Background(), "rpm", 6); !errors.Is(err, ErrReservationTooLarge) {
+		t.Errorf("Expected fraction cap to apply, got %v", err)
+	}
+	if info := rl.GetRateLimitInfo(); info["rpm"]["reserved"] != int64(0) {
+		t.Errorf("Expected reserved in rate limit info, got %v", info["rpm"])
+	}
+}
@@ -3,14 +3,17 @@ package router
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/jeffersonwarrior/modelscan/internal/proxy"
 	"github.com/jeffersonwarrior/modelscan/sdk/ratelimit"
 	"github.com/jeffersonwarrior/modelscan/storage"
 )
 
-// RoutingStrategy determines how to select a provider
+// RoutingStrategy names a registered Strategy that selects a provider
 type RoutingStrategy string
 
 const (
@@ -38,9 +41,13 @@ type ProviderOption struct {
 	ProviderName  string
 	ModelID       string
 	PlanType      string
-	InputCost     float64
-	OutputCost    float64
-	EstimatedCost float64
+	InputCost     float64 // USD per million input tokens
+	OutputCost    float64 // USD per million output tokens
+	EstimatedCost float64 // Expected cost of the request
+	WorstCaseCost float64 // Cost if the completion uses all of MaxOutputTokens
+	Quality       storage.QualityTier
+	QualityScore  float64
+	Capabilities  storage.ModelCapabilities // Zero when the provider has no capability data
 	AvgLatencyMs  int64
 	IsAvailable   bool
 	RateLimiter   *ratelimit.RateLimiter
@@ -50,19 +57,44 @@ type ProviderOption struct {
 // Router selects the best provider based on strategy
 type Router struct {
 	strategy      RoutingStrategy
+	picker        Strategy
 	healthTracker map[string]*ProviderHealth
-	rrIndex       int // Round-robin index
+	tenantHealth  map[string]*ProviderHealth // Keyed by tenant ID and provider
+	incidents     IncidentSource             // Optional status page incidents
 	mu            sync.RWMutex
 }
 
-// RouteRequest contains the routing decision context
+// IncidentSource reports providers with an ongoing incident on their status
+// page, such as a *statuspage.Watcher
+type IncidentSource interface {
+	Degraded(provider string) (reason string, degraded bool)
+}
+
+// RouteRequest contains the routing decision context. Costs are estimated
+// from InputTokens (or Prompt, run through the tokenizer) plus the expected
+// completion length; EstimatedTokens alone is split evenly between the two.
+// Capability requirements are checked against the capability matrix, and
+// models without capability data never satisfy one. Requests for a tenant
+// also skip providers that are failing for that tenant, whose own keys may
+// be exhausted while the shared ones still work.
 type RouteRequest struct {
-	Capability       string   // "chat", "embedding", "image", "audio", "video"
-	EstimatedTokens  int64    // Input + output token estimate
-	MaxCost          float64  // Budget constraint
-	MaxLatencyMs     int64    // Latency requirement
-	RequiredModels   []string // Specific models to consider
-	ExcludeProviders []string // Providers to avoid
+	Capability           string              // "chat", "embedding", "image", "audio", "video"
+	EstimatedTokens      int64               // Input + output token estimate, when the parts are unknown
+	InputTokens          int64               // Prompt tokens
+	Prompt               string              // Prompt text, counted when InputTokens is zero
+	ExpectedOutputTokens int64               // Likely completion length (default DefaultExpectedOutputTokens)
+	MaxOutputTokens      int64               // Completion cap (default DefaultMaxOutputTokens)
+	MaxCost              float64             // Budget constraint on the worst-case cost
+	MaxLatencyMs         int64               // Latency requirement
+	MinQuality           storage.QualityTier // Lowest capability tier allowed; unrated models are excluded
+	NeedsVision          bool                // Request contains images
+	NeedsTools           bool                // Request uses tool calling
+	NeedsJSONMode        bool                // Request asks for a JSON response format
+	MinContextTokens     int64               // Smallest context window that fits the request
+	Region               string              // Region the request must be served from
+	RequiredModels       []string            // Specific models to consider
+	ExcludeProviders     []string            // Providers to avoid
+	TenantID             string              // Tenant served, for per-tenant provider health
 }
 
 // RouteResult contains the selected provider
@@ -73,12 +105,88 @@ type RouteResult struct {
 	EstimatedCost float64
 }
 
-// NewRouter creates a new intelligent router
+// Completion lengths assumed when a request does not give them
+const (
+	DefaultExpectedOutputTokens = 512
+	DefaultMaxOutputTokens      = 4096
+)
+
+// TokenEstimate is the expected size of a request, used to price it
+type TokenEstimate struct {
+	InputTokens          int64
+	ExpectedOutputTokens int64
+	MaxOutputTokens      int64
+}
+
+// Cost returns the expected cost in USD at prices per million tokens
+func (e TokenEstimate) Cost(inputPer1M, outputPer1M float64) float64 {
+	return (float64(e.InputTokens)*inputPer1M + float64(e.ExpectedOutputTokens)*outputPer1M) / 1_000_000
+}
+
+// WorstCaseCost returns the cost in USD if the completion runs to
+// MaxOutputTokens
+func (e TokenEstimate) WorstCaseCost(inputPer1M, outputPer1M float64) float64 {
+	return (float64(e.InputTokens)*inputPer1M + float64(e.MaxOutputTokens)*outputPer1M) / 1_000_000
+}
+
+// NewTokenEstimate fills in the default output lengths and keeps the
+// expected length within the cap
+func NewTokenEstimate(input, expectedOutput, maxOutput int64) TokenEstimate {
+	if maxOutput <= 0 {
+		maxOutput = DefaultMaxOutputTokens
+	}
+	if expectedOutput <= 0 {
+		expectedOutput = DefaultExpectedOutputTokens
+	}
+	return TokenEstimate{
+		InputTokens:          input,
+		ExpectedOutputTokens: min(expectedOutput, maxOutput),
+		MaxOutputTokens:      maxOutput,
+	}
+}
+
+// Estimate returns the request's token estimate
+func (req RouteRequest) Estimate() TokenEstimate {
+	input, expected := req.InputTokens, req.ExpectedOutputTokens
+	if input == 0 && req.Prompt != "" {
+		input = int64(proxy.CountTokens(req.Prompt))
+	}
+	if input == 0 && req.EstimatedTokens > 0 {
+		input = req.EstimatedTokens / 2
+		if expected == 0 {
+			expected = req.EstimatedTokens - input
+		}
+	}
+	return NewTokenEstimate(input, expected, req.MaxOutputTokens)
+}
+
+// NewRouter creates a new intelligent router using the strategy
+// registered under strategy. An unknown name, including a misspelled one,
+// routes with StrategyBalanced; use NewRouterWithStrategy to reject it.
 func NewRouter(strategy RoutingStrategy) *Router {
 	return &Router{
 		strategy:      strategy,
+		picker:        newStrategy(strategy),
 		healthTracker: make(map[string]*ProviderHealth),
+		tenantHealth:  make(map[string]*ProviderHealth),
+	}
+}
+
+// NewRouterWithStrategy creates a router like NewRouter, but returns an
+// error if no strategy is registered under name
+func NewRouterWithStrategy(name RoutingStrategy) (*Router, error) {
+	if _, ok := LookupStrategy(name); !ok {
+		return nil, fmt.Errorf("unknown routing strategy %q (registered: %v)", name, Strategies())
 	}
+	return NewRouter(name), nil
+}
+
+// SetIncidents makes routing treat providers with an ongoing status page
+// incident as unhealthy, before their requests start failing
+func (r *Router) SetIncidents(src IncidentSource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.incidents = src
 }
 
 // Route selects the best provider for the request
@@ -93,29 +201,15 @@ func (r *Router) Route(ctx context.Context, req RouteRequest) (*RouteResult, err
 		return nil, fmt.Errorf("no providers available for capability: %s", req.Capability)
 	}
 
-	// Filter by budget and latency constraints
+	// Filter by capability, budget and latency constraints
 	filtered := r.filterProviders(providers, req)
 	if len(filtered) == 0 {
-		return nil, fmt.Errorf("no providers meet constraints (budget: $%.4f, latency: %dms)", req.MaxCost, req.MaxLatencyMs)
+		return nil, r.constraintError(providers, req)
 	}
 
-	// Select based on strategy
-	var selected *ProviderOption
-	var reason string
-
-	switch r.strategy {
-	case StrategyCheapest:
-		selected, reason = r.selectCheapest(filtered)
-	case StrategyFastest:
-		selected, reason = r.selectFastest(filtered)
-	case StrategyBalanced:
-		selected, reason = r.selectBalanced(filtered)
-	case StrategyRoundRobin:
-		selected, reason = r.selectRoundRobin(filtered)
-	case StrategyFallback:
-		selected, reason = r.selectFallback(filtered)
-	default:
-		selected, reason = r.selectBalanced(filtered)
+	selected, reason := r.picker.Pick(ctx, filtered, req)
+	if selected == nil {
+		return nil, fmt.Errorf("strategy %s selected no provider", r.strategy)
 	}
 
 	return &RouteResult{
@@ -131,8 +225,15 @@ func (r *Router) getAvailableProviders(ctx context.Context, req RouteRequest) ([
 	// Query database for providers with pricing
 	query := `
 		SELECT DISTINCT p.provider_name, p.model_id, p.plan_type, 
-		       p.input_cost, p.output_cost
+		       p.input_cost, p.output_cost,
+		       COALESCE(q.tier, 0), COALESCE(q.score, 0),
+		       COALESCE(c.supports_vision, 0), COALESCE(c.supports_tools, 0),
+		       COALESCE(c.supports_json_mode, 0), COALESCE(c.context_tokens, 0),
+		       COALESCE(c.regions, '')
 		FROM provider_pricing p
+		LEFT JOIN model_quality q ON q.model_id = p.model_id
+		LEFT JOIN model_capabilities c
+		       ON c.provider_name = p.provider_name AND c.model_id = p.model_id
 		WHERE p.input_cost > 0 OR p.output_cost > 0
 	`
 
@@ -142,20 +243,24 @@ func (r *Router) getAvailableProviders(ctx context.Context, req RouteRequest) ([
 	}
 	defer rows.Close()
 
+	estimate := req.Estimate()
 	var providers []*ProviderOption
 	for rows.Next() {
 		var opt ProviderOption
+		var regions string
+		caps := &opt.Capabilities
 		err := rows.Scan(&opt.ProviderName, &opt.ModelID, &opt.PlanType,
-			&opt.InputCost, &opt.OutputCost)
+			&opt.InputCost, &opt.OutputCost, &opt.Quality, &opt.QualityScore,
+			&caps.Vision, &caps.Tools, &caps.JSONMode, &caps.ContextTokens, &regions)
 		if err != nil {
 			continue
 		}
+		caps.ProviderName, caps.ModelID = opt.ProviderName, opt.ModelID
+		caps.Regions = storage.SplitRegions(regions)
 
-		// Calculate estimated cost (assuming 50/50 input/output split)
-		inputTokens := req.EstimatedTokens / 2
-		outputTokens := req.EstimatedTokens / 2
-		opt.EstimatedCost = (float64(inputTokens) * opt.InputCost / 1_000_000) +
-			(float64(outputTokens) * opt.OutputCost / 1_000_000)
+		// Price the request at this model's rates
+		opt.EstimatedCost = estimate.Cost(opt.InputCost, opt.OutputCost)
+		opt.WorstCaseCost = estimate.WorstCaseCost(opt.InputCost, opt.OutputCost)
 
 		// Check if provider is in exclude list
 		if r.isExcluded(opt.ProviderName, req.ExcludeProviders) {
@@ -170,12 +275,20 @@ func (r *Router) getAvailableProviders(ctx context.Context, req RouteRequest) ([
 		} else {
 			opt.RateLimiter = limiter
 			// Check if rate limit allows this request
-			opt.IsAvailable = r.checkRateLimitAvailability(ctx, limiter, req.EstimatedTokens)
+			opt.IsAvailable = r.checkRateLimitAvailability(ctx, limiter, estimate.InputTokens+estimate.ExpectedOutputTokens)
 		}
 
 		// Get health status
 		opt.Health = r.getHealth(opt.ProviderName)
-		if !opt.Health.IsHealthy {
+		healthy := opt.Health.IsHealthy
+		if req.TenantID != "" {
+			opt.Health = r.getTenantHealth(req.TenantID, opt.ProviderName)
+			healthy = healthy && opt.Health.IsHealthy
+		}
+		if healthy && r.incidentFor(opt.ProviderName) {
+			healthy = false
+		}
+		if !healthy {
 			opt.IsAvailable = false
 		}
 
@@ -189,142 +302,91 @@ func (r *Router) getAvailableProviders(ctx context.Context, req RouteRequest) ([
 func (r *Router) filterProviders(providers []*ProviderOption, req RouteRequest) []*ProviderOption {
 	var filtered []*ProviderOption
 	for _, p := range providers {
-		// Must be available
-		if !p.IsAvailable {
-			continue
+		if r.rejectedBy(p, req) == "" {
+			filtered = append(filtered, p)
 		}
-
-		// Must be within budget
-		if req.MaxCost > 0 && p.EstimatedCost > req.MaxCost {
-			continue
-		}
-
-		// Must meet latency requirement
-		if req.MaxLatencyMs > 0 && p.AvgLatencyMs > req.MaxLatencyMs {
-			continue
-		}
-
-		// Must match required models if specified
-		if len(req.RequiredModels) > 0 && !r.matchesModel(p.ModelID, req.RequiredModels) {
-			continue
-		}
-
-		filtered = append(filtered, p)
 	}
 	return filtered
 }
 
-// selectCheapest picks the lowest cost provider
-func (r *Router) selectCheapest(providers []*ProviderOption) (*ProviderOption, string) {
-	if len(providers) == 0 {
-		return nil, ""
-	}
-
-	cheapest := providers[0]
-	for _, p := range providers[1:] {
-		if p.EstimatedCost < cheapest.EstimatedCost {
-			cheapest = p
-		}
+// rejectedBy returns the first constraint the provider fails, or "" if it
+// meets them all. Capability requirements are checked first since no other
+// setting can make up for a missing capability.
+func (r *Router) rejectedBy(p *ProviderOption, req RouteRequest) string {
+	caps := p.Capabilities
+	switch {
+	// Must match required models if specified
+	case len(req.RequiredModels) > 0 && !r.matchesModel(p.ModelID, req.RequiredModels):
+		return "required_models"
+
+	// Must support every requested feature
+	case req.NeedsVision && !caps.Vision:
+		return "needs_vision"
+	case req.NeedsTools && !caps.Tools:
+		return "needs_tools"
+	case req.NeedsJSONMode && !caps.JSONMode:
+		return "needs_json_mode"
+	case req.MinContextTokens > 0 && caps.ContextTokens < req.MinContextTokens:
+		return fmt.Sprintf("min_context_tokens=%d", req.MinContextTokens)
+	case req.Region != "" && !caps.HasRegion(req.Region):
+		return "region=" + req.Region
+
+	// Must be capable enough
+	case req.MinQuality > storage.TierUnrated && p.Quality < req.MinQuality:
+		return "min_quality=" + req.MinQuality.String()
+
+	// Must be available
+	case !p.IsAvailable:
+		return "unavailable (rate limited or unhealthy)"
+
+	// Must be within budget even if the completion runs to its cap
+	case req.MaxCost > 0 && p.WorstCaseCost > req.MaxCost:
+		return fmt.Sprintf("max_cost=$%.4f", req.MaxCost)
+
+	// Must meet latency requirement
+	case req.MaxLatencyMs > 0 && p.AvgLatencyMs > req.MaxLatencyMs:
+		return fmt.Sprintf("max_latency=%dms", req.MaxLatencyMs)
 	}
-
-	return cheapest, fmt.Sprintf("cheapest option at $%.6f", cheapest.EstimatedCost)
+	return ""
 }
 
-// selectFastest picks the lowest latency provider
-func (r *Router) selectFastest(providers []*ProviderOption) (*ProviderOption, string) {
-	if len(providers) == 0 {
-		return nil, ""
-	}
-
-	fastest := providers[0]
-	for _, p := range providers[1:] {
-		if p.AvgLatencyMs < fastest.AvgLatencyMs {
-			fastest = p
-		}
-	}
-
-	return fastest, fmt.Sprintf("fastest option at %dms", fastest.AvgLatencyMs)
+// ConstraintError is returned by Route when no provider meets the request's
+// constraints. Eliminated counts the candidates each constraint removed,
+// attributing every candidate to the first constraint it failed.
+type ConstraintError struct {
+	Candidates int
+	Eliminated map[string]int
 }
 
-// selectBalanced scores providers based on cost and latency
-func (r *Router) selectBalanced(providers []*ProviderOption) (*ProviderOption, string) {
-	if len(providers) == 0 {
-		return nil, ""
+func (e *ConstraintError) Error() string {
+	constraints := make([]string, 0, len(e.Eliminated))
+	for c := range e.Eliminated {
+		constraints = append(constraints, c)
 	}
-
-	// Normalize and score (lower is better)
-	type scored struct {
-		provider *ProviderOption
-		score    float64
-	}
-
-	var maxCost, maxLatency float64
-	for _, p := range providers {
-		if p.EstimatedCost > maxCost {
-			maxCost = p.EstimatedCost
+	sort.Slice(constraints, func(i, j int) bool {
+		ci, cj := e.Eliminated[constraints[i]], e.Eliminated[constraints[j]]
+		if ci != cj {
+			return ci > cj
 		}
-		if float64(p.AvgLatencyMs) > maxLatency {
-			maxLatency = float64(p.AvgLatencyMs)
-		}
-	}
-
-	if maxCost == 0 {
-		maxCost = 1
-	}
-	if maxLatency == 0 {
-		maxLatency = 1
-	}
+		return constraints[i] < constraints[j]
+	})
 
-	var scores []scored
-	for _, p := range providers {
-		// Weighted score: 60% cost, 40% latency
-		costScore := p.EstimatedCost / maxCost
-		latencyScore := float64(p.AvgLatencyMs) / maxLatency
-		totalScore := (0.6 * costScore) + (0.4 * latencyScore)
-		scores = append(scores, scored{p, totalScore})
+	parts := make([]string, len(constraints))
+	for i, c := range constraints {
+		parts[i] = fmt.Sprintf("%s eliminated %d", c, e.Eliminated[c])
 	}
-
-	// Find best score (lowest)
-	best := scores[0]
-	for _, s := range scores[1:] {
-		if s.score < best.score {
-			best = s
-		}
-	}
-
-	return best.provider, fmt.Sprintf("balanced score %.3f (cost: $%.6f, latency: %dms)",
-		best.score, best.provider.EstimatedCost, best.provider.AvgLatencyMs)
-}
-
-// selectRoundRobin cycles through healthy providers
-func (r *Router) selectRoundRobin(providers []*ProviderOption) (*ProviderOption, string) {
-	if len(providers) == 0 {
-		return nil, ""
-	}
-
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	selected := providers[r.rrIndex%len(providers)]
-	r.rrIndex++
-
-	return selected, fmt.Sprintf("round-robin selection #%d", r.rrIndex)
+	return fmt.Sprintf("no providers meet constraints (%d candidates: %s)", e.Candidates, strings.Join(parts, ", "))
 }
 
-// selectFallback tries primary, then fallbacks
-func (r *Router) selectFallback(providers []*ProviderOption) (*ProviderOption, string) {
-	// First healthy provider
-	for i, p := range providers {
-		if p.Health.IsHealthy {
-			reason := "primary"
-			if i > 0 {
-				reason = fmt.Sprintf("fallback #%d", i)
-			}
-			return p, reason
+// constraintError explains why every provider was filtered out
+func (r *Router) constraintError(providers []*ProviderOption, req RouteRequest) error {
+	err := &ConstraintError{Candidates: len(providers), Eliminated: make(map[string]int)}
+	for _, p := range providers {
+		if reason := r.rejectedBy(p, req); reason != "" {
+			err.Eliminated[reason]++
 		}
 	}
-	// All unhealthy - return first anyway
-	return providers[0], "all unhealthy, using first"
+	return err
 }
 
 // checkRateLimitAvailability checks if rate limiter would allow the request
@@ -348,10 +410,32 @@ func (r *Router) checkRateLimitAvailability(ctx context.Context, limiter *rateli
 	return true
 }
 
+// incidentFor reports whether the provider has an ongoing incident
+func (r *Router) incidentFor(providerName string) bool {
+	r.mu.RLock()
+	src := r.incidents
+	r.mu.RUnlock()
+	if src == nil {
+		return false
+	}
+	_, degraded := src.Degraded(providerName)
+	return degraded
+}
+
 // getHealth retrieves or creates health tracker for provider
 func (r *Router) getHealth(providerName string) *ProviderHealth {
+	return r.tracker(r.healthTracker, providerName, providerName)
+}
+
+// getTenantHealth retrieves or creates a tenant's health tracker for provider
+func (r *Router) getTenantHealth(tenantID, providerName string) *ProviderHealth {
+	return r.tracker(r.tenantHealth, tenantID+"/"+providerName, providerName)
+}
+
+// tracker retrieves or creates the health tracker stored under key
+func (r *Router) tracker(trackers map[string]*ProviderHealth, key, providerName string) *ProviderHealth {
 	r.mu.RLock()
-	health, exists := r.healthTracker[providerName]
+	health, exists := trackers[key]
 	r.mu.RUnlock()
 
 	if exists {
@@ -362,7 +446,7 @@ func (r *Router) getHealth(providerName string) *ProviderHealth {
 	defer r.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	health, exists = r.healthTracker[providerName]
+	health, exists = trackers[key]
 	if exists {
 		return health
 	}
@@ -375,13 +459,42 @@ func (r *Router) getHealth(providerName string) *ProviderHealth {
 		IsHealthy:        true,
 		ConsecutiveFails: 0,
 	}
-	r.healthTracker[providerName] = health
+	trackers[key] = health
 	return health
 }
 
 // RecordSuccess updates health metrics after successful request
 func (r *Router) RecordSuccess(providerName string, latencyMs int64) {
-	health := r.getHealth(providerName)
+	r.getHealth(providerName).recordSuccess(latencyMs)
+}
+
+// RecordFailure updates health metrics after failed request
+func (r *Router) RecordFailure(providerName string, err error) {
+	r.getHealth(providerName).recordFailure()
+}
+
+// RecordTenantSuccess updates a tenant's health metrics for a provider
+// after a successful request. An empty tenant ID records globally.
+func (r *Router) RecordTenantSuccess(tenantID, providerName string, latencyMs int64) {
+	if tenantID == "" {
+		r.RecordSuccess(providerName, latencyMs)
+		return
+	}
+	r.getTenantHealth(tenantID, providerName).recordSuccess(latencyMs)
+}
+
+// RecordTenantFailure updates a tenant's health metrics for a provider
+// after a failed request, leaving the provider's health for other tenants
+// untouched. An empty tenant ID records globally.
+func (r *Router) RecordTenantFailure(tenantID, providerName string, err error) {
+	if tenantID == "" {
+		r.RecordFailure(providerName, err)
+		return
+	}
+	r.getTenantHealth(tenantID, providerName).recordFailure()
+}
+
+func (health *ProviderHealth) recordSuccess(latencyMs int64) {
 	health.mu.Lock()
 	defer health.mu.Unlock()
 
@@ -394,9 +507,7 @@ func (r *Router) RecordSuccess(providerName string, latencyMs int64) {
 	health.ErrorRate = health.ErrorRate * 0.95 // Decay error rate
 }
 
-// RecordFailure updates health metrics after failed request
-func (r *Router) RecordFailure(providerName string, err error) {
-	health := r.getHealth(providerName)
+func (health *ProviderHealth) recordFailure() {
 	health.mu.Lock()
 	defer health.mu.Unlock()
 
@@ -2,7 +2,9 @@ package router
 
 import (
 	"context"
+	"errors"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/jeffersonwarrior/modelscan/scraper"
@@ -24,6 +26,12 @@ func setupRouterTest(t *testing.T) string {
 	if err := scraper.SeedInitialPricing(); err != nil {
 		t.Fatalf("Failed to seed pricing: %v", err)
 	}
+	if err := scraper.SeedInitialQuality(); err != nil {
+		t.Fatalf("Failed to seed quality: %v", err)
+	}
+	if err := scraper.SeedInitialCapabilities(); err != nil {
+		t.Fatalf("Failed to seed capabilities: %v", err)
+	}
 
 	return dbPath
 }
@@ -195,6 +203,90 @@ func TestRouter_HealthTracking_RecoverAfterSuccess(t *testing.T) {
 	}
 }
 
+func TestRouter_TenantHealth(t *testing.T) {
+	dbPath := setupRouterTest(t)
+	defer teardownRouterTest(t, dbPath)
+
+	router := NewRouter(StrategyCheapest)
+
+	// Failures with one tenant's keys do not affect other tenants
+	for i := 0; i < 3; i++ {
+		router.RecordTenantFailure("team-a", "openai", nil)
+	}
+	if !router.getHealth("openai").IsHealthy {
+		t.Error("tenant failures should not mark the provider unhealthy globally")
+	}
+
+	openaiAvailable := func(tenantID string) bool {
+		providers, err := router.getAvailableProviders(context.Background(), RouteRequest{Capability: "chat", TenantID: tenantID})
+		if err != nil {
+			t.Fatalf("getAvailableProviders failed: %v", err)
+		}
+		available := false
+		for _, p := range providers {
+			if p.ProviderName == "openai" && p.IsAvailable {
+				available = true
+			}
+		}
+		return available
+	}
+	if openaiAvailable("team-a") {
+		t.Error("openai should be unavailable for team-a")
+	}
+	if !openaiAvailable("team-b") || !openaiAvailable("") {
+		t.Error("openai should stay available for other tenants")
+	}
+
+	// Global outages apply to every tenant
+	for i := 0; i < 3; i++ {
+		router.RecordTenantFailure("", "openai", nil)
+	}
+	if openaiAvailable("team-b") {
+		t.Error("a globally unhealthy provider should be unavailable for tenants")
+	}
+
+	router.RecordTenantSuccess("team-a", "openai", 100)
+	if !router.getTenantHealth("team-a", "openai").IsHealthy {
+		t.Error("tenant health should recover after success")
+	}
+}
+
+// incidentSet reports fixed providers as having an ongoing incident
+type incidentSet map[string]string
+
+func (s incidentSet) Degraded(provider string) (string, bool) {
+	reason, ok := s[provider]
+	return reason, ok
+}
+
+func TestRouter_IncidentsMarkProvidersUnavailable(t *testing.T) {
+	dbPath := setupRouterTest(t)
+	defer teardownRouterTest(t, dbPath)
+
+	router := NewRouter(StrategyCheapest)
+	router.SetIncidents(incidentSet{"openai": "major incident: elevated errors"})
+
+	providers, err := router.getAvailableProviders(context.Background(), RouteRequest{Capability: "chat"})
+	if err != nil {
+		t.Fatalf("getAvailableProviders failed: %v", err)
+	}
+	seen := false
+	for _, p := range providers {
+		if p.ProviderName == "openai" {
+			seen = true
+			if p.IsAvailable {
+				t.Error("openai should be unavailable during an incident")
+			}
+		}
+	}
+	if !seen {
+		t.Fatal("expected openai among the providers")
+	}
+	if !router.getHealth("openai").IsHealthy {
+		t.Error("incidents should not change the request-based health record")
+	}
+}
+
 func TestRouter_LatencyTracking_ExponentialMovingAverage(t *testing.T) {
 	dbPath := setupRouterTest(t)
 	defer teardownRouterTest(t, dbPath)
@@ -298,8 +390,7 @@ func TestRouter_Fallback_SelectsPrimaryFirst(t *testing.T) {
 		{ProviderName: "fallback2", Health: &ProviderHealth{IsHealthy: true}},
 	}
 
-	router := NewRouter(StrategyFallback)
-	selected, reason := router.selectFallback(providers)
+	selected, reason := newStrategy(StrategyFallback).Pick(context.Background(), providers, RouteRequest{})
 
 	if selected.ProviderName != "primary" {
 		t.Errorf("Should select primary when healthy, got %s", selected.ProviderName)
@@ -316,8 +407,7 @@ func TestRouter_Fallback_UsesBackupWhenPrimaryUnhealthy(t *testing.T) {
 		{ProviderName: "fallback2", Health: &ProviderHealth{IsHealthy: true}},
 	}
 
-	router := NewRouter(StrategyFallback)
-	selected, reason := router.selectFallback(providers)
+	selected, reason := newStrategy(StrategyFallback).Pick(context.Background(), providers, RouteRequest{})
 
 	if selected.ProviderName != "fallback1" {
 		t.Errorf("Should select first healthy fallback, got %s", selected.ProviderName)
@@ -405,3 +495,161 @@ func TestRouter_MatchesModel(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteRequest_Estimate(t *testing.T) {
+	// EstimatedTokens alone is split evenly
+	if e := (RouteRequest{EstimatedTokens: 1000}).Estimate(); e.InputTokens != 500 || e.ExpectedOutputTokens != 500 || e.MaxOutputTokens != DefaultMaxOutputTokens {
+		t.Errorf("legacy estimate = %+v", e)
+	}
+
+	// The prompt is counted with the tokenizer
+	e := (RouteRequest{Prompt: "Summarize the quarterly report in three bullet points."}).Estimate()
+	if e.InputTokens < 8 || e.InputTokens > 20 {
+		t.Errorf("prompt estimate = %d tokens", e.InputTokens)
+	}
+	if e.ExpectedOutputTokens != DefaultExpectedOutputTokens {
+		t.Errorf("expected output = %d, want default", e.ExpectedOutputTokens)
+	}
+
+	// The expected length never exceeds the cap
+	if e := (RouteRequest{InputTokens: 100, MaxOutputTokens: 50}).Estimate(); e.ExpectedOutputTokens != 50 || e.MaxOutputTokens != 50 {
+		t.Errorf("capped estimate = %+v", e)
+	}
+}
+
+func TestTokenEstimate_Cost(t *testing.T) {
+	e := TokenEstimate{InputTokens: 1_000_000, ExpectedOutputTokens: 500_000, MaxOutputTokens: 2_000_000}
+	if got := e.Cost(2, 10); got != 7 {
+		t.Errorf("Cost = %v, want 7", got)
+	}
+	if got := e.WorstCaseCost(2, 10); got != 22 {
+		t.Errorf("WorstCaseCost = %v, want 22", got)
+	}
+}
+
+func TestRouter_MaxCostExcludesWorstCase(t *testing.T) {
+	router := NewRouter(StrategyCheapest)
+	estimate := NewTokenEstimate(1000, 100, 4000)
+
+	// Cheap input but expensive output: cheapest expected, over budget at worst
+	long := &ProviderOption{ProviderName: "long", InputCost: 0.1, OutputCost: 20, IsAvailable: true}
+	flat := &ProviderOption{ProviderName: "flat", InputCost: 5, OutputCost: 5, IsAvailable: true}
+	for _, p := range []*ProviderOption{long, flat} {
+		p.EstimatedCost = estimate.Cost(p.InputCost, p.OutputCost)
+		p.WorstCaseCost = estimate.WorstCaseCost(p.InputCost, p.OutputCost)
+	}
+	if long.EstimatedCost >= flat.EstimatedCost {
+		t.Fatalf("fixture: long should be cheaper in expectation (%v vs %v)", long.EstimatedCost, flat.EstimatedCost)
+	}
+
+	all := []*ProviderOption{long, flat}
+	if selected, _ := selectCheapest(context.Background(), router.filterProviders(all, RouteRequest{}), RouteRequest{}); selected != long {
+		t.Errorf("without a budget selected %s, want long", selected.ProviderName)
+	}
+	filtered := router.filterProviders(all, RouteRequest{MaxCost: 0.05})
+	if len(filtered) != 1 || filtered[0] != flat {
+		t.Errorf("budget kept %d providers, want only flat", len(filtered))
+	}
+}
+
+func TestRouter_MinQuality(t *testing.T) {
+	dbPath := setupRouterTest(t)
+	defer teardownRouterTest(t, dbPath)
+
+	router := NewRouter(StrategyCheapest)
+	ctx := context.Background()
+
+	cheapest, err := router.Route(ctx, RouteRequest{Capability: "chat", EstimatedTokens: 1000})
+	if err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+	if cheapest.Provider.Quality >= storage.TierAdvanced {
+		t.Skipf("cheapest model %s is already advanced", cheapest.Provider.ModelID)
+	}
+
+	for _, strategy := range []RoutingStrategy{StrategyCheapest, StrategyBalanced} {
+		router := NewRouter(strategy)
+		result, err := router.Route(ctx, RouteRequest{Capability: "chat", EstimatedTokens: 1000, MinQuality: storage.TierAdvanced})
+		if err != nil {
+			t.Fatalf("%s: Route failed: %v", strategy, err)
+		}
+		if result.Provider.Quality < storage.TierAdvanced {
+			t.Errorf("%s routed to %s (%s)", strategy, result.Provider.ModelID, result.Provider.Quality)
+		}
+		for _, alt := range result.Alternatives {
+			if alt.Quality < storage.TierAdvanced {
+				t.Errorf("%s kept %s (%s) as an alternative", strategy, alt.ModelID, alt.Quality)
+			}
+		}
+	}
+
+	// An admin downgrade takes the model out of the tier
+	if err := storage.SetModelQuality(storage.ModelQuality{ModelID: "deepseek-chat", Tier: storage.TierStandard, Score: 82}); err != nil {
+		t.Fatalf("SetModelQuality: %v", err)
+	}
+	result, err := NewRouter(StrategyCheapest).Route(ctx, RouteRequest{Capability: "chat", EstimatedTokens: 1000, MinQuality: storage.TierAdvanced})
+	if err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+	if result.Provider.ModelID == "deepseek-chat" {
+		t.Error("downgraded model still selected")
+	}
+}
+
+func TestRouter_CapabilityFilters(t *testing.T) {
+	dbPath := setupRouterTest(t)
+	defer teardownRouterTest(t, dbPath)
+
+	router := NewRouter(StrategyCheapest)
+	ctx := context.Background()
+
+	result, err := router.Route(ctx, RouteRequest{Capability: "chat", EstimatedTokens: 1000, NeedsVision: true, NeedsJSONMode: true})
+	if err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+	for _, p := range result.Alternatives {
+		if !p.Capabilities.Vision || !p.Capabilities.JSONMode {
+			t.Errorf("%s/%s lacks a required capability: %+v", p.ProviderName, p.ModelID, p.Capabilities)
+		}
+	}
+
+	result, err = router.Route(ctx, RouteRequest{Capability: "chat", EstimatedTokens: 1000, NeedsTools: true, MinContextTokens: 1_000_000})
+	if err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+	if result.Provider.ProviderName != "google-gemini" {
+		t.Errorf("expected a Gemini model for a 1M token context, got %s/%s", result.Provider.ProviderName, result.Provider.ModelID)
+	}
+
+	result, err = router.Route(ctx, RouteRequest{Capability: "chat", EstimatedTokens: 1000, Region: "EU"})
+	if err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+	for _, p := range result.Alternatives {
+		if !p.Capabilities.HasRegion("eu") {
+			t.Errorf("%s/%s is not served from the EU", p.ProviderName, p.ModelID)
+		}
+	}
+
+	// DeepSeek is the only provider in China and has no vision models, so
+	// the vision models all fall to the region constraint
+	_, err = router.Route(ctx, RouteRequest{Capability: "chat", EstimatedTokens: 1000, NeedsVision: true, Region: "cn"})
+	var cerr *ConstraintError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("expected a ConstraintError, got %v", err)
+	}
+	if cerr.Eliminated["needs_vision"] == 0 || cerr.Eliminated["region=cn"] != 5 {
+		t.Errorf("unexpected eliminations: %v", cerr.Eliminated)
+	}
+	if !strings.Contains(err.Error(), "region=cn eliminated 5") {
+		t.Errorf("error does not name the constraint: %v", err)
+	}
+}
+
+func TestConstraintError_Error(t *testing.T) {
+	err := &ConstraintError{Candidates: 5, Eliminated: map[string]int{"needs_tools": 1, "region=eu": 3, "max_cost=$0.0100": 1}}
+	want := "no providers meet constraints (5 candidates: region=eu eliminated 3, max_cost=$0.0100 eliminated 1, needs_tools eliminated 1)"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,189 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Strategy picks a provider from the candidates that meet a request's
+// constraints. Candidates is never empty; Pick must return one of them,
+// with a reason for the choice.
+type Strategy interface {
+	Pick(ctx context.Context, candidates []*ProviderOption, req RouteRequest) (selected *ProviderOption, reason string)
+}
+
+// StrategyFunc adapts a function to a Strategy
+type StrategyFunc func(ctx context.Context, candidates []*ProviderOption, req RouteRequest) (*ProviderOption, string)
+
+// Pick calls f
+func (f StrategyFunc) Pick(ctx context.Context, candidates []*ProviderOption, req RouteRequest) (*ProviderOption, string) {
+	return f(ctx, candidates, req)
+}
+
+// StrategyFactory creates a Strategy for one Router, so a strategy with
+// state, such as round robin's position, keeps it per router
+type StrategyFactory func() Strategy
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = make(map[RoutingStrategy]StrategyFactory)
+)
+
+// RegisterStrategy makes a strategy available to NewRouter under name,
+// typically from an init function. Custom strategies, such as one aware of
+// data residency, are compiled in this way. It panics if name is empty or
+// already registered, or factory is nil.
+func RegisterStrategy(name RoutingStrategy, factory StrategyFactory) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	if name == "" || factory == nil {
+		panic("router: RegisterStrategy needs a name and a factory")
+	}
+	if _, dup := strategies[name]; dup {
+		panic("router: RegisterStrategy called twice for strategy " + string(name))
+	}
+	strategies[name] = factory
+}
+
+// Strategies returns the names of the registered strategies, sorted
+func Strategies() []RoutingStrategy {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	names := make([]RoutingStrategy, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// LookupStrategy returns the factory registered under name
+func LookupStrategy(name RoutingStrategy) (StrategyFactory, bool) {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	factory, ok := strategies[name]
+	return factory, ok
+}
+
+// newStrategy creates the strategy registered under name, or the balanced
+// strategy when there is none
+func newStrategy(name RoutingStrategy) Strategy {
+	factory, ok := LookupStrategy(name)
+	if !ok {
+		factory, _ = LookupStrategy(StrategyBalanced)
+	}
+	return factory()
+}
+
+// The built-in strategies are registered like any other
+func init() {
+	RegisterStrategy(StrategyCheapest, func() Strategy { return StrategyFunc(selectCheapest) })
+	RegisterStrategy(StrategyFastest, func() Strategy { return StrategyFunc(selectFastest) })
+	RegisterStrategy(StrategyBalanced, func() Strategy { return StrategyFunc(selectBalanced) })
+	RegisterStrategy(StrategyRoundRobin, func() Strategy { return &roundRobin{} })
+	RegisterStrategy(StrategyFallback, func() Strategy { return StrategyFunc(selectFallback) })
+}
+
+// selectCheapest picks the lowest cost provider
+func selectCheapest(_ context.Context, providers []*ProviderOption, _ RouteRequest) (*ProviderOption, string) {
+	cheapest := providers[0]
+	for _, p := range providers[1:] {
+		if p.EstimatedCost < cheapest.EstimatedCost {
+			cheapest = p
+		}
+	}
+
+	return cheapest, fmt.Sprintf("cheapest option at $%.6f", cheapest.EstimatedCost)
+}
+
+// selectFastest picks the lowest latency provider
+func selectFastest(_ context.Context, providers []*ProviderOption, _ RouteRequest) (*ProviderOption, string) {
+	fastest := providers[0]
+	for _, p := range providers[1:] {
+		if p.AvgLatencyMs < fastest.AvgLatencyMs {
+			fastest = p
+		}
+	}
+
+	return fastest, fmt.Sprintf("fastest option at %dms", fastest.AvgLatencyMs)
+}
+
+// selectBalanced scores providers based on cost and latency
+func selectBalanced(_ context.Context, providers []*ProviderOption, _ RouteRequest) (*ProviderOption, string) {
+	// Normalize and score (lower is better)
+	type scored struct {
+		provider *ProviderOption
+		score    float64
+	}
+
+	var maxCost, maxLatency float64
+	for _, p := range providers {
+		if p.EstimatedCost > maxCost {
+			maxCost = p.EstimatedCost
+		}
+		if float64(p.AvgLatencyMs) > maxLatency {
+			maxLatency = float64(p.AvgLatencyMs)
+		}
+	}
+
+	if maxCost == 0 {
+		maxCost = 1
+	}
+	if maxLatency == 0 {
+		maxLatency = 1
+	}
+
+	var scores []scored
+	for _, p := range providers {
+		// Weighted score: 60% cost, 40% latency
+		costScore := p.EstimatedCost / maxCost
+		latencyScore := float64(p.AvgLatencyMs) / maxLatency
+		totalScore := (0.6 * costScore) + (0.4 * latencyScore)
+		scores = append(scores, scored{p, totalScore})
+	}
+
+	// Find best score (lowest)
+	best := scores[0]
+	for _, s := range scores[1:] {
+		if s.score < best.score {
+			best = s
+		}
+	}
+
+	return best.provider, fmt.Sprintf("balanced score %.3f (cost: $%.6f, latency: %dms)",
+		best.score, best.provider.EstimatedCost, best.provider.AvgLatencyMs)
+}
+
+// roundRobin cycles through healthy providers
+type roundRobin struct {
+	mu    sync.Mutex
+	index int
+}
+
+func (rr *roundRobin) Pick(_ context.Context, providers []*ProviderOption, _ RouteRequest) (*ProviderOption, string) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	selected := providers[rr.index%len(providers)]
+	rr.index++
+
+	return selected, fmt.Sprintf("round-robin selection #%d", rr.index)
+}
+
+// selectFallback tries primary, then fallbacks
+func selectFallback(_ context.Context, providers []*ProviderOption, _ RouteRequest) (*ProviderOption, string) {
+	// First healthy provider
+	for i, p := range providers {
+		if p.Health.IsHealthy {
+			reason := "primary"
+			if i > 0 {
+				reason = fmt.Sprintf("fallback #%d", i)
+			}
+			return p, reason
+		}
+	}
+	// All unhealthy - return first anyway
+	return providers[0], "all unhealthy, using first"
+}
@@ -0,0 +1,115 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// regionAware is a custom strategy of the kind RegisterStrategy is for:
+// it keeps requests on one provider when it can
+type regionAware struct{ preferred string }
+
+func (s regionAware) Pick(ctx context.Context, candidates []*ProviderOption, req RouteRequest) (*ProviderOption, string) {
+	for _, p := range candidates {
+		if p.ProviderName == s.preferred {
+			return p, "preferred provider " + s.preferred
+		}
+	}
+	return candidates[0], "no preferred provider"
+}
+
+func TestRegisterStrategy_CustomStrategy(t *testing.T) {
+	dbPath := setupRouterTest(t)
+	defer teardownRouterTest(t, dbPath)
+
+	name := RoutingStrategy("test_preferred_" + t.Name())
+	RegisterStrategy(name, func() Strategy { return regionAware{preferred: "openai"} })
+
+	result, err := NewRouter(name).Route(context.Background(), RouteRequest{Capability: "chat", EstimatedTokens: 1000})
+	if err != nil {
+		t.Fatalf("Route failed: %v", err)
+	}
+	if result.Provider.ProviderName != "openai" || result.Reason != "preferred provider openai" {
+		t.Errorf("expected the custom strategy's pick, got %s (%s)", result.Provider.ProviderName, result.Reason)
+	}
+
+	found := false
+	for _, s := range Strategies() {
+		found = found || s == name
+	}
+	if !found {
+		t.Errorf("expected %s among %v", name, Strategies())
+	}
+}
+
+func TestRegisterStrategy_Panics(t *testing.T) {
+	for _, tc := range []struct {
+		name    RoutingStrategy
+		factory StrategyFactory
+	}{
+		{StrategyCheapest, func() Strategy { return regionAware{} }},
+		{"", func() Strategy { return regionAware{} }},
+		{"test_nil_factory", nil},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected RegisterStrategy(%q) to panic", tc.name)
+				}
+			}()
+			RegisterStrategy(tc.name, tc.factory)
+		}()
+	}
+}
+
+func TestNewRouter_UnknownStrategyIsBalanced(t *testing.T) {
+	providers := []*ProviderOption{
+		{ProviderName: "cheap", EstimatedCost: 0.001, AvgLatencyMs: 100},
+		{ProviderName: "dear", EstimatedCost: 0.01, AvgLatencyMs: 100},
+	}
+	_, reason := NewRouter("no_such_strategy").picker.Pick(context.Background(), providers, RouteRequest{})
+	_, want := selectBalanced(context.Background(), providers, RouteRequest{})
+	if reason != want {
+		t.Errorf("expected the balanced strategy, got reason %q", reason)
+	}
+}
+
+func TestNewRouterWithStrategy(t *testing.T) {
+	if r, err := NewRouterWithStrategy("no_such_strategy"); err == nil || r != nil || !strings.Contains(err.Error(), `"no_such_strategy"`) {
+		t.Errorf("NewRouterWithStrategy = %v, %v, want an error naming the strategy", r, err)
+	}
+	r, err := NewRouterWithStrategy(StrategyFastest)
+	if err != nil || r.strategy != StrategyFastest {
+		t.Fatalf("NewRouterWithStrategy = %v, %v", r, err)
+	}
+	if _, ok := LookupStrategy(StrategyRoundRobin); !ok {
+		t.Error("built-in strategy not found")
+	}
+}
+
+func TestRoundRobin_PerRouter(t *testing.T) {
+	providers := []*ProviderOption{{ProviderName: "a"}, {ProviderName: "b"}}
+	first, second := NewRouter(StrategyRoundRobin), NewRouter(StrategyRoundRobin)
+
+	p1, _ := first.picker.Pick(context.Background(), providers, RouteRequest{})
+	p2, _ := second.picker.Pick(context.Background(), providers, RouteRequest{})
+	p3, _ := first.picker.Pick(context.Background(), providers, RouteRequest{})
+	if p1.ProviderName != "a" || p2.ProviderName != "a" || p3.ProviderName != "b" {
+		t.Errorf("expected each router to keep its own position, got %s %s %s", p1.ProviderName, p2.ProviderName, p3.ProviderName)
+	}
+}
+
+func TestRouter_StrategyPickingNothing(t *testing.T) {
+	dbPath := setupRouterTest(t)
+	defer teardownRouterTest(t, dbPath)
+
+	name := RoutingStrategy("test_nothing_" + t.Name())
+	RegisterStrategy(name, func() Strategy {
+		return StrategyFunc(func(context.Context, []*ProviderOption, RouteRequest) (*ProviderOption, string) { return nil, "" })
+	})
+	if _, err := NewRouter(name).Route(context.Background(), RouteRequest{Capability: "chat"}); err == nil || err.Error() != fmt.Sprintf("strategy %s selected no provider", name) {
+		t.Errorf("expected an error for a strategy that picks nothing, got %v", err)
+	}
+}
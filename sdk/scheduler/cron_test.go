@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCron_Next(t *testing.T) {
+	from := time.Date(2026, 3, 14, 10, 7, 30, 0, time.UTC) // A Saturday
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 14, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 14, 10, 15, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2026, 3, 15, 2, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * MON", time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"30 8 * * 1-5", time.Date(2026, 3, 16, 8, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 */3 *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 FEB *", time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 * MON", time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)}, // Either day field matches
+		{"5,10 10 * * *", time.Date(2026, 3, 14, 10, 10, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tc := range cases {
+		c, err := ParseCron(tc.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", tc.expr, err)
+			continue
+		}
+		if got := c.Next(from); !got.Equal(tc.want) {
+			t.Errorf("%q: Next = %s, want %s", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * FOO *", "@often"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded", expr)
+		}
+	}
+}
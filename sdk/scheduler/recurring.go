@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jeffersonwarrior/modelscan/sdk/storage"
+)
+
+func (s *Scheduler) enqueueLoop(ctx context.Context) {
+	for {
+		if _, err := s.EnqueueDue(ctx, time.Now()); err != nil {
+			log.Printf("scheduler: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.opts.PollInterval):
+		}
+	}
+}
+
+// EnqueueDue queues a task for each recurring task due at now and returns
+// how many were queued. A definition fires once however many of its times
+// were missed while nothing was polling. Unless it allows overlap, a run
+// is skipped while the task from its last run is still pending or running.
+// Every firing, queued or skipped, is recorded in the definition's history.
+//
+// A definition seen for the first time is only scheduled; it fires at its
+// next matching time.
+func (s *Scheduler) EnqueueDue(ctx context.Context, now time.Time) (int, error) {
+	if s.recurring == nil {
+		return 0, nil
+	}
+	due, err := s.recurring.ListDue(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, def := range due {
+		ok, err := s.fire(ctx, def, now)
+		if err != nil {
+			return queued, fmt.Errorf("recurring task %s: %w", def.Name, err)
+		}
+		if ok {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+// fire queues a due definition's task, reporting whether it did
+func (s *Scheduler) fire(ctx context.Context, def *storage.RecurringTask, now time.Time) (bool, error) {
+	cron, err := ParseCron(def.Schedule)
+	if err != nil {
+		// Disabled, rather than failing on every poll
+		log.Printf("scheduler: disabling recurring task %s: %v", def.Name, err)
+		def.Enabled = false
+		if err := s.recurring.Update(ctx, def); err != nil {
+			return false, err
+		}
+		return false, s.recurring.RecordRun(ctx, def.ID, "", now, err.Error())
+	}
+
+	next := cron.Next(now.In(s.opts.Location))
+	if next.IsZero() {
+		next = now.AddDate(100, 0, 0) // Never matches; keep it out of ListDue
+	}
+	// Claims this firing; false means another scheduler already has
+	advanced, err := s.recurring.Advance(ctx, def.ID, def.NextRunAt, next)
+	if err != nil || !advanced || def.NextRunAt == nil {
+		return false, err
+	}
+	scheduledFor := *def.NextRunAt
+
+	if !def.AllowOverlap && def.LastTaskID != "" {
+		if last, err := s.tasks.Get(ctx, def.LastTaskID); err == nil && (last.Status == "pending" || last.Status == "running") {
+			reason := fmt.Sprintf("previous run %s is still %s", last.ID, last.Status)
+			return false, s.recurring.RecordRun(ctx, def.ID, "", scheduledFor, reason)
+		}
+	}
+
+	task := &storage.Task{
+		ID:          uuid.New().String(),
+		AgentID:     def.AgentID,
+		TeamID:      def.TeamID,
+		Type:        def.Type,
+		Status:      "pending",
+		Priority:    def.Priority,
+		Input:       def.Input,
+		MaxAttempts: def.MaxAttempts,
+		Metadata: map[string]interface{}{
+			"recurring_id":   def.ID,
+			"recurring_name": def.Name,
+			"scheduled_for":  scheduledFor.UTC().Format(time.RFC3339),
+		},
+	}
+	if err := s.tasks.Create(ctx, task); err != nil {
+		return false, err
+	}
+	return true, s.recurring.RecordRun(ctx, def.ID, task.ID, scheduledFor, "")
+}
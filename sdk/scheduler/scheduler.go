@@ -0,0 +1,233 @@
+// Package scheduler works through the task queue in sdk/storage. Tasks are
+// claimed highest priority first, subject to per-type concurrency limits,
+// and handed to a Handler. A failed task is retried with exponential
+// backoff until it has used its attempts, then moved to the dead_letter
+// status, where it stays until requeued.
+//
+// An orchestrator.Runner executes agent tasks:
+//
+//	s := scheduler.New(store.Tasks, runner.Execute, scheduler.Options{Workers: 4})
+//	s.SetRecurring(store.Recurring)
+//	go s.Run(ctx)
+//
+// With SetRecurring, Run also queues the recurring tasks stored in the
+// database as their cron schedules come due.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/storage"
+)
+
+const (
+	// DefaultMaxAttempts is used for tasks that set no max_attempts
+	DefaultMaxAttempts = 3
+	// DefaultRetryBackoff is the wait before the first retry; each
+	// further retry waits twice as long
+	DefaultRetryBackoff = 30 * time.Second
+	// DefaultMaxBackoff caps the wait between retries
+	DefaultMaxBackoff = 30 * time.Minute
+	// DefaultPollInterval is how often idle workers look for tasks
+	DefaultPollInterval = 2 * time.Second
+)
+
+// Handler runs a claimed task and records its outcome on it. A task left
+// with status "failed", or a returned error, counts as a failed attempt.
+type Handler func(ctx context.Context, task *storage.Task) error
+
+// Options configures a Scheduler
+type Options struct {
+	Workers      int           // Tasks run at once; zero runs one
+	PollInterval time.Duration // Zero uses DefaultPollInterval
+	MaxAttempts  int           // Zero uses DefaultMaxAttempts
+	RetryBackoff time.Duration // Zero uses DefaultRetryBackoff
+	MaxBackoff   time.Duration // Zero uses DefaultMaxBackoff
+
+	// Location is the time zone of recurring task schedules; nil uses
+	// time.Local
+	Location *time.Location
+
+	// TypeLimits caps the running tasks of a type, e.g. {"report": 1};
+	// types not listed are limited by Workers only
+	TypeLimits map[string]int
+}
+
+// Scheduler dispatches queued tasks to a handler
+type Scheduler struct {
+	tasks     *storage.TaskRepository
+	recurring *storage.RecurringTaskRepository
+	handler   Handler
+	opts      Options
+
+	mu      sync.Mutex
+	running map[string]int // Running tasks by type
+}
+
+// New creates a scheduler for the tasks in repo
+func New(tasks *storage.TaskRepository, handler Handler, opts Options) *Scheduler {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = DefaultRetryBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	return &Scheduler{tasks: tasks, handler: handler, opts: opts, running: make(map[string]int)}
+}
+
+// SetRecurring makes Run queue the recurring tasks in repo when they are
+// due
+func (s *Scheduler) SetRecurring(repo *storage.RecurringTaskRepository) {
+	s.recurring = repo
+}
+
+// Run dispatches tasks until ctx is cancelled, then waits for the running
+// ones to return
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	if s.recurring != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.enqueueLoop(ctx)
+		}()
+	}
+	for i := 0; i < s.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		ran, err := s.RunOnce(ctx)
+		if err != nil {
+			log.Printf("scheduler: %v", err)
+		}
+		if ran {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(s.opts.PollInterval):
+		}
+	}
+}
+
+// Running reports the running tasks by type
+func (s *Scheduler) Running() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int, len(s.running))
+	for t, n := range s.running {
+		if n > 0 {
+			out[t] = n
+		}
+	}
+	return out
+}
+
+// RunOnce claims the next ready task and runs it, reporting whether there
+// was one
+func (s *Scheduler) RunOnce(ctx context.Context) (bool, error) {
+	task, err := s.claim(ctx)
+	if err != nil || task == nil {
+		return false, err
+	}
+	defer s.release(task.Type)
+
+	handlerErr := s.call(ctx, task)
+
+	// Outcomes are recorded even when the scheduler is shutting down
+	ctx = context.WithoutCancel(ctx)
+	current, err := s.tasks.Get(ctx, task.ID)
+	if err != nil {
+		return true, err
+	}
+	reason := current.LastError
+	switch {
+	case handlerErr != nil:
+		reason = handlerErr.Error()
+	case current.Status != "failed":
+		return true, nil
+	}
+	if reason == "" {
+		reason = "failed without an error"
+	}
+
+	maxAttempts := current.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = s.opts.MaxAttempts
+	}
+	if current.Attempts >= maxAttempts {
+		log.Printf("scheduler: task %s dead-lettered after %d attempts: %s", task.ID, current.Attempts, reason)
+		return true, s.tasks.DeadLetter(ctx, task.ID, reason)
+	}
+	return true, s.tasks.Retry(ctx, task.ID, time.Now().Add(s.backoff(current.Attempts)), reason)
+}
+
+// claim dequeues a task whose type has capacity
+func (s *Scheduler) claim(ctx context.Context) (*storage.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var full []string
+	for taskType, limit := range s.opts.TypeLimits {
+		if s.running[taskType] >= limit {
+			full = append(full, taskType)
+		}
+	}
+	task, err := s.tasks.ClaimNext(ctx, full...)
+	if err != nil || task == nil {
+		return nil, err
+	}
+	s.running[task.Type]++
+	return task, nil
+}
+
+func (s *Scheduler) release(taskType string) {
+	s.mu.Lock()
+	s.running[taskType]--
+	s.mu.Unlock()
+}
+
+// call runs the handler, turning a panic into a failed attempt
+func (s *Scheduler) call(ctx context.Context, task *storage.Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return s.handler(ctx, task)
+}
+
+// backoff is the wait after the given number of failed attempts
+func (s *Scheduler) backoff(attempts int) time.Duration {
+	wait := s.opts.RetryBackoff
+	for i := 1; i < attempts && wait < s.opts.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > s.opts.MaxBackoff {
+		wait = s.opts.MaxBackoff
+	}
+	return wait
+}
@@ -0,0 +1,265 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/storage"
+)
+
+func newTestStore(t *testing.T) *storage.Storage {
+	t.Helper()
+	adb, err := storage.NewAgentDB(filepath.Join(t.TempDir(), "agents.db"))
+	if err != nil {
+		t.Fatalf("NewAgentDB: %v", err)
+	}
+	t.Cleanup(func() { adb.Close() })
+	store := storage.NewStorage(adb.GetDB(), 24*time.Hour)
+	if err := store.Agents.Create(context.Background(), &storage.Agent{ID: "worker", Name: "worker", Status: "idle"}); err != nil {
+		t.Fatalf("Create agent: %v", err)
+	}
+	return store
+}
+
+func queue(t *testing.T, store *storage.Storage, taskType string, priority int) *storage.Task {
+	t.Helper()
+	task := store.NewTaskWithDefaults("worker", taskType, "input", priority)
+	if err := store.Tasks.Create(context.Background(), task); err != nil {
+		t.Fatalf("Create task: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond) // Distinct created_at
+	return task
+}
+
+// finish records an outcome the way a handler does
+func finish(store *storage.Storage, task *storage.Task, status, lastError string) error {
+	task.Status, task.LastError = status, lastError
+	return store.Tasks.Update(context.Background(), task)
+}
+
+func TestScheduler_RetriesThenSucceeds(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	task := queue(t, store, "chat", 1)
+
+	calls := 0
+	s := New(store.Tasks, func(ctx context.Context, task *storage.Task) error {
+		calls++
+		if calls == 1 {
+			return finish(store, task, "failed", "rate limited")
+		}
+		return finish(store, task, "completed", "")
+	}, Options{RetryBackoff: 50 * time.Millisecond})
+
+	s.RunOnce(ctx)
+	got, _ := store.Tasks.Get(ctx, task.ID)
+	if got.Status != "pending" || got.LastError != "rate limited" || got.RunAfter == nil {
+		t.Fatalf("expected a delayed retry, got %+v", got)
+	}
+
+	// Not claimed again before the backoff has passed
+	if ran, _ := s.RunOnce(ctx); ran {
+		t.Fatal("retry claimed before its backoff")
+	}
+	stats, _ := store.Tasks.QueueStats(ctx, 5)
+	if stats.Delayed != 1 || stats.PendingByType["chat"] != 1 {
+		t.Errorf("unexpected queue stats %+v", stats)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if ran, err := s.RunOnce(ctx); !ran || err != nil {
+		t.Fatalf("RunOnce = %v, %v", ran, err)
+	}
+	got, _ = store.Tasks.Get(ctx, task.ID)
+	if got.Status != "completed" || got.Attempts != 2 {
+		t.Errorf("expected completion on the second attempt, got %+v", got)
+	}
+}
+
+func TestScheduler_DeadLetter(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	task := queue(t, store, "report", 1)
+
+	s := New(store.Tasks, func(ctx context.Context, task *storage.Task) error {
+		return errors.New("template missing")
+	}, Options{MaxAttempts: 2, RetryBackoff: time.Millisecond})
+
+	s.RunOnce(ctx)
+	time.Sleep(5 * time.Millisecond)
+	s.RunOnce(ctx)
+
+	got, _ := store.Tasks.Get(ctx, task.ID)
+	if got.Status != "dead_letter" || got.Attempts != 2 || got.LastError != "template missing" {
+		t.Fatalf("expected a dead-lettered task, got %+v", got)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if ran, _ := s.RunOnce(ctx); ran {
+		t.Error("dead-lettered task was claimed")
+	}
+
+	stats, err := store.Tasks.QueueStats(ctx, 5)
+	if err != nil {
+		t.Fatalf("QueueStats: %v", err)
+	}
+	if stats.ByStatus["dead_letter"] != 1 || len(stats.Failures) != 1 || stats.Failures[0].Reason != "template missing" {
+		t.Errorf("unexpected queue stats %+v", stats)
+	}
+
+	if err := store.Tasks.Requeue(ctx, task.ID); err != nil {
+		t.Fatalf("Requeue: %v", err)
+	}
+	got, _ = store.Tasks.Get(ctx, task.ID)
+	if got.Status != "pending" || got.Attempts != 0 {
+		t.Errorf("expected a fresh pending task, got %+v", got)
+	}
+}
+
+func TestScheduler_PriorityAndTypeLimits(t *testing.T) {
+	store := newTestStore(t)
+	queue(t, store, "chat", 1)
+	first := queue(t, store, "report", 5)
+	queue(t, store, "report", 5)
+
+	started := make(chan string, 3)
+	release := make(chan struct{})
+	s := New(store.Tasks, func(ctx context.Context, task *storage.Task) error {
+		started <- task.ID
+		<-release
+		return finish(store, task, "completed", "")
+	}, Options{Workers: 3, PollInterval: 10 * time.Millisecond, TypeLimits: map[string]int{"report": 1}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	if id := <-started; id != first.ID {
+		t.Errorf("expected the oldest high-priority task first, got %s", id)
+	}
+	<-started
+	time.Sleep(50 * time.Millisecond)
+	if running := s.Running(); running["report"] != 1 || running["chat"] != 1 {
+		t.Errorf("expected one report and one chat running, got %v", running)
+	}
+
+	close(release)
+	<-started // The second report, once the first finished
+	cancel()
+	<-done
+}
+
+func TestScheduler_PanicCountsAsFailure(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	task := queue(t, store, "chat", 1)
+
+	s := New(store.Tasks, func(ctx context.Context, task *storage.Task) error {
+		panic("nil pointer")
+	}, Options{MaxAttempts: 1})
+	s.RunOnce(ctx)
+
+	got, _ := store.Tasks.Get(ctx, task.ID)
+	if got.Status != "dead_letter" || got.LastError != "handler panicked: nil pointer" {
+		t.Errorf("unexpected task %+v", got)
+	}
+	if len(s.Running()) != 0 {
+		t.Errorf("running count leaked: %v", s.Running())
+	}
+}
+
+func TestScheduler_Backoff(t *testing.T) {
+	s := New(nil, nil, Options{RetryBackoff: time.Second, MaxBackoff: 5 * time.Second})
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := s.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func TestScheduler_Recurring(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	def := store.NewRecurringTaskWithDefaults("nightly-validation", "0 2 * * *", "worker", "validate", "all providers")
+	if err := store.Recurring.Create(ctx, def); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	s := New(store.Tasks, nil, Options{Location: time.UTC})
+	s.SetRecurring(store.Recurring)
+	day := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+
+	// First seen: scheduled, not fired
+	if n, err := s.EnqueueDue(ctx, day); n != 0 || err != nil {
+		t.Fatalf("EnqueueDue = %d, %v", n, err)
+	}
+	got, _ := store.Recurring.Get(ctx, def.ID)
+	if got.NextRunAt == nil || !got.NextRunAt.Equal(time.Date(2026, 3, 15, 2, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected next run %v", got.NextRunAt)
+	}
+
+	// Due, with a second scheduler racing for the same firing
+	other := New(store.Tasks, nil, Options{Location: time.UTC})
+	other.SetRecurring(store.Recurring)
+	due, _ := store.Recurring.ListDue(ctx, day.Add(15*time.Hour))
+	if n, err := s.EnqueueDue(ctx, day.Add(15*time.Hour)); n != 1 || err != nil {
+		t.Fatalf("EnqueueDue = %d, %v", n, err)
+	}
+	if ok, _ := other.fire(ctx, due[0], day.Add(15*time.Hour)); ok {
+		t.Error("a firing was queued twice")
+	}
+	got, _ = store.Recurring.Get(ctx, def.ID)
+	first, err := store.Tasks.Get(ctx, got.LastTaskID)
+	if err != nil {
+		t.Fatalf("queued task: %v", err)
+	}
+	if first.Type != "validate" || first.Input != "all providers" || first.Metadata["recurring_id"] != def.ID {
+		t.Errorf("unexpected task %+v", first)
+	}
+
+	// The next night's run overlaps the unfinished first one
+	if n, _ := s.EnqueueDue(ctx, day.Add(39*time.Hour)); n != 0 {
+		t.Error("overlapping run was queued")
+	}
+
+	// Once it finishes, the following night runs again
+	first.Status = "completed"
+	store.Tasks.Update(ctx, first)
+	if n, _ := s.EnqueueDue(ctx, day.Add(63*time.Hour)); n != 1 {
+		t.Error("run after completion was not queued")
+	}
+
+	runs, err := store.Recurring.History(ctx, def.ID, 10)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(runs) != 3 || runs[0].Status != "pending" || runs[1].Status != "skipped" || runs[2].Status != "completed" {
+		t.Fatalf("unexpected history %+v", runs)
+	}
+	if runs[1].TaskID != "" || runs[1].Error == "" || !runs[2].FiredAt.Equal(time.Date(2026, 3, 15, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected runs %+v %+v", runs[1], runs[2])
+	}
+}
+
+func TestScheduler_RecurringInvalidSchedule(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	def := store.NewRecurringTaskWithDefaults("broken", "every tuesday", "worker", "report", "")
+	store.Recurring.Create(ctx, def)
+
+	s := New(store.Tasks, nil, Options{})
+	s.SetRecurring(store.Recurring)
+	if n, err := s.EnqueueDue(ctx, time.Now()); n != 0 || err != nil {
+		t.Fatalf("EnqueueDue = %d, %v", n, err)
+	}
+	got, _ := store.Recurring.Get(ctx, def.ID)
+	runs, _ := store.Recurring.History(ctx, def.ID, 10)
+	if got.Enabled || len(runs) != 1 || runs[0].Status != "skipped" {
+		t.Errorf("expected the definition disabled with a skipped run, got %+v %+v", got, runs)
+	}
+}
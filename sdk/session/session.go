@@ -0,0 +1,427 @@
+// Package session persists multi-turn conversation history and keeps the
+// active context within the target model's context window.
+package session
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jeffersonwarrior/modelscan/providers"
+	"github.com/jeffersonwarrior/modelscan/sdk/ratelimit"
+	"github.com/jeffersonwarrior/modelscan/sdk/storage"
+	modelstorage "github.com/jeffersonwarrior/modelscan/storage"
+)
+
+// Roles used for conversation turns
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// turnOverheadTokens approximates per-message framing tokens (role, separators)
+const turnOverheadTokens = 4
+
+// Turn is a single message in a conversation
+type Turn struct {
+	ID        string    `json:"id"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Tokens    int       `json:"tokens"`
+	Summary   bool      `json:"summary,omitempty"`  // Generated summary of archived turns
+	Archived  bool      `json:"archived,omitempty"` // Truncated out of the active context
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ContextWindows looks up a model's context window in tokens.
+// Implementations return 0 when the model is unknown.
+type ContextWindows interface {
+	ContextWindow(model string) int
+}
+
+// ModelCatalog maps model IDs to context windows
+type ModelCatalog map[string]int
+
+// ContextWindow implements ContextWindows
+func (c ModelCatalog) ContextWindow(model string) int {
+	return c[model]
+}
+
+// NewModelCatalog builds a catalog from provider model listings
+func NewModelCatalog(models ...providers.Model) ModelCatalog {
+	catalog := make(ModelCatalog, len(models))
+	for _, m := range models {
+		if m.ContextWindow > 0 {
+			catalog[m.ID] = m.ContextWindow
+		}
+	}
+	return catalog
+}
+
+// LoadModelCatalog builds a catalog from the capability matrix stored by
+// provider validation runs. The storage database must already be initialized.
+func LoadModelCatalog(providerNames ...string) (ModelCatalog, error) {
+	catalog := make(ModelCatalog)
+	for _, name := range providerNames {
+		models, err := modelstorage.GetProviderModels(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load models for %s: %w", name, err)
+		}
+		for id, window := range NewModelCatalog(models...) {
+			catalog[id] = window
+		}
+	}
+	return catalog, nil
+}
+
+// Summarizer condenses turns that no longer fit into the context window.
+// previous is the existing summary, or empty if there is none.
+type Summarizer func(ctx context.Context, previous string, turns []Turn) (string, error)
+
+// Option configures a Manager
+type Option func(*Manager)
+
+// WithContextWindows sets the context window lookup (usually a ModelCatalog)
+func WithContextWindows(windows ContextWindows) Option {
+	return func(m *Manager) {
+		m.windows = windows
+	}
+}
+
+// WithDefaultContextWindow sets the window used for models missing from the lookup.
+// Zero disables truncation for unknown models.
+func WithDefaultContextWindow(tokens int) Option {
+	return func(m *Manager) {
+		m.defaultWindow = tokens
+	}
+}
+
+// WithReserveTokens sets how many tokens are kept free for the model's response
+func WithReserveTokens(tokens int) Option {
+	return func(m *Manager) {
+		m.reserve = tokens
+	}
+}
+
+// WithSummarizer summarizes truncated turns instead of dropping them
+func WithSummarizer(summarizer Summarizer) Option {
+	return func(m *Manager) {
+		m.summarizer = summarizer
+	}
+}
+
+// WithTokenEstimator overrides the token estimate for turn content
+func WithTokenEstimator(estimate func(content string) int) Option {
+	return func(m *Manager) {
+		m.estimate = estimate
+	}
+}
+
+// Manager stores conversations and truncates them to fit the target model
+type Manager struct {
+	repo          *storage.SessionRepository
+	windows       ContextWindows
+	defaultWindow int
+	reserve       int
+	summarizer    Summarizer
+	estimate      func(string) int
+	mu            sync.Mutex
+}
+
+// NewManager creates a session manager backed by the storage layer
+func NewManager(repo *storage.SessionRepository, opts ...Option) *Manager {
+	m := &Manager{
+		repo:    repo,
+		reserve: 1024,
+		estimate: func(content string) int {
+			return int(ratelimit.EstimateTokens(content))
+		},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Create starts a new session for the given model
+func (m *Manager) Create(ctx context.Context, model string, metadata map[string]interface{}) (*storage.Session, error) {
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+
+	session := &storage.Session{
+		ID:        uuid.New().String(),
+		Model:     model,
+		Metadata:  metadata,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := m.repo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Append adds a turn to the session and truncates older turns if the
+// active context no longer fits the session model's context window
+func (m *Manager) Append(ctx context.Context, sessionID, role, content string) (*Turn, error) {
+	if role == "" {
+		return nil, fmt.Errorf("role is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, err := m.repo.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &storage.SessionTurn{
+		ID:        uuid.New().String(),
+		SessionID: sessionID,
+		Role:      role,
+		Content:   content,
+		Tokens:    m.estimate(content) + turnOverheadTokens,
+	}
+	if err := m.repo.AppendTurn(ctx, record); err != nil {
+		return nil, err
+	}
+
+	if err := m.compact(ctx, session); err != nil {
+		return nil, err
+	}
+
+	turn := toTurn(record)
+	turn.CreatedAt = time.Now()
+	return &turn, nil
+}
+
+// Get returns the active context for the session: leading system turns,
+// then the summary of truncated turns (if any), then the remaining turns
+func (m *Manager) Get(ctx context.Context, sessionID string) ([]Turn, error) {
+	if _, err := m.repo.Get(ctx, sessionID); err != nil {
+		return nil, err
+	}
+
+	records, err := m.repo.ListTurns(ctx, sessionID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary *Turn
+	turns := make([]Turn, 0, len(records))
+	for _, r := range records {
+		t := toTurn(r)
+		if t.Summary {
+			summary = &t
+			continue
+		}
+		turns = append(turns, t)
+	}
+	if summary == nil {
+		return turns, nil
+	}
+
+	// Place the summary after the leading system prompt(s)
+	insertAt := 0
+	for insertAt < len(turns) && turns[insertAt].Role == RoleSystem {
+		insertAt++
+	}
+	turns = append(turns, Turn{})
+	copy(turns[insertAt+1:], turns[insertAt:])
+	turns[insertAt] = *summary
+	return turns, nil
+}
+
+// Replay calls fn for every original turn in order, including turns that
+// were truncated out of the active context. Generated summaries are skipped.
+func (m *Manager) Replay(ctx context.Context, sessionID string, fn func(Turn) error) error {
+	if _, err := m.repo.Get(ctx, sessionID); err != nil {
+		return err
+	}
+
+	records, err := m.repo.ListTurns(ctx, sessionID, true)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		if r.IsSummary {
+			continue
+		}
+		if err := fn(toTurn(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetModel switches the session to another model and re-truncates the
+// active context for the new model's window
+func (m *Manager) SetModel(ctx context.Context, sessionID, model string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.repo.SetModel(ctx, sessionID, model); err != nil {
+		return err
+	}
+
+	session, err := m.repo.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	return m.compact(ctx, session)
+}
+
+// Delete removes a session and its history
+func (m *Manager) Delete(ctx context.Context, sessionID string) error {
+	return m.repo.Delete(ctx, sessionID)
+}
+
+// Budget returns the token budget for the active context of a model,
+// or 0 if the model's context window is unknown
+func (m *Manager) Budget(model string) int {
+	window := 0
+	if m.windows != nil {
+		window = m.windows.ContextWindow(model)
+	}
+	if window <= 0 {
+		window = m.defaultWindow
+	}
+	if window <= 0 {
+		return 0
+	}
+
+	budget := window - m.reserve
+	if budget < 1 {
+		budget = 1
+	}
+	return budget
+}
+
+// compact archives the oldest turns until the active context fits the budget.
+// System turns and the most recent turn are never archived.
+func (m *Manager) compact(ctx context.Context, session *storage.Session) error {
+	budget := m.Budget(session.Model)
+	if budget == 0 {
+		return nil
+	}
+
+	records, err := m.repo.ListTurns(ctx, session.ID, false)
+	if err != nil {
+		return err
+	}
+
+	var pinned int
+	var summary *storage.SessionTurn
+	var candidates []*storage.SessionTurn
+	for _, r := range records {
+		switch {
+		case r.IsSummary:
+			summary = r
+		case r.Role == RoleSystem:
+			pinned += r.Tokens
+		default:
+			candidates = append(candidates, r)
+		}
+	}
+
+	summaryTokens := 0
+	if summary != nil {
+		summaryTokens = summary.Tokens
+	}
+	kept := 0
+	for _, c := range candidates {
+		kept += c.Tokens
+	}
+	if pinned+summaryTokens+kept <= budget {
+		return nil
+	}
+
+	// Archive the oldest turns, keeping at least the latest one
+	drop := 0
+	for drop < len(candidates)-1 && pinned+summaryTokens+kept > budget {
+		kept -= candidates[drop].Tokens
+		drop++
+	}
+	if drop == 0 {
+		return nil
+	}
+
+	var newSummary *storage.SessionTurn
+	if m.summarizer != nil {
+		for {
+			text, err := m.summarize(ctx, summary, candidates[:drop])
+			if err != nil {
+				// Fall back to plain truncation, keeping any previous summary
+				newSummary = nil
+				break
+			}
+			newSummary = &storage.SessionTurn{
+				ID:        uuid.New().String(),
+				SessionID: session.ID,
+				Role:      RoleSystem,
+				Content:   text,
+				Tokens:    m.estimate(text) + turnOverheadTokens,
+				IsSummary: true,
+			}
+			if pinned+newSummary.Tokens+kept <= budget || drop >= len(candidates)-1 {
+				break
+			}
+			kept -= candidates[drop].Tokens
+			drop++
+		}
+	}
+
+	archive := make([]string, 0, drop+1)
+	for _, c := range candidates[:drop] {
+		archive = append(archive, c.ID)
+	}
+	if newSummary != nil && summary != nil {
+		archive = append(archive, summary.ID)
+	}
+	if err := m.repo.ArchiveTurns(ctx, session.ID, archive); err != nil {
+		return err
+	}
+
+	if newSummary != nil {
+		if err := m.repo.AppendTurn(ctx, newSummary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// summarize calls the summarizer with the previous summary and the dropped turns
+func (m *Manager) summarize(ctx context.Context, previous *storage.SessionTurn, dropped []*storage.SessionTurn) (string, error) {
+	prev := ""
+	if previous != nil {
+		prev = previous.Content
+	}
+
+	turns := make([]Turn, len(dropped))
+	for i, d := range dropped {
+		turns[i] = toTurn(d)
+	}
+	return m.summarizer(ctx, prev, turns)
+}
+
+// toTurn converts a stored turn to the public type
+func toTurn(r *storage.SessionTurn) Turn {
+	return Turn{
+		ID:        r.ID,
+		Role:      r.Role,
+		Content:   r.Content,
+		Tokens:    r.Tokens,
+		Summary:   r.IsSummary,
+		Archived:  r.Archived,
+		CreatedAt: r.CreatedAt,
+	}
+}
@@ -0,0 +1,271 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jeffersonwarrior/modelscan/providers"
+	"github.com/jeffersonwarrior/modelscan/sdk/storage"
+)
+
+func setupManager(t *testing.T, opts ...Option) *Manager {
+	t.Helper()
+
+	adb, err := storage.NewAgentDB(filepath.Join(t.TempDir(), "session.db"))
+	if err != nil {
+		t.Fatalf("Failed to create AgentDB: %v", err)
+	}
+	t.Cleanup(func() { adb.Close() })
+
+	// One token per character keeps the arithmetic readable
+	opts = append([]Option{WithTokenEstimator(func(s string) int { return len(s) })}, opts...)
+	return NewManager(storage.NewSessionRepository(adb.GetDB()), opts...)
+}
+
+func contents(turns []Turn) []string {
+	out := make([]string, len(turns))
+	for i, t := range turns {
+		out[i] = t.Content
+	}
+	return out
+}
+
+func TestManager_AppendAndGet(t *testing.T) {
+	ctx := context.Background()
+	m := setupManager(t)
+
+	s, err := m.Create(ctx, "gpt-4o", map[string]interface{}{"user": "alice"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	for _, msg := range []struct{ role, content string }{
+		{RoleSystem, "be brief"},
+		{RoleUser, "hello"},
+		{RoleAssistant, "hi"},
+	} {
+		if _, err := m.Append(ctx, s.ID, msg.role, msg.content); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	turns, err := m.Get(ctx, s.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got := strings.Join(contents(turns), "|"); got != "be brief|hello|hi" {
+		t.Errorf("unexpected history: %s", got)
+	}
+	if turns[1].Tokens != len("hello")+turnOverheadTokens {
+		t.Errorf("expected token estimate with overhead, got %d", turns[1].Tokens)
+	}
+
+	if _, err := m.Append(ctx, s.ID, "", "x"); err == nil {
+		t.Error("expected error for missing role")
+	}
+	if _, err := m.Append(ctx, "missing", RoleUser, "x"); err == nil {
+		t.Error("expected error for unknown session")
+	}
+}
+
+func TestManager_TruncatesToContextWindow(t *testing.T) {
+	ctx := context.Background()
+	catalog := NewModelCatalog(providers.Model{ID: "small", ContextWindow: 60})
+	m := setupManager(t, WithContextWindows(catalog), WithReserveTokens(10))
+
+	s, _ := m.Create(ctx, "small", nil)
+	m.Append(ctx, s.ID, RoleSystem, "sys")                      // 7 tokens, pinned
+	m.Append(ctx, s.ID, RoleUser, strings.Repeat("a", 16))      // 20
+	m.Append(ctx, s.ID, RoleAssistant, strings.Repeat("b", 16)) // 20
+	m.Append(ctx, s.ID, RoleUser, strings.Repeat("c", 16))      // 20 -> 67 > 50
+
+	turns, _ := m.Get(ctx, s.ID)
+	got := contents(turns)
+	if len(got) != 3 || got[0] != "sys" || got[1][0] != 'b' || got[2][0] != 'c' {
+		t.Errorf("expected oldest non-system turn dropped, got %v", got)
+	}
+
+	// Replay still sees the full conversation
+	var replayed []Turn
+	m.Replay(ctx, s.ID, func(turn Turn) error {
+		replayed = append(replayed, turn)
+		return nil
+	})
+	if len(replayed) != 4 || !replayed[1].Archived {
+		t.Errorf("expected 4 replayed turns with the first user turn archived, got %d", len(replayed))
+	}
+}
+
+func TestManager_KeepsLatestTurn(t *testing.T) {
+	ctx := context.Background()
+	m := setupManager(t, WithDefaultContextWindow(20), WithReserveTokens(0))
+
+	s, _ := m.Create(ctx, "unknown-model", nil)
+	m.Append(ctx, s.ID, RoleUser, "short")
+	m.Append(ctx, s.ID, RoleUser, strings.Repeat("x", 50))
+
+	turns, _ := m.Get(ctx, s.ID)
+	if len(turns) != 1 || len(turns[0].Content) != 50 {
+		t.Errorf("expected only the oversized latest turn to remain, got %v", contents(turns))
+	}
+}
+
+func TestManager_NoTruncationForUnknownModel(t *testing.T) {
+	ctx := context.Background()
+	m := setupManager(t)
+
+	s, _ := m.Create(ctx, "unknown-model", nil)
+	for i := 0; i < 5; i++ {
+		m.Append(ctx, s.ID, RoleUser, strings.Repeat("x", 1000))
+	}
+
+	turns, _ := m.Get(ctx, s.ID)
+	if len(turns) != 5 {
+		t.Errorf("expected all turns kept without a context window, got %d", len(turns))
+	}
+}
+
+func TestManager_Summarizes(t *testing.T) {
+	ctx := context.Background()
+	var calls [][]Turn
+	var previous []string
+	summarizer := func(ctx context.Context, prev string, turns []Turn) (string, error) {
+		calls = append(calls, turns)
+		previous = append(previous, prev)
+		return "S" + string(rune('0'+len(calls))), nil
+	}
+	m := setupManager(t,
+		WithContextWindows(ModelCatalog{"small": 55}),
+		WithReserveTokens(0),
+		WithSummarizer(summarizer),
+	)
+
+	s, _ := m.Create(ctx, "small", nil)
+	m.Append(ctx, s.ID, RoleSystem, "sys")                      // 7
+	m.Append(ctx, s.ID, RoleUser, strings.Repeat("a", 16))      // 20
+	m.Append(ctx, s.ID, RoleAssistant, strings.Repeat("b", 16)) // 20
+	m.Append(ctx, s.ID, RoleUser, strings.Repeat("c", 16))      // 20 -> summarize "a"
+
+	turns, _ := m.Get(ctx, s.ID)
+	got := contents(turns)
+	if len(got) != 4 || got[0] != "sys" || got[1] != "S1" || !turns[1].Summary {
+		t.Fatalf("expected summary after system prompt, got %v", got)
+	}
+	if len(calls) != 1 || len(calls[0]) != 1 || calls[0][0].Content[0] != 'a' {
+		t.Errorf("expected first user turn to be summarized, got %v", calls)
+	}
+
+	// The next summary folds in the previous one
+	m.Append(ctx, s.ID, RoleAssistant, strings.Repeat("d", 16))
+	turns, _ = m.Get(ctx, s.ID)
+	got = contents(turns)
+	if got[1] != "S2" || previous[len(previous)-1] != "S1" {
+		t.Errorf("expected rolling summary, got %v (previous %v)", got, previous)
+	}
+	summaries := 0
+	for _, turn := range turns {
+		if turn.Summary {
+			summaries++
+		}
+	}
+	if summaries != 1 {
+		t.Errorf("expected exactly one active summary, got %d", summaries)
+	}
+
+	// Summaries are not part of the replayed conversation
+	n := 0
+	m.Replay(ctx, s.ID, func(Turn) error { n++; return nil })
+	if n != 5 {
+		t.Errorf("expected 5 original turns replayed, got %d", n)
+	}
+}
+
+func TestManager_SummarizerErrorFallsBackToTruncation(t *testing.T) {
+	ctx := context.Background()
+	m := setupManager(t,
+		WithContextWindows(ModelCatalog{"small": 45}),
+		WithReserveTokens(0),
+		WithSummarizer(func(context.Context, string, []Turn) (string, error) {
+			return "", errors.New("model unavailable")
+		}),
+	)
+
+	s, _ := m.Create(ctx, "small", nil)
+	m.Append(ctx, s.ID, RoleUser, strings.Repeat("a", 16))
+	m.Append(ctx, s.ID, RoleUser, strings.Repeat("b", 16))
+	if _, err := m.Append(ctx, s.ID, RoleUser, strings.Repeat("c", 16)); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	turns, _ := m.Get(ctx, s.ID)
+	if len(turns) != 2 || turns[0].Summary {
+		t.Errorf("expected plain truncation, got %v", contents(turns))
+	}
+}
+
+func TestManager_SetModelRetruncates(t *testing.T) {
+	ctx := context.Background()
+	m := setupManager(t, WithContextWindows(ModelCatalog{"large": 1000, "small": 30}), WithReserveTokens(0))
+
+	s, _ := m.Create(ctx, "large", nil)
+	for _, c := range []string{"a", "b", "c"} {
+		m.Append(ctx, s.ID, RoleUser, strings.Repeat(c, 16))
+	}
+	if turns, _ := m.Get(ctx, s.ID); len(turns) != 3 {
+		t.Fatalf("expected 3 turns on large model, got %d", len(turns))
+	}
+
+	if err := m.SetModel(ctx, s.ID, "small"); err != nil {
+		t.Fatalf("SetModel() error = %v", err)
+	}
+	turns, _ := m.Get(ctx, s.ID)
+	if len(turns) != 1 || turns[0].Content[0] != 'c' {
+		t.Errorf("expected only latest turn after switching to small model, got %v", contents(turns))
+	}
+}
+
+func TestManager_Delete(t *testing.T) {
+	ctx := context.Background()
+	m := setupManager(t)
+
+	s, _ := m.Create(ctx, "gpt-4o", nil)
+	m.Append(ctx, s.ID, RoleUser, "hello")
+
+	if err := m.Delete(ctx, s.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := m.Get(ctx, s.ID); err == nil {
+		t.Error("expected error getting deleted session")
+	}
+	if err := m.Delete(ctx, s.ID); err == nil {
+		t.Error("expected error deleting missing session")
+	}
+}
+
+func TestManager_Budget(t *testing.T) {
+	m := NewManager(nil, WithContextWindows(ModelCatalog{"gpt-4o": 128000}))
+
+	if got := m.Budget("gpt-4o"); got != 128000-1024 {
+		t.Errorf("expected default reserve applied, got %d", got)
+	}
+	if got := m.Budget("unknown"); got != 0 {
+		t.Errorf("expected 0 budget for unknown model, got %d", got)
+	}
+}
+
+func TestNewModelCatalog(t *testing.T) {
+	catalog := NewModelCatalog(
+		providers.Model{ID: "claude-sonnet-4-5", ContextWindow: 200000},
+		providers.Model{ID: "image-model", ContextWindow: 0},
+	)
+
+	if catalog.ContextWindow("claude-sonnet-4-5") != 200000 {
+		t.Errorf("unexpected context window: %d", catalog.ContextWindow("claude-sonnet-4-5"))
+	}
+	if _, ok := catalog["image-model"]; ok {
+		t.Error("expected models without a context window to be skipped")
+	}
+}
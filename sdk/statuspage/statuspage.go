@@ -0,0 +1,314 @@
+// Package statuspage watches provider status pages for ongoing incidents so
+// routing can steer around a provider before its requests start failing:
+//
+//	w := statuspage.NewWatcher(statuspage.Config{})
+//	w.Start()
+//	defer w.Stop()
+//	if reason, degraded := w.Degraded("openai"); degraded {
+//	    log.Printf("avoiding openai: %s", reason)
+//	}
+//
+// Status pages are read in the Atlassian Statuspage summary format
+// (/api/v2/summary.json), which OpenAI and Anthropic both publish.
+package statuspage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Impact is how badly an incident affects a provider
+type Impact string
+
+// Incident impacts, from least to most severe
+const (
+	ImpactNone        Impact = "none"
+	ImpactMaintenance Impact = "maintenance"
+	ImpactMinor       Impact = "minor"
+	ImpactMajor       Impact = "major"
+	ImpactCritical    Impact = "critical"
+)
+
+// rank orders impacts by severity; unknown impacts rank as none
+func (i Impact) rank() int {
+	switch i {
+	case ImpactMaintenance:
+		return 1
+	case ImpactMinor:
+		return 2
+	case ImpactMajor:
+		return 3
+	case ImpactCritical:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// ParseImpact validates an impact name. Empty means ImpactMajor.
+func ParseImpact(s string) (Impact, error) {
+	if s == "" {
+		return ImpactMajor, nil
+	}
+	impact := Impact(s)
+	if impact.rank() == 0 && impact != ImpactNone {
+		return "", fmt.Errorf("invalid impact %q (want none, maintenance, minor, major or critical)", s)
+	}
+	return impact, nil
+}
+
+// Incident is an unresolved incident or in-progress maintenance on a
+// provider's status page
+type Incident struct {
+	Provider  string    `json:"provider"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"` // e.g. "investigating", "identified", "monitoring"
+	Impact    Impact    `json:"impact"`
+	URL       string    `json:"url,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Source is one provider's status page
+type Source struct {
+	Provider string
+	URL      string // Statuspage summary JSON
+}
+
+// DefaultSources returns the status pages of the providers that publish one
+func DefaultSources() []Source {
+	return []Source{
+		{Provider: "openai", URL: "https://status.openai.com/api/v2/summary.json"},
+		{Provider: "anthropic", URL: "https://status.anthropic.com/api/v2/summary.json"},
+	}
+}
+
+// Config configures a Watcher
+type Config struct {
+	// Sources to poll (default DefaultSources)
+	Sources []Source
+	// Interval between polls (default 2m)
+	Interval time.Duration
+	// MinImpact is the least severe incident that marks a provider as
+	// degraded (default ImpactMajor)
+	MinImpact Impact
+	// Client fetches the status pages (default a client with a 10s timeout)
+	Client *http.Client
+}
+
+// Watcher polls status pages and keeps each provider's ongoing incidents.
+// A failed poll keeps the provider's incidents from its last successful one.
+type Watcher struct {
+	config Config
+
+	mu        sync.RWMutex
+	incidents map[string][]Incident
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewWatcher creates a watcher; call Start to begin polling
+func NewWatcher(cfg Config) *Watcher {
+	if len(cfg.Sources) == 0 {
+		cfg.Sources = DefaultSources()
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 2 * time.Minute
+	}
+	if cfg.MinImpact == "" {
+		cfg.MinImpact = ImpactMajor
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Watcher{
+		config:    cfg,
+		incidents: make(map[string][]Incident),
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start polls every source now and then once per interval
+func (w *Watcher) Start() {
+	w.startOnce.Do(func() {
+		w.wg.Add(1)
+		go w.loop()
+	})
+}
+
+// Stop halts polling and waits for an in-progress poll to finish
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+	w.wg.Wait()
+}
+
+// loop polls until stopped, logging failures
+func (w *Watcher) loop() {
+	defer w.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-w.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+	for {
+		if err := w.Poll(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("statuspage: %v", err)
+		}
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll fetches every source once, returning the failures joined
+func (w *Watcher) Poll(ctx context.Context) error {
+	var errs []error
+	for _, src := range w.config.Sources {
+		incidents, err := w.fetch(ctx, src)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", src.Provider, err))
+			continue
+		}
+		w.mu.Lock()
+		w.incidents[src.Provider] = incidents
+		w.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Incidents returns every provider's ongoing incidents of any impact,
+// ordered by provider and start time
+func (w *Watcher) Incidents() []Incident {
+	w.mu.RLock()
+	var all []Incident
+	for _, incidents := range w.incidents {
+		all = append(all, incidents...)
+	}
+	w.mu.RUnlock()
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Provider != all[j].Provider {
+			return all[i].Provider < all[j].Provider
+		}
+		return all[i].StartedAt.Before(all[j].StartedAt)
+	})
+	return all
+}
+
+// Degraded reports whether provider has an ongoing incident at or above
+// MinImpact, describing the most severe one
+func (w *Watcher) Degraded(provider string) (reason string, degraded bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var worst *Incident
+	for i, inc := range w.incidents[provider] {
+		if inc.Impact.rank() < w.config.MinImpact.rank() {
+			continue
+		}
+		if worst == nil || inc.Impact.rank() > worst.Impact.rank() {
+			worst = &w.incidents[provider][i]
+		}
+	}
+	if worst == nil {
+		return "", false
+	}
+	return fmt.Sprintf("%s incident: %s (%s)", worst.Impact, worst.Name, worst.Status), true
+}
+
+// summary is the part of a Statuspage summary.json the watcher reads
+type summary struct {
+	Status struct {
+		Indicator   string `json:"indicator"`
+		Description string `json:"description"`
+	} `json:"status"`
+	Incidents             []summaryIncident `json:"incidents"`
+	ScheduledMaintenances []summaryIncident `json:"scheduled_maintenances"`
+}
+
+type summaryIncident struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Impact    string    `json:"impact"`
+	Shortlink string    `json:"shortlink"`
+	StartedAt time.Time `json:"started_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// fetch reads one status page and returns its ongoing incidents
+func (w *Watcher) fetch(ctx context.Context, src Source) ([]Incident, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := w.config.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status page returned %d", resp.StatusCode)
+	}
+
+	var s summary
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(&s); err != nil {
+		return nil, fmt.Errorf("invalid status page: %w", err)
+	}
+
+	var incidents []Incident
+	add := func(si summaryIncident, impact Impact) {
+		started := si.StartedAt
+		if started.IsZero() {
+			started = si.CreatedAt
+		}
+		incidents = append(incidents, Incident{
+			Provider:  src.Provider,
+			Name:      si.Name,
+			Status:    si.Status,
+			Impact:    impact,
+			URL:       si.Shortlink,
+			StartedAt: started,
+		})
+	}
+	for _, si := range s.Incidents {
+		if si.Status != "resolved" && si.Status != "postmortem" {
+			add(si, Impact(si.Impact))
+		}
+	}
+	for _, si := range s.ScheduledMaintenances {
+		if si.Status == "in_progress" || si.Status == "verifying" {
+			add(si, ImpactMaintenance)
+		}
+	}
+
+	// A degraded page without a posted incident still counts
+	if indicator := Impact(s.Status.Indicator); len(incidents) == 0 && indicator.rank() > ImpactMaintenance.rank() {
+		incidents = append(incidents, Incident{
+			Provider: src.Provider,
+			Name:     s.Status.Description,
+			Status:   "ongoing",
+			Impact:   indicator,
+		})
+	}
+	return incidents, nil
+}
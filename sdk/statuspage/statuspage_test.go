@@ -0,0 +1,144 @@
+package statuspage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pages serves a settable summary per path
+type pages struct {
+	mu   sync.Mutex
+	body map[string]string
+}
+
+func (p *pages) set(path, body string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.body[path] = body
+}
+
+func (p *pages) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	body, ok := p.body[r.URL.Path]
+	p.mu.Unlock()
+	if !ok {
+		http.Error(w, "down", http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(body))
+}
+
+const majorIncident = `{
+	"status": {"indicator": "major", "description": "Partial System Outage"},
+	"incidents": [
+		{"name": "Elevated errors on chat completions", "status": "investigating", "impact": "major",
+		 "shortlink": "https://stspg.io/abc", "started_at": "2026-03-01T12:00:00Z"},
+		{"name": "Slow dashboard", "status": "monitoring", "impact": "minor", "started_at": "2026-03-01T11:00:00Z"},
+		{"name": "Old outage", "status": "resolved", "impact": "critical"}
+	],
+	"scheduled_maintenances": []
+}`
+
+func TestWatcher_Poll(t *testing.T) {
+	srv := &pages{body: map[string]string{
+		"/openai":    majorIncident,
+		"/anthropic": `{"status": {"indicator": "none", "description": "All Systems Operational"}, "incidents": []}`,
+		"/groq": `{"status": {"indicator": "minor", "description": "Degraded"}, "incidents": [],
+			"scheduled_maintenances": [{"name": "Database upgrade", "status": "in_progress", "impact": "maintenance"}]}`,
+	}}
+	server := httptest.NewServer(srv)
+	defer server.Close()
+
+	w := NewWatcher(Config{Sources: []Source{
+		{Provider: "openai", URL: server.URL + "/openai"},
+		{Provider: "anthropic", URL: server.URL + "/anthropic"},
+		{Provider: "groq", URL: server.URL + "/groq"},
+	}})
+	if err := w.Poll(context.Background()); err != nil {
+		t.Fatalf("Poll failed: %v", err)
+	}
+
+	reason, degraded := w.Degraded("openai")
+	if !degraded || !strings.Contains(reason, "Elevated errors") {
+		t.Errorf("expected openai degraded by the major incident, got %q %v", reason, degraded)
+	}
+	if _, degraded := w.Degraded("anthropic"); degraded {
+		t.Error("operational provider should not be degraded")
+	}
+	if _, degraded := w.Degraded("groq"); degraded {
+		t.Error("maintenance is below the default minimum impact")
+	}
+
+	incidents := w.Incidents()
+	if len(incidents) != 3 || incidents[0].Provider != "groq" || incidents[0].Impact != ImpactMaintenance {
+		t.Fatalf("unexpected incidents: %+v", incidents)
+	}
+	if incidents[1].Name != "Slow dashboard" || incidents[2].URL != "https://stspg.io/abc" {
+		t.Errorf("expected openai incidents by start time, got %+v", incidents[1:])
+	}
+
+	// Recovery clears the provider; an unreachable page keeps the last state
+	srv.set("/openai", `{"status": {"indicator": "none"}, "incidents": []}`)
+	srv.mu.Lock()
+	delete(srv.body, "/groq")
+	srv.mu.Unlock()
+	if err := w.Poll(context.Background()); err == nil || !strings.Contains(err.Error(), "groq") {
+		t.Errorf("expected the groq failure to be reported, got %v", err)
+	}
+	if _, degraded := w.Degraded("openai"); degraded {
+		t.Error("expected openai to recover")
+	}
+	if len(w.Incidents()) != 1 {
+		t.Errorf("expected groq's maintenance to be kept, got %+v", w.Incidents())
+	}
+}
+
+func TestWatcher_IndicatorWithoutIncident(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": {"indicator": "critical", "description": "Major Outage"}, "incidents": []}`))
+	}))
+	defer server.Close()
+
+	w := NewWatcher(Config{Sources: []Source{{Provider: "anthropic", URL: server.URL}}, MinImpact: ImpactMinor})
+	w.Poll(context.Background())
+	if reason, degraded := w.Degraded("anthropic"); !degraded || !strings.Contains(reason, "Major Outage") {
+		t.Errorf("expected the page indicator to count as an incident, got %q %v", reason, degraded)
+	}
+}
+
+func TestWatcher_StartStop(t *testing.T) {
+	polled := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polled <- struct{}{}
+		w.Write([]byte(majorIncident))
+	}))
+	defer server.Close()
+
+	w := NewWatcher(Config{Sources: []Source{{Provider: "openai", URL: server.URL}}, Interval: time.Hour})
+	w.Start()
+	select {
+	case <-polled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected an immediate poll on start")
+	}
+	w.Stop()
+	w.Stop()
+}
+
+func TestParseImpact(t *testing.T) {
+	if impact, err := ParseImpact(""); err != nil || impact != ImpactMajor {
+		t.Errorf("ParseImpact(\"\") = %q, %v", impact, err)
+	}
+	if impact, err := ParseImpact("minor"); err != nil || impact != ImpactMinor {
+		t.Errorf("ParseImpact(minor) = %q, %v", impact, err)
+	}
+	if _, err := ParseImpact("severe"); err == nil {
+		t.Error("expected error for an unknown impact")
+	}
+}
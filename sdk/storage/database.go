@@ -197,6 +197,10 @@ func (adb *AgentDB) runMigrations() error {
 		migrationV1InitialSchema,
 		migrationV2AddTeamDescription,
 		migrationV3UpdateToolExecutions,
+		migrationV4AddSessions,
+		migrationV5AddTaskScheduling,
+		migrationV6AddRecurringTasks,
+		migrationV7AddMessageTopics,
 	}
 
 	for i, migration := range migrations {
@@ -332,6 +336,146 @@ func migrationV3UpdateToolExecutions(db *sql.DB) error {
 	return nil
 }
 
+// migrationV4AddSessions adds conversation session storage
+func migrationV4AddSessions(db *sql.DB) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS sessions (
+			id TEXT PRIMARY KEY,
+			model TEXT,
+			metadata TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS session_turns (
+			id TEXT PRIMARY KEY,
+			session_id TEXT NOT NULL,
+			seq INTEGER NOT NULL,
+			role TEXT NOT NULL,
+			content TEXT,
+			tokens INTEGER DEFAULT 0,
+			is_summary INTEGER DEFAULT 0,
+			archived INTEGER DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_session_turns_seq ON session_turns(session_id, seq)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create session tables: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// migrationV5AddTaskScheduling adds retry bookkeeping to tasks
+func migrationV5AddTaskScheduling(db *sql.DB) error {
+	columns := []struct {
+		name, sqlType string
+	}{
+		{"attempts", "INTEGER DEFAULT 0"},
+		{"max_attempts", "INTEGER DEFAULT 0"},
+		{"run_after", "DATETIME"},
+		{"last_error", "TEXT"},
+	}
+
+	for _, col := range columns {
+		_, err := db.Exec(fmt.Sprintf("ALTER TABLE tasks ADD COLUMN %s %s", col.name, col.sqlType))
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("failed to add %s column to tasks: %w", col.name, err)
+		}
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_tasks_ready ON tasks(status, priority DESC, created_at)`); err != nil {
+		return fmt.Errorf("failed to create task queue index: %w", err)
+	}
+
+	return nil
+}
+
+// migrationV6AddRecurringTasks adds cron-scheduled task definitions and
+// their run history
+func migrationV6AddRecurringTasks(db *sql.DB) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS recurring_tasks (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			schedule TEXT NOT NULL,
+			agent_id TEXT NOT NULL,
+			team_id TEXT,
+			type TEXT,
+			input TEXT,
+			priority INTEGER DEFAULT 0,
+			max_attempts INTEGER DEFAULT 0,
+			enabled INTEGER DEFAULT 1,
+			allow_overlap INTEGER DEFAULT 0,
+			next_run_at DATETIME,
+			last_run_at DATETIME,
+			last_task_id TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE,
+			FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE SET NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS recurring_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			recurring_id TEXT NOT NULL,
+			task_id TEXT,
+			fired_at DATETIME NOT NULL,
+			skip_reason TEXT,
+			FOREIGN KEY (recurring_id) REFERENCES recurring_tasks(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_recurring_tasks_due ON recurring_tasks(enabled, next_run_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_recurring_runs_fired ON recurring_runs(recurring_id, fired_at DESC)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create recurring task tables: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// migrationV7AddMessageTopics lets the messages table carry message bus
+// topics, and tracks what durable subscribers have acknowledged
+func migrationV7AddMessageTopics(db *sql.DB) error {
+	for _, col := range []string{"topic", "sender", "recipient"} {
+		_, err := db.Exec(fmt.Sprintf("ALTER TABLE messages ADD COLUMN %s TEXT", col))
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("failed to add %s column to messages: %w", col, err)
+		}
+	}
+
+	queries := []string{
+		`CREATE INDEX IF NOT EXISTS idx_messages_topic ON messages(topic)`,
+		`CREATE TABLE IF NOT EXISTS message_subscriptions (
+			subscriber TEXT PRIMARY KEY,
+			pattern TEXT NOT NULL,
+			since_seq INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS message_acks (
+			subscriber TEXT NOT NULL,
+			message_id TEXT NOT NULL,
+			acked_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (subscriber, message_id),
+			FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+		)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create message topic tables: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // Close closes the database connection
 func (adb *AgentDB) Close() error {
 	if adb.db != nil {
@@ -0,0 +1,269 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RecurringTask is a task definition that is queued on a cron schedule
+type RecurringTask struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Schedule     string     `json:"schedule"` // Cron expression, e.g. "0 2 * * *"
+	AgentID      string     `json:"agent_id"`
+	TeamID       *string    `json:"team_id,omitempty"`
+	Type         string     `json:"type"`
+	Input        string     `json:"input"`
+	Priority     int        `json:"priority"`
+	MaxAttempts  int        `json:"max_attempts,omitempty"`
+	Enabled      bool       `json:"enabled"`
+	AllowOverlap bool       `json:"allow_overlap"` // Queue even while the last run is unfinished
+	NextRunAt    *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+	LastTaskID   string     `json:"last_task_id,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// RecurringRun is one firing of a recurring task. Skipped runs have no
+// task; Status is then "skipped" and Error says why.
+type RecurringRun struct {
+	RecurringID string     `json:"recurring_id"`
+	TaskID      string     `json:"task_id,omitempty"`
+	FiredAt     time.Time  `json:"fired_at"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// RecurringTaskRepository handles recurring task database operations
+type RecurringTaskRepository struct {
+	db *sql.DB
+}
+
+// NewRecurringTaskRepository creates a new recurring task repository
+func NewRecurringTaskRepository(db *sql.DB) *RecurringTaskRepository {
+	return &RecurringTaskRepository{db: db}
+}
+
+const recurringColumns = `id, name, schedule, agent_id, team_id, type, input, priority, max_attempts,
+		       enabled, allow_overlap, next_run_at, last_run_at, COALESCE(last_task_id, ''), created_at, updated_at`
+
+func scanRecurring(row interface{ Scan(...interface{}) error }) (*RecurringTask, error) {
+	def := &RecurringTask{}
+	err := row.Scan(
+		&def.ID, &def.Name, &def.Schedule, &def.AgentID, &def.TeamID, &def.Type,
+		&def.Input, &def.Priority, &def.MaxAttempts, &def.Enabled, &def.AllowOverlap,
+		&def.NextRunAt, &def.LastRunAt, &def.LastTaskID, &def.CreatedAt, &def.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// Create creates a new recurring task
+func (r *RecurringTaskRepository) Create(ctx context.Context, def *RecurringTask) error {
+	query := `
+		INSERT INTO recurring_tasks (id, name, schedule, agent_id, team_id, type, input, priority,
+		                             max_attempts, enabled, allow_overlap, next_run_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		def.ID, def.Name, def.Schedule, def.AgentID, def.TeamID, def.Type, def.Input,
+		def.Priority, def.MaxAttempts, def.Enabled, def.AllowOverlap, utcTime(def.NextRunAt))
+	if err != nil {
+		return fmt.Errorf("failed to create recurring task: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves a recurring task by ID
+func (r *RecurringTaskRepository) Get(ctx context.Context, id string) (*RecurringTask, error) {
+	query := `SELECT ` + recurringColumns + ` FROM recurring_tasks WHERE id = ?`
+
+	def, err := scanRecurring(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("recurring task not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get recurring task: %w", err)
+	}
+
+	return def, nil
+}
+
+// Update updates a recurring task's definition. Clear NextRunAt after
+// changing Schedule so the scheduler computes it afresh.
+func (r *RecurringTaskRepository) Update(ctx context.Context, def *RecurringTask) error {
+	query := `
+		UPDATE recurring_tasks
+		SET name = ?, schedule = ?, agent_id = ?, team_id = ?, type = ?, input = ?, priority = ?,
+		    max_attempts = ?, enabled = ?, allow_overlap = ?, next_run_at = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		def.Name, def.Schedule, def.AgentID, def.TeamID, def.Type, def.Input, def.Priority,
+		def.MaxAttempts, def.Enabled, def.AllowOverlap, utcTime(def.NextRunAt), def.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update recurring task: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("recurring task not found: %s", def.ID)
+	}
+
+	return nil
+}
+
+// Delete deletes a recurring task and its run history. Tasks it queued
+// are kept.
+func (r *RecurringTaskRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM recurring_tasks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete recurring task: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("recurring task not found: %s", id)
+	}
+
+	return nil
+}
+
+// List retrieves all recurring tasks by name
+func (r *RecurringTaskRepository) List(ctx context.Context) ([]*RecurringTask, error) {
+	return r.list(ctx, `SELECT `+recurringColumns+` FROM recurring_tasks ORDER BY name`)
+}
+
+// ListDue retrieves the enabled recurring tasks that are due at now, or
+// have no next run computed yet
+func (r *RecurringTaskRepository) ListDue(ctx context.Context, now time.Time) ([]*RecurringTask, error) {
+	return r.list(ctx, `
+		SELECT `+recurringColumns+` FROM recurring_tasks
+		WHERE enabled = 1 AND (next_run_at IS NULL OR next_run_at <= ?)
+		ORDER BY next_run_at`, now.UTC())
+}
+
+func (r *RecurringTaskRepository) list(ctx context.Context, query string, args ...interface{}) ([]*RecurringTask, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recurring tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var defs []*RecurringTask
+	for rows.Next() {
+		def, err := scanRecurring(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan recurring task: %w", err)
+		}
+		defs = append(defs, def)
+	}
+
+	return defs, rows.Err()
+}
+
+// Advance moves a due recurring task from its scheduled next run to next,
+// reporting false when another scheduler already did. Pass the NextRunAt
+// the task was listed with as from.
+func (r *RecurringTaskRepository) Advance(ctx context.Context, id string, from *time.Time, next time.Time) (bool, error) {
+	query := `UPDATE recurring_tasks SET next_run_at = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND `
+	args := []interface{}{next.UTC(), id}
+	if from == nil {
+		query += `next_run_at IS NULL`
+	} else {
+		query += `next_run_at = ?`
+		args = append(args, from.UTC())
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to advance recurring task: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected == 1, nil
+}
+
+// RecordRun adds a firing to a recurring task's history. An empty taskID
+// records a skipped run with reason.
+func (r *RecurringTaskRepository) RecordRun(ctx context.Context, id, taskID string, firedAt time.Time, reason string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var task interface{}
+	if taskID != "" {
+		task = taskID
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO recurring_runs (recurring_id, task_id, fired_at, skip_reason)
+		VALUES (?, ?, ?, ?)
+	`, id, task, firedAt.UTC(), reason)
+	if err != nil {
+		return fmt.Errorf("failed to record recurring run: %w", err)
+	}
+
+	if taskID != "" {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE recurring_tasks SET last_run_at = ?, last_task_id = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?
+		`, firedAt.UTC(), taskID, id)
+		if err != nil {
+			return fmt.Errorf("failed to update recurring task: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// History retrieves a recurring task's runs, newest first, with the
+// current status of the tasks they queued. A run whose task has since been
+// cleaned up has status "removed".
+func (r *RecurringTaskRepository) History(ctx context.Context, id string, limit int) ([]*RecurringRun, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT rr.recurring_id, COALESCE(rr.task_id, ''), rr.fired_at,
+		       CASE WHEN rr.task_id IS NULL THEN 'skipped' ELSE COALESCE(t.status, 'removed') END,
+		       CASE WHEN rr.task_id IS NULL THEN COALESCE(rr.skip_reason, '') ELSE COALESCE(t.last_error, '') END,
+		       t.completed_at
+		FROM recurring_runs rr
+		LEFT JOIN tasks t ON t.id = rr.task_id
+		WHERE rr.recurring_id = ?
+		ORDER BY rr.fired_at DESC, rr.id DESC
+		LIMIT ?
+	`, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recurring runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*RecurringRun
+	for rows.Next() {
+		run := &RecurringRun{}
+		if err := rows.Scan(&run.RecurringID, &run.TaskID, &run.FiredAt, &run.Status, &run.Error, &run.CompletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan recurring run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
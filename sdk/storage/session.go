@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Session represents a conversation session in the database
+type Session struct {
+	ID        string                 `json:"id"`
+	Model     string                 `json:"model"`
+	Metadata  map[string]interface{} `json:"metadata"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// SessionTurn represents a single conversation turn in the database
+type SessionTurn struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"session_id"`
+	Seq       int       `json:"seq"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Tokens    int       `json:"tokens"`
+	IsSummary bool      `json:"is_summary"`
+	Archived  bool      `json:"archived"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SessionRepository handles session database operations
+type SessionRepository struct {
+	db *sql.DB
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create creates a new session
+func (r *SessionRepository) Create(ctx context.Context, session *Session) error {
+	metadataJSON, _ := json.Marshal(session.Metadata)
+
+	query := `
+		INSERT INTO sessions (id, model, metadata)
+		VALUES (?, ?, ?)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, session.ID, session.Model, metadataJSON)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves a session by ID
+func (r *SessionRepository) Get(ctx context.Context, id string) (*Session, error) {
+	query := `
+		SELECT id, model, metadata, created_at, updated_at
+		FROM sessions WHERE id = ?
+	`
+
+	session := &Session{}
+	var model sql.NullString
+	var metadataJSON []byte
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&session.ID, &model, &metadataJSON, &session.CreatedAt, &session.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("session not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	session.Model = model.String
+
+	if len(metadataJSON) > 0 {
+		if err := json.Unmarshal(metadataJSON, &session.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+
+	return session, nil
+}
+
+// SetModel changes the target model of a session
+func (r *SessionRepository) SetModel(ctx context.Context, id, model string) error {
+	query := `UPDATE sessions SET model = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, model, id)
+	if err != nil {
+		return fmt.Errorf("failed to update session model: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("session not found: %s", id)
+	}
+
+	return nil
+}
+
+// Delete deletes a session and all of its turns
+func (r *SessionRepository) Delete(ctx context.Context, id string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM session_turns WHERE session_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete session turns: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM sessions WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("session not found: %s", id)
+	}
+
+	return tx.Commit()
+}
+
+// AppendTurn adds a turn to the end of a session, assigning its sequence number
+func (r *SessionRepository) AppendTurn(ctx context.Context, turn *SessionTurn) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var seq int
+	err = tx.QueryRowContext(ctx,
+		"SELECT COALESCE(MAX(seq), 0) + 1 FROM session_turns WHERE session_id = ?",
+		turn.SessionID).Scan(&seq)
+	if err != nil {
+		return fmt.Errorf("failed to get next turn sequence: %w", err)
+	}
+
+	query := `
+		INSERT INTO session_turns (id, session_id, seq, role, content, tokens, is_summary, archived)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		turn.ID, turn.SessionID, seq, turn.Role, turn.Content, turn.Tokens, turn.IsSummary, turn.Archived)
+	if err != nil {
+		return fmt.Errorf("failed to append session turn: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE sessions SET updated_at = CURRENT_TIMESTAMP WHERE id = ?", turn.SessionID); err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit session turn: %w", err)
+	}
+
+	turn.Seq = seq
+	return nil
+}
+
+// ListTurns retrieves the turns of a session in order.
+// Archived turns are only included when includeArchived is true.
+func (r *SessionRepository) ListTurns(ctx context.Context, sessionID string, includeArchived bool) ([]*SessionTurn, error) {
+	query := `
+		SELECT id, session_id, seq, role, content, tokens, is_summary, archived, created_at
+		FROM session_turns
+		WHERE session_id = ?
+	`
+	if !includeArchived {
+		query += " AND archived = 0"
+	}
+	query += " ORDER BY seq"
+
+	rows, err := r.db.QueryContext(ctx, query, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session turns: %w", err)
+	}
+	defer rows.Close()
+
+	var turns []*SessionTurn
+	for rows.Next() {
+		turn := &SessionTurn{}
+		var content sql.NullString
+		err := rows.Scan(
+			&turn.ID, &turn.SessionID, &turn.Seq, &turn.Role, &content,
+			&turn.Tokens, &turn.IsSummary, &turn.Archived, &turn.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session turn: %w", err)
+		}
+		turn.Content = content.String
+		turns = append(turns, turn)
+	}
+
+	return turns, rows.Err()
+}
+
+// ArchiveTurns marks turns as archived so they no longer count toward the context
+func (r *SessionRepository) ArchiveTurns(ctx context.Context, sessionID string, turnIDs []string) error {
+	if len(turnIDs) == 0 {
+		return nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(turnIDs)), ",")
+	query := fmt.Sprintf(
+		"UPDATE session_turns SET archived = 1 WHERE session_id = ? AND id IN (%s)", placeholders)
+
+	args := make([]interface{}, 0, len(turnIDs)+1)
+	args = append(args, sessionID)
+	for _, id := range turnIDs {
+		args = append(args, id)
+	}
+
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to archive session turns: %w", err)
+	}
+
+	return nil
+}
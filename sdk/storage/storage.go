@@ -18,6 +18,9 @@ type Storage struct {
 	Messages       *MessageRepository
 	Teams          *TeamRepository
 	ToolExecutions *ToolExecutionRepository
+	Sessions       *SessionRepository
+	Recurring      *RecurringTaskRepository
+	Topics         *TopicRepository
 	dataRetention  time.Duration
 }
 
@@ -30,6 +33,9 @@ func NewStorage(db *sql.DB, dataRetention time.Duration) *Storage {
 		Messages:       NewMessageRepository(db),
 		Teams:          NewTeamRepository(db),
 		ToolExecutions: NewToolExecutionRepository(db),
+		Sessions:       NewSessionRepository(db),
+		Recurring:      NewRecurringTaskRepository(db),
+		Topics:         NewTopicRepository(db),
 		dataRetention:  dataRetention,
 	}
 }
@@ -62,6 +68,22 @@ func (s *Storage) NewTaskWithDefaults(agentID, taskType, input string, priority
 	}
 }
 
+// NewRecurringTaskWithDefaults creates an enabled recurring task with
+// default values
+func (s *Storage) NewRecurringTaskWithDefaults(name, schedule, agentID, taskType, input string) *RecurringTask {
+	return &RecurringTask{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Schedule:  schedule,
+		AgentID:   agentID,
+		Type:      taskType,
+		Input:     input,
+		Enabled:   true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
 // NewMessageWithDefaults creates a new message with default values
 func (s *Storage) NewMessageWithDefaults(taskID, agentID, messageType, content string) *Message {
 	return &Message{
@@ -81,6 +81,10 @@ func setupTestDB(t *testing.T) (*sql.DB, string) {
 			started_at DATETIME,
 			completed_at DATETIME,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			attempts INTEGER DEFAULT 0,
+			max_attempts INTEGER DEFAULT 0,
+			run_after DATETIME,
+			last_error TEXT,
 			FOREIGN KEY (agent_id) REFERENCES agents(id) ON DELETE CASCADE,
 			FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE SET NULL
 		);
@@ -2034,6 +2038,42 @@ func TestTaskRepository_ListByStatus(t *testing.T) {
 	}
 }
 
+func TestTaskRepository_ClaimNext(t *testing.T) {
+	db, _ := setupTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	taskRepo := NewTaskRepository(db)
+	NewAgentRepository(db).Create(ctx, &Agent{ID: "agent-claim", Name: "Claim Agent", Status: "idle"})
+
+	for i, priority := range []int{1, 5, 5} {
+		task := &Task{ID: fmt.Sprintf("task-claim-%d", i), AgentID: "agent-claim", Type: "chat", Status: "pending", Priority: priority}
+		if err := taskRepo.Create(ctx, task); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Highest priority first, oldest first within a priority
+	for _, want := range []string{"task-claim-1", "task-claim-2", "task-claim-0"} {
+		task, err := taskRepo.ClaimNext(ctx)
+		if err != nil {
+			t.Fatalf("ClaimNext failed: %v", err)
+		}
+		if task == nil || task.ID != want {
+			t.Fatalf("Expected %s, got %+v", want, task)
+		}
+		if task.Status != "running" || task.StartedAt == nil {
+			t.Errorf("Expected a running task with started_at, got %s", task.Status)
+		}
+	}
+
+	task, err := taskRepo.ClaimNext(ctx)
+	if err != nil || task != nil {
+		t.Errorf("Expected no pending task, got %+v, %v", task, err)
+	}
+}
+
 func TestAgentRepository_Update_NonExistent(t *testing.T) {
 	db, _ := setupTestDB(t)
 	defer db.Close()
@@ -3380,3 +3420,76 @@ func TestToolExecutionRepository_DeleteByTask_Success(t *testing.T) {
 		t.Errorf("Expected 0 executions after delete, got %d", len(executions))
 	}
 }
+
+func TestSessionRepository(t *testing.T) {
+	adb, err := NewAgentDB(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatalf("Failed to create AgentDB: %v", err)
+	}
+	defer adb.Close()
+
+	ctx := context.Background()
+	repo := NewSessionRepository(adb.GetDB())
+
+	session := &Session{ID: "s1", Model: "gpt-4o", Metadata: map[string]interface{}{"user": "alice"}}
+	if err := repo.Create(ctx, session); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	got, err := repo.Get(ctx, "s1")
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if got.Model != "gpt-4o" || got.Metadata["user"] != "alice" {
+		t.Errorf("Unexpected session: %+v", got)
+	}
+
+	if err := repo.SetModel(ctx, "s1", "claude-sonnet-4-5"); err != nil {
+		t.Fatalf("Failed to set model: %v", err)
+	}
+	if err := repo.SetModel(ctx, "missing", "x"); err == nil {
+		t.Error("Expected error setting model on missing session")
+	}
+
+	var ids []string
+	for i, content := range []string{"one", "two", "three"} {
+		turn := &SessionTurn{ID: fmt.Sprintf("t%d", i), SessionID: "s1", Role: "user", Content: content, Tokens: 3}
+		if err := repo.AppendTurn(ctx, turn); err != nil {
+			t.Fatalf("Failed to append turn: %v", err)
+		}
+		if turn.Seq != i+1 {
+			t.Errorf("Expected seq %d, got %d", i+1, turn.Seq)
+		}
+		ids = append(ids, turn.ID)
+	}
+
+	if err := repo.ArchiveTurns(ctx, "s1", ids[:2]); err != nil {
+		t.Fatalf("Failed to archive turns: %v", err)
+	}
+
+	active, err := repo.ListTurns(ctx, "s1", false)
+	if err != nil {
+		t.Fatalf("Failed to list turns: %v", err)
+	}
+	if len(active) != 1 || active[0].Content != "three" {
+		t.Errorf("Expected only the unarchived turn, got %d turns", len(active))
+	}
+
+	all, err := repo.ListTurns(ctx, "s1", true)
+	if err != nil {
+		t.Fatalf("Failed to list all turns: %v", err)
+	}
+	if len(all) != 3 || !all[0].Archived || all[2].Archived {
+		t.Errorf("Unexpected turns including archived: %d", len(all))
+	}
+
+	if err := repo.Delete(ctx, "s1"); err != nil {
+		t.Fatalf("Failed to delete session: %v", err)
+	}
+	if _, err := repo.Get(ctx, "s1"); err == nil {
+		t.Error("Expected error getting deleted session")
+	}
+	if turns, _ := repo.ListTurns(ctx, "s1", true); len(turns) != 0 {
+		t.Errorf("Expected turns to be deleted with session, got %d", len(turns))
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -23,6 +24,39 @@ type Task struct {
 	StartedAt   *time.Time             `json:"started_at,omitempty"`
 	CompletedAt *time.Time             `json:"completed_at,omitempty"`
 	UpdatedAt   time.Time              `json:"updated_at"`
+
+	// Scheduling
+	Attempts    int        `json:"attempts"`               // Times the task has been claimed
+	MaxAttempts int        `json:"max_attempts,omitempty"` // Zero: the scheduler's default
+	RunAfter    *time.Time `json:"run_after,omitempty"`    // Not claimed before this time
+	LastError   string     `json:"last_error,omitempty"`   // Why the last attempt failed
+}
+
+// taskColumns are the columns scanned by scanTask
+const taskColumns = `id, agent_id, team_id, type, status, priority, input, output, metadata,
+		       created_at, started_at, completed_at, updated_at,
+		       COALESCE(attempts, 0), COALESCE(max_attempts, 0), run_after, COALESCE(last_error, '')`
+
+// scanTask reads a row of taskColumns
+func scanTask(row interface{ Scan(...interface{}) error }) (*Task, error) {
+	task := &Task{}
+	var metadataJSON []byte
+	err := row.Scan(
+		&task.ID, &task.AgentID, &task.TeamID, &task.Type, &task.Status,
+		&task.Priority, &task.Input, &task.Output, &metadataJSON,
+		&task.CreatedAt, &task.StartedAt, &task.CompletedAt, &task.UpdatedAt,
+		&task.Attempts, &task.MaxAttempts, &task.RunAfter, &task.LastError)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metadataJSON) > 0 {
+		if err := json.Unmarshal(metadataJSON, &task.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+
+	return task, nil
 }
 
 // TaskRepository handles task database operations
@@ -40,13 +74,13 @@ func (r *TaskRepository) Create(ctx context.Context, task *Task) error {
 	metadataJSON, _ := json.Marshal(task.Metadata)
 
 	query := `
-		INSERT INTO tasks (id, agent_id, team_id, type, status, priority, input, output, metadata)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO tasks (id, agent_id, team_id, type, status, priority, input, output, metadata, max_attempts, run_after)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
 		task.ID, task.AgentID, task.TeamID, task.Type, task.Status,
-		task.Priority, task.Input, task.Output, metadataJSON)
+		task.Priority, task.Input, task.Output, metadataJSON, task.MaxAttempts, utcTime(task.RunAfter))
 	if err != nil {
 		return fmt.Errorf("failed to create task: %w", err)
 	}
@@ -56,19 +90,9 @@ func (r *TaskRepository) Create(ctx context.Context, task *Task) error {
 
 // Get retrieves a task by ID
 func (r *TaskRepository) Get(ctx context.Context, id string) (*Task, error) {
-	query := `
-		SELECT id, agent_id, team_id, type, status, priority, input, output, metadata,
-		       created_at, started_at, completed_at, updated_at
-		FROM tasks WHERE id = ?
-	`
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE id = ?`
 
-	task := &Task{}
-	var metadataJSON []byte
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&task.ID, &task.AgentID, &task.TeamID, &task.Type, &task.Status,
-		&task.Priority, &task.Input, &task.Output, &metadataJSON,
-		&task.CreatedAt, &task.StartedAt, &task.CompletedAt, &task.UpdatedAt)
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("task not found: %s", id)
@@ -76,12 +100,6 @@ func (r *TaskRepository) Get(ctx context.Context, id string) (*Task, error) {
 		return nil, fmt.Errorf("failed to get task: %w", err)
 	}
 
-	if len(metadataJSON) > 0 {
-		if err := json.Unmarshal(metadataJSON, &task.Metadata); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-		}
-	}
-
 	return task, nil
 }
 
@@ -92,13 +110,15 @@ func (r *TaskRepository) Update(ctx context.Context, task *Task) error {
 	query := `
 		UPDATE tasks 
 		SET agent_id = ?, team_id = ?, type = ?, status = ?, priority = ?,
-		    input = ?, output = ?, metadata = ?, started_at = ?, completed_at = ?, updated_at = CURRENT_TIMESTAMP
+		    input = ?, output = ?, metadata = ?, started_at = ?, completed_at = ?,
+		    attempts = ?, max_attempts = ?, run_after = ?, last_error = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`
 
 	result, err := r.db.ExecContext(ctx, query,
 		task.AgentID, task.TeamID, task.Type, task.Status, task.Priority,
-		task.Input, task.Output, metadataJSON, task.StartedAt, task.CompletedAt, task.ID)
+		task.Input, task.Output, metadataJSON, task.StartedAt, task.CompletedAt,
+		task.Attempts, task.MaxAttempts, utcTime(task.RunAfter), task.LastError, task.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update task: %w", err)
 	}
@@ -139,8 +159,7 @@ func (r *TaskRepository) Delete(ctx context.Context, id string) error {
 // ListByAgent retrieves tasks for a specific agent
 func (r *TaskRepository) ListByAgent(ctx context.Context, agentID string, limit, offset int) ([]*Task, error) {
 	query := `
-		SELECT id, agent_id, team_id, type, status, priority, input, output, metadata,
-		       created_at, started_at, completed_at, updated_at
+		SELECT ` + taskColumns + `
 		FROM tasks 
 		WHERE agent_id = ?
 		ORDER BY created_at DESC
@@ -155,23 +174,11 @@ func (r *TaskRepository) ListByAgent(ctx context.Context, agentID string, limit,
 
 	var tasks []*Task
 	for rows.Next() {
-		task := &Task{}
-		var metadataJSON []byte
-
-		err := rows.Scan(
-			&task.ID, &task.AgentID, &task.TeamID, &task.Type, &task.Status,
-			&task.Priority, &task.Input, &task.Output, &metadataJSON,
-			&task.CreatedAt, &task.StartedAt, &task.CompletedAt, &task.UpdatedAt)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan task: %w", err)
 		}
 
-		if len(metadataJSON) > 0 {
-			if err := json.Unmarshal(metadataJSON, &task.Metadata); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-			}
-		}
-
 		tasks = append(tasks, task)
 	}
 
@@ -181,8 +188,7 @@ func (r *TaskRepository) ListByAgent(ctx context.Context, agentID string, limit,
 // ListByTeam retrieves tasks for a specific team
 func (r *TaskRepository) ListByTeam(ctx context.Context, teamID string, limit, offset int) ([]*Task, error) {
 	query := `
-		SELECT id, agent_id, team_id, type, status, priority, input, output, metadata,
-		       created_at, started_at, completed_at, updated_at
+		SELECT ` + taskColumns + `
 		FROM tasks 
 		WHERE team_id = ?
 		ORDER BY created_at DESC
@@ -197,23 +203,11 @@ func (r *TaskRepository) ListByTeam(ctx context.Context, teamID string, limit, o
 
 	var tasks []*Task
 	for rows.Next() {
-		task := &Task{}
-		var metadataJSON []byte
-
-		err := rows.Scan(
-			&task.ID, &task.AgentID, &task.TeamID, &task.Type, &task.Status,
-			&task.Priority, &task.Input, &task.Output, &metadataJSON,
-			&task.CreatedAt, &task.StartedAt, &task.CompletedAt, &task.UpdatedAt)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan task: %w", err)
 		}
 
-		if len(metadataJSON) > 0 {
-			if err := json.Unmarshal(metadataJSON, &task.Metadata); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-			}
-		}
-
 		tasks = append(tasks, task)
 	}
 
@@ -223,8 +217,7 @@ func (r *TaskRepository) ListByTeam(ctx context.Context, teamID string, limit, o
 // ListByStatus retrieves tasks by status
 func (r *TaskRepository) ListByStatus(ctx context.Context, status string, limit, offset int) ([]*Task, error) {
 	query := `
-		SELECT id, agent_id, team_id, type, status, priority, input, output, metadata,
-		       created_at, started_at, completed_at, updated_at
+		SELECT ` + taskColumns + `
 		FROM tasks 
 		WHERE status = ?
 		ORDER BY priority DESC, created_at ASC
@@ -239,23 +232,11 @@ func (r *TaskRepository) ListByStatus(ctx context.Context, status string, limit,
 
 	var tasks []*Task
 	for rows.Next() {
-		task := &Task{}
-		var metadataJSON []byte
-
-		err := rows.Scan(
-			&task.ID, &task.AgentID, &task.TeamID, &task.Type, &task.Status,
-			&task.Priority, &task.Input, &task.Output, &metadataJSON,
-			&task.CreatedAt, &task.StartedAt, &task.CompletedAt, &task.UpdatedAt)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan task: %w", err)
 		}
 
-		if len(metadataJSON) > 0 {
-			if err := json.Unmarshal(metadataJSON, &task.Metadata); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
-			}
-		}
-
 		tasks = append(tasks, task)
 	}
 
@@ -286,3 +267,169 @@ func (r *TaskRepository) UpdateStatus(ctx context.Context, id, status string) er
 
 	return nil
 }
+
+// ClaimNext marks the highest-priority pending task running and returns
+// it, or nil when none is ready. Tasks whose run_after is in the future,
+// or whose type is in skipTypes, are left for later. A task is claimed by
+// one caller only, even when several workers poll at once.
+func (r *TaskRepository) ClaimNext(ctx context.Context, skipTypes ...string) (*Task, error) {
+	query := `
+		SELECT id FROM tasks
+		WHERE status = 'pending' AND (run_after IS NULL OR run_after <= ?)`
+	args := []interface{}{time.Now().UTC()}
+	if len(skipTypes) > 0 {
+		query += ` AND type NOT IN (?` + strings.Repeat(", ?", len(skipTypes)-1) + `)`
+		for _, t := range skipTypes {
+			args = append(args, t)
+		}
+	}
+	query += `
+		ORDER BY priority DESC, created_at ASC
+		LIMIT 1`
+
+	for {
+		var id string
+		err := r.db.QueryRowContext(ctx, query, args...).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find pending task: %w", err)
+		}
+
+		result, err := r.db.ExecContext(ctx, `
+			UPDATE tasks
+			SET status = 'running', started_at = ?, attempts = COALESCE(attempts, 0) + 1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ? AND status = 'pending'
+		`, time.Now(), id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim task: %w", err)
+		}
+		claimed, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if claimed == 1 {
+			return r.Get(ctx, id)
+		}
+		// Another worker claimed it first; try the next one
+	}
+}
+
+// Retry returns a failed task to the queue, to be claimed again after
+// runAfter
+func (r *TaskRepository) Retry(ctx context.Context, id string, runAfter time.Time, reason string) error {
+	return r.setState(ctx, id, `status = 'pending', run_after = ?, last_error = ?, completed_at = NULL`,
+		runAfter.UTC(), reason)
+}
+
+// DeadLetter parks a task that failed too often; it is not claimed again
+// until requeued
+func (r *TaskRepository) DeadLetter(ctx context.Context, id, reason string) error {
+	return r.setState(ctx, id, `status = 'dead_letter', last_error = ?, completed_at = ?`,
+		reason, time.Now())
+}
+
+// Requeue makes a failed or dead-lettered task pending again with its
+// attempts reset
+func (r *TaskRepository) Requeue(ctx context.Context, id string) error {
+	return r.setState(ctx, id, `status = 'pending', attempts = 0, run_after = NULL, completed_at = NULL`)
+}
+
+func (r *TaskRepository) setState(ctx context.Context, id, set string, args ...interface{}) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE tasks SET `+set+`, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		append(args, id)...)
+	if err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("task not found: %s", id)
+	}
+
+	return nil
+}
+
+// QueueStats summarizes the task queue
+type QueueStats struct {
+	ByStatus      map[string]int  `json:"by_status"`
+	PendingByType map[string]int  `json:"pending_by_type"`
+	Delayed       int             `json:"delayed"` // Pending, waiting for a retry
+	Failures      []FailureReason `json:"failures"`
+}
+
+// FailureReason counts failed and dead-lettered tasks by their last error
+type FailureReason struct {
+	Reason   string    `json:"reason"`
+	Count    int       `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// QueueStats reports queue depth and the most common failure reasons, up
+// to failureLimit of them
+func (r *TaskRepository) QueueStats(ctx context.Context, failureLimit int) (*QueueStats, error) {
+	stats := &QueueStats{ByStatus: map[string]int{}, PendingByType: map[string]int{}}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT status, COALESCE(type, ''), COUNT(*),
+		       SUM(CASE WHEN run_after > ? THEN 1 ELSE 0 END)
+		FROM tasks GROUP BY status, type
+	`, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status, taskType string
+		var count, delayed int
+		if err := rows.Scan(&status, &taskType, &count, &delayed); err != nil {
+			return nil, fmt.Errorf("failed to scan task counts: %w", err)
+		}
+		stats.ByStatus[status] += count
+		if status == "pending" {
+			stats.PendingByType[taskType] += count
+			stats.Delayed += delayed
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+
+	failures, err := r.db.QueryContext(ctx, `
+		SELECT last_error, COUNT(*), MAX(updated_at)
+		FROM tasks
+		WHERE status IN ('failed', 'dead_letter') AND COALESCE(last_error, '') != ''
+		GROUP BY last_error
+		ORDER BY COUNT(*) DESC, MAX(updated_at) DESC
+		LIMIT ?
+	`, failureLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failure reasons: %w", err)
+	}
+	defer failures.Close()
+	for failures.Next() {
+		var f FailureReason
+		var lastSeen string
+		if err := failures.Scan(&f.Reason, &f.Count, &lastSeen); err != nil {
+			return nil, fmt.Errorf("failed to scan failure reason: %w", err)
+		}
+		f.LastSeen, _ = time.Parse("2006-01-02 15:04:05", lastSeen) // MAX() loses the column's DATETIME type
+		stats.Failures = append(stats.Failures, f)
+	}
+	return stats, failures.Err()
+}
+
+// utcTime stores scheduling times in UTC, so they compare correctly as
+// text
+func utcTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
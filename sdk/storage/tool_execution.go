@@ -35,13 +35,14 @@ func NewToolExecutionRepository(db *sql.DB) *ToolExecutionRepository {
 	return &ToolExecutionRepository{db: db}
 }
 
-// Create creates a new tool execution
+// Create creates a new tool execution. An empty AgentID is stored as NULL,
+// for runs outside any agent.
 func (r *ToolExecutionRepository) Create(ctx context.Context, execution *ToolExecution) error {
 	metadataJSON, _ := json.Marshal(execution.Metadata)
 
 	query := `
 		INSERT INTO tool_executions (id, task_id, agent_id, tool_name, tool_type, input, output, error, status, duration, metadata, started_at, completed_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		VALUES (?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -58,7 +59,7 @@ func (r *ToolExecutionRepository) Create(ctx context.Context, execution *ToolExe
 // Get retrieves a tool execution by ID
 func (r *ToolExecutionRepository) Get(ctx context.Context, id string) (*ToolExecution, error) {
 	query := `
-		SELECT id, task_id, agent_id, tool_name, tool_type, input, output, error, status, duration, metadata, started_at, completed_at
+		SELECT id, task_id, COALESCE(agent_id, ''), tool_name, tool_type, input, output, error, status, duration, metadata, started_at, completed_at
 		FROM tool_executions WHERE id = ?
 	`
 
@@ -91,7 +92,7 @@ func (r *ToolExecutionRepository) Update(ctx context.Context, execution *ToolExe
 
 	query := `
 		UPDATE tool_executions 
-		SET task_id = ?, agent_id = ?, tool_name = ?, tool_type = ?, input = ?, output = ?, 
+		SET task_id = ?, agent_id = NULLIF(?, ''), tool_name = ?, tool_type = ?, input = ?, output = ?, 
 		    error = ?, status = ?, duration = ?, metadata = ?, started_at = ?, completed_at = ?
 		WHERE id = ?
 	`
@@ -169,7 +170,7 @@ func (r *ToolExecutionRepository) MarkFailed(ctx context.Context, id, errorMsg s
 // ListByTask retrieves tool executions for a specific task
 func (r *ToolExecutionRepository) ListByTask(ctx context.Context, taskID string, limit, offset int) ([]*ToolExecution, error) {
 	query := `
-		SELECT id, task_id, agent_id, tool_name, tool_type, input, output, error, status, duration, metadata, started_at, completed_at
+		SELECT id, task_id, COALESCE(agent_id, ''), tool_name, tool_type, input, output, error, status, duration, metadata, started_at, completed_at
 		FROM tool_executions 
 		WHERE task_id = ?
 		ORDER BY started_at DESC
@@ -210,7 +211,7 @@ func (r *ToolExecutionRepository) ListByTask(ctx context.Context, taskID string,
 // ListByAgent retrieves tool executions for a specific agent
 func (r *ToolExecutionRepository) ListByAgent(ctx context.Context, agentID string, limit, offset int) ([]*ToolExecution, error) {
 	query := `
-		SELECT id, task_id, agent_id, tool_name, tool_type, input, output, error, status, duration, metadata, started_at, completed_at
+		SELECT id, task_id, COALESCE(agent_id, ''), tool_name, tool_type, input, output, error, status, duration, metadata, started_at, completed_at
 		FROM tool_executions 
 		WHERE agent_id = ?
 		ORDER BY started_at DESC
@@ -251,7 +252,7 @@ func (r *ToolExecutionRepository) ListByAgent(ctx context.Context, agentID strin
 // ListByTool retrieves executions for a specific tool
 func (r *ToolExecutionRepository) ListByTool(ctx context.Context, toolName string, limit, offset int) ([]*ToolExecution, error) {
 	query := `
-		SELECT id, task_id, agent_id, tool_name, tool_type, input, output, error, status, duration, metadata, started_at, completed_at
+		SELECT id, task_id, COALESCE(agent_id, ''), tool_name, tool_type, input, output, error, status, duration, metadata, started_at, completed_at
 		FROM tool_executions 
 		WHERE tool_name = ?
 		ORDER BY started_at DESC
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TopicMessage is a message published on a topic, stored in the messages
+// table. From and To are free-form; when they name agents, the from_agent
+// and to_agent columns are filled in too.
+type TopicMessage struct {
+	ID        string                 `json:"id"`
+	Topic     string                 `json:"topic"`
+	From      string                 `json:"from,omitempty"`
+	To        string                 `json:"to,omitempty"`
+	TeamID    *string                `json:"team_id,omitempty"`
+	Type      string                 `json:"type,omitempty"`
+	Content   string                 `json:"content"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// TopicRepository stores topic messages and tracks which of them each
+// durable subscriber has acknowledged
+type TopicRepository struct {
+	db *sql.DB
+}
+
+// NewTopicRepository creates a new topic repository
+func NewTopicRepository(db *sql.DB) *TopicRepository {
+	return &TopicRepository{db: db}
+}
+
+const topicColumns = `id, topic, COALESCE(sender, ''), COALESCE(recipient, ''), team_id,
+		       COALESCE(message_type, ''), COALESCE(content, ''), data, created_at`
+
+// Append stores a message
+func (r *TopicRepository) Append(ctx context.Context, msg *TopicMessage) error {
+	dataJSON, _ := json.Marshal(msg.Data)
+
+	query := `
+		INSERT INTO messages (id, topic, sender, recipient, from_agent, to_agent, team_id, message_type, content, data)
+		VALUES (?, ?, ?, ?, (SELECT id FROM agents WHERE id = ?), (SELECT id FROM agents WHERE id = ?),
+		        (SELECT id FROM teams WHERE id = ?), ?, ?, ?)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		msg.ID, msg.Topic, msg.From, msg.To, msg.From, msg.To, msg.TeamID,
+		msg.Type, msg.Content, dataJSON)
+	if err != nil {
+		return fmt.Errorf("failed to store topic message: %w", err)
+	}
+
+	return nil
+}
+
+// List retrieves the latest messages on topics matching pattern, oldest
+// first. A pattern ending in "*" matches topics by prefix.
+func (r *TopicRepository) List(ctx context.Context, pattern string, limit int) ([]*TopicMessage, error) {
+	filter, args := topicFilter(pattern)
+	return r.query(ctx, `
+		SELECT * FROM (
+			SELECT `+topicColumns+`, rowid AS seq FROM messages
+			WHERE `+filter+`
+			ORDER BY rowid DESC LIMIT ?
+		) ORDER BY seq`, append(args, limit)...)
+}
+
+// Register records a durable subscriber. Only messages stored after its
+// first registration are delivered to it.
+func (r *TopicRepository) Register(ctx context.Context, subscriber, pattern string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO message_subscriptions (subscriber, pattern, since_seq)
+		VALUES (?, ?, (SELECT COALESCE(MAX(rowid), 0) FROM messages))
+		ON CONFLICT(subscriber) DO UPDATE SET pattern = excluded.pattern
+	`, subscriber, pattern)
+	if err != nil {
+		return fmt.Errorf("failed to register subscriber: %w", err)
+	}
+	return nil
+}
+
+// Unacked retrieves the messages a durable subscriber has not
+// acknowledged, oldest first. A limit of zero or less retrieves all of
+// them.
+func (r *TopicRepository) Unacked(ctx context.Context, subscriber string, limit int) ([]*TopicMessage, error) {
+	if limit <= 0 {
+		limit = -1 // No limit in SQLite
+	}
+	var pattern string
+	var since int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT pattern, since_seq FROM message_subscriptions WHERE subscriber = ?`, subscriber).Scan(&pattern, &since)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("subscriber not found: %s", subscriber)
+		}
+		return nil, fmt.Errorf("failed to get subscriber: %w", err)
+	}
+
+	filter, args := topicFilter(pattern)
+	return r.query(ctx, `
+		SELECT `+topicColumns+`, m.rowid FROM messages m
+		WHERE `+filter+` AND m.rowid > ?
+		  AND NOT EXISTS (SELECT 1 FROM message_acks a WHERE a.subscriber = ? AND a.message_id = m.id)
+		ORDER BY m.rowid LIMIT ?`, append(args, since, subscriber, limit)...)
+}
+
+// Ack records that a durable subscriber has handled a message
+func (r *TopicRepository) Ack(ctx context.Context, subscriber, messageID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO message_acks (subscriber, message_id) VALUES (?, ?)
+	`, subscriber, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to ack message: %w", err)
+	}
+	return nil
+}
+
+// Unregister removes a durable subscriber and its acknowledgements
+func (r *TopicRepository) Unregister(ctx context.Context, subscriber string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM message_acks WHERE subscriber = ?`, subscriber); err != nil {
+		return fmt.Errorf("failed to delete acks: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM message_subscriptions WHERE subscriber = ?`, subscriber); err != nil {
+		return fmt.Errorf("failed to delete subscriber: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (r *TopicRepository) query(ctx context.Context, query string, args ...interface{}) ([]*TopicMessage, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topic messages: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []*TopicMessage
+	for rows.Next() {
+		msg := &TopicMessage{}
+		var dataJSON []byte
+		var seq int64
+		err := rows.Scan(&msg.ID, &msg.Topic, &msg.From, &msg.To, &msg.TeamID,
+			&msg.Type, &msg.Content, &dataJSON, &msg.CreatedAt, &seq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan topic message: %w", err)
+		}
+		if len(dataJSON) > 0 {
+			if err := json.Unmarshal(dataJSON, &msg.Data); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+			}
+		}
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, rows.Err()
+}
+
+// topicFilter is the WHERE clause matching a topic pattern. LIKE is not
+// used for prefixes as it ignores case.
+func topicFilter(pattern string) (string, []interface{}) {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return `substr(topic, 1, length(?)) = ?`, []interface{}{prefix, prefix}
+	}
+	return `topic = ?`, []interface{}{pattern}
+}
@@ -0,0 +1,142 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chunkReader returns one part per Read, so each part becomes one chunk of
+// an HTTP stream
+type chunkReader struct {
+	parts []string
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.parts) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.parts[0])
+	r.parts = r.parts[1:]
+	return n, nil
+}
+
+func TestStream_DropOldest(t *testing.T) {
+	reader := &chunkReader{parts: []string{"a", "b", "c", "d", "e"}}
+	s := NewStreamWithOptions(context.Background(), reader, StreamTypeHTTP, Options{
+		BufferSize: 2,
+		Overflow:   OverflowDropOldest,
+	})
+
+	// The producer never blocks, so it finishes without a reader
+	select {
+	case <-s.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("producer blocked despite drop_oldest")
+	}
+
+	var got []string
+	for c := range s.Chunks() {
+		got = append(got, c.Data)
+	}
+	if strings.Join(got, "") != "de" {
+		t.Errorf("expected the newest chunks, got %v", got)
+	}
+	if s.Dropped() != 3 {
+		t.Errorf("Dropped() = %d, want 3", s.Dropped())
+	}
+}
+
+func TestStream_SlowConsumerCancelsUpstream(t *testing.T) {
+	pr, pw := io.Pipe()
+	go func() {
+		for {
+			if _, err := pw.Write([]byte("token ")); err != nil {
+				return
+			}
+		}
+	}()
+
+	s := NewStreamWithOptions(context.Background(), pr, StreamTypeHTTP, Options{
+		BufferSize:          1,
+		SlowConsumerTimeout: 50 * time.Millisecond,
+	})
+
+	// Nobody reads; the stream gives up and closes the reader
+	select {
+	case <-s.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream kept waiting for a consumer that stopped reading")
+	}
+	if !errors.Is(s.Err(), ErrSlowConsumer) {
+		t.Errorf("Err() = %v, want ErrSlowConsumer", s.Err())
+	}
+	if _, err := pw.Write([]byte("more")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("expected the upstream reader to be closed, write got %v", err)
+	}
+}
+
+func TestStream_SlowConsumerThroughOperators(t *testing.T) {
+	pr, pw := io.Pipe()
+	go func() {
+		for {
+			if _, err := pw.Write([]byte("token ")); err != nil {
+				return
+			}
+		}
+	}()
+
+	src := NewStreamWithOptions(context.Background(), pr, StreamTypeHTTP, Options{
+		BufferSize:          1,
+		SlowConsumerTimeout: 50 * time.Millisecond,
+	})
+	mapped := src.Map(func(c *Chunk) *Chunk { return c })
+
+	select {
+	case <-mapped.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("derived stream kept waiting for its consumer")
+	}
+	if !errors.Is(mapped.Err(), ErrSlowConsumer) {
+		t.Errorf("Err() = %v, want ErrSlowConsumer", mapped.Err())
+	}
+	select {
+	case <-src.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("source was not canceled")
+	}
+}
+
+func TestBroadcast_BoundedSubscribers(t *testing.T) {
+	src := make(chan *Chunk)
+	b := chanStream(src).Broadcast()
+	lossy := b.SubscribeWithOptions(Options{BufferSize: 2, Overflow: OverflowDropOldest})
+	stuck := b.SubscribeWithOptions(Options{BufferSize: 2, SlowConsumerTimeout: 50 * time.Millisecond})
+	fast := b.Subscribe()
+	b.Start()
+
+	go func() {
+		for i := 0; i < 40; i++ {
+			src <- dataChunk("x")
+		}
+		close(src)
+	}()
+
+	if n := len(drain(fast)); n != 40 {
+		t.Errorf("fast subscriber got %d chunks, want 40", n)
+	}
+
+	// The blocked subscriber was detached with ErrSlowConsumer
+	drain(stuck)
+	if !errors.Is(stuck.Err(), ErrSlowConsumer) {
+		t.Errorf("stuck subscriber Err() = %v, want ErrSlowConsumer", stuck.Err())
+	}
+
+	n := len(drain(lossy))
+	if n >= 40 || lossy.Dropped() == 0 || int64(n)+lossy.Dropped() != 40 {
+		t.Errorf("lossy subscriber got %d chunks and dropped %d", n, lossy.Dropped())
+	}
+}
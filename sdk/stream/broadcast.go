@@ -0,0 +1,233 @@
+package stream
+
+import (
+	"sync"
+	"time"
+)
+
+// Broadcast fans one stream out to any number of subscribers without
+// re-reading the source. Each subscriber has its own queue, so a slow
+// consumer (a UI redrawing per chunk) never holds back a fast one (a token
+// counter), and closing one subscriber leaves the others running. When the
+// last subscriber closes, the source is canceled.
+//
+//	b := s.Broadcast()
+//	ui, logger := b.Subscribe(), b.Subscribe()
+//	b.Start()
+//
+// Subscribers share chunk values and must treat them as read-only.
+// SubscribeWithOptions bounds a subscriber's queue; see Options.
+type Broadcast struct {
+	src *Stream
+
+	mu       sync.Mutex
+	subs     map[*subscriber]struct{}
+	started  bool
+	finished bool
+}
+
+// subscriber is one consumer's queue
+type subscriber struct {
+	out  *Stream
+	opts Options // Zero BufferSize: unbounded queue
+
+	mu     sync.Mutex
+	queue  []*Chunk
+	ended  bool          // Source finished; drain the queue and stop
+	signal chan struct{} // Wakes the pump when chunks are queued
+	space  chan struct{} // Wakes a blocked push when the pump takes the queue
+}
+
+// Broadcast creates a fan-out over s. Subscribe consumers, then call Start;
+// s must not be read directly afterwards.
+func (s *Stream) Broadcast() *Broadcast {
+	return &Broadcast{src: s, subs: make(map[*subscriber]struct{})}
+}
+
+// Tee splits s into n independent streams that each receive every chunk
+func (s *Stream) Tee(n int) []*Stream {
+	b := s.Broadcast()
+	streams := make([]*Stream, n)
+	for i := range streams {
+		streams[i] = b.Subscribe()
+	}
+	b.Start()
+	return streams
+}
+
+// Subscribe adds a consumer with an unbounded queue. Subscribers added
+// after Start only see chunks from that point on; after the source ends
+// they get an empty stream carrying its error.
+func (b *Broadcast) Subscribe() *Stream {
+	return b.SubscribeWithOptions(Options{})
+}
+
+// SubscribeWithOptions adds a consumer whose queue holds at most
+// opts.BufferSize chunks (zero is unbounded). When it is full,
+// OverflowDropOldest discards the subscriber's oldest chunk, while
+// OverflowBlock holds up the whole broadcast until the subscriber catches
+// up; with a SlowConsumerTimeout, a subscriber blocking longer than that is
+// detached with ErrSlowConsumer instead.
+func (b *Broadcast) SubscribeWithOptions(opts Options) *Stream {
+	sub := &subscriber{
+		out:    b.src.derive(),
+		opts:   opts,
+		signal: make(chan struct{}, 1),
+		space:  make(chan struct{}, 1),
+	}
+	// A slow subscriber must not cancel the source for everyone else
+	sub.out.upstream = nil
+
+	b.mu.Lock()
+	if b.finished {
+		b.mu.Unlock()
+		sub.out.finish(b.src)
+		return sub.out
+	}
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	go b.pump(sub)
+	return sub.out
+}
+
+// Start begins reading the source. Calling it again has no effect.
+func (b *Broadcast) Start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.started {
+		return
+	}
+	b.started = true
+	go b.dispatch()
+}
+
+// Subscribers returns the number of open subscribers
+func (b *Broadcast) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// dispatch copies every source chunk to each subscriber's queue
+func (b *Broadcast) dispatch() {
+	for chunk := range b.src.chunks {
+		b.mu.Lock()
+		subs := make([]*subscriber, 0, len(b.subs))
+		for sub := range b.subs {
+			subs = append(subs, sub)
+		}
+		b.mu.Unlock()
+
+		for _, sub := range subs {
+			if !sub.push(chunk) {
+				sub.out.abandon()
+				b.remove(sub)
+			}
+		}
+	}
+
+	b.mu.Lock()
+	b.finished = true
+	for sub := range b.subs {
+		sub.end()
+	}
+	b.mu.Unlock()
+}
+
+// pump delivers a subscriber's queue at its consumer's pace
+func (b *Broadcast) pump(sub *subscriber) {
+	defer sub.out.finish(b.src)
+
+	for {
+		sub.mu.Lock()
+		queue, ended := sub.queue, sub.ended
+		sub.queue = nil
+		sub.mu.Unlock()
+		notify(sub.space)
+
+		for _, chunk := range queue {
+			if !sub.out.forward(chunk) {
+				b.remove(sub)
+				return
+			}
+		}
+		if len(queue) > 0 {
+			continue
+		}
+		if ended {
+			b.remove(sub)
+			return
+		}
+
+		select {
+		case <-sub.signal:
+		case <-sub.out.ctx.Done():
+			b.remove(sub)
+			return
+		}
+	}
+}
+
+// remove detaches a subscriber, canceling the source once none are left
+// while it is still running
+func (b *Broadcast) remove(sub *subscriber) {
+	b.mu.Lock()
+	delete(b.subs, sub)
+	idle := b.started && !b.finished && len(b.subs) == 0
+	b.mu.Unlock()
+
+	if idle && b.src.cancel != nil {
+		b.src.cancel()
+	}
+}
+
+// push queues a chunk under the subscriber's overflow policy, reporting
+// false when a blocked push outlasted SlowConsumerTimeout
+func (sub *subscriber) push(chunk *Chunk) bool {
+	var timeout <-chan time.Time
+	if sub.opts.SlowConsumerTimeout > 0 {
+		timer := time.NewTimer(sub.opts.SlowConsumerTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		sub.mu.Lock()
+		limit := sub.opts.BufferSize
+		if limit > 0 && len(sub.queue) >= limit && sub.opts.Overflow == OverflowDropOldest {
+			sub.queue = sub.queue[1:]
+			sub.out.dropped.Add(1)
+		}
+		if limit <= 0 || len(sub.queue) < limit {
+			sub.queue = append(sub.queue, chunk)
+			sub.mu.Unlock()
+			notify(sub.signal)
+			return true
+		}
+		sub.mu.Unlock()
+
+		select {
+		case <-sub.space:
+		case <-sub.out.ctx.Done():
+			return true // The pump detaches canceled subscribers
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+func (sub *subscriber) end() {
+	sub.mu.Lock()
+	sub.ended = true
+	sub.mu.Unlock()
+	notify(sub.signal)
+}
+
+// notify wakes a goroutine waiting on ch without blocking
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,133 @@
+package stream
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStream_Tee_EveryConsumerGetsEveryChunk(t *testing.T) {
+	sseData := `data: {"content": "Hello"}
+
+data: {"content": " World"}
+
+data: [DONE]
+
+`
+	streams := NewStream(context.Background(), strings.NewReader(sseData), StreamTypeSSE).Tee(3)
+
+	var wg sync.WaitGroup
+	results := make([]string, len(streams))
+	for i, s := range streams {
+		wg.Add(1)
+		go func(i int, s *Stream) {
+			defer wg.Done()
+			text, err := s.Collect()
+			if err != nil {
+				t.Errorf("consumer %d: %v", i, err)
+			}
+			results[i] = text
+		}(i, s)
+	}
+	wg.Wait()
+
+	for i, text := range results {
+		if text != "Hello World" {
+			t.Errorf("consumer %d got %q", i, text)
+		}
+	}
+}
+
+func TestBroadcast_SlowConsumerDoesNotBlockOthers(t *testing.T) {
+	src := make(chan *Chunk)
+	b := chanStream(src).Broadcast()
+	slow := b.Subscribe()
+	fast := b.Subscribe()
+	b.Start()
+
+	go func() {
+		for i := 0; i < 50; i++ {
+			src <- dataChunk("x")
+		}
+		close(src)
+	}()
+
+	// The fast consumer finishes while the slow one has not read anything
+	done := make(chan int)
+	go func() { done <- len(drain(fast)) }()
+	select {
+	case n := <-done:
+		if n != 50 {
+			t.Errorf("fast consumer got %d chunks, want 50", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("fast consumer was held back by the slow one")
+	}
+
+	if n := len(drain(slow)); n != 50 {
+		t.Errorf("slow consumer got %d chunks, want 50", n)
+	}
+}
+
+func TestBroadcast_IndependentCancellation(t *testing.T) {
+	src := make(chan *Chunk)
+	source := chanStream(src)
+	b := source.Broadcast()
+	a, c := b.Subscribe(), b.Subscribe()
+	b.Start()
+
+	src <- dataChunk("one")
+	<-a.Chunks()
+	a.Close()
+
+	src <- dataChunk("two")
+	close(src)
+
+	var got []string
+	for chunk := range c.Chunks() {
+		got = append(got, chunk.Data)
+	}
+	if strings.Join(got, ",") != "one,two" {
+		t.Errorf("remaining consumer got %v", got)
+	}
+	if source.ctx.Err() != nil {
+		t.Error("source canceled while a subscriber was still open")
+	}
+}
+
+func TestBroadcast_LastSubscriberCancelsSource(t *testing.T) {
+	src := make(chan *Chunk)
+	source := chanStream(src)
+	b := source.Broadcast()
+	a, c := b.Subscribe(), b.Subscribe()
+	b.Start()
+
+	a.Close()
+	c.Close()
+
+	select {
+	case <-source.ctx.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("source was not canceled after every subscriber closed")
+	}
+	if b.Subscribers() != 0 {
+		t.Errorf("Subscribers() = %d, want 0", b.Subscribers())
+	}
+	close(src)
+}
+
+func TestBroadcast_SubscribeAfterEnd(t *testing.T) {
+	src := make(chan *Chunk)
+	close(src)
+	b := chanStream(src).Broadcast()
+	first := b.Subscribe()
+	b.Start()
+	drain(first)
+
+	late := b.Subscribe()
+	if chunks := drain(late); len(chunks) != 0 {
+		t.Errorf("expected empty stream, got %d chunks", len(chunks))
+	}
+}
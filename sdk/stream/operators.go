@@ -0,0 +1,402 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ChunkTypeToolCall carries one complete tool call assembled by
+// AccumulateJSON: Data holds the arguments JSON and Metadata the call's
+// "index", "id" and "name"
+const ChunkTypeToolCall ChunkType = "tool_call"
+
+// derive creates an empty stream fed by an operator over s, with the same
+// options
+func (s *Stream) derive() *Stream {
+	ctx, cancel := context.WithCancel(s.ctx)
+	opts := s.opts
+	opts.setDefaults()
+	return &Stream{
+		streamType: s.streamType,
+		chunks:     make(chan *Chunk, opts.BufferSize),
+		done:       make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+		opts:       opts,
+		upstream:   s,
+	}
+}
+
+// finish closes a derived stream, keeping the source's error
+func (s *Stream) finish(src *Stream) {
+	if err := src.Err(); err != nil {
+		s.setError(err)
+	}
+	close(s.chunks)
+	close(s.done)
+}
+
+// mergeChunks joins buffered data chunks into one
+func mergeChunks(chunks []*Chunk) *Chunk {
+	var data strings.Builder
+	for _, c := range chunks {
+		data.WriteString(c.Data)
+	}
+	return &Chunk{
+		Type:     ChunkTypeData,
+		Data:     data.String(),
+		Metadata: map[string]interface{}{"batch_size": len(chunks)},
+	}
+}
+
+// Buffer batches data chunks, emitting their joined Data once n chunks are
+// buffered or flushInterval has passed since the first of them. Either
+// limit may be zero to disable it. Other chunk types flush the batch and
+// pass through, so a batch never spans an error or the end of the stream.
+func (s *Stream) Buffer(n int, flushInterval time.Duration) *Stream {
+	out := s.derive()
+
+	go func() {
+		defer out.finish(s)
+
+		var batch []*Chunk
+		timer := time.NewTimer(flushInterval)
+		timer.Stop()
+		defer timer.Stop()
+
+		flush := func() bool {
+			timer.Stop()
+			if len(batch) == 0 {
+				return true
+			}
+			merged := mergeChunks(batch)
+			batch = nil
+			return out.forward(merged)
+		}
+
+		for {
+			select {
+			case chunk, ok := <-s.chunks:
+				if !ok {
+					flush()
+					return
+				}
+				if chunk.Type != ChunkTypeData {
+					if !flush() || !out.forward(chunk) {
+						return
+					}
+					continue
+				}
+				batch = append(batch, chunk)
+				if len(batch) == 1 && flushInterval > 0 {
+					timer.Reset(flushInterval)
+				}
+				if n > 0 && len(batch) >= n && !flush() {
+					return
+				}
+			case <-timer.C:
+				if !flush() {
+					return
+				}
+			case <-out.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Debounce holds data chunks until the stream has been quiet for wait, then
+// emits everything received so far as one chunk. It suits UI updates that
+// should redraw once per burst rather than once per token. Other chunk
+// types flush pending data and pass through.
+func (s *Stream) Debounce(wait time.Duration) *Stream {
+	out := s.derive()
+
+	go func() {
+		defer out.finish(s)
+
+		var pending []*Chunk
+		timer := time.NewTimer(wait)
+		timer.Stop()
+		defer timer.Stop()
+
+		flush := func() bool {
+			timer.Stop()
+			if len(pending) == 0 {
+				return true
+			}
+			merged := mergeChunks(pending)
+			pending = nil
+			return out.forward(merged)
+		}
+
+		for {
+			select {
+			case chunk, ok := <-s.chunks:
+				if !ok {
+					flush()
+					return
+				}
+				if chunk.Type != ChunkTypeData {
+					if !flush() || !out.forward(chunk) {
+						return
+					}
+					continue
+				}
+				pending = append(pending, chunk)
+				timer.Reset(wait)
+			case <-timer.C:
+				if !flush() {
+					return
+				}
+			case <-out.ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// SplitSentences re-chunks data on sentence boundaries: each emitted chunk
+// ends with '.', '!' or '?' (plus any closing quotes or brackets) and the
+// whitespace that followed, or with a newline. Joining the chunks restores
+// the original text. Text left without a boundary is emitted when the
+// stream ends or a non-data chunk arrives.
+func (s *Stream) SplitSentences() *Stream {
+	out := s.derive()
+
+	go func() {
+		defer out.finish(s)
+
+		var buf []rune
+		flushRest := func() bool {
+			if len(buf) == 0 {
+				return true
+			}
+			rest := string(buf)
+			buf = nil
+			return out.forward(&Chunk{Type: ChunkTypeData, Data: rest})
+		}
+
+		for chunk := range s.chunks {
+			if chunk.Type != ChunkTypeData {
+				if !flushRest() || !out.forward(chunk) {
+					return
+				}
+				continue
+			}
+			buf = append(buf, []rune(chunk.Data)...)
+			for {
+				end := sentenceEnd(buf)
+				if end < 0 {
+					break
+				}
+				sentence := string(buf[:end])
+				buf = buf[end:]
+				if !out.forward(&Chunk{Type: ChunkTypeData, Data: sentence}) {
+					return
+				}
+			}
+		}
+		flushRest()
+	}()
+
+	return out
+}
+
+// sentenceEnd returns the length of the first complete sentence in text,
+// or -1. A terminator only counts once the whitespace after it has arrived.
+func sentenceEnd(text []rune) int {
+	for i, r := range text {
+		if r == '\n' {
+			return i + 1
+		}
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		j := i + 1
+		for j < len(text) && strings.ContainsRune(`.!?"')]`+"”’", text[j]) {
+			j++
+		}
+		if j < len(text) && unicode.IsSpace(text[j]) {
+			return j + 1
+		}
+	}
+	return -1
+}
+
+// toolCallFragment is one streamed piece of a tool call
+type toolCallFragment struct {
+	index int
+	id    string
+	name  string
+	args  string
+	stop  bool // The provider marked the call complete
+}
+
+// pendingToolCall is a tool call being reassembled
+type pendingToolCall struct {
+	id   string
+	name string
+	args strings.Builder
+}
+
+// AccumulateJSON reassembles tool calls whose arguments arrive as partial
+// JSON (OpenAI delta.tool_calls, Anthropic input_json_delta) and emits one
+// ChunkTypeToolCall chunk per call once its arguments form a complete JSON
+// value. Chunks that only carried tool call fragments are dropped; all
+// others pass through. A call still incomplete when the stream ends is
+// reported as a ChunkTypeError chunk.
+func (s *Stream) AccumulateJSON() *Stream {
+	out := s.derive()
+
+	go func() {
+		defer out.finish(s)
+
+		calls := make(map[int]*pendingToolCall)
+		emit := func(index int, call *pendingToolCall) bool {
+			delete(calls, index)
+			args := call.args.String()
+			if args == "" {
+				args = "{}"
+			}
+			if !json.Valid([]byte(args)) {
+				return out.forward(&Chunk{
+					Type:  ChunkTypeError,
+					Error: fmt.Errorf("tool call %q: invalid arguments JSON: %s", call.name, args),
+				})
+			}
+			return out.forward(&Chunk{
+				Type: ChunkTypeToolCall,
+				Data: args,
+				Raw:  []byte(args),
+				Metadata: map[string]interface{}{
+					"index": index,
+					"id":    call.id,
+					"name":  call.name,
+				},
+			})
+		}
+
+		for chunk := range s.chunks {
+			consumed := false
+			for _, f := range toolCallFragments(chunk.Metadata) {
+				call := calls[f.index]
+				if call == nil {
+					if f.stop {
+						continue
+					}
+					call = &pendingToolCall{}
+					calls[f.index] = call
+				}
+				consumed = true
+				if f.id != "" {
+					call.id = f.id
+				}
+				if f.name != "" {
+					call.name = f.name
+				}
+				call.args.WriteString(f.args)
+				if f.stop || (call.args.Len() > 0 && json.Valid([]byte(call.args.String()))) {
+					if !emit(f.index, call) {
+						return
+					}
+				}
+			}
+			if consumed && chunk.Data == "" {
+				continue
+			}
+			if !out.forward(chunk) {
+				return
+			}
+		}
+
+		indexes := make([]int, 0, len(calls))
+		for index := range calls {
+			indexes = append(indexes, index)
+		}
+		sort.Ints(indexes)
+		for _, index := range indexes {
+			call := calls[index]
+			out.forward(&Chunk{
+				Type:  ChunkTypeError,
+				Error: fmt.Errorf("tool call %q: stream ended with incomplete arguments: %s", call.name, call.args.String()),
+			})
+		}
+	}()
+
+	return out
+}
+
+// toolCallFragments extracts tool call pieces from a parsed SSE event
+func toolCallFragments(data map[string]interface{}) []toolCallFragment {
+	if data == nil {
+		return nil
+	}
+
+	// OpenAI: choices[0].delta.tool_calls[].{index, id, function.{name, arguments}}
+	if choices, ok := data["choices"].([]interface{}); ok && len(choices) > 0 {
+		choice, _ := choices[0].(map[string]interface{})
+		delta, _ := choice["delta"].(map[string]interface{})
+		calls, _ := delta["tool_calls"].([]interface{})
+		var fragments []toolCallFragment
+		for _, c := range calls {
+			call, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			f := toolCallFragment{index: intValue(call["index"])}
+			f.id, _ = call["id"].(string)
+			if fn, ok := call["function"].(map[string]interface{}); ok {
+				f.name, _ = fn["name"].(string)
+				f.args, _ = fn["arguments"].(string)
+			}
+			fragments = append(fragments, f)
+		}
+		return fragments
+	}
+
+	// Anthropic: content_block_start (tool_use), content_block_delta
+	// (input_json_delta) and content_block_stop, keyed by block index
+	index := intValue(data["index"])
+	switch data["type"] {
+	case "content_block_start":
+		block, _ := data["content_block"].(map[string]interface{})
+		if block["type"] != "tool_use" {
+			return nil
+		}
+		f := toolCallFragment{index: index}
+		f.id, _ = block["id"].(string)
+		f.name, _ = block["name"].(string)
+		return []toolCallFragment{f}
+	case "content_block_delta":
+		delta, _ := data["delta"].(map[string]interface{})
+		if delta["type"] != "input_json_delta" {
+			return nil
+		}
+		partial, _ := delta["partial_json"].(string)
+		return []toolCallFragment{{index: index, args: partial}}
+	case "content_block_stop":
+		return []toolCallFragment{{index: index, stop: true}}
+	}
+	return nil
+}
+
+// intValue reads a JSON number decoded into interface{}
+func intValue(v interface{}) int {
+	switch n := v.(type) {
+	case float64:
+		return int(n)
+	case int:
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,250 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chanStream builds a stream fed from a channel, for timing-sensitive tests
+func chanStream(src <-chan *Chunk) *Stream {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Stream{
+		streamType: StreamTypeHTTP,
+		chunks:     make(chan *Chunk, 10),
+		done:       make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	go func() {
+		defer close(s.chunks)
+		defer close(s.done)
+		for c := range src {
+			s.chunks <- c
+		}
+	}()
+	return s
+}
+
+func dataChunk(s string) *Chunk {
+	return &Chunk{Type: ChunkTypeData, Data: s}
+}
+
+func drain(s *Stream) []*Chunk {
+	var chunks []*Chunk
+	for c := range s.Chunks() {
+		chunks = append(chunks, c)
+	}
+	return chunks
+}
+
+func TestStream_Buffer_BatchesBySize(t *testing.T) {
+	src := make(chan *Chunk, 10)
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		src <- dataChunk(s)
+	}
+	src <- &Chunk{Type: ChunkTypeDone}
+	close(src)
+
+	chunks := drain(chanStream(src).Buffer(2, 0))
+
+	var got []string
+	for _, c := range chunks {
+		got = append(got, string(c.Type)+":"+c.Data)
+	}
+	want := []string{"data:ab", "data:cd", "data:e", "done:"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if chunks[0].Metadata["batch_size"] != 2 {
+		t.Errorf("expected batch_size 2, got %v", chunks[0].Metadata["batch_size"])
+	}
+}
+
+func TestStream_Buffer_FlushInterval(t *testing.T) {
+	src := make(chan *Chunk)
+	out := chanStream(src).Buffer(100, 20*time.Millisecond)
+
+	go func() {
+		src <- dataChunk("Hel")
+		src <- dataChunk("lo")
+	}()
+
+	select {
+	case c := <-out.Chunks():
+		if c.Data != "Hello" {
+			t.Errorf("expected Hello, got %q", c.Data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("buffer was not flushed by the interval")
+	}
+	close(src)
+	if rest := drain(out); len(rest) != 0 {
+		t.Errorf("expected no more chunks, got %d", len(rest))
+	}
+}
+
+func TestStream_Debounce_CoalescesBursts(t *testing.T) {
+	src := make(chan *Chunk)
+	out := chanStream(src).Debounce(30 * time.Millisecond)
+
+	go func() {
+		for _, s := range []string{"one ", "two ", "three"} {
+			src <- dataChunk(s)
+		}
+		time.Sleep(100 * time.Millisecond)
+		src <- dataChunk(" four")
+		close(src)
+	}()
+
+	chunks := drain(out)
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 debounced chunks, got %d", len(chunks))
+	}
+	if chunks[0].Data != "one two three" || chunks[1].Data != " four" {
+		t.Errorf("unexpected chunks %q, %q", chunks[0].Data, chunks[1].Data)
+	}
+}
+
+func TestStream_SplitSentences(t *testing.T) {
+	src := make(chan *Chunk, 10)
+	for _, s := range []string{"Hello wor", "ld. How are", " you?", ` He said "hi." `, "Pi is 3.14 and", " done"} {
+		src <- dataChunk(s)
+	}
+	close(src)
+
+	chunks := drain(chanStream(src).SplitSentences())
+
+	var got []string
+	for _, c := range chunks {
+		got = append(got, c.Data)
+	}
+	want := []string{"Hello world. ", "How are you? ", `He said "hi." `, "Pi is 3.14 and done"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestStream_SplitSentences_Newlines(t *testing.T) {
+	chunks := drain(NewStream(context.Background(), strings.NewReader("line one\nline two"), StreamTypeHTTP).SplitSentences())
+	if len(chunks) != 2 || chunks[0].Data != "line one\n" || chunks[1].Data != "line two" {
+		t.Errorf("unexpected chunks %+v", chunks)
+	}
+}
+
+func TestStream_AccumulateJSON_OpenAI(t *testing.T) {
+	sseData := `data: {"choices":[{"delta":{"content":"Let me check."}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"loc"}}]}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"ation\": \"Paris\"}"}}]}}]}
+
+data: {"choices":[{"delta":{},"finish_reason":"tool_calls"}]}
+
+data: [DONE]
+
+`
+	stream := NewStream(context.Background(), strings.NewReader(sseData), StreamTypeSSE)
+	chunks := drain(stream.AccumulateJSON())
+
+	var calls []*Chunk
+	var text strings.Builder
+	for _, c := range chunks {
+		switch c.Type {
+		case ChunkTypeToolCall:
+			calls = append(calls, c)
+		case ChunkTypeData:
+			text.WriteString(c.Data)
+		}
+	}
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d (%+v)", len(calls), chunks)
+	}
+	if calls[0].Data != `{"location": "Paris"}` {
+		t.Errorf("unexpected arguments %s", calls[0].Data)
+	}
+	if calls[0].Metadata["name"] != "get_weather" || calls[0].Metadata["id"] != "call_1" {
+		t.Errorf("unexpected metadata %v", calls[0].Metadata)
+	}
+	if text.String() != "Let me check." {
+		t.Errorf("expected text to pass through, got %q", text.String())
+	}
+	if chunks[len(chunks)-1].Type != ChunkTypeDone {
+		t.Error("expected done chunk to pass through")
+	}
+	// 1 text, 1 tool call, the finish_reason event and done
+	if len(chunks) != 4 {
+		t.Errorf("expected fragment chunks to be dropped, got %d chunks", len(chunks))
+	}
+}
+
+func TestStream_AccumulateJSON_Anthropic(t *testing.T) {
+	sseData := `event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"search","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"query\": "}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"go\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: content_block_start
+data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_2","name":"now","input":{}}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":2}
+
+`
+	stream := NewStream(context.Background(), strings.NewReader(sseData), StreamTypeSSE)
+	var calls []*Chunk
+	for _, c := range drain(stream.AccumulateJSON()) {
+		if c.Type == ChunkTypeToolCall {
+			calls = append(calls, c)
+		}
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %d", len(calls))
+	}
+	if calls[0].Data != `{"query": "go"}` || calls[0].Metadata["index"] != 1 {
+		t.Errorf("unexpected first call %s %v", calls[0].Data, calls[0].Metadata)
+	}
+	// A tool without arguments completes on content_block_stop
+	if calls[1].Data != "{}" || calls[1].Metadata["name"] != "now" {
+		t.Errorf("unexpected second call %s %v", calls[1].Data, calls[1].Metadata)
+	}
+}
+
+func TestStream_AccumulateJSON_Incomplete(t *testing.T) {
+	sseData := `data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"f","arguments":"{\"a\":"}}]}}]}
+
+`
+	stream := NewStream(context.Background(), strings.NewReader(sseData), StreamTypeSSE)
+	chunks := drain(stream.AccumulateJSON())
+	if len(chunks) != 1 || chunks[0].Type != ChunkTypeError || chunks[0].Error == nil {
+		t.Fatalf("expected one error chunk, got %+v", chunks)
+	}
+}
+
+func TestStream_Operators_KeepSourceError(t *testing.T) {
+	reader := io.MultiReader(strings.NewReader("partial"), &errReader{err: errors.New("connection reset")})
+	out := NewStream(context.Background(), reader, StreamTypeHTTP).Buffer(10, 0)
+	text, err := out.Collect()
+	if text != "partial" {
+		t.Errorf("expected buffered text, got %q", text)
+	}
+	if err == nil || err.Error() != "connection reset" {
+		t.Errorf("expected source error, got %v", err)
+	}
+}
+
+type errReader struct{ err error }
+
+func (r *errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -4,10 +4,13 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // StreamType indicates the streaming protocol
@@ -17,6 +20,7 @@ const (
 	StreamTypeSSE       StreamType = "sse"       // Server-Sent Events
 	StreamTypeWebSocket StreamType = "websocket" // WebSocket
 	StreamTypeHTTP      StreamType = "http"      // HTTP chunked
+	StreamTypeNDJSON    StreamType = "ndjson"    // Newline-delimited JSON
 )
 
 // ChunkType indicates what kind of data is in the chunk
@@ -29,6 +33,46 @@ const (
 	ChunkTypeDone     ChunkType = "done"     // Stream complete
 )
 
+// OverflowPolicy decides what a stream does when its consumer falls behind
+// and the chunk buffer is full
+type OverflowPolicy string
+
+const (
+	OverflowBlock      OverflowPolicy = "block"       // Wait for the consumer (default)
+	OverflowDropOldest OverflowPolicy = "drop_oldest" // Discard the oldest buffered chunk
+)
+
+// DefaultBufferSize is the number of chunks buffered between the reader and
+// the consumer when Options.BufferSize is not set
+const DefaultBufferSize = 10
+
+// ErrSlowConsumer is the stream error after a blocked send outlasted
+// Options.SlowConsumerTimeout and the stream was canceled
+var ErrSlowConsumer = errors.New("stream: consumer stopped reading")
+
+// Options configures buffering and backpressure for a stream. Streams
+// derived with Filter, Map and the other operators inherit them.
+type Options struct {
+	BufferSize int            // Chunks buffered for the consumer (default DefaultBufferSize)
+	Overflow   OverflowPolicy // What to do when the buffer is full (default OverflowBlock)
+
+	// SlowConsumerTimeout, with OverflowBlock, is how long a send may wait
+	// for the consumer. When it passes, the stream fails with
+	// ErrSlowConsumer, is canceled and closes its reader (when that is an
+	// io.Closer, e.g. a response body) so the upstream request ends
+	// instead of piling up. Zero waits forever.
+	SlowConsumerTimeout time.Duration
+}
+
+func (o *Options) setDefaults() {
+	if o.BufferSize <= 0 {
+		o.BufferSize = DefaultBufferSize
+	}
+	if o.Overflow == "" {
+		o.Overflow = OverflowBlock
+	}
+}
+
 // Chunk represents a single piece of streamed data
 type Chunk struct {
 	Type     ChunkType              // Type of chunk
@@ -49,36 +93,54 @@ type Stream struct {
 	mu         sync.RWMutex
 	ctx        context.Context
 	cancel     context.CancelFunc
+	opts       Options
+	dropped    atomic.Int64
+	upstream   *Stream // Stream an operator reads from, canceled with it on a slow consumer
 }
 
-// NewStream creates a new stream from a reader
+// NewStream creates a new stream from a reader with default Options
 func NewStream(ctx context.Context, reader io.Reader, streamType StreamType) *Stream {
+	return NewStreamWithOptions(ctx, reader, streamType, Options{})
+}
+
+// NewStreamWithOptions creates a new stream from a reader with the given
+// buffering and backpressure options
+func NewStreamWithOptions(ctx context.Context, reader io.Reader, streamType StreamType, opts Options) *Stream {
 	ctx, cancel := context.WithCancel(ctx)
+	opts.setDefaults()
 
 	s := &Stream{
 		streamType: streamType,
 		reader:     reader,
 		scanner:    bufio.NewScanner(reader),
-		chunks:     make(chan *Chunk, 10),
+		chunks:     make(chan *Chunk, opts.BufferSize),
 		done:       make(chan struct{}),
 		ctx:        ctx,
 		cancel:     cancel,
+		opts:       opts,
 	}
 
 	go s.processStream()
 	return s
 }
 
+// Dropped returns how many chunks OverflowDropOldest discarded
+func (s *Stream) Dropped() int64 {
+	return s.dropped.Load()
+}
+
 // Chunks returns a channel that receives chunks as they arrive
 func (s *Stream) Chunks() <-chan *Chunk {
 	return s.chunks
 }
 
-// Close closes the stream and releases resources
+// Close closes the stream and releases resources. Closing a stream built by
+// an operator also closes the streams feeding it, including a source reader
+// that is an io.Closer (such as an HTTP response body).
 func (s *Stream) Close() error {
-	s.cancel()
+	s.release()
 	<-s.done
-	return s.err
+	return s.Err()
 }
 
 // Err returns any error that occurred during streaming
@@ -100,6 +162,8 @@ func (s *Stream) processStream() {
 		s.processHTTP()
 	case StreamTypeWebSocket:
 		s.processWebSocket()
+	case StreamTypeNDJSON:
+		s.processNDJSON()
 	default:
 		s.setError(fmt.Errorf("unsupported stream type: %s", s.streamType))
 	}
@@ -136,7 +200,7 @@ func (s *Stream) processSSE() {
 	}
 
 	if err := s.scanner.Err(); err != nil {
-		s.setError(err)
+		s.readError(err)
 	}
 }
 
@@ -216,18 +280,66 @@ func (s *Stream) processHTTP() {
 
 		if err != nil {
 			if err != io.EOF {
-				s.setError(err)
+				s.readError(err)
 			}
 			return
 		}
 	}
 }
 
-// processWebSocket handles WebSocket frames (placeholder)
-func (s *Stream) processWebSocket() {
-	// WebSocket implementation would go here
-	// For now, treat like HTTP chunks
-	s.processHTTP()
+// maxNDJSONLine bounds one NDJSON line
+const maxNDJSONLine = 1024 * 1024
+
+// processNDJSON handles newline-delimited JSON (Ollama, Cohere v1): each
+// line becomes a chunk parsed like an SSE data line. A line marked done
+// ("done": true or "is_finished": true) is followed by a done chunk.
+func (s *Stream) processNDJSON() {
+	s.scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+
+	for s.scanner.Scan() {
+		select {
+		case <-s.ctx.Done():
+			s.setError(s.ctx.Err())
+			return
+		default:
+		}
+
+		line := strings.TrimSpace(s.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var jsonData map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &jsonData); err != nil {
+			s.sendChunk(&Chunk{
+				Type:  ChunkTypeError,
+				Raw:   []byte(line),
+				Error: fmt.Errorf("invalid NDJSON line: %w", err),
+			})
+			continue
+		}
+
+		chunk := &Chunk{
+			Type:     ChunkTypeData,
+			Data:     s.extractContent(jsonData),
+			Metadata: jsonData,
+			Raw:      []byte(line),
+		}
+		s.sendChunk(chunk)
+
+		if done, _ := jsonData["done"].(bool); done {
+			s.sendChunk(&Chunk{Type: ChunkTypeDone, Metadata: make(map[string]interface{})})
+			return
+		}
+		if finished, _ := jsonData["is_finished"].(bool); finished {
+			s.sendChunk(&Chunk{Type: ChunkTypeDone, Metadata: make(map[string]interface{})})
+			return
+		}
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		s.readError(err)
+	}
 }
 
 // extractContent extracts content from various provider response formats
@@ -270,6 +382,18 @@ func (s *Stream) extractContent(data map[string]interface{}) string {
 		}
 	}
 
+	// Ollama chat format: message.content
+	if message, ok := data["message"].(map[string]interface{}); ok {
+		if content, ok := message["content"].(string); ok {
+			return content
+		}
+	}
+
+	// Ollama generate format: response
+	if response, ok := data["response"].(string); ok {
+		return response
+	}
+
 	// Generic fallback: look for "text", "content", or "message" keys
 	if text, ok := data["text"].(string); ok {
 		return text
@@ -286,13 +410,88 @@ func (s *Stream) extractContent(data map[string]interface{}) string {
 
 // sendChunk sends a chunk to the channel
 func (s *Stream) sendChunk(chunk *Chunk) {
+	s.forward(chunk)
+}
+
+// forward sends a chunk downstream under the overflow policy, reporting
+// false once the stream is canceled
+func (s *Stream) forward(chunk *Chunk) bool {
+	select {
+	case s.chunks <- chunk:
+		return true
+	case <-s.ctx.Done():
+		return false
+	default:
+	}
+
+	if s.opts.Overflow == OverflowDropOldest {
+		for {
+			select {
+			case <-s.chunks:
+				s.dropped.Add(1)
+			default:
+			}
+			select {
+			case s.chunks <- chunk:
+				return true
+			case <-s.ctx.Done():
+				return false
+			default:
+			}
+		}
+	}
+
+	if s.opts.SlowConsumerTimeout <= 0 {
+		select {
+		case s.chunks <- chunk:
+			return true
+		case <-s.ctx.Done():
+			return false
+		}
+	}
+
+	timer := time.NewTimer(s.opts.SlowConsumerTimeout)
+	defer timer.Stop()
 	select {
 	case s.chunks <- chunk:
+		return true
 	case <-s.ctx.Done():
-		return
+		return false
+	case <-timer.C:
+		s.abandon()
+		return false
 	}
 }
 
+// abandon fails a stream whose consumer stopped reading and cancels
+// everything feeding it, closing the source reader
+func (s *Stream) abandon() {
+	s.setError(ErrSlowConsumer)
+	s.release()
+}
+
+// release cancels s and everything feeding it, closing the source reader so
+// a blocked read returns
+func (s *Stream) release() {
+	for st := s; st != nil; st = st.upstream {
+		if st.cancel != nil {
+			st.cancel()
+		}
+		if closer, ok := st.reader.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+// readError records a failed read, reporting the cancellation instead when
+// the reader failed because the stream was closed
+func (s *Stream) readError(err error) {
+	if ctxErr := s.ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+	s.setError(err)
+}
+
 // setError sets the error state
 func (s *Stream) setError(err error) {
 	s.mu.Lock()
@@ -321,24 +520,14 @@ func (s *Stream) Collect() (string, error) {
 
 // Filter creates a new stream with only chunks matching the predicate
 func (s *Stream) Filter(predicate func(*Chunk) bool) *Stream {
-	filtered := &Stream{
-		streamType: s.streamType,
-		chunks:     make(chan *Chunk, 10),
-		done:       make(chan struct{}),
-		ctx:        s.ctx,
-	}
+	filtered := s.derive()
 
 	go func() {
-		defer close(filtered.chunks)
-		defer close(filtered.done)
+		defer filtered.finish(s)
 
 		for chunk := range s.chunks {
-			if predicate(chunk) {
-				select {
-				case filtered.chunks <- chunk:
-				case <-filtered.ctx.Done():
-					return
-				}
+			if predicate(chunk) && !filtered.forward(chunk) {
+				return
 			}
 		}
 	}()
@@ -348,25 +537,15 @@ func (s *Stream) Filter(predicate func(*Chunk) bool) *Stream {
 
 // Map transforms chunks using the provided function
 func (s *Stream) Map(transform func(*Chunk) *Chunk) *Stream {
-	mapped := &Stream{
-		streamType: s.streamType,
-		chunks:     make(chan *Chunk, 10),
-		done:       make(chan struct{}),
-		ctx:        s.ctx,
-	}
+	mapped := s.derive()
 
 	go func() {
-		defer close(mapped.chunks)
-		defer close(mapped.done)
+		defer mapped.finish(s)
 
 		for chunk := range s.chunks {
 			transformed := transform(chunk)
-			if transformed != nil {
-				select {
-				case mapped.chunks <- transformed:
-				case <-mapped.ctx.Done():
-					return
-				}
+			if transformed != nil && !mapped.forward(transformed) {
+				return
 			}
 		}
 	}()
@@ -1,6 +1,7 @@
 package stream
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"strings"
@@ -416,22 +417,22 @@ func (sr *slowReader) Read(p []byte) (n int, err error) {
 }
 
 func TestStream_ProcessWebSocket(t *testing.T) {
-	// processWebSocket currently calls processHTTP internally
-	// Test that it doesn't panic and works as expected
 	responseBody := `{"choices":[{"delta":{"content":"Test"}}]}`
-	stream := NewStream(context.Background(), io.NopCloser(strings.NewReader(responseBody)), StreamTypeWebSocket)
+	frames := wsFrame(true, wsText, []byte(responseBody), false)
+	stream := NewStream(context.Background(), io.NopCloser(bytes.NewReader(frames)), StreamTypeWebSocket)
 	defer stream.Close()
 
-	// Collect chunks - just verify it works without panic
 	var chunkCount int
 	for chunk := range stream.Chunks() {
 		if chunk.Type == ChunkTypeData {
 			chunkCount++
+			if chunk.Data != "Test" {
+				t.Errorf("Expected content 'Test', got %q", chunk.Data)
+			}
 		}
 	}
 
-	// WebSocket processing should work (at least 1 chunk)
-	if chunkCount < 1 {
-		t.Errorf("Expected at least 1 chunk from WebSocket processing, got %d", chunkCount)
+	if chunkCount != 1 {
+		t.Errorf("Expected 1 chunk from WebSocket processing, got %d", chunkCount)
 	}
 }
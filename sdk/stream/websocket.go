@@ -0,0 +1,182 @@
+package stream
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// WebSocket frame opcodes (RFC 6455)
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+)
+
+// MaxWebSocketMessage bounds one reassembled WebSocket message
+const MaxWebSocketMessage = 16 << 20
+
+// processWebSocket reads RFC 6455 frames from a connection whose upgrade
+// handshake is already done. Each complete text message becomes a chunk
+// like an SSE data line; binary messages keep their payload in Raw. A close
+// frame ends the stream. When the reader is also an io.Writer (a net.Conn),
+// pings are answered with pongs.
+func (s *Stream) processWebSocket() {
+	r := bufio.NewReader(s.reader)
+	var message []byte
+	var opcode byte
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.setError(s.ctx.Err())
+			return
+		default:
+		}
+
+		fin, op, payload, err := readWebSocketFrame(r)
+		if err != nil {
+			if err != io.EOF {
+				s.readError(err)
+			}
+			return
+		}
+
+		switch op {
+		case wsClose:
+			return
+		case wsPing:
+			s.pong(payload)
+			continue
+		case wsPong:
+			continue
+		case wsContinuation:
+			message = append(message, payload...)
+		default:
+			opcode = op
+			message = append(message[:0], payload...)
+		}
+		if len(message) > MaxWebSocketMessage {
+			s.setError(fmt.Errorf("websocket message exceeds %d bytes", MaxWebSocketMessage))
+			return
+		}
+		if !fin {
+			continue
+		}
+
+		if opcode == wsBinary {
+			s.sendChunk(&Chunk{
+				Type:     ChunkTypeData,
+				Raw:      append([]byte(nil), message...),
+				Metadata: map[string]interface{}{"opcode": "binary"},
+			})
+		} else if !s.sendMessage(string(message)) {
+			return
+		}
+		message = message[:0]
+	}
+}
+
+// sendMessage turns one JSON (or plain text) message into a chunk, the same
+// way SSE data lines are parsed, reporting false after a done marker
+func (s *Stream) sendMessage(data string) bool {
+	if data == "[DONE]" {
+		s.sendChunk(&Chunk{Type: ChunkTypeDone, Metadata: make(map[string]interface{})})
+		return false
+	}
+	chunk := &Chunk{
+		Type:     ChunkTypeData,
+		Raw:      []byte(data),
+		Metadata: make(map[string]interface{}),
+	}
+	var jsonData map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &jsonData); err == nil {
+		chunk.Data = s.extractContent(jsonData)
+		for k, v := range jsonData {
+			chunk.Metadata[k] = v
+		}
+	} else {
+		chunk.Data = data
+	}
+	s.sendChunk(chunk)
+	return true
+}
+
+// pong answers a ping when the connection is writable. Client frames must
+// be masked.
+func (s *Stream) pong(payload []byte) {
+	w, ok := s.reader.(io.Writer)
+	if !ok || len(payload) > 125 {
+		return
+	}
+	frame := []byte{0x80 | wsPong, 0x80 | byte(len(payload))}
+	var mask [4]byte
+	rand.Read(mask[:])
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	w.Write(frame)
+}
+
+// readWebSocketFrame reads one frame, unmasking its payload if needed
+func readWebSocketFrame(r *bufio.Reader) (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(r, ext[:]); err != nil {
+			return false, 0, nil, unexpectedEOF(err)
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(r, ext[:]); err != nil {
+			return false, 0, nil, unexpectedEOF(err)
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > MaxWebSocketMessage {
+		return false, 0, nil, fmt.Errorf("websocket frame of %d bytes exceeds %d", length, MaxWebSocketMessage)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(r, mask[:]); err != nil {
+			return false, 0, nil, unexpectedEOF(err)
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return false, 0, nil, unexpectedEOF(err)
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// unexpectedEOF reports a connection lost mid-frame as an error rather than
+// a clean end of stream
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
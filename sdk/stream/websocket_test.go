@@ -0,0 +1,171 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// wsFrame encodes one WebSocket frame, masked as a client would send it or
+// unmasked as a server does
+func wsFrame(fin bool, opcode byte, payload []byte, masked bool) []byte {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+
+	maskBit := byte(0)
+	if masked {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, maskBit|byte(n))
+	case n < 65536:
+		frame = append(frame, maskBit|126, byte(n>>8), byte(n))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		frame = append(append(frame, maskBit|127), ext[:]...)
+	}
+
+	if !masked {
+		return append(frame, payload...)
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+// wsConn is a readable and writable fake connection
+type wsConn struct {
+	io.Reader
+	written bytes.Buffer
+}
+
+func (c *wsConn) Write(p []byte) (int, error) {
+	return c.written.Write(p)
+}
+
+func TestStream_WebSocket_Messages(t *testing.T) {
+	var frames bytes.Buffer
+	frames.Write(wsFrame(true, wsText, []byte(`{"type":"delta","delta":{"text":"Hello"}}`), false))
+	// A message split over a continuation frame, with a ping in between
+	frames.Write(wsFrame(false, wsText, []byte(`{"type":"delta","delta":{"text":" Wor`), false))
+	frames.Write(wsFrame(true, wsPing, []byte("hb"), false))
+	frames.Write(wsFrame(true, wsContinuation, []byte(`ld"}}`), false))
+	frames.Write(wsFrame(true, wsBinary, []byte{0xde, 0xad}, true))
+	frames.Write(wsFrame(true, wsText, bytes.Repeat([]byte("a"), 70000), false))
+	frames.Write(wsFrame(true, wsClose, nil, false))
+	frames.Write(wsFrame(true, wsText, []byte("after close"), false))
+
+	conn := &wsConn{Reader: &frames}
+	stream := NewStream(context.Background(), conn, StreamTypeWebSocket)
+	chunks := drain(stream)
+
+	if len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks, got %d", len(chunks))
+	}
+	if chunks[0].Data != "Hello" || chunks[1].Data != " World" {
+		t.Errorf("unexpected text %q, %q", chunks[0].Data, chunks[1].Data)
+	}
+	if chunks[0].Metadata["type"] != "delta" {
+		t.Errorf("expected JSON fields in metadata, got %v", chunks[0].Metadata)
+	}
+	if !bytes.Equal(chunks[2].Raw, []byte{0xde, 0xad}) || chunks[2].Metadata["opcode"] != "binary" {
+		t.Errorf("unexpected binary chunk %+v", chunks[2])
+	}
+	if len(chunks[3].Data) != 70000 {
+		t.Errorf("expected 70000 byte plain text message, got %d", len(chunks[3].Data))
+	}
+	if stream.Err() != nil {
+		t.Errorf("unexpected error %v", stream.Err())
+	}
+
+	// The ping was answered with a masked pong carrying the same payload
+	fin, op, payload, err := readWebSocketFrame(bufioReader(conn.written.Bytes()))
+	if err != nil || !fin || op != wsPong || string(payload) != "hb" {
+		t.Errorf("expected pong 'hb', got op=%x payload=%q err=%v", op, payload, err)
+	}
+	if conn.written.Bytes()[1]&0x80 == 0 {
+		t.Error("client pong must be masked")
+	}
+}
+
+func TestStream_WebSocket_DoneMarker(t *testing.T) {
+	var frames bytes.Buffer
+	frames.Write(wsFrame(true, wsText, []byte(`{"content":"hi"}`), false))
+	frames.Write(wsFrame(true, wsText, []byte("[DONE]"), false))
+	frames.Write(wsFrame(true, wsText, []byte(`{"content":"ignored"}`), false))
+
+	text, err := NewStream(context.Background(), &frames, StreamTypeWebSocket).Collect()
+	if err != nil || text != "hi" {
+		t.Errorf("Collect() = %q, %v", text, err)
+	}
+}
+
+func TestStream_WebSocket_TruncatedFrame(t *testing.T) {
+	frame := wsFrame(true, wsText, []byte("hello world"), false)
+	stream := NewStream(context.Background(), bytes.NewReader(frame[:6]), StreamTypeWebSocket)
+	drain(stream)
+	if !errors.Is(stream.Err(), io.ErrUnexpectedEOF) {
+		t.Errorf("Err() = %v, want io.ErrUnexpectedEOF", stream.Err())
+	}
+}
+
+func TestStream_NDJSON(t *testing.T) {
+	body := `{"model":"llama3","message":{"role":"assistant","content":"Hel"},"done":false}
+{"model":"llama3","message":{"role":"assistant","content":"lo"},"done":false}
+
+not json
+{"model":"llama3","message":{"role":"assistant","content":""},"done":true,"eval_count":2}
+{"model":"llama3","message":{"role":"assistant","content":"ignored"}}
+`
+	chunks := drain(NewStream(context.Background(), strings.NewReader(body), StreamTypeNDJSON))
+
+	var types []string
+	var text strings.Builder
+	for _, c := range chunks {
+		types = append(types, string(c.Type))
+		text.WriteString(c.Data)
+	}
+	if strings.Join(types, ",") != "data,data,error,data,done" {
+		t.Errorf("unexpected chunk types %v", types)
+	}
+	if text.String() != "Hello" {
+		t.Errorf("unexpected text %q", text.String())
+	}
+	if chunks[3].Metadata["eval_count"] != float64(2) {
+		t.Errorf("expected metadata on final line, got %v", chunks[3].Metadata)
+	}
+}
+
+func TestStream_NDJSON_CohereAndOperators(t *testing.T) {
+	body := `{"is_finished":false,"event_type":"text-generation","text":"One. "}
+{"is_finished":false,"event_type":"text-generation","text":"Two."}
+{"is_finished":true,"event_type":"stream-end"}
+`
+	stream := NewStream(context.Background(), strings.NewReader(body), StreamTypeNDJSON).SplitSentences()
+	var sentences []string
+	for c := range stream.Chunks() {
+		if c.Type == ChunkTypeData {
+			sentences = append(sentences, c.Data)
+		}
+	}
+	if strings.Join(sentences, "|") != "One. |Two." {
+		t.Errorf("unexpected sentences %q", sentences)
+	}
+}
+
+func bufioReader(b []byte) *bufio.Reader {
+	return bufio.NewReader(bytes.NewReader(b))
+}
@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+)
+
+// Func is the Go function behind a FuncTool
+type Func func(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error)
+
+// FuncTool runs an inline Go function. A panic in the function fails the
+// run instead of the process.
+type FuncTool struct {
+	name        string
+	description string
+	schema      map[string]interface{}
+	fn          Func
+}
+
+// NewFunc creates a tool from a Go function; schema is the JSON schema of
+// its input and may be nil
+func NewFunc(name, description string, schema map[string]interface{}, fn Func) *FuncTool {
+	return &FuncTool{name: name, description: description, schema: schema, fn: fn}
+}
+
+func (f *FuncTool) Name() string        { return f.name }
+func (f *FuncTool) Description() string { return f.description }
+func (f *FuncTool) Type() string        { return "func" }
+
+// InputSchema implements agent.ToolWithSchema
+func (f *FuncTool) InputSchema() map[string]interface{} { return f.schema }
+
+// OutputSchema implements agent.ToolWithSchema
+func (f *FuncTool) OutputSchema() map[string]interface{} { return nil }
+
+// Execute implements agent.Tool
+func (f *FuncTool) Execute(ctx context.Context, input map[string]interface{}) (out map[string]interface{}, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			out, err = nil, fmt.Errorf("tool '%s' panicked: %v", f.name, v)
+		}
+	}()
+	return f.fn(ctx, input)
+}
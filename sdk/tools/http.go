@@ -0,0 +1,138 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPToolConfig describes an HTTP endpoint the model may call. The model
+// only supplies the input: GET and DELETE send it as query parameters,
+// other methods as a JSON body. The URL, method and headers are fixed, so
+// the model cannot reach anything else.
+type HTTPToolConfig struct {
+	Name             string
+	Description      string
+	Method           string // Default GET
+	URL              string
+	Headers          map[string]string // e.g. Authorization for the endpoint
+	Schema           map[string]interface{}
+	Timeout          time.Duration // Zero leaves it to the runtime
+	MaxResponseBytes int64         // Zero uses DefaultMaxOutputBytes
+}
+
+// HTTPTool calls an HTTP endpoint
+type HTTPTool struct {
+	cfg    HTTPToolConfig
+	client *http.Client
+}
+
+// NewHTTPTool creates an HTTP tool
+func NewHTTPTool(cfg HTTPToolConfig) (*HTTPTool, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("http tool: name is required")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("http tool %s: url must be an absolute http(s) URL", cfg.Name)
+	}
+	if cfg.Method == "" {
+		cfg.Method = http.MethodGet
+	}
+	cfg.Method = strings.ToUpper(cfg.Method)
+	if cfg.MaxResponseBytes <= 0 {
+		cfg.MaxResponseBytes = DefaultMaxOutputBytes
+	}
+	return &HTTPTool{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// Redirects could leave the configured host
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+func (h *HTTPTool) Name() string        { return h.cfg.Name }
+func (h *HTTPTool) Description() string { return h.cfg.Description }
+func (h *HTTPTool) Type() string        { return "http" }
+
+// InputSchema implements agent.ToolWithSchema
+func (h *HTTPTool) InputSchema() map[string]interface{} { return h.cfg.Schema }
+
+// OutputSchema implements agent.ToolWithSchema
+func (h *HTTPTool) OutputSchema() map[string]interface{} { return nil }
+
+// Execute implements agent.Tool. The output holds the status and the
+// body, decoded when it is JSON; error statuses fail the run.
+func (h *HTTPTool) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	target := h.cfg.URL
+	var body io.Reader
+	switch h.cfg.Method {
+	case http.MethodGet, http.MethodDelete, http.MethodHead:
+		if len(input) > 0 {
+			u, _ := url.Parse(target)
+			q := u.Query()
+			for k, v := range input {
+				q.Set(k, fmt.Sprint(v))
+			}
+			u.RawQuery = q.Encode()
+			target = u.String()
+		}
+	default:
+		data, err := json.Marshal(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode input: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, h.cfg.Method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range h.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, h.cfg.MaxResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	truncated := int64(len(data)) > h.cfg.MaxResponseBytes
+	if truncated {
+		data = data[:h.cfg.MaxResponseBytes]
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	out := map[string]interface{}{"status": resp.StatusCode}
+	var decoded interface{}
+	if !truncated && json.Unmarshal(data, &decoded) == nil {
+		out["body"] = decoded
+	} else {
+		out["body"] = string(data)
+	}
+	if truncated {
+		out["truncated"] = true
+	}
+	return out, nil
+}
@@ -0,0 +1,258 @@
+// Package tools executes the tools a model asks for. A Runtime runs each
+// tool call against an agent.ToolRegistry within a time limit, records
+// the input, output and duration of every run in tool_executions and
+// returns results ready to be sent back to the model as tool messages.
+//
+// Besides any agent.Tool, three sandboxed kinds are provided: Go functions
+// (FuncTool), HTTP endpoints (HTTPTool) and allowlisted shell commands
+// (ShellTool).
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jeffersonwarrior/modelscan/sdk/agent"
+	"github.com/jeffersonwarrior/modelscan/sdk/storage"
+)
+
+// DefaultTimeout bounds a single tool run
+const DefaultTimeout = 30 * time.Second
+
+// DefaultMaxOutputBytes bounds the output returned to the model
+const DefaultMaxOutputBytes = 64 * 1024
+
+// Call is a model's request to run a tool
+type Call struct {
+	ID        string `json:"id"`        // Provider's tool call ID, echoed in the result
+	Name      string `json:"name"`      // Registered tool name
+	Arguments string `json:"arguments"` // JSON object
+}
+
+// Result is the outcome of a Call
+type Result struct {
+	CallID   string                 `json:"call_id"`
+	Name     string                 `json:"name"`
+	Output   map[string]interface{} `json:"output,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	Duration time.Duration          `json:"duration"`
+}
+
+// Content renders the result as the content of a tool message. Failures
+// are reported to the model rather than ending the conversation, so it can
+// correct its arguments or try something else.
+func (r Result) Content() string {
+	if r.Error != "" {
+		data, _ := json.Marshal(map[string]string{"error": r.Error})
+		return string(data)
+	}
+	data, err := json.Marshal(r.Output)
+	if err != nil {
+		return fmt.Sprintf(`{"error":"unencodable tool output: %v"}`, err)
+	}
+	return string(data)
+}
+
+// Definition describes a tool to the model, in the function calling shape
+// every provider accepts
+type Definition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// Typed is implemented by tools that report their kind for
+// tool_executions (func, http, shell)
+type Typed interface {
+	Type() string
+}
+
+// Recorder stores tool runs
+type Recorder interface {
+	Record(ctx context.Context, exec *storage.ToolExecution) error
+}
+
+// StorageRecorder records tool runs in the tool_executions table
+type StorageRecorder struct {
+	repo *storage.ToolExecutionRepository
+}
+
+// NewStorageRecorder creates a recorder backed by the repository
+func NewStorageRecorder(repo *storage.ToolExecutionRepository) *StorageRecorder {
+	return &StorageRecorder{repo: repo}
+}
+
+// Record implements Recorder
+func (s *StorageRecorder) Record(ctx context.Context, exec *storage.ToolExecution) error {
+	return s.repo.Create(ctx, exec)
+}
+
+type scopeKey struct{}
+
+type scope struct {
+	taskID  string
+	agentID string
+}
+
+// WithTask attributes the tool runs made with ctx to a task and agent
+func WithTask(ctx context.Context, taskID, agentID string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope{taskID: taskID, agentID: agentID})
+}
+
+// Options configures a Runtime
+type Options struct {
+	Timeout        time.Duration // Per run; zero uses DefaultTimeout
+	MaxOutputBytes int           // Encoded output limit; zero uses DefaultMaxOutputBytes
+	Recorder       Recorder      // Optional
+	Parallel       bool          // Run the calls of one turn concurrently
+}
+
+// Runtime runs tool calls against a registry
+type Runtime struct {
+	registry *agent.ToolRegistry
+	executor *agent.ToolExecutor
+	opts     Options
+}
+
+// NewRuntime creates a runtime for the tools in registry
+func NewRuntime(registry *agent.ToolRegistry, opts Options) *Runtime {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxOutputBytes <= 0 {
+		opts.MaxOutputBytes = DefaultMaxOutputBytes
+	}
+	return &Runtime{
+		registry: registry,
+		executor: agent.NewToolExecutor(registry),
+		opts:     opts,
+	}
+}
+
+// Definitions lists the registered tools for the model, in name order.
+// Tools without a schema accept any object.
+func (rt *Runtime) Definitions() []Definition {
+	names := rt.registry.List()
+	sort.Strings(names)
+	defs := make([]Definition, 0, len(names))
+	for _, name := range names {
+		tool, err := rt.registry.Get(name)
+		if err != nil {
+			continue
+		}
+		params := map[string]interface{}{"type": "object"}
+		if s, ok := tool.(agent.ToolWithSchema); ok && s.InputSchema() != nil {
+			params = s.InputSchema()
+		}
+		defs = append(defs, Definition{Name: name, Description: tool.Description(), Parameters: params})
+	}
+	return defs
+}
+
+// Run executes one call. Tool failures, bad arguments and timeouts are
+// returned in the result, never as a Go error.
+func (rt *Runtime) Run(ctx context.Context, call Call) Result {
+	res := Result{CallID: call.ID, Name: call.Name}
+	exec := &storage.ToolExecution{
+		ID:        uuid.New().String(),
+		ToolName:  call.Name,
+		ToolType:  "custom",
+		Input:     call.Arguments,
+		Status:    "running",
+		Metadata:  map[string]interface{}{"call_id": call.ID},
+		StartedAt: time.Now(),
+	}
+	if s, ok := ctx.Value(scopeKey{}).(scope); ok {
+		exec.TaskID, exec.AgentID = s.taskID, s.agentID
+	}
+	if tool, err := rt.registry.Get(call.Name); err == nil {
+		if typed, ok := tool.(Typed); ok {
+			exec.ToolType = typed.Type()
+		}
+	}
+
+	input := map[string]interface{}{}
+	var err error
+	if call.Arguments != "" {
+		if uerr := json.Unmarshal([]byte(call.Arguments), &input); uerr != nil {
+			err = fmt.Errorf("arguments are not a JSON object: %w", uerr)
+		}
+	}
+
+	start := time.Now()
+	if err == nil {
+		runCtx, cancel := context.WithTimeout(ctx, rt.opts.Timeout)
+		res.Output, err = rt.executor.Execute(runCtx, call.Name, input)
+		if err != nil && runCtx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("tool '%s' timed out after %s", call.Name, rt.opts.Timeout)
+		}
+		cancel()
+	}
+	res.Duration = time.Since(start)
+
+	if err == nil {
+		if data, merr := json.Marshal(res.Output); merr != nil {
+			err = fmt.Errorf("tool output is not JSON-encodable: %w", merr)
+		} else if len(data) > rt.opts.MaxOutputBytes {
+			res.Output = map[string]interface{}{
+				"truncated": true,
+				"output":    string(data[:rt.opts.MaxOutputBytes]),
+			}
+		}
+	}
+	if err != nil {
+		res.Output = nil
+		res.Error = err.Error()
+	}
+
+	rt.record(ctx, exec, res)
+	return res
+}
+
+// RunAll executes the calls of one model turn, returning results in call
+// order
+func (rt *Runtime) RunAll(ctx context.Context, calls []Call) []Result {
+	results := make([]Result, len(calls))
+	if !rt.opts.Parallel {
+		for i, call := range calls {
+			results[i] = rt.Run(ctx, call)
+		}
+		return results
+	}
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func(i int, call Call) {
+			defer wg.Done()
+			results[i] = rt.Run(ctx, call)
+		}(i, call)
+	}
+	wg.Wait()
+	return results
+}
+
+// record stores a finished run; recording failures do not fail the run
+func (rt *Runtime) record(ctx context.Context, exec *storage.ToolExecution, res Result) {
+	if rt.opts.Recorder == nil {
+		return
+	}
+	completed := exec.StartedAt.Add(res.Duration)
+	exec.CompletedAt = &completed
+	exec.Duration = res.Duration.Milliseconds()
+	if res.Error != "" {
+		exec.Status, exec.Error = "failed", res.Error
+	} else {
+		exec.Status, exec.Output = "completed", res.Content()
+	}
+	// The run is recorded even when the caller's context was cancelled
+	if err := rt.opts.Recorder.Record(context.WithoutCancel(ctx), exec); err != nil {
+		log.Printf("tools: failed to record run of %s: %v", exec.ToolName, err)
+	}
+}
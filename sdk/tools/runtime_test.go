@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/agent"
+	"github.com/jeffersonwarrior/modelscan/sdk/storage"
+)
+
+func newTestRuntime(t *testing.T, opts Options, tools ...agent.Tool) (*Runtime, *storage.ToolExecutionRepository) {
+	t.Helper()
+	adb, err := storage.NewAgentDB(filepath.Join(t.TempDir(), "agents.db"))
+	if err != nil {
+		t.Fatalf("NewAgentDB: %v", err)
+	}
+	t.Cleanup(func() { adb.Close() })
+	repo := storage.NewToolExecutionRepository(adb.GetDB())
+
+	registry := agent.NewToolRegistry()
+	for _, tool := range tools {
+		if err := registry.Register(tool); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+	opts.Recorder = NewStorageRecorder(repo)
+	return NewRuntime(registry, opts), repo
+}
+
+func TestRuntime_RunRecordsExecutions(t *testing.T) {
+	add := NewFunc("add", "Adds two numbers", map[string]interface{}{"type": "object"},
+		func(ctx context.Context, in map[string]interface{}) (map[string]interface{}, error) {
+			a, _ := in["a"].(float64)
+			b, _ := in["b"].(float64)
+			return map[string]interface{}{"sum": a + b}, nil
+		})
+	fail := NewFunc("fail", "Always fails", nil,
+		func(ctx context.Context, in map[string]interface{}) (map[string]interface{}, error) {
+			return nil, errors.New("backend down")
+		})
+	boom := NewFunc("boom", "Panics", nil,
+		func(ctx context.Context, in map[string]interface{}) (map[string]interface{}, error) {
+			panic("nil map")
+		})
+	rt, repo := newTestRuntime(t, Options{Parallel: true}, add, fail, boom)
+
+	ctx := WithTask(context.Background(), "task-1", "")
+	results := rt.RunAll(ctx, []Call{
+		{ID: "call_1", Name: "add", Arguments: `{"a": 2, "b": 3}`},
+		{ID: "call_2", Name: "fail", Arguments: `{}`},
+		{ID: "call_3", Name: "boom"},
+		{ID: "call_4", Name: "add", Arguments: `[1, 2]`},
+		{ID: "call_5", Name: "missing", Arguments: `{}`},
+	})
+
+	if results[0].CallID != "call_1" || results[0].Content() != `{"sum":5}` {
+		t.Errorf("add = %+v", results[0])
+	}
+	for i, want := range []string{"backend down", "panicked", "not a JSON object", "not found"} {
+		if r := results[i+1]; !strings.Contains(r.Error, want) || !strings.Contains(r.Content(), `"error"`) {
+			t.Errorf("call %s: error %q, want %q", r.CallID, r.Error, want)
+		}
+	}
+
+	execs, err := repo.ListByTask(context.Background(), "task-1", 10, 0)
+	if err != nil {
+		t.Fatalf("ListByTask: %v", err)
+	}
+	if len(execs) != 5 {
+		t.Fatalf("expected 5 recorded runs, got %d", len(execs))
+	}
+	byCall := make(map[string]*storage.ToolExecution)
+	for _, e := range execs {
+		byCall[e.Metadata["call_id"].(string)] = e
+	}
+	if e := byCall["call_1"]; e.Status != "completed" || e.ToolType != "func" || e.Input != `{"a": 2, "b": 3}` || e.Output != `{"sum":5}` || e.CompletedAt == nil {
+		t.Errorf("unexpected record %+v", e)
+	}
+	if e := byCall["call_2"]; e.Status != "failed" || !strings.Contains(e.Error, "backend down") {
+		t.Errorf("unexpected record %+v", e)
+	}
+}
+
+func TestRuntime_TimeoutAndTruncation(t *testing.T) {
+	slow := NewFunc("slow", "Waits for cancellation", nil,
+		func(ctx context.Context, in map[string]interface{}) (map[string]interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	big := NewFunc("big", "Large output", nil,
+		func(ctx context.Context, in map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"data": strings.Repeat("x", 500)}, nil
+		})
+	rt, _ := newTestRuntime(t, Options{Timeout: 20 * time.Millisecond, MaxOutputBytes: 100}, slow, big)
+
+	if r := rt.Run(context.Background(), Call{ID: "1", Name: "slow"}); !strings.Contains(r.Error, "timed out") {
+		t.Errorf("expected a timeout, got %+v", r)
+	}
+	r := rt.Run(context.Background(), Call{ID: "2", Name: "big"})
+	if r.Output["truncated"] != true || len(r.Output["output"].(string)) != 100 {
+		t.Errorf("expected truncated output, got %+v", r.Output)
+	}
+
+	defs := rt.Definitions()
+	if len(defs) != 2 || defs[0].Name != "big" || defs[0].Parameters["type"] != "object" {
+		t.Errorf("unexpected definitions %+v", defs)
+	}
+}
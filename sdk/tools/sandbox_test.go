@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPTool(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"city":"` + r.URL.Query().Get("city") + `","temp":4}`))
+		case http.MethodPost:
+			var in map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&in)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("bad ticket " + in["title"].(string)))
+		}
+	}))
+	defer srv.Close()
+
+	weather, err := NewHTTPTool(HTTPToolConfig{Name: "weather", URL: srv.URL + "/weather", Headers: map[string]string{"Authorization": "Bearer secret"}})
+	if err != nil {
+		t.Fatalf("NewHTTPTool: %v", err)
+	}
+	out, err := weather.Execute(context.Background(), map[string]interface{}{"city": "Oslo"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if body := out["body"].(map[string]interface{}); out["status"] != 200 || body["city"] != "Oslo" {
+		t.Errorf("unexpected output %v", out)
+	}
+
+	ticket, _ := NewHTTPTool(HTTPToolConfig{Name: "ticket", Method: "post", URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer secret"}})
+	if _, err := ticket.Execute(context.Background(), map[string]interface{}{"title": "x"}); err == nil || !strings.Contains(err.Error(), "status 400: bad ticket x") {
+		t.Errorf("expected a status error, got %v", err)
+	}
+
+	if _, err := NewHTTPTool(HTTPToolConfig{Name: "local", URL: "file:///etc/passwd"}); err == nil {
+		t.Error("expected non-http URLs to be rejected")
+	}
+}
+
+func TestShellTool(t *testing.T) {
+	sh, err := NewShellTool(ShellToolConfig{Name: "shell", AllowedCommands: []string{"echo", "false"}})
+	if err != nil {
+		t.Skipf("echo not available: %v", err)
+	}
+
+	// Arguments are passed as they are, never interpreted by a shell
+	out, err := sh.Execute(context.Background(), map[string]interface{}{"command": "echo", "args": []interface{}{"hi;", "rm", "-rf", "$HOME"}})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if out["stdout"] != "hi; rm -rf $HOME\n" || out["exit_code"] != 0 {
+		t.Errorf("unexpected output %v", out)
+	}
+
+	if out, err := sh.Execute(context.Background(), map[string]interface{}{"command": "false"}); err != nil || out["exit_code"] != 1 {
+		t.Errorf("expected exit code 1, got %v %v", out, err)
+	}
+
+	for _, in := range []map[string]interface{}{
+		{"command": "sh", "args": []interface{}{"-c", "id"}},
+		{"command": "/bin/echo/../sh"},
+		{"command": "echo", "args": "not a list"},
+	} {
+		if _, err := sh.Execute(context.Background(), in); err == nil {
+			t.Errorf("expected %v to be refused", in)
+		}
+	}
+}
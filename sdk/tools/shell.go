@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// ShellToolConfig describes the commands the model may run. Commands are
+// executed directly, never through a shell, so arguments cannot chain or
+// redirect commands.
+type ShellToolConfig struct {
+	Name        string
+	Description string
+	// AllowedCommands are the only programs the model may run, by name or
+	// path; names are resolved on PATH when the tool is created
+	AllowedCommands []string
+	Dir             string        // Working directory; empty is the current one
+	Env             []string      // Complete environment, KEY=value; nothing else is inherited
+	MaxArgs         int           // Zero allows 32
+	Timeout         time.Duration // Zero leaves it to the runtime
+	MaxOutputBytes  int           // Per stream; zero uses DefaultMaxOutputBytes
+}
+
+// ShellTool runs allowlisted commands
+type ShellTool struct {
+	cfg     ShellToolConfig
+	allowed map[string]string // name -> resolved path
+}
+
+// NewShellTool creates a shell tool, resolving the allowlisted commands
+func NewShellTool(cfg ShellToolConfig) (*ShellTool, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("shell tool: name is required")
+	}
+	if len(cfg.AllowedCommands) == 0 {
+		return nil, fmt.Errorf("shell tool %s: allowed_commands is required", cfg.Name)
+	}
+	if cfg.MaxArgs <= 0 {
+		cfg.MaxArgs = 32
+	}
+	if cfg.MaxOutputBytes <= 0 {
+		cfg.MaxOutputBytes = DefaultMaxOutputBytes
+	}
+	if cfg.Env == nil {
+		cfg.Env = []string{}
+	}
+
+	allowed := make(map[string]string, len(cfg.AllowedCommands))
+	for _, name := range cfg.AllowedCommands {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			return nil, fmt.Errorf("shell tool %s: command %q: %w", cfg.Name, name, err)
+		}
+		allowed[name] = path
+		allowed[filepath.Base(name)] = path
+	}
+	return &ShellTool{cfg: cfg, allowed: allowed}, nil
+}
+
+func (s *ShellTool) Name() string        { return s.cfg.Name }
+func (s *ShellTool) Description() string { return s.cfg.Description }
+func (s *ShellTool) Type() string        { return "shell" }
+
+// InputSchema implements agent.ToolWithSchema
+func (s *ShellTool) InputSchema() map[string]interface{} {
+	commands := make([]interface{}, 0, len(s.cfg.AllowedCommands))
+	for _, name := range s.cfg.AllowedCommands {
+		commands = append(commands, filepath.Base(name))
+	}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"command": map[string]interface{}{"type": "string", "enum": commands},
+			"args":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+		"required": []interface{}{"command"},
+	}
+}
+
+// OutputSchema implements agent.ToolWithSchema
+func (s *ShellTool) OutputSchema() map[string]interface{} { return nil }
+
+// Execute implements agent.Tool. A non-zero exit status is reported in the
+// output, not as a failure, since it is often the answer the model wants.
+func (s *ShellTool) Execute(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+	name, _ := input["command"].(string)
+	path, ok := s.allowed[name]
+	if !ok {
+		return nil, fmt.Errorf("command %q is not allowed", name)
+	}
+
+	var args []string
+	if raw, present := input["args"]; present {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("args must be an array of strings")
+		}
+		for _, a := range list {
+			arg, ok := a.(string)
+			if !ok {
+				return nil, fmt.Errorf("args must be an array of strings")
+			}
+			args = append(args, arg)
+		}
+	}
+	if len(args) > s.cfg.MaxArgs {
+		return nil, fmt.Errorf("at most %d args are allowed", s.cfg.MaxArgs)
+	}
+
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = s.cfg.Dir
+	cmd.Env = s.cfg.Env
+	cmd.WaitDelay = time.Second
+	stdout := &limitedBuffer{max: s.cfg.MaxOutputBytes}
+	stderr := &limitedBuffer{max: s.cfg.MaxOutputBytes}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("command %q: %w", name, ctx.Err())
+	}
+	exitCode := 0
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return nil, fmt.Errorf("command %q: %w", name, err)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	out := map[string]interface{}{
+		"exit_code": exitCode,
+		"stdout":    stdout.String(),
+		"stderr":    stderr.String(),
+	}
+	if stdout.truncated || stderr.truncated {
+		out["truncated"] = true
+	}
+	return out, nil
+}
+
+// limitedBuffer keeps the first max bytes written and discards the rest,
+// so a chatty command cannot exhaust memory
+type limitedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
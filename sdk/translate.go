@@ -0,0 +1,320 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jeffersonwarrior/modelscan/sdk/providererr"
+)
+
+// anthropicVersion is the Messages API version sent to Anthropic-format
+// providers
+const anthropicVersion = "2023-06-01"
+
+// defaultMaxTokens is sent to Anthropic-format providers, which require a
+// limit, when the request has none
+const defaultMaxTokens = 4096
+
+// Message is one chat turn. Role is "system", "user" or "assistant".
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest is a provider-neutral chat completion request
+type ChatRequest struct {
+	Model       string
+	Messages    []Message
+	MaxTokens   int      // Zero: provider default
+	Temperature *float64 // Nil: provider default
+	Stop        []string
+
+	// MaxCost excludes providers whose worst-case cost for the request
+	// (MaxTokens of output, or 4096 when unset) exceeds it, in USD; zero
+	// is no limit
+	MaxCost float64
+}
+
+// ChatResponse is a chat completion in a provider-neutral shape
+type ChatResponse struct {
+	ID           string  `json:"id"`
+	Provider     string  `json:"provider"`
+	Model        string  `json:"model"`
+	Content      string  `json:"content"`
+	FinishReason string  `json:"finish_reason"` // "stop", "length", "tool_calls" or "content_filter"
+	Usage        Usage   `json:"usage"`
+	Cost         float64 `json:"cost"` // USD, from the provider's pricing
+}
+
+// EmbedRequest asks for one embedding per input
+type EmbedRequest struct {
+	Model string
+	Input []string
+}
+
+// EmbedResponse holds embeddings in input order
+type EmbedResponse struct {
+	Provider   string      `json:"provider"`
+	Model      string      `json:"model"`
+	Embeddings [][]float64 `json:"embeddings"`
+	Usage      Usage       `json:"usage"`
+	Cost       float64     `json:"cost"`
+}
+
+// chat sends req in the provider's format and normalizes the answer
+func (p *provider) chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if p.cfg.Format == FormatAnthropic {
+		var out struct {
+			ID      string `json:"id"`
+			Model   string `json:"model"`
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+			StopReason string `json:"stop_reason"`
+			Usage      struct {
+				InputTokens  int `json:"input_tokens"`
+				OutputTokens int `json:"output_tokens"`
+			} `json:"usage"`
+		}
+		if err := p.post(ctx, "/messages", anthropicChatBody(req, false), &out); err != nil {
+			return nil, err
+		}
+		var text strings.Builder
+		for _, block := range out.Content {
+			if block.Type == "text" {
+				text.WriteString(block.Text)
+			}
+		}
+		return &ChatResponse{
+			ID:           out.ID,
+			Provider:     p.cfg.Name,
+			Model:        out.Model,
+			Content:      text.String(),
+			FinishReason: anthropicFinishReason(out.StopReason),
+			Usage:        Usage{InputTokens: out.Usage.InputTokens, OutputTokens: out.Usage.OutputTokens},
+		}, nil
+	}
+
+	var out struct {
+		ID      string `json:"id"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if err := p.post(ctx, "/chat/completions", openAIChatBody(req, false), &out); err != nil {
+		return nil, err
+	}
+	if len(out.Choices) == 0 {
+		return nil, fmt.Errorf("%s: response has no choices", p.cfg.Name)
+	}
+	return &ChatResponse{
+		ID:           out.ID,
+		Provider:     p.cfg.Name,
+		Model:        out.Model,
+		Content:      out.Choices[0].Message.Content,
+		FinishReason: out.Choices[0].FinishReason,
+		Usage:        Usage{InputTokens: out.Usage.PromptTokens, OutputTokens: out.Usage.CompletionTokens},
+	}, nil
+}
+
+// chatStream starts a streamed chat completion, returning the SSE body
+func (p *provider) chatStream(ctx context.Context, req ChatRequest) (io.ReadCloser, error) {
+	path, body := "/chat/completions", openAIChatBody(req, true)
+	if p.cfg.Format == FormatAnthropic {
+		path, body = "/messages", anthropicChatBody(req, true)
+	}
+	resp, err := p.send(ctx, path, body)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// embed creates embeddings through an OpenAI-format /embeddings endpoint
+func (p *provider) embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error) {
+	var out struct {
+		Model string `json:"model"`
+		Data  []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+		Usage struct {
+			PromptTokens int `json:"prompt_tokens"`
+		} `json:"usage"`
+	}
+	body := map[string]interface{}{"model": req.Model, "input": req.Input}
+	if err := p.post(ctx, "/embeddings", body, &out); err != nil {
+		return nil, err
+	}
+
+	embeddings := make([][]float64, len(req.Input))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(embeddings) {
+			return nil, fmt.Errorf("%s: embedding index %d out of range", p.cfg.Name, d.Index)
+		}
+		embeddings[d.Index] = d.Embedding
+	}
+	return &EmbedResponse{
+		Provider:   p.cfg.Name,
+		Model:      out.Model,
+		Embeddings: embeddings,
+		Usage:      Usage{InputTokens: out.Usage.PromptTokens},
+	}, nil
+}
+
+// post sends body and decodes a successful JSON response into out
+func (p *provider) post(ctx context.Context, path string, body, out interface{}) error {
+	resp, err := p.send(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s: failed to decode response: %w", p.cfg.Name, err)
+	}
+	return nil
+}
+
+// send POSTs body as JSON with the provider's authentication, retrying
+// through the HTTP client. Error statuses become *providererr.Error.
+func (p *provider) send(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	url := strings.TrimRight(p.cfg.BaseURL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.cfg.Format == FormatAnthropic {
+		req.Header.Set("x-api-key", p.cfg.APIKey)
+		req.Header.Set("anthropic-version", anthropicVersion)
+	}
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: request failed: %w", p.cfg.Name, err)
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, providererr.Classify(p.cfg.Name, resp.StatusCode, resp.Header, respBody)
+	}
+	return resp.Response, nil
+}
+
+// openAIChatBody builds a /chat/completions request
+func openAIChatBody(req ChatRequest, stream bool) map[string]interface{} {
+	body := map[string]interface{}{
+		"model":    req.Model,
+		"messages": req.Messages,
+	}
+	if req.MaxTokens > 0 {
+		body["max_tokens"] = req.MaxTokens
+	}
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if len(req.Stop) > 0 {
+		body["stop"] = req.Stop
+	}
+	if stream {
+		body["stream"] = true
+		body["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
+	return body
+}
+
+// anthropicChatBody builds a /messages request, moving system messages to
+// the top-level system prompt
+func anthropicChatBody(req ChatRequest, stream bool) map[string]interface{} {
+	var system []string
+	messages := make([]Message, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		if m.Role == "system" {
+			system = append(system, m.Content)
+			continue
+		}
+		messages = append(messages, m)
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxTokens
+	}
+	body := map[string]interface{}{
+		"model":      req.Model,
+		"messages":   messages,
+		"max_tokens": maxTokens,
+	}
+	if len(system) > 0 {
+		body["system"] = strings.Join(system, "\n\n")
+	}
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if len(req.Stop) > 0 {
+		body["stop_sequences"] = req.Stop
+	}
+	if stream {
+		body["stream"] = true
+	}
+	return body
+}
+
+// anthropicFinishReason maps an Anthropic stop_reason to the OpenAI
+// finish_reason vocabulary
+func anthropicFinishReason(reason string) string {
+	switch reason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "refusal":
+		return "content_filter"
+	}
+	return reason
+}
+
+// streamUsage adds token counts reported by a stream event to u: the
+// OpenAI final chunk's usage, or Anthropic's message_start (input) and
+// message_delta (output) usage
+func streamUsage(data map[string]interface{}, u *Usage) {
+	usage, _ := data["usage"].(map[string]interface{})
+	if message, ok := data["message"].(map[string]interface{}); ok && data["type"] == "message_start" {
+		usage, _ = message["usage"].(map[string]interface{})
+	}
+	if usage == nil {
+		return
+	}
+	if n, ok := usage["prompt_tokens"].(float64); ok {
+		u.InputTokens = int(n)
+	}
+	if n, ok := usage["completion_tokens"].(float64); ok {
+		u.OutputTokens = int(n)
+	}
+	if n, ok := usage["input_tokens"].(float64); ok {
+		u.InputTokens = int(n)
+	}
+	if n, ok := usage["output_tokens"].(float64); ok {
+		u.OutputTokens = int(n)
+	}
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ModelCapabilities records what a model supports when served by a given
+// provider. The same model may differ between providers (a smaller context
+// window, no JSON mode), so rows are keyed by both.
+type ModelCapabilities struct {
+	ProviderName  string   `json:"provider_name"`
+	ModelID       string   `json:"model_id"`
+	Vision        bool     `json:"vision"`
+	Tools         bool     `json:"tools"`
+	JSONMode      bool     `json:"json_mode"`
+	ContextTokens int64    `json:"context_tokens"`
+	Regions       []string `json:"regions,omitempty"` // e.g. "us", "eu"
+}
+
+// HasRegion reports whether the model is served from region
+func (c ModelCapabilities) HasRegion(region string) bool {
+	for _, r := range c.Regions {
+		if strings.EqualFold(r, region) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetModelCapabilities inserts or replaces a provider's capabilities for a model
+func SetModelCapabilities(c ModelCapabilities) error {
+	if c.ProviderName == "" || c.ModelID == "" {
+		return fmt.Errorf("provider name and model ID are required")
+	}
+	if c.ContextTokens < 0 {
+		return fmt.Errorf("context tokens must not be negative, got %d", c.ContextTokens)
+	}
+
+	_, err := rateLimitDB.Exec(`
+		INSERT INTO model_capabilities (provider_name, model_id, supports_vision, supports_tools, supports_json_mode, context_tokens, regions)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(provider_name, model_id) DO UPDATE SET
+			supports_vision = excluded.supports_vision,
+			supports_tools = excluded.supports_tools,
+			supports_json_mode = excluded.supports_json_mode,
+			context_tokens = excluded.context_tokens,
+			regions = excluded.regions
+	`, c.ProviderName, c.ModelID, c.Vision, c.Tools, c.JSONMode, c.ContextTokens, JoinRegions(c.Regions))
+	return err
+}
+
+// GetModelCapabilities returns a provider's capabilities for a model, or nil
+// if none are recorded
+func GetModelCapabilities(providerName, modelID string) (*ModelCapabilities, error) {
+	var c ModelCapabilities
+	var regions string
+	err := rateLimitDB.QueryRow(`
+		SELECT provider_name, model_id, supports_vision, supports_tools, supports_json_mode, context_tokens, regions
+		FROM model_capabilities WHERE provider_name = ? AND model_id = ?
+	`, providerName, modelID).Scan(&c.ProviderName, &c.ModelID, &c.Vision, &c.Tools, &c.JSONMode, &c.ContextTokens, &regions)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.Regions = SplitRegions(regions)
+	return &c, nil
+}
+
+// ListModelCapabilities returns the full capability matrix
+func ListModelCapabilities() ([]ModelCapabilities, error) {
+	rows, err := rateLimitDB.Query(`
+		SELECT provider_name, model_id, supports_vision, supports_tools, supports_json_mode, context_tokens, regions
+		FROM model_capabilities ORDER BY provider_name, model_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []ModelCapabilities
+	for rows.Next() {
+		var c ModelCapabilities
+		var regions string
+		if err := rows.Scan(&c.ProviderName, &c.ModelID, &c.Vision, &c.Tools, &c.JSONMode, &c.ContextTokens, &regions); err != nil {
+			return nil, err
+		}
+		c.Regions = SplitRegions(regions)
+		results = append(results, c)
+	}
+	return results, rows.Err()
+}
+
+// JoinRegions encodes a region list for storage
+func JoinRegions(regions []string) string {
+	return strings.Join(regions, ",")
+}
+
+// SplitRegions decodes a stored region list
+func SplitRegions(s string) []string {
+	var regions []string
+	for _, r := range strings.Split(s, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			regions = append(regions, r)
+		}
+	}
+	return regions
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestModelCapabilities(t *testing.T) {
+	if err := InitRateLimitDB(filepath.Join(t.TempDir(), "rate_limits.db")); err != nil {
+		t.Fatalf("Failed to initialize rate limit DB: %v", err)
+	}
+	defer CloseRateLimitDB()
+
+	caps := ModelCapabilities{ProviderName: "openai", ModelID: "gpt-4o", Vision: true, Tools: true, ContextTokens: 128000, Regions: []string{"us", "eu"}}
+	if err := SetModelCapabilities(caps); err != nil {
+		t.Fatalf("SetModelCapabilities() error = %v", err)
+	}
+	caps.JSONMode = true
+	if err := SetModelCapabilities(caps); err != nil {
+		t.Fatalf("SetModelCapabilities() error = %v", err)
+	}
+
+	got, err := GetModelCapabilities("openai", "gpt-4o")
+	if err != nil || got == nil || !got.JSONMode || got.ContextTokens != 128000 || !got.HasRegion("EU") {
+		t.Fatalf("GetModelCapabilities() = %+v, %v", got, err)
+	}
+	if got, _ := GetModelCapabilities("groq", "gpt-4o"); got != nil {
+		t.Errorf("capabilities leaked across providers: %+v", got)
+	}
+
+	SetModelCapabilities(ModelCapabilities{ProviderName: "groq", ModelID: "mixtral-8x7b"})
+	list, err := ListModelCapabilities()
+	if err != nil || len(list) != 2 || list[0].ProviderName != "groq" || list[0].Regions != nil {
+		t.Errorf("ListModelCapabilities() = %+v, %v", list, err)
+	}
+
+	if err := SetModelCapabilities(ModelCapabilities{ModelID: "gpt-4o"}); err == nil {
+		t.Error("expected error without a provider")
+	}
+}
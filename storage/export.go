@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jeffersonwarrior/modelscan/internal/database/dialect"
+	"github.com/jeffersonwarrior/modelscan/internal/export"
+)
+
+// ExportDatasets lists the datasets Export accepts. pricing comes from the
+// rate limit database, the others from the provider database.
+var ExportDatasets = []string{"models", "endpoints", "pricing"}
+
+// Export streams a dataset to w row by row and returns the number of rows
+// written. The caller closes w.
+func Export(dataset string, w export.Writer) (int, error) {
+	var (
+		conn  *dialect.DB
+		query string
+	)
+	switch dataset {
+	case "models":
+		conn, query = db, `SELECT provider_name, model_id, name, description, cost_per_1m_in, cost_per_1m_out,
+			context_window, max_tokens, supports_images, supports_tools, can_reason, can_stream,
+			categories, created_at
+			FROM models ORDER BY provider_name, model_id`
+	case "endpoints":
+		conn, query = db, `SELECT provider_name, path, method, description, status, latency_ms, error_message, created_at
+			FROM endpoints ORDER BY provider_name, path, method`
+	case "pricing":
+		conn, query = rateLimitDB, `SELECT provider_name, model_id, plan_type, input_cost, output_cost, unit_type,
+			currency, included_units
+			FROM provider_pricing ORDER BY provider_name, model_id, plan_type`
+	default:
+		return 0, fmt.Errorf("unknown export dataset %q", dataset)
+	}
+	if conn == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := conn.Query(query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query %s: %w", dataset, err)
+	}
+	defer rows.Close()
+
+	return export.WriteRows(w, rows)
+}
+
+// ExportToFile writes a dataset to outputPath in the given format
+func ExportToFile(dataset string, format export.Format, outputPath string) (int, error) {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer file.Close()
+
+	w, err := export.NewWriter(format, file)
+	if err != nil {
+		return 0, err
+	}
+	n, err := Export(dataset, w)
+	if err != nil {
+		return n, err
+	}
+	if err := w.Close(); err != nil {
+		return n, fmt.Errorf("failed to write export file: %w", err)
+	}
+	return n, file.Close()
+}
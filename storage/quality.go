@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QualityTier ranks models by capability. The zero value means the model
+// has not been rated and ranks below every tier.
+type QualityTier int
+
+const (
+	TierUnrated  QualityTier = iota
+	TierBasic                // Simple classification, extraction and chat
+	TierStandard             // General chat and light coding
+	TierAdvanced             // Reliable coding and multi-step reasoning
+	TierFrontier             // The strongest available models
+)
+
+var tierNames = []string{"unrated", "basic", "standard", "advanced", "frontier"}
+
+func (t QualityTier) String() string {
+	if t < 0 || int(t) >= len(tierNames) {
+		return fmt.Sprintf("tier(%d)", int(t))
+	}
+	return tierNames[t]
+}
+
+// ParseQualityTier parses a tier name
+func ParseQualityTier(s string) (QualityTier, error) {
+	for i, name := range tierNames {
+		if strings.EqualFold(s, name) {
+			return QualityTier(i), nil
+		}
+	}
+	return TierUnrated, fmt.Errorf("invalid quality tier %q (want basic, standard, advanced or frontier)", s)
+}
+
+// MarshalText encodes the tier as its name
+func (t QualityTier) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText decodes a tier name
+func (t *QualityTier) UnmarshalText(text []byte) error {
+	tier, err := ParseQualityTier(string(text))
+	if err != nil {
+		return err
+	}
+	*t = tier
+	return nil
+}
+
+// TierForScore maps a 0-100 benchmark score to a tier
+func TierForScore(score float64) QualityTier {
+	switch {
+	case score >= 85:
+		return TierFrontier
+	case score >= 70:
+		return TierAdvanced
+	case score >= 50:
+		return TierStandard
+	case score > 0:
+		return TierBasic
+	}
+	return TierUnrated
+}
+
+// Quality sources; seeding only overwrites benchmark ratings
+const (
+	QualitySourceBenchmark = "benchmark"
+	QualitySourceAdmin     = "admin"
+)
+
+// ModelQuality is a model's capability rating
+type ModelQuality struct {
+	ModelID   string      `json:"model_id"`
+	Tier      QualityTier `json:"tier"`
+	Score     float64     `json:"score"`  // 0-100 composite benchmark score
+	Source    string      `json:"source"` // benchmark or admin
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// SetModelQuality inserts or replaces a model's rating. A missing tier is
+// derived from the score. Ratings from QualitySourceBenchmark never replace
+// ones set by an admin.
+func SetModelQuality(q ModelQuality) error {
+	if q.ModelID == "" {
+		return fmt.Errorf("model ID is required")
+	}
+	if q.Score < 0 || q.Score > 100 {
+		return fmt.Errorf("score must be between 0 and 100, got %v", q.Score)
+	}
+	if q.Tier < TierUnrated || q.Tier > TierFrontier {
+		return fmt.Errorf("invalid quality tier %d", int(q.Tier))
+	}
+	if q.Tier == TierUnrated {
+		q.Tier = TierForScore(q.Score)
+	}
+	if q.Source == "" {
+		q.Source = QualitySourceAdmin
+	}
+	if q.UpdatedAt.IsZero() {
+		q.UpdatedAt = time.Now()
+	}
+
+	_, err := rateLimitDB.Exec(`
+		INSERT INTO model_quality (model_id, tier, score, source, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(model_id) DO UPDATE SET
+			tier = excluded.tier,
+			score = excluded.score,
+			source = excluded.source,
+			updated_at = excluded.updated_at
+		WHERE excluded.source <> ? OR model_quality.source = ?
+	`, q.ModelID, int(q.Tier), q.Score, q.Source, q.UpdatedAt, QualitySourceBenchmark, QualitySourceBenchmark)
+	return err
+}
+
+// GetModelQuality returns a model's rating, or nil if it is not rated
+func GetModelQuality(modelID string) (*ModelQuality, error) {
+	var q ModelQuality
+	var tier int
+	err := rateLimitDB.QueryRow(
+		"SELECT model_id, tier, score, source, updated_at FROM model_quality WHERE model_id = ?", modelID,
+	).Scan(&q.ModelID, &tier, &q.Score, &q.Source, &q.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	q.Tier = QualityTier(tier)
+	return &q, nil
+}
+
+// ListModelQuality returns every rating, best first
+func ListModelQuality() ([]ModelQuality, error) {
+	rows, err := rateLimitDB.Query(
+		"SELECT model_id, tier, score, source, updated_at FROM model_quality ORDER BY tier DESC, score DESC, model_id",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []ModelQuality
+	for rows.Next() {
+		var q ModelQuality
+		var tier int
+		if err := rows.Scan(&q.ModelID, &tier, &q.Score, &q.Source, &q.UpdatedAt); err != nil {
+			return nil, err
+		}
+		q.Tier = QualityTier(tier)
+		results = append(results, q)
+	}
+	return results, rows.Err()
+}
+
+// DeleteModelQuality removes a model's rating, reporting whether one existed
+func DeleteModelQuality(modelID string) (bool, error) {
+	result, err := rateLimitDB.Exec("DELETE FROM model_quality WHERE model_id = ?", modelID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestModelQuality(t *testing.T) {
+	if err := InitRateLimitDB(filepath.Join(t.TempDir(), "rate_limits.db")); err != nil {
+		t.Fatalf("Failed to initialize rate limit DB: %v", err)
+	}
+	defer CloseRateLimitDB()
+
+	// The tier is derived from the score when not given
+	if err := SetModelQuality(ModelQuality{ModelID: "gpt-4o", Score: 80, Source: QualitySourceBenchmark}); err != nil {
+		t.Fatalf("SetModelQuality() error = %v", err)
+	}
+	q, err := GetModelQuality("gpt-4o")
+	if err != nil || q == nil || q.Tier != TierAdvanced || q.Source != QualitySourceBenchmark {
+		t.Fatalf("GetModelQuality() = %+v, %v", q, err)
+	}
+
+	// Admin ratings win over later benchmark seeding
+	if err := SetModelQuality(ModelQuality{ModelID: "gpt-4o", Tier: TierFrontier, Score: 80}); err != nil {
+		t.Fatalf("SetModelQuality() error = %v", err)
+	}
+	if err := SetModelQuality(ModelQuality{ModelID: "gpt-4o", Score: 60, Source: QualitySourceBenchmark}); err != nil {
+		t.Fatalf("SetModelQuality() error = %v", err)
+	}
+	if q, _ := GetModelQuality("gpt-4o"); q.Tier != TierFrontier || q.Source != QualitySourceAdmin {
+		t.Errorf("admin rating overwritten: %+v", q)
+	}
+
+	SetModelQuality(ModelQuality{ModelID: "llama3.1-8b", Score: 40})
+	list, err := ListModelQuality()
+	if err != nil || len(list) != 2 || list[0].ModelID != "gpt-4o" || list[1].Tier != TierBasic {
+		t.Errorf("ListModelQuality() = %+v, %v", list, err)
+	}
+
+	if err := SetModelQuality(ModelQuality{ModelID: "x", Score: 101}); err == nil {
+		t.Error("expected error for a score above 100")
+	}
+	if ok, err := DeleteModelQuality("llama3.1-8b"); !ok || err != nil {
+		t.Errorf("DeleteModelQuality() = %v, %v", ok, err)
+	}
+	if q, _ := GetModelQuality("llama3.1-8b"); q != nil {
+		t.Errorf("rating not deleted: %+v", q)
+	}
+}
+
+func TestQualityTier_Text(t *testing.T) {
+	data, err := json.Marshal(ModelQuality{ModelID: "m", Tier: TierAdvanced})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var q ModelQuality
+	if err := json.Unmarshal(data, &q); err != nil || q.Tier != TierAdvanced {
+		t.Errorf("round trip = %+v, %v (%s)", q, err, data)
+	}
+	if _, err := ParseQualityTier("genius"); err == nil {
+		t.Error("expected error for an unknown tier")
+	}
+	if tier, _ := ParseQualityTier("Frontier"); tier != TierFrontier {
+		t.Errorf("ParseQualityTier is case sensitive: %v", tier)
+	}
+}
@@ -5,10 +5,11 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jeffersonwarrior/modelscan/internal/database/dialect"
 	_ "github.com/mattn/go-sqlite3"
 )
 
-var rateLimitDB *sql.DB
+var rateLimitDB *dialect.DB
 
 // RateLimit represents a rate limit configuration for a provider
 type RateLimit struct {
@@ -62,22 +63,26 @@ type PricingHistory struct {
 	ChangeReason  string
 }
 
-// InitRateLimitDB initializes the rate limit database with WAL mode
+// InitRateLimitDB initializes the rate limit database. dbPath is a SQLite
+// file path, opened in WAL mode, or a PostgreSQL connection string.
 func InitRateLimitDB(dbPath string) error {
+	d := dialect.Detect(dbPath)
 	var err error
-	rateLimitDB, err = sql.Open("sqlite3", dbPath)
+	rateLimitDB, err = dialect.Open(d, dbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open rate limit database: %w", err)
 	}
 
-	// Enable WAL mode for concurrent reads during writes
-	if _, err := rateLimitDB.Exec("PRAGMA journal_mode=WAL"); err != nil {
-		return fmt.Errorf("failed to enable WAL mode: %w", err)
-	}
+	if d == dialect.SQLite {
+		// Enable WAL mode for concurrent reads during writes
+		if _, err := rateLimitDB.Exec("PRAGMA journal_mode=WAL"); err != nil {
+			return fmt.Errorf("failed to enable WAL mode: %w", err)
+		}
 
-	// Enable foreign keys
-	if _, err := rateLimitDB.Exec("PRAGMA foreign_keys=ON"); err != nil {
-		return fmt.Errorf("failed to enable foreign keys: %w", err)
+		// Enable foreign keys
+		if _, err := rateLimitDB.Exec("PRAGMA foreign_keys=ON"); err != nil {
+			return fmt.Errorf("failed to enable foreign keys: %w", err)
+		}
 	}
 
 	if err := createRateLimitTables(); err != nil {
@@ -96,7 +101,7 @@ func CloseRateLimitDB() error {
 }
 
 // GetRateLimitDB returns the rate limit database connection
-func GetRateLimitDB() *sql.DB {
+func GetRateLimitDB() *dialect.DB {
 	return rateLimitDB
 }
 
@@ -160,6 +165,45 @@ func createRateLimitTables() error {
 			change_reason TEXT
 		)`,
 
+		// Scraped changes awaiting admin review
+		`CREATE TABLE IF NOT EXISTS staged_changes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			change_key TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			provider_name TEXT NOT NULL,
+			plan_type TEXT NOT NULL,
+			model_id TEXT DEFAULT '',
+			limit_type TEXT DEFAULT '',
+			old_value TEXT,
+			new_value TEXT NOT NULL,
+			summary TEXT NOT NULL,
+			source_url TEXT,
+			status TEXT NOT NULL DEFAULT 'pending',
+			created_at DATETIME NOT NULL,
+			reviewed_at DATETIME
+		)`,
+
+		// Model capability ratings used by quality-constrained routing
+		`CREATE TABLE IF NOT EXISTS model_quality (
+			model_id TEXT PRIMARY KEY,
+			tier INTEGER NOT NULL,
+			score REAL NOT NULL DEFAULT 0,
+			source TEXT NOT NULL,
+			updated_at DATETIME NOT NULL
+		)`,
+
+		// Per-provider model capabilities used by capability-filtered routing
+		`CREATE TABLE IF NOT EXISTS model_capabilities (
+			provider_name TEXT NOT NULL,
+			model_id TEXT NOT NULL,
+			supports_vision BOOLEAN NOT NULL DEFAULT 0,
+			supports_tools BOOLEAN NOT NULL DEFAULT 0,
+			supports_json_mode BOOLEAN NOT NULL DEFAULT 0,
+			context_tokens INTEGER NOT NULL DEFAULT 0,
+			regions TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY(provider_name, model_id)
+		)`,
+
 		// Indexes for performance
 		`CREATE INDEX IF NOT EXISTS idx_rate_limits_provider_plan 
 		 ON rate_limits(provider_name, plan_type, limit_type)`,
@@ -175,10 +219,16 @@ func createRateLimitTables() error {
 
 		`CREATE INDEX IF NOT EXISTS idx_pricing_history_date 
 		 ON pricing_history(change_date DESC)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_staged_changes_status 
+		 ON staged_changes(status, provider_name)`,
+
+		`CREATE INDEX IF NOT EXISTS idx_staged_changes_key 
+		 ON staged_changes(change_key, status)`,
 	}
 
 	for _, query := range queries {
-		if _, err := rateLimitDB.Exec(query); err != nil {
+		if err := rateLimitDB.ExecScript(query); err != nil {
 			return fmt.Errorf("failed to execute query: %w", err)
 		}
 	}
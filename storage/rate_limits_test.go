@@ -20,8 +20,8 @@ func TestCreateRateLimitTables_CreatesAllTables(t *testing.T) {
 	}
 	defer CloseRateLimitDB()
 
-	// Assert - Check all 4 tables exist
-	tables := []string{"rate_limits", "plan_metadata", "provider_pricing", "pricing_history"}
+	// Assert - Check all 5 tables exist
+	tables := []string{"rate_limits", "plan_metadata", "provider_pricing", "pricing_history", "staged_changes"}
 	for _, table := range tables {
 		var name string
 		err := GetRateLimitDB().QueryRow(
@@ -1,21 +1,23 @@
 package storage
 
 import (
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/jeffersonwarrior/modelscan/internal/database/dialect"
+	"github.com/jeffersonwarrior/modelscan/internal/redact"
 	"github.com/jeffersonwarrior/modelscan/providers"
 	_ "github.com/mattn/go-sqlite3"
 )
 
-var db *sql.DB
+var db *dialect.DB
 
-// InitDB initializes the SQLite database
+// InitDB initializes the database. dbPath is a SQLite file path or a
+// PostgreSQL connection string.
 func InitDB(dbPath string) error {
 	var err error
-	db, err = sql.Open("sqlite3", dbPath)
+	db, err = dialect.Open(dialect.Detect(dbPath), dbPath)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -80,7 +82,7 @@ func createTables() error {
 	}
 
 	for _, query := range queries {
-		if _, err := db.Exec(query); err != nil {
+		if err := db.ExecScript(query); err != nil {
 			return fmt.Errorf("failed to execute query %s: %w", query, err)
 		}
 	}
@@ -97,8 +99,11 @@ func StoreProviderInfo(name string, models []providers.Model, capabilities provi
 	// Store provider and capabilities
 	capsJSON, _ := json.Marshal(capabilities)
 	_, err := db.Exec(`
-		INSERT OR REPLACE INTO providers (name, capabilities) 
+		INSERT INTO providers (name, capabilities)
 		VALUES (?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			capabilities = excluded.capabilities,
+			created_at = CURRENT_TIMESTAMP
 	`, name, string(capsJSON))
 	if err != nil {
 		return fmt.Errorf("failed to insert provider: %w", err)
@@ -110,11 +115,25 @@ func StoreProviderInfo(name string, models []providers.Model, capabilities provi
 		modelCapsJSON, _ := json.Marshal(model.Capabilities)
 
 		_, err := db.Exec(`
-			INSERT OR REPLACE INTO models 
+			INSERT INTO models
 			(provider_name, model_id, name, description, cost_per_1m_in, cost_per_1m_out,
 			 context_window, max_tokens, supports_images, supports_tools, can_reason, can_stream,
 			 categories, capabilities)
 			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(provider_name, model_id) DO UPDATE SET
+				name = excluded.name,
+				description = excluded.description,
+				cost_per_1m_in = excluded.cost_per_1m_in,
+				cost_per_1m_out = excluded.cost_per_1m_out,
+				context_window = excluded.context_window,
+				max_tokens = excluded.max_tokens,
+				supports_images = excluded.supports_images,
+				supports_tools = excluded.supports_tools,
+				can_reason = excluded.can_reason,
+				can_stream = excluded.can_stream,
+				categories = excluded.categories,
+				capabilities = excluded.capabilities,
+				created_at = CURRENT_TIMESTAMP
 		`,
 			name, model.ID, model.Name, model.Description,
 			model.CostPer1MIn, model.CostPer1MOut,
@@ -146,13 +165,19 @@ func StoreEndpointResults(name string, endpoints []providers.Endpoint) error {
 		}
 
 		_, err := db.Exec(`
-			INSERT OR REPLACE INTO endpoints 
+			INSERT INTO endpoints
 			(provider_name, path, method, description, status, latency_ms, error_message)
 			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(provider_name, path, method) DO UPDATE SET
+				description = excluded.description,
+				status = excluded.status,
+				latency_ms = excluded.latency_ms,
+				error_message = excluded.error_message,
+				created_at = CURRENT_TIMESTAMP
 		`,
 			name, endpoint.Path, endpoint.Method,
 			endpoint.Description, string(endpoint.Status),
-			latencyMs, endpoint.Error,
+			latencyMs, redact.String(endpoint.Error),
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert endpoint %s: %w", endpoint.Path, err)
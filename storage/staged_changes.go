@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Staged change statuses
+const (
+	ChangePending  = "pending"
+	ChangeApproved = "approved"
+	ChangeRejected = "rejected"
+)
+
+// StagedChange is a scraped rate limit or pricing change awaiting review
+type StagedChange struct {
+	ID           int64
+	ChangeKey    string // identifies the record being changed
+	Kind         string // pricing, rate_limit
+	ProviderName string
+	PlanType     string
+	ModelID      string
+	LimitType    string
+	OldValue     sql.NullString // JSON of the stored record, if any
+	NewValue     string         // JSON of the proposed record
+	Summary      string
+	SourceURL    string
+	Status       string // pending, approved, rejected
+	CreatedAt    time.Time
+	ReviewedAt   sql.NullTime
+}
+
+// StageChange records a proposed change. A pending change for the same
+// record is replaced rather than duplicated. Returns the change ID.
+func StageChange(sc StagedChange) (int64, error) {
+	if sc.CreatedAt.IsZero() {
+		sc.CreatedAt = time.Now()
+	}
+
+	var id int64
+	err := rateLimitDB.QueryRow(
+		"SELECT id FROM staged_changes WHERE change_key = ? AND status = ?",
+		sc.ChangeKey, ChangePending,
+	).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	if err == nil {
+		_, err = rateLimitDB.Exec(`
+			UPDATE staged_changes
+			SET old_value = ?, new_value = ?, summary = ?, source_url = ?, created_at = ?
+			WHERE id = ?
+		`, sc.OldValue, sc.NewValue, sc.Summary, sc.SourceURL, sc.CreatedAt, id)
+		return id, err
+	}
+
+	return rateLimitDB.Insert(`
+		INSERT INTO staged_changes (
+			change_key, kind, provider_name, plan_type, model_id, limit_type,
+			old_value, new_value, summary, source_url, status, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		sc.ChangeKey, sc.Kind, sc.ProviderName, sc.PlanType, sc.ModelID, sc.LimitType,
+		sc.OldValue, sc.NewValue, sc.Summary, sc.SourceURL, ChangePending, sc.CreatedAt,
+	)
+}
+
+// GetStagedChange retrieves a staged change by ID, or nil if it does not exist
+func GetStagedChange(id int64) (*StagedChange, error) {
+	row := rateLimitDB.QueryRow(`
+		SELECT id, change_key, kind, provider_name, plan_type, model_id, limit_type,
+		       old_value, new_value, summary, source_url, status, created_at, reviewed_at
+		FROM staged_changes WHERE id = ?
+	`, id)
+
+	sc, err := scanStagedChange(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// ListStagedChanges retrieves staged changes, newest first. Empty status or
+// provider matches all.
+func ListStagedChanges(status, providerName string) ([]StagedChange, error) {
+	query := `
+		SELECT id, change_key, kind, provider_name, plan_type, model_id, limit_type,
+		       old_value, new_value, summary, source_url, status, created_at, reviewed_at
+		FROM staged_changes WHERE 1=1
+	`
+	var args []interface{}
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	if providerName != "" {
+		query += " AND provider_name = ?"
+		args = append(args, providerName)
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	rows, err := rateLimitDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []StagedChange
+	for rows.Next() {
+		sc, err := scanStagedChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *sc)
+	}
+
+	return results, rows.Err()
+}
+
+// ReviewStagedChange marks a pending change approved or rejected
+func ReviewStagedChange(id int64, status string) error {
+	if status != ChangeApproved && status != ChangeRejected {
+		return fmt.Errorf("invalid review status: %s", status)
+	}
+
+	result, err := rateLimitDB.Exec(
+		"UPDATE staged_changes SET status = ?, reviewed_at = ? WHERE id = ? AND status = ?",
+		status, time.Now(), id, ChangePending,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("no pending staged change with id %d", id)
+	}
+	return nil
+}
+
+// scanStagedChange reads one staged change row
+func scanStagedChange(row interface{ Scan(...interface{}) error }) (*StagedChange, error) {
+	var sc StagedChange
+	var sourceURL sql.NullString
+	err := row.Scan(
+		&sc.ID, &sc.ChangeKey, &sc.Kind, &sc.ProviderName, &sc.PlanType, &sc.ModelID, &sc.LimitType,
+		&sc.OldValue, &sc.NewValue, &sc.Summary, &sourceURL, &sc.Status, &sc.CreatedAt, &sc.ReviewedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	sc.SourceURL = sourceURL.String
+	return &sc, nil
+}
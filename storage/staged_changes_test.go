@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestStagedChanges(t *testing.T) {
+	if err := InitRateLimitDB(filepath.Join(t.TempDir(), "rate_limits.db")); err != nil {
+		t.Fatalf("Failed to initialize rate limit DB: %v", err)
+	}
+	defer CloseRateLimitDB()
+
+	change := StagedChange{
+		ChangeKey: "pricing:openai:tier-1:gpt-4o", Kind: "pricing",
+		ProviderName: "openai", PlanType: "tier-1", ModelID: "gpt-4o",
+		OldValue: sql.NullString{String: `{"InputCost":5}`, Valid: true},
+		NewValue: `{"InputCost":2.5}`, Summary: "input $5.00 -> $2.50",
+		SourceURL: "https://openai.com/api/pricing/",
+	}
+	id, err := StageChange(change)
+	if err != nil {
+		t.Fatalf("StageChange() error = %v", err)
+	}
+
+	// Re-staging the same record replaces the pending proposal
+	change.NewValue = `{"InputCost":2}`
+	again, err := StageChange(change)
+	if err != nil || again != id {
+		t.Fatalf("expected pending change %d to be updated, got %d (%v)", id, again, err)
+	}
+
+	other := StagedChange{
+		ChangeKey: "rate_limit:groq:free:llama-3.3-70b:rpm", Kind: "rate_limit",
+		ProviderName: "groq", PlanType: "free", ModelID: "llama-3.3-70b", LimitType: "rpm",
+		NewValue: `{"LimitValue":30}`, Summary: "new rpm limit 30",
+	}
+	if _, err := StageChange(other); err != nil {
+		t.Fatalf("StageChange() error = %v", err)
+	}
+
+	got, err := GetStagedChange(id)
+	if err != nil || got == nil {
+		t.Fatalf("GetStagedChange() = %v, %v", got, err)
+	}
+	if got.NewValue != `{"InputCost":2}` || got.Status != ChangePending || !got.OldValue.Valid || got.ReviewedAt.Valid {
+		t.Errorf("unexpected staged change %+v", got)
+	}
+	if missing, err := GetStagedChange(9999); err != nil || missing != nil {
+		t.Errorf("expected nil, nil for missing change, got %+v, %v", missing, err)
+	}
+
+	pending, err := ListStagedChanges(ChangePending, "")
+	if err != nil || len(pending) != 2 {
+		t.Fatalf("expected 2 pending changes, got %d (%v)", len(pending), err)
+	}
+	groq, err := ListStagedChanges("", "groq")
+	if err != nil || len(groq) != 1 || groq[0].LimitType != "rpm" || groq[0].OldValue.Valid {
+		t.Errorf("unexpected groq changes %+v (%v)", groq, err)
+	}
+
+	if err := ReviewStagedChange(id, ChangeApproved); err != nil {
+		t.Fatalf("ReviewStagedChange() error = %v", err)
+	}
+	if err := ReviewStagedChange(id, ChangeRejected); err == nil {
+		t.Error("expected error reviewing a change twice")
+	}
+	if err := ReviewStagedChange(id, "deleted"); err == nil {
+		t.Error("expected error for invalid status")
+	}
+
+	got, _ = GetStagedChange(id)
+	if got.Status != ChangeApproved || !got.ReviewedAt.Valid {
+		t.Errorf("expected approved change with review time, got %+v", got)
+	}
+
+	// Once reviewed, a new scrape stages a fresh change
+	newID, err := StageChange(change)
+	if err != nil || newID == id {
+		t.Errorf("expected new staged change after review, got %d (%v)", newID, err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jeffersonwarrior/modelscan/internal/export"
 	"github.com/jeffersonwarrior/modelscan/providers"
 )
 
@@ -518,7 +519,45 @@ func TestCloseDB_NilDatabase(t *testing.T) {
 	}
 }
 
-func TestAppendProviderDetails(t *testing.T) {
+func TestExportToMarkdown_MasksKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := InitDB(filepath.Join(tmpDir, "test.db")); err != nil {
+		t.Fatalf("InitDB() failed: %v", err)
+	}
+	defer CloseDB()
+
+	if err := StoreProviderInfo("leaky", []providers.Model{}, providers.ProviderCapabilities{}); err != nil {
+		t.Fatalf("StoreProviderInfo() failed: %v", err)
+	}
+	leak := "Incorrect API key provided: sk-proj-AbCdEf0123456789AbCdEf"
+	endpoints := []providers.Endpoint{{Path: "/v1/models", Method: "GET", Status: providers.StatusFailed, Error: leak}}
+	if err := StoreEndpointResults("leaky", endpoints); err != nil {
+		t.Fatalf("StoreEndpointResults() failed: %v", err)
+	}
+
+	var stored string
+	if err := db.QueryRow("SELECT error_message FROM endpoints WHERE provider_name = ?", "leaky").Scan(&stored); err != nil {
+		t.Fatalf("Failed to query endpoint: %v", err)
+	}
+	if strings.Contains(stored, "AbCdEf0123456789") {
+		t.Errorf("key stored unmasked: %q", stored)
+	}
+
+	// Rows written before masking existed are masked on export
+	if _, err := db.Exec("UPDATE endpoints SET error_message = ? WHERE provider_name = ?", leak, "leaky"); err != nil {
+		t.Fatalf("Failed to update endpoint: %v", err)
+	}
+	mdPath := filepath.Join(tmpDir, "report.md")
+	if err := ExportToMarkdown(mdPath); err != nil {
+		t.Fatalf("ExportToMarkdown() failed: %v", err)
+	}
+	content, _ := os.ReadFile(mdPath)
+	if strings.Contains(string(content), "AbCdEf0123456789") || !strings.Contains(string(content), "sk-proj-[REDACTED]") {
+		t.Errorf("Markdown export not masked:\n%s", content)
+	}
+}
+
+func TestExportToMarkdown_ProviderDetails(t *testing.T) {
 	// Initialize database with test data
 	dbPath := "/tmp/test_markdown_export.db"
 	defer os.Remove(dbPath)
@@ -584,23 +623,12 @@ func TestAppendProviderDetails(t *testing.T) {
 		t.Fatalf("StoreProviderInfo failed: %v", err)
 	}
 
-	// Create temporary file for export
+	// Export the provider's details
 	tmpFile := filepath.Join(t.TempDir(), "test_export.md")
-	file, err := os.Create(tmpFile)
-	if err != nil {
-		t.Fatalf("Failed to create temp file: %v", err)
-	}
-	defer file.Close()
-
-	// Test appendProviderDetails
-	err = appendProviderDetails(file, "test-provider")
-	if err != nil {
-		t.Fatalf("appendProviderDetails failed: %v", err)
+	if err := ExportToMarkdown(tmpFile); err != nil {
+		t.Fatalf("ExportToMarkdown failed: %v", err)
 	}
 
-	// Close file to flush
-	file.Close()
-
 	// Read back and verify content
 	content, err := os.ReadFile(tmpFile)
 	if err != nil {
@@ -656,29 +684,79 @@ func TestAppendProviderDetails(t *testing.T) {
 	}
 }
 
-func TestAppendProviderDetails_Error(t *testing.T) {
-	// Test with non-existent provider (should handle gracefully)
+func TestExportToMarkdown_Empty(t *testing.T) {
+	// An empty database still produces a report
 	tmpFile := filepath.Join(t.TempDir(), "test_error.md")
-	file, err := os.Create(tmpFile)
-	if err != nil {
-		t.Fatalf("Failed to create temp file: %v", err)
-	}
-	defer file.Close()
 
 	// Initialize empty database
-	dbPath := "/tmp/test_markdown_error.db"
-	defer os.Remove(dbPath)
-
-	err = InitDB(dbPath)
+	dbPath := filepath.Join(t.TempDir(), "test_markdown_error.db")
+	err := InitDB(dbPath)
 	if err != nil {
 		t.Fatalf("InitDB failed: %v", err)
 	}
 	defer CloseDB()
 
-	// Try to export non-existent provider
-	err = appendProviderDetails(file, "nonexistent-provider")
-	// Should complete without error even if no models found
+	if err := ExportToMarkdown(tmpFile); err != nil {
+		t.Fatalf("ExportToMarkdown failed: %v", err)
+	}
+	content, _ := os.ReadFile(tmpFile)
+	if !strings.Contains(string(content), "Total Providers: 0") {
+		t.Errorf("expected an empty summary, got:\n%s", content)
+	}
+
+	// Without a database there is nothing to report
+	CloseDB()
+	if err := ExportToMarkdown(tmpFile); err == nil {
+		t.Error("expected an error without a database")
+	}
+}
+
+func TestExport(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := InitDB(filepath.Join(tmpDir, "test.db")); err != nil {
+		t.Fatalf("InitDB() failed: %v", err)
+	}
+	defer CloseDB()
+
+	models := []providers.Model{
+		{ID: "model-b", Name: "Model B", CostPer1MIn: 1.5, SupportsTools: true},
+		{ID: "model-a", Name: "Model A", Description: "has, a comma"},
+	}
+	if err := StoreProviderInfo("export-test", models, providers.ProviderCapabilities{}); err != nil {
+		t.Fatalf("StoreProviderInfo() failed: %v", err)
+	}
+
+	csvPath := filepath.Join(tmpDir, "models.csv")
+	n, err := ExportToFile("models", export.FormatCSV, csvPath)
+	if err != nil || n != 2 {
+		t.Fatalf("ExportToFile() = %d, %v", n, err)
+	}
+	data, _ := os.ReadFile(csvPath)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "provider_name,model_id,name") || !strings.Contains(lines[1], `"has, a comma"`) {
+		t.Errorf("unexpected CSV export:\n%s", data)
+	}
+
+	colPath := filepath.Join(tmpDir, "models.mscol")
+	if _, err := ExportToFile("models", export.FormatColumnar, colPath); err != nil {
+		t.Fatalf("ExportToFile(columnar) failed: %v", err)
+	}
+	file, _ := os.Open(colPath)
+	defer file.Close()
+	r, err := export.NewColumnarReader(file)
 	if err != nil {
-		t.Logf("appendProviderDetails returned error (may be expected): %v", err)
+		t.Fatalf("NewColumnarReader() failed: %v", err)
+	}
+	row, err := r.Next()
+	if err != nil || row[1] != "model-a" {
+		t.Errorf("unexpected first columnar row %v (%v)", row, err)
+	}
+
+	CloseRateLimitDB()
+	if _, err := ExportToFile("pricing", export.FormatJSONL, filepath.Join(tmpDir, "pricing.jsonl")); err == nil {
+		t.Error("expected error exporting pricing without a rate limit database")
+	}
+	if _, err := ExportToFile("unknown", export.FormatJSONL, filepath.Join(tmpDir, "unknown.jsonl")); err == nil {
+		t.Error("expected error for unknown dataset")
 	}
 }
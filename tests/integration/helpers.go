@@ -96,6 +96,7 @@ func (a *testKeyManagerAdapter) ListActiveAPIKeys(providerID string) ([]*keymana
 			Degraded:      k.Degraded,
 			DegradedUntil: k.DegradedUntil,
 			CreatedAt:     k.CreatedAt,
+			TenantID:      k.TenantID,
 		}
 	}
 	return result, nil
@@ -136,6 +137,7 @@ func (a *testKeyManagerAdapter) GetAPIKey(id int) (*keymanager.APIKey, error) {
 		Degraded:      key.Degraded,
 		DegradedUntil: key.DegradedUntil,
 		CreatedAt:     key.CreatedAt,
+		TenantID:      key.TenantID,
 	}, nil
 }
 
@@ -544,6 +544,10 @@ func (m *mockAdminDB) GetUsageStats(modelID string, since time.Time) (map[string
 	return map[string]interface{}{"total_requests": 100}, nil
 }
 
+func (m *mockAdminDB) UsageByModel(since time.Time) ([]*admin.ModelUsageSummary, error) {
+	return []*admin.ModelUsageSummary{{Model: "gpt-4", Requests: 100}}, nil
+}
+
 func (m *mockAdminDB) GetKeyStats(keyID int, since time.Time) (*admin.KeyStats, error) {
 	return &admin.KeyStats{
 		RequestsToday:    50,
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// validation is the outcome of validating one provider
+type validation struct {
+	provider string
+	models   int
+	duration time.Duration
+	err      error
+}
+
+// validateFunc validates one provider and returns how many models it has
+type validateFunc func(ctx context.Context, name string) (int, error)
+
+// validateAll validates providers with at most concurrency running at once,
+// each bounded by timeout (zero for none). A line of progress is written to
+// out as each provider finishes. Results are in the order of names.
+func validateAll(ctx context.Context, names []string, concurrency int, timeout time.Duration, out io.Writer, validate validateFunc) []validation {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]validation, len(names))
+	sem := make(chan struct{}, concurrency)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var pctx context.Context
+			var cancel context.CancelFunc
+			if timeout > 0 {
+				pctx, cancel = context.WithTimeout(ctx, timeout)
+			} else {
+				pctx, cancel = context.WithCancel(ctx)
+			}
+			start := time.Now()
+			models, err := validate(pctx, name)
+			if err != nil && errors.Is(pctx.Err(), context.DeadlineExceeded) {
+				err = fmt.Errorf("timed out after %s: %w", timeout, err)
+			}
+			cancel()
+			results[i] = validation{provider: name, models: models, duration: time.Since(start), err: err}
+
+			mu.Lock()
+			done++
+			printProgress(out, done, len(names), results[i])
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// printProgress writes one provider's outcome
+func printProgress(out io.Writer, done, total int, v validation) {
+	width := len(fmt.Sprint(total))
+	if v.err != nil {
+		fmt.Fprintf(out, "[%*d/%d] ✗ %s (%s): %v\n", width, done, total, v.provider, v.duration.Round(time.Millisecond), v.err)
+		return
+	}
+	fmt.Fprintf(out, "[%*d/%d] ✓ %s: %d models (%s)\n", width, done, total, v.provider, v.models, v.duration.Round(time.Millisecond))
+}
+
+// summarize writes how many providers validated and every failure, sorted
+// by provider, and returns the number of failures
+func summarize(out io.Writer, results []validation, elapsed time.Duration) int {
+	var failed []validation
+	models := 0
+	for _, v := range results {
+		if v.err != nil {
+			failed = append(failed, v)
+		}
+		models += v.models
+	}
+	fmt.Fprintf(out, "\nValidated %d of %d providers (%d models) in %s\n",
+		len(results)-len(failed), len(results), models, elapsed.Round(time.Millisecond))
+	if len(failed) == 0 {
+		return 0
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i].provider < failed[j].provider })
+	fmt.Fprintf(out, "%d failed:\n", len(failed))
+	for _, v := range failed {
+		fmt.Fprintf(out, "  %s: %v\n", v.provider, v.err)
+	}
+	return len(failed)
+}
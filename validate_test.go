@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestValidateAll(t *testing.T) {
+	names := []string{"openai", "anthropic", "hung", "groq", "broken", "mistral"}
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+	)
+	validate := func(ctx context.Context, name string) (int, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > peak {
+			peak = inFlight
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		switch name {
+		case "hung":
+			<-ctx.Done()
+			return 0, ctx.Err()
+		case "broken":
+			return 0, errors.New("401 unauthorized")
+		}
+		time.Sleep(10 * time.Millisecond)
+		return len(name), nil
+	}
+
+	var out bytes.Buffer
+	start := time.Now()
+	results := validateAll(context.Background(), names, 2, 50*time.Millisecond, &out, validate)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected the hung provider to be cut off, took %s", elapsed)
+	}
+
+	if peak > 2 {
+		t.Errorf("expected at most 2 providers in flight, got %d", peak)
+	}
+	if len(results) != len(names) {
+		t.Fatalf("expected %d results, got %d", len(names), len(results))
+	}
+	for i, v := range results {
+		if v.provider != names[i] {
+			t.Errorf("result %d is %s, expected %s", i, v.provider, names[i])
+		}
+	}
+	hung := results[2]
+	if hung.err == nil || !errors.Is(hung.err, context.DeadlineExceeded) || !strings.Contains(hung.err.Error(), "timed out after 50ms") {
+		t.Errorf("expected the hung provider to time out, got %v", hung.err)
+	}
+	if results[0].err != nil || results[0].models != len("openai") {
+		t.Errorf("unexpected openai result %+v", results[0])
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != len(names) {
+		t.Errorf("expected a progress line per provider, got %d:\n%s", lines, out.String())
+	}
+
+	out.Reset()
+	if failed := summarize(&out, results, time.Second); failed != 2 {
+		t.Errorf("expected 2 failures, got %d", failed)
+	}
+	summary := out.String()
+	models := len("openai") + len("anthropic") + len("groq") + len("mistral")
+	if !strings.Contains(summary, fmt.Sprintf("Validated 4 of 6 providers (%d models)", models)) {
+		t.Errorf("unexpected summary:\n%s", summary)
+	}
+	if !strings.Contains(summary, "2 failed:\n  broken: 401 unauthorized\n  hung: timed out") {
+		t.Errorf("expected failures sorted by provider, got:\n%s", summary)
+	}
+
+	out.Reset()
+	if failed := summarize(&out, results[:2], time.Second); failed != 0 || strings.Contains(out.String(), "failed") {
+		t.Errorf("expected no failures, got %d:\n%s", failed, out.String())
+	}
+}